@@ -2,20 +2,23 @@ package main
 
 import (
 	"context"
-	"database/sql"
-	"fmt"
+	"flag"
+	"io"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
-	_ "github.com/lib/pq" // PostgreSQL driver
+	"nivai/backend/pkg/app"
 	"nivai/backend/pkg/config"
-	"nivai/backend/pkg/models"
-	"nivai/backend/pkg/routes"
-	"nivai/backend/pkg/services"
+	"nivai/backend/pkg/httpclient"
+	"nivai/backend/pkg/lifecycle"
+	"nivai/backend/pkg/logging"
+	"nivai/backend/pkg/secrets"
+	"nivai/backend/pkg/support"
 )
 
 /**
@@ -27,106 +30,147 @@ func main() {
 	// Initialize logger
 	logger := log.New(os.Stdout, "AIFAA API: ", log.LstdFlags)
 
-	// Load configuration
-	cfg, err := config.Load()
-	if err != nil {
-		logger.Fatalf("Failed to load configuration: %v", err)
+	demo := flag.Bool("demo", false, "Run without PostgreSQL or cloud storage, keeping all data in memory")
+	configFile := flag.String("config", "", "Configuration file (.json, .yaml or .toml)")
+	overrides := config.Overrides{}
+	flag.Var(overrides, "set", "Override a setting, e.g. -set database.postgres.host=db; repeatable")
+	flag.Parse()
+	if *demo {
+		overrides["demo"] = "true"
 	}
 
-	// Initialize storage service
-	logger.Println("Initializing storage service...")
-	storageFactory := services.NewStorageFactory()
-	storage, err := storageFactory.CreateDefaultStorage()
-
+	// Load configuration: flags win over the environment, which wins over the file
+	cfg, err := config.LoadFrom(config.Sources{File: *configFile, Overrides: overrides})
 	if err != nil {
-		logger.Printf("Warning: Could not initialize default storage: %v", err)
-		// Check if we have an external data path configured
-		if externalPath := os.Getenv("EXTERNAL_DATA_MOUNT"); externalPath != "" {
-			logger.Printf("Attempting to use configured mount point: %s", externalPath)
-
-			// Create directory if it doesn't exist
-			if _, err := os.Stat(externalPath); os.IsNotExist(err) {
-				logger.Printf("Creating mount directory: %s", externalPath)
-				if err := os.MkdirAll(externalPath, 0755); err != nil {
-					logger.Fatalf("Failed to create mount directory: %v", err)
-				}
-			}
-
-			// Set environment variable expected by storage factory
-			os.Setenv("EXTERNAL_DATA_PATH", externalPath)
-
-			// Try to initialize storage again
-			storage, err = storageFactory.CreateStorage(services.LocalFileStorageType)
-			if err != nil {
-				logger.Fatalf("Failed to initialize storage with mount point: %v", err)
-			}
-		} else {
-			logger.Fatalf("No valid storage configuration found and no mount point specified")
-		}
+		logger.Fatalf("Failed to load configuration: %v", err)
 	}
 
-	logger.Printf("Storage service initialized successfully")
+	// Keep recent log output in memory for support bundles; lines below the
+	// log level are dropped from both
+	logs := support.NewLogBuffer(cfg.Support.LogLines)
+	logger.SetOutput(logging.Filter(io.MultiWriter(os.Stdout, logs)))
+	log.SetOutput(logging.Filter(io.MultiWriter(os.Stderr, logs)))
 
-	// Initialize database connection
-	logger.Println("Initializing database connection...")
-	psqlInfo := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
-		cfg.Database.Postgres.Host,
-		cfg.Database.Postgres.Port,
-		cfg.Database.Postgres.User,
-		cfg.Database.Postgres.Password,
-		cfg.Database.Postgres.DBName,
-		cfg.Database.Postgres.SSLMode,
-	)
-
-	db, err := sql.Open("postgres", psqlInfo)
-	if err != nil {
-		logger.Fatalf("Failed to open database connection: %v", err)
+	// Show the effective configuration, then refuse to start with an invalid
+	// one rather than failing on first use
+	if table, err := cfg.Table(); err == nil {
+		logger.Printf("Effective configuration:\n%s", table)
 	}
-	defer db.Close()
-
-	err = db.Ping()
-	if err != nil {
-		logger.Fatalf("Failed to ping database: %v", err)
+	if err := resolveSecrets(cfg, logger); err != nil {
+		logger.Fatalf("%v", err)
 	}
-	logger.Println("Database connection initialized successfully")
+	if err := cfg.Validate(); err != nil {
+		logger.Fatalf("%v", err)
+	}
+	setLogLevel(cfg, logger)
 
-	// Create video repository
-	videoRepo := models.NewPostgresVideoRepository(db)
+	// Safe-to-change settings are reloaded on SIGHUP or through the admin API
+	reloader := config.NewReloader(cfg, config.Sources{File: *configFile, Overrides: overrides}).
+		WithPrepare(func(next *config.Config) error { return resolveSecrets(next, logger) })
+	reloader.Subscribe(func(next *config.Config) { setLogLevel(next, logger) })
+	go reloadOnHangup(reloader, logger)
 
-	// Create router and register routes
-	router := routes.SetupRoutes(cfg, storage, videoRepo)
+	// Until the application is constructed, the gate answers the probes and
+	// turns other requests away with 503
+	gate := app.NewGate()
 
 	// Configure server
 	server := &http.Server{
 		Addr:         ":" + cfg.Server.Port,
-		Handler:      router,
+		Handler:      gate,
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
 	}
 
+	// Open the listener; with SO_REUSEPORT the next deployment can bind the
+	// same port while this process drains
+	listener, err := lifecycle.Listen(context.Background(), server.Addr, cfg.Server.ReusePort)
+	if err != nil {
+		logger.Fatalf("Failed to listen on port %s: %v", cfg.Server.Port, err)
+	}
+
 	// Start server in a goroutine
 	go func() {
 		logger.Printf("Starting server on port %s", cfg.Server.Port)
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
 			logger.Fatalf("Server failed to start: %v", err)
 		}
 	}()
 
+	// Construct the database, storage, services and router, waiting for
+	// dependencies that are not up yet, then serve the API
+	application, err := app.New(cfg, app.WithLogger(logger), app.WithLogs(logs),
+		app.WithReloader(reloader), app.WithGate(gate))
+	if err != nil {
+		logger.Fatalf("Failed to initialize: %v", err)
+	}
+	defer application.Close()
+	application.Start(context.Background())
+	gate.Open(application.Router)
+	logger.Println("Ready to serve requests")
+
 	// Wait for interrupt signal to gracefully shutdown the server
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
-	// Create shutdown context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	// Drain: readiness reports 503 for the pre-stop delay, then in-flight
+	// requests, uploads and WebSocket connections get the drain timeout.
+	// A second signal skips the remaining waits.
+	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
+	go func() {
+		<-quit
+		logger.Println("Second signal received, skipping drain")
+		cancel()
+	}()
 
-	// Shutdown server gracefully
 	logger.Println("Shutting down server...")
-	if err := server.Shutdown(ctx); err != nil {
+	if err := application.Lifecycle.Shutdown(ctx, server); err != nil {
+		server.Close()
 		logger.Fatalf("Server forced to shutdown: %v", err)
 	}
 
 	logger.Println("Server exited properly")
 }
+
+// setLogLevel applies the configured log level
+func setLogLevel(cfg *config.Config, logger *log.Logger) {
+	level, err := logging.ParseLevel(cfg.Logging.Level)
+	if err != nil {
+		logger.Printf("Warning: %v, logging at info", err)
+	}
+	logging.SetLevel(level)
+}
+
+// reloadOnHangup reloads the configuration whenever the process gets SIGHUP
+func reloadOnHangup(reloader *config.Reloader, logger *log.Logger) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	for range hangup {
+		logger.Println("SIGHUP received, reloading configuration")
+		reloader.Reload("signal", "")
+	}
+}
+
+// resolveSecrets replaces secret references in the configuration, such as
+// vault://secret/data/nivai#db_password, by the secrets they name. It runs
+// after the configuration is printed, so the table shows references only.
+func resolveSecrets(cfg *config.Config, logger *log.Logger) error {
+	httpClients, err := httpclient.FromConfig(cfg)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	resolved, err := secrets.FromEnv(httpClients.Client(httpclient.DestinationSecrets)).ResolveAll(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	if len(resolved) > 0 {
+		logger.Printf("Resolved secrets for %s", strings.Join(resolved, ", "))
+	}
+	return nil
+}
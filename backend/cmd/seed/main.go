@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+
+	"nivai/backend/pkg/app"
+	"nivai/backend/pkg/config"
+	"nivai/backend/pkg/database"
+	"nivai/backend/pkg/secrets"
+	"nivai/backend/pkg/seed"
+	"nivai/backend/pkg/services"
+)
+
+/**
+ * Seed loads the sample matches, with their files and analytics, into the
+ * database and storage configured for the API server, so a new environment
+ * has data to show. Matches that already exist are left alone.
+ */
+func main() {
+	logger := log.New(os.Stdout, "AIFAA seed: ", log.LstdFlags)
+
+	configFile := flag.String("config", "", "Configuration file (.json, .yaml or .toml)")
+	overrides := config.Overrides{}
+	flag.Var(overrides, "set", "Override a setting, e.g. -set database.driver=sqlite; repeatable")
+	flag.Parse()
+
+	cfg, err := config.LoadFrom(config.Sources{File: *configFile, Overrides: overrides})
+	if err != nil {
+		logger.Fatalf("Failed to load configuration: %v", err)
+	}
+	if _, err := secrets.FromEnv(nil).ResolveAll(context.Background(), cfg); err != nil {
+		logger.Fatalf("%v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		logger.Fatalf("%v", err)
+	}
+
+	storage, err := app.OpenStorage(cfg, logger, nil)
+	if err != nil {
+		logger.Fatalf("Failed to initialize storage: %v", err)
+	}
+	paths, err := services.NewPathStrategy(cfg.Storage.PathStrategy)
+	if err != nil {
+		logger.Fatalf("Invalid storage path strategy: %v", err)
+	}
+
+	db, err := app.OpenDatabase(cfg, database.NewPools(), nil, logger)
+	if err != nil {
+		logger.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	result, err := seed.Load(db.Repos, storage, paths)
+	if err != nil {
+		logger.Fatalf("Failed to load sample data: %v", err)
+	}
+	logger.Printf("Loaded %d sample matches, %d already present", result.Created, result.Skipped)
+}
@@ -0,0 +1,260 @@
+// Package app wires the API server together: it opens the database and
+// storage of a configuration, constructs the services, controllers and
+// router, and owns the background workers and connections they need. The
+// server command and tests build the same application through New, so
+// construction is written once.
+//
+// An App has an explicit lifecycle: New constructs everything without
+// starting work, Start runs the background workers, and Close stops them and
+// releases the connections.
+package app
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"nivai/backend/pkg/config"
+	"nivai/backend/pkg/database"
+	"nivai/backend/pkg/httpclient"
+	"nivai/backend/pkg/lifecycle"
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/pythonapi"
+	"nivai/backend/pkg/seed"
+	"nivai/backend/pkg/services"
+	"nivai/backend/pkg/support"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// App is the constructed API server
+type App struct {
+	Config    *config.Config
+	Pools     *database.Pools
+	Repos     *models.Repositories
+	Storage   services.StorageService
+	Lifecycle *lifecycle.Manager
+	Logs      *support.LogBuffer
+	Router    http.Handler
+
+	logger   *log.Logger
+	reloader *config.Reloader
+	fileURLs *services.FileURLSigner // Signs the URLs of local files the API serves
+	clients  *httpclient.Factory     // Outbound HTTP clients, shared by startup probes and the services
+	db       *Database               // nil when the repositories are in memory or given
+	metrics  *prometheus.Registry    // Served at /metrics
+	queries  *database.QueryTimer    // Times the database queries
+	gate     *Gate                   // Told which dependencies are waited for; may be nil
+
+	mu      sync.Mutex
+	workers []func(ctx context.Context) // Background loops, run until their context is done
+	cancel  context.CancelFunc
+}
+
+// Option configures New
+type Option func(*App)
+
+// WithLogger logs construction progress to logger instead of the standard
+// logger
+func WithLogger(logger *log.Logger) Option {
+	return func(a *App) { a.logger = logger }
+}
+
+// WithLogs keeps recent log output for support bundles in logs, which the
+// caller attached to the log output; by default an unattached buffer is used
+func WithLogs(logs *support.LogBuffer) Option {
+	return func(a *App) { a.Logs = logs }
+}
+
+// WithReloader enables configuration reloads through the admin API, and
+// makes the reloadable components follow them
+func WithReloader(reloader *config.Reloader) Option {
+	return func(a *App) { a.reloader = reloader }
+}
+
+// WithRepositories uses repos instead of opening the configured database
+func WithRepositories(repos *models.Repositories) Option {
+	return func(a *App) { a.Repos = repos }
+}
+
+// WithStorage uses storage instead of the configured storage service
+func WithStorage(storage services.StorageService) Option {
+	return func(a *App) { a.Storage = storage }
+}
+
+// New constructs the application for cfg. In demo mode the repositories and
+// storage are kept in memory and loaded with the sample matches; otherwise
+// New waits for storage, the database and, if configured, the Python API,
+// retrying until the startup timeout. Nothing runs until Start; Close
+// releases what New opened, also when Start was never called.
+func New(cfg *config.Config, opts ...Option) (*App, error) {
+	a := &App{Config: cfg, Pools: database.NewPools(), logger: log.Default(), fileURLs: newFileURLSigner(cfg)}
+	for _, opt := range opts {
+		opt(a)
+	}
+	if a.Logs == nil {
+		a.Logs = support.NewLogBuffer(cfg.Support.LogLines)
+	}
+	a.clients = newHTTPClients(cfg)
+	a.metrics = prometheus.NewRegistry()
+	a.metrics.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	queries, err := database.NewQueryTimer(time.Duration(cfg.Database.SlowQueryMs)*time.Millisecond, a.metrics)
+	if err != nil {
+		return nil, err
+	}
+	a.queries = queries
+
+	if err := a.open(); err != nil {
+		a.Close()
+		return nil, err
+	}
+
+	// Lifecycle manager: tracks in-flight work and sequences graceful shutdown
+	a.Lifecycle = lifecycle.New(lifecycle.Config{
+		PreStopDelay: time.Duration(cfg.Shutdown.PreStopDelaySecs) * time.Second,
+		DrainTimeout: time.Duration(cfg.Shutdown.DrainTimeoutSecs) * time.Second,
+	})
+	a.Router = a.router()
+	return a, nil
+}
+
+// open opens the repositories and storage not given as options
+func (a *App) open() error {
+	// Demo mode keeps everything in memory, so the API runs without
+	// PostgreSQL or cloud storage, e.g. for frontend development
+	if a.Config.Demo {
+		if a.Storage == nil {
+			a.Storage = services.NewMemoryStorageService()
+		}
+		if a.Repos != nil {
+			return nil
+		}
+		a.logger.Println("Demo mode: data and files are kept in memory and lost on exit")
+		a.Repos = models.NewMemoryRepositories()
+		result, err := seed.Load(a.Repos, a.Storage, services.IDShardStrategy{})
+		if err != nil {
+			return err
+		}
+		a.logger.Printf("Demo mode: loaded %d sample matches", result.Created)
+		return nil
+	}
+
+	// Dependencies that are not up yet are retried, so the server can start
+	// before them
+	if a.Storage == nil {
+		err := a.retry("storage", func() (err error) {
+			a.Storage, err = OpenStorage(a.Config, a.logger, a.fileURLs)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if a.Repos == nil {
+		var db *Database
+		err := a.retry("database", func() (err error) {
+			db, err = OpenDatabase(a.Config, a.Pools, a.queries, a.logger)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		a.db, a.Repos = db, db.Repos
+		a.background(db.Run) // Read replica health checks
+	}
+	if a.Config.Startup.WaitForPythonAPI {
+		client := pythonapi.NewClient(a.Config.PythonAPI.BaseURL, a.clients.Client(httpclient.DestinationAnalytics))
+		err := a.retry("python_api", func() error {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			return client.Ping(ctx)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// retry calls open until it succeeds, doubling the wait between attempts up
+// to the maximum backoff. It gives up when the startup timeout would pass
+// before the next attempt.
+func (a *App) retry(name string, open func() error) error {
+	startup := a.Config.Startup
+	backoff := time.Duration(startup.InitialBackoffSecs) * time.Second
+	maxBackoff := time.Duration(startup.MaxBackoffSecs) * time.Second
+	var deadline time.Time
+	if startup.TimeoutSecs > 0 {
+		deadline = time.Now().Add(time.Duration(startup.TimeoutSecs) * time.Second)
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := open()
+		if err == nil {
+			if attempt > 1 {
+				a.logger.Printf("Startup: %s is up after %d attempts", name, attempt)
+			}
+			a.gate.up(name)
+			return nil
+		}
+		if !deadline.IsZero() && !time.Now().Add(backoff).Before(deadline) {
+			return fmt.Errorf("%s is not available after %d attempts: %w", name, attempt, err)
+		}
+		a.gate.failed(name, attempt)
+		a.logger.Printf("Startup: waiting for %s, retrying in %s: %v", name, backoff, err)
+		time.Sleep(backoff)
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+// newHTTPClients builds the outbound HTTP clients of cfg: one pooled,
+// instrumented client per destination. An invalid configuration falls back
+// to the built-in defaults rather than failing startup.
+func newHTTPClients(cfg *config.Config) *httpclient.Factory {
+	clients, err := httpclient.FromConfig(cfg)
+	if err != nil {
+		log.Printf("Warning: Invalid HTTP client configuration, using built-in defaults: %v", err)
+		clients, _ = httpclient.New(httpclient.Settings{}, nil)
+	}
+	return clients
+}
+
+// background registers a worker that Start runs
+func (a *App) background(run func(ctx context.Context)) {
+	a.workers = append(a.workers, run)
+}
+
+// Start runs the background workers: webhook delivery, the outbox relay,
+// retention, archiving, load shedding probes and the like. They stop when
+// ctx is done or Close is called.
+func (a *App) Start(ctx context.Context) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.cancel != nil {
+		return
+	}
+	ctx, a.cancel = context.WithCancel(ctx)
+	for _, run := range a.workers {
+		go run(ctx)
+	}
+	a.logger.Printf("Started %d background workers", len(a.workers))
+}
+
+// Close stops the background workers and closes the database and idle
+// outbound connections. Run it after the server has drained.
+func (a *App) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.cancel != nil {
+		a.cancel()
+	}
+	a.clients.CloseIdleConnections()
+	if a.db != nil {
+		return a.db.Close()
+	}
+	return nil
+}
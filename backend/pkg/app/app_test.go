@@ -0,0 +1,153 @@
+package app_test
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"nivai/backend/pkg/app"
+	"nivai/backend/pkg/config"
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/services"
+	"nivai/backend/pkg/support"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewInDemoMode(t *testing.T) {
+	cfg, err := config.Load()
+	require.NoError(t, err)
+	cfg.Demo = true
+
+	repos := models.NewMemoryRepositories()
+	require.NoError(t, repos.Videos.Create(&models.Video{
+		ID: "demo-1", Title: "Demo match", ProcessingState: "completed", CreatedAt: time.Now(),
+	}))
+	a, err := app.New(cfg, app.WithLogger(log.New(io.Discard, "", 0)),
+		app.WithRepositories(repos), app.WithStorage(services.NewMemoryStorageService()))
+	require.NoError(t, err)
+	a.Start(context.Background())
+	defer a.Close()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/videos/demo-1", nil)
+	req.Header.Set("Authorization", "Bearer demo")
+	rec := httptest.NewRecorder()
+	a.Router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Demo match")
+	assert.Equal(t, "1", rec.Header().Get("API-Version"))
+
+	// API v2 serves the same controllers with its own response shapes
+	req = httptest.NewRequest(http.MethodGet, "/api/v2/videos", nil)
+	req.Header.Set("Authorization", "Bearer demo")
+	rec = httptest.NewRecorder()
+	a.Router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "2", rec.Header().Get("API-Version"))
+	assert.Contains(t, rec.Body.String(), `"pagination":`)
+}
+
+func TestNewLoadsDemoData(t *testing.T) {
+	cfg, err := config.Load()
+	require.NoError(t, err)
+	cfg.Demo = true
+
+	a, err := app.New(cfg, app.WithLogger(log.New(io.Discard, "", 0)))
+	require.NoError(t, err)
+	defer a.Close()
+
+	videos, err := a.Repos.Videos.FindAll(100, 0)
+	require.NoError(t, err)
+	assert.NotEmpty(t, videos, "demo mode loads the sample matches")
+	assert.NotNil(t, a.Storage)
+	assert.NoError(t, a.Close(), "Close is safe without Start and when repeated")
+}
+
+func TestConfigReload(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(file, []byte("rate_limits:\n  default: 100\n"), 0o600))
+	sources := config.Sources{File: file, Overrides: config.Overrides{"demo": "true"}}
+	cfg, err := config.LoadFrom(sources)
+	require.NoError(t, err)
+
+	a, err := app.New(cfg, app.WithLogger(log.New(io.Discard, "", 0)),
+		app.WithRepositories(models.NewMemoryRepositories()),
+		app.WithLogs(support.NewLogBuffer(10)),
+		app.WithReloader(config.NewReloader(cfg, sources)))
+	require.NoError(t, err)
+	defer a.Close()
+
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer demo")
+		rec := httptest.NewRecorder()
+		a.Router.ServeHTTP(rec, req)
+		return rec
+	}
+	assert.Equal(t, "100", serve(http.MethodGet, "/api/v1/videos").Header().Get("X-RateLimit-Limit"))
+
+	require.NoError(t, os.WriteFile(file, []byte("rate_limits:\n  default: 50\n"), 0o600))
+	rec := serve(http.MethodPost, "/api/v1/admin/config/reload")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), `"key":"rate_limits.default","old":"100","new":"50"`)
+	assert.Contains(t, rec.Body.String(), `"actor":"mock-user-id"`)
+
+	assert.Equal(t, "50", serve(http.MethodGet, "/api/v1/videos").Header().Get("X-RateLimit-Limit"))
+
+	rec = serve(http.MethodGet, "/api/v1/admin/config/reloads")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"trigger":"api"`)
+}
+
+func TestNewGivesUpAfterStartupTimeout(t *testing.T) {
+	cfg, err := config.Load()
+	require.NoError(t, err)
+	cfg.Database.Driver = "sqlite"
+	cfg.Database.SQLite.Path = filepath.Join(t.TempDir(), "missing", "nivai.db")
+	cfg.Startup.InitialBackoffSecs = 1
+	cfg.Startup.TimeoutSecs = 1
+
+	gate := app.NewGate()
+	_, err = app.New(cfg, app.WithLogger(log.New(io.Discard, "", 0)),
+		app.WithStorage(services.NewMemoryStorageService()), app.WithGate(gate))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "database is not available after 1 attempts")
+}
+
+func TestGate(t *testing.T) {
+	gate := app.NewGate()
+	serve := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		gate.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	t.Run("probes and requests while starting", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve("/api/v1/health").Code, "the process is alive")
+
+		rec := serve("/api/v2/ready")
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"status":"starting"`)
+
+		rec = serve("/api/v1/videos")
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		assert.Equal(t, "5", rec.Header().Get("Retry-After"))
+	})
+
+	t.Run("open hands requests to the application", func(t *testing.T) {
+		gate.Open(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTeapot)
+		}))
+		assert.Equal(t, http.StatusTeapot, serve("/api/v1/ready").Code)
+		assert.Equal(t, http.StatusTeapot, serve("/api/v1/videos").Code)
+	})
+}
@@ -0,0 +1,107 @@
+package app
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"path"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"nivai/backend/pkg/controllers"
+	"nivai/backend/pkg/httperr"
+)
+
+// Gate serves requests while the application starts, so the server can
+// listen before its dependencies are up: the liveness probe answers 200, the
+// readiness probe 503 with the dependencies still waited for, and every other
+// request 503 with Retry-After. Once opened it hands all requests to the
+// application.
+type Gate struct {
+	handler atomic.Pointer[http.Handler]
+
+	mu      sync.Mutex
+	waiting map[string]int // Dependencies not up yet, with their failed attempts
+}
+
+// NewGate creates a closed gate
+func NewGate() *Gate {
+	return &Gate{waiting: make(map[string]int)}
+}
+
+// WithGate reports the dependencies New waits for to gate
+func WithGate(gate *Gate) Option {
+	return func(a *App) { a.gate = gate }
+}
+
+// Open sends every following request to handler
+func (g *Gate) Open(handler http.Handler) {
+	g.handler.Store(&handler)
+}
+
+// ServeHTTP serves r from the application once the gate is open
+func (g *Gate) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if handler := g.handler.Load(); handler != nil {
+		(*handler).ServeHTTP(w, r)
+		return
+	}
+
+	switch {
+	case probe(r.URL.Path, "health"):
+		controllers.HealthCheck(w, r)
+	case probe(r.URL.Path, "ready"):
+		g.serveStarting(w)
+	default:
+		w.Header().Set("Retry-After", "5")
+		httperr.WriteError(w, r, httperr.Unavailable("The server is starting"))
+	}
+}
+
+// serveStarting answers the readiness probe while starting
+func (g *Gate) serveStarting(w http.ResponseWriter) {
+	g.mu.Lock()
+	waiting := make(map[string]int, len(g.waiting))
+	for name, attempts := range g.waiting {
+		waiting[name] = attempts
+	}
+	g.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusServiceUnavailable)
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":      "starting",
+		"timestamp":   time.Now().Format(time.RFC3339),
+		"waiting_for": waiting,
+	}); err != nil {
+		log.Printf("Error encoding readiness response: %v", err)
+	}
+}
+
+// failed records a failed attempt to reach a dependency; a nil gate ignores it
+func (g *Gate) failed(name string, attempts int) {
+	if g == nil {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.waiting[name] = attempts
+}
+
+// up records that a dependency is reachable; a nil gate ignores it
+func (g *Gate) up(name string) {
+	if g == nil {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.waiting, name)
+}
+
+// probe reports whether p is the named probe endpoint of an API version,
+// such as /api/v1/ready
+func probe(p, name string) bool {
+	matched, _ := path.Match("/api/v*/"+name, p)
+	return matched
+}
@@ -0,0 +1,219 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	_ "github.com/lib/pq"           // PostgreSQL driver
+	_ "github.com/mattn/go-sqlite3" // SQLite driver, needs cgo
+	"nivai/backend/pkg/config"
+	"nivai/backend/pkg/database"
+	"nivai/backend/pkg/lock"
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/services"
+)
+
+// Database is the open database of a configuration with its repositories
+type Database struct {
+	Repos *models.Repositories
+
+	dbs      []*sql.DB                         // Primary first, then the read replicas
+	replicas *models.ReplicatedVideoRepository // Probes replica health while running; nil without replicas
+	lockDB   *sql.DB                           // PostgreSQL primary advisory locks are taken on; nil for SQLite
+}
+
+// OpenDatabase connects to the configured database, PostgreSQL with its read
+// replicas or SQLite, registers the connection pools with pools and applies
+// pending migrations. Queries are timed by queries, unless it is nil.
+func OpenDatabase(cfg *config.Config, pools *database.Pools, queries *database.QueryTimer, logger *log.Logger) (*Database, error) {
+	if cfg.Database.Driver == "sqlite" {
+		return openSQLite(cfg, pools, queries, logger)
+	}
+	return openPostgres(cfg, pools, queries, logger)
+}
+
+// Run probes the read replicas until ctx is done; without replicas it
+// returns at once
+func (d *Database) Run(ctx context.Context) {
+	if d.replicas != nil {
+		d.replicas.Run(ctx)
+	}
+}
+
+// Locker takes locks shared by the replicas as PostgreSQL advisory locks.
+// It returns nil for SQLite, whose single replica needs no shared locks.
+func (d *Database) Locker() lock.Locker {
+	if d.lockDB == nil {
+		return nil
+	}
+	return lock.NewPostgres(d.lockDB)
+}
+
+// Close closes the primary and replica connections
+func (d *Database) Close() error {
+	var errs []error
+	for _, db := range d.dbs {
+		errs = append(errs, db.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// openPostgres connects to PostgreSQL and its read replicas. Video reads are
+// spread over the replicas, if any.
+func openPostgres(cfg *config.Config, pools *database.Pools, queries *database.QueryTimer, logger *log.Logger) (*Database, error) {
+	logger.Println("Initializing database connection...")
+	pg := cfg.Database.Postgres
+	db, err := queries.Open("postgres", fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
+		pg.Host, pg.Port, pg.User, pg.Password, pg.DBName, pg.SSLMode), "primary")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database connection: %w", err)
+	}
+	d := &Database{dbs: []*sql.DB{db}, lockDB: db}
+	database.ConfigurePool(db, poolConfig(cfg))
+	pools.Add("primary", db)
+
+	if err := db.Ping(); err != nil {
+		d.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+	logger.Println("Database connection initialized successfully")
+
+	// Apply pending schema migrations
+	if err := database.Migrate(db); err != nil {
+		d.Close()
+		return nil, fmt.Errorf("failed to apply database migrations: %w", err)
+	}
+	logger.Println("Database migrations applied")
+
+	if d.Repos, err = models.NewPostgresRepositories(db); err != nil {
+		d.Close()
+		return nil, fmt.Errorf("failed to create repositories: %w", err)
+	}
+	if len(pg.ReadReplicas) == 0 {
+		return d, nil
+	}
+
+	var replicas []models.VideoReplica
+	for i, dsn := range pg.ReadReplicas {
+		name := fmt.Sprintf("replica-%d", i+1)
+		replicaDB, err := queries.Open("postgres", dsn, name)
+		if err != nil {
+			d.Close()
+			return nil, fmt.Errorf("failed to open read replica %d: %w", i+1, err)
+		}
+		d.dbs = append(d.dbs, replicaDB)
+		database.ConfigurePool(replicaDB, poolConfig(cfg))
+
+		replicaRepo, err := models.NewPostgresVideoRepository(replicaDB)
+		if err != nil {
+			d.Close()
+			return nil, fmt.Errorf("failed to create video repository for read replica %d: %w", i+1, err)
+		}
+		replicas = append(replicas, models.VideoReplica{Name: name, Repo: replicaRepo, Ping: replicaDB.PingContext})
+		pools.Add(name, replicaDB)
+	}
+	d.replicas = models.NewReplicatedVideoRepository(d.Repos.Videos, replicas,
+		time.Duration(pg.ReplicaCheckSecs)*time.Second)
+	d.Repos.Videos = d.replicas
+	logger.Printf("Video reads are routed to %d read replicas", len(replicas))
+	return d, nil
+}
+
+// openSQLite opens the local SQLite database file and applies pending
+// migrations. WAL mode lets readers run alongside the single writer, and
+// writers wait for each other instead of failing.
+func openSQLite(cfg *config.Config, pools *database.Pools, queries *database.QueryTimer, logger *log.Logger) (*Database, error) {
+	logger.Printf("Opening SQLite database %s...", cfg.Database.SQLite.Path)
+	db, err := queries.Open("sqlite3", "file:"+cfg.Database.SQLite.Path+"?_journal_mode=WAL&_busy_timeout=5000", "primary")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open SQLite database: %w", err)
+	}
+	d := &Database{dbs: []*sql.DB{db}}
+	database.ConfigurePool(db, poolConfig(cfg))
+	pools.Add("primary", db)
+
+	if err := db.Ping(); err != nil {
+		d.Close()
+		return nil, fmt.Errorf("failed to open SQLite database: %w", err)
+	}
+	if err := database.MigrateSQLite(db); err != nil {
+		d.Close()
+		return nil, fmt.Errorf("failed to apply database migrations: %w", err)
+	}
+	if d.Repos, err = models.NewSQLiteRepositories(db); err != nil {
+		d.Close()
+		return nil, fmt.Errorf("failed to create repositories: %w", err)
+	}
+	logger.Println("SQLite database ready")
+	return d, nil
+}
+
+// poolConfig returns the connection pool settings of the primary and replicas
+func poolConfig(cfg *config.Config) database.PoolConfig {
+	return database.PoolConfig{
+		MaxOpenConns:    cfg.Database.Postgres.MaxOpenConns,
+		MaxIdleConns:    cfg.Database.Postgres.MaxIdleConns,
+		ConnMaxLifetime: time.Duration(cfg.Database.Postgres.ConnMaxLifetimeMins) * time.Minute,
+		ConnMaxIdleTime: time.Duration(cfg.Database.Postgres.ConnMaxIdleTimeMins) * time.Minute,
+	}
+}
+
+// OpenStorage creates the configured storage service: a local directory or
+// Azure Blob Storage, falling back to local storage under the mount path,
+// which is created when missing. Local files get stream URLs signed by
+// fileURLs, or file:// URLs when it is nil.
+func OpenStorage(cfg *config.Config, logger *log.Logger, fileURLs *services.FileURLSigner) (services.StorageService, error) {
+	logger.Println("Initializing storage service...")
+	factory := services.NewStorageFactory().
+		WithAzureConfig(AzureStorageConfig(cfg)).
+		WithLocalPath(cfg.Storage.LocalPath).
+		WithLocalURLSigner(fileURLs)
+	storage, err := factory.CreateDefaultStorage()
+	if err == nil {
+		logger.Printf("Storage service initialized successfully")
+		return storage, nil
+	}
+
+	mount := cfg.Storage.MountPath
+	if mount == "" {
+		return nil, fmt.Errorf("no valid storage configuration found and no mount point specified: %w", err)
+	}
+	logger.Printf("Warning: Could not initialize default storage, using mount point %s: %v", mount, err)
+	if err := os.MkdirAll(mount, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create mount directory: %w", err)
+	}
+	storage, err = factory.WithLocalPath(mount).CreateStorage(services.LocalFileStorageType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize storage with mount point: %w", err)
+	}
+	logger.Printf("Storage service initialized successfully")
+	return storage, nil
+}
+
+// newFileURLSigner creates the signer of the temporary URLs the API serves
+// for local files
+func newFileURLSigner(cfg *config.Config) *services.FileURLSigner {
+	signed := cfg.Storage.SignedURLs
+	return services.NewFileURLSigner(signed.SigningKey, signed.BaseURL, time.Duration(signed.ExpiryMinutes)*time.Minute)
+}
+
+// AzureStorageConfig returns the configured Azure Blob Storage account,
+// container and credentials
+func AzureStorageConfig(cfg *config.Config) services.AzureStorageConfig {
+	azure := cfg.Storage.AzureBlobStorage
+	return services.AzureStorageConfig{
+		AccountName:      azure.AccountName,
+		ContainerName:    azure.ContainerName,
+		AuthMode:         azure.AuthMode,
+		AccountKey:       azure.AccountKey,
+		TenantID:         azure.TenantID,
+		ClientID:         azure.ClientID,
+		ClientSecret:     azure.ClientSecret,
+		ConnectionString: azure.ConnectionString,
+	}
+}
@@ -0,0 +1,738 @@
+package app
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"nivai/backend/pkg/broker"
+	"nivai/backend/pkg/cache"
+	"nivai/backend/pkg/config"
+	"nivai/backend/pkg/controllers"
+	"nivai/backend/pkg/debuglog"
+	"nivai/backend/pkg/events"
+	"nivai/backend/pkg/httpclient"
+	"nivai/backend/pkg/httperr"
+	"nivai/backend/pkg/lock"
+	"nivai/backend/pkg/metrics"
+	"nivai/backend/pkg/middleware"
+	"nivai/backend/pkg/notify"
+	"nivai/backend/pkg/pythonapi"
+	"nivai/backend/pkg/routes"
+	"nivai/backend/pkg/scheduler"
+	"nivai/backend/pkg/services"
+	"nivai/backend/pkg/slo"
+	"nivai/backend/pkg/support"
+	"nivai/backend/pkg/upload"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/redis/go-redis/v9"
+)
+
+// router constructs the services and controllers, and the router serving
+// the API with them. Background loops are registered with a.background, so
+// they run from Start.
+func (a *App) router() http.Handler {
+	cfg, repos, storage := a.Config, a.Repos, a.Storage
+
+	// Initialize router
+	router := mux.NewRouter()
+
+	// Unknown paths and methods get the same JSON errors as the handlers
+	router.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		httperr.WriteError(w, r, httperr.NotFound("No such endpoint"))
+	})
+	router.MethodNotAllowedHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		httperr.WriteError(w, r, httperr.New(http.StatusMethodNotAllowed, httperr.CodeMethodNotAllowed, "Method not allowed"))
+	})
+
+	// Apply common middleware to all routes. In-flight tracking comes first so
+	// shutdown waits for the whole request; the request bundle comes next so
+	// the logger and everything after it can read the request ID.
+	router.Use(a.Lifecycle.Track)
+	router.Use(middleware.RequestContext(cfg.Organization.Name, cfg.Organization.Locale))
+	router.Use(middleware.APIVersion)
+	router.Use(middleware.Logger)
+	apiSamples, pythonSamples := newDebugSamplers(cfg)
+	router.Use(apiSamples.Middleware)
+	corsPolicy := middleware.NewCORSPolicy(cfg.CORS.AllowedOrigins)
+	router.Use(corsPolicy.Handler)
+
+	// Locks shared by the replicas, so background work and reprocessing run once
+	locker := newLocker(cfg, a.db)
+
+	// Event bus shared by all publishers and subscribers
+	eventBus := events.NewBus()
+
+	// Recent events are kept for support bundles
+	eventRecorder := support.NewEventRecorder(cfg.Support.Events)
+	eventBus.Subscribe(events.Wildcard, eventRecorder.HandleEvent)
+
+	// SLO tracking: request metrics feed per-route-group objectives, and
+	// burn-rate alerts are published as events (deliverable via webhooks)
+	sloTracker := slo.NewTracker(slo.DefaultObjectives(), slo.WithEventBus(eventBus))
+	router.Use(metrics.Middleware(slo.Classify, sloTracker))
+	a.background(sloTracker.Run)
+
+	// Outbound HTTP clients: one pooled, instrumented client per destination
+	httpClients := a.clients
+
+	// Outgoing webhooks: deliveries are enqueued from events and sent by a background worker
+	// Personal data is stored encrypted when PII keys are configured;
+	// referees, which matches are filtered by, also need the index key
+	playerNames, referees := newPIICiphers(cfg)
+	videoRepo := services.NewRefereeCipherRepository(repos.Videos, referees)
+	webhookRepo := repos.Webhooks
+	var webhookOpts []services.WebhookServiceOption
+	if !cfg.HTTPClients.Defaults.PublicOnly && !cfg.HTTPClients.Destinations[httpclient.DestinationWebhooks].PublicOnly {
+		webhookOpts = append(webhookOpts, services.WithInternalWebhookTargets())
+	}
+	webhookService := services.NewWebhookService(webhookRepo, webhookOpts...)
+	webhookDispatcher := services.NewWebhookDispatcher(webhookRepo,
+		httpClients.Client(httpclient.DestinationWebhooks),
+		services.WebhookDispatcherConfig{
+			MaxAttempts:    cfg.Webhooks.MaxAttempts,
+			InitialBackoff: time.Duration(cfg.Webhooks.InitialBackoffSecs) * time.Second,
+			MaxBackoff:     time.Duration(cfg.Webhooks.MaxBackoffSecs) * time.Second,
+			PollInterval:   time.Duration(cfg.Webhooks.PollIntervalSecs) * time.Second,
+			Events:         eventBus,
+		})
+	eventBus.Subscribe(events.Wildcard, webhookDispatcher.HandleEvent)
+	a.background(webhookDispatcher.Run)
+
+	// Optional broker integration: lifecycle events go through the outbox table
+	if cfg.Broker.Type != "" {
+		publisher, err := broker.NewPublisher(cfg, httpClients.Client(httpclient.DestinationKafka))
+		if err != nil {
+			log.Printf("Warning: Message broker disabled: %v", err)
+		} else {
+			outboxRelay := services.NewOutboxRelay(repos.Outbox, publisher,
+				time.Duration(cfg.Broker.PollIntervalSecs)*time.Second)
+			eventBus.Subscribe(events.Wildcard, outboxRelay.HandleEvent)
+			a.background(outboxRelay.Run)
+		}
+	}
+
+	// Typed client for the Python analytics service, shared by all callers.
+	// With the gRPC transport, processing, status and summary calls use gRPC;
+	// player and team details stay on HTTP.
+	pythonOpts := []pythonapi.Option{
+		pythonapi.WithMaxResponseSize(int64(cfg.PythonAPI.MaxResponseMB) << 20),
+		pythonapi.WithContractValidation(pythonapi.ContractMode(cfg.PythonAPI.Contracts)),
+		pythonapi.WithWorkers(pythonapi.Routing(cfg.PythonAPI.Routing), cfg.PythonAPI.WorkerURLs...),
+		pythonapi.WithHealthCheckInterval(time.Duration(cfg.PythonAPI.HealthCheckSecs) * time.Second),
+	}
+	if cfg.PythonAPI.Transport == "grpc" {
+		transport, err := pythonapi.DialGRPC(cfg.PythonAPI.GRPCAddress)
+		if err != nil {
+			log.Printf("Warning: Python API gRPC transport disabled, falling back to HTTP: %v", err)
+		} else {
+			pythonOpts = append(pythonOpts, pythonapi.WithTransport(transport))
+		}
+	}
+	pythonOpts = append(pythonOpts, pythonapi.WithDebugLog(pythonSamples))
+	pythonClient := pythonapi.NewClient(cfg.PythonAPI.BaseURL, httpClients.Client(httpclient.DestinationAnalytics), pythonOpts...)
+	a.background(pythonClient.Run)
+
+	// WebSocket hub for real-time updates
+	wsHub := controllers.NewHub(controllers.WithLifecycle(a.Lifecycle))
+	a.background(func(context.Context) { wsHub.Run() }) // Stops with the lifecycle a.Lifecycle
+
+	// Match-day mode: fresher analytics cache, pre-warming and richer live updates
+	matchDayService := services.NewMatchDayService(repos.MatchDays, services.MatchDayConfig{
+		Lead:             time.Duration(cfg.MatchDay.LeadMinutes) * time.Minute,
+		Duration:         time.Duration(cfg.MatchDay.DurationMinutes) * time.Minute,
+		CacheTTL:         time.Duration(cfg.MatchDay.CacheTTLSecs) * time.Second,
+		MatchDayCacheTTL: time.Duration(cfg.MatchDay.MatchDayCacheTTLSecs) * time.Second,
+	})
+	// Results of processed matches are immutable and kept in a persistent store
+	resultStore, err := cache.NewStore(cfg)
+	if err != nil {
+		log.Printf("Warning: Persistent analytics cache disabled: %v", err)
+	}
+	analyticsCache := services.NewAnalyticsCache(pythonClient, matchDayService.CacheTTL,
+		services.WithResultStore(resultStore, services.ProcessedMatches(videoRepo)))
+	eventBus.Subscribe(events.Wildcard, analyticsCache.HandleEvent)
+	eventBus.Subscribe(events.Wildcard, services.NewLiveNotifier(matchDayService, analyticsCache, wsHub).HandleEvent)
+	matchDayWarmer := services.NewMatchDayWarmer(matchDayService, analyticsCache, wsHub,
+		time.Duration(cfg.MatchDay.RefreshIntervalSecs)*time.Second)
+	a.background(matchDayWarmer.Run)
+
+	// Analytics snapshots are captured whenever a match's analytics complete
+	fileRepo := repos.VideoFiles
+	snapshotService := services.NewAnalyticsSnapshotService(repos.Snapshots, pythonClient)
+	eventBus.Subscribe(events.AnalyticsCompleted, snapshotService.HandleEvent)
+
+	// Create controller instances with dependencies
+	// First, create the services that controllers depend on
+	// Service and controller share one layout, so a match's files stay together
+	pathStrategy, err := services.NewPathStrategy(cfg.Storage.PathStrategy)
+	if err != nil {
+		log.Printf("Warning: %v, using %s", err, services.PathStrategyIDShard)
+		pathStrategy = services.IDShardStrategy{}
+	}
+	// Stored files are handed to the Python API as paths it can read
+	pathResolver, err := services.NewPathResolver(cfg.PythonAPI.PathMode, cfg.PythonAPI.PathMap, storage)
+	if err != nil {
+		log.Printf("Warning: Invalid Python API path mode, passing storage paths: %v", err)
+		pathResolver = services.StoragePathResolver{}
+	}
+	videoServiceInstance := services.NewVideoService(videoRepo, storage,
+		services.WithEventBus(eventBus),
+		services.WithFileRepository(fileRepo),
+		services.WithPathStrategy(pathStrategy),
+		services.WithStateHistory(repos.StateHistory),
+	)
+	// The in-app inbox is a notification channel; the bootstrap reports its unread count
+	notificationInbox := services.NewNotificationInbox(repos.Inbox)
+	bootstrapService := services.NewBootstrapService(
+		services.NewRefereeCipherReferenceData(repos.ReferenceData, referees),
+		services.OrganizationSettings{
+			Name:     cfg.Organization.Name,
+			Timezone: cfg.Organization.Timezone,
+			Locale:   cfg.Organization.Locale,
+		},
+		cfg.Features,
+		notificationInbox,
+	)
+	// Storage usage accounting: uploads are charged, purged matches released
+	quotaService := services.NewQuotaService(repos.StorageUsage, services.QuotaConfig{
+		OrganizationBytes: cfg.Quotas.OrganizationBytes,
+		UserBytes:         cfg.Quotas.UserBytes,
+	})
+	eventBus.Subscribe(events.VideoPurged, quotaService.HandleEvent)
+
+	// Deleted matches keep their files for the grace period, then are purged
+	videoPurges := services.NewVideoPurgeService(videoRepo, videoServiceInstance, repos.VideoPurges, storage, eventBus,
+		time.Duration(cfg.Deletion.GracePeriodHours)*time.Hour)
+
+	// Matches can be created from their metadata before their files are attached
+	drafts := services.NewDraftService(videoRepo, videoServiceInstance, storage, videoPurges,
+		time.Duration(cfg.Drafts.TTLHours)*time.Hour)
+
+	// Users are notified on the channels of their preferences
+	notificationChannels := []notify.Channel{
+		notificationInbox,
+		notify.NewSlackChannel(httpClients.Client(httpclient.DestinationNotifications)),
+	}
+	if smtpCfg := cfg.Notifications.SMTP; smtpCfg.Host != "" {
+		notificationChannels = append(notificationChannels,
+			notify.NewSMTPChannel(smtpCfg.Host, smtpCfg.Port, smtpCfg.Username, smtpCfg.Password, smtpCfg.From))
+	}
+	notificationService := services.NewNotificationService(repos.Notifications, quotaService, notificationChannels,
+		services.NotificationConfig{Organization: cfg.Organization.Name, QueueSize: cfg.Notifications.QueueSize})
+	eventBus.Subscribe(events.Wildcard, notificationService.HandleEvent)
+	a.background(notificationService.Run)
+	auditor := services.NewConsistencyAuditor(videoRepo, fileRepo, storage, pythonClient, snapshotService, videoServiceInstance)
+
+	// Matches stuck waiting on analytics are settled with the Python API's status
+	reconciler := services.NewProcessingReconciler(videoRepo, pythonClient, videoServiceInstance, eventBus, services.ReconcilerConfig{
+		StuckAfter: time.Duration(cfg.Reconciler.StuckAfterMinutes) * time.Minute,
+	})
+
+	// Storage retention: expired files are archived or deleted on a schedule
+	retentionRules, err := services.ParseRetentionRules(cfg.Retention.Rules)
+	if err != nil {
+		log.Printf("Warning: Invalid retention rules, keeping all files: %v", err)
+		retentionRules = nil
+	}
+	retentionRepo := repos.Retention
+	retentionService := services.NewRetentionService(videoRepo, fileRepo, retentionRepo, storage, services.RetentionConfig{
+		Rules:         retentionRules,
+		DryRun:        cfg.Retention.DryRun,
+		ArchivePrefix: cfg.Retention.ArchivePrefix,
+	})
+
+	// Orphaned file collection: files without a match are reported or deleted
+	storageGC := services.NewStorageGCService(videoRepo, storage, services.StorageGCConfig{
+		Prefix:        cfg.StorageGC.Prefix,
+		MinAge:        time.Duration(cfg.StorageGC.MinAgeHours) * time.Hour,
+		DeleteOrphans: cfg.StorageGC.Delete,
+	}, services.WithPendingPurges(repos.VideoPurges))
+
+	// Scheduled jobs run on one replica: the elected leader
+	jobScheduler := newScheduler(cfg, locker)
+	for _, job := range []scheduler.Job{
+		{
+			Name:     "retention",
+			Schedule: jobSchedule(cfg.Scheduler.Retention, time.Duration(cfg.Retention.SweepIntervalHours)*time.Hour),
+			Run:      retentionService.RunScheduled,
+		},
+		{
+			Name:     "storage_gc",
+			Schedule: jobSchedule(cfg.Scheduler.StorageGC, time.Duration(cfg.StorageGC.IntervalHours)*time.Hour),
+			Run:      storageGC.RunScheduled,
+		},
+		{
+			Name:     "reconciler",
+			Schedule: jobSchedule(cfg.Scheduler.Reconciler, time.Duration(cfg.Reconciler.IntervalMinutes)*time.Minute),
+			Run:      reconciler.RunScheduled,
+		},
+		{
+			Name:     "video_purge",
+			Schedule: jobSchedule(cfg.Scheduler.VideoPurge, time.Duration(cfg.Deletion.PurgeIntervalMinutes)*time.Minute),
+			Run:      videoPurges.RunScheduled,
+		},
+		{
+			Name:     "draft_expiry",
+			Schedule: jobSchedule(cfg.Scheduler.DraftExpiry, time.Duration(cfg.Drafts.ExpiryIntervalMinutes)*time.Minute),
+			Run:      drafts.RunScheduled,
+		},
+		{
+			Name:     "usage_accounting",
+			Schedule: jobSchedule(cfg.Scheduler.UsageAccounting, 24*time.Hour),
+			Run: func(ctx context.Context) error {
+				_, err := quotaService.ReleaseDeleted(ctx, videoServiceInstance, repos.VideoPurges)
+				return err
+			},
+		},
+		{
+			Name:     "usage_summary",
+			Schedule: jobSchedule(cfg.Scheduler.UsageSummary, 7*24*time.Hour),
+			Run:      notificationService.SendUsageSummaries,
+		},
+	} {
+		if err := jobScheduler.Register(job); err != nil {
+			log.Printf("Warning: Scheduled job not registered: %v", err)
+		}
+	}
+
+	// Replication and archiving work on the stored bytes, below encryption
+	stored := storage
+	if encrypted, ok := storage.(*services.EncryptedStorage); ok {
+		stored = encrypted.Unwrap()
+	}
+
+	// Replicated storage copies writes to its secondary in the background
+	replicated, _ := stored.(*services.CompositeStorage)
+	if replicated != nil {
+		a.background(replicated.Run)
+	}
+
+	// Cold-storage archiving of completed matches' large files
+	archiveJobs := repos.ArchiveJobs
+	archiveService := services.NewArchiveService(videoRepo, archiveJobs, newArchiver(cfg, stored),
+		time.Duration(cfg.Archive.PollIntervalSecs)*time.Second, services.WithArchiveStateHistory(repos.StateHistory),
+		services.WithArchiveLocker(locker))
+	a.background(archiveService.Run)
+
+	// Non-critical writes are shed while the database or storage is degraded
+	dependencyChecks := map[string]middleware.DependencyCheck{"database": repos.Ping}
+	if checker, ok := stored.(services.HealthChecker); ok {
+		dependencyChecks["storage"] = checker.HealthCheck
+	}
+
+	// Uploaded files are scanned for malware while they stream to storage
+	var scanner upload.Scanner = upload.NopScanner{}
+	if cfg.Scanning.Type == "clamav" {
+		clamav, err := upload.NewClamAV(cfg.Scanning.ClamAVAddress,
+			time.Duration(cfg.Scanning.TimeoutSecs)*time.Second, cfg.Scanning.MaxStreamBytes)
+		if err != nil {
+			log.Printf("Warning: Malware scanning disabled: %v", err)
+		} else {
+			scanner = clamav
+			dependencyChecks["scanner"] = clamav.Ping
+		}
+	}
+
+	// Deliveries to the drop folder become matches, scanned like uploads
+	if source := newIngestSource(cfg); source != nil {
+		ingestion := services.NewIngestionService(source, videoServiceInstance, storage,
+			services.NewMatchProcessor(pythonClient, pathResolver, matchDayService), services.IngestionConfig{
+				SettleAfter:  time.Duration(cfg.Ingestion.SettleSecs) * time.Second,
+				Scanner:      scanner,
+				PathStrategy: pathStrategy,
+			})
+		if err := jobScheduler.Register(scheduler.Job{
+			Name:     "ingestion",
+			Schedule: jobSchedule(cfg.Scheduler.Ingestion, time.Duration(cfg.Ingestion.IntervalSecs)*time.Second),
+			Run:      ingestion.RunScheduled,
+		}); err != nil {
+			log.Printf("Warning: Scheduled job not registered: %v", err)
+		}
+	}
+
+	// Past matches listed in manifests are imported from the backfill directory
+	var backfill *services.BackfillService
+	if cfg.Backfill.Directory != "" {
+		importer := services.NewIngestionService(services.NewDirectorySource(cfg.Backfill.Directory), videoServiceInstance, storage,
+			services.NewMatchProcessor(pythonClient, pathResolver, matchDayService), services.IngestionConfig{
+				Scanner:      scanner,
+				PathStrategy: pathStrategy,
+			})
+		backfill = services.NewBackfillService(importer, videoServiceInstance, services.BackfillConfig{
+			BatchSize:    cfg.Backfill.BatchSize,
+			PollInterval: time.Duration(cfg.Backfill.PollSecs) * time.Second,
+			BatchTimeout: time.Duration(cfg.Backfill.BatchTimeoutMinutes) * time.Minute,
+			MaxEntries:   cfg.Backfill.MaxEntries,
+		})
+		a.background(backfill.Run)
+	}
+	a.background(jobScheduler.Run)
+
+	loadShedder := middleware.NewLoadShedder(middleware.LoadShedderConfig{
+		MaxInFlightWrites: cfg.LoadShedding.MaxInFlightWrites,
+		RetryAfter:        time.Duration(cfg.LoadShedding.RetryAfterSecs) * time.Second,
+		CheckInterval:     time.Duration(cfg.LoadShedding.CheckIntervalSecs) * time.Second,
+		CheckTimeout:      time.Duration(cfg.LoadShedding.CheckTimeoutSecs) * time.Second,
+	}, dependencyChecks)
+	a.background(loadShedder.Run)
+
+	// Support bundles: diagnostics for a time window or a single match
+	supportChecks := make(map[string]func(ctx context.Context) error, len(dependencyChecks))
+	for name, check := range dependencyChecks {
+		supportChecks[name] = check
+	}
+	supportBundles := services.NewSupportBundleService(videoRepo, fileRepo, archiveJobs,
+		retentionRepo, webhookRepo, services.SupportBundleConfig{
+			Config:    cfg,
+			Checks:    supportChecks,
+			Degraded:  loadShedder.Degraded,
+			Lifecycle: a.Lifecycle,
+			Logs:      a.Logs,
+			Events:    eventRecorder,
+		})
+
+	// Players are followed across matches by their roster ID. Their names
+	// are stored encrypted when PII keys are configured
+	playerIdentities := services.NewPlayerIdentityService(repos.Players, repos.PlayerMappings, videoRepo, storage,
+		services.WithNameCipher(playerNames))
+
+	// Matches are shared with people without an account through signed links
+	shares := services.NewShareService(repos.ShareLinks, videoRepo, services.ShareConfig{
+		SigningKey: cfg.Sharing.SigningKey,
+		DefaultTTL: time.Duration(cfg.Sharing.DefaultTTLHours) * time.Hour,
+		MaxTTL:     time.Duration(cfg.Sharing.MaxTTLHours) * time.Hour,
+	})
+
+	// Users see the matches granted to them, when grants are enforced
+	access := services.NewAccessService(repos.AccessGrants, videoRepo, services.AccessConfig{
+		Enforce:           cfg.Access.EnforceGrants,
+		UnrestrictedRoles: []string{middleware.RoleAdmin},
+	})
+
+	// Reloadable settings follow configuration reloads; the rest need a restart
+	rateLimiter := middleware.NewRateLimiter(cfg.RateLimits)
+	ipFilter, err := middleware.NewIPFilter(ipFilterRules(cfg))
+	if err != nil {
+		log.Printf("Warning: Invalid IP filter, client addresses are not restricted: %v", err)
+	}
+	if a.reloader != nil {
+		a.reloader.Subscribe(func(next *config.Config) {
+			rateLimiter.SetLimits(next.RateLimits)
+			if ipFilter != nil {
+				if err := ipFilter.SetRules(ipFilterRules(next)); err != nil {
+					log.Printf("Warning: Invalid IP filter, keeping the previous rules: %v", err)
+				}
+			}
+			corsPolicy.SetOrigins(next.CORS.AllowedOrigins)
+			apiSamples.SetSettings(debugLogSettings(next, next.DebugLog.SamplePercent))
+			pythonSamples.SetSettings(debugLogSettings(next, next.DebugLog.PythonAPISamplePercent))
+			pythonClient.SetWorkers(append([]string{next.PythonAPI.BaseURL}, next.PythonAPI.WorkerURLs...))
+		})
+	}
+
+	// Declare the API routes; their policies decide the middleware each gets.
+	// Callers are only admins in demo mode until tokens carry their role
+	authenticate := middleware.Authenticate
+	if cfg.Demo {
+		authenticate = middleware.DemoAuthenticate
+	}
+	registry := routes.NewRegistry(
+		routes.WithAuthenticator(authenticate),
+		routes.WithRateLimiter(rateLimiter),
+		routes.WithLoadShedder(loadShedder),
+		routes.WithVersionPolicy(1, v1Policy(cfg)),
+		routes.WithAccessControl(access),
+		routes.WithIPFilter(ipFilter),
+		routes.WithResponseCache(newResponseCache(cfg), responseCacheTTLs(cfg)),
+		routes.WithBodyLimits(map[routes.BodyLimitClass]int64{
+			routes.BodyLimitDefault: cfg.BodyLimits.DefaultKB << 10,
+			routes.BodyLimitImport:  cfg.BodyLimits.ImportMB << 20,
+			routes.BodyLimitUpload:  cfg.BodyLimits.UploadMB << 20,
+		}),
+	)
+	registry.Add(routes.APIRoutes(&routes.Controllers{
+		Video: controllers.NewVideoController(videoServiceInstance, storage, pythonClient, matchDayService, controllers.WithUploadScanner(scanner), controllers.WithQuotas(quotaService),
+			controllers.WithPathStrategy(pathStrategy), controllers.WithPathResolver(pathResolver),
+			controllers.WithDirectUploads(repos.UploadSessions, time.Duration(cfg.DirectUploads.URLExpiryMinutes)*time.Minute),
+			controllers.WithUploadProgress(newUploadProgressTracker(cfg, wsHub)),
+			controllers.WithProcessingLocks(locker),
+			controllers.WithVideoAccessControl(access),
+			controllers.WithPurgeQueue(videoPurges),
+			controllers.WithDrafts(drafts),
+			controllers.WithMatchBundles(services.NewMatchBundleService(videoRepo, fileRepo, repos.Snapshots, storage)),
+			controllers.WithUploadLimits(controllers.UploadLimits{
+				Video:    cfg.Uploads.MaxVideoMB << 20,
+				Tracking: cfg.Uploads.MaxTrackingMB << 20,
+				Events:   cfg.Uploads.MaxEventsMB << 20,
+			})),
+		Match: controllers.NewMatchController(videoServiceInstance, pythonClient, controllers.WithSnapshots(snapshotService), controllers.WithMatchAccessControl(access),
+			controllers.WithMatchFiles(storage, fileRepo), controllers.WithMatchShares(shares),
+			controllers.WithMatchCalendar(services.NewMatchCalendarService(repos.MatchCalendar)),
+			controllers.WithStatusFanOut(cfg.MatchList.StatusConcurrency, time.Duration(cfg.MatchList.StatusTimeoutSecs)*time.Second),
+			controllers.WithStatusDegradation(cfg.MatchList.BreakerFailures,
+				time.Duration(cfg.MatchList.BreakerCooldownSecs)*time.Second, cfg.MatchList.StatusCacheSize)),
+		MatchDay: controllers.NewMatchDayController(matchDayService, videoServiceInstance, access),
+		Player: controllers.NewPlayerController(services.NewPlayerAggregateService(videoRepo, analyticsCache,
+			services.WithPlayerMappings(repos.PlayerMappings)), access),
+		PlayerIdentity: controllers.NewPlayerIdentityController(playerIdentities),
+		PlayerPrivacy:  controllers.NewPlayerPrivacyController(services.NewPlayerPrivacyService(repos, analyticsCache, eventBus, playerNames)),
+		Share:          controllers.NewShareController(shares, videoServiceInstance, analyticsCache, storage),
+		File:           controllers.NewFileController(storage, a.fileURLs),
+		Access:         controllers.NewAccessController(access),
+		Notification:   controllers.NewNotificationController(notificationService, notificationInbox),
+		Analytics: controllers.NewAnalyticsController(analyticsCache, controllers.WithPlayerIdentities(playerIdentities),
+			controllers.WithPlayerDetailsRelay(pythonClient)),
+		Season:      controllers.NewSeasonController(services.NewSeasonAnalyticsService(videoRepo, analyticsCache)),
+		Webhook:     controllers.NewWebhookController(webhookService),
+		Audit:       controllers.NewAuditController(auditor),
+		Bootstrap:   controllers.NewBootstrapController(bootstrapService),
+		SLO:         controllers.NewSLOController(sloTracker),
+		HTTPClient:  controllers.NewHTTPClientController(httpClients),
+		Report:      controllers.NewReportController(services.NewReportService(videoServiceInstance, analyticsCache), access),
+		Retention:   controllers.NewRetentionController(retentionService, videoServiceInstance),
+		Archive:     controllers.NewArchiveController(archiveService, access),
+		Backfill:    controllers.NewBackfillController(backfill),
+		Support:     controllers.NewSupportController(supportBundles),
+		Usage:       controllers.NewUsageController(quotaService),
+		Stats:       controllers.NewStatsController(services.NewStatsService(repos.MatchStats, time.Duration(cfg.Stats.CacheTTLSecs)*time.Second), access),
+		StorageGC:   controllers.NewStorageGCController(storageGC),
+		Replication: controllers.NewReplicationController(replicated),
+		Scheduler:   controllers.NewSchedulerController(jobScheduler),
+		Database:    controllers.NewDatabaseController(a.Pools),
+		Config:      controllers.NewConfigController(a.reloader),
+		Hub:         wsHub,
+		Lifecycle:   a.Lifecycle,
+		OpenAPI:     registry.OpenAPIHandler("NIVAI API"),
+		Metrics:     promhttp.HandlerFor(a.metrics, promhttp.HandlerOpts{}).ServeHTTP,
+	})...)
+	registry.Mount(router)
+
+	return router
+}
+
+// v1Policy states whether API v1 is deprecated in favour of v2, and when it
+// will be removed.
+func v1Policy(cfg *config.Config) routes.VersionPolicy {
+	policy := routes.VersionPolicy{Deprecated: cfg.API.V1Deprecated, Successor: 2}
+	if cfg.API.V1Sunset != "" {
+		sunset, err := time.Parse(time.DateOnly, cfg.API.V1Sunset)
+		if err != nil {
+			log.Printf("Warning: Invalid API_V1_SUNSET %q, expected a date like 2027-06-30", cfg.API.V1Sunset)
+		} else {
+			policy.Sunset = sunset
+		}
+	}
+	return policy
+}
+
+// ipFilterRules reads the client address restrictions of the configuration.
+func ipFilterRules(cfg *config.Config) middleware.IPFilterRules {
+	f := cfg.IPFilter
+	return middleware.IPFilterRules{
+		AdminAllowlist: f.AdminAllowlist, UploadAllowlist: f.UploadAllowlist, Denylist: f.Denylist,
+		TrustedProxies: f.TrustedProxies, CountryHeader: f.CountryHeader,
+		AllowedCountries: f.AllowedCountries, DeniedCountries: f.DeniedCountries,
+	}
+}
+
+// newLocker creates the locks the replicas share: the configured backend,
+// or PostgreSQL's advisory locks when the database is PostgreSQL. Without
+// a database, or with SQLite, there is one replica and locks are local.
+func newLocker(cfg *config.Config, db *Database) lock.Locker {
+	switch cfg.Locks.Backend {
+	case "redis":
+		return lock.NewRedis(newRedisClient(cfg), "nivai:lock:", time.Duration(cfg.Locks.TTLSeconds)*time.Second)
+	case "local":
+		return lock.NewLocal()
+	}
+	if db != nil {
+		if locker := db.Locker(); locker != nil {
+			return locker
+		}
+	}
+	return lock.NewLocal()
+}
+
+// newIngestSource creates the drop folder of the configured ingestion
+// source, or returns nil when ingestion is disabled or the SFTP credentials
+// cannot be loaded.
+func newIngestSource(cfg *config.Config) services.IngestSource {
+	switch cfg.Ingestion.Source {
+	case "directory":
+		return services.NewDirectorySource(cfg.Ingestion.Directory)
+	case "sftp":
+		sftp := cfg.Ingestion.SFTP
+		source, err := services.NewSFTPSource(services.SFTPConfig{
+			Host:               sftp.Host,
+			Port:               sftp.Port,
+			User:               sftp.User,
+			Password:           sftp.Password,
+			PrivateKeyFile:     sftp.PrivateKeyFile,
+			HostKeyFingerprint: sftp.HostKeyFingerprint,
+			Directory:          cfg.Ingestion.Directory,
+		})
+		if err != nil {
+			log.Printf("Warning: SFTP ingestion disabled: %v", err)
+			return nil
+		}
+		return source
+	}
+	return nil
+}
+
+// newScheduler creates the scheduler of the background jobs, evaluating cron
+// expressions in the organization's timezone. The replica holding the
+// leader lock runs the scheduled jobs.
+func newScheduler(cfg *config.Config, locker lock.Locker) *scheduler.Scheduler {
+	loc, err := time.LoadLocation(cfg.Organization.Timezone)
+	if err != nil {
+		log.Printf("Warning: Unknown organization timezone %q, scheduling in UTC: %v", cfg.Organization.Timezone, err)
+		loc = time.UTC
+	}
+	return scheduler.New(scheduler.NewLockElector(locker, scheduler.LeaderLock),
+		scheduler.WithLocation(loc), scheduler.WithLocker(locker))
+}
+
+// jobSchedule is a job's configured schedule, or a fixed interval when none
+// is configured.
+func jobSchedule(schedule string, interval time.Duration) string {
+	if schedule != "" {
+		return schedule
+	}
+	return "@every " + interval.String()
+}
+
+// newArchiver selects the cold storage for archiving: the Archive access tier
+// of the Azure container, or a second storage backend. It returns nil, which
+// disables archiving, when none is configured or it cannot be set up.
+func newArchiver(cfg *config.Config, storage services.StorageService) services.Archiver {
+	switch cfg.Archive.Type {
+	case "azure_tier":
+		archiver, ok := storage.(services.Archiver)
+		if !ok {
+			log.Printf("Warning: Archiving disabled: storage backend has no archive tier")
+			return nil
+		}
+		return archiver
+	case "cold_storage":
+		var cold services.StorageService
+		var err error
+		switch {
+		case cfg.Archive.ColdPath != "":
+			cold, err = services.NewLocalFileStorage(cfg.Archive.ColdPath)
+		case cfg.Archive.ColdContainer != "":
+			coldConfig := AzureStorageConfig(cfg)
+			coldConfig.ContainerName = cfg.Archive.ColdContainer
+			cold, err = services.NewAzureBlobStorageWithConfig(coldConfig)
+		default:
+			err = errors.New("no cold path or container configured")
+		}
+		if err == nil {
+			var archiver *services.ColdStorage
+			if archiver, err = services.NewColdStorage(storage, cold); err == nil {
+				return archiver
+			}
+		}
+		log.Printf("Warning: Archiving disabled: %v", err)
+	}
+	return nil
+}
+
+// newPIICiphers creates the ciphers of player and referee names. They are
+// nil, which stores names in plain text, when no PII keys are configured;
+// referees also stay plain text without an index key.
+func newPIICiphers(cfg *config.Config) (players, referees *services.FieldCipher) {
+	if cfg.PII.EncryptionKeys == "" {
+		return nil, nil
+	}
+	keyring, err := services.ParseKeyring(cfg.PII.EncryptionKeys, cfg.PII.ActiveKey)
+	if err != nil {
+		log.Printf("Warning: Personal data encryption disabled: %v", err)
+		return nil, nil
+	}
+	players = services.NewFieldCipher(keyring)
+	indexKey, err := base64.StdEncoding.DecodeString(cfg.PII.IndexKey)
+	if err != nil || len(indexKey) == 0 {
+		log.Printf("Warning: Referee encryption disabled: no valid PII index key")
+		return players, nil
+	}
+	return players, services.NewFieldCipher(keyring, services.WithIndexKey(indexKey))
+}
+
+// newUploadProgressTracker creates the tracker reporting the progress of
+// copying uploads to storage. It returns nil, which disables progress
+// tracking, when no store is configured.
+func newUploadProgressTracker(cfg *config.Config, live services.LiveBroadcaster) *services.UploadProgressTracker {
+	var store services.UploadProgressStore
+	switch cfg.UploadProgress.Store {
+	case "", "none":
+		return nil
+	case "memory":
+		store = services.NewMemoryUploadProgressStore()
+	case "redis":
+		store = services.NewRedisUploadProgressStore(newRedisClient(cfg), "nivai:upload-progress:")
+	default:
+		log.Printf("Warning: Upload progress tracking disabled: unsupported store %q", cfg.UploadProgress.Store)
+		return nil
+	}
+	return services.NewUploadProgressTracker(store, live, services.UploadProgressConfig{
+		Interval: time.Duration(cfg.UploadProgress.IntervalMillis) * time.Millisecond,
+		TTL:      time.Duration(cfg.UploadProgress.TTLMinutes) * time.Minute,
+	})
+}
+
+// newDebugSamplers creates the body samplers of requests to the API and of
+// calls to the Python API. They log nothing until a sample percentage is
+// configured.
+func newDebugSamplers(cfg *config.Config) (api, python *debuglog.Sampler) {
+	api = debuglog.New("api", debugLogSettings(cfg, cfg.DebugLog.SamplePercent), nil)
+	python = debuglog.New("python_api", debugLogSettings(cfg, cfg.DebugLog.PythonAPISamplePercent), nil)
+	return api, python
+}
+
+// debugLogSettings returns the sampler settings for a sample percentage
+func debugLogSettings(cfg *config.Config, percent int) debuglog.Settings {
+	return debuglog.Settings{SamplePercent: percent, MaxBodyBytes: cfg.DebugLog.MaxBodyBytes}
+}
+
+// newResponseCache creates the cache of expensive read responses. It
+// returns nil, which disables response caching, when no store is
+// configured.
+func newResponseCache(cfg *config.Config) *middleware.ResponseCache {
+	switch cfg.ResponseCache.Store {
+	case "", "none":
+		return nil
+	case "memory":
+		return middleware.NewResponseCache(middleware.NewMemoryResponseCacheStore(int64(cfg.ResponseCache.MemoryMaxMB) << 20))
+	case "redis":
+		return middleware.NewResponseCache(middleware.NewRedisResponseCacheStore(newRedisClient(cfg), "nivai:response:"))
+	default:
+		log.Printf("Warning: Response caching disabled: unsupported store %q", cfg.ResponseCache.Store)
+		return nil
+	}
+}
+
+// responseCacheTTLs returns the configured TTL overrides by route name.
+func responseCacheTTLs(cfg *config.Config) map[string]time.Duration {
+	ttls := map[string]time.Duration{}
+	for route, seconds := range cfg.ResponseCache.TTLSeconds {
+		ttls[route] = time.Duration(seconds) * time.Second
+	}
+	return ttls
+}
+
+// newRedisClient connects to the configured Redis.
+func newRedisClient(cfg *config.Config) *redis.Client {
+	redisCfg := cfg.Database.Redis
+	return redis.NewClient(&redis.Options{
+		Addr:     redisCfg.Host + ":" + redisCfg.Port,
+		Password: redisCfg.Password,
+		DB:       redisCfg.DB,
+	})
+}
@@ -0,0 +1,47 @@
+// Package broker publishes platform events to external message brokers
+// (Kafka, RabbitMQ) for clubs that feed match data into their own platforms.
+package broker
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"nivai/backend/pkg/config"
+)
+
+// Supported broker types for config.Broker.Type.
+const (
+	TypeKafka    = "kafka"
+	TypeRabbitMQ = "rabbitmq"
+)
+
+// Message is a single event to publish.
+type Message struct {
+	ID        string    // Unique message ID; consumers use it to de-duplicate redeliveries
+	Type      string    // Event type, e.g. "analytics.completed"
+	Key       string    // Partition key (Kafka) used to keep a match's events in order
+	Body      []byte    // JSON-encoded event
+	Timestamp time.Time // When the event occurred
+}
+
+// Publisher sends messages to a broker. Publish must only return nil once
+// the broker has acknowledged the message.
+type Publisher interface {
+	Publish(ctx context.Context, msg Message) error
+	Close() error
+}
+
+// NewPublisher creates the publisher selected by cfg.Broker.Type.
+// httpClient is used by the Kafka REST publisher and may be nil.
+func NewPublisher(cfg *config.Config, httpClient *http.Client) (Publisher, error) {
+	switch cfg.Broker.Type {
+	case TypeKafka:
+		return NewKafkaRESTPublisher(cfg.Broker.Kafka.RESTProxyURL, cfg.Broker.Kafka.Topic, httpClient)
+	case TypeRabbitMQ:
+		return NewRabbitMQPublisher(cfg.Broker.RabbitMQ.URL, cfg.Broker.RabbitMQ.Exchange)
+	default:
+		return nil, fmt.Errorf("unsupported broker type: %q", cfg.Broker.Type)
+	}
+}
@@ -0,0 +1,103 @@
+package broker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// kafkaJSONContentType is the Kafka REST Proxy v2 content type for JSON records.
+const kafkaJSONContentType = "application/vnd.kafka.json.v2+json"
+
+// KafkaRESTPublisher publishes to a Kafka topic through a Kafka REST Proxy
+// (Confluent REST Proxy v2 API), which avoids a native client dependency.
+type KafkaRESTPublisher struct {
+	endpoint string
+	client   *http.Client
+}
+
+// NewKafkaRESTPublisher creates a publisher for topic behind the REST proxy at proxyURL.
+// If client is nil, a client with a 10s timeout is used.
+func NewKafkaRESTPublisher(proxyURL, topic string, client *http.Client) (*KafkaRESTPublisher, error) {
+	if proxyURL == "" || topic == "" {
+		return nil, errors.New("kafka publisher requires a REST proxy URL and a topic")
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &KafkaRESTPublisher{
+		endpoint: strings.TrimRight(proxyURL, "/") + "/topics/" + url.PathEscape(topic),
+		client:   client,
+	}, nil
+}
+
+// kafkaProduceRequest is the REST proxy produce request body.
+type kafkaProduceRequest struct {
+	Records []kafkaRecord `json:"records"`
+}
+
+type kafkaRecord struct {
+	Key   string          `json:"key,omitempty"`
+	Value json.RawMessage `json:"value"`
+}
+
+// kafkaProduceResponse reports per-record outcomes; a record failed if ErrorCode is set.
+type kafkaProduceResponse struct {
+	Offsets []struct {
+		Partition int    `json:"partition"`
+		Offset    int64  `json:"offset"`
+		ErrorCode *int   `json:"error_code"`
+		Error     string `json:"error"`
+	} `json:"offsets"`
+}
+
+// Publish produces msg to the topic and waits for the proxy's acknowledgement.
+func (p *KafkaRESTPublisher) Publish(ctx context.Context, msg Message) error {
+	body, err := json.Marshal(kafkaProduceRequest{
+		Records: []kafkaRecord{{Key: msg.Key, Value: msg.Body}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode kafka record: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", kafkaJSONContentType)
+	req.Header.Set("Accept", "application/vnd.kafka.v2+json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("kafka REST proxy request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("kafka REST proxy returned %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+
+	var produced kafkaProduceResponse
+	if err := json.Unmarshal(respBody, &produced); err != nil {
+		return fmt.Errorf("failed to decode kafka REST proxy response: %w", err)
+	}
+	for _, offset := range produced.Offsets {
+		if offset.ErrorCode != nil {
+			return fmt.Errorf("kafka rejected record (code %d): %s", *offset.ErrorCode, offset.Error)
+		}
+	}
+	return nil
+}
+
+// Close is a no-op; the REST publisher holds no connections of its own.
+func (p *KafkaRESTPublisher) Close() error {
+	return nil
+}
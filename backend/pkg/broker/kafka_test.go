@@ -0,0 +1,66 @@
+package broker_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"nivai/backend/pkg/broker"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKafkaRESTPublisher_Publish(t *testing.T) {
+	t.Run("Record is produced to the topic with its key", func(t *testing.T) {
+		var gotPath, gotContentType string
+		var gotBody map[string][]map[string]interface{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			gotPath = r.URL.Path
+			gotContentType = r.Header.Get("Content-Type")
+			_ = json.NewDecoder(r.Body).Decode(&gotBody)
+			w.Write([]byte(`{"offsets":[{"partition":0,"offset":42}]}`))
+		}))
+		defer server.Close()
+
+		publisher, err := broker.NewKafkaRESTPublisher(server.URL, "match-events", server.Client())
+		require.NoError(t, err)
+
+		err = publisher.Publish(context.Background(), broker.Message{ID: "m1", Key: "v1", Body: []byte(`{"type":"video.uploaded"}`)})
+		require.NoError(t, err)
+
+		assert.Equal(t, "/topics/match-events", gotPath)
+		assert.Equal(t, "application/vnd.kafka.json.v2+json", gotContentType)
+		require.Len(t, gotBody["records"], 1)
+		assert.Equal(t, "v1", gotBody["records"][0]["key"])
+		assert.Equal(t, map[string]interface{}{"type": "video.uploaded"}, gotBody["records"][0]["value"])
+	})
+
+	t.Run("Record-level errors fail the publish", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"offsets":[{"partition":null,"offset":null,"error_code":50003,"error":"timeout"}]}`))
+		}))
+		defer server.Close()
+
+		publisher, err := broker.NewKafkaRESTPublisher(server.URL, "match-events", server.Client())
+		require.NoError(t, err)
+
+		err = publisher.Publish(context.Background(), broker.Message{ID: "m1", Body: []byte(`{}`)})
+		assert.ErrorContains(t, err, "50003")
+	})
+
+	t.Run("Non-200 responses fail the publish", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "topic not found", http.StatusNotFound)
+		}))
+		defer server.Close()
+
+		publisher, err := broker.NewKafkaRESTPublisher(server.URL, "missing", server.Client())
+		require.NoError(t, err)
+
+		err = publisher.Publish(context.Background(), broker.Message{ID: "m1", Body: []byte(`{}`)})
+		assert.ErrorContains(t, err, "404")
+	})
+}
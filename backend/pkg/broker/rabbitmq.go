@@ -0,0 +1,116 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// RabbitMQPublisher publishes to a durable topic exchange with publisher
+// confirms. Events are routed by their type, so consumers can bind queues
+// to e.g. "analytics.*". The connection is (re)established lazily.
+type RabbitMQPublisher struct {
+	url      string
+	exchange string
+
+	mu      sync.Mutex
+	conn    *amqp.Connection
+	channel *amqp.Channel
+}
+
+// NewRabbitMQPublisher creates a publisher for exchange on the broker at amqpURL.
+func NewRabbitMQPublisher(amqpURL, exchange string) (*RabbitMQPublisher, error) {
+	if amqpURL == "" || exchange == "" {
+		return nil, errors.New("rabbitmq publisher requires a URL and an exchange")
+	}
+	return &RabbitMQPublisher{url: amqpURL, exchange: exchange}, nil
+}
+
+// Publish sends msg and waits for the broker's confirm.
+func (p *RabbitMQPublisher) Publish(ctx context.Context, msg Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.connect(); err != nil {
+		return err
+	}
+
+	confirmation, err := p.channel.PublishWithDeferredConfirmWithContext(ctx, p.exchange, msg.Type, false, false, amqp.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Persistent,
+		MessageId:    msg.ID,
+		Type:         msg.Type,
+		Timestamp:    msg.Timestamp,
+		Headers:      amqp.Table{"key": msg.Key},
+		Body:         msg.Body,
+	})
+	if err != nil {
+		p.reset()
+		return fmt.Errorf("rabbitmq publish failed: %w", err)
+	}
+
+	acked, err := confirmation.WaitContext(ctx)
+	if err != nil {
+		p.reset()
+		return fmt.Errorf("rabbitmq confirm failed: %w", err)
+	}
+	if !acked {
+		return errors.New("rabbitmq broker nacked message")
+	}
+	return nil
+}
+
+// Close closes the broker connection, if open.
+func (p *RabbitMQPublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.conn == nil {
+		return nil
+	}
+	err := p.conn.Close()
+	p.conn, p.channel = nil, nil
+	return err
+}
+
+// connect opens a connection and confirm-mode channel if none is usable.
+// Callers must hold p.mu.
+func (p *RabbitMQPublisher) connect() error {
+	if p.conn != nil && !p.conn.IsClosed() && p.channel != nil && !p.channel.IsClosed() {
+		return nil
+	}
+	p.reset()
+
+	conn, err := amqp.Dial(p.url)
+	if err != nil {
+		return fmt.Errorf("failed to connect to rabbitmq: %w", err)
+	}
+	channel, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to open rabbitmq channel: %w", err)
+	}
+	if err := channel.ExchangeDeclare(p.exchange, "topic", true, false, false, false, nil); err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to declare rabbitmq exchange %q: %w", p.exchange, err)
+	}
+	if err := channel.Confirm(false); err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to enable rabbitmq publisher confirms: %w", err)
+	}
+
+	p.conn, p.channel = conn, channel
+	return nil
+}
+
+// reset drops the current connection so the next publish reconnects.
+// Callers must hold p.mu.
+func (p *RabbitMQPublisher) reset() {
+	if p.conn != nil {
+		p.conn.Close()
+	}
+	p.conn, p.channel = nil, nil
+}
@@ -0,0 +1,78 @@
+package cache
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// DiskStore keeps entries as files below a directory, one subdirectory per
+// group. Names are hex-encoded so any group or key is a valid file name.
+type DiskStore struct {
+	dir string
+}
+
+// NewDiskStore creates a store in dir, creating the directory if needed.
+func NewDiskStore(dir string) (*DiskStore, error) {
+	if dir == "" {
+		return nil, errors.New("cache: disk store requires a directory")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("cache: failed to create %s: %w", dir, err)
+	}
+	return &DiskStore{dir: dir}, nil
+}
+
+// Get implements Store.
+func (s *DiskStore) Get(ctx context.Context, group, key string) ([]byte, error) {
+	value, err := os.ReadFile(s.path(group, key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrMiss
+	}
+	return value, err
+}
+
+// Set implements Store. The value is written to a temporary file and renamed
+// so readers never see a partial entry.
+func (s *DiskStore) Set(ctx context.Context, group, key string, value []byte) error {
+	groupDir := s.groupDir(group)
+	if err := os.MkdirAll(groupDir, 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(groupDir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(value); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), s.path(group, key)); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+// DeleteGroup implements Store.
+func (s *DiskStore) DeleteGroup(ctx context.Context, group string) error {
+	return os.RemoveAll(s.groupDir(group))
+}
+
+func (s *DiskStore) groupDir(group string) string {
+	return filepath.Join(s.dir, hex.EncodeToString([]byte(group)))
+}
+
+func (s *DiskStore) path(group, key string) string {
+	return filepath.Join(s.groupDir(group), hex.EncodeToString([]byte(key)))
+}
@@ -0,0 +1,39 @@
+package cache
+
+import (
+	"context"
+	"errors"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisStore keeps each group in a Redis hash, so a group is invalidated
+// with a single DEL.
+type RedisStore struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+// NewRedisStore creates a store whose hashes are named prefix+group.
+func NewRedisStore(client redis.UniversalClient, prefix string) *RedisStore {
+	return &RedisStore{client: client, prefix: prefix}
+}
+
+// Get implements Store.
+func (s *RedisStore) Get(ctx context.Context, group, key string) ([]byte, error) {
+	value, err := s.client.HGet(ctx, s.prefix+group, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrMiss
+	}
+	return value, err
+}
+
+// Set implements Store.
+func (s *RedisStore) Set(ctx context.Context, group, key string, value []byte) error {
+	return s.client.HSet(ctx, s.prefix+group, key, value).Err()
+}
+
+// DeleteGroup implements Store.
+func (s *RedisStore) DeleteGroup(ctx context.Context, group string) error {
+	return s.client.Del(ctx, s.prefix+group).Err()
+}
@@ -0,0 +1,92 @@
+// Package cache provides persistent key-value stores for cached results,
+// such as processed match analytics. Entries are organised in groups (e.g.
+// one per match) so all entries of a group can be invalidated together.
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"nivai/backend/pkg/config"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrMiss is returned by Get when the entry does not exist.
+var ErrMiss = errors.New("cache: miss")
+
+// Store is a persistent cache. Implementations are safe for concurrent use.
+type Store interface {
+	// Get returns the value stored under group/key, or ErrMiss.
+	Get(ctx context.Context, group, key string) ([]byte, error)
+	// Set stores a value under group/key, replacing any previous value.
+	Set(ctx context.Context, group, key string, value []byte) error
+	// DeleteGroup removes every entry of a group.
+	DeleteGroup(ctx context.Context, group string) error
+}
+
+// NewStore creates the store selected by cfg.AnalyticsCache.Store:
+// "memory", "disk" or "redis". "none" or empty returns a nil store.
+func NewStore(cfg *config.Config) (Store, error) {
+	switch cfg.AnalyticsCache.Store {
+	case "", "none":
+		return nil, nil
+	case "memory":
+		return NewMemoryStore(), nil
+	case "disk":
+		return NewDiskStore(cfg.AnalyticsCache.Dir)
+	case "redis":
+		redisCfg := cfg.Database.Redis
+		client := redis.NewClient(&redis.Options{
+			Addr:     redisCfg.Host + ":" + redisCfg.Port,
+			Password: redisCfg.Password,
+			DB:       redisCfg.DB,
+		})
+		return NewRedisStore(client, "nivai:analytics:"), nil
+	default:
+		return nil, fmt.Errorf("cache: unsupported store %q", cfg.AnalyticsCache.Store)
+	}
+}
+
+// MemoryStore keeps entries in process memory. Entries are lost on restart.
+type MemoryStore struct {
+	mu     sync.RWMutex
+	groups map[string]map[string][]byte
+}
+
+// NewMemoryStore creates an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{groups: make(map[string]map[string][]byte)}
+}
+
+// Get implements Store.
+func (s *MemoryStore) Get(ctx context.Context, group, key string) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	value, ok := s.groups[group][key]
+	if !ok {
+		return nil, ErrMiss
+	}
+	return append([]byte(nil), value...), nil
+}
+
+// Set implements Store.
+func (s *MemoryStore) Set(ctx context.Context, group, key string, value []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.groups[group] == nil {
+		s.groups[group] = make(map[string][]byte)
+	}
+	s.groups[group][key] = append([]byte(nil), value...)
+	return nil
+}
+
+// DeleteGroup implements Store.
+func (s *MemoryStore) DeleteGroup(ctx context.Context, group string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.groups, group)
+	return nil
+}
@@ -0,0 +1,59 @@
+package cache_test
+
+import (
+	"context"
+	"testing"
+
+	"nivai/backend/pkg/cache"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// exerciseStore runs the behaviour every Store must provide.
+func exerciseStore(t *testing.T, store cache.Store) {
+	ctx := context.Background()
+
+	_, err := store.Get(ctx, "m1", "summary|")
+	assert.ErrorIs(t, err, cache.ErrMiss)
+
+	require.NoError(t, store.Set(ctx, "m1", "summary|", []byte(`{"match_id":"m1"}`)))
+	require.NoError(t, store.Set(ctx, "m1", "player|7", []byte(`{"player_id":"7"}`)))
+	require.NoError(t, store.Set(ctx, "m2", "summary|", []byte(`{"match_id":"m2"}`)))
+	require.NoError(t, store.Set(ctx, "m1", "summary|", []byte(`{"match_id":"m1","v":2}`)))
+
+	value, err := store.Get(ctx, "m1", "summary|")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"match_id":"m1","v":2}`, string(value))
+
+	require.NoError(t, store.DeleteGroup(ctx, "m1"))
+	_, err = store.Get(ctx, "m1", "player|7")
+	assert.ErrorIs(t, err, cache.ErrMiss)
+	_, err = store.Get(ctx, "m2", "summary|")
+	assert.NoError(t, err, "other groups are kept")
+	assert.NoError(t, store.DeleteGroup(ctx, "unknown"))
+}
+
+func TestMemoryStore(t *testing.T) {
+	exerciseStore(t, cache.NewMemoryStore())
+}
+
+func TestDiskStore(t *testing.T) {
+	store, err := cache.NewDiskStore(t.TempDir())
+	require.NoError(t, err)
+	exerciseStore(t, store)
+
+	_, err = cache.NewDiskStore("")
+	assert.Error(t, err)
+}
+
+func TestRedisStore(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer client.Close()
+
+	exerciseStore(t, cache.NewRedisStore(client, "test:"))
+	assert.True(t, server.Exists("test:m2"))
+}
@@ -0,0 +1,195 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"nivai/backend/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeFile writes a configuration file to a temporary directory
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadDefaults(t *testing.T) {
+	cfg, err := config.LoadFrom(config.Sources{File: writeFile(t, "empty.json", "{}")})
+	require.NoError(t, err)
+
+	assert.Equal(t, "8080", cfg.Server.Port)
+	assert.Equal(t, "localhost", cfg.Database.Postgres.Host)
+	assert.Equal(t, 600, cfg.RateLimits["default"])
+	assert.Equal(t, 32, cfg.HTTPClients.Destinations["analytics"].MaxIdleConnsPerHost)
+	assert.Equal(t, int64(25<<20), cfg.Scanning.MaxStreamBytes)
+}
+
+func TestLoadFileFormats(t *testing.T) {
+	files := map[string]string{
+		"config.json": `{"server": {"port": "9000"}, "database": {"postgres": {"host": "db"}}, "rate_limits": {"upload": 5}}`,
+		"config.yaml": "server:\n  port: \"9000\"\ndatabase:\n  postgres:\n    host: db\nrate_limits:\n  upload: 5\n",
+		"config.toml": "rate_limits = { upload = 5 }\n[server]\nport = \"9000\"\n[database.postgres]\nhost = \"db\"\n",
+	}
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			cfg, err := config.LoadFrom(config.Sources{File: writeFile(t, name, content)})
+			require.NoError(t, err)
+
+			assert.Equal(t, "9000", cfg.Server.Port)
+			assert.Equal(t, "db", cfg.Database.Postgres.Host)
+			assert.Equal(t, 5, cfg.RateLimits["upload"])
+			assert.Equal(t, 600, cfg.RateLimits["default"], "unset entries keep their defaults")
+			assert.Equal(t, "5432", cfg.Database.Postgres.Port)
+		})
+	}
+
+	t.Run("unsupported format", func(t *testing.T) {
+		_, err := config.LoadFrom(config.Sources{File: writeFile(t, "config.ini", "port=9000")})
+		assert.ErrorContains(t, err, "unsupported")
+	})
+
+	t.Run("missing file named explicitly", func(t *testing.T) {
+		_, err := config.LoadFrom(config.Sources{File: filepath.Join(t.TempDir(), "missing.yaml")})
+		assert.Error(t, err)
+	})
+
+	t.Run("file named by environment", func(t *testing.T) {
+		t.Setenv("AIFAA_CONFIG_FILE", writeFile(t, "config.yml", "organization:\n  name: Club\n"))
+		cfg, err := config.Load()
+		require.NoError(t, err)
+		assert.Equal(t, "Club", cfg.Organization.Name)
+	})
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	file := writeFile(t, "config.yaml", "server:\n  port: \"7000\"\n  host: file-host\ndatabase:\n  postgres:\n    host: file-db\n    user: file-user\n")
+
+	// Legacy names beat the file, AIFAA_ names beat legacy names, and
+	// overrides beat the environment
+	t.Setenv("SERVER_PORT", "7100")
+	t.Setenv("DB_HOST", "legacy-db")
+	t.Setenv("AIFAA_DATABASE_POSTGRES_HOST", "env-db")
+	t.Setenv("AIFAA_SERVER_HOST", "env-host")
+
+	cfg, err := config.LoadFrom(config.Sources{
+		File:      file,
+		Overrides: config.Overrides{"server.host": "flag-host"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "flag-host", cfg.Server.Host)
+	assert.Equal(t, "env-db", cfg.Database.Postgres.Host)
+	assert.Equal(t, "7100", cfg.Server.Port)
+	assert.Equal(t, "file-user", cfg.Database.Postgres.User)
+	assert.Equal(t, "password", cfg.Database.Postgres.Password)
+}
+
+func TestLoadEnvNames(t *testing.T) {
+	file := writeFile(t, "empty.json", "{}")
+
+	t.Run("nested settings", func(t *testing.T) {
+		t.Setenv("AIFAA_STORAGE_AZURE_BLOB_STORAGE_ACCOUNT_NAME", "account")
+		t.Setenv("AIFAA_STORAGE_AZURE_BLOB_STORAGE_CONTAINER_NAME", "matches")
+		t.Setenv("AIFAA_SCANNING_MAX_STREAM_BYTES", "1024")
+		t.Setenv("AIFAA_DATABASE_POSTGRES_READ_REPLICAS", "replica-1, replica-2")
+		t.Setenv("AIFAA_DEMO", "true")
+		t.Setenv("AIFAA_PYTHON_API_MAX_RESPONSE_MB", "16")
+
+		cfg, err := config.LoadFrom(config.Sources{File: file})
+		require.NoError(t, err)
+		assert.Equal(t, "account", cfg.Storage.AzureBlobStorage.AccountName)
+		assert.Equal(t, "matches", cfg.Storage.AzureBlobStorage.ContainerName)
+		assert.Equal(t, int64(1024), cfg.Scanning.MaxStreamBytes)
+		assert.Equal(t, []string{"replica-1", "replica-2"}, cfg.Database.Postgres.ReadReplicas)
+		assert.True(t, cfg.Demo)
+		assert.Equal(t, 16, cfg.PythonAPI.MaxResponseMB)
+	})
+
+	t.Run("map entries", func(t *testing.T) {
+		t.Setenv("AIFAA_RATE_LIMITS_UPLOAD", "3")
+		t.Setenv("AIFAA_FEATURES", "exports")
+		t.Setenv("AIFAA_FEATURES_MATCH_DAY", "true")
+		t.Setenv("AIFAA_HTTP_CLIENTS_DESTINATIONS_ANALYTICS_DIAL_TIMEOUT_SECONDS", "2")
+		t.Setenv("AIFAA_HTTP_CLIENTS_DESTINATIONS_STATSBOMB_TIMEOUT_SECONDS", "20")
+
+		cfg, err := config.LoadFrom(config.Sources{File: file})
+		require.NoError(t, err)
+		assert.Equal(t, 3, cfg.RateLimits["upload"])
+		assert.Equal(t, map[string]bool{"exports": true, "match_day": true}, cfg.Features)
+
+		analytics := cfg.HTTPClients.Destinations["analytics"]
+		assert.Equal(t, 2, analytics.DialTimeoutSecs)
+		assert.Equal(t, 32, analytics.MaxIdleConnsPerHost, "other settings of the entry are kept")
+		assert.Equal(t, 20, cfg.HTTPClients.Destinations["statsbomb"].TimeoutSecs)
+	})
+
+	t.Run("legacy names", func(t *testing.T) {
+		t.Setenv("AZURE_STORAGE_ACCOUNT", "legacy-account")
+		t.Setenv("WEBHOOK_REQUEST_TIMEOUT_SECONDS", "4")
+		t.Setenv("RATE_LIMIT_AUTH_PER_MINUTE", "7")
+
+		cfg, err := config.LoadFrom(config.Sources{File: file})
+		require.NoError(t, err)
+		assert.Equal(t, "legacy-account", cfg.Storage.AzureBlobStorage.AccountName)
+		assert.Equal(t, 4, cfg.Webhooks.RequestTimeoutSecs)
+		assert.Equal(t, 4, cfg.HTTPClients.Destinations["webhooks"].TimeoutSecs)
+		assert.Equal(t, 7, cfg.RateLimits["auth"])
+	})
+
+	t.Run("invalid value", func(t *testing.T) {
+		t.Setenv("AIFAA_SERVER_REUSE_PORT", "sometimes")
+
+		_, err := config.LoadFrom(config.Sources{File: file})
+		assert.ErrorContains(t, err, "AIFAA_SERVER_REUSE_PORT")
+	})
+}
+
+func TestOverrides(t *testing.T) {
+	overrides := config.Overrides{}
+	require.NoError(t, overrides.Set("database.driver=sqlite"))
+	require.NoError(t, overrides.Set("python_api.path_map=videos/=/data/"))
+	assert.Error(t, overrides.Set("database.driver"))
+	assert.Equal(t, "database.driver=sqlite,python_api.path_map=videos/=/data/", overrides.String())
+
+	cfg, err := config.LoadFrom(config.Sources{File: writeFile(t, "empty.json", "{}"), Overrides: overrides})
+	require.NoError(t, err)
+	assert.Equal(t, "sqlite", cfg.Database.Driver)
+	assert.Equal(t, "videos/=/data/", cfg.PythonAPI.PathMap)
+
+	_, err = config.LoadFrom(config.Sources{Overrides: config.Overrides{"database.nope": "x"}})
+	assert.ErrorContains(t, err, "unknown setting")
+}
+
+func TestEnvName(t *testing.T) {
+	assert.Equal(t, "AIFAA_DATABASE_POSTGRES_HOST", config.EnvName("database.postgres.host"))
+}
+
+func TestTable(t *testing.T) {
+	t.Setenv("DB_PASSWORD", "hunter2")
+	t.Setenv("AIFAA_SERVER_HOST", "127.0.0.1")
+
+	cfg, err := config.LoadFrom(config.Sources{
+		File:      writeFile(t, "config.yaml", "organization:\n  name: Club\n"),
+		Overrides: config.Overrides{"rate_limits.upload": "5"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "default", cfg.Origin("server.port"))
+	assert.Equal(t, "AIFAA_SERVER_HOST", cfg.Origin("server.host"))
+	assert.Equal(t, "DB_PASSWORD", cfg.Origin("database.postgres.password"))
+	assert.Equal(t, "file", cfg.Origin("organization.name"))
+	assert.Equal(t, "flag", cfg.Origin("rate_limits.upload"))
+
+	table, err := cfg.Table()
+	require.NoError(t, err)
+	assert.NotContains(t, table, "hunter2")
+	assert.Regexp(t, `database\.postgres\.password\s+\[REDACTED\]\s+DB_PASSWORD`, table)
+	assert.Regexp(t, `organization\.name\s+Club\s+file`, table)
+	assert.Regexp(t, `scanning\.max_stream_bytes\s+26214400\s+default`, table)
+}
@@ -0,0 +1,112 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"nivai/backend/pkg/support"
+)
+
+// Origin returns where a setting's value came from: "default", "file", the
+// environment variable that set it, or "flag". Entries of a map set as a
+// whole share the map's origin.
+func (c *Config) Origin(key string) string {
+	for k := key; k != ""; {
+		if origin, ok := c.origins[k]; ok {
+			return origin
+		}
+		i := strings.LastIndex(k, ".")
+		if i < 0 {
+			break
+		}
+		k = k[:i]
+	}
+	return "default"
+}
+
+// Table renders the effective configuration as a table of every setting, its
+// value and its origin, with secrets redacted as in support bundles. The
+// server prints it at startup.
+func (c *Config) Table() (string, error) {
+	values, err := c.sanitizedValues()
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SETTING\tVALUE\tSOURCE")
+	for _, key := range sortedKeys(values) {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", key, values[key], c.Origin(key))
+	}
+	if err := w.Flush(); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// sanitizedValues returns the settings by key with secrets redacted
+func (c *Config) sanitizedValues() (map[string]string, error) {
+	tree, err := support.Sanitize(c)
+	if err != nil {
+		return nil, err
+	}
+	values := map[string]string{}
+	flatten(tree, "", values)
+	return values, nil
+}
+
+// flatValues returns the settings of v by key, in their string form
+func flatValues(v interface{}) (map[string]string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var tree interface{}
+	if err := json.Unmarshal(data, &tree); err != nil {
+		return nil, err
+	}
+	values := map[string]string{}
+	flatten(tree, "", values)
+	return values, nil
+}
+
+// flatten collects the leaves of a decoded JSON value by dotted key. Lists
+// become comma-separated values, as in environment variables.
+func flatten(v interface{}, key string, values map[string]string) {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for name, child := range value {
+			flatten(child, joinKey(key, name), values)
+		}
+	case []interface{}:
+		items := make([]string, len(value))
+		for i, item := range value {
+			items[i] = scalar(item)
+		}
+		values[key] = strings.Join(items, ",")
+	default:
+		values[key] = scalar(value)
+	}
+}
+
+// scalar formats a decoded JSON scalar; empty strings are quoted, so they
+// stand out in the table
+func scalar(v interface{}) string {
+	switch value := v.(type) {
+	case nil:
+		return `""`
+	case string:
+		if value == "" {
+			return `""`
+		}
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	default:
+		return fmt.Sprint(value)
+	}
+}
@@ -0,0 +1,118 @@
+package config
+
+// legacyEnv maps the environment variables used before the AIFAA_ names to
+// the settings they set. They still work; the AIFAA_ name wins when both are
+// set.
+var legacyEnv = []struct {
+	name string
+	key  string
+}{
+	{"SERVER_PORT", "server.port"},
+	{"SERVER_HOST", "server.host"},
+	{"SERVER_REUSE_PORT", "server.reuse_port"},
+	{"DEMO_MODE", "demo"},
+	{"SHUTDOWN_PRE_STOP_DELAY_SECONDS", "shutdown.pre_stop_delay_seconds"},
+	{"SHUTDOWN_DRAIN_TIMEOUT_SECONDS", "shutdown.drain_timeout_seconds"},
+	{"DB_DRIVER", "database.driver"},
+	{"DB_SQLITE_PATH", "database.sqlite.path"},
+	{"DB_HOST", "database.postgres.host"},
+	{"DB_PORT", "database.postgres.port"},
+	{"DB_USER", "database.postgres.user"},
+	{"DB_PASSWORD", "database.postgres.password"},
+	{"DB_NAME", "database.postgres.dbname"},
+	{"DB_SSL_MODE", "database.postgres.sslmode"},
+	{"DB_MAX_OPEN_CONNS", "database.postgres.max_open_conns"},
+	{"DB_MAX_IDLE_CONNS", "database.postgres.max_idle_conns"},
+	{"DB_CONN_MAX_LIFETIME_MINUTES", "database.postgres.conn_max_lifetime_minutes"},
+	{"DB_CONN_MAX_IDLE_TIME_MINUTES", "database.postgres.conn_max_idle_time_minutes"},
+	{"DB_READ_REPLICAS", "database.postgres.read_replicas"},
+	{"DB_REPLICA_CHECK_SECONDS", "database.postgres.replica_check_seconds"},
+	{"REDIS_HOST", "database.redis.host"},
+	{"REDIS_PORT", "database.redis.port"},
+	{"REDIS_PASSWORD", "database.redis.password"},
+	{"ORG_NAME", "organization.name"},
+	{"ORG_TIMEZONE", "organization.timezone"},
+	{"ORG_LOCALE", "organization.locale"},
+	{"FEATURE_FLAGS", "features"},
+	{"PYTHON_API_URL", "python_api.base_url"},
+	{"PYTHON_API_TRANSPORT", "python_api.transport"},
+	{"PYTHON_API_GRPC_ADDR", "python_api.grpc_address"},
+	{"PYTHON_API_PATH_MODE", "python_api.path_mode"},
+	{"PYTHON_API_PATH_MAP", "python_api.path_map"},
+	{"WEBHOOK_MAX_ATTEMPTS", "webhooks.max_attempts"},
+	{"WEBHOOK_INITIAL_BACKOFF_SECONDS", "webhooks.initial_backoff_seconds"},
+	{"WEBHOOK_MAX_BACKOFF_SECONDS", "webhooks.max_backoff_seconds"},
+	{"WEBHOOK_POLL_INTERVAL_SECONDS", "webhooks.poll_interval_seconds"},
+	{"WEBHOOK_REQUEST_TIMEOUT_SECONDS", "webhooks.request_timeout_seconds"},
+	{"WEBHOOK_REQUEST_TIMEOUT_SECONDS", "http_clients.destinations.webhooks.timeout_seconds"},
+	{"BROKER_TYPE", "broker.type"},
+	{"KAFKA_REST_PROXY_URL", "broker.kafka.rest_proxy_url"},
+	{"KAFKA_TOPIC", "broker.kafka.topic"},
+	{"RABBITMQ_URL", "broker.rabbitmq.url"},
+	{"RABBITMQ_EXCHANGE", "broker.rabbitmq.exchange"},
+	{"BROKER_POLL_INTERVAL_SECONDS", "broker.poll_interval_seconds"},
+	{"RATE_LIMIT_DEFAULT_PER_MINUTE", "rate_limits.default"},
+	{"RATE_LIMIT_EXPENSIVE_PER_MINUTE", "rate_limits.expensive"},
+	{"RATE_LIMIT_UPLOAD_PER_MINUTE", "rate_limits.upload"},
+	{"RATE_LIMIT_AUTH_PER_MINUTE", "rate_limits.auth"},
+	{"API_V1_DEPRECATED", "api.v1_deprecated"},
+	{"API_V1_SUNSET", "api.v1_sunset"},
+	{"AZURE_STORAGE_ACCOUNT", "storage.azure_blob_storage.account_name"},
+	{"AZURE_STORAGE_KEY", "storage.azure_blob_storage.account_key"},
+	{"AZURE_STORAGE_CONTAINER", "storage.azure_blob_storage.container_name"},
+	{"AZURE_STORAGE_AUTH_MODE", "storage.azure_blob_storage.auth_mode"},
+	{"AZURE_TENANT_ID", "storage.azure_blob_storage.tenant_id"},
+	{"AZURE_CLIENT_ID", "storage.azure_blob_storage.client_id"},
+	{"AZURE_CLIENT_SECRET", "storage.azure_blob_storage.client_secret"},
+	{"AZURE_STORAGE_CONNECTION_STRING", "storage.azure_blob_storage.connection_string"},
+	{"STORAGE_PATH_STRATEGY", "storage.path_strategy"},
+	{"EXTERNAL_DATA_PATH", "storage.local_path"},
+	{"EXTERNAL_DATA_MOUNT", "storage.mount_path"},
+	{"ARCHIVE_TYPE", "archive.type"},
+	{"ARCHIVE_COLD_PATH", "archive.cold_path"},
+	{"ARCHIVE_COLD_CONTAINER", "archive.cold_container"},
+	{"ARCHIVE_POLL_INTERVAL_SECONDS", "archive.poll_interval_seconds"},
+	{"RETENTION_RULES", "retention.rules"},
+	{"RETENTION_SWEEP_INTERVAL_HOURS", "retention.sweep_interval_hours"},
+	{"RETENTION_DRY_RUN", "retention.dry_run"},
+	{"RETENTION_ARCHIVE_PREFIX", "retention.archive_prefix"},
+	{"SCANNER_TYPE", "scanning.type"},
+	{"CLAMAV_ADDRESS", "scanning.clamav_address"},
+	{"CLAMAV_TIMEOUT_SECONDS", "scanning.timeout_seconds"},
+	{"CLAMAV_MAX_STREAM_BYTES", "scanning.max_stream_bytes"},
+	{"LOAD_SHED_MAX_IN_FLIGHT_WRITES", "load_shedding.max_in_flight_writes"},
+	{"LOAD_SHED_RETRY_AFTER_SECONDS", "load_shedding.retry_after_seconds"},
+	{"LOAD_SHED_CHECK_INTERVAL_SECONDS", "load_shedding.check_interval_seconds"},
+	{"LOAD_SHED_CHECK_TIMEOUT_SECONDS", "load_shedding.check_timeout_seconds"},
+	{"QUOTA_ORGANIZATION_BYTES", "quotas.organization_bytes"},
+	{"QUOTA_USER_BYTES", "quotas.user_bytes"},
+	{"DIRECT_UPLOAD_URL_EXPIRY_MINUTES", "direct_uploads.url_expiry_minutes"},
+	{"UPLOAD_PROGRESS_STORE", "upload_progress.store"},
+	{"UPLOAD_PROGRESS_INTERVAL_MS", "upload_progress.interval_millis"},
+	{"UPLOAD_PROGRESS_TTL_MINUTES", "upload_progress.ttl_minutes"},
+	{"STORAGE_GC_PREFIX", "storage_gc.prefix"},
+	{"STORAGE_GC_MIN_AGE_HOURS", "storage_gc.min_age_hours"},
+	{"STORAGE_GC_INTERVAL_HOURS", "storage_gc.interval_hours"},
+	{"STORAGE_GC_DELETE", "storage_gc.delete"},
+	{"SUPPORT_LOG_LINES", "support.log_lines"},
+	{"SUPPORT_EVENTS", "support.events"},
+	{"HTTP_CLIENT_TIMEOUT_SECONDS", "http_clients.defaults.timeout_seconds"},
+	{"HTTP_CLIENT_DIAL_TIMEOUT_SECONDS", "http_clients.defaults.dial_timeout_seconds"},
+	{"HTTP_CLIENT_TLS_HANDSHAKE_TIMEOUT_SECONDS", "http_clients.defaults.tls_handshake_timeout_seconds"},
+	{"HTTP_CLIENT_IDLE_CONN_TIMEOUT_SECONDS", "http_clients.defaults.idle_conn_timeout_seconds"},
+	{"HTTP_CLIENT_MAX_IDLE_CONNS_PER_HOST", "http_clients.defaults.max_idle_conns_per_host"},
+	{"HTTP_CLIENT_MAX_CONNS_PER_HOST", "http_clients.defaults.max_conns_per_host"},
+	{"HTTP_CLIENT_PROXY_URL", "http_clients.defaults.proxy_url"},
+	{"HTTP_CLIENT_CA_FILE", "http_clients.defaults.ca_file"},
+	{"HTTP_CLIENT_INSECURE_SKIP_VERIFY", "http_clients.defaults.insecure_skip_verify"},
+	{"PYTHON_API_TIMEOUT_SECONDS", "http_clients.destinations.analytics.timeout_seconds"},
+	{"PYTHON_API_MAX_IDLE_CONNS", "http_clients.destinations.analytics.max_idle_conns_per_host"},
+	{"KAFKA_REQUEST_TIMEOUT_SECONDS", "http_clients.destinations.kafka.timeout_seconds"},
+	{"ANALYTICS_CACHE_STORE", "analytics_cache.store"},
+	{"ANALYTICS_CACHE_DIR", "analytics_cache.dir"},
+	{"MATCH_DAY_LEAD_MINUTES", "match_day.lead_minutes"},
+	{"MATCH_DAY_DURATION_MINUTES", "match_day.duration_minutes"},
+	{"ANALYTICS_CACHE_TTL_SECONDS", "match_day.cache_ttl_seconds"},
+	{"MATCH_DAY_CACHE_TTL_SECONDS", "match_day.match_day_cache_ttl_seconds"},
+	{"MATCH_DAY_REFRESH_INTERVAL_SECONDS", "match_day.refresh_interval_seconds"},
+}
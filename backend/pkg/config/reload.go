@@ -0,0 +1,257 @@
+package config
+
+import (
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ReloadableKeys are the settings a reload applies to the running server,
+// with the settings below them. Changes to any other setting are reported as
+// needing a restart, and not applied.
+var ReloadableKeys = []string{
+	"logging.level",
+	"debug_log",
+	"cors.allowed_origins",
+	"rate_limits",
+	"ip_filter",
+	"python_api.base_url",
+	"python_api.worker_urls",
+}
+
+// maxReloads is how many reloads the audit history keeps
+const maxReloads = 100
+
+// Change is one changed setting, with secrets redacted
+type Change struct {
+	Key string `json:"key"`
+	Old string `json:"old"`
+	New string `json:"new"`
+}
+
+// Reload is the audit record of one reload
+type Reload struct {
+	ID        string    `json:"id"`
+	At        time.Time `json:"at"`
+	Trigger   string    `json:"trigger"` // "signal" or "api"
+	Actor     string    `json:"actor,omitempty"`
+	Applied   []Change  `json:"applied"`
+	Restart   []Change  `json:"restart_required"` // Changed, but only applied by a restart
+	Error     string    `json:"error,omitempty"`  // Why nothing was applied
+	Succeeded bool      `json:"succeeded"`
+}
+
+// Reloader reloads the configuration from its sources at runtime. Reloads
+// are all or nothing: a configuration that fails to load, prepare or
+// validate leaves the running one in place. Only ReloadableKeys are applied;
+// the subscribers receive the new configuration and adjust the components
+// they own.
+type Reloader struct {
+	sources Sources
+	prepare func(*Config) error
+
+	mu          sync.Mutex
+	current     *Config
+	subscribers []func(*Config)
+	history     []Reload
+}
+
+// NewReloader creates a reloader for the configuration loaded from sources
+func NewReloader(cfg *Config, sources Sources) *Reloader {
+	return &Reloader{sources: sources, current: cfg}
+}
+
+// WithPrepare runs fn on every reloaded configuration before it is
+// validated, e.g. to resolve secret references
+func (r *Reloader) WithPrepare(fn func(*Config) error) *Reloader {
+	r.prepare = fn
+	return r
+}
+
+// Subscribe calls fn with the new configuration after every reload that
+// applied changes. Subscribers must not modify it, and must not call the
+// reloader: they run while it holds its lock.
+func (r *Reloader) Subscribe(fn func(*Config)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.subscribers = append(r.subscribers, fn)
+}
+
+// Current returns the configuration in effect
+func (r *Reloader) Current() *Config {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.current
+}
+
+// History returns the audit records of past reloads, newest first
+func (r *Reloader) History() []Reload {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	history := make([]Reload, len(r.history))
+	for i, reload := range r.history {
+		history[len(r.history)-1-i] = reload
+	}
+	return history
+}
+
+// Reload loads the configuration again and applies the changed reloadable
+// settings. The trigger and actor are recorded in the audit history, along
+// with every change; each applied change is logged as well.
+//
+// It returns the audit record, and an error when nothing could be applied.
+func (r *Reloader) Reload(trigger, actor string) (Reload, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	reload := Reload{ID: uuid.New().String(), At: time.Now().UTC(), Trigger: trigger, Actor: actor}
+	next, err := r.load()
+	if err == nil {
+		reload.Applied, reload.Restart, err = diff(r.current, next)
+	}
+	if err != nil {
+		reload.Error = err.Error()
+		r.record(reload)
+		log.Printf("Warning: Configuration reload (%s) failed, keeping the running configuration: %v", trigger, err)
+		return reload, err
+	}
+	reload.Succeeded = true
+
+	for _, change := range reload.Applied {
+		log.Printf("Configuration reload (%s): %s changed from %s to %s", trigger, change.Key, change.Old, change.New)
+	}
+	for _, change := range reload.Restart {
+		log.Printf("Warning: Configuration reload (%s): %s changed, restart to apply it", trigger, change.Key)
+	}
+	if len(reload.Applied) > 0 {
+		r.current = merge(r.current, next)
+		for _, fn := range r.subscribers {
+			fn(r.current)
+		}
+	}
+	r.record(reload)
+	return reload, nil
+}
+
+// load loads, prepares and validates the configuration
+func (r *Reloader) load() (*Config, error) {
+	next, err := LoadFrom(r.sources)
+	if err != nil {
+		return nil, err
+	}
+	if r.prepare != nil {
+		if err := r.prepare(next); err != nil {
+			return nil, err
+		}
+	}
+	if err := next.Validate(); err != nil {
+		return nil, err
+	}
+	return next, nil
+}
+
+// record appends a reload to the history, dropping the oldest beyond
+// maxReloads. Callers hold r.mu.
+func (r *Reloader) record(reload Reload) {
+	r.history = append(r.history, reload)
+	if len(r.history) > maxReloads {
+		r.history = r.history[len(r.history)-maxReloads:]
+	}
+}
+
+// diff returns the changed settings, split into reloadable ones and ones
+// that need a restart. Changes are detected on the real values and reported
+// with secrets redacted.
+func diff(old, next *Config) (applied, restart []Change, err error) {
+	oldValues, err := flatValues(old)
+	if err != nil {
+		return nil, nil, err
+	}
+	nextValues, err := flatValues(next)
+	if err != nil {
+		return nil, nil, err
+	}
+	oldShown, err := old.sanitizedValues()
+	if err != nil {
+		return nil, nil, err
+	}
+	nextShown, err := next.sanitizedValues()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keys := map[string]bool{}
+	for key := range oldValues {
+		keys[key] = true
+	}
+	for key := range nextValues {
+		keys[key] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+
+	for _, key := range sorted {
+		if oldValues[key] == nextValues[key] {
+			continue
+		}
+		change := Change{Key: key, Old: shown(oldShown, key), New: shown(nextShown, key)}
+		if reloadable(key) {
+			applied = append(applied, change)
+		} else {
+			restart = append(restart, change)
+		}
+	}
+	return applied, restart, nil
+}
+
+// shown returns a setting as printed, or "(unset)" for map entries that do
+// not exist
+func shown(values map[string]string, key string) string {
+	if value, ok := values[key]; ok {
+		return value
+	}
+	return "(unset)"
+}
+
+// reloadable reports whether a setting is, or is below, a reloadable key
+func reloadable(key string) bool {
+	for _, k := range ReloadableKeys {
+		if key == k || strings.HasPrefix(key, k+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// merge returns a copy of current with the reloadable settings of next.
+// The settings are replaced, never modified, so holders of current keep a
+// consistent view.
+func merge(current, next *Config) *Config {
+	merged := *current
+	merged.Logging.Level = next.Logging.Level
+	merged.CORS.AllowedOrigins = next.CORS.AllowedOrigins
+	merged.RateLimits = next.RateLimits
+	merged.IPFilter = next.IPFilter
+	merged.PythonAPI.BaseURL = next.PythonAPI.BaseURL
+	merged.PythonAPI.WorkerURLs = next.PythonAPI.WorkerURLs
+
+	merged.origins = make(map[string]string, len(current.origins))
+	for key, origin := range current.origins {
+		if !reloadable(key) {
+			merged.origins[key] = origin
+		}
+	}
+	for key, origin := range next.origins {
+		if reloadable(key) {
+			merged.origins[key] = origin
+		}
+	}
+	return &merged
+}
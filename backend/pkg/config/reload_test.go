@@ -0,0 +1,83 @@
+package config_test
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"nivai/backend/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReloader(t *testing.T) {
+	file := writeFile(t, "config.yaml", "logging:\n  level: info\nrate_limits:\n  upload: 20\n")
+	sources := config.Sources{File: file, Overrides: config.Overrides{"demo": "true"}}
+	cfg, err := config.LoadFrom(sources)
+	require.NoError(t, err)
+
+	reloader := config.NewReloader(cfg, sources)
+	var applied []*config.Config
+	reloader.Subscribe(func(next *config.Config) { applied = append(applied, next) })
+
+	t.Run("reloadable settings are applied", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(file, []byte(
+			"logging:\n  level: debug\nrate_limits:\n  upload: 5\npython_api:\n  base_url: http://analytics:8081\nserver:\n  port: \"9090\"\n"), 0o600))
+
+		reload, err := reloader.Reload("api", "admin-1")
+		require.NoError(t, err)
+
+		assert.True(t, reload.Succeeded)
+		assert.Equal(t, "admin-1", reload.Actor)
+		assert.Equal(t, []config.Change{
+			{Key: "logging.level", Old: "info", New: "debug"},
+			{Key: "python_api.base_url", Old: "http://localhost:8081", New: "http://analytics:8081"},
+			{Key: "rate_limits.upload", Old: "20", New: "5"},
+		}, reload.Applied)
+		assert.Equal(t, []config.Change{{Key: "server.port", Old: "8080", New: "9090"}}, reload.Restart)
+
+		require.Len(t, applied, 1)
+		current := reloader.Current()
+		assert.Same(t, applied[0], current)
+		assert.Equal(t, "debug", current.Logging.Level)
+		assert.Equal(t, 5, current.RateLimits["upload"])
+		assert.Equal(t, "8080", current.Server.Port, "settings needing a restart are not applied")
+		assert.Equal(t, "file", current.Origin("logging.level"))
+
+		assert.Equal(t, "info", cfg.Logging.Level, "the previous configuration is not modified")
+		assert.Equal(t, 20, cfg.RateLimits["upload"])
+	})
+
+	t.Run("invalid configuration is not applied", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(file, []byte("logging:\n  level: loud\n"), 0o600))
+
+		reload, err := reloader.Reload("signal", "")
+		var invalid *config.ValidationError
+		require.True(t, errors.As(err, &invalid))
+		assert.False(t, reload.Succeeded)
+		assert.Contains(t, reload.Error, "logging.level")
+		assert.Equal(t, "debug", reloader.Current().Logging.Level)
+		assert.Len(t, applied, 1)
+	})
+
+	t.Run("secrets are redacted", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(file, []byte("logging:\n  level: debug\nrate_limits:\n  upload: 5\npython_api:\n  base_url: http://analytics:8081\ndatabase:\n  postgres:\n    password: hunter2\n"), 0o600))
+
+		reload, err := reloader.Reload("api", "admin-1")
+		require.NoError(t, err)
+		assert.Empty(t, reload.Applied)
+		require.Len(t, reload.Restart, 1)
+		assert.Equal(t, config.Change{Key: "database.postgres.password", Old: "[REDACTED]", New: "[REDACTED]"}, reload.Restart[0])
+		assert.Len(t, applied, 1, "subscribers only hear of applied changes")
+	})
+
+	t.Run("history is audited newest first", func(t *testing.T) {
+		history := reloader.History()
+		require.Len(t, history, 3)
+		assert.Equal(t, "api", history[0].Trigger)
+		assert.Equal(t, "signal", history[1].Trigger)
+		assert.False(t, history[1].Succeeded)
+		assert.NotEmpty(t, history[2].ID)
+	})
+}
@@ -0,0 +1,270 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix prefixes the environment variable of every setting
+const EnvPrefix = "AIFAA_"
+
+// EnvName returns the environment variable of a setting key: the key in
+// upper case, dots replaced by underscores, with the AIFAA_ prefix. For
+// example "database.postgres.host" is AIFAA_DATABASE_POSTGRES_HOST.
+func EnvName(key string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// Overrides are settings by key, collected from repeated "-set key=value"
+// command-line flags
+type Overrides map[string]string
+
+// String lists the overrides, for the flag package
+func (o Overrides) String() string {
+	var pairs []string
+	for _, key := range sortedKeys(o) {
+		pairs = append(pairs, key+"="+o[key])
+	}
+	return strings.Join(pairs, ",")
+}
+
+// Set adds a "key=value" override, for the flag package
+func (o Overrides) Set(value string) error {
+	key, setting, ok := strings.Cut(value, "=")
+	if !ok || key == "" {
+		return fmt.Errorf("override %q is not key=value", value)
+	}
+	o[key] = setting
+	return nil
+}
+
+// loadFile merges a configuration file into config. YAML and TOML files use
+// the same keys as JSON files.
+func loadFile(config *Config, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var settings map[string]interface{}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		if err := json.Unmarshal(data, config); err != nil {
+			return fmt.Errorf("invalid configuration file %s: %v", path, err)
+		}
+		return nil
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &settings)
+	case ".toml":
+		err = toml.Unmarshal(data, &settings)
+	default:
+		return fmt.Errorf("unsupported configuration file format %q", ext)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid configuration file %s: %v", path, err)
+	}
+
+	// Decode through JSON, so the json tags name the keys of every format
+	data, err = json.Marshal(settings)
+	if err != nil {
+		return fmt.Errorf("invalid configuration file %s: %v", path, err)
+	}
+	if err := json.Unmarshal(data, config); err != nil {
+		return fmt.Errorf("invalid configuration file %s: %v", path, err)
+	}
+	return nil
+}
+
+// applyEnv sets the settings named by environment variables: the legacy
+// names first, so the AIFAA_ names win when both are set
+func applyEnv(config *Config) error {
+	for _, legacy := range legacyEnv {
+		if value, ok := os.LookupEnv(legacy.name); ok {
+			if err := setKey(config, legacy.key, value); err != nil {
+				return fmt.Errorf("invalid %s: %v", legacy.name, err)
+			}
+			config.origins[legacy.key] = legacy.name
+		}
+	}
+
+	env := map[string]string{}
+	for _, pair := range os.Environ() {
+		if name, value, ok := strings.Cut(pair, "="); ok && strings.HasPrefix(name, EnvPrefix) {
+			env[name] = value
+		}
+	}
+	settings := map[string]string{}
+	collectEnv(reflect.TypeOf(*config), "", env, settings)
+
+	// Sorted, so a whole map is set before its entries
+	for _, key := range sortedKeys(settings) {
+		if err := setKey(config, key, settings[key]); err != nil {
+			return fmt.Errorf("invalid %s: %v", EnvName(key), err)
+		}
+		config.origins[key] = EnvName(key)
+	}
+	return nil
+}
+
+// collectEnv finds the AIFAA_ variables of the settings below key. Map
+// entries are named by their map key, e.g. AIFAA_RATE_LIMITS_UPLOAD, and
+// entries holding settings by map key and setting, e.g.
+// AIFAA_HTTP_CLIENTS_DESTINATIONS_ANALYTICS_TIMEOUT_SECONDS.
+func collectEnv(t reflect.Type, key string, env, settings map[string]string) {
+	if t.Kind() == reflect.Struct {
+		for i := 0; i < t.NumField(); i++ {
+			if name := fieldKey(t.Field(i)); name != "" {
+				collectEnv(t.Field(i).Type, joinKey(key, name), env, settings)
+			}
+		}
+		return
+	}
+
+	if value, ok := env[EnvName(key)]; ok {
+		settings[key] = value
+	}
+	if t.Kind() != reflect.Map {
+		return
+	}
+
+	prefix := EnvName(key) + "_"
+	for name, value := range env {
+		rest, ok := strings.CutPrefix(name, prefix)
+		if !ok || rest == "" {
+			continue
+		}
+		if t.Elem().Kind() != reflect.Struct {
+			settings[joinKey(key, strings.ToLower(rest))] = value
+			continue
+		}
+		if entry, field, ok := splitEntry(t.Elem(), rest); ok {
+			settings[joinKey(key, entry+"."+field)] = value
+		}
+	}
+}
+
+// splitEntry splits the variable name of a map entry's setting, such as
+// ANALYTICS_DIAL_TIMEOUT_SECONDS, into map key and setting, preferring the
+// longest matching setting
+func splitEntry(t reflect.Type, rest string) (entry, field string, ok bool) {
+	for i := 0; i < t.NumField(); i++ {
+		name := fieldKey(t.Field(i))
+		suffix := "_" + strings.ToUpper(name)
+		if name == "" || len(rest) <= len(suffix) || !strings.HasSuffix(rest, suffix) {
+			continue
+		}
+		if len(name) > len(field) {
+			entry, field, ok = strings.ToLower(strings.TrimSuffix(rest, suffix)), name, true
+		}
+	}
+	return entry, field, ok
+}
+
+// setKey sets the setting named by a dotted key, e.g.
+// "http_clients.destinations.analytics.timeout_seconds", from its string form
+func setKey(config *Config, key, value string) error {
+	return setPath(reflect.ValueOf(config).Elem(), strings.Split(key, "."), value)
+}
+
+// setPath sets the value below v named by path
+func setPath(v reflect.Value, path []string, value string) error {
+	if len(path) == 0 {
+		return setValue(v, value)
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if fieldKey(v.Type().Field(i)) == path[0] {
+				return setPath(v.Field(i), path[1:], value)
+			}
+		}
+	case reflect.Map:
+		// Map entries are not addressable: update a copy and store it back
+		key := reflect.ValueOf(path[0])
+		entry := reflect.New(v.Type().Elem()).Elem()
+		if existing := v.MapIndex(key); existing.IsValid() {
+			entry.Set(existing)
+		}
+		if err := setPath(entry, path[1:], value); err != nil {
+			return err
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+		v.SetMapIndex(key, entry)
+		return nil
+	}
+	return fmt.Errorf("unknown setting %q", path[0])
+}
+
+// setValue parses a setting from its string form. Lists are comma-separated,
+// and flag maps are flag lists as for parseFeatureFlags.
+func setValue(v reflect.Value, value string) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a boolean", value)
+		}
+		v.SetBool(parsed)
+	case reflect.Int, reflect.Int64:
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", value)
+		}
+		v.SetInt(parsed)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("cannot be set from a string")
+		}
+		v.Set(reflect.ValueOf(parseList(value)))
+	case reflect.Map:
+		if v.Type().Elem().Kind() != reflect.Bool {
+			return fmt.Errorf("cannot be set as a whole; set its entries")
+		}
+		v.Set(reflect.ValueOf(parseFeatureFlags(value)))
+	default:
+		return fmt.Errorf("cannot be set from a string")
+	}
+	return nil
+}
+
+// fieldKey returns the key of a struct field, its JSON name, or "" for
+// fields that are not settings
+func fieldKey(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if !f.IsExported() || name == "-" {
+		return ""
+	}
+	return name
+}
+
+// joinKey appends a name to a dotted key
+func joinKey(key, name string) string {
+	if key == "" {
+		return name
+	}
+	return key + "." + name
+}
+
+// sortedKeys returns the keys of a map in order
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,493 @@
+package config
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"nivai/backend/pkg/lock"
+	"nivai/backend/pkg/scheduler"
+)
+
+// ValidationError lists every problem Validate found, so they can all be
+// fixed at once
+type ValidationError struct {
+	Problems []string
+}
+
+// Error lists the problems, one per line
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// validator collects problems
+type validator struct {
+	problems []string
+}
+
+// problem records a problem
+func (v *validator) problem(format string, args ...interface{}) {
+	v.problems = append(v.problems, fmt.Sprintf(format, args...))
+}
+
+// required records a problem when a setting is empty
+func (v *validator) required(key, value, reason string) {
+	if value == "" {
+		v.problem("%s is required %s", key, reason)
+	}
+}
+
+// oneOf records a problem when a setting is not one of the allowed values
+func (v *validator) oneOf(key, value string, allowed ...string) {
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+	}
+	v.problem("%s is %q; use one of %s", key, value, strings.Join(allowed, ", "))
+}
+
+// positive records a problem when an interval or size is not above zero
+func (v *validator) positive(key string, value int) {
+	if value <= 0 {
+		v.problem("%s must be positive, is %d", key, value)
+	}
+}
+
+// schedule records a problem when a set job schedule cannot be parsed
+func (v *validator) schedule(key, value string) {
+	if value == "" {
+		return
+	}
+	if _, err := scheduler.Parse(value); err != nil {
+		v.problem("%s: %v", key, err)
+	}
+}
+
+// percent records a problem when a share is not between 0 and 100
+func (v *validator) percent(key string, value int) {
+	if value < 0 || value > 100 {
+		v.problem("%s must be between 0 and 100, is %d", key, value)
+	}
+}
+
+// notNegative records a problem when a limit is below zero
+func (v *validator) notNegative(key string, value int64) {
+	if value < 0 {
+		v.problem("%s must not be negative, is %d", key, value)
+	}
+}
+
+// url records a problem when a setting is not an absolute URL with one of
+// the given schemes
+func (v *validator) url(key, value string, schemes ...string) {
+	u, err := url.Parse(value)
+	if err != nil || u.Host == "" {
+		v.problem("%s is %q, not an absolute URL", key, value)
+		return
+	}
+	v.oneOf(key+" scheme", u.Scheme, schemes...)
+}
+
+// Validate checks that the configuration can be served: that settings parse,
+// and that every enabled feature has the settings it needs. Database and
+// storage settings are not checked in demo mode, which uses neither.
+//
+// It returns a *ValidationError listing every problem, or nil.
+func (c *Config) Validate() error {
+	v := &validator{}
+
+	port, err := strconv.Atoi(c.Server.Port)
+	if err != nil || port < 1 || port > 65535 {
+		v.problem("server.port is %q, not a port number", c.Server.Port)
+	}
+	if _, err := time.LoadLocation(c.Organization.Timezone); err != nil {
+		v.problem("organization.timezone is %q, not a known time zone", c.Organization.Timezone)
+	}
+	if c.API.V1Sunset != "" {
+		if _, err := time.Parse(time.DateOnly, c.API.V1Sunset); err != nil {
+			v.problem("api.v1_sunset is %q, not a date like 2027-06-30", c.API.V1Sunset)
+		}
+	}
+
+	v.oneOf("logging.level", c.Logging.Level, "debug", "info", "warn", "error")
+	for _, origin := range c.CORS.AllowedOrigins {
+		if origin != "*" {
+			v.url("cors.allowed_origins", origin, "http", "https")
+		}
+	}
+
+	if !c.Demo {
+		c.validateDatabase(v)
+		c.validateStorage(v)
+	}
+	v.oneOf("storage.path_strategy", c.Storage.PathStrategy, "", "id_shard", "date_tree", "org_prefixed")
+	v.positive("storage.signed_urls.expiry_minutes", c.Storage.SignedURLs.ExpiryMinutes)
+	if c.Storage.SignedURLs.BaseURL != "" {
+		v.url("storage.signed_urls.base_url", c.Storage.SignedURLs.BaseURL, "http", "https")
+	}
+	c.validateFeatures(v)
+
+	// Intervals drive tickers, which cannot tick every 0 seconds
+	for _, setting := range []struct {
+		key   string
+		value int
+	}{
+		{"webhooks.poll_interval_seconds", c.Webhooks.PollIntervalSecs},
+		{"broker.poll_interval_seconds", c.Broker.PollIntervalSecs},
+		{"archive.poll_interval_seconds", c.Archive.PollIntervalSecs},
+		{"retention.sweep_interval_hours", c.Retention.SweepIntervalHours},
+		{"storage_gc.interval_hours", c.StorageGC.IntervalHours},
+		{"reconciler.interval_minutes", c.Reconciler.IntervalMinutes},
+		{"deletion.purge_interval_minutes", c.Deletion.PurgeIntervalMinutes},
+		{"load_shedding.check_interval_seconds", c.LoadShedding.CheckIntervalSecs},
+		{"match_day.refresh_interval_seconds", c.MatchDay.RefreshIntervalSecs},
+		{"database.postgres.replica_check_seconds", c.Database.Postgres.ReplicaCheckSecs},
+		{"startup.initial_backoff_seconds", c.Startup.InitialBackoffSecs},
+		{"startup.max_backoff_seconds", c.Startup.MaxBackoffSecs},
+	} {
+		v.positive(setting.key, setting.value)
+	}
+	v.positive("webhooks.max_attempts", c.Webhooks.MaxAttempts)
+	v.positive("reconciler.stuck_after_minutes", c.Reconciler.StuckAfterMinutes)
+	v.schedule("scheduler.retention", c.Scheduler.Retention)
+	v.schedule("scheduler.storage_gc", c.Scheduler.StorageGC)
+	v.schedule("scheduler.reconciler", c.Scheduler.Reconciler)
+	v.schedule("scheduler.usage_accounting", c.Scheduler.UsageAccounting)
+	v.schedule("scheduler.ingestion", c.Scheduler.Ingestion)
+	v.schedule("scheduler.usage_summary", c.Scheduler.UsageSummary)
+	v.schedule("scheduler.video_purge", c.Scheduler.VideoPurge)
+	v.schedule("scheduler.draft_expiry", c.Scheduler.DraftExpiry)
+	v.notNegative("deletion.grace_period_hours", int64(c.Deletion.GracePeriodHours))
+	c.validateLocks(v)
+	c.validateIngestion(v)
+	if c.Backfill.Directory != "" {
+		v.positive("backfill.batch_size", c.Backfill.BatchSize)
+		v.positive("backfill.poll_seconds", c.Backfill.PollSecs)
+		v.positive("backfill.batch_timeout_minutes", c.Backfill.BatchTimeoutMinutes)
+		v.positive("backfill.max_entries", c.Backfill.MaxEntries)
+	}
+	v.positive("match_list.status_concurrency", c.MatchList.StatusConcurrency)
+	v.positive("match_list.status_timeout_seconds", c.MatchList.StatusTimeoutSecs)
+	v.positive("match_list.breaker_failures", c.MatchList.BreakerFailures)
+	v.positive("match_list.breaker_cooldown_seconds", c.MatchList.BreakerCooldownSecs)
+	v.positive("match_list.status_cache_size", c.MatchList.StatusCacheSize)
+	v.notNegative("stats.cache_ttl_seconds", int64(c.Stats.CacheTTLSecs))
+	v.positive("sharing.default_ttl_hours", c.Sharing.DefaultTTLHours)
+	if c.Sharing.MaxTTLHours < c.Sharing.DefaultTTLHours {
+		v.problem("sharing.max_ttl_hours must be at least sharing.default_ttl_hours (%d), is %d", c.Sharing.DefaultTTLHours, c.Sharing.MaxTTLHours)
+	}
+	v.positive("notifications.queue_size", c.Notifications.QueueSize)
+	if c.Notifications.SMTP.Host != "" {
+		v.positive("notifications.smtp.port", c.Notifications.SMTP.Port)
+		v.required("notifications.smtp.from", c.Notifications.SMTP.From, "for email notifications")
+	}
+	c.validatePII(v)
+	c.validateIPFilter(v)
+	c.validateHTTPClients(v)
+	v.percent("debug_log.sample_percent", c.DebugLog.SamplePercent)
+	v.percent("debug_log.python_api_sample_percent", c.DebugLog.PythonAPISamplePercent)
+	v.positive("debug_log.max_body_bytes", c.DebugLog.MaxBodyBytes)
+	v.notNegative("startup.timeout_seconds", int64(c.Startup.TimeoutSecs))
+	v.notNegative("uploads.max_video_mb", c.Uploads.MaxVideoMB)
+	v.notNegative("uploads.max_tracking_mb", c.Uploads.MaxTrackingMB)
+	v.notNegative("uploads.max_events_mb", c.Uploads.MaxEventsMB)
+	v.positive("drafts.ttl_hours", c.Drafts.TTLHours)
+	v.positive("drafts.expiry_interval_minutes", c.Drafts.ExpiryIntervalMinutes)
+	v.notNegative("quotas.organization_bytes", c.Quotas.OrganizationBytes)
+	v.notNegative("quotas.user_bytes", c.Quotas.UserBytes)
+	for _, class := range sortedLimits(c.RateLimits) {
+		v.notNegative("rate_limits."+class, int64(c.RateLimits[class]))
+	}
+	v.notNegative("body_limits.default_kb", c.BodyLimits.DefaultKB)
+	v.notNegative("body_limits.import_mb", c.BodyLimits.ImportMB)
+	v.notNegative("body_limits.upload_mb", c.BodyLimits.UploadMB)
+	if c.BodyLimits.UploadMB > 0 && c.BodyLimits.UploadMB < c.Uploads.MaxVideoMB {
+		v.problem("body_limits.upload_mb must be at least uploads.max_video_mb (%d), is %d", c.Uploads.MaxVideoMB, c.BodyLimits.UploadMB)
+	}
+
+	if len(v.problems) > 0 {
+		return &ValidationError{Problems: v.problems}
+	}
+	return nil
+}
+
+// validatePII checks the PII encryption keys are 32-byte base64 keys with
+// IDs, that the active key is one of them and that the index key is a
+// 32-byte base64 key too
+func (c *Config) validatePII(v *validator) {
+	if c.PII.IndexKey != "" {
+		if key, err := base64.StdEncoding.DecodeString(c.PII.IndexKey); err != nil || len(key) != 32 {
+			v.problem("pii.index_key must be 32 bytes in base64")
+		}
+	}
+	if c.PII.EncryptionKeys == "" {
+		return
+	}
+	ids := map[string]bool{}
+	for _, entry := range strings.Split(c.PII.EncryptionKeys, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, encoded, ok := strings.Cut(entry, ":")
+		if !ok || id == "" {
+			v.problem("pii.encryption_keys must be given as <id>:<base64 key>")
+			return
+		}
+		if key, err := base64.StdEncoding.DecodeString(encoded); err != nil || len(key) != 32 {
+			v.problem("pii.encryption_keys: key %q must be 32 bytes in base64", id)
+		}
+		ids[id] = true
+	}
+	if c.PII.ActiveKey != "" && !ids[c.PII.ActiveKey] {
+		v.problem("pii.active_key %q is not one of pii.encryption_keys", c.PII.ActiveKey)
+	}
+}
+
+// validateIPFilter checks the IP filter lists hold IPs or CIDR ranges, and
+// that countries are read from trusted proxies
+func (c *Config) validateIPFilter(v *validator) {
+	f := c.IPFilter
+	for _, list := range []struct {
+		key     string
+		entries []string
+	}{
+		{"ip_filter.admin_allowlist", f.AdminAllowlist},
+		{"ip_filter.upload_allowlist", f.UploadAllowlist},
+		{"ip_filter.denylist", f.Denylist},
+		{"ip_filter.trusted_proxies", f.TrustedProxies},
+	} {
+		for _, entry := range list.entries {
+			if _, err := netip.ParsePrefix(entry); err == nil {
+				continue
+			}
+			if _, err := netip.ParseAddr(entry); err != nil {
+				v.problem("%s holds %q, not an IP or CIDR range", list.key, entry)
+			}
+		}
+	}
+	if len(f.AllowedCountries) > 0 || len(f.DeniedCountries) > 0 {
+		v.required("ip_filter.country_header", f.CountryHeader, "to restrict countries")
+	}
+	if f.CountryHeader != "" && len(f.TrustedProxies) == 0 {
+		v.problem("ip_filter.country_header is only read from trusted proxies; set ip_filter.trusted_proxies")
+	}
+}
+
+// validateHTTPClients checks the outbound proxies are URLs and the CA bundles
+// exist, so a misconfigured egress fails startup instead of falling back to
+// direct connections
+func (c *Config) validateHTTPClients(v *validator) {
+	check := func(key string, s HTTPClientSettings) {
+		switch strings.ToLower(s.ProxyURL) {
+		case "", "direct", "none":
+		default:
+			v.url(key+".proxy_url", s.ProxyURL, "http", "https", "socks5")
+		}
+		if s.CAFile != "" {
+			if _, err := os.Stat(s.CAFile); err != nil {
+				v.problem("%s.ca_file: %v", key, err)
+			}
+		}
+	}
+	check("http_clients.defaults", c.HTTPClients.Defaults)
+	destinations := make([]string, 0, len(c.HTTPClients.Destinations))
+	for name := range c.HTTPClients.Destinations {
+		destinations = append(destinations, name)
+	}
+	sort.Strings(destinations)
+	for _, name := range destinations {
+		check("http_clients.destinations."+name, c.HTTPClients.Destinations[name])
+	}
+}
+
+// validateLocks checks the lock backend can be reached, and that Redis locks
+// outlive the refreshes that keep them
+func (c *Config) validateLocks(v *validator) {
+	switch c.Locks.Backend {
+	case "", "local":
+	case "postgres":
+		if c.Database.Driver != "postgres" {
+			v.problem("locks.backend is \"postgres\", but database.driver is %q", c.Database.Driver)
+		}
+	case "redis":
+		v.required("database.redis.host", c.Database.Redis.Host, "for Redis locks")
+		if min := 2 * int(lock.RefreshInterval/time.Second); c.Locks.TTLSeconds < min {
+			v.problem("locks.ttl_seconds must be at least %d, is %d", min, c.Locks.TTLSeconds)
+		}
+	default:
+		v.oneOf("locks.backend", c.Locks.Backend, "", "postgres", "redis", "local")
+	}
+}
+
+// validateIngestion checks the drop folder can be watched and, for SFTP,
+// that the server can be authenticated and identified
+func (c *Config) validateIngestion(v *validator) {
+	in := c.Ingestion
+	switch in.Source {
+	case "":
+		return
+	case "directory", "sftp":
+	default:
+		v.oneOf("ingestion.source", in.Source, "", "directory", "sftp")
+		return
+	}
+	v.required("ingestion.directory", in.Directory, "for ingestion")
+	v.positive("ingestion.interval_seconds", in.IntervalSecs)
+	v.positive("ingestion.settle_seconds", in.SettleSecs)
+	if in.Source == "sftp" {
+		v.required("ingestion.sftp.host", in.SFTP.Host, "for SFTP ingestion")
+		v.required("ingestion.sftp.user", in.SFTP.User, "for SFTP ingestion")
+		v.positive("ingestion.sftp.port", in.SFTP.Port)
+		if in.SFTP.Password == "" && in.SFTP.PrivateKeyFile == "" {
+			v.problem("ingestion.sftp.password or ingestion.sftp.private_key_file is required for SFTP ingestion")
+		}
+		if !strings.HasPrefix(in.SFTP.HostKeyFingerprint, "SHA256:") {
+			v.problem("ingestion.sftp.host_key_fingerprint must be a SHA256 fingerprint as printed by ssh-keygen -l, is %q", in.SFTP.HostKeyFingerprint)
+		}
+	}
+}
+
+// validateDatabase checks the settings of the configured database driver
+func (c *Config) validateDatabase(v *validator) {
+	switch c.Database.Driver {
+	case "postgres":
+		pg := c.Database.Postgres
+		v.required("database.postgres.host", pg.Host, "for PostgreSQL")
+		v.required("database.postgres.user", pg.User, "for PostgreSQL")
+		v.required("database.postgres.dbname", pg.DBName, "for PostgreSQL")
+		if _, err := strconv.Atoi(pg.Port); err != nil {
+			v.problem("database.postgres.port is %q, not a port number", pg.Port)
+		}
+	case "sqlite":
+		v.required("database.sqlite.path", c.Database.SQLite.Path, "for SQLite")
+	default:
+		v.oneOf("database.driver", c.Database.Driver, "postgres", "sqlite")
+	}
+	v.notNegative("database.slow_query_ms", int64(c.Database.SlowQueryMs))
+}
+
+// validateStorage checks that exactly one storage backend is configured, and
+// that it has the settings it needs. The mount path only serves when no
+// other backend is configured, so it never conflicts.
+func (c *Config) validateStorage(v *validator) {
+	azure := c.Storage.AzureBlobStorage
+	local := c.Storage.LocalPath != ""
+	hasAzure := azure.AccountName != "" || azure.ConnectionString != ""
+
+	switch {
+	case local && hasAzure:
+		v.problem("both local storage (storage.local_path) and Azure Blob Storage are configured; configure one")
+	case !local && !hasAzure && c.Storage.MountPath == "":
+		v.problem("no storage backend is configured; set storage.local_path, storage.mount_path or an Azure Blob Storage account")
+	}
+
+	if local {
+		if info, err := os.Stat(c.Storage.LocalPath); err != nil || !info.IsDir() {
+			v.problem("storage.local_path %q is not an existing directory", c.Storage.LocalPath)
+		}
+	}
+	if hasAzure && !local {
+		c.validateAzure(v)
+	}
+}
+
+// validateAzure checks the Azure Blob Storage container and the credentials
+// of its authentication mode
+func (c *Config) validateAzure(v *validator) {
+	azure := c.Storage.AzureBlobStorage
+	const prefix = "storage.azure_blob_storage."
+
+	v.required(prefix+"container_name", azure.ContainerName, "for Azure Blob Storage")
+	switch azure.AuthMode {
+	case "", "default", "managed_identity":
+	case "shared_key":
+		v.required(prefix+"account_key", azure.AccountKey, "for shared_key authentication")
+	case "service_principal":
+		v.required(prefix+"tenant_id", azure.TenantID, "for service_principal authentication")
+		v.required(prefix+"client_id", azure.ClientID, "for service_principal authentication")
+		v.required(prefix+"client_secret", azure.ClientSecret, "for service_principal authentication")
+	case "connection_string":
+		v.required(prefix+"connection_string", azure.ConnectionString, "for connection_string authentication")
+	default:
+		v.oneOf(prefix+"auth_mode", azure.AuthMode, "shared_key", "service_principal", "managed_identity", "default", "connection_string")
+	}
+}
+
+// validateFeatures checks the settings of the optional integrations that are
+// enabled
+func (c *Config) validateFeatures(v *validator) {
+	api := c.PythonAPI
+	v.url("python_api.base_url", api.BaseURL, "http", "https")
+	v.oneOf("python_api.transport", api.Transport, "http", "grpc")
+	if api.Transport == "grpc" {
+		v.required("python_api.grpc_address", api.GRPCAddress, "for the grpc transport")
+	}
+	v.positive("python_api.max_response_mb", api.MaxResponseMB)
+	v.oneOf("python_api.path_mode", api.PathMode, "storage", "prefix", "signed_url")
+	v.oneOf("python_api.contracts", api.Contracts, "enforce", "log", "off")
+	for i, worker := range api.WorkerURLs {
+		v.url(fmt.Sprintf("python_api.worker_urls[%d]", i), worker, "http", "https")
+	}
+	v.oneOf("python_api.routing", api.Routing, "round_robin", "least_in_flight")
+	v.positive("python_api.health_check_seconds", api.HealthCheckSecs)
+	if api.PathMode == "prefix" {
+		v.required("python_api.path_map", api.PathMap, "for the prefix path mode")
+	}
+
+	switch c.Broker.Type {
+	case "":
+	case "kafka":
+		v.url("broker.kafka.rest_proxy_url", c.Broker.Kafka.RESTProxyURL, "http", "https")
+		v.required("broker.kafka.topic", c.Broker.Kafka.Topic, "for Kafka")
+	case "rabbitmq":
+		v.url("broker.rabbitmq.url", c.Broker.RabbitMQ.URL, "amqp", "amqps")
+		v.required("broker.rabbitmq.exchange", c.Broker.RabbitMQ.Exchange, "for RabbitMQ")
+	default:
+		v.oneOf("broker.type", c.Broker.Type, "", "kafka", "rabbitmq")
+	}
+
+	v.oneOf("scanning.type", c.Scanning.Type, "", "none", "clamav")
+	if c.Scanning.Type == "clamav" {
+		v.required("scanning.clamav_address", c.Scanning.ClamAVAddress, "for ClamAV scanning")
+		v.positive("scanning.timeout_seconds", c.Scanning.TimeoutSecs)
+	}
+
+	v.oneOf("archive.type", c.Archive.Type, "", "none", "azure_tier", "cold_storage")
+	if c.Archive.Type == "cold_storage" && c.Archive.ColdPath == "" && c.Archive.ColdContainer == "" {
+		v.problem("archive.cold_path or archive.cold_container is required for cold_storage archiving")
+	}
+
+	v.oneOf("upload_progress.store", c.UploadProgress.Store, "", "none", "memory", "redis")
+	v.oneOf("analytics_cache.store", c.AnalyticsCache.Store, "", "none", "memory", "disk", "redis")
+	if c.AnalyticsCache.Store == "disk" {
+		v.required("analytics_cache.dir", c.AnalyticsCache.Dir, "for the disk analytics cache")
+	}
+	v.oneOf("response_cache.store", c.ResponseCache.Store, "", "none", "memory", "redis")
+	if c.ResponseCache.Store == "memory" {
+		v.positive("response_cache.memory_max_mb", c.ResponseCache.MemoryMaxMB)
+	}
+	for _, route := range sortedLimits(c.ResponseCache.TTLSeconds) {
+		v.notNegative("response_cache.ttl_seconds."+route, int64(c.ResponseCache.TTLSeconds[route]))
+	}
+	if c.UploadProgress.Store == "redis" || c.AnalyticsCache.Store == "redis" || c.ResponseCache.Store == "redis" {
+		v.required("database.redis.host", c.Database.Redis.Host, "for Redis stores")
+	}
+}
+
+// sortedLimits returns the rate limit classes in order
+func sortedLimits(limits map[string]int) []string {
+	classes := make([]string, 0, len(limits))
+	for class := range limits {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+	return classes
+}
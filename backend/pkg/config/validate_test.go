@@ -0,0 +1,175 @@
+package config_test
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"nivai/backend/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// validConfig loads the defaults with local storage in a temporary directory
+func validConfig(t *testing.T) *config.Config {
+	t.Helper()
+	cfg, err := config.LoadFrom(config.Sources{
+		File:      writeFile(t, "empty.json", "{}"),
+		Overrides: config.Overrides{"storage.local_path": t.TempDir()},
+	})
+	require.NoError(t, err)
+	return cfg
+}
+
+// problems returns the problems Validate reports
+func problems(t *testing.T, cfg *config.Config) []string {
+	t.Helper()
+	err := cfg.Validate()
+	if err == nil {
+		return nil
+	}
+	var invalid *config.ValidationError
+	require.True(t, errors.As(err, &invalid), "unexpected error %v", err)
+	return invalid.Problems
+}
+
+func TestValidate(t *testing.T) {
+	t.Run("defaults with storage are valid", func(t *testing.T) {
+		assert.NoError(t, validConfig(t).Validate())
+	})
+
+	t.Run("reports every problem", func(t *testing.T) {
+		cfg := validConfig(t)
+		cfg.Server.Port = "http"
+		cfg.PythonAPI.BaseURL = "localhost:8081"
+		cfg.Broker.Type = "kafka"
+		cfg.Broker.Kafka.Topic = ""
+		cfg.Webhooks.PollIntervalSecs = 0
+
+		assert.ElementsMatch(t, []string{
+			`server.port is "http", not a port number`,
+			`python_api.base_url is "localhost:8081", not an absolute URL`,
+			"broker.kafka.topic is required for Kafka",
+			"webhooks.poll_interval_seconds must be positive, is 0",
+		}, problems(t, cfg))
+		assert.Contains(t, cfg.Validate().Error(), "invalid configuration:\n  - ")
+	})
+
+	t.Run("storage backends", func(t *testing.T) {
+		cfg := validConfig(t)
+		cfg.Storage.AzureBlobStorage.AccountName = "account"
+		assert.Len(t, problems(t, cfg), 1, "local and Azure storage both configured")
+
+		cfg.Storage.LocalPath = ""
+		assert.Equal(t, []string{"storage.azure_blob_storage.container_name is required for Azure Blob Storage"}, problems(t, cfg))
+
+		cfg.Storage.AzureBlobStorage.ContainerName = "matches"
+		cfg.Storage.AzureBlobStorage.AuthMode = "service_principal"
+		cfg.Storage.AzureBlobStorage.TenantID = "tenant"
+		assert.ElementsMatch(t, []string{
+			"storage.azure_blob_storage.client_id is required for service_principal authentication",
+			"storage.azure_blob_storage.client_secret is required for service_principal authentication",
+		}, problems(t, cfg))
+
+		cfg.Storage.AzureBlobStorage = validConfig(t).Storage.AzureBlobStorage
+		assert.Len(t, problems(t, cfg), 1, "no storage backend")
+
+		cfg.Storage.MountPath = "/data/shared"
+		assert.Empty(t, problems(t, cfg), "the mount path is created on startup")
+
+		cfg.Storage.MountPath = ""
+		cfg.Storage.LocalPath = "/does/not/exist"
+		assert.Equal(t, []string{`storage.local_path "/does/not/exist" is not an existing directory`}, problems(t, cfg))
+	})
+
+	t.Run("enabled features need their settings", func(t *testing.T) {
+		cfg := validConfig(t)
+		cfg.PythonAPI.PathMode = "prefix"
+		cfg.Archive.Type = "cold_storage"
+		cfg.AnalyticsCache.Store = "redis"
+		cfg.Database.Redis.Host = ""
+
+		assert.ElementsMatch(t, []string{
+			"python_api.path_map is required for the prefix path mode",
+			"archive.cold_path or archive.cold_container is required for cold_storage archiving",
+			"database.redis.host is required for Redis stores",
+		}, problems(t, cfg))
+	})
+
+	t.Run("SFTP ingestion needs credentials and the host key", func(t *testing.T) {
+		cfg := validConfig(t)
+		cfg.Ingestion.Source = "sftp"
+		cfg.Ingestion.Directory = "/drop"
+		cfg.Ingestion.SFTP.Host = "sftp.provider.example"
+		cfg.Ingestion.SFTP.User = "nivai"
+
+		assert.ElementsMatch(t, []string{
+			"ingestion.sftp.password or ingestion.sftp.private_key_file is required for SFTP ingestion",
+			`ingestion.sftp.host_key_fingerprint must be a SHA256 fingerprint as printed by ssh-keygen -l, is ""`,
+		}, problems(t, cfg))
+	})
+
+	t.Run("PII encryption keys are 32-byte base64 keys", func(t *testing.T) {
+		cfg := validConfig(t)
+		cfg.PII.EncryptionKeys = "2024:" + strings.Repeat("A", 43) + "=, 2025:AAAA"
+		cfg.PII.ActiveKey = "2026"
+		cfg.PII.IndexKey = "AAAA"
+		assert.ElementsMatch(t, []string{
+			`pii.encryption_keys: key "2025" must be 32 bytes in base64`,
+			`pii.active_key "2026" is not one of pii.encryption_keys`,
+			`pii.index_key must be 32 bytes in base64`,
+		}, problems(t, cfg))
+
+		cfg.PII.EncryptionKeys = "2024:" + strings.Repeat("A", 43) + "="
+		cfg.PII.ActiveKey = ""
+		cfg.PII.IndexKey = strings.Repeat("B", 43) + "="
+		assert.Empty(t, problems(t, cfg))
+	})
+
+	t.Run("IP filter lists hold IPs or CIDR ranges", func(t *testing.T) {
+		cfg := validConfig(t)
+		cfg.IPFilter.AdminAllowlist = []string{"10.0.0.0/8", "203.0.113.7", "office"}
+		cfg.IPFilter.Denylist = []string{"10.0.0.0/33"}
+		cfg.IPFilter.DeniedCountries = []string{"KP"}
+		assert.ElementsMatch(t, []string{
+			`ip_filter.admin_allowlist holds "office", not an IP or CIDR range`,
+			`ip_filter.denylist holds "10.0.0.0/33", not an IP or CIDR range`,
+			"ip_filter.country_header is required to restrict countries",
+		}, problems(t, cfg))
+
+		cfg.IPFilter.AdminAllowlist = []string{"10.0.0.0/8"}
+		cfg.IPFilter.Denylist = nil
+		cfg.IPFilter.CountryHeader = "CF-IPCountry"
+		assert.Equal(t, []string{"ip_filter.country_header is only read from trusted proxies; set ip_filter.trusted_proxies"}, problems(t, cfg))
+
+		cfg.IPFilter.TrustedProxies = []string{"10.0.0.1"}
+		assert.Empty(t, problems(t, cfg))
+	})
+
+	t.Run("outbound proxies are URLs and CA bundles exist", func(t *testing.T) {
+		cfg := validConfig(t)
+		cfg.HTTPClients.Defaults.ProxyURL = "proxy.club.local:3128"
+		cfg.HTTPClients.Destinations["analytics"] = config.HTTPClientSettings{ProxyURL: "ftp://proxy.club.local"}
+		cfg.HTTPClients.Destinations["webhooks"] = config.HTTPClientSettings{CAFile: filepath.Join(t.TempDir(), "club-ca.pem")}
+		got := problems(t, cfg)
+		require.Len(t, got, 3)
+		assert.Equal(t, `http_clients.defaults.proxy_url is "proxy.club.local:3128", not an absolute URL`, got[0])
+		assert.Equal(t, `http_clients.destinations.analytics.proxy_url scheme is "ftp"; use one of http, https, socks5`, got[1])
+		assert.Contains(t, got[2], "http_clients.destinations.webhooks.ca_file: ")
+
+		cfg.HTTPClients.Defaults.ProxyURL = "http://proxy.club.local:3128"
+		cfg.HTTPClients.Destinations["analytics"] = config.HTTPClientSettings{ProxyURL: "direct"}
+		cfg.HTTPClients.Destinations["webhooks"] = config.HTTPClientSettings{CAFile: t.TempDir()}
+		assert.Empty(t, problems(t, cfg))
+	})
+
+	t.Run("demo mode needs no database or storage", func(t *testing.T) {
+		cfg := validConfig(t)
+		cfg.Demo = true
+		cfg.Storage.LocalPath = ""
+		cfg.Database.Driver = "oracle"
+		assert.Empty(t, problems(t, cfg))
+	})
+}
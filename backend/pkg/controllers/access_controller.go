@@ -0,0 +1,159 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"nivai/backend/pkg/httperr"
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/requestctx"
+	"nivai/backend/pkg/services"
+
+	"github.com/gorilla/mux"
+)
+
+// AccessController manages the access grants that give users and groups
+// access to single matches or to all matches of a team.
+type AccessController struct {
+	access *services.AccessService
+}
+
+// NewAccessController creates a new controller for the access grant endpoints.
+func NewAccessController(access *services.AccessService) *AccessController {
+	return &AccessController{access: access}
+}
+
+// createGrantRequest is the body of POST /api/v1/access-grants.
+type createGrantRequest struct {
+	SubjectType  string `json:"subject_type"`  // "user" or "group"
+	SubjectID    string `json:"subject_id"`    // User ID or group name
+	ResourceType string `json:"resource_type"` // "match" or "team"
+	ResourceID   string `json:"resource_id"`   // Match ID or team name
+	Permission   string `json:"permission"`    // "view" or "edit"; empty is view
+}
+
+// ListGrants handles GET /api/v1/access-grants.
+// With resource_type and resource_id it lists the grants on that match or
+// team, with subject_type and subject_id those of that user or group, and
+// otherwise all grants.
+func (ac *AccessController) ListGrants(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var grants []*models.AccessGrant
+	var err error
+	if subjectType := query.Get("subject_type"); subjectType != "" {
+		if subjectType != models.AccessSubjectUser && subjectType != models.AccessSubjectGroup {
+			httperr.WriteError(w, r, httperr.BadRequest("subject_type must be user or group"))
+			return
+		}
+		grants, err = ac.access.ListForSubject(subjectType, query.Get("subject_id"))
+	} else {
+		grants, err = ac.access.List(query.Get("resource_type"), query.Get("resource_id"))
+	}
+	if err != nil {
+		log.Printf("Error listing access grants: %v", err)
+		httperr.WriteError(w, r, httperr.Internal("Failed to list access grants"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(grants); err != nil {
+		log.Printf("Error encoding ListGrants response: %v", err)
+	}
+}
+
+// CreateGrant handles POST /api/v1/access-grants.
+// A subject that already has a grant on the resource gets its permission
+// changed; the response carries the grant either way.
+func (ac *AccessController) CreateGrant(w http.ResponseWriter, r *http.Request) {
+	var req createGrantRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httperr.WriteError(w, r, httperr.BadRequest("Invalid request payload"))
+		return
+	}
+
+	info := requestctx.From(r)
+	grant := &models.AccessGrant{
+		SubjectType:  req.SubjectType,
+		SubjectID:    req.SubjectID,
+		ResourceType: req.ResourceType,
+		ResourceID:   req.ResourceID,
+		Permission:   req.Permission,
+		GrantedBy:    info.Principal.UserID,
+	}
+	if err := ac.access.Grant(grant); err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidAccessGrant):
+			httperr.WriteError(w, r, httperr.BadRequest(err.Error()))
+		case errors.Is(err, services.ErrVideoNotFound):
+			httperr.WriteError(w, r, httperr.NotFound("Match not found"))
+		default:
+			log.Printf("Error saving access grant: %v", err)
+			httperr.WriteError(w, r, httperr.Internal("Failed to save access grant"))
+		}
+		return
+	}
+	info.Logger.Printf("Audit: %s %q granted %s access to %s %q by user %q",
+		grant.SubjectType, grant.SubjectID, grant.Permission, grant.ResourceType, grant.ResourceID, info.Principal.UserID)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(grant); err != nil {
+		log.Printf("Error encoding CreateGrant response: %v", err)
+	}
+}
+
+// RevokeGrant handles DELETE /api/v1/access-grants/{id}.
+func (ac *AccessController) RevokeGrant(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if err := ac.access.Revoke(id); err != nil {
+		if errors.Is(err, models.ErrAccessGrantNotFound) {
+			httperr.WriteError(w, r, httperr.NotFound("Access grant not found"))
+			return
+		}
+		log.Printf("Error revoking access grant %s: %v", id, err)
+		httperr.WriteError(w, r, httperr.Internal("Failed to revoke access grant"))
+		return
+	}
+	info := requestctx.From(r)
+	info.Logger.Printf("Audit: access grant %s revoked by user %q", id, info.Principal.UserID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// listAccessibleVideos lists the videos matching filters that the caller may
+// see: all of them without access control or for unrestricted callers.
+func listAccessibleVideos(r *http.Request, vs services.VideoService, access *services.AccessService, limit, offset int, filters map[string]string) ([]*models.Video, error) {
+	if access == nil {
+		return vs.ListVideos(limit, offset, filters)
+	}
+	scope, err := access.Scope(requestctx.From(r).Principal)
+	if err != nil {
+		return nil, err
+	}
+	if scope == nil {
+		return vs.ListVideos(limit, offset, filters)
+	}
+	return vs.ListVideosFor(scope, limit, offset, filters)
+}
+
+// allowMatch reports whether the caller may see, or with write change, the
+// match a resource such as a report or job belongs to, writing the error
+// response when not. Without access control every match is allowed.
+func allowMatch(w http.ResponseWriter, r *http.Request, access *services.AccessService, matchID string, write bool) bool {
+	if access == nil {
+		return true
+	}
+	info := requestctx.From(r)
+	allowed, err := access.CanAccessMatch(info.Principal, matchID, write)
+	if err != nil {
+		info.Logger.Printf("Error checking access of user %q to %s: %v", info.Principal.UserID, matchID, err)
+		httperr.WriteError(w, r, httperr.Internal("Failed to check access"))
+		return false
+	}
+	if !allowed {
+		httperr.WriteError(w, r, httperr.New(http.StatusForbidden, httperr.CodeForbidden, "No access to this match"))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,122 @@
+package controllers_test
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"nivai/backend/pkg/controllers"
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/pythonapi"
+	"nivai/backend/pkg/requestctx"
+	"nivai/backend/pkg/services"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccessController(t *testing.T) {
+	videoRepo := models.NewMemoryVideoRepository()
+	require.NoError(t, videoRepo.Create(&models.Video{ID: "m1", Title: "Ajax - PSV", HomeTeam: "Ajax", AwayTeam: "PSV"}))
+	require.NoError(t, videoRepo.Create(&models.Video{ID: "m2", Title: "AZ - Feyenoord", HomeTeam: "AZ", AwayTeam: "Feyenoord"}))
+	access := services.NewAccessService(models.NewMemoryAccessGrantRepository(), videoRepo,
+		services.AccessConfig{Enforce: true, UnrestrictedRoles: []string{"admin"}})
+	ac := controllers.NewAccessController(access)
+	vc := controllers.NewVideoController(services.NewVideoService(videoRepo, services.NewMemoryStorageService()), nil, nil, nil,
+		controllers.WithVideoAccessControl(access))
+
+	router := mux.NewRouter()
+	router.HandleFunc("/access-grants", ac.ListGrants).Methods("GET")
+	router.HandleFunc("/access-grants", ac.CreateGrant).Methods("POST")
+	router.HandleFunc("/access-grants/{id}", ac.RevokeGrant).Methods("DELETE")
+	router.HandleFunc("/videos", vc.ListVideos).Methods("GET")
+	serve := func(method, target, body string, principal requestctx.Principal) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req = req.WithContext(requestctx.NewContext(req.Context(), &requestctx.Info{Principal: principal, Logger: log.Default()}))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+	admin := requestctx.Principal{UserID: "root", Role: "admin"}
+	analyst := requestctx.Principal{UserID: "u1", Role: "user"}
+	listedVideos := func(principal requestctx.Principal) []string {
+		rr := serve("GET", "/videos", "", principal)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		var videos []models.Video
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &videos))
+		var ids []string
+		for _, video := range videos {
+			ids = append(ids, video.ID)
+		}
+		return ids
+	}
+
+	assert.Empty(t, listedVideos(analyst), "users without grants see no matches")
+	assert.Len(t, listedVideos(admin), 2)
+
+	rr := serve("POST", "/access-grants", `{"subject_type":"user","subject_id":"u1","resource_type":"team","resource_id":"PSV"}`, admin)
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	var grant models.AccessGrant
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &grant))
+	assert.Equal(t, "view", grant.Permission)
+	assert.Equal(t, "root", grant.GrantedBy)
+	assert.Equal(t, []string{"m1"}, listedVideos(analyst))
+
+	rr = serve("GET", "/access-grants?subject_type=user&subject_id=u1", "", admin)
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), grant.ID)
+	rr = serve("GET", "/access-grants?resource_type=team&resource_id=psv", "", admin)
+	assert.Contains(t, rr.Body.String(), grant.ID, "team names match case-insensitively")
+
+	assert.Equal(t, http.StatusBadRequest, serve("POST", "/access-grants", `{"subject_type":"user","subject_id":"u1","resource_type":"season","resource_id":"2024"}`, admin).Code)
+	assert.Equal(t, http.StatusNotFound, serve("POST", "/access-grants", `{"subject_type":"user","subject_id":"u1","resource_type":"match","resource_id":"m9"}`, admin).Code)
+
+	assert.Equal(t, http.StatusNoContent, serve("DELETE", "/access-grants/"+grant.ID, "", admin).Code)
+	assert.Equal(t, http.StatusNotFound, serve("DELETE", "/access-grants/"+grant.ID, "", admin).Code)
+	assert.Empty(t, listedVideos(analyst))
+}
+
+func TestMatchResourceAccess(t *testing.T) {
+	mockApi := httptest.NewServer(http.NotFoundHandler())
+	defer mockApi.Close()
+
+	videoRepo := models.NewMemoryVideoRepository()
+	require.NoError(t, videoRepo.Create(&models.Video{ID: "m1", Title: "Ajax - PSV", HomeTeam: "Ajax", AwayTeam: "PSV"}))
+	grants := models.NewMemoryAccessGrantRepository()
+	require.NoError(t, grants.Save(&models.AccessGrant{ID: "g1", SubjectType: models.AccessSubjectUser, SubjectID: "scout",
+		ResourceType: models.AccessResourceTeam, ResourceID: "PSV", Permission: models.AccessPermissionView}))
+	access := services.NewAccessService(grants, videoRepo, services.AccessConfig{Enforce: true})
+
+	reports := services.NewReportService(services.NewVideoService(videoRepo, services.NewMemoryStorageService()),
+		pythonapi.NewClient(mockApi.URL, mockApi.Client()))
+	report, err := reports.Start("m1")
+	require.NoError(t, err)
+	jobs := models.NewMemoryArchiveJobRepository()
+	require.NoError(t, jobs.Create(&models.ArchiveJob{ID: "j1", VideoID: "m1", Operation: models.ArchiveOperationRestore, Status: "running"}))
+	rc := controllers.NewReportController(reports, access)
+	ac := controllers.NewArchiveController(services.NewArchiveService(videoRepo, jobs, nil, time.Hour), access)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/reports/{id}", rc.GetReport).Methods("GET")
+	router.HandleFunc("/reports/{id}/download", rc.DownloadReport).Methods("GET")
+	router.HandleFunc("/archive-jobs/{id}", ac.GetArchiveJob).Methods("GET")
+	serve := func(target, user string) int {
+		req := httptest.NewRequest("GET", target, nil)
+		principal := requestctx.Principal{UserID: user, Role: "user"}
+		req = req.WithContext(requestctx.NewContext(req.Context(), &requestctx.Info{Principal: principal, Logger: log.Default()}))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	for _, target := range []string{"/reports/" + report.ID, "/reports/" + report.ID + "/download", "/archive-jobs/j1"} {
+		assert.Equal(t, http.StatusForbidden, serve(target, "stranger"), "%s is refused to callers without access to its match", target)
+		assert.NotEqual(t, http.StatusForbidden, serve(target, "scout"), "%s is shown to callers with access to its match", target)
+	}
+	assert.Equal(t, http.StatusNotFound, serve("/reports/missing", "stranger"))
+}
@@ -0,0 +1,157 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"nivai/backend/pkg/httperr"
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/requestctx"
+	"nivai/backend/pkg/services"
+
+	"github.com/gorilla/mux"
+)
+
+// VideoState is the processing state of a match as admins inspect it: the
+// state, where the state machine may take it next, its file paths and the
+// history of its state changes.
+type VideoState struct {
+	VideoID            string                    `json:"video_id"`
+	ProcessingState    models.ProcessingState    `json:"processing_state"`
+	AllowedTransitions []models.ProcessingState  `json:"allowed_transitions"`
+	FilePath           string                    `json:"file_path"`
+	TrackingPath       string                    `json:"tracking_path"`
+	EventFilePath      string                    `json:"event_file_path"`
+	UpdatedAt          time.Time                 `json:"updated_at"`
+	History            []*models.StateTransition `json:"history"`
+}
+
+// stateOverrideRequest is the body of EditVideoState. Omitted fields are
+// kept.
+type stateOverrideRequest struct {
+	State         models.ProcessingState `json:"state"`
+	Reason        string                 `json:"reason"`
+	FilePath      *string                `json:"file_path"`
+	TrackingPath  *string                `json:"tracking_path"`
+	EventFilePath *string                `json:"event_file_path"`
+}
+
+// GetVideoState handles GET /api/v1/admin/videos/{id}/state.
+func (vc *VideoController) GetVideoState(w http.ResponseWriter, r *http.Request) {
+	video, ok := vc.processingTarget(w, r)
+	if !ok {
+		return
+	}
+	vc.writeVideoState(w, r, video)
+}
+
+// EditVideoState handles PATCH /api/v1/admin/videos/{id}/state.
+// An admin forces the processing state, bypassing the state machine, and
+// re-points file paths to files already in storage, for recovering from
+// operational incidents. A reason is required and recorded in the state
+// history. Nothing starts processing; reprocess the match for that.
+func (vc *VideoController) EditVideoState(w http.ResponseWriter, r *http.Request) {
+	vc.withProcessingLock(w, r, vc.editVideoState)
+}
+
+// editVideoState overrides the state, holding the match's processing lock.
+func (vc *VideoController) editVideoState(w http.ResponseWriter, r *http.Request) {
+	info := requestctx.From(r)
+	id := mux.Vars(r)["id"]
+
+	var req stateOverrideRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		httperr.WriteError(w, r, httperr.BadRequest("Invalid request payload"))
+		return
+	}
+	if req.Reason == "" {
+		httperr.WriteError(w, r, httperr.BadRequest("reason is required"))
+		return
+	}
+	if req.State == "" && req.FilePath == nil && req.TrackingPath == nil && req.EventFilePath == nil {
+		httperr.WriteError(w, r, httperr.BadRequest("Nothing to change: give a state or file paths"))
+		return
+	}
+	if req.State != "" && !req.State.Valid() {
+		httperr.WriteError(w, r, httperr.BadRequest(fmt.Sprintf("Unknown processing state %q", req.State)))
+		return
+	}
+	previous, ok := vc.processingTarget(w, r)
+	if !ok {
+		return
+	}
+
+	// Paths may only point at stored files; an empty path clears it
+	for _, path := range []struct {
+		field string
+		value *string
+	}{{"file_path", req.FilePath}, {"tracking_path", req.TrackingPath}, {"event_file_path", req.EventFilePath}} {
+		if path.value == nil || *path.value == "" || vc.storageService == nil {
+			continue
+		}
+		exists, err := vc.storageService.Exists(*path.value)
+		if err != nil {
+			info.Logger.Printf("Error checking %s %s of video %s: %v", path.field, *path.value, id, err)
+			httperr.WriteError(w, r, httperr.Internal("Failed to retrieve file"))
+			return
+		}
+		if !exists {
+			httperr.WriteError(w, r, httperr.BadRequest(fmt.Sprintf("%s %s does not exist in storage", path.field, *path.value)))
+			return
+		}
+	}
+
+	override := services.StateOverride{State: req.State, FilePath: req.FilePath, TrackingPath: req.TrackingPath, EventFilePath: req.EventFilePath}
+	video, err := vc.videoService.OverrideState(id, override, services.StateChange{Actor: info.Principal.UserID, Reason: req.Reason})
+	if err != nil {
+		if errors.Is(err, services.ErrVideoNotFound) {
+			httperr.WriteError(w, r, httperr.NotFound("Match not found"))
+			return
+		}
+		if errors.Is(err, models.ErrStateConflict) {
+			httperr.WriteError(w, r, httperr.Conflict("Match processing state changed; try again"))
+			return
+		}
+		info.Logger.Printf("Error overriding processing state of video %s: %v", id, err)
+		httperr.WriteError(w, r, httperr.Internal("Failed to update processing state"))
+		return
+	}
+	log.Printf("Audit: processing state of video %s overridden by user %q from %s to %s: %s",
+		id, info.Principal.UserID, previous.ProcessingState, video.ProcessingState, req.Reason)
+
+	vc.writeVideoState(w, r, video)
+}
+
+// writeVideoState answers with the state of a match and its history.
+func (vc *VideoController) writeVideoState(w http.ResponseWriter, r *http.Request, video *models.Video) {
+	history, err := vc.videoService.GetStateHistory(video.ID)
+	if err != nil {
+		requestctx.From(r).Logger.Printf("Error retrieving state history of video %s: %v", video.ID, err)
+		httperr.WriteError(w, r, httperr.Internal("Failed to retrieve video history"))
+		return
+	}
+	if history == nil {
+		history = []*models.StateTransition{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(VideoState{
+		VideoID:            video.ID,
+		ProcessingState:    video.ProcessingState,
+		AllowedTransitions: video.ProcessingState.Transitions(),
+		FilePath:           video.FilePath,
+		TrackingPath:       video.TrackingPath,
+		EventFilePath:      video.EventFilePath,
+		UpdatedAt:          video.UpdatedAt,
+		History:            history,
+	})
+	if err != nil {
+		log.Printf("Error encoding video state: %v", err)
+	}
+}
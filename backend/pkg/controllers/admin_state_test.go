@@ -0,0 +1,90 @@
+package controllers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"nivai/backend/pkg/controllers"
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/pythonapi"
+	"nivai/backend/pkg/services"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVideoState(t *testing.T) {
+	setup := func(t *testing.T) (*mux.Router, *models.MemoryVideoRepository) {
+		storage := services.NewMemoryStorageService()
+		_, err := storage.UploadStream(strings.NewReader("frame,x,y\n"), "restored/v1/tracking.gzip")
+		require.NoError(t, err)
+		videoRepo := models.NewMemoryVideoRepository()
+		require.NoError(t, videoRepo.Create(&models.Video{ID: "v1", ProcessingState: models.StateProcessing,
+			TrackingPath: "videos/v1/tracking.gzip", EventFilePath: "videos/v1/events.gzip"}))
+		videoService := services.NewVideoService(videoRepo, storage, services.WithStateHistory(models.NewMemoryStateHistoryRepository()))
+		vc := controllers.NewVideoController(videoService, storage, pythonapi.NewClient("", nil), nil)
+
+		router := mux.NewRouter()
+		router.HandleFunc("/admin/videos/{id}/state", vc.GetVideoState).Methods("GET")
+		router.HandleFunc("/admin/videos/{id}/state", vc.EditVideoState).Methods("PATCH")
+		return router, videoRepo
+	}
+	serve := func(router *mux.Router, method, body string) (*httptest.ResponseRecorder, controllers.VideoState) {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(method, "/admin/videos/v1/state", strings.NewReader(body)))
+		var state controllers.VideoState
+		_ = json.Unmarshal(rr.Body.Bytes(), &state)
+		return rr, state
+	}
+
+	t.Run("Admins inspect the state and where it may go", func(t *testing.T) {
+		router, _ := setup(t)
+		rr, state := serve(router, "GET", "")
+
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		assert.Equal(t, models.StateProcessing, state.ProcessingState)
+		assert.Equal(t, []models.ProcessingState{models.StateCompleted, models.StateFailed, models.StateCancelled}, state.AllowedTransitions)
+		assert.Equal(t, "videos/v1/tracking.gzip", state.TrackingPath)
+		assert.Empty(t, state.History)
+	})
+
+	t.Run("Admins force the state and re-point stored files", func(t *testing.T) {
+		router, videoRepo := setup(t)
+		rr, state := serve(router, "PATCH", `{"state":"pending","reason":"INC-42: stuck after worker crash","tracking_path":"restored/v1/tracking.gzip"}`)
+
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		assert.Equal(t, models.StatePending, state.ProcessingState, "the state machine does not allow processing to pending")
+		require.Len(t, state.History, 1)
+		assert.Contains(t, state.History[0].Reason, "INC-42: stuck after worker crash")
+		video, err := videoRepo.FindByID("v1")
+		require.NoError(t, err)
+		assert.Equal(t, models.StatePending, video.ProcessingState)
+		assert.Equal(t, "restored/v1/tracking.gzip", video.TrackingPath)
+	})
+
+	t.Run("Invalid overrides are refused", func(t *testing.T) {
+		router, videoRepo := setup(t)
+		for body, message := range map[string]string{
+			`{"state":"failed"}`:                              "reason is required",
+			`{"reason":"x"}`:                                  "Nothing to change",
+			`{"state":"done","reason":"x"}`:                   `Unknown processing state \"done\"`,
+			`{"tracking_path":"videos/missing","reason":"x"}`: "tracking_path videos/missing does not exist in storage",
+			`{"state":"failed","reason":"x","force":true}`:    "Invalid request payload",
+		} {
+			rr, _ := serve(router, "PATCH", body)
+			assert.Equal(t, http.StatusBadRequest, rr.Code, body)
+			assert.Contains(t, rr.Body.String(), message, body)
+		}
+		video, err := videoRepo.FindByID("v1")
+		require.NoError(t, err)
+		assert.Equal(t, models.StateProcessing, video.ProcessingState)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("PATCH", "/admin/videos/missing/state", strings.NewReader(`{"state":"failed","reason":"x"}`)))
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
@@ -1,74 +1,137 @@
 package controllers
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"log"
 	"net/http"
-	"os"
-	"time"
+	"strings"
+
+	"nivai/backend/pkg/export"
+	"nivai/backend/pkg/httperr"
+	"nivai/backend/pkg/pythonapi"
+	"nivai/backend/pkg/requestctx"
+	"nivai/backend/pkg/services"
 
 	"github.com/gorilla/mux"
 )
 
 // AnalyticsController handles requests for analytics data.
 type AnalyticsController struct {
-	PythonApiBaseUrl string
-	HttpClient       *http.Client
+	analytics  services.AnalyticsReader
+	identities *services.PlayerIdentityService
+	relay      PlayerDetailsRelay
 }
 
-// NewAnalyticsController creates a new AnalyticsController.
-// If pythonApiBaseUrl is empty, it tries to get it from PYTHON_API_URL env var,
-// then defaults to "http://localhost:8081".
-// If client is nil, a default client with a 10-second timeout is used.
-func NewAnalyticsController(pythonApiBaseUrl string, client *http.Client) *AnalyticsController {
-	if pythonApiBaseUrl == "" {
-		envURL := os.Getenv("PYTHON_API_URL")
-		if envURL != "" {
-			pythonApiBaseUrl = envURL
-		} else {
-			pythonApiBaseUrl = "http://localhost:8081" // Default
-		}
-		log.Println("AnalyticsController: Using Python API URL:", pythonApiBaseUrl)
-	}
-	if client == nil {
-		client = &http.Client{Timeout: time.Second * 10}
+// PlayerDetailsRelay streams a player's details from the analytics service
+// to the client without decoding them, e.g. the Python API client.
+type PlayerDetailsRelay interface {
+	RelayPlayerDetails(ctx context.Context, w http.ResponseWriter, matchID, playerID string) error
+}
+
+// AnalyticsControllerOption configures optional behaviour of the AnalyticsController.
+type AnalyticsControllerOption func(*AnalyticsController)
+
+// WithPlayerIdentities lets player analytics be requested by roster player
+// ID, which is mapped to the player's ID in the match's tracking data.
+func WithPlayerIdentities(identities *services.PlayerIdentityService) AnalyticsControllerOption {
+	return func(ac *AnalyticsController) {
+		ac.identities = identities
 	}
-	return &AnalyticsController{
-		PythonApiBaseUrl: pythonApiBaseUrl,
-		HttpClient:       client,
+}
+
+// WithPlayerDetailsRelay streams player analytics, per-frame series too
+// large to buffer, from the analytics service instead of reading them
+// through the analytics reader. They are then not cached, validated or
+// given an ETag.
+func WithPlayerDetailsRelay(relay PlayerDetailsRelay) AnalyticsControllerOption {
+	return func(ac *AnalyticsController) {
+		ac.relay = relay
 	}
 }
 
-// relayRequest is a helper method to relay requests to the Python API.
-func (ac *AnalyticsController) relayRequest(w http.ResponseWriter, r *http.Request, targetUrl string, handlerName string) {
-	log.Printf("[%s] Relaying request to: %s", handlerName, targetUrl)
+// NewAnalyticsController creates a new AnalyticsController backed by the
+// given analytics reader: the Python API client or a cache in front of it.
+func NewAnalyticsController(analytics services.AnalyticsReader, opts ...AnalyticsControllerOption) *AnalyticsController {
+	ac := &AnalyticsController{analytics: analytics}
+	for _, opt := range opts {
+		opt(ac)
+	}
+	return ac
+}
 
-	resp, err := ac.HttpClient.Get(targetUrl)
+// writeAnalyticsResponse encodes a Python API result, or maps its error to an
+// HTTP response: upstream errors keep their status and detail, transport
+// failures become 502 Bad Gateway. Results carry an ETag, and a matching
+// If-None-Match yields 304 Not Modified.
+func writeAnalyticsResponse(w http.ResponseWriter, r *http.Request, handlerName string, result interface{}, err error) {
+	logger := requestctx.From(r).Logger
 	if err != nil {
-		log.Printf("[%s] Error making GET request to Python API (%s): %v", handlerName, targetUrl, err)
-		http.Error(w, fmt.Sprintf("Error connecting to analytics service: %v", err), http.StatusBadGateway)
+		var apiErr *pythonapi.APIError
+		switch {
+		case errors.As(err, &apiErr):
+			logger.Printf("[%s] Python API returned status %d: %s", handlerName, apiErr.StatusCode, apiErr.Detail)
+			httperr.WriteError(w, r, httperr.FromStatus(apiErr.StatusCode, apiErr.Detail))
+		case errors.Is(err, pythonapi.ErrUnavailable):
+			logger.Printf("[%s] Error connecting to Python API: %v", handlerName, err)
+			httperr.WriteError(w, r, httperr.New(http.StatusBadGateway, httperr.CodeUpstream, fmt.Sprintf("Error connecting to analytics service: %v", err)))
+		default:
+			logger.Printf("[%s] Error reading response from Python API: %v", handlerName, err)
+			httperr.WriteError(w, r, httperr.New(http.StatusBadGateway, httperr.CodeUpstream, "Error reading response from analytics service"))
+		}
 		return
 	}
-	defer resp.Body.Close()
 
-	bodyBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		log.Printf("[%s] Error reading response body from Python API (%s): %v", handlerName, targetUrl, err)
-		http.Error(w, "Error reading response from analytics service", http.StatusInternalServerError)
+	body, encErr := json.Marshal(result)
+	if encErr != nil {
+		logger.Printf("[%s] Error encoding response: %v", handlerName, encErr)
+		httperr.WriteError(w, r, httperr.Internal("Error encoding response"))
 		return
 	}
+	body = append(body, '\n')
 
-	// Relay headers, status code, and body
-	w.Header().Set("Content-Type", "application/json") // Assuming Python API always returns JSON
-	// Potentially copy more headers from resp.Header if needed
-	w.WriteHeader(resp.StatusCode)
-	_, writeErr := w.Write(bodyBytes)
-	if writeErr != nil {
-		log.Printf("[%s] Error writing response to client: %v", handlerName, writeErr)
+	// Clients may keep the result but must revalidate; unchanged analytics
+	// then cost a 304 instead of the full payload.
+	etag := computeETag(body)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "private, no-cache")
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if _, writeErr := w.Write(body); writeErr != nil {
+		logger.Printf("[%s] Error writing response to client: %v", handlerName, writeErr)
 	}
 }
 
+// computeETag returns a strong ETag for a response body.
+func computeETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// etagMatches reports whether an If-None-Match header matches etag, using
+// the weak comparison RFC 9110 prescribes for If-None-Match.
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
 // GetMatchAnalytics handles requests for match analytics.
 // Path: /analytics/match/{id}
 func (ac *AnalyticsController) GetMatchAnalytics(w http.ResponseWriter, r *http.Request) {
@@ -76,34 +139,58 @@ func (ac *AnalyticsController) GetMatchAnalytics(w http.ResponseWriter, r *http.
 	matchID, ok := vars["id"]
 	if !ok {
 		log.Println("[GetMatchAnalytics] Error: match_id not found in path variables")
-		http.Error(w, "Match ID is required in path", http.StatusBadRequest)
+		httperr.WriteError(w, r, httperr.BadRequest("Match ID is required in path"))
 		return
 	}
 
-	targetUrl := fmt.Sprintf("%s/match/%s/stats/summary", ac.PythonApiBaseUrl, matchID)
-	ac.relayRequest(w, r, targetUrl, "GetMatchAnalytics")
+	summary, err := ac.analytics.GetMatchSummary(r.Context(), matchID)
+	writeAnalyticsResponse(w, r, "GetMatchAnalytics", summary, err)
 }
 
 // GetPlayerAnalytics handles requests for player analytics.
 // Path: /analytics/player/{id}?match_id=<match_id_value>
+// The ID is a roster player mapped in the match, or a tracking player ID.
 func (ac *AnalyticsController) GetPlayerAnalytics(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	playerID, ok := vars["id"]
 	if !ok {
 		log.Println("[GetPlayerAnalytics] Error: player_id not found in path variables")
-		http.Error(w, "Player ID is required in path", http.StatusBadRequest)
+		httperr.WriteError(w, r, httperr.BadRequest("Player ID is required in path"))
 		return
 	}
 
 	matchID := r.URL.Query().Get("match_id")
 	if matchID == "" {
 		log.Println("[GetPlayerAnalytics] Error: match_id query parameter is required")
-		http.Error(w, "match_id query parameter is required", http.StatusBadRequest)
+		httperr.WriteError(w, r, httperr.BadRequest("match_id query parameter is required"))
 		return
 	}
 
-	targetUrl := fmt.Sprintf("%s/match/%s/player/%s/details", ac.PythonApiBaseUrl, matchID, playerID)
-	ac.relayRequest(w, r, targetUrl, "GetPlayerAnalytics")
+	if ac.identities != nil {
+		trackingID, err := ac.identities.TrackingPlayerID(matchID, playerID)
+		if err != nil {
+			log.Printf("[GetPlayerAnalytics] Error resolving player %s in match %s: %v", playerID, matchID, err)
+			httperr.WriteError(w, r, httperr.Internal("Failed to resolve player"))
+			return
+		}
+		playerID = trackingID
+	}
+
+	if ac.relay != nil {
+		w.Header().Set("Cache-Control", "private, no-cache")
+		err := ac.relay.RelayPlayerDetails(r.Context(), w, matchID, playerID)
+		if errors.Is(err, pythonapi.ErrRelayInterrupted) {
+			requestctx.From(r).Logger.Printf("[GetPlayerAnalytics] Relay from Python API interrupted: %v", err)
+			panic(http.ErrAbortHandler) // Reset the connection, so the cut body is not taken for the whole one
+		}
+		if err != nil {
+			writeAnalyticsResponse(w, r, "GetPlayerAnalytics", nil, err)
+		}
+		return
+	}
+
+	details, err := ac.analytics.GetPlayerDetails(r.Context(), matchID, playerID)
+	writeAnalyticsResponse(w, r, "GetPlayerAnalytics", details, err)
 }
 
 // GetTeamAnalytics handles requests for team analytics.
@@ -113,17 +200,98 @@ func (ac *AnalyticsController) GetTeamAnalytics(w http.ResponseWriter, r *http.R
 	teamID, ok := vars["id"]
 	if !ok {
 		log.Println("[GetTeamAnalytics] Error: team_id not found in path variables")
-		http.Error(w, "Team ID is required in path", http.StatusBadRequest)
+		httperr.WriteError(w, r, httperr.BadRequest("Team ID is required in path"))
 		return
 	}
 
 	matchID := r.URL.Query().Get("match_id")
 	if matchID == "" {
 		log.Println("[GetTeamAnalytics] Error: match_id query parameter is required")
-		http.Error(w, "match_id query parameter is required", http.StatusBadRequest)
+		httperr.WriteError(w, r, httperr.BadRequest("match_id query parameter is required"))
+		return
+	}
+
+	summary, err := ac.analytics.GetTeamSummaryOverTime(r.Context(), matchID, teamID)
+	writeAnalyticsResponse(w, r, "GetTeamAnalytics", summary, err)
+}
+
+// ExportMatchAnalytics serves a match summary as a spreadsheet download.
+// Path: /analytics/matches/{id}/export?format=csv|xlsx[&table=players|teams]
+// XLSX workbooks hold a Players and a Teams sheet; CSV holds one table,
+// players unless table=teams.
+func (ac *AnalyticsController) ExportMatchAnalytics(w http.ResponseWriter, r *http.Request) {
+	matchID := mux.Vars(r)["id"]
+	if matchID == "" {
+		httperr.WriteError(w, r, httperr.BadRequest("Match ID is required in path"))
+		return
+	}
+
+	query := r.URL.Query()
+	format := strings.ToLower(query.Get("format"))
+	if format == "" {
+		format = export.FormatCSV
+	}
+	contentType, ok := export.ContentTypes[format]
+	if !ok {
+		httperr.WriteError(w, r, httperr.BadRequest("format must be csv or xlsx"))
+		return
+	}
+	table := strings.ToLower(query.Get("table"))
+	if table == "" {
+		table = "players"
+	}
+	if table != "players" && table != "teams" {
+		httperr.WriteError(w, r, httperr.BadRequest("table must be players or teams"))
 		return
 	}
 
-	targetUrl := fmt.Sprintf("%s/match/%s/team/%s/summary-over-time", ac.PythonApiBaseUrl, matchID, teamID)
-	ac.relayRequest(w, r, targetUrl, "GetTeamAnalytics")
+	summary, err := ac.analytics.GetMatchSummary(r.Context(), matchID)
+	if err != nil {
+		writeAnalyticsResponse(w, r, "ExportMatchAnalytics", nil, err)
+		return
+	}
+	players, err := export.FromEntities("Players", "player_id", summary.Players)
+	if err != nil {
+		writeAnalyticsResponse(w, r, "ExportMatchAnalytics", nil, fmt.Errorf("%w: %v", pythonapi.ErrInvalidResponse, err))
+		return
+	}
+	teams, err := export.FromEntities("Teams", "team_id", summary.Teams)
+	if err != nil {
+		writeAnalyticsResponse(w, r, "ExportMatchAnalytics", nil, fmt.Errorf("%w: %v", pythonapi.ErrInvalidResponse, err))
+		return
+	}
+
+	filename := "match-" + safeFilename(matchID)
+	if format == export.FormatCSV {
+		filename += "-" + table
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, filename, format))
+	w.Header().Set("Cache-Control", "private, no-cache")
+
+	// The status line is committed with the first bytes, so errors from here
+	// on can only be logged.
+	switch {
+	case format == export.FormatXLSX:
+		err = export.WriteXLSX(w, players, teams)
+	case table == "teams":
+		err = export.WriteCSV(w, teams)
+	default:
+		err = export.WriteCSV(w, players)
+	}
+	if err != nil {
+		requestctx.From(r).Logger.Printf("[ExportMatchAnalytics] Error writing %s export for match %s: %v", format, matchID, err)
+	}
+}
+
+// safeFilename keeps the characters of an ID that are safe in a download
+// filename and replaces the rest with underscores.
+func safeFilename(id string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		}
+		return '_'
+	}, id)
 }
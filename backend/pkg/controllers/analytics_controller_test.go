@@ -1,6 +1,7 @@
 package controllers_test
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -9,6 +10,8 @@ import (
 	"testing"
 
 	"nivai/backend/pkg/controllers" // Adjust import path
+	"nivai/backend/pkg/httperr"
+	"nivai/backend/pkg/pythonapi"
 	// Assuming the actual analytics_controller.go initializes its own pythonApiBaseUrl and netClient
 	// If not, and they are package level, this test might interfere or need to use those.
 	// The current analytics_controller.go uses an init() for its client, so tests will use that.
@@ -17,6 +20,7 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
 )
 
 // mockPythonApi serves as a mock Python API for analytics endpoints
@@ -41,11 +45,15 @@ func mockPythonApi(t *testing.T, expectedPathPrefix string, responseBody map[str
 func TestGetMatchAnalytics(t *testing.T) {
 	t.Run("Successful data relay", func(t *testing.T) {
 		matchID := "testmatch123"
-		expectedResponse := map[string]interface{}{"data": "match_summary_data", "id": matchID}
+		expectedResponse := map[string]interface{}{
+			"match_id": matchID,
+			"players":  map[string]interface{}{"p1": map[string]interface{}{"total_distance": 10250.5}},
+			"teams":    map[string]interface{}{"home": map[string]interface{}{"possession": 54.2}},
+		}
 		mockApi := mockPythonApi(t, fmt.Sprintf("/match/%s/stats/summary", matchID), expectedResponse, http.StatusOK)
 		defer mockApi.Close()
 
-		ac := controllers.NewAnalyticsController(mockApi.URL, mockApi.Client())
+		ac := controllers.NewAnalyticsController(pythonapi.NewClient(mockApi.URL, mockApi.Client()))
 		router := mux.NewRouter()
 		router.HandleFunc("/api/v1/analytics/matches/{id}", ac.GetMatchAnalytics).Methods("GET")
 
@@ -62,13 +70,45 @@ func TestGetMatchAnalytics(t *testing.T) {
 		assert.Equal(t, expectedResponse, actualResponse)
 	})
 
+	t.Run("ETag revalidation returns 304", func(t *testing.T) {
+		matchID := "etagmatch"
+		expectedResponse := map[string]interface{}{"match_id": matchID, "players": map[string]interface{}{}, "teams": map[string]interface{}{}}
+		mockApi := mockPythonApi(t, fmt.Sprintf("/match/%s/stats/summary", matchID), expectedResponse, http.StatusOK)
+		defer mockApi.Close()
+
+		ac := controllers.NewAnalyticsController(pythonapi.NewClient(mockApi.URL, mockApi.Client()))
+		router := mux.NewRouter()
+		router.HandleFunc("/api/v1/analytics/matches/{id}", ac.GetMatchAnalytics).Methods("GET")
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/analytics/matches/"+matchID, nil))
+		require.Equal(t, http.StatusOK, rr.Code)
+		etag := rr.Header().Get("ETag")
+		require.NotEmpty(t, etag)
+		assert.Equal(t, "private, no-cache", rr.Header().Get("Cache-Control"))
+
+		req := httptest.NewRequest("GET", "/api/v1/analytics/matches/"+matchID, nil)
+		req.Header.Set("If-None-Match", `"other", W/`+etag)
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusNotModified, rr.Code)
+		assert.Empty(t, rr.Body.String())
+		assert.Equal(t, etag, rr.Header().Get("ETag"))
+
+		req = httptest.NewRequest("GET", "/api/v1/analytics/matches/"+matchID, nil)
+		req.Header.Set("If-None-Match", `"stale"`)
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
 	t.Run("Python API returns 404", func(t *testing.T) {
 		matchID := "notfoundmatch"
 		errorResponse := map[string]interface{}{"detail": "match not found in python api"}
 		mockApi := mockPythonApi(t, fmt.Sprintf("/match/%s/stats/summary", matchID), errorResponse, http.StatusNotFound)
 		defer mockApi.Close()
 
-		ac := controllers.NewAnalyticsController(mockApi.URL, mockApi.Client())
+		ac := controllers.NewAnalyticsController(pythonapi.NewClient(mockApi.URL, mockApi.Client()))
 		router := mux.NewRouter()
 		router.HandleFunc("/api/v1/analytics/matches/{id}", ac.GetMatchAnalytics).Methods("GET")
 
@@ -77,10 +117,11 @@ func TestGetMatchAnalytics(t *testing.T) {
 		router.ServeHTTP(rr, req)
 
 		assert.Equal(t, http.StatusNotFound, rr.Code) // Should relay the 404
-		var actualResponse map[string]interface{}
+		var actualResponse httperr.Error
 		err := json.NewDecoder(rr.Body).Decode(&actualResponse)
 		require.NoError(t, err)
-		assert.Equal(t, errorResponse, actualResponse)
+		assert.Equal(t, httperr.CodeNotFound, actualResponse.Code)
+		assert.Equal(t, errorResponse["detail"], actualResponse.Message)
 	})
 
 	t.Run("Python API unavailable", func(t *testing.T) {
@@ -89,7 +130,7 @@ func TestGetMatchAnalytics(t *testing.T) {
 		mockApi := mockPythonApi(t, "", nil, http.StatusOK)
 		mockApi.Close() // Simulate server down
 
-		ac := controllers.NewAnalyticsController(mockApi.URL, nil) // Use nil client, it should default
+		ac := controllers.NewAnalyticsController(pythonapi.NewClient(mockApi.URL, nil)) // Use nil client, it should default
 		// For this specific test, we can use a local router or call the method directly if no mux vars are needed by the handler itself
 		// Given GetMatchAnalytics uses mux.Vars, a router is needed.
 		localRouter := mux.NewRouter()
@@ -100,7 +141,7 @@ func TestGetMatchAnalytics(t *testing.T) {
 		localRouter.ServeHTTP(rr, req)
 
 		assert.Equal(t, http.StatusBadGateway, rr.Code)
-		// Check for plain text error message
+		// The error response names the cause
 		responseBody := rr.Body.String()
 		assert.Contains(t, responseBody, "Error connecting to analytics service")
 	})
@@ -108,7 +149,7 @@ func TestGetMatchAnalytics(t *testing.T) {
 	t.Run("Missing match_id in path", func(t *testing.T) {
 		// This test primarily tests mux routing.
 		// We need an AnalyticsController instance to register its methods.
-		ac := controllers.NewAnalyticsController("", nil) // URL/client don't matter as it shouldn't be called
+		ac := controllers.NewAnalyticsController(pythonapi.NewClient("", nil)) // URL/client don't matter as it shouldn't be called
 		testRouter := mux.NewRouter()
 		testRouter.HandleFunc("/api/v1/analytics/matches/{id}", ac.GetMatchAnalytics).Methods("GET")
 
@@ -127,7 +168,11 @@ func TestGetPlayerAnalytics(t *testing.T) {
 		playerID := "player1"
 		matchID := "match1"
 		expectedPath := fmt.Sprintf("/match/%s/player/%s/details", matchID, playerID)
-		expectedResponse := map[string]interface{}{"data": "player_details", "player_id": playerID}
+		expectedResponse := map[string]interface{}{
+			"match_id":    matchID,
+			"player_id":   playerID,
+			"time_series": []interface{}{map[string]interface{}{"minute": 1.0, "speed": 5.2}},
+		}
 
 		mockApi := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			assert.Equal(t, expectedPath, r.URL.Path)
@@ -138,7 +183,7 @@ func TestGetPlayerAnalytics(t *testing.T) {
 		}))
 		defer mockApi.Close()
 
-		ac := controllers.NewAnalyticsController(mockApi.URL, mockApi.Client())
+		ac := controllers.NewAnalyticsController(pythonapi.NewClient(mockApi.URL, mockApi.Client()))
 		router := mux.NewRouter()
 		// The actual route is /api/v1/analytics/players/{id} but mux expects path variables in handler registration
 		router.HandleFunc("/analytics/players/{id}", ac.GetPlayerAnalytics).Methods("GET")
@@ -158,7 +203,7 @@ func TestGetPlayerAnalytics(t *testing.T) {
 	t.Run("Missing match_id query for player", func(t *testing.T) {
 		playerID := "player1"
 		// No mock API needed as it should fail before calling it.
-		ac := controllers.NewAnalyticsController("", nil) // URL/client don't matter
+		ac := controllers.NewAnalyticsController(pythonapi.NewClient("", nil)) // URL/client don't matter
 		router := mux.NewRouter()
 		router.HandleFunc("/analytics/players/{id}", ac.GetPlayerAnalytics).Methods("GET")
 
@@ -170,6 +215,58 @@ func TestGetPlayerAnalytics(t *testing.T) {
 		assert.Equal(t, http.StatusBadRequest, rr.Code)
 		assert.Contains(t, rr.Body.String(), "match_id query parameter is required")
 	})
+
+	t.Run("Relayed player data is streamed as it is", func(t *testing.T) {
+		body := `{"match_id":"match1","player_id":"player1","time_series":[{"minute":1,"speed":5.2}]}`
+		mockApi := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if strings.Contains(r.URL.Path, "/player/missing/") {
+				http.Error(w, `{"detail":"Player ID missing not found in this match."}`, http.StatusNotFound)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(body))
+		}))
+		defer mockApi.Close()
+
+		client := pythonapi.NewClient(mockApi.URL, mockApi.Client())
+		ac := controllers.NewAnalyticsController(client, controllers.WithPlayerDetailsRelay(client))
+		router := mux.NewRouter()
+		router.HandleFunc("/analytics/players/{id}", ac.GetPlayerAnalytics).Methods("GET")
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/analytics/players/player1?match_id=match1", nil))
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, body, rr.Body.String(), "the body is not decoded and encoded again")
+		assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+		assert.Equal(t, "private, no-cache", rr.Header().Get("Cache-Control"))
+
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/analytics/players/missing?match_id=match1", nil))
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		assert.Contains(t, rr.Body.String(), "not found in this match")
+	})
+
+	t.Run("Interrupted relays abort the response", func(t *testing.T) {
+		relay := relayFunc(func(w http.ResponseWriter) error {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"time_series":[`))
+			return fmt.Errorf("%w: response exceeds the limit", pythonapi.ErrRelayInterrupted)
+		})
+		ac := controllers.NewAnalyticsController(pythonapi.NewClient("", nil), controllers.WithPlayerDetailsRelay(relay))
+		router := mux.NewRouter()
+		router.HandleFunc("/analytics/players/{id}", ac.GetPlayerAnalytics).Methods("GET")
+
+		req := httptest.NewRequest("GET", "/analytics/players/player1?match_id=match1", nil)
+		assert.PanicsWithValue(t, http.ErrAbortHandler, func() { router.ServeHTTP(httptest.NewRecorder(), req) },
+			"the cut body must not end like a whole one")
+	})
+}
+
+// relayFunc is a PlayerDetailsRelay writing the response with a function
+type relayFunc func(w http.ResponseWriter) error
+
+func (f relayFunc) RelayPlayerDetails(_ context.Context, w http.ResponseWriter, _, _ string) error {
+	return f(w)
 }
 
 func TestGetTeamAnalytics(t *testing.T) {
@@ -177,7 +274,11 @@ func TestGetTeamAnalytics(t *testing.T) {
 		teamID := "teamA"
 		matchID := "match1"
 		expectedPath := fmt.Sprintf("/match/%s/team/%s/summary-over-time", matchID, teamID)
-		expectedResponse := map[string]interface{}{"data": "team_summary_over_time", "team_id": teamID}
+		expectedResponse := map[string]interface{}{
+			"match_id":  matchID,
+			"team_id":   teamID,
+			"intervals": []interface{}{map[string]interface{}{"interval": "0-5", "distance": 5400.0}},
+		}
 
 		mockApi := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			assert.Equal(t, expectedPath, r.URL.Path)
@@ -188,7 +289,7 @@ func TestGetTeamAnalytics(t *testing.T) {
 		}))
 		defer mockApi.Close()
 
-		ac := controllers.NewAnalyticsController(mockApi.URL, mockApi.Client())
+		ac := controllers.NewAnalyticsController(pythonapi.NewClient(mockApi.URL, mockApi.Client()))
 		router := mux.NewRouter()
 		router.HandleFunc("/analytics/teams/{id}", ac.GetTeamAnalytics).Methods("GET")
 
@@ -206,7 +307,7 @@ func TestGetTeamAnalytics(t *testing.T) {
 
 	t.Run("Missing match_id query for team", func(t *testing.T) {
 		teamID := "teamA"
-		ac := controllers.NewAnalyticsController("", nil) // URL/client don't matter
+		ac := controllers.NewAnalyticsController(pythonapi.NewClient("", nil)) // URL/client don't matter
 		router := mux.NewRouter()
 		router.HandleFunc("/analytics/teams/{id}", ac.GetTeamAnalytics).Methods("GET")
 
@@ -224,3 +325,73 @@ func TestGetTeamAnalytics(t *testing.T) {
 // makes the tests much cleaner and removes the dependency on t.Setenv
 // or global variable manipulation for setting the Python API URL and HTTP client.
 // The long comment block below discussing those older strategies can now be removed.
+
+func TestExportMatchAnalytics(t *testing.T) {
+	summary := map[string]interface{}{
+		"match_id": "m1",
+		"players": map[string]interface{}{
+			"p2": map[string]interface{}{"total_distance_m": 10250.5, "max_speed_kmh": 31.2},
+			"p1": map[string]interface{}{"total_distance_m": 9800},
+		},
+		"teams": map[string]interface{}{"home": map[string]interface{}{"possession": 54.2}},
+	}
+	newRouter := func(baseURL string, client *http.Client) *mux.Router {
+		ac := controllers.NewAnalyticsController(pythonapi.NewClient(baseURL, client))
+		router := mux.NewRouter()
+		router.HandleFunc("/api/v1/analytics/matches/{id}/export", ac.ExportMatchAnalytics).Methods("GET")
+		return router
+	}
+
+	t.Run("CSV download of the player table", func(t *testing.T) {
+		mockApi := mockPythonApi(t, "/match/m1/stats/summary", summary, http.StatusOK)
+		defer mockApi.Close()
+
+		rr := httptest.NewRecorder()
+		newRouter(mockApi.URL, mockApi.Client()).ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/analytics/matches/m1/export?format=csv", nil))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "text/csv; charset=utf-8", rr.Header().Get("Content-Type"))
+		assert.Equal(t, `attachment; filename="match-m1-players.csv"`, rr.Header().Get("Content-Disposition"))
+		assert.Equal(t, "player_id,max_speed_kmh,total_distance_m\np1,,9800\np2,31.2,10250.5\n", rr.Body.String())
+	})
+
+	t.Run("XLSX download with a sheet per table", func(t *testing.T) {
+		mockApi := mockPythonApi(t, "/match/m1/stats/summary", summary, http.StatusOK)
+		defer mockApi.Close()
+
+		rr := httptest.NewRecorder()
+		newRouter(mockApi.URL, mockApi.Client()).ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/analytics/matches/m1/export?format=xlsx", nil))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", rr.Header().Get("Content-Type"))
+		assert.Equal(t, `attachment; filename="match-m1.xlsx"`, rr.Header().Get("Content-Disposition"))
+
+		book, err := excelize.OpenReader(rr.Body)
+		require.NoError(t, err)
+		defer book.Close()
+		assert.Equal(t, []string{"Players", "Teams"}, book.GetSheetList())
+		rows, err := book.GetRows("Teams")
+		require.NoError(t, err)
+		assert.Equal(t, [][]string{{"team_id", "possession"}, {"home", "54.2"}}, rows)
+	})
+
+	t.Run("Upstream errors keep their status", func(t *testing.T) {
+		mockApi := mockPythonApi(t, "/match/m1/stats/summary", map[string]interface{}{"detail": "Match data not processed or match ID not found."}, http.StatusNotFound)
+		defer mockApi.Close()
+
+		rr := httptest.NewRecorder()
+		newRouter(mockApi.URL, mockApi.Client()).ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/analytics/matches/m1/export?format=csv", nil))
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		assert.Empty(t, rr.Header().Get("Content-Disposition"))
+	})
+
+	t.Run("Unknown format or table is rejected", func(t *testing.T) {
+		router := newRouter("http://127.0.0.1:0", http.DefaultClient)
+		for _, query := range []string{"format=pdf", "format=csv&table=referees"} {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/analytics/matches/m1/export?"+query, nil))
+			assert.Equal(t, http.StatusBadRequest, rr.Code, query)
+		}
+	})
+}
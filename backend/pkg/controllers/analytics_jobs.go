@@ -0,0 +1,104 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"nivai/backend/pkg/httperr"
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/requestctx"
+)
+
+// AnalyticsJob is the analytics run of a match, as listed by ListJobs. A
+// match has one run at a time, so the job ID is the match ID.
+type AnalyticsJob struct {
+	ID        string                 `json:"id"`
+	MatchName string                 `json:"match_name"`
+	Status    models.ProcessingState `json:"status"`
+	Error     string                 `json:"error,omitempty"` // Reason of the last failure, for failed jobs
+	Attempts  int                    `json:"attempts"`        // Runs started, retries included
+	UpdatedAt time.Time              `json:"updated_at"`
+}
+
+// jobStatuses are the processing states ListJobs filters on.
+var jobStatuses = map[models.ProcessingState]bool{
+	models.StatePendingAnalytics: true,
+	models.StateProcessing:       true,
+	models.StateCompleted:        true,
+	models.StateFailed:           true,
+	models.StateCancelled:        true,
+}
+
+// ListJobs handles GET /api/v1/jobs?status=failed.
+// It lists the analytics jobs in one state, "failed" by default, with the
+// error the Python API reported for failed ones, so operators can find
+// matches that need a retry. limit and offset page through the jobs.
+func (vc *VideoController) ListJobs(w http.ResponseWriter, r *http.Request) {
+	status := models.StateFailed
+	if s := r.URL.Query().Get("status"); s != "" {
+		status = models.ProcessingState(s)
+	}
+	if !jobStatuses[status] {
+		httperr.WriteError(w, r, httperr.BadRequest("Invalid job status: "+string(status)))
+		return
+	}
+
+	limit, offset := parsePaginationParams(r)
+	videos, err := vc.videoService.ListVideos(limit, offset, map[string]string{"processing_state": string(status)})
+	if err != nil {
+		requestctx.From(r).Logger.Printf("Error listing %s jobs: %v", status, err)
+		httperr.WriteError(w, r, httperr.Internal("Failed to retrieve jobs"))
+		return
+	}
+
+	jobs := make([]AnalyticsJob, len(videos))
+	for i, video := range videos {
+		jobs[i] = vc.analyticsJob(video)
+	}
+	if err := writeList(w, r, jobs, len(jobs), limit, offset); err != nil {
+		log.Printf("Error encoding ListJobs response: %v", err)
+	}
+}
+
+// RetryJob handles POST /api/v1/jobs/{id}/retry.
+// A failed job is sent to the Python API again with the match's stored
+// files, like a reprocess; other jobs answer 409.
+func (vc *VideoController) RetryJob(w http.ResponseWriter, r *http.Request) {
+	vc.withProcessingLock(w, r, func(w http.ResponseWriter, r *http.Request) {
+		video, ok := vc.processingTarget(w, r)
+		if !ok {
+			return
+		}
+		if video.ProcessingState != models.StateFailed {
+			httperr.WriteError(w, r, httperr.Conflict("Only failed jobs can be retried"))
+			return
+		}
+		vc.reprocess(w, r, video, "retry of failed job")
+	})
+}
+
+// analyticsJob builds the job of a match from its state history. Without
+// history, the job has no error and counts no attempts.
+func (vc *VideoController) analyticsJob(video *models.Video) AnalyticsJob {
+	job := AnalyticsJob{ID: video.ID, MatchName: video.Title, Status: video.ProcessingState, UpdatedAt: video.UpdatedAt}
+
+	history, err := vc.videoService.GetStateHistory(video.ID)
+	if err != nil {
+		log.Printf("Error retrieving state history of job %s: %v", video.ID, err)
+		return job
+	}
+	var lastError string
+	for _, t := range history {
+		switch t.To {
+		case models.StatePendingAnalytics:
+			job.Attempts++
+		case models.StateFailed:
+			lastError = t.Reason
+		}
+	}
+	if job.Status == models.StateFailed {
+		job.Error = lastError
+	}
+	return job
+}
@@ -0,0 +1,69 @@
+package controllers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"nivai/backend/pkg/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListJobs(t *testing.T) {
+	videoSvc := new(MockVideoService)
+	router, _ := newProcessingRouter(t, videoSvc, http.StatusAccepted)
+
+	at := time.Date(2024, 8, 1, 12, 0, 0, 0, time.UTC)
+	failed := processingVideo(models.StateFailed)
+	failed.Title, failed.UpdatedAt = "Ajax - PSV", at
+	videoSvc.On("ListVideos", 10, 0, map[string]string{"processing_state": "failed"}).Return([]*models.Video{failed}, nil).Once()
+	videoSvc.On("GetStateHistory", "v1").Return([]*models.StateTransition{
+		{To: models.StatePendingAnalytics, Actor: "user-1"},
+		{From: models.StatePendingAnalytics, To: models.StateFailed, Actor: "system:analytics", Reason: "analytics status error: Tracking data loading failed."},
+		{From: models.StateFailed, To: models.StatePendingAnalytics, Actor: "admin-1"},
+		{From: models.StatePendingAnalytics, To: models.StateFailed, Actor: "system:analytics", Reason: "analytics status error: Data enrichment resulted in empty dataset."},
+	}, nil).Once()
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/jobs?status=failed", nil))
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.JSONEq(t, `[{"id":"v1","match_name":"Ajax - PSV","status":"failed",
+		"error":"analytics status error: Data enrichment resulted in empty dataset.","attempts":2,
+		"updated_at":"2024-08-01T12:00:00Z"}]`, rr.Body.String())
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/jobs?status=archived", nil))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	videoSvc.AssertExpectations(t)
+}
+
+func TestRetryJob(t *testing.T) {
+	t.Run("Sends a failed job to the Python API again", func(t *testing.T) {
+		videoSvc := new(MockVideoService)
+		router, calls := newProcessingRouter(t, videoSvc, http.StatusAccepted)
+		videoSvc.On("GetVideoByID", "v1").Return(processingVideo(models.StateFailed), nil).Once()
+		videoSvc.On("UpdateProcessingState", "v1", models.StatePendingAnalytics).Return(nil).Once()
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("POST", "/jobs/v1/retry", nil))
+
+		assert.Equal(t, http.StatusAccepted, rr.Code, rr.Body.String())
+		assert.Equal(t, []string{"POST /process-match"}, *calls)
+		videoSvc.AssertExpectations(t)
+	})
+
+	t.Run("Refuses jobs that did not fail", func(t *testing.T) {
+		videoSvc := new(MockVideoService)
+		router, calls := newProcessingRouter(t, videoSvc, http.StatusAccepted)
+		videoSvc.On("GetVideoByID", "v1").Return(processingVideo(models.StateCompleted), nil).Once()
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("POST", "/jobs/v1/retry", nil))
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		assert.Empty(t, *calls)
+	})
+}
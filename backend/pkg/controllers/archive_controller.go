@@ -0,0 +1,94 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"nivai/backend/pkg/httperr"
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/requestctx"
+	"nivai/backend/pkg/services"
+
+	"github.com/gorilla/mux"
+)
+
+// ArchiveController handles moving match files to cold storage and back.
+type ArchiveController struct {
+	archive *services.ArchiveService
+	access  *services.AccessService
+}
+
+// NewArchiveController creates a new controller for archive endpoints.
+// Jobs are only shown to callers with access to their match; a nil access
+// service shows them to everyone.
+func NewArchiveController(archive *services.ArchiveService, access *services.AccessService) *ArchiveController {
+	return &ArchiveController{archive: archive, access: access}
+}
+
+// ArchiveMatch handles POST /api/v1/matches/{id}/archive.
+// Only completed matches can be archived. The files move in the background;
+// the response is the job, which can be polled via GetArchiveJob.
+func (ac *ArchiveController) ArchiveMatch(w http.ResponseWriter, r *http.Request) {
+	ac.startJob(w, r, ac.archive.Archive)
+}
+
+// RestoreMatch handles POST /api/v1/matches/{id}/restore.
+// Restores from an archive tier can take hours; the match returns to
+// "completed" when the job completes.
+func (ac *ArchiveController) RestoreMatch(w http.ResponseWriter, r *http.Request) {
+	ac.startJob(w, r, ac.archive.Restore)
+}
+
+// GetArchiveJob handles GET /api/v1/archive-jobs/{id}.
+func (ac *ArchiveController) GetArchiveJob(w http.ResponseWriter, r *http.Request) {
+	logger := requestctx.From(r).Logger
+
+	job, err := ac.archive.Job(mux.Vars(r)["id"])
+	if err != nil {
+		if errors.Is(err, models.ErrArchiveJobNotFound) {
+			httperr.WriteError(w, r, httperr.NotFound("Archive job not found"))
+			return
+		}
+		logger.Printf("Error retrieving archive job: %v", err)
+		httperr.WriteError(w, r, httperr.Internal("Failed to retrieve archive job"))
+		return
+	}
+	if !allowMatch(w, r, ac.access, job.VideoID, false) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(job); err != nil {
+		logger.Printf("Error encoding GetArchiveJob response: %v", err)
+	}
+}
+
+// startJob starts an archive or restore job for the match in the path.
+func (ac *ArchiveController) startJob(w http.ResponseWriter, r *http.Request, start func(videoID string) (*models.ArchiveJob, error)) {
+	logger := requestctx.From(r).Logger
+
+	job, err := start(mux.Vars(r)["id"])
+	switch {
+	case errors.Is(err, services.ErrVideoNotFound):
+		httperr.WriteError(w, r, httperr.NotFound("Match not found"))
+		return
+	case errors.Is(err, services.ErrArchiveConflict):
+		httperr.WriteError(w, r, httperr.Conflict(err.Error()))
+		return
+	case errors.Is(err, services.ErrArchivingDisabled):
+		httperr.WriteError(w, r, httperr.NotImplemented("Cold-storage archiving is not configured"))
+		return
+	case err != nil:
+		logger.Printf("Error starting archive job: %v", err)
+		httperr.WriteError(w, r, httperr.Internal("Failed to start archive job"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/api/v1/archive-jobs/"+job.ID)
+	w.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(w).Encode(job); err != nil {
+		logger.Printf("Error encoding archive job response: %v", err)
+	}
+}
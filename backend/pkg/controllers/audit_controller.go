@@ -0,0 +1,77 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"nivai/backend/pkg/httperr"
+	"nivai/backend/pkg/services"
+
+	"github.com/gorilla/mux"
+)
+
+// AuditController exposes the consistency audit to administrators.
+type AuditController struct {
+	auditor *services.ConsistencyAuditor
+}
+
+// NewAuditController creates a new controller for audit endpoints.
+func NewAuditController(auditor *services.ConsistencyAuditor) *AuditController {
+	return &AuditController{auditor: auditor}
+}
+
+// StartAudit handles POST /api/v1/admin/audits.
+// The audit runs in the background; the response is the running report,
+// which can be polled via GetAudit.
+func (ac *AuditController) StartAudit(w http.ResponseWriter, r *http.Request) {
+	var opts services.AuditOptions
+	// An empty body means a read-only audit with default options.
+	if err := json.NewDecoder(r.Body).Decode(&opts); err != nil && !errors.Is(err, io.EOF) {
+		httperr.WriteError(w, r, httperr.BadRequest("Invalid request payload"))
+		return
+	}
+
+	report := ac.auditor.Start(opts)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/api/v1/admin/audits/"+report.ID)
+	w.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		log.Printf("Error encoding StartAudit response: %v", err)
+	}
+}
+
+// ListAudits handles GET /api/v1/admin/audits.
+func (ac *AuditController) ListAudits(w http.ResponseWriter, r *http.Request) {
+	reports := ac.auditor.List()
+	if reports == nil {
+		reports = []*services.AuditReport{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(reports); err != nil {
+		log.Printf("Error encoding ListAudits response: %v", err)
+	}
+}
+
+// GetAudit handles GET /api/v1/admin/audits/{id}.
+func (ac *AuditController) GetAudit(w http.ResponseWriter, r *http.Request) {
+	report, err := ac.auditor.Get(mux.Vars(r)["id"])
+	if err != nil {
+		if errors.Is(err, services.ErrAuditNotFound) {
+			httperr.WriteError(w, r, httperr.NotFound("Audit not found"))
+			return
+		}
+		log.Printf("Error retrieving audit: %v", err)
+		httperr.WriteError(w, r, httperr.Internal("Failed to retrieve audit"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		log.Printf("Error encoding GetAudit response: %v", err)
+	}
+}
@@ -3,6 +3,8 @@ package controllers
 import (
 	"encoding/json"
 	"net/http"
+
+	"nivai/backend/pkg/httperr"
 )
 
 /**
@@ -21,7 +23,7 @@ func Login(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&credentials); err != nil {
-		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		httperr.WriteError(w, r, httperr.BadRequest("Invalid request payload"))
 		return
 	}
 
@@ -58,7 +60,7 @@ func RefreshToken(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		httperr.WriteError(w, r, httperr.BadRequest("Invalid request payload"))
 		return
 	}
 
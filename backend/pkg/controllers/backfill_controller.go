@@ -0,0 +1,106 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"nivai/backend/pkg/httperr"
+	"nivai/backend/pkg/requestctx"
+	"nivai/backend/pkg/services"
+
+	"github.com/gorilla/mux"
+)
+
+// maxManifestSize limits the body of a backfill manifest.
+const maxManifestSize = int64(10 << 20) // 10 MB
+
+// BackfillController handles bulk imports of past matches.
+type BackfillController struct {
+	backfill *services.BackfillService
+}
+
+// NewBackfillController creates a new controller for backfill endpoints;
+// a nil service answers that backfill imports are not configured.
+func NewBackfillController(backfill *services.BackfillService) *BackfillController {
+	return &BackfillController{backfill: backfill}
+}
+
+// configured reports whether backfill imports are enabled, answering the
+// request when they are not.
+func (bc *BackfillController) configured(w http.ResponseWriter, r *http.Request) bool {
+	if bc.backfill == nil {
+		httperr.WriteError(w, r, httperr.NotImplemented("Backfill imports are not configured"))
+		return false
+	}
+	return true
+}
+
+// StartBackfill handles POST /api/v1/imports/backfill.
+// The body is a manifest listing past matches, as a JSON array or a CSV
+// file with a header row. The matches are imported in the background in
+// batches; the response is the job, which can be polled via GetBackfill.
+func (bc *BackfillController) StartBackfill(w http.ResponseWriter, r *http.Request) {
+	if !bc.configured(w, r) {
+		return
+	}
+	info := requestctx.From(r)
+
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxManifestSize))
+	if err != nil {
+		if isTooLarge(err) {
+			httperr.WriteError(w, r, httperr.New(http.StatusRequestEntityTooLarge, httperr.CodePayloadTooLarge, fmt.Sprintf("Manifest too large. Maximum size is %dMB.", maxManifestSize>>20)))
+			return
+		}
+		httperr.WriteError(w, r, httperr.BadRequest("Failed to read manifest"))
+		return
+	}
+	entries, err := bc.backfill.ParseManifest(data)
+	var invalid *services.ManifestError
+	if errors.As(err, &invalid) {
+		httperr.WriteError(w, r, httperr.BadRequest("Invalid backfill manifest").WithDetails(invalid.Problems))
+		return
+	}
+
+	job := bc.backfill.Start(entries, info.Principal.UserID)
+	info.Logger.Printf("Audit: backfill %s of %d matches started by user %q", job.ID, job.Total, info.Principal.UserID)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/api/v1/imports/backfill/"+job.ID)
+	w.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(w).Encode(job); err != nil {
+		info.Logger.Printf("Error encoding StartBackfill response: %v", err)
+	}
+}
+
+// ListBackfills handles GET /api/v1/imports/backfill.
+// Jobs are listed newest first, without their entries.
+func (bc *BackfillController) ListBackfills(w http.ResponseWriter, r *http.Request) {
+	if !bc.configured(w, r) {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{"jobs": bc.backfill.List()}); err != nil {
+		requestctx.From(r).Logger.Printf("Error encoding ListBackfills response: %v", err)
+	}
+}
+
+// GetBackfill handles GET /api/v1/imports/backfill/{id}.
+// The job includes the progress of every manifest entry.
+func (bc *BackfillController) GetBackfill(w http.ResponseWriter, r *http.Request) {
+	if !bc.configured(w, r) {
+		return
+	}
+	job, err := bc.backfill.Get(mux.Vars(r)["id"])
+	if err != nil {
+		httperr.WriteError(w, r, httperr.NotFound("Backfill job not found"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(job); err != nil {
+		requestctx.From(r).Logger.Printf("Error encoding GetBackfill response: %v", err)
+	}
+}
@@ -0,0 +1,76 @@
+package controllers_test
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"nivai/backend/pkg/controllers"
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/requestctx"
+	"nivai/backend/pkg/services"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// nopStarter leaves imported matches waiting for processing.
+type nopStarter struct{}
+
+func (nopStarter) Start(ctx context.Context, videoID, trackingPath, eventPath string) error {
+	return nil
+}
+
+func TestBackfillController(t *testing.T) {
+	serve := func(bc *controllers.BackfillController, method, target, body string) *httptest.ResponseRecorder {
+		router := mux.NewRouter()
+		router.HandleFunc("/imports/backfill", bc.StartBackfill).Methods("POST")
+		router.HandleFunc("/imports/backfill", bc.ListBackfills).Methods("GET")
+		router.HandleFunc("/imports/backfill/{id}", bc.GetBackfill).Methods("GET")
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req = req.WithContext(requestctx.NewContext(req.Context(), &requestctx.Info{
+			Principal: requestctx.Principal{UserID: "root", Role: "admin"}, Logger: log.Default()}))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("Manifests start a backfill job", func(t *testing.T) {
+		storage := services.NewMemoryStorageService()
+		videoService := services.NewVideoService(models.NewMemoryVideoRepository(), storage)
+		importer := services.NewIngestionService(services.NewDirectorySource(t.TempDir()), videoService, storage, nopStarter{}, services.IngestionConfig{})
+		bc := controllers.NewBackfillController(services.NewBackfillService(importer, videoService, services.BackfillConfig{}))
+
+		rr := serve(bc, "POST", "/imports/backfill", "title,tracking_path,event_file_path\nAjax - PSV,t.csv,e.json\n")
+		require.Equal(t, http.StatusAccepted, rr.Code, rr.Body.String())
+		var job services.BackfillJob
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &job))
+		assert.Equal(t, "/api/v1/imports/backfill/"+job.ID, rr.Header().Get("Location"))
+		assert.Equal(t, services.BackfillStatusQueued, job.Status)
+		assert.Equal(t, 1, job.Total)
+		assert.Equal(t, "root", job.CreatedBy)
+
+		rr = serve(bc, "GET", "/imports/backfill/"+job.ID, "")
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"tracking_path":"t.csv"`)
+		rr = serve(bc, "GET", "/imports/backfill", "")
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), job.ID)
+		assert.Equal(t, http.StatusNotFound, serve(bc, "GET", "/imports/backfill/unknown", "").Code)
+
+		rr = serve(bc, "POST", "/imports/backfill", `[{"title":"a","tracking_path":"/etc/passwd","event_file_path":"e.json"}]`)
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "entry 1: tracking_path must be relative to the backfill directory")
+	})
+
+	t.Run("Backfill without a directory is not configured", func(t *testing.T) {
+		bc := controllers.NewBackfillController(nil)
+		assert.Equal(t, http.StatusNotImplemented, serve(bc, "POST", "/imports/backfill", "[]").Code)
+		assert.Equal(t, http.StatusNotImplemented, serve(bc, "GET", "/imports/backfill", "").Code)
+	})
+}
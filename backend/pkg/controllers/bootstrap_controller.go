@@ -0,0 +1,41 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"nivai/backend/pkg/httperr"
+	"nivai/backend/pkg/requestctx"
+	"nivai/backend/pkg/services"
+)
+
+// BootstrapController serves the aggregate startup payload for the frontend.
+type BootstrapController struct {
+	bootstrapService services.BootstrapService
+}
+
+// NewBootstrapController creates a new controller for the bootstrap endpoint.
+func NewBootstrapController(bs services.BootstrapService) *BootstrapController {
+	return &BootstrapController{bootstrapService: bs}
+}
+
+// GetBootstrap handles GET /api/v1/bootstrap.
+// It returns the current user, organization settings, feature flags,
+// reference data and the unread notification count in a single response.
+func (bc *BootstrapController) GetBootstrap(w http.ResponseWriter, r *http.Request) {
+	rc := requestctx.From(r)
+
+	bootstrap, err := bc.bootstrapService.Bootstrap(r.Context(), services.BootstrapUser{ID: rc.Principal.UserID, Role: rc.Principal.Role})
+	if err != nil {
+		rc.Logger.Printf("Error building bootstrap payload: %v", err)
+		httperr.WriteError(w, r, httperr.Internal("Failed to load bootstrap data"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	// Personalized and short-lived; intermediaries must not cache it.
+	w.Header().Set("Cache-Control", "private, no-store")
+	if err := json.NewEncoder(w).Encode(bootstrap); err != nil {
+		rc.Logger.Printf("Error encoding GetBootstrap response: %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+package controllers_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"nivai/backend/pkg/controllers"
+	"nivai/backend/pkg/middleware"
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// --- MockBootstrapService ---
+type MockBootstrapService struct {
+	mock.Mock
+}
+
+func (m *MockBootstrapService) Bootstrap(ctx context.Context, user services.BootstrapUser) (*services.Bootstrap, error) {
+	args := m.Called(ctx, user)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.Bootstrap), args.Error(1)
+}
+
+func TestGetBootstrap(t *testing.T) {
+	t.Run("Returns payload for the authenticated user", func(t *testing.T) {
+		svc := new(MockBootstrapService)
+		user := services.BootstrapUser{ID: "mock-user-id", Role: middleware.RoleUser}
+		svc.On("Bootstrap", mock.Anything, user).Return(&services.Bootstrap{
+			User:          user,
+			FeatureFlags:  map[string]bool{},
+			ReferenceData: &models.ReferenceData{Teams: []string{"Ajax"}},
+		}, nil).Once()
+
+		req := httptest.NewRequest("GET", "/api/v1/bootstrap", nil)
+		req.Header.Set("Authorization", "Bearer token")
+		rr := httptest.NewRecorder()
+		middleware.Authenticate(http.HandlerFunc(controllers.NewBootstrapController(svc).GetBootstrap)).ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "private, no-store", rr.Header().Get("Cache-Control"))
+
+		var response map[string]interface{}
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+		assert.Equal(t, "mock-user-id", response["user"].(map[string]interface{})["id"])
+		assert.Equal(t, []interface{}{"Ajax"}, response["reference_data"].(map[string]interface{})["teams"])
+		svc.AssertExpectations(t)
+	})
+
+	t.Run("Service error returns 500", func(t *testing.T) {
+		svc := new(MockBootstrapService)
+		svc.On("Bootstrap", mock.Anything, mock.Anything).Return(nil, errors.New("db down")).Once()
+
+		req := httptest.NewRequest("GET", "/api/v1/bootstrap", nil)
+		rr := httptest.NewRecorder()
+		controllers.NewBootstrapController(svc).GetBootstrap(rr, req)
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+}
@@ -0,0 +1,51 @@
+package controllers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"time"
+
+	"nivai/backend/pkg/models"
+)
+
+// videosETag returns a weak ETag for a response listing videos, derived from
+// the IDs and last update times of the videos rather than the encoded body,
+// so it changes whenever a video in it is updated, added or removed.
+func videosETag(videos ...*models.Video) string {
+	hash := sha256.New()
+	for _, video := range videos {
+		hash.Write([]byte(video.ID))
+		hash.Write([]byte{0})
+		hash.Write(strconv.AppendInt(nil, video.UpdatedAt.UnixNano(), 10))
+		hash.Write([]byte{0})
+	}
+	return `W/"` + hex.EncodeToString(hash.Sum(nil)[:16]) + `"`
+}
+
+// notModified sets the validators of a response and reports whether the
+// request's preconditions show the client's copy is current, in which case
+// it has answered 304 Not Modified. If-None-Match takes precedence over
+// If-Modified-Since, as RFC 9110 prescribes; a zero lastModified leaves
+// Last-Modified out.
+func notModified(w http.ResponseWriter, r *http.Request, etag string, lastModified time.Time) bool {
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "private, no-cache")
+	if !lastModified.IsZero() {
+		w.Header().Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	fresh := false
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		fresh = etagMatches(inm, etag)
+	} else if ims := r.Header.Get("If-Modified-Since"); ims != "" && !lastModified.IsZero() {
+		since, err := http.ParseTime(ims)
+		// HTTP dates have whole seconds
+		fresh = err == nil && !lastModified.Truncate(time.Second).After(since)
+	}
+	if fresh {
+		w.WriteHeader(http.StatusNotModified)
+	}
+	return fresh
+}
@@ -0,0 +1,62 @@
+package controllers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"nivai/backend/pkg/config"
+	"nivai/backend/pkg/httperr"
+	"nivai/backend/pkg/requestctx"
+)
+
+// ConfigController lets administrators reload the configuration at runtime
+// and review past reloads.
+type ConfigController struct {
+	reloader *config.Reloader
+}
+
+// NewConfigController creates a new controller for configuration reloads.
+// Without a reloader, reloads answer 501 Not Implemented.
+func NewConfigController(reloader *config.Reloader) *ConfigController {
+	return &ConfigController{reloader: reloader}
+}
+
+// ReloadConfig handles POST /api/v1/admin/config/reload.
+// It applies the changed reloadable settings and responds with the audit
+// record of the reload, listing changes that need a restart as well. A
+// configuration that fails to load or validate is not applied and answers
+// 422 with the record as details.
+func (cc *ConfigController) ReloadConfig(w http.ResponseWriter, r *http.Request) {
+	if cc.reloader == nil {
+		httperr.WriteError(w, r, httperr.NotImplemented("Configuration reloads are not available"))
+		return
+	}
+
+	reload, err := cc.reloader.Reload("api", requestctx.From(r).Principal.UserID)
+	if err != nil {
+		httperr.WriteError(w, r, httperr.FromStatus(http.StatusUnprocessableEntity,
+			"Configuration reload failed; the running configuration is kept").WithDetails(reload))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(reload); err != nil {
+		log.Printf("Error encoding ReloadConfig response: %v", err)
+	}
+}
+
+// ListReloads handles GET /api/v1/admin/config/reloads.
+// It returns the audit records of recent reloads, newest first.
+func (cc *ConfigController) ListReloads(w http.ResponseWriter, r *http.Request) {
+	reloads := []config.Reload{}
+	if cc.reloader != nil {
+		reloads = cc.reloader.History()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := json.NewEncoder(w).Encode(reloads); err != nil {
+		log.Printf("Error encoding ListReloads response: %v", err)
+	}
+}
@@ -0,0 +1,30 @@
+package controllers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"nivai/backend/pkg/database"
+)
+
+// DatabaseController exposes database connection pool metrics to administrators.
+type DatabaseController struct {
+	pools *database.Pools
+}
+
+// NewDatabaseController creates a new controller for connection pool metrics.
+func NewDatabaseController(pools *database.Pools) *DatabaseController {
+	return &DatabaseController{pools: pools}
+}
+
+// GetPoolStats handles GET /api/v1/admin/database/pools.
+// It reports open, in-use and idle connections of the primary and each read
+// replica, and how often and how long queries waited for a connection.
+func (dc *DatabaseController) GetPoolStats(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := json.NewEncoder(w).Encode(dc.pools.Stats()); err != nil {
+		log.Printf("Error encoding GetPoolStats response: %v", err)
+	}
+}
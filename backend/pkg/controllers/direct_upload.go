@@ -0,0 +1,307 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"nivai/backend/pkg/httperr"
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/pythonapi"
+	"nivai/backend/pkg/requestctx"
+	"nivai/backend/pkg/services"
+	"nivai/backend/pkg/upload"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// errUploadIncomplete is returned when a finalized upload's files are not
+// all in storage as declared.
+var errUploadIncomplete = errors.New("upload incomplete")
+
+// WithDirectUploads lets clients upload files straight to storage through
+// presigned URLs valid for urlExpiry, when the storage backend can sign
+// them. Files of uploads that are never finalized are left to the orphaned
+// file collector.
+func WithDirectUploads(sessions models.UploadSessionRepository, urlExpiry time.Duration) VideoControllerOption {
+	return func(vc *VideoController) {
+		vc.uploadSessions = sessions
+		vc.uploadURLExpiry = urlExpiry
+	}
+}
+
+// presignRequest is the body of POST /api/v1/uploads/presign.
+type presignRequest struct {
+	models.UploadMetadata
+	Files []presignFile `json:"files"`
+}
+
+// presignFile is a file the client wants to upload.
+type presignFile struct {
+	Kind     string `json:"kind"`
+	Filename string `json:"filename"`
+	Size     int64  `json:"size"`
+}
+
+// presignedFile is the upload URL of one file.
+type presignedFile struct {
+	Kind string `json:"kind"`
+	Path string `json:"path"`
+	*services.UploadURL
+}
+
+// presignResponse is the response of POST /api/v1/uploads/presign.
+type presignResponse struct {
+	UploadID    string          `json:"upload_id"`
+	VideoID     string          `json:"video_id"`
+	ExpiresAt   time.Time       `json:"expires_at"`
+	Files       []presignedFile `json:"files"`
+	FinalizeURL string          `json:"finalize_url"`
+}
+
+// validate checks the declared files: tracking and events are required,
+// the video is optional, and each kind appears once.
+func (req *presignRequest) validate() error {
+	seen := map[string]bool{}
+	for _, file := range req.Files {
+		switch file.Kind {
+		case models.FileKindVideo, models.FileKindTracking, models.FileKindEvents:
+		default:
+			return fmt.Errorf("unknown file kind %q", file.Kind)
+		}
+		if seen[file.Kind] {
+			return fmt.Errorf("more than one %s file", file.Kind)
+		}
+		seen[file.Kind] = true
+		if file.Size <= 0 {
+			return fmt.Errorf("the %s file needs a positive size", file.Kind)
+		}
+	}
+	if !seen[models.FileKindTracking] || !seen[models.FileKindEvents] {
+		return errors.New("tracking and event files are required for analytics processing")
+	}
+	if req.MatchDate != "" {
+		if _, err := time.Parse("2006-01-02", req.MatchDate); err != nil {
+			return errors.New("invalid match_date, expected YYYY-MM-DD")
+		}
+	}
+	if req.Priority != "" && !pythonapi.ValidPriority(req.Priority) {
+		return errors.New("invalid priority, expected low, normal or high")
+	}
+	return nil
+}
+
+// PresignUpload handles POST /api/v1/uploads/presign.
+// It returns a time-limited upload URL per declared file, so large files go
+// straight to storage instead of through the API. The client uploads each
+// file with the given method and headers, then finalizes the upload.
+func (vc *VideoController) PresignUpload(w http.ResponseWriter, r *http.Request) {
+	info := requestctx.From(r)
+	signer, ok := vc.storageService.(services.UploadURLSigner)
+	if vc.uploadSessions == nil || !ok {
+		httperr.WriteError(w, r, httperr.NotImplemented("Direct uploads are not supported by the storage backend"))
+		return
+	}
+
+	var req presignRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httperr.WriteError(w, r, httperr.BadRequest("Invalid request payload"))
+		return
+	}
+	if err := req.validate(); err != nil {
+		httperr.WriteError(w, r, httperr.BadRequest(err.Error()))
+		return
+	}
+	if invalid := validateMetadata(&req.UploadMetadata); invalid != nil {
+		httperr.WriteError(w, r, invalid)
+		return
+	}
+
+	// Reject uploads that do not fit the storage quotas before signing anything.
+	if vc.quotas != nil {
+		var uploadSize int64
+		for _, file := range req.Files {
+			uploadSize += file.Size
+		}
+		if !vc.checkQuota(w, r, info, uploadSize) {
+			return
+		}
+	}
+
+	now := time.Now()
+	session := &models.UploadSession{
+		ID:           uuid.New().String(),
+		VideoID:      uuid.New().String(),
+		Organization: info.Org,
+		UserID:       info.Principal.UserID,
+		Metadata:     req.UploadMetadata,
+		ExpiresAt:    now.Add(vc.uploadURLExpiry),
+		CreatedAt:    now,
+	}
+	response := presignResponse{
+		UploadID:    session.ID,
+		VideoID:     session.VideoID,
+		ExpiresAt:   session.ExpiresAt,
+		FinalizeURL: "/api/v1/uploads/" + session.ID + "/finalize",
+	}
+	storagePath := vc.uploadDir(session.VideoID, session.Organization)
+	for _, file := range req.Files {
+		path := filepath.Join(storagePath, services.MatchFileName(session.VideoID, file.Kind, file.Filename))
+		uploadURL, err := signer.GetUploadURL(path, vc.uploadURLExpiry)
+		if err != nil {
+			info.Logger.Printf("Error signing upload URL for %s: %v", path, err)
+			httperr.WriteError(w, r, httperr.Internal("Failed to create upload URLs"))
+			return
+		}
+		session.Files = append(session.Files, models.UploadSessionFile{
+			Kind: file.Kind, Filename: file.Filename, Path: path, Size: file.Size,
+		})
+		response.Files = append(response.Files, presignedFile{Kind: file.Kind, Path: path, UploadURL: uploadURL})
+	}
+
+	if err := vc.uploadSessions.Create(session); err != nil {
+		info.Logger.Printf("Error saving upload session %s: %v", session.ID, err)
+		httperr.WriteError(w, r, httperr.Internal("Failed to create upload"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		info.Logger.Printf("Error encoding PresignUpload response: %v", err)
+	}
+}
+
+// FinalizeUpload handles POST /api/v1/uploads/{id}/finalize.
+// It verifies that every declared file is in storage with its declared
+// size, scans the files when a scanner is configured, and then creates the
+// match and starts its processing like a regular upload.
+func (vc *VideoController) FinalizeUpload(w http.ResponseWriter, r *http.Request) {
+	info := requestctx.From(r)
+	if vc.uploadSessions == nil {
+		httperr.WriteError(w, r, httperr.NotImplemented("Direct uploads are not supported by the storage backend"))
+		return
+	}
+
+	session, err := vc.uploadSessions.FindByID(mux.Vars(r)["id"])
+	if err != nil {
+		if errors.Is(err, models.ErrUploadSessionNotFound) {
+			httperr.WriteError(w, r, httperr.NotFound("Upload not found"))
+		} else {
+			info.Logger.Printf("Error retrieving upload session: %v", err)
+			httperr.WriteError(w, r, httperr.Internal("Failed to retrieve upload"))
+		}
+		return
+	}
+	// Other users' uploads are reported missing rather than forbidden
+	if session.UserID != info.Principal.UserID || session.Organization != info.Org {
+		httperr.WriteError(w, r, httperr.NotFound("Upload not found"))
+		return
+	}
+	if session.FinalizedAt.Valid {
+		httperr.WriteError(w, r, httperr.Conflict("Upload already finalized"))
+		return
+	}
+	if time.Now().After(session.ExpiresAt) {
+		httperr.WriteError(w, r, httperr.New(http.StatusGone, httperr.CodeGone, "Upload expired, request new upload URLs"))
+		return
+	}
+
+	storedFiles, threats, err := vc.verifyDirectUpload(r.Context(), session)
+	if err != nil {
+		switch {
+		case errors.Is(err, errUploadIncomplete):
+			httperr.WriteError(w, r, httperr.BadRequest(err.Error()))
+		case errors.Is(err, upload.ErrScanFailed):
+			httperr.WriteError(w, r, httperr.Unavailable(err.Error()))
+		default:
+			info.Logger.Printf("Error verifying upload %s: %v", session.ID, err)
+			httperr.WriteError(w, r, httperr.Internal("Failed to verify uploaded files"))
+		}
+		return
+	}
+
+	// Claim the session only once its files are verified, so a client that
+	// finalized too early can try again.
+	if err := vc.uploadSessions.MarkFinalized(session.ID, time.Now()); err != nil {
+		if errors.Is(err, models.ErrUploadSessionFinalized) {
+			httperr.WriteError(w, r, httperr.Conflict("Upload already finalized"))
+		} else {
+			info.Logger.Printf("Error finalizing upload session %s: %v", session.ID, err)
+			httperr.WriteError(w, r, httperr.Internal("Failed to finalize upload"))
+		}
+		return
+	}
+
+	metadata := session.Metadata
+	videoMetadata := &models.Video{
+		ID:              session.VideoID,
+		ProcessingState: models.StatePendingAnalytics,
+		CreatedAt:       time.Now(),
+	}
+	for _, file := range storedFiles {
+		switch file.Kind {
+		case models.FileKindVideo:
+			videoMetadata.FilePath = file.Path
+			videoMetadata.Size = file.Size
+			videoMetadata.StorageProvider = "default"
+			videoMetadata.Format = strings.TrimPrefix(filepath.Ext(file.Path), ".")
+		case models.FileKindTracking:
+			videoMetadata.TrackingPath = file.Path
+		case models.FileKindEvents:
+			videoMetadata.EventFilePath = file.Path
+		}
+	}
+	applyMetadata(videoMetadata, &metadata)
+
+	r = r.WithContext(services.WithPriority(r.Context(), metadata.Priority))
+	vc.completeUpload(w, r, videoMetadata, storedFiles, threats, metadata.KickoffAt)
+}
+
+// verifyDirectUpload checks that each file of a session is stored with its
+// declared size. When a scanner is configured the files are read back to
+// scan them, which also records their checksums; otherwise checksums are
+// left for the consistency audit to fill in.
+func (vc *VideoController) verifyDirectUpload(ctx context.Context, session *models.UploadSession) ([]*models.VideoFile, map[string]string, error) {
+	_, nop := vc.scanner.(upload.NopScanner)
+	scan := vc.scanner != nil && !nop
+
+	var storedFiles []*models.VideoFile
+	threats := map[string]string{}
+	for _, file := range session.Files {
+		metadata, err := vc.storageService.GetFileMetadata(file.Path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: the %s file has not been uploaded", errUploadIncomplete, file.Kind)
+		}
+		size, err := strconv.ParseInt(metadata["content-length"], 10, 64)
+		if err != nil || size != file.Size {
+			return nil, nil, fmt.Errorf("%w: the %s file is %s bytes, %d were declared", errUploadIncomplete, file.Kind, metadata["content-length"], file.Size)
+		}
+
+		stored := &models.VideoFile{Kind: file.Kind, Path: file.Path, Size: size}
+		if scan {
+			content, err := vc.storageService.GetFile(file.Path)
+			if err != nil {
+				return nil, nil, err
+			}
+			result, err := upload.Tee(ctx, content, nil, upload.Options{Scanner: vc.scanner})
+			content.Close()
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to scan %s file: %w", file.Kind, err)
+			}
+			stored.Checksum = result.Checksum
+			if result.Threat != "" {
+				threats[file.Kind] = result.Threat
+			}
+		}
+		storedFiles = append(storedFiles, stored)
+	}
+	return storedFiles, threats, nil
+}
@@ -0,0 +1,279 @@
+package controllers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"nivai/backend/pkg/controllers"
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/pythonapi"
+	"nivai/backend/pkg/services"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// --- MockSigningStorageService ---
+type MockSigningStorageService struct {
+	MockStorageService
+}
+
+func (m *MockSigningStorageService) GetUploadURL(path string, expiry time.Duration) (*services.UploadURL, error) {
+	args := m.Called(path, expiry)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.UploadURL), args.Error(1)
+}
+
+// --- MockUploadSessionRepository ---
+type MockUploadSessionRepository struct {
+	mock.Mock
+}
+
+func (m *MockUploadSessionRepository) Create(session *models.UploadSession) error {
+	args := m.Called(session)
+	return args.Error(0)
+}
+
+func (m *MockUploadSessionRepository) FindByID(id string) (*models.UploadSession, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.UploadSession), args.Error(1)
+}
+
+func (m *MockUploadSessionRepository) MarkFinalized(id string, at time.Time) error {
+	args := m.Called(id, at)
+	return args.Error(0)
+}
+
+func newDirectUploadRouter(vc *controllers.VideoController) *mux.Router {
+	router := mux.NewRouter()
+	router.HandleFunc("/api/v1/uploads/presign", vc.PresignUpload).Methods("POST")
+	router.HandleFunc("/api/v1/uploads/{id}/finalize", vc.FinalizeUpload).Methods("POST")
+	return router
+}
+
+func TestPresignUpload(t *testing.T) {
+	presignBody := `{"title": "Derby", "match_id": "m1", "match_date": "2024-03-02", "files": [
+		{"kind": "tracking", "filename": "match.gzip", "size": 600},
+		{"kind": "events", "filename": "match.json", "size": 100}]}`
+
+	t.Run("Upload URLs are signed per file", func(t *testing.T) {
+		storageSvc := new(MockSigningStorageService)
+		sessions := new(MockUploadSessionRepository)
+		videoService := services.NewVideoService(new(MockVideoRepository), storageSvc)
+		vc := controllers.NewVideoController(videoService, storageSvc, pythonapi.NewClient("", nil), nil,
+			controllers.WithDirectUploads(sessions, 15*time.Minute))
+
+		storageSvc.On("GetUploadURL", mock.MatchedBy(func(p string) bool { return strings.HasSuffix(p, "_tracking.gzip") }), 15*time.Minute).
+			Return(&services.UploadURL{URL: "https://storage/tracking?sig", Method: "PUT"}, nil).Once()
+		storageSvc.On("GetUploadURL", mock.MatchedBy(func(p string) bool { return strings.HasSuffix(p, "_events.gzip") }), 15*time.Minute).
+			Return(&services.UploadURL{URL: "https://storage/events?sig", Method: "PUT"}, nil).Once()
+		var session *models.UploadSession
+		sessions.On("Create", mock.AnythingOfType("*models.UploadSession")).Run(func(args mock.Arguments) {
+			session = args.Get(0).(*models.UploadSession)
+		}).Return(nil).Once()
+
+		rr := httptest.NewRecorder()
+		newDirectUploadRouter(vc).ServeHTTP(rr, httptest.NewRequest("POST", "/api/v1/uploads/presign", strings.NewReader(presignBody)))
+
+		require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+		var response struct {
+			UploadID    string `json:"upload_id"`
+			VideoID     string `json:"video_id"`
+			FinalizeURL string `json:"finalize_url"`
+			Files       []struct {
+				Kind   string `json:"kind"`
+				Path   string `json:"path"`
+				URL    string `json:"url"`
+				Method string `json:"method"`
+			} `json:"files"`
+		}
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+		require.NotNil(t, session)
+		assert.Equal(t, session.ID, response.UploadID)
+		assert.Equal(t, session.VideoID, response.VideoID)
+		assert.Equal(t, "/api/v1/uploads/"+session.ID+"/finalize", response.FinalizeURL)
+		require.Len(t, response.Files, 2)
+		assert.Equal(t, "https://storage/tracking?sig", response.Files[0].URL)
+		assert.Equal(t, "PUT", response.Files[0].Method)
+		assert.Equal(t, session.Files[0].Path, response.Files[0].Path)
+		assert.Contains(t, session.Files[0].Path, session.VideoID)
+		assert.Equal(t, "m1", session.Metadata.MatchID)
+		storageSvc.AssertExpectations(t)
+	})
+
+	t.Run("Storage without signed URLs is not supported", func(t *testing.T) {
+		storageSvc := new(MockStorageService)
+		videoService := services.NewVideoService(new(MockVideoRepository), storageSvc)
+		vc := controllers.NewVideoController(videoService, storageSvc, pythonapi.NewClient("", nil), nil,
+			controllers.WithDirectUploads(new(MockUploadSessionRepository), time.Hour))
+
+		rr := httptest.NewRecorder()
+		newDirectUploadRouter(vc).ServeHTTP(rr, httptest.NewRequest("POST", "/api/v1/uploads/presign", strings.NewReader(presignBody)))
+
+		assert.Equal(t, http.StatusNotImplemented, rr.Code)
+	})
+
+	t.Run("Invalid file declarations are rejected", func(t *testing.T) {
+		storageSvc := new(MockSigningStorageService)
+		videoService := services.NewVideoService(new(MockVideoRepository), storageSvc)
+		vc := controllers.NewVideoController(videoService, storageSvc, pythonapi.NewClient("", nil), nil,
+			controllers.WithDirectUploads(new(MockUploadSessionRepository), time.Hour))
+
+		for _, body := range []string{
+			`{"files": [{"kind": "tracking", "size": 1}]}`,
+			`{"files": [{"kind": "tracking", "size": 1}, {"kind": "events", "size": 0}]}`,
+			`{"files": [{"kind": "tracking", "size": 1}, {"kind": "events", "size": 1}, {"kind": "events", "size": 1}]}`,
+			`{"files": [{"kind": "tracking", "size": 1}, {"kind": "events", "size": 1}, {"kind": "poster", "size": 1}]}`,
+			`{"match_date": "02-03-2024", "files": [{"kind": "tracking", "size": 1}, {"kind": "events", "size": 1}]}`,
+			`{"kickoff_timezone": "Mars/Olympus", "files": [{"kind": "tracking", "size": 1}, {"kind": "events", "size": 1}]}`,
+		} {
+			rr := httptest.NewRecorder()
+			newDirectUploadRouter(vc).ServeHTTP(rr, httptest.NewRequest("POST", "/api/v1/uploads/presign", strings.NewReader(body)))
+			assert.Equal(t, http.StatusBadRequest, rr.Code, body)
+		}
+		storageSvc.AssertNotCalled(t, "GetUploadURL", mock.Anything, mock.Anything)
+	})
+}
+
+func TestFinalizeUpload(t *testing.T) {
+	newSession := func() *models.UploadSession {
+		return &models.UploadSession{
+			ID:      "up1",
+			VideoID: "vid1",
+			Metadata: models.UploadMetadata{
+				Title: "Derby", MatchID: "m1", HomeTeam: "Home", AwayTeam: "Away", MatchDate: "2024-03-02",
+			},
+			Files: []models.UploadSessionFile{
+				{Kind: models.FileKindTracking, Path: "videos/vid1/vid1_tracking.gzip", Size: 600},
+				{Kind: models.FileKindEvents, Path: "videos/vid1/vid1_events.gzip", Size: 100},
+			},
+			ExpiresAt: time.Now().Add(time.Hour),
+		}
+	}
+
+	t.Run("Verified uploads start processing", func(t *testing.T) {
+		videoRepo := new(MockVideoRepository)
+		storageSvc := new(MockSigningStorageService)
+		sessions := new(MockUploadSessionRepository)
+
+		var processBody map[string]string
+		pythonServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			json.NewDecoder(r.Body).Decode(&processBody)
+			w.WriteHeader(http.StatusAccepted)
+			w.Write([]byte(`{"message": "queued"}`))
+		}))
+		defer pythonServer.Close()
+
+		videoService := services.NewVideoService(videoRepo, storageSvc)
+		vc := controllers.NewVideoController(videoService, storageSvc, pythonapi.NewClient(pythonServer.URL, pythonServer.Client()), nil,
+			controllers.WithDirectUploads(sessions, time.Hour))
+
+		sessions.On("FindByID", "up1").Return(newSession(), nil).Once()
+		sessions.On("MarkFinalized", "up1", mock.AnythingOfType("time.Time")).Return(nil).Once()
+		storageSvc.On("GetFileMetadata", "videos/vid1/vid1_tracking.gzip").Return(map[string]string{"content-length": "600"}, nil).Once()
+		storageSvc.On("GetFileMetadata", "videos/vid1/vid1_events.gzip").Return(map[string]string{"content-length": "100"}, nil).Once()
+		var created *models.Video
+		videoRepo.On("Create", mock.AnythingOfType("*models.Video")).Run(func(args mock.Arguments) {
+			created = args.Get(0).(*models.Video)
+		}).Return(nil).Once()
+
+		rr := httptest.NewRecorder()
+		newDirectUploadRouter(vc).ServeHTTP(rr, httptest.NewRequest("POST", "/api/v1/uploads/up1/finalize", nil))
+
+		require.Equal(t, http.StatusAccepted, rr.Code, rr.Body.String())
+		require.NotNil(t, created)
+		assert.Equal(t, "vid1", created.ID)
+		assert.Equal(t, "m1", created.MatchID)
+		assert.Equal(t, "videos/vid1/vid1_tracking.gzip", created.TrackingPath)
+		assert.Equal(t, "videos/vid1/vid1_events.gzip", created.EventFilePath)
+		assert.Equal(t, time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC), created.MatchDate)
+		assert.Equal(t, "videos/vid1/vid1_tracking.gzip", processBody["tracking_data_path"])
+		sessions.AssertExpectations(t)
+		videoRepo.AssertExpectations(t)
+	})
+
+	t.Run("Missing or truncated files can be uploaded again", func(t *testing.T) {
+		for name, metadata := range map[string]map[string]string{
+			"missing":   nil,
+			"truncated": {"content-length": "599"},
+		} {
+			storageSvc := new(MockSigningStorageService)
+			sessions := new(MockUploadSessionRepository)
+			videoService := services.NewVideoService(new(MockVideoRepository), storageSvc)
+			vc := controllers.NewVideoController(videoService, storageSvc, pythonapi.NewClient("", nil), nil,
+				controllers.WithDirectUploads(sessions, time.Hour))
+
+			sessions.On("FindByID", "up1").Return(newSession(), nil).Once()
+			if metadata == nil {
+				storageSvc.On("GetFileMetadata", "videos/vid1/vid1_tracking.gzip").Return(nil, assert.AnError).Once()
+			} else {
+				storageSvc.On("GetFileMetadata", "videos/vid1/vid1_tracking.gzip").Return(metadata, nil).Once()
+			}
+
+			rr := httptest.NewRecorder()
+			newDirectUploadRouter(vc).ServeHTTP(rr, httptest.NewRequest("POST", "/api/v1/uploads/up1/finalize", nil))
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code, name)
+			assert.Contains(t, rr.Body.String(), "tracking", name)
+			sessions.AssertNotCalled(t, "MarkFinalized", mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("Uploads are finalized once", func(t *testing.T) {
+		storageSvc := new(MockSigningStorageService)
+		sessions := new(MockUploadSessionRepository)
+		videoRepo := new(MockVideoRepository)
+		videoService := services.NewVideoService(videoRepo, storageSvc)
+		vc := controllers.NewVideoController(videoService, storageSvc, pythonapi.NewClient("", nil), nil,
+			controllers.WithDirectUploads(sessions, time.Hour))
+
+		finalized := newSession()
+		finalized.ID = "done"
+		finalized.FinalizedAt.Valid = true
+		sessions.On("FindByID", "done").Return(finalized, nil).Once()
+		sessions.On("FindByID", "up1").Return(newSession(), nil).Once()
+		sessions.On("MarkFinalized", "up1", mock.AnythingOfType("time.Time")).Return(models.ErrUploadSessionFinalized).Once()
+		storageSvc.On("GetFileMetadata", "videos/vid1/vid1_tracking.gzip").Return(map[string]string{"content-length": "600"}, nil).Once()
+		storageSvc.On("GetFileMetadata", "videos/vid1/vid1_events.gzip").Return(map[string]string{"content-length": "100"}, nil).Once()
+
+		for _, id := range []string{"done", "up1"} {
+			rr := httptest.NewRecorder()
+			newDirectUploadRouter(vc).ServeHTTP(rr, httptest.NewRequest("POST", "/api/v1/uploads/"+id+"/finalize", bytes.NewReader(nil)))
+			assert.Equal(t, http.StatusConflict, rr.Code, id)
+		}
+		videoRepo.AssertNotCalled(t, "Create", mock.Anything)
+	})
+
+	t.Run("Unknown and expired uploads", func(t *testing.T) {
+		sessions := new(MockUploadSessionRepository)
+		storageSvc := new(MockSigningStorageService)
+		videoService := services.NewVideoService(new(MockVideoRepository), storageSvc)
+		vc := controllers.NewVideoController(videoService, storageSvc, pythonapi.NewClient("", nil), nil,
+			controllers.WithDirectUploads(sessions, time.Hour))
+
+		expired := newSession()
+		expired.ExpiresAt = time.Now().Add(-time.Minute)
+		sessions.On("FindByID", "missing").Return(nil, models.ErrUploadSessionNotFound).Once()
+		sessions.On("FindByID", "up1").Return(expired, nil).Once()
+
+		rr := httptest.NewRecorder()
+		newDirectUploadRouter(vc).ServeHTTP(rr, httptest.NewRequest("POST", "/api/v1/uploads/missing/finalize", nil))
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+
+		rr = httptest.NewRecorder()
+		newDirectUploadRouter(vc).ServeHTTP(rr, httptest.NewRequest("POST", "/api/v1/uploads/up1/finalize", nil))
+		assert.Equal(t, http.StatusGone, rr.Code)
+	})
+}
@@ -0,0 +1,134 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"nivai/backend/pkg/httperr"
+	"nivai/backend/pkg/ingest/adapters"
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/requestctx"
+	"nivai/backend/pkg/upload"
+)
+
+// maxEventFeedSize limits the body of an event feed import.
+const maxEventFeedSize = int64(100 << 20) // 100 MB
+
+// eventFeedProviders are the providers whose event feeds can be imported.
+// Opta feeds are detected as StatsPerform's.
+var eventFeedProviders = []string{"opta", "statsbomb", "statsperform"}
+
+// errNotEventFeed marks an import body that is no known event feed.
+var errNotEventFeed = errors.New("body is not a StatsBomb or Opta event feed")
+
+// ImportMatchEvents handles POST /api/v1/matches/{id}/events/import.
+// The body is a StatsBomb or Opta JSON event feed, named by the optional
+// provider query parameter or detected. It is converted to the platform's
+// event format and replaces the match's event file, after which the match
+// is reprocessed like on POST /matches/{id}/reprocess.
+func (vc *VideoController) ImportMatchEvents(w http.ResponseWriter, r *http.Request) {
+	vc.withProcessingLock(w, r, vc.importMatchEvents)
+}
+
+// importMatchEvents imports an event feed, holding the match's processing
+// lock.
+func (vc *VideoController) importMatchEvents(w http.ResponseWriter, r *http.Request) {
+	info := requestctx.From(r)
+	provider := strings.ToLower(r.URL.Query().Get(providerField))
+	if provider != "" && !slices.Contains(eventFeedProviders, provider) {
+		httperr.WriteError(w, r, httperr.BadRequest(fmt.Sprintf("Unknown event feed provider %q, expected one of: %s", provider, strings.Join(eventFeedProviders, ", "))))
+		return
+	}
+	video, ok := vc.processingTarget(w, r)
+	if !ok {
+		return
+	}
+	// Checked before the event file is replaced; reprocess checks again
+	if video.TrackingPath == "" || video.EventFilePath == "" {
+		httperr.WriteError(w, r, httperr.Conflict("Match has no tracking and event files to process"))
+		return
+	}
+	if !video.ProcessingState.CanTransitionTo(models.StatePendingAnalytics) {
+		httperr.WriteError(w, r, httperr.Conflict("Match events cannot be replaced while "+string(video.ProcessingState)))
+		return
+	}
+
+	// The feed is converted completely before the stored file is touched
+	spool, err := os.CreateTemp("", "nivai-events-*")
+	if err != nil {
+		info.Logger.Printf("Error spooling event feed of video %s: %v", video.ID, err)
+		httperr.WriteError(w, r, httperr.Internal("Failed to import event feed"))
+		return
+	}
+	defer os.Remove(spool.Name())
+	defer spool.Close()
+
+	var detected string
+	feed := http.MaxBytesReader(w, r.Body, maxEventFeedSize)
+	result, err := upload.Tee(r.Context(), feed, func(body io.Reader) error {
+		converted, name, err := adapters.Normalize(r.Context(), models.FileKindEvents, provider, body)
+		if err != nil {
+			return err
+		}
+		defer converted.Close()
+		if name == "" {
+			return errNotEventFeed
+		}
+		detected = name
+		_, err = io.Copy(spool, converted)
+		return err
+	}, upload.Options{Scanner: vc.scanner})
+	if err != nil {
+		httperr.WriteError(w, r, eventFeedError(err))
+		return
+	}
+	if result.Threat != "" {
+		httperr.WriteError(w, r, httperr.New(http.StatusUnprocessableEntity, httperr.CodeMalwareDetected,
+			"Event feed rejected, malware detected.").WithDetails(map[string]interface{}{
+			"video_id": video.ID,
+			"threats":  map[string]string{models.FileKindEvents: result.Threat},
+		}))
+		return
+	}
+	if _, err := spool.Seek(0, io.SeekStart); err != nil {
+		info.Logger.Printf("Error rewinding event feed of video %s: %v", video.ID, err)
+		httperr.WriteError(w, r, httperr.Internal("Failed to import event feed"))
+		return
+	}
+
+	path, size, checksum, _, err := vc.saveUploadedFile(r.Context(), spool, &multipart.FileHeader{Filename: filepath.Base(video.EventFilePath)},
+		filepath.Dir(video.EventFilePath), video.ID, models.FileKindEvents, nil)
+	if err != nil {
+		info.Logger.Printf("Error storing imported events of video %s: %v", video.ID, err)
+		httperr.WriteError(w, r, uploadError(err))
+		return
+	}
+	if err := vc.videoService.RecordVideoFiles(video.ID, []*models.VideoFile{{Kind: models.FileKindEvents, Path: path, Size: size, Checksum: checksum}}); err != nil {
+		info.Logger.Printf("Warning: Failed to record file checksum for video %s: %v", video.ID, err)
+	}
+	info.Logger.Printf("Imported %s event feed for video %s", detected, video.ID)
+
+	video.EventFilePath = path
+	vc.reprocess(w, r, video, "events imported from "+detected)
+}
+
+// eventFeedError maps an event feed import error to an API error.
+func eventFeedError(err error) *httperr.Error {
+	var invalidFeed *adapters.ConvertError
+	switch {
+	case isTooLarge(err):
+		return httperr.New(http.StatusRequestEntityTooLarge, httperr.CodePayloadTooLarge, fmt.Sprintf("Event feed too large. Maximum size is %dMB.", maxEventFeedSize>>20))
+	case errors.Is(err, errNotEventFeed), errors.As(err, &invalidFeed):
+		return httperr.BadRequest(err.Error())
+	case errors.Is(err, upload.ErrScanFailed):
+		return httperr.Unavailable(err.Error())
+	}
+	return httperr.Internal("Failed to import event feed")
+}
@@ -0,0 +1,110 @@
+package controllers_test
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"nivai/backend/pkg/controllers"
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/pythonapi"
+	"nivai/backend/pkg/services"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const statsBombFeed = `[
+{"id":"e1","index":1,"period":1,"timestamp":"00:00:00.000","minute":0,"second":0,"type":{"id":35,"name":"Starting XI"},"possession_team":{"id":1,"name":"Ajax"},"team":{"id":1,"name":"Ajax"}},
+{"id":"e2","index":2,"period":1,"timestamp":"00:00:01.000","minute":0,"second":1,"type":{"id":30,"name":"Pass"},"possession_team":{"id":1,"name":"Ajax"},"team":{"id":1,"name":"Ajax"},"player":{"id":7,"name":"A"},"location":[60.0,40.0],"pass":{"end_location":[70.0,40.0]}}
+]`
+
+// newEventImportRouter serves the event import of match v1, completed with
+// files in memory storage, against a fake Python API that records the event
+// files it is asked to process.
+func newEventImportRouter(t *testing.T, state models.ProcessingState) (*mux.Router, *services.MemoryStorageService, *models.MemoryVideoRepository, *[]string) {
+	var processed []string
+	pythonServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		processed = append(processed, string(body))
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(`{"message":"ok"}`))
+	}))
+	t.Cleanup(pythonServer.Close)
+
+	storage := services.NewMemoryStorageService()
+	_, err := storage.UploadStream(strings.NewReader("tracking rows"), "videos/v1/v1_tracking.gzip")
+	require.NoError(t, err)
+	_, err = storage.UploadStream(strings.NewReader("event rows"), "videos/v1/v1_events.gzip")
+	require.NoError(t, err)
+	videoRepo := models.NewMemoryVideoRepository()
+	require.NoError(t, videoRepo.Create(&models.Video{
+		ID: "v1", Title: "Ajax - PSV", ProcessingState: state,
+		TrackingPath: "videos/v1/v1_tracking.gzip", EventFilePath: "videos/v1/v1_events.gzip",
+	}))
+
+	vc := controllers.NewVideoController(services.NewVideoService(videoRepo, storage), storage,
+		pythonapi.NewClient(pythonServer.URL, pythonServer.Client()), nil)
+	router := mux.NewRouter()
+	router.HandleFunc("/matches/{id}/events/import", vc.ImportMatchEvents).Methods("POST")
+	return router, storage, videoRepo, &processed
+}
+
+// storedEvents reads match v1's event file.
+func storedEvents(t *testing.T, storage *services.MemoryStorageService) []byte {
+	t.Helper()
+	r, err := storage.GetFile("videos/v1/v1_events.gzip")
+	require.NoError(t, err)
+	defer r.Close()
+	content, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return content
+}
+
+func TestImportMatchEvents(t *testing.T) {
+	t.Run("A feed replaces the events and the match is reprocessed", func(t *testing.T) {
+		router, storage, videoRepo, processed := newEventImportRouter(t, models.StateCompleted)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("POST", "/matches/v1/events/import", strings.NewReader(statsBombFeed)))
+
+		require.Equal(t, http.StatusAccepted, rr.Code, rr.Body.String())
+		assert.True(t, bytes.HasPrefix(storedEvents(t, storage), []byte("PAR1")))
+		video, err := videoRepo.FindByID("v1")
+		require.NoError(t, err)
+		assert.Equal(t, models.StatePendingAnalytics, video.ProcessingState)
+		require.Len(t, *processed, 1)
+		assert.Contains(t, (*processed)[0], "v1_events.gzip")
+	})
+
+	t.Run("What is not an event feed leaves the match alone", func(t *testing.T) {
+		router, storage, _, processed := newEventImportRouter(t, models.StateCompleted)
+
+		for _, target := range []string{"/matches/v1/events/import", "/matches/v1/events/import?provider=opta", "/matches/v1/events/import?provider=tracab"} {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest("POST", target, strings.NewReader("event_id,event_type\n")))
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code, target)
+		}
+		assert.Equal(t, "event rows", string(storedEvents(t, storage)))
+		assert.Empty(t, *processed)
+	})
+
+	t.Run("Matches being processed cannot be changed", func(t *testing.T) {
+		router, storage, _, _ := newEventImportRouter(t, models.StateProcessing)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("POST", "/matches/v1/events/import", strings.NewReader(statsBombFeed)))
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		assert.Equal(t, "event rows", string(storedEvents(t, storage)))
+
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("POST", "/matches/missing/events/import", strings.NewReader(statsBombFeed)))
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
@@ -0,0 +1,86 @@
+package controllers
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"nivai/backend/pkg/httperr"
+	"nivai/backend/pkg/requestctx"
+	"nivai/backend/pkg/services"
+
+	"github.com/gorilla/mux"
+)
+
+// FileController serves stored files through the temporary signed URLs
+// local storage hands out instead of file:// URLs.
+type FileController struct {
+	storage services.StorageService
+	urls    *services.FileURLSigner
+}
+
+// NewFileController creates a new controller for signed file URLs.
+func NewFileController(storage services.StorageService, urls *services.FileURLSigner) *FileController {
+	return &FileController{storage: storage, urls: urls}
+}
+
+// ServeSignedFile handles GET /api/v1/files/{token}.
+// The token is the credential, so the route is public. Range requests are
+// honoured, so interrupted downloads and video seeking resume where they
+// left off.
+func (fc *FileController) ServeSignedFile(w http.ResponseWriter, r *http.Request) {
+	info := requestctx.From(r)
+	if fc.urls == nil {
+		httperr.WriteError(w, r, httperr.NotFound("File not found"))
+		return
+	}
+	path, err := fc.urls.Verify(mux.Vars(r)["token"])
+	switch {
+	case errors.Is(err, services.ErrFileURLExpired):
+		httperr.WriteError(w, r, httperr.New(http.StatusGone, httperr.CodeGone, err.Error()))
+		return
+	case err != nil:
+		httperr.WriteError(w, r, httperr.NotFound("File not found"))
+		return
+	}
+
+	file, err := fc.storage.GetFile(path)
+	if err != nil {
+		info.Logger.Printf("Error opening signed file %s: %v", path, err)
+		if strings.Contains(err.Error(), "not found") {
+			httperr.WriteError(w, r, httperr.NotFound("Stored file not found"))
+		} else {
+			httperr.WriteError(w, r, httperr.Internal("Failed to retrieve file"))
+		}
+		return
+	}
+	defer file.Close()
+
+	contentType := mime.TypeByExtension(filepath.Ext(path))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "private, no-store")
+
+	if seeker, ok := file.(io.ReadSeeker); ok {
+		// The modification time lets If-Range resume only unchanged files
+		var modified time.Time
+		if stat, ok := file.(interface{ Stat() (fs.FileInfo, error) }); ok {
+			if fi, err := stat.Stat(); err == nil {
+				modified = fi.ModTime()
+			}
+		}
+		http.ServeContent(w, r, "", modified, seeker)
+		return
+	}
+	w.Header().Set("Accept-Ranges", "none")
+	if _, err := io.Copy(w, file); err != nil {
+		info.Logger.Printf("Error streaming signed file %s: %v", path, err)
+	}
+}
@@ -0,0 +1,77 @@
+package controllers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"nivai/backend/pkg/controllers"
+	"nivai/backend/pkg/services"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileController(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "videos", "m1"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "videos", "m1", "m1.mp4"), []byte("0123456789"), 0644))
+
+	urls := services.NewFileURLSigner("secret", "", time.Hour)
+	storage, err := services.NewLocalFileStorage(dir, services.WithSignedURLs(urls))
+	require.NoError(t, err)
+	fc := controllers.NewFileController(storage, urls)
+	router := mux.NewRouter()
+	router.HandleFunc("/api/v1/files/{token}", fc.ServeSignedFile).Methods("GET")
+
+	serve := func(target string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", target, nil)
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("Local stream URLs are served by the API with range support", func(t *testing.T) {
+		url, err := storage.GetStreamURL("videos/m1/m1.mp4")
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(url, "/api/v1/files/"), url)
+
+		rr := serve(url, nil)
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "0123456789", rr.Body.String())
+		assert.Equal(t, "video/mp4", rr.Header().Get("Content-Type"))
+		assert.Equal(t, "bytes", rr.Header().Get("Accept-Ranges"))
+
+		rr = serve(url, map[string]string{"Range": "bytes=4-"})
+		assert.Equal(t, http.StatusPartialContent, rr.Code)
+		assert.Equal(t, "456789", rr.Body.String())
+		assert.Equal(t, "bytes 4-9/10", rr.Header().Get("Content-Range"))
+
+		// A resumed download of a file changed since gets it whole
+		rr = serve(url, map[string]string{"Range": "bytes=4-", "If-Range": time.Now().Add(-48 * time.Hour).UTC().Format(http.TimeFormat)})
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "0123456789", rr.Body.String())
+	})
+
+	t.Run("Invalid and expired URLs are refused", func(t *testing.T) {
+		url, err := storage.GetStreamURL("videos/m1/m1.mp4")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, serve(url+"x", nil).Code)
+
+		expired, err := services.NewFileURLSigner("secret", "", time.Nanosecond).Sign("videos/m1/m1.mp4")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusGone, serve(expired, nil).Code)
+
+		missing, err := urls.Sign("videos/m2/m2.mp4")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, serve(missing, nil).Code)
+	})
+}
@@ -2,8 +2,12 @@ package controllers
 
 import (
 	"encoding/json"
+	"log"
 	"net/http"
 	"time"
+
+	"nivai/backend/pkg/httperr"
+	"nivai/backend/pkg/lifecycle"
 )
 
 /**
@@ -29,7 +33,41 @@ func HealthCheck(w http.ResponseWriter, r *http.Request) {
 	// Write JSON response
 	if err := json.NewEncoder(w).Encode(response); err != nil {
 		// Log error but don't expose to client
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		httperr.WriteError(w, r, httperr.Internal("Internal server error"))
 		return
 	}
 }
+
+/**
+ * Readiness returns the readiness probe endpoint. Unlike HealthCheck, which
+ * only reports that the process is alive, it reports 503 Service Unavailable
+ * once the instance starts draining for shutdown, so load balancers stop
+ * routing new traffic to it while in-flight requests, uploads and WebSocket
+ * connections finish. A nil manager is always ready.
+ *
+ * @param manager The lifecycle manager
+ * @return The readiness handler
+ */
+func Readiness(manager *lifecycle.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		response := map[string]interface{}{
+			"status":    "ready",
+			"timestamp": time.Now().Format(time.RFC3339),
+		}
+		status := http.StatusOK
+		if manager != nil {
+			response["in_flight"] = manager.InFlight()
+			if !manager.Ready() {
+				response["status"] = "draining"
+				status = http.StatusServiceUnavailable
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(status)
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			log.Printf("Error encoding readiness response: %v", err)
+		}
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"nivai/backend/pkg/controllers" // Adjust import path as necessary
+	"nivai/backend/pkg/lifecycle"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -39,3 +40,19 @@ func TestHealthCheck(t *testing.T) {
 	// Check if the timestamp is recent (e.g., within the last 5 seconds)
 	assert.WithinDuration(t, time.Now(), timestamp, 5*time.Second, "Timestamp should be recent")
 }
+
+func TestReadiness(t *testing.T) {
+	manager := lifecycle.New(lifecycle.Config{})
+	handler := controllers.Readiness(manager)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/ready", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"status":"ready"`)
+
+	manager.Drain()
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/ready", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"status":"draining"`)
+}
@@ -0,0 +1,30 @@
+package controllers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"nivai/backend/pkg/httpclient"
+)
+
+// HTTPClientController exposes outbound HTTP client metrics to administrators.
+type HTTPClientController struct {
+	clients *httpclient.Factory
+}
+
+// NewHTTPClientController creates a new controller for outbound client metrics.
+func NewHTTPClientController(clients *httpclient.Factory) *HTTPClientController {
+	return &HTTPClientController{clients: clients}
+}
+
+// GetStats handles GET /api/v1/admin/http-clients.
+// It reports request counts, errors, status classes, connection reuse and
+// latency for each outbound destination.
+func (hc *HTTPClientController) GetStats(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := json.NewEncoder(w).Encode(hc.clients.Stats()); err != nil {
+		log.Printf("Error encoding GetStats response: %v", err)
+	}
+}
@@ -0,0 +1,222 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"nivai/backend/pkg/httperr"
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/requestctx"
+	"nivai/backend/pkg/services"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// WithMatchBundles enables exporting matches as tar.gz bundles and
+// importing them again. Without it, both endpoints answer 501.
+func WithMatchBundles(bundles *services.MatchBundleService) VideoControllerOption {
+	return func(vc *VideoController) {
+		vc.bundles = bundles
+	}
+}
+
+// ExportMatch handles GET /api/v1/matches/{id}/export.
+// It downloads a tar.gz bundle with the match's metadata, its stored files
+// and, with `include_analytics=true`, its stored analytics summary.
+// Archived and quarantined matches answer 409.
+func (vc *VideoController) ExportMatch(w http.ResponseWriter, r *http.Request) {
+	logger := requestctx.From(r).Logger
+	if vc.bundles == nil {
+		httperr.WriteError(w, r, httperr.NotImplemented("Match bundles are not configured"))
+		return
+	}
+
+	includeAnalytics := false
+	if value := r.URL.Query().Get("include_analytics"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			httperr.WriteError(w, r, httperr.BadRequest("Invalid include_analytics: expected true or false"))
+			return
+		}
+		includeAnalytics = parsed
+	}
+
+	export, err := vc.bundles.Export(mux.Vars(r)["id"], includeAnalytics)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrVideoNotFound):
+			httperr.WriteError(w, r, httperr.NotFound("Match not found"))
+		case errors.Is(err, services.ErrBundleUnavailable):
+			httperr.WriteError(w, r, httperr.Conflict(err.Error()))
+		default:
+			logger.Printf("Error exporting match: %v", err)
+			httperr.WriteError(w, r, httperr.Internal("Failed to export match"))
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", export.Filename()))
+	w.Header().Set("Cache-Control", "no-store")
+	if err := export.WriteTarGz(r.Context(), w); err != nil {
+		// The download has started; the client sees a truncated archive
+		logger.Printf("Error writing bundle of match %s: %v", export.Manifest.VideoID, err)
+	}
+}
+
+// ImportMatch handles POST /api/v1/matches/import.
+// The request body is a bundle as ExportMatch produces. The match is created
+// under a new ID with its files stored, scanned and charged like an upload,
+// and its processing starts; infected bundles are quarantined.
+func (vc *VideoController) ImportMatch(w http.ResponseWriter, r *http.Request) {
+	info := requestctx.From(r)
+	if vc.bundles == nil {
+		httperr.WriteError(w, r, httperr.NotImplemented("Match bundles are not configured"))
+		return
+	}
+	if !vc.limitToQuota(w, r, info) {
+		return
+	}
+
+	videoID := uuid.New().String()
+	threats := map[string]string{}
+	bundle, err := vc.bundles.Import(r.Context(), r.Body, vc.storeBundleFile(vc.uploadDir(videoID, info.Org), videoID, threats))
+	if err == nil && !hasFileKinds(bundle.Files, models.FileKindTracking, models.FileKindEvents) {
+		err = fmt.Errorf("%w: tracking and event files are required", services.ErrInvalidBundle)
+	}
+	if err != nil {
+		if bundle != nil {
+			for _, file := range bundle.Files {
+				vc.storageService.DeleteFile(file.Path)
+			}
+		}
+		if quotaErr := quotaError(err); quotaErr != nil {
+			httperr.WriteError(w, r, quotaErr)
+			return
+		}
+		if errors.Is(err, services.ErrInvalidBundle) {
+			httperr.WriteError(w, r, httperr.BadRequest(err.Error()))
+			return
+		}
+		info.Logger.Printf("Error importing match bundle: %v", err)
+		httperr.WriteError(w, r, uploadError(err))
+		return
+	}
+
+	video := importedVideo(videoID, bundle)
+	if !vc.saveUpload(w, r, video, bundle.Files, "imported from bundle of match "+bundle.Manifest.VideoID) {
+		return
+	}
+	if err := vc.bundles.SaveAnalytics(videoID, bundle.Analytics); err != nil {
+		info.Logger.Printf("Warning: Failed to save imported analytics of video %s: %v", videoID, err)
+	}
+	vc.finishUpload(w, r, video, threats, nil)
+}
+
+// storeBundleFile stores the files of an imported bundle in storageDir,
+// like uploaded files: each is capped, checksummed and scanned, and the
+// threats found are added to threats.
+func (vc *VideoController) storeBundleFile(storageDir, videoID string, threats map[string]string) services.BundleFileStore {
+	seen := map[string]bool{}
+	return func(ctx context.Context, file services.MatchBundleFile, src io.Reader) (*models.VideoFile, error) {
+		switch file.Kind {
+		case models.FileKindVideo, models.FileKindTracking, models.FileKindEvents:
+		default:
+			return nil, fmt.Errorf("%w: unknown file kind %q", services.ErrInvalidBundle, file.Kind)
+		}
+		if seen[file.Kind] {
+			return nil, fmt.Errorf("%w: more than one %s file", services.ErrInvalidBundle, file.Kind)
+		}
+		seen[file.Kind] = true
+		if limit := vc.uploadLimits.of(file.Kind); limit > 0 && file.Size > limit {
+			return nil, &partTooLargeError{kind: file.Kind, limit: limit}
+		}
+
+		// Storage backends may need to seek, which a tar stream cannot
+		spool, err := os.CreateTemp("", "nivai-import-*")
+		if err != nil {
+			return nil, fmt.Errorf("failed to spool %s file: %w", file.Kind, err)
+		}
+		defer os.Remove(spool.Name())
+		defer spool.Close()
+		if _, err := io.Copy(spool, src); err != nil {
+			return nil, fmt.Errorf("%w: failed to read %s file: %v", services.ErrInvalidBundle, file.Kind, err)
+		}
+		if _, err := spool.Seek(0, io.SeekStart); err != nil {
+			return nil, fmt.Errorf("failed to rewind %s file: %w", file.Kind, err)
+		}
+
+		path, size, checksum, threat, err := vc.saveUploadedFile(ctx, spool, &multipart.FileHeader{Filename: file.Name}, storageDir, videoID, file.Kind, nil)
+		if err != nil {
+			return nil, err
+		}
+		if threat != "" {
+			threats[file.Kind] = threat
+		}
+		return &models.VideoFile{Kind: file.Kind, Path: path, Size: size, Checksum: checksum}, nil
+	}
+}
+
+// importedVideo builds the metadata of an imported match: the bundle's
+// descriptive fields with the new ID and stored paths. It waits for
+// analytics again, as the analytics service has no results for it.
+func importedVideo(videoID string, bundle *services.ImportedBundle) *models.Video {
+	exported := bundle.Video
+	video := &models.Video{
+		ID:              videoID,
+		Title:           exported.Title,
+		Description:     exported.Description,
+		Duration:        exported.Duration,
+		Resolution:      exported.Resolution,
+		Format:          exported.Format,
+		ProcessingState: models.StatePendingAnalytics,
+		CreatedAt:       time.Now(),
+		MatchID:         exported.MatchID,
+		MatchDate:       exported.MatchDate,
+		HomeTeam:        exported.HomeTeam,
+		AwayTeam:        exported.AwayTeam,
+		Competition:     exported.Competition,
+		Season:          exported.Season,
+		Venue:           exported.Venue,
+		Referee:         exported.Referee,
+		Attendance:      exported.Attendance,
+		Weather:         exported.Weather,
+		KickoffAt:       exported.KickoffAt,
+		KickoffTimezone: exported.KickoffTimezone,
+	}
+	for _, file := range bundle.Files {
+		switch file.Kind {
+		case models.FileKindVideo:
+			video.FilePath = file.Path
+			video.Size = file.Size
+			video.StorageProvider = "default"
+		case models.FileKindTracking:
+			video.TrackingPath = file.Path
+		case models.FileKindEvents:
+			video.EventFilePath = file.Path
+		}
+	}
+	return video
+}
+
+// hasFileKinds reports whether files include every kind.
+func hasFileKinds(files []*models.VideoFile, kinds ...string) bool {
+	for _, kind := range kinds {
+		found := false
+		for _, file := range files {
+			found = found || file.Kind == kind
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,136 @@
+package controllers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"nivai/backend/pkg/controllers"
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/pythonapi"
+	"nivai/backend/pkg/services"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// newBundleRouter serves the export and import endpoints over in-memory
+// storage holding match v1, against a fake Python API accepting every match.
+func newBundleRouter(t *testing.T, videoSvc *MockVideoService, opts ...controllers.VideoControllerOption) (*mux.Router, *models.MemoryVideoRepository, *services.MemoryStorageService) {
+	pythonServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(`{"message":"ok"}`))
+	}))
+	t.Cleanup(pythonServer.Close)
+
+	storage := services.NewMemoryStorageService()
+	_, err := storage.UploadStream(strings.NewReader("tracking rows"), "videos/v1/v1_tracking.gzip")
+	require.NoError(t, err)
+	_, err = storage.UploadStream(strings.NewReader("event rows"), "videos/v1/v1_events.gzip")
+	require.NoError(t, err)
+	videoRepo := models.NewMemoryVideoRepository()
+	require.NoError(t, videoRepo.Create(&models.Video{
+		ID: "v1", Title: "Ajax - PSV", ProcessingState: models.StateCompleted,
+		TrackingPath: "videos/v1/v1_tracking.gzip", EventFilePath: "videos/v1/v1_events.gzip",
+	}))
+
+	bundles := services.NewMatchBundleService(videoRepo, nil, nil, storage)
+	vc := controllers.NewVideoController(videoSvc, storage, pythonapi.NewClient(pythonServer.URL, pythonServer.Client()), nil,
+		append([]controllers.VideoControllerOption{controllers.WithMatchBundles(bundles)}, opts...)...)
+	router := mux.NewRouter()
+	router.HandleFunc("/matches/import", vc.ImportMatch).Methods("POST")
+	router.HandleFunc("/matches/{id}/export", vc.ExportMatch).Methods("GET")
+	return router, videoRepo, storage
+}
+
+func TestMatchBundles(t *testing.T) {
+	t.Run("An exported match imports as a new match", func(t *testing.T) {
+		videoSvc := new(MockVideoService)
+		router, _, _ := newBundleRouter(t, videoSvc)
+		videoSvc.On("CreateVideoEntry", mock.MatchedBy(func(v *models.Video) bool {
+			return v.ID != "v1" && v.Title == "Ajax - PSV" && v.ProcessingState == models.StatePendingAnalytics
+		})).Return(&models.Video{}, nil).Once()
+		videoSvc.On("RecordVideoFiles", mock.Anything, mock.Anything).Return(nil).Once()
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/matches/v1/export", nil))
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		assert.Equal(t, "application/gzip", rr.Header().Get("Content-Type"))
+		assert.Contains(t, rr.Header().Get("Content-Disposition"), "match-v1.tar.gz")
+
+		imported := httptest.NewRecorder()
+		router.ServeHTTP(imported, httptest.NewRequest("POST", "/matches/import", bytes.NewReader(rr.Body.Bytes())))
+
+		require.Equal(t, http.StatusAccepted, imported.Code, imported.Body.String())
+		var body map[string]string
+		require.NoError(t, json.NewDecoder(imported.Body).Decode(&body))
+		assert.NotEqual(t, "v1", body["video_id"])
+		assert.Contains(t, body["tracking_path"], body["video_id"])
+		videoSvc.AssertExpectations(t)
+	})
+
+	t.Run("Archived matches cannot be exported", func(t *testing.T) {
+		router, videoRepo, _ := newBundleRouter(t, new(MockVideoService))
+		video, _ := videoRepo.FindByID("v1")
+		require.NoError(t, videoRepo.UpdateProcessingState("v1", video.ProcessingState, models.StateArchived, time.Now()))
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/matches/v1/export", nil))
+		assert.Equal(t, http.StatusConflict, rr.Code)
+
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/matches/missing/export", nil))
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("Rejects what is not a bundle", func(t *testing.T) {
+		videoSvc := new(MockVideoService)
+		router, _, _ := newBundleRouter(t, videoSvc)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("POST", "/matches/import", strings.NewReader("tracking rows")))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		videoSvc.AssertNotCalled(t, "CreateVideoEntry", mock.Anything)
+	})
+
+	t.Run("Chunked bundles stop at the storage quota and leave nothing stored", func(t *testing.T) {
+		usageRepo := new(MockStorageUsageRepository)
+		usageRepo.On("FindUsage", models.UsageScopeOrganization, mock.Anything).Return(&models.StorageUsage{Bytes: 990}, nil)
+		quotas := services.NewQuotaService(usageRepo, services.QuotaConfig{OrganizationBytes: 1000})
+		videoSvc := new(MockVideoService)
+		router, _, storage := newBundleRouter(t, videoSvc, controllers.WithQuotas(quotas))
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/matches/v1/export", nil))
+		require.Equal(t, http.StatusOK, rr.Code)
+		before, err := storage.ListFiles("")
+		require.NoError(t, err)
+
+		req := httptest.NewRequest("POST", "/matches/import", bytes.NewReader(rr.Body.Bytes()))
+		req.ContentLength = -1 // Transfer-Encoding: chunked
+		imported := httptest.NewRecorder()
+		router.ServeHTTP(imported, req)
+
+		assert.Equal(t, http.StatusPaymentRequired, imported.Code, imported.Body.String())
+		after, err := storage.ListFiles("")
+		require.NoError(t, err)
+		assert.Len(t, after, len(before))
+		videoSvc.AssertNotCalled(t, "CreateVideoEntry", mock.Anything)
+	})
+
+	t.Run("Answers 501 when bundles are not configured", func(t *testing.T) {
+		vc := controllers.NewVideoController(new(MockVideoService), new(MockStorageService), nil, nil)
+
+		rr := httptest.NewRecorder()
+		vc.ImportMatch(rr, httptest.NewRequest("POST", "/matches/import", nil))
+
+		assert.Equal(t, http.StatusNotImplemented, rr.Code)
+	})
+}
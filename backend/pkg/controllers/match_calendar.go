@@ -0,0 +1,77 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"nivai/backend/pkg/httperr"
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/requestctx"
+	"nivai/backend/pkg/services"
+)
+
+// WithMatchCalendar enables GET /api/v1/matches/calendar.
+func WithMatchCalendar(calendar *services.MatchCalendarService) MatchControllerOption {
+	return func(mc *MatchController) {
+		mc.calendar = calendar
+	}
+}
+
+// MatchCalendarResponse is the schedule view of a set of matches.
+type MatchCalendarResponse struct {
+	GroupBy string                    `json:"group_by"` // "week" or "month"
+	Total   int                       `json:"total"`    // Matches over all groups
+	Groups  []*services.CalendarGroup `json:"groups"`
+}
+
+// GetCalendar handles GET /api/v1/matches/calendar.
+// ?group_by=week|month (default week) sets the period; weeks start on
+// Monday, in UTC. ?season=, ?team=, ?competition=, ?from=&to= and
+// ?analytics_status= filter the matches as in the match list. Matches
+// without a match date are left out.
+func (mc *MatchController) GetCalendar(w http.ResponseWriter, r *http.Request) {
+	if mc.calendar == nil {
+		httperr.WriteError(w, r, httperr.NotImplemented("The match calendar is not configured"))
+		return
+	}
+
+	groupBy := r.URL.Query().Get("group_by")
+	if groupBy == "" {
+		groupBy = models.CalendarByWeek
+	}
+	if groupBy != models.CalendarByWeek && groupBy != models.CalendarByMonth {
+		httperr.WriteError(w, r, httperr.BadRequest(fmt.Sprintf("invalid group_by %q: use week or month", groupBy)))
+		return
+	}
+	filters, err := parseMatchFilters(r)
+	if err != nil {
+		httperr.WriteError(w, r, httperr.BadRequest(err.Error()))
+		return
+	}
+
+	var scope *models.AccessScope
+	if mc.access != nil {
+		if scope, err = mc.access.Scope(requestctx.From(r).Principal); err != nil {
+			log.Printf("Error resolving access scope for the match calendar: %v", err)
+			httperr.WriteError(w, r, httperr.Internal("Failed to build match calendar"))
+			return
+		}
+	}
+	groups, err := mc.calendar.Calendar(scope, filters, groupBy)
+	if err != nil {
+		log.Printf("Error building match calendar: %v", err)
+		httperr.WriteError(w, r, httperr.Internal("Failed to build match calendar"))
+		return
+	}
+
+	response := MatchCalendarResponse{GroupBy: groupBy, Groups: groups}
+	for _, group := range groups {
+		response.Total += group.Count
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Error encoding match calendar response: %v", err)
+	}
+}
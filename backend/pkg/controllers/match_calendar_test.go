@@ -0,0 +1,72 @@
+package controllers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"nivai/backend/pkg/controllers"
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/pythonapi"
+	"nivai/backend/pkg/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetCalendar(t *testing.T) {
+	repos := models.NewMemoryRepositories()
+	for _, video := range []*models.Video{
+		{ID: "m1", Title: "Ajax - PSV", HomeTeam: "Ajax", Season: "2024", MatchDate: time.Date(2024, 9, 1, 14, 30, 0, 0, time.UTC), ProcessingState: models.StateCompleted},
+		{ID: "m2", Title: "PSV - AZ", HomeTeam: "PSV", Season: "2024", MatchDate: time.Date(2024, 9, 1, 20, 0, 0, 0, time.UTC), ProcessingState: models.StateProcessing},
+		{ID: "m3", Title: "AZ - Ajax", HomeTeam: "AZ", Season: "2024", MatchDate: time.Date(2024, 9, 14, 18, 45, 0, 0, time.UTC), ProcessingState: models.StateArchived},
+		{ID: "m4", Title: "Old", Season: "2023", MatchDate: time.Date(2023, 9, 3, 12, 0, 0, 0, time.UTC), ProcessingState: models.StateFailed},
+	} {
+		require.NoError(t, repos.Videos.Create(video))
+	}
+	mc := controllers.NewMatchController(services.NewVideoService(repos.Videos, new(MockStorageService)), pythonapi.NewClient("", nil),
+		controllers.WithMatchCalendar(services.NewMatchCalendarService(repos.MatchCalendar)))
+	get := func(query string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(mc.GetCalendar).ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/matches/calendar?"+query, nil))
+		return rr
+	}
+
+	t.Run("Matches are grouped by week with status counts", func(t *testing.T) {
+		rr := get("season=2024")
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var calendar controllers.MatchCalendarResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &calendar))
+		assert.Equal(t, "week", calendar.GroupBy)
+		assert.Equal(t, 3, calendar.Total)
+		require.Len(t, calendar.Groups, 2)
+		week := calendar.Groups[0]
+		assert.Equal(t, time.Date(2024, 8, 26, 0, 0, 0, 0, time.UTC), week.Start)
+		assert.Equal(t, time.Date(2024, 9, 2, 0, 0, 0, 0, time.UTC), week.End)
+		assert.Equal(t, 2, week.Count)
+		assert.Equal(t, map[string]int{"processed": 1, "pending": 1}, week.AnalyticsStatuses)
+		assert.Equal(t, map[models.ProcessingState]int{models.StateCompleted: 1, models.StateProcessing: 1}, week.States)
+		require.Len(t, week.Matches, 2)
+		assert.Equal(t, "Ajax - PSV", week.Matches[0].Title)
+	})
+
+	t.Run("Months combine the weeks and filters apply", func(t *testing.T) {
+		rr := get("group_by=month&analytics_status=processed")
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var calendar controllers.MatchCalendarResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &calendar))
+		require.Len(t, calendar.Groups, 1)
+		assert.Equal(t, time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC), calendar.Groups[0].End)
+		assert.Equal(t, map[string]int{"processed": 2}, calendar.Groups[0].AnalyticsStatuses)
+	})
+
+	t.Run("Invalid parameters are rejected", func(t *testing.T) {
+		for _, query := range []string{"group_by=day", "analytics_status=done", "from=yesterday"} {
+			assert.Equal(t, http.StatusBadRequest, get(query).Code, query)
+		}
+	})
+}
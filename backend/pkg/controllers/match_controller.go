@@ -1,176 +1,398 @@
 package controllers
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"io"
 	"log"
 	"net/http"
-	"os"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
+	"nivai/backend/pkg/httperr"
 	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/pythonapi"
 	"nivai/backend/pkg/services"
+	"nivai/backend/pkg/stream"
 	// "github.com/gorilla/mux" // Not strictly needed if not extracting path vars here
 )
 
 // MatchController handles requests related to matches.
 type MatchController struct {
-	videoService     services.VideoService
-	PythonApiBaseUrl string
-	HttpClient       *http.Client
-}
-
-// NewMatchController creates a new MatchController.
-// If pythonApiBaseUrl is empty, it tries to get it from PYTHON_API_URL env var,
-// then defaults to "http://localhost:8081".
-// If client is nil, a default client with a 10-second timeout is used.
-func NewMatchController(vs services.VideoService, pythonApiBaseUrl string, client *http.Client) *MatchController {
-	if pythonApiBaseUrl == "" {
-		envURL := os.Getenv("PYTHON_API_URL")
-		if envURL != "" {
-			pythonApiBaseUrl = envURL
-		} else {
-			pythonApiBaseUrl = "http://localhost:8081" // Default
+	videoService services.VideoService
+	pythonClient *pythonapi.Client
+	snapshots    *services.AnalyticsSnapshotService
+	access       *services.AccessService
+	storage      services.StorageService
+	files        models.VideoFileRepository
+	shares       *services.ShareService
+	calendar     *services.MatchCalendarService
+
+	statusConcurrency int           // Status calls to the Python API in flight per list
+	statusTimeout     time.Duration // Limit of each status call
+	breaker           *statusBreaker
+	statusCache       *statusCache
+}
+
+// MatchControllerOption configures optional MatchController behaviour.
+type MatchControllerOption func(*MatchController)
+
+// WithSnapshots enables ?include=key_players, served from stored analytics
+// snapshots.
+func WithSnapshots(snapshots *services.AnalyticsSnapshotService) MatchControllerOption {
+	return func(mc *MatchController) {
+		mc.snapshots = snapshots
+	}
+}
+
+// WithMatchAccessControl limits match lists to the matches granted to the
+// caller.
+func WithMatchAccessControl(access *services.AccessService) MatchControllerOption {
+	return func(mc *MatchController) {
+		mc.access = access
+	}
+}
+
+// WithStatusFanOut limits the analytics status calls a match list makes to
+// the Python API: at most concurrency in flight, each given up after
+// timeout. Zero values keep the defaults of 8 calls and 5 seconds.
+func WithStatusFanOut(concurrency int, timeout time.Duration) MatchControllerOption {
+	return func(mc *MatchController) {
+		if concurrency > 0 {
+			mc.statusConcurrency = concurrency
+		}
+		if timeout > 0 {
+			mc.statusTimeout = timeout
 		}
-		log.Println("Using Python API URL for MatchController:", pythonApiBaseUrl)
 	}
-	if client == nil {
-		client = &http.Client{Timeout: time.Second * 10}
+}
+
+// NewMatchController creates a new MatchController backed by the given
+// video service and Python API client.
+func NewMatchController(vs services.VideoService, pythonClient *pythonapi.Client, opts ...MatchControllerOption) *MatchController {
+	mc := &MatchController{
+		videoService:      vs,
+		pythonClient:      pythonClient,
+		statusConcurrency: 8,
+		statusTimeout:     5 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(mc)
 	}
-	return &MatchController{
-		videoService:     vs,
-		PythonApiBaseUrl: pythonApiBaseUrl,
-		HttpClient:       client,
+	return mc
+}
+
+// keyPlayersPerMatch is how many top performers ?include=key_players adds.
+const keyPlayersPerMatch = 3
+
+// includeKeyPlayers reports whether the request asks for key players
+// (?include=key_players, comma-separated with other includes).
+func includeKeyPlayers(r *http.Request) bool {
+	for _, include := range strings.Split(r.URL.Query().Get("include"), ",") {
+		if strings.TrimSpace(include) == "key_players" {
+			return true
+		}
 	}
+	return false
 }
 
 // MatchListItem represents a single item in the list of matches.
 type MatchListItem struct {
-	ID              string    `json:"id"`
-	MatchName       string    `json:"match_name"`  // This is video.Title
-	UploadDate      time.Time `json:"upload_date"` // This is video.CreatedAt
-	AnalyticsStatus string    `json:"analytics_status"`
-	HomeTeam        string    `json:"home_team,omitempty"`
-	AwayTeam        string    `json:"away_team,omitempty"`
-	Competition     string    `json:"competition,omitempty"`
-	Season          string    `json:"season,omitempty"`
+	ID              string     `json:"id"`
+	MatchName       string     `json:"match_name"`  // This is video.Title
+	UploadDate      time.Time  `json:"upload_date"` // This is video.CreatedAt
+	AnalyticsStatus string     `json:"analytics_status"`
+	HomeTeam        string     `json:"home_team,omitempty"`
+	AwayTeam        string     `json:"away_team,omitempty"`
+	Competition     string     `json:"competition,omitempty"`
+	Season          string     `json:"season,omitempty"`
+	Venue           string     `json:"venue,omitempty"`
+	KickoffAt       *time.Time `json:"kickoff_at,omitempty"` // In the venue's time zone when known
+	// Set when the analytics status could not be fetched and is served from
+	// when it was last fetched, or from the processing state without a time
+	StatusStale     bool       `json:"status_stale,omitempty"`
+	StatusCheckedAt *time.Time `json:"status_checked_at,omitempty"`
+	// Top performers from the stored snapshot, only with ?include=key_players
+	// and only once the match has been processed.
+	KeyPlayers []services.KeyPlayer `json:"key_players,omitempty"`
 	// Potentially other fields like video thumbnail, duration etc.
 }
 
-// PythonStatusResponse is used to decode the status from the Python API.
-// Note: This struct might be duplicated in tests if not exported or shared.
-// For now, keeping it unexported as it's specific to this controller's interaction.
-type PythonStatusResponse struct {
-	Status  string `json:"status"`
-	Message string `json:"message,omitempty"`
-}
-
-// getAnalyticsStatus fetches the analytics status for a given match ID.
-// It's a method of MatchController now.
-func (mc *MatchController) getAnalyticsStatus(matchID string, wg *sync.WaitGroup, statusChan chan<- struct {
-	id     string
-	status string
-	err    error
-}) {
-	if wg != nil {
-		defer wg.Done()
+// getAnalyticsStatus fetches the analytics status of a video. Failures are
+// reported as "error_*" statuses so the list can still be served; with
+// WithStatusDegradation, statuses the Python API cannot give are served
+// stale instead.
+func (mc *MatchController) getAnalyticsStatus(ctx context.Context, video *models.Video) analyticsStatus {
+	if mc.breaker != nil {
+		return mc.degradedStatus(ctx, video)
 	}
+	status, _ := mc.fetchAnalyticsStatus(ctx, video.ID)
+	return status
+}
 
-	statusUrl := fmt.Sprintf("%s/match/%s/status", mc.PythonApiBaseUrl, matchID)
-	var analyticsStatus string
-	var anError error
+// fetchAnalyticsStatus calls the Python API for the analytics status of a
+// match, giving up after the per-call timeout. A failed call returns its
+// "error_*" status with the error.
+func (mc *MatchController) fetchAnalyticsStatus(ctx context.Context, matchID string) (analyticsStatus, error) {
+	ctx, cancel := context.WithTimeout(ctx, mc.statusTimeout)
+	defer cancel()
 
-	resp, err := mc.HttpClient.Get(statusUrl)
-	if err != nil {
+	status, err := mc.pythonClient.GetMatchStatus(ctx, matchID)
+	var apiErr *pythonapi.APIError
+	var failed string
+	switch {
+	case err == nil:
+		return analyticsStatus{MatchStatus: *status}, nil
+	case errors.As(err, &apiErr):
+		log.Printf("Non-OK status (%d) fetching analytics status for match %s: %s", apiErr.StatusCode, matchID, apiErr.Detail)
+		failed = fmt.Sprintf("error_status_%d", apiErr.StatusCode)
+	case errors.Is(err, pythonapi.ErrInvalidResponse):
+		log.Printf("Error decoding analytics status for match %s: %v", matchID, err)
+		failed = "error_decoding_status"
+	case errors.Is(err, context.DeadlineExceeded):
+		log.Printf("Timed out after %s fetching analytics status for match %s", mc.statusTimeout, matchID)
+		failed = "error_timeout"
+	default:
 		log.Printf("Error fetching analytics status for match %s: %v", matchID, err)
-		analyticsStatus = "error_fetching_status"
-		anError = err
-	} else {
-		defer resp.Body.Close()
-		if resp.StatusCode == http.StatusOK {
-			var statusResp PythonStatusResponse
-			if err := json.NewDecoder(resp.Body).Decode(&statusResp); err != nil {
-				log.Printf("Error decoding analytics status for match %s: %v", matchID, err)
-				analyticsStatus = "error_decoding_status"
-				anError = err
-			} else {
-				analyticsStatus = statusResp.Status
+		failed = "error_fetching_status"
+	}
+	return analyticsStatus{MatchStatus: pythonapi.MatchStatus{Status: failed}}, err
+}
+
+// resolveStatuses fetches the analytics status of each video with at most
+// the configured number of calls to the Python API in flight. Statuses are
+// returned in the order of videos; videos not reached before ctx is done get
+// "error_fetching_status". It also returns how many statuses are errors.
+func (mc *MatchController) resolveStatuses(ctx context.Context, videos []*models.Video) ([]analyticsStatus, int) {
+	statuses := make([]analyticsStatus, len(videos))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(mc.statusConcurrency, len(videos)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				statuses[i] = mc.getAnalyticsStatus(ctx, videos[i])
 			}
-		} else {
-			bodyBytes, _ := io.ReadAll(resp.Body) // Read body for more context on error
-			log.Printf("Non-OK status (%s) fetching analytics status for match %s: %s", resp.Status, matchID, string(bodyBytes))
-			analyticsStatus = fmt.Sprintf("error_status_%d", resp.StatusCode)
-			anError = fmt.Errorf("status %d: %s", resp.StatusCode, string(bodyBytes))
+		}()
+	}
+
+feed:
+	for i := range videos {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	failed := 0
+	for i, status := range statuses {
+		if status.Status == "" {
+			statuses[i].Status = "error_fetching_status"
+		}
+		if strings.HasPrefix(statuses[i].Status, "error_") {
+			failed++
 		}
 	}
-	statusChan <- struct {
-		id     string
-		status string
-		err    error
-	}{matchID, analyticsStatus, anError}
+	return statuses, failed
+}
+
+// syncProcessingState records a finished analytics run reported by the Python API
+// on the video record, which in turn publishes analytics.completed/failed events.
+// Only videos still waiting on analytics are updated. The error message of a
+// failed run becomes the reason in the state history, where the jobs API
+// reads it.
+func (mc *MatchController) syncProcessingState(video *models.Video, analytics analyticsStatus) {
+	if analytics.stale || video.ProcessingState != models.StatePendingAnalytics && video.ProcessingState != models.StateProcessing {
+		return
+	}
+
+	var newState models.ProcessingState
+	switch analytics.Status {
+	case pythonapi.StatusProcessed:
+		newState = models.StateCompleted
+	case pythonapi.StatusError:
+		newState = models.StateFailed
+	default:
+		return
+	}
+
+	reason := "analytics status " + analytics.Status
+	if analytics.Message != "" {
+		reason += ": " + analytics.Message
+	}
+	change := services.StateChange{Actor: services.ActorAnalytics, Reason: reason}
+	if err := mc.videoService.UpdateProcessingState(video.ID, newState, change); err != nil {
+		log.Printf("Error updating processing state for match %s: %v", video.ID, err)
+	}
 }
 
+// matchStreamBatchSize is how many videos are read per page when streaming
+// the full match list.
+const matchStreamBatchSize = 100
+
+// matchListDefaultLimit is the page size of match lists without ?limit=.
+const matchListDefaultLimit = 20
+
 // ListMatches handles requests to list all matches.
+// Clients asking for NDJSON (?format=ndjson or Accept: application/x-ndjson)
+// receive every match, one per line, as each page is resolved.
+// ?limit=&offset= page the list, and ?team=, ?competition=, ?season=,
+// ?match_id=, ?from=&to= (match date) and ?analytics_status= filter it.
+// ?sort=match_date|created_at|title&order=asc|desc sets the order.
 func (mc *MatchController) ListMatches(w http.ResponseWriter, r *http.Request) {
-	defaultLimit := 20
-	defaultOffset := 0
-	videos, err := mc.videoService.ListVideos(defaultLimit, defaultOffset, make(map[string]string))
+	filters, err := parseMatchFilters(r)
+	if err != nil {
+		httperr.WriteError(w, r, httperr.BadRequest(err.Error()))
+		return
+	}
+	limit, offset := parsePage(r, matchListDefaultLimit)
+
+	if stream.Requested(r) {
+		mc.streamMatches(w, r, offset, filters)
+		return
+	}
+
+	videos, err := listAccessibleVideos(r, mc.videoService, mc.access, limit, offset, filters)
 	if err != nil {
 		log.Printf("Error listing videos: %v", err)
-		http.Error(w, "Failed to retrieve match list", http.StatusInternalServerError)
+		httperr.WriteError(w, r, httperr.Internal("Failed to retrieve match list"))
 		return
 	}
 
-	if videos == nil {
-		videos = []*models.Video{}
+	// The list is served even when some statuses could not be fetched; the
+	// header tells clients which items to refresh later
+	matchListItems, failed, degraded := mc.buildMatchListItems(r.Context(), videos, includeKeyPlayers(r))
+	if failed > 0 {
+		w.Header().Set("X-Partial-Results", strconv.Itoa(failed))
+	}
+	if degraded {
+		markDegraded(w, degradedAnalytics)
 	}
 
-	matchListItems := make([]MatchListItem, len(videos))
-	statusChan := make(chan struct {
-		id     string
-		status string
-		err    error
-	}, len(videos))
-	var wg sync.WaitGroup
+	if err := writePage(w, r, matchListItems, len(matchListItems), limit, offset, degraded); err != nil {
+		log.Printf("Error encoding match list response: %v", err)
+	}
+}
 
-	if len(videos) > 0 {
-		for _, video := range videos {
-			wg.Add(1)
-			go mc.getAnalyticsStatus(video.ID, &wg, statusChan)
+// parseMatchFilters extracts the filters of a match list: those of video
+// lists, plus the analytics status, which is matched against the
+// processing states synced from the analytics service.
+func parseMatchFilters(r *http.Request) (map[string]string, error) {
+	filters, err := parseVideoFilters(r)
+	if err != nil {
+		return nil, err
+	}
+	if status := r.URL.Query().Get("analytics_status"); status != "" {
+		if _, err := services.AnalyticsStatusStates(status); err != nil {
+			return nil, err
 		}
+		filters["analytics_status"] = status
+	}
+	return filters, nil
+}
+
+// streamMatches writes the match list from offset on as NDJSON, paging
+// through the videos so the first matches reach the client before the last
+// page is read.
+func (mc *MatchController) streamMatches(w http.ResponseWriter, r *http.Request, offset int, filters map[string]string) {
+	videos, err := listAccessibleVideos(r, mc.videoService, mc.access, matchStreamBatchSize, offset, filters)
+	if err != nil {
+		log.Printf("Error listing videos: %v", err)
+		httperr.WriteError(w, r, httperr.Internal("Failed to retrieve match list"))
+		return
+	}
 
-		wg.Wait()
-		close(statusChan)
+	if mc.breaker != nil && mc.breaker.open() {
+		markDegraded(w, degradedAnalytics)
+	}
+	out := stream.New(w, r)
+	defer out.Close()
 
-		statuses := make(map[string]string)
-		for res := range statusChan {
-			if res.err != nil {
-				log.Printf("Error detail for match %s status check: %v", res.id, res.err)
+	keyPlayers := includeKeyPlayers(r)
+	for {
+		items, _, _ := mc.buildMatchListItems(r.Context(), videos, keyPlayers)
+		for _, item := range items {
+			if err := out.Write(item); err != nil {
+				log.Printf("Match list stream aborted: %v", err)
+				return
 			}
-			statuses[res.id] = res.status
+		}
+		if len(videos) < matchStreamBatchSize {
+			return
 		}
 
+		offset += len(videos)
+		videos, err = listAccessibleVideos(r, mc.videoService, mc.access, matchStreamBatchSize, offset, filters)
+		if err != nil {
+			log.Printf("Error listing videos at offset %d: %v", offset, err)
+			out.Fail("Failed to retrieve match list")
+			return
+		}
+	}
+}
+
+// buildMatchListItems resolves the analytics status of each video concurrently
+// and returns the corresponding list items in the same order, with the number
+// of items whose status could not be resolved and whether the list is
+// degraded: statuses were served stale or the status circuit is open. With
+// withKeyPlayers, the top performers of every page are read in one query.
+func (mc *MatchController) buildMatchListItems(ctx context.Context, videos []*models.Video, withKeyPlayers bool) ([]MatchListItem, int, bool) {
+	matchListItems := make([]MatchListItem, len(videos))
+	degraded := mc.breaker != nil && mc.breaker.open()
+	if len(videos) == 0 {
+		return matchListItems, 0, degraded
+	}
+
+	statuses, failed := mc.resolveStatuses(ctx, videos)
+
+	var keyPlayers map[string][]services.KeyPlayer
+	if withKeyPlayers && mc.snapshots != nil {
+		ids := make([]string, len(videos))
 		for i, video := range videos {
-			matchListItems[i] = MatchListItem{
-				ID:              video.ID,
-				MatchName:       video.Title,
-				UploadDate:      video.CreatedAt,
-				AnalyticsStatus: statuses[video.ID],
-				HomeTeam:        video.HomeTeam,
-				AwayTeam:        video.AwayTeam,
-				Competition:     video.Competition,
-				Season:          video.Season,
-			}
+			ids[i] = video.ID
+		}
+		var err error
+		if keyPlayers, err = mc.snapshots.KeyPlayers(ids, keyPlayersPerMatch); err != nil {
+			// The list is still useful without the chips.
+			log.Printf("Error loading key players for match list: %v", err)
 		}
-	} else {
-		close(statusChan) // Ensure channel is closed even if no videos
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(matchListItems); err != nil {
-		log.Printf("Error encoding match list response: %v", err)
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	for i, video := range videos {
+		mc.syncProcessingState(video, statuses[i])
+		matchListItems[i] = MatchListItem{
+			ID:              video.ID,
+			MatchName:       video.Title,
+			UploadDate:      video.CreatedAt,
+			AnalyticsStatus: statuses[i].Status,
+			HomeTeam:        video.HomeTeam,
+			AwayTeam:        video.AwayTeam,
+			Competition:     video.Competition,
+			Season:          video.Season,
+			Venue:           video.Venue,
+			KickoffAt:       video.KickoffAt,
+			KeyPlayers:      keyPlayers[video.ID],
+		}
+		if statuses[i].stale {
+			matchListItems[i].StatusStale = true
+			matchListItems[i].StatusCheckedAt = checkedAt(statuses[i])
+			degraded = true
+		}
+	}
+	return matchListItems, failed, degraded || mc.breaker != nil && mc.breaker.open()
+}
+
+// checkedAt returns when a stale status was fetched, nil when it was
+// derived from the processing state.
+func checkedAt(status analyticsStatus) *time.Time {
+	if status.checkedAt.IsZero() {
+		return nil
 	}
+	return &status.checkedAt
 }
@@ -1,18 +1,25 @@
 package controllers_test
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"nivai/backend/pkg/controllers" // Adjust if necessary
+	"nivai/backend/pkg/middleware"
 	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/pythonapi"
+	"nivai/backend/pkg/services"
 
+	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock" // For mocking services
 	"github.com/stretchr/testify/require"
@@ -39,6 +46,14 @@ func (m *MockVideoService) ListVideos(limit, offset int, filters map[string]stri
 	return args.Get(0).([]*models.Video), args.Error(1)
 }
 
+func (m *MockVideoService) ListVideosFor(access *models.AccessScope, limit, offset int, filters map[string]string) ([]*models.Video, error) {
+	args := m.Called(access, limit, offset, filters)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Video), args.Error(1)
+}
+
 func (m *MockVideoService) SaveVideoMetadata(video *models.Video) (*models.Video, error) {
 	args := m.Called(video)
 	if args.Get(0) == nil {
@@ -58,7 +73,7 @@ func (m *MockVideoService) CreateVideo(video *models.Video) error {
 	return args.Error(0)
 }
 
-func (m *MockVideoService) CreateVideoEntry(video *models.Video) (*models.Video, error) {
+func (m *MockVideoService) CreateVideoEntry(video *models.Video, change services.StateChange) (*models.Video, error) {
 	args := m.Called(video)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -79,6 +94,37 @@ func (m *MockVideoService) ProcessVideo(id string) error {
 	return args.Error(0)
 }
 
+func (m *MockVideoService) UpdateProcessingState(id string, state models.ProcessingState, change services.StateChange) error {
+	args := m.Called(id, state)
+	return args.Error(0)
+}
+
+func (m *MockVideoService) OverrideState(id string, override services.StateOverride, change services.StateChange) (*models.Video, error) {
+	args := m.Called(id, override)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Video), args.Error(1)
+}
+
+func (m *MockVideoService) GetStateHistory(id string) ([]*models.StateTransition, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.StateTransition), args.Error(1)
+}
+
+func (m *MockVideoService) RecordVideoFiles(id string, files []*models.VideoFile) error {
+	args := m.Called(id, files)
+	return args.Error(0)
+}
+
+func (m *MockVideoService) RejectVideo(id string, threats map[string]string) error {
+	args := m.Called(id, threats)
+	return args.Error(0)
+}
+
 func (m *MockVideoService) UploadVideo(videoFile multipart.File, videoFileHeader *multipart.FileHeader, videoDetails *models.Video) (*models.Video, error) {
 	args := m.Called(videoFile, videoFileHeader, videoDetails)
 	if args.Get(0) == nil {
@@ -88,7 +134,7 @@ func (m *MockVideoService) UploadVideo(videoFile multipart.File, videoFileHeader
 }
 
 // mockPythonStatusApi is a helper for match status checks
-func mockPythonStatusApi(t *testing.T, statusResponses map[string]controllers.PythonStatusResponse) *httptest.Server {
+func mockPythonStatusApi(t *testing.T, statusResponses map[string]pythonapi.MatchStatus) *httptest.Server {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		t.Logf("Mock Python Status API received request: %s", r.URL.Path)
 		parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/") // e.g., ["match", "match123", "status"]
@@ -101,7 +147,7 @@ func mockPythonStatusApi(t *testing.T, statusResponses map[string]controllers.Py
 		statusResp, ok := statusResponses[matchID]
 		if !ok {
 			// Default status if not specified for this matchID
-			statusResp = controllers.PythonStatusResponse{Status: "unknown_mock_default"}
+			statusResp = pythonapi.MatchStatus{Status: "unknown_mock_default"}
 		}
 
 		w.Header().Set("Content-Type", "application/json")
@@ -127,7 +173,7 @@ func TestListMatches(t *testing.T) {
 		mockVideoSvc.On("ListVideos", 20, 0, mock.AnythingOfType("map[string]string")).Return(sampleVideos, nil).Once()
 
 		// Setup mock Python API behavior for statuses
-		statusResps := map[string]controllers.PythonStatusResponse{
+		statusResps := map[string]pythonapi.MatchStatus{
 			"match1": {Status: "processed"},
 			"match2": {Status: "pending"},
 			// match3 will use default "unknown_mock_default" or could be error
@@ -136,7 +182,7 @@ func TestListMatches(t *testing.T) {
 		defer mockApi.Close()
 
 		// matchController now uses the locally defined mockVideoSvc
-		matchController := controllers.NewMatchController(mockVideoSvc, mockApi.URL, mockApi.Client())
+		matchController := controllers.NewMatchController(mockVideoSvc, pythonapi.NewClient(mockApi.URL, mockApi.Client()))
 
 		// This mock expectation was duplicated, removing one.
 		// The one at the top of the sub-test is correct.
@@ -171,9 +217,47 @@ func TestListMatches(t *testing.T) {
 		mockVideoSvc.AssertExpectations(t) // Verify that ListVideos was called as expected
 	})
 
+	t.Run("Key players from snapshots", func(t *testing.T) {
+		mockVideoSvc := new(MockVideoService)
+		mockVideoSvc.On("ListVideos", 20, 0, mock.AnythingOfType("map[string]string")).Return(sampleVideos, nil).Once()
+		mockApi := mockPythonStatusApi(t, map[string]pythonapi.MatchStatus{"match1": {Status: "processed"}})
+		defer mockApi.Close()
+
+		repo := snapshotRepo{"match1": &models.AnalyticsSnapshot{VideoID: "match1", Kind: models.SnapshotKindSummary, Payload: json.RawMessage(`{
+			"match_id": "match1",
+			"players": {
+				"7":  {"total_distance_m": 10250, "max_speed_kmh": 31.2},
+				"9":  {"total_distance_m": 11020},
+				"4":  {"total_distance_m": 9100},
+				"10": {"total_distance_m": 9800},
+				"1":  {"max_speed_kmh": 20}
+			}
+		}`)}}
+		snapshots := services.NewAnalyticsSnapshotService(repo, nil)
+		matchController := controllers.NewMatchController(mockVideoSvc, pythonapi.NewClient(mockApi.URL, mockApi.Client()), controllers.WithSnapshots(snapshots))
+
+		req := httptest.NewRequest("GET", "/api/v1/matches?include=key_players", nil)
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(matchController.ListMatches).ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var responseItems []controllers.MatchListItem
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&responseItems))
+		require.Len(t, responseItems, 3)
+
+		speed := 31.2
+		assert.Equal(t, []services.KeyPlayer{
+			{PlayerID: "9", TotalDistanceM: 11020},
+			{PlayerID: "7", TotalDistanceM: 10250, MaxSpeedKmh: &speed},
+			{PlayerID: "10", TotalDistanceM: 9800},
+		}, responseItems[0].KeyPlayers)
+		assert.Nil(t, responseItems[1].KeyPlayers, "unprocessed matches have no key players")
+		assert.NotContains(t, rr.Body.String(), `"key_players":null`)
+	})
+
 	t.Run("VideoService returns an error", func(t *testing.T) {
 		mockVideoSvc := new(MockVideoService)
-		matchController := controllers.NewMatchController(mockVideoSvc, "", nil)
+		matchController := controllers.NewMatchController(mockVideoSvc, pythonapi.NewClient("", nil))
 
 		mockVideoSvc.On("ListVideos", 20, 0, mock.AnythingOfType("map[string]string")).Return(nil, fmt.Errorf("database error")).Once()
 
@@ -186,9 +270,76 @@ func TestListMatches(t *testing.T) {
 		mockVideoSvc.AssertExpectations(t)
 	})
 
+	t.Run("Matches are sorted by the requested field", func(t *testing.T) {
+		mockVideoSvc := new(MockVideoService)
+		matchController := controllers.NewMatchController(mockVideoSvc, pythonapi.NewClient("", nil))
+
+		mockVideoSvc.On("ListVideos", 20, 0, map[string]string{"sort": "match_date", "order": "asc"}).Return([]*models.Video{}, nil).Once()
+
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(matchController.ListMatches).ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/matches?sort=match_date&order=ASC", nil))
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		for _, query := range []string{"sort=size", "order=random"} {
+			rr = httptest.NewRecorder()
+			http.HandlerFunc(matchController.ListMatches).ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/matches?"+query, nil))
+			assert.Equal(t, http.StatusBadRequest, rr.Code, query)
+		}
+		mockVideoSvc.AssertExpectations(t)
+	})
+
+	t.Run("Matches are filtered and paged by the query", func(t *testing.T) {
+		repos := models.NewMemoryRepositories()
+		day := func(d int) time.Time { return time.Date(2024, 9, d, 15, 0, 0, 0, time.UTC) }
+		for _, video := range []*models.Video{
+			{ID: "m1", Title: "Ajax - PSV", HomeTeam: "Ajax", AwayTeam: "PSV", Competition: "Eredivisie", MatchDate: day(1), ProcessingState: models.StateCompleted,
+				Venue: "Johan Cruijff ArenA", Referee: "D. Makkelie"},
+			{ID: "m2", Title: "PSV - AZ", HomeTeam: "PSV", AwayTeam: "AZ", Competition: "Eredivisie", MatchDate: day(8), ProcessingState: models.StateProcessing,
+				Venue: "Philips Stadion", Referee: "D. Makkelie"},
+			{ID: "m3", Title: "PSV - Ajax", HomeTeam: "PSV", AwayTeam: "Ajax", Competition: "KNVB Beker", MatchDate: day(15), ProcessingState: models.StateArchived},
+			{ID: "m4", Title: "AZ - Ajax", HomeTeam: "AZ", AwayTeam: "Ajax", Competition: "Eredivisie", MatchDate: day(22), ProcessingState: models.StateFailed},
+		} {
+			require.NoError(t, repos.Videos.Create(video))
+		}
+		api := mockPythonStatusApi(t, nil)
+		defer api.Close()
+		matchController := controllers.NewMatchController(services.NewVideoService(repos.Videos, new(MockStorageService)), pythonapi.NewClient(api.URL, api.Client()))
+		list := func(query string) (int, []string) {
+			rr := httptest.NewRecorder()
+			http.HandlerFunc(matchController.ListMatches).ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/matches?sort=match_date&order=asc&"+query, nil))
+			var items []controllers.MatchListItem
+			_ = json.Unmarshal(rr.Body.Bytes(), &items)
+			var ids []string
+			for _, item := range items {
+				ids = append(ids, item.ID)
+			}
+			return rr.Code, ids
+		}
+
+		for query, want := range map[string][]string{
+			"team=PSV":                                      {"m1", "m2", "m3"},
+			"competition=Eredivisie&from=2024-09-05":        {"m2", "m4"},
+			"to=2024-09-15T23:59:59Z&limit=1&offset=1":      {"m2"},
+			"analytics_status=processed":                    {"m1", "m3"},
+			"analytics_status=pending&team=AZ":              {"m2"},
+			"analytics_status=error&competition=Eredivisie": {"m4"},
+			"referee=D.+Makkelie":                           {"m1", "m2"},
+			"venue=Philips+Stadion&referee=D.+Makkelie":     {"m2"},
+		} {
+			code, ids := list(query)
+			require.Equal(t, http.StatusOK, code, query)
+			assert.Equal(t, want, ids, query)
+		}
+
+		for _, query := range []string{"analytics_status=done", "from=yesterday"} {
+			code, _ := list(query)
+			assert.Equal(t, http.StatusBadRequest, code, query)
+		}
+	})
+
 	t.Run("Empty list of matches", func(t *testing.T) {
 		mockVideoSvc := new(MockVideoService)
-		matchController := controllers.NewMatchController(mockVideoSvc, "", nil)
+		matchController := controllers.NewMatchController(mockVideoSvc, pythonapi.NewClient("", nil))
 
 		mockVideoSvc.On("ListVideos", 20, 0, mock.AnythingOfType("map[string]string")).Return([]*models.Video{}, nil).Once()
 
@@ -212,7 +363,7 @@ func TestListMatches(t *testing.T) {
 		}
 		// Removed incorrectly scoped mockVideoSvc.On("ListVideos",...) call from here
 
-		statusResps := map[string]controllers.PythonStatusResponse{
+		statusResps := map[string]pythonapi.MatchStatus{
 			"ok_match": {Status: "processed"},
 			// "err_match" will cause an error in the mock server if not defined, or we can make mock return error
 		}
@@ -232,7 +383,7 @@ func TestListMatches(t *testing.T) {
 		}))
 		defer mockApi.Close()
 
-		matchController := controllers.NewMatchController(mockVideoSvc, mockApi.URL, mockApi.Client())
+		matchController := controllers.NewMatchController(mockVideoSvc, pythonapi.NewClient(mockApi.URL, mockApi.Client()))
 
 		mockVideoSvc.On("ListVideos", 20, 0, mock.AnythingOfType("map[string]string")).Return(videosWithOneProblematic, nil).Once()
 
@@ -261,8 +412,225 @@ func TestListMatches(t *testing.T) {
 		}
 		assert.True(t, foundOkMatch, "OK match not found in response")
 		assert.True(t, foundErrMatch, "Error match not found in response")
+		assert.Equal(t, "1", rr.Header().Get("X-Partial-Results"))
+		mockVideoSvc.AssertExpectations(t)
+	})
+
+	t.Run("Status calls are bounded and time out", func(t *testing.T) {
+		videos := make([]*models.Video, 12)
+		for i := range videos {
+			videos[i] = &models.Video{ID: fmt.Sprintf("match%02d", i), Title: "Match"}
+		}
+		mockVideoSvc := new(MockVideoService)
+		mockVideoSvc.On("ListVideos", 20, 0, mock.AnythingOfType("map[string]string")).Return(videos, nil).Once()
+
+		var inFlight, peak atomic.Int32
+		mockApi := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			n := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			if strings.Contains(r.URL.Path, "match00") {
+				time.Sleep(500 * time.Millisecond) // Slower than the call timeout
+			} else {
+				time.Sleep(10 * time.Millisecond)
+			}
+			json.NewEncoder(w).Encode(pythonapi.MatchStatus{Status: "processed"})
+		}))
+		defer mockApi.Close()
+
+		matchController := controllers.NewMatchController(mockVideoSvc, pythonapi.NewClient(mockApi.URL, mockApi.Client()),
+			controllers.WithStatusFanOut(3, 100*time.Millisecond))
+
+		req := httptest.NewRequest("GET", "/api/v1/matches", nil)
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(matchController.ListMatches).ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var items []controllers.MatchListItem
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&items))
+		require.Len(t, items, 12)
+		assert.Equal(t, "error_timeout", items[0].AnalyticsStatus)
+		for _, item := range items[1:] {
+			assert.Equal(t, "processed", item.AnalyticsStatus)
+		}
+		assert.Equal(t, "1", rr.Header().Get("X-Partial-Results"))
+		assert.LessOrEqual(t, peak.Load(), int32(3))
+	})
+
+	t.Run("NDJSON clients receive every page", func(t *testing.T) {
+		firstPage := make([]*models.Video, 100)
+		for i := range firstPage {
+			firstPage[i] = &models.Video{ID: fmt.Sprintf("match%03d", i), Title: "Match", ProcessingState: "completed"}
+		}
+		lastPage := []*models.Video{{ID: "match100", Title: "Last", ProcessingState: "completed"}}
+		mockApi := mockPythonStatusApi(t, map[string]pythonapi.MatchStatus{})
+		defer mockApi.Close()
+
+		mockVideoSvc := new(MockVideoService)
+		mockVideoSvc.On("ListVideos", 100, 0, mock.AnythingOfType("map[string]string")).Return(firstPage, nil).Once()
+		mockVideoSvc.On("ListVideos", 100, 100, mock.AnythingOfType("map[string]string")).Return(lastPage, nil).Once()
+		matchController := controllers.NewMatchController(mockVideoSvc, pythonapi.NewClient(mockApi.URL, mockApi.Client()))
+
+		req := httptest.NewRequest("GET", "/api/v1/matches", nil)
+		req.Header.Set("Accept", "application/x-ndjson")
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(matchController.ListMatches).ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "application/x-ndjson", rr.Header().Get("Content-Type"))
+		lines := strings.Split(strings.TrimSpace(rr.Body.String()), "\n")
+		require.Len(t, lines, 101)
+		var last controllers.MatchListItem
+		require.NoError(t, json.Unmarshal([]byte(lines[100]), &last))
+		assert.Equal(t, "match100", last.ID)
+		assert.Equal(t, "Last", last.MatchName)
+		mockVideoSvc.AssertExpectations(t)
+	})
+
+	t.Run("Finished analytics are recorded on pending videos", func(t *testing.T) {
+		videos := []*models.Video{
+			{ID: "done", Title: "Done", ProcessingState: "pending_analytics"},
+			{ID: "broken", Title: "Broken", ProcessingState: "processing"},
+			{ID: "still_running", Title: "Running", ProcessingState: "pending_analytics"},
+			{ID: "already_done", Title: "Already", ProcessingState: "completed"},
+		}
+		mockApi := mockPythonStatusApi(t, map[string]pythonapi.MatchStatus{
+			"done":          {Status: "processed"},
+			"broken":        {Status: "error"},
+			"still_running": {Status: "pending"},
+			"already_done":  {Status: "processed"},
+		})
+		defer mockApi.Close()
+
+		mockVideoSvc := new(MockVideoService)
+		mockVideoSvc.On("ListVideos", 20, 0, mock.AnythingOfType("map[string]string")).Return(videos, nil).Once()
+		mockVideoSvc.On("UpdateProcessingState", "done", models.StateCompleted).Return(nil).Once()
+		mockVideoSvc.On("UpdateProcessingState", "broken", models.StateFailed).Return(nil).Once()
+		matchController := controllers.NewMatchController(mockVideoSvc, pythonapi.NewClient(mockApi.URL, mockApi.Client()))
+
+		req := httptest.NewRequest("GET", "/api/v1/matches", nil)
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(matchController.ListMatches).ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		mockVideoSvc.AssertExpectations(t)
+	})
+
+	t.Run("Statuses are served stale while the Python API is down", func(t *testing.T) {
+		var down atomic.Bool
+		var calls atomic.Int32
+		mockApi := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			if down.Load() {
+				http.Error(w, "service unavailable", http.StatusServiceUnavailable)
+				return
+			}
+			json.NewEncoder(w).Encode(pythonapi.MatchStatus{Status: "processed"})
+		}))
+		defer mockApi.Close()
+
+		fetched := &models.Video{ID: "fetched", Title: "Match", ProcessingState: models.StateProcessing}
+		synced := &models.Video{ID: "synced", Title: "Match", ProcessingState: models.StateFailed}
+		draft := &models.Video{ID: "draft", Title: "Match", ProcessingState: models.StateDraft}
+		mockVideoSvc := new(MockVideoService)
+		mockVideoSvc.On("ListVideos", 20, 0, mock.AnythingOfType("map[string]string")).Return([]*models.Video{fetched}, nil).Once()
+		mockVideoSvc.On("ListVideos", 20, 0, mock.AnythingOfType("map[string]string")).Return([]*models.Video{fetched, synced, draft}, nil).Once()
+		// Only the fetched status is synced; the stale one is not synced again
+		mockVideoSvc.On("UpdateProcessingState", "fetched", models.StateCompleted).Return(nil).Once()
+		matchController := controllers.NewMatchController(mockVideoSvc, pythonapi.NewClient(mockApi.URL, mockApi.Client()),
+			controllers.WithStatusFanOut(1, time.Second), controllers.WithStatusDegradation(2, time.Hour, 10))
+		router := mux.NewRouter()
+		router.Use(middleware.APIVersion)
+		router.HandleFunc("/api/v{version}/matches", matchController.ListMatches)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/v2/matches", nil))
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Empty(t, rr.Header().Get("X-Degraded"))
+		assert.NotContains(t, rr.Body.String(), "degraded")
+
+		down.Store(true)
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/v2/matches", nil))
+		require.Equal(t, http.StatusOK, rr.Code)
+		var page struct {
+			Items    []controllers.MatchListItem `json:"items"`
+			Degraded bool                        `json:"degraded"`
+		}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &page))
+		require.Len(t, page.Items, 3)
+
+		assert.Equal(t, "processed", page.Items[0].AnalyticsStatus, "the last status fetched")
+		assert.True(t, page.Items[0].StatusStale)
+		assert.NotNil(t, page.Items[0].StatusCheckedAt)
+		assert.Equal(t, "error", page.Items[1].AnalyticsStatus, "the status synced to the processing state")
+		assert.True(t, page.Items[1].StatusStale)
+		assert.Nil(t, page.Items[1].StatusCheckedAt)
+		assert.Equal(t, "error_circuit_open", page.Items[2].AnalyticsStatus)
+		assert.False(t, page.Items[2].StatusStale)
+
+		assert.True(t, page.Degraded)
+		assert.Equal(t, "analytics", rr.Header().Get("X-Degraded"))
+		assert.Equal(t, "no-store", rr.Header().Get("Cache-Control"), "stale pages must not be cached")
+		assert.Equal(t, "1", rr.Header().Get("X-Partial-Results"))
+		assert.Equal(t, int32(3), calls.Load(), "no calls once the circuit opened after two failures")
 		mockVideoSvc.AssertExpectations(t)
 	})
+
+	t.Run("A probe abandoned with its request does not hold the circuit open", func(t *testing.T) {
+		var mode atomic.Int32 // 0 down, 1 hanging until the client gives up, 2 up
+		var cancelRequest context.CancelFunc
+		mockApi := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch mode.Load() {
+			case 0:
+				http.Error(w, "service unavailable", http.StatusServiceUnavailable)
+			case 1:
+				cancelRequest()
+				<-r.Context().Done()
+			default:
+				json.NewEncoder(w).Encode(pythonapi.MatchStatus{Status: "processing"})
+			}
+		}))
+		defer mockApi.Close()
+
+		video := &models.Video{ID: "probed", Title: "Match", ProcessingState: models.StateProcessing}
+		mockVideoSvc := new(MockVideoService)
+		mockVideoSvc.On("ListVideos", 20, 0, mock.AnythingOfType("map[string]string")).Return([]*models.Video{video}, nil)
+		matchController := controllers.NewMatchController(mockVideoSvc, pythonapi.NewClient(mockApi.URL, mockApi.Client()),
+			controllers.WithStatusFanOut(1, time.Second), controllers.WithStatusDegradation(1, 10*time.Millisecond, 10))
+		router := mux.NewRouter()
+		router.Use(middleware.APIVersion)
+		router.HandleFunc("/api/v{version}/matches", matchController.ListMatches)
+		list := func(ctx context.Context) *httptest.ResponseRecorder {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/v2/matches", nil).WithContext(ctx))
+			return rr
+		}
+
+		rr := list(context.Background())
+		require.Equal(t, "analytics", rr.Header().Get("X-Degraded"), "one failure opens the circuit")
+
+		time.Sleep(20 * time.Millisecond)
+		mode.Store(1)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancelRequest = cancel
+		list(ctx)
+
+		mode.Store(2)
+		rr = list(context.Background())
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"analytics_status":"processing"`, "the next call probes the service")
+		assert.NotContains(t, rr.Body.String(), "status_stale")
+
+		rr = list(context.Background())
+		assert.Empty(t, rr.Header().Get("X-Degraded"), "the probe closed the circuit")
+		assert.Empty(t, rr.Header().Get("Cache-Control"))
+	})
 }
 
 // Note on PYTHON_API_URL and t.Setenv: Same caveats apply as in analytics_controller_test.go.
@@ -274,19 +642,8 @@ func TestListMatches(t *testing.T) {
 // The current ListMatches in match_controller.go uses default limit/offset and empty filters.
 // The test reflects this by expecting `mock.AnythingOfType` for filters.
 // If ListMatches were to parse query params for pagination/filtering, these tests would need updates.
-// The `PythonStatusResponse` struct is duplicated from match_controller.go for test setup.
-// This could be avoided if it were exported from controllers package, or defined in models.
-// For simplicity of this step, it's redefined here or assumed accessible.
-// The `controllers.PythonStatusResponse` is used in `mockPythonStatusApi`.
-// This assumes `PythonStatusResponse` is an exported type from `controllers` package.
-// If it's not, the mock function should define its own struct for encoding.
-// Looking at `match_controller.go` from previous step, `PythonStatusResponse` is defined there, unexported.
-// So, `mockPythonStatusApi` needs to define its own struct or the original needs to be exported.
-// For this test, I'll assume it can be imported or I'll redefine a compatible one locally if needed.
-// The current code `controllers.PythonStatusResponse` implies it's exported or this test is in `package controllers`.
-// Since it's `package controllers_test`, it must be exported from `controllers`.
-// I will proceed as if `controllers.PythonStatusResponse` is an exported type.
-// If not, the test would need `type PythonStatusResponse struct { Status string ...}` locally.
+// `mockPythonStatusApi` encodes `pythonapi.MatchStatus`, the same type the controller decodes
+// via the pythonapi client, so the mock stays in sync with the real response shape.
 //
 // The `getAnalyticsStatus` in `match_controller.go` is an unexported method.
 // The tests for `ListMatches` cover its behavior implicitly.
@@ -306,3 +663,28 @@ func TestListMatches(t *testing.T) {
 // One detail: `mockVideoSvc.On("ListVideos", 20, 0, mock.AnythingOfType("map[string]string"))` has hardcoded limit/offset.
 // This should match what `ListMatches` actually passes (which are current defaults).
 // This is fine as `ListMatches` itself uses these defaults currently.
+
+// snapshotRepo is an in-memory AnalyticsSnapshotRepository keyed by video ID.
+type snapshotRepo map[string]*models.AnalyticsSnapshot
+
+func (r snapshotRepo) Save(snapshot *models.AnalyticsSnapshot) error {
+	r[snapshot.VideoID] = snapshot
+	return nil
+}
+
+func (r snapshotRepo) Find(videoID, kind string) (*models.AnalyticsSnapshot, error) {
+	if snapshot, ok := r[videoID]; ok && snapshot.Kind == kind {
+		return snapshot, nil
+	}
+	return nil, errors.New("snapshot not found")
+}
+
+func (r snapshotRepo) FindMany(videoIDs []string, kind string) (map[string]*models.AnalyticsSnapshot, error) {
+	found := make(map[string]*models.AnalyticsSnapshot)
+	for _, id := range videoIDs {
+		if snapshot, err := r.Find(id, kind); err == nil {
+			found[id] = snapshot
+		}
+	}
+	return found, nil
+}
@@ -0,0 +1,148 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"nivai/backend/pkg/httperr"
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/requestctx"
+	"nivai/backend/pkg/services"
+
+	"github.com/gorilla/mux"
+)
+
+// MatchDayController exposes match-day mode per match.
+type MatchDayController struct {
+	matchDay     *services.MatchDayService
+	videoService services.VideoService
+	access       *services.AccessService
+}
+
+// NewMatchDayController creates a new controller for match-day endpoints.
+// Active matches are only listed to callers with access to them; a nil
+// access service lists them to everyone.
+func NewMatchDayController(matchDay *services.MatchDayService, vs services.VideoService, access *services.AccessService) *MatchDayController {
+	return &MatchDayController{matchDay: matchDay, videoService: vs, access: access}
+}
+
+// matchDayRequest is the body of PUT /api/v1/matches/{id}/match-day.
+type matchDayRequest struct {
+	Mode      string     `json:"mode"`
+	KickoffAt *time.Time `json:"kickoff_at"`
+}
+
+// ListActive handles GET /api/v1/matches/match-day.
+// It lists the matches currently in match-day mode that the caller may see.
+func (mc *MatchDayController) ListActive(w http.ResponseWriter, r *http.Request) {
+	var scope *models.AccessScope
+	if mc.access != nil {
+		var err error
+		if scope, err = mc.access.Scope(requestctx.From(r).Principal); err != nil {
+			log.Printf("Error retrieving access scope: %v", err)
+			httperr.WriteError(w, r, httperr.Internal("Failed to check access"))
+			return
+		}
+	}
+
+	statuses := []*services.MatchDayStatus{}
+	for _, matchID := range mc.matchDay.ActiveMatches() {
+		if scope != nil {
+			video, err := mc.videoService.GetVideoByID(matchID)
+			if errors.Is(err, services.ErrVideoNotFound) {
+				continue
+			}
+			if err != nil {
+				log.Printf("Error retrieving match %s: %v", matchID, err)
+				httperr.WriteError(w, r, httperr.Internal("Failed to retrieve match"))
+				return
+			}
+			if !scope.Allows(video) {
+				continue
+			}
+		}
+		status, err := mc.matchDay.Status(matchID)
+		if err != nil {
+			log.Printf("Error retrieving match-day status for match %s: %v", matchID, err)
+			httperr.WriteError(w, r, httperr.Internal("Failed to retrieve match-day status"))
+			return
+		}
+		statuses = append(statuses, status)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(statuses); err != nil {
+		log.Printf("Error encoding ListActive response: %v", err)
+	}
+}
+
+// GetMatchDay handles GET /api/v1/matches/{id}/match-day.
+func (mc *MatchDayController) GetMatchDay(w http.ResponseWriter, r *http.Request) {
+	matchID := mux.Vars(r)["id"]
+	if !mc.matchExists(w, r, matchID) {
+		return
+	}
+
+	status, err := mc.matchDay.Status(matchID)
+	if err != nil {
+		log.Printf("Error retrieving match-day status for match %s: %v", matchID, err)
+		httperr.WriteError(w, r, httperr.Internal("Failed to retrieve match-day status"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(status); err != nil {
+		log.Printf("Error encoding GetMatchDay response: %v", err)
+	}
+}
+
+// UpdateMatchDay handles PUT /api/v1/matches/{id}/match-day.
+// The body replaces the match's settings: mode is "auto", "on" or "off",
+// and kickoff_at (RFC 3339) drives automatic activation in mode "auto".
+func (mc *MatchDayController) UpdateMatchDay(w http.ResponseWriter, r *http.Request) {
+	matchID := mux.Vars(r)["id"]
+
+	var req matchDayRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httperr.WriteError(w, r, httperr.BadRequest("Invalid request payload"))
+		return
+	}
+
+	if !mc.matchExists(w, r, matchID) {
+		return
+	}
+
+	status, err := mc.matchDay.Update(matchID, req.Mode, req.KickoffAt)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidMatchDayMode) {
+			httperr.WriteError(w, r, httperr.BadRequest(`mode must be "auto", "on" or "off"`))
+			return
+		}
+		log.Printf("Error updating match-day settings for match %s: %v", matchID, err)
+		httperr.WriteError(w, r, httperr.Internal("Failed to update match-day settings"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(status); err != nil {
+		log.Printf("Error encoding UpdateMatchDay response: %v", err)
+	}
+}
+
+// matchExists writes a 404 or 500 response and returns false when the match
+// cannot be found.
+func (mc *MatchDayController) matchExists(w http.ResponseWriter, r *http.Request, matchID string) bool {
+	if _, err := mc.videoService.GetVideoByID(matchID); err != nil {
+		if errors.Is(err, services.ErrVideoNotFound) {
+			httperr.WriteError(w, r, httperr.NotFound("Match not found"))
+		} else {
+			log.Printf("Error retrieving match %s: %v", matchID, err)
+			httperr.WriteError(w, r, httperr.Internal("Failed to retrieve match"))
+		}
+		return false
+	}
+	return true
+}
@@ -0,0 +1,132 @@
+package controllers_test
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"nivai/backend/pkg/controllers"
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/requestctx"
+	"nivai/backend/pkg/services"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// --- MockMatchDayRepository ---
+type MockMatchDayRepository struct {
+	mock.Mock
+}
+
+func (m *MockMatchDayRepository) Save(setting *models.MatchDaySetting) error {
+	args := m.Called(setting)
+	return args.Error(0)
+}
+func (m *MockMatchDayRepository) Find(videoID string) (*models.MatchDaySetting, error) {
+	args := m.Called(videoID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.MatchDaySetting), args.Error(1)
+}
+func (m *MockMatchDayRepository) FindScheduled() ([]*models.MatchDaySetting, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.MatchDaySetting), args.Error(1)
+}
+
+func newMatchDayRouter(repo *MockMatchDayRepository, vs *MockVideoService) *mux.Router {
+	svc := services.NewMatchDayService(repo, services.MatchDayConfig{Lead: time.Hour, Duration: 3 * time.Hour})
+	mc := controllers.NewMatchDayController(svc, vs, nil)
+	router := mux.NewRouter()
+	router.HandleFunc("/api/v1/matches/{id}/match-day", mc.GetMatchDay).Methods("GET")
+	router.HandleFunc("/api/v1/matches/{id}/match-day", mc.UpdateMatchDay).Methods("PUT")
+	return router
+}
+
+func TestMatchDayController(t *testing.T) {
+	t.Run("Unknown match returns 404", func(t *testing.T) {
+		vs := new(MockVideoService)
+		vs.On("GetVideoByID", "missing").Return(nil, services.ErrVideoNotFound).Once()
+
+		rr := httptest.NewRecorder()
+		newMatchDayRouter(new(MockMatchDayRepository), vs).ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/matches/missing/match-day", nil))
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("Invalid mode returns 400", func(t *testing.T) {
+		vs := new(MockVideoService)
+		vs.On("GetVideoByID", "m1").Return(&models.Video{ID: "m1"}, nil).Once()
+
+		rr := httptest.NewRecorder()
+		body := strings.NewReader(`{"mode":"always"}`)
+		newMatchDayRouter(new(MockMatchDayRepository), vs).ServeHTTP(rr, httptest.NewRequest("PUT", "/api/v1/matches/m1/match-day", body))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("Manual activation", func(t *testing.T) {
+		vs := new(MockVideoService)
+		vs.On("GetVideoByID", "m1").Return(&models.Video{ID: "m1"}, nil).Once()
+		repo := new(MockMatchDayRepository)
+		repo.On("Save", mock.MatchedBy(func(s *models.MatchDaySetting) bool {
+			return s.VideoID == "m1" && s.Mode == models.MatchDayModeOn && s.KickoffAt.Valid
+		})).Return(nil).Once()
+
+		rr := httptest.NewRecorder()
+		body := strings.NewReader(`{"mode":"on","kickoff_at":"2024-05-01T18:45:00Z"}`)
+		newMatchDayRouter(repo, vs).ServeHTTP(rr, httptest.NewRequest("PUT", "/api/v1/matches/m1/match-day", body))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var status services.MatchDayStatus
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &status))
+		assert.True(t, status.Active)
+		assert.Equal(t, "2024-05-01T18:45:00Z", status.KickoffAt.Format(time.RFC3339))
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Active matches are listed only to callers with access to them", func(t *testing.T) {
+		videoRepo := models.NewMemoryVideoRepository()
+		require.NoError(t, videoRepo.Create(&models.Video{ID: "m1", Title: "Ajax - PSV", HomeTeam: "Ajax", AwayTeam: "PSV"}))
+		require.NoError(t, videoRepo.Create(&models.Video{ID: "m2", Title: "AZ - Feyenoord", HomeTeam: "AZ", AwayTeam: "Feyenoord"}))
+		grants := models.NewMemoryAccessGrantRepository()
+		require.NoError(t, grants.Save(&models.AccessGrant{ID: "g1", SubjectType: models.AccessSubjectUser, SubjectID: "scout",
+			ResourceType: models.AccessResourceTeam, ResourceID: "psv", Permission: models.AccessPermissionView}))
+		access := services.NewAccessService(grants, videoRepo, services.AccessConfig{Enforce: true, UnrestrictedRoles: []string{"admin"}})
+
+		svc := services.NewMatchDayService(models.NewMemoryMatchDayRepository(), services.MatchDayConfig{Lead: time.Hour, Duration: 3 * time.Hour})
+		for _, id := range []string{"m1", "m2"} {
+			_, err := svc.Update(id, models.MatchDayModeOn, nil)
+			require.NoError(t, err)
+		}
+		mc := controllers.NewMatchDayController(svc, services.NewVideoService(videoRepo, services.NewMemoryStorageService()), access)
+		active := func(principal requestctx.Principal) []string {
+			req := httptest.NewRequest("GET", "/api/v1/matches/match-day", nil)
+			req = req.WithContext(requestctx.NewContext(req.Context(), &requestctx.Info{Principal: principal, Logger: log.Default()}))
+			rr := httptest.NewRecorder()
+			mc.ListActive(rr, req)
+			require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+			var statuses []services.MatchDayStatus
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &statuses))
+			ids := []string{}
+			for _, status := range statuses {
+				ids = append(ids, status.MatchID)
+			}
+			return ids
+		}
+
+		assert.Equal(t, []string{"m1"}, active(requestctx.Principal{UserID: "scout", Role: "user"}))
+		assert.Empty(t, active(requestctx.Principal{UserID: "stranger", Role: "user"}))
+		assert.Equal(t, []string{"m1", "m2"}, active(requestctx.Principal{UserID: "root", Role: "admin"}))
+	})
+}
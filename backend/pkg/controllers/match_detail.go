@@ -0,0 +1,294 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"nivai/backend/pkg/httperr"
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/services"
+
+	"github.com/gorilla/mux"
+)
+
+// Resources a match detail can embed with ?include=
+const (
+	detailVideos     = "videos"
+	detailFiles      = "files"
+	detailAnalytics  = "analytics"
+	detailClips      = "clips"
+	detailKeyPlayers = "key_players"
+)
+
+// defaultDetailIncludes are embedded when the request has no ?include=;
+// key players need the stored snapshot and are only embedded on request.
+var defaultDetailIncludes = []string{detailVideos, detailFiles, detailAnalytics, detailClips}
+
+// WithMatchFiles enables ?include=files on match details, which checks the
+// stored files of a match and adds their recorded sizes and checksums.
+func WithMatchFiles(storage services.StorageService, files models.VideoFileRepository) MatchControllerOption {
+	return func(mc *MatchController) {
+		mc.storage = storage
+		mc.files = files
+	}
+}
+
+// WithMatchShares enables ?include=clips on match details, counting the
+// clips shared from a match.
+func WithMatchShares(shares *services.ShareService) MatchControllerOption {
+	return func(mc *MatchController) {
+		mc.shares = shares
+	}
+}
+
+// MatchFile is a file of a match and whether it can be downloaded now.
+type MatchFile struct {
+	Kind        string `json:"kind"` // "video", "tracking" or "events"
+	Available   bool   `json:"available"`
+	Archived    bool   `json:"archived,omitempty"` // In cold storage; restore the match to download it
+	Size        int64  `json:"size,omitempty"`
+	Checksum    string `json:"checksum,omitempty"`
+	DownloadURL string `json:"download_url,omitempty"`
+}
+
+// MatchDetail is a match with the resources a dashboard shows next to it,
+// so it needs one request instead of one per resource.
+type MatchDetail struct {
+	MatchListItem
+	MatchID         string                 `json:"match_id,omitempty"`
+	MatchDate       *time.Time             `json:"match_date,omitempty"`
+	Referee         string                 `json:"referee,omitempty"`
+	Attendance      int                    `json:"attendance,omitempty"`
+	Weather         string                 `json:"weather,omitempty"`
+	KickoffTimezone string                 `json:"kickoff_timezone,omitempty"`
+	ProcessingState models.ProcessingState `json:"processing_state"`
+	UpdatedAt       time.Time              `json:"updated_at"`
+
+	// Embedded resources, only with their ?include= value
+	AnalyticsMessage string          `json:"analytics_message,omitempty"`
+	Videos           []*models.Video `json:"videos,omitempty"` // Every video of the match, this one included
+	Files            []MatchFile     `json:"files,omitempty"`
+	ClipCount        *int            `json:"clip_count,omitempty"` // Active clip share links
+
+	// Includes that could not be resolved; the detail is served without them
+	Unavailable []string `json:"unavailable,omitempty"`
+	// Set when the analytics status is served stale while the Python API is down
+	Degraded bool `json:"degraded,omitempty"`
+}
+
+// parseDetailIncludes reads ?include= as a comma-separated list of
+// resources, or returns the defaults when it is absent.
+func parseDetailIncludes(r *http.Request) (map[string]bool, error) {
+	values := defaultDetailIncludes
+	if raw, ok := r.URL.Query()["include"]; ok {
+		values = strings.Split(strings.Join(raw, ","), ",")
+	}
+	includes := map[string]bool{}
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		switch value {
+		case "":
+		case detailVideos, detailFiles, detailAnalytics, detailClips, detailKeyPlayers:
+			includes[value] = true
+		default:
+			return nil, fmt.Errorf("unknown include %q, expected videos, files, analytics, clips or key_players", value)
+		}
+	}
+	return includes, nil
+}
+
+// GetMatch handles GET /api/v1/matches/{id}.
+// ?include= picks the embedded resources, comma-separated: videos, files,
+// analytics, clips and key_players. Without it, all but key_players are
+// embedded; an empty ?include= embeds none. Resources that cannot be
+// resolved are left out and named in `unavailable` and X-Partial-Results.
+func (mc *MatchController) GetMatch(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	includes, err := parseDetailIncludes(r)
+	if err != nil {
+		httperr.WriteError(w, r, httperr.BadRequest(err.Error()))
+		return
+	}
+
+	video, err := mc.videoService.GetVideoByID(id)
+	if err != nil {
+		if errors.Is(err, services.ErrVideoNotFound) {
+			httperr.WriteError(w, r, httperr.NotFound("Match not found"))
+		} else {
+			log.Printf("Error retrieving match %s: %v", id, err)
+			httperr.WriteError(w, r, httperr.Internal("Failed to retrieve match"))
+		}
+		return
+	}
+
+	detail := MatchDetail{
+		MatchListItem: MatchListItem{
+			ID:          video.ID,
+			MatchName:   video.Title,
+			UploadDate:  video.CreatedAt,
+			HomeTeam:    video.HomeTeam,
+			AwayTeam:    video.AwayTeam,
+			Competition: video.Competition,
+			Season:      video.Season,
+			Venue:       video.Venue,
+			KickoffAt:   video.KickoffAt,
+		},
+		MatchID:         video.MatchID,
+		Referee:         video.Referee,
+		Attendance:      video.Attendance,
+		Weather:         video.Weather,
+		KickoffTimezone: video.KickoffTimezone,
+		ProcessingState: video.ProcessingState,
+		UpdatedAt:       video.UpdatedAt,
+	}
+	if !video.MatchDate.IsZero() {
+		matchDate := video.MatchDate
+		detail.MatchDate = &matchDate
+	}
+
+	if includes[detailAnalytics] {
+		status := mc.getAnalyticsStatus(r.Context(), video)
+		mc.syncProcessingState(video, status)
+		detail.AnalyticsStatus, detail.AnalyticsMessage = status.Status, status.Message
+		detail.StatusStale, detail.StatusCheckedAt = status.stale, checkedAt(status)
+		if strings.HasPrefix(status.Status, "error_") {
+			detail.Unavailable = append(detail.Unavailable, detailAnalytics)
+		}
+		detail.Degraded = status.stale || mc.breaker != nil && mc.breaker.open()
+	}
+	if includes[detailVideos] {
+		mc.embedVideos(r, video, &detail)
+	}
+	if includes[detailFiles] {
+		mc.embedFiles(video, &detail)
+	}
+	if includes[detailClips] {
+		mc.embedClipCount(video, &detail)
+	}
+	if includes[detailKeyPlayers] {
+		mc.embedKeyPlayers(video, &detail)
+	}
+
+	if len(detail.Unavailable) > 0 {
+		w.Header().Set("X-Partial-Results", strconv.Itoa(len(detail.Unavailable)))
+	}
+	if detail.Degraded {
+		markDegraded(w, degradedAnalytics)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(detail); err != nil {
+		log.Printf("Error encoding match detail response: %v", err)
+	}
+}
+
+// embedVideos adds every video of the match the caller can see. Videos
+// without a match ID are their own match.
+func (mc *MatchController) embedVideos(r *http.Request, video *models.Video, detail *MatchDetail) {
+	if video.MatchID == "" {
+		detail.Videos = []*models.Video{video}
+		return
+	}
+	videos, err := listAccessibleVideos(r, mc.videoService, mc.access, 100, 0, map[string]string{"match_id": video.MatchID})
+	if err != nil {
+		log.Printf("Error listing videos of match %s: %v", video.MatchID, err)
+		detail.Unavailable = append(detail.Unavailable, detailVideos)
+		return
+	}
+	if !slices.ContainsFunc(videos, func(v *models.Video) bool { return v.ID == video.ID }) {
+		videos = append([]*models.Video{video}, videos...)
+	}
+	detail.Videos = videos
+}
+
+// embedFiles checks which files of the match are stored, adding their
+// recorded sizes and checksums.
+func (mc *MatchController) embedFiles(video *models.Video, detail *MatchDetail) {
+	if mc.storage == nil {
+		detail.Unavailable = append(detail.Unavailable, detailFiles)
+		return
+	}
+	records := map[string]*models.VideoFile{}
+	if mc.files != nil {
+		files, err := mc.files.FindByVideoID(video.ID)
+		if err != nil {
+			log.Printf("Error loading file records of match %s: %v", video.ID, err)
+		}
+		for _, file := range files {
+			records[file.Kind] = file
+		}
+	}
+
+	archived := video.ProcessingState == models.StateArchived || video.ProcessingState == models.StateRestoring
+	detail.Files = []MatchFile{}
+	for _, file := range []struct{ kind, path string }{
+		{models.FileKindVideo, video.FilePath},
+		{models.FileKindTracking, video.TrackingPath},
+		{models.FileKindEvents, video.EventFilePath},
+	} {
+		if file.path == "" {
+			continue
+		}
+		entry := MatchFile{Kind: file.kind, Archived: archived}
+		if record := records[file.kind]; record != nil {
+			entry.Size, entry.Checksum = record.Size, record.Checksum
+		}
+		if !archived {
+			exists, err := mc.storage.Exists(file.path)
+			if err != nil {
+				log.Printf("Error checking %s file of match %s: %v", file.kind, video.ID, err)
+				detail.Files = nil
+				detail.Unavailable = append(detail.Unavailable, detailFiles)
+				return
+			}
+			entry.Available = exists
+		}
+		if entry.Available {
+			entry.DownloadURL = "/api/v1/matches/" + video.ID + "/files/" + file.kind
+		}
+		detail.Files = append(detail.Files, entry)
+	}
+}
+
+// embedClipCount counts the clip share links of the match that still work.
+func (mc *MatchController) embedClipCount(video *models.Video, detail *MatchDetail) {
+	if mc.shares == nil {
+		detail.Unavailable = append(detail.Unavailable, detailClips)
+		return
+	}
+	links, err := mc.shares.List(video.ID)
+	if err != nil {
+		log.Printf("Error listing share links of match %s: %v", video.ID, err)
+		detail.Unavailable = append(detail.Unavailable, detailClips)
+		return
+	}
+	now := time.Now()
+	count := 0
+	for _, link := range links {
+		if link.Scope == models.ShareScopeClip && link.RevokedAt == nil && link.ExpiresAt.After(now) {
+			count++
+		}
+	}
+	detail.ClipCount = &count
+}
+
+// embedKeyPlayers adds the top performers from the stored snapshot.
+func (mc *MatchController) embedKeyPlayers(video *models.Video, detail *MatchDetail) {
+	if mc.snapshots == nil {
+		detail.Unavailable = append(detail.Unavailable, detailKeyPlayers)
+		return
+	}
+	keyPlayers, err := mc.snapshots.KeyPlayers([]string{video.ID}, keyPlayersPerMatch)
+	if err != nil {
+		log.Printf("Error loading key players of match %s: %v", video.ID, err)
+		detail.Unavailable = append(detail.Unavailable, detailKeyPlayers)
+		return
+	}
+	detail.KeyPlayers = keyPlayers[video.ID]
+}
@@ -0,0 +1,151 @@
+package controllers_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"nivai/backend/pkg/controllers"
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/pythonapi"
+	"nivai/backend/pkg/services"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetMatch(t *testing.T) {
+	matchDate := time.Date(2024, 9, 14, 14, 30, 0, 0, time.UTC)
+	kickoffAt := models.LocalKickoff(matchDate, "Europe/Amsterdam")
+
+	// setup stores a match recorded by two cameras, with a clip shared from
+	// the first, and serves the first through the detail endpoint.
+	setup := func(t *testing.T, statuses map[string]pythonapi.MatchStatus) (*mux.Router, *MockStorageService, *models.Repositories) {
+		repos := models.NewMemoryRepositories()
+		for _, video := range []*models.Video{
+			{ID: "cam1", Title: "Ajax - PSV", MatchID: "m1", MatchDate: matchDate, HomeTeam: "Ajax", AwayTeam: "PSV", ProcessingState: models.StateCompleted,
+				FilePath: "videos/cam1/match.mp4", TrackingPath: "videos/cam1/tracking.parquet",
+				Venue: "Johan Cruijff ArenA", Referee: "D. Makkelie", Attendance: 54000, KickoffAt: &kickoffAt, KickoffTimezone: "Europe/Amsterdam"},
+			{ID: "cam2", Title: "Ajax - PSV (tactical)", MatchID: "m1", ProcessingState: models.StateCompleted},
+			{ID: "other", Title: "PSV - Feyenoord", MatchID: "m2"},
+		} {
+			require.NoError(t, repos.Videos.Create(video))
+		}
+		require.NoError(t, repos.VideoFiles.Save(&models.VideoFile{VideoID: "cam1", Kind: models.FileKindVideo, Path: "videos/cam1/match.mp4", Size: 2048, Checksum: "abc"}))
+
+		shares := services.NewShareService(repos.ShareLinks, repos.Videos, services.ShareConfig{SigningKey: "test"})
+		_, _, err := shares.Create("cam1", "alice", services.ShareRequest{Scope: models.ShareScopeClip, ClipStartMS: 1000, ClipEndMS: 5000})
+		require.NoError(t, err)
+		_, _, err = shares.Create("cam1", "alice", services.ShareRequest{})
+		require.NoError(t, err)
+
+		api := mockPythonStatusApi(t, statuses)
+		t.Cleanup(api.Close)
+		storage := new(MockStorageService)
+		mc := controllers.NewMatchController(services.NewVideoService(repos.Videos, storage), pythonapi.NewClient(api.URL, api.Client()),
+			controllers.WithMatchFiles(storage, repos.VideoFiles), controllers.WithMatchShares(shares))
+		router := mux.NewRouter()
+		router.HandleFunc("/api/v1/matches/{id}", mc.GetMatch).Methods("GET")
+		return router, storage, repos
+	}
+	get := func(router *mux.Router, path string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		var body map[string]interface{}
+		_ = json.Unmarshal(rr.Body.Bytes(), &body)
+		return rr, body
+	}
+
+	t.Run("The detail embeds videos, files, analytics and clips by default", func(t *testing.T) {
+		router, storage, _ := setup(t, map[string]pythonapi.MatchStatus{"cam1": {Status: "processed"}})
+		storage.On("Exists", "videos/cam1/match.mp4").Return(true, nil).Once()
+		storage.On("Exists", "videos/cam1/tracking.parquet").Return(false, nil).Once()
+
+		rr, _ := get(router, "/api/v1/matches/cam1")
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Empty(t, rr.Header().Get("X-Partial-Results"))
+		var detail controllers.MatchDetail
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &detail))
+		assert.Equal(t, "Ajax - PSV", detail.MatchName)
+		assert.Equal(t, "m1", detail.MatchID)
+		assert.Equal(t, matchDate, *detail.MatchDate)
+		assert.Equal(t, "Johan Cruijff ArenA", detail.Venue)
+		assert.Equal(t, "D. Makkelie", detail.Referee)
+		assert.Equal(t, 54000, detail.Attendance)
+		assert.Equal(t, "Europe/Amsterdam", detail.KickoffTimezone)
+		require.NotNil(t, detail.KickoffAt)
+		assert.Equal(t, "2024-09-14T16:30:00+02:00", detail.KickoffAt.Format(time.RFC3339))
+		assert.Equal(t, "processed", detail.AnalyticsStatus)
+		require.Len(t, detail.Videos, 2)
+		assert.ElementsMatch(t, []string{"cam1", "cam2"}, []string{detail.Videos[0].ID, detail.Videos[1].ID})
+		assert.Equal(t, []controllers.MatchFile{
+			{Kind: models.FileKindVideo, Available: true, Size: 2048, Checksum: "abc", DownloadURL: "/api/v1/matches/cam1/files/video"},
+			{Kind: models.FileKindTracking},
+		}, detail.Files)
+		require.NotNil(t, detail.ClipCount)
+		assert.Equal(t, 1, *detail.ClipCount, "only clip links count")
+		storage.AssertExpectations(t)
+	})
+
+	t.Run("Includes limit what is resolved", func(t *testing.T) {
+		router, storage, _ := setup(t, nil)
+
+		rr, body := get(router, "/api/v1/matches/cam1?include=clips")
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, float64(1), body["clip_count"])
+		for _, key := range []string{"videos", "files", "key_players"} {
+			assert.NotContains(t, body, key)
+		}
+		assert.Empty(t, body["analytics_status"])
+		storage.AssertNotCalled(t, "Exists", mock.Anything)
+
+		rr, body = get(router, "/api/v1/matches/cam1?include=")
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.NotContains(t, body, "clip_count")
+
+		rr, _ = get(router, "/api/v1/matches/cam1?include=annotations")
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("Unresolved includes are left out and named", func(t *testing.T) {
+		router, storage, _ := setup(t, nil)
+		storage.On("Exists", mock.Anything).Return(false, errors.New("storage unavailable"))
+
+		rr, body := get(router, "/api/v1/matches/cam1?include=files,videos")
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "1", rr.Header().Get("X-Partial-Results"))
+		assert.Equal(t, []interface{}{"files"}, body["unavailable"])
+		assert.NotContains(t, body, "files")
+		assert.Contains(t, body, "videos")
+	})
+
+	t.Run("Archived files are not checked", func(t *testing.T) {
+		router, storage, repos := setup(t, nil)
+		video, err := repos.Videos.FindByID("cam1")
+		require.NoError(t, err)
+		require.NoError(t, repos.Videos.UpdateProcessingState("cam1", video.ProcessingState, models.StateArchived, time.Now()))
+
+		rr, body := get(router, "/api/v1/matches/cam1?include=files")
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		files := body["files"].([]interface{})
+		require.Len(t, files, 2)
+		assert.Equal(t, true, files[0].(map[string]interface{})["archived"])
+		storage.AssertNotCalled(t, "Exists", mock.Anything)
+	})
+
+	t.Run("Unknown matches are not found", func(t *testing.T) {
+		router, _, _ := setup(t, nil)
+
+		rr, _ := get(router, "/api/v1/matches/unknown")
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
@@ -0,0 +1,261 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"time"
+
+	"nivai/backend/pkg/httperr"
+	"nivai/backend/pkg/ingest/adapters"
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/requestctx"
+	"nivai/backend/pkg/services"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// WithDrafts lets clients create a match from its metadata first and
+// attach its files in later requests.
+func WithDrafts(drafts *services.DraftService) VideoControllerOption {
+	return func(vc *VideoController) {
+		vc.drafts = drafts
+	}
+}
+
+// draftResponse is a draft with the time it expires unless it changes.
+type draftResponse struct {
+	*models.Video
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// draftsConfigured reports whether drafts are enabled, answering the
+// request when they are not.
+func (vc *VideoController) draftsConfigured(w http.ResponseWriter, r *http.Request) bool {
+	if vc.drafts == nil {
+		httperr.WriteError(w, r, httperr.NotImplemented("Match drafts are not configured"))
+		return false
+	}
+	return true
+}
+
+// CreateDraft handles POST /api/v1/matches.
+// The match is created from its metadata as a draft; its files are
+// attached with AttachDraftFile and processing starts on SubmitDraft. The
+// metadata is a JSON object, decoded strictly, or form fields as on upload.
+func (vc *VideoController) CreateDraft(w http.ResponseWriter, r *http.Request) {
+	if !vc.draftsConfigured(w, r) {
+		return
+	}
+	info := requestctx.From(r)
+
+	metadata, invalid := draftMetadata(w, r)
+	if invalid != nil {
+		httperr.WriteError(w, r, invalid)
+		return
+	}
+	if metadata.Title == "" {
+		httperr.WriteError(w, r, httperr.BadRequest("title is required"))
+		return
+	}
+	if metadata.Priority != "" {
+		httperr.WriteError(w, r, httperr.BadRequest("priority is given when the draft is submitted"))
+		return
+	}
+	video := &models.Video{ID: uuid.New().String()}
+	applyMetadata(video, metadata)
+
+	draft, err := vc.drafts.Create(video, services.StateChange{Actor: info.Principal.UserID, Reason: "draft created"})
+	if err != nil {
+		info.Logger.Printf("Error creating draft: %v", err)
+		httperr.WriteError(w, r, httperr.Internal("Failed to create draft"))
+		return
+	}
+	if metadata.KickoffAt != nil && vc.matchDay != nil {
+		if _, err := vc.matchDay.Update(draft.ID, models.MatchDayModeAuto, metadata.KickoffAt); err != nil {
+			info.Logger.Printf("Warning: Failed to save kickoff time for draft %s: %v", draft.ID, err)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/api/v1/matches/"+draft.ID)
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(draftResponse{Video: draft, ExpiresAt: vc.drafts.ExpiresAt(draft)}); err != nil {
+		info.Logger.Printf("Error encoding CreateDraft response: %v", err)
+	}
+}
+
+// draftMetadata reads the metadata of a new draft from a JSON body, or from
+// the fields of a URL-encoded or multipart form.
+func draftMetadata(w http.ResponseWriter, r *http.Request) (*models.UploadMetadata, *httperr.Error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxFieldSize)
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "", "application/json":
+		return decodeMetadata(r.Body)
+	case "application/x-www-form-urlencoded", "multipart/form-data":
+		if err := r.ParseMultipartForm(maxFieldSize); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+			return nil, httperr.BadRequest("Invalid form: " + err.Error())
+		}
+		return formMetadata(r.PostForm)
+	}
+	return nil, httperr.New(http.StatusUnsupportedMediaType, httperr.CodeUnsupportedMedia, "Content-Type must be application/json, application/x-www-form-urlencoded or multipart/form-data")
+}
+
+// AttachDraftFile handles PUT /api/v1/matches/{id}/files/{type}.
+// The body is the tracking, events or video file; the optional filename
+// query parameter names it, which keeps a video's extension, and provider
+// names the data provider as for uploads. The file is converted, scanned
+// and stored like an uploaded one, replacing the draft's file of its type.
+func (vc *VideoController) AttachDraftFile(w http.ResponseWriter, r *http.Request) {
+	if !vc.draftsConfigured(w, r) {
+		return
+	}
+	vc.withProcessingLock(w, r, vc.attachDraftFile)
+}
+
+// attachDraftFile stores a draft's file, holding the match's processing
+// lock so the draft is not submitted meanwhile.
+func (vc *VideoController) attachDraftFile(w http.ResponseWriter, r *http.Request) {
+	info := requestctx.From(r)
+	kind := mux.Vars(r)["type"]
+	if kind != models.FileKindTracking && kind != models.FileKindEvents && kind != models.FileKindVideo {
+		httperr.WriteError(w, r, httperr.BadRequest("Invalid file type, expected tracking, events or video"))
+		return
+	}
+	provider := r.URL.Query().Get(providerField)
+	if !adapters.ValidProvider(provider) {
+		httperr.WriteError(w, r, invalidProvider(provider))
+		return
+	}
+	video, ok := vc.draftTarget(w, r)
+	if !ok {
+		return
+	}
+	if !vc.limitToQuota(w, r, info) {
+		return
+	}
+
+	// The body is spooled so the file can be converted and scanned like an
+	// uploaded one
+	spool, err := os.CreateTemp("", "nivai-draft-*")
+	if err != nil {
+		info.Logger.Printf("Error spooling %s file of draft %s: %v", kind, video.ID, err)
+		httperr.WriteError(w, r, httperr.Internal("Failed to store file"))
+		return
+	}
+	defer os.Remove(spool.Name())
+	defer spool.Close()
+	var body io.Reader = http.MaxBytesReader(w, r.Body, maxUploadSize)
+	if limit := vc.uploadLimits.of(kind); limit > 0 {
+		body = &cappedReader{r: body, err: &partTooLargeError{kind: kind, limit: limit}}
+	}
+	size, err := io.Copy(spool, body)
+	if err != nil {
+		httperr.WriteError(w, r, uploadError(err))
+		return
+	}
+	if size == 0 {
+		httperr.WriteError(w, r, httperr.BadRequest("The "+kind+" file is empty"))
+		return
+	}
+	if _, err := spool.Seek(0, io.SeekStart); err != nil {
+		info.Logger.Printf("Error rewinding %s file of draft %s: %v", kind, video.ID, err)
+		httperr.WriteError(w, r, httperr.Internal("Failed to store file"))
+		return
+	}
+
+	filename := r.URL.Query().Get("filename")
+	if filename == "" {
+		filename = kind
+	}
+	f := &uploadedFile{file: spool, header: &multipart.FileHeader{Filename: filename, Size: size}, kind: kind}
+	if err := vc.saveUploadedFiles(r.Context(), []*uploadedFile{f}, vc.uploadDir(video.ID, info.Org), video.ID, provider, nil); err != nil {
+		httperr.WriteError(w, r, uploadError(err))
+		return
+	}
+	stored := &models.VideoFile{Kind: kind, Path: f.path, Size: f.size, Checksum: f.checksum}
+	draft, err := vc.drafts.Attach(video.ID, stored)
+	if err != nil {
+		vc.storageService.DeleteFile(f.path)
+		if errors.Is(err, services.ErrNotDraft) {
+			httperr.WriteError(w, r, httperr.Conflict("Match is no longer a draft"))
+			return
+		}
+		info.Logger.Printf("Error attaching %s file to draft %s: %v", kind, video.ID, err)
+		httperr.WriteError(w, r, httperr.Internal("Failed to attach file"))
+		return
+	}
+	if vc.quotas != nil {
+		if err := vc.quotas.Charge(video.ID, info.Org, info.Principal.UserID, []*models.VideoFile{stored}); err != nil {
+			info.Logger.Printf("Warning: Failed to charge storage of draft %s: %v", video.ID, err)
+		}
+	}
+
+	// Infected files quarantine the draft, as they do an upload
+	if f.threat != "" {
+		vc.finishUpload(w, r, draft, map[string]string{kind: f.threat}, nil)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(draftResponse{Video: draft, ExpiresAt: vc.drafts.ExpiresAt(draft)}); err != nil {
+		info.Logger.Printf("Error encoding AttachDraftFile response: %v", err)
+	}
+}
+
+// SubmitDraft handles POST /api/v1/matches/{id}/submit.
+// A draft with its tracking and event files attached leaves the draft
+// state and its processing starts, as after an upload. The optional
+// priority query parameter sets the processing priority.
+func (vc *VideoController) SubmitDraft(w http.ResponseWriter, r *http.Request) {
+	if !vc.draftsConfigured(w, r) {
+		return
+	}
+	vc.withProcessingLock(w, r, vc.submitDraft)
+}
+
+// submitDraft submits a draft, holding the match's processing lock.
+func (vc *VideoController) submitDraft(w http.ResponseWriter, r *http.Request) {
+	info := requestctx.From(r)
+	priority := r.URL.Query().Get(priorityField)
+	if invalid := parsePriority(priority); invalid != nil {
+		httperr.WriteError(w, r, invalid)
+		return
+	}
+	video, ok := vc.draftTarget(w, r)
+	if !ok {
+		return
+	}
+	if video.TrackingPath == "" || video.EventFilePath == "" {
+		httperr.WriteError(w, r, httperr.Conflict("Tracking and event files are required for analytics processing."))
+		return
+	}
+
+	change := services.StateChange{Actor: info.Principal.UserID, Reason: "draft submitted"}
+	if !vc.changeProcessingState(w, r, video.ID, models.StatePendingAnalytics, change) {
+		return
+	}
+	r = r.WithContext(services.WithPriority(r.Context(), priority))
+	vc.finishUpload(w, r, video, nil, nil)
+}
+
+// draftTarget loads the draft of an attach or submit request, writing the
+// error response if it cannot or the match is no draft.
+func (vc *VideoController) draftTarget(w http.ResponseWriter, r *http.Request) (*models.Video, bool) {
+	video, ok := vc.processingTarget(w, r)
+	if !ok {
+		return nil, false
+	}
+	if video.ProcessingState != models.StateDraft {
+		httperr.WriteError(w, r, httperr.Conflict(fmt.Sprintf("Match is %s, not a draft", video.ProcessingState)))
+		return nil, false
+	}
+	return video, true
+}
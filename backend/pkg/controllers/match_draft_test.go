@@ -0,0 +1,146 @@
+package controllers_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"nivai/backend/pkg/controllers"
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/pythonapi"
+	"nivai/backend/pkg/services"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// newDraftRouter serves the draft endpoints against a fake Python API that
+// records the matches it is asked to process.
+func newDraftRouter(t *testing.T, opts ...controllers.VideoControllerOption) (*mux.Router, *models.MemoryVideoRepository, *[]string) {
+	var processed []string
+	pythonServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		processed = append(processed, string(body))
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(`{"message":"ok"}`))
+	}))
+	t.Cleanup(pythonServer.Close)
+
+	storage := services.NewMemoryStorageService()
+	repos := models.NewMemoryRepositories()
+	videoRepo := repos.Videos.(*models.MemoryVideoRepository)
+	videoService := services.NewVideoService(videoRepo, storage)
+	purges := services.NewVideoPurgeService(videoRepo, videoService, repos.VideoPurges, storage, nil, 0)
+	vc := controllers.NewVideoController(videoService, storage, pythonapi.NewClient(pythonServer.URL, pythonServer.Client()), nil,
+		append([]controllers.VideoControllerOption{controllers.WithDrafts(services.NewDraftService(videoRepo, videoService, storage, purges, 0))}, opts...)...)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/matches", vc.CreateDraft).Methods("POST")
+	router.HandleFunc("/matches/{id}/files/{type}", vc.AttachDraftFile).Methods("PUT")
+	router.HandleFunc("/matches/{id}/submit", vc.SubmitDraft).Methods("POST")
+	return router, videoRepo, &processed
+}
+
+func TestMatchDrafts(t *testing.T) {
+	serve := func(router *mux.Router, method, target, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(method, target, strings.NewReader(body)))
+		return rr
+	}
+	createDraft := func(t *testing.T, router *mux.Router) string {
+		rr := serve(router, "POST", "/matches", `{"title":"Ajax - PSV","home_team":"Ajax","away_team":"PSV","match_date":"2024-05-12"}`)
+		require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+		var draft struct {
+			ID              string `json:"id"`
+			ProcessingState string `json:"processing_state"`
+			ExpiresAt       string `json:"expires_at"`
+		}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &draft))
+		assert.Equal(t, "/api/v1/matches/"+draft.ID, rr.Header().Get("Location"))
+		assert.Equal(t, string(models.StateDraft), draft.ProcessingState)
+		assert.NotEmpty(t, draft.ExpiresAt)
+		return draft.ID
+	}
+
+	t.Run("Drafts get their files attached and are then submitted", func(t *testing.T) {
+		router, videoRepo, processed := newDraftRouter(t)
+		id := createDraft(t, router)
+
+		rr := serve(router, "PUT", "/matches/"+id+"/files/tracking", "frame,x,y\n1,0,0\n")
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		rr = serve(router, "PUT", "/matches/"+id+"/files/events", "event_id,event_type\n1,pass\n")
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		assert.Empty(t, *processed, "nothing is processed before the draft is submitted")
+
+		rr = serve(router, "POST", "/matches/"+id+"/submit", "")
+		require.Equal(t, http.StatusAccepted, rr.Code, rr.Body.String())
+		video, err := videoRepo.FindByID(id)
+		require.NoError(t, err)
+		assert.Equal(t, models.StatePendingAnalytics, video.ProcessingState)
+		assert.NotEmpty(t, video.TrackingPath)
+		assert.NotEmpty(t, video.EventFilePath)
+		require.Len(t, *processed, 1)
+		assert.Contains(t, (*processed)[0], id)
+
+		rr = serve(router, "PUT", "/matches/"+id+"/files/tracking", "frame,x,y\n")
+		assert.Equal(t, http.StatusConflict, rr.Code, "submitted matches are no drafts")
+	})
+
+	t.Run("Drafts without their files cannot be submitted", func(t *testing.T) {
+		router, videoRepo, processed := newDraftRouter(t)
+		id := createDraft(t, router)
+		require.Equal(t, http.StatusOK, serve(router, "PUT", "/matches/"+id+"/files/tracking", "frame,x,y\n1,0,0\n").Code)
+
+		rr := serve(router, "POST", "/matches/"+id+"/submit", "")
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		video, err := videoRepo.FindByID(id)
+		require.NoError(t, err)
+		assert.Equal(t, models.StateDraft, video.ProcessingState)
+		assert.Empty(t, *processed)
+	})
+
+	t.Run("Chunked draft files stop at the storage quota", func(t *testing.T) {
+		usageRepo := new(MockStorageUsageRepository)
+		usageRepo.On("FindUsage", models.UsageScopeOrganization, mock.Anything).Return(&models.StorageUsage{Bytes: 990}, nil)
+		quotas := services.NewQuotaService(usageRepo, services.QuotaConfig{OrganizationBytes: 1000})
+		router, videoRepo, _ := newDraftRouter(t, controllers.WithQuotas(quotas))
+		id := createDraft(t, router)
+
+		req := httptest.NewRequest("PUT", "/matches/"+id+"/files/tracking", strings.NewReader("frame,x,y\n"+strings.Repeat("1,0,0\n", 100)))
+		req.ContentLength = -1 // Transfer-Encoding: chunked
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusPaymentRequired, rr.Code, rr.Body.String())
+		video, err := videoRepo.FindByID(id)
+		require.NoError(t, err)
+		assert.Empty(t, video.TrackingPath)
+	})
+
+	t.Run("Invalid drafts and files are refused", func(t *testing.T) {
+		router, videoRepo, _ := newDraftRouter(t)
+		assert.Equal(t, http.StatusBadRequest, serve(router, "POST", "/matches", `{"home_team":"Ajax"}`).Code)
+		assert.Equal(t, http.StatusBadRequest, serve(router, "POST", "/matches", `{"title":"a","match_date":"12-05-2024"}`).Code)
+
+		id := createDraft(t, router)
+		assert.Equal(t, http.StatusBadRequest, serve(router, "PUT", "/matches/"+id+"/files/lineup", "x").Code)
+		assert.Equal(t, http.StatusBadRequest, serve(router, "PUT", "/matches/"+id+"/files/tracking", "").Code)
+		assert.Equal(t, http.StatusNotFound, serve(router, "PUT", "/matches/missing/files/tracking", "x").Code)
+
+		require.NoError(t, videoRepo.Create(&models.Video{ID: "done", Title: "AZ - Feyenoord", ProcessingState: models.StateCompleted}))
+		assert.Equal(t, http.StatusConflict, serve(router, "PUT", "/matches/done/files/tracking", "frame,x,y\n").Code)
+		assert.Equal(t, http.StatusConflict, serve(router, "POST", "/matches/done/submit", "").Code)
+	})
+
+	t.Run("Drafts are not configured without a draft service", func(t *testing.T) {
+		vc := controllers.NewVideoController(services.NewVideoService(models.NewMemoryVideoRepository(), nil), nil, nil, nil)
+		rr := httptest.NewRecorder()
+		vc.CreateDraft(rr, httptest.NewRequest("POST", "/matches", strings.NewReader(`{"title":"a"}`)))
+		assert.Equal(t, http.StatusNotImplemented, rr.Code)
+	})
+}
@@ -0,0 +1,181 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"nivai/backend/pkg/httperr"
+	"nivai/backend/pkg/lock"
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/pythonapi"
+	"nivai/backend/pkg/requestctx"
+	"nivai/backend/pkg/services"
+
+	"github.com/gorilla/mux"
+)
+
+// processingResponse is the body of the reprocess and cancel endpoints.
+type processingResponse struct {
+	VideoID         string                 `json:"video_id"`
+	ProcessingState models.ProcessingState `json:"processing_state"`
+}
+
+// ReprocessMatch handles POST /api/v1/matches/{id}/reprocess.
+// The match's stored tracking and event files are sent to the Python API
+// again and the match returns to "pending_analytics"; its current analytics
+// stay cached until the new results arrive. Matches being processed must be
+// cancelled first. An optional priority query parameter sets the processing
+// priority, as for uploads.
+func (vc *VideoController) ReprocessMatch(w http.ResponseWriter, r *http.Request) {
+	vc.withProcessingLock(w, r, func(w http.ResponseWriter, r *http.Request) {
+		priority := r.URL.Query().Get(priorityField)
+		if invalid := parsePriority(priority); invalid != nil {
+			httperr.WriteError(w, r, invalid)
+			return
+		}
+		video, ok := vc.processingTarget(w, r)
+		if !ok {
+			return
+		}
+		vc.reprocess(w, r.WithContext(services.WithPriority(r.Context(), priority)), video, "reprocess requested")
+	})
+}
+
+// reprocess moves a match back to "pending_analytics" and sends its stored
+// files to the Python API. If the Python API does not take the job, the
+// match is marked failed with the error, so it shows up as a failed job.
+func (vc *VideoController) reprocess(w http.ResponseWriter, r *http.Request, video *models.Video, reason string) {
+	info := requestctx.From(r)
+	if video.TrackingPath == "" || video.EventFilePath == "" {
+		httperr.WriteError(w, r, httperr.Conflict("Match has no tracking and event files to process"))
+		return
+	}
+	if !video.ProcessingState.CanTransitionTo(models.StatePendingAnalytics) {
+		httperr.WriteError(w, r, httperr.Conflict("Match cannot be reprocessed while "+string(video.ProcessingState)))
+		return
+	}
+
+	change := services.StateChange{Actor: info.Principal.UserID, Reason: reason}
+	if !vc.changeProcessingState(w, r, video.ID, models.StatePendingAnalytics, change) {
+		return
+	}
+
+	if err := vc.startProcessing(r.Context(), video.ID, video.TrackingPath, video.EventFilePath); err != nil {
+		info.Logger.Printf("Error starting reprocessing of video %s: %v", video.ID, err)
+		failed := services.StateChange{Actor: services.ActorAnalytics, Reason: "processing could not be started: " + err.Error()}
+		if err := vc.videoService.UpdateProcessingState(video.ID, models.StateFailed, failed); err != nil {
+			info.Logger.Printf("Error marking video %s failed: %v", video.ID, err)
+		}
+		httperr.WriteError(w, r, httperr.New(http.StatusBadGateway, httperr.CodeUpstream, "Failed to start processing"))
+		return
+	}
+
+	writeProcessingResponse(w, http.StatusAccepted, video.ID, models.StatePendingAnalytics)
+}
+
+// CancelMatch handles POST /api/v1/matches/{id}/cancel.
+// The Python API is asked to abort processing, after which the match is
+// marked "cancelled". A match the Python API does not know, e.g. after its
+// restart, is cancelled as well; one it already finished answers 409.
+func (vc *VideoController) CancelMatch(w http.ResponseWriter, r *http.Request) {
+	vc.withProcessingLock(w, r, vc.cancelMatch)
+}
+
+// cancelMatch cancels the processing of a match, holding its lock.
+func (vc *VideoController) cancelMatch(w http.ResponseWriter, r *http.Request) {
+	info := requestctx.From(r)
+	video, ok := vc.processingTarget(w, r)
+	if !ok {
+		return
+	}
+	if !video.ProcessingState.CanTransitionTo(models.StateCancelled) {
+		httperr.WriteError(w, r, httperr.Conflict("Match is not being processed"))
+		return
+	}
+
+	err := vc.pythonClient.CancelMatch(r.Context(), video.ID)
+	var apiErr *pythonapi.APIError
+	switch {
+	case err == nil, errors.Is(err, pythonapi.ErrNotFound):
+	case errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusConflict:
+		httperr.WriteError(w, r, httperr.Conflict("Match processing already finished"))
+		return
+	default:
+		info.Logger.Printf("Error cancelling processing of video %s: %v", video.ID, err)
+		httperr.WriteError(w, r, httperr.New(http.StatusBadGateway, httperr.CodeUpstream, "Failed to cancel processing"))
+		return
+	}
+
+	change := services.StateChange{Actor: info.Principal.UserID, Reason: "cancelled by user"}
+	if !vc.changeProcessingState(w, r, video.ID, models.StateCancelled, change) {
+		return
+	}
+	writeProcessingResponse(w, http.StatusOK, video.ID, models.StateCancelled)
+}
+
+// withProcessingLock runs handle holding the lock on the processing of the
+// match in the path, so a reprocess, retry or cancel of the same match on
+// another replica cannot interleave with it. It answers 409 while another
+// request holds the lock.
+func (vc *VideoController) withProcessingLock(w http.ResponseWriter, r *http.Request, handle http.HandlerFunc) {
+	id := mux.Vars(r)["id"]
+	err := lock.Do(r.Context(), vc.locks, "match:"+id+":processing", func(ctx context.Context) error {
+		handle(w, r.WithContext(ctx))
+		return nil
+	})
+	switch {
+	case errors.Is(err, lock.ErrHeld):
+		httperr.WriteError(w, r, httperr.Conflict("Match processing is being changed by another request; try again"))
+	case err != nil:
+		requestctx.From(r).Logger.Printf("Error locking processing of video %s: %v", id, err)
+		httperr.WriteError(w, r, httperr.Unavailable("Failed to lock match processing"))
+	}
+}
+
+// processingTarget loads the match of a reprocess, cancel or retry request,
+// writing the error response if it cannot.
+func (vc *VideoController) processingTarget(w http.ResponseWriter, r *http.Request) (*models.Video, bool) {
+	id := mux.Vars(r)["id"]
+	video, err := vc.videoService.GetVideoByID(id)
+	if err != nil {
+		if errors.Is(err, services.ErrVideoNotFound) {
+			httperr.WriteError(w, r, httperr.NotFound("Match not found"))
+		} else {
+			requestctx.From(r).Logger.Printf("Error retrieving video %s: %v", id, err)
+			httperr.WriteError(w, r, httperr.Internal("Failed to retrieve match"))
+		}
+		return nil, false
+	}
+	return video, true
+}
+
+// changeProcessingState moves a match to a new state, writing the error
+// response if it cannot. A concurrent change that makes the transition
+// invalid answers 409.
+func (vc *VideoController) changeProcessingState(w http.ResponseWriter, r *http.Request, id string, state models.ProcessingState, change services.StateChange) bool {
+	err := vc.videoService.UpdateProcessingState(id, state, change)
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, models.ErrInvalidTransition), errors.Is(err, models.ErrStateConflict):
+		httperr.WriteError(w, r, httperr.Conflict("Match processing state changed; try again"))
+	case errors.Is(err, services.ErrVideoNotFound):
+		httperr.WriteError(w, r, httperr.NotFound("Match not found"))
+	default:
+		requestctx.From(r).Logger.Printf("Error updating processing state of video %s: %v", id, err)
+		httperr.WriteError(w, r, httperr.Internal("Failed to update processing state"))
+	}
+	return false
+}
+
+// writeProcessingResponse writes the state a match was moved to.
+func writeProcessingResponse(w http.ResponseWriter, status int, id string, state models.ProcessingState) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(processingResponse{VideoID: id, ProcessingState: state}); err != nil {
+		log.Printf("Error encoding processing response: %v", err)
+	}
+}
@@ -0,0 +1,196 @@
+package controllers_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"nivai/backend/pkg/controllers"
+	"nivai/backend/pkg/lock"
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/pythonapi"
+	"nivai/backend/pkg/services"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newProcessingRouter serves the reprocess, cancel and job endpoints against
+// a fake Python API answering every call with status.
+func newProcessingRouter(t *testing.T, videoSvc *MockVideoService, status int, opts ...controllers.VideoControllerOption) (*mux.Router, *[]string) {
+	var calls []string
+	pythonServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.Method+" "+r.URL.Path)
+		w.WriteHeader(status)
+		w.Write([]byte(`{"message":"ok","match_id":"v1"}`))
+	}))
+	t.Cleanup(pythonServer.Close)
+
+	vc := controllers.NewVideoController(videoSvc, new(MockStorageService), pythonapi.NewClient(pythonServer.URL, pythonServer.Client()), nil, opts...)
+	router := mux.NewRouter()
+	router.HandleFunc("/matches/{id}/reprocess", vc.ReprocessMatch).Methods("POST")
+	router.HandleFunc("/matches/{id}/cancel", vc.CancelMatch).Methods("POST")
+	router.HandleFunc("/jobs", vc.ListJobs).Methods("GET")
+	router.HandleFunc("/jobs/{id}/retry", vc.RetryJob).Methods("POST")
+	return router, &calls
+}
+
+func processingVideo(state models.ProcessingState) *models.Video {
+	return &models.Video{ID: "v1", ProcessingState: state, TrackingPath: "videos/v1/v1_tracking.gzip", EventFilePath: "videos/v1/v1_events.gzip"}
+}
+
+func TestReprocessMatch(t *testing.T) {
+	t.Run("Resets the state and sends the stored files again", func(t *testing.T) {
+		videoSvc := new(MockVideoService)
+		router, calls := newProcessingRouter(t, videoSvc, http.StatusAccepted)
+		videoSvc.On("GetVideoByID", "v1").Return(processingVideo(models.StateCompleted), nil).Once()
+		videoSvc.On("UpdateProcessingState", "v1", models.StatePendingAnalytics).Return(nil).Once()
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("POST", "/matches/v1/reprocess", nil))
+
+		require.Equal(t, http.StatusAccepted, rr.Code, rr.Body.String())
+		var body map[string]string
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&body))
+		assert.Equal(t, "pending_analytics", body["processing_state"])
+		assert.Equal(t, []string{"POST /process-match"}, *calls)
+		videoSvc.AssertExpectations(t)
+	})
+
+	t.Run("Sends the requested priority", func(t *testing.T) {
+		var got pythonapi.ProcessMatchRequest
+		pythonServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_ = json.NewDecoder(r.Body).Decode(&got)
+			w.WriteHeader(http.StatusAccepted)
+			w.Write([]byte(`{"message":"ok","match_id":"v1"}`))
+		}))
+		defer pythonServer.Close()
+		videoSvc := new(MockVideoService)
+		vc := controllers.NewVideoController(videoSvc, new(MockStorageService), pythonapi.NewClient(pythonServer.URL, pythonServer.Client()), nil)
+		router := mux.NewRouter()
+		router.HandleFunc("/matches/{id}/reprocess", vc.ReprocessMatch).Methods("POST")
+		videoSvc.On("GetVideoByID", "v1").Return(processingVideo(models.StateCompleted), nil).Once()
+		videoSvc.On("UpdateProcessingState", "v1", models.StatePendingAnalytics).Return(nil).Once()
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("POST", "/matches/v1/reprocess?priority=low", nil))
+		require.Equal(t, http.StatusAccepted, rr.Code, rr.Body.String())
+		assert.Equal(t, pythonapi.PriorityLow, got.Priority)
+
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("POST", "/matches/v1/reprocess?priority=urgent", nil))
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		videoSvc.AssertExpectations(t)
+	})
+
+	t.Run("Marks the match failed when the Python API refuses", func(t *testing.T) {
+		videoSvc := new(MockVideoService)
+		router, _ := newProcessingRouter(t, videoSvc, http.StatusInternalServerError)
+		videoSvc.On("GetVideoByID", "v1").Return(processingVideo(models.StateFailed), nil).Once()
+		videoSvc.On("UpdateProcessingState", "v1", models.StatePendingAnalytics).Return(nil).Once()
+		videoSvc.On("UpdateProcessingState", "v1", models.StateFailed).Return(nil).Once()
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("POST", "/matches/v1/reprocess", nil))
+
+		assert.Equal(t, http.StatusBadGateway, rr.Code)
+		videoSvc.AssertExpectations(t)
+	})
+
+	t.Run("Refuses matches being processed", func(t *testing.T) {
+		videoSvc := new(MockVideoService)
+		router, calls := newProcessingRouter(t, videoSvc, http.StatusAccepted)
+		videoSvc.On("GetVideoByID", "v1").Return(processingVideo(models.StateProcessing), nil).Once()
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("POST", "/matches/v1/reprocess", nil))
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		assert.Empty(t, *calls)
+		videoSvc.AssertNotCalled(t, "UpdateProcessingState")
+	})
+
+	t.Run("Refuses while another replica changes the match", func(t *testing.T) {
+		locker := lock.NewLocal()
+		held, err := locker.TryLock(context.Background(), "match:v1:processing")
+		require.NoError(t, err)
+		defer held.Release(context.Background())
+
+		videoSvc := new(MockVideoService)
+		router, calls := newProcessingRouter(t, videoSvc, http.StatusAccepted, controllers.WithProcessingLocks(locker))
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("POST", "/matches/v1/reprocess", nil))
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		assert.Empty(t, *calls)
+		videoSvc.AssertNotCalled(t, "GetVideoByID")
+	})
+
+	t.Run("Unknown match", func(t *testing.T) {
+		videoSvc := new(MockVideoService)
+		router, _ := newProcessingRouter(t, videoSvc, http.StatusAccepted)
+		videoSvc.On("GetVideoByID", "missing").Return(nil, services.ErrVideoNotFound).Once()
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("POST", "/matches/missing/reprocess", nil))
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
+
+func TestCancelMatch(t *testing.T) {
+	t.Run("Aborts processing and marks the match cancelled", func(t *testing.T) {
+		videoSvc := new(MockVideoService)
+		router, calls := newProcessingRouter(t, videoSvc, http.StatusOK)
+		videoSvc.On("GetVideoByID", "v1").Return(processingVideo(models.StateProcessing), nil).Once()
+		videoSvc.On("UpdateProcessingState", "v1", models.StateCancelled).Return(nil).Once()
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("POST", "/matches/v1/cancel", nil))
+
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		assert.Equal(t, []string{"POST /match/v1/cancel"}, *calls)
+		videoSvc.AssertExpectations(t)
+	})
+
+	t.Run("Cancels matches the Python API does not know", func(t *testing.T) {
+		videoSvc := new(MockVideoService)
+		router, _ := newProcessingRouter(t, videoSvc, http.StatusNotFound)
+		videoSvc.On("GetVideoByID", "v1").Return(processingVideo(models.StatePendingAnalytics), nil).Once()
+		videoSvc.On("UpdateProcessingState", "v1", models.StateCancelled).Return(nil).Once()
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("POST", "/matches/v1/cancel", nil))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		videoSvc.AssertExpectations(t)
+	})
+
+	t.Run("Keeps matches the Python API already finished", func(t *testing.T) {
+		videoSvc := new(MockVideoService)
+		router, _ := newProcessingRouter(t, videoSvc, http.StatusConflict)
+		videoSvc.On("GetVideoByID", "v1").Return(processingVideo(models.StateProcessing), nil).Once()
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("POST", "/matches/v1/cancel", nil))
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		videoSvc.AssertNotCalled(t, "UpdateProcessingState")
+	})
+
+	t.Run("Refuses matches not being processed", func(t *testing.T) {
+		videoSvc := new(MockVideoService)
+		router, calls := newProcessingRouter(t, videoSvc, http.StatusOK)
+		videoSvc.On("GetVideoByID", "v1").Return(processingVideo(models.StateCompleted), nil).Once()
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("POST", "/matches/v1/cancel", nil))
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		assert.Empty(t, *calls)
+	})
+}
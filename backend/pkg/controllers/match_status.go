@@ -0,0 +1,205 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/pythonapi"
+	"nivai/backend/pkg/services"
+)
+
+// degradedHeader names the dependency whose outage a response was served
+// around.
+const degradedHeader = "X-Degraded"
+
+// degradedAnalytics is the X-Degraded value while analytics statuses are
+// served stale.
+const degradedAnalytics = "analytics"
+
+// markDegraded marks a response as served around a dependency's outage.
+// Degraded responses are stale, so no cache may keep them.
+func markDegraded(w http.ResponseWriter, dependency string) {
+	w.Header().Set(degradedHeader, dependency)
+	w.Header().Set("Cache-Control", "no-store")
+}
+
+// analyticsStatus is a match's analytics status as a list shows it. A
+// stale status was not fetched for this response: it is the last one
+// fetched, from checkedAt, or the one last synced to the processing state.
+type analyticsStatus struct {
+	pythonapi.MatchStatus
+	stale     bool
+	checkedAt time.Time // Zero when derived from the processing state
+}
+
+// WithStatusDegradation keeps match lists fast while the Python API is
+// down. After failures consecutive failed status calls the circuit opens:
+// for cooldown no status calls are made, then one call probes whether the
+// service is back. Statuses that cannot be fetched are served stale from
+// the last cacheSize fetched, or from the processing state.
+func WithStatusDegradation(failures int, cooldown time.Duration, cacheSize int) MatchControllerOption {
+	return func(mc *MatchController) {
+		mc.breaker = &statusBreaker{threshold: failures, cooldown: cooldown, now: time.Now}
+		mc.statusCache = &statusCache{max: cacheSize}
+	}
+}
+
+// statusBreaker is the circuit breaker of status calls. It counts
+// consecutive failures and opens at the threshold; once the cooldown has
+// passed, a single call is let through to probe the service.
+type statusBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time // Zero while closed
+	probing   bool
+}
+
+// allow reports whether a status call may be made.
+func (b *statusBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch {
+	case b.openUntil.IsZero():
+		return true
+	case b.probing || b.now().Before(b.openUntil):
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+// record counts the outcome of a status call, opening the circuit at the
+// threshold and closing it on success.
+func (b *statusBreaker) record(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	wasOpen := !b.openUntil.IsZero()
+	b.probing = false
+	if !failed {
+		if wasOpen {
+			log.Printf("Analytics status circuit closed; the Python API answers again")
+		}
+		b.failures, b.openUntil = 0, time.Time{}
+		return
+	}
+	b.failures++
+	if wasOpen || b.failures >= b.threshold {
+		if !wasOpen {
+			log.Printf("Analytics status circuit opened after %d failed calls; serving stale statuses for %s", b.failures, b.cooldown)
+		}
+		b.openUntil = b.now().Add(b.cooldown)
+	}
+}
+
+// release gives up a status call without an outcome, so that an abandoned
+// probe does not hold the circuit open: the next call probes again.
+func (b *statusBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// open reports whether status calls are skipped.
+func (b *statusBreaker) open() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return !b.openUntil.IsZero()
+}
+
+// cachedStatus is a status fetched from the Python API and when.
+type cachedStatus struct {
+	status    pythonapi.MatchStatus
+	checkedAt time.Time
+}
+
+// statusCache keeps the last status fetched per match, forgetting the
+// oldest matches when full.
+type statusCache struct {
+	max int
+
+	mu      sync.Mutex
+	entries map[string]cachedStatus
+	order   []string // Match IDs, oldest first
+}
+
+// get returns the last status fetched for a match.
+func (c *statusCache) get(matchID string) (cachedStatus, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[matchID]
+	return entry, ok
+}
+
+// set stores the status fetched for a match.
+func (c *statusCache) set(matchID string, status pythonapi.MatchStatus, at time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = map[string]cachedStatus{}
+	}
+	if _, ok := c.entries[matchID]; !ok {
+		if len(c.order) >= c.max {
+			delete(c.entries, c.order[0])
+			c.order = c.order[1:]
+		}
+		c.order = append(c.order, matchID)
+	}
+	c.entries[matchID] = cachedStatus{status: status, checkedAt: at}
+}
+
+// unavailable reports whether a failed status call means the Python API is
+// unavailable, rather than that it does not know the match.
+func unavailable(err error) bool {
+	var apiErr *pythonapi.APIError
+	return !errors.As(err, &apiErr) || apiErr.StatusCode >= 500
+}
+
+// staleStatus returns the status served for a match whose status cannot be
+// fetched: the last one fetched, or the one synced to its processing state.
+func (mc *MatchController) staleStatus(video *models.Video) (analyticsStatus, bool) {
+	if entry, ok := mc.statusCache.get(video.ID); ok {
+		return analyticsStatus{MatchStatus: entry.status, stale: true, checkedAt: entry.checkedAt}, true
+	}
+	if status := services.AnalyticsStatusOf(video.ProcessingState); status != "" {
+		return analyticsStatus{MatchStatus: pythonapi.MatchStatus{Status: status}, stale: true}, true
+	}
+	return analyticsStatus{}, false
+}
+
+// degradedStatus fetches a match's status through the circuit breaker,
+// serving it stale while the Python API is unavailable.
+func (mc *MatchController) degradedStatus(ctx context.Context, video *models.Video) analyticsStatus {
+	if !mc.breaker.allow() {
+		if status, ok := mc.staleStatus(video); ok {
+			return status
+		}
+		return analyticsStatus{MatchStatus: pythonapi.MatchStatus{Status: "error_circuit_open"}}
+	}
+
+	status, err := mc.fetchAnalyticsStatus(ctx, video.ID)
+	if ctx.Err() == nil {
+		mc.breaker.record(err != nil && unavailable(err))
+	} else {
+		// A call abandoned with the request says nothing about the service
+		mc.breaker.release()
+	}
+	if err == nil {
+		mc.statusCache.set(video.ID, status.MatchStatus, time.Now())
+		return status
+	}
+	if unavailable(err) {
+		if stale, ok := mc.staleStatus(video); ok {
+			return stale
+		}
+	}
+	return status
+}
@@ -0,0 +1,227 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"path/filepath"
+
+	"nivai/backend/pkg/httperr"
+	"nivai/backend/pkg/ingest/adapters"
+	"nivai/backend/pkg/requestctx"
+	"nivai/backend/pkg/services"
+
+	"github.com/google/uuid"
+)
+
+// maxFieldSize caps each non-file field of a streamed upload form.
+const maxFieldSize = 64 << 10
+
+// uploadFields maps the file fields of the upload form to the kind of file
+// they carry.
+var uploadFields = map[string]string{
+	"video_file":    "video",
+	"tracking_file": "tracking",
+	"event_file":    "events",
+}
+
+// errInvalidUploadForm marks a multipart body that cannot be read as an
+// upload form.
+var errInvalidUploadForm = errors.New("invalid multipart form")
+
+// UploadLimits caps the size of each file of a multipart upload. A zero cap
+// leaves the file limited by the request size only.
+type UploadLimits struct {
+	Video    int64
+	Tracking int64
+	Events   int64
+}
+
+// of returns the cap of a kind of file.
+func (l UploadLimits) of(kind string) int64 {
+	switch kind {
+	case "video":
+		return l.Video
+	case "tracking":
+		return l.Tracking
+	case "events":
+		return l.Events
+	}
+	return 0
+}
+
+// WithUploadLimits caps the size of each uploaded file. Files over their cap
+// are rejected with 413 while they stream, and nothing of the upload is kept.
+func WithUploadLimits(limits UploadLimits) VideoControllerOption {
+	return func(vc *VideoController) {
+		vc.uploadLimits = limits
+	}
+}
+
+// partTooLargeError reports a file over its size cap.
+type partTooLargeError struct {
+	kind  string
+	limit int64
+}
+
+func (e *partTooLargeError) Error() string {
+	return fmt.Sprintf("%s file exceeds the maximum size of %dMB", e.kind, e.limit>>20)
+}
+
+// cappedReader fails with a partTooLargeError once more than the cap has
+// been read.
+type cappedReader struct {
+	r    io.Reader
+	read int64
+	err  *partTooLargeError
+}
+
+func (c *cappedReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.read += int64(n)
+	if c.read > c.err.limit {
+		return n, c.err
+	}
+	return n, err
+}
+
+// streamUpload handles a multipart upload by streaming each file to storage
+// as its part arrives, instead of buffering the form in memory and temporary
+// files first. The body is limited to the storage quotas (limitToQuota),
+// since the file sizes are only known once they are read. Form fields may
+// come before or after the files; they are checked once the whole body is
+// read, and the stored files are deleted if they are invalid.
+func (vc *VideoController) streamUpload(w http.ResponseWriter, r *http.Request, streamer services.StreamUploader, reader *multipart.Reader, uploadID string) {
+	info := requestctx.From(r)
+
+	videoID := uuid.New().String()
+	var progress *services.UploadCopy
+	if uploadID != "" {
+		progress = vc.progress.Start(uploadID, videoID, info.Org, info.Principal.UserID, max(r.ContentLength, 0))
+	}
+
+	fields := r.URL.Query()
+	if provider := fields.Get(providerField); !adapters.ValidProvider(provider) {
+		httperr.WriteError(w, r, invalidProvider(provider))
+		return
+	}
+	files, err := vc.streamUploadedFiles(r.Context(), streamer, reader, vc.uploadDir(videoID, info.Org), videoID, progress, fields)
+	if err != nil {
+		progress.Failed(err)
+		httperr.WriteError(w, r, uploadError(err))
+		return
+	}
+
+	video, tracking, events := files["video"], files["tracking"], files["events"]
+	var reject *httperr.Error
+	if tracking == nil || events == nil {
+		reject = httperr.BadRequest("Tracking and event files are required for analytics processing.")
+	}
+	metadata, invalid := formMetadata(fields)
+	if reject == nil && invalid != nil {
+		reject = invalid
+	}
+	if reject != nil {
+		vc.deleteUploadedFiles(files)
+		progress.Failed(reject)
+		httperr.WriteError(w, r, reject)
+		return
+	}
+	if video == nil {
+		video = &uploadedFile{kind: "video"}
+	}
+	progress.Copied()
+
+	vc.createUploadedVideo(w, r, videoID, video, tracking, events, metadata)
+}
+
+// streamUploadedFiles reads the parts of a multipart upload in order. Files
+// are streamed to storage, checksummed and scanned as they arrive; other
+// fields are added to fields. Unknown file fields are skipped. On failure,
+// the files already stored are deleted.
+func (vc *VideoController) streamUploadedFiles(ctx context.Context, streamer services.StreamUploader, reader *multipart.Reader, storageDir, videoID string, progress *services.UploadCopy, fields url.Values) (map[string]*uploadedFile, error) {
+	files := make(map[string]*uploadedFile)
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return files, nil
+		}
+		if err == nil {
+			err = vc.streamPart(ctx, streamer, part, storageDir, videoID, progress, files, fields)
+			part.Close()
+		} else if !isBodyLimit(err) {
+			err = fmt.Errorf("%w: %v", errInvalidUploadForm, err)
+		}
+		if err != nil {
+			vc.deleteUploadedFiles(files)
+			return nil, err
+		}
+	}
+}
+
+// streamPart stores one part of a multipart upload: a file in storage, or a
+// field in fields. The JSON metadata is a field even when sent as a file.
+func (vc *VideoController) streamPart(ctx context.Context, streamer services.StreamUploader, part *multipart.Part, storageDir, videoID string, progress *services.UploadCopy, files map[string]*uploadedFile, fields url.Values) error {
+	if part.FileName() == "" || part.FormName() == metadataField {
+		value, err := io.ReadAll(io.LimitReader(part, maxFieldSize+1))
+		switch {
+		case isBodyLimit(err):
+			return err
+		case err != nil:
+			return fmt.Errorf("%w: %v", errInvalidUploadForm, err)
+		case len(value) > maxFieldSize:
+			return fmt.Errorf("%w: field %s is larger than %dKB", errInvalidUploadForm, part.FormName(), maxFieldSize>>10)
+		}
+		fields.Add(part.FormName(), string(value))
+		return nil
+	}
+
+	kind, ok := uploadFields[part.FormName()]
+	if !ok {
+		return nil
+	}
+	if files[kind] != nil {
+		return fmt.Errorf("%w: more than one %s", errInvalidUploadForm, part.FormName())
+	}
+
+	f := &uploadedFile{header: &multipart.FileHeader{Filename: part.FileName(), Header: part.Header}, kind: kind}
+	files[kind] = f
+	var src io.Reader = part
+	if limit := vc.uploadLimits.of(kind); limit > 0 {
+		src = &cappedReader{r: part, err: &partTooLargeError{kind: kind, limit: limit}}
+	}
+	// Progress counts the bytes received, before any format conversion
+	converted, _, err := normalizeUpload(ctx, kind, fields.Get(providerField), progress.Reader(kind, src))
+	if err != nil {
+		return err
+	}
+	defer converted.Close()
+	destPath := filepath.Join(storageDir, services.MatchFileName(videoID, kind, part.FileName()))
+	f.path, f.size, f.checksum, f.threat, err = vc.streamFile(ctx, streamer, converted, destPath, kind, nil)
+	return err
+}
+
+// deleteUploadedFiles deletes the stored files of a failed upload.
+func (vc *VideoController) deleteUploadedFiles(files map[string]*uploadedFile) {
+	for _, f := range files {
+		if f.path != "" {
+			vc.storageService.DeleteFile(f.path)
+		}
+	}
+}
+
+// isTooLarge reports whether err is the request body exceeding its limit.
+func isTooLarge(err error) bool {
+	var tooLarge *http.MaxBytesError
+	return errors.As(err, &tooLarge)
+}
+
+// isBodyLimit reports whether err is the request body exceeding its size
+// limit or the storage quota.
+func isBodyLimit(err error) bool {
+	return isTooLarge(err) || errors.Is(err, services.ErrQuotaExceeded)
+}
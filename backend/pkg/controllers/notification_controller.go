@@ -0,0 +1,142 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"nivai/backend/pkg/httperr"
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/requestctx"
+	"nivai/backend/pkg/services"
+
+	"github.com/gorilla/mux"
+)
+
+// NotificationController lets users choose which notifications they receive
+// and where, and read their in-app inbox.
+type NotificationController struct {
+	notifications *services.NotificationService
+	inbox         *services.NotificationInbox
+}
+
+// NewNotificationController creates a new controller for the notification endpoints.
+func NewNotificationController(notifications *services.NotificationService, inbox *services.NotificationInbox) *NotificationController {
+	return &NotificationController{notifications: notifications, inbox: inbox}
+}
+
+// notificationPreferencesResponse carries a user's preferences with what
+// they can choose from.
+type notificationPreferencesResponse struct {
+	*models.NotificationPreference
+	AvailableChannels []string `json:"available_channels"` // Channels this server can send on
+	AvailableKinds    []string `json:"available_kinds"`
+}
+
+// GetPreferences handles GET /api/v1/notifications/preferences.
+// It returns the caller's preferences; users who never set them receive nothing.
+func (nc *NotificationController) GetPreferences(w http.ResponseWriter, r *http.Request) {
+	pref, err := nc.notifications.Preferences(requestctx.From(r).Principal.UserID)
+	if err != nil {
+		log.Printf("Error reading notification preferences: %v", err)
+		httperr.WriteError(w, r, httperr.Internal("Failed to read notification preferences"))
+		return
+	}
+	nc.writePreferences(w, pref)
+}
+
+// UpdatePreferences handles PUT /api/v1/notifications/preferences.
+// The body replaces the caller's preferences: email, slack_webhook_url,
+// channels and kinds.
+func (nc *NotificationController) UpdatePreferences(w http.ResponseWriter, r *http.Request) {
+	var pref models.NotificationPreference
+	if err := json.NewDecoder(r.Body).Decode(&pref); err != nil {
+		httperr.WriteError(w, r, httperr.BadRequest("Invalid request payload"))
+		return
+	}
+	pref.UserID = requestctx.From(r).Principal.UserID
+
+	if err := nc.notifications.UpdatePreferences(&pref); err != nil {
+		if errors.Is(err, services.ErrInvalidNotificationPreference) {
+			httperr.WriteError(w, r, httperr.BadRequest(err.Error()))
+			return
+		}
+		log.Printf("Error saving notification preferences: %v", err)
+		httperr.WriteError(w, r, httperr.Internal("Failed to save notification preferences"))
+		return
+	}
+	nc.writePreferences(w, &pref)
+}
+
+// ListNotifications handles GET /api/v1/notifications?unread=&limit=&offset=.
+// It lists the caller's inbox newest first; unread=true leaves out read ones.
+func (nc *NotificationController) ListNotifications(w http.ResponseWriter, r *http.Request) {
+	limit, offset := parsePaginationParams(r)
+	unreadOnly := false
+	if value := r.URL.Query().Get("unread"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			httperr.WriteError(w, r, httperr.BadRequest("unread must be true or false"))
+			return
+		}
+		unreadOnly = parsed
+	}
+
+	notifications, err := nc.inbox.List(requestctx.From(r).Principal.UserID, unreadOnly, limit, offset)
+	if err != nil {
+		log.Printf("Error listing notifications: %v", err)
+		httperr.WriteError(w, r, httperr.Internal("Failed to list notifications"))
+		return
+	}
+
+	if err := writeList(w, r, notifications, len(notifications), limit, offset); err != nil {
+		log.Printf("Error encoding ListNotifications response: %v", err)
+	}
+}
+
+// MarkNotificationRead handles POST /api/v1/notifications/{id}/read.
+// Marking a read notification again keeps its read time.
+func (nc *NotificationController) MarkNotificationRead(w http.ResponseWriter, r *http.Request) {
+	err := nc.inbox.MarkRead(requestctx.From(r).Principal.UserID, mux.Vars(r)["id"])
+	if errors.Is(err, models.ErrNotificationNotFound) {
+		httperr.WriteError(w, r, httperr.NotFound("Notification not found"))
+		return
+	}
+	if err != nil {
+		log.Printf("Error marking notification read: %v", err)
+		httperr.WriteError(w, r, httperr.Internal("Failed to mark notification read"))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// MarkAllNotificationsRead handles POST /api/v1/notifications/read-all.
+// It responds with how many notifications were unread.
+func (nc *NotificationController) MarkAllNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	marked, err := nc.inbox.MarkAllRead(requestctx.From(r).Principal.UserID)
+	if err != nil {
+		log.Printf("Error marking notifications read: %v", err)
+		httperr.WriteError(w, r, httperr.Internal("Failed to mark notifications read"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]int{"marked": marked}); err != nil {
+		log.Printf("Error encoding MarkAllNotificationsRead response: %v", err)
+	}
+}
+
+// writePreferences writes preferences with the available choices.
+func (nc *NotificationController) writePreferences(w http.ResponseWriter, pref *models.NotificationPreference) {
+	w.Header().Set("Content-Type", "application/json")
+	resp := notificationPreferencesResponse{
+		NotificationPreference: pref,
+		AvailableChannels:      nc.notifications.Channels(),
+		AvailableKinds:         models.NotificationKinds,
+	}
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Printf("Error encoding notification preferences response: %v", err)
+	}
+}
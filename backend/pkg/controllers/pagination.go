@@ -0,0 +1,54 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"nivai/backend/pkg/requestctx"
+)
+
+// envelopeAPIVersion is the first API version that wraps lists in a Page
+const envelopeAPIVersion = 2
+
+// Page is a list response from API v2 on: one page of items and where it is
+// in the list.
+type Page struct {
+	Items      interface{} `json:"items"`
+	Pagination Pagination  `json:"pagination"`
+	// Set when a dependency is down and the items are served partly stale
+	Degraded bool `json:"degraded,omitempty"`
+}
+
+// Pagination locates a page in a list. A full page may be followed by more.
+type Pagination struct {
+	Limit   int  `json:"limit"`
+	Offset  int  `json:"offset"`
+	Count   int  `json:"count"`
+	HasMore bool `json:"has_more"`
+}
+
+// writeList writes one page of a list: a bare JSON array in API v1, a Page
+// from v2 on.
+func writeList(w http.ResponseWriter, r *http.Request, items interface{}, count, limit, offset int) error {
+	return writePage(w, r, items, count, limit, offset, false)
+}
+
+// writePage writes one page of a list like writeList, marking the Page
+// degraded, and not to be cached, when it was served around a dependency's
+// outage.
+func writePage(w http.ResponseWriter, r *http.Request, items interface{}, count, limit, offset int, degraded bool) error {
+	if degraded {
+		w.Header().Set("Cache-Control", "no-store")
+	}
+	var body interface{} = items
+	if requestctx.From(r).APIVersion >= envelopeAPIVersion {
+		body = Page{
+			Items:      items,
+			Pagination: Pagination{Limit: limit, Offset: offset, Count: count, HasMore: count == limit},
+			Degraded:   degraded,
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(body)
+}
@@ -2,19 +2,99 @@ package controllers
 
 import (
 	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
 	"net/url" // For url.QueryEscape
+	"time"
+
+	"nivai/backend/pkg/httperr"
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/requestctx"
+	"nivai/backend/pkg/services"
+
+	"github.com/gorilla/mux"
 )
 
-// PlayerController handles requests related to player data, like image searches.
+// PlayerController handles requests related to player data, like image
+// searches and cross-match aggregates.
 type PlayerController struct {
-	// Placeholder for future dependencies e.g., an image search service client
+	aggregates *services.PlayerAggregateService
+	access     *services.AccessService
+}
+
+// NewPlayerController creates a new instance of PlayerController. Aggregates
+// only cover the matches the caller has access to; a nil access service
+// covers all of them.
+func NewPlayerController(aggregates *services.PlayerAggregateService, access *services.AccessService) *PlayerController {
+	return &PlayerController{aggregates: aggregates, access: access}
 }
 
-// NewPlayerController creates a new instance of PlayerController.
-func NewPlayerController() *PlayerController {
-	return &PlayerController{}
+// GetPlayerAggregate handles requests for a player's statistics across matches.
+// Path: /analytics/players/{id}/aggregate?from=<date>&to=<date>
+// Dates are YYYY-MM-DD (to is inclusive) or RFC 3339 timestamps; without from
+// all earlier matches are included, and to defaults to now.
+func (pc *PlayerController) GetPlayerAggregate(w http.ResponseWriter, r *http.Request) {
+	playerID, ok := mux.Vars(r)["id"]
+	if !ok || playerID == "" {
+		httperr.WriteError(w, r, httperr.BadRequest("Player ID is required in path"))
+		return
+	}
+
+	query := r.URL.Query()
+	from, err := parseRangeTime(query.Get("from"), false)
+	if err != nil {
+		httperr.WriteError(w, r, httperr.BadRequest(err.Error()))
+		return
+	}
+	to, err := parseRangeTime(query.Get("to"), true)
+	if err != nil {
+		httperr.WriteError(w, r, httperr.BadRequest(err.Error()))
+		return
+	}
+	if to.IsZero() {
+		to = time.Now().UTC()
+	}
+	if !from.IsZero() && from.After(to) {
+		httperr.WriteError(w, r, httperr.BadRequest("from must not be after to"))
+		return
+	}
+
+	var scope *models.AccessScope
+	if pc.access != nil {
+		if scope, err = pc.access.Scope(requestctx.From(r).Principal); err != nil {
+			log.Printf("[GetPlayerAggregate] Error resolving access scope: %v", err)
+			httperr.WriteError(w, r, httperr.Internal("Failed to retrieve matches"))
+			return
+		}
+	}
+
+	aggregate, err := pc.aggregates.Aggregate(r.Context(), playerID, from, to, scope)
+	if err != nil && !isAnalyticsError(err) {
+		log.Printf("[GetPlayerAggregate] Error listing matches for player %s: %v", playerID, err)
+		httperr.WriteError(w, r, httperr.Internal("Failed to retrieve matches"))
+		return
+	}
+	writeAnalyticsResponse(w, r, "GetPlayerAggregate", aggregate, err)
+}
+
+// parseRangeTime parses a from/to query value. Date-only end values cover
+// the whole day. An empty value yields the zero time.
+func parseRangeTime(value string, endOfDay bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q: use YYYY-MM-DD or RFC 3339", value)
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return t, nil
 }
 
 // SearchPlayerImage handles requests to search for a player's image.
@@ -25,7 +105,7 @@ func (pc *PlayerController) SearchPlayerImage(w http.ResponseWriter, r *http.Req
 	playerName := r.URL.Query().Get("name")
 
 	if playerName == "" {
-		http.Error(w, "Query parameter 'name' (player name) is required.", http.StatusBadRequest)
+		httperr.WriteError(w, r, httperr.BadRequest("Query parameter 'name' (player name) is required."))
 		return
 	}
 
@@ -7,15 +7,20 @@ import (
 	"net/url"
 	"strings"
 	"testing"
+	"time"
 
 	"nivai/backend/pkg/controllers" // Adjust import path if your module structure is different
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/pythonapi"
+	"nivai/backend/pkg/services"
 
+	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
 func TestSearchPlayerImage(t *testing.T) {
-	playerController := controllers.NewPlayerController()
+	playerController := controllers.NewPlayerController(nil, nil)
 
 	t.Run("Successful placeholder generation", func(t *testing.T) {
 		playerName := "Test Player"
@@ -81,3 +86,49 @@ func TestSearchPlayerImage(t *testing.T) {
 		assert.True(t, strings.HasSuffix(response["image_url"], url.QueryEscape("Player "+playerName)))
 	})
 }
+
+func TestGetPlayerAggregate(t *testing.T) {
+	from := time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 9, 30, 23, 59, 59, 999999999, time.UTC)
+
+	newRouter := func(pc *controllers.PlayerController) *mux.Router {
+		router := mux.NewRouter()
+		router.HandleFunc("/api/v1/analytics/players/{id}/aggregate", pc.GetPlayerAggregate).Methods("GET")
+		return router
+	}
+
+	t.Run("Aggregate over the requested range", func(t *testing.T) {
+		mockApi := mockPythonApi(t, "/match/m1/stats/summary", map[string]interface{}{
+			"match_id": "m1",
+			"players":  map[string]interface{}{"p7": map[string]interface{}{"total_distance_m": 9000}},
+			"teams":    map[string]interface{}{},
+		}, http.StatusOK)
+		defer mockApi.Close()
+
+		repo := new(MockVideoRepository)
+		repo.On("FindByDateRange", from, to, 100, 0).Return([]*models.Video{
+			{ID: "m1", ProcessingState: "completed"},
+		}, nil).Once()
+		aggregates := services.NewPlayerAggregateService(repo, pythonapi.NewClient(mockApi.URL, mockApi.Client()))
+
+		req := httptest.NewRequest("GET", "/api/v1/analytics/players/p7/aggregate?from=2024-09-01&to=2024-09-30", nil)
+		rr := httptest.NewRecorder()
+		newRouter(controllers.NewPlayerController(aggregates, nil)).ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var aggregate services.PlayerAggregate
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&aggregate))
+		assert.Equal(t, 1, aggregate.MatchesPlayed)
+		assert.Equal(t, 9000.0, aggregate.Stats["total_distance_m"].P50)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Invalid ranges are rejected", func(t *testing.T) {
+		router := newRouter(controllers.NewPlayerController(nil, nil))
+		for _, query := range []string{"from=yesterday", "to=2024-13-01", "from=2024-10-01&to=2024-09-01"} {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/analytics/players/p7/aggregate?"+query, nil))
+			assert.Equal(t, http.StatusBadRequest, rr.Code, query)
+		}
+	})
+}
@@ -0,0 +1,180 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"nivai/backend/pkg/httperr"
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/services"
+
+	"github.com/gorilla/mux"
+)
+
+// PlayerIdentityController manages the player roster and the mappings of
+// each match's tracking players to it.
+type PlayerIdentityController struct {
+	identities *services.PlayerIdentityService
+}
+
+// NewPlayerIdentityController creates a new controller for the roster and
+// player mapping endpoints.
+func NewPlayerIdentityController(identities *services.PlayerIdentityService) *PlayerIdentityController {
+	return &PlayerIdentityController{identities: identities}
+}
+
+// createPlayerRequest is the body of POST /api/v1/players.
+type createPlayerRequest struct {
+	Name         string   `json:"name"`
+	Team         string   `json:"team"`
+	JerseyNumber int      `json:"jersey_number"`
+	ExternalIDs  []string `json:"external_ids"`
+}
+
+// mapPlayerRequest is the body of PUT /api/v1/matches/{id}/players/{tracking_id}.
+type mapPlayerRequest struct {
+	PlayerID string `json:"player_id"`
+}
+
+// ListPlayers handles GET /api/v1/players?team=&limit=&offset=.
+// It lists the roster by name, of one team when team is given.
+func (pc *PlayerIdentityController) ListPlayers(w http.ResponseWriter, r *http.Request) {
+	limit, offset := parsePaginationParams(r)
+
+	players, err := pc.identities.ListPlayers(r.URL.Query().Get("team"), limit, offset)
+	if err != nil {
+		log.Printf("Error listing players: %v", err)
+		httperr.WriteError(w, r, httperr.Internal("Failed to retrieve players"))
+		return
+	}
+
+	if err := writeList(w, r, players, len(players), limit, offset); err != nil {
+		log.Printf("Error encoding ListPlayers response: %v", err)
+	}
+}
+
+// CreatePlayer handles POST /api/v1/players.
+// external_ids are the player's IDs in event feeds, which let AutoMap find
+// the player in a match's events.
+func (pc *PlayerIdentityController) CreatePlayer(w http.ResponseWriter, r *http.Request) {
+	var req createPlayerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httperr.WriteError(w, r, httperr.BadRequest("Invalid request payload"))
+		return
+	}
+
+	player := &models.Player{Name: req.Name, Team: req.Team, JerseyNumber: req.JerseyNumber, ExternalIDs: req.ExternalIDs}
+	if err := pc.identities.CreatePlayer(player); err != nil {
+		if errors.Is(err, services.ErrInvalidPlayer) {
+			httperr.WriteError(w, r, httperr.BadRequest(err.Error()))
+			return
+		}
+		log.Printf("Error creating player: %v", err)
+		httperr.WriteError(w, r, httperr.Internal("Failed to create player"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(player); err != nil {
+		log.Printf("Error encoding CreatePlayer response: %v", err)
+	}
+}
+
+// GetPlayer handles GET /api/v1/players/{id}.
+func (pc *PlayerIdentityController) GetPlayer(w http.ResponseWriter, r *http.Request) {
+	player, err := pc.identities.GetPlayer(mux.Vars(r)["id"])
+	if err != nil {
+		writePlayerIdentityError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(player); err != nil {
+		log.Printf("Error encoding GetPlayer response: %v", err)
+	}
+}
+
+// ListMatchPlayers handles GET /api/v1/matches/{id}/players.
+// It lists the match's tracking players that are mapped to the roster.
+func (pc *PlayerIdentityController) ListMatchPlayers(w http.ResponseWriter, r *http.Request) {
+	mappings, err := pc.identities.MatchMappings(mux.Vars(r)["id"])
+	if err != nil {
+		writePlayerIdentityError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(mappings); err != nil {
+		log.Printf("Error encoding ListMatchPlayers response: %v", err)
+	}
+}
+
+// MapMatchPlayer handles PUT /api/v1/matches/{id}/players/{tracking_id}.
+// It maps the tracking player to the roster player in the body by hand;
+// automatic mapping leaves it alone.
+func (pc *PlayerIdentityController) MapMatchPlayer(w http.ResponseWriter, r *http.Request) {
+	var req mapPlayerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.PlayerID == "" {
+		httperr.WriteError(w, r, httperr.BadRequest("Request body must name a player_id"))
+		return
+	}
+
+	vars := mux.Vars(r)
+	mapping, err := pc.identities.MapPlayer(vars["id"], vars["tracking_id"], req.PlayerID)
+	if err != nil {
+		writePlayerIdentityError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(mapping); err != nil {
+		log.Printf("Error encoding MapMatchPlayer response: %v", err)
+	}
+}
+
+// UnmapMatchPlayer handles DELETE /api/v1/matches/{id}/players/{tracking_id}.
+func (pc *PlayerIdentityController) UnmapMatchPlayer(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	if err := pc.identities.UnmapPlayer(vars["id"], vars["tracking_id"]); err != nil {
+		writePlayerIdentityError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AutoMapMatchPlayers handles POST /api/v1/matches/{id}/players/auto-map.
+// It maps the match's tracking players to the roster of its teams from its
+// event data and jersey numbers, keeping manual mappings, and returns all
+// mappings of the match.
+func (pc *PlayerIdentityController) AutoMapMatchPlayers(w http.ResponseWriter, r *http.Request) {
+	mappings, err := pc.identities.AutoMap(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writePlayerIdentityError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(mappings); err != nil {
+		log.Printf("Error encoding AutoMapMatchPlayers response: %v", err)
+	}
+}
+
+// writePlayerIdentityError maps roster and mapping errors to HTTP responses.
+func writePlayerIdentityError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, services.ErrVideoNotFound):
+		httperr.WriteError(w, r, httperr.NotFound("Match not found"))
+	case errors.Is(err, models.ErrPlayerNotFound):
+		httperr.WriteError(w, r, httperr.NotFound("Player not found"))
+	case errors.Is(err, models.ErrPlayerMappingNotFound):
+		httperr.WriteError(w, r, httperr.NotFound("Tracking player is not mapped"))
+	case errors.Is(err, services.ErrPlayerMappingUnavailable):
+		httperr.WriteError(w, r, httperr.Conflict(err.Error()))
+	default:
+		log.Printf("Error handling player identity request: %v", err)
+		httperr.WriteError(w, r, httperr.Internal("Failed to process player request"))
+	}
+}
@@ -0,0 +1,94 @@
+package controllers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"nivai/backend/pkg/controllers"
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/pythonapi"
+	"nivai/backend/pkg/services"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlayerIdentityController(t *testing.T) {
+	var requestedPaths []string
+	mockApi := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestedPaths = append(requestedPaths, r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"match_id":"m1","player_id":"home_23","time_series":[]}`))
+	}))
+	defer mockApi.Close()
+
+	videoRepo := models.NewMemoryVideoRepository()
+	require.NoError(t, videoRepo.Create(&models.Video{ID: "m1", Title: "Ajax - PSV", ProcessingState: models.StateCompleted}))
+	identities := services.NewPlayerIdentityService(models.NewMemoryPlayerRepository(), models.NewMemoryPlayerMappingRepository(),
+		videoRepo, services.NewMemoryStorageService())
+	pc := controllers.NewPlayerIdentityController(identities)
+	ac := controllers.NewAnalyticsController(pythonapi.NewClient(mockApi.URL, mockApi.Client()), controllers.WithPlayerIdentities(identities))
+
+	router := mux.NewRouter()
+	router.HandleFunc("/players", pc.ListPlayers).Methods("GET")
+	router.HandleFunc("/players", pc.CreatePlayer).Methods("POST")
+	router.HandleFunc("/players/{id}", pc.GetPlayer).Methods("GET")
+	router.HandleFunc("/matches/{id}/players", pc.ListMatchPlayers).Methods("GET")
+	router.HandleFunc("/matches/{id}/players/auto-map", pc.AutoMapMatchPlayers).Methods("POST")
+	router.HandleFunc("/matches/{id}/players/{tracking_id}", pc.MapMatchPlayer).Methods("PUT")
+	router.HandleFunc("/matches/{id}/players/{tracking_id}", pc.UnmapMatchPlayer).Methods("DELETE")
+	router.HandleFunc("/analytics/players/{id}", ac.GetPlayerAnalytics).Methods("GET")
+	serve := func(method, target, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(method, target, strings.NewReader(body)))
+		return rr
+	}
+
+	rr := serve("POST", "/players", `{"name":"Steven Berghuis","team":"Ajax","jersey_number":23,"external_ids":["5503"]}`)
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	var player models.Player
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&player))
+	assert.NotEmpty(t, player.ID)
+	assert.Equal(t, []string{"5503"}, player.ExternalIDs)
+
+	t.Run("Roster players are created and listed", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, serve("POST", "/players", `{"team":"Ajax"}`).Code)
+		assert.Equal(t, http.StatusNotFound, serve("GET", "/players/missing", "").Code)
+		assert.Equal(t, http.StatusOK, serve("GET", "/players/"+player.ID, "").Code)
+
+		var players []models.Player
+		require.NoError(t, json.NewDecoder(serve("GET", "/players?team=ajax", "").Body).Decode(&players))
+		require.Len(t, players, 1)
+		assert.Equal(t, "Steven Berghuis", players[0].Name)
+	})
+
+	t.Run("Mapped players are analysed by their roster ID", func(t *testing.T) {
+		rr := serve("PUT", "/matches/m1/players/home_23", `{"player_id":"`+player.ID+`"}`)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+		var mappings []models.PlayerMapping
+		require.NoError(t, json.NewDecoder(serve("GET", "/matches/m1/players", "").Body).Decode(&mappings))
+		require.Len(t, mappings, 1)
+		assert.Equal(t, models.MappingSourceManual, mappings[0].Source)
+
+		requestedPaths = nil
+		rr = serve("GET", "/analytics/players/"+player.ID+"?match_id=m1", "")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, []string{"/match/m1/player/home_23/details"}, requestedPaths)
+
+		assert.Equal(t, http.StatusNoContent, serve("DELETE", "/matches/m1/players/home_23", "").Code)
+		assert.Equal(t, http.StatusNotFound, serve("DELETE", "/matches/m1/players/home_23", "").Code)
+	})
+
+	t.Run("Invalid mappings are refused", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, serve("PUT", "/matches/m1/players/home_23", `{}`).Code)
+		assert.Equal(t, http.StatusNotFound, serve("PUT", "/matches/m1/players/home_23", `{"player_id":"missing"}`).Code)
+		assert.Equal(t, http.StatusNotFound, serve("PUT", "/matches/missing/players/home_23", `{"player_id":"`+player.ID+`"}`).Code)
+		assert.Equal(t, http.StatusNotFound, serve("GET", "/matches/missing/players", "").Code)
+		assert.Equal(t, http.StatusConflict, serve("POST", "/matches/m1/players/auto-map", "").Code, "the match has no tracking file")
+	})
+}
@@ -0,0 +1,118 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"nivai/backend/pkg/httperr"
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/requestctx"
+	"nivai/backend/pkg/services"
+
+	"github.com/gorilla/mux"
+)
+
+// PlayerPrivacyController serves data subject requests of players: exports
+// of their data, and pseudonymization or erasure.
+type PlayerPrivacyController struct {
+	privacy *services.PlayerPrivacyService
+}
+
+// NewPlayerPrivacyController creates a new controller for the player data
+// export and erasure endpoints.
+func NewPlayerPrivacyController(privacy *services.PlayerPrivacyService) *PlayerPrivacyController {
+	return &PlayerPrivacyController{privacy: privacy}
+}
+
+// erasePlayerRequest is the body of POST /api/v1/admin/players/{id}/erase.
+type erasePlayerRequest struct {
+	Mode   string `json:"mode"`   // "pseudonymize" or "erase"
+	Reason string `json:"reason"` // Required, e.g. the ticket of the request
+}
+
+// ExportPlayerData handles GET /api/v1/admin/players/{id}/export.
+// It downloads everything stored about a roster player as JSON.
+func (pc *PlayerPrivacyController) ExportPlayerData(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	export, err := pc.privacy.Export(r.Context(), id)
+	if err != nil {
+		writePlayerPrivacyError(w, r, err)
+		return
+	}
+
+	info := requestctx.From(r)
+	info.Logger.Printf("Audit: data of player %s exported by user %q", id, info.Principal.UserID)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="player-%s.json"`, id))
+	if err := json.NewEncoder(w).Encode(export); err != nil {
+		log.Printf("Error encoding ExportPlayerData response: %v", err)
+	}
+}
+
+// ErasePlayer handles POST /api/v1/admin/players/{id}/erase.
+// It pseudonymizes or erases the player across matches and responds with
+// the recorded erasure.
+func (pc *PlayerPrivacyController) ErasePlayer(w http.ResponseWriter, r *http.Request) {
+	var req erasePlayerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httperr.WriteError(w, r, httperr.BadRequest("Invalid request payload"))
+		return
+	}
+
+	info := requestctx.From(r)
+	erasure, err := pc.privacy.Erase(r.Context(), services.ErasureRequest{
+		PlayerID: mux.Vars(r)["id"], Mode: req.Mode, Reason: req.Reason, RequestedBy: info.Principal.UserID,
+	})
+	if err != nil {
+		writePlayerPrivacyError(w, r, err)
+		return
+	}
+
+	info.Logger.Printf("Audit: player %s %s by user %q in %d matches, erasure %s",
+		erasure.PlayerID, erasedVerb(erasure.Mode), info.Principal.UserID, len(erasure.MatchIDs), erasure.ID)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(erasure); err != nil {
+		log.Printf("Error encoding ErasePlayer response: %v", err)
+	}
+}
+
+// ListPlayerErasures handles GET /api/v1/admin/player-erasures?player_id=&limit=&offset=.
+// It lists the erasure audit trail newest first, of one player when player_id is given.
+func (pc *PlayerPrivacyController) ListPlayerErasures(w http.ResponseWriter, r *http.Request) {
+	limit, offset := parsePaginationParams(r)
+
+	erasures, err := pc.privacy.Erasures(r.URL.Query().Get("player_id"), limit, offset)
+	if err != nil {
+		log.Printf("Error listing player erasures: %v", err)
+		httperr.WriteError(w, r, httperr.Internal("Failed to retrieve player erasures"))
+		return
+	}
+
+	if err := writeList(w, r, erasures, len(erasures), limit, offset); err != nil {
+		log.Printf("Error encoding ListPlayerErasures response: %v", err)
+	}
+}
+
+// erasedVerb describes an erasure mode in the audit log.
+func erasedVerb(mode string) string {
+	if mode == models.ErasureModePseudonymize {
+		return "pseudonymized"
+	}
+	return "erased"
+}
+
+// writePlayerPrivacyError maps export and erasure errors to HTTP responses.
+func writePlayerPrivacyError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, models.ErrPlayerNotFound):
+		httperr.WriteError(w, r, httperr.NotFound("Player not found"))
+	case errors.Is(err, services.ErrInvalidErasure):
+		httperr.WriteError(w, r, httperr.BadRequest(err.Error()))
+	default:
+		log.Printf("Error handling player data request: %v", err)
+		httperr.WriteError(w, r, httperr.Internal("Failed to process player data request"))
+	}
+}
@@ -0,0 +1,37 @@
+package controllers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"nivai/backend/pkg/httperr"
+	"nivai/backend/pkg/services"
+)
+
+// ReplicationController exposes storage replication metrics to administrators.
+type ReplicationController struct {
+	storage *services.CompositeStorage
+}
+
+// NewReplicationController creates a new controller for replication metrics.
+// storage is nil when replication is not configured.
+func NewReplicationController(storage *services.CompositeStorage) *ReplicationController {
+	return &ReplicationController{storage: storage}
+}
+
+// GetReplicationStats handles GET /api/v1/admin/storage/replication.
+// It reports how many changes the secondary storage is behind, the age of
+// the oldest one, and how many reads failed over to the secondary.
+func (rc *ReplicationController) GetReplicationStats(w http.ResponseWriter, r *http.Request) {
+	if rc.storage == nil {
+		httperr.WriteError(w, r, httperr.NotFound("Storage replication is not configured"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := json.NewEncoder(w).Encode(rc.storage.Stats()); err != nil {
+		log.Printf("Error encoding GetReplicationStats response: %v", err)
+	}
+}
@@ -0,0 +1,93 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"nivai/backend/pkg/httperr"
+	"nivai/backend/pkg/requestctx"
+	"nivai/backend/pkg/services"
+
+	"github.com/gorilla/mux"
+)
+
+// ReportController handles PDF match report generation and download.
+type ReportController struct {
+	reports *services.ReportService
+	access  *services.AccessService
+}
+
+// NewReportController creates a new controller for report endpoints.
+// Reports are only shown to callers with access to their match; a nil
+// access service shows them to everyone.
+func NewReportController(reports *services.ReportService, access *services.AccessService) *ReportController {
+	return &ReportController{reports: reports, access: access}
+}
+
+// StartReport handles POST /api/v1/matches/{id}/report.
+// The report is generated in the background; the response is the job,
+// which can be polled via GetReport until it has a download URL.
+func (rc *ReportController) StartReport(w http.ResponseWriter, r *http.Request) {
+	logger := requestctx.From(r).Logger
+
+	job, err := rc.reports.Start(mux.Vars(r)["id"])
+	if err != nil {
+		if errors.Is(err, services.ErrVideoNotFound) {
+			httperr.WriteError(w, r, httperr.NotFound("Match not found"))
+			return
+		}
+		logger.Printf("Error starting report: %v", err)
+		httperr.WriteError(w, r, httperr.Internal("Failed to start report"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/api/v1/reports/"+job.ID)
+	w.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(w).Encode(job); err != nil {
+		logger.Printf("Error encoding StartReport response: %v", err)
+	}
+}
+
+// GetReport handles GET /api/v1/reports/{id}.
+func (rc *ReportController) GetReport(w http.ResponseWriter, r *http.Request) {
+	job, err := rc.reports.Get(mux.Vars(r)["id"])
+	if err != nil {
+		httperr.WriteError(w, r, httperr.NotFound("Report not found"))
+		return
+	}
+	if !allowMatch(w, r, rc.access, job.MatchID, false) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(job); err != nil {
+		requestctx.From(r).Logger.Printf("Error encoding GetReport response: %v", err)
+	}
+}
+
+// DownloadReport handles GET /api/v1/reports/{id}/download.
+// Reports that are not completed yet (or failed) return 409 Conflict.
+func (rc *ReportController) DownloadReport(w http.ResponseWriter, r *http.Request) {
+	pdf, job, err := rc.reports.Download(mux.Vars(r)["id"])
+	switch {
+	case errors.Is(err, services.ErrReportNotFound):
+		httperr.WriteError(w, r, httperr.NotFound("Report not found"))
+		return
+	case !allowMatch(w, r, rc.access, job.MatchID, false):
+		return
+	case errors.Is(err, services.ErrReportNotReady):
+		httperr.WriteError(w, r, httperr.Conflict("Report is "+job.Status))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", `attachment; filename="match-`+safeFilename(job.MatchID)+`-report.pdf"`)
+	w.Header().Set("Content-Length", strconv.Itoa(len(pdf)))
+	w.Header().Set("Cache-Control", "private, max-age=3600")
+	if _, err := w.Write(pdf); err != nil {
+		requestctx.From(r).Logger.Printf("Error writing report download: %v", err)
+	}
+}
@@ -0,0 +1,121 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"nivai/backend/pkg/httperr"
+	"nivai/backend/pkg/services"
+
+	"github.com/gorilla/mux"
+)
+
+// RetentionController exposes storage retention rules and sweeps.
+type RetentionController struct {
+	retention    *services.RetentionService
+	videoService services.VideoService
+}
+
+// NewRetentionController creates a new controller for retention endpoints.
+func NewRetentionController(retention *services.RetentionService, vs services.VideoService) *RetentionController {
+	return &RetentionController{retention: retention, videoService: vs}
+}
+
+// retentionRequest is the body of PUT /api/v1/matches/{id}/retention.
+type retentionRequest struct {
+	Rules []services.RetentionRule `json:"rules"`
+}
+
+// GetRetentionReport handles GET /api/v1/admin/retention/report.
+// It is a dry run: it lists the files a sweep would archive or delete now
+// without touching them.
+func (rc *RetentionController) GetRetentionReport(w http.ResponseWriter, r *http.Request) {
+	rc.writeReport(w, rc.retention.Sweep(r.Context(), true))
+}
+
+// SweepRetention handles POST /api/v1/admin/retention/sweep.
+// It runs a sweep immediately instead of waiting for the schedule.
+func (rc *RetentionController) SweepRetention(w http.ResponseWriter, r *http.Request) {
+	rc.writeReport(w, rc.retention.Sweep(r.Context(), false))
+}
+
+// GetMatchRetention handles GET /api/v1/matches/{id}/retention.
+// It lists the rules in force for the match and when each file is due.
+func (rc *RetentionController) GetMatchRetention(w http.ResponseWriter, r *http.Request) {
+	matchID := mux.Vars(r)["id"]
+	if !rc.matchExists(w, r, matchID) {
+		return
+	}
+	rc.writeMatchRetention(w, r, matchID)
+}
+
+// UpdateMatchRetention handles PUT /api/v1/matches/{id}/retention.
+// The rules replace the match's overrides; an empty list restores the defaults.
+func (rc *RetentionController) UpdateMatchRetention(w http.ResponseWriter, r *http.Request) {
+	matchID := mux.Vars(r)["id"]
+
+	var req retentionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httperr.WriteError(w, r, httperr.BadRequest("Invalid request payload"))
+		return
+	}
+
+	if !rc.matchExists(w, r, matchID) {
+		return
+	}
+
+	if err := rc.retention.SetOverrides(matchID, req.Rules); err != nil {
+		if errors.Is(err, services.ErrInvalidRetentionRule) {
+			httperr.WriteError(w, r, httperr.BadRequest(err.Error()))
+			return
+		}
+		log.Printf("Error updating retention overrides for match %s: %v", matchID, err)
+		httperr.WriteError(w, r, httperr.Internal("Failed to update retention rules"))
+		return
+	}
+	rc.writeMatchRetention(w, r, matchID)
+}
+
+// writeMatchRetention writes the effective rules of a match.
+func (rc *RetentionController) writeMatchRetention(w http.ResponseWriter, r *http.Request, matchID string) {
+	retention, err := rc.retention.MatchRetention(matchID)
+	if err != nil {
+		log.Printf("Error retrieving retention rules for match %s: %v", matchID, err)
+		httperr.WriteError(w, r, httperr.Internal("Failed to retrieve retention rules"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(retention); err != nil {
+		log.Printf("Error encoding retention response: %v", err)
+	}
+}
+
+// writeReport writes a sweep report; a sweep that failed part-way is a 500
+// with the partial report.
+func (rc *RetentionController) writeReport(w http.ResponseWriter, report *services.RetentionReport) {
+	w.Header().Set("Content-Type", "application/json")
+	if report.Error != "" {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		log.Printf("Error encoding retention report: %v", err)
+	}
+}
+
+// matchExists writes a 404 or 500 response and returns false when the match
+// cannot be found.
+func (rc *RetentionController) matchExists(w http.ResponseWriter, r *http.Request, matchID string) bool {
+	if _, err := rc.videoService.GetVideoByID(matchID); err != nil {
+		if errors.Is(err, services.ErrVideoNotFound) {
+			httperr.WriteError(w, r, httperr.NotFound("Match not found"))
+		} else {
+			log.Printf("Error retrieving match %s: %v", matchID, err)
+			httperr.WriteError(w, r, httperr.Internal("Failed to retrieve match"))
+		}
+		return false
+	}
+	return true
+}
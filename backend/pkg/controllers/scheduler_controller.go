@@ -0,0 +1,57 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"nivai/backend/pkg/httperr"
+	"nivai/backend/pkg/requestctx"
+	"nivai/backend/pkg/scheduler"
+
+	"github.com/gorilla/mux"
+)
+
+// SchedulerController exposes the scheduled background jobs to administrators.
+type SchedulerController struct {
+	scheduler *scheduler.Scheduler
+}
+
+// NewSchedulerController creates a new controller for scheduler endpoints.
+func NewSchedulerController(s *scheduler.Scheduler) *SchedulerController {
+	return &SchedulerController{scheduler: s}
+}
+
+// ListScheduledJobs handles GET /api/v1/admin/scheduler/jobs.
+// It lists the jobs with their schedules, next and last runs, and whether
+// this replica is the leader running them.
+func (sc *SchedulerController) ListScheduledJobs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"leader": sc.scheduler.Leader(),
+		"jobs":   sc.scheduler.Jobs(),
+	}); err != nil {
+		requestctx.From(r).Logger.Printf("Error encoding ListScheduledJobs response: %v", err)
+	}
+}
+
+// TriggerScheduledJob handles POST /api/v1/admin/scheduler/jobs/{name}/run.
+// The job starts at once on the replica receiving the request, leader or
+// not; the response is its status, which can be polled via ListScheduledJobs.
+func (sc *SchedulerController) TriggerScheduledJob(w http.ResponseWriter, r *http.Request) {
+	status, err := sc.scheduler.Trigger(mux.Vars(r)["name"])
+	switch {
+	case errors.Is(err, scheduler.ErrUnknownJob):
+		httperr.WriteError(w, r, httperr.NotFound("Scheduled job not found"))
+		return
+	case errors.Is(err, scheduler.ErrJobRunning):
+		httperr.WriteError(w, r, httperr.Conflict("Scheduled job is already running"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(w).Encode(status); err != nil {
+		requestctx.From(r).Logger.Printf("Error encoding TriggerScheduledJob response: %v", err)
+	}
+}
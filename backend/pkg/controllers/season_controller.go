@@ -0,0 +1,51 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"nivai/backend/pkg/httperr"
+	"nivai/backend/pkg/pythonapi"
+	"nivai/backend/pkg/services"
+
+	"github.com/gorilla/mux"
+)
+
+// SeasonController serves analytics aggregated over a season.
+type SeasonController struct {
+	season *services.SeasonAnalyticsService
+}
+
+// NewSeasonController creates a new SeasonController.
+func NewSeasonController(season *services.SeasonAnalyticsService) *SeasonController {
+	return &SeasonController{season: season}
+}
+
+// GetTeamSeason handles requests for a team's season totals and per-match trend.
+// Path: /analytics/teams/{id}/season?season=<season>
+// Without a season parameter all of the team's processed matches are included.
+func (sc *SeasonController) GetTeamSeason(w http.ResponseWriter, r *http.Request) {
+	teamID, ok := mux.Vars(r)["id"]
+	if !ok || teamID == "" {
+		httperr.WriteError(w, r, httperr.BadRequest("Team ID is required in path"))
+		return
+	}
+
+	summary, err := sc.season.TeamSeason(r.Context(), teamID, r.URL.Query().Get("season"))
+	if err != nil && !isAnalyticsError(err) {
+		log.Printf("[GetTeamSeason] Error listing matches for team %s: %v", teamID, err)
+		httperr.WriteError(w, r, httperr.Internal("Failed to retrieve team matches"))
+		return
+	}
+	writeAnalyticsResponse(w, r, "GetTeamSeason", summary, err)
+}
+
+// isAnalyticsError reports whether err came from the analytics service, as
+// opposed to a local failure such as a database error.
+func isAnalyticsError(err error) bool {
+	var apiErr *pythonapi.APIError
+	return errors.As(err, &apiErr) ||
+		errors.Is(err, pythonapi.ErrUnavailable) ||
+		errors.Is(err, pythonapi.ErrInvalidResponse)
+}
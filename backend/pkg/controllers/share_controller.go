@@ -0,0 +1,305 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"nivai/backend/pkg/httperr"
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/requestctx"
+	"nivai/backend/pkg/services"
+
+	"github.com/gorilla/mux"
+)
+
+// ShareController manages share links of matches and serves the shared
+// matches to their holders, who need no account.
+type ShareController struct {
+	shares         *services.ShareService
+	videoService   services.VideoService
+	analytics      services.AnalyticsReader
+	storageService services.StorageService
+}
+
+// NewShareController creates a new controller for the share link endpoints
+// and the shared match endpoints the links open.
+func NewShareController(shares *services.ShareService, vs services.VideoService, analytics services.AnalyticsReader, ss services.StorageService) *ShareController {
+	return &ShareController{shares: shares, videoService: vs, analytics: analytics, storageService: ss}
+}
+
+// createShareRequest is the body of POST /api/v1/matches/{id}/share.
+type createShareRequest struct {
+	Scope          string `json:"scope"`            // "full", "analytics" or "clip"; empty is full
+	ExpiresInHours int    `json:"expires_in_hours"` // 0 uses the default lifetime
+	ClipStartMS    int64  `json:"clip_start_ms"`
+	ClipEndMS      int64  `json:"clip_end_ms"`
+	Label          string `json:"label"`
+}
+
+// createShareResponse carries a new share link with its token, which is not
+// shown again.
+type createShareResponse struct {
+	Share *models.ShareLink `json:"share"`
+	Token string            `json:"token"`
+	URL   string            `json:"url"` // Path of the shared match, to send to the recipient
+}
+
+// sharedMatch is what a share link shows of a match: no storage paths or
+// uploader.
+type sharedMatch struct {
+	ID          string    `json:"id"`
+	Title       string    `json:"title"`
+	HomeTeam    string    `json:"home_team,omitempty"`
+	AwayTeam    string    `json:"away_team,omitempty"`
+	MatchDate   time.Time `json:"match_date,omitempty"`
+	Competition string    `json:"competition,omitempty"`
+	Season      string    `json:"season,omitempty"`
+	Duration    float64   `json:"duration"`
+}
+
+// sharedMatchResponse is the body of GET /api/v1/shared/{token}.
+type sharedMatchResponse struct {
+	Match       sharedMatch `json:"match"`
+	Scope       string      `json:"scope"`
+	ExpiresAt   time.Time   `json:"expires_at"`
+	ClipStartMS int64       `json:"clip_start_ms,omitempty"` // Window of the video a clip link shows
+	ClipEndMS   int64       `json:"clip_end_ms,omitempty"`
+}
+
+// CreateShare handles POST /api/v1/matches/{id}/share.
+// It creates a signed, expiring share link for the match and returns its
+// token; the token is only shown in this response.
+func (sc *ShareController) CreateShare(w http.ResponseWriter, r *http.Request) {
+	var req createShareRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		httperr.WriteError(w, r, httperr.BadRequest("Invalid request payload"))
+		return
+	}
+
+	info := requestctx.From(r)
+	link, token, err := sc.shares.Create(mux.Vars(r)["id"], info.Principal.UserID, services.ShareRequest{
+		Scope:       req.Scope,
+		TTL:         time.Duration(req.ExpiresInHours) * time.Hour,
+		ClipStartMS: req.ClipStartMS,
+		ClipEndMS:   req.ClipEndMS,
+		Label:       req.Label,
+	})
+	if err != nil {
+		writeShareError(w, r, err)
+		return
+	}
+	info.Logger.Printf("Audit: share link %s of match %s created by user %q, scope %s, expires %s",
+		link.ID, link.VideoID, info.Principal.UserID, link.Scope, link.ExpiresAt.Format(time.RFC3339))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	resp := createShareResponse{Share: link, Token: token, URL: fmt.Sprintf("/api/v%d/shared/%s", max(info.APIVersion, 1), token)}
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Printf("Error encoding CreateShare response: %v", err)
+	}
+}
+
+// ListShares handles GET /api/v1/matches/{id}/shares.
+// It lists the match's share links, newest first, including revoked and
+// expired ones.
+func (sc *ShareController) ListShares(w http.ResponseWriter, r *http.Request) {
+	links, err := sc.shares.List(mux.Vars(r)["id"])
+	if err != nil {
+		writeShareError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(links); err != nil {
+		log.Printf("Error encoding ListShares response: %v", err)
+	}
+}
+
+// RevokeShare handles DELETE /api/v1/matches/{id}/shares/{share_id}.
+// The link's token stops working at once; the link and its access log are kept.
+func (sc *ShareController) RevokeShare(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	if err := sc.shares.Revoke(vars["id"], vars["share_id"]); err != nil {
+		writeShareError(w, r, err)
+		return
+	}
+	info := requestctx.From(r)
+	info.Logger.Printf("Audit: share link %s of match %s revoked by user %q", vars["share_id"], vars["id"], info.Principal.UserID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListShareAccesses handles GET /api/v1/matches/{id}/shares/{share_id}/accesses?limit=&offset=.
+// It lists every use of the link, newest first, including refused ones.
+func (sc *ShareController) ListShareAccesses(w http.ResponseWriter, r *http.Request) {
+	limit, offset := parsePaginationParams(r)
+	vars := mux.Vars(r)
+
+	accesses, err := sc.shares.Accesses(vars["id"], vars["share_id"], limit, offset)
+	if err != nil {
+		writeShareError(w, r, err)
+		return
+	}
+
+	if err := writeList(w, r, accesses, len(accesses), limit, offset); err != nil {
+		log.Printf("Error encoding ListShareAccesses response: %v", err)
+	}
+}
+
+// GetSharedMatch handles GET /api/v1/shared/{token}.
+// It describes the shared match and what the link grants.
+func (sc *ShareController) GetSharedMatch(w http.ResponseWriter, r *http.Request) {
+	link, video, ok := sc.open(w, r, services.ShareResourceMatch)
+	if !ok {
+		return
+	}
+
+	resp := sharedMatchResponse{
+		Match: sharedMatch{
+			ID: video.ID, Title: video.Title, HomeTeam: video.HomeTeam, AwayTeam: video.AwayTeam,
+			MatchDate: video.MatchDate, Competition: video.Competition, Season: video.Season, Duration: video.Duration,
+		},
+		Scope:       link.Scope,
+		ExpiresAt:   link.ExpiresAt,
+		ClipStartMS: link.ClipStartMS,
+		ClipEndMS:   link.ClipEndMS,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "private, no-store")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Printf("Error encoding GetSharedMatch response: %v", err)
+	}
+}
+
+// GetSharedAnalytics handles GET /api/v1/shared/{token}/analytics.
+// It returns the shared match's analytics summary, read-only.
+func (sc *ShareController) GetSharedAnalytics(w http.ResponseWriter, r *http.Request) {
+	link, _, ok := sc.open(w, r, services.ShareResourceAnalytics)
+	if !ok {
+		return
+	}
+
+	summary, err := sc.analytics.GetMatchSummary(r.Context(), link.VideoID)
+	writeAnalyticsResponse(w, r, "GetSharedAnalytics", summary, err)
+}
+
+// GetSharedVideo handles GET /api/v1/shared/{token}/video, streaming the
+// shared match's video with range support. Clip links get the whole video
+// too, since a byte range of the file is not a playable cut of it; the
+// window to play is sent as X-Clip-Start-Ms and X-Clip-End-Ms, and as a
+// media fragment in Content-Location.
+func (sc *ShareController) GetSharedVideo(w http.ResponseWriter, r *http.Request) {
+	link, video, ok := sc.open(w, r, services.ShareResourceVideo)
+	if !ok {
+		return
+	}
+
+	if video.ProcessingState == models.StateRejected {
+		httperr.WriteError(w, r, httperr.New(http.StatusForbidden, httperr.CodeForbidden, "Match files are quarantined"))
+		return
+	}
+	if video.ProcessingState == models.StateArchived || video.ProcessingState == models.StateRestoring {
+		httperr.WriteError(w, r, httperr.Conflict("Match video is in cold storage"))
+		return
+	}
+	if video.FilePath == "" {
+		httperr.WriteError(w, r, httperr.NotFound("Match has no video file"))
+		return
+	}
+
+	file, err := sc.storageService.GetFile(video.FilePath)
+	if err != nil {
+		requestctx.From(r).Logger.Printf("Error opening shared video of match %s: %v", video.ID, err)
+		if strings.Contains(err.Error(), "not found") {
+			httperr.WriteError(w, r, httperr.NotFound("Stored file not found"))
+		} else {
+			httperr.WriteError(w, r, httperr.Internal("Failed to retrieve file"))
+		}
+		return
+	}
+	defer file.Close()
+
+	contentType := mime.TypeByExtension(filepath.Ext(video.FilePath))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "private, no-store")
+	if link.Scope == models.ShareScopeClip {
+		w.Header().Set("X-Clip-Start-Ms", fmt.Sprint(link.ClipStartMS))
+		w.Header().Set("X-Clip-End-Ms", fmt.Sprint(link.ClipEndMS))
+		w.Header().Set("Content-Location", fmt.Sprintf("%s#t=%.3f,%.3f", r.URL.Path, float64(link.ClipStartMS)/1000, float64(link.ClipEndMS)/1000))
+	}
+
+	if seeker, ok := file.(io.ReadSeeker); ok {
+		http.ServeContent(w, r, "", video.UpdatedAt, seeker)
+		return
+	}
+	w.Header().Set("Accept-Ranges", "none")
+	if _, err := io.Copy(w, file); err != nil {
+		requestctx.From(r).Logger.Printf("Error streaming shared video of match %s: %v", video.ID, err)
+	}
+}
+
+// open resolves the request's share token for a resource, logging the
+// access, and loads the shared match. It writes the error response and
+// returns false when access is refused.
+func (sc *ShareController) open(w http.ResponseWriter, r *http.Request, resource string) (*models.ShareLink, *models.Video, bool) {
+	link, err := sc.shares.Open(mux.Vars(r)["token"], models.ShareAccess{
+		Resource:   resource,
+		RemoteAddr: remoteHost(r),
+		UserAgent:  r.UserAgent(),
+	})
+	if err != nil {
+		writeShareError(w, r, err)
+		return nil, nil, false
+	}
+
+	video, err := sc.videoService.GetVideoByID(link.VideoID)
+	if err != nil {
+		if errors.Is(err, services.ErrVideoNotFound) {
+			httperr.WriteError(w, r, httperr.New(http.StatusGone, httperr.CodeGone, "The shared match has been deleted"))
+		} else {
+			httperr.WriteError(w, r, httperr.Internal("Failed to retrieve match"))
+		}
+		return nil, nil, false
+	}
+	return link, video, true
+}
+
+// remoteHost returns the IP address a request came from.
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// writeShareError maps share link errors to HTTP responses. Invalid tokens
+// are reported as not found, so tokens cannot be probed.
+func writeShareError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, services.ErrVideoNotFound):
+		httperr.WriteError(w, r, httperr.NotFound("Match not found"))
+	case errors.Is(err, models.ErrShareLinkNotFound), errors.Is(err, services.ErrShareTokenInvalid):
+		httperr.WriteError(w, r, httperr.NotFound("Share link not found"))
+	case errors.Is(err, services.ErrInvalidShare):
+		httperr.WriteError(w, r, httperr.BadRequest(err.Error()))
+	case errors.Is(err, services.ErrShareExpired), errors.Is(err, services.ErrShareRevoked):
+		httperr.WriteError(w, r, httperr.New(http.StatusGone, httperr.CodeGone, err.Error()))
+	case errors.Is(err, services.ErrShareScope):
+		httperr.WriteError(w, r, httperr.New(http.StatusForbidden, httperr.CodeForbidden, err.Error()))
+	default:
+		log.Printf("Error handling share link request: %v", err)
+		httperr.WriteError(w, r, httperr.Internal("Failed to process share link request"))
+	}
+}
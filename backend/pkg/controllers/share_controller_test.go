@@ -0,0 +1,138 @@
+package controllers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"nivai/backend/pkg/controllers"
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/pythonapi"
+	"nivai/backend/pkg/services"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShareController(t *testing.T) {
+	mockApi := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"match_id":"m1","players":{},"teams":{}}`))
+	}))
+	defer mockApi.Close()
+
+	storage := services.NewMemoryStorageService()
+	// One byte per second of the 100 second match
+	video := make([]byte, 100)
+	for i := range video {
+		video[i] = byte(i)
+	}
+	_, err := storage.UploadStream(bytes.NewReader(video), "videos/m1/m1.mp4")
+	require.NoError(t, err)
+	videoRepo := models.NewMemoryVideoRepository()
+	require.NoError(t, videoRepo.Create(&models.Video{
+		ID: "m1", Title: "Ajax - PSV", HomeTeam: "Ajax", AwayTeam: "PSV", ProcessingState: models.StateCompleted,
+		FilePath: "videos/m1/m1.mp4", TrackingPath: "videos/m1/m1_tracking.gzip", Duration: 100, Size: int64(len(video)),
+	}))
+	shares := services.NewShareService(models.NewMemoryShareLinkRepository(), videoRepo,
+		services.ShareConfig{SigningKey: "secret", DefaultTTL: time.Hour, MaxTTL: 24 * time.Hour})
+	sc := controllers.NewShareController(shares, services.NewVideoService(videoRepo, storage),
+		pythonapi.NewClient(mockApi.URL, mockApi.Client()), storage)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/matches/{id}/share", sc.CreateShare).Methods("POST")
+	router.HandleFunc("/matches/{id}/shares", sc.ListShares).Methods("GET")
+	router.HandleFunc("/matches/{id}/shares/{share_id}", sc.RevokeShare).Methods("DELETE")
+	router.HandleFunc("/matches/{id}/shares/{share_id}/accesses", sc.ListShareAccesses).Methods("GET")
+	router.HandleFunc("/shared/{token}", sc.GetSharedMatch).Methods("GET")
+	router.HandleFunc("/shared/{token}/analytics", sc.GetSharedAnalytics).Methods("GET")
+	router.HandleFunc("/shared/{token}/video", sc.GetSharedVideo).Methods("GET")
+	serve := func(method, target, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(method, target, strings.NewReader(body)))
+		return rr
+	}
+	share := func(body string) (string, string) {
+		rr := serve("POST", "/matches/m1/share", body)
+		require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+		var created struct {
+			Share models.ShareLink `json:"share"`
+			Token string           `json:"token"`
+			URL   string           `json:"url"`
+		}
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&created))
+		assert.Equal(t, "/api/v1/shared/"+created.Token, created.URL)
+		return created.Share.ID, created.Token
+	}
+
+	t.Run("Analytics links show the match and its analytics, not its video", func(t *testing.T) {
+		shareID, token := share(`{"scope":"analytics","expires_in_hours":2,"label":"Trialist"}`)
+
+		rr := serve("GET", "/shared/"+token, "")
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		assert.Contains(t, rr.Body.String(), `"title":"Ajax - PSV"`)
+		assert.NotContains(t, rr.Body.String(), "m1_tracking", "storage paths are not shared")
+
+		assert.Equal(t, http.StatusOK, serve("GET", "/shared/"+token+"/analytics", "").Code)
+		assert.Equal(t, http.StatusForbidden, serve("GET", "/shared/"+token+"/video", "").Code)
+
+		var accesses []models.ShareAccess
+		require.NoError(t, json.NewDecoder(serve("GET", "/matches/m1/shares/"+shareID+"/accesses", "").Body).Decode(&accesses))
+		require.Len(t, accesses, 3)
+		assert.Equal(t, services.ShareResourceVideo, accesses[0].Resource)
+		assert.False(t, accesses[0].Allowed)
+	})
+
+	t.Run("Clip links stream the video with their window as metadata", func(t *testing.T) {
+		_, token := share(`{"scope":"clip","clip_start_ms":60000,"clip_end_ms":90500}`)
+
+		rr := serve("GET", "/shared/"+token+"/video", "")
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		body, _ := io.ReadAll(rr.Body)
+		assert.Equal(t, video, body, "a byte range of the file is not a playable clip")
+		assert.Equal(t, "60000", rr.Header().Get("X-Clip-Start-Ms"))
+		assert.Equal(t, "90500", rr.Header().Get("X-Clip-End-Ms"))
+		assert.Equal(t, "/shared/"+token+"/video#t=60.000,90.500", rr.Header().Get("Content-Location"))
+		assert.Equal(t, http.StatusForbidden, serve("GET", "/shared/"+token+"/analytics", "").Code)
+
+		req := httptest.NewRequest("GET", "/shared/"+token+"/video", nil)
+		req.Header.Set("Range", "bytes=95-")
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		require.Equal(t, http.StatusPartialContent, rr.Code)
+		assert.Equal(t, video[95:], rr.Body.Bytes())
+	})
+
+	t.Run("Full links send no clip window", func(t *testing.T) {
+		_, token := share(`{}`)
+		rr := serve("GET", "/shared/"+token+"/video", "")
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Empty(t, rr.Header().Get("X-Clip-Start-Ms"))
+		assert.Empty(t, rr.Header().Get("Content-Location"))
+	})
+
+	t.Run("Revoked and unknown links are refused", func(t *testing.T) {
+		shareID, token := share(`{}`)
+		assert.Equal(t, http.StatusNoContent, serve("DELETE", "/matches/m1/shares/"+shareID, "").Code)
+		assert.Equal(t, http.StatusGone, serve("GET", "/shared/"+token, "").Code)
+		assert.Equal(t, http.StatusNotFound, serve("GET", "/shared/forged.token", "").Code)
+		assert.Equal(t, http.StatusNotFound, serve("DELETE", "/matches/m1/shares/missing", "").Code)
+
+		var links []models.ShareLink
+		require.NoError(t, json.NewDecoder(serve("GET", "/matches/m1/shares", "").Body).Decode(&links))
+		assert.Len(t, links, 4)
+	})
+
+	t.Run("Invalid share requests are refused", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, serve("POST", "/matches/m1/share", `{"scope":"edit"}`).Code)
+		assert.Equal(t, http.StatusBadRequest, serve("POST", "/matches/m1/share", `{"expires_in_hours":100}`).Code)
+		assert.Equal(t, http.StatusBadRequest, serve("POST", "/matches/m1/share", `{`).Code)
+		assert.Equal(t, http.StatusNotFound, serve("POST", "/matches/missing/share", `{}`).Code)
+	})
+}
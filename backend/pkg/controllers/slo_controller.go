@@ -0,0 +1,30 @@
+package controllers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"nivai/backend/pkg/slo"
+)
+
+// SLOController exposes service level objective status to administrators.
+type SLOController struct {
+	tracker *slo.Tracker
+}
+
+// NewSLOController creates a new controller for SLO endpoints.
+func NewSLOController(tracker *slo.Tracker) *SLOController {
+	return &SLOController{tracker: tracker}
+}
+
+// GetSLOs handles GET /api/v1/admin/slo.
+// It reports each objective's error rate and burn rate per window and which
+// burn-rate alerts are currently firing.
+func (sc *SLOController) GetSLOs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := json.NewEncoder(w).Encode(sc.tracker.Report()); err != nil {
+		log.Printf("Error encoding GetSLOs response: %v", err)
+	}
+}
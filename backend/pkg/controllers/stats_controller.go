@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"nivai/backend/pkg/httperr"
+	"nivai/backend/pkg/requestctx"
+	"nivai/backend/pkg/services"
+)
+
+// StatsController serves the dashboard statistics.
+type StatsController struct {
+	stats  *services.StatsService
+	access *services.AccessService
+}
+
+// NewStatsController creates a new controller for statistics endpoints.
+// access may be nil when match access control is disabled.
+func NewStatsController(stats *services.StatsService, access *services.AccessService) *StatsController {
+	return &StatsController{stats: stats, access: access}
+}
+
+// GetOverview handles GET /api/v1/stats/overview.
+// It reports the matches by processing state, the storage they use, the
+// uploads and average processing time of the last 30 days. The statistics
+// cover every match, so callers limited to some matches are refused.
+func (sc *StatsController) GetOverview(w http.ResponseWriter, r *http.Request) {
+	info := requestctx.From(r)
+
+	if sc.access != nil {
+		scope, err := sc.access.Scope(info.Principal)
+		if err != nil {
+			info.Logger.Printf("Error resolving access scope for statistics: %v", err)
+			httperr.WriteError(w, r, httperr.Internal("Failed to retrieve statistics"))
+			return
+		}
+		if scope != nil {
+			httperr.WriteError(w, r, httperr.New(http.StatusForbidden, httperr.CodeForbidden, "Statistics cover all matches and need access to all of them"))
+			return
+		}
+	}
+
+	overview, err := sc.stats.Overview()
+	if err != nil {
+		info.Logger.Printf("Error computing statistics: %v", err)
+		httperr.WriteError(w, r, httperr.Internal("Failed to retrieve statistics"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "private, no-cache")
+	if err := json.NewEncoder(w).Encode(overview); err != nil {
+		info.Logger.Printf("Error encoding GetOverview response: %v", err)
+	}
+}
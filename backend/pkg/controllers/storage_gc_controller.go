@@ -0,0 +1,45 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"nivai/backend/pkg/requestctx"
+	"nivai/backend/pkg/services"
+)
+
+// StorageGCController exposes the orphaned file collector to administrators.
+type StorageGCController struct {
+	gc *services.StorageGCService
+}
+
+// NewStorageGCController creates a new controller for storage reconciliation endpoints.
+func NewStorageGCController(gc *services.StorageGCService) *StorageGCController {
+	return &StorageGCController{gc: gc}
+}
+
+// GetOrphanReport handles GET /api/v1/admin/storage/orphans.
+// It is a dry run: it lists stored files no match refers to and files
+// matches refer to that are missing from storage, without deleting anything.
+func (gc *StorageGCController) GetOrphanReport(w http.ResponseWriter, r *http.Request) {
+	gc.writeReport(w, r, gc.gc.Reconcile(r.Context(), true))
+}
+
+// CollectOrphans handles POST /api/v1/admin/storage/gc.
+// It reconciles storage immediately and deletes orphans older than the
+// configured minimum age.
+func (gc *StorageGCController) CollectOrphans(w http.ResponseWriter, r *http.Request) {
+	gc.writeReport(w, r, gc.gc.Reconcile(r.Context(), false))
+}
+
+// writeReport writes a reconciliation report; a run that failed is a 500
+// with the partial report.
+func (gc *StorageGCController) writeReport(w http.ResponseWriter, r *http.Request, report *services.StorageGCReport) {
+	w.Header().Set("Content-Type", "application/json")
+	if report.Error != "" {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		requestctx.From(r).Logger.Printf("Error encoding storage GC report: %v", err)
+	}
+}
@@ -0,0 +1,65 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"nivai/backend/pkg/httperr"
+	"nivai/backend/pkg/requestctx"
+	"nivai/backend/pkg/services"
+)
+
+// SupportController serves support bundles to administrators.
+type SupportController struct {
+	bundles *services.SupportBundleService
+}
+
+// NewSupportController creates a new controller for support endpoints.
+func NewSupportController(bundles *services.SupportBundleService) *SupportController {
+	return &SupportController{bundles: bundles}
+}
+
+// GetSupportBundle handles GET /api/v1/admin/support-bundle.
+// It downloads a zip archive with sanitized configuration, health status,
+// logs, events and failed jobs for a time window (`since` and `until`,
+// RFC 3339, default the last 24 hours), narrowed to one match and extended
+// with its lifecycle history when `match_id` is given.
+func (sc *SupportController) GetSupportBundle(w http.ResponseWriter, r *http.Request) {
+	logger := requestctx.From(r).Logger
+	query := r.URL.Query()
+
+	req := services.SupportBundleRequest{VideoID: query.Get("match_id")}
+	for name, t := range map[string]*time.Time{"since": &req.Since, "until": &req.Until} {
+		if value := query.Get(name); value != "" {
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				httperr.WriteError(w, r, httperr.BadRequest(fmt.Sprintf("Invalid %s: expected an RFC 3339 timestamp", name)))
+				return
+			}
+			*t = parsed
+		}
+	}
+
+	bundle, err := sc.bundles.Generate(r.Context(), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrVideoNotFound):
+			httperr.WriteError(w, r, httperr.NotFound("Match not found"))
+		case errors.Is(err, services.ErrInvalidSupportWindow):
+			httperr.WriteError(w, r, httperr.BadRequest(err.Error()))
+		default:
+			logger.Printf("Error generating support bundle: %v", err)
+			httperr.WriteError(w, r, httperr.Internal("Failed to generate support bundle"))
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", bundle.Filename()))
+	w.Header().Set("Cache-Control", "no-store")
+	if err := bundle.WriteZip(w); err != nil {
+		logger.Printf("Error writing support bundle: %v", err)
+	}
+}
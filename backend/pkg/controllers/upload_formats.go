@@ -0,0 +1,80 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"os"
+	"strings"
+
+	"nivai/backend/pkg/httperr"
+	"nivai/backend/pkg/ingest/adapters"
+	"nivai/backend/pkg/models"
+)
+
+// providerField names the data provider of an upload's tracking and event
+// files. It may be a form field or a query parameter; when it is absent the
+// provider is detected from the files.
+const providerField = "provider"
+
+// normalizeUpload converts a tracking or event file in a provider's export
+// format to the canonical format as it is read, returning the provider it
+// is converted from. Video files, canonical files and files in no known
+// format are read unchanged, with an empty provider. The returned reader
+// must be closed.
+func normalizeUpload(ctx context.Context, kind, provider string, src io.Reader) (io.ReadCloser, string, error) {
+	if kind != models.FileKindTracking && kind != models.FileKindEvents {
+		return io.NopCloser(src), "", nil
+	}
+	converted, detected, err := adapters.Normalize(ctx, kind, provider, src)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read %s file: %w", kind, err)
+	}
+	if detected != "" {
+		log.Printf("Converting uploaded %s file from the %s format", kind, detected)
+	}
+	return converted, detected, nil
+}
+
+// normalizeFile is normalizeUpload for a buffered upload, whose file must
+// stay seekable: a converted file is spooled to a temporary file, which is
+// removed by the returned cleanup.
+func normalizeFile(ctx context.Context, f *uploadedFile, provider string) (multipart.File, func(), error) {
+	converted, detected, err := normalizeUpload(ctx, f.kind, provider, f.file)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer converted.Close()
+	if detected == "" {
+		// The format was sniffed from the start of the file
+		if _, err := f.file.Seek(0, io.SeekStart); err != nil {
+			return nil, nil, fmt.Errorf("failed to rewind %s file: %w", f.kind, err)
+		}
+		return f.file, func() {}, nil
+	}
+
+	spool, err := os.CreateTemp("", "nivai-convert-*")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to spool %s file: %w", f.kind, err)
+	}
+	cleanup := func() {
+		spool.Close()
+		os.Remove(spool.Name())
+	}
+	if _, err := io.Copy(spool, converted); err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("failed to convert %s file: %w", f.kind, err)
+	}
+	if _, err := spool.Seek(0, io.SeekStart); err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("failed to rewind %s file: %w", f.kind, err)
+	}
+	return spool, cleanup, nil
+}
+
+// invalidProvider is the error of an upload naming an unknown provider.
+func invalidProvider(provider string) *httperr.Error {
+	return httperr.BadRequest(fmt.Sprintf("Unknown provider %q, expected one of: %s", provider, strings.Join(adapters.Providers(), ", ")))
+}
@@ -0,0 +1,117 @@
+package controllers_test
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"nivai/backend/pkg/controllers"
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/pythonapi"
+	"nivai/backend/pkg/services"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const tracabUpload = "1000:1,7,10,100,-200,0.0;0,8,4,-500,300,0.0;:0,0,0,0.0,,Alive;:\n"
+
+func TestUploadProviderFormats(t *testing.T) {
+	// upload posts a match to a controller streaming to fresh memory storage,
+	// returning the response and the stored tracking file
+	upload := func(target, tracking string, fields ...string) (*httptest.ResponseRecorder, []byte) {
+		videoRepo := new(MockVideoRepository)
+		videoRepo.On("Create", mock.AnythingOfType("*models.Video")).Return(nil)
+		storage := services.NewMemoryStorageService()
+		videoController := controllers.NewVideoController(services.NewVideoService(videoRepo, storage), storage, pythonapi.NewClient("", nil), nil)
+		router := mux.NewRouter()
+		router.HandleFunc("/api/v1/videos", videoController.UploadVideo).Methods("POST")
+
+		body := new(bytes.Buffer)
+		writer := multipart.NewWriter(body)
+		for i := 0; i < len(fields); i += 2 {
+			writer.WriteField(fields[i], fields[i+1])
+		}
+		trackingPart, _ := writer.CreateFormFile("tracking_file", "match.dat")
+		trackingPart.Write([]byte(tracking))
+		eventPart, _ := writer.CreateFormFile("event_file", "events.csv")
+		eventPart.Write([]byte("event_id,event_type\n"))
+		writer.Close()
+
+		req := httptest.NewRequest("POST", target, body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		files, err := storage.ListFiles("")
+		require.NoError(t, err)
+		for _, file := range files {
+			if strings.HasSuffix(file.Path, "_tracking.gzip") {
+				r, err := storage.GetFile(file.Path)
+				require.NoError(t, err)
+				defer r.Close()
+				content, _ := io.ReadAll(r)
+				return rr, content
+			}
+		}
+		return rr, nil
+	}
+
+	t.Run("Detected exports are stored in the canonical format", func(t *testing.T) {
+		rr, stored := upload("/api/v1/videos", tracabUpload)
+
+		require.Equal(t, http.StatusAccepted, rr.Code, rr.Body.String())
+		assert.True(t, bytes.HasPrefix(stored, []byte("PAR1")), "the Tracab file is stored as Parquet")
+	})
+
+	t.Run("Files that are not the named provider's are rejected", func(t *testing.T) {
+		rr, stored := upload("/api/v1/videos", "player_id,x,y\n", "provider", "tracab")
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "invalid tracab tracking file")
+		assert.Nil(t, stored, "nothing of the upload is kept")
+	})
+
+	t.Run("Unknown providers are rejected", func(t *testing.T) {
+		rr, _ := upload("/api/v1/videos?provider=hawkeye", tracabUpload)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "tracab")
+	})
+
+	t.Run("Buffered uploads are converted before they are stored", func(t *testing.T) {
+		mockStorage := new(MockStorageService)
+		var stored []byte
+		mockStorage.On("UploadFile", mock.Anything, mock.MatchedBy(func(path string) bool { return strings.HasSuffix(path, "_tracking.gzip") })).Run(func(args mock.Arguments) {
+			stored, _ = io.ReadAll(args.Get(0).(multipart.File))
+		}).Return(&services.FileUploadInfo{Path: "videos/v/v_tracking.gzip"}, nil).Once()
+		mockStorage.On("UploadFile", mock.Anything, mock.Anything).Return(&services.FileUploadInfo{Path: "videos/v/v_events.gzip"}, nil).Once()
+		videoSvc := new(MockVideoService)
+		videoSvc.On("CreateVideoEntry", mock.AnythingOfType("*models.Video")).Return(&models.Video{}, nil).Once()
+		videoSvc.On("RecordVideoFiles", mock.Anything, mock.Anything).Return(nil).Once()
+		buffered := controllers.NewVideoController(videoSvc, mockStorage, pythonapi.NewClient("", nil), nil)
+
+		body := new(bytes.Buffer)
+		writer := multipart.NewWriter(body)
+		writer.WriteField("provider", "tracab")
+		trackingPart, _ := writer.CreateFormFile("tracking_file", "match.dat")
+		trackingPart.Write([]byte(tracabUpload))
+		eventPart, _ := writer.CreateFormFile("event_file", "events.csv")
+		eventPart.Write([]byte("event_id,event_type\n"))
+		writer.Close()
+		req := httptest.NewRequest("POST", "/api/v1/videos", body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		rr := httptest.NewRecorder()
+		buffered.UploadVideo(rr, req)
+
+		require.Equal(t, http.StatusAccepted, rr.Code, rr.Body.String())
+		assert.True(t, bytes.HasPrefix(stored, []byte("PAR1")))
+		mockStorage.AssertExpectations(t)
+	})
+}
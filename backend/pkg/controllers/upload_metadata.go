@@ -0,0 +1,171 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"nivai/backend/pkg/httperr"
+	"nivai/backend/pkg/models"
+)
+
+// metadataField is the optional upload form field carrying the match
+// metadata as one JSON object, instead of a form field each.
+const metadataField = "metadata"
+
+// metadataFormFields are the form fields the JSON metadata replaces.
+var metadataFormFields = []string{"title", "description", "match_id", "home_team", "away_team", "competition", "season", "match_date",
+	"venue", "referee", "attendance", "weather", "kickoff_at", "kickoff_timezone", priorityField}
+
+// maxMetadataText limits the length of the venue, referee and weather.
+const maxMetadataText = 200
+
+// decodeMetadata decodes match metadata from a JSON object. Unknown fields
+// and anything after the object are refused, so misspelled fields are not
+// silently dropped.
+func decodeMetadata(r io.Reader) (*models.UploadMetadata, *httperr.Error) {
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	var metadata models.UploadMetadata
+	if err := decoder.Decode(&metadata); err != nil {
+		return nil, invalidMetadata(err)
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, httperr.BadRequest("Invalid metadata: unexpected data after the JSON object")
+	}
+	if metadata.MatchDate != "" {
+		if _, err := time.Parse("2006-01-02", metadata.MatchDate); err != nil {
+			return nil, httperr.BadRequest("Invalid match_date, expected YYYY-MM-DD")
+		}
+	}
+	if invalid := validateMetadata(&metadata); invalid != nil {
+		return nil, invalid
+	}
+	return &metadata, nil
+}
+
+// validateMetadata checks the priority and the match context of metadata.
+func validateMetadata(metadata *models.UploadMetadata) *httperr.Error {
+	if invalid := parsePriority(metadata.Priority); invalid != nil {
+		return invalid
+	}
+	if metadata.Attendance < 0 {
+		return httperr.BadRequest("Invalid attendance, expected a number of at least 0")
+	}
+	for _, text := range []struct{ field, value string }{
+		{"venue", metadata.Venue}, {"referee", metadata.Referee}, {"weather", metadata.Weather},
+	} {
+		if utf8.RuneCountInString(text.value) > maxMetadataText {
+			return httperr.BadRequest(fmt.Sprintf("%s is longer than %d characters", text.field, maxMetadataText))
+		}
+	}
+	if metadata.KickoffTimezone != "" {
+		if _, err := time.LoadLocation(metadata.KickoffTimezone); err != nil || metadata.KickoffTimezone == "Local" {
+			return httperr.BadRequest("Invalid kickoff_timezone, expected an IANA time zone (e.g. Europe/Amsterdam)")
+		}
+	}
+	return nil
+}
+
+// invalidMetadata maps a JSON decoding error to an API error naming the
+// offending field where possible.
+func invalidMetadata(err error) *httperr.Error {
+	var typeErr *json.UnmarshalTypeError
+	var timeErr *time.ParseError
+	switch {
+	case errors.Is(err, io.EOF):
+		return httperr.BadRequest("Invalid metadata: expected a JSON object")
+	case errors.As(err, &timeErr):
+		return httperr.BadRequest("Invalid kickoff_at, expected RFC 3339 (e.g. 2024-05-01T18:45:00Z)")
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		return httperr.BadRequest(fmt.Sprintf("Invalid metadata: %s must be a %s", typeErr.Field, typeErr.Type))
+	}
+	return httperr.BadRequest("Invalid metadata: " + strings.TrimPrefix(err.Error(), "json: "))
+}
+
+// formMetadata reads the match metadata of an upload form: the JSON
+// metadata field when given, which cannot be combined with the separate
+// fields it replaces, or else those fields.
+func formMetadata(fields url.Values) (*models.UploadMetadata, *httperr.Error) {
+	if fields.Has(metadataField) {
+		for _, name := range metadataFormFields {
+			if fields.Has(name) {
+				return nil, httperr.BadRequest(fmt.Sprintf("%s cannot be combined with the %s field", name, metadataField))
+			}
+		}
+		return decodeMetadata(strings.NewReader(fields.Get(metadataField)))
+	}
+
+	kickoffAt, invalid := parseKickoff(fields.Get("kickoff_at"))
+	if invalid != nil {
+		return nil, invalid
+	}
+	attendance := 0
+	if value := fields.Get("attendance"); value != "" {
+		var err error
+		if attendance, err = strconv.Atoi(value); err != nil {
+			return nil, httperr.BadRequest("Invalid attendance, expected a whole number")
+		}
+	}
+	metadata := &models.UploadMetadata{
+		Title:           fields.Get("title"),
+		Description:     fields.Get("description"),
+		MatchID:         fields.Get("match_id"),
+		HomeTeam:        fields.Get("home_team"),
+		AwayTeam:        fields.Get("away_team"),
+		Competition:     fields.Get("competition"),
+		Season:          fields.Get("season"),
+		MatchDate:       fields.Get("match_date"),
+		Venue:           fields.Get("venue"),
+		Referee:         fields.Get("referee"),
+		Attendance:      attendance,
+		Weather:         fields.Get("weather"),
+		KickoffAt:       kickoffAt,
+		KickoffTimezone: fields.Get("kickoff_timezone"),
+		Priority:        fields.Get(priorityField),
+	}
+	if invalid := validateMetadata(metadata); invalid != nil {
+		return nil, invalid
+	}
+	return metadata, nil
+}
+
+// applyMetadata sets the match metadata on a video. The teams, competition,
+// season, date and the rest of the match context are only kept for
+// metadata naming the match; the kickoff time always is.
+func applyMetadata(video *models.Video, metadata *models.UploadMetadata) {
+	video.Title = metadata.Title
+	video.Description = metadata.Description
+	if metadata.KickoffAt != nil {
+		kickoffAt := models.LocalKickoff(*metadata.KickoffAt, metadata.KickoffTimezone)
+		video.KickoffAt = &kickoffAt
+		video.KickoffTimezone = metadata.KickoffTimezone
+	}
+	if metadata.MatchID == "" {
+		return
+	}
+	video.MatchID = metadata.MatchID
+	video.HomeTeam = metadata.HomeTeam
+	video.AwayTeam = metadata.AwayTeam
+	video.Competition = metadata.Competition
+	video.Season = metadata.Season
+	video.Venue = metadata.Venue
+	video.Referee = metadata.Referee
+	video.Attendance = metadata.Attendance
+	video.Weather = metadata.Weather
+	if metadata.MatchDate != "" {
+		matchDate, err := time.Parse("2006-01-02", metadata.MatchDate)
+		if err != nil {
+			log.Printf("Warning: Could not parse match_date '%s': %v", metadata.MatchDate, err)
+			return
+		}
+		video.MatchDate = matchDate
+	}
+}
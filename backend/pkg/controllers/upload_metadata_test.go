@@ -0,0 +1,153 @@
+package controllers_test
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"nivai/backend/pkg/controllers"
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/pythonapi"
+	"nivai/backend/pkg/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// bufferedStorage hides the streaming uploads of the storage it wraps, so
+// uploads are buffered before they are stored.
+type bufferedStorage struct {
+	services.StorageService
+}
+
+func TestUploadMetadata(t *testing.T) {
+	// upload posts a match with the given metadata parts, returning the
+	// response and the saved match
+	upload := func(t *testing.T, storage services.StorageService, parts func(*multipart.Writer)) (*httptest.ResponseRecorder, *models.Video) {
+		videoRepo := models.NewMemoryVideoRepository()
+		vc := controllers.NewVideoController(services.NewVideoService(videoRepo, storage), storage, pythonapi.NewClient("", nil), nil)
+
+		body := new(bytes.Buffer)
+		writer := multipart.NewWriter(body)
+		parts(writer)
+		trackingPart, _ := writer.CreateFormFile("tracking_file", "tracking.csv")
+		trackingPart.Write([]byte("frame,x,y\n"))
+		eventPart, _ := writer.CreateFormFile("event_file", "events.csv")
+		eventPart.Write([]byte("event_id,event_type\n"))
+		writer.Close()
+
+		req := httptest.NewRequest("POST", "/api/v1/videos", body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		rr := httptest.NewRecorder()
+		vc.UploadVideo(rr, req)
+
+		videos, err := videoRepo.FindAll(10, 0)
+		require.NoError(t, err)
+		if len(videos) == 0 {
+			return rr, nil
+		}
+		return rr, videos[0]
+	}
+	metadata := `{"title":"Ajax - PSV","match_id":"m1","home_team":"Ajax","away_team":"PSV","match_date":"2024-05-12","priority":"low"}`
+
+	t.Run("A JSON metadata field describes the match", func(t *testing.T) {
+		rr, video := upload(t, services.NewMemoryStorageService(), func(w *multipart.Writer) {
+			w.WriteField("metadata", metadata)
+		})
+
+		require.Equal(t, http.StatusAccepted, rr.Code, rr.Body.String())
+		require.NotNil(t, video)
+		assert.Equal(t, "Ajax - PSV", video.Title)
+		assert.Equal(t, "PSV", video.AwayTeam)
+		assert.Equal(t, time.Date(2024, 5, 12, 0, 0, 0, 0, time.UTC), video.MatchDate)
+	})
+
+	t.Run("Buffered uploads take the metadata as a JSON file part", func(t *testing.T) {
+		rr, video := upload(t, bufferedStorage{services.NewMemoryStorageService()}, func(w *multipart.Writer) {
+			part, _ := w.CreatePart(textproto.MIMEHeader{
+				"Content-Disposition": {`form-data; name="metadata"; filename="metadata.json"`},
+				"Content-Type":        {"application/json"},
+			})
+			part.Write([]byte(metadata))
+		})
+
+		require.Equal(t, http.StatusAccepted, rr.Code, rr.Body.String())
+		require.NotNil(t, video)
+		assert.Equal(t, "m1", video.MatchID)
+	})
+
+	t.Run("The match context is kept with the kickoff in the venue's time zone", func(t *testing.T) {
+		rr, video := upload(t, services.NewMemoryStorageService(), func(w *multipart.Writer) {
+			for field, value := range map[string]string{
+				"title": "Ajax - PSV", "match_id": "m1", "venue": "Johan Cruijff ArenA", "referee": "D. Makkelie",
+				"attendance": "54000", "weather": "Rain, 9°C", "kickoff_at": "2024-05-12T12:30:00Z", "kickoff_timezone": "Europe/Amsterdam",
+			} {
+				w.WriteField(field, value)
+			}
+		})
+
+		require.Equal(t, http.StatusAccepted, rr.Code, rr.Body.String())
+		require.NotNil(t, video)
+		assert.Equal(t, "Johan Cruijff ArenA", video.Venue)
+		assert.Equal(t, "D. Makkelie", video.Referee)
+		assert.Equal(t, 54000, video.Attendance)
+		assert.Equal(t, "Rain, 9°C", video.Weather)
+		require.NotNil(t, video.KickoffAt)
+		assert.Equal(t, "2024-05-12T14:30:00+02:00", video.KickoffAt.Format(time.RFC3339))
+		assert.Equal(t, "Europe/Amsterdam", video.KickoffTimezone)
+	})
+
+	t.Run("Invalid metadata is rejected before the match is saved", func(t *testing.T) {
+		for name, tc := range map[string]struct {
+			parts   func(*multipart.Writer)
+			message string
+		}{
+			"unknown field": {func(w *multipart.Writer) { w.WriteField("metadata", `{"title":"a","lineups":[]}`) }, `unknown field \"lineups\"`},
+			"wrong type":    {func(w *multipart.Writer) { w.WriteField("metadata", `{"title":1}`) }, "title must be a string"},
+			"trailing data": {func(w *multipart.Writer) { w.WriteField("metadata", `{"title":"a"} {}`) }, "unexpected data"},
+			"kickoff":       {func(w *multipart.Writer) { w.WriteField("metadata", `{"kickoff_at":"tonight"}`) }, "Invalid kickoff_at"},
+			"time zone":     {func(w *multipart.Writer) { w.WriteField("metadata", `{"kickoff_timezone":"CEST"}`) }, "Invalid kickoff_timezone"},
+			"attendance":    {func(w *multipart.Writer) { w.WriteField("attendance", "-1") }, "Invalid attendance"},
+			"long venue":    {func(w *multipart.Writer) { w.WriteField("venue", strings.Repeat("a", 201)) }, "venue is longer than 200 characters"},
+			"combined fields": {func(w *multipart.Writer) {
+				w.WriteField("title", "a")
+				w.WriteField("metadata", `{"match_id":"m1"}`)
+			}, "title cannot be combined with the metadata field"},
+		} {
+			rr, video := upload(t, services.NewMemoryStorageService(), tc.parts)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code, name)
+			assert.Contains(t, rr.Body.String(), tc.message, name)
+			assert.Nil(t, video, name)
+		}
+	})
+}
+
+func TestCreateDraftContentTypes(t *testing.T) {
+	create := func(t *testing.T, contentType, body string) *httptest.ResponseRecorder {
+		router, _, _ := newDraftRouter(t)
+		req := httptest.NewRequest("POST", "/matches", strings.NewReader(body))
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := create(t, "application/x-www-form-urlencoded", "title=Ajax+-+PSV&match_id=m1&home_team=Ajax")
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	assert.Contains(t, rr.Body.String(), `"home_team":"Ajax"`)
+	assert.Equal(t, http.StatusCreated, create(t, "application/json; charset=utf-8", `{"title":"a"}`).Code)
+
+	rr = create(t, "application/json", `{"title":"a","lineups":[]}`)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), `unknown field \"lineups\"`)
+	assert.Equal(t, http.StatusBadRequest, create(t, "", `{"title":"a","priority":"high"}`).Code)
+	assert.Equal(t, http.StatusUnsupportedMediaType, create(t, "text/csv", "title\na\n").Code)
+}
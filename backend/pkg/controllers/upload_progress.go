@@ -0,0 +1,55 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"nivai/backend/pkg/httperr"
+	"nivai/backend/pkg/requestctx"
+	"nivai/backend/pkg/services"
+
+	"github.com/gorilla/mux"
+)
+
+// WithUploadProgress reports the progress of copying multipart uploads to
+// storage. Clients opt in per upload by sending an X-Upload-ID header with a
+// UUID of their choosing, then poll GET /api/v1/uploads/{id}/progress or
+// follow upload.progress messages on the WebSocket.
+func WithUploadProgress(tracker *services.UploadProgressTracker) VideoControllerOption {
+	return func(vc *VideoController) {
+		vc.progress = tracker
+	}
+}
+
+// GetUploadProgress handles GET /api/v1/uploads/{id}/progress.
+// It returns how much of an upload has been copied to storage.
+func (vc *VideoController) GetUploadProgress(w http.ResponseWriter, r *http.Request) {
+	info := requestctx.From(r)
+	if vc.progress == nil {
+		httperr.WriteError(w, r, httperr.NotImplemented("Upload progress tracking is disabled"))
+		return
+	}
+
+	progress, err := vc.progress.Get(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		if errors.Is(err, services.ErrUploadProgressNotFound) {
+			httperr.WriteError(w, r, httperr.NotFound("Upload not found"))
+		} else {
+			info.Logger.Printf("Error retrieving upload progress: %v", err)
+			httperr.WriteError(w, r, httperr.Internal("Failed to retrieve upload progress"))
+		}
+		return
+	}
+	// Other users' uploads are reported missing rather than forbidden
+	if progress.UserID != info.Principal.UserID || progress.Organization != info.Org {
+		httperr.WriteError(w, r, httperr.NotFound("Upload not found"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := json.NewEncoder(w).Encode(progress); err != nil {
+		info.Logger.Printf("Error encoding GetUploadProgress response: %v", err)
+	}
+}
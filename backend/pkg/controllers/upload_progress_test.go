@@ -0,0 +1,113 @@
+package controllers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"nivai/backend/pkg/controllers"
+	"nivai/backend/pkg/pythonapi"
+	"nivai/backend/pkg/requestctx"
+	"nivai/backend/pkg/services"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newUploadProgressRouter(vc *controllers.VideoController) *mux.Router {
+	router := mux.NewRouter()
+	router.HandleFunc("/api/v1/videos", vc.UploadVideo).Methods("POST")
+	router.HandleFunc("/api/v1/uploads/{id}/progress", vc.GetUploadProgress).Methods("GET")
+	return router
+}
+
+func newProgressUploadRequest(t *testing.T, uploadID string) *http.Request {
+	t.Helper()
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	trackingPart, _ := writer.CreateFormFile("tracking_file", "test_tracking.gzip")
+	trackingPart.Write(bytes.Repeat([]byte("t"), 600))
+	eventPart, _ := writer.CreateFormFile("event_file", "test_events.gzip")
+	eventPart.Write(bytes.Repeat([]byte("e"), 400))
+	require.NoError(t, writer.Close())
+
+	req := httptest.NewRequest("POST", "/api/v1/videos", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("X-Upload-ID", uploadID)
+	return req
+}
+
+func TestUploadProgress(t *testing.T) {
+	const uploadID = "5f0c6a56-8d5c-4b5f-9a51-6f1d2b2f3c4d"
+
+	t.Run("The copy of an upload is tracked under its upload ID", func(t *testing.T) {
+		storageSvc := new(MockStorageService)
+		videoRepo := new(MockVideoRepository)
+		tracker := services.NewUploadProgressTracker(services.NewMemoryUploadProgressStore(), nil, services.UploadProgressConfig{})
+		vc := controllers.NewVideoController(services.NewVideoService(videoRepo, storageSvc), storageSvc, pythonapi.NewClient("", nil), nil,
+			controllers.WithUploadProgress(tracker))
+		router := newUploadProgressRouter(vc)
+
+		storageSvc.On("UploadFile", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			io.ReadAll(args.Get(0).(multipart.File))
+		}).Return(&services.FileUploadInfo{Path: "videos/stored", Size: 1}, nil)
+		videoRepo.On("Create", mock.AnythingOfType("*models.Video")).Return(nil)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newProgressUploadRequest(t, uploadID))
+		require.Equal(t, http.StatusAccepted, rr.Code, rr.Body.String())
+		var upload map[string]string
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&upload))
+
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/uploads/"+uploadID+"/progress", nil))
+		require.Equal(t, http.StatusOK, rr.Code)
+		var progress services.UploadProgress
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&progress))
+		assert.Equal(t, services.UploadStateCopied, progress.State)
+		assert.Equal(t, upload["video_id"], progress.VideoID)
+		assert.Equal(t, int64(1000), progress.TotalBytes)
+		assert.Equal(t, int64(1000), progress.BytesCopied)
+		assert.Equal(t, 100, progress.Percent)
+
+		// Progress is private to the uploader
+		req := httptest.NewRequest("GET", "/api/v1/uploads/"+uploadID+"/progress", nil)
+		req = req.WithContext(requestctx.NewContext(req.Context(), &requestctx.Info{Principal: requestctx.Principal{UserID: "someone-else"}}))
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/uploads/unknown/progress", nil))
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("Upload IDs must be UUIDs", func(t *testing.T) {
+		storageSvc := new(MockStorageService)
+		tracker := services.NewUploadProgressTracker(services.NewMemoryUploadProgressStore(), nil, services.UploadProgressConfig{})
+		vc := controllers.NewVideoController(services.NewVideoService(new(MockVideoRepository), storageSvc), storageSvc, pythonapi.NewClient("", nil), nil,
+			controllers.WithUploadProgress(tracker))
+
+		rr := httptest.NewRecorder()
+		newUploadProgressRouter(vc).ServeHTTP(rr, newProgressUploadRequest(t, "upload-1"))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		storageSvc.AssertNotCalled(t, "UploadFile", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Progress tracking disabled", func(t *testing.T) {
+		storageSvc := new(MockStorageService)
+		vc := controllers.NewVideoController(services.NewVideoService(new(MockVideoRepository), storageSvc), storageSvc, pythonapi.NewClient("", nil), nil)
+
+		rr := httptest.NewRecorder()
+		newUploadProgressRouter(vc).ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/uploads/"+uploadID+"/progress", nil))
+
+		assert.Equal(t, http.StatusNotImplemented, rr.Code)
+	})
+}
@@ -0,0 +1,89 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"nivai/backend/pkg/httperr"
+	"nivai/backend/pkg/requestctx"
+	"nivai/backend/pkg/services"
+)
+
+// checkQuota writes a 402 or 413 response and returns false when an upload
+// of size bytes does not fit the caller's storage quotas.
+func (vc *VideoController) checkQuota(w http.ResponseWriter, r *http.Request, info *requestctx.Info, size int64) bool {
+	err := vc.quotas.CheckUpload(info.Org, info.Principal.UserID, size)
+	if err == nil {
+		return true
+	}
+	if quotaErr := quotaError(err); quotaErr != nil {
+		httperr.WriteError(w, r, quotaErr)
+		return false
+	}
+	// Accounting problems must not block uploads
+	info.Logger.Printf("Warning: Failed to check storage quota, accepting upload: %v", err)
+	return true
+}
+
+// quotaError is the response to an upload over a storage quota, or nil for
+// other errors.
+func quotaError(err error) *httperr.Error {
+	switch {
+	case errors.Is(err, services.ErrUploadExceedsQuota):
+		return httperr.New(http.StatusRequestEntityTooLarge, httperr.CodePayloadTooLarge, err.Error())
+	case errors.Is(err, services.ErrQuotaExceeded):
+		return httperr.New(http.StatusPaymentRequired, httperr.CodeQuotaExceeded, err.Error())
+	}
+	return nil
+}
+
+// limitToQuota enforces the caller's storage quotas on a request body that
+// is stored as it streams. A known Content-Length is checked up front; in
+// any case the body fails with ErrQuotaExceeded once more bytes are read
+// than the quotas have left, so chunked bodies cannot bypass them. Callers
+// delete what they stored when reading fails. It writes the error response
+// and returns false when the upload is refused up front.
+func (vc *VideoController) limitToQuota(w http.ResponseWriter, r *http.Request, info *requestctx.Info) bool {
+	if vc.quotas == nil {
+		return true
+	}
+	if r.ContentLength > 0 && !vc.checkQuota(w, r, info, r.ContentLength) {
+		return false
+	}
+	allowance, err := vc.quotas.Allowance(info.Org, info.Principal.UserID)
+	if err != nil {
+		// Accounting problems must not block uploads
+		info.Logger.Printf("Warning: Failed to check storage quota, accepting upload: %v", err)
+		return true
+	}
+	if allowance >= 0 {
+		r.Body = &quotaReader{ReadCloser: r.Body, allowance: allowance}
+	}
+	return true
+}
+
+// quotaReader fails with ErrQuotaExceeded once the body goes past allowance
+// bytes. Bytes past it are never returned and the error sticks, so readers
+// that drop an error returned with data still cannot read on.
+type quotaReader struct {
+	io.ReadCloser
+	allowance int64
+	read      int64
+	err       error
+}
+
+func (q *quotaReader) Read(p []byte) (int, error) {
+	if q.err != nil {
+		return 0, q.err
+	}
+	n, err := q.ReadCloser.Read(p)
+	if q.read+int64(n) > q.allowance {
+		n = int(q.allowance - q.read)
+		q.err = fmt.Errorf("%w: the upload needs more than the %d bytes left", services.ErrQuotaExceeded, q.allowance)
+		err = q.err
+	}
+	q.read += int64(n)
+	return n, err
+}
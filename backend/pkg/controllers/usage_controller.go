@@ -0,0 +1,40 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"nivai/backend/pkg/httperr"
+	"nivai/backend/pkg/requestctx"
+	"nivai/backend/pkg/services"
+)
+
+// UsageController reports storage usage and quotas.
+type UsageController struct {
+	quotas *services.QuotaService
+}
+
+// NewUsageController creates a new controller for usage endpoints.
+func NewUsageController(quotas *services.QuotaService) *UsageController {
+	return &UsageController{quotas: quotas}
+}
+
+// GetUsage handles GET /api/v1/usage.
+// It reports the bytes stored by the caller's organization and by the caller,
+// with their quotas and remaining space when quotas are configured.
+func (uc *UsageController) GetUsage(w http.ResponseWriter, r *http.Request) {
+	info := requestctx.From(r)
+
+	usage, err := uc.quotas.Usage(info.Org, info.Principal.UserID)
+	if err != nil {
+		info.Logger.Printf("Error retrieving storage usage: %v", err)
+		httperr.WriteError(w, r, httperr.Internal("Failed to retrieve storage usage"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := json.NewEncoder(w).Encode(usage); err != nil {
+		info.Logger.Printf("Error encoding GetUsage response: %v", err)
+	}
+}
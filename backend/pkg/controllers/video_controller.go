@@ -1,141 +1,364 @@
 package controllers
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"log"
+	"mime"
 	"mime/multipart"
 	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
+	"nivai/backend/pkg/httperr"
+	"nivai/backend/pkg/ingest/adapters"
+	"nivai/backend/pkg/lock"
 	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/pythonapi"
+	"nivai/backend/pkg/requestctx"
 	"nivai/backend/pkg/services"
+	"nivai/backend/pkg/stream"
+	"nivai/backend/pkg/upload"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
+	"golang.org/x/sync/errgroup"
 )
 
 // VideoController manages HTTP requests related to video resources.
 type VideoController struct {
-	videoService     services.VideoService
-	storageService   services.StorageService
-	PythonApiBaseUrl string
-	HttpClient       *http.Client
+	videoService   services.VideoService
+	storageService services.StorageService
+	pythonClient   *pythonapi.Client
+	matchDay       *services.MatchDayService
+	scanner        upload.Scanner
+	quotas         *services.QuotaService
+	pathStrategy   services.PathStrategy
+	pathResolver   services.PathResolver
+	processor      *services.MatchProcessor
+	locks          lock.Locker
+	bundles        *services.MatchBundleService
+	access         *services.AccessService
+	purges         *services.VideoPurgeService
+	drafts         *services.DraftService
+
+	uploadSessions  models.UploadSessionRepository
+	uploadURLExpiry time.Duration
+	progress        *services.UploadProgressTracker
+	uploadLimits    UploadLimits
 }
 
-// NewVideoController creates a new controller for video-related endpoints.
-func NewVideoController(vs services.VideoService, ss services.StorageService, pythonApiBaseUrl string, client *http.Client) *VideoController {
-	if pythonApiBaseUrl == "" {
-		envURL := os.Getenv("PYTHON_API_URL")
-		if envURL != "" {
-			pythonApiBaseUrl = envURL
-		} else {
-			pythonApiBaseUrl = "http://localhost:8081" // Default
-		}
-		log.Println("Using Python API URL for VideoController:", pythonApiBaseUrl)
+// VideoControllerOption configures optional VideoController behaviour.
+type VideoControllerOption func(*VideoController)
+
+// WithVideoAccessControl limits video lists to the matches granted to the
+// caller.
+func WithVideoAccessControl(access *services.AccessService) VideoControllerOption {
+	return func(vc *VideoController) {
+		vc.access = access
 	}
-	if client == nil {
-		client = &http.Client{Timeout: time.Second * 20} // Or a more specific timeout for video processing calls
+}
+
+// WithUploadScanner scans every uploaded file for malware while it streams
+// to storage. Infected uploads are stored but quarantined: the video is
+// rejected and never analysed. If the scanner cannot reach a verdict the
+// upload fails with 503.
+func WithUploadScanner(scanner upload.Scanner) VideoControllerOption {
+	return func(vc *VideoController) {
+		vc.scanner = scanner
 	}
-	return &VideoController{
-		videoService:     vs,
-		storageService:   ss,
-		PythonApiBaseUrl: pythonApiBaseUrl,
-		HttpClient:       client,
+}
+
+// WithQuotas enforces storage quotas on uploads and charges stored uploads
+// to the uploading organization and user. Uploads that would exceed a quota
+// are rejected with 402, or 413 when larger than the whole quota.
+func WithQuotas(quotas *services.QuotaService) VideoControllerOption {
+	return func(vc *VideoController) {
+		vc.quotas = quotas
 	}
 }
 
-// callPythonProcessMatchAPI triggers the Python API for match processing.
-func (vc *VideoController) callPythonProcessMatchAPI(videoID, trackingPath, eventPath string) {
-	// Body will be updated in Stage 2
-	pyApiReqBody := map[string]string{
-		"tracking_data_path": trackingPath, // Ensure these are accessible by Python API
-		"event_data_path":    eventPath,
-		"match_id":           videoID,
-	}
-	jsonReqBody, err := json.Marshal(pyApiReqBody)
-	if err != nil {
-		log.Printf("Error marshalling Python API request body for video %s: %v", videoID, err)
-		return
+// WithPathStrategy sets where uploaded match files are stored. It must be
+// the strategy the video service uses.
+func WithPathStrategy(strategy services.PathStrategy) VideoControllerOption {
+	return func(vc *VideoController) {
+		vc.pathStrategy = strategy
 	}
+}
 
-	pyProcessUrl := fmt.Sprintf("%s/process-match", vc.PythonApiBaseUrl) // Will use vc.
-	log.Printf("Calling Python API to process match %s: %s with body %s", videoID, pyProcessUrl, string(jsonReqBody))
+// WithPathResolver sets how stored files are handed to the Python API:
+// as storage paths, mapped into its mount namespace, or as signed URLs.
+func WithPathResolver(resolver services.PathResolver) VideoControllerOption {
+	return func(vc *VideoController) {
+		vc.pathResolver = resolver
+	}
+}
 
-	resp, postErr := vc.HttpClient.Post(pyProcessUrl, "application/json", bytes.NewBuffer(jsonReqBody)) // Will use vc.
-	if postErr != nil {
-		log.Printf("Error calling Python API /process-match for video %s: %v", videoID, postErr)
-	} else {
-		defer resp.Body.Close()
-		respBodyBytes, _ := io.ReadAll(resp.Body)
-		log.Printf("Python API /process-match response for video %s: Status: %s, Body: %s", videoID, resp.Status, string(respBodyBytes))
-		if resp.StatusCode >= 300 {
-			log.Printf("Python API /process-match returned non-success status for video %s: %s", videoID, resp.Status)
-		} else {
-			log.Printf("Python API /process-match successfully triggered for video %s.", videoID)
-		}
+// WithProcessingLocks sets the locks shared by the replicas that keep
+// reprocess, retry and cancel requests for one match from interleaving.
+// Without it, they only exclude each other within this process.
+func WithProcessingLocks(locker lock.Locker) VideoControllerOption {
+	return func(vc *VideoController) {
+		vc.locks = locker
 	}
 }
 
-// Helper function to save a single uploaded file
+// WithPurgeQueue makes deletes keep a match's files for the purge queue's
+// grace period, during which the delete can be undone. Without it, files
+// are deleted right away.
+func WithPurgeQueue(purges *services.VideoPurgeService) VideoControllerOption {
+	return func(vc *VideoController) {
+		vc.purges = purges
+	}
+}
+
+// NewVideoController creates a new controller for video-related endpoints.
+// matchDay may be nil, in which case uploads ignore kickoff times and are
+// processed at normal priority.
+func NewVideoController(vs services.VideoService, ss services.StorageService, pythonClient *pythonapi.Client, matchDay *services.MatchDayService, opts ...VideoControllerOption) *VideoController {
+	vc := &VideoController{
+		videoService:   vs,
+		storageService: ss,
+		pythonClient:   pythonClient,
+		matchDay:       matchDay,
+		pathStrategy:   services.IDShardStrategy{},
+		pathResolver:   services.StoragePathResolver{},
+		locks:          lock.NewLocal(),
+	}
+	for _, opt := range opts {
+		opt(vc)
+	}
+	vc.processor = services.NewMatchProcessor(pythonClient, vc.pathResolver, matchDay)
+	return vc
+}
+
+// callPythonProcessMatchAPI triggers the Python API for match processing.
+// Failures are logged only; the upload itself has already succeeded.
+func (vc *VideoController) callPythonProcessMatchAPI(ctx context.Context, videoID, trackingPath, eventPath string) {
+	if err := vc.startProcessing(ctx, videoID, trackingPath, eventPath); err != nil {
+		log.Printf("Error starting processing of video %s: %v", videoID, err)
+	}
+}
+
+// startProcessing sends a match to the Python API for processing.
+func (vc *VideoController) startProcessing(ctx context.Context, videoID, trackingPath, eventPath string) error {
+	return vc.processor.Start(ctx, videoID, trackingPath, eventPath)
+}
+
+// Helper function to save a single uploaded file.
+// Returns the storage path, size, hex-encoded SHA-256 checksum of the file and
+// the threat the scanner found in it, if any.
+// The file is read once: checksum, scan and storage consume the same stream
+// when the storage backend supports streaming uploads.
 func (vc *VideoController) saveUploadedFile( // Renamed c to vc for consistency
+	ctx context.Context,
 	file multipart.File,
 	header *multipart.FileHeader,
 	storageDir string,
 	baseFilename string,
 	fileTypeIdentifier string,
-) (string, int64, error) {
-	// Body will remain the same for now, using vc.storageService
+	progress *services.UploadCopy,
+) (string, int64, string, string, error) {
 	if file == nil || header == nil {
-		return "", 0, fmt.Errorf("%s file is missing", fileTypeIdentifier)
+		return "", 0, "", "", fmt.Errorf("%s file is missing", fileTypeIdentifier)
+	}
+
+	destPath := filepath.Join(storageDir, services.MatchFileName(baseFilename, fileTypeIdentifier, header.Filename))
+
+	if streamer, ok := vc.storageService.(services.StreamUploader); ok {
+		return vc.streamFile(ctx, streamer, file, destPath, fileTypeIdentifier, progress)
 	}
 
-	originalFilename := header.Filename
-	fileExt := filepath.Ext(originalFilename)
-	var storageFilename string
-	switch fileTypeIdentifier {
-	case "tracking":
-		storageFilename = baseFilename + "_tracking.gzip"
-	case "events":
-		storageFilename = baseFilename + "_events.gzip"
-	default:
-		storageFilename = baseFilename + fileExt
+	// Backends that need a seekable file: checksum and scan first, then rewind.
+	result, err := upload.Tee(ctx, file, nil, upload.Options{Scanner: vc.scanner})
+	if err != nil {
+		return "", 0, "", "", fmt.Errorf("failed to read %s file: %w", fileTypeIdentifier, err)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", 0, "", "", fmt.Errorf("failed to rewind %s file: %w", fileTypeIdentifier, err)
+	}
+
+	uploadInfo, err := vc.storageService.UploadFile(progressFile{file, progress.Reader(fileTypeIdentifier, file)}, destPath) // Renamed c to vc
+	if err != nil {
+		return "", 0, "", "", fmt.Errorf("failed to upload %s file to %s: %w", fileTypeIdentifier, destPath, err)
+	}
+	return uploadInfo.Path, uploadInfo.Size, result.Checksum, result.Threat, nil
+}
+
+// streamFile stores a file read from src at destPath while checksumming and
+// scanning it in the same pass. Partially stored content is removed on
+// failure.
+func (vc *VideoController) streamFile(ctx context.Context, streamer services.StreamUploader, src io.Reader, destPath, fileTypeIdentifier string, progress *services.UploadCopy) (string, int64, string, string, error) {
+	var uploadInfo *services.FileUploadInfo
+	result, err := upload.Tee(ctx, progress.Reader(fileTypeIdentifier, src), func(r io.Reader) error {
+		var err error
+		uploadInfo, err = streamer.UploadStream(r, destPath)
+		return err
+	}, upload.Options{Scanner: vc.scanner})
+	if err != nil {
+		vc.storageService.DeleteFile(destPath) // Remove partially stored content
+		return "", 0, "", "", fmt.Errorf("failed to upload %s file to %s: %w", fileTypeIdentifier, destPath, err)
+	}
+	return uploadInfo.Path, result.Size, result.Checksum, result.Threat, nil
+}
+
+// uploadedFile is one file of a match upload and, once saved, where it is
+// stored.
+type uploadedFile struct {
+	file   multipart.File
+	header *multipart.FileHeader
+	kind   string // File type identifier in the stored name
+
+	path, checksum, threat string
+	size                   int64
+}
+
+// saveUploadedFiles saves the files concurrently, converting tracking and
+// event files in a provider's format first. The first failure cancels
+// the copies still running, and the files already stored are deleted again,
+// so a failed upload leaves nothing behind.
+func (vc *VideoController) saveUploadedFiles(ctx context.Context, files []*uploadedFile, storageDir, baseFilename, provider string, progress *services.UploadCopy) error {
+	group, ctx := errgroup.WithContext(ctx)
+	for _, f := range files {
+		group.Go(func() error {
+			file, cleanup, err := normalizeFile(ctx, f, provider)
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+			f.path, f.size, f.checksum, f.threat, err = vc.saveUploadedFile(ctx, file, f.header, storageDir, baseFilename, f.kind, progress)
+			return err
+		})
+	}
+	err := group.Wait()
+	if err != nil {
+		for _, f := range files {
+			if f.path != "" {
+				vc.storageService.DeleteFile(f.path)
+			}
+		}
 	}
+	return err
+}
 
-	destPath := filepath.Join(storageDir, storageFilename)
+// progressFile reads a multipart file through a progress-counting reader.
+type progressFile struct {
+	multipart.File
+	r io.Reader
+}
+
+func (f progressFile) Read(p []byte) (int, error) {
+	return f.r.Read(p)
+}
+
+// uploadDir returns the storage directory of a match's uploaded files.
+func (vc *VideoController) uploadDir(videoID, org string) string {
+	return vc.pathStrategy.Dir(services.StoragePathInfo{VideoID: videoID, Organization: org, UploadedAt: time.Now()})
+}
 
-	uploadInfo, err := vc.storageService.UploadFile(file, destPath) // Renamed c to vc
+// priorityField names the processing priority of an upload: "low", "normal"
+// or "high". Without it, uploads in match-day mode are processed at high
+// priority and others at normal priority.
+const priorityField = "priority"
+
+// parsePriority checks the optional processing priority of an upload or
+// reprocessing.
+func parsePriority(value string) *httperr.Error {
+	if value != "" && !pythonapi.ValidPriority(value) {
+		return httperr.BadRequest("Invalid priority, expected low, normal or high")
+	}
+	return nil
+}
+
+// parseKickoff parses the optional kickoff time (RFC 3339) of an upload,
+// which activates match-day mode around kickoff.
+func parseKickoff(value string) (*time.Time, *httperr.Error) {
+	if value == "" {
+		return nil, nil
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
 	if err != nil {
-		return "", 0, fmt.Errorf("failed to upload %s file to %s: %w", fileTypeIdentifier, destPath, err)
+		return nil, httperr.BadRequest("Invalid kickoff_at, expected RFC 3339 (e.g. 2024-05-01T18:45:00Z)")
 	}
-	return uploadInfo.Path, uploadInfo.Size, nil
+	return &parsed, nil
+}
+
+// maxUploadSize limits the request body of a multipart upload.
+const maxUploadSize = int64(500 << 20) // 500 MB
+
+// uploadError maps a saveUploadedFile error to an API error.
+func uploadError(err error) *httperr.Error {
+	if quotaErr := quotaError(err); quotaErr != nil {
+		return quotaErr
+	}
+	var partTooLarge *partTooLargeError
+	var invalidFormat *adapters.ConvertError
+	switch {
+	case errors.Is(err, upload.ErrScanFailed):
+		return httperr.Unavailable(err.Error())
+	case errors.As(err, &partTooLarge):
+		return httperr.New(http.StatusRequestEntityTooLarge, httperr.CodePayloadTooLarge, partTooLarge.Error())
+	case isTooLarge(err):
+		return uploadTooLarge()
+	case errors.Is(err, errInvalidUploadForm), errors.Is(err, adapters.ErrUnknownProvider), errors.As(err, &invalidFormat):
+		return httperr.BadRequest(err.Error())
+	}
+	return httperr.Internal(err.Error())
+}
+
+// uploadTooLarge is the error of an upload over the request size limit.
+func uploadTooLarge() *httperr.Error {
+	return httperr.New(http.StatusRequestEntityTooLarge, httperr.CodePayloadTooLarge, fmt.Sprintf("File(s) too large. Maximum total size is %dMB.", maxUploadSize>>20))
 }
 
 // UploadVideo handles the video, tracking, and event file upload process.
 func (vc *VideoController) UploadVideo(w http.ResponseWriter, r *http.Request) { // Renamed c to vc
 	// Limit the request body size
-	maxUploadSize := int64(500 << 20) // 500 MB
 	r.Body = http.MaxBytesReader(w, r.Body, maxUploadSize)
 
+	// Clients choose the upload ID so they can follow the copy's progress
+	uploadID := r.Header.Get("X-Upload-ID")
+	if uploadID != "" {
+		if _, err := uuid.Parse(uploadID); err != nil {
+			httperr.WriteError(w, r, httperr.BadRequest("Invalid X-Upload-ID, expected a UUID"))
+			return
+		}
+	}
+
+	// Backends storing plain streams get each file as it arrives; others
+	// need the form buffered to seekable temporary files first
+	if streamer, ok := vc.storageService.(services.StreamUploader); ok {
+		body := r.Body
+		if !vc.limitToQuota(w, r, requestctx.From(r)) {
+			return
+		}
+		if reader, err := r.MultipartReader(); err == nil {
+			vc.streamUpload(w, r, streamer, reader, uploadID)
+			return
+		}
+		// The buffered form is checked against its file sizes instead
+		r.Body = body
+	}
+
 	if err := r.ParseMultipartForm(maxUploadSize); err != nil {
 		if strings.Contains(err.Error(), "request body too large") {
-			http.Error(w, fmt.Sprintf("File(s) too large. Maximum total size is %dMB.", maxUploadSize>>20), http.StatusRequestEntityTooLarge)
+			httperr.WriteError(w, r, uploadTooLarge())
 		} else {
-			http.Error(w, "Invalid multipart form: "+err.Error(), http.StatusBadRequest)
+			httperr.WriteError(w, r, httperr.BadRequest("Invalid multipart form: "+err.Error()))
 		}
 		return
 	}
 
 	videoFile, videoHeader, errVideoFile := r.FormFile("video_file")
 	if errVideoFile != nil && !errors.Is(errVideoFile, http.ErrMissingFile) {
-		http.Error(w, "Error processing video_file: "+errVideoFile.Error(), http.StatusInternalServerError)
+		httperr.WriteError(w, r, httperr.Internal("Error processing video_file: "+errVideoFile.Error()))
 		return
 	}
 	if videoFile != nil {
@@ -144,7 +367,7 @@ func (vc *VideoController) UploadVideo(w http.ResponseWriter, r *http.Request) {
 
 	trackingFile, trackingHeader, errTrackingFile := r.FormFile("tracking_file")
 	if errTrackingFile != nil && !errors.Is(errTrackingFile, http.ErrMissingFile) {
-		http.Error(w, "Error processing tracking_file: "+errTrackingFile.Error(), http.StatusInternalServerError)
+		httperr.WriteError(w, r, httperr.Internal("Error processing tracking_file: "+errTrackingFile.Error()))
 		return
 	}
 	if trackingFile != nil {
@@ -153,7 +376,7 @@ func (vc *VideoController) UploadVideo(w http.ResponseWriter, r *http.Request) {
 
 	eventFile, eventHeader, errEventFile := r.FormFile("event_file")
 	if errEventFile != nil && !errors.Is(errEventFile, http.ErrMissingFile) {
-		http.Error(w, "Error processing event_file: "+errEventFile.Error(), http.StatusInternalServerError)
+		httperr.WriteError(w, r, httperr.Internal("Error processing event_file: "+errEventFile.Error()))
 		return
 	}
 	if eventFile != nil {
@@ -166,7 +389,7 @@ func (vc *VideoController) UploadVideo(w http.ResponseWriter, r *http.Request) {
 		// For this example, let's make tracking and event files mandatory if analytics is the goal.
 		// Video file can be optional.
 		// The subtask implies these are primarily for analytics.
-		http.Error(w, "Tracking and event files are required for analytics processing.", http.StatusBadRequest)
+		httperr.WriteError(w, r, httperr.BadRequest("Tracking and event files are required for analytics processing."))
 		return
 	}
 	// If video_file is also mandatory:
@@ -175,89 +398,134 @@ func (vc *VideoController) UploadVideo(w http.ResponseWriter, r *http.Request) {
 	// 	return
 	// }
 
-	videoID := uuid.New().String()
-	storagePath := filepath.Join("videos", videoID[0:2], videoID[2:4], videoID)
-
-	// vc.storageService.CreateDirectory was removed as it's not in the StorageService interface.
-	// The UploadFile method of the storage service will be responsible for handling paths.
-
-	var videoDestPath string
-	var videoSize int64
-	var errSave error
-
-	if videoFile != nil {
-		videoDestPath, videoSize, errSave = vc.saveUploadedFile(videoFile, videoHeader, storagePath, videoID, "video")
-		if errSave != nil {
-			http.Error(w, errSave.Error(), http.StatusInternalServerError)
-			return // Early exit on critical file save error
+	// The metadata may also come as a JSON file part
+	if metadataFile, _, err := r.FormFile(metadataField); err == nil {
+		value, err := io.ReadAll(io.LimitReader(metadataFile, maxFieldSize+1))
+		metadataFile.Close()
+		if err != nil || len(value) > maxFieldSize {
+			httperr.WriteError(w, r, httperr.BadRequest(fmt.Sprintf("Invalid metadata: expected a JSON object of at most %dKB", maxFieldSize>>10)))
+			return
 		}
+		r.Form.Add(metadataField, string(value))
+	}
+	metadata, invalid := formMetadata(r.Form)
+	if invalid != nil {
+		httperr.WriteError(w, r, invalid)
+		return
+	}
+	provider := r.FormValue(providerField)
+	if !adapters.ValidProvider(provider) {
+		httperr.WriteError(w, r, invalidProvider(provider))
+		return
 	}
 
-	trackingDestPath, _, errSave := vc.saveUploadedFile(trackingFile, trackingHeader, storagePath, videoID, "tracking")
-	if errSave != nil {
-		// Attempt to cleanup video file if tracking save fails
-		if videoDestPath != "" {
-			vc.storageService.DeleteFile(videoDestPath)
+	// Reject uploads that do not fit the storage quotas before storing anything.
+	info := requestctx.From(r)
+	var uploadSize int64
+	for _, header := range []*multipart.FileHeader{videoHeader, trackingHeader, eventHeader} {
+		if header != nil {
+			uploadSize += header.Size
 		}
-		http.Error(w, errSave.Error(), http.StatusInternalServerError)
+	}
+	if vc.quotas != nil && !vc.checkQuota(w, r, info, uploadSize) {
 		return
 	}
 
-	eventDestPath, _, errSave := vc.saveUploadedFile(eventFile, eventHeader, storagePath, videoID, "events")
-	if errSave != nil {
-		// Attempt to cleanup video and tracking files if event save fails
-		if videoDestPath != "" {
-			vc.storageService.DeleteFile(videoDestPath)
-		}
-		vc.storageService.DeleteFile(trackingDestPath) // trackingDestPath would be valid here
-		http.Error(w, errSave.Error(), http.StatusInternalServerError)
+	videoID := uuid.New().String()
+	storagePath := vc.uploadDir(videoID, info.Org)
+
+	var progress *services.UploadCopy
+	if uploadID != "" {
+		progress = vc.progress.Start(uploadID, videoID, info.Org, info.Principal.UserID, uploadSize)
+	}
+
+	// The files are copied to storage concurrently; a failed copy cancels the
+	// others and removes whatever was stored.
+	video := &uploadedFile{file: videoFile, header: videoHeader, kind: "video"}
+	tracking := &uploadedFile{file: trackingFile, header: trackingHeader, kind: "tracking"}
+	events := &uploadedFile{file: eventFile, header: eventHeader, kind: "events"}
+	files := []*uploadedFile{tracking, events}
+	if videoFile != nil {
+		files = append(files, video)
+	}
+	if err := vc.saveUploadedFiles(r.Context(), files, storagePath, videoID, provider, progress); err != nil {
+		progress.Failed(err)
+		httperr.WriteError(w, r, uploadError(err))
 		return
 	}
+	progress.Copied()
+
+	vc.createUploadedVideo(w, r, videoID, video, tracking, events, metadata)
+}
 
+// createUploadedVideo builds the metadata of a match from its stored files
+// and the upload's metadata, and completes the upload.
+func (vc *VideoController) createUploadedVideo(w http.ResponseWriter, r *http.Request, videoID string, video, tracking, events *uploadedFile, metadata *models.UploadMetadata) {
 	// Create video metadata object
 	videoMetadata := &models.Video{
 		ID:              videoID,
-		Title:           r.FormValue("title"),
-		Description:     r.FormValue("description"),
-		ProcessingState: "pending_analytics", // New state? Or keep "pending"?
+		ProcessingState: models.StatePendingAnalytics,
 		// UploadedAt: time.Now(), // This field was in the original, but not in the model from read_files
 		CreatedAt:     time.Now(), // Assuming CreatedAt is the upload time
-		FilePath:      videoDestPath,
-		TrackingPath:  trackingDestPath,
-		EventFilePath: eventDestPath,
-		// Size: videoSize, // If Video model had FileSize for main video
-		// ContentType: videoHeader.Header.Get("Content-Type"), // If model had ContentType
-		// Filename: videoHeader.Filename, // If model had Filename
+		FilePath:      video.path,
+		TrackingPath:  tracking.path,
+		EventFilePath: events.path,
+		// Size: video.size, // If Video model had FileSize for main video
+		// ContentType: video.header.Header.Get("Content-Type"), // If model had ContentType
+		// Filename: video.header.Filename, // If model had Filename
 	}
-	if videoHeader != nil {
-		videoMetadata.Format = strings.TrimPrefix(filepath.Ext(videoHeader.Filename), ".")
-		videoMetadata.Size = videoSize // Size of the video file itself
+	if video.header != nil {
+		videoMetadata.Format = strings.TrimPrefix(filepath.Ext(video.header.Filename), ".")
+		videoMetadata.Size = video.size // Size of the video file itself
 	}
 
-	if videoDestPath != "" {
+	if video.path != "" {
 		videoMetadata.StorageProvider = "default" // Placeholder - this needs a proper source
 	}
 
 	// Get match metadata if provided
-	if matchID := r.FormValue("match_id"); matchID != "" {
-		videoMetadata.MatchID = matchID
-		videoMetadata.HomeTeam = r.FormValue("home_team")
-		videoMetadata.AwayTeam = r.FormValue("away_team")
-		videoMetadata.Competition = r.FormValue("competition")
-		videoMetadata.Season = r.FormValue("season")
-
-		matchDateStr := r.FormValue("match_date")
-		if matchDateStr != "" {
-			parsedDate, err := time.Parse("2006-01-02", matchDateStr)
-			if err == nil {
-				videoMetadata.MatchDate = parsedDate
-			} else {
-				log.Printf("Warning: Could not parse match_date '%s': %v", matchDateStr, err)
-				// Optionally, you could set an error response here if match_date is critical and invalid
-			}
+	applyMetadata(videoMetadata, metadata)
+
+	storedFiles := []*models.VideoFile{
+		{Kind: models.FileKindTracking, Path: tracking.path, Size: tracking.size, Checksum: tracking.checksum},
+		{Kind: models.FileKindEvents, Path: events.path, Size: events.size, Checksum: events.checksum},
+	}
+	if video.path != "" {
+		storedFiles = append(storedFiles, &models.VideoFile{Kind: models.FileKindVideo, Path: video.path, Size: video.size, Checksum: video.checksum})
+	}
+	threats := map[string]string{}
+	for kind, threat := range map[string]string{
+		models.FileKindVideo:    video.threat,
+		models.FileKindTracking: tracking.threat,
+		models.FileKindEvents:   events.threat,
+	} {
+		if threat != "" {
+			threats[kind] = threat
 		}
 	}
 
+	r = r.WithContext(services.WithPriority(r.Context(), metadata.Priority))
+	vc.completeUpload(w, r, videoMetadata, storedFiles, threats, metadata.KickoffAt)
+}
+
+// completeUpload saves the metadata of a match whose files are stored,
+// records the files and charges them to the uploader, then quarantines the
+// match if threats were found in its files or starts its processing.
+// The files are deleted if the metadata cannot be saved.
+func (vc *VideoController) completeUpload(w http.ResponseWriter, r *http.Request, videoMetadata *models.Video, storedFiles []*models.VideoFile, threats map[string]string, kickoffAt *time.Time) {
+	if !vc.saveUpload(w, r, videoMetadata, storedFiles, "upload completed") {
+		return
+	}
+	vc.finishUpload(w, r, videoMetadata, threats, kickoffAt)
+}
+
+// saveUpload saves the metadata of a match whose files are stored, records
+// the files and charges them to the uploader. The files are deleted and the
+// error response written if the metadata cannot be saved.
+func (vc *VideoController) saveUpload(w http.ResponseWriter, r *http.Request, videoMetadata *models.Video, storedFiles []*models.VideoFile, reason string) bool {
+	info := requestctx.From(r)
+	videoID := videoMetadata.ID
+
 	// Save the video metadata (which now includes paths to tracking and event files)
 	// This part needs to be adapted if VideoService.SaveVideoMetadata is the correct method
 	// or if there's a different metadata storage mechanism.
@@ -267,42 +535,61 @@ func (vc *VideoController) UploadVideo(w http.ResponseWriter, r *http.Request) {
 	// Let's assume there's a method like CreateVideo in VideoService that handles this.
 	// If VideoService is tightly coupled to a DB via a repository, that's where it should go.
 
-	savedMatchData, err := vc.videoService.CreateVideoEntry(videoMetadata)
+	savedMatchData, err := vc.videoService.CreateVideoEntry(videoMetadata, services.StateChange{Actor: info.Principal.UserID, Reason: reason})
 	if err != nil {
 		log.Printf("Error saving video/match metadata for ID %s: %v", videoID, err)
 		// Attempt to clean up uploaded files if metadata saving fails
-		if videoDestPath != "" {
-			vc.storageService.DeleteFile(videoDestPath)
-		}
-		if trackingDestPath != "" {
-			vc.storageService.DeleteFile(trackingDestPath)
+		for _, file := range storedFiles {
+			vc.storageService.DeleteFile(file.Path)
 		}
-		if eventDestPath != "" {
-			vc.storageService.DeleteFile(eventDestPath)
-		}
-		http.Error(w, "Failed to save video/match metadata: "+err.Error(), http.StatusInternalServerError)
-		return
+		httperr.WriteError(w, r, httperr.Internal("Failed to save video/match metadata: "+err.Error()))
+		return false
 	}
 	log.Printf("Video/match metadata saved for ID %s: %+v", videoID, savedMatchData)
+
+	// Record file checksums for later integrity audits; failure here is not fatal.
+	if err := vc.videoService.RecordVideoFiles(videoID, storedFiles); err != nil {
+		log.Printf("Warning: Failed to record file checksums for video %s: %v", videoID, err)
+	}
+	if vc.quotas != nil {
+		if err := vc.quotas.Charge(videoID, info.Org, info.Principal.UserID, storedFiles); err != nil {
+			log.Printf("Warning: Failed to charge storage of video %s: %v", videoID, err)
+		}
+	}
 	// videoID from uuid.New().String() should match savedMatchData.ID if CreateVideoEntry uses the passed ID.
+	return true
+}
 
-	// Trigger Python API /process-match
-	// CRITICAL ASSUMPTION: trackingDestPath and eventDestPath must be accessible by the Python API
-	// This usually means they are absolute paths on a shared volume/filesystem.
-	// If storageService returns relative paths, they need to be converted to absolute paths.
-	// For example, if storageService saves under /app/storage_root/videos/...
-	// and Python API can access /python_accessible_storage_root/videos/...
-	// then paths need transformation.
-	// For now, assume paths are directly usable or Python API knows where to find them based on config.
+// finishUpload quarantines a saved match if threats were found in its files
+// or starts its processing, and writes the response.
+func (vc *VideoController) finishUpload(w http.ResponseWriter, r *http.Request, videoMetadata *models.Video, threats map[string]string, kickoffAt *time.Time) {
+	videoID := videoMetadata.ID
+	trackingDestPath, eventDestPath := videoMetadata.TrackingPath, videoMetadata.EventFilePath
 
-	// Convert paths to absolute if they are not already, assuming storageService provides paths relative to some root
-	// that might not be the same for the Python API.
-	// This is a placeholder for actual path resolution logic needed for your deployment.
-	absTrackingPath := trackingDestPath // Placeholder: vc.storageService.GetAbsolutePath(trackingDestPath)
-	absEventPath := eventDestPath       // Placeholder: vc.storageService.GetAbsolutePath(eventDestPath)
+	// Quarantine infected uploads: the files stay in storage for inspection,
+	// but the video is rejected and never reaches analytics.
+	if len(threats) > 0 {
+		log.Printf("Upload %s quarantined, malware detected: %v", videoID, threats)
+		if err := vc.videoService.RejectVideo(videoID, threats); err != nil {
+			log.Printf("Error rejecting infected video %s: %v", videoID, err)
+		}
+		httperr.WriteError(w, r, httperr.New(http.StatusUnprocessableEntity, httperr.CodeMalwareDetected,
+			"Upload rejected, malware detected.").WithDetails(map[string]interface{}{
+			"video_id": videoID,
+			"threats":  threats,
+		}))
+		return
+	}
+
+	if kickoffAt != nil && vc.matchDay != nil {
+		if _, err := vc.matchDay.Update(videoID, models.MatchDayModeAuto, kickoffAt); err != nil {
+			log.Printf("Warning: Failed to save kickoff time for video %s: %v", videoID, err)
+		}
+	}
 
-	// Directly call the method; marshaling and error handling are inside callPythonProcessMatchAPI
-	vc.callPythonProcessMatchAPI(videoID, absTrackingPath, absEventPath)
+	// Trigger Python API /process-match; the path resolver makes the storage
+	// paths readable from the Python API's container
+	vc.callPythonProcessMatchAPI(r.Context(), videoID, trackingDestPath, eventDestPath)
 
 	// Return minimal info about the uploaded files, primarily the ID.
 	// The client can then use other endpoints to get full metadata if needed.
@@ -312,9 +599,9 @@ func (vc *VideoController) UploadVideo(w http.ResponseWriter, r *http.Request) {
 	if err := json.NewEncoder(w).Encode(map[string]string{
 		"message":         "Upload received, processing initiated.",
 		"video_id":        videoID,
-		"video_file_path": videoDestPath,    // if video was uploaded
-		"tracking_path":   trackingDestPath, // always present based on current logic
-		"event_file_path": eventDestPath,    // always present
+		"video_file_path": videoMetadata.FilePath, // if video was uploaded
+		"tracking_path":   trackingDestPath,       // always present based on current logic
+		"event_file_path": eventDestPath,          // always present
 	}); err != nil {
 		log.Printf("Error encoding UploadVideo final response for video %s: %v", videoID, err)
 	}
@@ -327,7 +614,9 @@ func (vc *VideoController) UploadVideo(w http.ResponseWriter, r *http.Request) {
 
 /**
  * GetVideo retrieves a single video by its ID.
- * Handles the GET /api/v1/videos/{id} endpoint.
+ * Handles the GET /api/v1/videos/{id} endpoint. Responses carry an ETag
+ * and Last-Modified from the video's last update, and answer 304 when the
+ * client's copy is current.
  *
  * @param w The HTTP response writer
  * @param r The HTTP request
@@ -337,7 +626,7 @@ func (vc *VideoController) GetVideo(w http.ResponseWriter, r *http.Request) { //
 	vars := mux.Vars(r)
 	id, ok := vars["id"]
 	if !ok {
-		http.Error(w, "Missing video ID", http.StatusBadRequest)
+		httperr.WriteError(w, r, httperr.BadRequest("Missing video ID"))
 		return
 	}
 
@@ -345,23 +634,143 @@ func (vc *VideoController) GetVideo(w http.ResponseWriter, r *http.Request) { //
 	video, err := vc.videoService.GetVideoByID(id) // Renamed c to vc
 	if err != nil {
 		if errors.Is(err, services.ErrVideoNotFound) { // Assuming services exports this error
-			http.Error(w, "Video not found", http.StatusNotFound)
+			httperr.WriteError(w, r, httperr.NotFound("Video not found"))
 		} else {
-			http.Error(w, "Failed to retrieve video", http.StatusInternalServerError)
+			httperr.WriteError(w, r, httperr.Internal("Failed to retrieve video"))
 		}
 		return
 	}
 
+	if notModified(w, r, videosETag(video), video.UpdatedAt) {
+		return
+	}
+
 	// Return video as JSON response
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(video); err != nil {
-		http.Error(w, "Error encoding response", http.StatusInternalServerError)
+		httperr.WriteError(w, r, httperr.Internal("Error encoding response"))
+	}
+}
+
+// GetVideoHistory handles GET /api/v1/videos/{id}/history, listing the
+// processing state changes of a video, oldest first, with who made each
+// change, when and why.
+func (vc *VideoController) GetVideoHistory(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	history, err := vc.videoService.GetStateHistory(id)
+	if err != nil {
+		if errors.Is(err, services.ErrVideoNotFound) {
+			httperr.WriteError(w, r, httperr.NotFound("Video not found"))
+		} else {
+			requestctx.From(r).Logger.Printf("Error retrieving state history of video %s: %v", id, err)
+			httperr.WriteError(w, r, httperr.Internal("Failed to retrieve video history"))
+		}
+		return
+	}
+	if history == nil {
+		history = []*models.StateTransition{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(history); err != nil {
+		log.Printf("Error encoding GetVideoHistory response: %v", err)
+	}
+}
+
+// matchFilePath returns the stored path of a match's file of the given kind.
+func matchFilePath(video *models.Video, kind string) string {
+	switch kind {
+	case models.FileKindTracking:
+		return video.TrackingPath
+	case models.FileKindEvents:
+		return video.EventFilePath
+	case models.FileKindVideo:
+		return video.FilePath
+	}
+	return ""
+}
+
+// DownloadMatchFile handles GET /api/v1/matches/{id}/files/{type}, streaming
+// the originally uploaded tracking, events or video file. Range requests are
+// honoured when the storage backend returns a seekable file. Every download
+// is written to the audit log with the caller's identity.
+func (vc *VideoController) DownloadMatchFile(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, kind := vars["id"], vars["type"]
+	info := requestctx.From(r)
+
+	if kind != models.FileKindTracking && kind != models.FileKindEvents && kind != models.FileKindVideo {
+		httperr.WriteError(w, r, httperr.BadRequest("Invalid file type, expected tracking, events or video"))
+		return
+	}
+
+	video, err := vc.videoService.GetVideoByID(id)
+	if err != nil {
+		if errors.Is(err, services.ErrVideoNotFound) {
+			httperr.WriteError(w, r, httperr.NotFound("Match not found"))
+		} else {
+			httperr.WriteError(w, r, httperr.Internal("Failed to retrieve match"))
+		}
+		return
+	}
+
+	if video.ProcessingState == models.StateRejected {
+		info.Logger.Printf("Audit: refused download of quarantined %s file of match %s by user %q", kind, id, info.Principal.UserID)
+		httperr.WriteError(w, r, httperr.New(http.StatusForbidden, httperr.CodeForbidden, "Match files are quarantined"))
+		return
+	}
+	if (video.ProcessingState == models.StateArchived || video.ProcessingState == models.StateRestoring) && kind != models.FileKindEvents {
+		httperr.WriteError(w, r, httperr.Conflict("Match file is in cold storage; restore the match first"))
+		return
+	}
+
+	path := matchFilePath(video, kind)
+	if path == "" {
+		httperr.WriteError(w, r, httperr.NotFound("Match has no "+kind+" file"))
+		return
+	}
+
+	file, err := vc.storageService.GetFile(path)
+	if err != nil {
+		info.Logger.Printf("Error opening %s file of match %s: %v", kind, id, err)
+		if strings.Contains(err.Error(), "not found") {
+			httperr.WriteError(w, r, httperr.NotFound("Stored file not found"))
+		} else {
+			httperr.WriteError(w, r, httperr.Internal("Failed to retrieve file"))
+		}
+		return
+	}
+	defer file.Close()
+
+	info.Logger.Printf("Audit: file download user=%q role=%q match=%s type=%s range=%q",
+		info.Principal.UserID, info.Principal.Role, id, kind, r.Header.Get("Range"))
+
+	filename := "match-" + safeFilename(id) + "-" + kind + filepath.Ext(path)
+	contentType := "application/gzip"
+	if kind == models.FileKindVideo {
+		if contentType = mime.TypeByExtension(filepath.Ext(path)); contentType == "" {
+			contentType = "application/octet-stream"
+		}
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	w.Header().Set("Cache-Control", "private")
+
+	if seeker, ok := file.(io.ReadSeeker); ok {
+		http.ServeContent(w, r, filename, video.UpdatedAt, seeker)
+		return
+	}
+	w.Header().Set("Accept-Ranges", "none")
+	if _, err := io.Copy(w, file); err != nil {
+		info.Logger.Printf("Error streaming %s file of match %s: %v", kind, id, err)
 	}
 }
 
 /**
  * ListVideos retrieves a paginated list of videos.
- * Handles the GET /api/v1/videos endpoint with optional filtering.
+ * Handles the GET /api/v1/videos endpoint with optional filtering. Pages
+ * carry an ETag of their videos and answer 304 to a matching If-None-Match;
+ * Last-Modified is left out, since it would miss removed videos.
  *
  * @param w The HTTP response writer
  * @param r The HTTP request
@@ -371,19 +780,75 @@ func (vc *VideoController) ListVideos(w http.ResponseWriter, r *http.Request) {
 	limit, offset := parsePaginationParams(r)
 
 	// Parse additional filter parameters
-	filters := parseVideoFilters(r)
+	filters, err := parseVideoFilters(r)
+	if err != nil {
+		httperr.WriteError(w, r, httperr.BadRequest(err.Error()))
+		return
+	}
+
+	// NDJSON clients get every matching video rather than a single page
+	if stream.Requested(r) {
+		vc.streamVideos(w, r, offset, filters)
+		return
+	}
 
 	// Retrieve videos using service
-	videos, err := vc.videoService.ListVideos(limit, offset, filters) // Renamed c to vc
+	videos, err := listAccessibleVideos(r, vc.videoService, vc.access, limit, offset, filters)
 	if err != nil {
-		http.Error(w, "Failed to retrieve videos", http.StatusInternalServerError)
+		httperr.WriteError(w, r, httperr.Internal("Failed to retrieve videos"))
+		return
+	}
+
+	if notModified(w, r, videosETag(videos...), time.Time{}) {
 		return
 	}
 
 	// Return videos as JSON response
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(videos); err != nil {
-		http.Error(w, "Error encoding response", http.StatusInternalServerError)
+	if err := writeList(w, r, videos, len(videos), limit, offset); err != nil {
+		log.Printf("Error encoding ListVideos response: %v", err)
+	}
+}
+
+// videoStreamBatchSize is how many videos are read per page when streaming.
+const videoStreamBatchSize = 100
+
+/**
+ * streamVideos writes all videos matching the filters as NDJSON, starting at
+ * offset and paging through the repository until it is exhausted.
+ *
+ * @param w The HTTP response writer
+ * @param r The HTTP request
+ * @param offset Number of videos to skip
+ * @param filters Map of filter criteria
+ */
+func (vc *VideoController) streamVideos(w http.ResponseWriter, r *http.Request, offset int, filters map[string]string) {
+	videos, err := listAccessibleVideos(r, vc.videoService, vc.access, videoStreamBatchSize, offset, filters)
+	if err != nil {
+		httperr.WriteError(w, r, httperr.Internal("Failed to retrieve videos"))
+		return
+	}
+
+	out := stream.New(w, r)
+	defer out.Close()
+
+	for {
+		for _, video := range videos {
+			if err := out.Write(video); err != nil {
+				log.Printf("Video list stream aborted: %v", err)
+				return
+			}
+		}
+		if len(videos) < videoStreamBatchSize {
+			return
+		}
+
+		offset += len(videos)
+		videos, err = listAccessibleVideos(r, vc.videoService, vc.access, videoStreamBatchSize, offset, filters)
+		if err != nil {
+			log.Printf("Error listing videos at offset %d: %v", offset, err)
+			out.Fail("Failed to retrieve videos")
+			return
+		}
 	}
 }
 
@@ -399,7 +864,7 @@ func (vc *VideoController) DeleteVideo(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, ok := vars["id"]
 	if !ok {
-		http.Error(w, "Missing video ID", http.StatusBadRequest)
+		httperr.WriteError(w, r, httperr.BadRequest("Missing video ID"))
 		return
 	}
 
@@ -407,13 +872,30 @@ func (vc *VideoController) DeleteVideo(w http.ResponseWriter, r *http.Request) {
 	video, err := vc.videoService.GetVideoByID(id) // Renamed c to vc
 	if err != nil {
 		if errors.Is(err, services.ErrVideoNotFound) { // Assuming services exports this error
-			http.Error(w, "Video not found", http.StatusNotFound)
+			httperr.WriteError(w, r, httperr.NotFound("Video not found"))
 		} else {
-			http.Error(w, "Failed to retrieve video metadata", http.StatusInternalServerError)
+			httperr.WriteError(w, r, httperr.Internal("Failed to retrieve video metadata"))
 		}
 		return
 	}
 
+	// Two-phase delete: the purge queue removes the files after the grace period
+	if vc.purges != nil {
+		purge, err := vc.purges.Delete(video, requestctx.From(r).Principal.UserID)
+		if err != nil {
+			if errors.Is(err, services.ErrVideoNotFound) {
+				httperr.WriteError(w, r, httperr.NotFound("Video not found"))
+			} else {
+				log.Printf("Error deleting video %s: %v", id, err)
+				httperr.WriteError(w, r, httperr.Internal("Failed to delete video metadata"))
+			}
+			return
+		}
+		requestctx.From(r).Logger.Printf("Audit: video %s deleted; files purged after %s", id, purge.PurgeAfter.Format(time.RFC3339))
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
 	// Delete the actual file first (video, tracking, events)
 	if video.FilePath != "" {
 		if err := vc.storageService.DeleteFile(video.FilePath); err != nil && !os.IsNotExist(err) { // Renamed c to vc
@@ -433,7 +915,7 @@ func (vc *VideoController) DeleteVideo(w http.ResponseWriter, r *http.Request) {
 
 	// Delete video metadata
 	if err := vc.videoService.DeleteVideo(id); err != nil { // Renamed c to vc
-		http.Error(w, "Failed to delete video metadata", http.StatusInternalServerError)
+		httperr.WriteError(w, r, httperr.Internal("Failed to delete video metadata"))
 		return
 	}
 
@@ -441,6 +923,64 @@ func (vc *VideoController) DeleteVideo(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+/**
+ * UndeleteVideo undoes the deletion of a video whose files have not been
+ * purged yet.
+ * Handles the POST /api/v1/videos/{id}/undelete endpoint.
+ *
+ * @param w The HTTP response writer
+ * @param r The HTTP request
+ */
+func (vc *VideoController) UndeleteVideo(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if vc.purges == nil {
+		httperr.WriteError(w, r, httperr.NotFound("No pending deletion for this video"))
+		return
+	}
+	video, err := vc.purges.Restore(id)
+	if err != nil {
+		if errors.Is(err, models.ErrVideoPurgeNotFound) {
+			httperr.WriteError(w, r, httperr.NotFound("No pending deletion for this video"))
+		} else {
+			log.Printf("Error undeleting video %s: %v", id, err)
+			httperr.WriteError(w, r, httperr.Internal("Failed to undelete video"))
+		}
+		return
+	}
+	requestctx.From(r).Logger.Printf("Audit: video %s undeleted", id)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(video); err != nil {
+		log.Printf("Error encoding undeleted video: %v", err)
+	}
+}
+
+/**
+ * ListPendingPurges lists deleted videos whose files wait out their grace
+ * period.
+ * Handles the GET /api/v1/admin/video-purges endpoint.
+ *
+ * @param w The HTTP response writer
+ * @param r The HTTP request
+ */
+func (vc *VideoController) ListPendingPurges(w http.ResponseWriter, r *http.Request) {
+	purges := []*models.VideoPurge{}
+	if vc.purges != nil {
+		limit, offset := parsePaginationParams(r)
+		var err error
+		if purges, err = vc.purges.Pending(limit, offset); err != nil {
+			log.Printf("Error listing pending purges: %v", err)
+			httperr.WriteError(w, r, httperr.Internal("Failed to list pending purges"))
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(purges); err != nil {
+		log.Printf("Error encoding pending purges: %v", err)
+	}
+}
+
 /**
  * parsePaginationParams extracts pagination parameters from the request.
  * Provides default values if parameters are not present or invalid.
@@ -449,12 +989,18 @@ func (vc *VideoController) DeleteVideo(w http.ResponseWriter, r *http.Request) {
  * @return Limit and offset values for pagination
  */
 func parsePaginationParams(r *http.Request) (int, int) {
+	return parsePage(r, 10)
+}
+
+// parsePage extracts the limit and offset of a list request, with the
+// given default limit.
+func parsePage(r *http.Request, defaultLimit int) (int, int) {
 	// Get query parameters
 	query := r.URL.Query()
 
 	// Parse limit parameter
 	limitStr := query.Get("limit")
-	limit := 10 // Default limit
+	limit := defaultLimit
 	if limitStr != "" {
 		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 {
 			limit = parsedLimit
@@ -474,12 +1020,14 @@ func parsePaginationParams(r *http.Request) (int, int) {
 }
 
 /**
- * parseVideoFilters extracts filter parameters for video queries.
+ * parseVideoFilters extracts filter and sort parameters for video queries.
+ * All filters are combined; "from" and "to" bound the match date and accept
+ * YYYY-MM-DD or RFC 3339.
  *
  * @param r The HTTP request
- * @return Map of filter parameters
+ * @return Map of filter parameters, or an error for invalid dates
  */
-func parseVideoFilters(r *http.Request) map[string]string {
+func parseVideoFilters(r *http.Request) (map[string]string, error) {
 	query := r.URL.Query()
 	filters := make(map[string]string)
 
@@ -500,9 +1048,68 @@ func parseVideoFilters(r *http.Request) map[string]string {
 		filters["season"] = season
 	}
 
+	if venue := query.Get("venue"); venue != "" {
+		filters["venue"] = venue
+	}
+
+	if referee := query.Get("referee"); referee != "" {
+		filters["referee"] = referee
+	}
+
 	if state := query.Get("processing_state"); state != "" {
 		filters["processing_state"] = state
 	}
 
-	return filters
+	from, err := parseRangeTime(query.Get("from"), false)
+	if err != nil {
+		return nil, err
+	}
+	to, err := parseRangeTime(query.Get("to"), true)
+	if err != nil {
+		return nil, err
+	}
+	if !from.IsZero() && !to.IsZero() && from.After(to) {
+		return nil, errors.New("from must not be after to")
+	}
+	if !from.IsZero() {
+		filters["from"] = from.Format(time.RFC3339Nano)
+	}
+	if !to.IsZero() {
+		filters["to"] = to.Format(time.RFC3339Nano)
+	}
+
+	if err := parseSortParams(r, filters); err != nil {
+		return nil, err
+	}
+	return filters, nil
+}
+
+// videoSortFields are the accepted values of the sort parameter
+var videoSortFields = []string{models.VideoSortMatchDate, models.VideoSortCreatedAt, models.VideoSortTitle}
+
+/**
+ * parseSortParams validates the sort and order parameters of a list request
+ * (?sort=match_date|created_at|title&order=asc|desc) and adds them to filters.
+ *
+ * @param r The HTTP request
+ * @param filters Map of filter parameters to add the sort order to
+ * @return Error naming the accepted values when a parameter is invalid
+ */
+func parseSortParams(r *http.Request, filters map[string]string) error {
+	query := r.URL.Query()
+
+	if sort := query.Get("sort"); sort != "" {
+		if !slices.Contains(videoSortFields, sort) {
+			return fmt.Errorf("invalid sort %q: use one of %s", sort, strings.Join(videoSortFields, ", "))
+		}
+		filters["sort"] = sort
+	}
+
+	if order := strings.ToLower(query.Get("order")); order != "" {
+		if order != models.SortAscending && order != models.SortDescending {
+			return fmt.Errorf("invalid order %q: use asc or desc", order)
+		}
+		filters["order"] = order
+	}
+	return nil
 }
@@ -2,6 +2,7 @@ package controllers_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -11,12 +12,17 @@ import (
 	"net/http/httptest"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"nivai/backend/pkg/controllers"
+	"nivai/backend/pkg/httperr"
+	"nivai/backend/pkg/middleware"
 	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/pythonapi"
 	"nivai/backend/pkg/services"
+	"nivai/backend/pkg/upload"
 
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
@@ -55,11 +61,21 @@ func (m *MockVideoRepository) Update(video *models.Video) error {
 	return args.Error(0)
 }
 
+func (m *MockVideoRepository) UpdateProcessingState(id string, from, to models.ProcessingState, updatedAt time.Time) error {
+	args := m.Called(id, from, to, updatedAt)
+	return args.Error(0)
+}
+
 func (m *MockVideoRepository) Delete(id string) error {
 	args := m.Called(id)
 	return args.Error(0)
 }
 
+func (m *MockVideoRepository) Undelete(id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
 func (m *MockVideoRepository) FindByMatchID(matchID string) ([]*models.Video, error) {
 	args := m.Called(matchID)
 	if args.Get(0) == nil {
@@ -92,6 +108,18 @@ func (m *MockVideoRepository) FindByProcessingState(state string, limit, offset
 	return args.Get(0).([]*models.Video), args.Error(1)
 }
 
+func (m *MockVideoRepository) FindByQuery(query models.VideoQuery) ([]*models.Video, error) {
+	args := m.Called(query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Video), args.Error(1)
+}
+
+func (m *MockVideoRepository) WithContext(ctx context.Context) models.VideoRepository {
+	return m
+}
+
 // --- Mock StorageService ---
 type MockStorageService struct {
 	mock.Mock
@@ -144,6 +172,29 @@ func (m *MockStorageService) GetFileMetadata(path string) (map[string]string, er
 	return args.Get(0).(map[string]string), args.Error(1)
 }
 
+func (m *MockStorageService) ListFiles(prefix string) ([]services.FileInfo, error) {
+	args := m.Called(prefix)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]services.FileInfo), args.Error(1)
+}
+
+func (m *MockStorageService) Exists(path string) (bool, error) {
+	args := m.Called(path)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockStorageService) CopyFile(src, dst string) error {
+	args := m.Called(src, dst)
+	return args.Error(0)
+}
+
+func (m *MockStorageService) MoveFile(src, dst string) error {
+	args := m.Called(src, dst)
+	return args.Error(0)
+}
+
 func (m *MockStorageService) GetStreamURL(path string) (string, error) {
 	args := m.Called(path)
 	return args.String(0), args.Error(1)
@@ -157,6 +208,37 @@ func (m *MockStorageService) UploadFile(file multipart.File, path string) (*serv
 	return args.Get(0).(*services.FileUploadInfo), args.Error(1)
 }
 
+// --- MockStorageUsageRepository ---
+type MockStorageUsageRepository struct {
+	mock.Mock
+}
+
+func (m *MockStorageUsageRepository) FindUsage(scope, owner string) (*models.StorageUsage, error) {
+	args := m.Called(scope, owner)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.StorageUsage), args.Error(1)
+}
+func (m *MockStorageUsageRepository) Charge(charge *models.StorageCharge) error {
+	args := m.Called(charge)
+	return args.Error(0)
+}
+func (m *MockStorageUsageRepository) Release(videoID string) (*models.StorageCharge, error) {
+	args := m.Called(videoID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.StorageCharge), args.Error(1)
+}
+func (m *MockStorageUsageRepository) ChargedVideoIDs() ([]string, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
 // MockWriteCloser
 type MockWriteCloser struct {
 	io.Writer
@@ -230,7 +312,7 @@ func TestUploadVideo(t *testing.T) {
 		}))
 		defer pythonApiMockServer.Close()
 
-		videoController := controllers.NewVideoController(videoService, mockStorageSvc, pythonApiMockServer.URL, pythonApiMockServer.Client())
+		videoController := controllers.NewVideoController(videoService, mockStorageSvc, pythonapi.NewClient(pythonApiMockServer.URL, pythonApiMockServer.Client()), nil)
 
 		req := httptest.NewRequest("POST", "/api/v1/videos", body)
 		req.Header.Set("Content-Type", writer.FormDataContentType())
@@ -268,7 +350,7 @@ func TestUploadVideo(t *testing.T) {
 		localMockVideoRepo := new(MockVideoRepository)
 		localMockStorageSvc := new(MockStorageService)
 		localVideoService := services.NewVideoService(localMockVideoRepo, localMockStorageSvc)
-		localVideoController := controllers.NewVideoController(localVideoService, localMockStorageSvc, "", nil)
+		localVideoController := controllers.NewVideoController(localVideoService, localMockStorageSvc, pythonapi.NewClient("", nil), nil)
 		localRouter := mux.NewRouter()
 		localRouter.HandleFunc("/api/v1/videos", localVideoController.UploadVideo).Methods("POST")
 
@@ -288,11 +370,158 @@ func TestUploadVideo(t *testing.T) {
 		assert.Contains(t, rr.Body.String(), "Tracking and event files are required")
 	})
 
+	t.Run("Infected upload is quarantined", func(t *testing.T) {
+		localMockVideoRepo := new(MockVideoRepository)
+		localMockStorageSvc := new(MockStorageService)
+		localVideoService := services.NewVideoService(localMockVideoRepo, localMockStorageSvc)
+		scanner := upload.ScannerFunc(func(ctx context.Context, r io.Reader) error {
+			content, err := io.ReadAll(r)
+			if err == nil && bytes.Contains(content, []byte("EICAR")) {
+				return &upload.InfectedError{Signature: "Eicar-Test-Signature"}
+			}
+			return err
+		})
+		pythonApi := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t.Errorf("analytics must not be triggered for a quarantined upload: %s", r.URL.Path)
+		}))
+		defer pythonApi.Close()
+		localVideoController := controllers.NewVideoController(localVideoService, localMockStorageSvc, pythonapi.NewClient(pythonApi.URL, pythonApi.Client()), nil, controllers.WithUploadScanner(scanner))
+		localRouter := mux.NewRouter()
+		localRouter.HandleFunc("/api/v1/videos", localVideoController.UploadVideo).Methods("POST")
+
+		body := new(bytes.Buffer)
+		writer := multipart.NewWriter(body)
+		trackingPart, _ := writer.CreateFormFile("tracking_file", "test_tracking.gzip")
+		trackingPart.Write([]byte("X5O!P%@AP EICAR"))
+		eventPart, _ := writer.CreateFormFile("event_file", "test_events.gzip")
+		eventPart.Write([]byte("dummy event content"))
+		writer.Close()
+
+		localMockStorageSvc.On("UploadFile", mock.Anything, mock.Anything).Return(&services.FileUploadInfo{Path: "stored", Size: 10}, nil).Twice()
+		created := &models.Video{}
+		localMockVideoRepo.On("Create", mock.AnythingOfType("*models.Video")).Run(func(args mock.Arguments) {
+			*created = *args.Get(0).(*models.Video)
+		}).Return(nil).Once()
+		localMockVideoRepo.On("FindByID", mock.Anything).Return(created, nil).Once()
+		localMockVideoRepo.On("UpdateProcessingState", mock.Anything, mock.Anything, models.StateRejected, mock.Anything).Return(nil).Once()
+
+		req := httptest.NewRequest("POST", "/api/v1/videos", body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		rr := httptest.NewRecorder()
+		localRouter.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		var response struct {
+			Code    string `json:"code"`
+			Details struct {
+				VideoID string            `json:"video_id"`
+				Threats map[string]string `json:"threats"`
+			} `json:"details"`
+		}
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+		assert.Equal(t, httperr.CodeMalwareDetected, response.Code)
+		assert.Equal(t, map[string]string{"tracking": "Eicar-Test-Signature"}, response.Details.Threats)
+		assert.Equal(t, created.ID, response.Details.VideoID)
+		localMockStorageSvc.AssertExpectations(t)
+		localMockVideoRepo.AssertExpectations(t)
+	})
+
+	t.Run("Scanner unavailable", func(t *testing.T) {
+		localMockVideoRepo := new(MockVideoRepository)
+		localMockStorageSvc := new(MockStorageService)
+		localVideoService := services.NewVideoService(localMockVideoRepo, localMockStorageSvc)
+		scanner := upload.ScannerFunc(func(ctx context.Context, r io.Reader) error {
+			return errors.New("clamav: connection refused")
+		})
+		localVideoController := controllers.NewVideoController(localVideoService, localMockStorageSvc, pythonapi.NewClient("", nil), nil, controllers.WithUploadScanner(scanner))
+		localRouter := mux.NewRouter()
+		localRouter.HandleFunc("/api/v1/videos", localVideoController.UploadVideo).Methods("POST")
+
+		body := new(bytes.Buffer)
+		writer := multipart.NewWriter(body)
+		trackingPart, _ := writer.CreateFormFile("tracking_file", "test_tracking.gzip")
+		trackingPart.Write([]byte("dummy tracking content"))
+		eventPart, _ := writer.CreateFormFile("event_file", "test_events.gzip")
+		eventPart.Write([]byte("dummy event content"))
+		writer.Close()
+
+		req := httptest.NewRequest("POST", "/api/v1/videos", body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		rr := httptest.NewRecorder()
+		localRouter.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+		assert.Contains(t, rr.Body.String(), "malware scan failed")
+		localMockStorageSvc.AssertNotCalled(t, "UploadFile", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Uploads over the storage quota are rejected", func(t *testing.T) {
+		usageRepo := new(MockStorageUsageRepository)
+		usageRepo.On("FindUsage", models.UsageScopeOrganization, mock.Anything).Return(&models.StorageUsage{Bytes: 990}, nil)
+		storageSvc := new(MockStorageService)
+		quotas := services.NewQuotaService(usageRepo, services.QuotaConfig{OrganizationBytes: 1000})
+		videoController := controllers.NewVideoController(services.NewVideoService(new(MockVideoRepository), storageSvc), storageSvc,
+			pythonapi.NewClient("", nil), nil, controllers.WithQuotas(quotas))
+		router := mux.NewRouter()
+		router.HandleFunc("/api/v1/videos", videoController.UploadVideo).Methods("POST")
+
+		for size, status := range map[int]int{20: http.StatusPaymentRequired, 1000: http.StatusRequestEntityTooLarge} {
+			body := new(bytes.Buffer)
+			writer := multipart.NewWriter(body)
+			trackingPart, _ := writer.CreateFormFile("tracking_file", "test_tracking.gzip")
+			trackingPart.Write(bytes.Repeat([]byte("t"), size))
+			eventPart, _ := writer.CreateFormFile("event_file", "test_events.gzip")
+			eventPart.Write([]byte("e"))
+			writer.Close()
+
+			req := httptest.NewRequest("POST", "/api/v1/videos", body)
+			req.Header.Set("Content-Type", writer.FormDataContentType())
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, status, rr.Code, "upload of %d bytes", size+1)
+			assert.Contains(t, rr.Body.String(), "quota")
+		}
+		storageSvc.AssertNotCalled(t, "UploadFile", mock.Anything, mock.Anything)
+		usageRepo.AssertNotCalled(t, "Charge", mock.Anything)
+	})
+
+	t.Run("Chunked uploads stop at the storage quota and leave nothing stored", func(t *testing.T) {
+		usageRepo := new(MockStorageUsageRepository)
+		usageRepo.On("FindUsage", models.UsageScopeOrganization, mock.Anything).Return(&models.StorageUsage{Bytes: 900}, nil)
+		storage := services.NewMemoryStorageService()
+		quotas := services.NewQuotaService(usageRepo, services.QuotaConfig{OrganizationBytes: 1000})
+		videoController := controllers.NewVideoController(services.NewVideoService(new(MockVideoRepository), storage), storage,
+			pythonapi.NewClient("", nil), nil, controllers.WithQuotas(quotas))
+		router := mux.NewRouter()
+		router.HandleFunc("/api/v1/videos", videoController.UploadVideo).Methods("POST")
+
+		body := new(bytes.Buffer)
+		writer := multipart.NewWriter(body)
+		eventPart, _ := writer.CreateFormFile("event_file", "test_events.gzip")
+		eventPart.Write([]byte("e"))
+		trackingPart, _ := writer.CreateFormFile("tracking_file", "test_tracking.gzip")
+		trackingPart.Write(bytes.Repeat([]byte("t"), 4096))
+		writer.Close()
+
+		req := httptest.NewRequest("POST", "/api/v1/videos", body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		req.ContentLength = -1 // Transfer-Encoding: chunked
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusPaymentRequired, rr.Code, rr.Body.String())
+		assert.Contains(t, rr.Body.String(), "quota")
+		stored, err := storage.ListFiles("")
+		require.NoError(t, err)
+		assert.Empty(t, stored, "the event file streamed before the quota ran out is deleted")
+	})
+
 	t.Run("Storage service Create (for file) fails", func(t *testing.T) {
 		localMockVideoRepo := new(MockVideoRepository)
 		localMockStorageSvc := new(MockStorageService)
 		localVideoService := services.NewVideoService(localMockVideoRepo, localMockStorageSvc)
-		localVideoController := controllers.NewVideoController(localVideoService, localMockStorageSvc, "", nil)
+		localVideoController := controllers.NewVideoController(localVideoService, localMockStorageSvc, pythonapi.NewClient("", nil), nil)
 		localRouter := mux.NewRouter()
 		localRouter.HandleFunc("/api/v1/videos", localVideoController.UploadVideo).Methods("POST")
 
@@ -323,13 +552,193 @@ func TestUploadVideo(t *testing.T) {
 		assert.Contains(t, rr.Body.String(), "cannot create event file")
 		localMockStorageSvc.AssertExpectations(t)
 	})
+
+	t.Run("Files are stored in the directory of the path strategy", func(t *testing.T) {
+		videoRepo := new(MockVideoRepository)
+		storageSvc := new(MockStorageService)
+		videoController := controllers.NewVideoController(services.NewVideoService(videoRepo, storageSvc), storageSvc,
+			pythonapi.NewClient("", nil), nil, controllers.WithPathStrategy(services.OrgPrefixedStrategy{}))
+		router := mux.NewRouter()
+		router.HandleFunc("/api/v1/videos", videoController.UploadVideo).Methods("POST")
+
+		var mu sync.Mutex
+		var paths []string
+		storageSvc.On("UploadFile", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			mu.Lock()
+			defer mu.Unlock()
+			paths = append(paths, filepath.ToSlash(args.String(1)))
+		}).Return(&services.FileUploadInfo{Path: "stored"}, nil)
+		videoRepo.On("Create", mock.AnythingOfType("*models.Video")).Return(nil)
+
+		body := new(bytes.Buffer)
+		writer := multipart.NewWriter(body)
+		trackingPart, _ := writer.CreateFormFile("tracking_file", "track.gzip")
+		trackingPart.Write([]byte("track"))
+		eventPart, _ := writer.CreateFormFile("event_file", "event.gzip")
+		eventPart.Write([]byte("event"))
+		writer.Close()
+
+		req := httptest.NewRequest("POST", "/api/v1/videos", body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusAccepted, rr.Code, rr.Body.String())
+		var response map[string]string
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+		videoID := response["video_id"]
+		dir := "videos/default/" + videoID[0:2] + "/" + videoID[2:4] + "/" + videoID + "/"
+		assert.ElementsMatch(t, []string{dir + videoID + "_tracking.gzip", dir + videoID + "_events.gzip"}, paths)
+	})
+
+	t.Run("Files are copied concurrently", func(t *testing.T) {
+		videoRepo := new(MockVideoRepository)
+		storageSvc := new(MockStorageService)
+		videoController := controllers.NewVideoController(services.NewVideoService(videoRepo, storageSvc), storageSvc,
+			pythonapi.NewClient("", nil), nil)
+		router := mux.NewRouter()
+		router.HandleFunc("/api/v1/videos", videoController.UploadVideo).Methods("POST")
+
+		// Every copy waits until all three have started
+		var started sync.WaitGroup
+		started.Add(3)
+		allStarted := make(chan struct{})
+		go func() { started.Wait(); close(allStarted) }()
+		storageSvc.On("UploadFile", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			started.Done()
+			select {
+			case <-allStarted:
+			case <-time.After(5 * time.Second):
+				t.Error("copies ran one after another")
+			}
+		}).Return(&services.FileUploadInfo{Path: "stored"}, nil)
+		videoRepo.On("Create", mock.AnythingOfType("*models.Video")).Return(nil)
+
+		body := new(bytes.Buffer)
+		writer := multipart.NewWriter(body)
+		for field, name := range map[string]string{"video_file": "video.mp4", "tracking_file": "track.gzip", "event_file": "event.gzip"} {
+			part, _ := writer.CreateFormFile(field, name)
+			part.Write([]byte(field))
+		}
+		writer.Close()
+
+		req := httptest.NewRequest("POST", "/api/v1/videos", body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusAccepted, rr.Code, rr.Body.String())
+		storageSvc.AssertNumberOfCalls(t, "UploadFile", 3)
+	})
+
+	t.Run("Streaming storage gets the parts as they arrive", func(t *testing.T) {
+		videoRepo := new(MockVideoRepository)
+		storage := services.NewMemoryStorageService()
+		videoController := controllers.NewVideoController(services.NewVideoService(videoRepo, storage), storage,
+			pythonapi.NewClient("", nil), nil, controllers.WithUploadLimits(controllers.UploadLimits{Events: 10}))
+		router := mux.NewRouter()
+		router.HandleFunc("/api/v1/videos", videoController.UploadVideo).Methods("POST")
+
+		var created *models.Video
+		videoRepo.On("Create", mock.AnythingOfType("*models.Video")).Run(func(args mock.Arguments) {
+			created = args.Get(0).(*models.Video)
+		}).Return(nil)
+
+		upload := func(events string, fields ...string) *httptest.ResponseRecorder {
+			body := new(bytes.Buffer)
+			writer := multipart.NewWriter(body)
+			trackingPart, _ := writer.CreateFormFile("tracking_file", "track.gzip")
+			trackingPart.Write([]byte("tracking"))
+			if events != "" {
+				eventPart, _ := writer.CreateFormFile("event_file", "event.gzip")
+				eventPart.Write([]byte(events))
+			}
+			for i := 0; i < len(fields); i += 2 {
+				writer.WriteField(fields[i], fields[i+1]) // Fields after the files
+			}
+			writer.Close()
+
+			req := httptest.NewRequest("POST", "/api/v1/videos", body)
+			req.Header.Set("Content-Type", writer.FormDataContentType())
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			return rr
+		}
+		stored := func() []services.FileInfo {
+			files, err := storage.ListFiles("")
+			require.NoError(t, err)
+			return files
+		}
+
+		rr := upload("events", "title", "Streamed match")
+		require.Equal(t, http.StatusAccepted, rr.Code, rr.Body.String())
+		require.NotNil(t, created)
+		assert.Equal(t, "Streamed match", created.Title)
+		assert.Len(t, stored(), 2)
+
+		rr = upload("more than ten bytes of events")
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+		assert.Contains(t, rr.Body.String(), "events file exceeds")
+		assert.Len(t, stored(), 2, "the tracking file of the rejected upload is deleted")
+
+		rr = upload("")
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Len(t, stored(), 2)
+
+		rr = upload("events", "kickoff_at", "tonight")
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "kickoff_at")
+		assert.Len(t, stored(), 2)
+	})
+
+	t.Run("Stored paths are resolved for the Python API", func(t *testing.T) {
+		videoRepo := new(MockVideoRepository)
+		storageSvc := new(MockStorageService)
+		storageSvc.On("UploadFile", mock.Anything, mock.MatchedBy(func(p string) bool { return strings.HasSuffix(p, "_tracking.gzip") })).
+			Return(&services.FileUploadInfo{Path: "videos/ab/cd/m1/m1_tracking.gzip"}, nil)
+		storageSvc.On("UploadFile", mock.Anything, mock.MatchedBy(func(p string) bool { return strings.HasSuffix(p, "_events.gzip") })).
+			Return(&services.FileUploadInfo{Path: "videos/ab/cd/m1/m1_events.gzip"}, nil)
+		videoRepo.On("Create", mock.AnythingOfType("*models.Video")).Return(nil)
+
+		var processRequest map[string]string
+		pythonAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			json.NewDecoder(r.Body).Decode(&processRequest)
+			w.WriteHeader(http.StatusAccepted)
+			json.NewEncoder(w).Encode(map[string]string{"message": "processing"})
+		}))
+		defer pythonAPI.Close()
+
+		resolver, err := services.NewPathResolver(services.PathModePrefix, "videos/=/data/shared/videos/", nil)
+		require.NoError(t, err)
+		videoController := controllers.NewVideoController(services.NewVideoService(videoRepo, storageSvc), storageSvc,
+			pythonapi.NewClient(pythonAPI.URL, pythonAPI.Client()), nil, controllers.WithPathResolver(resolver))
+		router := mux.NewRouter()
+		router.HandleFunc("/api/v1/videos", videoController.UploadVideo).Methods("POST")
+
+		body := new(bytes.Buffer)
+		writer := multipart.NewWriter(body)
+		trackingPart, _ := writer.CreateFormFile("tracking_file", "track.gzip")
+		trackingPart.Write([]byte("track"))
+		eventPart, _ := writer.CreateFormFile("event_file", "event.gzip")
+		eventPart.Write([]byte("event"))
+		writer.Close()
+
+		req := httptest.NewRequest("POST", "/api/v1/videos", body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusAccepted, rr.Code, rr.Body.String())
+		assert.Equal(t, "/data/shared/videos/ab/cd/m1/m1_tracking.gzip", processRequest["tracking_data_path"])
+		assert.Equal(t, "/data/shared/videos/ab/cd/m1/m1_events.gzip", processRequest["event_data_path"])
+	})
 }
 
 func TestGetVideo(t *testing.T) {
 	mockVideoRepo := new(MockVideoRepository)
 	mockStorageSvc := new(MockStorageService)
 	videoService := services.NewVideoService(mockVideoRepo, mockStorageSvc)
-	videoController := controllers.NewVideoController(videoService, mockStorageSvc, "", nil)
+	videoController := controllers.NewVideoController(videoService, mockStorageSvc, pythonapi.NewClient("", nil), nil)
 
 	router := mux.NewRouter()
 	router.HandleFunc("/videos/{id}", videoController.GetVideo)
@@ -345,13 +754,172 @@ func TestGetVideo(t *testing.T) {
 		assert.Contains(t, rr.Body.String(), "Video not found")
 		mockVideoRepo.AssertExpectations(t)
 	})
+
+	t.Run("Conditional requests", func(t *testing.T) {
+		updated := time.Date(2024, 8, 1, 12, 30, 15, 500, time.UTC)
+		video := &models.Video{ID: "v1", Title: "Match", UpdatedAt: updated}
+		mockVideoRepo.On("FindByID", "v1").Return(video, nil)
+
+		get := func(header, value string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest("GET", "/videos/v1", nil)
+			if header != "" {
+				req.Header.Set(header, value)
+			}
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			return rr
+		}
+
+		rr := get("", "")
+		require.Equal(t, http.StatusOK, rr.Code)
+		etag := rr.Header().Get("ETag")
+		assert.NotEmpty(t, etag)
+		assert.Equal(t, "Thu, 01 Aug 2024 12:30:15 GMT", rr.Header().Get("Last-Modified"))
+
+		rr = get("If-None-Match", etag)
+		assert.Equal(t, http.StatusNotModified, rr.Code)
+		assert.Empty(t, rr.Body.String())
+		rr = get("If-Modified-Since", "Thu, 01 Aug 2024 12:30:15 GMT")
+		assert.Equal(t, http.StatusNotModified, rr.Code)
+		assert.Equal(t, http.StatusOK, get("If-Modified-Since", "Thu, 01 Aug 2024 12:30:14 GMT").Code)
+
+		// An update changes the validators
+		video.UpdatedAt = updated.Add(time.Minute)
+		assert.Equal(t, http.StatusOK, get("If-None-Match", etag).Code)
+	})
+}
+
+func TestGetVideoHistory(t *testing.T) {
+	mockVideoSvc := new(MockVideoService)
+	videoController := controllers.NewVideoController(mockVideoSvc, new(MockStorageService), pythonapi.NewClient("", nil), nil)
+	router := mux.NewRouter()
+	router.HandleFunc("/videos/{id}/history", videoController.GetVideoHistory)
+
+	at := time.Date(2024, 8, 1, 12, 0, 0, 0, time.UTC)
+	mockVideoSvc.On("GetStateHistory", "v1").Return([]*models.StateTransition{
+		{ID: 1, VideoID: "v1", To: models.StatePendingAnalytics, Actor: "user-1", Reason: "upload completed", CreatedAt: at},
+		{ID: 2, VideoID: "v1", From: models.StatePendingAnalytics, To: models.StateCompleted, Actor: "system:analytics", CreatedAt: at},
+	}, nil).Once()
+	mockVideoSvc.On("GetStateHistory", "missing").Return(nil, services.ErrVideoNotFound).Once()
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/videos/v1/history", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `[
+		{"id":1,"video_id":"v1","from":"","to":"pending_analytics","actor":"user-1","reason":"upload completed","created_at":"2024-08-01T12:00:00Z"},
+		{"id":2,"video_id":"v1","from":"pending_analytics","to":"completed","actor":"system:analytics","created_at":"2024-08-01T12:00:00Z"}
+	]`, rr.Body.String())
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/videos/missing/history", nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	mockVideoSvc.AssertExpectations(t)
+}
+
+func TestListVideos(t *testing.T) {
+	mockVideoRepo := new(MockVideoRepository)
+	mockStorageSvc := new(MockStorageService)
+	videoService := services.NewVideoService(mockVideoRepo, mockStorageSvc)
+	videoController := controllers.NewVideoController(videoService, mockStorageSvc, pythonapi.NewClient("", nil), nil)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/videos", videoController.ListVideos)
+
+	t.Run("Filters are combined into one query", func(t *testing.T) {
+		mockVideoRepo.On("FindByQuery", models.VideoQuery{
+			Team:            "Ajax",
+			Season:          "2024",
+			ProcessingState: "completed",
+			MatchDateFrom:   time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC),
+			MatchDateTo:     time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC).Add(-time.Nanosecond),
+			Limit:           5,
+		}).Return([]*models.Video{{ID: "v1"}}, nil).Once()
+
+		req := httptest.NewRequest("GET", "/videos?team=Ajax&season=2024&processing_state=completed&from=2024-08-01&to=2024-08-31&limit=5", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"id":"v1"`)
+		mockVideoRepo.AssertExpectations(t)
+	})
+
+	t.Run("API v2 wraps the page in an envelope", func(t *testing.T) {
+		mockVideoRepo.On("FindByQuery", models.VideoQuery{Limit: 1}).Return([]*models.Video{{ID: "v1"}}, nil).Twice()
+		v2Router := mux.NewRouter()
+		v2Router.Use(middleware.APIVersion)
+		v2Router.HandleFunc("/api/v{version}/videos", videoController.ListVideos)
+
+		rr := httptest.NewRecorder()
+		v2Router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/videos?limit=1", nil))
+		assert.JSONEq(t, `[{"id":"v1"}]`, string(onlyIDs(t, rr.Body.Bytes())))
+
+		rr = httptest.NewRecorder()
+		v2Router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/v2/videos?limit=1", nil))
+		var page struct {
+			Items      []models.Video         `json:"items"`
+			Pagination controllers.Pagination `json:"pagination"`
+		}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &page))
+		require.Len(t, page.Items, 1)
+		assert.Equal(t, "v1", page.Items[0].ID)
+		assert.Equal(t, controllers.Pagination{Limit: 1, Offset: 0, Count: 1, HasMore: true}, page.Pagination)
+		mockVideoRepo.AssertExpectations(t)
+	})
+
+	t.Run("Unchanged pages answer 304", func(t *testing.T) {
+		page := []*models.Video{{ID: "v1", UpdatedAt: time.Unix(100, 0)}, {ID: "v2", UpdatedAt: time.Unix(200, 0)}}
+		mockVideoRepo.On("FindByQuery", models.VideoQuery{Limit: 2}).Return(page, nil).Times(3)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/videos?limit=2", nil))
+		require.Equal(t, http.StatusOK, rr.Code)
+		etag := rr.Header().Get("ETag")
+		assert.Empty(t, rr.Header().Get("Last-Modified"))
+
+		req := httptest.NewRequest("GET", "/videos?limit=2", nil)
+		req.Header.Set("If-None-Match", etag)
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusNotModified, rr.Code)
+
+		page[1].UpdatedAt = time.Unix(300, 0)
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.NotEqual(t, etag, rr.Header().Get("ETag"))
+		mockVideoRepo.AssertExpectations(t)
+	})
+
+	t.Run("Videos are sorted by the requested field", func(t *testing.T) {
+		mockVideoRepo.On("FindByQuery", models.VideoQuery{Sort: "title", Order: "asc", Limit: 10}).Return([]*models.Video{}, nil).Once()
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/videos?sort=title&order=asc", nil))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		mockVideoRepo.AssertExpectations(t)
+
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/videos?sort=duration", nil))
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "match_date, created_at, title")
+	})
+
+	t.Run("Invalid date ranges are rejected", func(t *testing.T) {
+		for _, query := range []string{"from=last-week", "from=2024-09-01&to=2024-08-01"} {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest("GET", "/videos?"+query, nil))
+			assert.Equal(t, http.StatusBadRequest, rr.Code, query)
+		}
+	})
 }
 
 func TestDeleteVideo(t *testing.T) {
 	mockVideoRepo := new(MockVideoRepository)
 	mockStorageSvc := new(MockStorageService)
 	videoService := services.NewVideoService(mockVideoRepo, mockStorageSvc)
-	videoController := controllers.NewVideoController(videoService, mockStorageSvc, "", nil)
+	videoController := controllers.NewVideoController(videoService, mockStorageSvc, pythonapi.NewClient("", nil), nil)
 
 	router := mux.NewRouter()
 	router.HandleFunc("/videos/{id}", videoController.DeleteVideo)
@@ -386,6 +954,120 @@ func TestDeleteVideo(t *testing.T) {
 		mockVideoRepo.AssertExpectations(t)
 		mockStorageSvc.AssertExpectations(t)
 	})
+
+	t.Run("DeleteVideo with a purge queue keeps the files until undeleted", func(t *testing.T) {
+		repos := models.NewMemoryRepositories()
+		require.NoError(t, repos.Videos.Create(&models.Video{ID: "m1", FilePath: "videos/m1/match.mp4"}))
+		storage := new(MockStorageService)
+		videoService := services.NewVideoService(repos.Videos, storage)
+		purges := services.NewVideoPurgeService(repos.Videos, videoService, repos.VideoPurges, storage, nil, time.Hour)
+		vc := controllers.NewVideoController(videoService, storage, pythonapi.NewClient("", nil), nil, controllers.WithPurgeQueue(purges))
+		router := mux.NewRouter()
+		router.HandleFunc("/videos/{id}", vc.DeleteVideo).Methods("DELETE")
+		router.HandleFunc("/videos/{id}/undelete", vc.UndeleteVideo).Methods("POST")
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("DELETE", "/videos/m1", nil))
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		storage.AssertNotCalled(t, "DeleteFile", mock.Anything)
+
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("POST", "/videos/m1/undelete", nil))
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"id":"m1"`)
+		_, err := repos.Videos.FindByID("m1")
+		assert.NoError(t, err)
+
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("POST", "/videos/m1/undelete", nil))
+		assert.Equal(t, http.StatusNotFound, rr.Code, "no deletion is pending any more")
+	})
 }
 
 // End of video_controller_test.go
+
+// seekableFile is a stored file that supports range requests.
+type seekableFile struct{ *strings.Reader }
+
+func (seekableFile) Close() error { return nil }
+
+func TestDownloadMatchFile(t *testing.T) {
+	video := &models.Video{ID: "vid123", TrackingPath: "videos/vid123/vid123_tracking.gzip", FilePath: "videos/vid123/vid123.mp4"}
+
+	serve := func(storage *MockStorageService, repo *MockVideoRepository, path string, header http.Header) *httptest.ResponseRecorder {
+		vc := controllers.NewVideoController(services.NewVideoService(repo, storage), storage, pythonapi.NewClient("", nil), nil)
+		router := mux.NewRouter()
+		router.HandleFunc("/api/v1/matches/{id}/files/{type}", vc.DownloadMatchFile).Methods("GET")
+		req := httptest.NewRequest("GET", path, nil)
+		for name, values := range header {
+			req.Header[name] = values
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("Range request on a seekable file", func(t *testing.T) {
+		repo, storage := new(MockVideoRepository), new(MockStorageService)
+		repo.On("FindByID", "vid123").Return(video, nil)
+		storage.On("GetFile", video.TrackingPath).Return(seekableFile{strings.NewReader("0123456789")}, nil)
+
+		rr := serve(storage, repo, "/api/v1/matches/vid123/files/tracking", http.Header{"Range": {"bytes=2-5"}})
+
+		assert.Equal(t, http.StatusPartialContent, rr.Code)
+		assert.Equal(t, "2345", rr.Body.String())
+		assert.Equal(t, "bytes 2-5/10", rr.Header().Get("Content-Range"))
+		assert.Equal(t, "application/gzip", rr.Header().Get("Content-Type"))
+		assert.Equal(t, `attachment; filename="match-vid123-tracking.gzip"`, rr.Header().Get("Content-Disposition"))
+	})
+
+	t.Run("Non-seekable file is streamed whole", func(t *testing.T) {
+		repo, storage := new(MockVideoRepository), new(MockStorageService)
+		repo.On("FindByID", "vid123").Return(video, nil)
+		storage.On("GetFile", video.FilePath).Return(io.NopCloser(strings.NewReader("video bytes")), nil)
+
+		rr := serve(storage, repo, "/api/v1/matches/vid123/files/video", http.Header{"Range": {"bytes=0-1"}})
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "video bytes", rr.Body.String())
+		assert.Equal(t, "none", rr.Header().Get("Accept-Ranges"))
+		assert.Equal(t, "video/mp4", rr.Header().Get("Content-Type"))
+	})
+
+	t.Run("Missing and invalid files", func(t *testing.T) {
+		repo, storage := new(MockVideoRepository), new(MockStorageService)
+		repo.On("FindByID", "vid123").Return(video, nil)
+		repo.On("FindByID", "nope").Return(nil, errors.New("video not found"))
+
+		assert.Equal(t, http.StatusBadRequest, serve(storage, repo, "/api/v1/matches/vid123/files/secrets", nil).Code)
+		assert.Equal(t, http.StatusNotFound, serve(storage, repo, "/api/v1/matches/vid123/files/events", nil).Code)
+		assert.Equal(t, http.StatusNotFound, serve(storage, repo, "/api/v1/matches/nope/files/tracking", nil).Code)
+		storage.AssertNotCalled(t, "GetFile", mock.Anything)
+	})
+
+	t.Run("Quarantined files are not served", func(t *testing.T) {
+		repo, storage := new(MockVideoRepository), new(MockStorageService)
+		repo.On("FindByID", "vid123").Return(&models.Video{ID: "vid123", TrackingPath: video.TrackingPath, ProcessingState: "rejected"}, nil)
+
+		assert.Equal(t, http.StatusForbidden, serve(storage, repo, "/api/v1/matches/vid123/files/tracking", nil).Code)
+		storage.AssertNotCalled(t, "GetFile", mock.Anything)
+	})
+	t.Run("Archived files must be restored first", func(t *testing.T) {
+		repo, storage := new(MockVideoRepository), new(MockStorageService)
+		repo.On("FindByID", "vid123").Return(&models.Video{ID: "vid123", TrackingPath: video.TrackingPath, ProcessingState: "archived"}, nil)
+
+		assert.Equal(t, http.StatusConflict, serve(storage, repo, "/api/v1/matches/vid123/files/tracking", nil).Code)
+		storage.AssertNotCalled(t, "GetFile", mock.Anything)
+	})
+}
+
+// onlyIDs reduces a JSON array of videos to their IDs.
+func onlyIDs(t *testing.T, body []byte) []byte {
+	var videos []struct {
+		ID string `json:"id"`
+	}
+	require.NoError(t, json.Unmarshal(body, &videos))
+	ids, err := json.Marshal(videos)
+	require.NoError(t, err)
+	return ids
+}
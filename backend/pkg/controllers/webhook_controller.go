@@ -0,0 +1,134 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"nivai/backend/pkg/httperr"
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/services"
+
+	"github.com/gorilla/mux"
+)
+
+// WebhookController manages webhook subscriptions for integrators.
+type WebhookController struct {
+	webhookService services.WebhookService
+}
+
+// NewWebhookController creates a new controller for webhook endpoints.
+func NewWebhookController(ws services.WebhookService) *WebhookController {
+	return &WebhookController{webhookService: ws}
+}
+
+// createWebhookRequest is the body accepted by CreateWebhook.
+type createWebhookRequest struct {
+	URL        string   `json:"url"`
+	Secret     string   `json:"secret"`
+	EventTypes []string `json:"event_types"`
+}
+
+// createWebhookResponse includes the signing secret, which is only ever
+// returned on creation.
+type createWebhookResponse struct {
+	*models.Webhook
+	Secret string `json:"secret"`
+}
+
+// CreateWebhook handles POST /api/v1/webhooks.
+func (wc *WebhookController) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var req createWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httperr.WriteError(w, r, httperr.BadRequest("Invalid request payload"))
+		return
+	}
+
+	webhook, err := wc.webhookService.CreateWebhook(req.URL, req.Secret, req.EventTypes)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidWebhookURL) || errors.Is(err, services.ErrInvalidWebhookEvent) {
+			httperr.WriteError(w, r, httperr.BadRequest(err.Error()))
+			return
+		}
+		log.Printf("Error creating webhook: %v", err)
+		httperr.WriteError(w, r, httperr.Internal("Failed to create webhook"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(createWebhookResponse{Webhook: webhook, Secret: webhook.Secret}); err != nil {
+		log.Printf("Error encoding CreateWebhook response: %v", err)
+	}
+}
+
+// ListWebhooks handles GET /api/v1/webhooks.
+func (wc *WebhookController) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	webhooks, err := wc.webhookService.ListWebhooks()
+	if err != nil {
+		log.Printf("Error listing webhooks: %v", err)
+		httperr.WriteError(w, r, httperr.Internal("Failed to retrieve webhooks"))
+		return
+	}
+	if webhooks == nil {
+		webhooks = []*models.Webhook{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(webhooks); err != nil {
+		log.Printf("Error encoding ListWebhooks response: %v", err)
+	}
+}
+
+// GetWebhook handles GET /api/v1/webhooks/{id}.
+func (wc *WebhookController) GetWebhook(w http.ResponseWriter, r *http.Request) {
+	webhook, err := wc.webhookService.GetWebhook(mux.Vars(r)["id"])
+	if err != nil {
+		wc.writeLookupError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(webhook); err != nil {
+		log.Printf("Error encoding GetWebhook response: %v", err)
+	}
+}
+
+// DeleteWebhook handles DELETE /api/v1/webhooks/{id}.
+func (wc *WebhookController) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	if err := wc.webhookService.DeleteWebhook(mux.Vars(r)["id"]); err != nil {
+		wc.writeLookupError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListDeliveries handles GET /api/v1/webhooks/{id}/deliveries.
+func (wc *WebhookController) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	limit, offset := parsePaginationParams(r)
+
+	deliveries, err := wc.webhookService.ListDeliveries(mux.Vars(r)["id"], limit, offset)
+	if err != nil {
+		wc.writeLookupError(w, r, err)
+		return
+	}
+	if deliveries == nil {
+		deliveries = []*models.WebhookDelivery{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(deliveries); err != nil {
+		log.Printf("Error encoding ListDeliveries response: %v", err)
+	}
+}
+
+// writeLookupError maps service errors for single-webhook lookups to HTTP responses.
+func (wc *WebhookController) writeLookupError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, services.ErrWebhookNotFound) {
+		httperr.WriteError(w, r, httperr.NotFound("Webhook not found"))
+		return
+	}
+	log.Printf("Error handling webhook request: %v", err)
+	httperr.WriteError(w, r, httperr.Internal("Failed to process webhook request"))
+}
@@ -5,6 +5,8 @@ import (
 	"net/http"
 	"sync"
 
+	"nivai/backend/pkg/lifecycle"
+
 	"github.com/gorilla/websocket"
 )
 
@@ -21,6 +23,12 @@ type Client struct {
 
 	// Reference to the hub for broadcasting
 	hub *Hub
+
+	// Close frame sent when the hub closes the send channel
+	closeMessage []byte
+
+	// Ends the connection's hold on graceful shutdown
+	release func()
 }
 
 /**
@@ -42,6 +50,26 @@ type Hub struct {
 
 	// Mutex for concurrent access to clients map
 	mu sync.Mutex
+
+	// Optional; connections hold up shutdown and are closed when it stops
+	lifecycle *lifecycle.Manager
+}
+
+/**
+ * HubOption configures optional Hub dependencies.
+ */
+type HubOption func(*Hub)
+
+/**
+ * WithLifecycle makes every connection hold up graceful shutdown until it
+ * closes, and closes all connections with "going away" once the server
+ * stops accepting new ones, so clients reconnect to another instance.
+ *
+ * @param manager The lifecycle manager
+ * @return The hub option
+ */
+func WithLifecycle(manager *lifecycle.Manager) HubOption {
+	return func(h *Hub) { h.lifecycle = manager }
 }
 
 // WebSocket connection upgrader with configuration
@@ -59,16 +87,21 @@ var upgrader = websocket.Upgrader{
  * NewHub creates a new hub instance.
  * Initializes channels and client map for the hub.
  *
+ * @param opts Optional dependencies
  * @return A new Hub instance ready to be run
  */
-func NewHub() *Hub {
-	return &Hub{
+func NewHub(opts ...HubOption) *Hub {
+	h := &Hub{
 		clients:    make(map[*Client]bool),
 		register:   make(chan *Client),
 		unregister: make(chan *Client),
 		broadcast:  make(chan []byte),
 		mu:         sync.Mutex{},
 	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
 }
 
 /**
@@ -77,8 +110,25 @@ func NewHub() *Hub {
  * Should be run in a goroutine.
  */
 func (h *Hub) Run() {
+	var stopping <-chan struct{}
+	if h.lifecycle != nil {
+		stopping = h.lifecycle.Stopping()
+	}
 	for {
 		select {
+		case <-stopping:
+			// Ask every client to reconnect elsewhere; their read pumps
+			// release the shutdown holds once the connections close
+			stopping = nil
+			goingAway := websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down")
+			h.mu.Lock()
+			for client := range h.clients {
+				client.closeMessage = goingAway
+				close(client.send)
+				delete(h.clients, client)
+			}
+			h.mu.Unlock()
+
 		case client := <-h.register:
 			// Register new client
 			h.mu.Lock()
@@ -112,6 +162,19 @@ func (h *Hub) Run() {
 	}
 }
 
+/**
+ * Broadcast sends a server-originated message to all connected clients.
+ * It implements services.LiveBroadcaster and requires Run to be running.
+ *
+ * @param message The encoded message; empty messages are dropped
+ */
+func (h *Hub) Broadcast(message []byte) {
+	if len(message) == 0 {
+		return
+	}
+	h.broadcast <- message
+}
+
 /**
  * readPump pumps messages from the WebSocket connection to the hub.
  * Continuously reads from the WebSocket and forwards messages to the hub.
@@ -121,6 +184,7 @@ func (c *Client) readPump() {
 	defer func() {
 		c.hub.unregister <- c
 		c.conn.Close()
+		c.release()
 	}()
 
 	for {
@@ -151,7 +215,7 @@ func (c *Client) writePump() {
 		message, ok := <-c.send
 		if !ok {
 			// The hub closed the channel
-			c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+			c.conn.WriteMessage(websocket.CloseMessage, c.closeMessage)
 			return
 		}
 
@@ -181,9 +245,14 @@ func (h *Hub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	// Create a new client
 	client := &Client{
-		conn: conn,
-		send: make(chan []byte, 256),
-		hub:  h, // Use the hub instance 'h'
+		conn:         conn,
+		send:         make(chan []byte, 256),
+		hub:          h, // Use the hub instance 'h'
+		closeMessage: []byte{},
+		release:      func() {},
+	}
+	if h.lifecycle != nil {
+		client.release = h.lifecycle.Hold()
 	}
 
 	// Register the client
@@ -1,6 +1,7 @@
 package controllers_test
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -8,6 +9,7 @@ import (
 	"time"
 
 	"nivai/backend/pkg/controllers" // Adjust import path as necessary
+	"nivai/backend/pkg/lifecycle"
 
 	"github.com/gorilla/websocket"
 	"github.com/stretchr/testify/assert"
@@ -119,4 +121,31 @@ func TestWebSocketHandler(t *testing.T) {
 			strings.Contains(err.Error(), "connection reset by peer") // Common on some systems
 		assert.True(t, isCloseError, "Error should be a WebSocket close error or network closed error, got: %v", err)
 	})
+
+	t.Run("Connections go away when shutdown stops the server", func(t *testing.T) {
+		manager := lifecycle.New(lifecycle.Config{PreStopDelay: time.Millisecond, DrainTimeout: 2 * time.Second})
+		testHub := controllers.NewHub(controllers.WithLifecycle(manager))
+		go testHub.Run()
+
+		server := httptest.NewServer(testHub)
+		defer server.Close()
+		wsURL := "ws" + strings.TrimPrefix(server.URL, "http")
+
+		conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+		require.NoError(t, err)
+		defer conn.Close()
+		require.Eventually(t, func() bool { return manager.InFlight() == 1 }, time.Second, 10*time.Millisecond,
+			"the connection holds up shutdown")
+
+		done := make(chan error, 1)
+		go func() { done <- manager.Shutdown(context.Background(), server.Config) }()
+
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		_, _, err = conn.ReadMessage()
+		assert.True(t, websocket.IsCloseError(err, websocket.CloseGoingAway), "expected a going-away close, got: %v", err)
+		conn.Close()
+
+		require.NoError(t, <-done)
+		assert.Equal(t, 0, manager.InFlight())
+	})
 }
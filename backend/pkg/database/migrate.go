@@ -0,0 +1,198 @@
+package database
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+//go:embed migrations/sqlite/*.sql
+var sqliteMigrationFiles embed.FS
+
+// noTransaction marks a migration that must not run inside a transaction.
+// Its statements are separated by semicolons at the end of a line; when one
+// fails, the version is not recorded and the statements before it stay
+// applied, so they must be safe to run again (e.g. IF NOT EXISTS).
+const noTransaction = "-- nivai:no-transaction"
+
+// migrationSet is the migrations of one database, and how it records them.
+type migrationSet struct {
+	files       embed.FS
+	pattern     string
+	createTable string
+}
+
+var (
+	postgresMigrations = migrationSet{
+		files:   migrationFiles,
+		pattern: "migrations/*.sql",
+		createTable: `
+			CREATE TABLE IF NOT EXISTS schema_migrations (
+				version    TEXT PRIMARY KEY,
+				applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+			)
+		`,
+	}
+	sqliteMigrations = migrationSet{
+		files:   sqliteMigrationFiles,
+		pattern: "migrations/sqlite/*.sql",
+		createTable: `
+			CREATE TABLE IF NOT EXISTS schema_migrations (
+				version    TEXT PRIMARY KEY,
+				applied_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+			)
+		`,
+	}
+)
+
+/**
+ * Migrate applies all pending SQL migrations embedded in the binary.
+ * Migrations are plain SQL files named "<version>_<description>.sql" and are
+ * applied in lexical order, each inside its own transaction. A migration
+ * whose first line is the noTransaction marker runs its statements one by
+ * one outside a transaction instead, as CREATE INDEX CONCURRENTLY requires.
+ * Applied versions are recorded in the schema_migrations table so each file
+ * runs only once.
+ *
+ * @param db Database connection
+ * @return Error if any migration fails to apply
+ */
+func Migrate(db *sql.DB) error {
+	return migrate(db, postgresMigrations)
+}
+
+/**
+ * MigrateSQLite applies the pending SQLite migrations, kept separately in
+ * migrations/sqlite. SQLite deployments only store videos in the database.
+ *
+ * @param db Database connection
+ * @return Error if any migration fails to apply
+ */
+func MigrateSQLite(db *sql.DB) error {
+	return migrate(db, sqliteMigrations)
+}
+
+// migrate applies the pending migrations of a set.
+func migrate(db *sql.DB, set migrationSet) error {
+	if _, err := db.Exec(set.createTable); err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	applied, err := appliedVersions(db)
+	if err != nil {
+		return err
+	}
+
+	names, err := fs.Glob(set.files, set.pattern)
+	if err != nil {
+		return err
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		version := migrationVersion(name)
+		if applied[version] {
+			continue
+		}
+
+		contents, err := set.files.ReadFile(name)
+		if err != nil {
+			return err
+		}
+
+		apply := applyMigration
+		if strings.HasPrefix(string(contents), noTransaction+"\n") {
+			apply = applyMigrationWithoutTransaction
+		}
+		if err := apply(db, version, string(contents)); err != nil {
+			return fmt.Errorf("migration %s failed: %w", version, err)
+		}
+	}
+
+	return nil
+}
+
+// appliedVersions returns the set of migration versions already recorded.
+func appliedVersions(db *sql.DB) (map[string]bool, error) {
+	rows, err := db.Query(`SELECT version FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[string]bool)
+	for rows.Next() {
+		var version string
+		if err := rows.Scan(&version); err != nil {
+			return nil, err
+		}
+		applied[version] = true
+	}
+	return applied, rows.Err()
+}
+
+// applyMigration runs one migration and records it in a single transaction.
+func applyMigration(db *sql.DB, version, statements string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(statements); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if _, err := tx.Exec(`INSERT INTO schema_migrations (version) VALUES ($1)`, version); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// applyMigrationWithoutTransaction runs the statements of a migration one at
+// a time, then records it. Each statement commits on its own.
+func applyMigrationWithoutTransaction(db *sql.DB, version, statements string) error {
+	for _, statement := range splitStatements(statements) {
+		if _, err := db.Exec(statement); err != nil {
+			return err
+		}
+	}
+	_, err := db.Exec(`INSERT INTO schema_migrations (version) VALUES ($1)`, version)
+	return err
+}
+
+// splitStatements splits a migration at the semicolons ending its lines,
+// dropping the parts holding only comments.
+func splitStatements(statements string) []string {
+	var split []string
+	var current strings.Builder
+	for _, line := range strings.Split(statements, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		current.WriteString(line + "\n")
+		if strings.HasSuffix(trimmed, ";") {
+			split = append(split, strings.TrimSpace(current.String()))
+			current.Reset()
+		}
+	}
+	if current.Len() > 0 {
+		split = append(split, strings.TrimSpace(current.String()))
+	}
+	return split
+}
+
+// migrationVersion strips the directory and extension from a migration file name.
+func migrationVersion(name string) string {
+	return strings.TrimSuffix(path.Base(name), ".sql")
+}
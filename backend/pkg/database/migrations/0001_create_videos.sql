@@ -0,0 +1,24 @@
+-- Baseline schema for the videos table used by PostgresVideoRepository.
+CREATE TABLE IF NOT EXISTS videos (
+    id               TEXT PRIMARY KEY,
+    title            TEXT NOT NULL DEFAULT '',
+    description      TEXT NOT NULL DEFAULT '',
+    file_path        TEXT NOT NULL DEFAULT '',
+    storage_provider TEXT NOT NULL DEFAULT '',
+    duration         DOUBLE PRECISION NOT NULL DEFAULT 0,
+    resolution       TEXT NOT NULL DEFAULT '',
+    format           TEXT NOT NULL DEFAULT '',
+    size             BIGINT NOT NULL DEFAULT 0,
+    processing_state TEXT NOT NULL DEFAULT 'pending',
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    deleted_at       TIMESTAMPTZ,
+    match_id         TEXT NOT NULL DEFAULT '',
+    match_date       TIMESTAMPTZ NOT NULL DEFAULT '0001-01-01 00:00:00+00',
+    home_team        TEXT NOT NULL DEFAULT '',
+    away_team        TEXT NOT NULL DEFAULT '',
+    competition      TEXT NOT NULL DEFAULT '',
+    season           TEXT NOT NULL DEFAULT '',
+    tracking_path    TEXT NOT NULL DEFAULT '',
+    event_file_path  TEXT NOT NULL DEFAULT ''
+);
@@ -0,0 +1,22 @@
+-- Registry of stored files per video with their checksums, used to verify
+-- storage integrity.
+CREATE TABLE IF NOT EXISTS video_files (
+    video_id   TEXT NOT NULL REFERENCES videos (id),
+    kind       TEXT NOT NULL,
+    path       TEXT NOT NULL,
+    size       BIGINT NOT NULL DEFAULT 0,
+    checksum   TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (video_id, kind)
+);
+
+-- Persisted copies of analytics results fetched from the Python service once
+-- a match has been processed.
+CREATE TABLE IF NOT EXISTS analytics_snapshots (
+    video_id   TEXT NOT NULL REFERENCES videos (id),
+    kind       TEXT NOT NULL,
+    payload    JSONB NOT NULL,
+    fetched_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (video_id, kind)
+);
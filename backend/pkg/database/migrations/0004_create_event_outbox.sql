@@ -0,0 +1,15 @@
+-- Transactional outbox for match lifecycle events relayed to a message broker.
+CREATE TABLE IF NOT EXISTS event_outbox (
+    id           TEXT PRIMARY KEY,
+    event_type   TEXT NOT NULL,
+    message_key  TEXT NOT NULL,
+    payload      JSONB NOT NULL,
+    attempts     INTEGER NOT NULL DEFAULT 0,
+    last_error   TEXT NOT NULL DEFAULT '',
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    published_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_event_outbox_unpublished
+    ON event_outbox (created_at)
+    WHERE published_at IS NULL;
@@ -0,0 +1,8 @@
+-- Per-match match-day mode: "auto" activates around the kickoff time, "on"
+-- and "off" are manual overrides.
+CREATE TABLE IF NOT EXISTS match_day_settings (
+    video_id   TEXT PRIMARY KEY REFERENCES videos (id),
+    mode       TEXT NOT NULL DEFAULT 'auto',
+    kickoff_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
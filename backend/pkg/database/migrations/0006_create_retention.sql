@@ -0,0 +1,22 @@
+-- Per-match overrides of the storage retention rules, by file kind.
+CREATE TABLE IF NOT EXISTS retention_overrides (
+    video_id   TEXT NOT NULL REFERENCES videos (id),
+    kind       TEXT NOT NULL,
+    action     TEXT NOT NULL,
+    days       INTEGER NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (video_id, kind)
+);
+
+-- Log of files archived or deleted by the retention sweeper.
+CREATE TABLE IF NOT EXISTS retention_actions (
+    id           BIGSERIAL PRIMARY KEY,
+    video_id     TEXT NOT NULL REFERENCES videos (id),
+    kind         TEXT NOT NULL,
+    action       TEXT NOT NULL,
+    path         TEXT NOT NULL,
+    archive_path TEXT NOT NULL DEFAULT '',
+    performed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS retention_actions_video_id_idx ON retention_actions (video_id);
@@ -0,0 +1,16 @@
+-- Cold-storage archive and restore jobs per match. Restores from an archive
+-- tier can take hours, so running jobs are polled until they finish.
+CREATE TABLE IF NOT EXISTS archive_jobs (
+    id           TEXT PRIMARY KEY,
+    video_id     TEXT NOT NULL REFERENCES videos (id),
+    operation    TEXT NOT NULL,
+    status       TEXT NOT NULL,
+    error        TEXT NOT NULL DEFAULT '',
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    completed_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_archive_jobs_running
+    ON archive_jobs (created_at)
+    WHERE status = 'running';
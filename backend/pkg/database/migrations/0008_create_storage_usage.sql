@@ -0,0 +1,21 @@
+-- Bytes and files stored per organization and per user, maintained as
+-- uploads are charged and deleted matches are released.
+CREATE TABLE IF NOT EXISTS storage_usage (
+    scope      TEXT NOT NULL, -- 'organization' or 'user'
+    owner      TEXT NOT NULL,
+    bytes      BIGINT NOT NULL DEFAULT 0,
+    files      INTEGER NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (scope, owner)
+);
+
+-- What each upload was charged to, so deleting the match releases exactly
+-- that amount from the same owners.
+CREATE TABLE IF NOT EXISTS storage_charges (
+    video_id     TEXT PRIMARY KEY REFERENCES videos (id),
+    organization TEXT NOT NULL,
+    user_id      TEXT NOT NULL,
+    bytes        BIGINT NOT NULL,
+    files        INTEGER NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,13 @@
+-- Direct-to-storage uploads: the files a client was given upload URLs for,
+-- and the match metadata to create once it finalizes the upload.
+CREATE TABLE IF NOT EXISTS upload_sessions (
+    id           TEXT PRIMARY KEY,
+    video_id     TEXT NOT NULL UNIQUE,
+    organization TEXT NOT NULL,
+    user_id      TEXT NOT NULL,
+    metadata     JSONB NOT NULL,
+    files        JSONB NOT NULL,
+    expires_at   TIMESTAMPTZ NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    finalized_at TIMESTAMPTZ
+);
@@ -0,0 +1,14 @@
+-- Processing state changes per match: who made them, when and why. Rows
+-- are only appended, and read per match in order.
+CREATE TABLE IF NOT EXISTS state_history (
+    id         BIGSERIAL PRIMARY KEY,
+    video_id   TEXT NOT NULL REFERENCES videos (id),
+    from_state TEXT NOT NULL DEFAULT '',
+    to_state   TEXT NOT NULL,
+    actor      TEXT NOT NULL,
+    reason     TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_state_history_video
+    ON state_history (video_id, created_at);
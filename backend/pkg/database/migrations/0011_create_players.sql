@@ -0,0 +1,28 @@
+-- The player roster, and the mappings of each match's tracking player IDs
+-- to it. A roster player maps to one tracking player per match.
+CREATE TABLE IF NOT EXISTS players (
+    id            TEXT PRIMARY KEY,
+    name          TEXT NOT NULL,
+    team          TEXT NOT NULL DEFAULT '',
+    jersey_number INTEGER NOT NULL DEFAULT 0,
+    external_ids  TEXT[] NOT NULL DEFAULT '{}',
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_players_team
+    ON players (LOWER(team), name);
+
+CREATE TABLE IF NOT EXISTS player_mappings (
+    video_id           TEXT NOT NULL REFERENCES videos (id),
+    tracking_player_id TEXT NOT NULL,
+    player_id          TEXT NOT NULL REFERENCES players (id),
+    source             TEXT NOT NULL,
+    confidence         DOUBLE PRECISION NOT NULL DEFAULT 1,
+    created_at         TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at         TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (video_id, tracking_player_id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_player_mappings_player
+    ON player_mappings (player_id, video_id);
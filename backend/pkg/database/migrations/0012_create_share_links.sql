@@ -0,0 +1,31 @@
+-- Links sharing one match with people without an account, and the log of
+-- every use of them.
+CREATE TABLE IF NOT EXISTS share_links (
+    id            TEXT PRIMARY KEY,
+    video_id      TEXT NOT NULL REFERENCES videos (id),
+    scope         TEXT NOT NULL,
+    clip_start_ms BIGINT NOT NULL DEFAULT 0,
+    clip_end_ms   BIGINT NOT NULL DEFAULT 0,
+    label         TEXT NOT NULL DEFAULT '',
+    created_by    TEXT NOT NULL DEFAULT '',
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at    TIMESTAMPTZ NOT NULL,
+    revoked_at    TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_share_links_video
+    ON share_links (video_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS share_accesses (
+    id          BIGSERIAL PRIMARY KEY,
+    share_id    TEXT NOT NULL REFERENCES share_links (id),
+    resource    TEXT NOT NULL,
+    allowed     BOOLEAN NOT NULL,
+    reason      TEXT NOT NULL DEFAULT '',
+    remote_addr TEXT NOT NULL DEFAULT '',
+    user_agent  TEXT NOT NULL DEFAULT '',
+    at          TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_share_accesses_share
+    ON share_accesses (share_id, at DESC);
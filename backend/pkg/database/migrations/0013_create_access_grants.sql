@@ -0,0 +1,20 @@
+-- Grants giving users and groups access to single matches, or to every
+-- match of a team, beyond their role. One grant per subject and resource.
+CREATE TABLE IF NOT EXISTS access_grants (
+    id            TEXT PRIMARY KEY,
+    subject_type  TEXT NOT NULL,
+    subject_id    TEXT NOT NULL,
+    resource_type TEXT NOT NULL,
+    resource_id   TEXT NOT NULL,
+    permission    TEXT NOT NULL,
+    granted_by    TEXT NOT NULL DEFAULT '',
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (subject_type, subject_id, resource_type, resource_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_access_grants_resource
+    ON access_grants (resource_type, LOWER(resource_id));
+
+-- Access-scoped match lists compare teams case-insensitively
+CREATE INDEX IF NOT EXISTS idx_videos_home_team_lower ON videos (LOWER(home_team));
+CREATE INDEX IF NOT EXISTS idx_videos_away_team_lower ON videos (LOWER(away_team));
@@ -0,0 +1,12 @@
+-- Which notifications each user receives, and on which channels
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    user_id           TEXT PRIMARY KEY,
+    email             TEXT NOT NULL DEFAULT '',
+    slack_webhook_url TEXT NOT NULL DEFAULT '',
+    channels          TEXT[] NOT NULL DEFAULT '{}',
+    kinds             TEXT[] NOT NULL DEFAULT '{}',
+    updated_at        TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_preferences_kinds
+    ON notification_preferences USING GIN (kinds);
@@ -0,0 +1,18 @@
+-- In-app notification inboxes, read by the dashboard
+CREATE TABLE IF NOT EXISTS notifications (
+    id         TEXT PRIMARY KEY,
+    user_id    TEXT NOT NULL,
+    kind       TEXT NOT NULL,
+    subject    TEXT NOT NULL,
+    body       TEXT NOT NULL DEFAULT '',
+    video_id   TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    read_at    TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_user_created
+    ON notifications (user_id, created_at DESC);
+
+-- Unread counts are read on every dashboard start
+CREATE INDEX IF NOT EXISTS idx_notifications_unread
+    ON notifications (user_id) WHERE read_at IS NULL;
@@ -0,0 +1,14 @@
+-- Audit trail of roster players pseudonymized or erased for data subject
+-- requests. Rows name players by ID only and are kept after the player is gone.
+CREATE TABLE IF NOT EXISTS player_erasures (
+    id           TEXT PRIMARY KEY,
+    player_id    TEXT NOT NULL,
+    mode         TEXT NOT NULL,
+    reason       TEXT NOT NULL DEFAULT '',
+    requested_by TEXT NOT NULL DEFAULT '',
+    match_ids    TEXT[] NOT NULL DEFAULT '{}',
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_player_erasures_player
+    ON player_erasures (player_id, created_at DESC);
@@ -0,0 +1,13 @@
+-- Deleted matches whose stored files are removed once the grace period
+-- ends. A row is removed when the files are purged or the deletion is undone.
+CREATE TABLE IF NOT EXISTS video_purges (
+    video_id    TEXT PRIMARY KEY,
+    title       TEXT NOT NULL DEFAULT '',
+    paths       TEXT[] NOT NULL DEFAULT '{}',
+    deleted_by  TEXT NOT NULL DEFAULT '',
+    deleted_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    purge_after TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_video_purges_due
+    ON video_purges (purge_after);
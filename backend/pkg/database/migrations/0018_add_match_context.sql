@@ -0,0 +1,12 @@
+-- Match context shown in reports: venue, referee, attendance, weather and
+-- the kickoff time with the venue's time zone. kickoff_at stays NULL when
+-- the kickoff is unknown.
+ALTER TABLE videos ADD COLUMN IF NOT EXISTS venue TEXT NOT NULL DEFAULT '';
+ALTER TABLE videos ADD COLUMN IF NOT EXISTS referee TEXT NOT NULL DEFAULT '';
+ALTER TABLE videos ADD COLUMN IF NOT EXISTS attendance INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE videos ADD COLUMN IF NOT EXISTS weather TEXT NOT NULL DEFAULT '';
+ALTER TABLE videos ADD COLUMN IF NOT EXISTS kickoff_at TIMESTAMPTZ;
+ALTER TABLE videos ADD COLUMN IF NOT EXISTS kickoff_timezone TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_videos_venue ON videos (venue) WHERE deleted_at IS NULL AND venue <> '';
+CREATE INDEX IF NOT EXISTS idx_videos_referee ON videos (referee) WHERE deleted_at IS NULL AND referee <> '';
@@ -0,0 +1,15 @@
+-- nivai:no-transaction
+-- Indexes of the video filters and orders of the Find* queries, built
+-- CONCURRENTLY so writes to videos go on while they build. That cannot run
+-- in a transaction, so the runner applies these statements one at a time.
+-- A build that fails leaves an INVALID index which IF NOT EXISTS would keep:
+-- drop it (DROP INDEX CONCURRENTLY) before the migration runs again.
+-- Every query skips soft-deleted rows, so the default list order leads with deleted_at.
+-- The team filter matches either side: home_team leads the composite index
+-- and away_team has its own, so both sides of the OR are index lookups.
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_videos_deleted_created ON videos (deleted_at, created_at DESC);
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_videos_match_id ON videos (match_id);
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_videos_teams ON videos (home_team, away_team);
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_videos_away_team ON videos (away_team);
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_videos_processing_state ON videos (processing_state);
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_videos_match_date ON videos (match_date DESC);
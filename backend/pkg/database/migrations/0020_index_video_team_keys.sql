@@ -0,0 +1,7 @@
+-- nivai:no-transaction
+-- Indexes of the teams in lower case, which access scopes match
+-- case-insensitively with LOWER(home_team) and LOWER(away_team): the plain
+-- team indexes of 0019 cannot serve those. Built CONCURRENTLY like 0019;
+-- drop an INVALID index left by a failed build before running it again.
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_videos_home_team_key ON videos (LOWER(home_team));
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_videos_away_team_key ON videos (LOWER(away_team));
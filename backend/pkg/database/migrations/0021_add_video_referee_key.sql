@@ -0,0 +1,7 @@
+-- Blind index of the referee, set when referees are stored encrypted: the
+-- referee filter and the referee reference data match on it, since the
+-- encrypted values differ for every match. Rows stored in plain text keep
+-- it empty, so the partial index starts out empty.
+ALTER TABLE videos ADD COLUMN IF NOT EXISTS referee_key TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_videos_referee_key ON videos (referee_key) WHERE deleted_at IS NULL AND referee_key <> '';
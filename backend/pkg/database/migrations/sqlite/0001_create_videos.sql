@@ -0,0 +1,25 @@
+-- Videos table of SQLite deployments, mirroring the PostgreSQL schema.
+-- Timestamps are stored as text in UTC, so they sort and compare correctly.
+CREATE TABLE IF NOT EXISTS videos (
+    id               TEXT PRIMARY KEY,
+    title            TEXT NOT NULL DEFAULT '',
+    description      TEXT NOT NULL DEFAULT '',
+    file_path        TEXT NOT NULL DEFAULT '',
+    storage_provider TEXT NOT NULL DEFAULT '',
+    duration         REAL NOT NULL DEFAULT 0,
+    resolution       TEXT NOT NULL DEFAULT '',
+    format           TEXT NOT NULL DEFAULT '',
+    size             INTEGER NOT NULL DEFAULT 0,
+    processing_state TEXT NOT NULL DEFAULT 'pending',
+    created_at       DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at       DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at       DATETIME,
+    match_id         TEXT NOT NULL DEFAULT '',
+    match_date       DATETIME NOT NULL DEFAULT '0001-01-01 00:00:00+00:00',
+    home_team        TEXT NOT NULL DEFAULT '',
+    away_team        TEXT NOT NULL DEFAULT '',
+    competition      TEXT NOT NULL DEFAULT '',
+    season           TEXT NOT NULL DEFAULT '',
+    tracking_path    TEXT NOT NULL DEFAULT '',
+    event_file_path  TEXT NOT NULL DEFAULT ''
+);
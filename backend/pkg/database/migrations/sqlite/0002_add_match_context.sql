@@ -0,0 +1,7 @@
+-- Match context shown in reports, mirroring PostgreSQL migration 0018.
+ALTER TABLE videos ADD COLUMN venue TEXT NOT NULL DEFAULT '';
+ALTER TABLE videos ADD COLUMN referee TEXT NOT NULL DEFAULT '';
+ALTER TABLE videos ADD COLUMN attendance INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE videos ADD COLUMN weather TEXT NOT NULL DEFAULT '';
+ALTER TABLE videos ADD COLUMN kickoff_at DATETIME;
+ALTER TABLE videos ADD COLUMN kickoff_timezone TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,11 @@
+-- Indexes of the video filters, mirroring PostgreSQL migration 0019, but for
+-- the default list order. SQLite's statistics do not tell NULLs apart from
+-- other values, so an index leading with deleted_at makes "deleted_at IS
+-- NULL" look selective and is preferred over the index of the actual
+-- filter. A partial index over the rows not deleted serves the same order.
+CREATE INDEX IF NOT EXISTS idx_videos_created_not_deleted ON videos (created_at DESC) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_videos_match_id ON videos (match_id);
+CREATE INDEX IF NOT EXISTS idx_videos_teams ON videos (home_team, away_team);
+CREATE INDEX IF NOT EXISTS idx_videos_away_team ON videos (away_team);
+CREATE INDEX IF NOT EXISTS idx_videos_processing_state ON videos (processing_state);
+CREATE INDEX IF NOT EXISTS idx_videos_match_date ON videos (match_date DESC);
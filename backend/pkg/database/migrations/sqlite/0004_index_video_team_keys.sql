@@ -0,0 +1,4 @@
+-- Indexes of the teams in lower case, mirroring PostgreSQL migration 0020,
+-- for the case-insensitive team matches of access scopes.
+CREATE INDEX IF NOT EXISTS idx_videos_home_team_key ON videos (LOWER(home_team));
+CREATE INDEX IF NOT EXISTS idx_videos_away_team_key ON videos (LOWER(away_team));
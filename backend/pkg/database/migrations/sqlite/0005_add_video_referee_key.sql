@@ -0,0 +1,2 @@
+-- Blind index of the referee, mirroring PostgreSQL migration 0021.
+ALTER TABLE videos ADD COLUMN referee_key TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,128 @@
+package database
+
+import (
+	"database/sql"
+	"sync"
+	"time"
+)
+
+/**
+ * PoolConfig sizes a connection pool. Zero values keep database/sql's
+ * defaults.
+ */
+type PoolConfig struct {
+	MaxOpenConns    int           // Connections open at once, in use or idle
+	MaxIdleConns    int           // Idle connections kept for reuse
+	ConnMaxLifetime time.Duration // Connections are replaced after this age
+	ConnMaxIdleTime time.Duration // Idle connections are closed after this time
+}
+
+/**
+ * ConfigurePool applies a pool configuration to a database connection.
+ *
+ * @param db Database connection
+ * @param cfg The pool configuration
+ */
+func ConfigurePool(db *sql.DB, cfg PoolConfig) {
+	if cfg.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(cfg.MaxOpenConns)
+	}
+	if cfg.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(cfg.MaxIdleConns)
+	}
+	if cfg.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	}
+	if cfg.ConnMaxIdleTime > 0 {
+		db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+	}
+}
+
+/**
+ * PoolStats reports the state of a connection pool. Exhausted pools have
+ * every allowed connection in use; a growing WaitCount means queries are
+ * queueing for a connection.
+ */
+type PoolStats struct {
+	MaxOpenConnections int     `json:"max_open_connections"` // 0 is unlimited
+	OpenConnections    int     `json:"open_connections"`
+	InUse              int     `json:"in_use"`
+	Idle               int     `json:"idle"`
+	Exhausted          bool    `json:"exhausted"`
+	WaitCount          int64   `json:"wait_count"`
+	WaitDurationMs     float64 `json:"wait_duration_ms"`
+	MaxIdleClosed      int64   `json:"max_idle_closed"`
+	MaxIdleTimeClosed  int64   `json:"max_idle_time_closed"`
+	MaxLifetimeClosed  int64   `json:"max_lifetime_closed"`
+}
+
+/**
+ * Pools keeps the application's connection pools by name ("primary",
+ * "replica-1", ...) so their statistics can be reported together.
+ */
+type Pools struct {
+	mu    sync.Mutex
+	names []string
+	dbs   map[string]*sql.DB
+}
+
+/**
+ * NewPools creates an empty set of connection pools.
+ *
+ * @return A new set of pools
+ */
+func NewPools() *Pools {
+	return &Pools{dbs: map[string]*sql.DB{}}
+}
+
+/**
+ * Add registers a connection pool under a name.
+ *
+ * @param name The name the pool is reported under
+ * @param db Database connection
+ */
+func (p *Pools) Add(name string, db *sql.DB) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.dbs[name]; !ok {
+		p.names = append(p.names, name)
+	}
+	p.dbs[name] = db
+}
+
+/**
+ * Stats reports the statistics of every registered pool.
+ *
+ * @return The statistics by pool name
+ */
+func (p *Pools) Stats() map[string]PoolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	stats := make(map[string]PoolStats, len(p.dbs))
+	for _, name := range p.names {
+		stats[name] = Stats(p.dbs[name])
+	}
+	return stats
+}
+
+/**
+ * Stats reports the statistics of one connection pool.
+ *
+ * @param db Database connection
+ * @return The pool statistics
+ */
+func Stats(db *sql.DB) PoolStats {
+	s := db.Stats()
+	return PoolStats{
+		MaxOpenConnections: s.MaxOpenConnections,
+		OpenConnections:    s.OpenConnections,
+		InUse:              s.InUse,
+		Idle:               s.Idle,
+		Exhausted:          s.MaxOpenConnections > 0 && s.InUse >= s.MaxOpenConnections,
+		WaitCount:          s.WaitCount,
+		WaitDurationMs:     float64(s.WaitDuration) / float64(time.Millisecond),
+		MaxIdleClosed:      s.MaxIdleClosed,
+		MaxIdleTimeClosed:  s.MaxIdleTimeClosed,
+		MaxLifetimeClosed:  s.MaxLifetimeClosed,
+	}
+}
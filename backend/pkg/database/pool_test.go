@@ -0,0 +1,36 @@
+package database_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"nivai/backend/pkg/database"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPools(t *testing.T) {
+	db, _, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	database.ConfigurePool(db, database.PoolConfig{MaxOpenConns: 1, MaxIdleConns: 1, ConnMaxLifetime: time.Hour})
+	pools := database.NewPools()
+	pools.Add("primary", db)
+
+	stats := pools.Stats()["primary"]
+	assert.Equal(t, 1, stats.MaxOpenConnections)
+	assert.False(t, stats.Exhausted)
+
+	// Holding the only connection exhausts the pool
+	conn, err := db.Conn(context.Background())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	stats = pools.Stats()["primary"]
+	assert.Equal(t, 1, stats.InUse)
+	assert.True(t, stats.Exhausted)
+}
@@ -0,0 +1,280 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"log"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// maxLoggedQuery is how much of a slow query's SQL is logged
+const maxLoggedQuery = 500
+
+// queryBuckets are the histogram buckets of query durations, in seconds
+var queryBuckets = []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+/**
+ * QueryTimer times the queries of the connections it opens. Each query is
+ * attributed to the repository method that ran it, found on the call stack
+ * (e.g. "models.postgresVideoRepository.FindByQuery"); its duration goes
+ * to a histogram per method and pool, and queries slower than the threshold
+ * are logged with their SQL, never their parameters. Queries returning rows
+ * are timed until the first rows arrive, not until they are read.
+ */
+type QueryTimer struct {
+	slow      time.Duration
+	durations *prometheus.HistogramVec
+}
+
+/**
+ * NewQueryTimer creates a query timer and registers its histogram
+ * (nivai_repository_query_duration_seconds) with registerer.
+ *
+ * @param slow Queries slower than this are logged; 0 logs none
+ * @param registerer Where the histogram is registered; nil registers none
+ * @return A new query timer or error
+ */
+func NewQueryTimer(slow time.Duration, registerer prometheus.Registerer) (*QueryTimer, error) {
+	durations := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "nivai_repository_query_duration_seconds",
+		Help:    "Duration of database queries by repository method and connection pool.",
+		Buckets: queryBuckets,
+	}, []string{"method", "pool"})
+	if registerer != nil {
+		if err := registerer.Register(durations); err != nil {
+			return nil, err
+		}
+	}
+	return &QueryTimer{slow: slow, durations: durations}, nil
+}
+
+/**
+ * Open opens a database like sql.Open, with every query timed. A nil timer
+ * opens it untimed.
+ *
+ * @param driverName The registered driver, e.g. "postgres"
+ * @param dsn The data source name
+ * @param pool The pool name queries are reported under ("primary", ...)
+ * @return The database or error
+ */
+func (t *QueryTimer) Open(driverName, dsn, pool string) (*sql.DB, error) {
+	db, err := sql.Open(driverName, dsn)
+	if err != nil || t == nil {
+		return db, err
+	}
+	// sql.Open only looked the driver up; nothing is connected yet
+	d := db.Driver()
+	db.Close()
+
+	var connector driver.Connector = dsnConnector{dsn: dsn, driver: d}
+	if dc, ok := d.(driver.DriverContext); ok {
+		if connector, err = dc.OpenConnector(dsn); err != nil {
+			return nil, err
+		}
+	}
+	return sql.OpenDB(&timedConnector{Connector: connector, timer: t, pool: pool}), nil
+}
+
+// observe records the duration of a query that started at start.
+func (t *QueryTimer) observe(pool, query string, args int, start time.Time) {
+	elapsed := time.Since(start)
+	method := callerMethod()
+	t.durations.WithLabelValues(method, pool).Observe(elapsed.Seconds())
+	if t.slow > 0 && elapsed >= t.slow {
+		log.Printf("Slow query in %s on %s took %s: %s [%d parameters redacted]",
+			method, pool, elapsed.Round(time.Millisecond), compactQuery(query), args)
+	}
+}
+
+var (
+	whitespace  = regexp.MustCompile(`\s+`)
+	closureName = regexp.MustCompile(`(\.func\d+)+$`)
+)
+
+// compactQuery puts a query on one line, cut at maxLoggedQuery characters.
+func compactQuery(query string) string {
+	query = strings.TrimSpace(whitespace.ReplaceAllString(query, " "))
+	if len(query) > maxLoggedQuery {
+		query = query[:maxLoggedQuery] + "..."
+	}
+	return query
+}
+
+// callerMethod names the function of this module that ran a query: the
+// first caller outside database/sql, the drivers and this file's wrappers,
+// as "package.Type.Method".
+func callerMethod() string {
+	pcs := make([]uintptr, 64)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+	for {
+		frame, more := frames.Next()
+		name, ok := strings.CutPrefix(frame.Function, "nivai/backend/pkg/")
+		if ok && !strings.HasPrefix(name, "database.(*timed") && !strings.HasPrefix(name, "database.(*QueryTimer)") {
+			name = strings.NewReplacer("(*", "", ")", "").Replace(name)
+			return closureName.ReplaceAllString(name, "")
+		}
+		if !more {
+			return "unknown"
+		}
+	}
+}
+
+// dsnConnector opens connections of drivers without their own connector.
+type dsnConnector struct {
+	dsn    string
+	driver driver.Driver
+}
+
+func (c dsnConnector) Connect(context.Context) (driver.Conn, error) { return c.driver.Open(c.dsn) }
+func (c dsnConnector) Driver() driver.Driver                        { return c.driver }
+
+// timedConnector opens timed connections.
+type timedConnector struct {
+	driver.Connector
+	timer *QueryTimer
+	pool  string
+}
+
+func (c *timedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &timedConn{Conn: conn, connector: c}, nil
+}
+
+// timedConn times the queries run on a connection, also those in
+// transactions and prepared statements. Optional interfaces the driver's
+// connection lacks fall back the way database/sql would.
+type timedConn struct {
+	driver.Conn
+	connector *timedConnector
+}
+
+func (c *timedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	rows, err := queryer.QueryContext(ctx, query, args)
+	if !errors.Is(err, driver.ErrSkip) {
+		c.connector.timer.observe(c.connector.pool, query, len(args), start)
+	}
+	return rows, err
+}
+
+func (c *timedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	result, err := execer.ExecContext(ctx, query, args)
+	if !errors.Is(err, driver.ErrSkip) {
+		c.connector.timer.observe(c.connector.pool, query, len(args), start)
+	}
+	return result, err
+}
+
+func (c *timedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var stmt driver.Stmt
+	var err error
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		stmt, err = preparer.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.Conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &timedStmt{Stmt: stmt, conn: c, query: query}, nil
+}
+
+func (c *timedConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *timedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	if opts.Isolation != driver.IsolationLevel(sql.LevelDefault) || opts.ReadOnly {
+		return nil, errors.New("database: driver does not support transaction options")
+	}
+	return c.Conn.Begin()
+}
+
+func (c *timedConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *timedConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *timedConn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+// timedStmt times the executions of a prepared statement.
+type timedStmt struct {
+	driver.Stmt
+	conn  *timedConn
+	query string
+}
+
+func (s *timedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	start := time.Now()
+	defer s.conn.connector.timer.observe(s.conn.connector.pool, s.query, len(args), start)
+	if queryer, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		return queryer.QueryContext(ctx, args)
+	}
+	values, err := namedValues(args)
+	if err != nil {
+		return nil, err
+	}
+	return s.Stmt.Query(values)
+}
+
+func (s *timedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	start := time.Now()
+	defer s.conn.connector.timer.observe(s.conn.connector.pool, s.query, len(args), start)
+	if execer, ok := s.Stmt.(driver.StmtExecContext); ok {
+		return execer.ExecContext(ctx, args)
+	}
+	values, err := namedValues(args)
+	if err != nil {
+		return nil, err
+	}
+	return s.Stmt.Exec(values)
+}
+
+// namedValues converts arguments for drivers without context support,
+// which take no named parameters.
+func namedValues(args []driver.NamedValue) ([]driver.Value, error) {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		if arg.Name != "" {
+			return nil, errors.New("database: driver does not support named parameters")
+		}
+		values[i] = arg.Value
+	}
+	return values, nil
+}
@@ -0,0 +1,65 @@
+package database_test
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	"nivai/backend/pkg/database"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryTimer(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	timer, err := database.NewQueryTimer(time.Nanosecond, registry)
+	require.NoError(t, err)
+	db, err := timer.Open("sqlite3", ":memory:", "primary")
+	require.NoError(t, err)
+	defer db.Close()
+	db.SetMaxOpenConns(1) // One in-memory database
+
+	var out bytes.Buffer
+	log.SetOutput(&out)
+	defer log.SetOutput(os.Stderr)
+
+	_, err = db.Exec("CREATE TABLE clubs (id INTEGER PRIMARY KEY,\n\t name TEXT)")
+	require.NoError(t, err)
+	_, err = db.Exec("INSERT INTO clubs (name) VALUES (?)", "Secret FC")
+	require.NoError(t, err)
+	stmt, err := db.Prepare("SELECT name FROM clubs WHERE id = ?")
+	require.NoError(t, err)
+	defer stmt.Close()
+	var name string
+	require.NoError(t, stmt.QueryRow(1).Scan(&name))
+	assert.Equal(t, "Secret FC", name)
+
+	t.Run("Slow queries are logged without their parameters", func(t *testing.T) {
+		logged := out.String()
+		assert.Contains(t, logged, "Slow query in database_test.TestQueryTimer on primary")
+		assert.Contains(t, logged, "CREATE TABLE clubs (id INTEGER PRIMARY KEY, name TEXT)")
+		assert.Contains(t, logged, "INSERT INTO clubs (name) VALUES (?) [1 parameters redacted]")
+		assert.Contains(t, logged, "SELECT name FROM clubs WHERE id = ?")
+		assert.NotContains(t, logged, "Secret FC")
+	})
+
+	t.Run("Durations are exported per method and pool", func(t *testing.T) {
+		families, err := registry.Gather()
+		require.NoError(t, err)
+		require.Len(t, families, 1)
+		assert.Equal(t, "nivai_repository_query_duration_seconds", families[0].GetName())
+		require.Len(t, families[0].GetMetric(), 1)
+		histogram := families[0].GetMetric()[0]
+		labels := map[string]string{}
+		for _, label := range histogram.GetLabel() {
+			labels[label.GetName()] = label.GetValue()
+		}
+		assert.Equal(t, map[string]string{"method": "database_test.TestQueryTimer", "pool": "primary"}, labels)
+		assert.Equal(t, uint64(3), histogram.GetHistogram().GetSampleCount())
+	})
+}
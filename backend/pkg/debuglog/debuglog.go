@@ -0,0 +1,393 @@
+// Package debuglog logs sampled HTTP request and response bodies, to
+// diagnose contract mismatches with integrations such as the Python
+// analytics service, e.g. a renamed JSON key. Bodies are cut at a size limit
+// and secrets are redacted before they are logged; binary bodies are logged
+// by size and type only.
+//
+// Sampling is opt-in: the sample percentage is zero unless configured, and
+// the lines are logged at debug level, so nothing is captured while the log
+// level is above debug.
+package debuglog
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"nivai/backend/pkg/logging"
+	"nivai/backend/pkg/requestctx"
+	"nivai/backend/pkg/support"
+)
+
+// DefaultMaxBodyBytes is the body length logged when Settings leave it unset
+const DefaultMaxBodyBytes = 4096
+
+// Settings configure a sampler
+type Settings struct {
+	SamplePercent int // Share of the exchanges logged, 0 to 100
+	MaxBodyBytes  int // Longest body logged; longer bodies are cut
+}
+
+// Sampler decides which exchanges are logged and logs them. It is safe for
+// concurrent use, and a nil sampler logs nothing.
+type Sampler struct {
+	name     string // Logged with each line, e.g. "api" or "python_api"
+	settings atomic.Pointer[Settings]
+	logger   *log.Logger
+	roll     func() int // Returns 0 to 99
+}
+
+// New creates a sampler logging to logger, or the standard logger when nil
+func New(name string, settings Settings, logger *log.Logger) *Sampler {
+	if logger == nil {
+		logger = log.Default()
+	}
+	s := &Sampler{name: name, logger: logger, roll: func() int { return rand.IntN(100) }}
+	s.SetSettings(settings)
+	return s
+}
+
+// SetSettings replaces the settings, e.g. on a configuration reload
+func (s *Sampler) SetSettings(settings Settings) {
+	if settings.MaxBodyBytes <= 0 {
+		settings.MaxBodyBytes = DefaultMaxBodyBytes
+	}
+	s.settings.Store(&settings)
+}
+
+// sample reports whether to log the next exchange, returning the body limit
+func (s *Sampler) sample() (int, bool) {
+	if s == nil || logging.CurrentLevel() > logging.LevelDebug {
+		return 0, false
+	}
+	settings := s.settings.Load()
+	if settings.SamplePercent <= 0 || s.roll() >= settings.SamplePercent {
+		return 0, false
+	}
+	return settings.MaxBodyBytes, true
+}
+
+// Middleware logs sampled requests to the API with their responses. It must
+// run after requestctx is set up, so lines carry the request ID. WebSocket
+// upgrades are never sampled.
+func (s *Sampler) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limit, ok := s.sample()
+		if !ok || r.Header.Get("Upgrade") != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		start := time.Now()
+		request := &capture{ReadCloser: r.Body, limit: limit}
+		if r.Body != nil && r.Body != http.NoBody {
+			r.Body = request
+		}
+		response := &captureWriter{ResponseWriter: w, status: http.StatusOK, body: capture{limit: limit}}
+		next.ServeHTTP(response, r)
+
+		s.log(exchange{
+			requestID: requestctx.From(r).RequestID,
+			method:    r.Method,
+			url:       r.URL,
+			status:    response.status,
+			duration:  time.Since(start),
+			reqHeader: r.Header, reqBody: request,
+			respHeader: w.Header(), respBody: &response.body,
+		})
+	})
+}
+
+// Transport returns a round tripper logging sampled calls made through next,
+// or http.DefaultTransport when next is nil. A call is logged once its
+// response body is closed, with the part of the body that was read.
+func (s *Sampler) Transport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return roundTripper{sampler: s, next: next}
+}
+
+type roundTripper struct {
+	sampler *Sampler
+	next    http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper
+func (t roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	limit, ok := t.sampler.sample()
+	if !ok {
+		return t.next.RoundTrip(req)
+	}
+
+	start := time.Now()
+	request := &capture{limit: limit}
+	if req.GetBody != nil {
+		// The transport consumes req.Body; log from a copy
+		if body, err := req.GetBody(); err == nil {
+			_, _ = io.Copy(io.Discard, &capture{ReadCloser: body, limit: limit, into: request})
+			body.Close()
+		}
+	}
+	e := exchange{
+		requestID: requestctx.FromContext(req.Context()).RequestID,
+		method:    req.Method,
+		url:       req.URL,
+		reqHeader: req.Header, reqBody: request,
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		e.duration, e.err = time.Since(start), err
+		t.sampler.log(e)
+		return nil, err
+	}
+	e.status, e.respHeader = resp.StatusCode, resp.Header
+	response := &capture{ReadCloser: resp.Body, limit: limit}
+	response.onClose = func() {
+		e.duration, e.respBody = time.Since(start), response
+		t.sampler.log(e)
+	}
+	resp.Body = response
+	return resp, nil
+}
+
+// exchange is a request with its response, as logged
+type exchange struct {
+	requestID  string
+	method     string
+	url        *url.URL
+	status     int
+	duration   time.Duration
+	err        error
+	reqHeader  http.Header
+	reqBody    *capture
+	respHeader http.Header
+	respBody   *capture
+}
+
+// log writes one line per exchange
+func (s *Sampler) log(e exchange) {
+	requestID := e.requestID
+	if requestID == "" {
+		requestID = "unknown"
+	}
+	outcome := fmt.Sprintf("%d", e.status)
+	if e.err != nil {
+		outcome = "error " + e.err.Error()
+	}
+	line := fmt.Sprintf("Debug: [%s] %s sample %s %s %s in %s; request %s%s",
+		requestID, s.name, e.method, redactURL(e.url), outcome, e.duration.Round(time.Millisecond),
+		headers(e.reqHeader), body(e.reqHeader, e.reqBody))
+	if e.err == nil {
+		line += fmt.Sprintf("; response %s%s", headers(e.respHeader), body(e.respHeader, e.respBody))
+	}
+	s.logger.Print(line)
+}
+
+// capture keeps the first limit bytes read through it, counting the rest
+type capture struct {
+	io.ReadCloser
+	limit   int
+	data    bytes.Buffer
+	total   int64
+	into    *capture // Keep the bytes there instead
+	onClose func()
+}
+
+// Read implements io.Reader
+func (c *capture) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.keep(p[:n])
+	return n, err
+}
+
+// Close implements io.Closer, calling onClose once
+func (c *capture) Close() error {
+	err := c.ReadCloser.Close()
+	if c.onClose != nil {
+		c.onClose()
+		c.onClose = nil
+	}
+	return err
+}
+
+// keep records p
+func (c *capture) keep(p []byte) {
+	target := c
+	if c.into != nil {
+		target = c.into
+	}
+	target.total += int64(len(p))
+	if room := target.limit - target.data.Len(); room > 0 {
+		target.data.Write(p[:min(room, len(p))])
+	}
+}
+
+// captureWriter passes a response through while capturing its status and
+// the start of its body
+type captureWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        capture
+}
+
+// WriteHeader records the status
+func (w *captureWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status, w.wroteHeader = status, true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write records the start of the body
+func (w *captureWriter) Write(p []byte) (int, error) {
+	w.wroteHeader = true
+	w.body.keep(p)
+	return w.ResponseWriter.Write(p)
+}
+
+// Flush passes flushes through, so streamed responses keep streaming
+func (w *captureWriter) Flush() {
+	w.wroteHeader = true
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap returns the embedded ResponseWriter so http.ResponseController can
+// reach its deadline methods
+func (w *captureWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// sensitiveHeaders are redacted besides headers with a secret-like name
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+}
+
+// headers renders headers in name order with secrets redacted
+func headers(h http.Header) string {
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		value := strings.Join(h[name], ", ")
+		if sensitiveHeaders[http.CanonicalHeaderKey(name)] || secretField(name) {
+			value = support.Redacted
+		}
+		parts = append(parts, name+": "+value)
+	}
+	return "{" + strings.Join(parts, "; ") + "}"
+}
+
+// body renders a captured body: text with secrets redacted and cut at the
+// limit, other content by its size and type only
+func body(h http.Header, c *capture) string {
+	if c == nil || c.total == 0 {
+		return ""
+	}
+	contentType := h.Get("Content-Type")
+	size := fmt.Sprintf("%d bytes", c.total)
+	if int64(c.data.Len()) < c.total {
+		size = fmt.Sprintf("first %d of %d bytes", c.data.Len(), c.total)
+	}
+	if !isText(contentType) {
+		return fmt.Sprintf(" [%d bytes of %s]", c.total, contentType)
+	}
+
+	text := c.data.String()
+	if strings.HasPrefix(contentType, "application/x-www-form-urlencoded") {
+		text = redactForm(text)
+	} else {
+		text = redactJSON(text)
+	}
+	return fmt.Sprintf(" (%s) %s", size, text)
+}
+
+// isText reports whether a content type is logged as text
+func isText(contentType string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.TrimSpace(strings.ToLower(mediaType))
+	switch {
+	case mediaType == "", strings.HasPrefix(mediaType, "text/"), strings.HasSuffix(mediaType, "json"),
+		strings.HasSuffix(mediaType, "+json"), strings.HasSuffix(mediaType, "xml"),
+		mediaType == "application/x-www-form-urlencoded":
+		return true
+	}
+	return false
+}
+
+// secretValue matches a JSON string, number or literal field; a string cut
+// off at the limit matches up to the end
+var secretValue = regexp.MustCompile(`"((?:[^"\\]|\\.)*)"(\s*:\s*)("(?:[^"\\]|\\.)*(?:"|$)|-?[0-9][0-9.eE+-]*|true|false|null)`)
+
+// redactJSON replaces the values of secret-like fields. It works on cut off
+// bodies, which no longer parse.
+func redactJSON(text string) string {
+	return secretValue.ReplaceAllStringFunc(text, func(field string) string {
+		match := secretValue.FindStringSubmatch(field)
+		if !secretField(match[1]) {
+			return field
+		}
+		return `"` + match[1] + `"` + match[2] + `"` + support.Redacted + `"`
+	})
+}
+
+// redactForm replaces the values of secret-like form or query parameters
+func redactForm(text string) string {
+	pairs := strings.Split(text, "&")
+	for i, pair := range pairs {
+		key, _, found := strings.Cut(pair, "=")
+		if name, err := url.QueryUnescape(key); found && err == nil && secretField(name) {
+			pairs[i] = key + "=" + url.QueryEscape(support.Redacted)
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// redactURL renders a URL without secret query parameters, such as the
+// signatures of signed storage URLs
+func redactURL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	redacted := *u
+	redacted.User = nil
+	redacted.RawQuery = redactForm(u.RawQuery)
+	return redacted.String()
+}
+
+// secretWords mark secrets in field names besides the configuration's
+// secret keys, including camelCase names such as accessToken
+var secretWords = []string{"password", "secret", "token", "apikey", "api_key", "authorization", "signature", "credential"}
+
+// secretField reports whether a JSON field, parameter or header names a secret
+func secretField(name string) bool {
+	if support.IsSecretKey(name) {
+		return true
+	}
+	lower := strings.ToLower(name)
+	for _, word := range secretWords {
+		if strings.Contains(lower, word) {
+			return true
+		}
+	}
+	return lower == "sig"
+}
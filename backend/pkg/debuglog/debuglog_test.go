@@ -0,0 +1,108 @@
+package debuglog_test
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"nivai/backend/pkg/debuglog"
+	"nivai/backend/pkg/logging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// debugLevel enables debug lines for the duration of a test
+func debugLevel(t *testing.T) {
+	previous := logging.CurrentLevel()
+	logging.SetLevel(logging.LevelDebug)
+	t.Cleanup(func() { logging.SetLevel(previous) })
+}
+
+func TestSampler_Middleware(t *testing.T) {
+	debugLevel(t)
+	var out bytes.Buffer
+	sampler := debuglog.New("api", debuglog.Settings{SamplePercent: 100, MaxBodyBytes: 80}, log.New(&out, "", 0))
+	handler := sampler.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id":"` + strings.Repeat("x", 100) + `"}`))
+	}))
+	serve := func(req *http.Request) string {
+		out.Reset()
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		return out.String()
+	}
+
+	t.Run("Bodies are logged with secrets redacted and cut at the limit", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/api/v1/videos?sig=abc&team=Ajax", strings.NewReader(`{"apiKey":7,"password":"hunter2","tracking_data_path":"a.parquet"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer token")
+		line := serve(req)
+
+		assert.True(t, strings.HasPrefix(line, "Debug: [unknown] api sample POST /api/v1/videos?sig=%5BREDACTED%5D&team=Ajax 201 in "), line)
+		assert.Contains(t, line, `"tracking_data_path":"a.parquet"`)
+		assert.Contains(t, line, `"password":"[REDACTED]"`)
+		assert.Contains(t, line, `"apiKey":"[REDACTED]"`)
+		assert.Contains(t, line, "Authorization: [REDACTED]")
+		assert.NotContains(t, line, "hunter2")
+		assert.Contains(t, line, "(66 bytes)")
+		assert.Contains(t, line, "(first 80 of 109 bytes)")
+	})
+
+	t.Run("Binary bodies are logged by size", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/api/v1/videos", strings.NewReader("\x00\x00\x00 ftypmp42"))
+		req.Header.Set("Content-Type", "video/mp4")
+		assert.Contains(t, serve(req), "request {Content-Type: video/mp4} [12 bytes of video/mp4]")
+	})
+
+	t.Run("Nothing is logged unless sampled at debug level", func(t *testing.T) {
+		sampler.SetSettings(debuglog.Settings{SamplePercent: 0})
+		assert.Empty(t, serve(httptest.NewRequest("GET", "/api/v1/videos", nil)))
+
+		sampler.SetSettings(debuglog.Settings{SamplePercent: 100})
+		logging.SetLevel(logging.LevelInfo)
+		assert.Empty(t, serve(httptest.NewRequest("GET", "/api/v1/videos", nil)))
+		logging.SetLevel(logging.LevelDebug)
+
+		var none *debuglog.Sampler
+		rr := httptest.NewRecorder()
+		none.Middleware(http.NotFoundHandler()).ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
+
+func TestSampler_Transport(t *testing.T) {
+	debugLevel(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		http.Error(w, `{"detail":"missing event_data_path"}`, http.StatusUnprocessableEntity)
+	}))
+	defer server.Close()
+
+	var out bytes.Buffer
+	sampler := debuglog.New("python_api", debuglog.Settings{SamplePercent: 100}, log.New(&out, "", 0))
+	client := &http.Client{Transport: sampler.Transport(nil)}
+
+	resp, err := client.Post(server.URL+"/process-match", "application/json", strings.NewReader(`{"match_id":"m1","tracking_data_path":"t.parquet"}`))
+	require.NoError(t, err)
+	assert.Empty(t, out.String(), "logged once the body is closed")
+	_, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	line := out.String()
+	assert.Contains(t, line, "python_api sample POST "+server.URL+"/process-match 422 in ")
+	assert.Contains(t, line, `request {Content-Type: application/json} (50 bytes) {"match_id":"m1","tracking_data_path":"t.parquet"}`)
+	assert.Contains(t, line, `{"detail":"missing event_data_path"}`)
+
+	out.Reset()
+	server.Close()
+	_, err = client.Get(server.URL + "/")
+	require.Error(t, err)
+	assert.Contains(t, out.String(), "python_api sample GET "+server.URL+"/ error ")
+}
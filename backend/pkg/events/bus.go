@@ -0,0 +1,150 @@
+package events
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Event types emitted by the platform. These names are part of the public
+// webhook contract, so existing values must not be renamed.
+const (
+	VideoUploaded      = "video.uploaded"
+	VideoDeleted       = "video.deleted"
+	AnalyticsCompleted = "analytics.completed"
+	AnalyticsFailed    = "analytics.failed"
+	// VideoRejected fires when an upload is quarantined because malware
+	// was found in one of its files.
+	VideoRejected = "video.rejected"
+	// AnalyticsRequested fires when a match is sent for processing again;
+	// AnalyticsCancelled when its processing is aborted.
+	AnalyticsRequested = "analytics.requested"
+	AnalyticsCancelled = "analytics.cancelled"
+	// AnalyticsStalled fires when a match has waited on the analytics
+	// service for longer than expected while the service still reports it
+	// pending.
+	AnalyticsStalled = "analytics.stalled"
+
+	// SLO alerts fire when an error budget burns too fast and resolve once
+	// the burn rate drops again.
+	SLOBurnRateAlert    = "slo.burn_rate_alert"
+	SLOBurnRateResolved = "slo.burn_rate_resolved"
+
+	// PlayerErased fires when a roster player is pseudonymized or erased,
+	// so integrators can erase their copies of the player's data.
+	PlayerErased = "player.erased"
+
+	// VideoRestored fires when the deletion of a match is undone within
+	// its grace period; VideoPurged when the grace period ended and the
+	// match's files were removed from storage.
+	VideoRestored = "video.restored"
+	VideoPurged   = "video.purged"
+
+	// WebhookDeliveryFailed fires when a webhook delivery is given up after
+	// its last attempt. It is internal and never sent to webhooks, so a
+	// failing endpoint is not sent its own failures.
+	WebhookDeliveryFailed = "webhook.delivery_failed"
+
+	// Wildcard subscribes a handler to every event type.
+	Wildcard = "*"
+)
+
+// knownTypes lists the event types integrators are allowed to subscribe to.
+var knownTypes = map[string]bool{
+	VideoUploaded:       true,
+	VideoDeleted:        true,
+	AnalyticsCompleted:  true,
+	AnalyticsFailed:     true,
+	VideoRejected:       true,
+	AnalyticsRequested:  true,
+	AnalyticsCancelled:  true,
+	AnalyticsStalled:    true,
+	SLOBurnRateAlert:    true,
+	SLOBurnRateResolved: true,
+	PlayerErased:        true,
+	VideoRestored:       true,
+	VideoPurged:         true,
+}
+
+// IsKnownType reports whether eventType is a subscribable event type.
+// The wildcard "*" is accepted as well.
+func IsKnownType(eventType string) bool {
+	return eventType == Wildcard || knownTypes[eventType]
+}
+
+// Event is a single domain event published on the bus.
+type Event struct {
+	ID         string                 `json:"id"`
+	Type       string                 `json:"type"`
+	OccurredAt time.Time              `json:"occurred_at"`
+	Data       map[string]interface{} `json:"data"`
+}
+
+// New creates an event of the given type with a fresh ID and timestamp.
+func New(eventType string, data map[string]interface{}) Event {
+	if data == nil {
+		data = map[string]interface{}{}
+	}
+	return Event{
+		ID:         uuid.New().String(),
+		Type:       eventType,
+		OccurredAt: time.Now().UTC(),
+		Data:       data,
+	}
+}
+
+// Handler processes a published event. Handlers run synchronously on the
+// publishing goroutine and should hand off slow work (e.g. network calls).
+type Handler func(Event)
+
+// Bus is a minimal in-process publish/subscribe event bus.
+// A nil *Bus is valid and silently drops published events, which lets
+// components publish unconditionally whether or not a bus is wired in.
+type Bus struct {
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+}
+
+// NewBus creates an empty event bus.
+func NewBus() *Bus {
+	return &Bus{handlers: make(map[string][]Handler)}
+}
+
+// Subscribe registers a handler for an event type, or for all events when
+// eventType is Wildcard.
+func (b *Bus) Subscribe(eventType string, h Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[eventType] = append(b.handlers[eventType], h)
+}
+
+// Publish delivers the event to all handlers subscribed to its type and to
+// all wildcard handlers. A panicking handler is logged and does not prevent
+// delivery to the remaining handlers.
+func (b *Bus) Publish(e Event) {
+	if b == nil {
+		return
+	}
+
+	b.mu.RLock()
+	handlers := make([]Handler, 0, len(b.handlers[e.Type])+len(b.handlers[Wildcard]))
+	handlers = append(handlers, b.handlers[e.Type]...)
+	handlers = append(handlers, b.handlers[Wildcard]...)
+	b.mu.RUnlock()
+
+	for _, h := range handlers {
+		b.invoke(h, e)
+	}
+}
+
+// invoke runs a single handler, recovering from panics.
+func (b *Bus) invoke(h Handler, e Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("events: handler for %s (%s) panicked: %v", e.Type, e.ID, r)
+		}
+	}()
+	h(e)
+}
@@ -0,0 +1,81 @@
+package export_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"testing"
+
+	"nivai/backend/pkg/export"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+)
+
+func TestFromEntities(t *testing.T) {
+	raw := json.RawMessage(`{
+		"10": {"total_distance_m": 9800.5, "zones": {"z1": 12, "z2": 3}},
+		"2":  {"total_distance_m": 10250, "max_speed_kmh": 31.2, "name": "=HYPERLINK(\"x\")", "splits": [1, 2]}
+	}`)
+
+	table, err := export.FromEntities("Players", "player_id", raw)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"player_id", "max_speed_kmh", "name", "splits", "total_distance_m", "zones.z1", "zones.z2"}, table.Columns)
+	require.Len(t, table.Rows, 2)
+	assert.Equal(t, []interface{}{"2", 31.2, `=HYPERLINK("x")`, "[1,2]", 10250.0, nil, nil}, table.Rows[0])
+	assert.Equal(t, []interface{}{"10", nil, nil, nil, 9800.5, 12.0, 3.0}, table.Rows[1])
+
+	empty, err := export.FromEntities("Teams", "team_id", json.RawMessage(`null`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"team_id"}, empty.Columns)
+	assert.Empty(t, empty.Rows)
+
+	_, err = export.FromEntities("Teams", "team_id", json.RawMessage(`[1, 2]`))
+	assert.Error(t, err)
+}
+
+func TestWriteCSV(t *testing.T) {
+	table := &export.Table{
+		Columns: []string{"player_id", "name", "total_distance_m"},
+		Rows: [][]interface{}{
+			{"7", "-1+1", 10250.0},
+			{"9", "Smith, J.", nil},
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, export.WriteCSV(&buf, table))
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"player_id", "name", "total_distance_m"},
+		{"7", "'-1+1", "10250"},
+		{"9", "Smith, J.", ""},
+	}, records)
+}
+
+func TestWriteXLSX(t *testing.T) {
+	players := &export.Table{Name: "Players", Columns: []string{"player_id", "total_distance_m"}, Rows: [][]interface{}{{"7", 10250.5}}}
+	teams := &export.Table{Name: "Teams/Home", Columns: []string{"team_id"}, Rows: [][]interface{}{{"home"}}}
+
+	var buf bytes.Buffer
+	require.NoError(t, export.WriteXLSX(&buf, players, teams))
+
+	book, err := excelize.OpenReader(&buf)
+	require.NoError(t, err)
+	defer book.Close()
+
+	assert.Equal(t, []string{"Players", "Teams_Home"}, book.GetSheetList())
+	rows, err := book.GetRows("Players")
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"player_id", "total_distance_m"}, {"7", "10250.5"}}, rows)
+
+	cellType, err := book.GetCellType("Players", "B2")
+	require.NoError(t, err)
+	assert.NotEqual(t, excelize.CellTypeSharedString, cellType, "numbers stay numeric")
+
+	assert.ErrorIs(t, export.WriteXLSX(&buf), export.ErrNoTables)
+}
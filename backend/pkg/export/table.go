@@ -0,0 +1,131 @@
+// Package export turns analytics payloads into tables and writes them as
+// spreadsheet downloads (CSV or XLSX).
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Supported export formats.
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// ContentTypes maps each export format to its media type.
+var ContentTypes = map[string]string{
+	FormatCSV:  "text/csv; charset=utf-8",
+	FormatXLSX: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// Table is a named, rectangular set of rows. Cells hold float64, string or
+// bool values, or nil for missing values.
+type Table struct {
+	Name    string
+	Columns []string
+	Rows    [][]interface{}
+}
+
+// FromEntities builds a table from a JSON object keyed by entity ID whose
+// values are objects of statistics, such as the players and teams of a match
+// summary. Each entity becomes a row, with its ID in the first column and one
+// column per statistic name in the union of all entities. Nested objects are
+// flattened into dotted column names; arrays are kept as JSON text.
+func FromEntities(name, idColumn string, raw json.RawMessage) (*Table, error) {
+	table := &Table{Name: name, Columns: []string{idColumn}}
+	if len(bytes.TrimSpace(raw)) == 0 || bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+		return table, nil
+	}
+
+	var entities map[string]map[string]interface{}
+	if err := json.Unmarshal(raw, &entities); err != nil {
+		return nil, fmt.Errorf("decoding %s: %w", name, err)
+	}
+
+	ids := make([]string, 0, len(entities))
+	flattened := make(map[string]map[string]interface{}, len(entities))
+	columnSet := map[string]bool{}
+	for id, stats := range entities {
+		ids = append(ids, id)
+		cells := map[string]interface{}{}
+		flatten("", stats, cells)
+		flattened[id] = cells
+		for column := range cells {
+			columnSet[column] = true
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return naturalLess(ids[i], ids[j]) })
+
+	columns := make([]string, 0, len(columnSet))
+	for column := range columnSet {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	table.Columns = append(table.Columns, columns...)
+
+	for _, id := range ids {
+		row := make([]interface{}, 0, len(table.Columns))
+		row = append(row, id)
+		for _, column := range columns {
+			row = append(row, flattened[id][column])
+		}
+		table.Rows = append(table.Rows, row)
+	}
+	return table, nil
+}
+
+// flatten copies the scalar values of an object into cells, joining the keys
+// of nested objects with dots.
+func flatten(prefix string, object map[string]interface{}, cells map[string]interface{}) {
+	for key, value := range object {
+		column := key
+		if prefix != "" {
+			column = prefix + "." + key
+		}
+		switch v := value.(type) {
+		case map[string]interface{}:
+			flatten(column, v, cells)
+		case []interface{}:
+			encoded, _ := json.Marshal(v)
+			cells[column] = string(encoded)
+		default:
+			cells[column] = v
+		}
+	}
+}
+
+// naturalLess orders IDs numerically when both are integers (so player 2
+// precedes player 10), and lexically otherwise.
+func naturalLess(a, b string) bool {
+	x, errA := strconv.Atoi(a)
+	y, errB := strconv.Atoi(b)
+	if errA == nil && errB == nil {
+		return x < y
+	}
+	return a < b
+}
+
+// cellText formats a cell for text output. Strings that spreadsheet
+// applications would evaluate as formulas are prefixed with a quote.
+func cellText(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case string:
+		if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+			return "'" + v
+		}
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,133 @@
+package export
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// ErrNoTables is returned when asked to write a workbook without sheets.
+var ErrNoTables = errors.New("export: no tables to write")
+
+// WriteCSV writes a table as CSV with a header row. Rows are written through
+// as they are produced, so large tables are not held in an output buffer.
+func WriteCSV(w io.Writer, table *Table) error {
+	out := csv.NewWriter(w)
+	if err := out.Write(table.Columns); err != nil {
+		return err
+	}
+	record := make([]string, len(table.Columns))
+	for _, row := range table.Rows {
+		for i := range record {
+			record[i] = ""
+			if i < len(row) {
+				record[i] = cellText(row[i])
+			}
+		}
+		if err := out.Write(record); err != nil {
+			return err
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// WriteXLSX writes the tables as the sheets of one workbook, in order. Sheets
+// are filled with excelize's stream writer, which spills rows to temporary
+// files instead of building the whole sheet in memory. Numbers stay numeric
+// cells so they can be charted and summed in Excel.
+func WriteXLSX(w io.Writer, tables ...*Table) error {
+	if len(tables) == 0 {
+		return ErrNoTables
+	}
+
+	book := excelize.NewFile()
+	defer book.Close()
+
+	bold, err := book.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		return err
+	}
+
+	for i, table := range tables {
+		sheet := sheetName(table.Name, i)
+		if i == 0 {
+			if err := book.SetSheetName("Sheet1", sheet); err != nil {
+				return err
+			}
+		} else if _, err := book.NewSheet(sheet); err != nil {
+			return err
+		}
+
+		stream, err := book.NewStreamWriter(sheet)
+		if err != nil {
+			return err
+		}
+		// Keep the header row visible while scrolling
+		if err := stream.SetPanes(&excelize.Panes{
+			Freeze: true, YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft",
+		}); err != nil {
+			return err
+		}
+		header := make([]interface{}, len(table.Columns))
+		for j, column := range table.Columns {
+			header[j] = excelize.Cell{StyleID: bold, Value: column}
+		}
+		if err := stream.SetRow("A1", header); err != nil {
+			return err
+		}
+		for r, row := range table.Rows {
+			cell, err := excelize.CoordinatesToCellName(1, r+2)
+			if err != nil {
+				return err
+			}
+			if err := stream.SetRow(cell, xlsxRow(row)); err != nil {
+				return err
+			}
+		}
+		if err := stream.Flush(); err != nil {
+			return err
+		}
+	}
+
+	_, err = book.WriteTo(w)
+	return err
+}
+
+// xlsxRow converts a table row to cell values, guarding strings against
+// formula evaluation the same way CSV cells are.
+func xlsxRow(row []interface{}) []interface{} {
+	cells := make([]interface{}, len(row))
+	for i, value := range row {
+		if s, ok := value.(string); ok {
+			cells[i] = cellText(s)
+		} else {
+			cells[i] = value
+		}
+	}
+	return cells
+}
+
+// sheetName returns a valid Excel sheet name for the i-th table:
+// at most 31 characters, without the characters Excel forbids.
+func sheetName(name string, i int) string {
+	cleaned := make([]rune, 0, len(name))
+	for _, r := range name {
+		switch r {
+		case ':', '\\', '/', '?', '*', '[', ']':
+			cleaned = append(cleaned, '_')
+		default:
+			cleaned = append(cleaned, r)
+		}
+	}
+	if len(cleaned) == 0 {
+		return fmt.Sprintf("Sheet%d", i+1)
+	}
+	if len(cleaned) > 31 {
+		cleaned = cleaned[:31]
+	}
+	return string(cleaned)
+}
@@ -0,0 +1,350 @@
+// Package httpclient builds the outbound HTTP clients used for the analytics
+// service, webhooks and integrations. Each named destination gets its own
+// connection pool, timeouts, TLS and proxy settings, and request metrics.
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"nivai/backend/pkg/config"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// Well-known destination names.
+const (
+	DestinationAnalytics = "analytics"
+	DestinationWebhooks  = "webhooks"
+	DestinationKafka     = "kafka"
+	DestinationSecrets   = "secrets"
+	// Slack notifications post to the webhooks users configure
+	DestinationNotifications = "notifications"
+)
+
+// ProxyDirect disables proxying for a destination, ignoring HTTP(S)_PROXY.
+const ProxyDirect = "direct"
+
+// Settings configures one destination's client. Zero values inherit from the
+// factory defaults, and unset defaults fall back to built-in values.
+type Settings struct {
+	Timeout             time.Duration
+	DialTimeout         time.Duration
+	TLSHandshakeTimeout time.Duration
+	IdleConnTimeout     time.Duration
+	MaxIdleConnsPerHost int
+	MaxConnsPerHost     int
+	ProxyURL            string
+	NoProxy             string // Hosts reached directly, as in NO_PROXY; empty inherits NO_PROXY
+	CAFile              string // PEM bundle, or a directory of them
+	InsecureSkipVerify  bool
+	PublicOnly          bool // Refuse to connect to non-public addresses; see IsPublicIP
+}
+
+// builtinDefaults apply to any setting left unset in the factory defaults.
+var builtinDefaults = Settings{
+	Timeout:             10 * time.Second,
+	DialTimeout:         5 * time.Second,
+	TLSHandshakeTimeout: 5 * time.Second,
+	IdleConnTimeout:     90 * time.Second,
+	MaxIdleConnsPerHost: 10,
+}
+
+// merge returns s with its unset fields taken from base.
+func (s Settings) merge(base Settings) Settings {
+	if s.Timeout <= 0 {
+		s.Timeout = base.Timeout
+	}
+	if s.DialTimeout <= 0 {
+		s.DialTimeout = base.DialTimeout
+	}
+	if s.TLSHandshakeTimeout <= 0 {
+		s.TLSHandshakeTimeout = base.TLSHandshakeTimeout
+	}
+	if s.IdleConnTimeout <= 0 {
+		s.IdleConnTimeout = base.IdleConnTimeout
+	}
+	if s.MaxIdleConnsPerHost <= 0 {
+		s.MaxIdleConnsPerHost = base.MaxIdleConnsPerHost
+	}
+	if s.MaxConnsPerHost <= 0 {
+		s.MaxConnsPerHost = base.MaxConnsPerHost
+	}
+	if s.ProxyURL == "" {
+		s.ProxyURL = base.ProxyURL
+	}
+	if s.NoProxy == "" {
+		s.NoProxy = base.NoProxy
+	}
+	if s.CAFile == "" {
+		s.CAFile = base.CAFile
+	}
+	s.InsecureSkipVerify = s.InsecureSkipVerify || base.InsecureSkipVerify
+	s.PublicOnly = s.PublicOnly || base.PublicOnly
+	return s
+}
+
+// Factory hands out one shared *http.Client per destination.
+type Factory struct {
+	defaults     Settings
+	destinations map[string]Settings
+
+	mu      sync.Mutex
+	clients map[string]*http.Client
+	metrics map[string]*destinationMetrics
+}
+
+// New creates a factory and builds the clients of all configured
+// destinations, so invalid TLS or proxy settings are reported up front.
+func New(defaults Settings, destinations map[string]Settings) (*Factory, error) {
+	f := &Factory{
+		defaults:     defaults.merge(builtinDefaults),
+		destinations: map[string]Settings{},
+		clients:      map[string]*http.Client{},
+		metrics:      map[string]*destinationMetrics{},
+	}
+	for name, settings := range destinations {
+		f.destinations[name] = settings.merge(f.defaults)
+	}
+
+	if _, err := newTransport(f.defaults); err != nil {
+		return nil, fmt.Errorf("http client defaults: %w", err)
+	}
+	for name := range f.destinations {
+		if _, err := f.build(name); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// FromConfig creates a factory from the http_clients configuration section.
+func FromConfig(cfg *config.Config) (*Factory, error) {
+	destinations := make(map[string]Settings, len(cfg.HTTPClients.Destinations))
+	for name, settings := range cfg.HTTPClients.Destinations {
+		destinations[name] = fromConfig(settings)
+	}
+	return New(fromConfig(cfg.HTTPClients.Defaults), destinations)
+}
+
+// fromConfig converts configuration seconds into durations.
+func fromConfig(c config.HTTPClientSettings) Settings {
+	return Settings{
+		Timeout:             time.Duration(c.TimeoutSecs) * time.Second,
+		DialTimeout:         time.Duration(c.DialTimeoutSecs) * time.Second,
+		TLSHandshakeTimeout: time.Duration(c.TLSHandshakeTimeoutSecs) * time.Second,
+		IdleConnTimeout:     time.Duration(c.IdleConnTimeoutSecs) * time.Second,
+		MaxIdleConnsPerHost: c.MaxIdleConnsPerHost,
+		MaxConnsPerHost:     c.MaxConnsPerHost,
+		ProxyURL:            c.ProxyURL,
+		NoProxy:             c.NoProxy,
+		CAFile:              c.CAFile,
+		InsecureSkipVerify:  c.InsecureSkipVerify,
+		PublicOnly:          c.PublicOnly,
+	}
+}
+
+// Client returns the shared client for a destination. Unknown destinations
+// use the default settings with a pool of their own. A nil factory returns
+// nil, letting callers fall back to their own default client.
+func (f *Factory) Client(name string) *http.Client {
+	if f == nil {
+		return nil
+	}
+	client, err := f.build(name)
+	if err != nil {
+		// Not expected: every destination and the defaults were built in New
+		log.Printf("Warning: HTTP client %q unavailable, using a plain client: %v", name, err)
+		return &http.Client{Timeout: f.defaults.Timeout}
+	}
+	return client
+}
+
+// build returns the cached client for name, creating it on first use.
+func (f *Factory) build(name string) (*http.Client, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if client, ok := f.clients[name]; ok {
+		return client, nil
+	}
+
+	settings, ok := f.destinations[name]
+	if !ok {
+		settings = f.defaults
+	}
+	transport, err := newTransport(settings)
+	if err != nil {
+		return nil, fmt.Errorf("http client %q: %w", name, err)
+	}
+	client := f.newClient(name, settings, transport)
+	f.clients[name] = client
+	return client, nil
+}
+
+// newClient wraps transport with metrics for name. Callers hold f.mu.
+func (f *Factory) newClient(name string, settings Settings, transport *http.Transport) *http.Client {
+	m, ok := f.metrics[name]
+	if !ok {
+		m = &destinationMetrics{name: name}
+		f.metrics[name] = m
+	}
+	return &http.Client{
+		Timeout:   settings.Timeout,
+		Transport: &instrumentedTransport{next: transport, metrics: m},
+	}
+}
+
+// Stats returns request metrics for every destination that has been used,
+// ordered by name.
+func (f *Factory) Stats() []DestinationStats {
+	f.mu.Lock()
+	metrics := make([]*destinationMetrics, 0, len(f.metrics))
+	for _, m := range f.metrics {
+		metrics = append(metrics, m)
+	}
+	f.mu.Unlock()
+
+	stats := make([]DestinationStats, 0, len(metrics))
+	for _, m := range metrics {
+		stats = append(stats, m.snapshot())
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Destination < stats[j].Destination })
+	return stats
+}
+
+// CloseIdleConnections closes idle connections in every pool, e.g. on shutdown.
+func (f *Factory) CloseIdleConnections() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, client := range f.clients {
+		client.CloseIdleConnections()
+	}
+}
+
+// newTransport builds a pooled transport from fully merged settings.
+func newTransport(s Settings) (*http.Transport, error) {
+	proxy, err := proxyFunc(s.ProxyURL, s.NoProxy)
+	if err != nil {
+		return nil, err
+	}
+	tlsCfg, err := tlsConfig(s)
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := &net.Dialer{Timeout: s.DialTimeout, KeepAlive: 30 * time.Second}
+	if s.PublicOnly {
+		// Checked on the address dialed, so hosts resolving to internal
+		// addresses, now or after a DNS change, are refused too
+		dialer.Control = refuseNonPublic
+	}
+	return &http.Transport{
+		Proxy:                 proxy,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   s.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       s.MaxConnsPerHost,
+		IdleConnTimeout:       s.IdleConnTimeout,
+		TLSHandshakeTimeout:   s.TLSHandshakeTimeout,
+		ExpectContinueTimeout: time.Second,
+		TLSClientConfig:       tlsCfg,
+	}, nil
+}
+
+// proxyFunc resolves the proxy setting: empty defers to the environment,
+// "direct" disables proxying, anything else must be a proxy URL. Hosts in
+// noProxy bypass the proxy; when empty, NO_PROXY applies.
+func proxyFunc(raw, noProxy string) (func(*http.Request) (*url.URL, error), error) {
+	env := httpproxy.FromEnvironment()
+	if noProxy != "" {
+		env.NoProxy = noProxy
+	}
+	switch strings.ToLower(raw) {
+	case "":
+		if noProxy == "" {
+			return http.ProxyFromEnvironment, nil
+		}
+	case ProxyDirect, "none":
+		return nil, nil
+	default:
+		proxyURL, err := url.Parse(raw)
+		if err != nil || proxyURL.Host == "" {
+			return nil, fmt.Errorf("invalid proxy URL %q", raw)
+		}
+		env.HTTPProxy, env.HTTPSProxy = raw, raw
+	}
+	resolve := env.ProxyFunc()
+	return func(req *http.Request) (*url.URL, error) { return resolve(req.URL) }, nil
+}
+
+// tlsConfig builds the client TLS configuration, adding the roots in CAFile
+// to the system pool.
+func tlsConfig(s Settings) (*tls.Config, error) {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12, InsecureSkipVerify: s.InsecureSkipVerify}
+	if s.CAFile == "" {
+		return cfg, nil
+	}
+
+	bundles, err := caBundles(s.CAFile)
+	if err != nil {
+		return nil, err
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	for _, path := range bundles {
+		pem, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading CA file: %w", err)
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("CA file %s contains no PEM certificates", filepath.Base(path))
+		}
+	}
+	cfg.RootCAs = pool
+	return cfg, nil
+}
+
+// caBundles returns the PEM files at path: the file itself, or the .pem,
+// .crt and .cer files in a directory, as private CAs are often shipped.
+func caBundles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading CA file: %w", err)
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading CA directory: %w", err)
+	}
+	var bundles []string
+	for _, entry := range entries {
+		switch strings.ToLower(filepath.Ext(entry.Name())) {
+		case ".pem", ".crt", ".cer":
+			if !entry.IsDir() {
+				bundles = append(bundles, filepath.Join(path, entry.Name()))
+			}
+		}
+	}
+	if len(bundles) == 0 {
+		return nil, errors.New("CA directory contains no .pem, .crt or .cer files")
+	}
+	return bundles, nil
+}
@@ -0,0 +1,164 @@
+package httpclient_test
+
+import (
+	"encoding/pem"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"nivai/backend/pkg/httpclient"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFactory_Client(t *testing.T) {
+	t.Run("Destinations get their own settings and a shared client", func(t *testing.T) {
+		f, err := httpclient.New(httpclient.Settings{Timeout: 7 * time.Second}, map[string]httpclient.Settings{
+			httpclient.DestinationAnalytics: {Timeout: 3 * time.Second},
+		})
+		require.NoError(t, err)
+
+		analytics := f.Client(httpclient.DestinationAnalytics)
+		assert.Equal(t, 3*time.Second, analytics.Timeout)
+		assert.Same(t, analytics, f.Client(httpclient.DestinationAnalytics))
+
+		other := f.Client("integrations")
+		assert.Equal(t, 7*time.Second, other.Timeout)
+		assert.NotSame(t, analytics, other)
+	})
+
+	t.Run("Nil factory returns nil", func(t *testing.T) {
+		var f *httpclient.Factory
+		assert.Nil(t, f.Client(httpclient.DestinationWebhooks))
+	})
+
+	t.Run("Invalid settings are rejected up front", func(t *testing.T) {
+		_, err := httpclient.New(httpclient.Settings{}, map[string]httpclient.Settings{
+			httpclient.DestinationKafka: {ProxyURL: "::not a url"},
+		})
+		assert.Error(t, err)
+
+		_, err = httpclient.New(httpclient.Settings{CAFile: filepath.Join(t.TempDir(), "missing.pem")}, nil)
+		assert.Error(t, err)
+
+		bad := filepath.Join(t.TempDir(), "bad.pem")
+		require.NoError(t, os.WriteFile(bad, []byte("not a certificate"), 0o600))
+		_, err = httpclient.New(httpclient.Settings{}, map[string]httpclient.Settings{
+			httpclient.DestinationWebhooks: {CAFile: bad},
+		})
+		assert.Error(t, err)
+
+		_, err = httpclient.New(httpclient.Settings{ProxyURL: httpclient.ProxyDirect}, nil)
+		assert.NoError(t, err)
+	})
+}
+
+func TestFactory_Proxy(t *testing.T) {
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.Host)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer proxy.Close()
+
+	f, err := httpclient.New(httpclient.Settings{ProxyURL: proxy.URL, Timeout: 5 * time.Second}, map[string]httpclient.Settings{
+		httpclient.DestinationAnalytics: {NoProxy: "analytics.invalid"},
+	})
+	require.NoError(t, err)
+
+	resp, err := f.Client(httpclient.DestinationWebhooks).Get("http://hooks.invalid/event")
+	require.NoError(t, err)
+	resp.Body.Close()
+	_, err = f.Client(httpclient.DestinationAnalytics).Get("http://analytics.invalid/")
+	assert.Error(t, err, "excluded hosts are dialed directly")
+	resp, err = f.Client(httpclient.DestinationAnalytics).Get("http://hooks.invalid/event")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, []string{"hooks.invalid", "hooks.invalid"}, proxied)
+}
+
+func TestFactory_CADirectory(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	dir := t.TempDir()
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "club-ca.crt"), cert, 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README"), []byte("not a certificate"), 0o600))
+
+	f, err := httpclient.New(httpclient.Settings{ProxyURL: httpclient.ProxyDirect, CAFile: dir}, nil)
+	require.NoError(t, err)
+	resp, err := f.Client(httpclient.DestinationAnalytics).Get(server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	_, err = httpclient.New(httpclient.Settings{CAFile: t.TempDir()}, nil)
+	assert.Error(t, err, "a directory without certificates")
+}
+
+func TestFactory_Stats(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	f, err := httpclient.New(httpclient.Settings{ProxyURL: httpclient.ProxyDirect}, nil)
+	require.NoError(t, err)
+	client := f.Client(httpclient.DestinationWebhooks)
+
+	for _, path := range []string{"/", "/", "/missing"} {
+		resp, err := client.Get(server.URL + path)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+	pooled := f.Stats()[0]
+	assert.EqualValues(t, 1, pooled.NewConnections)
+	assert.EqualValues(t, 2, pooled.ReusedConnections)
+
+	server.Close()
+	_, err = client.Get(server.URL)
+	require.Error(t, err)
+
+	stats := f.Stats()
+	require.Len(t, stats, 1)
+	s := stats[0]
+	assert.Equal(t, httpclient.DestinationWebhooks, s.Destination)
+	assert.EqualValues(t, 4, s.Requests)
+	assert.EqualValues(t, 0, s.InFlight)
+	assert.EqualValues(t, 1, s.Errors)
+	assert.EqualValues(t, 2, s.StatusClasses["2xx"])
+	assert.EqualValues(t, 1, s.StatusClasses["4xx"])
+	assert.Greater(t, s.MaxLatencyMs, 0.0)
+}
+
+func TestFactory_PublicOnly(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	f, err := httpclient.New(httpclient.Settings{ProxyURL: httpclient.ProxyDirect}, map[string]httpclient.Settings{
+		httpclient.DestinationWebhooks: {PublicOnly: true},
+	})
+	require.NoError(t, err)
+
+	_, err = f.Client(httpclient.DestinationWebhooks).Get(server.URL)
+	assert.ErrorIs(t, err, httpclient.ErrNonPublicAddress, "loopback is refused when dialing")
+	resp, err := f.Client(httpclient.DestinationAnalytics).Get(server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	for ip, public := range map[string]bool{
+		"93.184.215.14": true, "2606:2800:21f:cb07::1": true,
+		"127.0.0.1": false, "10.0.0.1": false, "172.16.5.4": false, "192.168.0.1": false,
+		"169.254.169.254": false, "100.64.0.1": false, "0.0.0.0": false, "::1": false,
+		"fe80::1": false, "fd00::1": false, "::ffff:127.0.0.1": false,
+	} {
+		assert.Equal(t, public, httpclient.IsPublicIP(net.ParseIP(ip)), ip)
+	}
+}
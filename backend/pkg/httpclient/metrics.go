@@ -0,0 +1,130 @@
+package httpclient
+
+import (
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// DestinationStats summarises the outbound requests to one destination since
+// startup.
+type DestinationStats struct {
+	Destination       string           `json:"destination"`
+	Requests          int64            `json:"requests"`
+	InFlight          int64            `json:"in_flight"`
+	Errors            int64            `json:"errors"` // Transport failures and timeouts
+	StatusClasses     map[string]int64 `json:"status_classes"`
+	NewConnections    int64            `json:"new_connections"`
+	ReusedConnections int64            `json:"reused_connections"`
+	AvgLatencyMs      float64          `json:"avg_latency_ms"`
+	MaxLatencyMs      float64          `json:"max_latency_ms"`
+}
+
+// destinationMetrics accumulates DestinationStats for one destination.
+type destinationMetrics struct {
+	name string
+
+	mu            sync.Mutex
+	requests      int64
+	inFlight      int64
+	errors        int64
+	statusClasses map[string]int64
+	newConns      int64
+	reusedConns   int64
+	totalLatency  time.Duration
+	maxLatency    time.Duration
+}
+
+// snapshot copies the current counters.
+func (m *destinationMetrics) snapshot() DestinationStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := DestinationStats{
+		Destination:       m.name,
+		Requests:          m.requests,
+		InFlight:          m.inFlight,
+		Errors:            m.errors,
+		StatusClasses:     map[string]int64{},
+		NewConnections:    m.newConns,
+		ReusedConnections: m.reusedConns,
+		MaxLatencyMs:      float64(m.maxLatency) / float64(time.Millisecond),
+	}
+	for class, count := range m.statusClasses {
+		stats.StatusClasses[class] = count
+	}
+	if completed := m.requests - m.inFlight; completed > 0 {
+		stats.AvgLatencyMs = float64(m.totalLatency) / float64(completed) / float64(time.Millisecond)
+	}
+	return stats
+}
+
+// start records a request being sent.
+func (m *destinationMetrics) start() {
+	m.mu.Lock()
+	m.requests++
+	m.inFlight++
+	m.mu.Unlock()
+}
+
+// finish records the outcome of a request.
+func (m *destinationMetrics) finish(resp *http.Response, err error, latency time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.inFlight--
+	m.totalLatency += latency
+	if latency > m.maxLatency {
+		m.maxLatency = latency
+	}
+	if err != nil {
+		m.errors++
+		return
+	}
+	if m.statusClasses == nil {
+		m.statusClasses = map[string]int64{}
+	}
+	m.statusClasses[statusClass(resp.StatusCode)]++
+}
+
+// gotConn records whether a pooled connection was reused.
+func (m *destinationMetrics) gotConn(info httptrace.GotConnInfo) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if info.Reused {
+		m.reusedConns++
+	} else {
+		m.newConns++
+	}
+}
+
+// statusClass maps a status code to "2xx", "4xx", ...
+func statusClass(code int) string {
+	return string(rune('0'+code/100)) + "xx"
+}
+
+// instrumentedTransport records metrics around each round trip. Latency is
+// measured up to the response headers.
+type instrumentedTransport struct {
+	next    http.RoundTripper
+	metrics *destinationMetrics
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *instrumentedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	trace := &httptrace.ClientTrace{GotConn: t.metrics.gotConn}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+
+	t.metrics.start()
+	started := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	t.metrics.finish(resp, err, time.Since(started))
+	return resp, err
+}
+
+// CloseIdleConnections lets http.Client.CloseIdleConnections reach the pool.
+func (t *instrumentedTransport) CloseIdleConnections() {
+	if closer, ok := t.next.(interface{ CloseIdleConnections() }); ok {
+		closer.CloseIdleConnections()
+	}
+}
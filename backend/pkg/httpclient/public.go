@@ -0,0 +1,59 @@
+package httpclient
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+)
+
+// ErrNonPublicAddress is returned when a client limited to public addresses
+// would connect to an internal one.
+var ErrNonPublicAddress = errors.New("address is not public")
+
+// nonPublicNets are the special-purpose IPv4 ranges net.IP does not classify:
+// "this network", carrier-grade NAT, IETF protocol assignments and
+// benchmarking.
+var nonPublicNets = []*net.IPNet{
+	mustCIDR("0.0.0.0/8"),
+	mustCIDR("100.64.0.0/10"),
+	mustCIDR("192.0.0.0/24"),
+	mustCIDR("198.18.0.0/15"),
+}
+
+func mustCIDR(cidr string) *net.IPNet {
+	_, n, err := net.ParseCIDR(cidr)
+	if err != nil {
+		panic(err)
+	}
+	return n
+}
+
+// IsPublicIP reports whether ip is reachable on the internet: not loopback,
+// private, link-local (such as the 169.254.169.254 cloud metadata service),
+// multicast, unspecified or otherwise reserved.
+func IsPublicIP(ip net.IP) bool {
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsMulticast() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return false
+	}
+	for _, n := range nonPublicNets {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// refuseNonPublic is a dialer control refusing connections to addresses
+// that are not public. With a proxy, the proxy is the address dialed.
+func refuseNonPublic(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if !IsPublicIP(net.ParseIP(host)) {
+		return fmt.Errorf("%w: %s", ErrNonPublicAddress, host)
+	}
+	return nil
+}
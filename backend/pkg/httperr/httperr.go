@@ -0,0 +1,170 @@
+// Package httperr defines the error responses of the API. Every error is
+// answered with the same JSON body:
+//
+//	{"code": "not_found", "message": "Video not found", "request_id": "..."}
+//
+// Clients branch on the code, which is stable; the message is meant for
+// people and may change. Messages are translated to the language the
+// request's Accept-Language header asks for where a translation exists, and
+// the Content-Language header names the language of the message. Some
+// errors add a "details" object.
+package httperr
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"nivai/backend/pkg/i18n"
+	"nivai/backend/pkg/requestctx"
+)
+
+// Error codes
+const (
+	CodeBadRequest       = "bad_request"
+	CodeUnauthorized     = "unauthorized"
+	CodeQuotaExceeded    = "quota_exceeded"
+	CodeForbidden        = "forbidden"
+	CodeNotFound         = "not_found"
+	CodeMethodNotAllowed = "method_not_allowed"
+	CodeConflict         = "conflict"
+	CodeGone             = "gone"
+	CodePayloadTooLarge  = "payload_too_large"
+	CodeUnsupportedMedia = "unsupported_media_type"
+	CodeMalwareDetected  = "malware_detected"
+	CodeRateLimited      = "rate_limited"
+	CodeInternal         = "internal_error"
+	CodeNotImplemented   = "not_implemented"
+	CodeUpstream         = "upstream_error"
+	CodeUnavailable      = "service_unavailable"
+)
+
+// Error is an API error: the HTTP status and the JSON body it is answered
+// with.
+type Error struct {
+	Status    int         `json:"-"`
+	Code      string      `json:"code"`
+	Message   string      `json:"message"`
+	Details   interface{} `json:"details,omitempty"`
+	RequestID string      `json:"request_id,omitempty"`
+}
+
+// Error returns the message.
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// New creates an error with the given status, code and message.
+func New(status int, code, message string) *Error {
+	return &Error{Status: status, Code: code, Message: message}
+}
+
+// FromStatus creates an error with the code belonging to an HTTP status, for
+// statuses decided elsewhere, such as those relayed from upstream services.
+func FromStatus(status int, message string) *Error {
+	return New(status, CodeForStatus(status), message)
+}
+
+// WithDetails returns a copy of the error carrying details.
+func (e *Error) WithDetails(details interface{}) *Error {
+	c := *e
+	c.Details = details
+	return &c
+}
+
+// BadRequest is a 400 for invalid input.
+func BadRequest(message string) *Error {
+	return New(http.StatusBadRequest, CodeBadRequest, message)
+}
+
+// NotFound is a 404 for a missing resource.
+func NotFound(message string) *Error {
+	return New(http.StatusNotFound, CodeNotFound, message)
+}
+
+// Conflict is a 409 for a request the resource's current state rules out.
+func Conflict(message string) *Error {
+	return New(http.StatusConflict, CodeConflict, message)
+}
+
+// Internal is a 500 for failures on the server's side.
+func Internal(message string) *Error {
+	return New(http.StatusInternalServerError, CodeInternal, message)
+}
+
+// NotImplemented is a 501 for features the deployment does not offer.
+func NotImplemented(message string) *Error {
+	return New(http.StatusNotImplemented, CodeNotImplemented, message)
+}
+
+// Unavailable is a 503 for requests worth retrying later.
+func Unavailable(message string) *Error {
+	return New(http.StatusServiceUnavailable, CodeUnavailable, message)
+}
+
+// CodeForStatus returns the error code of an HTTP status.
+func CodeForStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeBadRequest
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusPaymentRequired:
+		return CodeQuotaExceeded
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusMethodNotAllowed:
+		return CodeMethodNotAllowed
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusGone:
+		return CodeGone
+	case http.StatusRequestEntityTooLarge:
+		return CodePayloadTooLarge
+	case http.StatusUnsupportedMediaType:
+		return CodeUnsupportedMedia
+	case http.StatusTooManyRequests:
+		return CodeRateLimited
+	case http.StatusNotImplemented:
+		return CodeNotImplemented
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		return CodeUpstream
+	case http.StatusServiceUnavailable:
+		return CodeUnavailable
+	}
+	if status >= 500 {
+		return CodeInternal
+	}
+	return CodeBadRequest
+}
+
+// WriteError answers a request with an error, tagged with the request's ID.
+// Errors other than *Error become a 500 that does not reveal them; callers
+// log those first.
+func WriteError(w http.ResponseWriter, r *http.Request, err error) {
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		apiErr = Internal("Internal server error")
+	}
+
+	body := *apiErr
+	body.RequestID = requestctx.From(r).RequestID
+	// Untranslated messages are answered in English
+	language := i18n.Negotiate(r.Header.Get("Accept-Language"))
+	if message, ok := i18n.Translate(language, body.Message); ok {
+		body.Message = message
+	} else {
+		language = i18n.English
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Language", language)
+	w.Header().Add("Vary", "Accept-Language")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(body.Status)
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.Encode(body)
+}
@@ -0,0 +1,65 @@
+package httperr_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"nivai/backend/pkg/httperr"
+	"nivai/backend/pkg/requestctx"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteError(t *testing.T) {
+	newRequest := func() *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/videos/v1", nil)
+		return r.WithContext(requestctx.NewContext(r.Context(), requestctx.New("req-1")))
+	}
+
+	t.Run("API errors keep their status, code and details", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		httperr.WriteError(rr, newRequest(), httperr.NotFound("Video <v1> not found").WithDetails(map[string]string{"id": "v1"}))
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+		assert.JSONEq(t, `{"code":"not_found","message":"Video <v1> not found","details":{"id":"v1"},"request_id":"req-1"}`, rr.Body.String())
+	})
+
+	t.Run("Other errors are not revealed", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		httperr.WriteError(rr, newRequest(), errors.New("pq: password authentication failed"))
+
+		var body httperr.Error
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&body))
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+		assert.Equal(t, httperr.CodeInternal, body.Code)
+		assert.NotContains(t, body.Message, "password")
+	})
+
+	t.Run("Messages are answered in the requested language", func(t *testing.T) {
+		r := newRequest()
+		r.Header.Set("Accept-Language", "nl-NL,nl;q=0.9,en;q=0.8")
+		rr := httptest.NewRecorder()
+		httperr.WriteError(rr, r, httperr.NotFound("Match not found"))
+
+		assert.Equal(t, "nl", rr.Header().Get("Content-Language"))
+		assert.Equal(t, "Accept-Language", rr.Header().Get("Vary"))
+		assert.JSONEq(t, `{"code":"not_found","message":"Wedstrijd niet gevonden","request_id":"req-1"}`, rr.Body.String())
+
+		rr = httptest.NewRecorder()
+		httperr.WriteError(rr, r, httperr.NotFound("Video <v1> not found"))
+		assert.Equal(t, "en", rr.Header().Get("Content-Language"), "untranslated messages stay English")
+		assert.Contains(t, rr.Body.String(), "Video <v1> not found")
+	})
+
+	t.Run("Codes follow relayed statuses", func(t *testing.T) {
+		assert.Equal(t, httperr.CodeUpstream, httperr.FromStatus(http.StatusBadGateway, "").Code)
+		assert.Equal(t, httperr.CodeConflict, httperr.FromStatus(http.StatusConflict, "").Code)
+		assert.Equal(t, httperr.CodeBadRequest, httperr.FromStatus(http.StatusTeapot, "").Code)
+		assert.Equal(t, httperr.CodeInternal, httperr.FromStatus(http.StatusInsufficientStorage, "").Code)
+	})
+}
@@ -0,0 +1,193 @@
+package i18n
+
+// dutchMessages translates the API's messages to Dutch. Entries with {}
+// translate every message that differs only in those parts.
+var dutchMessages = map[string]string{
+	// Authentication and access
+	"Access from this address is not allowed":                     "Toegang vanaf dit adres is niet toegestaan",
+	"Admin access required":                                       "Beheerderstoegang vereist",
+	"Authorization header missing":                                "Authorization-header ontbreekt",
+	"Invalid authorization format":                                "Ongeldig autorisatieformaat",
+	"Failed to check access":                                      "Toegang controleren mislukt",
+	"Statistics cover all matches and need access to all of them": "Statistieken beslaan alle wedstrijden en vereisen toegang tot al die wedstrijden",
+	"Rate limit exceeded":                                         "Limiet voor het aantal verzoeken overschreden",
+	"Server busy, please retry later":                             "Server bezet, probeer het later opnieuw",
+	"Service degraded ({}), please retry later":                   "Dienst beperkt beschikbaar ({}), probeer het later opnieuw",
+	"The server is starting":                                      "De server wordt gestart",
+	"Method not allowed":                                          "Methode niet toegestaan",
+	"No such endpoint":                                            "Dit endpoint bestaat niet",
+	"Internal server error":                                       "Interne serverfout",
+	"Invalid request payload":                                     "Ongeldige inhoud van het verzoek",
+	"Error encoding response":                                     "Fout bij het opbouwen van het antwoord",
+	"Error connecting to analytics service: {}":                   "Fout bij het verbinden met de analyseservice: {}",
+	"Error reading response from analytics service":               "Fout bij het lezen van het antwoord van de analyseservice",
+	"Access grant not found":                                      "Toegangsrecht niet gevonden",
+	"Failed to list access grants":                                "Toegangsrechten ophalen mislukt",
+	"Failed to save access grant":                                 "Toegangsrecht opslaan mislukt",
+	"Failed to revoke access grant":                               "Toegangsrecht intrekken mislukt",
+	"subject_type must be user or group":                          "subject_type moet user of group zijn",
+	"Configuration reloads are not available":                     "Het herladen van de configuratie is niet beschikbaar",
+	"Failed to generate support bundle":                           "Supportbundel genereren mislukt",
+	"Failed to load bootstrap data":                               "Opstartgegevens laden mislukt",
+	"Scheduled job not found":                                     "Geplande taak niet gevonden",
+	"Scheduled job is already running":                            "Geplande taak wordt al uitgevoerd",
+	"Failed to retrieve jobs":                                     "Taken ophalen mislukt",
+	"Only failed jobs can be retried":                             "Alleen mislukte taken kunnen opnieuw worden uitgevoerd",
+	"Invalid job status: {}":                                      "Ongeldige taakstatus: {}",
+	"Failed to retrieve storage usage":                            "Opslaggebruik ophalen mislukt",
+	"Storage replication is not configured":                       "Opslagreplicatie is niet geconfigureerd",
+	"Failed to retrieve retention rules":                          "Bewaarregels ophalen mislukt",
+	"Failed to update retention rules":                            "Bewaarregels bijwerken mislukt",
+	"Audit not found":                                             "Audit niet gevonden",
+	"Failed to retrieve audit":                                    "Audit ophalen mislukt",
+	"Webhook not found":                                           "Webhook niet gevonden",
+	"Failed to create webhook":                                    "Webhook aanmaken mislukt",
+	"Failed to retrieve webhooks":                                 "Webhooks ophalen mislukt",
+	"Failed to process webhook request":                           "Webhookverzoek verwerken mislukt",
+	"Notification not found":                                      "Melding niet gevonden",
+	"Failed to list notifications":                                "Meldingen ophalen mislukt",
+	"Failed to mark notification read":                            "Melding als gelezen markeren mislukt",
+	"Failed to mark notifications read":                           "Meldingen als gelezen markeren mislukt",
+	"Failed to read notification preferences":                     "Meldingsvoorkeuren ophalen mislukt",
+	"Failed to save notification preferences":                     "Meldingsvoorkeuren opslaan mislukt",
+	"unread must be true or false":                                "unread moet true of false zijn",
+	"Share link not found":                                        "Deellink niet gevonden",
+	"Failed to process share link request":                        "Deellinkverzoek verwerken mislukt",
+	"The shared match has been deleted":                           "De gedeelde wedstrijd is verwijderd",
+
+	// Matches and videos
+	"Match not found":                               "Wedstrijd niet gevonden",
+	"Video not found":                               "Video niet gevonden",
+	"Missing video ID":                              "Video-ID ontbreekt",
+	"Match ID is required in path":                  "Wedstrijd-ID is verplicht in het pad",
+	"match_id query parameter is required":          "Queryparameter match_id is verplicht",
+	"Failed to retrieve match":                      "Wedstrijd ophalen mislukt",
+	"Failed to retrieve matches":                    "Wedstrijden ophalen mislukt",
+	"Failed to retrieve match list":                 "Wedstrijdlijst ophalen mislukt",
+	"Failed to retrieve video":                      "Video ophalen mislukt",
+	"Failed to retrieve videos":                     "Video's ophalen mislukt",
+	"Failed to retrieve video metadata":             "Videogegevens ophalen mislukt",
+	"Failed to retrieve video history":              "Videogeschiedenis ophalen mislukt",
+	"Failed to delete video metadata":               "Videogegevens verwijderen mislukt",
+	"Failed to undelete video":                      "Video terugzetten mislukt",
+	"No pending deletion for this video":            "Er staat geen verwijdering open voor deze video",
+	"Failed to list pending purges":                 "Openstaande verwijderingen ophalen mislukt",
+	"Failed to save video/match metadata: {}":       "Video- of wedstrijdgegevens opslaan mislukt: {}",
+	"from must not be after to":                     "from mag niet na to liggen",
+	"invalid group_by {}: use week or month":        "ongeldige group_by {}: gebruik week of month",
+	"The match calendar is not configured":          "De wedstrijdkalender is niet geconfigureerd",
+	"Failed to build match calendar":                "Wedstrijdkalender opbouwen mislukt",
+	"Failed to retrieve match-day status":           "Wedstrijddagstatus ophalen mislukt",
+	"Failed to update match-day settings":           "Wedstrijddaginstellingen bijwerken mislukt",
+	"Match bundles are not configured":              "Wedstrijdbundels zijn niet geconfigureerd",
+	"Failed to export match":                        "Wedstrijd exporteren mislukt",
+	"Match drafts are not configured":               "Wedstrijdconcepten zijn niet geconfigureerd",
+	"Failed to create draft":                        "Concept aanmaken mislukt",
+	"Failed to attach file":                         "Bestand toevoegen mislukt",
+	"Match is no longer a draft":                    "Wedstrijd is geen concept meer",
+	"Match is {}, not a draft":                      "Wedstrijd is {}, geen concept",
+	"priority is given when the draft is submitted": "De prioriteit wordt opgegeven bij het indienen van het concept",
+	"title is required":                             "title is verplicht",
+	"Content-Type must be application/json, application/x-www-form-urlencoded or multipart/form-data": "Content-Type moet application/json, application/x-www-form-urlencoded of multipart/form-data zijn",
+
+	// Processing
+	"Match has no tracking and event files to process":                "Wedstrijd heeft geen tracking- en eventbestanden om te verwerken",
+	"Tracking and event files are required for analytics processing.": "Tracking- en eventbestanden zijn vereist voor de analyse.",
+	"Match is not being processed":                                    "Wedstrijd wordt niet verwerkt",
+	"Match processing already finished":                               "Verwerking van de wedstrijd is al voltooid",
+	"Match processing is being changed by another request; try again": "De verwerking van de wedstrijd wordt door een ander verzoek gewijzigd; probeer het opnieuw",
+	"Match processing state changed; try again":                       "De verwerkingsstatus van de wedstrijd is gewijzigd; probeer het opnieuw",
+	"Match cannot be reprocessed while {}":                            "Wedstrijd kan niet opnieuw worden verwerkt tijdens {}",
+	"Match events cannot be replaced while {}":                        "Wedstrijdevents kunnen niet worden vervangen tijdens {}",
+	"Failed to lock match processing":                                 "Verwerking van de wedstrijd vergrendelen mislukt",
+	"Failed to start processing":                                      "Verwerking starten mislukt",
+	"Failed to cancel processing":                                     "Verwerking annuleren mislukt",
+	"reason is required":                                              "reason is verplicht",
+	"Nothing to change: give a state or file paths":                   "Niets te wijzigen: geef een status of bestandspaden op",
+	"Unknown processing state {}":                                     "Onbekende verwerkingsstatus {}",
+	"{} {} does not exist in storage":                                 "{} {} bestaat niet in de opslag",
+	"Failed to update processing state":                               "Verwerkingsstatus bijwerken mislukt",
+	"Failed to retrieve statistics":                                   "Statistieken ophalen mislukt",
+	"Backfill imports are not configured":                             "Historische imports zijn niet geconfigureerd",
+	"Backfill job not found":                                          "Historische import niet gevonden",
+	"Invalid backfill manifest":                                       "Ongeldig manifest voor historische import",
+	"Failed to read manifest":                                         "Manifest lezen mislukt",
+	"Manifest too large. Maximum size is {}MB.":                       "Manifest te groot. De maximale grootte is {}MB.",
+	"Cold-storage archiving is not configured":                        "Archivering in koude opslag is niet geconfigureerd",
+	"Archive job not found":                                           "Archiveringstaak niet gevonden",
+	"Failed to retrieve archive job":                                  "Archiveringstaak ophalen mislukt",
+	"Failed to start archive job":                                     "Archiveringstaak starten mislukt",
+
+	// Uploads and files
+	"Upload not found":                                        "Upload niet gevonden",
+	"Upload already finalized":                                "Upload is al afgerond",
+	"Upload expired, request new upload URLs":                 "Upload verlopen, vraag nieuwe upload-URL's aan",
+	"Direct uploads are not supported by the storage backend": "Directe uploads worden niet ondersteund door de opslag",
+	"Failed to create upload":                                 "Upload aanmaken mislukt",
+	"Failed to create upload URLs":                            "Upload-URL's aanmaken mislukt",
+	"Failed to finalize upload":                               "Upload afronden mislukt",
+	"Failed to retrieve upload":                               "Upload ophalen mislukt",
+	"Failed to verify uploaded files":                         "Geüploade bestanden controleren mislukt",
+	"Upload progress tracking is disabled":                    "Het volgen van uploadvoortgang is uitgeschakeld",
+	"Failed to retrieve upload progress":                      "Uploadvoortgang ophalen mislukt",
+	"Invalid X-Upload-ID, expected a UUID":                    "Ongeldige X-Upload-ID, een UUID verwacht",
+	"File(s) too large. Maximum total size is {}MB.":          "Bestand(en) te groot. De maximale totale grootte is {}MB.",
+	"Invalid form: {}":                                        "Ongeldig formulier: {}",
+	"Invalid multipart form: {}":                              "Ongeldig multipart-formulier: {}",
+	"Error processing tracking_file: {}":                      "Fout bij het verwerken van tracking_file: {}",
+	"Error processing event_file: {}":                         "Fout bij het verwerken van event_file: {}",
+	"Error processing video_file: {}":                         "Fout bij het verwerken van video_file: {}",
+	"Unknown provider {}, expected one of: {}":                "Onbekende leverancier {}, verwacht een van: {}",
+	"Invalid file type, expected tracking, events or video":   "Ongeldig bestandstype, verwacht tracking, events of video",
+	"The {} file is empty":                                    "Het {}-bestand is leeg",
+	"Failed to store file":                                    "Bestand opslaan mislukt",
+	"File not found":                                          "Bestand niet gevonden",
+	"Stored file not found":                                   "Opgeslagen bestand niet gevonden",
+	"Failed to retrieve file":                                 "Bestand ophalen mislukt",
+	"Match has no video file":                                 "Wedstrijd heeft geen videobestand",
+	"Match has no {} file":                                    "Wedstrijd heeft geen {}-bestand",
+	"Match files are quarantined":                             "Wedstrijdbestanden staan in quarantaine",
+	"Match file is in cold storage; restore the match first":  "Wedstrijdbestand staat in koude opslag; zet de wedstrijd eerst terug",
+	"Match video is in cold storage":                          "Wedstrijdvideo staat in koude opslag",
+	"Failed to import event feed":                             "Eventfeed importeren mislukt",
+	"Event feed too large. Maximum size is {}MB.":             "Eventfeed te groot. De maximale grootte is {}MB.",
+	"Unknown event feed provider {}, expected one of: {}":     "Onbekende eventfeedleverancier {}, verwacht een van: {}",
+	"Request body too large. Maximum size is {}.":             "Request body te groot. De maximale grootte is {}.",
+
+	// Match metadata
+	"Invalid metadata: expected a JSON object":                                     "Ongeldige metadata: een JSON-object verwacht",
+	"Invalid metadata: expected a JSON object of at most {}KB":                     "Ongeldige metadata: een JSON-object van maximaal {}KB verwacht",
+	"Invalid metadata: unexpected data after the JSON object":                      "Ongeldige metadata: onverwachte gegevens na het JSON-object",
+	"Invalid metadata: {} must be a {}":                                            "Ongeldige metadata: {} moet een {} zijn",
+	"Invalid metadata: {}":                                                         "Ongeldige metadata: {}",
+	"{} cannot be combined with the {} field":                                      "{} kan niet worden gecombineerd met het veld {}",
+	"{} is longer than {} characters":                                              "{} is langer dan {} tekens",
+	"Invalid match_date, expected YYYY-MM-DD":                                      "Ongeldige match_date, verwacht JJJJ-MM-DD",
+	"Invalid kickoff_at, expected RFC 3339 (e.g. 2024-05-01T18:45:00Z)":            "Ongeldige kickoff_at, verwacht RFC 3339 (bijv. 2024-05-01T18:45:00Z)",
+	"Invalid kickoff_timezone, expected an IANA time zone (e.g. Europe/Amsterdam)": "Ongeldige kickoff_timezone, verwacht een IANA-tijdzone (bijv. Europe/Amsterdam)",
+	"Invalid attendance, expected a whole number":                                  "Ongeldig aantal toeschouwers, verwacht een geheel getal",
+	"Invalid attendance, expected a number of at least 0":                          "Ongeldig aantal toeschouwers, verwacht een getal van minimaal 0",
+	"Invalid priority, expected low, normal or high":                               "Ongeldige prioriteit, verwacht low, normal of high",
+	"Invalid {}: expected an RFC 3339 timestamp":                                   "Ongeldige {}: verwacht een RFC 3339-tijdstip",
+	"Invalid include_analytics: expected true or false":                            "Ongeldige include_analytics: verwacht true of false",
+
+	// Players, teams and reports
+	"Player not found":                                  "Speler niet gevonden",
+	"Player ID is required in path":                     "Speler-ID is verplicht in het pad",
+	"Team ID is required in path":                       "Team-ID is verplicht in het pad",
+	"Query parameter 'name' (player name) is required.": "Queryparameter 'name' (spelersnaam) is verplicht.",
+	"Request body must name a player_id":                "De inhoud van het verzoek moet een player_id bevatten",
+	"Tracking player is not mapped":                     "Trackingspeler is niet gekoppeld",
+	"Failed to create player":                           "Speler aanmaken mislukt",
+	"Failed to resolve player":                          "Speler bepalen mislukt",
+	"Failed to retrieve players":                        "Spelers ophalen mislukt",
+	"Failed to retrieve player erasures":                "Verwijderde spelers ophalen mislukt",
+	"Failed to process player request":                  "Spelersverzoek verwerken mislukt",
+	"Failed to process player data request":             "Verzoek om spelersgegevens verwerken mislukt",
+	"Failed to retrieve team matches":                   "Wedstrijden van het team ophalen mislukt",
+	"Report not found":                                  "Rapport niet gevonden",
+	"Report is {}":                                      "Rapport is {}",
+	"Failed to start report":                            "Rapport starten mislukt",
+	"format must be csv or xlsx":                        "format moet csv of xlsx zijn",
+	"table must be players or teams":                    "table moet players of teams zijn",
+}
@@ -0,0 +1,107 @@
+// Package i18n translates the user-facing messages of the API. Messages are
+// written in English, the source language; the other languages have a
+// catalog mapping English messages to their translation. Clients pick the
+// language with the Accept-Language header, and messages without a
+// translation stay English.
+package i18n
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// Supported languages. English comes first, so it is the fallback of
+// negotiation.
+const (
+	English = "en"
+	Dutch   = "nl"
+)
+
+// placeholder marks the variable parts of a catalog entry, such as the
+// names and numbers formatted into a message.
+const placeholder = "{}"
+
+// catalog holds the translations of one language: exact messages, and
+// patterns for the messages with placeholders.
+type catalog struct {
+	messages map[string]string
+	patterns []pattern
+}
+
+// pattern translates the messages matching a catalog entry with
+// placeholders, carrying the variable parts over in order.
+type pattern struct {
+	message     string
+	source      *regexp.Regexp
+	translation string
+}
+
+var (
+	catalogs = map[string]*catalog{
+		Dutch: newCatalog(dutchMessages),
+	}
+	matcher = language.NewMatcher([]language.Tag{language.English, language.Dutch})
+)
+
+// newCatalog compiles the entries of a language, keyed by the English
+// message.
+func newCatalog(entries map[string]string) *catalog {
+	c := &catalog{messages: make(map[string]string, len(entries))}
+	for message, translation := range entries {
+		if !strings.Contains(message, placeholder) {
+			c.messages[message] = translation
+			continue
+		}
+		source := strings.ReplaceAll(regexp.QuoteMeta(message), regexp.QuoteMeta(placeholder), "(.+?)")
+		c.patterns = append(c.patterns, pattern{message: message, source: regexp.MustCompile("^" + source + "$"), translation: translation})
+	}
+	// The longest entries are the most specific, so they are tried first
+	sort.Slice(c.patterns, func(i, j int) bool {
+		if len(c.patterns[i].message) != len(c.patterns[j].message) {
+			return len(c.patterns[i].message) > len(c.patterns[j].message)
+		}
+		return c.patterns[i].message < c.patterns[j].message
+	})
+	return c
+}
+
+// Negotiate returns the supported language that best matches an
+// Accept-Language header, English when none does.
+func Negotiate(acceptLanguage string) string {
+	if acceptLanguage == "" {
+		return English
+	}
+	tag, _ := language.MatchStrings(matcher, acceptLanguage)
+	base, _ := tag.Base()
+	if _, ok := catalogs[base.String()]; ok {
+		return base.String()
+	}
+	return English
+}
+
+// Translate returns the message in a language, and whether it was
+// translated. English and untranslated messages are returned unchanged.
+func Translate(lang, message string) (string, bool) {
+	c, ok := catalogs[lang]
+	if !ok {
+		return message, false
+	}
+	if translation, ok := c.messages[message]; ok {
+		return translation, true
+	}
+	for _, p := range c.patterns {
+		parts := p.source.FindStringSubmatch(message)
+		if parts == nil {
+			continue
+		}
+		translation := p.translation
+		for _, part := range parts[1:] {
+			translation = strings.Replace(translation, placeholder, part, 1)
+		}
+		return translation, true
+	}
+	return message, false
+}
@@ -0,0 +1,53 @@
+package i18n_test
+
+import (
+	"testing"
+
+	"nivai/backend/pkg/i18n"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiate(t *testing.T) {
+	for header, want := range map[string]string{
+		"":                          i18n.English,
+		"nl":                        i18n.Dutch,
+		"nl-NL,nl;q=0.9,en;q=0.8":   i18n.Dutch,
+		"nl-BE":                     i18n.Dutch,
+		"en-GB,en;q=0.9,nl;q=0.5":   i18n.English,
+		"de-DE,de;q=0.9":            i18n.English,
+		"de;q=0.9,nl;q=0.8":         i18n.Dutch,
+		"*":                         i18n.English,
+		"not a language header ;;;": i18n.English,
+	} {
+		assert.Equal(t, want, i18n.Negotiate(header), header)
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	t.Run("Messages are looked up in the language's catalog", func(t *testing.T) {
+		message, ok := i18n.Translate(i18n.Dutch, "Match not found")
+		assert.True(t, ok)
+		assert.Equal(t, "Wedstrijd niet gevonden", message)
+	})
+
+	t.Run("Formatted messages keep their variable parts", func(t *testing.T) {
+		message, ok := i18n.Translate(i18n.Dutch, "venue is longer than 200 characters")
+		assert.True(t, ok)
+		assert.Equal(t, "venue is langer dan 200 tekens", message)
+
+		message, ok = i18n.Translate(i18n.Dutch, `Invalid metadata: title must be a string`)
+		assert.True(t, ok)
+		assert.Equal(t, "Ongeldige metadata: title moet een string zijn", message, "the most specific entry wins")
+	})
+
+	t.Run("English and untranslated messages are unchanged", func(t *testing.T) {
+		message, ok := i18n.Translate(i18n.English, "Match not found")
+		assert.False(t, ok)
+		assert.Equal(t, "Match not found", message)
+
+		message, ok = i18n.Translate(i18n.Dutch, "Something nobody translated")
+		assert.False(t, ok)
+		assert.Equal(t, "Something nobody translated", message)
+	})
+}
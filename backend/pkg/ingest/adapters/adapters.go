@@ -0,0 +1,185 @@
+// Package adapters normalizes the tracking and event exports of data
+// providers into the platform's canonical format: gzip-compressed Parquet
+// with the columns the Python API loads (see canonical.go).
+//
+// Uploads and drop folder deliveries pass through Normalize before they are
+// stored. The provider is named by the uploader or detected from the first
+// bytes of the file; files already in the canonical format, and files no
+// adapter recognizes, are stored unchanged.
+package adapters
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// sniffSize is how much of a file is inspected to detect its provider.
+const sniffSize = 16 << 10
+
+var (
+	parquetMagic = []byte("PAR1")
+	gzipMagic    = []byte{0x1f, 0x8b}
+)
+
+// ErrUnknownProvider is returned for a provider no adapter is registered for.
+var ErrUnknownProvider = errors.New("unknown data provider")
+
+// ConvertError is a file that claims, or was detected, to be a provider's
+// export but cannot be converted.
+type ConvertError struct {
+	Provider string
+	Kind     string
+	Err      error
+}
+
+func (e *ConvertError) Error() string {
+	return fmt.Sprintf("invalid %s %s file: %v", e.Provider, e.Kind, e.Err)
+}
+
+func (e *ConvertError) Unwrap() error {
+	return e.Err
+}
+
+// Adapter converts one provider's export of one kind of file ("tracking"
+// or "events") into the canonical format.
+type Adapter interface {
+	Provider() string
+	Kind() string
+	// Sniff reports whether head, the start of a decompressed file, looks
+	// like this adapter's format. head may end mid-line.
+	Sniff(head []byte) bool
+	// Convert reads the provider's file from r and writes it in the
+	// canonical format to w.
+	Convert(ctx context.Context, r io.Reader, w io.Writer) error
+}
+
+// registered are the adapters, in the order they are tried when sniffing.
+var registered = []Adapter{
+	Tracab{},
+	SecondSpectrum{},
+	StatsPerformTracking{},
+	StatsPerformEvents{},
+	Opta{},
+	StatsBomb{},
+}
+
+// Providers returns the names of the providers with an adapter, sorted.
+func Providers() []string {
+	seen := map[string]bool{}
+	var providers []string
+	for _, adapter := range registered {
+		if !seen[adapter.Provider()] {
+			seen[adapter.Provider()] = true
+			providers = append(providers, adapter.Provider())
+		}
+	}
+	sort.Strings(providers)
+	return providers
+}
+
+// ValidProvider reports whether provider names a provider with an adapter.
+// The empty provider, meaning detect, is valid.
+func ValidProvider(provider string) bool {
+	if provider == "" {
+		return true
+	}
+	for _, name := range Providers() {
+		if strings.EqualFold(name, provider) {
+			return true
+		}
+	}
+	return false
+}
+
+// Lookup returns the provider's adapter for a kind of file.
+func Lookup(provider, kind string) (Adapter, bool) {
+	for _, adapter := range registered {
+		if strings.EqualFold(adapter.Provider(), provider) && adapter.Kind() == kind {
+			return adapter, true
+		}
+	}
+	return nil, false
+}
+
+// Detect returns the adapter whose format head, the start of a decompressed
+// file, is in, or nil when no adapter recognizes it.
+func Detect(kind string, head []byte) Adapter {
+	for _, adapter := range registered {
+		if adapter.Kind() == kind && adapter.Sniff(head) {
+			return adapter
+		}
+	}
+	return nil
+}
+
+// Normalize returns r converted to the canonical format, and the provider
+// whose adapter converted it. provider names the provider of the file; when
+// empty, or when that provider has no adapter for kind, the provider is
+// detected. Content already in the canonical format or in no known format is
+// returned unchanged, with an empty provider. Gzip-compressed exports are
+// decompressed before conversion.
+//
+// Conversion runs while the returned reader is read; a conversion failure is
+// returned by Read as a *ConvertError. Reading must continue to EOF or error,
+// or the returned reader be closed, to end the conversion.
+func Normalize(ctx context.Context, kind, provider string, r io.Reader) (io.ReadCloser, string, error) {
+	if !ValidProvider(provider) {
+		return nil, "", fmt.Errorf("%w %q, expected one of %s", ErrUnknownProvider, provider, strings.Join(Providers(), ", "))
+	}
+
+	buffered := bufio.NewReaderSize(r, sniffSize)
+	head, err := buffered.Peek(sniffSize)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, bufio.ErrBufferFull) {
+		return nil, "", err
+	}
+	if bytes.HasPrefix(head, parquetMagic) {
+		return io.NopCloser(buffered), "", nil
+	}
+	compressed := bytes.HasPrefix(head, gzipMagic)
+	if compressed {
+		head = gunzipHead(head)
+	}
+
+	adapter, ok := Lookup(provider, kind)
+	if !ok {
+		adapter = Detect(kind, head)
+	}
+	if adapter == nil {
+		return io.NopCloser(buffered), "", nil
+	}
+
+	var src io.Reader = buffered
+	if compressed {
+		gz, err := gzip.NewReader(buffered)
+		if err != nil {
+			return nil, "", &ConvertError{Provider: adapter.Provider(), Kind: kind, Err: err}
+		}
+		src = gz
+	}
+	pr, pw := io.Pipe()
+	go func() {
+		err := adapter.Convert(ctx, src, pw)
+		if err != nil && !errors.Is(err, io.ErrClosedPipe) {
+			err = &ConvertError{Provider: adapter.Provider(), Kind: kind, Err: err}
+		}
+		pw.CloseWithError(err)
+	}()
+	return pr, adapter.Provider(), nil
+}
+
+// gunzipHead decompresses as much of the start of a gzip stream as it can.
+func gunzipHead(head []byte) []byte {
+	gz, err := gzip.NewReader(bytes.NewReader(head))
+	if err != nil {
+		return nil
+	}
+	out, _ := io.ReadAll(io.LimitReader(gz, sniffSize)) // The stream is cut off; keep what decompressed
+	return out
+}
@@ -0,0 +1,191 @@
+package adapters_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"nivai/backend/pkg/ingest/adapters"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const tracabFrames = `1000:1,7,10,100,-200,0.0;0,8,4,-500,300,0.0;3,9,0,0,0,0.0;:0,0,0,0.0,,Dead;:
+1001:1,7,10,110,-200,0.2;0,8,4,-500,300,0.0;3,9,0,0,0,0.0;:0,0,0,0.0,,Alive;:
+`
+
+const secondSpectrumFrames = `{"period":1,"frameIdx":0,"gameClock":0.0,"wallClock":1600000000000,"homePlayers":[{"playerId":"h1","number":"9","xyz":[1.0,2.0,0.0]}],"awayPlayers":[{"playerId":"a1","number":"5","xyz":[-3.0,4.0,0.0]}]}
+{"period":1,"frameIdx":1,"gameClock":0.04,"wallClock":1600000000040,"homePlayers":[{"playerId":"h1","number":"9","xyz":[1.1,2.0,0.0]}],"awayPlayers":[{"playerId":"a1","number":"5","xyz":[-3.0,4.0,0.0]}]}
+`
+
+const statsPerformFrames = `1600000000000;1,1,0:0,p1,10,52.5,34.0;1,p2,4,60.0,30.0;3,r1,0,50.0,30.0:52.5,34.0,0.0
+1600000000100;2,1,0:0,p1,10,53.5,34.0;1,p2,4,60.0,30.0;3,r1,0,50.0,30.0:52.5,34.0,0.0
+`
+
+const statsPerformFeed = `{"matchInfo":{"contestant":[{"id":"c1","position":"home"},{"id":"c2","position":"away"}]},
+"liveData":{"event":[
+{"id":11,"typeId":1,"timeMin":0,"timeSec":5,"contestantId":"c1","playerId":"p1","x":50.0,"y":50.0,"qualifier":[{"qualifierId":140,"value":"75.0"},{"qualifierId":141,"value":"25.0"}]},
+{"id":12,"typeId":16,"timeMin":46,"timeSec":0,"contestantId":"c2","playerId":"p2","x":90.0,"y":50.0},
+{"id":13,"typeId":999,"timeMin":47,"timeSec":0,"contestantId":"c2","playerId":"p2","x":0.0,"y":100.0}
+]}}`
+
+const statsBombEvents = `[
+{"id":"e1","index":1,"period":1,"timestamp":"00:00:00.000","minute":0,"second":0,"type":{"id":35,"name":"Starting XI"},"possession_team":{"id":217,"name":"Barcelona"},"team":{"id":217,"name":"Barcelona"}},
+{"id":"e2","index":2,"period":1,"timestamp":"00:00:00.000","minute":0,"second":0,"type":{"id":35,"name":"Starting XI"},"possession_team":{"id":217,"name":"Barcelona"},"team":{"id":206,"name":"Alaves"}},
+{"id":"e3","index":3,"period":1,"timestamp":"00:01:01.250","minute":1,"second":1,"type":{"id":30,"name":"Pass"},"possession_team":{"id":217,"name":"Barcelona"},"team":{"id":217,"name":"Barcelona"},"player":{"id":5503,"name":"Lionel Messi"},"location":[60.0,40.0],"pass":{"end_location":[90.0,20.0]}},
+{"id":"e4","index":4,"period":2,"timestamp":"00:00:02.500","minute":45,"second":2,"type":{"id":42,"name":"Ball Receipt*"},"possession_team":{"id":206,"name":"Alaves"},"team":{"id":206,"name":"Alaves"},"player":{"id":6374,"name":"Someone"},"location":[30.0,60.0]}
+]`
+
+// normalize normalizes content and reads the result whole.
+func normalize(t *testing.T, kind, provider string, content []byte) ([]byte, string) {
+	t.Helper()
+	r, detected, err := adapters.Normalize(context.Background(), kind, provider, bytes.NewReader(content))
+	require.NoError(t, err)
+	defer r.Close()
+	out, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return out, detected
+}
+
+// readRows reads the rows of a canonical file.
+func readRows[T any](t *testing.T, content []byte) []T {
+	t.Helper()
+	rows, err := parquet.Read[T](bytes.NewReader(content), int64(len(content)))
+	require.NoError(t, err)
+	return rows
+}
+
+func TestTrackingAdapters(t *testing.T) {
+	t.Run("Tracab positions are converted to meters", func(t *testing.T) {
+		out, provider := normalize(t, "tracking", "", []byte(tracabFrames))
+
+		assert.Equal(t, "tracab", provider)
+		rows := readRows[adapters.TrackingRow](t, out)
+		require.Len(t, rows, 4, "referees are skipped")
+		assert.Equal(t, adapters.TrackingRow{PlayerID: "home_10", TeamID: "home", X: 1, Y: -2}, rows[0])
+		assert.Equal(t, "away_4", rows[1].PlayerID)
+		moved := rows[2]
+		assert.Equal(t, int64(40), moved.TimestampMS)
+		assert.InDelta(t, 1.1, moved.X, 1e-9)
+		assert.Greater(t, moved.SmoothXSpeed, 0.0, "speeds are derived from the positions")
+		assert.Zero(t, moved.SmoothYSpeed)
+	})
+
+	t.Run("Second Spectrum frames are timed by their wall clock", func(t *testing.T) {
+		out, provider := normalize(t, "tracking", "", []byte(secondSpectrumFrames))
+
+		assert.Equal(t, "secondspectrum", provider)
+		rows := readRows[adapters.TrackingRow](t, out)
+		require.Len(t, rows, 4)
+		assert.Equal(t, adapters.TrackingRow{PlayerID: "h1", TeamID: "home", X: 1, Y: 2}, rows[0])
+		assert.Equal(t, "away", rows[1].TeamID)
+		assert.Equal(t, int64(40), rows[2].TimestampMS)
+	})
+
+	t.Run("StatsPerform positions are moved to the centre spot", func(t *testing.T) {
+		out, provider := normalize(t, "tracking", "", []byte(statsPerformFrames))
+
+		assert.Equal(t, "statsperform", provider)
+		rows := readRows[adapters.TrackingRow](t, out)
+		require.Len(t, rows, 4)
+		assert.Equal(t, adapters.TrackingRow{PlayerID: "p1", TeamID: "home"}, rows[0])
+		assert.Equal(t, int64(100), rows[2].TimestampMS)
+		assert.InDelta(t, 1.0, rows[2].X, 1e-9)
+	})
+}
+
+func TestEventAdapters(t *testing.T) {
+	t.Run("StatsPerform positions are converted from percent", func(t *testing.T) {
+		out, provider := normalize(t, "events", "", []byte(statsPerformFeed))
+
+		assert.Equal(t, "statsperform", provider)
+		rows := readRows[adapters.EventRow](t, out)
+		require.Len(t, rows, 3)
+		assert.Equal(t, adapters.EventRow{
+			EventID: "11", EventType: "PASS", PlayerID: "p1", TeamID: "home", TimestampMS: 5000,
+			StartX: 0, StartY: 0, EndX: 26.25, EndY: -17,
+		}, rows[0])
+		assert.Equal(t, "SHOT", rows[1].EventType)
+		assert.Equal(t, "away", rows[1].TeamID)
+		assert.Equal(t, int64(46*60*1000), rows[1].TimestampMS)
+		assert.Equal(t, rows[1].StartX, rows[1].EndX, "events without an end stay at their start")
+		assert.Equal(t, "TYPE_999", rows[2].EventType)
+	})
+
+	t.Run("Opta names the StatsPerform feed", func(t *testing.T) {
+		out, provider := normalize(t, "events", "opta", []byte(statsPerformFeed))
+
+		assert.Equal(t, "opta", provider)
+		assert.Len(t, readRows[adapters.EventRow](t, out), 3)
+	})
+
+	t.Run("StatsBomb positions are converted from yards", func(t *testing.T) {
+		out, provider := normalize(t, "events", "", []byte(statsBombEvents))
+
+		assert.Equal(t, "statsbomb", provider)
+		rows := readRows[adapters.EventRow](t, out)
+		require.Len(t, rows, 2, "lineups have no location")
+		assert.Equal(t, adapters.EventRow{
+			EventID: "e3", EventType: "PASS", PlayerID: "5503", TeamID: "home", TimestampMS: 61250,
+			StartX: 0, StartY: 0, EndX: 26.25, EndY: 17,
+		}, rows[0])
+		assert.Equal(t, "BALL_RECEIPT", rows[1].EventType)
+		assert.Equal(t, "away", rows[1].TeamID)
+		assert.Equal(t, rows[1].StartY, rows[1].EndY)
+	})
+}
+
+func TestNormalize(t *testing.T) {
+	t.Run("Canonical and unknown files pass unchanged", func(t *testing.T) {
+		canonical, _ := normalize(t, "tracking", "", []byte(tracabFrames))
+
+		for _, content := range [][]byte{canonical, []byte("player_id,x,y\n")} {
+			out, provider := normalize(t, "tracking", "", content)
+			assert.Empty(t, provider)
+			assert.Equal(t, content, out)
+		}
+	})
+
+	t.Run("Compressed exports are decompressed", func(t *testing.T) {
+		var compressed bytes.Buffer
+		gz := gzip.NewWriter(&compressed)
+		gz.Write([]byte(tracabFrames))
+		gz.Close()
+
+		out, provider := normalize(t, "tracking", "", compressed.Bytes())
+
+		assert.Equal(t, "tracab", provider)
+		assert.Len(t, readRows[adapters.TrackingRow](t, out), 4)
+	})
+
+	t.Run("A named provider skips detection", func(t *testing.T) {
+		r, provider, err := adapters.Normalize(context.Background(), "tracking", "Tracab", strings.NewReader("not tracab"))
+		require.NoError(t, err)
+		assert.Equal(t, "tracab", provider)
+
+		_, err = io.ReadAll(r)
+
+		var convertErr *adapters.ConvertError
+		require.ErrorAs(t, err, &convertErr)
+		assert.Equal(t, "tracab", convertErr.Provider)
+	})
+
+	t.Run("A provider without an adapter for the kind is detected", func(t *testing.T) {
+		_, provider := normalize(t, "events", "tracab", []byte(statsPerformFeed))
+
+		assert.Equal(t, "statsperform", provider)
+	})
+
+	t.Run("Unknown providers are refused", func(t *testing.T) {
+		_, _, err := adapters.Normalize(context.Background(), "tracking", "hawkeye", strings.NewReader(tracabFrames))
+
+		assert.ErrorIs(t, err, adapters.ErrUnknownProvider)
+		assert.True(t, adapters.ValidProvider(""))
+		assert.Equal(t, []string{"opta", "secondspectrum", "statsbomb", "statsperform", "tracab"}, adapters.Providers())
+	})
+}
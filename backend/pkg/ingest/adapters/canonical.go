@@ -0,0 +1,125 @@
+package adapters
+
+import (
+	"context"
+	"io"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// Pitch dimensions, in meters, assumed when a provider gives relative
+// coordinates.
+const (
+	pitchLength = 105.0
+	pitchWidth  = 68.0
+)
+
+// rowBatch is the number of rows buffered before they are written.
+const rowBatch = 4096
+
+// TrackingRow is a row of a canonical tracking file: a player's position at
+// a moment. Coordinates are in meters with the origin at the centre spot;
+// speeds are in meters per second.
+type TrackingRow struct {
+	PlayerID     string  `parquet:"player_id"`
+	TeamID       string  `parquet:"team_id"`
+	TimestampMS  int64   `parquet:"timestamp_ms"`
+	X            float64 `parquet:"x"`
+	Y            float64 `parquet:"y"`
+	SmoothXSpeed float64 `parquet:"smooth_x_speed"`
+	SmoothYSpeed float64 `parquet:"smooth_y_speed"`
+}
+
+// EventRow is a row of a canonical event file. Event types are upper case,
+// like "PASS" and "SHOT". Coordinates are as in TrackingRow; events without
+// an end location repeat their start.
+type EventRow struct {
+	EventID     string  `parquet:"event_id"`
+	EventType   string  `parquet:"event_type"`
+	PlayerID    string  `parquet:"player_id"`
+	TeamID      string  `parquet:"team_id"`
+	TimestampMS int64   `parquet:"timestamp_ms"`
+	StartX      float64 `parquet:"start_x"`
+	StartY      float64 `parquet:"start_y"`
+	EndX        float64 `parquet:"end_x"`
+	EndY        float64 `parquet:"end_y"`
+}
+
+// rowWriter writes canonical rows in batches as gzip-compressed Parquet.
+type rowWriter[T any] struct {
+	ctx    context.Context
+	writer *parquet.GenericWriter[T]
+	batch  []T
+}
+
+func newRowWriter[T any](ctx context.Context, w io.Writer) *rowWriter[T] {
+	return &rowWriter[T]{
+		ctx:    ctx,
+		writer: parquet.NewGenericWriter[T](w, parquet.Compression(&parquet.Gzip)),
+		batch:  make([]T, 0, rowBatch),
+	}
+}
+
+// Write adds a row, flushing the batch when it is full.
+func (w *rowWriter[T]) Write(row T) error {
+	w.batch = append(w.batch, row)
+	if len(w.batch) < rowBatch {
+		return nil
+	}
+	return w.flush()
+}
+
+func (w *rowWriter[T]) flush() error {
+	if err := w.ctx.Err(); err != nil {
+		return err
+	}
+	if _, err := w.writer.Write(w.batch); err != nil {
+		return err
+	}
+	w.batch = w.batch[:0]
+	return nil
+}
+
+// Close writes the remaining rows and the file footer.
+func (w *rowWriter[T]) Close() error {
+	if err := w.flush(); err != nil {
+		return err
+	}
+	return w.writer.Close()
+}
+
+// velocity is the last position and smoothed velocity of one player.
+type velocity struct {
+	timestampMS int64
+	x, y        float64
+	vx, vy      float64
+}
+
+// velocitySmoothing is the weight of the newest finite difference in the
+// exponentially smoothed speed.
+const velocitySmoothing = 0.3
+
+// velocities derives smoothed speeds from consecutive player positions, for
+// providers that export positions only.
+type velocities map[string]*velocity
+
+// Add records a player's position and fills in the row's smoothed speeds.
+func (v velocities) Add(row *TrackingRow) {
+	last, ok := v[row.PlayerID]
+	if !ok {
+		v[row.PlayerID] = &velocity{timestampMS: row.TimestampMS, x: row.X, y: row.Y}
+		return
+	}
+	if dt := float64(row.TimestampMS-last.timestampMS) / 1000; dt > 0 {
+		last.vx += velocitySmoothing * ((row.X-last.x)/dt - last.vx)
+		last.vy += velocitySmoothing * ((row.Y-last.y)/dt - last.vy)
+	}
+	last.timestampMS, last.x, last.y = row.TimestampMS, row.X, row.Y
+	row.SmoothXSpeed, row.SmoothYSpeed = last.vx, last.vy
+}
+
+// fromPercent converts a coordinate in percent of the pitch, from one
+// corner, to meters from the centre spot.
+func fromPercent(percent, size float64) float64 {
+	return (percent/100 - 0.5) * size
+}
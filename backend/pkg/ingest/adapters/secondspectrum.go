@@ -0,0 +1,92 @@
+package adapters
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// periodMS is the length of a regular period, used to place frames that
+// only carry a game clock.
+const periodMS = 45 * 60 * 1000
+
+// secondSpectrumFrame is a line of a Second Spectrum tracking file.
+type secondSpectrumFrame struct {
+	Period      int                    `json:"period"`
+	FrameIdx    int64                  `json:"frameIdx"`
+	GameClock   float64                `json:"gameClock"` // Seconds into the period
+	WallClock   int64                  `json:"wallClock"` // Unix milliseconds
+	HomePlayers []secondSpectrumPlayer `json:"homePlayers"`
+	AwayPlayers []secondSpectrumPlayer `json:"awayPlayers"`
+}
+
+type secondSpectrumPlayer struct {
+	PlayerID string    `json:"playerId"`
+	XYZ      []float64 `json:"xyz"`
+}
+
+// SecondSpectrum converts Second Spectrum JSONL tracking files: one JSON
+// frame per line with the home and away players' positions in meters from
+// the centre spot. Frames are timed by their wall clock, relative to the
+// first frame, or by period and game clock when the wall clock is missing.
+type SecondSpectrum struct{}
+
+func (SecondSpectrum) Provider() string { return "secondspectrum" }
+func (SecondSpectrum) Kind() string     { return "tracking" }
+
+func (SecondSpectrum) Sniff(head []byte) bool {
+	head = bytes.TrimSpace(head)
+	return bytes.HasPrefix(head, []byte("{")) &&
+		bytes.Contains(head, []byte(`"frameIdx"`)) && bytes.Contains(head, []byte(`"homePlayers"`))
+}
+
+func (SecondSpectrum) Convert(ctx context.Context, r io.Reader, w io.Writer) error {
+	out := newRowWriter[TrackingRow](ctx, w)
+	speeds := velocities{}
+	decoder := json.NewDecoder(r)
+	firstWallClock := int64(0)
+	for {
+		var frame secondSpectrumFrame
+		if err := decoder.Decode(&frame); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return fmt.Errorf("frame after offset %d: %w", decoder.InputOffset(), err)
+		}
+		var timestampMS int64
+		if frame.WallClock > 0 {
+			if firstWallClock == 0 {
+				firstWallClock = frame.WallClock
+			}
+			timestampMS = frame.WallClock - firstWallClock
+		} else {
+			timestampMS = int64(max(frame.Period-1, 0))*periodMS + int64(frame.GameClock*1000)
+		}
+		teams := []struct {
+			id      string
+			players []secondSpectrumPlayer
+		}{{"home", frame.HomePlayers}, {"away", frame.AwayPlayers}}
+		for _, team := range teams {
+			for _, player := range team.players {
+				if len(player.XYZ) < 2 {
+					return fmt.Errorf("frame %d: player %s has no position", frame.FrameIdx, player.PlayerID)
+				}
+				row := TrackingRow{
+					PlayerID:    player.PlayerID,
+					TeamID:      team.id,
+					TimestampMS: timestampMS,
+					X:           player.XYZ[0],
+					Y:           player.XYZ[1],
+				}
+				speeds.Add(&row)
+				if err := out.Write(row); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return out.Close()
+}
@@ -0,0 +1,155 @@
+package adapters
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// StatsBomb pitch dimensions, in yards.
+const (
+	statsBombLength = 120.0
+	statsBombWidth  = 80.0
+)
+
+// statsBombStartingXI is the type ID of the lineup events opening a match,
+// home team first.
+const statsBombStartingXI = 35
+
+type statsBombName struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+type statsBombEnd struct {
+	EndLocation []float64 `json:"end_location"`
+}
+
+// statsBombEvent is an event of a StatsBomb events file.
+type statsBombEvent struct {
+	ID        string         `json:"id"`
+	Timestamp string         `json:"timestamp"` // "00:12:34.567" into the period
+	Minute    int64          `json:"minute"`    // Match minute, counting on through the periods
+	Second    int64          `json:"second"`
+	Type      statsBombName  `json:"type"`
+	Team      statsBombName  `json:"team"`
+	Player    *statsBombName `json:"player"`
+	Location  []float64      `json:"location"` // Yards from the top left corner
+	Pass      *statsBombEnd  `json:"pass"`
+	Carry     *statsBombEnd  `json:"carry"`
+	Shot      *statsBombEnd  `json:"shot"`
+}
+
+// endLocation returns where a pass, carry or shot ended, if the event is one.
+func (e *statsBombEvent) endLocation() []float64 {
+	for _, end := range []*statsBombEnd{e.Pass, e.Carry, e.Shot} {
+		if end != nil && len(end.EndLocation) >= 2 {
+			return end.EndLocation
+		}
+	}
+	return nil
+}
+
+// StatsBomb converts StatsBomb events files: a JSON array of events with
+// locations in yards on a 120 by 80 yard pitch, converted assuming a 105 by
+// 68 m pitch. Teams are "home" and "away" by the order of the lineup events
+// opening the file. Events without a location, like lineups and period
+// starts, are skipped.
+type StatsBomb struct{}
+
+func (StatsBomb) Provider() string { return "statsbomb" }
+func (StatsBomb) Kind() string     { return "events" }
+
+func (StatsBomb) Sniff(head []byte) bool {
+	head = bytes.TrimSpace(head)
+	return bytes.HasPrefix(head, []byte("[")) &&
+		bytes.Contains(head, []byte(`"possession_team"`)) && bytes.Contains(head, []byte(`"timestamp"`))
+}
+
+func (StatsBomb) Convert(ctx context.Context, r io.Reader, w io.Writer) error {
+	decoder := json.NewDecoder(r)
+	if token, err := decoder.Token(); err != nil || token != json.Delim('[') {
+		return fmt.Errorf("expected a JSON array of events")
+	}
+
+	out := newRowWriter[EventRow](ctx, w)
+	teams := map[int64]string{}
+	for decoder.More() {
+		var event statsBombEvent
+		if err := decoder.Decode(&event); err != nil {
+			return fmt.Errorf("event after offset %d: %w", decoder.InputOffset(), err)
+		}
+		if event.Type.ID == statsBombStartingXI && len(teams) < 2 {
+			teams[event.Team.ID] = []string{"home", "away"}[len(teams)]
+		}
+		if len(event.Location) < 2 {
+			continue
+		}
+		team := teams[event.Team.ID]
+		if team == "" {
+			team = event.Team.Name
+		}
+		var player string
+		if event.Player != nil {
+			player = strconv.FormatInt(event.Player.ID, 10)
+		}
+		end := event.endLocation()
+		if end == nil {
+			end = event.Location
+		}
+		row := EventRow{
+			EventID:     event.ID,
+			EventType:   eventTypeName(event.Type.Name),
+			PlayerID:    player,
+			TeamID:      team,
+			TimestampMS: (event.Minute*60+event.Second)*1000 + statsBombMillis(event.Timestamp),
+			StartX:      fromStatsBombX(event.Location[0]),
+			StartY:      fromStatsBombY(event.Location[1]),
+			EndX:        fromStatsBombX(end[0]),
+			EndY:        fromStatsBombY(end[1]),
+		}
+		if err := out.Write(row); err != nil {
+			return err
+		}
+	}
+	if _, err := decoder.Token(); err != nil {
+		return fmt.Errorf("unterminated array of events: %w", err)
+	}
+	return out.Close()
+}
+
+// fromStatsBombX converts a StatsBomb x to meters from the centre spot.
+func fromStatsBombX(x float64) float64 {
+	return (x/statsBombLength - 0.5) * pitchLength
+}
+
+// fromStatsBombY converts a StatsBomb y, which grows downwards, to meters
+// from the centre spot, growing upwards.
+func fromStatsBombY(y float64) float64 {
+	return (0.5 - y/statsBombWidth) * pitchWidth
+}
+
+// statsBombMillis returns the milliseconds of a "HH:MM:SS.mmm" timestamp.
+func statsBombMillis(timestamp string) int64 {
+	_, fraction, ok := strings.Cut(timestamp, ".")
+	if !ok {
+		return 0
+	}
+	fraction = (fraction + "000")[:3]
+	ms, _ := strconv.ParseInt(fraction, 10, 64)
+	return ms
+}
+
+// eventTypeName converts a provider's event type name to the canonical
+// upper case form: "Ball Receipt*" becomes "BALL_RECEIPT".
+func eventTypeName(name string) string {
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.ToUpper(strings.Join(words, "_"))
+}
@@ -0,0 +1,237 @@
+package adapters
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// statsPerformTrackingPattern matches the start of a StatsPerform MA25
+// frame: a Unix millisecond timestamp, then the frame number and period.
+var statsPerformTrackingPattern = regexp.MustCompile(`^\d{10,};\d+,\d+`)
+
+// statsPerformTeams names the StatsPerform object types of players; other
+// types are referees and the ball, which are skipped.
+var statsPerformTeams = map[string]string{"0": "home", "1": "away"}
+
+// StatsPerformTracking converts StatsPerform MA25 tracking files. Each line
+// is a frame:
+//
+//	<unix ms>;<frame>,<period>,<status>:<type>,<player id>,<shirt>,<x>,<y>;...:<ball>
+//
+// with positions in meters from the bottom left corner of a 105 by 68 m
+// pitch. Frames are timed relative to the first frame.
+type StatsPerformTracking struct{}
+
+func (StatsPerformTracking) Provider() string { return "statsperform" }
+func (StatsPerformTracking) Kind() string     { return "tracking" }
+
+func (StatsPerformTracking) Sniff(head []byte) bool {
+	return statsPerformTrackingPattern.Match(head)
+}
+
+func (StatsPerformTracking) Convert(ctx context.Context, r io.Reader, w io.Writer) error {
+	out := newRowWriter[TrackingRow](ctx, w)
+	speeds := velocities{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64<<10), 1<<20)
+	firstMS := int64(-1)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		sections := strings.Split(text, ":")
+		header, _, found := strings.Cut(sections[0], ";")
+		if len(sections) < 2 || !found {
+			return fmt.Errorf("line %d: expected timestamp, frame and objects", line)
+		}
+		ms, err := strconv.ParseInt(header, 10, 64)
+		if err != nil {
+			return fmt.Errorf("line %d: invalid timestamp %q", line, header)
+		}
+		if firstMS < 0 {
+			firstMS = ms
+		}
+		for _, object := range strings.Split(sections[1], ";") {
+			if object == "" {
+				continue
+			}
+			fields := strings.Split(object, ",")
+			if len(fields) < 5 {
+				return fmt.Errorf("line %d: invalid object %q", line, object)
+			}
+			team, ok := statsPerformTeams[fields[0]]
+			if !ok {
+				continue
+			}
+			x, errX := strconv.ParseFloat(fields[3], 64)
+			y, errY := strconv.ParseFloat(fields[4], 64)
+			if errX != nil || errY != nil {
+				return fmt.Errorf("line %d: invalid position in object %q", line, object)
+			}
+			row := TrackingRow{
+				PlayerID:    fields[1],
+				TeamID:      team,
+				TimestampMS: ms - firstMS,
+				X:           x - pitchLength/2,
+				Y:           y - pitchWidth/2,
+			}
+			speeds.Add(&row)
+			if err := out.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+// statsPerformEventTypes names the MA3 event type IDs. Shots of every
+// outcome are "SHOT"; unlisted types are named by their ID, "TYPE_<id>".
+var statsPerformEventTypes = map[int]string{
+	1:  "PASS",
+	2:  "OFFSIDE_PASS",
+	3:  "TAKE_ON",
+	4:  "FOUL",
+	5:  "OUT",
+	6:  "CORNER_AWARDED",
+	7:  "TACKLE",
+	8:  "INTERCEPTION",
+	10: "SAVE",
+	11: "CLAIM",
+	12: "CLEARANCE",
+	13: "SHOT",
+	14: "SHOT",
+	15: "SHOT",
+	16: "SHOT",
+	17: "CARD",
+	18: "PLAYER_OFF",
+	19: "PLAYER_ON",
+	44: "AERIAL",
+	49: "BALL_RECOVERY",
+	50: "DISPOSSESSED",
+	51: "ERROR",
+	61: "BALL_TOUCH",
+}
+
+// MA3 qualifiers holding the end of a pass.
+const (
+	qualifierPassEndX = 140
+	qualifierPassEndY = 141
+)
+
+// statsPerformMatch is a StatsPerform MA3 match events feed.
+type statsPerformMatch struct {
+	MatchInfo struct {
+		Contestant []struct {
+			ID       string `json:"id"`
+			Position string `json:"position"` // "home" or "away"
+		} `json:"contestant"`
+	} `json:"matchInfo"`
+	LiveData struct {
+		Event []statsPerformEvent `json:"event"`
+	} `json:"liveData"`
+}
+
+type statsPerformEvent struct {
+	ID           int64   `json:"id"`
+	TypeID       int     `json:"typeId"`
+	TimeMin      int64   `json:"timeMin"` // Match minute, counting on through the periods
+	TimeSec      int64   `json:"timeSec"`
+	ContestantID string  `json:"contestantId"`
+	PlayerID     string  `json:"playerId"`
+	X            float64 `json:"x"` // Percent of the pitch length
+	Y            float64 `json:"y"` // Percent of the pitch width
+	Qualifier    []struct {
+		QualifierID int    `json:"qualifierId"`
+		Value       string `json:"value"`
+	} `json:"qualifier"`
+}
+
+// StatsPerformEvents converts StatsPerform (Opta) MA3 match event feeds.
+// Positions are given in percent of the pitch and converted assuming a 105
+// by 68 m pitch; teams are named "home" and "away" by the feed's
+// contestants.
+type StatsPerformEvents struct{}
+
+func (StatsPerformEvents) Provider() string { return "statsperform" }
+func (StatsPerformEvents) Kind() string     { return "events" }
+
+func (StatsPerformEvents) Sniff(head []byte) bool {
+	head = bytes.TrimSpace(head)
+	if !bytes.HasPrefix(head, []byte("{")) {
+		return false
+	}
+	// The match info may fill the head before the events start
+	return bytes.Contains(head, []byte(`"liveData"`)) ||
+		bytes.Contains(head, []byte(`"typeId"`)) && bytes.Contains(head, []byte(`"contestantId"`))
+}
+
+func (StatsPerformEvents) Convert(ctx context.Context, r io.Reader, w io.Writer) error {
+	var feed statsPerformMatch
+	if err := json.NewDecoder(r).Decode(&feed); err != nil {
+		return fmt.Errorf("failed to parse feed: %w", err)
+	}
+	teams := map[string]string{}
+	for _, contestant := range feed.MatchInfo.Contestant {
+		teams[contestant.ID] = contestant.Position
+	}
+
+	out := newRowWriter[EventRow](ctx, w)
+	for _, event := range feed.LiveData.Event {
+		eventType, ok := statsPerformEventTypes[event.TypeID]
+		if !ok {
+			eventType = "TYPE_" + strconv.Itoa(event.TypeID)
+		}
+		team := teams[event.ContestantID]
+		if team == "" {
+			team = event.ContestantID
+		}
+		endX, endY := event.X, event.Y
+		for _, qualifier := range event.Qualifier {
+			value, err := strconv.ParseFloat(qualifier.Value, 64)
+			if err != nil {
+				continue
+			}
+			switch qualifier.QualifierID {
+			case qualifierPassEndX:
+				endX = value
+			case qualifierPassEndY:
+				endY = value
+			}
+		}
+		row := EventRow{
+			EventID:     strconv.FormatInt(event.ID, 10),
+			EventType:   eventType,
+			PlayerID:    event.PlayerID,
+			TeamID:      team,
+			TimestampMS: (event.TimeMin*60 + event.TimeSec) * 1000,
+			StartX:      fromPercent(event.X, pitchLength),
+			StartY:      fromPercent(event.Y, pitchWidth),
+			EndX:        fromPercent(endX, pitchLength),
+			EndY:        fromPercent(endY, pitchWidth),
+		}
+		if err := out.Write(row); err != nil {
+			return err
+		}
+	}
+	return out.Close()
+}
+
+// Opta converts Opta event feeds, which StatsPerform delivers as MA3 feeds.
+// It lets uploads and imports name the provider by its original brand;
+// detection reports Opta feeds as StatsPerform's.
+type Opta struct {
+	StatsPerformEvents
+}
+
+func (Opta) Provider() string { return "opta" }
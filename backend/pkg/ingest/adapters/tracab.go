@@ -0,0 +1,96 @@
+package adapters
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// tracabFrameMS is the time between Tracab frames, which are 25 per second.
+const tracabFrameMS = 40
+
+// tracabPattern matches the start of a Tracab DAT frame: the frame number,
+// then the first target's team, tracking ID, jersey and position.
+var tracabPattern = regexp.MustCompile(`^\d+:-?\d+,-?\d+,-?\d+,-?\d+,-?\d+,`)
+
+// tracabTeams names the Tracab team codes of players; other codes are
+// referees and unknown objects, which are skipped.
+var tracabTeams = map[string]string{"1": "home", "0": "away"}
+
+// Tracab converts Tracab DAT tracking files. Each line is a frame:
+//
+//	<frame>:<team>,<tracking id>,<jersey>,<x>,<y>,<speed>;...;:<ball>;:
+//
+// with positions in centimeters from the centre spot. Players are
+// identified by team and jersey, "home_10", as tracking IDs are not stable
+// across a match.
+type Tracab struct{}
+
+func (Tracab) Provider() string { return "tracab" }
+func (Tracab) Kind() string     { return "tracking" }
+
+func (Tracab) Sniff(head []byte) bool {
+	return tracabPattern.Match(head)
+}
+
+func (Tracab) Convert(ctx context.Context, r io.Reader, w io.Writer) error {
+	out := newRowWriter[TrackingRow](ctx, w)
+	speeds := velocities{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64<<10), 1<<20)
+	firstFrame := int64(-1)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		sections := strings.Split(text, ":")
+		if len(sections) < 2 {
+			return fmt.Errorf("line %d: expected frame and targets", line)
+		}
+		frame, err := strconv.ParseInt(sections[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("line %d: invalid frame number %q", line, sections[0])
+		}
+		if firstFrame < 0 {
+			firstFrame = frame
+		}
+		for _, target := range strings.Split(sections[1], ";") {
+			if target == "" {
+				continue
+			}
+			fields := strings.Split(target, ",")
+			if len(fields) < 5 {
+				return fmt.Errorf("line %d: invalid target %q", line, target)
+			}
+			team, ok := tracabTeams[fields[0]]
+			if !ok {
+				continue
+			}
+			x, errX := strconv.ParseFloat(fields[3], 64)
+			y, errY := strconv.ParseFloat(fields[4], 64)
+			if errX != nil || errY != nil {
+				return fmt.Errorf("line %d: invalid position in target %q", line, target)
+			}
+			row := TrackingRow{
+				PlayerID:    team + "_" + fields[2],
+				TeamID:      team,
+				TimestampMS: (frame - firstFrame) * tracabFrameMS,
+				X:           x / 100,
+				Y:           y / 100,
+			}
+			speeds.Add(&row)
+			if err := out.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return out.Close()
+}
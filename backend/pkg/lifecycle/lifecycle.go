@@ -0,0 +1,181 @@
+// Package lifecycle coordinates graceful shutdown so deploys do not cut off
+// in-flight requests, long uploads or WebSocket connections.
+//
+// Shutdown runs in phases:
+//
+//  1. Draining: readiness reports 503 so load balancers stop routing new
+//     traffic here, while the server keeps serving whatever still arrives.
+//  2. After the pre-stop delay, Stopping is signalled: the HTTP server stops
+//     accepting connections and long-lived connections (WebSockets) are asked
+//     to go away.
+//  3. Tracked work is waited for until it finishes or the drain timeout
+//     expires, whichever comes first.
+package lifecycle
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Config tunes the shutdown phases. Zero values use the defaults.
+type Config struct {
+	PreStopDelay time.Duration // Draining time before the server stops accepting connections (default 5s)
+	DrainTimeout time.Duration // Maximum wait for in-flight work after that (default 30s)
+}
+
+// Server is the part of *http.Server that Shutdown uses.
+type Server interface {
+	Shutdown(ctx context.Context) error
+}
+
+// Manager tracks in-flight work and sequences a graceful shutdown.
+type Manager struct {
+	cfg Config
+
+	drainOnce sync.Once
+	draining  chan struct{}
+	stopOnce  sync.Once
+	stopping  chan struct{}
+
+	mu       sync.Mutex
+	inFlight int
+	idle     chan struct{} // Closed and replaced whenever inFlight drops to 0
+}
+
+// New creates a manager in the ready state.
+func New(cfg Config) *Manager {
+	if cfg.PreStopDelay <= 0 {
+		cfg.PreStopDelay = 5 * time.Second
+	}
+	if cfg.DrainTimeout <= 0 {
+		cfg.DrainTimeout = 30 * time.Second
+	}
+	return &Manager{
+		cfg:      cfg,
+		draining: make(chan struct{}),
+		stopping: make(chan struct{}),
+		idle:     make(chan struct{}),
+	}
+}
+
+// Ready reports whether the instance should receive new traffic. It turns
+// false once draining starts and never turns back.
+func (m *Manager) Ready() bool {
+	select {
+	case <-m.draining:
+		return false
+	default:
+		return true
+	}
+}
+
+// Draining is closed when draining starts.
+func (m *Manager) Draining() <-chan struct{} {
+	return m.draining
+}
+
+// Stopping is closed when the server stops accepting connections; holders of
+// long-lived connections should then close them.
+func (m *Manager) Stopping() <-chan struct{} {
+	return m.stopping
+}
+
+// InFlight returns the number of tracked requests and connections.
+func (m *Manager) InFlight() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.inFlight
+}
+
+// Hold tracks one unit of work, such as a hijacked connection the HTTP server
+// no longer sees, until the returned release function is called. Calling
+// release more than once has no further effect.
+func (m *Manager) Hold() (release func()) {
+	m.mu.Lock()
+	m.inFlight++
+	m.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			m.inFlight--
+			if m.inFlight == 0 {
+				close(m.idle)
+				m.idle = make(chan struct{})
+			}
+		})
+	}
+}
+
+// Track is middleware holding every request for its duration. While
+// draining, responses close their keep-alive connection so clients reconnect
+// through the load balancer to another instance.
+func (m *Manager) Track(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		release := m.Hold()
+		defer release()
+		if !m.Ready() {
+			w.Header().Set("Connection", "close")
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Wait blocks until no work is in flight or ctx is done.
+func (m *Manager) Wait(ctx context.Context) error {
+	for {
+		m.mu.Lock()
+		if m.inFlight == 0 {
+			m.mu.Unlock()
+			return nil
+		}
+		idle := m.idle
+		m.mu.Unlock()
+
+		select {
+		case <-idle:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Drain starts draining. It is safe to call more than once.
+func (m *Manager) Drain() {
+	m.drainOnce.Do(func() { close(m.draining) })
+}
+
+// Shutdown drains, waits the pre-stop delay, stops server and then waits for
+// tracked work until the drain timeout. It returns an error if work was still
+// in flight when the timeout expired; the caller should then close server
+// forcibly. Cancelling ctx skips the remaining waits.
+func (m *Manager) Shutdown(ctx context.Context, server Server) error {
+	m.Drain()
+	log.Printf("Lifecycle: draining, readiness reports unavailable for %s before stopping", m.cfg.PreStopDelay)
+	select {
+	case <-time.After(m.cfg.PreStopDelay):
+	case <-ctx.Done():
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, m.cfg.DrainTimeout)
+	defer cancel()
+
+	m.stopOnce.Do(func() { close(m.stopping) })
+	log.Printf("Lifecycle: stopping, waiting up to %s for %d in-flight requests and connections", m.cfg.DrainTimeout, m.InFlight())
+
+	// server.Shutdown returns once the server's own connections are idle;
+	// hijacked connections are only visible to the manager.
+	if err := server.Shutdown(ctx); err != nil {
+		return err
+	}
+	if err := m.Wait(ctx); err != nil {
+		log.Printf("Lifecycle: drain timeout expired with %d requests and connections in flight", m.InFlight())
+		return err
+	}
+	return nil
+}
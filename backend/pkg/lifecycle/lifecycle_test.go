@@ -0,0 +1,90 @@
+package lifecycle_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"nivai/backend/pkg/lifecycle"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeServer records when Shutdown is called.
+type fakeServer struct {
+	calledAt time.Time
+}
+
+func (s *fakeServer) Shutdown(ctx context.Context) error {
+	s.calledAt = time.Now()
+	return nil
+}
+
+func TestManager(t *testing.T) {
+	t.Run("Shutdown drains, stops and waits for in-flight work", func(t *testing.T) {
+		manager := lifecycle.New(lifecycle.Config{PreStopDelay: 50 * time.Millisecond, DrainTimeout: time.Second})
+		release := manager.Hold() // e.g. a WebSocket connection
+		assert.True(t, manager.Ready())
+
+		server := &fakeServer{}
+		done := make(chan error, 1)
+		start := time.Now()
+		go func() { done <- manager.Shutdown(context.Background(), server) }()
+
+		<-manager.Draining()
+		assert.False(t, manager.Ready(), "readiness flips before the server stops")
+
+		<-manager.Stopping()
+		select {
+		case <-done:
+			t.Fatal("Shutdown returned while work was in flight")
+		case <-time.After(50 * time.Millisecond):
+		}
+		release()
+		release() // Releasing twice is harmless
+
+		require.NoError(t, <-done)
+		assert.GreaterOrEqual(t, server.calledAt.Sub(start), 50*time.Millisecond, "server stops after the pre-stop delay")
+		assert.Equal(t, 0, manager.InFlight())
+	})
+
+	t.Run("Drain timeout", func(t *testing.T) {
+		manager := lifecycle.New(lifecycle.Config{PreStopDelay: time.Millisecond, DrainTimeout: 20 * time.Millisecond})
+		manager.Hold()
+		err := manager.Shutdown(context.Background(), &fakeServer{})
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Equal(t, 1, manager.InFlight())
+	})
+
+	t.Run("Track holds requests and closes connections while draining", func(t *testing.T) {
+		manager := lifecycle.New(lifecycle.Config{})
+		var inFlight int
+		handler := manager.Track(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			inFlight = manager.InFlight()
+		}))
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+		assert.Equal(t, 1, inFlight)
+		assert.Empty(t, rr.Header().Get("Connection"))
+
+		manager.Drain()
+		rr = httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+		assert.Equal(t, "close", rr.Header().Get("Connection"))
+		assert.Equal(t, 0, manager.InFlight())
+	})
+
+	t.Run("Listen", func(t *testing.T) {
+		first, err := lifecycle.Listen(context.Background(), "127.0.0.1:0", true)
+		require.NoError(t, err)
+		defer first.Close()
+
+		second, err := lifecycle.Listen(context.Background(), first.Addr().String(), true)
+		require.NoError(t, err, "a second listener can bind the same port")
+		second.Close()
+	})
+}
@@ -0,0 +1,17 @@
+package lifecycle
+
+import (
+	"context"
+	"net"
+)
+
+// Listen opens the TCP listener for the HTTP server. With reusePort the socket
+// is opened with SO_REUSEPORT, so a new process can bind the same port and
+// start accepting connections while the old one is still draining.
+func Listen(ctx context.Context, address string, reusePort bool) (net.Listener, error) {
+	var lc net.ListenConfig
+	if reusePort {
+		lc.Control = reusePortControl
+	}
+	return lc.Listen(ctx, "tcp", address)
+}
@@ -0,0 +1,13 @@
+//go:build !(linux || darwin || freebsd)
+
+package lifecycle
+
+import (
+	"errors"
+	"syscall"
+)
+
+// reusePortControl fails: SO_REUSEPORT is not available on this platform.
+func reusePortControl(network, address string, conn syscall.RawConn) error {
+	return errors.New("SO_REUSEPORT is not supported on this platform")
+}
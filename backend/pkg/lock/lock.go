@@ -0,0 +1,132 @@
+// Package lock provides named locks shared by the API's replicas, so work
+// that must happen once, such as a scheduled job or reprocessing a match,
+// does not run on two replicas at the same time.
+package lock
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrHeld is returned when taking a lock another holder has.
+	ErrHeld = errors.New("lock is held elsewhere")
+	// ErrLost is returned when refreshing a lock that is no longer held.
+	ErrLost = errors.New("lock was lost")
+)
+
+// RefreshInterval is how often Do refreshes a held lock. Locks that expire
+// must outlive it several times over.
+const RefreshInterval = 10 * time.Second
+
+// Locker takes named locks.
+type Locker interface {
+	// TryLock takes the named lock without waiting. It returns ErrHeld when
+	// another holder has it.
+	TryLock(ctx context.Context, name string) (Lock, error)
+}
+
+// Lock is a held lock.
+type Lock interface {
+	// Refresh checks that the lock is still held, extending it where it
+	// expires. It returns ErrLost when it is not.
+	Refresh(ctx context.Context) error
+	// Release gives the lock up.
+	Release(ctx context.Context) error
+}
+
+// Do runs fn holding the named lock, refreshing it while fn runs, and
+// releases it afterwards. It returns ErrHeld without running fn when
+// another holder has the lock. fn's context is cancelled when the lock is
+// lost, as the work may then run elsewhere.
+func Do(ctx context.Context, locker Locker, name string, fn func(ctx context.Context) error) error {
+	held, err := locker.TryLock(ctx, name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// Released even when ctx is done, so others need not wait for expiry
+		releaseCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := held.Release(releaseCtx); err != nil {
+			log.Printf("Lock: failed to release %s: %v", name, err)
+		}
+	}()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	refreshed := make(chan struct{})
+	go func() {
+		defer close(refreshed)
+		ticker := time.NewTicker(RefreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			if err := held.Refresh(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("Lock: lost %s, stopping its work: %v", name, err)
+				cancel()
+				return
+			}
+		}
+	}()
+
+	err = fn(ctx)
+	cancel()
+	<-refreshed
+	return err
+}
+
+// Local locks within this process, for deployments of one replica and
+// tests.
+type Local struct {
+	mu   sync.Mutex
+	held map[string]*localLock
+}
+
+// NewLocal creates a process-local locker.
+func NewLocal() *Local {
+	return &Local{held: make(map[string]*localLock)}
+}
+
+// TryLock takes the named lock if no one in this process holds it.
+func (l *Local) TryLock(_ context.Context, name string) (Lock, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.held[name]; ok {
+		return nil, ErrHeld
+	}
+	held := &localLock{locker: l, name: name}
+	l.held[name] = held
+	return held, nil
+}
+
+// localLock is a lock held in this process.
+type localLock struct {
+	locker *Local
+	name   string
+}
+
+func (h *localLock) Refresh(context.Context) error {
+	h.locker.mu.Lock()
+	defer h.locker.mu.Unlock()
+	if h.locker.held[h.name] != h {
+		return ErrLost
+	}
+	return nil
+}
+
+func (h *localLock) Release(context.Context) error {
+	h.locker.mu.Lock()
+	defer h.locker.mu.Unlock()
+	if h.locker.held[h.name] == h {
+		delete(h.locker.held, h.name)
+	}
+	return nil
+}
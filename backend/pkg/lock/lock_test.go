@@ -0,0 +1,112 @@
+package lock_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"nivai/backend/pkg/lock"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// exerciseLocker checks the behaviour every locker shares.
+func exerciseLocker(t *testing.T, locker lock.Locker) {
+	ctx := context.Background()
+
+	held, err := locker.TryLock(ctx, "job:retention")
+	require.NoError(t, err)
+	_, err = locker.TryLock(ctx, "job:retention")
+	assert.ErrorIs(t, err, lock.ErrHeld)
+
+	other, err := locker.TryLock(ctx, "job:storage_gc")
+	require.NoError(t, err, "names lock independently")
+	require.NoError(t, other.Release(ctx))
+
+	require.NoError(t, held.Refresh(ctx))
+	require.NoError(t, held.Release(ctx))
+	again, err := locker.TryLock(ctx, "job:retention")
+	require.NoError(t, err, "released locks can be taken again")
+	require.NoError(t, again.Release(ctx))
+}
+
+func TestLocal(t *testing.T) {
+	exerciseLocker(t, lock.NewLocal())
+}
+
+func TestRedis(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer client.Close()
+
+	exerciseLocker(t, lock.NewRedis(client, "test:lock:", time.Minute))
+
+	t.Run("Expired locks are lost", func(t *testing.T) {
+		locker := lock.NewRedis(client, "test:lock:", time.Minute)
+		held, err := locker.TryLock(context.Background(), "reconciler")
+		require.NoError(t, err)
+		assert.True(t, server.Exists("test:lock:reconciler"))
+
+		server.FastForward(2 * time.Minute)
+		_, err = locker.TryLock(context.Background(), "reconciler")
+		require.NoError(t, err, "an expired lock is free")
+		assert.ErrorIs(t, held.Refresh(context.Background()), lock.ErrLost)
+	})
+}
+
+func TestPostgres(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT pg_try_advisory_lock").WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(true))
+	mock.ExpectExec("SELECT 1").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("SELECT pg_advisory_unlock").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT pg_try_advisory_lock").WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(false))
+
+	locker := lock.NewPostgres(db)
+	held, err := locker.TryLock(context.Background(), "scheduler")
+	require.NoError(t, err)
+	require.NoError(t, held.Refresh(context.Background()))
+	require.NoError(t, held.Release(context.Background()))
+
+	_, err = locker.TryLock(context.Background(), "scheduler")
+	assert.ErrorIs(t, err, lock.ErrHeld)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDo(t *testing.T) {
+	locker := lock.NewLocal()
+
+	t.Run("Runs holding the lock", func(t *testing.T) {
+		err := lock.Do(context.Background(), locker, "reprocess:m1", func(ctx context.Context) error {
+			_, err := locker.TryLock(ctx, "reprocess:m1")
+			assert.ErrorIs(t, err, lock.ErrHeld)
+			return errors.New("analytics unavailable")
+		})
+		assert.EqualError(t, err, "analytics unavailable")
+
+		held, err := locker.TryLock(context.Background(), "reprocess:m1")
+		require.NoError(t, err, "released afterwards")
+		require.NoError(t, held.Release(context.Background()))
+	})
+
+	t.Run("Does not run while held elsewhere", func(t *testing.T) {
+		held, err := locker.TryLock(context.Background(), "reprocess:m2")
+		require.NoError(t, err)
+		defer held.Release(context.Background())
+
+		ran := false
+		err = lock.Do(context.Background(), locker, "reprocess:m2", func(context.Context) error {
+			ran = true
+			return nil
+		})
+		assert.ErrorIs(t, err, lock.ErrHeld)
+		assert.False(t, ran)
+	})
+}
@@ -0,0 +1,89 @@
+package lock
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"hash/fnv"
+)
+
+// Postgres locks with PostgreSQL session advisory locks. Each held lock
+// keeps one connection out of the pool, so it is released when its holder
+// releases it, exits or loses the connection; it never expires while the
+// holder runs.
+type Postgres struct {
+	db *sql.DB
+}
+
+// NewPostgres creates a locker on the database's advisory locks.
+func NewPostgres(db *sql.DB) *Postgres {
+	return &Postgres{db: db}
+}
+
+// TryLock takes the advisory lock whose key is derived from name.
+func (p *Postgres) TryLock(ctx context.Context, name string) (Lock, error) {
+	conn, err := p.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get a connection for lock %s: %w", name, err)
+	}
+	key := advisoryKey(name)
+	var locked bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&locked); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to take lock %s: %w", name, err)
+	}
+	if !locked {
+		conn.Close()
+		return nil, ErrHeld
+	}
+	return &postgresLock{conn: conn, key: key}, nil
+}
+
+// advisoryKey maps a lock name to an advisory lock key.
+func advisoryKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("nivai:" + name))
+	return int64(h.Sum64())
+}
+
+// postgresLock is an advisory lock held on its connection.
+type postgresLock struct {
+	conn *sql.Conn
+	key  int64
+}
+
+// Refresh checks the connection holding the lock is alive; the lock goes
+// with it.
+func (l *postgresLock) Refresh(ctx context.Context) error {
+	if l.conn == nil {
+		return ErrLost
+	}
+	if _, err := l.conn.ExecContext(ctx, "SELECT 1"); err != nil {
+		l.discard()
+		return fmt.Errorf("%w: %v", ErrLost, err)
+	}
+	return nil
+}
+
+// Release unlocks and returns the connection to the pool.
+func (l *postgresLock) Release(ctx context.Context) error {
+	if l.conn == nil {
+		return nil
+	}
+	if _, err := l.conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", l.key); err != nil {
+		l.discard()
+		return err
+	}
+	err := l.conn.Close()
+	l.conn = nil
+	return err
+}
+
+// discard closes the connection instead of returning it to the pool, where
+// it would keep a lock it may still hold.
+func (l *postgresLock) discard() {
+	l.conn.Raw(func(any) error { return driver.ErrBadConn })
+	l.conn.Close()
+	l.conn = nil
+}
@@ -0,0 +1,65 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-redsync/redsync/v4"
+	"github.com/go-redsync/redsync/v4/redis/goredis/v9"
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis locks with Redis keys that expire, using the Redlock algorithm. A
+// lock whose holder stops refreshing it, e.g. because it crashed, is free
+// again after its TTL.
+type Redis struct {
+	sync   *redsync.Redsync
+	prefix string
+	ttl    time.Duration
+}
+
+// NewRedis creates a locker keeping its locks under prefix, expiring after
+// ttl without a refresh (default 30s).
+func NewRedis(client redis.UniversalClient, prefix string, ttl time.Duration) *Redis {
+	if ttl <= 0 {
+		ttl = 30 * time.Second
+	}
+	return &Redis{sync: redsync.New(goredis.NewPool(client)), prefix: prefix, ttl: ttl}
+}
+
+// TryLock sets the lock's key if it is not set.
+func (r *Redis) TryLock(ctx context.Context, name string) (Lock, error) {
+	mutex := r.sync.NewMutex(r.prefix+name, redsync.WithExpiry(r.ttl), redsync.WithTries(1))
+	if err := mutex.TryLockContext(ctx); err != nil {
+		var taken *redsync.ErrTaken
+		if errors.Is(err, redsync.ErrFailed) || errors.As(err, &taken) {
+			return nil, ErrHeld
+		}
+		return nil, fmt.Errorf("failed to take lock %s: %w", name, err)
+	}
+	return &redisLock{mutex: mutex}, nil
+}
+
+// redisLock is a held Redis lock.
+type redisLock struct {
+	mutex *redsync.Mutex
+}
+
+// Refresh extends the lock's expiry.
+func (l *redisLock) Refresh(ctx context.Context) error {
+	if ok, err := l.mutex.ExtendContext(ctx); !ok {
+		return fmt.Errorf("%w: %v", ErrLost, err)
+	}
+	return nil
+}
+
+// Release deletes the lock's key, if it still holds it.
+func (l *redisLock) Release(ctx context.Context) error {
+	_, err := l.mutex.UnlockContext(ctx)
+	if errors.Is(err, redsync.ErrLockAlreadyExpired) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,107 @@
+// Package logging filters the standard log output by level. The code base
+// logs with the standard library's log package, and by convention marks
+// problems in the message: "Warning: ..." for warnings and "Error ..." or
+// "Failed ..." for errors. Filter reads those markers, so the log level can
+// be changed at runtime without touching the call sites.
+package logging
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+)
+
+// Level is the severity of a log line
+type Level int32
+
+// Levels from least to most severe
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// levelNames are the configuration names of the levels
+var levelNames = []string{"debug", "info", "warn", "error"}
+
+// String returns the configuration name of the level
+func (l Level) String() string {
+	if l < LevelDebug || l > LevelError {
+		return fmt.Sprintf("level(%d)", l)
+	}
+	return levelNames[l]
+}
+
+// ParseLevel parses a level name: debug, info, warn or error
+func ParseLevel(name string) (Level, error) {
+	for i, n := range levelNames {
+		if strings.EqualFold(name, n) {
+			return Level(i), nil
+		}
+	}
+	return LevelInfo, fmt.Errorf("unknown log level %q; use one of %s", name, strings.Join(levelNames, ", "))
+}
+
+// current is the level below which lines are dropped
+var current atomic.Int32
+
+func init() {
+	current.Store(int32(LevelInfo))
+}
+
+// SetLevel changes the level of every Filter; it is safe to call while
+// logging
+func SetLevel(level Level) {
+	current.Store(int32(level))
+}
+
+// CurrentLevel returns the level set with SetLevel, info by default
+func CurrentLevel() Level {
+	return Level(current.Load())
+}
+
+// markers classify a line by the first marker it contains
+var markers = []struct {
+	text  string
+	level Level
+}{
+	{"Debug:", LevelDebug},
+	{"Warning", LevelWarn},
+	{"Error", LevelError},
+	{"Failed", LevelError},
+	{"failed", LevelError},
+	{"panic", LevelError},
+}
+
+// Classify returns the level of a log line from its markers; unmarked lines
+// are info
+func Classify(line []byte) Level {
+	for _, m := range markers {
+		if bytes.Contains(line, []byte(m.text)) {
+			return m.level
+		}
+	}
+	return LevelInfo
+}
+
+// filter drops the lines below the current level
+type filter struct {
+	next io.Writer
+}
+
+// Filter wraps a log output, dropping lines below the current level. Each
+// Write is one line, as the log package writes them.
+func Filter(next io.Writer) io.Writer {
+	return filter{next: next}
+}
+
+// Write passes p on when its level is enabled; dropped lines count as written
+func (f filter) Write(p []byte) (int, error) {
+	if Classify(p) < CurrentLevel() {
+		return len(p), nil
+	}
+	return f.next.Write(p)
+}
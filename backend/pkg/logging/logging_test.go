@@ -0,0 +1,48 @@
+package logging_test
+
+import (
+	"bytes"
+	"log"
+	"testing"
+
+	"nivai/backend/pkg/logging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLevel(t *testing.T) {
+	level, err := logging.ParseLevel("WARN")
+	require.NoError(t, err)
+	assert.Equal(t, logging.LevelWarn, level)
+	assert.Equal(t, "warn", level.String())
+
+	_, err = logging.ParseLevel("verbose")
+	assert.ErrorContains(t, err, "unknown log level")
+}
+
+func TestClassify(t *testing.T) {
+	assert.Equal(t, logging.LevelDebug, logging.Classify([]byte("2026/01/02 10:00:00 Debug: cache miss")))
+	assert.Equal(t, logging.LevelInfo, logging.Classify([]byte("2026/01/02 10:00:00 Retention sweep finished")))
+	assert.Equal(t, logging.LevelWarn, logging.Classify([]byte("2026/01/02 10:00:00 Warning: Malware scanning disabled")))
+	assert.Equal(t, logging.LevelError, logging.Classify([]byte("2026/01/02 10:00:00 Error encoding response")))
+	assert.Equal(t, logging.LevelError, logging.Classify([]byte("AIFAA API: Failed to listen on port 8080")))
+}
+
+func TestFilter(t *testing.T) {
+	defer logging.SetLevel(logging.CurrentLevel())
+
+	var out bytes.Buffer
+	logger := log.New(logging.Filter(&out), "", 0)
+
+	logging.SetLevel(logging.LevelWarn)
+	logger.Printf("Retention sweep finished")
+	logger.Printf("Warning: Message broker disabled")
+	logger.Printf("Error retrieving audit")
+	assert.Equal(t, "Warning: Message broker disabled\nError retrieving audit\n", out.String())
+
+	out.Reset()
+	logging.SetLevel(logging.LevelDebug)
+	logger.Printf("Debug: cache miss")
+	assert.Equal(t, "Debug: cache miss\n", out.String())
+}
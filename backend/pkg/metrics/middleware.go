@@ -0,0 +1,110 @@
+// Package metrics records per-request observations from the HTTP layer and
+// fans them out to observers such as the SLO tracker.
+package metrics
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Observation describes a single completed HTTP request.
+type Observation struct {
+	Group    string // Route group, e.g. "reads" or "uploads"
+	Method   string
+	Route    string // Route template if known, otherwise the request path
+	Status   int
+	Duration time.Duration
+	At       time.Time // When the request completed
+}
+
+// Observer receives request observations. Observe is called on the request
+// goroutine and must be fast and safe for concurrent use.
+type Observer interface {
+	Observe(Observation)
+}
+
+// Classifier assigns a request to a route group and returns its route label.
+// An empty group means the request is not observed.
+type Classifier func(r *http.Request) (group, route string)
+
+// Middleware times each request and reports it to the observers.
+// The classifier runs after the handler, so route information set during
+// routing (e.g. mux.CurrentRoute) is available.
+func Middleware(classify Classifier, observers ...Observer) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+			next.ServeHTTP(recorder, r)
+
+			if recorder.hijacked {
+				return // Long-lived connections (WebSockets) are not request/response traffic
+			}
+			group, route := classify(r)
+			if group == "" {
+				return
+			}
+
+			end := time.Now()
+			observation := Observation{
+				Group:    group,
+				Method:   r.Method,
+				Route:    route,
+				Status:   recorder.status,
+				Duration: end.Sub(start),
+				At:       end,
+			}
+			for _, observer := range observers {
+				observer.Observe(observation)
+			}
+		})
+	}
+}
+
+// statusRecorder captures the response status while passing through
+// streaming and connection hijacking support.
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	hijacked    bool
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	if !s.wroteHeader {
+		s.status = code
+		s.wroteHeader = true
+	}
+	s.ResponseWriter.WriteHeader(code)
+}
+
+func (s *statusRecorder) Write(b []byte) (int, error) {
+	s.wroteHeader = true
+	return s.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher when the underlying writer does.
+func (s *statusRecorder) Flush() {
+	if flusher, ok := s.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
+
+// Hijack implements http.Hijacker when the underlying writer does.
+func (s *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := s.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not support hijacking")
+	}
+	s.hijacked = true
+	return hijacker.Hijack()
+}
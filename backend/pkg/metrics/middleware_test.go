@@ -0,0 +1,58 @@
+package metrics_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"nivai/backend/pkg/metrics"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingObserver struct {
+	observations []metrics.Observation
+}
+
+func (r *recordingObserver) Observe(o metrics.Observation) {
+	r.observations = append(r.observations, o)
+}
+
+func TestMiddleware(t *testing.T) {
+	classify := func(r *http.Request) (string, string) {
+		if r.URL.Path == "/ignored" {
+			return "", r.URL.Path
+		}
+		return "reads", r.URL.Path
+	}
+
+	t.Run("Records status and route", func(t *testing.T) {
+		observer := &recordingObserver{}
+		handler := metrics.Middleware(classify, observer)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "boom", http.StatusBadGateway)
+		}))
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/v1/matches", nil))
+
+		require.Len(t, observer.observations, 1)
+		obs := observer.observations[0]
+		assert.Equal(t, "reads", obs.Group)
+		assert.Equal(t, "/api/v1/matches", obs.Route)
+		assert.Equal(t, http.StatusBadGateway, obs.Status)
+		assert.False(t, obs.At.IsZero())
+	})
+
+	t.Run("Implicit 200 and unclassified requests", func(t *testing.T) {
+		observer := &recordingObserver{}
+		handler := metrics.Middleware(classify, observer)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("ok"))
+		}))
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/v1/health", nil))
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/ignored", nil))
+
+		require.Len(t, observer.observations, 1)
+		assert.Equal(t, http.StatusOK, observer.observations[0].Status)
+	})
+}
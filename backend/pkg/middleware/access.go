@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"nivai/backend/pkg/httperr"
+	"nivai/backend/pkg/requestctx"
+
+	"github.com/gorilla/mux"
+)
+
+/**
+ * MatchAuthorizer decides whether the caller of a request may use a match,
+ * or a team's matches as a whole.
+ */
+type MatchAuthorizer interface {
+	CanAccessMatch(principal requestctx.Principal, matchID string, write bool) (bool, error)
+	CanAccessTeam(principal requestctx.Principal, team string) (bool, error)
+}
+
+/**
+ * RequireMatchAccess restricts a route to callers with access to the match
+ * the request names. Must be applied after Authenticate, which places the
+ * caller in the context.
+ *
+ * @param authz Decides on access
+ * @param param Where the request names the match: a path variable, e.g.
+ *              "id", or a query parameter prefixed with "?", e.g.
+ *              "?match_id". Requests without it pass.
+ * @param write Whether the route changes the match
+ * @return The middleware
+ */
+func RequireMatchAccess(authz MatchAuthorizer, param string, write bool) mux.MiddlewareFunc {
+	return requireAccess(param, "No access to this match", func(principal requestctx.Principal, id string) (bool, error) {
+		return authz.CanAccessMatch(principal, id, write)
+	})
+}
+
+/**
+ * RequireTeamAccess restricts a route to callers with access to all matches
+ * of the team the request names. Must be applied after Authenticate.
+ *
+ * @param authz Decides on access
+ * @param param Where the request names the team, as for RequireMatchAccess
+ * @return The middleware
+ */
+func RequireTeamAccess(authz MatchAuthorizer, param string) mux.MiddlewareFunc {
+	return requireAccess(param, "No access to this team", authz.CanAccessTeam)
+}
+
+// requireAccess builds a middleware refusing requests for resources allow
+// denies.
+func requireAccess(param, denied string, allow func(requestctx.Principal, string) (bool, error)) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := mux.Vars(r)[param]
+			if name, ok := strings.CutPrefix(param, "?"); ok {
+				id = r.URL.Query().Get(name)
+			}
+			if id == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			info := requestctx.From(r)
+			allowed, err := allow(info.Principal, id)
+			if err != nil {
+				info.Logger.Printf("Error checking access of user %q to %s: %v", info.Principal.UserID, id, err)
+				httperr.WriteError(w, r, httperr.Internal("Failed to check access"))
+				return
+			}
+			if !allowed {
+				httperr.WriteError(w, r, httperr.New(http.StatusForbidden, httperr.CodeForbidden, denied))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
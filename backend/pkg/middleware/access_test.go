@@ -0,0 +1,65 @@
+package middleware_test
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"nivai/backend/pkg/middleware"
+	"nivai/backend/pkg/requestctx"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+// grantTable allows the users listed per match or team; writes need an
+// entry marked edit.
+type grantTable map[string]map[string]string
+
+func (g grantTable) CanAccessMatch(principal requestctx.Principal, matchID string, write bool) (bool, error) {
+	if matchID == "broken" {
+		return false, errors.New("grant store down")
+	}
+	permission, ok := g[matchID][principal.UserID]
+	return ok && (!write || permission == "edit"), nil
+}
+
+func (g grantTable) CanAccessTeam(principal requestctx.Principal, team string) (bool, error) {
+	_, ok := g[team][principal.UserID]
+	return ok, nil
+}
+
+func TestRequireMatchAccess(t *testing.T) {
+	grants := grantTable{"m1": {"viewer": "view", "editor": "edit"}, "Ajax": {"viewer": "view"}}
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+
+	router := mux.NewRouter()
+	router.Handle("/matches/{id}", middleware.RequireMatchAccess(grants, "id", false)(ok)).Methods("GET")
+	router.Handle("/matches/{id}", middleware.RequireMatchAccess(grants, "id", true)(ok)).Methods("DELETE")
+	router.Handle("/players/{id}", middleware.RequireMatchAccess(grants, "?match_id", false)(ok)).Methods("GET")
+	router.Handle("/teams/{id}", middleware.RequireTeamAccess(grants, "id")(ok)).Methods("GET")
+	request := func(method, target, userID string) int {
+		req := httptest.NewRequest(method, target, nil)
+		req = req.WithContext(requestctx.NewContext(req.Context(), &requestctx.Info{
+			Principal: requestctx.Principal{UserID: userID}, Logger: log.Default(),
+		}))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	assert.Equal(t, http.StatusOK, request("GET", "/matches/m1", "viewer"))
+	assert.Equal(t, http.StatusForbidden, request("GET", "/matches/m2", "viewer"))
+	assert.Equal(t, http.StatusForbidden, request("DELETE", "/matches/m1", "viewer"), "writes need edit")
+	assert.Equal(t, http.StatusOK, request("DELETE", "/matches/m1", "editor"))
+	assert.Equal(t, http.StatusInternalServerError, request("GET", "/matches/broken", "viewer"))
+
+	assert.Equal(t, http.StatusOK, request("GET", "/players/p1?match_id=m1", "viewer"))
+	assert.Equal(t, http.StatusForbidden, request("GET", "/players/p1?match_id=m2", "viewer"))
+	assert.Equal(t, http.StatusOK, request("GET", "/players/p1", "viewer"), "requests naming no match pass")
+
+	assert.Equal(t, http.StatusOK, request("GET", "/teams/Ajax", "viewer"))
+	assert.Equal(t, http.StatusForbidden, request("GET", "/teams/PSV", "viewer"))
+}
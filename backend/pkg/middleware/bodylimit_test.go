@@ -0,0 +1,51 @@
+package middleware_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"nivai/backend/pkg/middleware"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimitBody(t *testing.T) {
+	// read answers 413 when the body cannot be read past its limit
+	read := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var tooLarge *http.MaxBytesError
+		if _, err := io.ReadAll(r.Body); errors.As(err, &tooLarge) {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	serve := func(handler http.Handler, body string, chunked bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/v1/matches", strings.NewReader(body))
+		if chunked {
+			req.ContentLength = -1
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("Bodies declared too large are refused unread", func(t *testing.T) {
+		rr := serve(middleware.LimitBody(1<<10)(http.NotFoundHandler()), strings.Repeat("x", 1<<10+1), false)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+		assert.Contains(t, rr.Body.String(), "Maximum size is 1KB")
+	})
+
+	t.Run("Bodies without a length stop at the limit", func(t *testing.T) {
+		handler := middleware.LimitBody(16)(read)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, serve(handler, strings.Repeat("x", 17), true).Code)
+		assert.Equal(t, http.StatusOK, serve(handler, strings.Repeat("x", 16), true).Code)
+	})
+
+	t.Run("No limit leaves bodies alone", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve(middleware.LimitBody(0)(read), strings.Repeat("x", 1<<10), true).Code)
+	})
+}
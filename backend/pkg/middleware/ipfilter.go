@@ -0,0 +1,266 @@
+package middleware
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync"
+
+	"nivai/backend/pkg/httperr"
+	"nivai/backend/pkg/requestctx"
+)
+
+const (
+	// IPScopeAdmin restricts a route to the admin allowlist
+	IPScopeAdmin = "admin"
+	// IPScopeUpload restricts a route to the upload allowlist
+	IPScopeUpload = "upload"
+)
+
+/**
+ * IPFilterRules configures an IPFilter. Addresses are IPs or CIDR ranges,
+ * e.g. "203.0.113.7" or "10.0.0.0/8". Empty lists restrict nothing.
+ */
+type IPFilterRules struct {
+	AdminAllowlist   []string // Addresses that may call admin routes
+	UploadAllowlist  []string // Addresses that may upload match files
+	Denylist         []string // Addresses refused on every route
+	TrustedProxies   []string // Proxies whose X-Forwarded-For and country header are believed
+	CountryHeader    string   // Header a trusted proxy puts the client's country in, e.g. "CF-IPCountry"
+	AllowedCountries []string // ISO 3166 country codes allowed on every route
+	DeniedCountries  []string // ISO 3166 country codes refused on every route
+}
+
+// ipRules are IPFilterRules parsed for matching
+type ipRules struct {
+	allow            map[string][]netip.Prefix
+	deny             []netip.Prefix
+	proxies          []netip.Prefix
+	countryHeader    string
+	allowedCountries map[string]bool
+	deniedCountries  map[string]bool
+}
+
+/**
+ * IPFilter refuses requests by client address and country: addresses on
+ * the denylist or from denied countries are refused everywhere, and routes
+ * of a scope only accept addresses on the scope's allowlist, when it has
+ * one. Refused requests get 403 Forbidden and are logged for audit.
+ *
+ * The client address is the remote address, or for requests through a
+ * trusted proxy the last address in X-Forwarded-For that is not a trusted
+ * proxy itself. The country is only read from requests through a trusted
+ * proxy, as clients could set it themselves otherwise. The rules can be
+ * replaced at runtime.
+ */
+type IPFilter struct {
+	mu    sync.RWMutex
+	rules ipRules
+}
+
+/**
+ * NewIPFilter creates an IP filter.
+ *
+ * @param rules The allowlists, denylist, trusted proxies and countries
+ * @return A new IP filter, or an error naming an invalid address
+ */
+func NewIPFilter(rules IPFilterRules) (*IPFilter, error) {
+	f := &IPFilter{}
+	if err := f.SetRules(rules); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+/**
+ * SetRules replaces the rules, e.g. on a configuration reload. Invalid
+ * rules leave the current ones in place.
+ *
+ * @param rules The allowlists, denylist, trusted proxies and countries
+ * @return An error naming an invalid address
+ */
+func (f *IPFilter) SetRules(rules IPFilterRules) error {
+	parsed := ipRules{
+		allow:            map[string][]netip.Prefix{},
+		countryHeader:    rules.CountryHeader,
+		allowedCountries: countrySet(rules.AllowedCountries),
+		deniedCountries:  countrySet(rules.DeniedCountries),
+	}
+	var err error
+	for scope, list := range map[string][]string{IPScopeAdmin: rules.AdminAllowlist, IPScopeUpload: rules.UploadAllowlist} {
+		if parsed.allow[scope], err = ParseIPPrefixes(list); err != nil {
+			return fmt.Errorf("invalid %s allowlist: %w", scope, err)
+		}
+	}
+	if parsed.deny, err = ParseIPPrefixes(rules.Denylist); err != nil {
+		return fmt.Errorf("invalid denylist: %w", err)
+	}
+	if parsed.proxies, err = ParseIPPrefixes(rules.TrustedProxies); err != nil {
+		return fmt.Errorf("invalid trusted proxies: %w", err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rules = parsed
+	return nil
+}
+
+/**
+ * Restrict returns middleware enforcing the denylist, the countries and the
+ * allowlists of the given scopes. A nil filter restricts nothing.
+ *
+ * @param scopes The scopes of the route, e.g. IPScopeAdmin
+ * @return Middleware refusing requests the rules do not allow
+ */
+func (f *IPFilter) Restrict(scopes ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if f == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			f.mu.RLock()
+			rules := f.rules
+			f.mu.RUnlock()
+
+			ip, country := rules.client(r)
+			if reason := rules.refusal(ip, country, scopes); reason != "" {
+				client := "unknown address"
+				if ip.IsValid() {
+					client = ip.String()
+				}
+				requestctx.From(r).Logger.Printf("Audit: %s %s from %s refused: %s", r.Method, r.URL.Path, client, reason)
+				httperr.WriteError(w, r, httperr.New(http.StatusForbidden, httperr.CodeForbidden, "Access from this address is not allowed"))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+/**
+ * ParseIPPrefixes parses IPs and CIDR ranges. IPs become single-address
+ * ranges.
+ *
+ * @param entries IPs or CIDR ranges, e.g. "203.0.113.7" or "10.0.0.0/8"
+ * @return The ranges, or an error naming the first invalid entry
+ */
+func ParseIPPrefixes(entries []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if strings.Contains(entry, "/") {
+			prefix, err := netip.ParsePrefix(entry)
+			if err != nil {
+				return nil, fmt.Errorf("%q is not an IP or CIDR range", entry)
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(entry)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an IP or CIDR range", entry)
+		}
+		addr = addr.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
+
+/**
+ * client finds the address and country of the client of a request.
+ *
+ * @param r The HTTP request
+ * @return The client address, invalid when it cannot be parsed, and its
+ *         upper-case country code, empty when unknown
+ */
+func (rules ipRules) client(r *http.Request) (netip.Addr, string) {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, ""
+	}
+	ip = ip.Unmap()
+	if !inPrefixes(rules.proxies, ip) {
+		return ip, ""
+	}
+
+	country := ""
+	if rules.countryHeader != "" {
+		country = strings.ToUpper(strings.TrimSpace(r.Header.Get(rules.countryHeader)))
+	}
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if hop == "" {
+			continue
+		}
+		forwarded, err := netip.ParseAddr(hop)
+		if err != nil {
+			return netip.Addr{}, country
+		}
+		ip = forwarded.Unmap()
+		if !inPrefixes(rules.proxies, ip) {
+			break
+		}
+	}
+	return ip, country
+}
+
+/**
+ * refusal decides whether a client may call a route of the given scopes.
+ *
+ * @param ip The client address; invalid when unknown
+ * @param country The client's country; empty when unknown
+ * @param scopes The scopes of the route
+ * @return Why the client is refused, or "" when it is allowed
+ */
+func (rules ipRules) refusal(ip netip.Addr, country string, scopes []string) string {
+	if ip.IsValid() && inPrefixes(rules.deny, ip) {
+		return "address is denylisted"
+	}
+	if country != "" && rules.deniedCountries[country] {
+		return "country " + country + " is denied"
+	}
+	if len(rules.allowedCountries) > 0 && !rules.allowedCountries[country] {
+		if country == "" {
+			return "country is unknown"
+		}
+		return "country " + country + " is not allowed"
+	}
+	for _, scope := range scopes {
+		allowed := rules.allow[scope]
+		if len(allowed) > 0 && (!ip.IsValid() || !inPrefixes(allowed, ip)) {
+			return "address is not on the " + scope + " allowlist"
+		}
+	}
+	return ""
+}
+
+// inPrefixes reports whether any of the ranges holds ip.
+func inPrefixes(prefixes []netip.Prefix, ip netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// countrySet builds an upper-case set of country codes.
+func countrySet(codes []string) map[string]bool {
+	set := make(map[string]bool, len(codes))
+	for _, code := range codes {
+		if code = strings.ToUpper(strings.TrimSpace(code)); code != "" {
+			set[code] = true
+		}
+	}
+	return set
+}
@@ -0,0 +1,124 @@
+package middleware_test
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"nivai/backend/pkg/middleware"
+	"nivai/backend/pkg/requestctx"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIPFilter(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+
+	request := func(handler http.Handler, remoteAddr string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/v1/admin/config/reload", nil)
+		req.RemoteAddr = remoteAddr
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("Scoped routes only accept their allowlist", func(t *testing.T) {
+		filter, err := middleware.NewIPFilter(middleware.IPFilterRules{
+			AdminAllowlist:  []string{"10.0.0.0/8", "203.0.113.7"},
+			UploadAllowlist: []string{"192.168.1.0/24"},
+		})
+		require.NoError(t, err)
+		admin := filter.Restrict(middleware.IPScopeAdmin)(ok)
+		unscoped := filter.Restrict()(ok)
+
+		assert.Equal(t, http.StatusOK, request(admin, "10.20.30.40:1234", nil).Code)
+		assert.Equal(t, http.StatusOK, request(admin, "203.0.113.7:1234", nil).Code)
+		assert.Equal(t, http.StatusOK, request(admin, "[::ffff:10.0.0.1]:1234", nil).Code, "IPv4-mapped addresses match IPv4 ranges")
+		assert.Equal(t, http.StatusForbidden, request(admin, "203.0.113.8:1234", nil).Code)
+		assert.Equal(t, http.StatusForbidden, request(admin, "192.168.1.5:1234", nil).Code)
+		assert.Equal(t, http.StatusOK, request(unscoped, "198.51.100.1:1234", nil).Code)
+	})
+
+	t.Run("Denylisted addresses are refused everywhere and audited", func(t *testing.T) {
+		var logs bytes.Buffer
+		filter, err := middleware.NewIPFilter(middleware.IPFilterRules{
+			AdminAllowlist: []string{"10.0.0.0/8"},
+			Denylist:       []string{"10.6.6.0/24"},
+		})
+		require.NoError(t, err)
+		handler := filter.Restrict()(ok)
+
+		req := httptest.NewRequest("GET", "/api/v1/videos", nil)
+		req.RemoteAddr = "10.6.6.6:1234"
+		req = req.WithContext(requestctx.NewContext(req.Context(), &requestctx.Info{Logger: log.New(&logs, "", 0)}))
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"forbidden"`)
+		assert.Equal(t, "Audit: GET /api/v1/videos from 10.6.6.6 refused: address is denylisted\n", logs.String())
+		assert.Equal(t, http.StatusForbidden, request(filter.Restrict(middleware.IPScopeAdmin)(ok), "10.6.6.7:1", nil).Code)
+	})
+
+	t.Run("Forwarded addresses and countries are only believed from trusted proxies", func(t *testing.T) {
+		filter, err := middleware.NewIPFilter(middleware.IPFilterRules{
+			AdminAllowlist:  []string{"203.0.113.0/24"},
+			TrustedProxies:  []string{"10.0.0.0/8"},
+			CountryHeader:   "CF-IPCountry",
+			DeniedCountries: []string{"kp"},
+		})
+		require.NoError(t, err)
+		admin := filter.Restrict(middleware.IPScopeAdmin)(ok)
+
+		// The client is the last address that is not a trusted proxy; earlier ones are set by the client
+		forwarded := map[string]string{"X-Forwarded-For": "198.51.100.1, 203.0.113.9, 10.0.0.2"}
+		assert.Equal(t, http.StatusOK, request(admin, "10.0.0.1:1234", forwarded).Code)
+		spoofed := map[string]string{"X-Forwarded-For": "203.0.113.9, 198.51.100.1"}
+		assert.Equal(t, http.StatusForbidden, request(admin, "10.0.0.1:1234", spoofed).Code)
+		assert.Equal(t, http.StatusForbidden, request(admin, "198.51.100.1:1234", map[string]string{"X-Forwarded-For": "203.0.113.9"}).Code)
+
+		denied := map[string]string{"X-Forwarded-For": "203.0.113.9", "CF-IPCountry": "KP"}
+		assert.Equal(t, http.StatusForbidden, request(admin, "10.0.0.1:1234", denied).Code)
+		assert.Equal(t, http.StatusOK, request(admin, "203.0.113.9:1234", map[string]string{"CF-IPCountry": "KP"}).Code, "clients cannot set their country")
+	})
+
+	t.Run("Allowed countries refuse unknown countries", func(t *testing.T) {
+		filter, err := middleware.NewIPFilter(middleware.IPFilterRules{
+			TrustedProxies:   []string{"10.0.0.1"},
+			CountryHeader:    "CF-IPCountry",
+			AllowedCountries: []string{"NL", "BE"},
+		})
+		require.NoError(t, err)
+		handler := filter.Restrict()(ok)
+
+		assert.Equal(t, http.StatusOK, request(handler, "10.0.0.1:1", map[string]string{"CF-IPCountry": "nl"}).Code)
+		assert.Equal(t, http.StatusForbidden, request(handler, "10.0.0.1:1", map[string]string{"CF-IPCountry": "DE"}).Code)
+		assert.Equal(t, http.StatusForbidden, request(handler, "10.0.0.1:1", nil).Code)
+	})
+
+	t.Run("Rules can be replaced and invalid rules are refused", func(t *testing.T) {
+		filter, err := middleware.NewIPFilter(middleware.IPFilterRules{Denylist: []string{"198.51.100.1"}})
+		require.NoError(t, err)
+		handler := filter.Restrict()(ok)
+		assert.Equal(t, http.StatusForbidden, request(handler, "198.51.100.1:1", nil).Code)
+
+		require.NoError(t, filter.SetRules(middleware.IPFilterRules{}))
+		assert.Equal(t, http.StatusOK, request(handler, "198.51.100.1:1", nil).Code)
+
+		assert.Error(t, filter.SetRules(middleware.IPFilterRules{Denylist: []string{"198.51.100.1", "not-an-ip"}}))
+		assert.Equal(t, http.StatusOK, request(handler, "198.51.100.1:1", nil).Code, "invalid rules leave the current ones in place")
+		_, err = middleware.NewIPFilter(middleware.IPFilterRules{AdminAllowlist: []string{"10.0.0.0/33"}})
+		assert.Error(t, err)
+	})
+
+	t.Run("A nil filter restricts nothing", func(t *testing.T) {
+		var filter *middleware.IPFilter
+		assert.Equal(t, http.StatusOK, request(filter.Restrict(middleware.IPScopeAdmin)(ok), "198.51.100.1:1", nil).Code)
+	})
+}
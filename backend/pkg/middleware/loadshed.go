@@ -0,0 +1,181 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"nivai/backend/pkg/httperr"
+)
+
+/**
+ * DependencyCheck probes one dependency (database, storage, ...). A non-nil
+ * error marks the dependency as degraded.
+ */
+type DependencyCheck func(ctx context.Context) error
+
+/**
+ * LoadShedderConfig tunes a LoadShedder. Zero values use the defaults.
+ */
+type LoadShedderConfig struct {
+	MaxInFlightWrites int           // Concurrent sheddable writes before new ones are rejected; 0 disables the limit
+	RetryAfter        time.Duration // Advertised in the Retry-After header (default 30s)
+	CheckInterval     time.Duration // Time between dependency probes (default 10s)
+	CheckTimeout      time.Duration // Timeout of one probe (default 3s)
+}
+
+/**
+ * LoadShedder rejects non-critical writes early with 503 Service Unavailable
+ * while a dependency is degraded or too many writes are already in flight,
+ * so uploads do not pile onto a struggling database or storage backend.
+ * Reads are never shed. Dependency health is probed in the background by Run,
+ * so the request path only reads the last result.
+ */
+type LoadShedder struct {
+	cfg    LoadShedderConfig
+	checks map[string]DependencyCheck
+
+	inFlight atomic.Int64
+
+	mu       sync.RWMutex
+	degraded map[string]error
+}
+
+/**
+ * NewLoadShedder creates a load shedder for the given dependency checks,
+ * keyed by dependency name. All dependencies start out healthy.
+ *
+ * @param cfg Shedding limits and probe timing
+ * @param checks Dependency probes by name
+ * @return A new load shedder
+ */
+func NewLoadShedder(cfg LoadShedderConfig, checks map[string]DependencyCheck) *LoadShedder {
+	if cfg.RetryAfter <= 0 {
+		cfg.RetryAfter = 30 * time.Second
+	}
+	if cfg.CheckInterval <= 0 {
+		cfg.CheckInterval = 10 * time.Second
+	}
+	if cfg.CheckTimeout <= 0 {
+		cfg.CheckTimeout = 3 * time.Second
+	}
+	return &LoadShedder{cfg: cfg, checks: checks, degraded: map[string]error{}}
+}
+
+/**
+ * Run probes the dependencies every check interval until ctx is cancelled.
+ *
+ * @param ctx Context controlling the probe loop
+ */
+func (s *LoadShedder) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.CheckInterval)
+	defer ticker.Stop()
+	for {
+		s.Check(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+/**
+ * Check probes every dependency once, concurrently, and records which are
+ * degraded. Changes in health are logged.
+ *
+ * @param ctx Context for the probes
+ */
+func (s *LoadShedder) Check(ctx context.Context) {
+	results := make(map[string]error, len(s.checks))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range s.checks {
+		wg.Add(1)
+		go func(name string, check DependencyCheck) {
+			defer wg.Done()
+			probeCtx, cancel := context.WithTimeout(ctx, s.cfg.CheckTimeout)
+			defer cancel()
+			err := check(probeCtx)
+			mu.Lock()
+			results[name] = err
+			mu.Unlock()
+		}(name, check)
+	}
+	wg.Wait()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for name, err := range results {
+		_, wasDegraded := s.degraded[name]
+		switch {
+		case err != nil && !wasDegraded:
+			log.Printf("Load shedding: %s degraded, rejecting non-critical writes: %v", name, err)
+		case err == nil && wasDegraded:
+			log.Printf("Load shedding: %s recovered", name)
+		}
+		if err != nil {
+			s.degraded[name] = err
+		} else {
+			delete(s.degraded, name)
+		}
+	}
+}
+
+/**
+ * Degraded returns the names of the dependencies that failed their last
+ * probe, sorted.
+ *
+ * @return The degraded dependencies
+ */
+func (s *LoadShedder) Degraded() []string {
+	if s == nil {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	names := make([]string, 0, len(s.degraded))
+	for name := range s.degraded {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+/**
+ * Shed wraps a sheddable write. While a dependency is degraded, or
+ * MaxInFlightWrites writes are already being served, requests get 503 with
+ * a Retry-After header instead of reaching the handler. A nil shedder sheds
+ * nothing.
+ *
+ * @param next The route handler
+ * @return The guarded handler
+ */
+func (s *LoadShedder) Shed(next http.Handler) http.Handler {
+	if s == nil {
+		return next
+	}
+	retryAfter := strconv.Itoa(int(s.cfg.RetryAfter.Seconds()))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if degraded := s.Degraded(); len(degraded) > 0 {
+			w.Header().Set("Retry-After", retryAfter)
+			httperr.WriteError(w, r, httperr.Unavailable("Service degraded ("+strings.Join(degraded, ", ")+"), please retry later"))
+			return
+		}
+
+		inFlight := s.inFlight.Add(1)
+		defer s.inFlight.Add(-1)
+		if s.cfg.MaxInFlightWrites > 0 && inFlight > int64(s.cfg.MaxInFlightWrites) {
+			w.Header().Set("Retry-After", retryAfter)
+			httperr.WriteError(w, r, httperr.Unavailable("Server busy, please retry later"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
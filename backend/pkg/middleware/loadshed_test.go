@@ -0,0 +1,93 @@
+package middleware_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"nivai/backend/pkg/middleware"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadShedder(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+	request := func(handler http.Handler) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("POST", "/api/v1/videos", nil))
+		return rr
+	}
+
+	t.Run("Writes are shed while a dependency is degraded", func(t *testing.T) {
+		var dbErr error
+		shedder := middleware.NewLoadShedder(middleware.LoadShedderConfig{RetryAfter: 15 * time.Second},
+			map[string]middleware.DependencyCheck{
+				"database": func(ctx context.Context) error { return dbErr },
+				"storage":  func(ctx context.Context) error { return nil },
+			})
+		handler := shedder.Shed(ok)
+
+		shedder.Check(context.Background())
+		assert.Equal(t, http.StatusOK, request(handler).Code)
+
+		dbErr = errors.New("connection refused")
+		shedder.Check(context.Background())
+		assert.Equal(t, []string{"database"}, shedder.Degraded())
+		rr := request(handler)
+		assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+		assert.Equal(t, "15", rr.Header().Get("Retry-After"))
+		assert.Contains(t, rr.Body.String(), "database")
+
+		dbErr = nil
+		shedder.Check(context.Background())
+		assert.Empty(t, shedder.Degraded())
+		assert.Equal(t, http.StatusOK, request(handler).Code)
+	})
+
+	t.Run("Probes time out", func(t *testing.T) {
+		shedder := middleware.NewLoadShedder(middleware.LoadShedderConfig{CheckTimeout: 10 * time.Millisecond},
+			map[string]middleware.DependencyCheck{
+				"storage": func(ctx context.Context) error { <-ctx.Done(); return ctx.Err() },
+			})
+		shedder.Check(context.Background())
+		assert.Equal(t, []string{"storage"}, shedder.Degraded())
+	})
+
+	t.Run("Writes over the in-flight limit are shed", func(t *testing.T) {
+		release := make(chan struct{})
+		var entered sync.WaitGroup
+		entered.Add(2)
+		blocking := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			entered.Done()
+			<-release
+			w.WriteHeader(http.StatusOK)
+		})
+		handler := middleware.NewLoadShedder(middleware.LoadShedderConfig{MaxInFlightWrites: 2}, nil).Shed(blocking)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 2; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				assert.Equal(t, http.StatusOK, request(handler).Code)
+			}()
+		}
+		entered.Wait()
+
+		rr := request(handler)
+		assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+		assert.Equal(t, "30", rr.Header().Get("Retry-After"))
+
+		close(release)
+		wg.Wait()
+	})
+
+	t.Run("A nil shedder sheds nothing", func(t *testing.T) {
+		var shedder *middleware.LoadShedder
+		assert.Equal(t, http.StatusOK, request(shedder.Shed(ok)).Code)
+	})
+}
@@ -5,20 +5,38 @@ import (
 	"log"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
+	"nivai/backend/pkg/httperr"
+	"nivai/backend/pkg/requestctx"
+
 	"github.com/google/uuid"
 )
 
 // ContextKey type for request context keys
 type ContextKey string
 
+// The individual context keys are still populated for existing callers; new
+// code reads the request-scoped bundle with requestctx.From instead.
 const (
-	// RequestIDKey is the key used to store request ID in context
+	// RequestIDKey is the key used to store request ID in context.
+	// Deprecated: use requestctx.From(r).RequestID.
 	RequestIDKey ContextKey = "requestID"
 
-	// UserIDKey is the key used to store authenticated user ID in context
+	// UserIDKey is the key used to store authenticated user ID in context.
+	// Deprecated: use requestctx.From(r).Principal.UserID.
 	UserIDKey ContextKey = "userID"
+
+	// UserRoleKey is the key used to store the authenticated user's role in context.
+	// Deprecated: use requestctx.From(r).Principal.Role.
+	UserRoleKey ContextKey = "userRole"
+)
+
+// Roles of authenticated users
+const (
+	RoleAdmin = "admin" // Required for administrative endpoints
+	RoleUser  = "user"  // Every other user
 )
 
 /**
@@ -41,10 +59,10 @@ func Logger(next http.Handler) http.Handler {
 		// Calculate request duration
 		duration := time.Since(start)
 
-		// Get request ID from context if available
-		requestID := "unknown"
-		if id, ok := r.Context().Value(RequestIDKey).(string); ok {
-			requestID = id
+		// Get request ID from the request bundle if available
+		requestID := requestctx.From(r).RequestID
+		if requestID == "" {
+			requestID = "unknown"
 		}
 
 		// Log request details
@@ -61,15 +79,97 @@ func Logger(next http.Handler) http.Handler {
 
 /**
  * CORS middleware adds Cross-Origin Resource Sharing headers to responses.
- * Configures which origins, methods, and headers are allowed.
+ * Configures which origins, methods, and headers are allowed. It allows any
+ * origin; use a CORSPolicy to restrict them.
  *
  * @param next The next handler in the chain
  * @return An http.Handler that handles CORS
  */
 func CORS(next http.Handler) http.Handler {
+	return NewCORSPolicy([]string{"*"}).Handler(next)
+}
+
+/**
+ * CORSPolicy adds Cross-Origin Resource Sharing headers for a set of allowed
+ * origins that can be replaced at runtime.
+ */
+type CORSPolicy struct {
+	mu      sync.RWMutex
+	any     bool
+	origins map[string]bool
+}
+
+/**
+ * NewCORSPolicy creates a policy allowing the given origins.
+ *
+ * @param origins Allowed origins, e.g. "https://app.example.com"; "*" allows any
+ * @return A new CORS policy
+ */
+func NewCORSPolicy(origins []string) *CORSPolicy {
+	p := &CORSPolicy{}
+	p.SetOrigins(origins)
+	return p
+}
+
+/**
+ * SetOrigins replaces the allowed origins, e.g. on a configuration reload.
+ *
+ * @param origins Allowed origins; "*" allows any
+ */
+func (p *CORSPolicy) SetOrigins(origins []string) {
+	allowed := make(map[string]bool, len(origins))
+	anyOrigin := false
+	for _, origin := range origins {
+		if origin == "*" {
+			anyOrigin = true
+		}
+		allowed[strings.TrimRight(origin, "/")] = true
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.any = anyOrigin
+	p.origins = allowed
+}
+
+/**
+ * allowOrigin returns the Access-Control-Allow-Origin value for a request
+ * origin.
+ *
+ * @param origin The Origin header of the request
+ * @return "*", the origin itself, or "" when it is not allowed; and whether
+ *         the answer depends on the origin, so responses must Vary on it
+ */
+func (p *CORSPolicy) allowOrigin(origin string) (string, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	switch {
+	case p.any:
+		return "*", false
+	case origin != "" && p.origins[origin]:
+		return origin, true
+	default:
+		return "", true
+	}
+}
+
+/**
+ * Handler returns middleware adding the policy's CORS headers. Requests from
+ * origins that are not allowed get no Access-Control-Allow-Origin header, so
+ * browsers refuse them.
+ *
+ * @param next The next handler in the chain
+ * @return An http.Handler that handles CORS
+ */
+func (p *CORSPolicy) Handler(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Set CORS headers
-		w.Header().Set("Access-Control-Allow-Origin", "*")
+		allowed, vary := p.allowOrigin(r.Header.Get("Origin"))
+		if allowed != "" {
+			w.Header().Set("Access-Control-Allow-Origin", allowed)
+		}
+		if vary {
+			w.Header().Add("Vary", "Origin")
+		}
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
 		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
 
@@ -86,46 +186,89 @@ func CORS(next http.Handler) http.Handler {
 /**
  * RequestID middleware adds a unique ID to each request.
  * This ID is used for request tracing and debugging.
+ * It is RequestContext without organization or locale defaults.
  *
  * @param next The next handler in the chain
  * @return An http.Handler that adds a request ID
  */
 func RequestID(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Generate a new UUID for the request
-		requestID := uuid.New().String()
+	return RequestContext("", "")(next)
+}
 
-		// Add request ID to response headers for tracing
-		w.Header().Set("X-Request-ID", requestID)
+/**
+ * RequestContext middleware creates the request-scoped bundle read with
+ * requestctx.From: a new request ID (also sent as X-Request-ID), the trace
+ * from the traceparent header, the organization, the locale preferred by
+ * Accept-Language, and a logger tagged with the request ID. It must run
+ * before any middleware that reads or completes the bundle.
+ *
+ * @param org The organization requests are served for
+ * @param defaultLocale The locale used when Accept-Language names none
+ * @return Middleware creating the request bundle
+ */
+func RequestContext(org, defaultLocale string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Generate a new UUID for the request
+			requestID := uuid.New().String()
 
-		// Add request ID to context
-		ctx := context.WithValue(r.Context(), RequestIDKey, requestID)
+			// Add request ID to response headers for tracing
+			w.Header().Set("X-Request-ID", requestID)
 
-		// Serve request with updated context
-		next.ServeHTTP(w, r.WithContext(ctx))
-	})
+			info := requestctx.New(requestID)
+			info.Org = org
+			info.Locale = requestctx.PreferredLocale(r.Header.Get("Accept-Language"), defaultLocale)
+			info.Trace = requestctx.ParseTraceparent(r.Header.Get("traceparent"))
+
+			ctx := requestctx.NewContext(r.Context(), info)
+			ctx = context.WithValue(ctx, RequestIDKey, requestID)
+
+			// Serve request with updated context
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
 }
 
 /**
  * Authenticate middleware validates JWT tokens for protected routes.
  * Extracts and validates the token from the Authorization header.
  *
+ * Until tokens are validated, every caller is the same placeholder user with
+ * the user role, so administrative endpoints stay closed.
+ *
  * @param next The next handler in the chain
  * @return An http.Handler that performs authentication
  */
 func Authenticate(next http.Handler) http.Handler {
+	return authenticate(next, RoleUser)
+}
+
+/**
+ * DemoAuthenticate is Authenticate with the placeholder user an admin, for
+ * demo mode, whose data is sample data kept in memory.
+ *
+ * @param next The next handler in the chain
+ * @return An http.Handler that performs authentication
+ */
+func DemoAuthenticate(next http.Handler) http.Handler {
+	return authenticate(next, RoleAdmin)
+}
+
+// authenticate checks the bearer token and authenticates the caller as the
+// placeholder user with role
+func authenticate(next http.Handler, role string) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Get Authorization header
 		authHeader := r.Header.Get("Authorization")
 
 		if authHeader == "" {
-			http.Error(w, "Authorization header missing", http.StatusUnauthorized)
+			httperr.WriteError(w, r, httperr.New(http.StatusUnauthorized, httperr.CodeUnauthorized, "Authorization header missing"))
 			return
 		}
 
 		// Check if the header has the correct format
 		if !strings.HasPrefix(authHeader, "Bearer ") {
-			http.Error(w, "Invalid authorization format", http.StatusUnauthorized)
+			httperr.WriteError(w, r, httperr.New(http.StatusUnauthorized, httperr.CodeUnauthorized, "Invalid authorization format"))
 			return
 		}
 
@@ -138,14 +281,50 @@ func Authenticate(next http.Handler) http.Handler {
 		// 2. Check expiration time
 		// 3. Extract user ID or other claims
 
-		// For now, assume token is valid and add mock user ID to context
-		ctx := context.WithValue(r.Context(), UserIDKey, "mock-user-id")
+		// For now, assume token is valid and use a mock user
+		// TODO: Take the role from the token claims once JWT validation exists
+		principal := requestctx.Principal{UserID: "mock-user-id", Role: role}
+		ctx := withPrincipal(r.Context(), principal)
 
 		// Pass the request with the authenticated context
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
+/**
+ * RequireAdmin middleware restricts a route to users with the admin role.
+ * Must be applied after Authenticate, which places the role in the context.
+ *
+ * @param next The next handler in the chain
+ * @return An http.Handler that enforces the admin role
+ */
+func RequireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !requestctx.From(r).Principal.HasRole(RoleAdmin) {
+			httperr.WriteError(w, r, httperr.New(http.StatusForbidden, httperr.CodeForbidden, "Admin access required"))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+/**
+ * withPrincipal records the authenticated caller in the request bundle and
+ * the legacy context keys. Without a bundle (RequestContext did not run), a
+ * bundle holding only the principal is added.
+ *
+ * @param ctx The request context
+ * @param principal The authenticated caller
+ * @return The context carrying the principal
+ */
+func withPrincipal(ctx context.Context, principal requestctx.Principal) context.Context {
+	ctx, info := requestctx.Ensure(ctx)
+	info.Principal = principal
+	ctx = context.WithValue(ctx, UserIDKey, principal.UserID)
+	return context.WithValue(ctx, UserRoleKey, principal.Role)
+}
+
 // responseWriter is a wrapper for http.ResponseWriter that captures the status code
 type responseWriter struct {
 	http.ResponseWriter
@@ -162,3 +341,9 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.status = code
 	rw.ResponseWriter.WriteHeader(code)
 }
+
+// Unwrap returns the embedded ResponseWriter so http.ResponseController can
+// reach its Flush and deadline methods
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
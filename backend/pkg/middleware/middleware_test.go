@@ -2,13 +2,16 @@ package middleware_test
 
 import (
 	"bytes"
+	"context"
 	"log"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strconv"
 	"testing"
 
 	"nivai/backend/pkg/middleware" // Adjust import path as necessary
+	"nivai/backend/pkg/requestctx"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -100,6 +103,36 @@ func TestCORSMiddleware(t *testing.T) {
 	})
 }
 
+func TestCORSPolicy(t *testing.T) {
+	policy := middleware.NewCORSPolicy([]string{"https://app.example.com/"})
+	handler := policy.Handler(&mockHandler{})
+
+	request := func(origin string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Origin", origin)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := request("https://app.example.com")
+	assert.Equal(t, "https://app.example.com", rr.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "Origin", rr.Header().Get("Vary"))
+
+	rr = request("https://evil.example.com")
+	assert.Empty(t, rr.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, http.StatusOK, rr.Code, "the request itself is served; browsers enforce CORS")
+
+	policy.SetOrigins([]string{"https://evil.example.com"})
+	assert.Equal(t, "https://evil.example.com", request("https://evil.example.com").Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, request("https://app.example.com").Header().Get("Access-Control-Allow-Origin"))
+
+	policy.SetOrigins([]string{"*"})
+	rr = request("https://other.example.com")
+	assert.Equal(t, "*", rr.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, rr.Header().Get("Vary"))
+}
+
 func TestRequestIDMiddleware(t *testing.T) {
 	var capturedRequestID string
 	var requestIDFromCtx interface{}
@@ -196,6 +229,46 @@ func TestAuthenticateMiddleware(t *testing.T) {
 	})
 }
 
+func TestRequireAdminMiddleware(t *testing.T) {
+	nextHandler := &mockHandler{}
+	adminHandler := middleware.RequireAdmin(nextHandler)
+
+	t.Run("No role in context", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/admin", nil)
+		rr := httptest.NewRecorder()
+		adminHandler.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+
+	t.Run("Non-admin role", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/admin", nil)
+		req = req.WithContext(context.WithValue(req.Context(), middleware.UserRoleKey, "analyst"))
+		rr := httptest.NewRecorder()
+		adminHandler.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+
+	t.Run("Authenticated mock user is not admin", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/admin", nil)
+		req.Header.Set("Authorization", "Bearer mock_jwt_token")
+		rr := httptest.NewRecorder()
+		middleware.Authenticate(adminHandler).ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+
+	t.Run("Demo mock user is admin", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/admin", nil)
+		req.Header.Set("Authorization", "Bearer mock_jwt_token")
+		rr := httptest.NewRecorder()
+		middleware.DemoAuthenticate(adminHandler).ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+}
+
 // TestResponseWriterWrapper explicitly tests the responseWriter used by Logger.
 func TestResponseWriterWrapper(t *testing.T) {
 	t.Run("WriteHeader captures status", func(t *testing.T) {
@@ -217,3 +290,48 @@ func TestResponseWriterWrapper(t *testing.T) {
 		assert.True(t, true, "responseWriter.Write implicitly tested via LoggerMiddleware")
 	})
 }
+
+func TestRequestContextMiddleware(t *testing.T) {
+	var info *requestctx.Info
+	handler := middleware.RequestContext("NIVAI", "en")(middleware.Authenticate(&mockHandler{
+		ServeHTTPFunc: func(w http.ResponseWriter, r *http.Request) {
+			info = requestctx.From(r)
+			w.WriteHeader(http.StatusOK)
+		},
+	}))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Authorization", "Bearer mock_jwt_token")
+	req.Header.Set("Accept-Language", "nl-NL,nl;q=0.9")
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	require.NotNil(t, info)
+	assert.Equal(t, rr.Header().Get("X-Request-ID"), info.RequestID)
+	assert.Equal(t, "NIVAI", info.Org)
+	assert.Equal(t, "nl-NL", info.Locale)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", info.Trace.TraceID)
+	assert.Equal(t, requestctx.Principal{UserID: "mock-user-id", Role: middleware.RoleUser}, info.Principal)
+}
+
+func TestAPIVersion(t *testing.T) {
+	for path, expected := range map[string]int{
+		"/api/v1/videos":   1,
+		"/api/v2/videos/1": 2,
+		"/api/v2":          2,
+		"/api/v10/matches": 10,
+		"/ws":              middleware.DefaultAPIVersion,
+		"/api/vx/videos":   middleware.DefaultAPIVersion,
+	} {
+		var version int
+		handler := middleware.RequestID(middleware.APIVersion(&mockHandler{ServeHTTPFunc: func(w http.ResponseWriter, r *http.Request) {
+			version = requestctx.From(r).APIVersion
+		}}))
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		assert.Equal(t, expected, version, path)
+		assert.Equal(t, strconv.Itoa(expected), rr.Header().Get("API-Version"), path)
+	}
+}
@@ -0,0 +1,185 @@
+package middleware
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"nivai/backend/pkg/httperr"
+	"nivai/backend/pkg/requestctx"
+)
+
+// idleBucketTTL is how long an unused client bucket is kept before it is swept
+const idleBucketTTL = 10 * time.Minute
+
+/**
+ * RateLimiter enforces per-client request rates for named rate limit
+ * classes using token buckets. Clients are identified by the authenticated
+ * user ID, or by remote IP address for anonymous requests.
+ */
+type RateLimiter struct {
+	now func() time.Time
+
+	mu        sync.Mutex
+	perMinute map[string]int
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+// tokenBucket holds the remaining tokens of one client in one class
+type tokenBucket struct {
+	tokens   float64
+	updated  time.Time
+	capacity float64
+}
+
+/**
+ * NewRateLimiter creates a rate limiter from requests-per-minute limits
+ * keyed by class. Classes without a positive limit are not limited.
+ *
+ * @param perMinute Requests per minute allowed per client, by class
+ * @return A new rate limiter
+ */
+func NewRateLimiter(perMinute map[string]int) *RateLimiter {
+	l := &RateLimiter{
+		now:     time.Now,
+		buckets: map[string]*tokenBucket{},
+	}
+	l.SetLimits(perMinute)
+	return l
+}
+
+/**
+ * SetLimits replaces the limits of every class, e.g. on a configuration
+ * reload. Clients keep their buckets; a bucket is refilled at the new rate
+ * and capped at the new burst from its next request on.
+ *
+ * @param perMinute Requests per minute allowed per client, by class
+ */
+func (l *RateLimiter) SetLimits(perMinute map[string]int) {
+	limits := make(map[string]int, len(perMinute))
+	for class, limit := range perMinute {
+		limits[class] = limit
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.perMinute = limits
+}
+
+/**
+ * limit returns the current limit of a class.
+ *
+ * @param class The rate limit class
+ * @return Requests per minute, 0 or less when the class is not limited
+ */
+func (l *RateLimiter) limit(class string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.perMinute[class]
+}
+
+/**
+ * Limit returns middleware enforcing the limit of a class. Requests over the
+ * limit get 429 Too Many Requests with a Retry-After header. The middleware
+ * must run after Authenticate for per-user limits. The limit is read on
+ * every request, so SetLimits takes effect immediately.
+ *
+ * @param class The rate limit class
+ * @return Middleware enforcing the class limit
+ */
+func (l *RateLimiter) Limit(class string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if l == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			limit := l.limit(class)
+			if limit <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+			allowed, remaining, retryAfter := l.allow(class+"|"+clientKey(r), limit)
+			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(limit))
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+			if !allowed {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+				httperr.WriteError(w, r, httperr.New(http.StatusTooManyRequests, httperr.CodeRateLimited, "Rate limit exceeded"))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+/**
+ * allow takes a token from the bucket for key, refilling it at limit tokens
+ * per minute with a burst of limit.
+ *
+ * @param key The bucket key (class and client)
+ * @param limit Requests per minute
+ * @return Whether the request is allowed, the remaining tokens, and how long
+ *         until a token is available when it is not
+ */
+func (l *RateLimiter) allow(key string, limit int) (bool, int, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	rate := float64(limit) / 60 // Tokens per second
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: float64(limit), updated: now, capacity: float64(limit)}
+		l.buckets[key] = b
+	}
+	b.capacity = float64(limit)
+	b.tokens = math.Min(b.capacity, b.tokens+now.Sub(b.updated).Seconds()*rate)
+	b.updated = now
+
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / rate * float64(time.Second))
+		return false, 0, wait
+	}
+	b.tokens--
+	return true, int(b.tokens), 0
+}
+
+/**
+ * sweep drops buckets that have been idle long enough to be full again.
+ * It runs at most once per idleBucketTTL. Callers hold l.mu.
+ *
+ * @param now The current time
+ */
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < idleBucketTTL {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if now.Sub(b.updated) >= idleBucketTTL {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+/**
+ * clientKey identifies the client of a request: the authenticated user if
+ * any, otherwise the remote IP address.
+ *
+ * @param r The HTTP request
+ * @return The client key
+ */
+func clientKey(r *http.Request) string {
+	if principal := requestctx.From(r).Principal; principal.Authenticated() {
+		return "user:" + principal.UserID
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
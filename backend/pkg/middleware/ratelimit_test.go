@@ -0,0 +1,76 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"nivai/backend/pkg/middleware"
+	"nivai/backend/pkg/requestctx"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiter(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+	limiter := middleware.NewRateLimiter(map[string]int{"upload": 2, "disabled": 0})
+
+	request := func(handler http.Handler, remoteAddr, userID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/v1/videos", nil)
+		req.RemoteAddr = remoteAddr
+		if userID != "" {
+			req = req.WithContext(requestctx.NewContext(req.Context(), &requestctx.Info{Principal: requestctx.Principal{UserID: userID}}))
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("Requests over the limit are rejected", func(t *testing.T) {
+		handler := limiter.Limit("upload")(ok)
+
+		assert.Equal(t, http.StatusOK, request(handler, "10.0.0.1:1234", "").Code)
+		rr := request(handler, "10.0.0.1:5678", "")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "2", rr.Header().Get("X-RateLimit-Limit"))
+		assert.Equal(t, "0", rr.Header().Get("X-RateLimit-Remaining"))
+
+		rr = request(handler, "10.0.0.1:9999", "")
+		assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+		assert.Equal(t, "30", rr.Header().Get("Retry-After"))
+
+		// Other clients have their own bucket
+		assert.Equal(t, http.StatusOK, request(handler, "10.0.0.2:1234", "").Code)
+	})
+
+	t.Run("Authenticated users are limited per user", func(t *testing.T) {
+		handler := limiter.Limit("upload")(ok)
+
+		assert.Equal(t, http.StatusOK, request(handler, "10.0.0.3:1", "alice").Code)
+		assert.Equal(t, http.StatusOK, request(handler, "10.0.0.4:1", "alice").Code)
+		assert.Equal(t, http.StatusTooManyRequests, request(handler, "10.0.0.5:1", "alice").Code)
+		assert.Equal(t, http.StatusOK, request(handler, "10.0.0.3:1", "bob").Code)
+	})
+
+	t.Run("Classes without a limit pass through", func(t *testing.T) {
+		for _, class := range []string{"disabled", "unknown"} {
+			handler := limiter.Limit(class)(ok)
+			for i := 0; i < 5; i++ {
+				assert.Equal(t, http.StatusOK, request(handler, "10.0.0.9:1", "").Code)
+			}
+		}
+
+		var nilLimiter *middleware.RateLimiter
+		assert.Equal(t, http.StatusOK, request(nilLimiter.Limit("upload")(ok), "10.0.0.9:1", "").Code)
+	})
+	t.Run("Limits can be changed at runtime", func(t *testing.T) {
+		handler := limiter.Limit("disabled")(ok)
+		assert.Equal(t, http.StatusOK, request(handler, "10.0.1.1:1", "").Code)
+
+		limiter.SetLimits(map[string]int{"disabled": 1})
+		assert.Equal(t, http.StatusOK, request(handler, "10.0.1.2:1", "").Code)
+		rr := request(handler, "10.0.1.2:1", "")
+		assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+		assert.Equal(t, "1", rr.Header().Get("X-RateLimit-Limit"))
+	})
+}
@@ -0,0 +1,379 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"nivai/backend/pkg/requestctx"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrResponseCacheMiss is returned by a ResponseCacheStore without the entry.
+var ErrResponseCacheMiss = errors.New("response cache: miss")
+
+// maxCachedBody is the largest response body kept; larger responses are
+// served but not cached
+const maxCachedBody = 4 << 20
+
+/**
+ * CacheVary states whose requests share a cached response.
+ */
+type CacheVary string
+
+// Cache variations
+const (
+	CacheVaryNone CacheVary = "none" // Every caller shares the response
+	CacheVaryOrg  CacheVary = "org"  // Callers of the same organization share it
+	CacheVaryUser CacheVary = "user" // Each caller has their own
+)
+
+/**
+ * CachePolicy caches the successful GET responses of a route. The zero
+ * policy caches nothing.
+ */
+type CachePolicy struct {
+	TTL  time.Duration
+	Vary CacheVary // Defaults to CacheVaryUser, the only safe choice for access-scoped responses
+}
+
+/**
+ * ResponseCacheStore keeps cached responses until they expire.
+ * Implementations are safe for concurrent use.
+ */
+type ResponseCacheStore interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+}
+
+/**
+ * ResponseCache serves repeated reads of expensive endpoints from a store
+ * instead of recomputing them. Responses are cached per URL (path and
+ * query), content negotiation headers and the policy's variation; only
+ * complete 200 responses are kept, so errors and partially resolved or
+ * degraded lists are always recomputed. Entries are not invalidated by writes: a policy's
+ * TTL is how stale a response may be.
+ */
+type ResponseCache struct {
+	store ResponseCacheStore
+	now   func() time.Time
+}
+
+/**
+ * NewResponseCache creates a response cache over store.
+ *
+ * @param store Where the responses are kept
+ * @return A new response cache
+ */
+func NewResponseCache(store ResponseCacheStore) *ResponseCache {
+	return &ResponseCache{store: store, now: time.Now}
+}
+
+// cachedResponse is a response as kept in the store
+type cachedResponse struct {
+	StoredAt time.Time   `json:"stored_at"`
+	Header   http.Header `json:"header"`
+	Body     []byte      `json:"body"`
+}
+
+/**
+ * Cache returns middleware caching the responses of a route by policy. It
+ * must run after authentication, so responses varying by caller know who
+ * the caller is. Hits carry X-Cache: HIT and an Age header; requests with
+ * Cache-Control: no-cache skip the lookup and refresh the entry. A nil
+ * cache or zero TTL caches nothing.
+ *
+ * @param policy The route's cache policy
+ * @return The middleware
+ */
+func (c *ResponseCache) Cache(policy CachePolicy) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if c == nil || policy.TTL <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet {
+				next.ServeHTTP(w, r)
+				return
+			}
+			info := requestctx.From(r)
+			key := cacheKey(r, policy.Vary)
+
+			if !strings.Contains(r.Header.Get("Cache-Control"), "no-cache") {
+				if value, err := c.store.Get(r.Context(), key); err == nil {
+					var cached cachedResponse
+					if err := json.Unmarshal(value, &cached); err == nil {
+						c.writeCached(w, &cached)
+						return
+					}
+				} else if !errors.Is(err, ErrResponseCacheMiss) {
+					info.Logger.Printf("Response cache: lookup failed, serving uncached: %v", err)
+				}
+			}
+
+			recorder := &cacheRecorder{ResponseWriter: w, status: http.StatusOK, before: w.Header().Clone(), cacheControl: cacheControl(policy)}
+			w.Header().Set("X-Cache", "MISS")
+			next.ServeHTTP(recorder, r)
+
+			header, ok := recorder.cacheable()
+			if !ok {
+				return
+			}
+			value, err := json.Marshal(cachedResponse{StoredAt: c.now().UTC(), Header: header, Body: recorder.body.Bytes()})
+			if err == nil {
+				err = c.store.Set(r.Context(), key, value, policy.TTL)
+			}
+			if err != nil {
+				info.Logger.Printf("Response cache: failed to store %s: %v", r.URL.Path, err)
+			}
+		})
+	}
+}
+
+// writeCached serves a cached response
+func (c *ResponseCache) writeCached(w http.ResponseWriter, cached *cachedResponse) {
+	for name, values := range cached.Header {
+		w.Header()[name] = values
+	}
+	w.Header().Set("X-Cache", "HIT")
+	w.Header().Set("Age", strconv.Itoa(int(max(c.now().Sub(cached.StoredAt), 0).Seconds())))
+	w.Header().Set("Content-Length", strconv.Itoa(len(cached.Body)))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(cached.Body)
+}
+
+// cacheKey identifies the response to a request: its URL with the query in
+// canonical order, its content negotiation, and who shares it
+func cacheKey(r *http.Request, vary CacheVary) string {
+	info := requestctx.From(r)
+	var who string
+	switch vary {
+	case CacheVaryNone:
+	case CacheVaryOrg:
+		who = "org:" + info.Org
+	default:
+		who = "org:" + info.Org + "|user:" + info.Principal.UserID
+	}
+
+	hash := sha256.New()
+	for _, part := range []string{who, r.URL.Path, r.URL.Query().Encode(), r.Header.Get("Accept"), r.Header.Get("Accept-Language")} {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// cacheControl tells browsers and proxies how long they may reuse a response
+func cacheControl(policy CachePolicy) string {
+	scope := "private"
+	if policy.Vary == CacheVaryNone {
+		scope = "public"
+	}
+	return scope + ", max-age=" + strconv.Itoa(int(policy.TTL.Seconds()))
+}
+
+// cacheRecorder passes a response through while keeping a copy of it
+type cacheRecorder struct {
+	http.ResponseWriter
+	status       int
+	before       http.Header // Headers set by earlier middleware, not part of the response
+	cacheControl string      // Set on successful responses without their own
+	wroteHeader  bool
+	body         bytes.Buffer
+	tooLarge     bool
+}
+
+// WriteHeader records the status, and lets clients reuse successful
+// responses as long as the cache does
+func (r *cacheRecorder) WriteHeader(status int) {
+	if r.wroteHeader {
+		return
+	}
+	r.status, r.wroteHeader = status, true
+	if status == http.StatusOK && r.Header().Get("Cache-Control") == "" {
+		r.Header().Set("Cache-Control", r.cacheControl)
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Write copies the body until it grows too large to cache
+func (r *cacheRecorder) Write(p []byte) (int, error) {
+	if !r.wroteHeader {
+		r.WriteHeader(http.StatusOK)
+	}
+	if !r.tooLarge {
+		if r.body.Len()+len(p) > maxCachedBody {
+			r.tooLarge = true
+			r.body = bytes.Buffer{}
+		} else {
+			r.body.Write(p)
+		}
+	}
+	return r.ResponseWriter.Write(p)
+}
+
+// Flush passes flushes through, so streamed responses keep streaming
+func (r *cacheRecorder) Flush() {
+	if !r.wroteHeader {
+		r.WriteHeader(http.StatusOK)
+	}
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// cacheable returns the headers the handler set, or false when the
+// response must not be cached: not a complete 200, marked no-store,
+// partial or degraded, or too large
+func (r *cacheRecorder) cacheable() (http.Header, bool) {
+	header := r.Header()
+	if r.status != http.StatusOK || r.tooLarge || header.Get("X-Partial-Results") != "" || header.Get("X-Degraded") != "" ||
+		strings.Contains(header.Get("Cache-Control"), "no-store") || header.Get("Set-Cookie") != "" {
+		return nil, false
+	}
+	if strings.HasPrefix(header.Get("Content-Type"), "application/x-ndjson") {
+		return nil, false
+	}
+
+	own := http.Header{}
+	for name, values := range header {
+		if name == "X-Cache" || name == "Content-Length" || slices.Equal(r.before[name], values) {
+			continue
+		}
+		own[name] = values
+	}
+	return own, true
+}
+
+/**
+ * MemoryResponseCacheStore keeps cached responses in process memory, so each
+ * instance caches on its own. It holds at most a budget of bytes, forgetting
+ * the oldest entries when full.
+ */
+type MemoryResponseCacheStore struct {
+	maxBytes int64
+
+	mu      sync.Mutex
+	entries map[string]memoryCacheEntry
+	order   []string // Keys, oldest first
+	size    int64    // Bytes of the values kept
+	now     func() time.Time
+}
+
+type memoryCacheEntry struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+// DefaultMemoryResponseCacheBytes is the budget of an in-memory response
+// store created without one.
+const DefaultMemoryResponseCacheBytes = 64 << 20
+
+/**
+ * NewMemoryResponseCacheStore creates an empty in-memory response store.
+ *
+ * @param maxBytes Most bytes of responses kept; DefaultMemoryResponseCacheBytes when not positive
+ * @return A new in-memory response store
+ */
+func NewMemoryResponseCacheStore(maxBytes int64) *MemoryResponseCacheStore {
+	if maxBytes <= 0 {
+		maxBytes = DefaultMemoryResponseCacheBytes
+	}
+	return &MemoryResponseCacheStore{maxBytes: maxBytes, entries: map[string]memoryCacheEntry{}, now: time.Now}
+}
+
+/**
+ * Get implements ResponseCacheStore.
+ */
+func (s *MemoryResponseCacheStore) Get(ctx context.Context, key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.entries[key]
+	if !ok || s.now().After(entry.expiresAt) {
+		return nil, ErrResponseCacheMiss
+	}
+	return entry.value, nil
+}
+
+/**
+ * Set implements ResponseCacheStore. Expired entries are dropped when a new
+ * entry is stored, then the oldest entries until the new one fits; a value
+ * larger than the whole budget is not kept.
+ */
+func (s *MemoryResponseCacheStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	if old, ok := s.entries[key]; ok {
+		s.size -= int64(len(old.value))
+	} else {
+		s.order = slices.DeleteFunc(s.order, func(k string) bool {
+			if entry := s.entries[k]; now.After(entry.expiresAt) {
+				s.size -= int64(len(entry.value))
+				delete(s.entries, k)
+				return true
+			}
+			return false
+		})
+		s.order = append(s.order, key)
+	}
+	s.entries[key] = memoryCacheEntry{value: value, expiresAt: now.Add(ttl)}
+	s.size += int64(len(value))
+
+	for s.size > s.maxBytes && len(s.order) > 0 {
+		oldest := s.order[0]
+		s.order = s.order[1:]
+		s.size -= int64(len(s.entries[oldest].value))
+		delete(s.entries, oldest)
+	}
+	return nil
+}
+
+/**
+ * RedisResponseCacheStore keeps cached responses in Redis, shared by every
+ * instance and expired by Redis.
+ */
+type RedisResponseCacheStore struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+/**
+ * NewRedisResponseCacheStore creates a response store whose keys are
+ * prefix+key.
+ *
+ * @param client The Redis client
+ * @param prefix Prefix of the keys
+ * @return A new Redis response store
+ */
+func NewRedisResponseCacheStore(client redis.UniversalClient, prefix string) *RedisResponseCacheStore {
+	return &RedisResponseCacheStore{client: client, prefix: prefix}
+}
+
+/**
+ * Get implements ResponseCacheStore.
+ */
+func (s *RedisResponseCacheStore) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := s.client.Get(ctx, s.prefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrResponseCacheMiss
+	}
+	return value, err
+}
+
+/**
+ * Set implements ResponseCacheStore.
+ */
+func (s *RedisResponseCacheStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return s.client.Set(ctx, s.prefix+key, value, ttl).Err()
+}
@@ -0,0 +1,167 @@
+package middleware_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"nivai/backend/pkg/middleware"
+	"nivai/backend/pkg/requestctx"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponseCache(t *testing.T) {
+	// counter answers with how often it was called, so hits are recognizable
+	counter := func() (http.Handler, *int) {
+		calls := 0
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			if r.URL.Query().Get("partial") != "" {
+				w.Header().Set("X-Partial-Results", "1")
+			}
+			if r.URL.Query().Get("degraded") != "" {
+				w.Header().Set("X-Degraded", "analytics")
+			}
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"calls":%d}`, calls)
+		}), &calls
+	}
+	request := func(handler http.Handler, target, org, userID string, header ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", target, nil)
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		req = req.WithContext(requestctx.NewContext(req.Context(), &requestctx.Info{
+			Org: org, Principal: requestctx.Principal{UserID: userID}, Logger: log.Default(),
+		}))
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("Repeated reads are served from the cache", func(t *testing.T) {
+		next, calls := counter()
+		handler := middleware.NewResponseCache(middleware.NewMemoryResponseCacheStore(0)).Cache(middleware.CachePolicy{TTL: time.Minute})(next)
+
+		first := request(handler, "/api/v1/matches?team=Ajax&limit=5", "nivai", "alice")
+		assert.Equal(t, "MISS", first.Header().Get("X-Cache"))
+		assert.Equal(t, "private, max-age=60", first.Header().Get("Cache-Control"))
+
+		second := request(handler, "/api/v1/matches?limit=5&team=Ajax", "nivai", "alice")
+		assert.Equal(t, http.StatusOK, second.Code)
+		assert.Equal(t, "HIT", second.Header().Get("X-Cache"))
+		assert.Equal(t, `{"calls":1}`, second.Body.String(), "the query order does not matter")
+		assert.Equal(t, "application/json", second.Header().Get("Content-Type"))
+		assert.Equal(t, "0", second.Header().Get("Age"))
+
+		assert.Equal(t, `{"calls":2}`, request(handler, "/api/v1/matches?team=PSV", "nivai", "alice").Body.String())
+		assert.Equal(t, `{"calls":3}`, request(handler, "/api/v1/matches?team=Ajax&limit=5", "nivai", "alice", "Accept", "application/x-ndjson").Body.String())
+		assert.Equal(t, `{"calls":4}`, request(handler, "/api/v1/matches?team=Ajax&limit=5", "nivai", "alice", "Cache-Control", "no-cache").Body.String())
+		assert.Equal(t, `{"calls":4}`, request(handler, "/api/v1/matches?team=Ajax&limit=5", "nivai", "alice").Body.String(), "no-cache refreshes the entry")
+		assert.Equal(t, 4, *calls)
+	})
+
+	t.Run("Responses vary by caller as the policy says", func(t *testing.T) {
+		for vary, want := range map[middleware.CacheVary][]string{
+			middleware.CacheVaryUser: {`{"calls":1}`, `{"calls":2}`, `{"calls":3}`},
+			middleware.CacheVaryOrg:  {`{"calls":1}`, `{"calls":1}`, `{"calls":2}`},
+			middleware.CacheVaryNone: {`{"calls":1}`, `{"calls":1}`, `{"calls":1}`},
+		} {
+			next, _ := counter()
+			handler := middleware.NewResponseCache(middleware.NewMemoryResponseCacheStore(0)).Cache(middleware.CachePolicy{TTL: time.Minute, Vary: vary})(next)
+
+			got := []string{
+				request(handler, "/stats", "nivai", "alice").Body.String(),
+				request(handler, "/stats", "nivai", "bob").Body.String(),
+				request(handler, "/stats", "other", "carol").Body.String(),
+			}
+			assert.Equal(t, want, got, vary)
+		}
+	})
+
+	t.Run("Partial, degraded and failed responses are not cached", func(t *testing.T) {
+		next, calls := counter()
+		failing := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/fail" {
+				http.Error(w, "unavailable", http.StatusServiceUnavailable)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+		handler := middleware.NewResponseCache(middleware.NewMemoryResponseCacheStore(0)).Cache(middleware.CachePolicy{TTL: time.Minute})(failing)
+
+		request(handler, "/list?partial=1", "nivai", "alice")
+		assert.Equal(t, `{"calls":2}`, request(handler, "/list?partial=1", "nivai", "alice").Body.String())
+		request(handler, "/list?degraded=1", "nivai", "alice")
+		assert.Equal(t, `{"calls":4}`, request(handler, "/list?degraded=1", "nivai", "alice").Body.String(), "degraded responses are stale")
+		request(handler, "/fail", "nivai", "alice")
+		failed := request(handler, "/fail", "nivai", "alice")
+		assert.Equal(t, "MISS", failed.Header().Get("X-Cache"))
+		assert.Empty(t, failed.Header().Get("Cache-Control"), "clients must not reuse errors")
+		assert.Equal(t, 4, *calls)
+	})
+
+	t.Run("Without a cache or TTL nothing is cached", func(t *testing.T) {
+		next, calls := counter()
+		var cache *middleware.ResponseCache
+		request(cache.Cache(middleware.CachePolicy{TTL: time.Minute})(next), "/stats", "nivai", "alice")
+		handler := middleware.NewResponseCache(middleware.NewMemoryResponseCacheStore(0)).Cache(middleware.CachePolicy{})(next)
+		request(handler, "/stats", "nivai", "alice")
+		request(handler, "/stats", "nivai", "alice")
+		assert.Equal(t, 3, *calls)
+	})
+
+	t.Run("Redis keeps entries for the TTL", func(t *testing.T) {
+		server := miniredis.RunT(t)
+		client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+		defer client.Close()
+		next, calls := counter()
+		handler := middleware.NewResponseCache(middleware.NewRedisResponseCacheStore(client, "test:")).Cache(middleware.CachePolicy{TTL: time.Minute})(next)
+
+		request(handler, "/stats", "nivai", "alice")
+		assert.Equal(t, "HIT", request(handler, "/stats", "nivai", "alice").Header().Get("X-Cache"))
+		require.Len(t, server.Keys(), 1)
+
+		server.FastForward(2 * time.Minute)
+		assert.Equal(t, "MISS", request(handler, "/stats", "nivai", "alice").Header().Get("X-Cache"))
+		assert.Equal(t, 2, *calls)
+	})
+}
+
+func TestMemoryResponseCacheStore(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("The oldest entries are forgotten when the budget is full", func(t *testing.T) {
+		store := middleware.NewMemoryResponseCacheStore(10)
+		require.NoError(t, store.Set(ctx, "a", []byte("aaaa"), time.Minute))
+		require.NoError(t, store.Set(ctx, "b", []byte("bbbb"), time.Minute))
+		require.NoError(t, store.Set(ctx, "a", []byte("AAAA"), time.Minute))
+		require.NoError(t, store.Set(ctx, "c", []byte("cccc"), time.Minute))
+
+		_, err := store.Get(ctx, "a")
+		assert.ErrorIs(t, err, middleware.ErrResponseCacheMiss, "updating an entry does not make it younger")
+		for key, want := range map[string]string{"b": "bbbb", "c": "cccc"} {
+			value, err := store.Get(ctx, key)
+			require.NoError(t, err)
+			assert.Equal(t, want, string(value))
+		}
+	})
+
+	t.Run("Values larger than the budget are not kept", func(t *testing.T) {
+		store := middleware.NewMemoryResponseCacheStore(10)
+		require.NoError(t, store.Set(ctx, "a", []byte("aaaa"), time.Minute))
+		require.NoError(t, store.Set(ctx, "big", make([]byte, 11), time.Minute))
+		for _, key := range []string{"a", "big"} {
+			_, err := store.Get(ctx, key)
+			assert.ErrorIs(t, err, middleware.ErrResponseCacheMiss, key)
+		}
+	})
+}
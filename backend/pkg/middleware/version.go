@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+
+	"nivai/backend/pkg/requestctx"
+)
+
+// DefaultAPIVersion is the version of requests outside the versioned API,
+// such as the WebSocket
+const DefaultAPIVersion = 1
+
+// versionPath matches the version segment of API paths: /api/v2/...
+var versionPath = regexp.MustCompile(`^/api/v([1-9][0-9]*)(?:/|$)`)
+
+/**
+ * APIVersion records the major API version of a request, taken from its
+ * path (/api/v2/...), in the request bundle, and names it in the API-Version
+ * response header. Handlers shared between versions read it to pick the
+ * response shape.
+ *
+ * @param next The next handler in the chain
+ * @return An http.Handler that records the API version
+ */
+func APIVersion(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		version := PathAPIVersion(r.URL.Path)
+		ctx, info := requestctx.Ensure(r.Context())
+		info.APIVersion = version
+		w.Header().Set("API-Version", strconv.Itoa(version))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+/**
+ * PathAPIVersion returns the major API version a path belongs to.
+ *
+ * @param path The request path
+ * @return The version, or DefaultAPIVersion for unversioned paths
+ */
+func PathAPIVersion(path string) int {
+	match := versionPath.FindStringSubmatch(path)
+	if match == nil {
+		return DefaultAPIVersion
+	}
+	version, err := strconv.Atoi(match[1])
+	if err != nil {
+		return DefaultAPIVersion
+	}
+	return version
+}
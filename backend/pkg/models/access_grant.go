@@ -0,0 +1,206 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ErrAccessGrantNotFound is returned when an access grant ID is unknown.
+var ErrAccessGrantNotFound = errors.New("access grant not found")
+
+// Subjects an access grant is given to
+const (
+	AccessSubjectUser  = "user"
+	AccessSubjectGroup = "group"
+)
+
+// Resources an access grant covers
+const (
+	AccessResourceMatch = "match" // One match, by ID
+	AccessResourceTeam  = "team"  // Every match a team plays in, home or away
+)
+
+// Permissions an access grant gives
+const (
+	AccessPermissionView = "view" // Read the match, its files and analytics
+	AccessPermissionEdit = "edit" // Also change and delete it
+)
+
+/**
+ * AccessGrant gives a user or group access to a match, or to all matches of
+ * a team, beyond what their role allows. A subject has at most one grant
+ * per resource.
+ */
+type AccessGrant struct {
+	ID           string    `json:"id"`
+	SubjectType  string    `json:"subject_type"` // One of the AccessSubject constants
+	SubjectID    string    `json:"subject_id"`
+	ResourceType string    `json:"resource_type"` // One of the AccessResource constants
+	ResourceID   string    `json:"resource_id"`   // Match ID, or team name as in the matches' home and away team
+	Permission   string    `json:"permission"`    // One of the AccessPermission constants
+	GrantedBy    string    `json:"granted_by"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+/**
+ * AccessScope limits queries to the matches a caller was granted: the
+ * listed matches and every match of the listed teams. A nil scope is
+ * unrestricted; an empty one allows nothing.
+ */
+type AccessScope struct {
+	MatchIDs []string
+	Teams    []string // Compared case-insensitively
+}
+
+// Allows reports whether the scope includes a video
+func (s *AccessScope) Allows(video *Video) bool {
+	if s == nil {
+		return true
+	}
+	for _, id := range s.MatchIDs {
+		if video.ID == id {
+			return true
+		}
+	}
+	for _, team := range s.Teams {
+		if strings.EqualFold(video.HomeTeam, team) || strings.EqualFold(video.AwayTeam, team) {
+			return true
+		}
+	}
+	return false
+}
+
+// lowerTeams returns the scope's teams in lower case, for SQL comparison
+func (s *AccessScope) lowerTeams() []string {
+	teams := make([]string, len(s.Teams))
+	for i, team := range s.Teams {
+		teams[i] = strings.ToLower(team)
+	}
+	return teams
+}
+
+/**
+ * AccessGrantRepository defines data access for access grants.
+ */
+type AccessGrantRepository interface {
+	// Save inserts a grant, or changes the permission of the subject's
+	// existing grant on the resource, whose ID and creation it then takes
+	Save(grant *AccessGrant) error
+	Delete(id string) error
+	FindByID(id string) (*AccessGrant, error)
+	// FindByResource lists the grants on a resource, or all grants when
+	// resourceType is empty, by creation
+	FindByResource(resourceType, resourceID string) ([]*AccessGrant, error)
+	// FindBySubjects lists the grants of a user and of the groups given
+	FindBySubjects(userID string, groups []string) ([]*AccessGrant, error)
+}
+
+/**
+ * PostgresAccessGrantRepository implements AccessGrantRepository using PostgreSQL.
+ */
+type PostgresAccessGrantRepository struct {
+	db *sql.DB
+}
+
+/**
+ * NewPostgresAccessGrantRepository creates a new PostgreSQL-backed access grant repository.
+ *
+ * @param db Database connection
+ * @return A new access grant repository
+ */
+func NewPostgresAccessGrantRepository(db *sql.DB) AccessGrantRepository {
+	return &PostgresAccessGrantRepository{db: db}
+}
+
+// Save inserts a grant, or changes the permission of the subject's existing
+// grant on the resource
+func (r *PostgresAccessGrantRepository) Save(grant *AccessGrant) error {
+	query := `
+		INSERT INTO access_grants (id, subject_type, subject_id, resource_type, resource_id, permission, granted_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (subject_type, subject_id, resource_type, resource_id) DO UPDATE
+		SET permission = EXCLUDED.permission, granted_by = EXCLUDED.granted_by
+		RETURNING id, created_at
+	`
+	return r.db.QueryRow(query,
+		grant.ID, grant.SubjectType, grant.SubjectID, grant.ResourceType, grant.ResourceID,
+		grant.Permission, grant.GrantedBy, grant.CreatedAt,
+	).Scan(&grant.ID, &grant.CreatedAt)
+}
+
+// Delete removes a grant
+func (r *PostgresAccessGrantRepository) Delete(id string) error {
+	result, err := r.db.Exec(`DELETE FROM access_grants WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrAccessGrantNotFound
+	}
+	return nil
+}
+
+// FindByID retrieves a grant
+func (r *PostgresAccessGrantRepository) FindByID(id string) (*AccessGrant, error) {
+	grants, err := r.queryGrants(`
+		SELECT id, subject_type, subject_id, resource_type, resource_id, permission, granted_by, created_at
+		FROM access_grants
+		WHERE id = $1
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(grants) == 0 {
+		return nil, ErrAccessGrantNotFound
+	}
+	return grants[0], nil
+}
+
+// FindByResource retrieves the grants on a resource, or all grants, by creation
+func (r *PostgresAccessGrantRepository) FindByResource(resourceType, resourceID string) ([]*AccessGrant, error) {
+	return r.queryGrants(`
+		SELECT id, subject_type, subject_id, resource_type, resource_id, permission, granted_by, created_at
+		FROM access_grants
+		WHERE $1 = '' OR (resource_type = $1 AND LOWER(resource_id) = LOWER($2))
+		ORDER BY created_at, id
+	`, resourceType, resourceID)
+}
+
+// FindBySubjects retrieves the grants of a user and of the groups given
+func (r *PostgresAccessGrantRepository) FindBySubjects(userID string, groups []string) ([]*AccessGrant, error) {
+	return r.queryGrants(`
+		SELECT id, subject_type, subject_id, resource_type, resource_id, permission, granted_by, created_at
+		FROM access_grants
+		WHERE (subject_type = 'user' AND subject_id = $1)
+		   OR (subject_type = 'group' AND subject_id = ANY($2))
+		ORDER BY created_at, id
+	`, userID, pq.Array(groups))
+}
+
+// queryGrants runs a query selecting access grant rows
+func (r *PostgresAccessGrantRepository) queryGrants(query string, args ...interface{}) ([]*AccessGrant, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	grants := []*AccessGrant{}
+	for rows.Next() {
+		var g AccessGrant
+		if err := rows.Scan(&g.ID, &g.SubjectType, &g.SubjectID, &g.ResourceType, &g.ResourceID,
+			&g.Permission, &g.GrantedBy, &g.CreatedAt); err != nil {
+			return nil, err
+		}
+		grants = append(grants, &g)
+	}
+	return grants, rows.Err()
+}
@@ -0,0 +1,117 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Snapshot kinds
+const (
+	SnapshotKindSummary = "summary"
+)
+
+/**
+ * AnalyticsSnapshot is a persisted copy of an analytics result fetched from
+ * the Python service, keyed by video and result kind.
+ */
+type AnalyticsSnapshot struct {
+	VideoID   string          `json:"video_id"`
+	Kind      string          `json:"kind"`
+	Payload   json.RawMessage `json:"payload"`
+	FetchedAt time.Time       `json:"fetched_at"`
+}
+
+/**
+ * AnalyticsSnapshotRepository defines data access for analytics snapshots.
+ */
+type AnalyticsSnapshotRepository interface {
+	Save(snapshot *AnalyticsSnapshot) error
+	Find(videoID, kind string) (*AnalyticsSnapshot, error)
+	FindMany(videoIDs []string, kind string) (map[string]*AnalyticsSnapshot, error)
+}
+
+/**
+ * PostgresAnalyticsSnapshotRepository implements AnalyticsSnapshotRepository using PostgreSQL.
+ */
+type PostgresAnalyticsSnapshotRepository struct {
+	db *sql.DB
+}
+
+/**
+ * NewPostgresAnalyticsSnapshotRepository creates a new PostgreSQL-backed snapshot repository.
+ *
+ * @param db Database connection
+ * @return A new analytics snapshot repository
+ */
+func NewPostgresAnalyticsSnapshotRepository(db *sql.DB) AnalyticsSnapshotRepository {
+	return &PostgresAnalyticsSnapshotRepository{db: db}
+}
+
+// Save inserts or replaces a snapshot
+func (r *PostgresAnalyticsSnapshotRepository) Save(snapshot *AnalyticsSnapshot) error {
+	query := `
+		INSERT INTO analytics_snapshots (video_id, kind, payload, fetched_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (video_id, kind) DO UPDATE
+		SET payload = EXCLUDED.payload, fetched_at = EXCLUDED.fetched_at
+	`
+	_, err := r.db.Exec(query, snapshot.VideoID, snapshot.Kind, []byte(snapshot.Payload), snapshot.FetchedAt)
+	return err
+}
+
+// Find retrieves a snapshot by video ID and kind
+func (r *PostgresAnalyticsSnapshotRepository) Find(videoID, kind string) (*AnalyticsSnapshot, error) {
+	query := `
+		SELECT video_id, kind, payload, fetched_at
+		FROM analytics_snapshots
+		WHERE video_id = $1 AND kind = $2
+	`
+
+	var snapshot AnalyticsSnapshot
+	var payload []byte
+	err := r.db.QueryRow(query, videoID, kind).Scan(&snapshot.VideoID, &snapshot.Kind, &payload, &snapshot.FetchedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("snapshot not found")
+		}
+		return nil, err
+	}
+	snapshot.Payload = payload
+
+	return &snapshot, nil
+}
+
+// FindMany retrieves the snapshots of several videos in one query, keyed by
+// video ID. Videos without a snapshot are absent from the result.
+func (r *PostgresAnalyticsSnapshotRepository) FindMany(videoIDs []string, kind string) (map[string]*AnalyticsSnapshot, error) {
+	snapshots := make(map[string]*AnalyticsSnapshot, len(videoIDs))
+	if len(videoIDs) == 0 {
+		return snapshots, nil
+	}
+
+	query := `
+		SELECT video_id, kind, payload, fetched_at
+		FROM analytics_snapshots
+		WHERE kind = $1 AND video_id = ANY($2)
+	`
+	rows, err := r.db.Query(query, kind, pq.Array(videoIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var snapshot AnalyticsSnapshot
+		var payload []byte
+		if err := rows.Scan(&snapshot.VideoID, &snapshot.Kind, &payload, &snapshot.FetchedAt); err != nil {
+			return nil, err
+		}
+		snapshot.Payload = payload
+		snapshots[snapshot.VideoID] = &snapshot
+	}
+	return snapshots, rows.Err()
+}
@@ -0,0 +1,167 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// Archive job operations
+const (
+	ArchiveOperationArchive = "archive"
+	ArchiveOperationRestore = "restore"
+)
+
+// Archive job statuses
+const (
+	ArchiveJobRunning   = "running"
+	ArchiveJobCompleted = "completed"
+	ArchiveJobFailed    = "failed"
+)
+
+// ErrArchiveJobNotFound is returned when an archive job ID is unknown.
+var ErrArchiveJobNotFound = errors.New("archive job not found")
+
+/**
+ * ArchiveJob tracks moving a match's large files to cold storage or
+ * restoring them from it.
+ */
+type ArchiveJob struct {
+	ID          string       `json:"id"`
+	VideoID     string       `json:"video_id"`
+	Operation   string       `json:"operation"` // "archive" or "restore"
+	Status      string       `json:"status"`    // "running", "completed" or "failed"
+	Error       string       `json:"error,omitempty"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+	CompletedAt sql.NullTime `json:"completed_at"`
+}
+
+/**
+ * ArchiveJobRepository defines data access for archive jobs.
+ */
+type ArchiveJobRepository interface {
+	Create(job *ArchiveJob) error
+	Update(job *ArchiveJob) error
+	FindByID(id string) (*ArchiveJob, error)
+	FindRunning(operation string) ([]*ArchiveJob, error)
+	FindByVideoID(videoID string) ([]*ArchiveJob, error)
+	FindFailedSince(since time.Time) ([]*ArchiveJob, error)
+}
+
+/**
+ * PostgresArchiveJobRepository implements ArchiveJobRepository using PostgreSQL.
+ */
+type PostgresArchiveJobRepository struct {
+	db *sql.DB
+}
+
+/**
+ * NewPostgresArchiveJobRepository creates a new PostgreSQL-backed archive job repository.
+ *
+ * @param db Database connection
+ * @return A new archive job repository
+ */
+func NewPostgresArchiveJobRepository(db *sql.DB) ArchiveJobRepository {
+	return &PostgresArchiveJobRepository{db: db}
+}
+
+// Create inserts a new job
+func (r *PostgresArchiveJobRepository) Create(job *ArchiveJob) error {
+	query := `
+		INSERT INTO archive_jobs (id, video_id, operation, status, error, created_at, updated_at, completed_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	_, err := r.db.Exec(query,
+		job.ID, job.VideoID, job.Operation, job.Status, job.Error, job.CreatedAt, job.UpdatedAt, job.CompletedAt,
+	)
+	return err
+}
+
+// Update saves the status of a job
+func (r *PostgresArchiveJobRepository) Update(job *ArchiveJob) error {
+	query := `
+		UPDATE archive_jobs
+		SET status = $2, error = $3, updated_at = $4, completed_at = $5
+		WHERE id = $1
+	`
+	_, err := r.db.Exec(query, job.ID, job.Status, job.Error, job.UpdatedAt, job.CompletedAt)
+	return err
+}
+
+// FindByID retrieves a job
+func (r *PostgresArchiveJobRepository) FindByID(id string) (*ArchiveJob, error) {
+	query := `
+		SELECT id, video_id, operation, status, error, created_at, updated_at, completed_at
+		FROM archive_jobs
+		WHERE id = $1
+	`
+
+	var job ArchiveJob
+	err := r.db.QueryRow(query, id).Scan(
+		&job.ID, &job.VideoID, &job.Operation, &job.Status, &job.Error,
+		&job.CreatedAt, &job.UpdatedAt, &job.CompletedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrArchiveJobNotFound
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+// FindRunning retrieves the running jobs of an operation, oldest first
+func (r *PostgresArchiveJobRepository) FindRunning(operation string) ([]*ArchiveJob, error) {
+	query := `
+		SELECT id, video_id, operation, status, error, created_at, updated_at, completed_at
+		FROM archive_jobs
+		WHERE status = 'running' AND operation = $1
+		ORDER BY created_at
+	`
+	return r.queryJobs(query, operation)
+}
+
+// FindByVideoID retrieves all jobs of a match, oldest first
+func (r *PostgresArchiveJobRepository) FindByVideoID(videoID string) ([]*ArchiveJob, error) {
+	query := `
+		SELECT id, video_id, operation, status, error, created_at, updated_at, completed_at
+		FROM archive_jobs
+		WHERE video_id = $1
+		ORDER BY created_at
+	`
+	return r.queryJobs(query, videoID)
+}
+
+// FindFailedSince retrieves the jobs that failed at or after since, newest first
+func (r *PostgresArchiveJobRepository) FindFailedSince(since time.Time) ([]*ArchiveJob, error) {
+	query := `
+		SELECT id, video_id, operation, status, error, created_at, updated_at, completed_at
+		FROM archive_jobs
+		WHERE status = 'failed' AND updated_at >= $1
+		ORDER BY updated_at DESC
+	`
+	return r.queryJobs(query, since)
+}
+
+// queryJobs runs a query returning archive job rows
+func (r *PostgresArchiveJobRepository) queryJobs(query string, args ...interface{}) ([]*ArchiveJob, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []*ArchiveJob
+	for rows.Next() {
+		var job ArchiveJob
+		if err := rows.Scan(
+			&job.ID, &job.VideoID, &job.Operation, &job.Status, &job.Error,
+			&job.CreatedAt, &job.UpdatedAt, &job.CompletedAt,
+		); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, &job)
+	}
+	return jobs, rows.Err()
+}
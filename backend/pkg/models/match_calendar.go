@@ -0,0 +1,159 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// Periods matches can be grouped by in the calendar
+const (
+	CalendarByWeek  = "week"  // Weeks start on Monday
+	CalendarByMonth = "month" // Calendar months
+)
+
+/**
+ * MatchCalendarEntry is a match as shown in the schedule view.
+ */
+type MatchCalendarEntry struct {
+	ID              string          `json:"id"`
+	Title           string          `json:"title"`
+	MatchDate       time.Time       `json:"match_date"`
+	HomeTeam        string          `json:"home_team,omitempty"`
+	AwayTeam        string          `json:"away_team,omitempty"`
+	Competition     string          `json:"competition,omitempty"`
+	ProcessingState ProcessingState `json:"processing_state"`
+}
+
+/**
+ * MatchCalendarGroup holds the matches played in one week or month, with
+ * how many of them are in each processing state.
+ */
+type MatchCalendarGroup struct {
+	Start   time.Time               `json:"start"` // Midnight UTC on the first day of the period
+	Count   int                     `json:"count"`
+	States  map[ProcessingState]int `json:"states"`
+	Matches []MatchCalendarEntry    `json:"matches"` // By match date
+}
+
+/**
+ * MatchCalendarRepository defines read access to the match calendar.
+ */
+type MatchCalendarRepository interface {
+	// Summarize groups the dated matches of a query by the week or month of their match date, earliest first; the query's order and page are ignored
+	Summarize(query VideoQuery, period string) ([]*MatchCalendarGroup, error)
+}
+
+/**
+ * PostgresMatchCalendarRepository groups the videos table in a single
+ * aggregate query, one row per period and processing state.
+ */
+type PostgresMatchCalendarRepository struct {
+	db *gorm.DB
+}
+
+/**
+ * NewPostgresMatchCalendarRepository creates a new PostgreSQL-backed match calendar repository.
+ *
+ * @param db Database connection
+ * @return A new match calendar repository or error
+ */
+func NewPostgresMatchCalendarRepository(db *sql.DB) (MatchCalendarRepository, error) {
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db}), gormConfig())
+	if err != nil {
+		return nil, err
+	}
+	return &PostgresMatchCalendarRepository{db: gormDB}, nil
+}
+
+// calendarRow is a period and processing state of the calendar query
+type calendarRow struct {
+	Period          time.Time
+	ProcessingState sql.NullString
+	Count           int
+	Matches         []byte // JSON array of MatchCalendarEntry
+}
+
+// Summarize groups the dated matches of a query by week or month
+func (r *PostgresMatchCalendarRepository) Summarize(query VideoQuery, period string) ([]*MatchCalendarGroup, error) {
+	if err := validCalendarPeriod(period); err != nil {
+		return nil, err
+	}
+
+	// period is whitelisted above, so it can be part of the SQL
+	var rows []calendarRow
+	err := filterVideos(r.db.Model(&videoRecord{}), query).
+		Where("match_date > ?", time.Time{}).
+		Select(fmt.Sprintf(`date_trunc('%s', match_date AT TIME ZONE 'UTC') AS period,
+			processing_state,
+			COUNT(*) AS count,
+			json_agg(json_build_object(
+				'id', id, 'title', COALESCE(title, ''), 'match_date', match_date,
+				'home_team', COALESCE(home_team, ''), 'away_team', COALESCE(away_team, ''),
+				'competition', COALESCE(competition, ''), 'processing_state', COALESCE(processing_state, '')
+			) ORDER BY match_date, id) AS matches`, period)).
+		Group("1, 2").
+		Order("1, 2").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var groups []*MatchCalendarGroup
+	for _, row := range rows {
+		var matches []MatchCalendarEntry
+		if err := json.Unmarshal(row.Matches, &matches); err != nil {
+			return nil, fmt.Errorf("failed to decode calendar matches: %w", err)
+		}
+		start := time.Date(row.Period.Year(), row.Period.Month(), row.Period.Day(), 0, 0, 0, 0, time.UTC)
+		if len(groups) == 0 || !groups[len(groups)-1].Start.Equal(start) {
+			groups = append(groups, &MatchCalendarGroup{Start: start, States: map[ProcessingState]int{}})
+		}
+		groups[len(groups)-1].add(ProcessingState(row.ProcessingState.String), row.Count, matches)
+	}
+	for _, group := range groups {
+		group.sortMatches()
+	}
+	return groups, nil
+}
+
+// add counts matches in a processing state towards the group
+func (g *MatchCalendarGroup) add(state ProcessingState, count int, matches []MatchCalendarEntry) {
+	g.Count += count
+	g.States[state] += count
+	g.Matches = append(g.Matches, matches...)
+}
+
+// sortMatches orders the matches of the group by match date, then ID
+func (g *MatchCalendarGroup) sortMatches() {
+	sort.SliceStable(g.Matches, func(i, j int) bool {
+		a, b := g.Matches[i], g.Matches[j]
+		if !a.MatchDate.Equal(b.MatchDate) {
+			return a.MatchDate.Before(b.MatchDate)
+		}
+		return a.ID < b.ID
+	})
+}
+
+// validCalendarPeriod rejects periods other than week and month
+func validCalendarPeriod(period string) error {
+	if period != CalendarByWeek && period != CalendarByMonth {
+		return fmt.Errorf("unknown calendar period %q", period)
+	}
+	return nil
+}
+
+// calendarPeriodStart returns the start of the week or month of t, in UTC
+func calendarPeriodStart(t time.Time, period string) time.Time {
+	t = t.UTC()
+	if period == CalendarByMonth {
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+}
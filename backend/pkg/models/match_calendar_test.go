@@ -0,0 +1,86 @@
+package models_test
+
+import (
+	"testing"
+	"time"
+
+	"nivai/backend/pkg/database"
+	"nivai/backend/pkg/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testMatchCalendar runs the calendar expectations against a calendar
+// repository reading the videos of videos.
+func testMatchCalendar(t *testing.T, videos models.VideoRepository, calendar models.MatchCalendarRepository) {
+	amsterdam := time.FixedZone("CEST", 2*60*60)
+	for _, video := range []*models.Video{
+		// Monday 2 September 00:30 in Amsterdam is still Sunday in UTC
+		{ID: "m1", Title: "Ajax - PSV", HomeTeam: "Ajax", AwayTeam: "PSV", Season: "2024", MatchDate: time.Date(2024, 9, 2, 0, 30, 0, 0, amsterdam), ProcessingState: models.StateCompleted},
+		{ID: "m2", Title: "PSV - AZ", HomeTeam: "PSV", AwayTeam: "AZ", Season: "2024", MatchDate: time.Date(2024, 9, 1, 14, 30, 0, 0, time.UTC), ProcessingState: models.StateFailed},
+		{ID: "m3", Title: "AZ - Ajax", HomeTeam: "AZ", AwayTeam: "Ajax", Season: "2024", MatchDate: time.Date(2024, 9, 2, 20, 0, 0, 0, time.UTC), ProcessingState: models.StateCompleted},
+		{ID: "m4", Title: "Ajax - AZ", HomeTeam: "Ajax", AwayTeam: "AZ", Season: "2024", MatchDate: time.Date(2024, 10, 5, 18, 45, 0, 0, time.UTC), ProcessingState: models.StatePending},
+		{ID: "m5", Title: "Old", Season: "2023", MatchDate: time.Date(2023, 9, 3, 12, 0, 0, 0, time.UTC), ProcessingState: models.StateCompleted},
+		{ID: "m6", Title: "Undated", Season: "2024", ProcessingState: models.StatePending},
+	} {
+		video.CreatedAt = time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC)
+		video.UpdatedAt = video.CreatedAt
+		require.NoError(t, videos.Create(video))
+	}
+	day := func(month time.Month, d int) time.Time { return time.Date(2024, month, d, 0, 0, 0, 0, time.UTC) }
+	ids := func(group *models.MatchCalendarGroup) []string {
+		var ids []string
+		for _, match := range group.Matches {
+			ids = append(ids, match.ID)
+		}
+		return ids
+	}
+
+	groups, err := calendar.Summarize(models.VideoQuery{Season: "2024"}, models.CalendarByWeek)
+	require.NoError(t, err)
+	require.Len(t, groups, 3)
+	assert.Equal(t, day(time.August, 26), groups[0].Start)
+	assert.Equal(t, []string{"m2", "m1"}, ids(groups[0]))
+	assert.Equal(t, map[models.ProcessingState]int{models.StateCompleted: 1, models.StateFailed: 1}, groups[0].States)
+	assert.Equal(t, day(time.September, 2), groups[1].Start)
+	assert.Equal(t, 1, groups[1].Count)
+	assert.Equal(t, "AZ - Ajax", groups[1].Matches[0].Title)
+	assert.True(t, groups[1].Matches[0].MatchDate.Equal(time.Date(2024, 9, 2, 20, 0, 0, 0, time.UTC)))
+	assert.Equal(t, day(time.September, 30), groups[2].Start)
+
+	groups, err = calendar.Summarize(models.VideoQuery{Season: "2024", Team: "Ajax"}, models.CalendarByMonth)
+	require.NoError(t, err)
+	require.Len(t, groups, 2)
+	assert.Equal(t, day(time.September, 1), groups[0].Start)
+	assert.Equal(t, []string{"m1", "m3"}, ids(groups[0]))
+	assert.Equal(t, map[models.ProcessingState]int{models.StateCompleted: 2}, groups[0].States)
+	assert.Equal(t, []string{"m4"}, ids(groups[1]))
+
+	groups, err = calendar.Summarize(models.VideoQuery{Access: &models.AccessScope{MatchIDs: []string{"m4"}}}, models.CalendarByMonth)
+	require.NoError(t, err)
+	require.Len(t, groups, 1)
+	assert.Equal(t, 1, groups[0].Count)
+
+	_, err = calendar.Summarize(models.VideoQuery{}, "day")
+	assert.Error(t, err)
+}
+
+func TestMemoryMatchCalendarRepository(t *testing.T) {
+	repos := models.NewMemoryRepositories()
+	testMatchCalendar(t, repos.Videos, repos.MatchCalendar)
+}
+
+func TestSQLiteMatchCalendarRepository(t *testing.T) {
+	repos, err := models.NewSQLiteRepositories(openTestSQLite(t))
+	require.NoError(t, err)
+	testMatchCalendar(t, repos.Videos, repos.MatchCalendar)
+}
+
+func TestPostgresMatchCalendarRepositoryAgainstPostgres(t *testing.T) {
+	db := openTestPostgres(t)
+	require.NoError(t, database.Migrate(db))
+	repos, err := models.NewPostgresRepositories(db)
+	require.NoError(t, err)
+	testMatchCalendar(t, repos.Videos, repos.MatchCalendar)
+}
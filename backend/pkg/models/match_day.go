@@ -0,0 +1,110 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// Match-day modes
+const (
+	MatchDayModeAuto = "auto" // Active around the kickoff time
+	MatchDayModeOn   = "on"   // Manually activated
+	MatchDayModeOff  = "off"  // Manually deactivated, even around kickoff
+)
+
+/**
+ * MatchDaySetting holds the match-day mode and kickoff time of a match.
+ * Matches without a setting behave as mode "auto" without a kickoff time,
+ * i.e. match-day mode is off.
+ */
+type MatchDaySetting struct {
+	VideoID   string       `json:"video_id"`
+	Mode      string       `json:"mode"`
+	KickoffAt sql.NullTime `json:"kickoff_at"`
+	UpdatedAt time.Time    `json:"updated_at"`
+}
+
+/**
+ * MatchDayRepository defines data access for match-day settings.
+ */
+type MatchDayRepository interface {
+	Save(setting *MatchDaySetting) error
+	Find(videoID string) (*MatchDaySetting, error)
+	FindScheduled() ([]*MatchDaySetting, error)
+}
+
+/**
+ * PostgresMatchDayRepository implements MatchDayRepository using PostgreSQL.
+ */
+type PostgresMatchDayRepository struct {
+	db *sql.DB
+}
+
+/**
+ * NewPostgresMatchDayRepository creates a new PostgreSQL-backed match-day repository.
+ *
+ * @param db Database connection
+ * @return A new match-day repository
+ */
+func NewPostgresMatchDayRepository(db *sql.DB) MatchDayRepository {
+	return &PostgresMatchDayRepository{db: db}
+}
+
+// Save inserts or replaces the setting of a match
+func (r *PostgresMatchDayRepository) Save(setting *MatchDaySetting) error {
+	query := `
+		INSERT INTO match_day_settings (video_id, mode, kickoff_at, updated_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (video_id) DO UPDATE
+		SET mode = EXCLUDED.mode, kickoff_at = EXCLUDED.kickoff_at, updated_at = EXCLUDED.updated_at
+	`
+	_, err := r.db.Exec(query, setting.VideoID, setting.Mode, setting.KickoffAt, setting.UpdatedAt)
+	return err
+}
+
+// Find retrieves the setting of a match
+func (r *PostgresMatchDayRepository) Find(videoID string) (*MatchDaySetting, error) {
+	query := `
+		SELECT video_id, mode, kickoff_at, updated_at
+		FROM match_day_settings
+		WHERE video_id = $1
+	`
+
+	var setting MatchDaySetting
+	err := r.db.QueryRow(query, videoID).Scan(&setting.VideoID, &setting.Mode, &setting.KickoffAt, &setting.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("match-day setting not found")
+		}
+		return nil, err
+	}
+
+	return &setting, nil
+}
+
+// FindScheduled retrieves every match that is manually activated or has a kickoff time
+func (r *PostgresMatchDayRepository) FindScheduled() ([]*MatchDaySetting, error) {
+	query := `
+		SELECT video_id, mode, kickoff_at, updated_at
+		FROM match_day_settings
+		WHERE mode = 'on' OR kickoff_at IS NOT NULL
+	`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var settings []*MatchDaySetting
+	for rows.Next() {
+		var setting MatchDaySetting
+		if err := rows.Scan(&setting.VideoID, &setting.Mode, &setting.KickoffAt, &setting.UpdatedAt); err != nil {
+			return nil, err
+		}
+		settings = append(settings, &setting)
+	}
+
+	return settings, rows.Err()
+}
@@ -0,0 +1,118 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+/**
+ * MatchStats summarizes the stored matches for the dashboard.
+ */
+type MatchStats struct {
+	Total        int                     `json:"total"`
+	ByState      map[ProcessingState]int `json:"by_state"`
+	StorageBytes int64                   `json:"storage_bytes"` // Sum of the recorded video sizes
+	UploadsSince int                     `json:"uploads_since"` // Matches created since the requested time
+
+	// Time from a match's first analytics submission to its first
+	// completion, over the matches completed since the requested time
+	ProcessedSince       int     `json:"processed_since"`
+	AvgProcessingSeconds float64 `json:"avg_processing_seconds"`
+}
+
+/**
+ * MatchStatsRepository defines read access to match statistics.
+ */
+type MatchStatsRepository interface {
+	// Overview aggregates the non-deleted matches; since bounds the uploads and processing times counted
+	Overview(since time.Time) (*MatchStats, error)
+}
+
+/**
+ * PostgresMatchStatsRepository aggregates the videos and state_history tables.
+ */
+type PostgresMatchStatsRepository struct {
+	db *sql.DB
+}
+
+/**
+ * NewPostgresMatchStatsRepository creates a new PostgreSQL-backed match statistics repository.
+ *
+ * @param db Database connection
+ * @return A new match statistics repository
+ */
+func NewPostgresMatchStatsRepository(db *sql.DB) MatchStatsRepository {
+	return &PostgresMatchStatsRepository{db: db}
+}
+
+// Overview counts the matches by state, sums their sizes and averages their processing times
+func (r *PostgresMatchStatsRepository) Overview(since time.Time) (*MatchStats, error) {
+	rows, err := r.db.Query(`
+		SELECT COALESCE(processing_state, ''), COUNT(*), COALESCE(SUM(size), 0),
+		       COUNT(*) FILTER (WHERE created_at >= $1)
+		FROM videos
+		WHERE deleted_at IS NULL
+		GROUP BY 1
+	`, since.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := &MatchStats{ByState: map[ProcessingState]int{}}
+	for rows.Next() {
+		var (
+			state          string
+			count, uploads int
+			bytes          int64
+		)
+		if err := rows.Scan(&state, &count, &bytes, &uploads); err != nil {
+			return nil, err
+		}
+		stats.ByState[ProcessingState(state)] = count
+		stats.Total += count
+		stats.StorageBytes += bytes
+		stats.UploadsSince += uploads
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var avg sql.NullFloat64
+	err = r.db.QueryRow(`
+		SELECT COUNT(*), AVG(EXTRACT(EPOCH FROM done.at - started.at))
+		FROM (
+			SELECT video_id, MIN(created_at) AS at FROM state_history
+			WHERE to_state IN ($2, $3) GROUP BY video_id
+		) started
+		JOIN (
+			SELECT video_id, MIN(created_at) AS at FROM state_history
+			WHERE to_state = $4 GROUP BY video_id
+		) done USING (video_id)
+		JOIN videos v ON v.id = video_id AND v.deleted_at IS NULL
+		WHERE done.at >= $1 AND done.at >= started.at
+	`, since.UTC(), StatePendingAnalytics, StateProcessing, StateCompleted).Scan(&stats.ProcessedSince, &avg)
+	if err != nil {
+		return nil, err
+	}
+	stats.AvgProcessingSeconds = avg.Float64
+	return stats, nil
+}
+
+// processingDuration returns the time from the first analytics submission
+// in a match's history to its first completion after it
+func processingDuration(history []*StateTransition) (started, done time.Time, ok bool) {
+	for _, transition := range history {
+		switch transition.To {
+		case StatePendingAnalytics, StateProcessing:
+			if started.IsZero() {
+				started = transition.CreatedAt
+			}
+		case StateCompleted:
+			if !started.IsZero() {
+				return started, transition.CreatedAt, true
+			}
+		}
+	}
+	return time.Time{}, time.Time{}, false
+}
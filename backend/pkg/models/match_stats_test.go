@@ -0,0 +1,72 @@
+package models_test
+
+import (
+	"testing"
+	"time"
+
+	"nivai/backend/pkg/database"
+	"nivai/backend/pkg/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testMatchStats runs the statistics expectations against a statistics
+// repository reading repos.
+func testMatchStats(t *testing.T, repos *models.Repositories) {
+	since := time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC)
+	for _, video := range []*models.Video{
+		{ID: "old", Size: 1000, ProcessingState: models.StateCompleted, CreatedAt: since.AddDate(0, -2, 0)},
+		{ID: "new", Size: 2000, ProcessingState: models.StateCompleted, CreatedAt: since.Add(time.Hour)},
+		{ID: "queued", Size: 500, ProcessingState: models.StatePendingAnalytics, CreatedAt: since.Add(2 * time.Hour)},
+		{ID: "deleted", Size: 9000, ProcessingState: models.StateCompleted, CreatedAt: since.Add(3 * time.Hour)},
+	} {
+		video.UpdatedAt = video.CreatedAt
+		require.NoError(t, repos.Videos.Create(video))
+	}
+	require.NoError(t, repos.Videos.Delete("deleted"))
+
+	for _, transition := range []*models.StateTransition{
+		// Processed before the window
+		{VideoID: "old", To: models.StatePendingAnalytics, CreatedAt: since.AddDate(0, -2, 0)},
+		{VideoID: "old", From: models.StatePendingAnalytics, To: models.StateCompleted, CreatedAt: since.AddDate(0, -2, 0).Add(time.Hour)},
+		// Processed in ten minutes, then reprocessed; only the first run counts
+		{VideoID: "new", To: models.StatePendingAnalytics, CreatedAt: since.Add(time.Hour)},
+		{VideoID: "new", From: models.StatePendingAnalytics, To: models.StateProcessing, CreatedAt: since.Add(time.Hour + time.Minute)},
+		{VideoID: "new", From: models.StateProcessing, To: models.StateCompleted, CreatedAt: since.Add(time.Hour + 10*time.Minute)},
+		{VideoID: "new", From: models.StateCompleted, To: models.StatePendingAnalytics, CreatedAt: since.Add(5 * time.Hour)},
+		{VideoID: "new", From: models.StatePendingAnalytics, To: models.StateCompleted, CreatedAt: since.Add(7 * time.Hour)},
+		{VideoID: "queued", To: models.StatePendingAnalytics, CreatedAt: since.Add(2 * time.Hour)},
+		{VideoID: "deleted", To: models.StatePendingAnalytics, CreatedAt: since.Add(3 * time.Hour)},
+		{VideoID: "deleted", From: models.StatePendingAnalytics, To: models.StateCompleted, CreatedAt: since.Add(4 * time.Hour)},
+	} {
+		require.NoError(t, repos.StateHistory.Create(transition))
+	}
+
+	stats, err := repos.MatchStats.Overview(since)
+	require.NoError(t, err)
+	assert.Equal(t, 3, stats.Total)
+	assert.Equal(t, map[models.ProcessingState]int{models.StateCompleted: 2, models.StatePendingAnalytics: 1}, stats.ByState)
+	assert.Equal(t, int64(3500), stats.StorageBytes)
+	assert.Equal(t, 2, stats.UploadsSince)
+	assert.Equal(t, 1, stats.ProcessedSince)
+	assert.InDelta(t, 600, stats.AvgProcessingSeconds, 0.001)
+}
+
+func TestMemoryMatchStatsRepository(t *testing.T) {
+	testMatchStats(t, models.NewMemoryRepositories())
+}
+
+func TestSQLiteMatchStatsRepository(t *testing.T) {
+	repos, err := models.NewSQLiteRepositories(openTestSQLite(t))
+	require.NoError(t, err)
+	testMatchStats(t, repos)
+}
+
+func TestPostgresMatchStatsRepositoryAgainstPostgres(t *testing.T) {
+	db := openTestPostgres(t)
+	require.NoError(t, database.Migrate(db))
+	repos, err := models.NewPostgresRepositories(db)
+	require.NoError(t, err)
+	testMatchStats(t, repos)
+}
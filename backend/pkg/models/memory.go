@@ -0,0 +1,1570 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// In-memory implementations of the repositories, for demo mode and as test
+// doubles. They follow the semantics of their PostgreSQL counterparts,
+// including error messages, default limits and sort orders; nothing is
+// persisted across restarts.
+
+/**
+ * MemoryWebhookRepository implements WebhookRepository in memory.
+ */
+type MemoryWebhookRepository struct {
+	mu         sync.Mutex
+	webhooks   map[string]*Webhook
+	deleted    map[string]bool
+	deliveries map[string]*WebhookDelivery
+}
+
+/**
+ * NewMemoryWebhookRepository creates an empty in-memory webhook repository.
+ *
+ * @return A new webhook repository
+ */
+func NewMemoryWebhookRepository() *MemoryWebhookRepository {
+	return &MemoryWebhookRepository{
+		webhooks:   map[string]*Webhook{},
+		deleted:    map[string]bool{},
+		deliveries: map[string]*WebhookDelivery{},
+	}
+}
+
+// Create stores a new webhook subscription
+func (r *MemoryWebhookRepository) Create(webhook *Webhook) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.webhooks[webhook.ID] = cloneWebhook(webhook)
+	return nil
+}
+
+// FindByID retrieves a webhook subscription that is not deleted
+func (r *MemoryWebhookRepository) FindByID(id string) (*Webhook, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	webhook, ok := r.webhooks[id]
+	if !ok || r.deleted[id] {
+		return nil, errors.New("webhook not found")
+	}
+	return cloneWebhook(webhook), nil
+}
+
+// FindAll retrieves all webhook subscriptions, newest first
+func (r *MemoryWebhookRepository) FindAll() ([]*Webhook, error) {
+	webhooks := r.findWebhooks(func(*Webhook) bool { return true })
+	sort.SliceStable(webhooks, func(i, j int) bool { return webhooks[i].CreatedAt.After(webhooks[j].CreatedAt) })
+	return webhooks, nil
+}
+
+// FindActiveByEventType retrieves active webhooks subscribed to eventType or to all events, oldest first
+func (r *MemoryWebhookRepository) FindActiveByEventType(eventType string) ([]*Webhook, error) {
+	webhooks := r.findWebhooks(func(webhook *Webhook) bool {
+		return webhook.Active && (slices.Contains(webhook.EventTypes, eventType) || slices.Contains(webhook.EventTypes, "*"))
+	})
+	sort.SliceStable(webhooks, func(i, j int) bool { return webhooks[i].CreatedAt.Before(webhooks[j].CreatedAt) })
+	return webhooks, nil
+}
+
+// Delete soft-deletes a webhook subscription
+func (r *MemoryWebhookRepository) Delete(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	webhook, ok := r.webhooks[id]
+	if !ok || r.deleted[id] {
+		return errors.New("webhook not found")
+	}
+	webhook.Active = false
+	r.deleted[id] = true
+	return nil
+}
+
+// CreateDelivery stores a new delivery record
+func (r *MemoryWebhookRepository) CreateDelivery(delivery *WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.deliveries[delivery.ID] = clone(delivery)
+	return nil
+}
+
+// UpdateDelivery stores the outcome of a delivery attempt
+func (r *MemoryWebhookRepository) UpdateDelivery(delivery *WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.deliveries[delivery.ID]
+	if !ok {
+		return errors.New("webhook delivery not found")
+	}
+	stored.Status = delivery.Status
+	stored.Attempts = delivery.Attempts
+	stored.NextAttemptAt = delivery.NextAttemptAt
+	stored.LastStatusCode = delivery.LastStatusCode
+	stored.LastError = delivery.LastError
+	stored.UpdatedAt = time.Now()
+	stored.DeliveredAt = delivery.DeliveredAt
+	return nil
+}
+
+// FindDueDeliveries retrieves pending deliveries whose next attempt is due
+func (r *MemoryWebhookRepository) FindDueDeliveries(now time.Time, limit int) ([]*WebhookDelivery, error) {
+	deliveries := r.findDeliveries(func(d *WebhookDelivery) bool {
+		return d.Status == DeliveryStatusPending && !d.NextAttemptAt.After(now)
+	})
+	sort.SliceStable(deliveries, func(i, j int) bool { return deliveries[i].NextAttemptAt.Before(deliveries[j].NextAttemptAt) })
+	return paginate(deliveries, defaultLimit(limit, 50), 0), nil
+}
+
+// FindDeliveriesByWebhook retrieves the delivery log for a webhook, newest first
+func (r *MemoryWebhookRepository) FindDeliveriesByWebhook(webhookID string, limit, offset int) ([]*WebhookDelivery, error) {
+	deliveries := r.findDeliveries(func(d *WebhookDelivery) bool { return d.WebhookID == webhookID })
+	sort.SliceStable(deliveries, func(i, j int) bool { return deliveries[i].CreatedAt.After(deliveries[j].CreatedAt) })
+	return paginate(deliveries, defaultLimit(limit, 10), offset), nil
+}
+
+// FindFailedDeliveries retrieves deliveries that gave up at or after since, newest first
+func (r *MemoryWebhookRepository) FindFailedDeliveries(since time.Time, limit int) ([]*WebhookDelivery, error) {
+	deliveries := r.findDeliveries(func(d *WebhookDelivery) bool {
+		return d.Status == DeliveryStatusFailed && !d.UpdatedAt.Before(since)
+	})
+	sort.SliceStable(deliveries, func(i, j int) bool { return deliveries[i].UpdatedAt.After(deliveries[j].UpdatedAt) })
+	return paginate(deliveries, defaultLimit(limit, 100), 0), nil
+}
+
+// findWebhooks returns copies of the subscriptions that are not deleted and match keep
+func (r *MemoryWebhookRepository) findWebhooks(keep func(*Webhook) bool) []*Webhook {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	webhooks := []*Webhook{}
+	for id, webhook := range r.webhooks {
+		if !r.deleted[id] && keep(webhook) {
+			webhooks = append(webhooks, cloneWebhook(webhook))
+		}
+	}
+	return webhooks
+}
+
+// findDeliveries returns copies of the deliveries matching keep
+func (r *MemoryWebhookRepository) findDeliveries(keep func(*WebhookDelivery) bool) []*WebhookDelivery {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	deliveries := []*WebhookDelivery{}
+	for _, delivery := range r.deliveries {
+		if keep(delivery) {
+			deliveries = append(deliveries, clone(delivery))
+		}
+	}
+	return deliveries
+}
+
+// cloneWebhook copies a webhook including its event types
+func cloneWebhook(webhook *Webhook) *Webhook {
+	c := clone(webhook)
+	c.EventTypes = slices.Clone(webhook.EventTypes)
+	return c
+}
+
+/**
+ * MemoryOutboxRepository implements OutboxRepository in memory.
+ */
+type MemoryOutboxRepository struct {
+	mu       sync.Mutex
+	messages map[string]*OutboxMessage
+}
+
+/**
+ * NewMemoryOutboxRepository creates an empty in-memory outbox repository.
+ *
+ * @return A new outbox repository
+ */
+func NewMemoryOutboxRepository() *MemoryOutboxRepository {
+	return &MemoryOutboxRepository{messages: map[string]*OutboxMessage{}}
+}
+
+// Enqueue stores a message; enqueuing the same event twice is a no-op
+func (r *MemoryOutboxRepository) Enqueue(message *OutboxMessage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.messages[message.ID]; !ok {
+		r.messages[message.ID] = clone(message)
+	}
+	return nil
+}
+
+// FindUnpublished retrieves the oldest messages not yet published
+func (r *MemoryOutboxRepository) FindUnpublished(limit int) ([]*OutboxMessage, error) {
+	r.mu.Lock()
+	messages := []*OutboxMessage{}
+	for _, message := range r.messages {
+		if !message.PublishedAt.Valid {
+			messages = append(messages, clone(message))
+		}
+	}
+	r.mu.Unlock()
+
+	sort.SliceStable(messages, func(i, j int) bool { return messages[i].CreatedAt.Before(messages[j].CreatedAt) })
+	return paginate(messages, defaultLimit(limit, 100), 0), nil
+}
+
+// MarkPublished records that a message was acknowledged by the broker
+func (r *MemoryOutboxRepository) MarkPublished(id string, at time.Time) error {
+	return r.update(id, func(message *OutboxMessage) {
+		message.PublishedAt = sql.NullTime{Time: at, Valid: true}
+		message.Attempts++
+		message.LastError = ""
+	})
+}
+
+// MarkFailed records a failed publish attempt; the message stays unpublished
+func (r *MemoryOutboxRepository) MarkFailed(id string, lastError string) error {
+	return r.update(id, func(message *OutboxMessage) {
+		message.Attempts++
+		message.LastError = lastError
+	})
+}
+
+// update applies a change to a stored message
+func (r *MemoryOutboxRepository) update(id string, change func(*OutboxMessage)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	message, ok := r.messages[id]
+	if !ok {
+		return errors.New("outbox message not found")
+	}
+	change(message)
+	return nil
+}
+
+/**
+ * MemoryMatchDayRepository implements MatchDayRepository in memory.
+ */
+type MemoryMatchDayRepository struct {
+	mu       sync.Mutex
+	settings map[string]*MatchDaySetting
+}
+
+/**
+ * NewMemoryMatchDayRepository creates an empty in-memory match-day repository.
+ *
+ * @return A new match-day repository
+ */
+func NewMemoryMatchDayRepository() *MemoryMatchDayRepository {
+	return &MemoryMatchDayRepository{settings: map[string]*MatchDaySetting{}}
+}
+
+// Save inserts or replaces the setting of a match
+func (r *MemoryMatchDayRepository) Save(setting *MatchDaySetting) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.settings[setting.VideoID] = clone(setting)
+	return nil
+}
+
+// Find retrieves the setting of a match
+func (r *MemoryMatchDayRepository) Find(videoID string) (*MatchDaySetting, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	setting, ok := r.settings[videoID]
+	if !ok {
+		return nil, errors.New("match-day setting not found")
+	}
+	return clone(setting), nil
+}
+
+// FindScheduled retrieves every match that is manually activated or has a kickoff time
+func (r *MemoryMatchDayRepository) FindScheduled() ([]*MatchDaySetting, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var settings []*MatchDaySetting
+	for _, setting := range r.settings {
+		if setting.Mode == MatchDayModeOn || setting.KickoffAt.Valid {
+			settings = append(settings, clone(setting))
+		}
+	}
+	return settings, nil
+}
+
+/**
+ * MemoryVideoFileRepository implements VideoFileRepository in memory.
+ */
+type MemoryVideoFileRepository struct {
+	mu    sync.Mutex
+	files map[string][]*VideoFile // By video ID
+}
+
+/**
+ * NewMemoryVideoFileRepository creates an empty in-memory file record repository.
+ *
+ * @return A new video file repository
+ */
+func NewMemoryVideoFileRepository() *MemoryVideoFileRepository {
+	return &MemoryVideoFileRepository{files: map[string][]*VideoFile{}}
+}
+
+// Save inserts or replaces the record for a video's file of the given kind
+func (r *MemoryVideoFileRepository) Save(file *VideoFile) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	files := r.files[file.VideoID]
+	for i, stored := range files {
+		if stored.Kind == file.Kind {
+			replaced := clone(file)
+			replaced.CreatedAt = stored.CreatedAt
+			files[i] = replaced
+			return nil
+		}
+	}
+	r.files[file.VideoID] = append(files, clone(file))
+	return nil
+}
+
+// FindByVideoID retrieves all file records for a video, by kind
+func (r *MemoryVideoFileRepository) FindByVideoID(videoID string) ([]*VideoFile, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var files []*VideoFile
+	for _, file := range r.files[videoID] {
+		files = append(files, clone(file))
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Kind < files[j].Kind })
+	return files, nil
+}
+
+/**
+ * MemoryAnalyticsSnapshotRepository implements AnalyticsSnapshotRepository in memory.
+ */
+type MemoryAnalyticsSnapshotRepository struct {
+	mu        sync.Mutex
+	snapshots map[[2]string]*AnalyticsSnapshot // By video ID and kind
+}
+
+/**
+ * NewMemoryAnalyticsSnapshotRepository creates an empty in-memory snapshot repository.
+ *
+ * @return A new analytics snapshot repository
+ */
+func NewMemoryAnalyticsSnapshotRepository() *MemoryAnalyticsSnapshotRepository {
+	return &MemoryAnalyticsSnapshotRepository{snapshots: map[[2]string]*AnalyticsSnapshot{}}
+}
+
+// Save inserts or replaces a snapshot
+func (r *MemoryAnalyticsSnapshotRepository) Save(snapshot *AnalyticsSnapshot) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.snapshots[[2]string{snapshot.VideoID, snapshot.Kind}] = clone(snapshot)
+	return nil
+}
+
+// Find retrieves a snapshot by video ID and kind
+func (r *MemoryAnalyticsSnapshotRepository) Find(videoID, kind string) (*AnalyticsSnapshot, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	snapshot, ok := r.snapshots[[2]string{videoID, kind}]
+	if !ok {
+		return nil, errors.New("snapshot not found")
+	}
+	return clone(snapshot), nil
+}
+
+// FindMany retrieves the snapshots of several videos, keyed by video ID
+func (r *MemoryAnalyticsSnapshotRepository) FindMany(videoIDs []string, kind string) (map[string]*AnalyticsSnapshot, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	snapshots := make(map[string]*AnalyticsSnapshot, len(videoIDs))
+	for _, videoID := range videoIDs {
+		if snapshot, ok := r.snapshots[[2]string{videoID, kind}]; ok {
+			snapshots[videoID] = clone(snapshot)
+		}
+	}
+	return snapshots, nil
+}
+
+/**
+ * MemoryReferenceDataRepository derives reference data from an in-memory
+ * video repository.
+ */
+type MemoryReferenceDataRepository struct {
+	videos *MemoryVideoRepository
+}
+
+/**
+ * NewMemoryReferenceDataRepository creates a reference data repository over
+ * the videos of an in-memory video repository.
+ *
+ * @param videos The video repository
+ * @return A new reference data repository
+ */
+func NewMemoryReferenceDataRepository(videos *MemoryVideoRepository) *MemoryReferenceDataRepository {
+	return &MemoryReferenceDataRepository{videos: videos}
+}
+
+// Load retrieves the distinct competitions, seasons (newest first), teams,
+// venues and referees; encrypted referees once per blind index
+func (r *MemoryReferenceDataRepository) Load() (*ReferenceData, error) {
+	videos, err := r.videos.matching(VideoQuery{})
+	if err != nil {
+		return nil, err
+	}
+
+	competitions, seasons, teams := map[string]bool{}, map[string]bool{}, map[string]bool{}
+	venues, referees, refereeKeys := map[string]bool{}, map[string]bool{}, map[string]bool{}
+	for _, video := range videos {
+		competitions[video.Competition] = true
+		seasons[video.Season] = true
+		teams[video.HomeTeam] = true
+		teams[video.AwayTeam] = true
+		venues[video.Venue] = true
+		if video.RefereeKey == "" || !refereeKeys[video.RefereeKey] {
+			referees[video.Referee] = true
+			refereeKeys[video.RefereeKey] = true
+		}
+	}
+
+	data := &ReferenceData{Competitions: distinct(competitions), Seasons: distinct(seasons), Teams: distinct(teams),
+		Venues: distinct(venues), Referees: distinct(referees)}
+	slices.Reverse(data.Seasons)
+	return data, nil
+}
+
+// distinct returns the non-empty values of a set, sorted
+func distinct(set map[string]bool) []string {
+	values := []string{}
+	for value := range set {
+		if value != "" {
+			values = append(values, value)
+		}
+	}
+	sort.Strings(values)
+	return values
+}
+
+/**
+ * MemoryStorageUsageRepository implements StorageUsageRepository in memory.
+ */
+type MemoryStorageUsageRepository struct {
+	mu      sync.Mutex
+	usage   map[[2]string]*StorageUsage // By scope and owner
+	charges map[string]*StorageCharge   // By video ID
+}
+
+/**
+ * NewMemoryStorageUsageRepository creates an empty in-memory storage usage repository.
+ *
+ * @return A new storage usage repository
+ */
+func NewMemoryStorageUsageRepository() *MemoryStorageUsageRepository {
+	return &MemoryStorageUsageRepository{usage: map[[2]string]*StorageUsage{}, charges: map[string]*StorageCharge{}}
+}
+
+// FindUsage retrieves the usage of an owner; owners without uploads have zero usage
+func (r *MemoryStorageUsageRepository) FindUsage(scope, owner string) (*StorageUsage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if usage, ok := r.usage[[2]string{scope, owner}]; ok {
+		return clone(usage), nil
+	}
+	return &StorageUsage{Scope: scope, Owner: owner}, nil
+}
+
+// Charge adds an upload to its owners' usage; charging a match twice is a no-op
+func (r *MemoryStorageUsageRepository) Charge(charge *StorageCharge) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.charges[charge.VideoID]; ok {
+		return nil
+	}
+	r.charges[charge.VideoID] = clone(charge)
+	r.adjust(charge, 1)
+	return nil
+}
+
+// Release removes a match's charge from its owners' usage and returns it
+func (r *MemoryStorageUsageRepository) Release(videoID string) (*StorageCharge, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	charge, ok := r.charges[videoID]
+	if !ok {
+		return nil, ErrStorageChargeNotFound
+	}
+	delete(r.charges, videoID)
+	r.adjust(charge, -1)
+	return charge, nil
+}
+
+// ChargedVideoIDs lists the matches with a charge
+func (r *MemoryStorageUsageRepository) ChargedVideoIDs() ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ids := make([]string, 0, len(r.charges))
+	for id := range r.charges {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// adjust adds (sign 1) or subtracts (sign -1) a charge from the usage of its
+// organization and user, never going below zero
+func (r *MemoryStorageUsageRepository) adjust(charge *StorageCharge, sign int64) {
+	for scope, owner := range map[string]string{
+		UsageScopeOrganization: charge.Organization,
+		UsageScopeUser:         charge.UserID,
+	} {
+		key := [2]string{scope, owner}
+		usage, ok := r.usage[key]
+		if !ok {
+			usage = &StorageUsage{Scope: scope, Owner: owner}
+			r.usage[key] = usage
+		}
+		usage.Bytes = max(usage.Bytes+sign*charge.Bytes, 0)
+		usage.Files = max(usage.Files+int(sign)*charge.Files, 0)
+		usage.UpdatedAt = time.Now()
+	}
+}
+
+/**
+ * MemoryRetentionRepository implements RetentionRepository in memory.
+ */
+type MemoryRetentionRepository struct {
+	mu        sync.Mutex
+	overrides map[string][]*RetentionOverride     // By video ID
+	actions   map[string][]*RetentionActionRecord // By video ID, oldest first
+}
+
+/**
+ * NewMemoryRetentionRepository creates an empty in-memory retention repository.
+ *
+ * @return A new retention repository
+ */
+func NewMemoryRetentionRepository() *MemoryRetentionRepository {
+	return &MemoryRetentionRepository{
+		overrides: map[string][]*RetentionOverride{},
+		actions:   map[string][]*RetentionActionRecord{},
+	}
+}
+
+// ReplaceOverrides replaces all overrides of a match
+func (r *MemoryRetentionRepository) ReplaceOverrides(videoID string, overrides []*RetentionOverride) error {
+	stored := make([]*RetentionOverride, 0, len(overrides))
+	for _, o := range overrides {
+		c := clone(o)
+		c.VideoID = videoID
+		stored = append(stored, c)
+	}
+	sort.SliceStable(stored, func(i, j int) bool { return stored[i].Kind < stored[j].Kind })
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.overrides[videoID] = stored
+	return nil
+}
+
+// FindOverrides retrieves the overrides of a match
+func (r *MemoryRetentionRepository) FindOverrides(videoID string) ([]*RetentionOverride, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var overrides []*RetentionOverride
+	for _, o := range r.overrides[videoID] {
+		overrides = append(overrides, clone(o))
+	}
+	return overrides, nil
+}
+
+// RecordAction appends an entry to the retention action log
+func (r *MemoryRetentionRepository) RecordAction(record *RetentionActionRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.actions[record.VideoID] = append(r.actions[record.VideoID], clone(record))
+	return nil
+}
+
+// FindActions retrieves the retention actions taken on a match, oldest first
+func (r *MemoryRetentionRepository) FindActions(videoID string) ([]*RetentionActionRecord, error) {
+	r.mu.Lock()
+	var records []*RetentionActionRecord
+	for _, record := range r.actions[videoID] {
+		records = append(records, clone(record))
+	}
+	r.mu.Unlock()
+
+	sort.SliceStable(records, func(i, j int) bool { return records[i].PerformedAt.Before(records[j].PerformedAt) })
+	return records, nil
+}
+
+/**
+ * MemoryArchiveJobRepository implements ArchiveJobRepository in memory.
+ */
+type MemoryArchiveJobRepository struct {
+	mu   sync.Mutex
+	jobs map[string]*ArchiveJob
+}
+
+/**
+ * NewMemoryArchiveJobRepository creates an empty in-memory archive job repository.
+ *
+ * @return A new archive job repository
+ */
+func NewMemoryArchiveJobRepository() *MemoryArchiveJobRepository {
+	return &MemoryArchiveJobRepository{jobs: map[string]*ArchiveJob{}}
+}
+
+// Create stores a new job
+func (r *MemoryArchiveJobRepository) Create(job *ArchiveJob) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.jobs[job.ID] = clone(job)
+	return nil
+}
+
+// Update saves the status of a job
+func (r *MemoryArchiveJobRepository) Update(job *ArchiveJob) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if stored, ok := r.jobs[job.ID]; ok {
+		stored.Status = job.Status
+		stored.Error = job.Error
+		stored.UpdatedAt = job.UpdatedAt
+		stored.CompletedAt = job.CompletedAt
+	}
+	return nil
+}
+
+// FindByID retrieves a job
+func (r *MemoryArchiveJobRepository) FindByID(id string) (*ArchiveJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[id]
+	if !ok {
+		return nil, ErrArchiveJobNotFound
+	}
+	return clone(job), nil
+}
+
+// FindRunning retrieves the running jobs of an operation, oldest first
+func (r *MemoryArchiveJobRepository) FindRunning(operation string) ([]*ArchiveJob, error) {
+	jobs := r.findJobs(func(job *ArchiveJob) bool {
+		return job.Status == ArchiveJobRunning && job.Operation == operation
+	})
+	sort.SliceStable(jobs, func(i, j int) bool { return jobs[i].CreatedAt.Before(jobs[j].CreatedAt) })
+	return jobs, nil
+}
+
+// FindByVideoID retrieves all jobs of a match, oldest first
+func (r *MemoryArchiveJobRepository) FindByVideoID(videoID string) ([]*ArchiveJob, error) {
+	jobs := r.findJobs(func(job *ArchiveJob) bool { return job.VideoID == videoID })
+	sort.SliceStable(jobs, func(i, j int) bool { return jobs[i].CreatedAt.Before(jobs[j].CreatedAt) })
+	return jobs, nil
+}
+
+// FindFailedSince retrieves the jobs that failed at or after since, newest first
+func (r *MemoryArchiveJobRepository) FindFailedSince(since time.Time) ([]*ArchiveJob, error) {
+	jobs := r.findJobs(func(job *ArchiveJob) bool {
+		return job.Status == ArchiveJobFailed && !job.UpdatedAt.Before(since)
+	})
+	sort.SliceStable(jobs, func(i, j int) bool { return jobs[i].UpdatedAt.After(jobs[j].UpdatedAt) })
+	return jobs, nil
+}
+
+// findJobs returns copies of the jobs matching keep
+func (r *MemoryArchiveJobRepository) findJobs(keep func(*ArchiveJob) bool) []*ArchiveJob {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var jobs []*ArchiveJob
+	for _, job := range r.jobs {
+		if keep(job) {
+			jobs = append(jobs, clone(job))
+		}
+	}
+	return jobs
+}
+
+/**
+ * MemoryUploadSessionRepository implements UploadSessionRepository in memory.
+ */
+type MemoryUploadSessionRepository struct {
+	mu       sync.Mutex
+	sessions map[string]*UploadSession
+}
+
+/**
+ * NewMemoryUploadSessionRepository creates an empty in-memory upload session repository.
+ *
+ * @return A new upload session repository
+ */
+func NewMemoryUploadSessionRepository() *MemoryUploadSessionRepository {
+	return &MemoryUploadSessionRepository{sessions: map[string]*UploadSession{}}
+}
+
+// Create stores a new session
+func (r *MemoryUploadSessionRepository) Create(session *UploadSession) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sessions[session.ID] = cloneUploadSession(session)
+	return nil
+}
+
+// FindByID retrieves a session
+func (r *MemoryUploadSessionRepository) FindByID(id string) (*UploadSession, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	session, ok := r.sessions[id]
+	if !ok {
+		return nil, ErrUploadSessionNotFound
+	}
+	return cloneUploadSession(session), nil
+}
+
+// MarkFinalized sets the finalized time unless it is already set
+func (r *MemoryUploadSessionRepository) MarkFinalized(id string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	session, ok := r.sessions[id]
+	if !ok || session.FinalizedAt.Valid {
+		return ErrUploadSessionFinalized
+	}
+	session.FinalizedAt = sql.NullTime{Time: at, Valid: true}
+	return nil
+}
+
+// cloneUploadSession copies a session including its files
+func cloneUploadSession(session *UploadSession) *UploadSession {
+	c := clone(session)
+	c.Files = slices.Clone(session.Files)
+	return c
+}
+
+/**
+ * MemoryStateHistoryRepository implements StateHistoryRepository in memory.
+ */
+type MemoryStateHistoryRepository struct {
+	mu          sync.Mutex
+	transitions []*StateTransition
+}
+
+/**
+ * NewMemoryStateHistoryRepository creates an empty in-memory state history repository.
+ *
+ * @return A new state history repository
+ */
+func NewMemoryStateHistoryRepository() *MemoryStateHistoryRepository {
+	return &MemoryStateHistoryRepository{}
+}
+
+// Create appends a transition and sets its ID
+func (r *MemoryStateHistoryRepository) Create(transition *StateTransition) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	transition.ID = int64(len(r.transitions) + 1)
+	r.transitions = append(r.transitions, clone(transition))
+	return nil
+}
+
+// FindByVideoID retrieves the transitions of a match, oldest first
+func (r *MemoryStateHistoryRepository) FindByVideoID(videoID string) ([]*StateTransition, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var history []*StateTransition
+	for _, transition := range r.transitions {
+		if transition.VideoID == videoID {
+			history = append(history, clone(transition))
+		}
+	}
+	return history, nil
+}
+
+/**
+ * MemoryPlayerRepository implements PlayerRepository in memory.
+ */
+type MemoryPlayerRepository struct {
+	mu      sync.Mutex
+	players map[string]*Player
+}
+
+/**
+ * NewMemoryPlayerRepository creates an empty in-memory player repository.
+ *
+ * @return A new player repository
+ */
+func NewMemoryPlayerRepository() *MemoryPlayerRepository {
+	return &MemoryPlayerRepository{players: map[string]*Player{}}
+}
+
+// Create stores a new player
+func (r *MemoryPlayerRepository) Create(player *Player) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.players[player.ID] = clonePlayer(player)
+	return nil
+}
+
+// FindByID retrieves a player
+func (r *MemoryPlayerRepository) FindByID(id string) (*Player, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	player, ok := r.players[id]
+	if !ok {
+		return nil, ErrPlayerNotFound
+	}
+	return clonePlayer(player), nil
+}
+
+// FindAll retrieves the players, of one team unless team is empty, by name
+func (r *MemoryPlayerRepository) FindAll(team string, limit, offset int) ([]*Player, error) {
+	return r.findAll(team, limit, offset, func(a, b *Player) bool {
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.ID < b.ID
+	}), nil
+}
+
+// FindAllByCreation retrieves the players, of one team unless team is
+// empty, in the order they were added
+func (r *MemoryPlayerRepository) FindAllByCreation(team string, limit, offset int) ([]*Player, error) {
+	return r.findAll(team, limit, offset, func(a, b *Player) bool {
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return a.ID < b.ID
+	}), nil
+}
+
+// findAll retrieves a page of the players, of one team unless team is
+// empty, sorted by less
+func (r *MemoryPlayerRepository) findAll(team string, limit, offset int, less func(a, b *Player) bool) []*Player {
+	r.mu.Lock()
+	players := []*Player{}
+	for _, player := range r.players {
+		if team == "" || strings.EqualFold(player.Team, team) {
+			players = append(players, clonePlayer(player))
+		}
+	}
+	r.mu.Unlock()
+	sort.Slice(players, func(i, j int) bool { return less(players[i], players[j]) })
+	return paginate(players, defaultLimit(limit, 100), offset)
+}
+
+// Update replaces a player's name, team, jersey number and external IDs
+func (r *MemoryPlayerRepository) Update(player *Player) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.players[player.ID]
+	if !ok {
+		return ErrPlayerNotFound
+	}
+	c := clonePlayer(player)
+	c.CreatedAt = stored.CreatedAt
+	r.players[player.ID] = c
+	return nil
+}
+
+// Delete removes a player
+func (r *MemoryPlayerRepository) Delete(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.players[id]; !ok {
+		return ErrPlayerNotFound
+	}
+	delete(r.players, id)
+	return nil
+}
+
+// clonePlayer copies a player including its external IDs
+func clonePlayer(player *Player) *Player {
+	c := clone(player)
+	c.ExternalIDs = slices.Clone(player.ExternalIDs)
+	return c
+}
+
+/**
+ * MemoryPlayerMappingRepository implements PlayerMappingRepository in memory.
+ */
+type MemoryPlayerMappingRepository struct {
+	mu       sync.Mutex
+	mappings map[[2]string]*PlayerMapping // By video ID and tracking player ID
+}
+
+/**
+ * NewMemoryPlayerMappingRepository creates an empty in-memory player mapping repository.
+ *
+ * @return A new player mapping repository
+ */
+func NewMemoryPlayerMappingRepository() *MemoryPlayerMappingRepository {
+	return &MemoryPlayerMappingRepository{mappings: map[[2]string]*PlayerMapping{}}
+}
+
+// Save inserts or replaces the mapping of a match's tracking player,
+// removing a mapping of the same roster player to another tracking player
+func (r *MemoryPlayerMappingRepository) Save(mapping *PlayerMapping) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for key, stored := range r.mappings {
+		if stored.VideoID == mapping.VideoID && stored.PlayerID == mapping.PlayerID && stored.TrackingPlayerID != mapping.TrackingPlayerID {
+			delete(r.mappings, key)
+		}
+	}
+	key := [2]string{mapping.VideoID, mapping.TrackingPlayerID}
+	c := clone(mapping)
+	if stored, ok := r.mappings[key]; ok {
+		c.CreatedAt = stored.CreatedAt
+	}
+	r.mappings[key] = c
+	return nil
+}
+
+// Delete removes the mapping of a match's tracking player
+func (r *MemoryPlayerMappingRepository) Delete(videoID, trackingPlayerID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := [2]string{videoID, trackingPlayerID}
+	if _, ok := r.mappings[key]; !ok {
+		return ErrPlayerMappingNotFound
+	}
+	delete(r.mappings, key)
+	return nil
+}
+
+// FindByVideoID retrieves the mappings of a match, by tracking player ID
+func (r *MemoryPlayerMappingRepository) FindByVideoID(videoID string) ([]*PlayerMapping, error) {
+	mappings := r.findMappings(func(m *PlayerMapping) bool { return m.VideoID == videoID })
+	sort.Slice(mappings, func(i, j int) bool { return mappings[i].TrackingPlayerID < mappings[j].TrackingPlayerID })
+	return mappings, nil
+}
+
+// FindByPlayerID retrieves the mappings of a roster player, one per match
+func (r *MemoryPlayerMappingRepository) FindByPlayerID(playerID string) ([]*PlayerMapping, error) {
+	mappings := r.findMappings(func(m *PlayerMapping) bool { return m.PlayerID == playerID })
+	sort.Slice(mappings, func(i, j int) bool { return mappings[i].VideoID < mappings[j].VideoID })
+	return mappings, nil
+}
+
+// findMappings returns copies of the mappings matching keep
+func (r *MemoryPlayerMappingRepository) findMappings(keep func(*PlayerMapping) bool) []*PlayerMapping {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	mappings := []*PlayerMapping{}
+	for _, mapping := range r.mappings {
+		if keep(mapping) {
+			mappings = append(mappings, clone(mapping))
+		}
+	}
+	return mappings
+}
+
+/**
+ * MemoryShareLinkRepository implements ShareLinkRepository in memory.
+ */
+type MemoryShareLinkRepository struct {
+	mu       sync.Mutex
+	links    map[string]*ShareLink
+	accesses []*ShareAccess
+}
+
+/**
+ * NewMemoryShareLinkRepository creates an empty in-memory share link repository.
+ *
+ * @return A new share link repository
+ */
+func NewMemoryShareLinkRepository() *MemoryShareLinkRepository {
+	return &MemoryShareLinkRepository{links: map[string]*ShareLink{}}
+}
+
+// Create stores a new share link
+func (r *MemoryShareLinkRepository) Create(link *ShareLink) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.links[link.ID] = cloneShareLink(link)
+	return nil
+}
+
+// FindByID retrieves a share link
+func (r *MemoryShareLinkRepository) FindByID(id string) (*ShareLink, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	link, ok := r.links[id]
+	if !ok {
+		return nil, ErrShareLinkNotFound
+	}
+	return cloneShareLink(link), nil
+}
+
+// FindByVideoID retrieves the links of a match, newest first
+func (r *MemoryShareLinkRepository) FindByVideoID(videoID string) ([]*ShareLink, error) {
+	r.mu.Lock()
+	links := []*ShareLink{}
+	for _, link := range r.links {
+		if link.VideoID == videoID {
+			links = append(links, cloneShareLink(link))
+		}
+	}
+	r.mu.Unlock()
+	sort.Slice(links, func(i, j int) bool {
+		if !links[i].CreatedAt.Equal(links[j].CreatedAt) {
+			return links[i].CreatedAt.After(links[j].CreatedAt)
+		}
+		return links[i].ID < links[j].ID
+	})
+	return links, nil
+}
+
+// Revoke marks a link revoked, keeping an earlier revocation time
+func (r *MemoryShareLinkRepository) Revoke(id string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	link, ok := r.links[id]
+	if !ok {
+		return ErrShareLinkNotFound
+	}
+	if link.RevokedAt == nil {
+		link.RevokedAt = &at
+	}
+	return nil
+}
+
+// RecordAccess appends an access and sets its ID
+func (r *MemoryShareLinkRepository) RecordAccess(access *ShareAccess) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	access.ID = int64(len(r.accesses) + 1)
+	r.accesses = append(r.accesses, clone(access))
+	return nil
+}
+
+// FindAccesses retrieves the accesses of a link, newest first
+func (r *MemoryShareLinkRepository) FindAccesses(shareID string, limit, offset int) ([]*ShareAccess, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	accesses := []*ShareAccess{}
+	for i := len(r.accesses) - 1; i >= 0; i-- {
+		if r.accesses[i].ShareID == shareID {
+			accesses = append(accesses, clone(r.accesses[i]))
+		}
+	}
+	return paginate(accesses, defaultLimit(limit, 100), offset), nil
+}
+
+// cloneShareLink copies a share link including its revocation time
+func cloneShareLink(link *ShareLink) *ShareLink {
+	c := clone(link)
+	if link.RevokedAt != nil {
+		revokedAt := *link.RevokedAt
+		c.RevokedAt = &revokedAt
+	}
+	return c
+}
+
+/**
+ * MemoryAccessGrantRepository implements AccessGrantRepository in memory.
+ */
+type MemoryAccessGrantRepository struct {
+	mu     sync.Mutex
+	grants map[string]*AccessGrant
+}
+
+/**
+ * NewMemoryAccessGrantRepository creates an empty in-memory access grant repository.
+ *
+ * @return A new access grant repository
+ */
+func NewMemoryAccessGrantRepository() *MemoryAccessGrantRepository {
+	return &MemoryAccessGrantRepository{grants: map[string]*AccessGrant{}}
+}
+
+// Save inserts a grant, or changes the permission of the subject's existing
+// grant on the resource
+func (r *MemoryAccessGrantRepository) Save(grant *AccessGrant) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, stored := range r.grants {
+		if stored.SubjectType == grant.SubjectType && stored.SubjectID == grant.SubjectID &&
+			stored.ResourceType == grant.ResourceType && stored.ResourceID == grant.ResourceID {
+			stored.Permission = grant.Permission
+			stored.GrantedBy = grant.GrantedBy
+			grant.ID, grant.CreatedAt = stored.ID, stored.CreatedAt
+			return nil
+		}
+	}
+	r.grants[grant.ID] = clone(grant)
+	return nil
+}
+
+// Delete removes a grant
+func (r *MemoryAccessGrantRepository) Delete(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.grants[id]; !ok {
+		return ErrAccessGrantNotFound
+	}
+	delete(r.grants, id)
+	return nil
+}
+
+// FindByID retrieves a grant
+func (r *MemoryAccessGrantRepository) FindByID(id string) (*AccessGrant, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	grant, ok := r.grants[id]
+	if !ok {
+		return nil, ErrAccessGrantNotFound
+	}
+	return clone(grant), nil
+}
+
+// FindByResource retrieves the grants on a resource, or all grants, by creation
+func (r *MemoryAccessGrantRepository) FindByResource(resourceType, resourceID string) ([]*AccessGrant, error) {
+	return r.findGrants(func(g *AccessGrant) bool {
+		return resourceType == "" || g.ResourceType == resourceType && strings.EqualFold(g.ResourceID, resourceID)
+	}), nil
+}
+
+// FindBySubjects retrieves the grants of a user and of the groups given
+func (r *MemoryAccessGrantRepository) FindBySubjects(userID string, groups []string) ([]*AccessGrant, error) {
+	return r.findGrants(func(g *AccessGrant) bool {
+		return g.SubjectType == AccessSubjectUser && g.SubjectID == userID ||
+			g.SubjectType == AccessSubjectGroup && slices.Contains(groups, g.SubjectID)
+	}), nil
+}
+
+// findGrants returns copies of the grants matching keep, by creation
+func (r *MemoryAccessGrantRepository) findGrants(keep func(*AccessGrant) bool) []*AccessGrant {
+	r.mu.Lock()
+	grants := []*AccessGrant{}
+	for _, grant := range r.grants {
+		if keep(grant) {
+			grants = append(grants, clone(grant))
+		}
+	}
+	r.mu.Unlock()
+	sort.Slice(grants, func(i, j int) bool {
+		if !grants[i].CreatedAt.Equal(grants[j].CreatedAt) {
+			return grants[i].CreatedAt.Before(grants[j].CreatedAt)
+		}
+		return grants[i].ID < grants[j].ID
+	})
+	return grants
+}
+
+/**
+ * MemoryNotificationPreferenceRepository implements NotificationPreferenceRepository in memory.
+ */
+type MemoryNotificationPreferenceRepository struct {
+	mu    sync.Mutex
+	prefs map[string]*NotificationPreference
+}
+
+/**
+ * NewMemoryNotificationPreferenceRepository creates an empty in-memory notification preference repository.
+ *
+ * @return A new notification preference repository
+ */
+func NewMemoryNotificationPreferenceRepository() *MemoryNotificationPreferenceRepository {
+	return &MemoryNotificationPreferenceRepository{prefs: map[string]*NotificationPreference{}}
+}
+
+// Save inserts or replaces a user's preferences
+func (r *MemoryNotificationPreferenceRepository) Save(pref *NotificationPreference) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.prefs[pref.UserID] = cloneNotificationPreference(pref)
+	return nil
+}
+
+// FindByUserID retrieves a user's preferences
+func (r *MemoryNotificationPreferenceRepository) FindByUserID(userID string) (*NotificationPreference, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	pref, ok := r.prefs[userID]
+	if !ok {
+		return nil, ErrNotificationPreferenceNotFound
+	}
+	return cloneNotificationPreference(pref), nil
+}
+
+// FindByKind retrieves the preferences subscribed to a notification kind, by user
+func (r *MemoryNotificationPreferenceRepository) FindByKind(kind string) ([]*NotificationPreference, error) {
+	r.mu.Lock()
+	prefs := []*NotificationPreference{}
+	for _, pref := range r.prefs {
+		if slices.Contains(pref.Kinds, kind) {
+			prefs = append(prefs, cloneNotificationPreference(pref))
+		}
+	}
+	r.mu.Unlock()
+	sort.Slice(prefs, func(i, j int) bool { return prefs[i].UserID < prefs[j].UserID })
+	return prefs, nil
+}
+
+// cloneNotificationPreference copies preferences, including their lists
+func cloneNotificationPreference(pref *NotificationPreference) *NotificationPreference {
+	c := clone(pref)
+	c.Channels = slices.Clone(pref.Channels)
+	c.Kinds = slices.Clone(pref.Kinds)
+	return c
+}
+
+/**
+ * MemoryNotificationRepository implements NotificationRepository in memory.
+ */
+type MemoryNotificationRepository struct {
+	mu            sync.Mutex
+	notifications []*Notification
+}
+
+/**
+ * NewMemoryNotificationRepository creates an empty in-memory notification repository.
+ *
+ * @return A new notification repository
+ */
+func NewMemoryNotificationRepository() *MemoryNotificationRepository {
+	return &MemoryNotificationRepository{}
+}
+
+// Create inserts a notification
+func (r *MemoryNotificationRepository) Create(notification *Notification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.notifications = append(r.notifications, cloneNotification(notification))
+	return nil
+}
+
+// FindByUser retrieves a user's notifications, or only the unread ones, newest first
+func (r *MemoryNotificationRepository) FindByUser(userID string, unreadOnly bool, limit, offset int) ([]*Notification, error) {
+	r.mu.Lock()
+	notifications := []*Notification{}
+	for _, n := range r.notifications {
+		if n.UserID == userID && (!unreadOnly || n.ReadAt == nil) {
+			notifications = append(notifications, cloneNotification(n))
+		}
+	}
+	r.mu.Unlock()
+	sort.SliceStable(notifications, func(i, j int) bool {
+		if !notifications[i].CreatedAt.Equal(notifications[j].CreatedAt) {
+			return notifications[i].CreatedAt.After(notifications[j].CreatedAt)
+		}
+		return notifications[i].ID > notifications[j].ID
+	})
+	return paginate(notifications, defaultLimit(limit, 20), offset), nil
+}
+
+// CountUnread counts a user's unread notifications
+func (r *MemoryNotificationRepository) CountUnread(userID string) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	count := 0
+	for _, n := range r.notifications {
+		if n.UserID == userID && n.ReadAt == nil {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// MarkRead marks one of a user's notifications read
+func (r *MemoryNotificationRepository) MarkRead(userID, id string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, n := range r.notifications {
+		if n.UserID == userID && n.ID == id {
+			if n.ReadAt == nil {
+				n.ReadAt = &at
+			}
+			return nil
+		}
+	}
+	return ErrNotificationNotFound
+}
+
+// MarkAllRead marks a user's unread notifications read
+func (r *MemoryNotificationRepository) MarkAllRead(userID string, at time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	marked := 0
+	for _, n := range r.notifications {
+		if n.UserID == userID && n.ReadAt == nil {
+			n.ReadAt = &at
+			marked++
+		}
+	}
+	return marked, nil
+}
+
+// cloneNotification copies a notification, including its read time
+func cloneNotification(notification *Notification) *Notification {
+	c := clone(notification)
+	if notification.ReadAt != nil {
+		readAt := *notification.ReadAt
+		c.ReadAt = &readAt
+	}
+	return c
+}
+
+/**
+ * MemoryPlayerErasureRepository implements PlayerErasureRepository in memory.
+ */
+type MemoryPlayerErasureRepository struct {
+	mu       sync.Mutex
+	erasures []*PlayerErasure
+}
+
+/**
+ * NewMemoryPlayerErasureRepository creates an empty in-memory player erasure repository.
+ *
+ * @return A new player erasure repository
+ */
+func NewMemoryPlayerErasureRepository() *MemoryPlayerErasureRepository {
+	return &MemoryPlayerErasureRepository{}
+}
+
+// Create stores an erasure
+func (r *MemoryPlayerErasureRepository) Create(erasure *PlayerErasure) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c := clone(erasure)
+	c.MatchIDs = slices.Clone(erasure.MatchIDs)
+	r.erasures = append(r.erasures, c)
+	return nil
+}
+
+// FindAll retrieves the erasures, of one player unless playerID is empty, newest first
+func (r *MemoryPlayerErasureRepository) FindAll(playerID string, limit, offset int) ([]*PlayerErasure, error) {
+	r.mu.Lock()
+	erasures := []*PlayerErasure{}
+	for _, erasure := range r.erasures {
+		if playerID == "" || erasure.PlayerID == playerID {
+			c := clone(erasure)
+			c.MatchIDs = slices.Clone(erasure.MatchIDs)
+			erasures = append(erasures, c)
+		}
+	}
+	r.mu.Unlock()
+	sort.SliceStable(erasures, func(i, j int) bool { return erasures[i].CreatedAt.After(erasures[j].CreatedAt) })
+	return paginate(erasures, defaultLimit(limit, 100), offset), nil
+}
+
+/**
+ * MemoryVideoPurgeRepository implements VideoPurgeRepository in memory.
+ */
+type MemoryVideoPurgeRepository struct {
+	mu     sync.Mutex
+	purges map[string]*VideoPurge
+}
+
+/**
+ * NewMemoryVideoPurgeRepository creates an empty in-memory video purge repository.
+ *
+ * @return A new video purge repository
+ */
+func NewMemoryVideoPurgeRepository() *MemoryVideoPurgeRepository {
+	return &MemoryVideoPurgeRepository{purges: map[string]*VideoPurge{}}
+}
+
+// Create stores a purge, replacing a pending purge of the same match
+func (r *MemoryVideoPurgeRepository) Create(purge *VideoPurge) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.purges[purge.VideoID] = cloneVideoPurge(purge)
+	return nil
+}
+
+// FindByVideoID retrieves the pending purge of a match
+func (r *MemoryVideoPurgeRepository) FindByVideoID(videoID string) (*VideoPurge, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	purge, ok := r.purges[videoID]
+	if !ok {
+		return nil, ErrVideoPurgeNotFound
+	}
+	return cloneVideoPurge(purge), nil
+}
+
+// FindDue retrieves the purges whose grace period ended before the given time, oldest first
+func (r *MemoryVideoPurgeRepository) FindDue(before time.Time, limit int) ([]*VideoPurge, error) {
+	purges := r.sorted(func(p *VideoPurge) bool { return !p.PurgeAfter.After(before) })
+	return paginate(purges, defaultLimit(limit, 100), 0), nil
+}
+
+// FindAll retrieves the pending purges, soonest first
+func (r *MemoryVideoPurgeRepository) FindAll(limit, offset int) ([]*VideoPurge, error) {
+	purges := r.sorted(func(p *VideoPurge) bool { return true })
+	return paginate(purges, defaultLimit(limit, 100), offset), nil
+}
+
+// Delete removes the pending purge of a match
+func (r *MemoryVideoPurgeRepository) Delete(videoID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.purges[videoID]; !ok {
+		return ErrVideoPurgeNotFound
+	}
+	delete(r.purges, videoID)
+	return nil
+}
+
+// sorted copies the purges matching keep, soonest first
+func (r *MemoryVideoPurgeRepository) sorted(keep func(*VideoPurge) bool) []*VideoPurge {
+	r.mu.Lock()
+	purges := []*VideoPurge{}
+	for _, purge := range r.purges {
+		if keep(purge) {
+			purges = append(purges, cloneVideoPurge(purge))
+		}
+	}
+	r.mu.Unlock()
+	sort.Slice(purges, func(i, j int) bool {
+		if !purges[i].PurgeAfter.Equal(purges[j].PurgeAfter) {
+			return purges[i].PurgeAfter.Before(purges[j].PurgeAfter)
+		}
+		return purges[i].VideoID < purges[j].VideoID
+	})
+	return purges
+}
+
+// cloneVideoPurge copies a purge, including its paths
+func cloneVideoPurge(purge *VideoPurge) *VideoPurge {
+	c := clone(purge)
+	c.Paths = slices.Clone(purge.Paths)
+	return c
+}
+
+/**
+ * MemoryMatchCalendarRepository groups matches by reading them from a video
+ * repository page by page. It serves the in-memory and SQLite video
+ * repositories, whose queries cannot aggregate like PostgreSQL's.
+ */
+type MemoryMatchCalendarRepository struct {
+	videos VideoRepository
+}
+
+/**
+ * NewMemoryMatchCalendarRepository creates a match calendar repository over
+ * the videos of a video repository.
+ *
+ * @param videos The video repository
+ * @return A new match calendar repository
+ */
+func NewMemoryMatchCalendarRepository(videos VideoRepository) *MemoryMatchCalendarRepository {
+	return &MemoryMatchCalendarRepository{videos: videos}
+}
+
+// Summarize groups the dated matches of a query by week or month
+func (r *MemoryMatchCalendarRepository) Summarize(query VideoQuery, period string) ([]*MatchCalendarGroup, error) {
+	if err := validCalendarPeriod(period); err != nil {
+		return nil, err
+	}
+
+	const batchSize = 500
+	query.Sort, query.Order = VideoSortMatchDate, SortAscending
+	query.Limit, query.Offset = batchSize, 0
+	var groups []*MatchCalendarGroup
+	for {
+		videos, err := r.videos.FindByQuery(query)
+		if err != nil {
+			return nil, err
+		}
+		for _, video := range videos {
+			if video.MatchDate.IsZero() {
+				continue
+			}
+			start := calendarPeriodStart(video.MatchDate, period)
+			if len(groups) == 0 || !groups[len(groups)-1].Start.Equal(start) {
+				groups = append(groups, &MatchCalendarGroup{Start: start, States: map[ProcessingState]int{}})
+			}
+			groups[len(groups)-1].add(video.ProcessingState, 1, []MatchCalendarEntry{{
+				ID:              video.ID,
+				Title:           video.Title,
+				MatchDate:       video.MatchDate.UTC(),
+				HomeTeam:        video.HomeTeam,
+				AwayTeam:        video.AwayTeam,
+				Competition:     video.Competition,
+				ProcessingState: video.ProcessingState,
+			}})
+		}
+		if len(videos) < batchSize {
+			return groups, nil
+		}
+		query.Offset += batchSize
+	}
+}
+
+/**
+ * MemoryMatchStatsRepository aggregates matches read from a video repository
+ * page by page, with their processing times from a state history
+ * repository. It serves the in-memory and SQLite repositories.
+ */
+type MemoryMatchStatsRepository struct {
+	videos  VideoRepository
+	history StateHistoryRepository
+}
+
+/**
+ * NewMemoryMatchStatsRepository creates a match statistics repository over a
+ * video and a state history repository.
+ *
+ * @param videos The video repository
+ * @param history The state history repository
+ * @return A new match statistics repository
+ */
+func NewMemoryMatchStatsRepository(videos VideoRepository, history StateHistoryRepository) *MemoryMatchStatsRepository {
+	return &MemoryMatchStatsRepository{videos: videos, history: history}
+}
+
+// Overview counts the matches by state, sums their sizes and averages their processing times
+func (r *MemoryMatchStatsRepository) Overview(since time.Time) (*MatchStats, error) {
+	const batchSize = 500
+	stats := &MatchStats{ByState: map[ProcessingState]int{}}
+	var processing time.Duration
+	query := VideoQuery{Sort: VideoSortCreatedAt, Order: SortAscending, Limit: batchSize}
+	for {
+		videos, err := r.videos.FindByQuery(query)
+		if err != nil {
+			return nil, err
+		}
+		for _, video := range videos {
+			stats.Total++
+			stats.ByState[video.ProcessingState]++
+			stats.StorageBytes += video.Size
+			if !video.CreatedAt.Before(since) {
+				stats.UploadsSince++
+			}
+
+			history, err := r.history.FindByVideoID(video.ID)
+			if err != nil {
+				return nil, err
+			}
+			if started, done, ok := processingDuration(history); ok && !done.Before(since) {
+				stats.ProcessedSince++
+				processing += done.Sub(started)
+			}
+		}
+		if len(videos) < batchSize {
+			break
+		}
+		query.Offset += batchSize
+	}
+
+	if stats.ProcessedSince > 0 {
+		stats.AvgProcessingSeconds = processing.Seconds() / float64(stats.ProcessedSince)
+	}
+	return stats, nil
+}
+
+// defaultLimit applies a default to non-positive limits
+func defaultLimit(limit, fallback int) int {
+	if limit <= 0 {
+		return fallback
+	}
+	return limit
+}
+
+// paginate returns the page of items after offset, at most limit long
+func paginate[T any](items []T, limit, offset int) []T {
+	start := min(max(offset, 0), len(items))
+	end := min(start+limit, len(items))
+	return items[start:end]
+}
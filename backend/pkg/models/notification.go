@@ -0,0 +1,132 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// ErrNotificationNotFound is returned when a notification ID is unknown to
+// its user.
+var ErrNotificationNotFound = errors.New("notification not found")
+
+/**
+ * Notification is a message in a user's in-app inbox.
+ */
+type Notification struct {
+	ID        string     `json:"id"`
+	UserID    string     `json:"user_id"`
+	Kind      string     `json:"kind"` // One of the Notification kind constants
+	Subject   string     `json:"subject"`
+	Body      string     `json:"body"`
+	VideoID   string     `json:"video_id,omitempty"` // Match the notification is about, if any
+	CreatedAt time.Time  `json:"created_at"`
+	ReadAt    *time.Time `json:"read_at,omitempty"` // Absent while unread
+}
+
+/**
+ * NotificationRepository defines data access for the in-app inboxes.
+ */
+type NotificationRepository interface {
+	Create(notification *Notification) error
+	// FindByUser lists a user's notifications, or only the unread ones, newest first
+	FindByUser(userID string, unreadOnly bool, limit, offset int) ([]*Notification, error)
+	CountUnread(userID string) (int, error)
+	// MarkRead marks one of a user's notifications read; read ones keep their time
+	MarkRead(userID, id string, at time.Time) error
+	// MarkAllRead marks a user's unread notifications read and returns how many
+	MarkAllRead(userID string, at time.Time) (int, error)
+}
+
+/**
+ * PostgresNotificationRepository implements NotificationRepository using PostgreSQL.
+ */
+type PostgresNotificationRepository struct {
+	db *sql.DB
+}
+
+/**
+ * NewPostgresNotificationRepository creates a new PostgreSQL-backed notification repository.
+ *
+ * @param db Database connection
+ * @return A new notification repository
+ */
+func NewPostgresNotificationRepository(db *sql.DB) NotificationRepository {
+	return &PostgresNotificationRepository{db: db}
+}
+
+// Create inserts a notification
+func (r *PostgresNotificationRepository) Create(n *Notification) error {
+	query := `
+		INSERT INTO notifications (id, user_id, kind, subject, body, video_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+	_, err := r.db.Exec(query, n.ID, n.UserID, n.Kind, n.Subject, n.Body, n.VideoID, n.CreatedAt)
+	return err
+}
+
+// FindByUser retrieves a user's notifications, or only the unread ones, newest first
+func (r *PostgresNotificationRepository) FindByUser(userID string, unreadOnly bool, limit, offset int) ([]*Notification, error) {
+	query := `
+		SELECT id, user_id, kind, subject, body, video_id, created_at, read_at
+		FROM notifications
+		WHERE user_id = $1 AND (NOT $2 OR read_at IS NULL)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $3 OFFSET $4
+	`
+	rows, err := r.db.Query(query, userID, unreadOnly, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notifications := []*Notification{}
+	for rows.Next() {
+		var n Notification
+		var readAt sql.NullTime
+		if err := rows.Scan(&n.ID, &n.UserID, &n.Kind, &n.Subject, &n.Body, &n.VideoID, &n.CreatedAt, &readAt); err != nil {
+			return nil, err
+		}
+		if readAt.Valid {
+			n.ReadAt = &readAt.Time
+		}
+		notifications = append(notifications, &n)
+	}
+	return notifications, rows.Err()
+}
+
+// CountUnread counts a user's unread notifications
+func (r *PostgresNotificationRepository) CountUnread(userID string) (int, error) {
+	var count int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM notifications WHERE user_id = $1 AND read_at IS NULL`, userID).Scan(&count)
+	return count, err
+}
+
+// MarkRead marks one of a user's notifications read
+func (r *PostgresNotificationRepository) MarkRead(userID, id string, at time.Time) error {
+	result, err := r.db.Exec(`
+		UPDATE notifications SET read_at = COALESCE(read_at, $3)
+		WHERE user_id = $1 AND id = $2
+	`, userID, id, at)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrNotificationNotFound
+	}
+	return nil
+}
+
+// MarkAllRead marks a user's unread notifications read
+func (r *PostgresNotificationRepository) MarkAllRead(userID string, at time.Time) (int, error) {
+	result, err := r.db.Exec(`UPDATE notifications SET read_at = $2 WHERE user_id = $1 AND read_at IS NULL`, userID, at)
+	if err != nil {
+		return 0, err
+	}
+	rows, err := result.RowsAffected()
+	return int(rows), err
+}
@@ -0,0 +1,130 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ErrNotificationPreferenceNotFound is returned when a user never set their
+// notification preferences.
+var ErrNotificationPreferenceNotFound = errors.New("notification preference not found")
+
+// Notification kinds users can subscribe to
+const (
+	NotificationProcessingFinished = "processing_finished" // A match's analytics completed
+	NotificationProcessingFailed   = "processing_failed"   // A match's analytics failed
+	NotificationUsageSummary       = "usage_summary"       // Weekly storage usage summary
+	NotificationWebhookFailed      = "webhook_failed"      // A webhook delivery was given up
+)
+
+// NotificationKinds lists every notification kind
+var NotificationKinds = []string{
+	NotificationProcessingFinished,
+	NotificationProcessingFailed,
+	NotificationUsageSummary,
+	NotificationWebhookFailed,
+}
+
+/**
+ * NotificationPreference holds which notifications a user receives, and
+ * where.
+ */
+type NotificationPreference struct {
+	UserID          string    `json:"user_id"`
+	Email           string    `json:"email,omitempty"`
+	SlackWebhookURL string    `json:"slack_webhook_url,omitempty"` // Incoming webhook of the user's Slack channel
+	Channels        []string  `json:"channels"`                    // Channels to deliver on, e.g. "email" and "slack"
+	Kinds           []string  `json:"kinds"`                       // Notification kinds subscribed to
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+/**
+ * NotificationPreferenceRepository defines data access for notification preferences.
+ */
+type NotificationPreferenceRepository interface {
+	// Save inserts or replaces a user's preferences
+	Save(pref *NotificationPreference) error
+	FindByUserID(userID string) (*NotificationPreference, error)
+	// FindByKind lists the preferences subscribed to a notification kind, by user
+	FindByKind(kind string) ([]*NotificationPreference, error)
+}
+
+/**
+ * PostgresNotificationPreferenceRepository implements NotificationPreferenceRepository using PostgreSQL.
+ */
+type PostgresNotificationPreferenceRepository struct {
+	db *sql.DB
+}
+
+/**
+ * NewPostgresNotificationPreferenceRepository creates a new PostgreSQL-backed notification preference repository.
+ *
+ * @param db Database connection
+ * @return A new notification preference repository
+ */
+func NewPostgresNotificationPreferenceRepository(db *sql.DB) NotificationPreferenceRepository {
+	return &PostgresNotificationPreferenceRepository{db: db}
+}
+
+// Save inserts or replaces a user's preferences
+func (r *PostgresNotificationPreferenceRepository) Save(pref *NotificationPreference) error {
+	query := `
+		INSERT INTO notification_preferences (user_id, email, slack_webhook_url, channels, kinds, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (user_id) DO UPDATE
+		SET email = EXCLUDED.email, slack_webhook_url = EXCLUDED.slack_webhook_url,
+		    channels = EXCLUDED.channels, kinds = EXCLUDED.kinds, updated_at = EXCLUDED.updated_at
+	`
+	_, err := r.db.Exec(query, pref.UserID, pref.Email, pref.SlackWebhookURL,
+		pq.Array(pref.Channels), pq.Array(pref.Kinds), pref.UpdatedAt)
+	return err
+}
+
+// FindByUserID retrieves a user's preferences
+func (r *PostgresNotificationPreferenceRepository) FindByUserID(userID string) (*NotificationPreference, error) {
+	prefs, err := r.queryPreferences(`
+		SELECT user_id, email, slack_webhook_url, channels, kinds, updated_at
+		FROM notification_preferences
+		WHERE user_id = $1
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(prefs) == 0 {
+		return nil, ErrNotificationPreferenceNotFound
+	}
+	return prefs[0], nil
+}
+
+// FindByKind retrieves the preferences subscribed to a notification kind, by user
+func (r *PostgresNotificationPreferenceRepository) FindByKind(kind string) ([]*NotificationPreference, error) {
+	return r.queryPreferences(`
+		SELECT user_id, email, slack_webhook_url, channels, kinds, updated_at
+		FROM notification_preferences
+		WHERE $1 = ANY(kinds)
+		ORDER BY user_id
+	`, kind)
+}
+
+// queryPreferences runs a query selecting notification preference rows
+func (r *PostgresNotificationPreferenceRepository) queryPreferences(query string, args ...interface{}) ([]*NotificationPreference, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	prefs := []*NotificationPreference{}
+	for rows.Next() {
+		var p NotificationPreference
+		if err := rows.Scan(&p.UserID, &p.Email, &p.SlackWebhookURL,
+			pq.Array(&p.Channels), pq.Array(&p.Kinds), &p.UpdatedAt); err != nil {
+			return nil, err
+		}
+		prefs = append(prefs, &p)
+	}
+	return prefs, rows.Err()
+}
@@ -0,0 +1,132 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+/**
+ * OutboxMessage is an event waiting to be relayed to the message broker.
+ * The ID is the originating event ID, so consumers can de-duplicate
+ * redeliveries.
+ */
+type OutboxMessage struct {
+	ID          string          `json:"id"`
+	EventType   string          `json:"event_type"`
+	Key         string          `json:"key"` // Partition/routing key, e.g. the video ID
+	Payload     json.RawMessage `json:"payload"`
+	Attempts    int             `json:"attempts"`
+	LastError   string          `json:"last_error,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	PublishedAt sql.NullTime    `json:"published_at,omitempty"`
+}
+
+/**
+ * OutboxRepository defines data access for the event outbox.
+ */
+type OutboxRepository interface {
+	Enqueue(message *OutboxMessage) error
+	FindUnpublished(limit int) ([]*OutboxMessage, error)
+	MarkPublished(id string, at time.Time) error
+	MarkFailed(id string, lastError string) error
+}
+
+/**
+ * PostgresOutboxRepository implements OutboxRepository using PostgreSQL.
+ */
+type PostgresOutboxRepository struct {
+	db *sql.DB
+}
+
+/**
+ * NewPostgresOutboxRepository creates a new PostgreSQL-backed outbox repository.
+ *
+ * @param db Database connection
+ * @return A new outbox repository
+ */
+func NewPostgresOutboxRepository(db *sql.DB) OutboxRepository {
+	return &PostgresOutboxRepository{db: db}
+}
+
+// Enqueue stores a message; enqueuing the same event twice is a no-op
+func (r *PostgresOutboxRepository) Enqueue(message *OutboxMessage) error {
+	query := `
+		INSERT INTO event_outbox (id, event_type, message_key, payload, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (id) DO NOTHING
+	`
+	_, err := r.db.Exec(query,
+		message.ID, message.EventType, message.Key, []byte(message.Payload), message.CreatedAt,
+	)
+	return err
+}
+
+// FindUnpublished retrieves the oldest messages not yet published
+func (r *PostgresOutboxRepository) FindUnpublished(limit int) ([]*OutboxMessage, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+
+	query := `
+		SELECT id, event_type, message_key, payload, attempts, last_error, created_at, published_at
+		FROM event_outbox
+		WHERE published_at IS NULL
+		ORDER BY created_at
+		LIMIT $1
+	`
+
+	rows, err := r.db.Query(query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []*OutboxMessage
+	for rows.Next() {
+		var message OutboxMessage
+		var payload []byte
+		if err := rows.Scan(
+			&message.ID, &message.EventType, &message.Key, &payload, &message.Attempts,
+			&message.LastError, &message.CreatedAt, &message.PublishedAt,
+		); err != nil {
+			return nil, err
+		}
+		message.Payload = payload
+		messages = append(messages, &message)
+	}
+
+	return messages, rows.Err()
+}
+
+// MarkPublished records that a message was acknowledged by the broker
+func (r *PostgresOutboxRepository) MarkPublished(id string, at time.Time) error {
+	query := `UPDATE event_outbox SET published_at = $2, attempts = attempts + 1, last_error = '' WHERE id = $1`
+	return r.execOne(query, id, at)
+}
+
+// MarkFailed records a failed publish attempt; the message stays unpublished
+func (r *PostgresOutboxRepository) MarkFailed(id string, lastError string) error {
+	query := `UPDATE event_outbox SET attempts = attempts + 1, last_error = $2 WHERE id = $1`
+	return r.execOne(query, id, lastError)
+}
+
+// execOne runs an update that must affect exactly one outbox row
+func (r *PostgresOutboxRepository) execOne(query string, args ...interface{}) error {
+	result, err := r.db.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("outbox message not found")
+	}
+
+	return nil
+}
@@ -0,0 +1,312 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+var (
+	// ErrPlayerNotFound is returned when a player ID is unknown.
+	ErrPlayerNotFound = errors.New("player not found")
+	// ErrPlayerMappingNotFound is returned when a tracking player of a match is not mapped.
+	ErrPlayerMappingNotFound = errors.New("player mapping not found")
+)
+
+// Sources of a player mapping
+const (
+	MappingSourceManual    = "manual"     // Set through the API
+	MappingSourceEventData = "event_data" // Event positions matched to tracking positions
+	MappingSourceJersey    = "jersey"     // Team and jersey number of the tracking ID
+)
+
+/**
+ * Player is a roster entry: the stable identity of a player across matches.
+ * Tracking data names players per match; mappings link those names to it.
+ */
+type Player struct {
+	ID           string    `json:"id"`
+	Name         string    `json:"name"`
+	Team         string    `json:"team,omitempty"`          // As in the matches' home and away team
+	JerseyNumber int       `json:"jersey_number,omitempty"` // 0 when unknown
+	ExternalIDs  []string  `json:"external_ids"`            // The player's IDs in event feeds, like StatsBomb's or Opta's
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+/**
+ * PlayerMapping links the player ID of a match's tracking data to a
+ * roster player. A tracking player maps to at most one roster player, and
+ * a roster player to at most one tracking player per match.
+ */
+type PlayerMapping struct {
+	VideoID          string    `json:"video_id"`
+	TrackingPlayerID string    `json:"tracking_player_id"`
+	PlayerID         string    `json:"player_id"`
+	Source           string    `json:"source"`     // One of the MappingSource constants
+	Confidence       float64   `json:"confidence"` // 1 for manual mappings
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+/**
+ * PlayerRepository defines data access for the player roster.
+ */
+type PlayerRepository interface {
+	Create(player *Player) error
+	FindByID(id string) (*Player, error)
+	// FindAll lists the players, of one team unless team is empty, by name
+	FindAll(team string, limit, offset int) ([]*Player, error)
+	// FindAllByCreation lists the players like FindAll, in the order they
+	// were added, for names stored encrypted that cannot be sorted
+	FindAllByCreation(team string, limit, offset int) ([]*Player, error)
+	// Update replaces a player's name, team, jersey number and external IDs
+	Update(player *Player) error
+	// Delete removes a player; their mappings must be removed first
+	Delete(id string) error
+}
+
+/**
+ * PlayerMappingRepository defines data access for the mappings of tracking
+ * players to roster players.
+ */
+type PlayerMappingRepository interface {
+	// Save inserts or replaces the mapping of a match's tracking player
+	Save(mapping *PlayerMapping) error
+	Delete(videoID, trackingPlayerID string) error
+	FindByVideoID(videoID string) ([]*PlayerMapping, error)
+	FindByPlayerID(playerID string) ([]*PlayerMapping, error)
+}
+
+/**
+ * PostgresPlayerRepository implements PlayerRepository using PostgreSQL.
+ */
+type PostgresPlayerRepository struct {
+	db *sql.DB
+}
+
+/**
+ * NewPostgresPlayerRepository creates a new PostgreSQL-backed player repository.
+ *
+ * @param db Database connection
+ * @return A new player repository
+ */
+func NewPostgresPlayerRepository(db *sql.DB) PlayerRepository {
+	return &PostgresPlayerRepository{db: db}
+}
+
+// Create inserts a new player
+func (r *PostgresPlayerRepository) Create(player *Player) error {
+	query := `
+		INSERT INTO players (id, name, team, jersey_number, external_ids, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+	_, err := r.db.Exec(query,
+		player.ID, player.Name, player.Team, player.JerseyNumber, pq.Array(player.ExternalIDs),
+		player.CreatedAt, player.UpdatedAt,
+	)
+	return err
+}
+
+// FindByID retrieves a player
+func (r *PostgresPlayerRepository) FindByID(id string) (*Player, error) {
+	players, err := r.queryPlayers(`
+		SELECT id, name, team, jersey_number, external_ids, created_at, updated_at
+		FROM players
+		WHERE id = $1
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(players) == 0 {
+		return nil, ErrPlayerNotFound
+	}
+	return players[0], nil
+}
+
+// FindAll retrieves the players, of one team unless team is empty, by name
+func (r *PostgresPlayerRepository) FindAll(team string, limit, offset int) ([]*Player, error) {
+	query := `
+		SELECT id, name, team, jersey_number, external_ids, created_at, updated_at
+		FROM players
+		WHERE $1 = '' OR LOWER(team) = LOWER($1)
+		ORDER BY name, id
+		LIMIT $2 OFFSET $3
+	`
+	return r.queryPlayers(query, team, defaultLimit(limit, 100), max(offset, 0))
+}
+
+// FindAllByCreation retrieves the players, of one team unless team is
+// empty, in the order they were added
+func (r *PostgresPlayerRepository) FindAllByCreation(team string, limit, offset int) ([]*Player, error) {
+	query := `
+		SELECT id, name, team, jersey_number, external_ids, created_at, updated_at
+		FROM players
+		WHERE $1 = '' OR LOWER(team) = LOWER($1)
+		ORDER BY created_at, id
+		LIMIT $2 OFFSET $3
+	`
+	return r.queryPlayers(query, team, defaultLimit(limit, 100), max(offset, 0))
+}
+
+// Update replaces a player's name, team, jersey number and external IDs
+func (r *PostgresPlayerRepository) Update(player *Player) error {
+	query := `
+		UPDATE players
+		SET name = $2, team = $3, jersey_number = $4, external_ids = $5, updated_at = $6
+		WHERE id = $1
+	`
+	result, err := r.db.Exec(query,
+		player.ID, player.Name, player.Team, player.JerseyNumber, pq.Array(player.ExternalIDs), player.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	return playerAffected(result)
+}
+
+// Delete removes a player
+func (r *PostgresPlayerRepository) Delete(id string) error {
+	result, err := r.db.Exec(`DELETE FROM players WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	return playerAffected(result)
+}
+
+// playerAffected returns ErrPlayerNotFound when a statement changed no player
+func playerAffected(result sql.Result) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrPlayerNotFound
+	}
+	return nil
+}
+
+// queryPlayers runs a query selecting player rows
+func (r *PostgresPlayerRepository) queryPlayers(query string, args ...interface{}) ([]*Player, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	players := []*Player{}
+	for rows.Next() {
+		var p Player
+		if err := rows.Scan(&p.ID, &p.Name, &p.Team, &p.JerseyNumber, pq.Array(&p.ExternalIDs), &p.CreatedAt, &p.UpdatedAt); err != nil {
+			return nil, err
+		}
+		players = append(players, &p)
+	}
+	return players, rows.Err()
+}
+
+/**
+ * PostgresPlayerMappingRepository implements PlayerMappingRepository using PostgreSQL.
+ */
+type PostgresPlayerMappingRepository struct {
+	db *sql.DB
+}
+
+/**
+ * NewPostgresPlayerMappingRepository creates a new PostgreSQL-backed player mapping repository.
+ *
+ * @param db Database connection
+ * @return A new player mapping repository
+ */
+func NewPostgresPlayerMappingRepository(db *sql.DB) PlayerMappingRepository {
+	return &PostgresPlayerMappingRepository{db: db}
+}
+
+// Save inserts or replaces the mapping of a match's tracking player. A
+// mapping of the same roster player to another tracking player of the match
+// is removed.
+func (r *PostgresPlayerMappingRepository) Save(mapping *PlayerMapping) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM player_mappings WHERE video_id = $1 AND player_id = $2 AND tracking_player_id <> $3`,
+		mapping.VideoID, mapping.PlayerID, mapping.TrackingPlayerID); err != nil {
+		return err
+	}
+	query := `
+		INSERT INTO player_mappings (video_id, tracking_player_id, player_id, source, confidence, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (video_id, tracking_player_id) DO UPDATE
+		SET player_id = EXCLUDED.player_id, source = EXCLUDED.source,
+		    confidence = EXCLUDED.confidence, updated_at = EXCLUDED.updated_at
+	`
+	if _, err := tx.Exec(query,
+		mapping.VideoID, mapping.TrackingPlayerID, mapping.PlayerID, mapping.Source, mapping.Confidence,
+		mapping.CreatedAt, mapping.UpdatedAt,
+	); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Delete removes the mapping of a match's tracking player
+func (r *PostgresPlayerMappingRepository) Delete(videoID, trackingPlayerID string) error {
+	result, err := r.db.Exec(`DELETE FROM player_mappings WHERE video_id = $1 AND tracking_player_id = $2`, videoID, trackingPlayerID)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrPlayerMappingNotFound
+	}
+	return nil
+}
+
+// FindByVideoID retrieves the mappings of a match, by tracking player ID
+func (r *PostgresPlayerMappingRepository) FindByVideoID(videoID string) ([]*PlayerMapping, error) {
+	query := `
+		SELECT video_id, tracking_player_id, player_id, source, confidence, created_at, updated_at
+		FROM player_mappings
+		WHERE video_id = $1
+		ORDER BY tracking_player_id
+	`
+	return r.queryMappings(query, videoID)
+}
+
+// FindByPlayerID retrieves the mappings of a roster player, one per match
+func (r *PostgresPlayerMappingRepository) FindByPlayerID(playerID string) ([]*PlayerMapping, error) {
+	query := `
+		SELECT video_id, tracking_player_id, player_id, source, confidence, created_at, updated_at
+		FROM player_mappings
+		WHERE player_id = $1
+		ORDER BY video_id
+	`
+	return r.queryMappings(query, playerID)
+}
+
+// queryMappings runs a query selecting mapping rows
+func (r *PostgresPlayerMappingRepository) queryMappings(query string, args ...interface{}) ([]*PlayerMapping, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	mappings := []*PlayerMapping{}
+	for rows.Next() {
+		var m PlayerMapping
+		if err := rows.Scan(&m.VideoID, &m.TrackingPlayerID, &m.PlayerID, &m.Source, &m.Confidence, &m.CreatedAt, &m.UpdatedAt); err != nil {
+			return nil, err
+		}
+		mappings = append(mappings, &m)
+	}
+	return mappings, rows.Err()
+}
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Modes of a player erasure
+const (
+	// ErasureModePseudonymize replaces the player's name and feed IDs but
+	// keeps their mappings, so their statistics stay linked to a pseudonym.
+	ErasureModePseudonymize = "pseudonymize"
+	// ErasureModeErase removes the player from the roster, their mappings
+	// and their statistics in the stored analytics.
+	ErasureModeErase = "erase"
+)
+
+/**
+ * PlayerErasure records the pseudonymization or erasure of a roster player
+ * for a data subject request. It names the player only by roster ID, so the
+ * audit trail outlives the data it describes.
+ */
+type PlayerErasure struct {
+	ID          string    `json:"id"`
+	PlayerID    string    `json:"player_id"`
+	Mode        string    `json:"mode"` // One of the ErasureMode constants
+	Reason      string    `json:"reason"`
+	RequestedBy string    `json:"requested_by"`
+	MatchIDs    []string  `json:"match_ids"` // Matches whose derived analytics were invalidated
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+/**
+ * PlayerErasureRepository defines data access for the erasure audit trail.
+ */
+type PlayerErasureRepository interface {
+	Create(erasure *PlayerErasure) error
+	// FindAll lists the erasures, of one player unless playerID is empty, newest first
+	FindAll(playerID string, limit, offset int) ([]*PlayerErasure, error)
+}
+
+/**
+ * PostgresPlayerErasureRepository implements PlayerErasureRepository using PostgreSQL.
+ */
+type PostgresPlayerErasureRepository struct {
+	db *sql.DB
+}
+
+/**
+ * NewPostgresPlayerErasureRepository creates a new PostgreSQL-backed player erasure repository.
+ *
+ * @param db Database connection
+ * @return A new player erasure repository
+ */
+func NewPostgresPlayerErasureRepository(db *sql.DB) PlayerErasureRepository {
+	return &PostgresPlayerErasureRepository{db: db}
+}
+
+// Create inserts an erasure
+func (r *PostgresPlayerErasureRepository) Create(e *PlayerErasure) error {
+	query := `
+		INSERT INTO player_erasures (id, player_id, mode, reason, requested_by, match_ids, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+	_, err := r.db.Exec(query, e.ID, e.PlayerID, e.Mode, e.Reason, e.RequestedBy, pq.Array(e.MatchIDs), e.CreatedAt)
+	return err
+}
+
+// FindAll retrieves the erasures, of one player unless playerID is empty, newest first
+func (r *PostgresPlayerErasureRepository) FindAll(playerID string, limit, offset int) ([]*PlayerErasure, error) {
+	query := `
+		SELECT id, player_id, mode, reason, requested_by, match_ids, created_at
+		FROM player_erasures
+		WHERE $1 = '' OR player_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2 OFFSET $3
+	`
+	rows, err := r.db.Query(query, playerID, defaultLimit(limit, 100), max(offset, 0))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	erasures := []*PlayerErasure{}
+	for rows.Next() {
+		var e PlayerErasure
+		if err := rows.Scan(&e.ID, &e.PlayerID, &e.Mode, &e.Reason, &e.RequestedBy, pq.Array(&e.MatchIDs), &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		erasures = append(erasures, &e)
+	}
+	return erasures, rows.Err()
+}
@@ -0,0 +1,99 @@
+package models_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testPostgres is the PostgreSQL container started for the tests run
+// without NIVAI_TEST_POSTGRES_DSN. It is started by the first test that
+// needs it and removed when all tests are done.
+var testPostgres struct {
+	once sync.Once
+	id   string
+	dsn  string
+	err  error
+}
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	if testPostgres.id != "" {
+		exec.Command("docker", "rm", "-f", testPostgres.id).Run()
+	}
+	os.Exit(code)
+}
+
+// testPostgresDSN returns NIVAI_TEST_POSTGRES_DSN or, without it, the DSN
+// of a postgres:15-alpine container started with Docker.
+func testPostgresDSN() (string, error) {
+	if dsn := os.Getenv("NIVAI_TEST_POSTGRES_DSN"); dsn != "" {
+		return dsn, nil
+	}
+	testPostgres.once.Do(func() {
+		testPostgres.id, testPostgres.dsn, testPostgres.err = startPostgresContainer()
+	})
+	return testPostgres.dsn, testPostgres.err
+}
+
+// startPostgresContainer runs PostgreSQL on a free local port and waits
+// until it accepts connections.
+func startPostgresContainer() (id, dsn string, err error) {
+	if _, err := exec.LookPath("docker"); err != nil {
+		return "", "", errors.New("NIVAI_TEST_POSTGRES_DSN not set and docker not found")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Minute) // Includes pulling the image
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, "docker", "run", "-d", "--rm",
+		"-e", "POSTGRES_PASSWORD=test", "-p", "127.0.0.1::5432", "postgres:15-alpine").Output()
+	if err != nil {
+		return "", "", fmt.Errorf("starting PostgreSQL container: %w", err)
+	}
+	id = strings.TrimSpace(string(out))
+	out, err = exec.CommandContext(ctx, "docker", "port", id, "5432/tcp").Output()
+	if err != nil {
+		return id, "", fmt.Errorf("reading PostgreSQL container port: %w", err)
+	}
+	addr, _, _ := strings.Cut(strings.TrimSpace(string(out)), "\n")
+	dsn = "postgres://postgres:test@" + addr + "/postgres?sslmode=disable"
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return id, "", err
+	}
+	defer db.Close()
+	// The image's initialization listens on a socket only, so the first TCP
+	// connection is to the initialized server
+	for {
+		if err = db.PingContext(ctx); err == nil {
+			return id, dsn, nil
+		}
+		select {
+		case <-ctx.Done():
+			return id, "", fmt.Errorf("waiting for PostgreSQL container: %w", err)
+		case <-time.After(250 * time.Millisecond):
+		}
+	}
+}
+
+// requirePostgres returns the DSN of the database to test against. Without
+// one the test is skipped, except in CI, where it must not pass unrun.
+func requirePostgres(t *testing.T) string {
+	t.Helper()
+	dsn, err := testPostgresDSN()
+	if err != nil {
+		if os.Getenv("CI") != "" {
+			t.Fatalf("PostgreSQL tests cannot run in CI: %v", err)
+		}
+		t.Skip(err)
+	}
+	return dsn
+}
@@ -0,0 +1,102 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+)
+
+/**
+ * ProcessingState is where a match is in its lifecycle, from upload through
+ * analytics to cold storage. States change only along the transitions of
+ * the state machine below.
+ */
+type ProcessingState string
+
+// Processing states
+const (
+	StateDraft            ProcessingState = "draft"             // Created from metadata, awaiting its files
+	StatePending          ProcessingState = "pending"           // Uploaded without analytics files, awaiting video processing
+	StatePendingAnalytics ProcessingState = "pending_analytics" // Sent to the analytics service
+	StateProcessing       ProcessingState = "processing"        // Being processed
+	StateCompleted        ProcessingState = "completed"         // Analytics available
+	StateFailed           ProcessingState = "failed"            // Processing failed
+	StateCancelled        ProcessingState = "cancelled"         // Processing aborted on request
+	StateRejected         ProcessingState = "rejected"          // Quarantined for malware; final
+	StateArchived         ProcessingState = "archived"          // Large files in cold storage
+	StateRestoring        ProcessingState = "restoring"         // Being restored from cold storage
+)
+
+// ErrInvalidTransition is returned for a state change the state machine does not allow.
+var ErrInvalidTransition = errors.New("invalid processing state transition")
+
+// ErrStateConflict is returned when a video is no longer in the processing
+// state a change was made from.
+var ErrStateConflict = errors.New("processing state changed concurrently")
+
+// transitions lists the states each state may change to. A new match has
+// no state yet.
+var transitions = map[ProcessingState][]ProcessingState{
+	"":                    {StateDraft, StatePending, StatePendingAnalytics},
+	StateDraft:            {StatePendingAnalytics, StateRejected},
+	StatePending:          {StateProcessing, StatePendingAnalytics, StateFailed, StateRejected},
+	StatePendingAnalytics: {StateProcessing, StateCompleted, StateFailed, StateCancelled, StateRejected},
+	StateProcessing:       {StateCompleted, StateFailed, StateCancelled},
+	StateCompleted:        {StatePendingAnalytics, StateArchived},
+	StateFailed:           {StatePendingAnalytics},
+	StateCancelled:        {StatePendingAnalytics},
+	StateArchived:         {StateRestoring},
+	StateRestoring:        {StateCompleted, StateArchived},
+}
+
+/**
+ * Valid reports whether s is a known processing state.
+ *
+ * @return Whether the state is known
+ */
+func (s ProcessingState) Valid() bool {
+	switch s {
+	case StateDraft, StatePending, StatePendingAnalytics, StateProcessing, StateCompleted,
+		StateFailed, StateCancelled, StateRejected, StateArchived, StateRestoring:
+		return true
+	}
+	return false
+}
+
+/**
+ * Transitions returns the states the state machine allows changing s to.
+ *
+ * @return The next states, empty for a final state
+ */
+func (s ProcessingState) Transitions() []ProcessingState {
+	return append([]ProcessingState{}, transitions[s]...)
+}
+
+/**
+ * CanTransitionTo reports whether the state machine allows changing from s
+ * to next.
+ *
+ * @param next The new state
+ * @return Whether the transition is allowed
+ */
+func (s ProcessingState) CanTransitionTo(next ProcessingState) bool {
+	for _, allowed := range transitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+/**
+ * CheckTransition returns an ErrInvalidTransition error when the state
+ * machine does not allow changing from s to next.
+ *
+ * @param next The new state
+ * @return nil, or an error wrapping ErrInvalidTransition
+ */
+func (s ProcessingState) CheckTransition(next ProcessingState) error {
+	if s.CanTransitionTo(next) {
+		return nil
+	}
+	return fmt.Errorf("%w: %q to %q", ErrInvalidTransition, s, next)
+}
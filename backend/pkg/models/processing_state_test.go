@@ -0,0 +1,50 @@
+package models_test
+
+import (
+	"testing"
+
+	"nivai/backend/pkg/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProcessingStateTransitions(t *testing.T) {
+	allowed := []struct{ from, to models.ProcessingState }{
+		{"", models.StatePendingAnalytics},
+		{models.StatePendingAnalytics, models.StateCompleted},
+		{models.StatePendingAnalytics, models.StateRejected},
+		{models.StateProcessing, models.StateFailed},
+		{models.StateFailed, models.StatePendingAnalytics},
+		{models.StateCompleted, models.StateArchived},
+		{models.StateArchived, models.StateRestoring},
+		{models.StateRestoring, models.StateCompleted},
+		{models.StateProcessing, models.StateCancelled},
+		{models.StateCancelled, models.StatePendingAnalytics},
+		{models.StateCompleted, models.StatePendingAnalytics},
+		{"", models.StateDraft},
+		{models.StateDraft, models.StatePendingAnalytics},
+		{models.StateDraft, models.StateRejected},
+	}
+	for _, tc := range allowed {
+		assert.NoError(t, tc.from.CheckTransition(tc.to), "%q to %q", tc.from, tc.to)
+	}
+
+	refused := []struct{ from, to models.ProcessingState }{
+		{"", models.StateCompleted},
+		{models.StateRejected, models.StatePendingAnalytics},
+		{models.StateCompleted, models.StateProcessing},
+		{models.StateArchived, models.StateCompleted},
+		{models.StatePending, "unknown"},
+		{models.StateCompleted, models.StateCancelled},
+		{models.StateCancelled, models.StateCompleted},
+		{models.StateDraft, models.StateProcessing},
+		{models.StateCompleted, models.StateDraft},
+	}
+	for _, tc := range refused {
+		assert.ErrorIs(t, tc.from.CheckTransition(tc.to), models.ErrInvalidTransition, "%q to %q", tc.from, tc.to)
+	}
+
+	assert.True(t, models.StateRejected.Valid())
+	assert.False(t, models.ProcessingState("").Valid())
+	assert.False(t, models.ProcessingState("done").Valid())
+}
@@ -0,0 +1,118 @@
+package models
+
+import (
+	"database/sql"
+)
+
+/**
+ * ReferenceData holds the distinct competitions, seasons, teams, venues and
+ * referees known from uploaded matches, used to populate frontend filters
+ * and pickers.
+ */
+type ReferenceData struct {
+	Competitions []string `json:"competitions"`
+	Seasons      []string `json:"seasons"`
+	Teams        []string `json:"teams"`
+	Venues       []string `json:"venues"`
+	Referees     []string `json:"referees"`
+}
+
+/**
+ * ReferenceDataRepository defines read access to reference data.
+ */
+type ReferenceDataRepository interface {
+	Load() (*ReferenceData, error)
+}
+
+/**
+ * PostgresReferenceDataRepository derives reference data from the videos table.
+ */
+type PostgresReferenceDataRepository struct {
+	db *sql.DB
+}
+
+/**
+ * NewPostgresReferenceDataRepository creates a new PostgreSQL-backed reference data repository.
+ *
+ * @param db Database connection
+ * @return A new reference data repository
+ */
+func NewPostgresReferenceDataRepository(db *sql.DB) ReferenceDataRepository {
+	return &PostgresReferenceDataRepository{db: db}
+}
+
+// Load retrieves the distinct competitions, seasons (newest first), teams,
+// venues and referees; encrypted referees once per blind index
+func (r *PostgresReferenceDataRepository) Load() (*ReferenceData, error) {
+	competitions, err := r.queryStrings(`
+		SELECT DISTINCT competition FROM videos
+		WHERE deleted_at IS NULL AND competition <> ''
+		ORDER BY competition
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	seasons, err := r.queryStrings(`
+		SELECT DISTINCT season FROM videos
+		WHERE deleted_at IS NULL AND season <> ''
+		ORDER BY season DESC
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	teams, err := r.queryStrings(`
+		SELECT team FROM (
+			SELECT home_team AS team FROM videos WHERE deleted_at IS NULL
+			UNION
+			SELECT away_team AS team FROM videos WHERE deleted_at IS NULL
+		) t
+		WHERE team <> ''
+		ORDER BY team
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	venues, err := r.queryStrings(`
+		SELECT DISTINCT venue FROM videos
+		WHERE deleted_at IS NULL AND venue <> ''
+		ORDER BY venue
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	referees, err := r.queryStrings(`
+		SELECT MIN(referee) FROM videos
+		WHERE deleted_at IS NULL AND referee <> ''
+		GROUP BY COALESCE(NULLIF(referee_key, ''), referee)
+		ORDER BY 1
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ReferenceData{Competitions: competitions, Seasons: seasons, Teams: teams, Venues: venues, Referees: referees}, nil
+}
+
+// queryStrings runs a query returning a single text column
+func (r *PostgresReferenceDataRepository) queryStrings(query string) ([]string, error) {
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := []string{}
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+
+	return values, rows.Err()
+}
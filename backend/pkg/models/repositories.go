@@ -0,0 +1,142 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+)
+
+/**
+ * Repositories bundles the data access of every subsystem, so the API can
+ * be wired against PostgreSQL or, in demo mode, against memory.
+ */
+type Repositories struct {
+	Videos         VideoRepository
+	Webhooks       WebhookRepository
+	Outbox         OutboxRepository
+	MatchDays      MatchDayRepository
+	VideoFiles     VideoFileRepository
+	Snapshots      AnalyticsSnapshotRepository
+	ReferenceData  ReferenceDataRepository
+	StorageUsage   StorageUsageRepository
+	Retention      RetentionRepository
+	ArchiveJobs    ArchiveJobRepository
+	UploadSessions UploadSessionRepository
+	StateHistory   StateHistoryRepository
+	Players        PlayerRepository
+	PlayerMappings PlayerMappingRepository
+	PlayerErasures PlayerErasureRepository
+	ShareLinks     ShareLinkRepository
+	AccessGrants   AccessGrantRepository
+	Notifications  NotificationPreferenceRepository
+	Inbox          NotificationRepository
+	VideoPurges    VideoPurgeRepository
+	MatchCalendar  MatchCalendarRepository
+	MatchStats     MatchStatsRepository
+
+	// Ping reports whether the database is reachable, for readiness checks
+	Ping func(ctx context.Context) error
+}
+
+/**
+ * NewPostgresRepositories creates the PostgreSQL-backed repositories.
+ *
+ * @param db Database connection
+ * @return The repositories or error
+ */
+func NewPostgresRepositories(db *sql.DB) (*Repositories, error) {
+	videos, err := NewPostgresVideoRepository(db)
+	if err != nil {
+		return nil, err
+	}
+	calendar, err := NewPostgresMatchCalendarRepository(db)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Repositories{
+		Videos:         videos,
+		Webhooks:       NewPostgresWebhookRepository(db),
+		Outbox:         NewPostgresOutboxRepository(db),
+		MatchDays:      NewPostgresMatchDayRepository(db),
+		VideoFiles:     NewPostgresVideoFileRepository(db),
+		Snapshots:      NewPostgresAnalyticsSnapshotRepository(db),
+		ReferenceData:  NewPostgresReferenceDataRepository(db),
+		StorageUsage:   NewPostgresStorageUsageRepository(db),
+		Retention:      NewPostgresRetentionRepository(db),
+		ArchiveJobs:    NewPostgresArchiveJobRepository(db),
+		UploadSessions: NewPostgresUploadSessionRepository(db),
+		StateHistory:   NewPostgresStateHistoryRepository(db),
+		Players:        NewPostgresPlayerRepository(db),
+		PlayerMappings: NewPostgresPlayerMappingRepository(db),
+		PlayerErasures: NewPostgresPlayerErasureRepository(db),
+		ShareLinks:     NewPostgresShareLinkRepository(db),
+		AccessGrants:   NewPostgresAccessGrantRepository(db),
+		Notifications:  NewPostgresNotificationPreferenceRepository(db),
+		Inbox:          NewPostgresNotificationRepository(db),
+		VideoPurges:    NewPostgresVideoPurgeRepository(db),
+		MatchCalendar:  calendar,
+		MatchStats:     NewPostgresMatchStatsRepository(db),
+		Ping:           db.PingContext,
+	}, nil
+}
+
+/**
+ * NewSQLiteRepositories creates the repositories of a single-node
+ * deployment on a local SQLite database. Videos and the reference data
+ * derived from them are stored in SQLite; the other subsystems' data is
+ * kept in memory and lost on restart.
+ *
+ * @param db Database connection opened with the "sqlite3" driver
+ * @return The repositories or error
+ */
+func NewSQLiteRepositories(db *sql.DB) (*Repositories, error) {
+	videos, err := NewSQLiteVideoRepository(db)
+	if err != nil {
+		return nil, err
+	}
+
+	repos := NewMemoryRepositories()
+	repos.Videos = videos
+	repos.MatchCalendar = NewMemoryMatchCalendarRepository(videos)
+	repos.MatchStats = NewMemoryMatchStatsRepository(videos, repos.StateHistory)
+	// The reference data queries are plain SQL that SQLite runs unchanged
+	repos.ReferenceData = NewPostgresReferenceDataRepository(db)
+	repos.Ping = db.PingContext
+	return repos, nil
+}
+
+/**
+ * NewMemoryRepositories creates empty in-memory repositories. Nothing is
+ * persisted, and the database is always reachable.
+ *
+ * @return The repositories
+ */
+func NewMemoryRepositories() *Repositories {
+	videos := NewMemoryVideoRepository()
+	history := NewMemoryStateHistoryRepository()
+	return &Repositories{
+		Videos:         videos,
+		Webhooks:       NewMemoryWebhookRepository(),
+		Outbox:         NewMemoryOutboxRepository(),
+		MatchDays:      NewMemoryMatchDayRepository(),
+		VideoFiles:     NewMemoryVideoFileRepository(),
+		Snapshots:      NewMemoryAnalyticsSnapshotRepository(),
+		ReferenceData:  NewMemoryReferenceDataRepository(videos),
+		StorageUsage:   NewMemoryStorageUsageRepository(),
+		Retention:      NewMemoryRetentionRepository(),
+		ArchiveJobs:    NewMemoryArchiveJobRepository(),
+		UploadSessions: NewMemoryUploadSessionRepository(),
+		StateHistory:   history,
+		Players:        NewMemoryPlayerRepository(),
+		PlayerMappings: NewMemoryPlayerMappingRepository(),
+		PlayerErasures: NewMemoryPlayerErasureRepository(),
+		ShareLinks:     NewMemoryShareLinkRepository(),
+		AccessGrants:   NewMemoryAccessGrantRepository(),
+		Notifications:  NewMemoryNotificationPreferenceRepository(),
+		Inbox:          NewMemoryNotificationRepository(),
+		VideoPurges:    NewMemoryVideoPurgeRepository(),
+		MatchCalendar:  NewMemoryMatchCalendarRepository(videos),
+		MatchStats:     NewMemoryMatchStatsRepository(videos, history),
+		Ping:           func(ctx context.Context) error { return nil },
+	}
+}
@@ -0,0 +1,148 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+/**
+ * RetentionOverride replaces the default retention rule for one kind of
+ * file ("video", "tracking", "events") of a single match, e.g. to keep the
+ * raw video of a cup final forever.
+ */
+type RetentionOverride struct {
+	VideoID   string    `json:"video_id"`
+	Kind      string    `json:"kind"`
+	Action    string    `json:"action"` // "keep", "archive" or "delete"
+	Days      int       `json:"days"`   // Age in days after which the action applies
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+/**
+ * RetentionActionRecord logs a file archived or deleted by retention.
+ */
+type RetentionActionRecord struct {
+	VideoID     string    `json:"video_id"`
+	Kind        string    `json:"kind"`
+	Action      string    `json:"action"`
+	Path        string    `json:"path"`
+	ArchivePath string    `json:"archive_path,omitempty"`
+	PerformedAt time.Time `json:"performed_at"`
+}
+
+/**
+ * RetentionRepository defines data access for retention overrides and the
+ * log of retention actions.
+ */
+type RetentionRepository interface {
+	ReplaceOverrides(videoID string, overrides []*RetentionOverride) error
+	FindOverrides(videoID string) ([]*RetentionOverride, error)
+	RecordAction(record *RetentionActionRecord) error
+	FindActions(videoID string) ([]*RetentionActionRecord, error)
+}
+
+/**
+ * PostgresRetentionRepository implements RetentionRepository using PostgreSQL.
+ */
+type PostgresRetentionRepository struct {
+	db *sql.DB
+}
+
+/**
+ * NewPostgresRetentionRepository creates a new PostgreSQL-backed retention repository.
+ *
+ * @param db Database connection
+ * @return A new retention repository
+ */
+func NewPostgresRetentionRepository(db *sql.DB) RetentionRepository {
+	return &PostgresRetentionRepository{db: db}
+}
+
+// ReplaceOverrides atomically replaces all overrides of a match
+func (r *PostgresRetentionRepository) ReplaceOverrides(videoID string, overrides []*RetentionOverride) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM retention_overrides WHERE video_id = $1`, videoID); err != nil {
+		return err
+	}
+	for _, o := range overrides {
+		_, err := tx.Exec(`
+			INSERT INTO retention_overrides (video_id, kind, action, days, updated_at)
+			VALUES ($1, $2, $3, $4, $5)
+		`, videoID, o.Kind, o.Action, o.Days, o.UpdatedAt)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// FindOverrides retrieves the overrides of a match
+func (r *PostgresRetentionRepository) FindOverrides(videoID string) ([]*RetentionOverride, error) {
+	query := `
+		SELECT video_id, kind, action, days, updated_at
+		FROM retention_overrides
+		WHERE video_id = $1
+		ORDER BY kind
+	`
+
+	rows, err := r.db.Query(query, videoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var overrides []*RetentionOverride
+	for rows.Next() {
+		var o RetentionOverride
+		if err := rows.Scan(&o.VideoID, &o.Kind, &o.Action, &o.Days, &o.UpdatedAt); err != nil {
+			return nil, err
+		}
+		overrides = append(overrides, &o)
+	}
+	return overrides, rows.Err()
+}
+
+// RecordAction appends an entry to the retention action log
+func (r *PostgresRetentionRepository) RecordAction(record *RetentionActionRecord) error {
+	query := `
+		INSERT INTO retention_actions (video_id, kind, action, path, archive_path, performed_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	_, err := r.db.Exec(query,
+		record.VideoID, record.Kind, record.Action, record.Path, record.ArchivePath, record.PerformedAt,
+	)
+	return err
+}
+
+// FindActions retrieves the retention actions taken on a match, oldest first
+func (r *PostgresRetentionRepository) FindActions(videoID string) ([]*RetentionActionRecord, error) {
+	query := `
+		SELECT video_id, kind, action, path, archive_path, performed_at
+		FROM retention_actions
+		WHERE video_id = $1
+		ORDER BY performed_at
+	`
+
+	rows, err := r.db.Query(query, videoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []*RetentionActionRecord
+	for rows.Next() {
+		var record RetentionActionRecord
+		if err := rows.Scan(
+			&record.VideoID, &record.Kind, &record.Action, &record.Path, &record.ArchivePath, &record.PerformedAt,
+		); err != nil {
+			return nil, err
+		}
+		records = append(records, &record)
+	}
+	return records, rows.Err()
+}
@@ -0,0 +1,199 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// ErrShareLinkNotFound is returned when a share link ID is unknown.
+var ErrShareLinkNotFound = errors.New("share link not found")
+
+// Scopes of a share link
+const (
+	ShareScopeFull      = "full"      // The match, its analytics and its video
+	ShareScopeAnalytics = "analytics" // The match and its analytics, read-only
+	ShareScopeClip      = "clip"      // The match and one window of its video
+)
+
+/**
+ * ShareLink grants people without an account access to one match until it
+ * expires or is revoked. The link itself is a signed token naming it, so
+ * revoking the link stops every copy of the token.
+ */
+type ShareLink struct {
+	ID          string     `json:"id"`
+	VideoID     string     `json:"video_id"`
+	Scope       string     `json:"scope"`                   // One of the ShareScope constants
+	ClipStartMS int64      `json:"clip_start_ms,omitempty"` // Window of the video a clip link shows
+	ClipEndMS   int64      `json:"clip_end_ms,omitempty"`
+	Label       string     `json:"label,omitempty"` // Who the link was shared with, e.g. "PSV scouting"
+	CreatedBy   string     `json:"created_by"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+}
+
+/**
+ * ShareAccess records one use of a share link, allowed or not.
+ */
+type ShareAccess struct {
+	ID         int64     `json:"id"`
+	ShareID    string    `json:"share_id"`
+	Resource   string    `json:"resource"` // "match", "analytics" or "video"
+	Allowed    bool      `json:"allowed"`
+	Reason     string    `json:"reason,omitempty"` // Why access was refused
+	RemoteAddr string    `json:"remote_addr"`
+	UserAgent  string    `json:"user_agent,omitempty"`
+	At         time.Time `json:"at"`
+}
+
+/**
+ * ShareLinkRepository defines data access for share links and their access log.
+ */
+type ShareLinkRepository interface {
+	Create(link *ShareLink) error
+	FindByID(id string) (*ShareLink, error)
+	// FindByVideoID lists the links of a match, newest first
+	FindByVideoID(videoID string) ([]*ShareLink, error)
+	// Revoke marks a link revoked; revoking it again keeps the first time
+	Revoke(id string, at time.Time) error
+	// RecordAccess appends an access and sets its ID
+	RecordAccess(access *ShareAccess) error
+	// FindAccesses lists the accesses of a link, newest first
+	FindAccesses(shareID string, limit, offset int) ([]*ShareAccess, error)
+}
+
+/**
+ * PostgresShareLinkRepository implements ShareLinkRepository using PostgreSQL.
+ */
+type PostgresShareLinkRepository struct {
+	db *sql.DB
+}
+
+/**
+ * NewPostgresShareLinkRepository creates a new PostgreSQL-backed share link repository.
+ *
+ * @param db Database connection
+ * @return A new share link repository
+ */
+func NewPostgresShareLinkRepository(db *sql.DB) ShareLinkRepository {
+	return &PostgresShareLinkRepository{db: db}
+}
+
+// Create inserts a new share link
+func (r *PostgresShareLinkRepository) Create(link *ShareLink) error {
+	query := `
+		INSERT INTO share_links (id, video_id, scope, clip_start_ms, clip_end_ms, label, created_by, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+	_, err := r.db.Exec(query,
+		link.ID, link.VideoID, link.Scope, link.ClipStartMS, link.ClipEndMS, link.Label,
+		link.CreatedBy, link.CreatedAt, link.ExpiresAt,
+	)
+	return err
+}
+
+// FindByID retrieves a share link
+func (r *PostgresShareLinkRepository) FindByID(id string) (*ShareLink, error) {
+	links, err := r.queryLinks(`
+		SELECT id, video_id, scope, clip_start_ms, clip_end_ms, label, created_by, created_at, expires_at, revoked_at
+		FROM share_links
+		WHERE id = $1
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(links) == 0 {
+		return nil, ErrShareLinkNotFound
+	}
+	return links[0], nil
+}
+
+// FindByVideoID retrieves the links of a match, newest first
+func (r *PostgresShareLinkRepository) FindByVideoID(videoID string) ([]*ShareLink, error) {
+	return r.queryLinks(`
+		SELECT id, video_id, scope, clip_start_ms, clip_end_ms, label, created_by, created_at, expires_at, revoked_at
+		FROM share_links
+		WHERE video_id = $1
+		ORDER BY created_at DESC, id
+	`, videoID)
+}
+
+// Revoke marks a link revoked, keeping an earlier revocation time
+func (r *PostgresShareLinkRepository) Revoke(id string, at time.Time) error {
+	result, err := r.db.Exec(`UPDATE share_links SET revoked_at = COALESCE(revoked_at, $2) WHERE id = $1`, id, at)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrShareLinkNotFound
+	}
+	return nil
+}
+
+// RecordAccess appends an access and sets its ID
+func (r *PostgresShareLinkRepository) RecordAccess(access *ShareAccess) error {
+	query := `
+		INSERT INTO share_accesses (share_id, resource, allowed, reason, remote_addr, user_agent, at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id
+	`
+	return r.db.QueryRow(query,
+		access.ShareID, access.Resource, access.Allowed, access.Reason, access.RemoteAddr, access.UserAgent, access.At,
+	).Scan(&access.ID)
+}
+
+// FindAccesses retrieves the accesses of a link, newest first
+func (r *PostgresShareLinkRepository) FindAccesses(shareID string, limit, offset int) ([]*ShareAccess, error) {
+	query := `
+		SELECT id, share_id, resource, allowed, reason, remote_addr, user_agent, at
+		FROM share_accesses
+		WHERE share_id = $1
+		ORDER BY at DESC, id DESC
+		LIMIT $2 OFFSET $3
+	`
+	rows, err := r.db.Query(query, shareID, defaultLimit(limit, 100), max(offset, 0))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	accesses := []*ShareAccess{}
+	for rows.Next() {
+		var a ShareAccess
+		if err := rows.Scan(&a.ID, &a.ShareID, &a.Resource, &a.Allowed, &a.Reason, &a.RemoteAddr, &a.UserAgent, &a.At); err != nil {
+			return nil, err
+		}
+		accesses = append(accesses, &a)
+	}
+	return accesses, rows.Err()
+}
+
+// queryLinks runs a query selecting share link rows
+func (r *PostgresShareLinkRepository) queryLinks(query string, args ...interface{}) ([]*ShareLink, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	links := []*ShareLink{}
+	for rows.Next() {
+		var l ShareLink
+		var revokedAt sql.NullTime
+		if err := rows.Scan(&l.ID, &l.VideoID, &l.Scope, &l.ClipStartMS, &l.ClipEndMS, &l.Label,
+			&l.CreatedBy, &l.CreatedAt, &l.ExpiresAt, &revokedAt); err != nil {
+			return nil, err
+		}
+		if revokedAt.Valid {
+			l.RevokedAt = &revokedAt.Time
+		}
+		links = append(links, &l)
+	}
+	return links, rows.Err()
+}
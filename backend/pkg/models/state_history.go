@@ -0,0 +1,82 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+/**
+ * StateTransition records one change of a match's processing state: who
+ * made it, when and why. From is empty for the state a match is created in.
+ */
+type StateTransition struct {
+	ID        int64           `json:"id"`
+	VideoID   string          `json:"video_id"`
+	From      ProcessingState `json:"from"`
+	To        ProcessingState `json:"to"`
+	Actor     string          `json:"actor"` // User ID, or "system:<component>" for automatic changes
+	Reason    string          `json:"reason,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+/**
+ * StateHistoryRepository defines data access for processing state history.
+ */
+type StateHistoryRepository interface {
+	Create(transition *StateTransition) error
+	FindByVideoID(videoID string) ([]*StateTransition, error)
+}
+
+/**
+ * PostgresStateHistoryRepository implements StateHistoryRepository using PostgreSQL.
+ */
+type PostgresStateHistoryRepository struct {
+	db *sql.DB
+}
+
+/**
+ * NewPostgresStateHistoryRepository creates a new PostgreSQL-backed state history repository.
+ *
+ * @param db Database connection
+ * @return A new state history repository
+ */
+func NewPostgresStateHistoryRepository(db *sql.DB) StateHistoryRepository {
+	return &PostgresStateHistoryRepository{db: db}
+}
+
+// Create appends a transition and sets its ID
+func (r *PostgresStateHistoryRepository) Create(transition *StateTransition) error {
+	query := `
+		INSERT INTO state_history (video_id, from_state, to_state, actor, reason, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`
+	return r.db.QueryRow(query,
+		transition.VideoID, transition.From, transition.To, transition.Actor, transition.Reason, transition.CreatedAt,
+	).Scan(&transition.ID)
+}
+
+// FindByVideoID retrieves the transitions of a match, oldest first
+func (r *PostgresStateHistoryRepository) FindByVideoID(videoID string) ([]*StateTransition, error) {
+	query := `
+		SELECT id, video_id, from_state, to_state, actor, reason, created_at
+		FROM state_history
+		WHERE video_id = $1
+		ORDER BY created_at, id
+	`
+	rows, err := r.db.Query(query, videoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var history []*StateTransition
+	for rows.Next() {
+		var t StateTransition
+		if err := rows.Scan(&t.ID, &t.VideoID, &t.From, &t.To, &t.Actor, &t.Reason, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+		history = append(history, &t)
+	}
+	return history, rows.Err()
+}
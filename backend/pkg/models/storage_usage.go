@@ -0,0 +1,186 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// Storage usage scopes
+const (
+	UsageScopeOrganization = "organization"
+	UsageScopeUser         = "user"
+)
+
+// ErrStorageChargeNotFound is returned when a match was never charged, for
+// example because it was uploaded before usage accounting existed.
+var ErrStorageChargeNotFound = errors.New("storage charge not found")
+
+/**
+ * StorageUsage is the storage used by an organization or a user.
+ */
+type StorageUsage struct {
+	Scope     string    `json:"scope"` // "organization" or "user"
+	Owner     string    `json:"owner"`
+	Bytes     int64     `json:"bytes"`
+	Files     int       `json:"files"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+/**
+ * StorageCharge records the storage an upload added to its organization's
+ * and uploader's usage.
+ */
+type StorageCharge struct {
+	VideoID      string    `json:"video_id"`
+	Organization string    `json:"organization"`
+	UserID       string    `json:"user_id"`
+	Bytes        int64     `json:"bytes"`
+	Files        int       `json:"files"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+/**
+ * StorageUsageRepository defines data access for storage usage accounting.
+ */
+type StorageUsageRepository interface {
+	// FindUsage returns the usage of an owner; owners without uploads have zero usage
+	FindUsage(scope, owner string) (*StorageUsage, error)
+	// Charge adds an upload to its owners' usage; charging a match twice is a no-op
+	Charge(charge *StorageCharge) error
+	// Release removes a match's charge from its owners' usage and returns it
+	Release(videoID string) (*StorageCharge, error)
+	// ChargedVideoIDs lists the matches with a charge
+	ChargedVideoIDs() ([]string, error)
+}
+
+/**
+ * PostgresStorageUsageRepository implements StorageUsageRepository using PostgreSQL.
+ */
+type PostgresStorageUsageRepository struct {
+	db *sql.DB
+}
+
+/**
+ * NewPostgresStorageUsageRepository creates a new PostgreSQL-backed storage usage repository.
+ *
+ * @param db Database connection
+ * @return A new storage usage repository
+ */
+func NewPostgresStorageUsageRepository(db *sql.DB) StorageUsageRepository {
+	return &PostgresStorageUsageRepository{db: db}
+}
+
+// FindUsage retrieves the usage of an owner
+func (r *PostgresStorageUsageRepository) FindUsage(scope, owner string) (*StorageUsage, error) {
+	query := `
+		SELECT scope, owner, bytes, files, updated_at
+		FROM storage_usage
+		WHERE scope = $1 AND owner = $2
+	`
+
+	usage := StorageUsage{Scope: scope, Owner: owner}
+	err := r.db.QueryRow(query, scope, owner).Scan(
+		&usage.Scope, &usage.Owner, &usage.Bytes, &usage.Files, &usage.UpdatedAt,
+	)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	return &usage, nil
+}
+
+// Charge records the charge and adds it to the organization's and user's usage in one transaction
+func (r *PostgresStorageUsageRepository) Charge(charge *StorageCharge) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		INSERT INTO storage_charges (video_id, organization, user_id, bytes, files, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (video_id) DO NOTHING
+	`, charge.VideoID, charge.Organization, charge.UserID, charge.Bytes, charge.Files, charge.CreatedAt)
+	if err != nil {
+		return err
+	}
+	if charged, err := result.RowsAffected(); err != nil || charged == 0 {
+		return err
+	}
+
+	if err := adjustUsage(tx, charge, 1); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Release deletes the charge of a match and subtracts it from its owners' usage in one transaction
+func (r *PostgresStorageUsageRepository) Release(videoID string) (*StorageCharge, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var charge StorageCharge
+	err = tx.QueryRow(`
+		DELETE FROM storage_charges
+		WHERE video_id = $1
+		RETURNING video_id, organization, user_id, bytes, files, created_at
+	`, videoID).Scan(
+		&charge.VideoID, &charge.Organization, &charge.UserID, &charge.Bytes, &charge.Files, &charge.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrStorageChargeNotFound
+		}
+		return nil, err
+	}
+
+	if err := adjustUsage(tx, &charge, -1); err != nil {
+		return nil, err
+	}
+	return &charge, tx.Commit()
+}
+
+// ChargedVideoIDs lists the matches with a charge, oldest first
+func (r *PostgresStorageUsageRepository) ChargedVideoIDs() ([]string, error) {
+	rows, err := r.db.Query(`SELECT video_id FROM storage_charges ORDER BY created_at, video_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// adjustUsage adds (sign 1) or subtracts (sign -1) a charge from the usage
+// of its organization and user, never going below zero
+func adjustUsage(tx *sql.Tx, charge *StorageCharge, sign int64) error {
+	query := `
+		INSERT INTO storage_usage (scope, owner, bytes, files, updated_at)
+		VALUES ($1, $2, GREATEST($3::BIGINT, 0), GREATEST($4::INTEGER, 0), NOW())
+		ON CONFLICT (scope, owner) DO UPDATE
+		SET bytes = GREATEST(storage_usage.bytes + $3, 0),
+		    files = GREATEST(storage_usage.files + $4, 0),
+		    updated_at = NOW()
+	`
+	for scope, owner := range map[string]string{
+		UsageScopeOrganization: charge.Organization,
+		UsageScopeUser:         charge.UserID,
+	} {
+		if _, err := tx.Exec(query, scope, owner, sign*charge.Bytes, sign*int64(charge.Files)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrUploadSessionNotFound is returned when an upload session ID is unknown.
+	ErrUploadSessionNotFound = errors.New("upload session not found")
+	// ErrUploadSessionFinalized is returned when finalizing a session twice.
+	ErrUploadSessionFinalized = errors.New("upload session already finalized")
+)
+
+/**
+ * UploadMetadata describes the match a direct upload creates.
+ */
+type UploadMetadata struct {
+	Title       string     `json:"title"`
+	Description string     `json:"description,omitempty"`
+	MatchID     string     `json:"match_id,omitempty"`
+	HomeTeam    string     `json:"home_team,omitempty"`
+	AwayTeam    string     `json:"away_team,omitempty"`
+	Competition string     `json:"competition,omitempty"`
+	Season      string     `json:"season,omitempty"`
+	MatchDate   string     `json:"match_date,omitempty"` // YYYY-MM-DD
+	Venue       string     `json:"venue,omitempty"`
+	Referee     string     `json:"referee,omitempty"`
+	Attendance  int        `json:"attendance,omitempty"`
+	Weather     string     `json:"weather,omitempty"`
+	KickoffAt   *time.Time `json:"kickoff_at,omitempty"`
+	// IANA zone of the venue the kickoff time is shown in, e.g. "Europe/Amsterdam"
+	KickoffTimezone string `json:"kickoff_timezone,omitempty"`
+	Priority        string `json:"priority,omitempty"` // Processing priority: "low", "normal" or "high"
+}
+
+/**
+ * UploadSessionFile is a file a client was given an upload URL for.
+ */
+type UploadSessionFile struct {
+	Kind     string `json:"kind"` // "video", "tracking" or "events"
+	Filename string `json:"filename"`
+	Path     string `json:"path"`
+	Size     int64  `json:"size"` // Declared by the client, verified on finalize
+}
+
+/**
+ * UploadSession tracks a direct-to-storage upload from presigning its URLs
+ * until the client finalizes it.
+ */
+type UploadSession struct {
+	ID           string              `json:"id"`
+	VideoID      string              `json:"video_id"`
+	Organization string              `json:"organization"`
+	UserID       string              `json:"user_id"`
+	Metadata     UploadMetadata      `json:"metadata"`
+	Files        []UploadSessionFile `json:"files"`
+	ExpiresAt    time.Time           `json:"expires_at"`
+	CreatedAt    time.Time           `json:"created_at"`
+	FinalizedAt  sql.NullTime        `json:"finalized_at"`
+}
+
+/**
+ * UploadSessionRepository defines data access for direct upload sessions.
+ */
+type UploadSessionRepository interface {
+	Create(session *UploadSession) error
+	FindByID(id string) (*UploadSession, error)
+	// MarkFinalized claims a session for finalizing; it fails with
+	// ErrUploadSessionFinalized if the session was already claimed
+	MarkFinalized(id string, at time.Time) error
+}
+
+/**
+ * PostgresUploadSessionRepository implements UploadSessionRepository using PostgreSQL.
+ */
+type PostgresUploadSessionRepository struct {
+	db *sql.DB
+}
+
+/**
+ * NewPostgresUploadSessionRepository creates a new PostgreSQL-backed upload session repository.
+ *
+ * @param db Database connection
+ * @return A new upload session repository
+ */
+func NewPostgresUploadSessionRepository(db *sql.DB) UploadSessionRepository {
+	return &PostgresUploadSessionRepository{db: db}
+}
+
+// Create inserts a new session
+func (r *PostgresUploadSessionRepository) Create(session *UploadSession) error {
+	metadata, err := json.Marshal(session.Metadata)
+	if err != nil {
+		return err
+	}
+	files, err := json.Marshal(session.Files)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO upload_sessions (id, video_id, organization, user_id, metadata, files, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	_, err = r.db.Exec(query,
+		session.ID, session.VideoID, session.Organization, session.UserID, metadata, files, session.ExpiresAt, session.CreatedAt,
+	)
+	return err
+}
+
+// FindByID retrieves a session
+func (r *PostgresUploadSessionRepository) FindByID(id string) (*UploadSession, error) {
+	query := `
+		SELECT id, video_id, organization, user_id, metadata, files, expires_at, created_at, finalized_at
+		FROM upload_sessions
+		WHERE id = $1
+	`
+
+	var session UploadSession
+	var metadata, files []byte
+	err := r.db.QueryRow(query, id).Scan(
+		&session.ID, &session.VideoID, &session.Organization, &session.UserID, &metadata, &files,
+		&session.ExpiresAt, &session.CreatedAt, &session.FinalizedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrUploadSessionNotFound
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(metadata, &session.Metadata); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(files, &session.Files); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// MarkFinalized sets the finalized time unless it is already set
+func (r *PostgresUploadSessionRepository) MarkFinalized(id string, at time.Time) error {
+	query := `
+		UPDATE upload_sessions
+		SET finalized_at = $2
+		WHERE id = $1 AND finalized_at IS NULL
+	`
+	result, err := r.db.Exec(query, id, at)
+	if err != nil {
+		return err
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return ErrUploadSessionFinalized
+	}
+	return nil
+}
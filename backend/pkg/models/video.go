@@ -1,9 +1,17 @@
 package models
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
 )
 
 /**
@@ -12,19 +20,19 @@ import (
  * associated metadata, and processing status.
  */
 type Video struct {
-	ID              string       `json:"id"`
-	Title           string       `json:"title"`
-	Description     string       `json:"description"`
-	FilePath        string       `json:"file_path"`
-	StorageProvider string       `json:"storage_provider"` // "azure_blob", "local", etc.
-	Duration        float64      `json:"duration"`         // Duration in seconds
-	Resolution      string       `json:"resolution"`       // e.g., "1920x1080"
-	Format          string       `json:"format"`           // e.g., "mp4", "mov"
-	Size            int64        `json:"size"`             // Size in bytes
-	ProcessingState string       `json:"processing_state"` // "pending", "processing", "completed", "failed"
-	CreatedAt       time.Time    `json:"created_at"`
-	UpdatedAt       time.Time    `json:"updated_at"`
-	DeletedAt       sql.NullTime `json:"deleted_at,omitempty"`
+	ID              string          `json:"id"`
+	Title           string          `json:"title"`
+	Description     string          `json:"description"`
+	FilePath        string          `json:"file_path"`
+	StorageProvider string          `json:"storage_provider"` // "azure_blob", "local", etc.
+	Duration        float64         `json:"duration"`         // Duration in seconds
+	Resolution      string          `json:"resolution"`       // e.g., "1920x1080"
+	Format          string          `json:"format"`           // e.g., "mp4", "mov"
+	Size            int64           `json:"size"`             // Size in bytes
+	ProcessingState ProcessingState `json:"processing_state"`
+	CreatedAt       time.Time       `json:"created_at"`
+	UpdatedAt       time.Time       `json:"updated_at"`
+	DeletedAt       sql.NullTime    `json:"deleted_at,omitempty"`
 
 	// Metadata related to the match/event
 	MatchID     string    `json:"match_id,omitempty"`
@@ -34,12 +42,59 @@ type Video struct {
 	Competition string    `json:"competition,omitempty"`
 	Season      string    `json:"season,omitempty"`
 
+	// Context of the match for analysts' reports
+	Venue           string     `json:"venue,omitempty"`
+	Referee         string     `json:"referee,omitempty"`
+	RefereeKey      string     `json:"-"` // Blind index of the referee, set when referees are stored encrypted
+	Attendance      int        `json:"attendance,omitempty"`
+	Weather         string     `json:"weather,omitempty"`          // Free text, e.g. "Rain, 12°C"
+	KickoffAt       *time.Time `json:"kickoff_at,omitempty"`       // In KickoffTimezone when set
+	KickoffTimezone string     `json:"kickoff_timezone,omitempty"` // IANA zone of the venue, e.g. "Europe/Amsterdam"
+
 	// Tracking data information
 	// HasTrackingData bool       `json:"has_tracking_data"` // Field removed, infer from TrackingPath
 	TrackingPath  string `json:"tracking_path,omitempty"`
 	EventFilePath string `json:"event_file_path,omitempty"`
 }
 
+// Fields videos can be sorted by
+const (
+	VideoSortCreatedAt = "created_at"
+	VideoSortMatchDate = "match_date"
+	VideoSortTitle     = "title"
+)
+
+// Sort orders
+const (
+	SortAscending  = "asc"
+	SortDescending = "desc"
+)
+
+/**
+ * VideoQuery selects videos by any combination of filters; empty fields do
+ * not filter. Videos are sorted by Sort (created_at by default) in Order
+ * (descending by default).
+ */
+type VideoQuery struct {
+	MatchID          string
+	Team             string // Home or away team
+	Competition      string
+	Season           string
+	Venue            string
+	Referee          string
+	RefereeKey       string // Blind index of Referee: matches referees stored encrypted, and Referee those stored in plain text
+	ProcessingState  string
+	ProcessingStates []string     // Any of these states; combined with ProcessingState
+	MatchDateFrom    time.Time    // Inclusive; zero means unbounded
+	MatchDateTo      time.Time    // Inclusive; zero means unbounded
+	Access           *AccessScope // Only the matches the caller was granted; nil means all
+
+	Sort   string // "created_at", "match_date" or "title"
+	Order  string // "asc" or "desc"
+	Limit  int
+	Offset int
+}
+
 /**
  * VideoRepository defines the interface for video data access operations.
  * Follows the repository pattern to abstract database operations.
@@ -49,22 +104,33 @@ type VideoRepository interface {
 	FindByID(id string) (*Video, error)
 	FindAll(limit, offset int) ([]*Video, error)
 	Create(video *Video) error
+	// Update saves a video, except its processing state
 	Update(video *Video) error
+	// UpdateProcessingState changes only the processing state, and only
+	// while it is still from; otherwise it returns ErrStateConflict
+	UpdateProcessingState(id string, from, to ProcessingState, updatedAt time.Time) error
 	Delete(id string) error
+	// Undelete reverts a soft delete
+	Undelete(id string) error
 
 	// Additional query methods
 	FindByMatchID(matchID string) ([]*Video, error)
 	FindByTeam(teamName string, limit, offset int) ([]*Video, error)
 	FindByDateRange(start, end time.Time, limit, offset int) ([]*Video, error)
 	FindByProcessingState(state string, limit, offset int) ([]*Video, error)
+	FindByQuery(query VideoQuery) ([]*Video, error)
+
+	// WithContext returns a repository whose queries are cancelled with ctx
+	WithContext(ctx context.Context) VideoRepository
 }
 
 /**
  * PostgresVideoRepository implements VideoRepository using PostgreSQL.
- * Handles database operations for video data.
+ * Queries are built by GORM from videoRecord, so every column is read and
+ * written through one field list instead of a Scan list per query.
  */
 type PostgresVideoRepository struct {
-	db *sql.DB
+	db *gorm.DB
 }
 
 /**
@@ -72,10 +138,187 @@ type PostgresVideoRepository struct {
  * Initializes the repository with a database connection.
  *
  * @param db Database connection
- * @return A new video repository
+ * @return A new video repository or error
  */
-func NewPostgresVideoRepository(db *sql.DB) VideoRepository {
-	return &PostgresVideoRepository{db: db}
+func NewPostgresVideoRepository(db *sql.DB) (VideoRepository, error) {
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db}), gormConfig())
+	if err != nil {
+		return nil, err
+	}
+	return &PostgresVideoRepository{db: gormDB}, nil
+}
+
+// gormConfig is the GORM configuration of the video repositories
+func gormConfig() *gorm.Config {
+	return &gorm.Config{
+		Logger:               logger.Default.LogMode(logger.Silent),
+		DisableAutomaticPing: true,
+		// Each write is a single statement; no wrapping transaction needed
+		SkipDefaultTransaction: true,
+		// Times are written in UTC, so SQLite's text timestamps sort in time order
+		NowFunc: func() time.Time { return time.Now().UTC() },
+	}
+}
+
+/**
+ * videoRecord is the row layout of the videos table. Every column but the
+ * key is nullable here, so rows written before the NOT NULL defaults (or by
+ * other tools) scan without errors; NULL reads as the zero value.
+ */
+type videoRecord struct {
+	ID              string `gorm:"primaryKey"`
+	Title           sql.NullString
+	Description     sql.NullString
+	FilePath        sql.NullString
+	StorageProvider sql.NullString
+	Duration        sql.NullFloat64
+	Resolution      sql.NullString
+	Format          sql.NullString
+	Size            sql.NullInt64
+	ProcessingState sql.NullString
+	CreatedAt       sql.NullTime `gorm:"autoCreateTime:false"`
+	UpdatedAt       sql.NullTime `gorm:"autoUpdateTime:false"`
+	DeletedAt       gorm.DeletedAt
+	MatchID         sql.NullString
+	MatchDate       sql.NullTime
+	HomeTeam        sql.NullString
+	AwayTeam        sql.NullString
+	Competition     sql.NullString
+	Season          sql.NullString
+	TrackingPath    sql.NullString
+	EventFilePath   sql.NullString
+	Venue           sql.NullString
+	Referee         sql.NullString
+	RefereeKey      sql.NullString
+	Attendance      sql.NullInt64
+	Weather         sql.NullString
+	KickoffAt       sql.NullTime
+	KickoffTimezone sql.NullString
+}
+
+// TableName maps videoRecord to the videos table
+func (videoRecord) TableName() string {
+	return "videos"
+}
+
+// updatableVideoColumns are the columns Update writes. The processing state
+// is left out: only UpdateProcessingState changes it, so a stale copy of a
+// video cannot undo a concurrent state change.
+var updatableVideoColumns = []string{
+	"title", "description", "file_path", "storage_provider",
+	"duration", "resolution", "format", "size",
+	"updated_at", "match_id", "match_date", "home_team", "away_team",
+	"competition", "season", "tracking_path", "event_file_path",
+	"venue", "referee", "referee_key", "attendance", "weather", "kickoff_at", "kickoff_timezone",
+}
+
+// newVideoRecord converts a video into its row, with times in UTC
+func newVideoRecord(video *Video) *videoRecord {
+	record := &videoRecord{
+		ID:              video.ID,
+		Title:           nullString(video.Title),
+		Description:     nullString(video.Description),
+		FilePath:        nullString(video.FilePath),
+		StorageProvider: nullString(video.StorageProvider),
+		Duration:        sql.NullFloat64{Float64: video.Duration, Valid: true},
+		Resolution:      nullString(video.Resolution),
+		Format:          nullString(video.Format),
+		Size:            sql.NullInt64{Int64: video.Size, Valid: true},
+		ProcessingState: nullString(string(video.ProcessingState)),
+		CreatedAt:       sql.NullTime{Time: video.CreatedAt.UTC(), Valid: true},
+		UpdatedAt:       sql.NullTime{Time: video.UpdatedAt.UTC(), Valid: true},
+		DeletedAt:       gorm.DeletedAt{Time: video.DeletedAt.Time.UTC(), Valid: video.DeletedAt.Valid},
+		MatchID:         nullString(video.MatchID),
+		MatchDate:       sql.NullTime{Time: video.MatchDate.UTC(), Valid: true},
+		HomeTeam:        nullString(video.HomeTeam),
+		AwayTeam:        nullString(video.AwayTeam),
+		Competition:     nullString(video.Competition),
+		Season:          nullString(video.Season),
+		TrackingPath:    nullString(video.TrackingPath),
+		EventFilePath:   nullString(video.EventFilePath),
+		Venue:           nullString(video.Venue),
+		Referee:         nullString(video.Referee),
+		RefereeKey:      nullString(video.RefereeKey),
+		Attendance:      sql.NullInt64{Int64: int64(video.Attendance), Valid: true},
+		Weather:         nullString(video.Weather),
+		KickoffTimezone: nullString(video.KickoffTimezone),
+	}
+	if video.KickoffAt != nil {
+		record.KickoffAt = sql.NullTime{Time: video.KickoffAt.UTC(), Valid: true}
+	}
+	return record
+}
+
+// video converts a row into a video, reading NULL as the zero value
+func (r *videoRecord) video() *Video {
+	video := &Video{
+		ID:              r.ID,
+		Title:           r.Title.String,
+		Description:     r.Description.String,
+		FilePath:        r.FilePath.String,
+		StorageProvider: r.StorageProvider.String,
+		Duration:        r.Duration.Float64,
+		Resolution:      r.Resolution.String,
+		Format:          r.Format.String,
+		Size:            r.Size.Int64,
+		ProcessingState: ProcessingState(r.ProcessingState.String),
+		CreatedAt:       r.CreatedAt.Time,
+		UpdatedAt:       r.UpdatedAt.Time,
+		DeletedAt:       sql.NullTime(r.DeletedAt),
+		MatchID:         r.MatchID.String,
+		MatchDate:       r.MatchDate.Time,
+		HomeTeam:        r.HomeTeam.String,
+		AwayTeam:        r.AwayTeam.String,
+		Competition:     r.Competition.String,
+		Season:          r.Season.String,
+		TrackingPath:    r.TrackingPath.String,
+		EventFilePath:   r.EventFilePath.String,
+		Venue:           r.Venue.String,
+		Referee:         r.Referee.String,
+		RefereeKey:      r.RefereeKey.String,
+		Attendance:      int(r.Attendance.Int64),
+		Weather:         r.Weather.String,
+		KickoffTimezone: r.KickoffTimezone.String,
+	}
+	if r.KickoffAt.Valid {
+		kickoffAt := LocalKickoff(r.KickoffAt.Time, video.KickoffTimezone)
+		video.KickoffAt = &kickoffAt
+	}
+	return video
+}
+
+/**
+ * LocalKickoff returns a kickoff time in the time zone of the venue, or
+ * unchanged when the zone is empty or unknown.
+ *
+ * @param kickoffAt The kickoff time
+ * @param timezone IANA name of the venue's time zone
+ * @return The kickoff time in the venue's zone
+ */
+func LocalKickoff(kickoffAt time.Time, timezone string) time.Time {
+	if timezone == "" {
+		return kickoffAt
+	}
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return kickoffAt
+	}
+	return kickoffAt.In(location)
+}
+
+// nullString stores strings as non-NULL, matching the column defaults
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: true}
+}
+
+/**
+ * WithContext returns a repository whose queries are cancelled with ctx.
+ *
+ * @param ctx The context for the queries
+ * @return The context-bound repository
+ */
+func (r *PostgresVideoRepository) WithContext(ctx context.Context) VideoRepository {
+	return &PostgresVideoRepository{db: r.db.WithContext(ctx)}
 }
 
 /**
@@ -89,35 +332,14 @@ func (r *PostgresVideoRepository) FindByID(id string) (*Video, error) {
 		return nil, errors.New("id cannot be empty")
 	}
 
-	query := `
-		SELECT id, title, description, file_path, storage_provider,
-			   duration, resolution, format, size, processing_state,
-			   created_at, updated_at, deleted_at,
-			   match_id, match_date, home_team, away_team, competition, season,
-			   tracking_path, event_file_path
-		FROM videos
-		WHERE id = $1 AND deleted_at IS NULL
-	`
-	// Note: event_file_path was missing from the original SELECT, added it for consistency.
-	// Assuming HasTrackingData was the last field before TrackingPath.
-
-	var video Video
-	err := r.db.QueryRow(query, id).Scan(
-		&video.ID, &video.Title, &video.Description, &video.FilePath, &video.StorageProvider,
-		&video.Duration, &video.Resolution, &video.Format, &video.Size, &video.ProcessingState,
-		&video.CreatedAt, &video.UpdatedAt, &video.DeletedAt,
-		&video.MatchID, &video.MatchDate, &video.HomeTeam, &video.AwayTeam, &video.Competition, &video.Season,
-		&video.TrackingPath, &video.EventFilePath,
-	)
-
-	if err != nil {
-		if err == sql.ErrNoRows {
+	var record videoRecord
+	if err := r.db.Where("id = ?", id).Take(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("video not found")
 		}
 		return nil, err
 	}
-
-	return &video, nil
+	return record.video(), nil
 }
 
 /**
@@ -128,304 +350,220 @@ func (r *PostgresVideoRepository) FindByID(id string) (*Video, error) {
  * @return A slice of videos or an error
  */
 func (r *PostgresVideoRepository) FindAll(limit, offset int) ([]*Video, error) {
-	if limit <= 0 {
-		limit = 10 // Default limit
-	}
-
-	query := `
-		SELECT id, title, description, file_path, storage_provider,
-			   duration, resolution, format, size, processing_state,
-			   created_at, updated_at, deleted_at,
-			   match_id, match_date, home_team, away_team, competition, season,
-			   tracking_path, event_file_path
-		FROM videos
-		WHERE deleted_at IS NULL
-		ORDER BY created_at DESC
-		LIMIT $1 OFFSET $2
-	`
-
-	rows, err := r.db.Query(query, limit, offset)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var videos []*Video
-	for rows.Next() {
-		var video Video
-		err := rows.Scan(
-			&video.ID, &video.Title, &video.Description, &video.FilePath, &video.StorageProvider,
-			&video.Duration, &video.Resolution, &video.Format, &video.Size, &video.ProcessingState,
-			&video.CreatedAt, &video.UpdatedAt, &video.DeletedAt,
-			&video.MatchID, &video.MatchDate, &video.HomeTeam, &video.AwayTeam, &video.Competition, &video.Season,
-			&video.TrackingPath, &video.EventFilePath,
-		)
-
-		if err != nil {
-			return nil, err
-		}
-
-		videos = append(videos, &video)
-	}
-
-	if err = rows.Err(); err != nil {
-		return nil, err
-	}
-
-	return videos, nil
+	return r.find(r.db.Order("created_at DESC").Limit(pageLimit(limit)).Offset(offset))
 }
 
 // Create inserts a new video into the database
 func (r *PostgresVideoRepository) Create(video *Video) error {
-	query := `
-		INSERT INTO videos (id, title, description, file_path, storage_provider,
-				   duration, resolution, format, size, processing_state,
-				   created_at, updated_at,
-				   match_id, match_date, home_team, away_team, competition, season,
-				   tracking_path, event_file_path)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20)
-	`
-	// Assuming event_file_path is the 20th argument now.
-
-	_, err := r.db.Exec(query,
-		video.ID, video.Title, video.Description, video.FilePath, video.StorageProvider,
-		video.Duration, video.Resolution, video.Format, video.Size, video.ProcessingState,
-		video.CreatedAt, video.UpdatedAt,
-		video.MatchID, video.MatchDate, video.HomeTeam, video.AwayTeam, video.Competition, video.Season,
-		video.TrackingPath, video.EventFilePath, // video.HasTrackingData removed
-	)
-
-	return err
-}
-
-// Update modifies an existing video in the database
-func (r *PostgresVideoRepository) Update(video *Video) error {
-	query := `
-		UPDATE videos 
-		SET title = $2, description = $3, file_path = $4, storage_provider = $5,
-		    duration = $6, resolution = $7, format = $8, size = $9, processing_state = $10,
-		    updated_at = $11, match_id = $12, match_date = $13, home_team = $14, 
-		    away_team = $15, competition = $16, season = $17, tracking_path = $18,
-		    event_file_path = $19
-		WHERE id = $1 AND deleted_at IS NULL
-	`
-	// Assuming event_file_path is $19 now.
-
-	result, err := r.db.Exec(query,
-		video.ID, video.Title, video.Description, video.FilePath, video.StorageProvider,
-		video.Duration, video.Resolution, video.Format, video.Size, video.ProcessingState,
-		time.Now(), video.MatchID, video.MatchDate, video.HomeTeam, video.AwayTeam,
-		video.Competition, video.Season, video.TrackingPath, video.EventFilePath, // video.HasTrackingData removed
-	)
+	return r.db.Create(newVideoRecord(video)).Error
+}
 
-	if err != nil {
-		return err
-	}
+// Update modifies an existing video in the database, except its processing state
+func (r *PostgresVideoRepository) Update(video *Video) error {
+	record := newVideoRecord(video)
+	record.UpdatedAt = sql.NullTime{Time: time.Now().UTC(), Valid: true}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return err
+	result := r.db.Model(&videoRecord{}).Where("id = ?", video.ID).Select(updatableVideoColumns).Updates(record)
+	if result.Error != nil {
+		return result.Error
 	}
-
-	if rowsAffected == 0 {
+	if result.RowsAffected == 0 {
 		return errors.New("video not found")
 	}
+	return nil
+}
 
+// UpdateProcessingState moves a video from one processing state to another
+// in a single compare-and-set statement
+func (r *PostgresVideoRepository) UpdateProcessingState(id string, from, to ProcessingState, updatedAt time.Time) error {
+	result := r.db.Model(&videoRecord{}).Where("id = ? AND processing_state = ?", id, string(from)).
+		Updates(map[string]interface{}{"processing_state": string(to), "updated_at": updatedAt.UTC()})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrStateConflict
+	}
 	return nil
 }
 
 // Delete performs a soft delete on a video
 func (r *PostgresVideoRepository) Delete(id string) error {
-	query := `UPDATE videos SET deleted_at = $2 WHERE id = $1 AND deleted_at IS NULL`
-
-	result, err := r.db.Exec(query, id, time.Now())
-	if err != nil {
-		return err
+	result := r.db.Where("id = ?", id).Delete(&videoRecord{})
+	if result.Error != nil {
+		return result.Error
 	}
-
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return err
+	if result.RowsAffected == 0 {
+		return errors.New("video not found")
 	}
+	return nil
+}
 
-	if rowsAffected == 0 {
+// Undelete clears the soft delete of a video
+func (r *PostgresVideoRepository) Undelete(id string) error {
+	result := r.db.Unscoped().Model(&videoRecord{}).Where("id = ? AND deleted_at IS NOT NULL", id).Update("deleted_at", nil)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
 		return errors.New("video not found")
 	}
-
 	return nil
 }
 
 // FindByMatchID retrieves videos for a specific match
 func (r *PostgresVideoRepository) FindByMatchID(matchID string) ([]*Video, error) {
-	query := `
-		SELECT id, title, description, file_path, storage_provider,
-			   duration, resolution, format, size, processing_state,
-			   created_at, updated_at, deleted_at,
-			   match_id, match_date, home_team, away_team, competition, season,
-			   tracking_path, event_file_path
-		FROM videos
-		WHERE match_id = $1 AND deleted_at IS NULL
-		ORDER BY created_at DESC
-	`
-
-	rows, err := r.db.Query(query, matchID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var videos []*Video
-	for rows.Next() {
-		var video Video
-		err := rows.Scan(
-			&video.ID, &video.Title, &video.Description, &video.FilePath, &video.StorageProvider,
-			&video.Duration, &video.Resolution, &video.Format, &video.Size, &video.ProcessingState,
-			&video.CreatedAt, &video.UpdatedAt, &video.DeletedAt,
-			&video.MatchID, &video.MatchDate, &video.HomeTeam, &video.AwayTeam, &video.Competition, &video.Season,
-			&video.TrackingPath, &video.EventFilePath,
-		)
-
-		if err != nil {
-			return nil, err
-		}
-
-		videos = append(videos, &video)
-	}
-
-	return videos, nil
+	return r.find(r.db.Where("match_id = ?", matchID).Order("created_at DESC"))
 }
 
 // FindByTeam retrieves videos for a specific team
 func (r *PostgresVideoRepository) FindByTeam(teamName string, limit, offset int) ([]*Video, error) {
-	if limit <= 0 {
-		limit = 10
-	}
-
-	query := `
-		SELECT id, title, description, file_path, storage_provider,
-			   duration, resolution, format, size, processing_state,
-			   created_at, updated_at, deleted_at,
-			   match_id, match_date, home_team, away_team, competition, season,
-			   tracking_path, event_file_path
-		FROM videos
-		WHERE (home_team = $1 OR away_team = $1) AND deleted_at IS NULL
-		ORDER BY match_date DESC
-		LIMIT $2 OFFSET $3
-	`
-
-	rows, err := r.db.Query(query, teamName, limit, offset)
+	return r.find(r.db.Where("home_team = @team OR away_team = @team", sql.Named("team", teamName)).
+		Order("match_date DESC").Limit(pageLimit(limit)).Offset(offset))
+}
+
+// FindByDateRange retrieves videos within a date range
+func (r *PostgresVideoRepository) FindByDateRange(start, end time.Time, limit, offset int) ([]*Video, error) {
+	return r.find(r.db.Where("match_date BETWEEN ? AND ?", start.UTC(), end.UTC()).
+		Order("match_date DESC").Limit(pageLimit(limit)).Offset(offset))
+}
+
+// FindByProcessingState retrieves videos by processing state
+func (r *PostgresVideoRepository) FindByProcessingState(state string, limit, offset int) ([]*Video, error) {
+	return r.find(r.db.Where("processing_state = ?", state).
+		Order("created_at DESC").Limit(pageLimit(limit)).Offset(offset))
+}
+
+/**
+ * FindByQuery retrieves the videos matching all filters of a query.
+ *
+ * @param query The filters, sort order and page
+ * @return A slice of videos or an error for unknown sort fields or orders
+ */
+func (r *PostgresVideoRepository) FindByQuery(query VideoQuery) ([]*Video, error) {
+	order, err := videoOrder(query.Sort, query.Order)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	var videos []*Video
-	for rows.Next() {
-		var video Video
-		err := rows.Scan(
-			&video.ID, &video.Title, &video.Description, &video.FilePath, &video.StorageProvider,
-			&video.Duration, &video.Resolution, &video.Format, &video.Size, &video.ProcessingState,
-			&video.CreatedAt, &video.UpdatedAt, &video.DeletedAt,
-			&video.MatchID, &video.MatchDate, &video.HomeTeam, &video.AwayTeam, &video.Competition, &video.Season,
-			&video.TrackingPath, &video.EventFilePath,
-		)
-
-		if err != nil {
-			return nil, err
-		}
 
-		videos = append(videos, &video)
+	return r.find(filterVideos(r.db, query).Order(order).Limit(pageLimit(query.Limit)).Offset(query.Offset))
+}
+
+// filterVideos adds the filters of a query, but not its order and page, to db
+func filterVideos(db *gorm.DB, query VideoQuery) *gorm.DB {
+	if query.MatchID != "" {
+		db = db.Where("match_id = ?", query.MatchID)
+	}
+	if query.Team != "" {
+		db = db.Where("home_team = @team OR away_team = @team", sql.Named("team", query.Team))
+	}
+	if query.Competition != "" {
+		db = db.Where("competition = ?", query.Competition)
 	}
+	if query.Season != "" {
+		db = db.Where("season = ?", query.Season)
+	}
+	if query.Venue != "" {
+		db = db.Where("venue = ?", query.Venue)
+	}
+	if query.RefereeKey != "" {
+		db = db.Where("(referee_key = ? OR referee = ?)", query.RefereeKey, query.Referee)
+	} else if query.Referee != "" {
+		db = db.Where("referee = ?", query.Referee)
+	}
+	if query.ProcessingState != "" {
+		db = db.Where("processing_state = ?", query.ProcessingState)
+	}
+	if len(query.ProcessingStates) > 0 {
+		db = db.Where("processing_state IN ?", query.ProcessingStates)
+	}
+	if !query.MatchDateFrom.IsZero() {
+		db = db.Where("match_date >= ?", query.MatchDateFrom.UTC())
+	}
+	if !query.MatchDateTo.IsZero() {
+		db = db.Where("match_date <= ?", query.MatchDateTo.UTC())
+	}
+	if query.Access != nil {
+		db = db.Where(accessCondition(db, query.Access))
+	}
+	return db
+}
 
-	return videos, nil
+// accessCondition limits a query to the matches of an access scope: those
+// listed, and those a listed team plays in. Each alternative is an index
+// lookup, teams through the LOWER indexes of migration 0020, so the OR of
+// them is too; an empty scope matches nothing.
+func accessCondition(db *gorm.DB, scope *AccessScope) *gorm.DB {
+	var clauses []string
+	var args []interface{}
+	if len(scope.MatchIDs) > 0 {
+		clauses = append(clauses, "id IN ?")
+		args = append(args, scope.MatchIDs)
+	}
+	if teams := scope.lowerTeams(); len(teams) > 0 {
+		clauses = append(clauses, "LOWER(home_team) IN ?", "LOWER(away_team) IN ?")
+		args = append(args, teams, teams)
+	}
+	if len(clauses) == 0 {
+		return db.Session(&gorm.Session{NewDB: true}).Where("1 = 0")
+	}
+	return db.Session(&gorm.Session{NewDB: true}).Where(strings.Join(clauses, " OR "), args...)
 }
 
-// FindByDateRange retrieves videos within a date range
-func (r *PostgresVideoRepository) FindByDateRange(start, end time.Time, limit, offset int) ([]*Video, error) {
-	if limit <= 0 {
-		limit = 10
-	}
-
-	query := `
-		SELECT id, title, description, file_path, storage_provider,
-			   duration, resolution, format, size, processing_state,
-			   created_at, updated_at, deleted_at,
-			   match_id, match_date, home_team, away_team, competition, season,
-			   tracking_path, event_file_path
-		FROM videos
-		WHERE match_date BETWEEN $1 AND $2 AND deleted_at IS NULL
-		ORDER BY match_date DESC
-		LIMIT $3 OFFSET $4
-	`
-
-	rows, err := r.db.Query(query, start, end, limit, offset)
-	if err != nil {
-		return nil, err
+// videoSortColumns whitelists the columns videos can be sorted by
+var videoSortColumns = map[string]bool{
+	VideoSortCreatedAt: true,
+	VideoSortMatchDate: true,
+	VideoSortTitle:     true,
+}
+
+// videoSort validates the sort field and order of a query and applies the
+// defaults, returning the field and whether it sorts descending
+func videoSort(sort, order string) (string, bool, error) {
+	if sort == "" {
+		sort = VideoSortCreatedAt
 	}
-	defer rows.Close()
-
-	var videos []*Video
-	for rows.Next() {
-		var video Video
-		err := rows.Scan(
-			&video.ID, &video.Title, &video.Description, &video.FilePath, &video.StorageProvider,
-			&video.Duration, &video.Resolution, &video.Format, &video.Size, &video.ProcessingState,
-			&video.CreatedAt, &video.UpdatedAt, &video.DeletedAt,
-			&video.MatchID, &video.MatchDate, &video.HomeTeam, &video.AwayTeam, &video.Competition, &video.Season,
-			&video.TrackingPath, &video.EventFilePath,
-		)
-
-		if err != nil {
-			return nil, err
-		}
+	if !videoSortColumns[sort] {
+		return "", false, fmt.Errorf("unknown sort field %q", sort)
+	}
+	if order != "" && order != SortAscending && order != SortDescending {
+		return "", false, fmt.Errorf("unknown sort order %q", order)
+	}
+	return sort, order != SortAscending, nil
+}
 
-		videos = append(videos, &video)
+// videoOrder builds the ORDER BY of a query, breaking ties by ID so pages
+// do not overlap
+func videoOrder(sort, order string) (clause.OrderBy, error) {
+	column, desc, err := videoSort(sort, order)
+	if err != nil {
+		return clause.OrderBy{}, err
 	}
 
-	return videos, nil
+	return clause.OrderBy{Columns: []clause.OrderByColumn{
+		{Column: clause.Column{Name: column}, Desc: desc},
+		{Column: clause.Column{Name: "id"}},
+	}}, nil
 }
 
-// FindByProcessingState retrieves videos by processing state
-func (r *PostgresVideoRepository) FindByProcessingState(state string, limit, offset int) ([]*Video, error) {
-	if limit <= 0 {
-		limit = 10
-	}
-
-	query := `
-		SELECT id, title, description, file_path, storage_provider,
-			   duration, resolution, format, size, processing_state,
-			   created_at, updated_at, deleted_at,
-			   match_id, match_date, home_team, away_team, competition, season,
-			   tracking_path, event_file_path
-		FROM videos
-		WHERE processing_state = $1 AND deleted_at IS NULL
-		ORDER BY created_at DESC
-		LIMIT $2 OFFSET $3
-	`
-
-	rows, err := r.db.Query(query, state, limit, offset)
-	if err != nil {
+// find runs a query over non-deleted videos and converts the rows
+func (r *PostgresVideoRepository) find(query *gorm.DB) ([]*Video, error) {
+	var records []videoRecord
+	if err := query.Find(&records).Error; err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	var videos []*Video
-	for rows.Next() {
-		var video Video
-		err := rows.Scan(
-			&video.ID, &video.Title, &video.Description, &video.FilePath, &video.StorageProvider,
-			&video.Duration, &video.Resolution, &video.Format, &video.Size, &video.ProcessingState,
-			&video.CreatedAt, &video.UpdatedAt, &video.DeletedAt,
-			&video.MatchID, &video.MatchDate, &video.HomeTeam, &video.AwayTeam, &video.Competition, &video.Season,
-			&video.TrackingPath, &video.EventFilePath,
-		)
-
-		if err != nil {
-			return nil, err
-		}
 
-		videos = append(videos, &video)
+	videos := make([]*Video, 0, len(records))
+	for i := range records {
+		videos = append(videos, records[i].video())
 	}
-
 	return videos, nil
 }
+
+// pageLimit applies the default page size of 10
+func pageLimit(limit int) int {
+	if limit <= 0 {
+		return 10
+	}
+	return limit
+}
@@ -0,0 +1,96 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Kinds of files stored for a video
+const (
+	FileKindVideo    = "video"
+	FileKindTracking = "tracking"
+	FileKindEvents   = "events"
+)
+
+/**
+ * VideoFile records a file stored for a video together with its size and
+ * SHA-256 checksum, so storage integrity can be verified later.
+ */
+type VideoFile struct {
+	VideoID   string    `json:"video_id"`
+	Kind      string    `json:"kind"` // "video", "tracking", "events"
+	Path      string    `json:"path"`
+	Size      int64     `json:"size"`
+	Checksum  string    `json:"checksum"` // Hex-encoded SHA-256
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+/**
+ * VideoFileRepository defines data access for stored file records.
+ */
+type VideoFileRepository interface {
+	Save(file *VideoFile) error
+	FindByVideoID(videoID string) ([]*VideoFile, error)
+}
+
+/**
+ * PostgresVideoFileRepository implements VideoFileRepository using PostgreSQL.
+ */
+type PostgresVideoFileRepository struct {
+	db *sql.DB
+}
+
+/**
+ * NewPostgresVideoFileRepository creates a new PostgreSQL-backed file record repository.
+ *
+ * @param db Database connection
+ * @return A new video file repository
+ */
+func NewPostgresVideoFileRepository(db *sql.DB) VideoFileRepository {
+	return &PostgresVideoFileRepository{db: db}
+}
+
+// Save inserts or replaces the record for a video's file of the given kind
+func (r *PostgresVideoFileRepository) Save(file *VideoFile) error {
+	query := `
+		INSERT INTO video_files (video_id, kind, path, size, checksum, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (video_id, kind) DO UPDATE
+		SET path = EXCLUDED.path, size = EXCLUDED.size, checksum = EXCLUDED.checksum,
+		    updated_at = EXCLUDED.updated_at
+	`
+	_, err := r.db.Exec(query,
+		file.VideoID, file.Kind, file.Path, file.Size, file.Checksum, file.CreatedAt, file.UpdatedAt,
+	)
+	return err
+}
+
+// FindByVideoID retrieves all file records for a video
+func (r *PostgresVideoFileRepository) FindByVideoID(videoID string) ([]*VideoFile, error) {
+	query := `
+		SELECT video_id, kind, path, size, checksum, created_at, updated_at
+		FROM video_files
+		WHERE video_id = $1
+		ORDER BY kind
+	`
+
+	rows, err := r.db.Query(query, videoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var files []*VideoFile
+	for rows.Next() {
+		var file VideoFile
+		if err := rows.Scan(
+			&file.VideoID, &file.Kind, &file.Path, &file.Size, &file.Checksum, &file.CreatedAt, &file.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		files = append(files, &file)
+	}
+
+	return files, rows.Err()
+}
@@ -0,0 +1,221 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+/**
+ * MemoryVideoRepository implements VideoRepository in memory, for demo mode
+ * and as a test double. It follows the PostgreSQL repository's semantics:
+ * deleted videos are soft deleted and hidden from every query, lists use the
+ * same default order and page size, and missing videos are "video not found".
+ * Videos are copied on the way in and out, so callers cannot change stored
+ * videos without Update.
+ */
+type MemoryVideoRepository struct {
+	mu     sync.RWMutex
+	videos map[string]*Video
+}
+
+/**
+ * NewMemoryVideoRepository creates an empty in-memory video repository.
+ *
+ * @return A new video repository
+ */
+func NewMemoryVideoRepository() *MemoryVideoRepository {
+	return &MemoryVideoRepository{videos: map[string]*Video{}}
+}
+
+// WithContext returns the repository itself; in-memory queries never block
+func (r *MemoryVideoRepository) WithContext(ctx context.Context) VideoRepository {
+	return r
+}
+
+// FindByID retrieves a video that is not deleted
+func (r *MemoryVideoRepository) FindByID(id string) (*Video, error) {
+	if id == "" {
+		return nil, errors.New("id cannot be empty")
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	video, ok := r.videos[id]
+	if !ok || video.DeletedAt.Valid {
+		return nil, errors.New("video not found")
+	}
+	return clone(video), nil
+}
+
+// FindAll retrieves a page of videos, newest first
+func (r *MemoryVideoRepository) FindAll(limit, offset int) ([]*Video, error) {
+	return r.FindByQuery(VideoQuery{Limit: limit, Offset: offset})
+}
+
+// Create stores a new video
+func (r *MemoryVideoRepository) Create(video *Video) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.videos[video.ID]; ok {
+		return fmt.Errorf("video %s already exists", video.ID)
+	}
+	r.videos[video.ID] = clone(video)
+	return nil
+}
+
+// Update replaces a video, keeping its creation and deletion times and its
+// processing state
+func (r *MemoryVideoRepository) Update(video *Video) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.videos[video.ID]
+	if !ok || stored.DeletedAt.Valid {
+		return errors.New("video not found")
+	}
+
+	updated := clone(video)
+	updated.CreatedAt = stored.CreatedAt
+	updated.DeletedAt = stored.DeletedAt
+	updated.ProcessingState = stored.ProcessingState
+	updated.UpdatedAt = time.Now()
+	r.videos[video.ID] = updated
+	return nil
+}
+
+// UpdateProcessingState moves a video from one processing state to another
+func (r *MemoryVideoRepository) UpdateProcessingState(id string, from, to ProcessingState, updatedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.videos[id]
+	if !ok || stored.DeletedAt.Valid || stored.ProcessingState != from {
+		return ErrStateConflict
+	}
+	stored.ProcessingState, stored.UpdatedAt = to, updatedAt
+	return nil
+}
+
+// Delete soft deletes a video
+func (r *MemoryVideoRepository) Delete(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	video, ok := r.videos[id]
+	if !ok || video.DeletedAt.Valid {
+		return errors.New("video not found")
+	}
+	video.DeletedAt.Time, video.DeletedAt.Valid = time.Now(), true
+	return nil
+}
+
+// Undelete clears the soft delete of a video
+func (r *MemoryVideoRepository) Undelete(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	video, ok := r.videos[id]
+	if !ok || !video.DeletedAt.Valid {
+		return errors.New("video not found")
+	}
+	video.DeletedAt.Time, video.DeletedAt.Valid = time.Time{}, false
+	return nil
+}
+
+// FindByMatchID retrieves all videos of a match, newest first
+func (r *MemoryVideoRepository) FindByMatchID(matchID string) ([]*Video, error) {
+	return r.matching(VideoQuery{MatchID: matchID})
+}
+
+// FindByTeam retrieves a page of a team's videos, latest match first
+func (r *MemoryVideoRepository) FindByTeam(teamName string, limit, offset int) ([]*Video, error) {
+	return r.FindByQuery(VideoQuery{Team: teamName, Sort: VideoSortMatchDate, Limit: limit, Offset: offset})
+}
+
+// FindByDateRange retrieves a page of videos of matches between start and end, latest first
+func (r *MemoryVideoRepository) FindByDateRange(start, end time.Time, limit, offset int) ([]*Video, error) {
+	return r.FindByQuery(VideoQuery{
+		MatchDateFrom: start, MatchDateTo: end, Sort: VideoSortMatchDate, Limit: limit, Offset: offset,
+	})
+}
+
+// FindByProcessingState retrieves a page of videos in a processing state, newest first
+func (r *MemoryVideoRepository) FindByProcessingState(state string, limit, offset int) ([]*Video, error) {
+	return r.FindByQuery(VideoQuery{ProcessingState: state, Limit: limit, Offset: offset})
+}
+
+// FindByQuery retrieves a page of the videos matching all filters of a query
+func (r *MemoryVideoRepository) FindByQuery(query VideoQuery) ([]*Video, error) {
+	videos, err := r.matching(query)
+	if err != nil {
+		return nil, err
+	}
+
+	return paginate(videos, pageLimit(query.Limit), query.Offset), nil
+}
+
+// matching returns copies of all videos matching the filters of a query, sorted
+func (r *MemoryVideoRepository) matching(query VideoQuery) ([]*Video, error) {
+	field, desc, err := videoSort(query.Sort, query.Order)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	videos := []*Video{}
+	for _, video := range r.videos {
+		if !video.DeletedAt.Valid && query.matches(video) {
+			videos = append(videos, clone(video))
+		}
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(videos, func(i, j int) bool {
+		a, b := videos[i], videos[j]
+		if c := compareVideos(a, b, field); c != 0 {
+			return (c < 0) != desc
+		}
+		return a.ID < b.ID
+	})
+	return videos, nil
+}
+
+// matches reports whether a video passes every filter of the query
+func (q VideoQuery) matches(video *Video) bool {
+	switch {
+	case q.MatchID != "" && video.MatchID != q.MatchID,
+		q.Team != "" && video.HomeTeam != q.Team && video.AwayTeam != q.Team,
+		q.Competition != "" && video.Competition != q.Competition,
+		q.Season != "" && video.Season != q.Season,
+		q.Venue != "" && video.Venue != q.Venue,
+		q.RefereeKey != "" && video.RefereeKey != q.RefereeKey && video.Referee != q.Referee,
+		q.RefereeKey == "" && q.Referee != "" && video.Referee != q.Referee,
+		q.ProcessingState != "" && string(video.ProcessingState) != q.ProcessingState,
+		len(q.ProcessingStates) > 0 && !slices.Contains(q.ProcessingStates, string(video.ProcessingState)),
+		!q.MatchDateFrom.IsZero() && video.MatchDate.Before(q.MatchDateFrom),
+		!q.MatchDateTo.IsZero() && video.MatchDate.After(q.MatchDateTo),
+		!q.Access.Allows(video):
+		return false
+	}
+	return true
+}
+
+// compareVideos orders two videos by a sort field
+func compareVideos(a, b *Video, field string) int {
+	switch field {
+	case VideoSortMatchDate:
+		return a.MatchDate.Compare(b.MatchDate)
+	case VideoSortTitle:
+		return strings.Compare(a.Title, b.Title)
+	default:
+		return a.CreatedAt.Compare(b.CreatedAt)
+	}
+}
+
+// clone returns a shallow copy of a stored value
+func clone[T any](v *T) *T {
+	c := *v
+	return &c
+}
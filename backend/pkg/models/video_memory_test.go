@@ -0,0 +1,155 @@
+package models_test
+
+import (
+	"testing"
+	"time"
+
+	"nivai/backend/pkg/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryVideoRepository(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 3, d, 0, 0, 0, 0, time.UTC) }
+	newRepo := func(t *testing.T) *models.MemoryVideoRepository {
+		repo := models.NewMemoryVideoRepository()
+		for _, video := range []*models.Video{
+			{ID: "v1", Title: "B", HomeTeam: "Ajax", AwayTeam: "PSV", Season: "2024", MatchDate: day(1), CreatedAt: day(10)},
+			{ID: "v2", Title: "A", HomeTeam: "PSV", AwayTeam: "Feyenoord", Season: "2024", MatchDate: day(8), CreatedAt: day(11)},
+			{ID: "v3", Title: "C", HomeTeam: "Ajax", AwayTeam: "AZ", Season: "2023", MatchDate: day(15), CreatedAt: day(12)},
+		} {
+			require.NoError(t, repo.Create(video))
+		}
+		return repo
+	}
+	ids := func(videos []*models.Video) []string {
+		var ids []string
+		for _, video := range videos {
+			ids = append(ids, video.ID)
+		}
+		return ids
+	}
+
+	t.Run("Queries combine filters, sort and paginate", func(t *testing.T) {
+		repo := newRepo(t)
+
+		videos, err := repo.FindAll(0, 0)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"v3", "v2", "v1"}, ids(videos))
+
+		videos, err = repo.FindByQuery(models.VideoQuery{Team: "PSV", Season: "2024", Sort: models.VideoSortTitle, Order: models.SortAscending})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"v2", "v1"}, ids(videos))
+
+		videos, err = repo.FindByDateRange(day(1), day(8), 1, 1)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"v1"}, ids(videos))
+
+		video, err := repo.FindByID("v2")
+		require.NoError(t, err)
+		require.NoError(t, repo.UpdateProcessingState("v2", video.ProcessingState, models.StateArchived, time.Now()))
+		videos, err = repo.FindByQuery(models.VideoQuery{ProcessingStates: []string{string(models.StateCompleted), string(models.StateArchived)}})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"v2"}, ids(videos))
+
+		_, err = repo.FindByQuery(models.VideoQuery{Sort: "size"})
+		assert.Error(t, err)
+	})
+
+	t.Run("Deleted videos are hidden", func(t *testing.T) {
+		repo := newRepo(t)
+		require.NoError(t, repo.Delete("v1"))
+
+		_, err := repo.FindByID("v1")
+		assert.EqualError(t, err, "video not found")
+		assert.EqualError(t, repo.Delete("v1"), "video not found")
+		assert.EqualError(t, repo.Update(&models.Video{ID: "v1"}), "video not found")
+		assert.EqualError(t, repo.Undelete("v2"), "video not found", "only deleted videos can be undeleted")
+
+		videos, err := repo.FindByTeam("Ajax", 10, 0)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"v3"}, ids(videos))
+	})
+
+	t.Run("Stored videos only change through Update", func(t *testing.T) {
+		repo := newRepo(t)
+
+		video, err := repo.FindByID("v1")
+		require.NoError(t, err)
+		video.Title = "Changed"
+		stored, _ := repo.FindByID("v1")
+		assert.Equal(t, "B", stored.Title)
+
+		video.CreatedAt = time.Time{}
+		require.NoError(t, repo.Update(video))
+		stored, _ = repo.FindByID("v1")
+		assert.Equal(t, "Changed", stored.Title)
+		assert.Equal(t, day(10), stored.CreatedAt)
+		assert.Error(t, repo.Create(&models.Video{ID: "v1"}))
+	})
+}
+
+func TestMemoryRepositories(t *testing.T) {
+	repos := models.NewMemoryRepositories()
+
+	t.Run("Reference data is derived from the videos", func(t *testing.T) {
+		require.NoError(t, repos.Videos.Create(&models.Video{ID: "v1", HomeTeam: "Ajax", AwayTeam: "PSV", Competition: "Eredivisie", Season: "2023",
+			Venue: "Johan Cruijff ArenA", Referee: "D. Makkelie"}))
+		require.NoError(t, repos.Videos.Create(&models.Video{ID: "v2", HomeTeam: "PSV", Season: "2024", Venue: "Philips Stadion"}))
+
+		data, err := repos.ReferenceData.Load()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Eredivisie"}, data.Competitions)
+		assert.Equal(t, []string{"2024", "2023"}, data.Seasons)
+		assert.Equal(t, []string{"Ajax", "PSV"}, data.Teams)
+		assert.Equal(t, []string{"Johan Cruijff ArenA", "Philips Stadion"}, data.Venues)
+		assert.Equal(t, []string{"D. Makkelie"}, data.Referees)
+
+		videos, err := repos.Videos.FindByQuery(models.VideoQuery{Venue: "Philips Stadion"})
+		require.NoError(t, err)
+		require.Len(t, videos, 1)
+		assert.Equal(t, "v2", videos[0].ID)
+	})
+
+	t.Run("Storage charges are counted once and released", func(t *testing.T) {
+		charge := &models.StorageCharge{VideoID: "v1", Organization: "org", UserID: "u1", Bytes: 100, Files: 2}
+		require.NoError(t, repos.StorageUsage.Charge(charge))
+		require.NoError(t, repos.StorageUsage.Charge(charge))
+
+		usage, err := repos.StorageUsage.FindUsage(models.UsageScopeOrganization, "org")
+		require.NoError(t, err)
+		assert.Equal(t, int64(100), usage.Bytes)
+
+		_, err = repos.StorageUsage.Release("v1")
+		require.NoError(t, err)
+		_, err = repos.StorageUsage.Release("v1")
+		assert.ErrorIs(t, err, models.ErrStorageChargeNotFound)
+		usage, _ = repos.StorageUsage.FindUsage(models.UsageScopeUser, "u1")
+		assert.Zero(t, usage.Bytes)
+	})
+
+	t.Run("Webhooks match their event types until deleted", func(t *testing.T) {
+		require.NoError(t, repos.Webhooks.Create(&models.Webhook{ID: "w1", EventTypes: []string{"video.uploaded"}, Active: true}))
+		require.NoError(t, repos.Webhooks.Create(&models.Webhook{ID: "w2", EventTypes: []string{"*"}, Active: true}))
+
+		webhooks, err := repos.Webhooks.FindActiveByEventType("video.uploaded")
+		require.NoError(t, err)
+		assert.Len(t, webhooks, 2)
+
+		require.NoError(t, repos.Webhooks.Delete("w2"))
+		webhooks, _ = repos.Webhooks.FindActiveByEventType("video.deleted")
+		assert.Empty(t, webhooks)
+		_, err = repos.Webhooks.FindByID("w2")
+		assert.EqualError(t, err, "webhook not found")
+	})
+
+	t.Run("Upload sessions are finalized once", func(t *testing.T) {
+		require.NoError(t, repos.UploadSessions.Create(&models.UploadSession{ID: "s1"}))
+		require.NoError(t, repos.UploadSessions.MarkFinalized("s1", time.Now()))
+		assert.ErrorIs(t, repos.UploadSessions.MarkFinalized("s1", time.Now()), models.ErrUploadSessionFinalized)
+
+		_, err := repos.UploadSessions.FindByID("missing")
+		assert.ErrorIs(t, err, models.ErrUploadSessionNotFound)
+	})
+}
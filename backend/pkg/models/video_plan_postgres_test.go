@@ -0,0 +1,171 @@
+package models_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+
+	"nivai/backend/pkg/database"
+	"nivai/backend/pkg/models"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// postgresRecorder opens PostgreSQL connections that remember the last
+// query run on them in a queryRecorder.
+type postgresRecorder struct {
+	driver.Connector
+	recorder *queryRecorder
+}
+
+func (p *postgresRecorder) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := p.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &recordingPostgresConn{Conn: conn, recorder: p.recorder}, nil
+}
+
+// recordingPostgresConn records the queries run on a PostgreSQL connection.
+type recordingPostgresConn struct {
+	driver.Conn
+	recorder *queryRecorder
+}
+
+func (c *recordingPostgresConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.recorder.record(query, args)
+	return c.Conn.(driver.QueryerContext).QueryContext(ctx, query, args)
+}
+
+func (c *recordingPostgresConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return c.Conn.(driver.ExecerContext).ExecContext(ctx, query, args)
+}
+
+func (c *recordingPostgresConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	return c.Conn.(driver.ConnBeginTx).BeginTx(ctx, opts)
+}
+
+// seedPlanVideos inserts the planVideos synthetic matches of the SQLite plan
+// test into PostgreSQL and analyses the table.
+const seedPlanVideos = `
+	INSERT INTO videos (id, title, processing_state, created_at, updated_at, deleted_at,
+		match_id, match_date, home_team, away_team, competition, season)
+	SELECT 'video-' || lpad(i::text, 5, '0'), 'Match',
+		(ARRAY['completed', 'completed', 'completed', 'completed', 'archived', 'pending_analytics', 'processing', 'failed'])[i % 8 + 1],
+		start + i * INTERVAL '1 hour', start + i * INTERVAL '1 hour',
+		CASE WHEN i % 50 = 0 THEN start + (i + 1) * INTERVAL '1 hour' END,
+		'match-' || lpad((i / 2)::text, 5, '0'), start + i * INTERVAL '1 hour' - INTERVAL '1 day',
+		'Team ' || i % 40, 'Team ' || (i + 7) % 40, 'Eredivisie', (2015 + i / 2000)::text
+	FROM generate_series(0, $1::int - 1) AS i, (SELECT TIMESTAMPTZ '2015-01-01 00:00:00+00' AS start) AS s
+`
+
+// postgresQueryPlan explains a recorded query, one line per plan line.
+func postgresQueryPlan(t *testing.T, db *sql.DB, query recordedQuery) []string {
+	t.Helper()
+	args := make([]interface{}, len(query.args))
+	for i, arg := range query.args {
+		args[i] = arg.Value
+	}
+	rows, err := db.Query("EXPLAIN (FORMAT TEXT) "+query.sql, args...)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	var lines []string
+	for rows.Next() {
+		var line string
+		require.NoError(t, rows.Scan(&line))
+		lines = append(lines, line)
+	}
+	require.NoError(t, rows.Err())
+	return lines
+}
+
+// TestVideoQueryPlansAgainstPostgres is TestVideoQueryPlans on PostgreSQL,
+// whose planner is the one production runs: every Find* query must be
+// answered through the indexes of migrations 0019 and 0020, not a sequential scan of
+// videos. It runs where the other PostgreSQL tests do; see requirePostgres.
+func TestVideoQueryPlansAgainstPostgres(t *testing.T) {
+	recorder := &queryRecorder{}
+	db := openTestPostgresWith(t, func(dsn string) (*sql.DB, error) {
+		connector, err := pq.NewConnector(dsn)
+		if err != nil {
+			return nil, err
+		}
+		return sql.OpenDB(&postgresRecorder{Connector: connector, recorder: recorder}), nil
+	})
+	require.NoError(t, database.Migrate(db))
+	_, err := db.Exec(seedPlanVideos, planVideos)
+	require.NoError(t, err)
+	_, err = db.Exec("ANALYZE videos")
+	require.NoError(t, err)
+
+	var indexes []string
+	rows, err := db.Query(`SELECT indexname FROM pg_indexes WHERE schemaname = current_schema() AND tablename = 'videos'`)
+	require.NoError(t, err)
+	for rows.Next() {
+		var name string
+		require.NoError(t, rows.Scan(&name))
+		indexes = append(indexes, name)
+	}
+	require.NoError(t, rows.Close())
+	for _, index := range []string{"idx_videos_deleted_created", "idx_videos_match_id", "idx_videos_teams",
+		"idx_videos_away_team", "idx_videos_processing_state", "idx_videos_match_date",
+		"idx_videos_home_team_key", "idx_videos_away_team_key"} {
+		assert.Contains(t, indexes, index, "migrations 0019 and 0020 build the index concurrently")
+	}
+
+	repo, err := models.NewPostgresVideoRepository(db)
+	require.NoError(t, err)
+	week := time.Date(2016, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, tc := range []struct {
+		name  string
+		find  func() ([]*models.Video, error)
+		index string // Index the plan must use; empty when the planner may pick any
+	}{
+		{"FindAll", func() ([]*models.Video, error) { return repo.FindAll(20, 0) }, ""},
+		{"FindByMatchID", func() ([]*models.Video, error) { return repo.FindByMatchID("match-04242") }, "idx_videos_match_id"},
+		{"FindByTeam", func() ([]*models.Video, error) { return repo.FindByTeam("Team 7", 20, 0) }, ""},
+		{"FindByDateRange", func() ([]*models.Video, error) {
+			return repo.FindByDateRange(week, week.AddDate(0, 0, 7), 20, 0)
+		}, ""},
+		{"FindByProcessingState", func() ([]*models.Video, error) { return repo.FindByProcessingState("failed", 20, 0) }, ""},
+		{"FindByQuery default order", func() ([]*models.Video, error) {
+			return repo.FindByQuery(models.VideoQuery{Limit: 20})
+		}, ""},
+		{"FindByQuery match", func() ([]*models.Video, error) {
+			return repo.FindByQuery(models.VideoQuery{MatchID: "match-04242", Limit: 20})
+		}, "idx_videos_match_id"},
+		{"FindByQuery team", func() ([]*models.Video, error) {
+			return repo.FindByQuery(models.VideoQuery{Team: "Team 7", Limit: 20})
+		}, ""},
+		{"FindByQuery access teams", func() ([]*models.Video, error) {
+			return repo.FindByQuery(models.VideoQuery{Access: &models.AccessScope{MatchIDs: []string{"video-00001"}, Teams: []string{"team 7"}}, Limit: 20})
+		}, ""},
+		{"FindByQuery state", func() ([]*models.Video, error) {
+			return repo.FindByQuery(models.VideoQuery{ProcessingState: "failed", Limit: 20})
+		}, ""},
+		{"FindByQuery dates", func() ([]*models.Video, error) {
+			return repo.FindByQuery(models.VideoQuery{MatchDateFrom: week, MatchDateTo: week.AddDate(0, 0, 7), Limit: 20})
+		}, ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			videos, err := tc.find()
+			require.NoError(t, err)
+			assert.NotEmpty(t, videos, "the dataset should match the filter")
+
+			query := recorder.lastQuery()
+			require.NotEmpty(t, query.sql)
+			plan := strings.Join(postgresQueryPlan(t, db, query), "\n")
+			assert.NotContains(t, plan, "Seq Scan on videos", "%s reads the whole table:\n%s\n%s", tc.name, query.sql, plan)
+			if tc.index != "" {
+				assert.Contains(t, plan, tc.index, "%s:\n%s", query.sql, plan)
+			}
+		})
+	}
+}
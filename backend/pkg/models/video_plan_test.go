@@ -0,0 +1,204 @@
+package models_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"nivai/backend/pkg/database"
+	"nivai/backend/pkg/models"
+
+	"github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// planVideos is the size of the synthetic dataset the query plans are
+// checked on, large enough that ANALYZE favours indexes where they help.
+const planVideos = 20000
+
+// fullScan matches a query plan step reading the whole videos table.
+var fullScan = regexp.MustCompile(`^SCAN (TABLE )?videos$`)
+
+// recordedQuery is a query the repository ran, with its arguments.
+type recordedQuery struct {
+	sql  string
+	args []driver.NamedValue
+}
+
+// queryRecorder opens SQLite connections that remember the last query run.
+type queryRecorder struct {
+	driver sqlite3.SQLiteDriver
+	dsn    string
+
+	mu   sync.Mutex
+	last recordedQuery
+}
+
+func (q *queryRecorder) Connect(context.Context) (driver.Conn, error) {
+	conn, err := q.driver.Open(q.dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &recordingConn{SQLiteConn: conn.(*sqlite3.SQLiteConn), recorder: q}, nil
+}
+
+func (q *queryRecorder) Driver() driver.Driver { return &q.driver }
+
+// record remembers a query as the last one run.
+func (q *queryRecorder) record(query string, args []driver.NamedValue) {
+	q.mu.Lock()
+	q.last = recordedQuery{sql: query, args: args}
+	q.mu.Unlock()
+}
+
+// lastQuery returns the last query run since the previous call.
+func (q *queryRecorder) lastQuery() recordedQuery {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	last := q.last
+	q.last = recordedQuery{}
+	return last
+}
+
+// recordingConn records the queries run on a SQLite connection.
+type recordingConn struct {
+	*sqlite3.SQLiteConn
+	recorder *queryRecorder
+}
+
+func (c *recordingConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.recorder.record(query, args)
+	return c.SQLiteConn.QueryContext(ctx, query, args)
+}
+
+// openPlanDatabase creates a migrated SQLite database holding planVideos
+// synthetic matches, and the recorder of the queries run on it.
+func openPlanDatabase(t *testing.T) (*sql.DB, *queryRecorder) {
+	t.Helper()
+	recorder := &queryRecorder{dsn: "file:" + filepath.Join(t.TempDir(), "plans.db")}
+	db := sql.OpenDB(recorder)
+	if err := db.Ping(); err != nil {
+		t.Skipf("SQLite unavailable (built without cgo?): %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	require.NoError(t, database.MigrateSQLite(db))
+
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	insert, err := tx.Prepare(`INSERT INTO videos (id, title, processing_state, created_at, updated_at, deleted_at,
+		match_id, match_date, home_team, away_team, competition, season) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	require.NoError(t, err)
+	states := []string{"completed", "completed", "completed", "completed", "archived", "pending_analytics", "processing", "failed"}
+	start := time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < planVideos; i++ {
+		created := start.Add(time.Duration(i) * time.Hour)
+		var deleted interface{}
+		if i%50 == 0 {
+			deleted = created.Add(time.Hour)
+		}
+		_, err := insert.Exec(fmt.Sprintf("video-%05d", i), "Match", states[i%len(states)], created, created, deleted,
+			fmt.Sprintf("match-%05d", i/2), created.Add(-24*time.Hour),
+			fmt.Sprintf("Team %d", i%40), fmt.Sprintf("Team %d", (i+7)%40), "Eredivisie", fmt.Sprint(2015+i/2000))
+		require.NoError(t, err)
+	}
+	require.NoError(t, insert.Close())
+	require.NoError(t, tx.Commit())
+	_, err = db.Exec("ANALYZE")
+	require.NoError(t, err)
+	return db, recorder
+}
+
+// queryPlan explains a recorded query, one line per plan step.
+func queryPlan(t *testing.T, db *sql.DB, query recordedQuery) []string {
+	t.Helper()
+	args := make([]interface{}, len(query.args))
+	for i, arg := range query.args {
+		args[i] = arg.Value
+		if arg.Name != "" {
+			args[i] = sql.Named(arg.Name, arg.Value)
+		}
+	}
+	rows, err := db.Query("EXPLAIN QUERY PLAN "+query.sql, args...)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	var steps []string
+	for rows.Next() {
+		var id, parent, unused int
+		var detail string
+		require.NoError(t, rows.Scan(&id, &parent, &unused, &detail))
+		steps = append(steps, detail)
+	}
+	require.NoError(t, rows.Err())
+	return steps
+}
+
+// TestVideoQueryPlans guards the indexes of the video filters: every Find*
+// query must be answered through an index on a large dataset, not by
+// reading the whole table. A failure names the query and its plan.
+func TestVideoQueryPlans(t *testing.T) {
+	db, recorder := openPlanDatabase(t)
+	repos, err := models.NewSQLiteRepositories(db)
+	require.NoError(t, err)
+	repo := repos.Videos
+	week := time.Date(2016, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, tc := range []struct {
+		name  string
+		find  func() ([]*models.Video, error)
+		index string // Index the plan must use; empty when any will do, e.g. walking the list order
+	}{
+		{"FindAll", func() ([]*models.Video, error) { return repo.FindAll(20, 0) }, "idx_videos_created_not_deleted"},
+		{"FindByMatchID", func() ([]*models.Video, error) { return repo.FindByMatchID("match-04242") }, "idx_videos_match_id"},
+		{"FindByTeam", func() ([]*models.Video, error) { return repo.FindByTeam("Team 7", 20, 0) }, "idx_videos_teams"},
+		{"FindByDateRange", func() ([]*models.Video, error) {
+			return repo.FindByDateRange(week, week.AddDate(0, 0, 7), 20, 0)
+		}, "idx_videos_match_date"},
+		{"FindByProcessingState", func() ([]*models.Video, error) { return repo.FindByProcessingState("failed", 20, 0) }, ""},
+		{"FindByQuery default order", func() ([]*models.Video, error) {
+			return repo.FindByQuery(models.VideoQuery{Limit: 20})
+		}, "idx_videos_created_not_deleted"},
+		{"FindByQuery match", func() ([]*models.Video, error) {
+			return repo.FindByQuery(models.VideoQuery{MatchID: "match-04242", Limit: 20})
+		}, "idx_videos_match_id"},
+		{"FindByQuery team", func() ([]*models.Video, error) {
+			return repo.FindByQuery(models.VideoQuery{Team: "Team 7", Limit: 20})
+		}, "idx_videos_teams"},
+		{"FindByQuery access teams", func() ([]*models.Video, error) {
+			return repo.FindByQuery(models.VideoQuery{Access: &models.AccessScope{Teams: []string{"team 7"}}, Limit: 20})
+		}, "idx_videos_home_team_key"},
+		{"FindByQuery access matches and teams", func() ([]*models.Video, error) {
+			return repo.FindByQuery(models.VideoQuery{Access: &models.AccessScope{MatchIDs: []string{"video-00001"}, Teams: []string{"team 7"}}, Limit: 20})
+		}, "idx_videos_away_team_key"},
+		{"FindByQuery state", func() ([]*models.Video, error) {
+			return repo.FindByQuery(models.VideoQuery{ProcessingState: "failed", Limit: 20})
+		}, ""},
+		{"FindByQuery dates", func() ([]*models.Video, error) {
+			return repo.FindByQuery(models.VideoQuery{MatchDateFrom: week, MatchDateTo: week.AddDate(0, 0, 7), Limit: 20})
+		}, "idx_videos_match_date"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			videos, err := tc.find()
+			require.NoError(t, err)
+			assert.NotEmpty(t, videos, "the dataset should match the filter")
+
+			query := recorder.lastQuery()
+			require.NotEmpty(t, query.sql)
+			plan := queryPlan(t, db, query)
+			for _, step := range plan {
+				assert.False(t, fullScan.MatchString(step), "%s reads the whole table:\n%s\n%s", tc.name, query.sql, strings.Join(plan, "\n"))
+			}
+			if tc.index != "" {
+				assert.Contains(t, strings.Join(plan, "\n"), tc.index, "%s:\n%s", query.sql, strings.Join(plan, "\n"))
+			}
+		})
+	}
+}
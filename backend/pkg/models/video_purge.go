@@ -0,0 +1,136 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ErrVideoPurgeNotFound is returned when a match has no pending purge.
+var ErrVideoPurgeNotFound = errors.New("video purge not found")
+
+/**
+ * VideoPurge is a deleted match whose files wait out the grace period
+ * before they are removed from storage. Until then the deletion can be
+ * undone.
+ */
+type VideoPurge struct {
+	VideoID    string    `json:"video_id"`
+	Title      string    `json:"title"`
+	Paths      []string  `json:"paths"` // Stored files removed by the purge
+	DeletedBy  string    `json:"deleted_by"`
+	DeletedAt  time.Time `json:"deleted_at"`
+	PurgeAfter time.Time `json:"purge_after"`
+}
+
+/**
+ * VideoPurgeRepository defines data access for the pending purge queue.
+ */
+type VideoPurgeRepository interface {
+	// Create queues a purge, replacing a pending purge of the same match
+	Create(purge *VideoPurge) error
+	FindByVideoID(videoID string) (*VideoPurge, error)
+	// FindDue lists the purges whose grace period ended before the given time, oldest first
+	FindDue(before time.Time, limit int) ([]*VideoPurge, error)
+	// FindAll lists the pending purges, soonest first
+	FindAll(limit, offset int) ([]*VideoPurge, error)
+	Delete(videoID string) error
+}
+
+/**
+ * PostgresVideoPurgeRepository implements VideoPurgeRepository using PostgreSQL.
+ */
+type PostgresVideoPurgeRepository struct {
+	db *sql.DB
+}
+
+/**
+ * NewPostgresVideoPurgeRepository creates a new PostgreSQL-backed video purge repository.
+ *
+ * @param db Database connection
+ * @return A new video purge repository
+ */
+func NewPostgresVideoPurgeRepository(db *sql.DB) VideoPurgeRepository {
+	return &PostgresVideoPurgeRepository{db: db}
+}
+
+// Create inserts a purge, replacing a pending purge of the same match
+func (r *PostgresVideoPurgeRepository) Create(p *VideoPurge) error {
+	query := `
+		INSERT INTO video_purges (video_id, title, paths, deleted_by, deleted_at, purge_after)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (video_id) DO UPDATE SET
+			title = EXCLUDED.title, paths = EXCLUDED.paths, deleted_by = EXCLUDED.deleted_by,
+			deleted_at = EXCLUDED.deleted_at, purge_after = EXCLUDED.purge_after
+	`
+	_, err := r.db.Exec(query, p.VideoID, p.Title, pq.Array(p.Paths), p.DeletedBy, p.DeletedAt, p.PurgeAfter)
+	return err
+}
+
+// FindByVideoID retrieves the pending purge of a match
+func (r *PostgresVideoPurgeRepository) FindByVideoID(videoID string) (*VideoPurge, error) {
+	purges, err := r.query(`
+		SELECT video_id, title, paths, deleted_by, deleted_at, purge_after
+		FROM video_purges WHERE video_id = $1
+	`, videoID)
+	if err != nil {
+		return nil, err
+	}
+	if len(purges) == 0 {
+		return nil, ErrVideoPurgeNotFound
+	}
+	return purges[0], nil
+}
+
+// FindDue retrieves the purges whose grace period ended before the given time, oldest first
+func (r *PostgresVideoPurgeRepository) FindDue(before time.Time, limit int) ([]*VideoPurge, error) {
+	return r.query(`
+		SELECT video_id, title, paths, deleted_by, deleted_at, purge_after
+		FROM video_purges WHERE purge_after <= $1
+		ORDER BY purge_after, video_id
+		LIMIT $2
+	`, before, defaultLimit(limit, 100))
+}
+
+// FindAll retrieves the pending purges, soonest first
+func (r *PostgresVideoPurgeRepository) FindAll(limit, offset int) ([]*VideoPurge, error) {
+	return r.query(`
+		SELECT video_id, title, paths, deleted_by, deleted_at, purge_after
+		FROM video_purges
+		ORDER BY purge_after, video_id
+		LIMIT $1 OFFSET $2
+	`, defaultLimit(limit, 100), max(offset, 0))
+}
+
+// Delete removes the pending purge of a match
+func (r *PostgresVideoPurgeRepository) Delete(videoID string) error {
+	result, err := r.db.Exec(`DELETE FROM video_purges WHERE video_id = $1`, videoID)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return ErrVideoPurgeNotFound
+	}
+	return nil
+}
+
+// query scans the purges a query returns
+func (r *PostgresVideoPurgeRepository) query(query string, args ...interface{}) ([]*VideoPurge, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	purges := []*VideoPurge{}
+	for rows.Next() {
+		var p VideoPurge
+		if err := rows.Scan(&p.VideoID, &p.Title, pq.Array(&p.Paths), &p.DeletedBy, &p.DeletedAt, &p.PurgeAfter); err != nil {
+			return nil, err
+		}
+		purges = append(purges, &p)
+	}
+	return purges, rows.Err()
+}
@@ -0,0 +1,248 @@
+package models
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// replicaPingTimeout bounds the health check of a single replica.
+const replicaPingTimeout = 2 * time.Second
+
+/**
+ * VideoReplica is a read replica of the videos table: its repository and a
+ * health check, typically the replica database's PingContext.
+ */
+type VideoReplica struct {
+	Name string
+	Repo VideoRepository
+	Ping func(ctx context.Context) error
+}
+
+// replicaState is the health of a replica, shared by context-bound copies.
+type replicaState struct {
+	name    string
+	ping    func(ctx context.Context) error
+	healthy atomic.Bool
+}
+
+// boundReplica is a replica's repository together with its health.
+type boundReplica struct {
+	state *replicaState
+	repo  VideoRepository
+}
+
+/**
+ * ReplicatedVideoRepository routes reads to healthy read replicas in turn
+ * and writes to the primary. A replica that fails a query and its health
+ * check is taken out of rotation and the query is retried on the primary;
+ * Run puts it back once it answers again. Without healthy replicas all
+ * queries go to the primary.
+ *
+ * Lookups of single videos that a replica does not have yet, e.g. just
+ * after they were created, are retried on the primary to hide replication
+ * lag.
+ */
+type ReplicatedVideoRepository struct {
+	primary  VideoRepository
+	replicas []boundReplica
+	next     *atomic.Uint64
+	interval time.Duration
+}
+
+/**
+ * NewReplicatedVideoRepository creates a repository reading from replicas
+ * and writing to primary. All replicas start out healthy.
+ *
+ * @param primary The repository of the primary database
+ * @param replicas The read replicas
+ * @param checkInterval How often Run checks the replicas' health (default 10s)
+ * @return A new replicated video repository
+ */
+func NewReplicatedVideoRepository(primary VideoRepository, replicas []VideoReplica, checkInterval time.Duration) *ReplicatedVideoRepository {
+	if checkInterval <= 0 {
+		checkInterval = 10 * time.Second
+	}
+	r := &ReplicatedVideoRepository{primary: primary, next: new(atomic.Uint64), interval: checkInterval}
+	for _, replica := range replicas {
+		state := &replicaState{name: replica.Name, ping: replica.Ping}
+		state.healthy.Store(true)
+		r.replicas = append(r.replicas, boundReplica{state: state, repo: replica.Repo})
+	}
+	return r
+}
+
+/**
+ * Run checks the health of the replicas until ctx is cancelled.
+ *
+ * @param ctx Context controlling the health checks
+ */
+func (r *ReplicatedVideoRepository) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		r.CheckReplicas(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+/**
+ * CheckReplicas pings every replica and updates whether it receives reads.
+ *
+ * @param ctx Context for the health checks
+ */
+func (r *ReplicatedVideoRepository) CheckReplicas(ctx context.Context) {
+	for _, replica := range r.replicas {
+		r.check(ctx, replica.state)
+	}
+}
+
+/**
+ * HealthyReplicas reports the names of the replicas currently receiving reads.
+ *
+ * @return The healthy replicas' names
+ */
+func (r *ReplicatedVideoRepository) HealthyReplicas() []string {
+	names := []string{}
+	for _, replica := range r.replicas {
+		if replica.state.healthy.Load() {
+			names = append(names, replica.state.name)
+		}
+	}
+	return names
+}
+
+// check pings a replica, logs health changes and reports whether it is healthy
+func (r *ReplicatedVideoRepository) check(ctx context.Context, state *replicaState) bool {
+	ctx, cancel := context.WithTimeout(ctx, replicaPingTimeout)
+	defer cancel()
+
+	err := state.ping(ctx)
+	healthy := err == nil
+	if was := state.healthy.Swap(healthy); was != healthy {
+		if healthy {
+			log.Printf("Read replica %s is healthy again", state.name)
+		} else {
+			log.Printf("Read replica %s is unhealthy, reading from the primary: %v", state.name, err)
+		}
+	}
+	return healthy
+}
+
+// replica returns the next healthy replica, or false if there is none
+func (r *ReplicatedVideoRepository) replica() (boundReplica, bool) {
+	n := len(r.replicas)
+	start := int(r.next.Add(1) % uint64(max(n, 1)))
+	for i := 0; i < n; i++ {
+		replica := r.replicas[(start+i)%n]
+		if replica.state.healthy.Load() {
+			return replica, true
+		}
+	}
+	return boundReplica{}, false
+}
+
+// read runs a list query on a replica, falling back to the primary when the
+// replica is down
+func (r *ReplicatedVideoRepository) read(query func(repo VideoRepository) ([]*Video, error)) ([]*Video, error) {
+	replica, ok := r.replica()
+	if !ok {
+		return query(r.primary)
+	}
+	videos, err := query(replica.repo)
+	if err != nil && !r.check(context.Background(), replica.state) {
+		return query(r.primary)
+	}
+	return videos, err
+}
+
+/**
+ * WithContext returns a repository whose queries are cancelled with ctx.
+ * Replica health is shared with the original.
+ *
+ * @param ctx The context for the queries
+ * @return The context-bound repository
+ */
+func (r *ReplicatedVideoRepository) WithContext(ctx context.Context) VideoRepository {
+	bound := &ReplicatedVideoRepository{primary: r.primary.WithContext(ctx), next: r.next, interval: r.interval}
+	for _, replica := range r.replicas {
+		bound.replicas = append(bound.replicas, boundReplica{state: replica.state, repo: replica.repo.WithContext(ctx)})
+	}
+	return bound
+}
+
+// FindByID reads a video from a replica, or from the primary if the replica
+// is down or does not have it (yet)
+func (r *ReplicatedVideoRepository) FindByID(id string) (*Video, error) {
+	if replica, ok := r.replica(); ok {
+		video, err := replica.repo.FindByID(id)
+		if err == nil {
+			return video, nil
+		}
+		if !strings.Contains(err.Error(), "not found") && r.check(context.Background(), replica.state) {
+			return nil, err
+		}
+	}
+	return r.primary.FindByID(id)
+}
+
+// FindAll reads a page of videos from a replica
+func (r *ReplicatedVideoRepository) FindAll(limit, offset int) ([]*Video, error) {
+	return r.read(func(repo VideoRepository) ([]*Video, error) { return repo.FindAll(limit, offset) })
+}
+
+// FindByMatchID reads the videos of a match from a replica
+func (r *ReplicatedVideoRepository) FindByMatchID(matchID string) ([]*Video, error) {
+	return r.read(func(repo VideoRepository) ([]*Video, error) { return repo.FindByMatchID(matchID) })
+}
+
+// FindByTeam reads a team's videos from a replica
+func (r *ReplicatedVideoRepository) FindByTeam(teamName string, limit, offset int) ([]*Video, error) {
+	return r.read(func(repo VideoRepository) ([]*Video, error) { return repo.FindByTeam(teamName, limit, offset) })
+}
+
+// FindByDateRange reads videos within a date range from a replica
+func (r *ReplicatedVideoRepository) FindByDateRange(start, end time.Time, limit, offset int) ([]*Video, error) {
+	return r.read(func(repo VideoRepository) ([]*Video, error) { return repo.FindByDateRange(start, end, limit, offset) })
+}
+
+// FindByProcessingState reads videos by processing state from a replica
+func (r *ReplicatedVideoRepository) FindByProcessingState(state string, limit, offset int) ([]*Video, error) {
+	return r.read(func(repo VideoRepository) ([]*Video, error) { return repo.FindByProcessingState(state, limit, offset) })
+}
+
+// FindByQuery reads the videos matching a query from a replica
+func (r *ReplicatedVideoRepository) FindByQuery(query VideoQuery) ([]*Video, error) {
+	return r.read(func(repo VideoRepository) ([]*Video, error) { return repo.FindByQuery(query) })
+}
+
+// Create inserts a video on the primary
+func (r *ReplicatedVideoRepository) Create(video *Video) error {
+	return r.primary.Create(video)
+}
+
+// Update modifies a video on the primary
+func (r *ReplicatedVideoRepository) Update(video *Video) error {
+	return r.primary.Update(video)
+}
+
+// UpdateProcessingState changes a video's processing state on the primary
+func (r *ReplicatedVideoRepository) UpdateProcessingState(id string, from, to ProcessingState, updatedAt time.Time) error {
+	return r.primary.UpdateProcessingState(id, from, to, updatedAt)
+}
+
+// Delete soft deletes a video on the primary
+func (r *ReplicatedVideoRepository) Delete(id string) error {
+	return r.primary.Delete(id)
+}
+
+// Undelete clears the soft delete of a video on the primary
+func (r *ReplicatedVideoRepository) Undelete(id string) error {
+	return r.primary.Undelete(id)
+}
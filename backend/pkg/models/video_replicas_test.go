@@ -0,0 +1,117 @@
+package models_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"nivai/backend/pkg/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubVideoRepository answers reads with its name as the video ID; methods
+// the tests do not use panic through the nil embedded interface.
+type stubVideoRepository struct {
+	models.VideoRepository
+	name    string
+	err     error
+	created []*models.Video
+}
+
+func (s *stubVideoRepository) FindByID(id string) (*models.Video, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &models.Video{ID: s.name}, nil
+}
+
+func (s *stubVideoRepository) FindAll(limit, offset int) ([]*models.Video, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return []*models.Video{{ID: s.name}}, nil
+}
+
+func (s *stubVideoRepository) Create(video *models.Video) error {
+	s.created = append(s.created, video)
+	return nil
+}
+
+func (s *stubVideoRepository) WithContext(ctx context.Context) models.VideoRepository {
+	return s
+}
+
+// stubPing is a replica health check that can be switched off.
+type stubPing struct{ err error }
+
+func (p *stubPing) Ping(ctx context.Context) error { return p.err }
+
+func TestReplicatedVideoRepository(t *testing.T) {
+	newRepo := func() (*models.ReplicatedVideoRepository, *stubVideoRepository, []*stubVideoRepository, []*stubPing) {
+		primary := &stubVideoRepository{name: "primary"}
+		replicas := []*stubVideoRepository{{name: "replica-1"}, {name: "replica-2"}}
+		pings := []*stubPing{{}, {}}
+		repo := models.NewReplicatedVideoRepository(primary, []models.VideoReplica{
+			{Name: "replica-1", Repo: replicas[0], Ping: pings[0].Ping},
+			{Name: "replica-2", Repo: replicas[1], Ping: pings[1].Ping},
+		}, time.Minute)
+		return repo, primary, replicas, pings
+	}
+	readFrom := func(t *testing.T, repo models.VideoRepository) string {
+		t.Helper()
+		videos, err := repo.FindAll(10, 0)
+		require.NoError(t, err)
+		return videos[0].ID
+	}
+
+	t.Run("Reads rotate over the replicas and writes go to the primary", func(t *testing.T) {
+		repo, primary, _, _ := newRepo()
+
+		served := map[string]int{}
+		for i := 0; i < 4; i++ {
+			served[readFrom(t, repo)]++
+		}
+		assert.Equal(t, map[string]int{"replica-1": 2, "replica-2": 2}, served)
+
+		require.NoError(t, repo.Create(&models.Video{ID: "v1"}))
+		assert.Len(t, primary.created, 1)
+	})
+
+	t.Run("A failing replica is skipped until it is healthy again", func(t *testing.T) {
+		repo, _, replicas, pings := newRepo()
+		replicas[0].err = errors.New("connection refused")
+		pings[0].err = errors.New("connection refused")
+
+		for i := 0; i < 2; i++ {
+			assert.NotEqual(t, "replica-1", readFrom(t, repo))
+		}
+		assert.Equal(t, []string{"replica-2"}, repo.HealthyReplicas())
+
+		replicas[0].err, pings[0].err = nil, nil
+		repo.CheckReplicas(context.Background())
+		assert.Equal(t, []string{"replica-1", "replica-2"}, repo.HealthyReplicas())
+	})
+
+	t.Run("Reads fall back to the primary without healthy replicas", func(t *testing.T) {
+		repo, _, _, pings := newRepo()
+		pings[0].err = errors.New("down")
+		pings[1].err = errors.New("down")
+		repo.CheckReplicas(context.Background())
+
+		assert.Equal(t, "primary", readFrom(t, repo.WithContext(context.Background())))
+	})
+
+	t.Run("Videos missing on a lagging replica are read from the primary", func(t *testing.T) {
+		repo, _, replicas, _ := newRepo()
+		replicas[0].err = errors.New("video not found")
+		replicas[1].err = errors.New("video not found")
+
+		video, err := repo.FindByID("v1")
+		require.NoError(t, err)
+		assert.Equal(t, "primary", video.ID)
+		assert.Len(t, repo.HealthyReplicas(), 2)
+	})
+}
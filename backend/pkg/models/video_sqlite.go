@@ -0,0 +1,43 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+/**
+ * SQLiteVideoRepository implements VideoRepository using a local SQLite
+ * database file, for single-node deployments without PostgreSQL. GORM
+ * builds the same queries for both databases, so it shares the
+ * implementation of PostgresVideoRepository; only the dialect differs.
+ */
+type SQLiteVideoRepository struct {
+	*PostgresVideoRepository
+}
+
+/**
+ * NewSQLiteVideoRepository creates a new SQLite-backed video repository.
+ *
+ * @param db Database connection opened with the "sqlite3" driver
+ * @return A new video repository or error
+ */
+func NewSQLiteVideoRepository(db *sql.DB) (VideoRepository, error) {
+	gormDB, err := gorm.Open(sqlite.Dialector{Conn: db}, gormConfig())
+	if err != nil {
+		return nil, err
+	}
+	return &SQLiteVideoRepository{&PostgresVideoRepository{db: gormDB}}, nil
+}
+
+/**
+ * WithContext returns a repository whose queries are cancelled with ctx.
+ *
+ * @param ctx The context for the queries
+ * @return The context-bound repository
+ */
+func (r *SQLiteVideoRepository) WithContext(ctx context.Context) VideoRepository {
+	return &SQLiteVideoRepository{&PostgresVideoRepository{db: r.db.WithContext(ctx)}}
+}
@@ -0,0 +1,154 @@
+package models_test
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"nivai/backend/pkg/database"
+	"nivai/backend/pkg/models"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// openTestSQLite creates a migrated SQLite database in a temporary file.
+func openTestSQLite(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "nivai.db"))
+	require.NoError(t, err)
+	if err := db.Ping(); err != nil {
+		t.Skipf("SQLite unavailable (built without cgo?): %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	require.NoError(t, database.MigrateSQLite(db))
+	require.NoError(t, database.MigrateSQLite(db), "migrations must be idempotent")
+	return db
+}
+
+func TestSQLiteVideoRepository(t *testing.T) {
+	db := openTestSQLite(t)
+	repos, err := models.NewSQLiteRepositories(db)
+	require.NoError(t, err)
+	repo := repos.Videos
+
+	amsterdam := time.FixedZone("CEST", 2*60*60)
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	kickoff := time.Date(2024, 4, 1, 20, 0, 0, 0, time.UTC)
+	for i, video := range []*models.Video{
+		{ID: "v1", Title: "Ajax - PSV", HomeTeam: "Ajax", AwayTeam: "PSV", Competition: "Eredivisie", Season: "2023",
+			MatchDate: time.Date(2024, 4, 1, 20, 0, 0, 0, time.UTC),
+			Venue:     "Johan Cruijff ArenA", Referee: "D. Makkelie", Attendance: 54000, Weather: "Rain, 9°C",
+			KickoffAt: &kickoff, KickoffTimezone: "Europe/Amsterdam"},
+		// Stored in UTC: 2024-04-01 19:00, before v1 despite the later wall clock
+		// Referees of v2 and v3 are the same one stored encrypted, by its blind index
+		{ID: "v2", Title: "PSV - AZ", HomeTeam: "PSV", AwayTeam: "AZ", Season: "2024",
+			MatchDate: time.Date(2024, 4, 1, 21, 0, 0, 0, amsterdam), Referee: "enc:v1:k:b", RefereeKey: "makkelie"},
+		{ID: "v3", Title: "AZ - Ajax", HomeTeam: "AZ", AwayTeam: "Ajax", Season: "2024",
+			MatchDate: time.Date(2024, 4, 8, 14, 30, 0, 0, time.UTC), Referee: "enc:v1:k:a", RefereeKey: "makkelie"},
+	} {
+		video.ProcessingState = "completed"
+		video.CreatedAt = created.Add(time.Duration(i) * time.Minute)
+		video.UpdatedAt = video.CreatedAt
+		require.NoError(t, repo.Create(video))
+	}
+	ids := func(videos []*models.Video) []string {
+		var ids []string
+		for _, video := range videos {
+			ids = append(ids, video.ID)
+		}
+		return ids
+	}
+
+	t.Run("Round trip", func(t *testing.T) {
+		video, err := repo.FindByID("v1")
+		require.NoError(t, err)
+		assert.Equal(t, "Ajax - PSV", video.Title)
+		assert.True(t, created.Equal(video.CreatedAt))
+		assert.False(t, video.DeletedAt.Valid)
+		assert.Equal(t, "Johan Cruijff ArenA", video.Venue)
+		assert.Equal(t, 54000, video.Attendance)
+		assert.Equal(t, "Rain, 9°C", video.Weather)
+		require.NotNil(t, video.KickoffAt)
+		assert.True(t, kickoff.Equal(*video.KickoffAt))
+		assert.Equal(t, "2024-04-01T22:00:00+02:00", video.KickoffAt.Format(time.RFC3339), "kickoff is read in the venue's zone")
+
+		video, err = repo.FindByID("v2")
+		require.NoError(t, err)
+		assert.Nil(t, video.KickoffAt)
+	})
+
+	t.Run("Queries filter and sort in time order", func(t *testing.T) {
+		videos, err := repo.FindByQuery(models.VideoQuery{Team: "PSV", Sort: models.VideoSortMatchDate, Order: models.SortAscending})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"v2", "v1"}, ids(videos))
+
+		videos, err = repo.FindByDateRange(time.Date(2024, 4, 1, 19, 30, 0, 0, time.UTC), time.Date(2024, 4, 8, 16, 30, 0, 0, amsterdam), 10, 0)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"v3", "v1"}, ids(videos))
+
+		videos, err = repo.FindAll(2, 0)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"v3", "v2"}, ids(videos))
+
+		videos, err = repo.FindByQuery(models.VideoQuery{Venue: "Johan Cruijff ArenA", Referee: "D. Makkelie"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"v1"}, ids(videos))
+
+		videos, err = repo.FindByQuery(models.VideoQuery{Referee: "D. Makkelie", RefereeKey: "makkelie"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"v3", "v2", "v1"}, ids(videos), "the blind index finds encrypted referees, the name plain ones")
+	})
+
+	t.Run("Access scopes limit queries to granted matches and teams", func(t *testing.T) {
+		videos, err := repo.FindByQuery(models.VideoQuery{Access: &models.AccessScope{MatchIDs: []string{"v3"}, Teams: []string{"psv"}}})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"v3", "v2", "v1"}, ids(videos))
+
+		videos, err = repo.FindByQuery(models.VideoQuery{Season: "2024", Access: &models.AccessScope{Teams: []string{"PSV"}}})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"v2"}, ids(videos), "scopes narrow the other filters")
+
+		videos, err = repo.FindByQuery(models.VideoQuery{Access: &models.AccessScope{}})
+		require.NoError(t, err)
+		assert.Empty(t, videos)
+	})
+
+	t.Run("Reference data is read from SQLite", func(t *testing.T) {
+		data, err := repos.ReferenceData.Load()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Eredivisie"}, data.Competitions)
+		assert.Equal(t, []string{"2024", "2023"}, data.Seasons)
+		assert.Equal(t, []string{"AZ", "Ajax", "PSV"}, data.Teams)
+		assert.Equal(t, []string{"Johan Cruijff ArenA"}, data.Venues)
+		assert.Equal(t, []string{"D. Makkelie", "enc:v1:k:a"}, data.Referees, "one stored value per blind index")
+	})
+
+	t.Run("Update and soft delete", func(t *testing.T) {
+		video, err := repo.FindByID("v3")
+		require.NoError(t, err)
+		state := video.ProcessingState
+		require.NoError(t, repo.UpdateProcessingState("v3", state, models.StateArchived, time.Now()))
+		assert.ErrorIs(t, repo.UpdateProcessingState("v3", state, models.StateArchived, time.Now()), models.ErrStateConflict,
+			"the state changes only from the expected state")
+		video.Title = "Renamed"
+		require.NoError(t, repo.Update(video), "a stale copy keeps the newer state")
+
+		videos, err := repo.FindByProcessingState("archived", 10, 0)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"v3"}, ids(videos))
+
+		require.NoError(t, repo.Delete("v3"))
+		_, err = repo.FindByID("v3")
+		assert.EqualError(t, err, "video not found")
+		assert.EqualError(t, repo.Delete("v3"), "video not found")
+
+		require.NoError(t, repo.Undelete("v3"))
+		_, err = repo.FindByID("v3")
+		assert.NoError(t, err)
+		assert.EqualError(t, repo.Undelete("v3"), "video not found", "only deleted videos can be undeleted")
+	})
+}
@@ -0,0 +1,149 @@
+package models_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"nivai/backend/pkg/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var videoColumns = []string{
+	"id", "title", "description", "file_path", "storage_provider",
+	"duration", "resolution", "format", "size", "processing_state",
+	"created_at", "updated_at", "deleted_at",
+	"match_id", "match_date", "home_team", "away_team", "competition", "season",
+	"tracking_path", "event_file_path",
+}
+
+func newVideoRepository(t *testing.T) (models.VideoRepository, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	repo, err := models.NewPostgresVideoRepository(db)
+	require.NoError(t, err)
+	return repo, mock
+}
+
+func TestPostgresVideoRepository(t *testing.T) {
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Rows with NULL match metadata are read as empty", func(t *testing.T) {
+		repo, mock := newVideoRepository(t)
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "videos" WHERE id = $1 AND "videos"."deleted_at" IS NULL LIMIT $2`)).
+			WithArgs("v1", 1).
+			WillReturnRows(sqlmock.NewRows(videoColumns).AddRow(
+				"v1", "Final", nil, "videos/v1/v1.mp4", "local",
+				nil, nil, "mp4", 1024, "completed",
+				created, created, nil,
+				nil, nil, nil, nil, nil, nil,
+				"videos/v1/v1_tracking.gzip", "videos/v1/v1_events.gzip",
+			))
+
+		video, err := repo.FindByID("v1")
+		require.NoError(t, err)
+		assert.Equal(t, "Final", video.Title)
+		assert.Empty(t, video.MatchID)
+		assert.Empty(t, video.HomeTeam)
+		assert.True(t, video.MatchDate.IsZero())
+		assert.Equal(t, "videos/v1/v1_events.gzip", video.EventFilePath)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Missing videos are reported as not found", func(t *testing.T) {
+		repo, mock := newVideoRepository(t)
+		mock.ExpectQuery(`SELECT \* FROM "videos"`).WillReturnRows(sqlmock.NewRows(videoColumns))
+		mock.ExpectExec(`UPDATE "videos" SET "deleted_at"=\$1 WHERE id = \$2 AND "videos"."deleted_at" IS NULL`).
+			WithArgs(sqlmock.AnyArg(), "v1").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		_, err := repo.FindByID("v1")
+		assert.EqualError(t, err, "video not found")
+		assert.EqualError(t, repo.Delete("v1"), "video not found")
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Every column is written, including the match context", func(t *testing.T) {
+		repo, mock := newVideoRepository(t)
+		video := &models.Video{ID: "v1", Title: "Final", ProcessingState: "pending", CreatedAt: created, UpdatedAt: created,
+			HomeTeam: "Ajax", EventFilePath: "videos/v1/v1_events.gzip"}
+
+		mock.ExpectExec(`INSERT INTO "videos" \("id","title",.*"tracking_path","event_file_path",.*"kickoff_at","kickoff_timezone"\)`).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`UPDATE "videos" SET "title"=\$1,.*"size"=\$8,"updated_at"=\$9,.*"event_file_path"=\$17,.*"referee"=\$19,"referee_key"=\$20,.*"kickoff_timezone"=\$24 WHERE id = \$25 AND "videos"."deleted_at" IS NULL`).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		require.NoError(t, repo.Create(video))
+		require.NoError(t, repo.Update(video), "Update leaves the processing state alone")
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Processing states change only from the expected state", func(t *testing.T) {
+		repo, mock := newVideoRepository(t)
+		at := created.Add(time.Minute)
+		update := regexp.QuoteMeta(`UPDATE "videos" SET "processing_state"=$1,"updated_at"=$2 WHERE (id = $3 AND processing_state = $4) AND "videos"."deleted_at" IS NULL`)
+		mock.ExpectExec(update).WithArgs("completed", at, "v1", "processing").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(update).WithArgs("completed", at, "v1", "processing").WillReturnResult(sqlmock.NewResult(0, 0))
+
+		require.NoError(t, repo.UpdateProcessingState("v1", models.StateProcessing, models.StateCompleted, at))
+		assert.ErrorIs(t, repo.UpdateProcessingState("v1", models.StateProcessing, models.StateCompleted, at), models.ErrStateConflict)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Team queries match either side and page the results", func(t *testing.T) {
+		repo, mock := newVideoRepository(t)
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "videos" WHERE (home_team = $1 OR away_team = $2) AND "videos"."deleted_at" IS NULL ORDER BY match_date DESC LIMIT $3 OFFSET $4`)).
+			WithArgs("Ajax", "Ajax", 10, 20).
+			WillReturnRows(sqlmock.NewRows(videoColumns).AddRow(
+				"v1", "Final", "", "", "", 0, "", "", 0, "completed", created, created, nil,
+				"m1", created, "Ajax", "PSV", "Eredivisie", "2024", "", "",
+			))
+
+		videos, err := repo.FindByTeam("Ajax", 0, 20)
+		require.NoError(t, err)
+		require.Len(t, videos, 1)
+		assert.Equal(t, "PSV", videos[0].AwayTeam)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Query filters are combined", func(t *testing.T) {
+		repo, mock := newVideoRepository(t)
+		from := time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC)
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "videos" WHERE (home_team = $1 OR away_team = $2) AND season = $3 AND processing_state = $4 AND match_date >= $5 AND "videos"."deleted_at" IS NULL ORDER BY "match_date","id" LIMIT $6`)).
+			WithArgs("Ajax", "Ajax", "2024", "completed", from, 25).
+			WillReturnRows(sqlmock.NewRows(videoColumns))
+
+		videos, err := repo.FindByQuery(models.VideoQuery{
+			Team: "Ajax", Season: "2024", ProcessingState: "completed", MatchDateFrom: from,
+			Sort: models.VideoSortMatchDate, Order: models.SortAscending, Limit: 25,
+		})
+		require.NoError(t, err)
+		assert.Empty(t, videos)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Unknown sort fields are rejected", func(t *testing.T) {
+		repo, _ := newVideoRepository(t)
+		_, err := repo.FindByQuery(models.VideoQuery{Sort: "size; DROP TABLE videos"})
+		assert.ErrorContains(t, err, "unknown sort field")
+		_, err = repo.FindByQuery(models.VideoQuery{Order: "sideways"})
+		assert.ErrorContains(t, err, "unknown sort order")
+	})
+
+	t.Run("Queries are cancelled with their context", func(t *testing.T) {
+		repo, mock := newVideoRepository(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := repo.WithContext(ctx).FindAll(10, 0)
+		assert.ErrorIs(t, err, context.Canceled)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,309 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Delivery status values for WebhookDelivery.Status
+const (
+	DeliveryStatusPending   = "pending"
+	DeliveryStatusSucceeded = "succeeded"
+	DeliveryStatusFailed    = "failed"
+)
+
+/**
+ * Webhook represents an integrator's subscription to platform events.
+ * Matching events are POSTed to URL and signed with Secret.
+ */
+type Webhook struct {
+	ID         string    `json:"id"`
+	URL        string    `json:"url"`
+	Secret     string    `json:"-"` // Never serialized; only returned once on creation
+	EventTypes []string  `json:"event_types"`
+	Active     bool      `json:"active"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+/**
+ * WebhookDelivery records a single event delivery to a webhook,
+ * including retry bookkeeping and the outcome of the last attempt.
+ */
+type WebhookDelivery struct {
+	ID             string          `json:"id"`
+	WebhookID      string          `json:"webhook_id"`
+	EventID        string          `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"` // "pending", "succeeded", "failed"
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	LastStatusCode int             `json:"last_status_code,omitempty"`
+	LastError      string          `json:"last_error,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+	DeliveredAt    sql.NullTime    `json:"delivered_at,omitempty"`
+}
+
+/**
+ * WebhookRepository defines data access for webhook subscriptions and deliveries.
+ */
+type WebhookRepository interface {
+	// Subscriptions
+	Create(webhook *Webhook) error
+	FindByID(id string) (*Webhook, error)
+	FindAll() ([]*Webhook, error)
+	FindActiveByEventType(eventType string) ([]*Webhook, error)
+	Delete(id string) error
+
+	// Deliveries
+	CreateDelivery(delivery *WebhookDelivery) error
+	UpdateDelivery(delivery *WebhookDelivery) error
+	FindDueDeliveries(now time.Time, limit int) ([]*WebhookDelivery, error)
+	FindDeliveriesByWebhook(webhookID string, limit, offset int) ([]*WebhookDelivery, error)
+	FindFailedDeliveries(since time.Time, limit int) ([]*WebhookDelivery, error)
+}
+
+/**
+ * PostgresWebhookRepository implements WebhookRepository using PostgreSQL.
+ */
+type PostgresWebhookRepository struct {
+	db *sql.DB
+}
+
+/**
+ * NewPostgresWebhookRepository creates a new PostgreSQL-backed webhook repository.
+ *
+ * @param db Database connection
+ * @return A new webhook repository
+ */
+func NewPostgresWebhookRepository(db *sql.DB) WebhookRepository {
+	return &PostgresWebhookRepository{db: db}
+}
+
+// Create inserts a new webhook subscription
+func (r *PostgresWebhookRepository) Create(webhook *Webhook) error {
+	query := `
+		INSERT INTO webhooks (id, url, secret, event_types, active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+	_, err := r.db.Exec(query,
+		webhook.ID, webhook.URL, webhook.Secret, pq.Array(webhook.EventTypes),
+		webhook.Active, webhook.CreatedAt, webhook.UpdatedAt,
+	)
+	return err
+}
+
+// FindByID retrieves a webhook subscription by ID
+func (r *PostgresWebhookRepository) FindByID(id string) (*Webhook, error) {
+	query := `
+		SELECT id, url, secret, event_types, active, created_at, updated_at
+		FROM webhooks
+		WHERE id = $1 AND deleted_at IS NULL
+	`
+
+	var webhook Webhook
+	err := r.db.QueryRow(query, id).Scan(
+		&webhook.ID, &webhook.URL, &webhook.Secret, pq.Array(&webhook.EventTypes),
+		&webhook.Active, &webhook.CreatedAt, &webhook.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("webhook not found")
+		}
+		return nil, err
+	}
+
+	return &webhook, nil
+}
+
+// FindAll retrieves all webhook subscriptions
+func (r *PostgresWebhookRepository) FindAll() ([]*Webhook, error) {
+	query := `
+		SELECT id, url, secret, event_types, active, created_at, updated_at
+		FROM webhooks
+		WHERE deleted_at IS NULL
+		ORDER BY created_at DESC
+	`
+	return r.queryWebhooks(query)
+}
+
+// FindActiveByEventType retrieves active webhooks subscribed to eventType or to all events
+func (r *PostgresWebhookRepository) FindActiveByEventType(eventType string) ([]*Webhook, error) {
+	query := `
+		SELECT id, url, secret, event_types, active, created_at, updated_at
+		FROM webhooks
+		WHERE active AND deleted_at IS NULL AND ($1 = ANY(event_types) OR '*' = ANY(event_types))
+		ORDER BY created_at
+	`
+	return r.queryWebhooks(query, eventType)
+}
+
+// Delete soft-deletes a webhook subscription
+func (r *PostgresWebhookRepository) Delete(id string) error {
+	query := `UPDATE webhooks SET deleted_at = $2, active = FALSE WHERE id = $1 AND deleted_at IS NULL`
+
+	result, err := r.db.Exec(query, id, time.Now())
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("webhook not found")
+	}
+
+	return nil
+}
+
+// CreateDelivery inserts a new delivery record
+func (r *PostgresWebhookRepository) CreateDelivery(delivery *WebhookDelivery) error {
+	query := `
+		INSERT INTO webhook_deliveries (id, webhook_id, event_id, event_type, payload, status,
+			attempts, next_attempt_at, last_status_code, last_error, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	`
+	_, err := r.db.Exec(query,
+		delivery.ID, delivery.WebhookID, delivery.EventID, delivery.EventType, []byte(delivery.Payload),
+		delivery.Status, delivery.Attempts, delivery.NextAttemptAt, delivery.LastStatusCode,
+		delivery.LastError, delivery.CreatedAt, delivery.UpdatedAt,
+	)
+	return err
+}
+
+// UpdateDelivery persists the outcome of a delivery attempt
+func (r *PostgresWebhookRepository) UpdateDelivery(delivery *WebhookDelivery) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = $2, attempts = $3, next_attempt_at = $4, last_status_code = $5,
+		    last_error = $6, updated_at = $7, delivered_at = $8
+		WHERE id = $1
+	`
+	result, err := r.db.Exec(query,
+		delivery.ID, delivery.Status, delivery.Attempts, delivery.NextAttemptAt,
+		delivery.LastStatusCode, delivery.LastError, time.Now(), delivery.DeliveredAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("webhook delivery not found")
+	}
+
+	return nil
+}
+
+// FindDueDeliveries retrieves pending deliveries whose next attempt is due
+func (r *PostgresWebhookRepository) FindDueDeliveries(now time.Time, limit int) ([]*WebhookDelivery, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+
+	query := `
+		SELECT id, webhook_id, event_id, event_type, payload, status, attempts, next_attempt_at,
+		       last_status_code, last_error, created_at, updated_at, delivered_at
+		FROM webhook_deliveries
+		WHERE status = $1 AND next_attempt_at <= $2
+		ORDER BY next_attempt_at
+		LIMIT $3
+	`
+	return r.queryDeliveries(query, DeliveryStatusPending, now, limit)
+}
+
+// FindDeliveriesByWebhook retrieves the delivery log for a webhook, newest first
+func (r *PostgresWebhookRepository) FindDeliveriesByWebhook(webhookID string, limit, offset int) ([]*WebhookDelivery, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+
+	query := `
+		SELECT id, webhook_id, event_id, event_type, payload, status, attempts, next_attempt_at,
+		       last_status_code, last_error, created_at, updated_at, delivered_at
+		FROM webhook_deliveries
+		WHERE webhook_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2 OFFSET $3
+	`
+	return r.queryDeliveries(query, webhookID, limit, offset)
+}
+
+// FindFailedDeliveries retrieves deliveries that gave up at or after since, newest first
+func (r *PostgresWebhookRepository) FindFailedDeliveries(since time.Time, limit int) ([]*WebhookDelivery, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+
+	query := `
+		SELECT id, webhook_id, event_id, event_type, payload, status, attempts, next_attempt_at,
+		       last_status_code, last_error, created_at, updated_at, delivered_at
+		FROM webhook_deliveries
+		WHERE status = $1 AND updated_at >= $2
+		ORDER BY updated_at DESC
+		LIMIT $3
+	`
+	return r.queryDeliveries(query, DeliveryStatusFailed, since, limit)
+}
+
+// queryWebhooks runs a query returning webhook rows
+func (r *PostgresWebhookRepository) queryWebhooks(query string, args ...interface{}) ([]*Webhook, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var webhooks []*Webhook
+	for rows.Next() {
+		var webhook Webhook
+		if err := rows.Scan(
+			&webhook.ID, &webhook.URL, &webhook.Secret, pq.Array(&webhook.EventTypes),
+			&webhook.Active, &webhook.CreatedAt, &webhook.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, &webhook)
+	}
+
+	return webhooks, rows.Err()
+}
+
+// queryDeliveries runs a query returning webhook delivery rows
+func (r *PostgresWebhookRepository) queryDeliveries(query string, args ...interface{}) ([]*WebhookDelivery, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []*WebhookDelivery
+	for rows.Next() {
+		var delivery WebhookDelivery
+		var payload []byte
+		if err := rows.Scan(
+			&delivery.ID, &delivery.WebhookID, &delivery.EventID, &delivery.EventType, &payload,
+			&delivery.Status, &delivery.Attempts, &delivery.NextAttemptAt, &delivery.LastStatusCode,
+			&delivery.LastError, &delivery.CreatedAt, &delivery.UpdatedAt, &delivery.DeliveredAt,
+		); err != nil {
+			return nil, err
+		}
+		delivery.Payload = payload
+		deliveries = append(deliveries, &delivery)
+	}
+
+	return deliveries, rows.Err()
+}
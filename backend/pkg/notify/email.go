@@ -0,0 +1,70 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SMTPChannel sends notifications as plain text email through an SMTP server.
+type SMTPChannel struct {
+	addr string
+	auth smtp.Auth
+	from string
+	// send is smtp.SendMail; replaced in tests
+	send func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewSMTPChannel creates an email channel sending through host:port as from.
+// Without a username the server is used without authentication; with one,
+// PLAIN authentication requires TLS, which the server must offer.
+func NewSMTPChannel(host string, port int, username, password, from string) *SMTPChannel {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &SMTPChannel{
+		addr: net.JoinHostPort(host, strconv.Itoa(port)),
+		auth: auth,
+		from: from,
+		send: smtp.SendMail,
+	}
+}
+
+// Name returns "email".
+func (c *SMTPChannel) Name() string { return ChannelEmail }
+
+// Send emails msg to the recipient's address.
+func (c *SMTPChannel) Send(ctx context.Context, to Recipient, msg Message) error {
+	if to.Email == "" {
+		return ErrNoAddress
+	}
+	if strings.ContainsAny(to.Email, "\r\n") {
+		return fmt.Errorf("invalid email address %q", to.Email)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return c.send(c.addr, c.auth, c.from, []string{to.Email}, c.compose(to.Email, msg))
+}
+
+// compose renders msg as an RFC 5322 message.
+func (c *SMTPChannel) compose(to string, msg Message) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", c.from)
+	fmt.Fprintf(&buf, "To: %s\r\n", to)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("\r\n")
+	buf.WriteString(strings.ReplaceAll(strings.ReplaceAll(msg.Body, "\r\n", "\n"), "\n", "\r\n"))
+	buf.WriteString("\r\n")
+	return buf.Bytes()
+}
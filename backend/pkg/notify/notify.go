@@ -0,0 +1,42 @@
+// Package notify delivers notifications to users through pluggable channels,
+// such as email and Slack, so coaches hear about their matches without
+// watching the dashboard.
+package notify
+
+import (
+	"context"
+	"errors"
+)
+
+// Channel names, as users select them in their preferences.
+const (
+	ChannelEmail = "email"
+	ChannelSlack = "slack"
+	ChannelInApp = "in_app" // The user's inbox in the dashboard
+)
+
+// ErrNoAddress is returned when a recipient has no address on a channel,
+// such as no email address for email.
+var ErrNoAddress = errors.New("recipient has no address on this channel")
+
+// Message is a single notification.
+type Message struct {
+	Kind    string // Notification kind, e.g. "processing_finished"
+	Subject string
+	Body    string // Plain text
+	VideoID string // Match the message is about, if any
+}
+
+// Recipient is a user and their addresses on the channels.
+type Recipient struct {
+	UserID          string
+	Email           string
+	SlackWebhookURL string
+}
+
+// Channel delivers messages over one medium. Send must only return nil once
+// the medium accepted the message.
+type Channel interface {
+	Name() string
+	Send(ctx context.Context, to Recipient, msg Message) error
+}
@@ -0,0 +1,56 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// SlackChannel posts notifications to the Slack incoming webhook each
+// recipient configured for their channel.
+type SlackChannel struct {
+	client *http.Client
+}
+
+// NewSlackChannel creates a Slack channel posting with client, or with
+// http.DefaultClient when client is nil.
+func NewSlackChannel(client *http.Client) *SlackChannel {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &SlackChannel{client: client}
+}
+
+// Name returns "slack".
+func (c *SlackChannel) Name() string { return ChannelSlack }
+
+// Send posts msg to the recipient's incoming webhook.
+func (c *SlackChannel) Send(ctx context.Context, to Recipient, msg Message) error {
+	if to.SlackWebhookURL == "" {
+		return ErrNoAddress
+	}
+	body, err := json.Marshal(map[string]string{"text": "*" + msg.Subject + "*\n" + msg.Body})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, to.SlackWebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("slack responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package notify_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"nivai/backend/pkg/notify"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlackChannel_Send(t *testing.T) {
+	msg := notify.Message{Kind: "processing_finished", Subject: "Analytics ready: Ajax - PSV", Body: "Open the match in the dashboard."}
+
+	t.Run("Messages are posted to the recipient's webhook", func(t *testing.T) {
+		var got map[string]string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+			w.Write([]byte("ok"))
+		}))
+		defer server.Close()
+
+		channel := notify.NewSlackChannel(server.Client())
+		require.NoError(t, channel.Send(context.Background(), notify.Recipient{UserID: "u1", SlackWebhookURL: server.URL}, msg))
+		assert.Equal(t, "*Analytics ready: Ajax - PSV*\nOpen the match in the dashboard.", got["text"])
+	})
+
+	t.Run("Rejected posts and missing webhooks fail", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "invalid_token", http.StatusForbidden)
+		}))
+		defer server.Close()
+
+		channel := notify.NewSlackChannel(server.Client())
+		err := channel.Send(context.Background(), notify.Recipient{UserID: "u1", SlackWebhookURL: server.URL}, msg)
+		assert.EqualError(t, err, "slack responded with status 403")
+		assert.ErrorIs(t, channel.Send(context.Background(), notify.Recipient{UserID: "u1"}, msg), notify.ErrNoAddress)
+	})
+}
@@ -0,0 +1,206 @@
+// gRPC interface of the Python analytics service, used by the Go API as an
+// alternative to the HTTP API when both run in the same cluster.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: analytics/v1/analytics.proto
+
+package analyticspb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	AnalyticsService_ProcessMatch_FullMethodName    = "/nivai.analytics.v1.AnalyticsService/ProcessMatch"
+	AnalyticsService_GetMatchStatus_FullMethodName  = "/nivai.analytics.v1.AnalyticsService/GetMatchStatus"
+	AnalyticsService_GetMatchSummary_FullMethodName = "/nivai.analytics.v1.AnalyticsService/GetMatchSummary"
+)
+
+// AnalyticsServiceClient is the client API for AnalyticsService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type AnalyticsServiceClient interface {
+	// Starts background processing of a match's tracking and event data.
+	ProcessMatch(ctx context.Context, in *ProcessMatchRequest, opts ...grpc.CallOption) (*ProcessMatchResponse, error)
+	// Returns the processing status of a match.
+	GetMatchStatus(ctx context.Context, in *GetMatchStatusRequest, opts ...grpc.CallOption) (*MatchStatus, error)
+	// Returns per-player and per-team summary statistics of a processed match.
+	GetMatchSummary(ctx context.Context, in *GetMatchSummaryRequest, opts ...grpc.CallOption) (*MatchSummary, error)
+}
+
+type analyticsServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAnalyticsServiceClient(cc grpc.ClientConnInterface) AnalyticsServiceClient {
+	return &analyticsServiceClient{cc}
+}
+
+func (c *analyticsServiceClient) ProcessMatch(ctx context.Context, in *ProcessMatchRequest, opts ...grpc.CallOption) (*ProcessMatchResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ProcessMatchResponse)
+	err := c.cc.Invoke(ctx, AnalyticsService_ProcessMatch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *analyticsServiceClient) GetMatchStatus(ctx context.Context, in *GetMatchStatusRequest, opts ...grpc.CallOption) (*MatchStatus, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MatchStatus)
+	err := c.cc.Invoke(ctx, AnalyticsService_GetMatchStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *analyticsServiceClient) GetMatchSummary(ctx context.Context, in *GetMatchSummaryRequest, opts ...grpc.CallOption) (*MatchSummary, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MatchSummary)
+	err := c.cc.Invoke(ctx, AnalyticsService_GetMatchSummary_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AnalyticsServiceServer is the server API for AnalyticsService service.
+// All implementations must embed UnimplementedAnalyticsServiceServer
+// for forward compatibility.
+type AnalyticsServiceServer interface {
+	// Starts background processing of a match's tracking and event data.
+	ProcessMatch(context.Context, *ProcessMatchRequest) (*ProcessMatchResponse, error)
+	// Returns the processing status of a match.
+	GetMatchStatus(context.Context, *GetMatchStatusRequest) (*MatchStatus, error)
+	// Returns per-player and per-team summary statistics of a processed match.
+	GetMatchSummary(context.Context, *GetMatchSummaryRequest) (*MatchSummary, error)
+	mustEmbedUnimplementedAnalyticsServiceServer()
+}
+
+// UnimplementedAnalyticsServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedAnalyticsServiceServer struct{}
+
+func (UnimplementedAnalyticsServiceServer) ProcessMatch(context.Context, *ProcessMatchRequest) (*ProcessMatchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ProcessMatch not implemented")
+}
+func (UnimplementedAnalyticsServiceServer) GetMatchStatus(context.Context, *GetMatchStatusRequest) (*MatchStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetMatchStatus not implemented")
+}
+func (UnimplementedAnalyticsServiceServer) GetMatchSummary(context.Context, *GetMatchSummaryRequest) (*MatchSummary, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetMatchSummary not implemented")
+}
+func (UnimplementedAnalyticsServiceServer) mustEmbedUnimplementedAnalyticsServiceServer() {}
+func (UnimplementedAnalyticsServiceServer) testEmbeddedByValue()                          {}
+
+// UnsafeAnalyticsServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AnalyticsServiceServer will
+// result in compilation errors.
+type UnsafeAnalyticsServiceServer interface {
+	mustEmbedUnimplementedAnalyticsServiceServer()
+}
+
+func RegisterAnalyticsServiceServer(s grpc.ServiceRegistrar, srv AnalyticsServiceServer) {
+	// If the following call pancis, it indicates UnimplementedAnalyticsServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&AnalyticsService_ServiceDesc, srv)
+}
+
+func _AnalyticsService_ProcessMatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ProcessMatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AnalyticsServiceServer).ProcessMatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AnalyticsService_ProcessMatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AnalyticsServiceServer).ProcessMatch(ctx, req.(*ProcessMatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AnalyticsService_GetMatchStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetMatchStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AnalyticsServiceServer).GetMatchStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AnalyticsService_GetMatchStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AnalyticsServiceServer).GetMatchStatus(ctx, req.(*GetMatchStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AnalyticsService_GetMatchSummary_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetMatchSummaryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AnalyticsServiceServer).GetMatchSummary(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AnalyticsService_GetMatchSummary_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AnalyticsServiceServer).GetMatchSummary(ctx, req.(*GetMatchSummaryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// AnalyticsService_ServiceDesc is the grpc.ServiceDesc for AnalyticsService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var AnalyticsService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "nivai.analytics.v1.AnalyticsService",
+	HandlerType: (*AnalyticsServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ProcessMatch",
+			Handler:    _AnalyticsService_ProcessMatch_Handler,
+		},
+		{
+			MethodName: "GetMatchStatus",
+			Handler:    _AnalyticsService_GetMatchStatus_Handler,
+		},
+		{
+			MethodName: "GetMatchSummary",
+			Handler:    _AnalyticsService_GetMatchSummary_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "analytics/v1/analytics.proto",
+}
@@ -0,0 +1,383 @@
+// Package pythonapi is a typed client for the Python analytics service.
+package pythonapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"nivai/backend/pkg/debuglog"
+)
+
+// DefaultBaseURL is used when no base URL is configured.
+const DefaultBaseURL = "http://localhost:8081"
+
+// maxErrorBody bounds how much of an error response is read.
+const maxErrorBody = 64 * 1024
+
+// DefaultMaxResponseSize bounds successful response bodies unless
+// WithMaxResponseSize sets another limit.
+const DefaultMaxResponseSize = 64 << 20
+
+// Transport carries the core match calls over an alternative protocol.
+// Implementations must return errors compatible with the HTTP client:
+// *APIError for service errors and ErrUnavailable for connection failures.
+type Transport interface {
+	ProcessMatch(ctx context.Context, req ProcessMatchRequest) (*ProcessMatchResponse, error)
+	GetMatchStatus(ctx context.Context, matchID string) (*MatchStatus, error)
+	GetMatchSummary(ctx context.Context, matchID string) (*MatchSummary, error)
+}
+
+// Client calls the Python analytics API. It is safe for concurrent use.
+type Client struct {
+	workers    atomic.Pointer[[]*worker] // Replaced by SetWorkers on configuration reloads
+	httpClient *http.Client
+	transport  Transport // Optional; the core match calls use HTTP when nil
+	maxBody    int64     // Largest response body decoded
+	contracts  ContractMode
+	service    atomic.Pointer[string] // Schema version the service last answered with
+	logf       func(format string, args ...interface{})
+
+	routing       Routing
+	next          atomic.Uint64 // Turn of the routing strategies
+	assigned      assignments
+	checkInterval time.Duration
+}
+
+// ContractMode states what the client does with payloads that break their
+// contract.
+type ContractMode string
+
+// Contract modes
+const (
+	ContractsEnforce ContractMode = "enforce" // Refuse the payload with a ContractError
+	ContractsLog     ContractMode = "log"     // Log the ContractError and carry on
+	ContractsOff     ContractMode = "off"     // Do not validate payloads
+)
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithTransport routes ProcessMatch, GetMatchStatus and GetMatchSummary
+// through t, e.g. a GRPCTransport. Player and team details, which have no
+// transport equivalent, keep using HTTP.
+func WithTransport(t Transport) Option {
+	return func(c *Client) { c.transport = t }
+}
+
+// WithMaxResponseSize rejects response bodies larger than n bytes with
+// ErrInvalidResponse instead of decoding them; n <= 0 keeps the default.
+// Bodies are decoded as they are read, so the limit bounds the memory one
+// call holds. Relayed bodies are not held at all; they are cut at the limit.
+func WithMaxResponseSize(n int64) Option {
+	return func(c *Client) {
+		if n > 0 {
+			c.maxBody = n
+		}
+	}
+}
+
+// WithDebugLog logs calls sampled by sampler, with their request and response
+// bodies, to diagnose contract mismatches. Calls over a Transport are not
+// logged.
+func WithDebugLog(sampler *debuglog.Sampler) Option {
+	return func(c *Client) {
+		client := *c.httpClient
+		client.Transport = sampler.Transport(client.Transport)
+		c.httpClient = &client
+	}
+}
+
+// WithContractValidation sets what happens to payloads breaking their JSON
+// schema; ContractsEnforce is the default. ContractsLog helps roll out a
+// service version whose payloads may not match yet.
+func WithContractValidation(mode ContractMode) Option {
+	return func(c *Client) {
+		if mode != "" {
+			c.contracts = mode
+		}
+	}
+}
+
+// NewClient creates a client for the service at baseURL (DefaultBaseURL if empty).
+// If httpClient is nil, a client with a 10-second timeout is used.
+func NewClient(baseURL string, httpClient *http.Client, opts ...Option) *Client {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	c := &Client{httpClient: httpClient, maxBody: DefaultMaxResponseSize, contracts: ContractsEnforce, logf: log.Printf,
+		routing: RoundRobin, checkInterval: DefaultHealthCheckInterval}
+	c.SetBaseURL(baseURL)
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// BaseURL returns the service URL the client talks to; with several
+// workers, the first one's.
+func (c *Client) BaseURL() string {
+	return (*c.workers.Load())[0].url
+}
+
+// SetBaseURL points the client at a single other service URL
+// (DefaultBaseURL if empty). Calls already sent finish against the old one.
+func (c *Client) SetBaseURL(baseURL string) {
+	c.SetWorkers([]string{baseURL})
+}
+
+// Ping checks that the service answers over HTTP.
+func (c *Client) Ping(ctx context.Context) error {
+	var resp json.RawMessage
+	return c.do(ctx, http.MethodGet, "/", nil, &resp, call{})
+}
+
+// ProcessMatch starts background processing of a match's tracking and event data.
+func (c *Client) ProcessMatch(ctx context.Context, req ProcessMatchRequest) (*ProcessMatchResponse, error) {
+	if c.transport != nil {
+		return c.transport.ProcessMatch(ctx, req)
+	}
+	var resp ProcessMatchResponse
+	if err := c.do(ctx, http.MethodPost, "/process-match", req, &resp, call{matchID: req.MatchID, assign: true, request: ContractProcessMatchRequest, response: ContractProcessMatchResponse}); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GetMatchStatus returns the processing status of a match.
+func (c *Client) GetMatchStatus(ctx context.Context, matchID string) (*MatchStatus, error) {
+	if c.transport != nil {
+		return c.transport.GetMatchStatus(ctx, matchID)
+	}
+	var resp MatchStatus
+	if err := c.do(ctx, http.MethodGet, "/match/"+url.PathEscape(matchID)+"/status", nil, &resp, call{matchID: matchID, response: ContractMatchStatus}); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GetMatchSummary returns summary statistics for a processed match.
+func (c *Client) GetMatchSummary(ctx context.Context, matchID string) (*MatchSummary, error) {
+	if c.transport != nil {
+		return c.transport.GetMatchSummary(ctx, matchID)
+	}
+	var resp MatchSummary
+	if err := c.do(ctx, http.MethodGet, "/match/"+url.PathEscape(matchID)+"/stats/summary", nil, &resp, call{matchID: matchID, response: ContractMatchSummary}); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// CancelMatch asks the service to abort processing a match. A match that
+// already finished yields an *APIError with status 409.
+func (c *Client) CancelMatch(ctx context.Context, matchID string) error {
+	var resp ProcessMatchResponse
+	return c.do(ctx, http.MethodPost, "/match/"+url.PathEscape(matchID)+"/cancel", nil, &resp, call{matchID: matchID, response: ContractProcessMatchResponse})
+}
+
+// GetPlayerDetails returns time-series data for a player in a processed match.
+func (c *Client) GetPlayerDetails(ctx context.Context, matchID, playerID string) (*PlayerDetails, error) {
+	path := "/match/" + url.PathEscape(matchID) + "/player/" + url.PathEscape(playerID) + "/details"
+	var resp PlayerDetails
+	if err := c.do(ctx, http.MethodGet, path, nil, &resp, call{matchID: matchID, response: ContractPlayerDetails}); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// RelayPlayerDetails streams a player's time-series data in a processed
+// match to w, without decoding it: the series holds a value per frame, too
+// much to buffer for each request. Errors before anything is written are
+// those of GetPlayerDetails; once the response is started they wrap
+// ErrRelayInterrupted.
+func (c *Client) RelayPlayerDetails(ctx context.Context, w http.ResponseWriter, matchID, playerID string) error {
+	path := "/match/" + url.PathEscape(matchID) + "/player/" + url.PathEscape(playerID) + "/details"
+	return c.relay(ctx, w, path, call{matchID: matchID})
+}
+
+// GetTeamSummaryOverTime returns interval statistics for a team in a processed match.
+func (c *Client) GetTeamSummaryOverTime(ctx context.Context, matchID, teamID string) (*TeamSummaryOverTime, error) {
+	path := "/match/" + url.PathEscape(matchID) + "/team/" + url.PathEscape(teamID) + "/summary-over-time"
+	var resp TeamSummaryOverTime
+	if err := c.do(ctx, http.MethodGet, path, nil, &resp, call{matchID: matchID, response: ContractTeamSummaryOverTime}); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ServiceSchemaVersion returns the contract version the service last
+// answered with, or "" when it has not answered yet or does not negotiate.
+func (c *Client) ServiceSchemaVersion() string {
+	if version := c.service.Load(); version != nil {
+		return *version
+	}
+	return ""
+}
+
+// call describes a call beyond its HTTP request: the match it routes by,
+// whether it assigns the match to a worker, and the contracts of its request
+// and response body. Empty contract names are not validated.
+type call struct {
+	matchID           string
+	assign            bool
+	request, response string
+}
+
+// do sends a request with an optional JSON body to the worker routed to and
+// decodes a JSON response into out, validating both against their contracts.
+func (c *Client) do(ctx context.Context, method, path string, body, out interface{}, spec call) error {
+	var encoded []byte
+	if body != nil {
+		var err error
+		if encoded, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("python api: failed to encode request: %w", err)
+		}
+		if err := c.checkContract(SchemaVersion, spec.request, encoded); err != nil {
+			return fmt.Errorf("%w: %s %s: %w", ErrInvalidRequest, method, path, err)
+		}
+	}
+
+	return c.send(ctx, method, path, encoded, spec, func(resp *http.Response, version string) error {
+		if resp.ContentLength > c.maxBody {
+			return fmt.Errorf("%w: %s %s: response of %d bytes exceeds the limit of %d", ErrInvalidResponse, method, path, resp.ContentLength, c.maxBody)
+		}
+		limited := &io.LimitedReader{R: resp.Body, N: c.maxBody + 1}
+		var raw json.RawMessage
+		err := json.NewDecoder(limited).Decode(&raw)
+		if limited.N == 0 {
+			return fmt.Errorf("%w: %s %s: response exceeds the limit of %d bytes", ErrInvalidResponse, method, path, c.maxBody)
+		}
+		if err == nil {
+			if err := c.checkContract(version, spec.response, raw); err != nil {
+				return fmt.Errorf("%w: %s %s: %w", ErrInvalidResponse, method, path, err)
+			}
+			err = json.Unmarshal(raw, out)
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %s %s: %w", ErrInvalidResponse, method, path, err)
+		}
+		return nil
+	})
+}
+
+// send sends a request with an optional JSON body to the worker routed to
+// and hands a successful response to read, with the schema version the
+// service answered with. Error statuses and unsupported versions are
+// returned as errors without calling read.
+func (c *Client) send(ctx context.Context, method, path string, body []byte, spec call,
+	read func(resp *http.Response, version string) error) error {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	w := c.route(ctx, spec.matchID, spec.assign)
+	w.inFlight.Add(1)
+	defer w.inFlight.Add(-1)
+
+	req, err := http.NewRequestWithContext(ctx, method, w.url+path, reader)
+	if err != nil {
+		return fmt.Errorf("python api: failed to build request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set(SchemaVersionHeader, SchemaVersion)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		if len(*c.workers.Load()) > 1 && ctx.Err() == nil {
+			c.check(context.Background(), w)
+		}
+		return fmt.Errorf("%w: %s %s: %w", ErrUnavailable, method, path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		errBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return newAPIError(resp.StatusCode, errBody)
+	}
+
+	version := SchemaVersion
+	if answered := resp.Header.Get(SchemaVersionHeader); answered != "" {
+		c.service.Store(&answered)
+		if !slices.Contains(SupportedSchemaVersions(), answered) {
+			return fmt.Errorf("%w: %s %s: service answered with schema version %q, client supports %s",
+				ErrInvalidResponse, method, path, answered, strings.Join(SupportedSchemaVersions(), ", "))
+		}
+		version = answered
+	}
+	return read(resp, version)
+}
+
+// relay streams the successful response of a GET to w as it arrives, with
+// its status and content headers, flushing each chunk. Nothing is written
+// when the call fails or announces a body over the size limit; a body
+// exceeding the limit while it is copied is cut, and ErrRelayInterrupted
+// returned. The body is not validated against its contract.
+func (c *Client) relay(ctx context.Context, w http.ResponseWriter, path string, spec call) error {
+	return c.send(ctx, http.MethodGet, path, nil, spec, func(resp *http.Response, _ string) error {
+		if resp.ContentLength > c.maxBody {
+			return fmt.Errorf("%w: GET %s: response of %d bytes exceeds the limit of %d", ErrInvalidResponse, path, resp.ContentLength, c.maxBody)
+		}
+		for _, name := range []string{"Content-Type", "Content-Encoding", SchemaVersionHeader} {
+			if value := resp.Header.Get(name); value != "" {
+				w.Header().Set(name, value)
+			}
+		}
+		if resp.ContentLength >= 0 {
+			w.Header().Set("Content-Length", strconv.FormatInt(resp.ContentLength, 10))
+		}
+		w.WriteHeader(resp.StatusCode)
+
+		limited := &io.LimitedReader{R: resp.Body, N: c.maxBody + 1}
+		_, err := io.Copy(flushWriter{w: w, rc: http.NewResponseController(w)}, limited)
+		if limited.N == 0 {
+			return fmt.Errorf("%w: GET %s: response exceeds the limit of %d bytes", ErrRelayInterrupted, path, c.maxBody)
+		}
+		if err != nil {
+			return fmt.Errorf("%w: GET %s: %w", ErrRelayInterrupted, path, err)
+		}
+		return nil
+	})
+}
+
+// flushWriter flushes each write through to the client, so relayed bodies
+// are not held back by buffering.
+type flushWriter struct {
+	w  io.Writer
+	rc *http.ResponseController
+}
+
+func (f flushWriter) Write(p []byte) (int, error) {
+	n, err := f.w.Write(p)
+	if err == nil {
+		f.rc.Flush() // Writers that cannot flush still get the bytes
+	}
+	return n, err
+}
+
+// checkContract validates a payload as the contract mode says, returning
+// the violation only when contracts are enforced.
+func (c *Client) checkContract(version, contract string, payload []byte) error {
+	if contract == "" || c.contracts == ContractsOff {
+		return nil
+	}
+	err := ValidateContract(version, contract, payload)
+	if err != nil && c.contracts == ContractsLog {
+		c.logf("Warning: Python API contract violation: %v", err)
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,258 @@
+package pythonapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"nivai/backend/pkg/pythonapi"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_ProcessMatch(t *testing.T) {
+	var gotMethod, gotPath string
+	var gotBody map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod, gotPath = r.Method, r.URL.Path
+		_ = json.NewDecoder(r.Body).Decode(&gotBody)
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(`{"message":"Match processing started in background.","match_id":"m1"}`))
+	}))
+	defer server.Close()
+
+	client := pythonapi.NewClient(server.URL, server.Client())
+	resp, err := client.ProcessMatch(context.Background(), pythonapi.ProcessMatchRequest{
+		TrackingDataPath: "/data/m1_tracking.gzip",
+		EventDataPath:    "/data/m1_events.gzip",
+		MatchID:          "m1",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, http.MethodPost, gotMethod)
+	assert.Equal(t, "/process-match", gotPath)
+	assert.Equal(t, map[string]string{
+		"tracking_data_path": "/data/m1_tracking.gzip",
+		"event_data_path":    "/data/m1_events.gzip",
+		"match_id":           "m1",
+	}, gotBody)
+	assert.Equal(t, "m1", resp.MatchID)
+}
+
+func TestClient_Ping(t *testing.T) {
+	var gotPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		w.Write([]byte(`{"message":"AIFAA Python API"}`))
+	}))
+	defer server.Close()
+
+	require.NoError(t, pythonapi.NewClient(server.URL, server.Client()).Ping(context.Background()))
+	assert.Equal(t, "/", gotPath)
+
+	server.Close()
+	assert.ErrorIs(t, pythonapi.NewClient(server.URL, server.Client()).Ping(context.Background()), pythonapi.ErrUnavailable)
+}
+
+func TestClient_GetMatchStatus(t *testing.T) {
+	t.Run("Decodes status and escapes the match ID", func(t *testing.T) {
+		var gotPath string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			gotPath = r.URL.EscapedPath()
+			w.Write([]byte(`{"status":"processed","match_id":"a/b"}`))
+		}))
+		defer server.Close()
+
+		status, err := pythonapi.NewClient(server.URL+"/", server.Client()).GetMatchStatus(context.Background(), "a/b")
+		require.NoError(t, err)
+		assert.Equal(t, "/match/a%2Fb/status", gotPath)
+		assert.Equal(t, pythonapi.StatusProcessed, status.Status)
+	})
+
+	t.Run("404 is an APIError matching ErrNotFound", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"detail":"Match ID not found."}`))
+		}))
+		defer server.Close()
+
+		_, err := pythonapi.NewClient(server.URL, server.Client()).GetMatchStatus(context.Background(), "missing")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, pythonapi.ErrNotFound)
+
+		var apiErr *pythonapi.APIError
+		require.True(t, errors.As(err, &apiErr))
+		assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+		assert.Equal(t, "Match ID not found.", apiErr.Detail)
+	})
+
+	t.Run("Server errors do not match ErrNotFound", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "boom", http.StatusInternalServerError)
+		}))
+		defer server.Close()
+
+		_, err := pythonapi.NewClient(server.URL, server.Client()).GetMatchStatus(context.Background(), "m1")
+		assert.NotErrorIs(t, err, pythonapi.ErrNotFound)
+		assert.ErrorContains(t, err, "boom")
+	})
+
+	t.Run("Invalid JSON is ErrInvalidResponse", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`not json`))
+		}))
+		defer server.Close()
+
+		_, err := pythonapi.NewClient(server.URL, server.Client()).GetMatchStatus(context.Background(), "m1")
+		assert.ErrorIs(t, err, pythonapi.ErrInvalidResponse)
+	})
+
+	t.Run("Unreachable service is ErrUnavailable", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		server.Close()
+
+		_, err := pythonapi.NewClient(server.URL, nil).GetMatchStatus(context.Background(), "m1")
+		assert.ErrorIs(t, err, pythonapi.ErrUnavailable)
+	})
+
+	t.Run("Context cancellation aborts the request", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-r.Context().Done()
+		}))
+		defer server.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, err := pythonapi.NewClient(server.URL, server.Client()).GetMatchStatus(ctx, "m1")
+		assert.ErrorIs(t, err, pythonapi.ErrUnavailable)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
+func TestClient_CancelMatch(t *testing.T) {
+	var gotMethod, gotPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod, gotPath = r.Method, r.URL.Path
+		if strings.Contains(r.URL.Path, "done") {
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(`{"detail":"Match processing already finished with status 'processed'."}`))
+			return
+		}
+		w.Write([]byte(`{"message":"Match processing cancelled.","match_id":"m1"}`))
+	}))
+	defer server.Close()
+
+	client := pythonapi.NewClient(server.URL, server.Client())
+	require.NoError(t, client.CancelMatch(context.Background(), "m1"))
+	assert.Equal(t, http.MethodPost, gotMethod)
+	assert.Equal(t, "/match/m1/cancel", gotPath)
+
+	var apiErr *pythonapi.APIError
+	require.ErrorAs(t, client.CancelMatch(context.Background(), "done"), &apiErr)
+	assert.Equal(t, http.StatusConflict, apiErr.StatusCode)
+}
+
+func TestClient_GetTeamSummaryOverTime(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/match/m1/team/home/summary-over-time", r.URL.Path)
+		w.Write([]byte(`{"match_id":"m1","team_id":"home","intervals":[{"interval":"0-5"}]}`))
+	}))
+	defer server.Close()
+
+	summary, err := pythonapi.NewClient(server.URL, server.Client()).GetTeamSummaryOverTime(context.Background(), "m1", "home")
+	require.NoError(t, err)
+	assert.Equal(t, "home", summary.TeamID)
+	assert.JSONEq(t, `[{"interval":"0-5"}]`, string(summary.Intervals))
+}
+
+func TestClient_MaxResponseSize(t *testing.T) {
+	body := `{"match_id":"m1","player_id":"7","time_series":[` + strings.Repeat(`{"frame":1},`, 200) + `{"frame":2}]}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "/player/chunked/") {
+			w.(http.Flusher).Flush() // No Content-Length
+		}
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	t.Run("within the limit", func(t *testing.T) {
+		client := pythonapi.NewClient(server.URL, server.Client(), pythonapi.WithMaxResponseSize(int64(len(body))))
+		details, err := client.GetPlayerDetails(context.Background(), "m1", "7")
+		require.NoError(t, err)
+		assert.Equal(t, "7", details.PlayerID)
+	})
+
+	t.Run("over the limit by Content-Length", func(t *testing.T) {
+		client := pythonapi.NewClient(server.URL, server.Client(), pythonapi.WithMaxResponseSize(1024))
+		_, err := client.GetPlayerDetails(context.Background(), "m1", "7")
+		assert.ErrorIs(t, err, pythonapi.ErrInvalidResponse)
+		assert.Contains(t, err.Error(), "exceeds the limit")
+	})
+
+	t.Run("over the limit while streaming", func(t *testing.T) {
+		client := pythonapi.NewClient(server.URL, server.Client(), pythonapi.WithMaxResponseSize(1024))
+		_, err := client.GetPlayerDetails(context.Background(), "m1", "chunked")
+		assert.ErrorIs(t, err, pythonapi.ErrInvalidResponse)
+		assert.Contains(t, err.Error(), "exceeds the limit")
+	})
+}
+
+func TestClient_RelayPlayerDetails(t *testing.T) {
+	body := `{"match_id":"m1","player_id":"7","time_series":[` + strings.Repeat(`{"frame":1},`, 200) + `{"frame":2}]}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.Contains(r.URL.Path, "/player/missing/"):
+			http.Error(w, `{"detail":"Player ID missing not found in this match."}`, http.StatusNotFound)
+			return
+		case strings.Contains(r.URL.Path, "/player/chunked/"):
+			w.(http.Flusher).Flush() // No Content-Length
+		default:
+			w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	t.Run("Bodies are copied as they are", func(t *testing.T) {
+		client := pythonapi.NewClient(server.URL, server.Client())
+		rec := httptest.NewRecorder()
+		require.NoError(t, client.RelayPlayerDetails(context.Background(), rec, "m1", "7"))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+		assert.Equal(t, strconv.Itoa(len(body)), rec.Header().Get("Content-Length"))
+		assert.Equal(t, body, rec.Body.String())
+		assert.True(t, rec.Flushed)
+	})
+
+	t.Run("Failed calls write nothing", func(t *testing.T) {
+		client := pythonapi.NewClient(server.URL, server.Client())
+		rec := httptest.NewRecorder()
+		err := client.RelayPlayerDetails(context.Background(), rec, "m1", "missing")
+		assert.ErrorIs(t, err, pythonapi.ErrNotFound)
+		assert.False(t, rec.Flushed)
+		assert.Empty(t, rec.Body.String())
+
+		client = pythonapi.NewClient(server.URL, server.Client(), pythonapi.WithMaxResponseSize(1024))
+		rec = httptest.NewRecorder()
+		err = client.RelayPlayerDetails(context.Background(), rec, "m1", "7")
+		assert.ErrorIs(t, err, pythonapi.ErrInvalidResponse, "announced over the limit")
+		assert.NotErrorIs(t, err, pythonapi.ErrRelayInterrupted)
+		assert.Empty(t, rec.Body.String())
+	})
+
+	t.Run("Bodies over the limit are cut", func(t *testing.T) {
+		client := pythonapi.NewClient(server.URL, server.Client(), pythonapi.WithMaxResponseSize(1024))
+		rec := httptest.NewRecorder()
+		err := client.RelayPlayerDetails(context.Background(), rec, "m1", "chunked")
+		assert.ErrorIs(t, err, pythonapi.ErrRelayInterrupted)
+		assert.LessOrEqual(t, rec.Body.Len(), 1025)
+	})
+}
@@ -0,0 +1,56 @@
+package pythonapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrNotFound matches APIErrors with status 404 (unknown or unprocessed match).
+	ErrNotFound = errors.New("python api: not found")
+
+	// ErrUnavailable wraps transport failures: the service could not be reached
+	// or did not answer in time.
+	ErrUnavailable = errors.New("python api: unavailable")
+
+	// ErrInvalidResponse wraps responses that could not be decoded.
+	ErrInvalidResponse = errors.New("python api: invalid response")
+
+	// ErrRelayInterrupted wraps failures of a relay after the response was
+	// started, so only part of the body reached the client.
+	ErrRelayInterrupted = errors.New("python api: relay interrupted")
+)
+
+// APIError is returned when the Python API answers with a non-2xx status.
+type APIError struct {
+	StatusCode int
+	Detail     string // FastAPI "detail" message, or the raw body
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("python api: status %d: %s", e.StatusCode, e.Detail)
+}
+
+// Is makes errors.Is(err, ErrNotFound) true for 404 responses.
+func (e *APIError) Is(target error) bool {
+	return target == ErrNotFound && e.StatusCode == 404
+}
+
+// newAPIError builds an APIError from a response body, extracting FastAPI's
+// {"detail": ...} when present.
+func newAPIError(statusCode int, body []byte) *APIError {
+	var payload struct {
+		Detail json.RawMessage `json:"detail"`
+	}
+	detail := string(body)
+	if err := json.Unmarshal(body, &payload); err == nil && len(payload.Detail) > 0 {
+		var message string
+		if err := json.Unmarshal(payload.Detail, &message); err == nil {
+			detail = message
+		} else {
+			detail = string(payload.Detail) // e.g. validation error lists
+		}
+	}
+	return &APIError{StatusCode: statusCode, Detail: detail}
+}
@@ -0,0 +1,134 @@
+package pythonapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"nivai/backend/pkg/pythonapi/analyticspb"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// GRPCTransport calls the Python service's AnalyticsService over gRPC
+// (proto/analytics/v1/analytics.proto). It is meant for deployments where
+// both services run in the same cluster.
+type GRPCTransport struct {
+	client analyticspb.AnalyticsServiceClient
+	conn   *grpc.ClientConn // Owned connection, nil if supplied by the caller
+}
+
+// Compile-time check that GRPCTransport is a Transport.
+var _ Transport = (*GRPCTransport)(nil)
+
+// DialGRPC creates a transport for the service at target (host:port).
+// Connections are established lazily and use plaintext, as traffic stays
+// inside the cluster network.
+func DialGRPC(target string, opts ...grpc.DialOption) (*GRPCTransport, error) {
+	opts = append([]grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}, opts...)
+	conn, err := grpc.NewClient(target, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("python api: invalid gRPC target %q: %w", target, err)
+	}
+	return &GRPCTransport{client: analyticspb.NewAnalyticsServiceClient(conn), conn: conn}, nil
+}
+
+// NewGRPCTransport creates a transport over an existing connection.
+func NewGRPCTransport(cc grpc.ClientConnInterface) *GRPCTransport {
+	return &GRPCTransport{client: analyticspb.NewAnalyticsServiceClient(cc)}
+}
+
+// Close closes the connection if the transport created it.
+func (t *GRPCTransport) Close() error {
+	if t.conn == nil {
+		return nil
+	}
+	return t.conn.Close()
+}
+
+// ProcessMatch starts background processing of a match.
+func (t *GRPCTransport) ProcessMatch(ctx context.Context, req ProcessMatchRequest) (*ProcessMatchResponse, error) {
+	resp, err := t.client.ProcessMatch(ctx, &analyticspb.ProcessMatchRequest{
+		TrackingDataPath: req.TrackingDataPath,
+		EventDataPath:    req.EventDataPath,
+		MatchId:          req.MatchID,
+		Priority:         req.Priority,
+	})
+	if err != nil {
+		return nil, fromGRPCError("ProcessMatch", err)
+	}
+	return &ProcessMatchResponse{Message: resp.GetMessage(), MatchID: resp.GetMatchId()}, nil
+}
+
+// GetMatchStatus returns the processing status of a match.
+func (t *GRPCTransport) GetMatchStatus(ctx context.Context, matchID string) (*MatchStatus, error) {
+	resp, err := t.client.GetMatchStatus(ctx, &analyticspb.GetMatchStatusRequest{MatchId: matchID})
+	if err != nil {
+		return nil, fromGRPCError("GetMatchStatus", err)
+	}
+	return &MatchStatus{Status: resp.GetStatus(), MatchID: resp.GetMatchId(), Message: resp.GetMessage()}, nil
+}
+
+// GetMatchSummary returns summary statistics for a processed match.
+func (t *GRPCTransport) GetMatchSummary(ctx context.Context, matchID string) (*MatchSummary, error) {
+	resp, err := t.client.GetMatchSummary(ctx, &analyticspb.GetMatchSummaryRequest{MatchId: matchID})
+	if err != nil {
+		return nil, fromGRPCError("GetMatchSummary", err)
+	}
+
+	summary := &MatchSummary{MatchID: resp.GetMatchId()}
+	if summary.Players, err = rawJSON(resp.GetPlayersJson()); err != nil {
+		return nil, fmt.Errorf("%w: players: %w", ErrInvalidResponse, err)
+	}
+	if summary.Teams, err = rawJSON(resp.GetTeamsJson()); err != nil {
+		return nil, fmt.Errorf("%w: teams: %w", ErrInvalidResponse, err)
+	}
+	return summary, nil
+}
+
+// rawJSON validates an embedded JSON document; empty documents become null.
+func rawJSON(data []byte) (json.RawMessage, error) {
+	if len(data) == 0 {
+		return json.RawMessage("null"), nil
+	}
+	if !json.Valid(data) {
+		return nil, fmt.Errorf("invalid JSON document")
+	}
+	return json.RawMessage(data), nil
+}
+
+// grpcHTTPStatus maps gRPC codes onto the HTTP statuses the HTTP API would
+// have returned, so callers handle both transports identically.
+var grpcHTTPStatus = map[codes.Code]int{
+	codes.InvalidArgument:    http.StatusBadRequest,
+	codes.NotFound:           http.StatusNotFound,
+	codes.AlreadyExists:      http.StatusConflict,
+	codes.FailedPrecondition: http.StatusConflict,
+	codes.PermissionDenied:   http.StatusForbidden,
+	codes.Unauthenticated:    http.StatusUnauthorized,
+	codes.ResourceExhausted:  http.StatusTooManyRequests,
+	codes.Unimplemented:      http.StatusNotImplemented,
+}
+
+// fromGRPCError converts a gRPC error into the client's error types.
+func fromGRPCError(method string, err error) error {
+	st, ok := status.FromError(err)
+	if !ok {
+		return fmt.Errorf("%w: %s: %w", ErrUnavailable, method, err)
+	}
+
+	switch st.Code() {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.Canceled:
+		return fmt.Errorf("%w: %s: %w", ErrUnavailable, method, err)
+	}
+
+	statusCode, ok := grpcHTTPStatus[st.Code()]
+	if !ok {
+		statusCode = http.StatusInternalServerError
+	}
+	return &APIError{StatusCode: statusCode, Detail: st.Message()}
+}
@@ -0,0 +1,137 @@
+package pythonapi_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"nivai/backend/pkg/pythonapi"
+	"nivai/backend/pkg/pythonapi/analyticspb"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// fakeAnalyticsServer is an in-process AnalyticsService.
+type fakeAnalyticsServer struct {
+	analyticspb.UnimplementedAnalyticsServiceServer
+	lastProcess *analyticspb.ProcessMatchRequest
+}
+
+func (s *fakeAnalyticsServer) ProcessMatch(ctx context.Context, req *analyticspb.ProcessMatchRequest) (*analyticspb.ProcessMatchResponse, error) {
+	s.lastProcess = req
+	return &analyticspb.ProcessMatchResponse{Message: "Match processing started in background.", MatchId: req.GetMatchId()}, nil
+}
+
+func (s *fakeAnalyticsServer) GetMatchStatus(ctx context.Context, req *analyticspb.GetMatchStatusRequest) (*analyticspb.MatchStatus, error) {
+	if req.GetMatchId() != "m1" {
+		return nil, status.Error(codes.NotFound, "Match ID not found.")
+	}
+	return &analyticspb.MatchStatus{Status: pythonapi.StatusProcessed, MatchId: "m1"}, nil
+}
+
+func (s *fakeAnalyticsServer) GetMatchSummary(ctx context.Context, req *analyticspb.GetMatchSummaryRequest) (*analyticspb.MatchSummary, error) {
+	if req.GetMatchId() == "broken" {
+		return &analyticspb.MatchSummary{MatchId: "broken", PlayersJson: []byte("{not json")}, nil
+	}
+	return &analyticspb.MatchSummary{
+		MatchId:     req.GetMatchId(),
+		PlayersJson: []byte(`{"7":{"total_distance":10234.5}}`),
+		TeamsJson:   []byte(`{"home":{"total_distance":110000}}`),
+	}, nil
+}
+
+// newGRPCClient starts the fake server on an in-memory listener and returns
+// a client whose core calls use the gRPC transport.
+func newGRPCClient(t *testing.T, server *fakeAnalyticsServer, httpURL string) *pythonapi.Client {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	grpcServer := grpc.NewServer()
+	analyticspb.RegisterAnalyticsServiceServer(grpcServer, server)
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+
+	transport, err := pythonapi.DialGRPC("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { transport.Close() })
+
+	return pythonapi.NewClient(httpURL, nil, pythonapi.WithTransport(transport))
+}
+
+func TestGRPCTransport(t *testing.T) {
+	ctx := context.Background()
+	server := &fakeAnalyticsServer{}
+
+	// Player and team details still go over HTTP
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"match_id":"m1","player_id":"7","time_series":[]}`))
+	}))
+	defer httpServer.Close()
+
+	client := newGRPCClient(t, server, httpServer.URL)
+
+	t.Run("ProcessMatch", func(t *testing.T) {
+		resp, err := client.ProcessMatch(ctx, pythonapi.ProcessMatchRequest{
+			TrackingDataPath: "/data/m1_tracking.gzip",
+			EventDataPath:    "/data/m1_events.gzip",
+			MatchID:          "m1",
+			Priority:         pythonapi.PriorityHigh,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "m1", resp.MatchID)
+		assert.Equal(t, "high", server.lastProcess.GetPriority())
+		assert.Equal(t, "/data/m1_events.gzip", server.lastProcess.GetEventDataPath())
+	})
+
+	t.Run("Status and not found", func(t *testing.T) {
+		st, err := client.GetMatchStatus(ctx, "m1")
+		require.NoError(t, err)
+		assert.Equal(t, pythonapi.StatusProcessed, st.Status)
+
+		_, err = client.GetMatchStatus(ctx, "unknown")
+		assert.ErrorIs(t, err, pythonapi.ErrNotFound)
+		var apiErr *pythonapi.APIError
+		require.True(t, errors.As(err, &apiErr))
+		assert.Equal(t, "Match ID not found.", apiErr.Detail)
+	})
+
+	t.Run("Summary carries JSON documents", func(t *testing.T) {
+		summary, err := client.GetMatchSummary(ctx, "m1")
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"7":{"total_distance":10234.5}}`, string(summary.Players))
+
+		_, err = client.GetMatchSummary(ctx, "broken")
+		assert.ErrorIs(t, err, pythonapi.ErrInvalidResponse)
+	})
+
+	t.Run("Details fall back to HTTP", func(t *testing.T) {
+		details, err := client.GetPlayerDetails(ctx, "m1", "7")
+		require.NoError(t, err)
+		assert.Equal(t, "7", details.PlayerID)
+	})
+}
+
+func TestGRPCTransport_Unavailable(t *testing.T) {
+	listener := bufconn.Listen(1 << 20)
+	listener.Close() // Nothing is listening
+
+	transport, err := pythonapi.DialGRPC("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+	)
+	require.NoError(t, err)
+	defer transport.Close()
+
+	_, err = transport.GetMatchStatus(context.Background(), "m1")
+	assert.ErrorIs(t, err, pythonapi.ErrUnavailable)
+}
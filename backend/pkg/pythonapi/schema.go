@@ -0,0 +1,242 @@
+package pythonapi
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+)
+
+// SchemaVersion is the contract version the client speaks. It is sent in
+// the SchemaVersionHeader of every request; a service answering with a
+// version the client has no schemas for is rejected.
+const SchemaVersion = "1"
+
+// SchemaVersionHeader carries the contract version in requests and responses.
+const SchemaVersionHeader = "X-Schema-Version"
+
+// Contracts are the JSON schemas of the request and response payloads, by
+// version. The Python service's contract tests read the same files.
+//
+//go:embed schemas
+var contracts embed.FS
+
+// Contract names: the schema file names without extension.
+const (
+	ContractProcessMatchRequest  = "process_match_request"
+	ContractProcessMatchResponse = "process_match_response"
+	ContractMatchStatus          = "match_status"
+	ContractMatchSummary         = "match_summary"
+	ContractPlayerDetails        = "player_details"
+	ContractTeamSummaryOverTime  = "team_summary_over_time"
+)
+
+// ErrInvalidRequest wraps request payloads the client refuses to send
+// because they break the contract.
+var ErrInvalidRequest = errors.New("python api: invalid request")
+
+// ContractError describes where a payload breaks its contract. It is
+// wrapped in ErrInvalidRequest for requests and ErrInvalidResponse for
+// responses.
+type ContractError struct {
+	Contract string // Contract name, e.g. ContractProcessMatchRequest
+	Version  string
+	Path     string // Location of the offending value, e.g. "$.event_data_path"
+	Problem  string
+}
+
+func (e *ContractError) Error() string {
+	return fmt.Sprintf("%s v%s: %s: %s", e.Contract, e.Version, e.Path, e.Problem)
+}
+
+// schema is the subset of JSON Schema the contracts use.
+type schema struct {
+	Type                 schemaTypes        `json:"type"`
+	Properties           map[string]*schema `json:"properties"`
+	Required             []string           `json:"required"`
+	AdditionalProperties *bool              `json:"additionalProperties"`
+	Items                *schema            `json:"items"`
+	Enum                 []string           `json:"enum"`
+	MinLength            int                `json:"minLength"`
+}
+
+// schemaTypes is a JSON Schema type, given as one name or a list of them.
+type schemaTypes []string
+
+func (t *schemaTypes) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*t = schemaTypes{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*t = many
+	return nil
+}
+
+// schemas holds the parsed contracts by version and name.
+var schemas = loadSchemas()
+
+// loadSchemas parses the embedded contracts. They ship with the binary, so
+// a broken schema is a programming error.
+func loadSchemas() map[string]map[string]*schema {
+	versions := map[string]map[string]*schema{}
+	dirs, err := contracts.ReadDir("schemas")
+	if err != nil {
+		panic(err)
+	}
+	for _, dir := range dirs {
+		version := strings.TrimPrefix(dir.Name(), "v")
+		files, err := contracts.ReadDir(path.Join("schemas", dir.Name()))
+		if err != nil {
+			panic(err)
+		}
+		versions[version] = map[string]*schema{}
+		for _, file := range files {
+			data, err := contracts.ReadFile(path.Join("schemas", dir.Name(), file.Name()))
+			if err != nil {
+				panic(err)
+			}
+			var s schema
+			if err := json.Unmarshal(data, &s); err != nil {
+				panic(fmt.Sprintf("pythonapi: schema %s/%s: %v", dir.Name(), file.Name(), err))
+			}
+			versions[version][strings.TrimSuffix(file.Name(), ".json")] = &s
+		}
+	}
+	return versions
+}
+
+// SupportedSchemaVersions returns the contract versions the client has
+// schemas for, in order.
+func SupportedSchemaVersions() []string {
+	versions := make([]string, 0, len(schemas))
+	for version := range schemas {
+		versions = append(versions, version)
+	}
+	slices.Sort(versions)
+	return versions
+}
+
+// ValidateContract checks a JSON payload against a contract of a version.
+// It returns a *ContractError for the first problem found, or an error when
+// the contract is unknown.
+func ValidateContract(version, contract string, payload []byte) error {
+	s, ok := schemas[version][contract]
+	if !ok {
+		return fmt.Errorf("python api: no contract %q in schema version %q", contract, version)
+	}
+	if problem, at := s.validate("$", payload); problem != "" {
+		return &ContractError{Contract: contract, Version: version, Path: at, Problem: problem}
+	}
+	return nil
+}
+
+// validate checks value, returning the problem and where it is. Nested
+// values are only decoded where the schema looks into them, so large
+// statistics payloads are checked without decoding them whole.
+func (s *schema) validate(at string, value json.RawMessage) (string, string) {
+	kind := jsonType(value)
+	if len(s.Type) > 0 && !s.allows(kind) {
+		return fmt.Sprintf("is %s, want %s", kind, strings.Join(s.Type, " or ")), at
+	}
+
+	switch kind {
+	case "string":
+		if s.MinLength == 0 && len(s.Enum) == 0 {
+			return "", ""
+		}
+		var str string
+		if err := json.Unmarshal(value, &str); err != nil {
+			return "is not a valid string", at
+		}
+		if len([]rune(str)) < s.MinLength {
+			return fmt.Sprintf("is shorter than %d characters", s.MinLength), at
+		}
+		if len(s.Enum) > 0 && !slices.Contains(s.Enum, str) {
+			return fmt.Sprintf("is %q, want one of %s", str, strings.Join(s.Enum, ", ")), at
+		}
+	case "object":
+		if s.Properties == nil && s.Required == nil {
+			return "", ""
+		}
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(value, &fields); err != nil {
+			return "is not a valid object", at
+		}
+		for _, name := range s.Required {
+			if _, ok := fields[name]; !ok {
+				return "is required", at + "." + name
+			}
+		}
+		names := make([]string, 0, len(fields))
+		for name := range fields {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		for _, name := range names {
+			property, ok := s.Properties[name]
+			if !ok {
+				if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+					return "is not part of the contract", at + "." + name
+				}
+				continue
+			}
+			if problem, where := property.validate(at+"."+name, fields[name]); problem != "" {
+				return problem, where
+			}
+		}
+	case "array":
+		if s.Items == nil {
+			return "", ""
+		}
+		var items []json.RawMessage
+		if err := json.Unmarshal(value, &items); err != nil {
+			return "is not a valid array", at
+		}
+		for i, item := range items {
+			if problem, where := s.Items.validate(fmt.Sprintf("%s[%d]", at, i), item); problem != "" {
+				return problem, where
+			}
+		}
+	}
+	return "", ""
+}
+
+// allows reports whether the schema accepts a JSON type; integers are
+// numbers.
+func (s *schema) allows(kind string) bool {
+	return slices.Contains(s.Type, kind) || (kind == "integer" && slices.Contains(s.Type, "number"))
+}
+
+// jsonType returns the JSON Schema type of an encoded value from its first
+// byte, telling integers from other numbers.
+func jsonType(value json.RawMessage) string {
+	value = bytes.TrimSpace(value)
+	if len(value) == 0 {
+		return "nothing"
+	}
+	switch value[0] {
+	case '{':
+		return "object"
+	case '[':
+		return "array"
+	case '"':
+		return "string"
+	case 't', 'f':
+		return "boolean"
+	case 'n':
+		return "null"
+	}
+	if bytes.ContainsAny(value, ".eE") {
+		return "number"
+	}
+	return "integer"
+}
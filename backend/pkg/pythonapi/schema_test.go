@@ -0,0 +1,109 @@
+package pythonapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"nivai/backend/pkg/pythonapi"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestContracts pins the payloads both services exchange to the schemas,
+// so a renamed key fails here rather than in production
+func TestContracts(t *testing.T) {
+	request, err := json.Marshal(pythonapi.ProcessMatchRequest{
+		TrackingDataPath: "/data/m1_tracking.gzip", EventDataPath: "/data/m1_events.gzip", MatchID: "m1", Priority: pythonapi.PriorityHigh,
+	})
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		contract, payload string
+		path, problem     string // Expected ContractError; empty when valid
+	}{
+		{pythonapi.ContractProcessMatchRequest, string(request), "", ""},
+		{pythonapi.ContractProcessMatchRequest, `{"tracking_data_path":"t.gzip","event_file_path":"e.gzip"}`, "$.event_data_path", "is required"},
+		{pythonapi.ContractProcessMatchRequest, `{"tracking_data_path":"t.gzip","event_data_path":"e.gzip","events":"e.gzip"}`, "$.events", "is not part of the contract"},
+		{pythonapi.ContractProcessMatchRequest, `{"tracking_data_path":"","event_data_path":"e.gzip"}`, "$.tracking_data_path", "is shorter than 1 characters"},
+		{pythonapi.ContractProcessMatchRequest, `{"tracking_data_path":"t","event_data_path":"e","priority":"urgent"}`, "$.priority", `is "urgent", want one of low, normal, high`},
+		{pythonapi.ContractProcessMatchResponse, `{"message":"Match processing started in background.","match_id":"m1"}`, "", ""},
+		{pythonapi.ContractProcessMatchResponse, `{"message":"Match processing cancelled.","match_id":null,"queued":true}`, "", ""},
+		{pythonapi.ContractMatchStatus, `{"status":"error","match_id":"m1","message":"Tracking data could not be loaded"}`, "", ""},
+		{pythonapi.ContractMatchStatus, `{"state":"processed"}`, "$.status", "is required"},
+		{pythonapi.ContractMatchSummary, `{"match_id":"m1","players":{"p7":{"total_distance_m":9000}},"teams":{}}`, "", ""},
+		{pythonapi.ContractMatchSummary, `{"match_id":"m1","players":[],"teams":{}}`, "$.players", "is array, want object"},
+		{pythonapi.ContractPlayerDetails, `{"match_id":"m1","player_id":"7","time_series":[{"timestamp_ms":0,"speed":1.5}]}`, "", ""},
+		{pythonapi.ContractTeamSummaryOverTime, `{"match_id":"m1","team_id":"home","intervals":[{"interval":"0-5"},3]}`, "$.intervals[1]", "is integer, want object"},
+	} {
+		err := pythonapi.ValidateContract(pythonapi.SchemaVersion, tc.contract, []byte(tc.payload))
+		if tc.path == "" {
+			assert.NoError(t, err, tc.payload)
+			continue
+		}
+		var violation *pythonapi.ContractError
+		require.True(t, errors.As(err, &violation), "%s: %v", tc.payload, err)
+		assert.Equal(t, tc.path, violation.Path, tc.payload)
+		assert.Equal(t, tc.problem, violation.Problem, tc.payload)
+	}
+
+	assert.Error(t, pythonapi.ValidateContract("0", pythonapi.ContractMatchStatus, []byte(`{}`)))
+	assert.Equal(t, []string{"1"}, pythonapi.SupportedSchemaVersions())
+}
+
+func TestClient_Contracts(t *testing.T) {
+	var calls int
+	var gotVersion, answerVersion, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		gotVersion = r.Header.Get(pythonapi.SchemaVersionHeader)
+		if answerVersion != "" {
+			w.Header().Set(pythonapi.SchemaVersionHeader, answerVersion)
+		}
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+	client := pythonapi.NewClient(server.URL, server.Client())
+
+	t.Run("Requests breaking the contract are not sent", func(t *testing.T) {
+		_, err := client.ProcessMatch(context.Background(), pythonapi.ProcessMatchRequest{TrackingDataPath: "t.gzip"})
+		assert.ErrorIs(t, err, pythonapi.ErrInvalidRequest)
+		assert.Contains(t, err.Error(), "process_match_request v1: $.event_data_path: is shorter than 1 characters")
+		assert.Zero(t, calls)
+	})
+
+	t.Run("Responses breaking the contract are invalid", func(t *testing.T) {
+		body = `{"match_id":"m1","players":[]}`
+		_, err := client.GetMatchSummary(context.Background(), "m1")
+		assert.ErrorIs(t, err, pythonapi.ErrInvalidResponse)
+		var violation *pythonapi.ContractError
+		require.ErrorAs(t, err, &violation)
+		assert.Equal(t, "$.teams", violation.Path)
+		assert.Equal(t, pythonapi.SchemaVersion, gotVersion)
+
+		logging := pythonapi.NewClient(server.URL, server.Client(), pythonapi.WithContractValidation(pythonapi.ContractsLog))
+		summary, err := logging.GetMatchSummary(context.Background(), "m1")
+		require.NoError(t, err)
+		assert.Equal(t, "m1", summary.MatchID)
+	})
+
+	t.Run("The service's schema version is negotiated", func(t *testing.T) {
+		body = `{"status":"processed","match_id":"m1"}`
+		assert.Empty(t, client.ServiceSchemaVersion())
+
+		answerVersion = "1"
+		_, err := client.GetMatchStatus(context.Background(), "m1")
+		require.NoError(t, err)
+		assert.Equal(t, "1", client.ServiceSchemaVersion())
+
+		answerVersion = "2"
+		_, err = client.GetMatchStatus(context.Background(), "m1")
+		assert.ErrorIs(t, err, pythonapi.ErrInvalidResponse)
+		assert.Contains(t, err.Error(), `service answered with schema version "2", client supports 1`)
+		assert.Equal(t, "2", client.ServiceSchemaVersion())
+	})
+}
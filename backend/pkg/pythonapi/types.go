@@ -0,0 +1,72 @@
+package pythonapi
+
+import "encoding/json"
+
+// Match processing statuses reported by the Python API.
+const (
+	StatusPending   = "pending"
+	StatusProcessed = "processed"
+	StatusError     = "error"
+	StatusCancelled = "cancelled"
+)
+
+// Processing priorities for ProcessMatchRequest. The service runs each
+// priority in its own lane with its own concurrency limit, so a backfill at
+// PriorityLow never holds up a match at PriorityHigh. Matches in match-day
+// mode are submitted with PriorityHigh.
+const (
+	PriorityLow    = "low"
+	PriorityNormal = "normal"
+	PriorityHigh   = "high"
+)
+
+// ValidPriority reports whether priority is one of the processing priorities.
+func ValidPriority(priority string) bool {
+	return priority == PriorityLow || priority == PriorityNormal || priority == PriorityHigh
+}
+
+// ProcessMatchRequest is the body of POST /process-match. The paths must be
+// readable by the Python service.
+type ProcessMatchRequest struct {
+	TrackingDataPath string `json:"tracking_data_path"`
+	EventDataPath    string `json:"event_data_path"`
+	MatchID          string `json:"match_id,omitempty"`
+	Priority         string `json:"priority,omitempty"`
+}
+
+// ProcessMatchResponse is returned when background processing was started.
+type ProcessMatchResponse struct {
+	Message string `json:"message"`
+	MatchID string `json:"match_id,omitempty"`
+}
+
+// MatchStatus is the processing status of a match.
+type MatchStatus struct {
+	Status  string `json:"status"`
+	MatchID string `json:"match_id,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// The statistics payloads below have no response models on the Python side
+// yet, so their contents are kept as raw JSON and passed through unchanged.
+
+// MatchSummary holds per-player and per-team summary statistics for a match.
+type MatchSummary struct {
+	MatchID string          `json:"match_id"`
+	Players json.RawMessage `json:"players"`
+	Teams   json.RawMessage `json:"teams"`
+}
+
+// PlayerDetails holds time-series data for one player in a match.
+type PlayerDetails struct {
+	MatchID    string          `json:"match_id"`
+	PlayerID   string          `json:"player_id"`
+	TimeSeries json.RawMessage `json:"time_series"`
+}
+
+// TeamSummaryOverTime holds interval statistics for one team in a match.
+type TeamSummaryOverTime struct {
+	MatchID   string          `json:"match_id"`
+	TeamID    string          `json:"team_id"`
+	Intervals json.RawMessage `json:"intervals"`
+}
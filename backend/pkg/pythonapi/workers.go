@@ -0,0 +1,281 @@
+package pythonapi
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Routing is how the client spreads calls over the service's workers.
+type Routing string
+
+// Routing strategies
+const (
+	RoundRobin    Routing = "round_robin"     // Each worker in turn
+	LeastInFlight Routing = "least_in_flight" // The worker with the fewest calls in flight
+)
+
+// DefaultHealthCheckInterval is how often Run checks the workers unless
+// WithHealthCheckInterval sets another interval.
+const DefaultHealthCheckInterval = 10 * time.Second
+
+// workerCheckTimeout bounds the health check of a single worker.
+const workerCheckTimeout = 2 * time.Second
+
+// maxAssignments bounds how many match-to-worker assignments are kept; the
+// oldest are forgotten first and found again on their next call.
+const maxAssignments = 10000
+
+// worker is one instance of the service, with its health and load.
+type worker struct {
+	url      string
+	healthy  atomic.Bool
+	inFlight atomic.Int64
+}
+
+// assignments remember which worker holds each match. The service keeps a
+// processed match in the memory of the worker that processed it, so all
+// calls for the match must go there.
+type assignments struct {
+	mu     sync.Mutex
+	worker map[string]string // Match ID to worker URL
+	order  []string          // Match IDs, oldest first
+}
+
+// get returns the worker URL a match is assigned to.
+func (a *assignments) get(matchID string) (string, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	workerURL, ok := a.worker[matchID]
+	return workerURL, ok
+}
+
+// set assigns a match to a worker, forgetting the oldest assignment when
+// full.
+func (a *assignments) set(matchID, workerURL string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.worker == nil {
+		a.worker = map[string]string{}
+	}
+	if _, ok := a.worker[matchID]; !ok {
+		if len(a.order) >= maxAssignments {
+			delete(a.worker, a.order[0])
+			a.order = a.order[1:]
+		}
+		a.order = append(a.order, matchID)
+	}
+	a.worker[matchID] = workerURL
+}
+
+// WithWorkers spreads calls over the service at baseURL and further workers
+// at urls. Calls for a match stick to the worker that processed it; others,
+// including ProcessMatch, are routed by routing (RoundRobin if empty). Calls
+// over a Transport are not routed.
+func WithWorkers(routing Routing, urls ...string) Option {
+	return func(c *Client) {
+		if routing != "" {
+			c.routing = routing
+		}
+		c.SetWorkers(append([]string{c.BaseURL()}, urls...))
+	}
+}
+
+// WithHealthCheckInterval sets how often Run checks the workers' health;
+// d <= 0 keeps DefaultHealthCheckInterval.
+func WithHealthCheckInterval(d time.Duration) Option {
+	return func(c *Client) {
+		if d > 0 {
+			c.checkInterval = d
+		}
+	}
+}
+
+// SetWorkers replaces the workers calls are routed to (DefaultBaseURL if
+// none). Workers kept from before keep their health and load; calls already
+// sent finish against the old ones.
+func (c *Client) SetWorkers(urls []string) {
+	known := map[string]*worker{}
+	if current := c.workers.Load(); current != nil {
+		for _, w := range *current {
+			known[w.url] = w
+		}
+	}
+
+	var workers []*worker
+	seen := map[string]bool{}
+	for _, raw := range urls {
+		u := strings.TrimRight(raw, "/")
+		if u == "" || seen[u] {
+			continue
+		}
+		seen[u] = true
+		w, ok := known[u]
+		if !ok {
+			w = &worker{url: u}
+			w.healthy.Store(true)
+		}
+		workers = append(workers, w)
+	}
+	if len(workers) == 0 {
+		w := &worker{url: DefaultBaseURL}
+		w.healthy.Store(true)
+		workers = append(workers, w)
+	}
+	c.workers.Store(&workers)
+}
+
+// Workers returns the URLs of the workers calls are routed to, the base URL
+// first.
+func (c *Client) Workers() []string {
+	workers := *c.workers.Load()
+	urls := make([]string, len(workers))
+	for i, w := range workers {
+		urls[i] = w.url
+	}
+	return urls
+}
+
+// HealthyWorkers returns the URLs of the workers currently receiving calls.
+func (c *Client) HealthyWorkers() []string {
+	urls := []string{}
+	for _, w := range *c.workers.Load() {
+		if w.healthy.Load() {
+			urls = append(urls, w.url)
+		}
+	}
+	return urls
+}
+
+// Run checks the health of the workers until ctx is cancelled. A single
+// worker is not checked, as there is nothing to route around.
+func (c *Client) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.checkInterval)
+	defer ticker.Stop()
+	for {
+		if len(*c.workers.Load()) > 1 {
+			c.CheckWorkers(ctx)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CheckWorkers asks every worker for its root endpoint and updates whether
+// it receives calls.
+func (c *Client) CheckWorkers(ctx context.Context) {
+	for _, w := range *c.workers.Load() {
+		c.check(ctx, w)
+	}
+}
+
+// check asks a worker for its root endpoint, logs health changes and
+// reports whether it is healthy.
+func (c *Client) check(ctx context.Context, w *worker) bool {
+	ctx, cancel := context.WithTimeout(ctx, workerCheckTimeout)
+	defer cancel()
+
+	status, err := c.probe(ctx, w, "/")
+	healthy := err == nil && status < 500
+	if was := w.healthy.Swap(healthy); was != healthy {
+		if healthy {
+			log.Printf("Python API worker %s is healthy again", w.url)
+		} else if err != nil {
+			log.Printf("Python API worker %s is unhealthy, routing around it: %v", w.url, err)
+		} else {
+			log.Printf("Python API worker %s is unhealthy, routing around it: status %d", w.url, status)
+		}
+	}
+	return healthy
+}
+
+// probe sends a GET to a worker, returning the status code.
+func (c *Client) probe(ctx context.Context, w *worker, path string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, w.url+path, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set(SchemaVersionHeader, SchemaVersion)
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxErrorBody))
+	return resp.StatusCode, nil
+}
+
+// route returns the worker for a call. Calls for a match go to the worker
+// holding it, found by asking the healthy workers for the match's status
+// when this client has not seen it yet, e.g. after a restart or when another
+// backend instance started the processing. ProcessMatch (assign) picks a
+// worker by the routing strategy and assigns the match to it.
+func (c *Client) route(ctx context.Context, matchID string, assign bool) *worker {
+	workers := *c.workers.Load()
+	if len(workers) == 1 {
+		return workers[0]
+	}
+	if matchID == "" {
+		return c.pick(workers)
+	}
+	if assign {
+		w := c.pick(workers)
+		c.assigned.set(matchID, w.url)
+		return w
+	}
+
+	if u, ok := c.assigned.get(matchID); ok {
+		for _, w := range workers {
+			if w.url == u && w.healthy.Load() {
+				return w
+			}
+		}
+	}
+	for _, w := range workers {
+		if !w.healthy.Load() {
+			continue
+		}
+		if status, err := c.probe(ctx, w, "/match/"+url.PathEscape(matchID)+"/status"); err == nil && status < 300 {
+			c.assigned.set(matchID, w.url)
+			return w
+		}
+	}
+	// No worker holds the match; any of them answers that
+	return c.pick(workers)
+}
+
+// pick chooses a healthy worker by the routing strategy, or any worker when
+// none is healthy.
+func (c *Client) pick(workers []*worker) *worker {
+	candidates := make([]*worker, 0, len(workers))
+	for _, w := range workers {
+		if w.healthy.Load() {
+			candidates = append(candidates, w)
+		}
+	}
+	if len(candidates) == 0 {
+		candidates = workers
+	}
+
+	start := int(c.next.Add(1) % uint64(len(candidates)))
+	chosen := candidates[start]
+	if c.routing == LeastInFlight {
+		// Ties go to the next worker in turn, so idle workers share the load
+		for i := 1; i < len(candidates); i++ {
+			w := candidates[(start+i)%len(candidates)]
+			if w.inFlight.Load() < chosen.inFlight.Load() {
+				chosen = w
+			}
+		}
+	}
+	return chosen
+}
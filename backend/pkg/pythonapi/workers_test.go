@@ -0,0 +1,137 @@
+package pythonapi_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"nivai/backend/pkg/pythonapi"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeWorker is a Python API worker keeping the matches it processed
+type fakeWorker struct {
+	*httptest.Server
+	mu      sync.Mutex
+	matches map[string]bool
+	calls   int
+	down    bool
+}
+
+func newFakeWorker(t *testing.T) *fakeWorker {
+	w := &fakeWorker{matches: map[string]bool{}}
+	w.Server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		w.calls++
+		if w.down {
+			rw.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		switch {
+		case r.URL.Path == "/":
+			rw.Write([]byte(`{"message":"AIFAA Python API"}`))
+		case r.URL.Path == "/process-match":
+			w.matches["m1"] = true
+			rw.WriteHeader(http.StatusAccepted)
+			rw.Write([]byte(`{"message":"Match processing started in background.","match_id":"m1"}`))
+		case strings.HasSuffix(r.URL.Path, "/status") && w.matches[strings.Split(r.URL.Path, "/")[2]]:
+			rw.Write([]byte(`{"status":"processed","match_id":"m1"}`))
+		default:
+			http.Error(rw, `{"detail":"Match ID not found."}`, http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(w.Close)
+	return w
+}
+
+// take returns the calls since the last take
+func (w *fakeWorker) take() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	calls := w.calls
+	w.calls = 0
+	return calls
+}
+
+func TestClient_Workers(t *testing.T) {
+	first, second := newFakeWorker(t), newFakeWorker(t)
+	ctx := context.Background()
+	newClient := func(routing pythonapi.Routing) *pythonapi.Client {
+		return pythonapi.NewClient(first.URL, http.DefaultClient, pythonapi.WithWorkers(routing, second.URL+"/", first.URL))
+	}
+
+	t.Run("Calls are spread over the workers", func(t *testing.T) {
+		client := newClient(pythonapi.RoundRobin)
+		assert.Equal(t, []string{first.URL, second.URL}, client.Workers())
+		for i := 0; i < 4; i++ {
+			require.NoError(t, client.Ping(ctx))
+		}
+		assert.Equal(t, 2, first.take())
+		assert.Equal(t, 2, second.take())
+	})
+
+	t.Run("Calls for a match stick to the worker that processed it", func(t *testing.T) {
+		client := newClient(pythonapi.LeastInFlight)
+		_, err := client.ProcessMatch(ctx, pythonapi.ProcessMatchRequest{TrackingDataPath: "t", EventDataPath: "e", MatchID: "m1"})
+		require.NoError(t, err)
+		holder, other := first, second
+		if second.matches["m1"] {
+			holder, other = second, first
+		}
+		holder.take()
+
+		for i := 0; i < 3; i++ {
+			_, err := client.GetMatchStatus(ctx, "m1")
+			require.NoError(t, err)
+		}
+		assert.Equal(t, 3, holder.take())
+		assert.Zero(t, other.take())
+
+		// Another client finds the worker holding the match by asking
+		fresh := newClient(pythonapi.RoundRobin)
+		for i := 0; i < 2; i++ {
+			_, err := fresh.GetMatchStatus(ctx, "m1")
+			require.NoError(t, err)
+		}
+		assert.Equal(t, 3, holder.take(), "one lookup and two calls")
+		assert.LessOrEqual(t, other.take(), 1)
+
+		_, err = fresh.GetMatchStatus(ctx, "unknown")
+		var apiErr *pythonapi.APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+		delete(holder.matches, "m1")
+		first.take()
+		second.take()
+	})
+
+	t.Run("Unhealthy workers are routed around until they recover", func(t *testing.T) {
+		client := newClient(pythonapi.RoundRobin)
+		second.mu.Lock()
+		second.down = true
+		second.mu.Unlock()
+		client.CheckWorkers(ctx)
+		assert.Equal(t, []string{first.URL}, client.HealthyWorkers())
+
+		second.take()
+		for i := 0; i < 3; i++ {
+			require.NoError(t, client.Ping(ctx))
+		}
+		assert.Zero(t, second.take())
+
+		second.mu.Lock()
+		second.down = false
+		second.mu.Unlock()
+		client.CheckWorkers(ctx)
+		assert.Equal(t, []string{first.URL, second.URL}, client.HealthyWorkers())
+
+		client.SetWorkers(nil)
+		assert.Equal(t, []string{pythonapi.DefaultBaseURL}, client.Workers())
+	})
+}
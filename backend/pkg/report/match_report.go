@@ -0,0 +1,402 @@
+// Package report renders match reports as PDF documents: team statistics,
+// key players and bar charts drawn server-side, so the document needs no
+// client-side charting.
+package report
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// KeyPlayerCount is how many players the key players section lists.
+const KeyPlayerCount = 5
+
+// keyPlayerStat ranks players for the key players section; the chart and
+// table fall back to the first statistic available when it is missing.
+const keyPlayerStat = "total_distance_m"
+
+// teamChartStats are compared between the teams in the team chart, when present.
+var teamChartStats = []string{
+	"total_distance_m",
+	"total_high_intensity_running_distance_m",
+	"total_sprint_distance_m",
+	"num_accelerations",
+	"num_decelerations",
+}
+
+// playerTableStats are the columns of the key players table, when present.
+var playerTableStats = []string{
+	"total_distance_m",
+	"max_speed_kmh",
+	"total_sprint_distance_m",
+	"num_accelerations",
+}
+
+// ErrNoData is returned when a report has neither team nor player statistics.
+var ErrNoData = errors.New("report: no statistics to report")
+
+// Stats are the numeric statistics of one team or player.
+type Stats struct {
+	ID    string
+	Stats map[string]float64
+}
+
+// MatchReport is the content of a match report.
+type MatchReport struct {
+	MatchID     string
+	Title       string
+	HomeTeam    string
+	AwayTeam    string
+	Competition string
+	Season      string
+	MatchDate   time.Time
+	GeneratedAt time.Time
+	Teams       []Stats
+	Players     []Stats
+}
+
+// Page layout, in millimetres on A4 portrait.
+const (
+	pageMargin   = 15.0
+	contentWidth = 210 - 2*pageMargin
+	rowHeight    = 7.0
+)
+
+// Chart colours (RGB): one per team, the first also used for player bars.
+var seriesColors = [][3]int{{33, 102, 172}, {214, 96, 77}, {77, 146, 33}, {128, 128, 128}}
+
+// RenderPDF writes the report as a PDF document to w.
+func RenderPDF(w io.Writer, r *MatchReport) error {
+	if len(r.Teams) == 0 && len(r.Players) == 0 {
+		return ErrNoData
+	}
+
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(pageMargin, pageMargin, pageMargin)
+	pdf.SetAutoPageBreak(true, pageMargin)
+	pdf.SetTitle(heading(r), true)
+	pdf.SetCreator("NIVAI", true)
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-pageMargin)
+		pdf.SetFont("Helvetica", "I", 8)
+		pdf.SetTextColor(128, 128, 128)
+		footer := fmt.Sprintf("Match %s - generated %s", r.MatchID, r.GeneratedAt.UTC().Format("2006-01-02 15:04 MST"))
+		pdf.CellFormat(0, 5, footer, "", 0, "L", false, 0, "")
+		pdf.CellFormat(0, 5, fmt.Sprintf("Page %d", pdf.PageNo()), "", 0, "R", false, 0, "")
+	})
+	tr := pdf.UnicodeTranslatorFromDescriptor("") // cp1252 for the core fonts
+	pdf.AddPage()
+
+	writeHeader(pdf, tr, r)
+	if len(r.Teams) > 0 {
+		writeTeamSection(pdf, tr, r.Teams)
+	}
+	if len(r.Players) > 0 {
+		writeKeyPlayers(pdf, tr, r.Players)
+	}
+
+	if err := pdf.Error(); err != nil {
+		return err
+	}
+	return pdf.Output(w)
+}
+
+// heading is the report title: the fixture when the teams are known.
+func heading(r *MatchReport) string {
+	if r.HomeTeam != "" && r.AwayTeam != "" {
+		return r.HomeTeam + " vs " + r.AwayTeam
+	}
+	if r.Title != "" {
+		return r.Title
+	}
+	return "Match " + r.MatchID
+}
+
+func writeHeader(pdf *gofpdf.Fpdf, tr func(string) string, r *MatchReport) {
+	pdf.SetFont("Helvetica", "B", 18)
+	pdf.CellFormat(0, 10, tr(heading(r)), "", 1, "L", false, 0, "")
+
+	var details []string
+	if r.Competition != "" {
+		details = append(details, r.Competition)
+	}
+	if r.Season != "" {
+		details = append(details, r.Season)
+	}
+	if !r.MatchDate.IsZero() {
+		details = append(details, r.MatchDate.Format("2 January 2006"))
+	}
+	if len(details) > 0 {
+		pdf.SetFont("Helvetica", "", 11)
+		pdf.SetTextColor(80, 80, 80)
+		pdf.CellFormat(0, 6, tr(strings.Join(details, " | ")), "", 1, "L", false, 0, "")
+		pdf.SetTextColor(0, 0, 0)
+	}
+	pdf.Ln(4)
+}
+
+func writeSectionTitle(pdf *gofpdf.Fpdf, title string) {
+	pdf.Ln(2)
+	pdf.SetFont("Helvetica", "B", 13)
+	pdf.CellFormat(0, 8, title, "B", 1, "L", false, 0, "")
+	pdf.Ln(2)
+}
+
+// writeTeamSection writes a table with one row per statistic and one column
+// per team, followed by a grouped bar chart comparing the teams.
+func writeTeamSection(pdf *gofpdf.Fpdf, tr func(string) string, teams []Stats) {
+	writeSectionTitle(pdf, "Team statistics")
+
+	names := statNames(teams)
+	labelWidth := contentWidth * 0.4
+	valueWidth := (contentWidth - labelWidth) / float64(len(teams))
+
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.SetFillColor(235, 235, 235)
+	pdf.CellFormat(labelWidth, rowHeight, "Statistic", "1", 0, "L", true, 0, "")
+	for _, team := range teams {
+		pdf.CellFormat(valueWidth, rowHeight, tr(team.ID), "1", 0, "C", true, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("Helvetica", "", 10)
+	for _, name := range names {
+		pdf.CellFormat(labelWidth, rowHeight, tr(Label(name)), "1", 0, "L", false, 0, "")
+		for _, team := range teams {
+			pdf.CellFormat(valueWidth, rowHeight, formatValue(team.Stats, name), "1", 0, "R", false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+
+	var charted []string
+	for _, name := range teamChartStats {
+		for _, team := range teams {
+			if _, ok := team.Stats[name]; ok {
+				charted = append(charted, name)
+				break
+			}
+		}
+	}
+	if len(charted) > 0 {
+		pdf.Ln(4)
+		drawTeamChart(pdf, tr, teams, charted)
+	}
+}
+
+// drawTeamChart draws one group of horizontal bars per statistic, one bar per
+// team. Bars are scaled per statistic, so statistics of different magnitudes
+// can share the chart.
+func drawTeamChart(pdf *gofpdf.Fpdf, tr func(string) string, teams []Stats, names []string) {
+	const barHeight = 4.0
+	labelWidth := contentWidth * 0.4
+	barArea := contentWidth - labelWidth - 25
+
+	groupHeight := barHeight*float64(len(teams)) + 4
+	ensureSpace(pdf, groupHeight*float64(len(names))+10)
+
+	// Legend
+	pdf.SetFont("Helvetica", "", 9)
+	for i, team := range teams {
+		setFill(pdf, i)
+		pdf.Rect(pdf.GetX(), pdf.GetY()+1.5, 3, 3, "F")
+		pdf.SetX(pdf.GetX() + 4)
+		pdf.CellFormat(pdf.GetStringWidth(tr(team.ID))+6, 6, tr(team.ID), "", 0, "L", false, 0, "")
+	}
+	pdf.Ln(8)
+
+	for _, name := range names {
+		maximum := 0.0
+		for _, team := range teams {
+			maximum = math.Max(maximum, team.Stats[name])
+		}
+		top := pdf.GetY()
+		pdf.SetFont("Helvetica", "", 9)
+		pdf.SetXY(pageMargin, top)
+		pdf.CellFormat(labelWidth, barHeight*float64(len(teams)), tr(Label(name)), "", 0, "L", false, 0, "")
+		for i, team := range teams {
+			value, ok := team.Stats[name]
+			y := top + barHeight*float64(i)
+			if ok && maximum > 0 {
+				setFill(pdf, i)
+				pdf.Rect(pageMargin+labelWidth, y+0.5, barArea*value/maximum, barHeight-1, "F")
+			}
+			pdf.SetXY(pageMargin+labelWidth+barArea+1, y)
+			pdf.SetFont("Helvetica", "", 8)
+			pdf.CellFormat(24, barHeight, formatValue(team.Stats, name), "", 0, "L", false, 0, "")
+		}
+		pdf.SetXY(pageMargin, top+groupHeight)
+	}
+}
+
+// writeKeyPlayers lists the players with the highest ranking statistic and
+// charts it.
+func writeKeyPlayers(pdf *gofpdf.Fpdf, tr func(string) string, players []Stats) {
+	rankBy := keyPlayerStat
+	if !hasStat(players, rankBy) {
+		rankBy = statNames(players)[0]
+	}
+	key := topPlayers(players, rankBy, KeyPlayerCount)
+
+	var columns []string
+	for _, name := range playerTableStats {
+		if hasStat(key, name) {
+			columns = append(columns, name)
+		}
+	}
+	if len(columns) == 0 {
+		columns = []string{rankBy}
+	}
+
+	ensureSpace(pdf, rowHeight*float64(len(key)+1)+20)
+	writeSectionTitle(pdf, "Key players")
+
+	idWidth := contentWidth * 0.25
+	valueWidth := (contentWidth - idWidth) / float64(len(columns))
+	pdf.SetFont("Helvetica", "B", 9)
+	pdf.SetFillColor(235, 235, 235)
+	pdf.CellFormat(idWidth, rowHeight, "Player", "1", 0, "L", true, 0, "")
+	for _, name := range columns {
+		pdf.CellFormat(valueWidth, rowHeight, tr(Label(name)), "1", 0, "C", true, 0, "")
+	}
+	pdf.Ln(-1)
+	pdf.SetFont("Helvetica", "", 10)
+	for _, player := range key {
+		pdf.CellFormat(idWidth, rowHeight, tr(player.ID), "1", 0, "L", false, 0, "")
+		for _, name := range columns {
+			pdf.CellFormat(valueWidth, rowHeight, formatValue(player.Stats, name), "1", 0, "R", false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+
+	// Bar chart of the ranking statistic
+	pdf.Ln(4)
+	ensureSpace(pdf, 8*float64(len(key))+10)
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.CellFormat(0, 6, tr(Label(rankBy)), "", 1, "L", false, 0, "")
+	maximum := 0.0
+	for _, player := range key {
+		maximum = math.Max(maximum, player.Stats[rankBy])
+	}
+	labelWidth := idWidth
+	barArea := contentWidth - labelWidth - 25
+	pdf.SetFont("Helvetica", "", 9)
+	for _, player := range key {
+		y := pdf.GetY()
+		pdf.CellFormat(labelWidth, 7, tr(player.ID), "", 0, "L", false, 0, "")
+		if maximum > 0 {
+			setFill(pdf, 0)
+			pdf.Rect(pageMargin+labelWidth, y+1, barArea*player.Stats[rankBy]/maximum, 5, "F")
+		}
+		pdf.SetXY(pageMargin+labelWidth+barArea+1, y)
+		pdf.CellFormat(24, 7, formatValue(player.Stats, rankBy), "", 1, "L", false, 0, "")
+	}
+}
+
+// topPlayers returns up to n players with the highest value of stat, ties
+// broken by ID; players without the statistic are left out.
+func topPlayers(players []Stats, stat string, n int) []Stats {
+	ranked := make([]Stats, 0, len(players))
+	for _, player := range players {
+		if _, ok := player.Stats[stat]; ok {
+			ranked = append(ranked, player)
+		}
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		a, b := ranked[i].Stats[stat], ranked[j].Stats[stat]
+		if a != b {
+			return a > b
+		}
+		return ranked[i].ID < ranked[j].ID
+	})
+	if len(ranked) > n {
+		ranked = ranked[:n]
+	}
+	return ranked
+}
+
+// ensureSpace starts a new page when less than height remains on this one.
+func ensureSpace(pdf *gofpdf.Fpdf, height float64) {
+	_, pageHeight := pdf.GetPageSize()
+	if pdf.GetY()+height > pageHeight-pageMargin-5 {
+		pdf.AddPage()
+	}
+}
+
+func setFill(pdf *gofpdf.Fpdf, series int) {
+	c := seriesColors[series%len(seriesColors)]
+	pdf.SetFillColor(c[0], c[1], c[2])
+}
+
+// statNames returns the sorted union of the statistic names of all entries.
+func statNames(entries []Stats) []string {
+	seen := map[string]bool{}
+	var names []string
+	for _, entry := range entries {
+		for name := range entry.Stats {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func hasStat(entries []Stats, name string) bool {
+	for _, entry := range entries {
+		if _, ok := entry.Stats[name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// formatValue formats a statistic for display; missing values show a dash.
+func formatValue(stats map[string]float64, name string) string {
+	value, ok := stats[name]
+	if !ok {
+		return "-"
+	}
+	if value == math.Trunc(value) && math.Abs(value) < 1e15 {
+		return strconv.FormatInt(int64(value), 10)
+	}
+	return strconv.FormatFloat(value, 'f', 1, 64)
+}
+
+// unitSuffixes map statistic name suffixes to display units.
+var unitSuffixes = []struct{ suffix, unit string }{
+	{"_kmh", "km/h"},
+	{"_m", "m"},
+	{"_minutes", "min"},
+	{"_pct", "%"},
+}
+
+// Label turns a statistic name into a display label, for example
+// "max_speed_kmh" into "Max speed (km/h)".
+func Label(name string) string {
+	unit := ""
+	for _, u := range unitSuffixes {
+		if strings.HasSuffix(name, u.suffix) && len(name) > len(u.suffix) {
+			name, unit = strings.TrimSuffix(name, u.suffix), u.unit
+			break
+		}
+	}
+	name = strings.TrimPrefix(name, "num_")
+	words := strings.ReplaceAll(name, "_", " ")
+	if words != "" {
+		words = strings.ToUpper(words[:1]) + words[1:]
+	}
+	if unit != "" {
+		words += " (" + unit + ")"
+	}
+	return words
+}
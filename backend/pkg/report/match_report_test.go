@@ -0,0 +1,56 @@
+package report_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"nivai/backend/pkg/report"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderPDF(t *testing.T) {
+	t.Run("Renders teams, key players and charts", func(t *testing.T) {
+		players := make([]report.Stats, 0, 22)
+		for i := 1; i <= 22; i++ {
+			players = append(players, report.Stats{ID: fmt.Sprintf("p%d", i), Stats: map[string]float64{
+				"total_distance_m": 8000 + float64(i)*150,
+				"max_speed_kmh":    28 + float64(i%5),
+			}})
+		}
+
+		var buf bytes.Buffer
+		err := report.RenderPDF(&buf, &report.MatchReport{
+			MatchID:     "m1",
+			HomeTeam:    "Ajax",
+			AwayTeam:    "Feyenoord",
+			Competition: "Eredivisie",
+			MatchDate:   time.Date(2024, 9, 22, 14, 30, 0, 0, time.UTC),
+			GeneratedAt: time.Now(),
+			Teams: []report.Stats{
+				{ID: "Ajax", Stats: map[string]float64{"total_distance_m": 112000, "num_accelerations": 410}},
+				{ID: "Feyenoord", Stats: map[string]float64{"total_distance_m": 108500.5}},
+			},
+			Players: players,
+		})
+		require.NoError(t, err)
+		assert.True(t, bytes.HasPrefix(buf.Bytes(), []byte("%PDF-")))
+		assert.True(t, bytes.HasSuffix(bytes.TrimSpace(buf.Bytes()), []byte("%%EOF")))
+	})
+
+	t.Run("Reports without statistics are rejected", func(t *testing.T) {
+		var buf bytes.Buffer
+		assert.ErrorIs(t, report.RenderPDF(&buf, &report.MatchReport{MatchID: "m1"}), report.ErrNoData)
+	})
+}
+
+func TestLabel(t *testing.T) {
+	assert.Equal(t, "Max speed (km/h)", report.Label("max_speed_kmh"))
+	assert.Equal(t, "Total distance (m)", report.Label("total_distance_m"))
+	assert.Equal(t, "Accelerations", report.Label("num_accelerations"))
+	assert.Equal(t, "Duration (min)", report.Label("duration_minutes"))
+	assert.Equal(t, "Possession", report.Label("possession"))
+}
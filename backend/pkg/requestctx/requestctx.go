@@ -0,0 +1,139 @@
+// Package requestctx carries the per-request dependency bundle: who is
+// calling, for which organization and locale, under which request and trace
+// IDs, and a logger that tags every line with the request ID.
+//
+// The bundle is created by middleware.RequestContext at the start of the
+// middleware chain and completed as the request passes through (Authenticate
+// sets the principal). Handlers read it with From and treat it as read-only.
+package requestctx
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	UserID string
+	Role   string
+	Groups []string // Groups the user belongs to, for access grants
+}
+
+// Authenticated reports whether the request was authenticated.
+func (p Principal) Authenticated() bool {
+	return p.UserID != ""
+}
+
+// HasRole reports whether the caller has the given role.
+func (p Principal) HasRole(role string) bool {
+	return p.Authenticated() && p.Role == role
+}
+
+// Trace identifies the distributed trace a request belongs to, following
+// the W3C Trace Context traceparent header.
+type Trace struct {
+	TraceID      string
+	ParentSpanID string // Span of the caller, empty when the trace starts here
+	Sampled      bool
+}
+
+// Info is the request-scoped dependency bundle.
+type Info struct {
+	RequestID  string
+	Principal  Principal
+	Org        string
+	Locale     string
+	Trace      Trace
+	APIVersion int // Major API version requested, e.g. 2 for /api/v2/...
+	Logger     *log.Logger
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying info.
+func NewContext(ctx context.Context, info *Info) context.Context {
+	return context.WithValue(ctx, contextKey{}, info)
+}
+
+// FromContext returns the bundle stored in ctx. Outside of a request (for
+// example in background jobs or handler tests without middleware) it returns
+// an empty bundle whose logger writes to the standard logger, so callers never
+// need a nil check.
+func FromContext(ctx context.Context) *Info {
+	if info, ok := ctx.Value(contextKey{}).(*Info); ok && info != nil {
+		return info
+	}
+	return &Info{Logger: log.Default()}
+}
+
+// Ensure returns the bundle stored in ctx, attaching an empty one first when
+// there is none, so middleware can complete it.
+func Ensure(ctx context.Context) (context.Context, *Info) {
+	if info, ok := ctx.Value(contextKey{}).(*Info); ok && info != nil {
+		return ctx, info
+	}
+	info := &Info{Logger: log.Default()}
+	return NewContext(ctx, info), info
+}
+
+// From returns the bundle of a request; see FromContext.
+func From(r *http.Request) *Info {
+	return FromContext(r.Context())
+}
+
+// New creates a bundle for a request with the given ID. The logger prefixes
+// lines with the ID and writes wherever the standard logger does.
+func New(requestID string) *Info {
+	return &Info{
+		RequestID: requestID,
+		Logger:    log.New(log.Writer(), "["+requestID+"] ", log.Flags()|log.Lmsgprefix),
+	}
+}
+
+// ParseTraceparent reads a W3C traceparent header
+// ("00-<trace-id>-<parent-id>-<flags>"). Invalid headers are ignored and a new
+// trace is started with a random trace ID.
+func ParseTraceparent(header string) Trace {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) == 4 && len(parts[0]) == 2 && parts[0] != "ff" &&
+		isHex(parts[1], 32) && isHex(parts[2], 16) && isHex(parts[3], 2) &&
+		strings.Trim(parts[1], "0") != "" && strings.Trim(parts[2], "0") != "" {
+		flags, _ := hex.DecodeString(parts[3])
+		return Trace{
+			TraceID:      strings.ToLower(parts[1]),
+			ParentSpanID: strings.ToLower(parts[2]),
+			Sampled:      flags[0]&1 == 1,
+		}
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return Trace{}
+	}
+	return Trace{TraceID: hex.EncodeToString(id)}
+}
+
+// isHex reports whether s is n hexadecimal characters.
+func isHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
+// PreferredLocale returns the first language tag of an Accept-Language
+// header, or fallback when the header names none.
+func PreferredLocale(acceptLanguage, fallback string) string {
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag := strings.TrimSpace(strings.SplitN(part, ";", 2)[0])
+		if tag != "" && tag != "*" {
+			return tag
+		}
+	}
+	return fallback
+}
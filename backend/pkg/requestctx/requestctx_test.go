@@ -0,0 +1,66 @@
+package requestctx_test
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os"
+	"testing"
+
+	"nivai/backend/pkg/requestctx"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromContext(t *testing.T) {
+	t.Run("Missing bundle yields an empty one", func(t *testing.T) {
+		info := requestctx.FromContext(context.Background())
+		require.NotNil(t, info)
+		require.NotNil(t, info.Logger)
+		assert.False(t, info.Principal.Authenticated())
+	})
+
+	t.Run("Ensure attaches a bundle once", func(t *testing.T) {
+		ctx, info := requestctx.Ensure(context.Background())
+		info.Principal = requestctx.Principal{UserID: "u1", Role: "admin"}
+
+		again, same := requestctx.Ensure(ctx)
+		assert.Same(t, info, same)
+		assert.True(t, requestctx.FromContext(again).Principal.HasRole("admin"))
+		assert.False(t, requestctx.Principal{Role: "admin"}.HasRole("admin"), "roles need an authenticated user")
+	})
+
+	t.Run("Logger tags lines with the request ID", func(t *testing.T) {
+		var out bytes.Buffer
+		log.SetOutput(&out)
+		defer log.SetOutput(os.Stderr)
+
+		requestctx.New("req-1").Logger.Printf("hello")
+		assert.Contains(t, out.String(), "[req-1] hello")
+	})
+}
+
+func TestParseTraceparent(t *testing.T) {
+	trace := requestctx.ParseTraceparent("00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01")
+	assert.Equal(t, requestctx.Trace{TraceID: "4bf92f3577b34da6a3ce929d0e0e4736", ParentSpanID: "00f067aa0ba902b7", Sampled: true}, trace)
+
+	for _, header := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-xyz-00f067aa0ba902b7-01",
+	} {
+		started := requestctx.ParseTraceparent(header)
+		assert.Len(t, started.TraceID, 32, header)
+		assert.Empty(t, started.ParentSpanID, header)
+	}
+}
+
+func TestPreferredLocale(t *testing.T) {
+	assert.Equal(t, "nl-NL", requestctx.PreferredLocale("nl-NL,nl;q=0.9,en;q=0.8", "en"))
+	assert.Equal(t, "de", requestctx.PreferredLocale(" de;q=0.7 ", "en"))
+	assert.Equal(t, "en", requestctx.PreferredLocale("*", "en"))
+	assert.Equal(t, "en", requestctx.PreferredLocale("", "en"))
+}
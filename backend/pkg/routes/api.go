@@ -0,0 +1,333 @@
+package routes
+
+import (
+	"net/http"
+	"time"
+
+	"nivai/backend/pkg/controllers"
+	"nivai/backend/pkg/lifecycle"
+	"nivai/backend/pkg/middleware"
+)
+
+/**
+ * Controllers holds the handlers the API routes dispatch to.
+ */
+type Controllers struct {
+	Video          *controllers.VideoController
+	Match          *controllers.MatchController
+	MatchDay       *controllers.MatchDayController
+	Player         *controllers.PlayerController
+	PlayerIdentity *controllers.PlayerIdentityController
+	PlayerPrivacy  *controllers.PlayerPrivacyController
+	Share          *controllers.ShareController
+	File           *controllers.FileController
+	Access         *controllers.AccessController
+	Notification   *controllers.NotificationController
+	Analytics      *controllers.AnalyticsController
+	Season         *controllers.SeasonController
+	Webhook        *controllers.WebhookController
+	Audit          *controllers.AuditController
+	Bootstrap      *controllers.BootstrapController
+	SLO            *controllers.SLOController
+	HTTPClient     *controllers.HTTPClientController
+	Report         *controllers.ReportController
+	Retention      *controllers.RetentionController
+	Archive        *controllers.ArchiveController
+	Backfill       *controllers.BackfillController
+	Support        *controllers.SupportController
+	Usage          *controllers.UsageController
+	Stats          *controllers.StatsController
+	StorageGC      *controllers.StorageGCController
+	Replication    *controllers.ReplicationController
+	Scheduler      *controllers.SchedulerController
+	Database       *controllers.DatabaseController
+	Config         *controllers.ConfigController
+	Hub            *controllers.Hub
+	Lifecycle      *lifecycle.Manager
+	OpenAPI        http.HandlerFunc
+	Metrics        http.HandlerFunc
+}
+
+// APIVersions are the major versions of the API, oldest first
+var APIVersions = []int{1, 2}
+
+// Response cache policies of expensive reads. Lists are cached per caller,
+// as they only hold the matches the caller may see; team routes are checked
+// for access before the cache, so a team's statistics are shared within an
+// organization.
+var (
+	cacheList      = middleware.CachePolicy{TTL: 30 * time.Second, Vary: middleware.CacheVaryUser}
+	cacheAggregate = middleware.CachePolicy{TTL: 5 * time.Minute, Vary: middleware.CacheVaryUser}
+	cacheTeam      = middleware.CachePolicy{TTL: 5 * time.Minute, Vary: middleware.CacheVaryOrg}
+)
+
+/**
+ * APIRoutes declares every API endpoint with its auth policy and rate limit
+ * class. The router, the OpenAPI documents and the authorization tests are
+ * all generated from this table, so a route's policies are stated once, here.
+ *
+ * @param c The controllers to dispatch to
+ * @return The route declarations in matching order
+ */
+func APIRoutes(c *Controllers) []Route {
+	var routes []Route
+	for _, version := range APIVersions {
+		routes = append(routes, versionRoutes(c, version)...)
+	}
+
+	// Real-time updates. Browsers cannot set headers on WebSocket upgrades.
+	// Prometheus scrapes without credentials; keep /metrics off the public
+	// network.
+	return append(routes,
+		Route{Name: "webSocket", Method: "GET", Path: "/ws", Tag: "realtime", Summary: "WebSocket for real-time updates",
+			Handler: c.Hub.ServeHTTP, Auth: AuthPublic},
+		Route{Name: "getMetrics", Method: "GET", Path: "/metrics", Tag: "service", Summary: "Prometheus metrics, such as repository query durations",
+			Handler: c.Metrics, Auth: AuthPublic})
+}
+
+/**
+ * versionRoutes declares the endpoints of one API version. All versions are
+ * wired to the same controllers; handlers pick the response shape by the
+ * version of the request (requestctx.Info.APIVersion), so a breaking change
+ * in a newer version is made in the handler, not by copying routes.
+ *
+ * @param c The controllers to dispatch to
+ * @param version The major API version
+ * @return The version's route declarations in matching order
+ */
+func versionRoutes(c *Controllers, version int) []Route {
+	v := versionPrefix(version)
+	routes := []Route{
+		// Service
+		{Name: "healthCheck", Method: "GET", Path: v + "/health", Tag: "service", Summary: "Report service health",
+			Handler: controllers.HealthCheck, Auth: AuthPublic},
+		{Name: "readinessCheck", Method: "GET", Path: v + "/ready", Tag: "service", Summary: "Report whether the instance accepts new traffic",
+			Handler: controllers.Readiness(c.Lifecycle), Auth: AuthPublic},
+		{Name: "getOpenAPI", Method: "GET", Path: v + "/openapi.json", Tag: "service", Summary: "OpenAPI document of this API",
+			Handler: c.OpenAPI, Auth: AuthPublic, RateLimit: RateLimitDefault},
+		{Name: "getBootstrap", Method: "GET", Path: v + "/bootstrap", Tag: "service", Summary: "Aggregate startup data for the frontend",
+			Handler: c.Bootstrap.GetBootstrap, Auth: AuthUser, RateLimit: RateLimitDefault},
+		{Name: "getUsage", Method: "GET", Path: v + "/usage", Tag: "service", Summary: "Storage used by the caller and their organization, with quotas",
+			Handler: c.Usage.GetUsage, Auth: AuthUser, RateLimit: RateLimitDefault},
+		{Name: "getStatsOverview", Method: "GET", Path: v + "/stats/overview", Tag: "service", Summary: "Dashboard statistics: matches by state, storage, recent uploads and processing time",
+			Handler: c.Stats.GetOverview, Auth: AuthUser, RateLimit: RateLimitDefault},
+
+		// Auth
+		{Name: "login", Method: "POST", Path: v + "/auth/login", Tag: "auth", Summary: "Exchange credentials for a token",
+			Handler: controllers.Login, Auth: AuthPublic, RateLimit: RateLimitAuth, Critical: true},
+		{Name: "refreshToken", Method: "POST", Path: v + "/auth/refresh", Tag: "auth", Summary: "Refresh an access token",
+			Handler: controllers.RefreshToken, Auth: AuthPublic, RateLimit: RateLimitAuth, Critical: true},
+
+		// Videos
+		{Name: "listVideos", Method: "GET", Path: v + "/videos", Tag: "videos", Summary: "List videos",
+			Handler: c.Video.ListVideos, Auth: AuthUser, RateLimit: RateLimitDefault},
+		{Name: "uploadVideo", Method: "POST", Path: v + "/videos", Tag: "videos", Summary: "Upload a match video",
+			Handler: c.Video.UploadVideo, Auth: AuthUser, RateLimit: RateLimitUpload, BodyLimit: BodyLimitUpload},
+		{Name: "presignUpload", Method: "POST", Path: v + "/uploads/presign", Tag: "videos", Summary: "Get URLs to upload match files directly to storage",
+			Handler: c.Video.PresignUpload, Auth: AuthUser, RateLimit: RateLimitUpload},
+		{Name: "finalizeUpload", Method: "POST", Path: v + "/uploads/{id}/finalize", Tag: "videos", Summary: "Verify directly uploaded files and start processing",
+			Handler: c.Video.FinalizeUpload, Auth: AuthUser, RateLimit: RateLimitUpload},
+		{Name: "getUploadProgress", Method: "GET", Path: v + "/uploads/{id}/progress", Tag: "videos", Summary: "Get the progress of copying an upload to storage",
+			Handler: c.Video.GetUploadProgress, Auth: AuthUser, RateLimit: RateLimitDefault},
+		{Name: "getVideo", Method: "GET", Path: v + "/videos/{id}", Tag: "videos", Summary: "Get a video",
+			Handler: c.Video.GetVideo, Auth: AuthUser, RateLimit: RateLimitDefault, Match: "id"},
+		{Name: "getVideoHistory", Method: "GET", Path: v + "/videos/{id}/history", Tag: "videos", Summary: "Get the processing state changes of a video",
+			Handler: c.Video.GetVideoHistory, Auth: AuthUser, RateLimit: RateLimitDefault, Match: "id"},
+		{Name: "deleteVideo", Method: "DELETE", Path: v + "/videos/{id}", Tag: "videos", Summary: "Delete a video",
+			Handler: c.Video.DeleteVideo, Auth: AuthUser, RateLimit: RateLimitDefault, Match: "id"},
+		{Name: "undeleteVideo", Method: "POST", Path: v + "/videos/{id}/undelete", Tag: "videos", Summary: "Undo the deletion of a video within its grace period",
+			Handler: c.Video.UndeleteVideo, Auth: AuthUser, RateLimit: RateLimitDefault, Match: "id"},
+
+		// Analytics. image_search precedes players/{id}, which would match it.
+		{Name: "getMatchAnalytics", Method: "GET", Path: v + "/analytics/matches/{id}", Tag: "analytics", Summary: "Match analytics",
+			Handler: c.Analytics.GetMatchAnalytics, Auth: AuthUser, RateLimit: RateLimitDefault, Match: "id"},
+		{Name: "exportMatchAnalytics", Method: "GET", Path: v + "/analytics/matches/{id}/export", Tag: "analytics", Summary: "Download match analytics as CSV or XLSX",
+			Handler: c.Analytics.ExportMatchAnalytics, Auth: AuthUser, RateLimit: RateLimitDefault, Match: "id"},
+		{Name: "searchPlayerImage", Method: "GET", Path: v + "/analytics/players/image_search", Tag: "analytics", Summary: "Find a player image by name",
+			Handler: c.Player.SearchPlayerImage, Auth: AuthUser, RateLimit: RateLimitDefault},
+		{Name: "getPlayerAnalytics", Method: "GET", Path: v + "/analytics/players/{id}", Tag: "analytics", Summary: "Player analytics",
+			Handler: c.Analytics.GetPlayerAnalytics, Auth: AuthUser, RateLimit: RateLimitDefault, Match: "?match_id"},
+		{Name: "getPlayerAggregate", Method: "GET", Path: v + "/analytics/players/{id}/aggregate", Tag: "analytics", Summary: "Player statistics across matches",
+			Handler: c.Player.GetPlayerAggregate, Auth: AuthUser, RateLimit: RateLimitExpensive, Cache: cacheAggregate},
+		{Name: "getTeamAnalytics", Method: "GET", Path: v + "/analytics/teams/{id}", Tag: "analytics", Summary: "Team analytics",
+			Handler: c.Analytics.GetTeamAnalytics, Auth: AuthUser, RateLimit: RateLimitDefault, Match: "?match_id"},
+		{Name: "getTeamSeason", Method: "GET", Path: v + "/analytics/teams/{id}/season", Tag: "analytics", Summary: "Team statistics over a season",
+			Handler: c.Season.GetTeamSeason, Auth: AuthUser, RateLimit: RateLimitExpensive, Team: "id", Cache: cacheTeam},
+
+		// Players
+		{Name: "listPlayers", Method: "GET", Path: v + "/players", Tag: "players", Summary: "List the player roster",
+			Handler: c.PlayerIdentity.ListPlayers, Auth: AuthUser, RateLimit: RateLimitDefault},
+		{Name: "createPlayer", Method: "POST", Path: v + "/players", Tag: "players", Summary: "Add a player to the roster",
+			Handler: c.PlayerIdentity.CreatePlayer, Auth: AuthUser, RateLimit: RateLimitDefault},
+		{Name: "getPlayer", Method: "GET", Path: v + "/players/{id}", Tag: "players", Summary: "Get a roster player",
+			Handler: c.PlayerIdentity.GetPlayer, Auth: AuthUser, RateLimit: RateLimitDefault},
+		{Name: "listMatchPlayers", Method: "GET", Path: v + "/matches/{id}/players", Tag: "players", Summary: "List a match's tracking players mapped to the roster",
+			Handler: c.PlayerIdentity.ListMatchPlayers, Auth: AuthUser, RateLimit: RateLimitDefault, Match: "id"},
+		{Name: "autoMapMatchPlayers", Method: "POST", Path: v + "/matches/{id}/players/auto-map", Tag: "players", Summary: "Map a match's tracking players to the roster from event data and jersey numbers",
+			Handler: c.PlayerIdentity.AutoMapMatchPlayers, Auth: AuthUser, RateLimit: RateLimitExpensive, Match: "id"},
+		{Name: "mapMatchPlayer", Method: "PUT", Path: v + "/matches/{id}/players/{tracking_id}", Tag: "players", Summary: "Map a match's tracking player to a roster player",
+			Handler: c.PlayerIdentity.MapMatchPlayer, Auth: AuthUser, RateLimit: RateLimitDefault, Match: "id"},
+		{Name: "unmapMatchPlayer", Method: "DELETE", Path: v + "/matches/{id}/players/{tracking_id}", Tag: "players", Summary: "Remove the mapping of a match's tracking player",
+			Handler: c.PlayerIdentity.UnmapMatchPlayer, Auth: AuthUser, RateLimit: RateLimitDefault, Match: "id"},
+
+		// Share links, and the shared matches they open without an account
+		{Name: "createMatchShare", Method: "POST", Path: v + "/matches/{id}/share", Tag: "sharing", Summary: "Create an expiring share link for a match",
+			Handler: c.Share.CreateShare, Auth: AuthUser, RateLimit: RateLimitDefault, Match: "id"},
+		{Name: "listMatchShares", Method: "GET", Path: v + "/matches/{id}/shares", Tag: "sharing", Summary: "List a match's share links",
+			Handler: c.Share.ListShares, Auth: AuthUser, RateLimit: RateLimitDefault, Match: "id"},
+		{Name: "revokeMatchShare", Method: "DELETE", Path: v + "/matches/{id}/shares/{share_id}", Tag: "sharing", Summary: "Revoke a share link",
+			Handler: c.Share.RevokeShare, Auth: AuthUser, RateLimit: RateLimitDefault, Match: "id"},
+		{Name: "listMatchShareAccesses", Method: "GET", Path: v + "/matches/{id}/shares/{share_id}/accesses", Tag: "sharing", Summary: "List the uses of a share link",
+			Handler: c.Share.ListShareAccesses, Auth: AuthUser, RateLimit: RateLimitDefault, Match: "id"},
+		{Name: "getSharedMatch", Method: "GET", Path: v + "/shared/{token}", Tag: "sharing", Summary: "Get a shared match",
+			Handler: c.Share.GetSharedMatch, Auth: AuthPublic, RateLimit: RateLimitDefault},
+		{Name: "getSharedAnalytics", Method: "GET", Path: v + "/shared/{token}/analytics", Tag: "sharing", Summary: "Get a shared match's analytics",
+			Handler: c.Share.GetSharedAnalytics, Auth: AuthPublic, RateLimit: RateLimitDefault},
+		{Name: "getSharedVideo", Method: "GET", Path: v + "/shared/{token}/video", Tag: "sharing", Summary: "Stream a shared match's video",
+			Handler: c.Share.GetSharedVideo, Auth: AuthPublic, RateLimit: RateLimitDefault},
+
+		// Matches
+		{Name: "listMatches", Method: "GET", Path: v + "/matches", Tag: "matches", Summary: "List matches",
+			Handler: c.Match.ListMatches, Auth: AuthUser, RateLimit: RateLimitDefault, Cache: cacheList},
+		{Name: "listMatchDays", Method: "GET", Path: v + "/matches/match-day", Tag: "matches", Summary: "List matches in match-day mode",
+			Handler: c.MatchDay.ListActive, Auth: AuthUser, RateLimit: RateLimitDefault},
+		{Name: "getMatchCalendar", Method: "GET", Path: v + "/matches/calendar", Tag: "matches", Summary: "Group matches by week or month with status counts",
+			Handler: c.Match.GetCalendar, Auth: AuthUser, RateLimit: RateLimitDefault, Cache: cacheList},
+		{Name: "getMatch", Method: "GET", Path: v + "/matches/{id}", Tag: "matches", Summary: "Get a match with its videos, files, analytics status and clip count",
+			Handler: c.Match.GetMatch, Auth: AuthUser, RateLimit: RateLimitDefault, Match: "id"},
+		{Name: "getMatchDay", Method: "GET", Path: v + "/matches/{id}/match-day", Tag: "matches", Summary: "Get a match's match-day window",
+			Handler: c.MatchDay.GetMatchDay, Auth: AuthUser, RateLimit: RateLimitDefault, Match: "id"},
+		{Name: "updateMatchDay", Method: "PUT", Path: v + "/matches/{id}/match-day", Tag: "matches", Summary: "Set a match's match-day window",
+			Handler: c.MatchDay.UpdateMatchDay, Auth: AuthUser, RateLimit: RateLimitDefault, Match: "id"},
+		{Name: "getMatchRetention", Method: "GET", Path: v + "/matches/{id}/retention", Tag: "matches", Summary: "Get a match's storage retention rules",
+			Handler: c.Retention.GetMatchRetention, Auth: AuthUser, RateLimit: RateLimitDefault, Match: "id"},
+		{Name: "updateMatchRetention", Method: "PUT", Path: v + "/matches/{id}/retention", Tag: "matches", Summary: "Override a match's storage retention rules",
+			Handler: c.Retention.UpdateMatchRetention, Auth: AuthAdmin, RateLimit: RateLimitDefault},
+		{Name: "archiveMatch", Method: "POST", Path: v + "/matches/{id}/archive", Tag: "matches", Summary: "Move a match's large files to cold storage",
+			Handler: c.Archive.ArchiveMatch, Auth: AuthAdmin, RateLimit: RateLimitExpensive},
+		{Name: "reprocessMatch", Method: "POST", Path: v + "/matches/{id}/reprocess", Tag: "matches", Summary: "Send a match's stored files to analytics again",
+			Handler: c.Video.ReprocessMatch, Auth: AuthAdmin, RateLimit: RateLimitExpensive},
+		{Name: "importMatchEvents", Method: "POST", Path: v + "/matches/{id}/events/import", Tag: "matches", Summary: "Replace a match's events with a StatsBomb or Opta feed and reprocess it",
+			Handler: c.Video.ImportMatchEvents, Auth: AuthAdmin, RateLimit: RateLimitUpload, BodyLimit: BodyLimitImport},
+		{Name: "cancelMatchProcessing", Method: "POST", Path: v + "/matches/{id}/cancel", Tag: "matches", Summary: "Abort a match's analytics processing",
+			Handler: c.Video.CancelMatch, Auth: AuthAdmin, RateLimit: RateLimitDefault},
+		{Name: "listJobs", Method: "GET", Path: v + "/jobs", Tag: "matches", Summary: "List analytics jobs by status, with the errors of failed ones",
+			Handler: c.Video.ListJobs, Auth: AuthAdmin, RateLimit: RateLimitDefault},
+		{Name: "retryJob", Method: "POST", Path: v + "/jobs/{id}/retry", Tag: "matches", Summary: "Send a failed analytics job to the Python API again",
+			Handler: c.Video.RetryJob, Auth: AuthAdmin, RateLimit: RateLimitExpensive},
+		{Name: "restoreMatch", Method: "POST", Path: v + "/matches/{id}/restore", Tag: "matches", Summary: "Restore a match's files from cold storage",
+			Handler: c.Archive.RestoreMatch, Auth: AuthUser, RateLimit: RateLimitExpensive, Match: "id"},
+		{Name: "getArchiveJob", Method: "GET", Path: v + "/archive-jobs/{id}", Tag: "matches", Summary: "Get an archive or restore job",
+			Handler: c.Archive.GetArchiveJob, Auth: AuthUser, RateLimit: RateLimitDefault},
+		{Name: "downloadMatchFile", Method: "GET", Path: v + "/matches/{id}/files/{type}", Tag: "matches", Summary: "Download an uploaded tracking, events or video file",
+			Handler: c.Video.DownloadMatchFile, Auth: AuthUser, RateLimit: RateLimitDefault, Match: "id"},
+		{Name: "downloadSignedFile", Method: "GET", Path: v + "/files/{token}", Tag: "matches", Summary: "Download a stored file through a temporary signed URL",
+			Handler: c.File.ServeSignedFile, Auth: AuthPublic, RateLimit: RateLimitDefault},
+		{Name: "exportMatch", Method: "GET", Path: v + "/matches/{id}/export", Tag: "matches", Summary: "Download a match with its files as a tar.gz bundle",
+			Handler: c.Video.ExportMatch, Auth: AuthAdmin, RateLimit: RateLimitExpensive},
+		{Name: "importMatch", Method: "POST", Path: v + "/matches/import", Tag: "matches", Summary: "Create a match from an exported bundle and start its processing",
+			Handler: c.Video.ImportMatch, Auth: AuthAdmin, RateLimit: RateLimitUpload, BodyLimit: BodyLimitUpload},
+		{Name: "createMatchDraft", Method: "POST", Path: v + "/matches", Tag: "matches", Summary: "Create a draft match from its metadata, to attach its files to later",
+			Handler: c.Video.CreateDraft, Auth: AuthUser, RateLimit: RateLimitDefault},
+		{Name: "attachMatchFile", Method: "PUT", Path: v + "/matches/{id}/files/{type}", Tag: "matches", Summary: "Attach a tracking, events or video file to a draft match",
+			Handler: c.Video.AttachDraftFile, Auth: AuthUser, RateLimit: RateLimitUpload, BodyLimit: BodyLimitUpload, Match: "id"},
+		{Name: "submitMatchDraft", Method: "POST", Path: v + "/matches/{id}/submit", Tag: "matches", Summary: "Start the processing of a draft match with its files attached",
+			Handler: c.Video.SubmitDraft, Auth: AuthUser, RateLimit: RateLimitDefault, Match: "id"},
+		{Name: "startMatchReport", Method: "POST", Path: v + "/matches/{id}/report", Tag: "reports", Summary: "Start generating a PDF match report",
+			Handler: c.Report.StartReport, Auth: AuthUser, RateLimit: RateLimitExpensive, Match: "id"},
+
+		// Reports
+		{Name: "getReport", Method: "GET", Path: v + "/reports/{id}", Tag: "reports", Summary: "Get a report job",
+			Handler: c.Report.GetReport, Auth: AuthUser, RateLimit: RateLimitDefault},
+		{Name: "downloadReport", Method: "GET", Path: v + "/reports/{id}/download", Tag: "reports", Summary: "Download a completed PDF report",
+			Handler: c.Report.DownloadReport, Auth: AuthUser, RateLimit: RateLimitDefault},
+
+		// Webhooks
+		{Name: "listWebhooks", Method: "GET", Path: v + "/webhooks", Tag: "webhooks", Summary: "List webhook subscriptions",
+			Handler: c.Webhook.ListWebhooks, Auth: AuthAdmin, RateLimit: RateLimitDefault},
+		{Name: "createWebhook", Method: "POST", Path: v + "/webhooks", Tag: "webhooks", Summary: "Create a webhook subscription",
+			Handler: c.Webhook.CreateWebhook, Auth: AuthAdmin, RateLimit: RateLimitDefault},
+		{Name: "getWebhook", Method: "GET", Path: v + "/webhooks/{id}", Tag: "webhooks", Summary: "Get a webhook subscription",
+			Handler: c.Webhook.GetWebhook, Auth: AuthAdmin, RateLimit: RateLimitDefault},
+		{Name: "deleteWebhook", Method: "DELETE", Path: v + "/webhooks/{id}", Tag: "webhooks", Summary: "Delete a webhook subscription",
+			Handler: c.Webhook.DeleteWebhook, Auth: AuthAdmin, RateLimit: RateLimitDefault},
+		{Name: "listWebhookDeliveries", Method: "GET", Path: v + "/webhooks/{id}/deliveries", Tag: "webhooks", Summary: "List deliveries of a webhook",
+			Handler: c.Webhook.ListDeliveries, Auth: AuthAdmin, RateLimit: RateLimitDefault},
+
+		// Notifications
+		{Name: "getNotificationPreferences", Method: "GET", Path: v + "/notifications/preferences", Tag: "notifications", Summary: "Get the caller's notification preferences",
+			Handler: c.Notification.GetPreferences, Auth: AuthUser, RateLimit: RateLimitDefault},
+		{Name: "updateNotificationPreferences", Method: "PUT", Path: v + "/notifications/preferences", Tag: "notifications", Summary: "Choose which notifications the caller receives, and where",
+			Handler: c.Notification.UpdatePreferences, Auth: AuthUser, RateLimit: RateLimitDefault},
+		{Name: "listNotifications", Method: "GET", Path: v + "/notifications", Tag: "notifications", Summary: "List the caller's in-app notifications",
+			Handler: c.Notification.ListNotifications, Auth: AuthUser, RateLimit: RateLimitDefault},
+		{Name: "markAllNotificationsRead", Method: "POST", Path: v + "/notifications/read-all", Tag: "notifications", Summary: "Mark all of the caller's notifications read",
+			Handler: c.Notification.MarkAllNotificationsRead, Auth: AuthUser, RateLimit: RateLimitDefault},
+		{Name: "markNotificationRead", Method: "POST", Path: v + "/notifications/{id}/read", Tag: "notifications", Summary: "Mark a notification read",
+			Handler: c.Notification.MarkNotificationRead, Auth: AuthUser, RateLimit: RateLimitDefault},
+
+		// Access grants to single matches and teams
+		{Name: "listAccessGrants", Method: "GET", Path: v + "/access-grants", Tag: "access", Summary: "List access grants on a match or team, or of a user or group",
+			Handler: c.Access.ListGrants, Auth: AuthAdmin, RateLimit: RateLimitDefault},
+		{Name: "createAccessGrant", Method: "POST", Path: v + "/access-grants", Tag: "access", Summary: "Give a user or group access to a match or team",
+			Handler: c.Access.CreateGrant, Auth: AuthAdmin, RateLimit: RateLimitDefault},
+		{Name: "revokeAccessGrant", Method: "DELETE", Path: v + "/access-grants/{id}", Tag: "access", Summary: "Revoke an access grant",
+			Handler: c.Access.RevokeGrant, Auth: AuthAdmin, RateLimit: RateLimitDefault},
+
+		// Admin
+		{Name: "listAudits", Method: "GET", Path: v + "/admin/audits", Tag: "admin", Summary: "List consistency audits",
+			Handler: c.Audit.ListAudits, Auth: AuthAdmin, RateLimit: RateLimitDefault},
+		{Name: "startAudit", Method: "POST", Path: v + "/admin/audits", Tag: "admin", Summary: "Start a consistency audit",
+			Handler: c.Audit.StartAudit, Auth: AuthAdmin, RateLimit: RateLimitExpensive},
+		{Name: "getAudit", Method: "GET", Path: v + "/admin/audits/{id}", Tag: "admin", Summary: "Get a consistency audit",
+			Handler: c.Audit.GetAudit, Auth: AuthAdmin, RateLimit: RateLimitDefault},
+		{Name: "getRetentionReport", Method: "GET", Path: v + "/admin/retention/report", Tag: "admin", Summary: "Dry-run report of files due for retention",
+			Handler: c.Retention.GetRetentionReport, Auth: AuthAdmin, RateLimit: RateLimitExpensive},
+		{Name: "sweepRetention", Method: "POST", Path: v + "/admin/retention/sweep", Tag: "admin", Summary: "Archive or delete files due for retention now",
+			Handler: c.Retention.SweepRetention, Auth: AuthAdmin, RateLimit: RateLimitExpensive},
+		{Name: "getOrphanReport", Method: "GET", Path: v + "/admin/storage/orphans", Tag: "admin", Summary: "Dry-run report of orphaned and missing match files",
+			Handler: c.StorageGC.GetOrphanReport, Auth: AuthAdmin, RateLimit: RateLimitExpensive},
+		{Name: "collectOrphans", Method: "POST", Path: v + "/admin/storage/gc", Tag: "admin", Summary: "Delete stored files no match refers to",
+			Handler: c.StorageGC.CollectOrphans, Auth: AuthAdmin, RateLimit: RateLimitExpensive},
+		{Name: "getReplicationStats", Method: "GET", Path: v + "/admin/storage/replication", Tag: "admin", Summary: "Storage replication lag and failover reads",
+			Handler: c.Replication.GetReplicationStats, Auth: AuthAdmin, RateLimit: RateLimitDefault},
+		{Name: "listScheduledJobs", Method: "GET", Path: v + "/admin/scheduler/jobs", Tag: "admin", Summary: "Scheduled background jobs and their last runs",
+			Handler: c.Scheduler.ListScheduledJobs, Auth: AuthAdmin, RateLimit: RateLimitDefault},
+		{Name: "triggerScheduledJob", Method: "POST", Path: v + "/admin/scheduler/jobs/{name}/run", Tag: "admin", Summary: "Run a scheduled background job now",
+			Handler: c.Scheduler.TriggerScheduledJob, Auth: AuthAdmin, RateLimit: RateLimitExpensive},
+		{Name: "getDatabasePoolStats", Method: "GET", Path: v + "/admin/database/pools", Tag: "admin", Summary: "Database connection pool statistics",
+			Handler: c.Database.GetPoolStats, Auth: AuthAdmin, RateLimit: RateLimitDefault},
+		{Name: "getSLOs", Method: "GET", Path: v + "/admin/slo", Tag: "admin", Summary: "SLO status and error budgets",
+			Handler: c.SLO.GetSLOs, Auth: AuthAdmin, RateLimit: RateLimitDefault},
+		{Name: "getHTTPClientStats", Method: "GET", Path: v + "/admin/http-clients", Tag: "admin", Summary: "Outbound HTTP client statistics",
+			Handler: c.HTTPClient.GetStats, Auth: AuthAdmin, RateLimit: RateLimitDefault},
+		{Name: "getSupportBundle", Method: "GET", Path: v + "/admin/support-bundle", Tag: "admin", Summary: "Download a support bundle for a time window or match",
+			Handler: c.Support.GetSupportBundle, Auth: AuthAdmin, RateLimit: RateLimitExpensive},
+		{Name: "reloadConfig", Method: "POST", Path: v + "/admin/config/reload", Tag: "admin", Summary: "Reload the runtime-changeable settings of the configuration",
+			Handler: c.Config.ReloadConfig, Auth: AuthAdmin, RateLimit: RateLimitExpensive},
+		{Name: "listConfigReloads", Method: "GET", Path: v + "/admin/config/reloads", Tag: "admin", Summary: "Audit history of configuration reloads",
+			Handler: c.Config.ListReloads, Auth: AuthAdmin, RateLimit: RateLimitDefault},
+		{Name: "startBackfill", Method: "POST", Path: v + "/imports/backfill", Tag: "admin", Summary: "Import past matches listed in a CSV or JSON manifest in batches",
+			Handler: c.Backfill.StartBackfill, Auth: AuthAdmin, RateLimit: RateLimitExpensive, BodyLimit: BodyLimitImport},
+		{Name: "listBackfills", Method: "GET", Path: v + "/imports/backfill", Tag: "admin", Summary: "List backfill import jobs",
+			Handler: c.Backfill.ListBackfills, Auth: AuthAdmin, RateLimit: RateLimitDefault},
+		{Name: "getBackfill", Method: "GET", Path: v + "/imports/backfill/{id}", Tag: "admin", Summary: "Get a backfill import job and the progress of its matches",
+			Handler: c.Backfill.GetBackfill, Auth: AuthAdmin, RateLimit: RateLimitDefault},
+		{Name: "exportPlayerData", Method: "GET", Path: v + "/admin/players/{id}/export", Tag: "admin", Summary: "Download all data associated with a roster player",
+			Handler: c.PlayerPrivacy.ExportPlayerData, Auth: AuthAdmin, RateLimit: RateLimitExpensive},
+		{Name: "erasePlayer", Method: "POST", Path: v + "/admin/players/{id}/erase", Tag: "admin", Summary: "Pseudonymize or erase a player across matches",
+			Handler: c.PlayerPrivacy.ErasePlayer, Auth: AuthAdmin, RateLimit: RateLimitExpensive},
+		{Name: "listPlayerErasures", Method: "GET", Path: v + "/admin/player-erasures", Tag: "admin", Summary: "Audit trail of player erasures",
+			Handler: c.PlayerPrivacy.ListPlayerErasures, Auth: AuthAdmin, RateLimit: RateLimitDefault},
+		{Name: "listPendingPurges", Method: "GET", Path: v + "/admin/video-purges", Tag: "admin", Summary: "Deleted videos whose files wait to be purged",
+			Handler: c.Video.ListPendingPurges, Auth: AuthAdmin, RateLimit: RateLimitDefault},
+		{Name: "getVideoState", Method: "GET", Path: v + "/admin/videos/{id}/state", Tag: "admin", Summary: "Inspect the processing state, file paths and state history of a video",
+			Handler: c.Video.GetVideoState, Auth: AuthAdmin, RateLimit: RateLimitDefault},
+		{Name: "editVideoState", Method: "PATCH", Path: v + "/admin/videos/{id}/state", Tag: "admin", Summary: "Force the processing state or re-point the file paths of a video",
+			Handler: c.Video.EditVideoState, Auth: AuthAdmin, RateLimit: RateLimitDefault},
+	}
+	for i := range routes {
+		routes[i].Version = version
+	}
+	return routes
+}
@@ -0,0 +1,183 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"nivai/backend/pkg/middleware"
+)
+
+// pathParam matches mux path variables such as {id} or {id:[0-9]+}
+var pathParam = regexp.MustCompile(`\{([^}:]+)(?::[^}]*)?\}`)
+
+/**
+ * OpenAPIDocument is the subset of an OpenAPI 3.0 document generated from
+ * the route registry.
+ */
+type OpenAPIDocument struct {
+	OpenAPI    string                                 `json:"openapi"`
+	Info       OpenAPIInfo                            `json:"info"`
+	Paths      map[string]map[string]OpenAPIOperation `json:"paths"`
+	Components OpenAPIComponents                      `json:"components"`
+}
+
+/**
+ * OpenAPIInfo describes the API.
+ */
+type OpenAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+/**
+ * OpenAPIOperation describes one route. The auth policy and rate limit class
+ * are exposed as x- extensions.
+ */
+type OpenAPIOperation struct {
+	OperationID string                     `json:"operationId"`
+	Summary     string                     `json:"summary,omitempty"`
+	Tags        []string                   `json:"tags,omitempty"`
+	Deprecated  bool                       `json:"deprecated,omitempty"`
+	Parameters  []OpenAPIParameter         `json:"parameters,omitempty"`
+	Security    []map[string][]string      `json:"security"`
+	Responses   map[string]OpenAPIResponse `json:"responses"`
+	AuthPolicy  AuthPolicy                 `json:"x-auth-policy"`
+	RateLimit   RateLimitClass             `json:"x-rate-limit-class,omitempty"`
+}
+
+/**
+ * OpenAPIParameter describes a path parameter.
+ */
+type OpenAPIParameter struct {
+	Name     string            `json:"name"`
+	In       string            `json:"in"`
+	Required bool              `json:"required"`
+	Schema   map[string]string `json:"schema"`
+}
+
+/**
+ * OpenAPIResponse describes a response status.
+ */
+type OpenAPIResponse struct {
+	Description string                      `json:"description"`
+	Content     map[string]OpenAPIMediaType `json:"content,omitempty"`
+}
+
+/**
+ * OpenAPIMediaType references the schema of a response body.
+ */
+type OpenAPIMediaType struct {
+	Schema map[string]string `json:"schema"`
+}
+
+/**
+ * OpenAPIComponents holds the security schemes and shared schemas.
+ */
+type OpenAPIComponents struct {
+	SecuritySchemes map[string]map[string]string `json:"securitySchemes"`
+	Schemas         map[string]interface{}       `json:"schemas,omitempty"`
+}
+
+// errorSchema describes the JSON body of every error response (see httperr)
+var errorSchema = map[string]interface{}{
+	"type":     "object",
+	"required": []string{"code", "message"},
+	"properties": map[string]interface{}{
+		"code":       map[string]string{"type": "string", "description": "Stable error code, e.g. not_found"},
+		"message":    map[string]string{"type": "string"},
+		"details":    map[string]string{"type": "object"},
+		"request_id": map[string]string{"type": "string"},
+	},
+}
+
+// errorResponse is a response answered with an error body
+func errorResponse(description string) OpenAPIResponse {
+	return OpenAPIResponse{
+		Description: description,
+		Content: map[string]OpenAPIMediaType{
+			"application/json": {Schema: map[string]string{"$ref": "#/components/schemas/Error"}},
+		},
+	}
+}
+
+/**
+ * OpenAPI generates the OpenAPI document of one API version, covering its
+ * routes and the unversioned ones.
+ *
+ * @param title The API title
+ * @param apiVersion The major API version
+ * @return The generated document
+ */
+func (r *Registry) OpenAPI(title string, apiVersion int) *OpenAPIDocument {
+	doc := &OpenAPIDocument{
+		OpenAPI: "3.0.3",
+		Info:    OpenAPIInfo{Title: title, Version: fmt.Sprintf("%d.0.0", apiVersion)},
+		Paths:   map[string]map[string]OpenAPIOperation{},
+		Components: OpenAPIComponents{SecuritySchemes: map[string]map[string]string{
+			"bearerAuth": {"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+		}, Schemas: map[string]interface{}{"Error": errorSchema}},
+	}
+
+	for _, route := range r.routes {
+		if route.Version != 0 && route.Version != apiVersion {
+			continue
+		}
+		path := pathParam.ReplaceAllString(route.Path, "{$1}")
+		op := OpenAPIOperation{
+			OperationID: route.Name,
+			Summary:     route.Summary,
+			Deprecated:  route.Deprecated || r.versions[route.Version].Deprecated,
+			Security:    []map[string][]string{},
+			Responses:   map[string]OpenAPIResponse{"default": {Description: "Response"}},
+			AuthPolicy:  route.Auth,
+			RateLimit:   route.RateLimit,
+		}
+		if route.Tag != "" {
+			op.Tags = []string{route.Tag}
+		}
+		for _, match := range pathParam.FindAllStringSubmatch(route.Path, -1) {
+			op.Parameters = append(op.Parameters, OpenAPIParameter{
+				Name: match[1], In: "path", Required: true, Schema: map[string]string{"type": "string"},
+			})
+		}
+		if route.Auth != AuthPublic {
+			op.Security = []map[string][]string{{"bearerAuth": {}}}
+			op.Responses["401"] = errorResponse("Missing or invalid credentials")
+		}
+		if route.Auth == AuthAdmin {
+			op.Responses["403"] = errorResponse("Admin role required")
+		} else if route.Match != "" || route.Team != "" {
+			op.Responses["403"] = errorResponse("No access granted to the match or team")
+		}
+		if route.RateLimit != RateLimitNone {
+			op.Responses["429"] = errorResponse("Rate limit exceeded")
+		}
+
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = map[string]OpenAPIOperation{}
+		}
+		doc.Paths[path][strings.ToLower(route.Method)] = op
+	}
+	return doc
+}
+
+/**
+ * OpenAPIHandler serves the generated OpenAPI document of the API version
+ * the request was made against as JSON.
+ *
+ * @param title The API title
+ * @return The handler
+ */
+func (r *Registry) OpenAPIHandler(title string) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		apiVersion := middleware.PathAPIVersion(req.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(r.OpenAPI(title, apiVersion)); err != nil {
+			log.Printf("Error encoding OpenAPI document: %v", err)
+		}
+	}
+}
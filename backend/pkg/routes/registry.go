@@ -0,0 +1,403 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"nivai/backend/pkg/middleware"
+
+	"github.com/gorilla/mux"
+)
+
+/**
+ * AuthPolicy states who may call a route.
+ */
+type AuthPolicy string
+
+const (
+	// AuthPublic routes need no credentials
+	AuthPublic AuthPolicy = "public"
+	// AuthUser routes need an authenticated user
+	AuthUser AuthPolicy = "user"
+	// AuthAdmin routes need an authenticated user with the admin role
+	AuthAdmin AuthPolicy = "admin"
+)
+
+/**
+ * RateLimitClass groups routes that share a per-client rate limit.
+ * The limits per class are configured in config.RateLimits.
+ */
+type RateLimitClass string
+
+const (
+	// RateLimitNone disables rate limiting for a route
+	RateLimitNone RateLimitClass = ""
+	// RateLimitDefault applies to ordinary reads and writes
+	RateLimitDefault RateLimitClass = "default"
+	// RateLimitExpensive applies to routes that fan out to many upstream calls
+	RateLimitExpensive RateLimitClass = "expensive"
+	// RateLimitUpload applies to file uploads
+	RateLimitUpload RateLimitClass = "upload"
+	// RateLimitAuth applies to login and token refresh
+	RateLimitAuth RateLimitClass = "auth"
+)
+
+/**
+ * BodyLimitClass groups routes that share a request body size limit.
+ * The limits per class are configured in config.BodyLimits.
+ */
+type BodyLimitClass string
+
+const (
+	// BodyLimitDefault applies to JSON endpoints
+	BodyLimitDefault BodyLimitClass = ""
+	// BodyLimitImport applies to event feeds and backfill manifests
+	BodyLimitImport BodyLimitClass = "import"
+	// BodyLimitUpload applies to match files and bundles
+	BodyLimitUpload BodyLimitClass = "upload"
+)
+
+/**
+ * Route declares one API endpoint together with its policies.
+ */
+type Route struct {
+	Name       string // Unique operation name, also used as the OpenAPI operationId
+	Method     string
+	Path       string // Full mux path template, e.g. "/api/v1/videos/{id}"
+	Version    int    // Major API version of the path; 0 for unversioned routes
+	Summary    string
+	Tag        string // OpenAPI tag grouping related routes
+	Handler    http.HandlerFunc
+	Auth       AuthPolicy
+	RateLimit  RateLimitClass
+	BodyLimit  BodyLimitClass         // Which limit caps the request body
+	Deprecated bool                   // Responses carry a Deprecation header
+	Critical   bool                   // Writes that stay available while load is being shed
+	Match      string                 // Where the request names its match ("id", or "?match_id" for a query parameter); callers need access to it
+	Team       string                 // Where the request names its team; callers need access to all its matches
+	Cache      middleware.CachePolicy // How long successful GET responses are served from the response cache
+}
+
+/**
+ * VersionPolicy states the lifecycle of an API version. Every route of a
+ * deprecated version is deprecated, and its responses link to the same
+ * endpoint in the successor version, if that has it.
+ */
+type VersionPolicy struct {
+	Deprecated bool
+	Sunset     time.Time // When the version will be removed; zero if not announced
+	Successor  int       // The version replacing this one; 0 for none
+}
+
+/**
+ * Registry holds the declared routes and mounts them on a router with the
+ * middleware their policies require.
+ */
+type Registry struct {
+	routes       []Route
+	names        map[string]bool
+	endpoints    map[string]bool
+	versions     map[int]VersionPolicy
+	authenticate mux.MiddlewareFunc
+	requireAdmin mux.MiddlewareFunc
+	limiter      *middleware.RateLimiter
+	shedder      *middleware.LoadShedder
+	access       middleware.MatchAuthorizer
+	ipFilter     *middleware.IPFilter
+	cache        *middleware.ResponseCache
+	cacheTTLs    map[string]time.Duration
+	bodyLimits   map[BodyLimitClass]int64
+}
+
+/**
+ * RegistryOption configures a Registry.
+ */
+type RegistryOption func(*Registry)
+
+/**
+ * WithAuthenticator replaces the middleware used for AuthUser and AuthAdmin
+ * routes (middleware.Authenticate by default).
+ *
+ * @param authenticate The authentication middleware
+ * @return The registry option
+ */
+func WithAuthenticator(authenticate mux.MiddlewareFunc) RegistryOption {
+	return func(r *Registry) { r.authenticate = authenticate }
+}
+
+/**
+ * WithRateLimiter enforces the routes' rate limit classes with limiter.
+ * Without it, routes are not rate limited.
+ *
+ * @param limiter The rate limiter
+ * @return The registry option
+ */
+func WithRateLimiter(limiter *middleware.RateLimiter) RegistryOption {
+	return func(r *Registry) { r.limiter = limiter }
+}
+
+/**
+ * WithLoadShedder sheds non-critical writes (POST, PUT, PATCH and DELETE
+ * routes not marked Critical) with shedder. Without it, nothing is shed.
+ *
+ * @param shedder The load shedder
+ * @return The registry option
+ */
+func WithLoadShedder(shedder *middleware.LoadShedder) RegistryOption {
+	return func(r *Registry) { r.shedder = shedder }
+}
+
+/**
+ * WithAccessControl restricts routes naming a match or team to callers with
+ * access to it, as decided by authz. Without it, access is not checked.
+ *
+ * @param authz Decides on access to matches and teams
+ * @return The registry option
+ */
+func WithAccessControl(authz middleware.MatchAuthorizer) RegistryOption {
+	return func(r *Registry) { r.access = authz }
+}
+
+/**
+ * WithIPFilter refuses requests by client address with filter: its denylist
+ * and countries apply to every route, its admin allowlist to AuthAdmin
+ * routes and its upload allowlist to writes of the upload rate limit class.
+ * Without it, client addresses are not restricted.
+ *
+ * @param filter The IP filter
+ * @return The registry option
+ */
+func WithIPFilter(filter *middleware.IPFilter) RegistryOption {
+	return func(r *Registry) { r.ipFilter = filter }
+}
+
+/**
+ * WithResponseCache serves the routes with a cache policy from cache.
+ * ttls overrides the TTL of routes by name, compared case-insensitively; a
+ * zero TTL stops caching a route. Without it, nothing is cached.
+ *
+ * @param cache The response cache
+ * @param ttls TTL overrides by route name; may be nil
+ * @return The registry option
+ */
+func WithResponseCache(cache *middleware.ResponseCache, ttls map[string]time.Duration) RegistryOption {
+	return func(r *Registry) {
+		r.cache = cache
+		r.cacheTTLs = map[string]time.Duration{}
+		for name, ttl := range ttls {
+			r.cacheTTLs[strings.ToLower(name)] = ttl
+		}
+	}
+}
+
+/**
+ * WithBodyLimits caps request bodies by the routes' body limit classes.
+ * Classes without a positive limit, and all routes without this option, are
+ * not capped.
+ *
+ * @param limits The largest body in bytes per class
+ * @return The registry option
+ */
+func WithBodyLimits(limits map[BodyLimitClass]int64) RegistryOption {
+	return func(r *Registry) { r.bodyLimits = limits }
+}
+
+/**
+ * WithVersionPolicy sets the lifecycle of an API version. Versions without a
+ * policy are current.
+ *
+ * @param version The major API version
+ * @param policy The version's lifecycle
+ * @return The registry option
+ */
+func WithVersionPolicy(version int, policy VersionPolicy) RegistryOption {
+	return func(r *Registry) { r.versions[version] = policy }
+}
+
+/**
+ * NewRegistry creates an empty route registry.
+ *
+ * @param opts Registry options
+ * @return A new registry
+ */
+func NewRegistry(opts ...RegistryOption) *Registry {
+	r := &Registry{
+		names:        map[string]bool{},
+		endpoints:    map[string]bool{},
+		versions:     map[int]VersionPolicy{},
+		authenticate: middleware.Authenticate,
+		requireAdmin: middleware.RequireAdmin,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+/**
+ * Add declares routes. Routes are matched in the order they are added, so
+ * literal paths must precede templates that would also match them. Names
+ * are unique per API version. Incomplete or duplicate declarations are
+ * programming errors and panic.
+ *
+ * @param routes The routes to add
+ */
+func (r *Registry) Add(routes ...Route) {
+	for _, route := range routes {
+		if route.Name == "" || route.Method == "" || route.Path == "" || route.Handler == nil {
+			panic(fmt.Sprintf("routes: incomplete route declaration %q %s %s", route.Name, route.Method, route.Path))
+		}
+		switch route.Auth {
+		case AuthPublic, AuthUser, AuthAdmin:
+		default:
+			panic(fmt.Sprintf("routes: route %q has no auth policy", route.Name))
+		}
+		name := fmt.Sprintf("v%d %s", route.Version, route.Name)
+		endpoint := route.Method + " " + route.Path
+		if r.names[name] || r.endpoints[endpoint] {
+			panic(fmt.Sprintf("routes: duplicate route %q (%s)", route.Name, endpoint))
+		}
+		r.names[name] = true
+		r.endpoints[endpoint] = true
+		r.routes = append(r.routes, route)
+	}
+}
+
+/**
+ * Routes returns the declared routes in registration order.
+ *
+ * @return A copy of the declared routes
+ */
+func (r *Registry) Routes() []Route {
+	return append([]Route(nil), r.routes...)
+}
+
+/**
+ * Mount registers every route on router, wrapped in the middleware its
+ * policies require: the IP filter (first, so refused clients learn nothing
+ * about the route), then load shedding (so shed requests cost nothing),
+ * then the body limit (so oversized bodies are refused unread), then
+ * authentication, then the admin check, then the rate limit (so
+ * authenticated clients are limited per user), then the match or team
+ * access check, then deprecation headers, then the response cache (last,
+ * so cached responses are only served to callers who passed every check).
+ *
+ * @param router The router to register on
+ */
+func (r *Registry) Mount(router *mux.Router) {
+	for _, route := range r.routes {
+		router.Handle(route.Path, r.chain(route)).Methods(route.Method).Name(route.Name)
+	}
+}
+
+/**
+ * chain builds the handler for a route from its policies.
+ *
+ * @param route The route
+ * @return The wrapped handler
+ */
+func (r *Registry) chain(route Route) http.Handler {
+	var handler http.Handler = route.Handler
+	if r.cache != nil && route.Cache.TTL > 0 {
+		policy := route.Cache
+		if ttl, ok := r.cacheTTLs[strings.ToLower(route.Name)]; ok {
+			policy.TTL = ttl
+		}
+		handler = r.cache.Cache(policy)(handler)
+	}
+	if policy := r.versions[route.Version]; route.Deprecated || policy.Deprecated {
+		from, to := r.successor(route, policy)
+		handler = deprecated(handler, policy, from, to)
+	}
+	if r.access != nil && route.Match != "" {
+		handler = middleware.RequireMatchAccess(r.access, route.Match, isWrite(route.Method))(handler)
+	}
+	if r.access != nil && route.Team != "" {
+		handler = middleware.RequireTeamAccess(r.access, route.Team)(handler)
+	}
+	if route.RateLimit != RateLimitNone {
+		handler = r.limiter.Limit(string(route.RateLimit))(handler)
+	}
+	switch route.Auth {
+	case AuthAdmin:
+		handler = r.authenticate(r.requireAdmin(handler))
+	case AuthUser:
+		handler = r.authenticate(handler)
+	}
+	handler = middleware.LimitBody(r.bodyLimits[route.BodyLimit])(handler)
+	if isWrite(route.Method) && !route.Critical {
+		handler = r.shedder.Shed(handler)
+	}
+	return r.ipFilter.Restrict(ipScopes(route)...)(handler)
+}
+
+// ipScopes returns the IP filter scopes of a route.
+func ipScopes(route Route) []string {
+	var scopes []string
+	if route.Auth == AuthAdmin {
+		scopes = append(scopes, middleware.IPScopeAdmin)
+	}
+	if route.RateLimit == RateLimitUpload && isWrite(route.Method) {
+		scopes = append(scopes, middleware.IPScopeUpload)
+	}
+	return scopes
+}
+
+// isWrite reports whether a method modifies state.
+func isWrite(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+/**
+ * deprecated marks responses of a deprecated route (RFC 9745), with the
+ * version's sunset date (RFC 8594) and a link to the successor endpoint.
+ *
+ * @param next The route handler
+ * @param policy The lifecycle of the route's API version
+ * @param from The route's version prefix, e.g. "/api/v1"
+ * @param to The successor's version prefix; empty when there is no successor
+ * @return A handler adding the deprecation headers
+ */
+func deprecated(next http.Handler, policy VersionPolicy, from, to string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", "true")
+		if !policy.Sunset.IsZero() {
+			w.Header().Set("Sunset", policy.Sunset.UTC().Format(http.TimeFormat))
+		}
+		if to != "" {
+			path := to + strings.TrimPrefix(r.URL.Path, from)
+			w.Header().Add("Link", "<"+path+`>; rel="successor-version"`)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+/**
+ * successor finds the endpoint replacing a route in the successor version.
+ *
+ * @param route The deprecated route
+ * @param policy The lifecycle of the route's API version
+ * @return The route's version prefix and the successor's, or empty strings
+ *         when the successor has no such endpoint
+ */
+func (r *Registry) successor(route Route, policy VersionPolicy) (string, string) {
+	if route.Version == 0 || policy.Successor == 0 {
+		return "", ""
+	}
+	from, to := versionPrefix(route.Version), versionPrefix(policy.Successor)
+	if !strings.HasPrefix(route.Path, from) || !r.endpoints[route.Method+" "+to+strings.TrimPrefix(route.Path, from)] {
+		return "", ""
+	}
+	return from, to
+}
+
+// versionPrefix returns the path prefix of an API version: "/api/v2"
+func versionPrefix(version int) string {
+	return fmt.Sprintf("/api/v%d", version)
+}
@@ -0,0 +1,324 @@
+package routes_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"nivai/backend/pkg/middleware"
+	"nivai/backend/pkg/requestctx"
+	"nivai/backend/pkg/routes"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAuthenticate authenticates requests carrying an X-Test-Role header with
+// that role, and rejects all others.
+func fakeAuthenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		role := r.Header.Get("X-Test-Role")
+		if role == "" {
+			http.Error(w, "Authorization header required", http.StatusUnauthorized)
+			return
+		}
+		ctx, info := requestctx.Ensure(r.Context())
+		info.Principal = requestctx.Principal{UserID: "test-user", Role: role}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// stubbedAPIRoutes returns the API route table with every handler replaced by
+// one that answers 200.
+func stubbedAPIRoutes() []routes.Route {
+	declared := routes.APIRoutes(&routes.Controllers{})
+	for i := range declared {
+		declared[i].Handler = func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	}
+	return declared
+}
+
+// concretePath fills in the path variables of a route template.
+func concretePath(template string) string {
+	return strings.NewReplacer("{id}", "42", "{type}", "tracking").Replace(template)
+}
+
+func TestAPIRoutesAuthorizationMatrix(t *testing.T) {
+	registry := routes.NewRegistry(routes.WithAuthenticator(fakeAuthenticate))
+	registry.Add(stubbedAPIRoutes()...)
+	router := mux.NewRouter()
+	registry.Mount(router)
+
+	expected := map[routes.AuthPolicy]map[string]int{
+		routes.AuthPublic: {"": http.StatusOK, "user": http.StatusOK, middleware.RoleAdmin: http.StatusOK},
+		routes.AuthUser:   {"": http.StatusUnauthorized, "user": http.StatusOK, middleware.RoleAdmin: http.StatusOK},
+		routes.AuthAdmin:  {"": http.StatusUnauthorized, "user": http.StatusForbidden, middleware.RoleAdmin: http.StatusOK},
+	}
+
+	for _, route := range registry.Routes() {
+		for role, status := range expected[route.Auth] {
+			req := httptest.NewRequest(route.Method, concretePath(route.Path), nil)
+			if role != "" {
+				req.Header.Set("X-Test-Role", role)
+			}
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			var match mux.RouteMatch
+			require.True(t, router.Match(req, &match), route.Name)
+			assert.Equal(t, route.Name, match.Route.GetName(), "%s %s is shadowed", route.Method, route.Path)
+			assert.Equal(t, status, rr.Code, "%s as %q", route.Name, role)
+		}
+	}
+}
+
+func TestAPIRoutesRejectNonAdminsWithDefaultAuthenticator(t *testing.T) {
+	registry := routes.NewRegistry()
+	registry.Add(stubbedAPIRoutes()...)
+	router := mux.NewRouter()
+	registry.Mount(router)
+
+	expected := map[routes.AuthPolicy]int{routes.AuthUser: http.StatusOK, routes.AuthAdmin: http.StatusForbidden}
+	for _, route := range registry.Routes() {
+		status, ok := expected[route.Auth]
+		if !ok {
+			continue
+		}
+		req := httptest.NewRequest(route.Method, concretePath(route.Path), nil)
+		req.Header.Set("Authorization", "Bearer mock_jwt_token")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		assert.Equal(t, status, rr.Code, "%s as an authenticated user", route.Name)
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+
+	t.Run("Duplicate and incomplete routes panic", func(t *testing.T) {
+		registry := routes.NewRegistry()
+		registry.Add(routes.Route{Name: "a", Method: "GET", Path: "/a", Handler: ok, Auth: routes.AuthPublic})
+
+		assert.Panics(t, func() {
+			registry.Add(routes.Route{Name: "b", Method: "GET", Path: "/a", Handler: ok, Auth: routes.AuthPublic})
+		})
+		assert.Panics(t, func() {
+			registry.Add(routes.Route{Name: "a", Method: "POST", Path: "/a", Handler: ok, Auth: routes.AuthPublic})
+		})
+		assert.Panics(t, func() {
+			registry.Add(routes.Route{Name: "c", Method: "GET", Path: "/c", Handler: ok})
+		})
+		assert.Panics(t, func() {
+			registry.Add(routes.Route{Name: "d", Method: "GET", Path: "/d", Auth: routes.AuthPublic})
+		})
+	})
+
+	t.Run("Deprecated routes carry a Deprecation header", func(t *testing.T) {
+		registry := routes.NewRegistry()
+		registry.Add(
+			routes.Route{Name: "old", Method: "GET", Path: "/old", Handler: ok, Auth: routes.AuthPublic, Deprecated: true},
+			routes.Route{Name: "new", Method: "GET", Path: "/new", Handler: ok, Auth: routes.AuthPublic},
+		)
+		router := mux.NewRouter()
+		registry.Mount(router)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/old", nil))
+		assert.Equal(t, "true", rr.Header().Get("Deprecation"))
+
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/new", nil))
+		assert.Empty(t, rr.Header().Get("Deprecation"))
+	})
+
+	t.Run("Deprecated versions link to their successor", func(t *testing.T) {
+		sunset := time.Date(2027, 6, 30, 0, 0, 0, 0, time.UTC)
+		registry := routes.NewRegistry(routes.WithVersionPolicy(1, routes.VersionPolicy{Deprecated: true, Sunset: sunset, Successor: 2}))
+		registry.Add(
+			routes.Route{Name: "get", Method: "GET", Path: "/api/v1/things/{id}", Version: 1, Handler: ok, Auth: routes.AuthPublic},
+			routes.Route{Name: "legacy", Method: "GET", Path: "/api/v1/legacy", Version: 1, Handler: ok, Auth: routes.AuthPublic},
+			routes.Route{Name: "get", Method: "GET", Path: "/api/v2/things/{id}", Version: 2, Handler: ok, Auth: routes.AuthPublic},
+		)
+		router := mux.NewRouter()
+		registry.Mount(router)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/things/7", nil))
+		assert.Equal(t, "true", rr.Header().Get("Deprecation"))
+		assert.Equal(t, "Wed, 30 Jun 2027 00:00:00 GMT", rr.Header().Get("Sunset"))
+		assert.Equal(t, `</api/v2/things/7>; rel="successor-version"`, rr.Header().Get("Link"))
+
+		// Endpoints the successor dropped have no link
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/legacy", nil))
+		assert.Equal(t, "true", rr.Header().Get("Deprecation"))
+		assert.Empty(t, rr.Header().Get("Link"))
+
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/v2/things/7", nil))
+		assert.Empty(t, rr.Header().Get("Deprecation"))
+	})
+
+	t.Run("Rate limit classes are enforced", func(t *testing.T) {
+		registry := routes.NewRegistry(routes.WithRateLimiter(middleware.NewRateLimiter(map[string]int{"auth": 1})))
+		registry.Add(
+			routes.Route{Name: "login", Method: "POST", Path: "/login", Handler: ok, Auth: routes.AuthPublic, RateLimit: routes.RateLimitAuth},
+			routes.Route{Name: "health", Method: "GET", Path: "/health", Handler: ok, Auth: routes.AuthPublic},
+		)
+		router := mux.NewRouter()
+		registry.Mount(router)
+
+		var codes []int
+		for _, req := range []struct{ method, path string }{
+			{"POST", "/login"}, {"POST", "/login"}, {"GET", "/health"}, {"GET", "/health"},
+		} {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(req.method, req.path, nil))
+			codes = append(codes, rr.Code)
+		}
+		assert.Equal(t, []int{http.StatusOK, http.StatusTooManyRequests, http.StatusOK, http.StatusOK}, codes)
+	})
+
+	t.Run("Body limits apply by class", func(t *testing.T) {
+		registry := routes.NewRegistry(routes.WithBodyLimits(map[routes.BodyLimitClass]int64{
+			routes.BodyLimitDefault: 8,
+			routes.BodyLimitUpload:  64,
+		}))
+		registry.Add(
+			routes.Route{Name: "settings", Method: "PUT", Path: "/settings", Handler: ok, Auth: routes.AuthPublic},
+			routes.Route{Name: "upload", Method: "POST", Path: "/videos", Handler: ok, Auth: routes.AuthPublic, BodyLimit: routes.BodyLimitUpload},
+			routes.Route{Name: "import", Method: "POST", Path: "/imports", Handler: ok, Auth: routes.AuthPublic, BodyLimit: routes.BodyLimitImport},
+		)
+		router := mux.NewRouter()
+		registry.Mount(router)
+
+		var codes []int
+		for _, req := range []struct{ method, path string }{{"PUT", "/settings"}, {"POST", "/videos"}, {"POST", "/imports"}} {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(req.method, req.path, strings.NewReader(strings.Repeat("x", 16))))
+			codes = append(codes, rr.Code)
+		}
+		assert.Equal(t, []int{http.StatusRequestEntityTooLarge, http.StatusOK, http.StatusOK}, codes, "classes without a limit are not capped")
+	})
+
+	t.Run("Only non-critical writes are shed", func(t *testing.T) {
+		shedder := middleware.NewLoadShedder(middleware.LoadShedderConfig{}, map[string]middleware.DependencyCheck{
+			"database": func(ctx context.Context) error { return errors.New("down") },
+		})
+		shedder.Check(context.Background())
+		registry := routes.NewRegistry(routes.WithLoadShedder(shedder))
+		registry.Add(
+			routes.Route{Name: "upload", Method: "POST", Path: "/videos", Handler: ok, Auth: routes.AuthPublic},
+			routes.Route{Name: "list", Method: "GET", Path: "/videos", Handler: ok, Auth: routes.AuthPublic},
+			routes.Route{Name: "login", Method: "POST", Path: "/login", Handler: ok, Auth: routes.AuthPublic, Critical: true},
+		)
+		router := mux.NewRouter()
+		registry.Mount(router)
+
+		var codes []int
+		for _, req := range []struct{ method, path string }{{"POST", "/videos"}, {"GET", "/videos"}, {"POST", "/login"}} {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(req.method, req.path, nil))
+			codes = append(codes, rr.Code)
+		}
+		assert.Equal(t, []int{http.StatusServiceUnavailable, http.StatusOK, http.StatusOK}, codes)
+	})
+
+	t.Run("Cache policies apply after authentication, with overrides by name", func(t *testing.T) {
+		calls := map[string]int{}
+		counting := func(w http.ResponseWriter, r *http.Request) { calls[r.URL.Path]++ }
+		cache := middleware.NewResponseCache(middleware.NewMemoryResponseCacheStore(0))
+		registry := routes.NewRegistry(routes.WithAuthenticator(fakeAuthenticate),
+			routes.WithResponseCache(cache, map[string]time.Duration{"listmatches": 0}))
+		policy := middleware.CachePolicy{TTL: time.Minute}
+		registry.Add(
+			routes.Route{Name: "getTeamSeason", Method: "GET", Path: "/season", Handler: counting, Auth: routes.AuthUser, Cache: policy},
+			routes.Route{Name: "listMatches", Method: "GET", Path: "/matches", Handler: counting, Auth: routes.AuthUser, Cache: policy},
+		)
+		router := mux.NewRouter()
+		registry.Mount(router)
+
+		for i := 0; i < 2; i++ {
+			for _, path := range []string{"/season", "/matches"} {
+				r := httptest.NewRequest("GET", path, nil)
+				r.Header.Set("X-Test-Role", "user")
+				router.ServeHTTP(httptest.NewRecorder(), r)
+			}
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/season", nil))
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code, "cached responses need authentication too")
+		assert.Equal(t, map[string]int{"/season": 1, "/matches": 2}, calls)
+	})
+
+	t.Run("Admin routes and uploads only accept their allowlists", func(t *testing.T) {
+		filter, err := middleware.NewIPFilter(middleware.IPFilterRules{
+			AdminAllowlist:  []string{"10.0.0.1"},
+			UploadAllowlist: []string{"10.0.0.2"},
+		})
+		require.NoError(t, err)
+		registry := routes.NewRegistry(routes.WithAuthenticator(fakeAuthenticate), routes.WithIPFilter(filter))
+		registry.Add(
+			routes.Route{Name: "reload", Method: "POST", Path: "/admin/reload", Handler: ok, Auth: routes.AuthAdmin},
+			routes.Route{Name: "upload", Method: "POST", Path: "/videos", Handler: ok, Auth: routes.AuthPublic, RateLimit: routes.RateLimitUpload},
+			routes.Route{Name: "download", Method: "GET", Path: "/videos", Handler: ok, Auth: routes.AuthPublic, RateLimit: routes.RateLimitUpload},
+		)
+		router := mux.NewRouter()
+		registry.Mount(router)
+
+		codes := map[string][]int{}
+		for _, remoteAddr := range []string{"10.0.0.1:1", "10.0.0.2:1"} {
+			for _, req := range []struct{ method, path string }{{"POST", "/admin/reload"}, {"POST", "/videos"}, {"GET", "/videos"}} {
+				r := httptest.NewRequest(req.method, req.path, nil)
+				r.RemoteAddr = remoteAddr
+				r.Header.Set("X-Test-Role", middleware.RoleAdmin)
+				rr := httptest.NewRecorder()
+				router.ServeHTTP(rr, r)
+				codes[remoteAddr] = append(codes[remoteAddr], rr.Code)
+			}
+		}
+		assert.Equal(t, []int{http.StatusOK, http.StatusForbidden, http.StatusOK}, codes["10.0.0.1:1"])
+		assert.Equal(t, []int{http.StatusForbidden, http.StatusOK, http.StatusOK}, codes["10.0.0.2:1"])
+	})
+}
+
+func TestOpenAPI(t *testing.T) {
+	registry := routes.NewRegistry()
+	registry.Add(stubbedAPIRoutes()...)
+
+	for _, version := range routes.APIVersions {
+		rr := httptest.NewRecorder()
+		registry.OpenAPIHandler("NIVAI API")(rr, httptest.NewRequest("GET", fmt.Sprintf("/api/v%d/openapi.json", version), nil))
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var doc routes.OpenAPIDocument
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&doc))
+		assert.Equal(t, "3.0.3", doc.OpenAPI)
+		assert.Equal(t, fmt.Sprintf("%d.0.0", version), doc.Info.Version)
+		assert.Contains(t, doc.Components.Schemas, "Error")
+
+		documented := 0
+		for _, route := range registry.Routes() {
+			op, ok := doc.Paths[route.Path][strings.ToLower(route.Method)]
+			if route.Version != 0 && route.Version != version {
+				assert.False(t, ok, "%s %s is documented for v%d", route.Method, route.Path, version)
+				continue
+			}
+			require.True(t, ok, "%s %s is missing from the document", route.Method, route.Path)
+			assert.Equal(t, route.Name, op.OperationID)
+			assert.Equal(t, route.Auth, op.AuthPolicy)
+			assert.Equal(t, route.Auth == routes.AuthPublic, len(op.Security) == 0, route.Name)
+			assert.Equal(t, strings.Count(route.Path, "{"), len(op.Parameters), route.Name)
+			documented++
+		}
+		assert.NotZero(t, documented)
+	}
+}
@@ -0,0 +1,80 @@
+package scheduler_test
+
+import (
+	"testing"
+	"time"
+
+	"nivai/backend/pkg/scheduler"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse_Next(t *testing.T) {
+	// A Wednesday
+	from := time.Date(2024, time.May, 15, 10, 17, 42, 0, time.UTC)
+
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2024, time.May, 15, 10, 18, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, time.May, 15, 10, 30, 0, 0, time.UTC)},
+		{"0 4 * * *", time.Date(2024, time.May, 16, 4, 0, 0, 0, time.UTC)},
+		{"30 9-17/4 * * *", time.Date(2024, time.May, 15, 13, 30, 0, 0, time.UTC)},
+		{"0 0 1,15 * *", time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 0", time.Date(2024, time.May, 19, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2024, time.May, 19, 0, 0, 0, 0, time.UTC)},
+		{"0 0 13 * 5", time.Date(2024, time.May, 17, 0, 0, 0, 0, time.UTC)}, // Either day field matches
+		{"0 0 29 2 *", time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2024, time.May, 15, 11, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC)},
+		{"@every 90s", from.Add(90 * time.Second)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			schedule, err := scheduler.Parse(tt.spec)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, schedule.Next(from))
+		})
+	}
+
+	t.Run("Impossible dates never run", func(t *testing.T) {
+		schedule, err := scheduler.Parse("0 0 30 2 *")
+		require.NoError(t, err)
+		assert.True(t, schedule.Next(from).IsZero())
+	})
+
+	t.Run("Times are in the given location", func(t *testing.T) {
+		amsterdam, err := time.LoadLocation("Europe/Amsterdam")
+		if err != nil {
+			t.Skip("timezone database not available")
+		}
+		schedule, err := scheduler.Parse("0 4 * * *")
+		require.NoError(t, err)
+		next := schedule.Next(from.In(amsterdam))
+		assert.Equal(t, time.Date(2024, time.May, 16, 2, 0, 0, 0, time.UTC), next.UTC())
+	})
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"@yearly",
+		"@every",
+		"@every soon",
+		"@every 500ms",
+	} {
+		_, err := scheduler.Parse(spec)
+		assert.Error(t, err, spec)
+	}
+}
@@ -0,0 +1,85 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"nivai/backend/pkg/lock"
+)
+
+// Elector decides which replica runs the scheduled jobs, so a deployment of
+// several replicas runs each job once.
+type Elector interface {
+	// Elect reports whether this replica leads, trying to become the
+	// leader if it does not.
+	Elect(ctx context.Context) bool
+	// Resign gives up leadership.
+	Resign()
+}
+
+// Always makes every replica the leader, for single-replica deployments.
+type Always struct{}
+
+func (Always) Elect(context.Context) bool { return true }
+func (Always) Resign()                    {}
+
+// LeaderLock is the name of the lock the scheduler's leader holds.
+const LeaderLock = "scheduler:leader"
+
+// LockElector elects the replica holding a shared lock. The leader
+// refreshes the lock at every election, so the scheduler's check interval
+// must be shorter than the lock's expiry, if it has one; another replica
+// takes over at its next election after the leader resigns, exits or loses
+// the lock. It is not safe for concurrent use; the scheduler calls it from
+// its run loop only.
+type LockElector struct {
+	locker lock.Locker
+	name   string
+	held   lock.Lock // Held while leading
+}
+
+// NewLockElector creates an elector competing for the named lock.
+func NewLockElector(locker lock.Locker, name string) *LockElector {
+	return &LockElector{locker: locker, name: name}
+}
+
+// Elect refreshes the lock while leading, and otherwise tries to take it
+// without waiting.
+func (e *LockElector) Elect(ctx context.Context) bool {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if e.held != nil {
+		err := e.held.Refresh(ctx)
+		if err == nil {
+			return true
+		}
+		log.Printf("Scheduler: lost the leader lock: %v", err)
+		e.held = nil
+	}
+
+	held, err := e.locker.TryLock(ctx, e.name)
+	if err != nil {
+		if !errors.Is(err, lock.ErrHeld) {
+			log.Printf("Scheduler: failed to try the leader lock: %v", err)
+		}
+		return false
+	}
+	e.held = held
+	return true
+}
+
+// Resign releases the lock.
+func (e *LockElector) Resign() {
+	if e.held == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := e.held.Release(ctx); err != nil {
+		log.Printf("Scheduler: failed to release the leader lock: %v", err)
+	}
+	e.held = nil
+}
@@ -0,0 +1,300 @@
+// Package scheduler runs background jobs on cron schedules. With several
+// replicas, only the elected leader runs scheduled jobs; jobs triggered by
+// hand run on the replica asked, and a job lock keeps them from running on
+// two replicas at once.
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"nivai/backend/pkg/lock"
+)
+
+var (
+	// ErrUnknownJob is returned when triggering a job that is not registered.
+	ErrUnknownJob = errors.New("unknown job")
+	// ErrJobRunning is returned when triggering a job that is already running.
+	ErrJobRunning = errors.New("job is already running")
+)
+
+// Triggers of a job run
+const (
+	TriggerSchedule = "schedule"
+	TriggerManual   = "manual"
+)
+
+// defaultCheckInterval is how often the leader refreshes its lock and a
+// follower tries to become the leader, and the longest the loop sleeps
+// between checks for due jobs.
+const defaultCheckInterval = 10 * time.Second
+
+// Job is a unit of background work.
+type Job struct {
+	Name     string
+	Schedule string // Cron expression, macro or "@every <duration>"; see Parse
+	Run      func(ctx context.Context) error
+}
+
+// RunRecord describes one run of a job.
+type RunRecord struct {
+	Trigger    string    `json:"trigger"` // "schedule" or "manual"
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"` // Zero while running
+	Error      string    `json:"error,omitempty"`
+}
+
+// JobStatus describes a registered job.
+type JobStatus struct {
+	Name     string     `json:"name"`
+	Schedule string     `json:"schedule"`
+	NextRun  time.Time  `json:"next_run"`
+	Running  bool       `json:"running"`
+	LastRun  *RunRecord `json:"last_run,omitempty"`
+}
+
+// job is a registered job and its state.
+type job struct {
+	Job
+	schedule Schedule
+	next     time.Time
+	running  bool
+	last     *RunRecord
+}
+
+// Option configures a Scheduler.
+type Option func(*Scheduler)
+
+// WithLocation evaluates cron expressions in loc instead of UTC.
+func WithLocation(loc *time.Location) Option {
+	return func(s *Scheduler) { s.loc = loc }
+}
+
+// WithCheckInterval sets how often a follower tries to become the leader.
+func WithCheckInterval(d time.Duration) Option {
+	return func(s *Scheduler) {
+		if d > 0 {
+			s.checkInterval = d
+		}
+	}
+}
+
+// WithLocker makes every run of a job hold a lock named after it, so a job
+// triggered by hand does not run while it runs on another replica.
+func WithLocker(locker lock.Locker) Option {
+	return func(s *Scheduler) { s.locker = locker }
+}
+
+// WithClock replaces the clock, for tests.
+func WithClock(now func() time.Time) Option {
+	return func(s *Scheduler) { s.now = now }
+}
+
+// Scheduler runs registered jobs when they are due. A job never runs twice
+// at the same time; a run that is due while the previous one still runs is
+// skipped.
+type Scheduler struct {
+	elector       Elector
+	loc           *time.Location
+	checkInterval time.Duration
+	locker        lock.Locker // Nil runs jobs without a lock
+	now           func() time.Time
+
+	mu      sync.Mutex
+	jobs    map[string]*job
+	leader  bool
+	baseCtx context.Context // Context of Run, for manual runs
+	wg      sync.WaitGroup
+}
+
+// New creates a scheduler whose scheduled jobs run while elector elects it.
+func New(elector Elector, opts ...Option) *Scheduler {
+	s := &Scheduler{
+		elector:       elector,
+		loc:           time.UTC,
+		checkInterval: defaultCheckInterval,
+		now:           time.Now,
+		jobs:          make(map[string]*job),
+		baseCtx:       context.Background(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Register adds a job. Jobs must be registered before Run.
+func (s *Scheduler) Register(j Job) error {
+	schedule, err := Parse(j.Schedule)
+	if err != nil {
+		return fmt.Errorf("job %s: %w", j.Name, err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.jobs[j.Name]; ok {
+		return fmt.Errorf("job %s is already registered", j.Name)
+	}
+	s.jobs[j.Name] = &job{Job: j, schedule: schedule, next: schedule.Next(s.now().In(s.loc))}
+	return nil
+}
+
+// Run elects a leader and runs the due jobs while leading, until ctx is
+// done. It then resigns and waits for the running jobs, which see ctx done.
+func (s *Scheduler) Run(ctx context.Context) {
+	s.mu.Lock()
+	s.baseCtx = ctx
+	s.mu.Unlock()
+	defer func() {
+		s.elector.Resign()
+		s.wg.Wait()
+	}()
+
+	lastElection := time.Time{}
+	for {
+		now := s.now()
+		if now.Sub(lastElection) >= s.checkInterval {
+			s.setLeader(s.elector.Elect(ctx))
+			lastElection = now
+		}
+		wait := s.runDue(ctx, now)
+
+		timer := time.NewTimer(min(wait, s.checkInterval))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// Leader reports whether this replica runs the scheduled jobs.
+func (s *Scheduler) Leader() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.leader
+}
+
+// Jobs lists the registered jobs by name.
+func (s *Scheduler) Jobs() []JobStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	statuses := make([]JobStatus, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		statuses = append(statuses, j.status())
+	}
+	sort.Slice(statuses, func(a, b int) bool { return statuses[a].Name < statuses[b].Name })
+	return statuses
+}
+
+// Trigger starts a job now on this replica, whether or not it leads, and
+// returns its status. Its schedule is unchanged.
+func (s *Scheduler) Trigger(name string) (JobStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j, ok := s.jobs[name]
+	if !ok {
+		return JobStatus{}, ErrUnknownJob
+	}
+	if j.running {
+		return j.status(), ErrJobRunning
+	}
+	s.start(s.baseCtx, j, TriggerManual)
+	return j.status(), nil
+}
+
+// setLeader records the election's outcome, logging changes.
+func (s *Scheduler) setLeader(leader bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if leader != s.leader {
+		if leader {
+			log.Printf("Scheduler: this replica now runs the scheduled jobs")
+		} else {
+			log.Printf("Scheduler: this replica no longer runs the scheduled jobs")
+		}
+	}
+	s.leader = leader
+}
+
+// runDue starts the jobs due at now, if leading; followers only advance the
+// schedules, which the leader ran. It returns the time until the next job
+// is due.
+func (s *Scheduler) runDue(ctx context.Context, now time.Time) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wait := s.checkInterval
+	for _, j := range s.jobs {
+		if !now.Before(j.next) {
+			switch {
+			case !s.leader:
+			case j.running:
+				log.Printf("Scheduler: skipping job %s, its previous run is still going", j.Name)
+			default:
+				s.start(ctx, j, TriggerSchedule)
+			}
+			j.next = j.schedule.Next(now.In(s.loc))
+		}
+		if !j.next.IsZero() {
+			wait = min(wait, j.next.Sub(now))
+		}
+	}
+	return max(wait, 0)
+}
+
+// start runs a job in the background. s.mu must be held.
+func (s *Scheduler) start(ctx context.Context, j *job, trigger string) {
+	record := &RunRecord{Trigger: trigger, StartedAt: s.now()}
+	j.running, j.last = true, record
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		var err error
+		if s.locker != nil {
+			err = lock.Do(ctx, s.locker, "scheduler:job:"+j.Name, func(ctx context.Context) error {
+				return s.safeRun(ctx, j)
+			})
+			if errors.Is(err, lock.ErrHeld) {
+				err = errors.New("skipped, the job is running on another replica")
+			}
+		} else {
+			err = s.safeRun(ctx, j)
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		record.FinishedAt = s.now()
+		if err != nil {
+			record.Error = err.Error()
+			log.Printf("Scheduler: job %s failed: %v", j.Name, err)
+		}
+		j.running = false
+	}()
+}
+
+// safeRun runs a job, turning a panic into an error so one broken job does
+// not take the process down.
+func (s *Scheduler) safeRun(ctx context.Context, j *job) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return j.Run(ctx)
+}
+
+// status describes the job. s.mu must be held.
+func (j *job) status() JobStatus {
+	status := JobStatus{Name: j.Name, Schedule: j.Job.Schedule, NextRun: j.next, Running: j.running}
+	if j.last != nil {
+		last := *j.last
+		status.LastRun = &last
+	}
+	return status
+}
@@ -0,0 +1,166 @@
+package scheduler_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"nivai/backend/pkg/lock"
+	"nivai/backend/pkg/scheduler"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock is a clock tests move forward by hand.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// fixedElector always gives the same election result.
+type fixedElector bool
+
+func (e fixedElector) Elect(context.Context) bool { return bool(e) }
+func (fixedElector) Resign()                      {}
+
+// startScheduler runs a scheduler with one job signalling runs on the
+// returned channel, until the test ends.
+func startScheduler(t *testing.T, elector scheduler.Elector, clock *fakeClock) (*scheduler.Scheduler, chan struct{}) {
+	t.Helper()
+	runs := make(chan struct{}, 10)
+	s := scheduler.New(elector, scheduler.WithClock(clock.Now), scheduler.WithCheckInterval(5*time.Millisecond))
+	require.NoError(t, s.Register(scheduler.Job{Name: "sweep", Schedule: "@every 1m", Run: func(context.Context) error {
+		runs <- struct{}{}
+		return errors.New("disk full")
+	}}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return s, runs
+}
+
+func TestScheduler_Run(t *testing.T) {
+	start := time.Date(2024, time.May, 15, 10, 0, 0, 0, time.UTC)
+
+	t.Run("The leader runs due jobs", func(t *testing.T) {
+		clock := &fakeClock{now: start}
+		s, runs := startScheduler(t, scheduler.Always{}, clock)
+		require.Eventually(t, s.Leader, time.Second, time.Millisecond)
+
+		clock.Advance(time.Minute)
+		select {
+		case <-runs:
+		case <-time.After(time.Second):
+			t.Fatal("job did not run")
+		}
+		require.Eventually(t, func() bool { return !s.Jobs()[0].Running }, time.Second, time.Millisecond)
+
+		job := s.Jobs()[0]
+		assert.Equal(t, start.Add(2*time.Minute), job.NextRun)
+		require.NotNil(t, job.LastRun)
+		assert.Equal(t, scheduler.TriggerSchedule, job.LastRun.Trigger)
+		assert.Equal(t, "disk full", job.LastRun.Error)
+	})
+
+	t.Run("Followers only advance the schedule", func(t *testing.T) {
+		clock := &fakeClock{now: start}
+		s, runs := startScheduler(t, fixedElector(false), clock)
+
+		clock.Advance(time.Minute)
+		require.Eventually(t, func() bool { return s.Jobs()[0].NextRun.Equal(start.Add(2 * time.Minute)) }, time.Second, time.Millisecond)
+		assert.False(t, s.Leader())
+		assert.Empty(t, runs)
+		assert.Nil(t, s.Jobs()[0].LastRun)
+	})
+}
+
+func TestScheduler_Trigger(t *testing.T) {
+	release := make(chan struct{})
+	s := scheduler.New(fixedElector(false))
+	require.NoError(t, s.Register(scheduler.Job{Name: "gc", Schedule: "@daily", Run: func(context.Context) error {
+		<-release
+		return nil
+	}}))
+
+	status, err := s.Trigger("gc")
+	require.NoError(t, err, "followers run triggered jobs")
+	assert.True(t, status.Running)
+	assert.Equal(t, scheduler.TriggerManual, status.LastRun.Trigger)
+
+	_, err = s.Trigger("gc")
+	assert.ErrorIs(t, err, scheduler.ErrJobRunning)
+	_, err = s.Trigger("missing")
+	assert.ErrorIs(t, err, scheduler.ErrUnknownJob)
+
+	close(release)
+	require.Eventually(t, func() bool { return !s.Jobs()[0].Running }, time.Second, time.Millisecond)
+	assert.False(t, s.Jobs()[0].LastRun.FinishedAt.IsZero())
+}
+
+func TestScheduler_Register(t *testing.T) {
+	s := scheduler.New(scheduler.Always{})
+	require.NoError(t, s.Register(scheduler.Job{Name: "gc", Schedule: "@daily"}))
+	assert.Error(t, s.Register(scheduler.Job{Name: "gc", Schedule: "@hourly"}), "names are unique")
+	assert.Error(t, s.Register(scheduler.Job{Name: "bad", Schedule: "every day"}))
+}
+
+func TestLockElector(t *testing.T) {
+	locker := lock.NewLocal()
+	first := scheduler.NewLockElector(locker, scheduler.LeaderLock)
+	second := scheduler.NewLockElector(locker, scheduler.LeaderLock)
+
+	assert.True(t, first.Elect(context.Background()))
+	assert.True(t, first.Elect(context.Background()), "the leader stays leader")
+	assert.False(t, second.Elect(context.Background()))
+
+	first.Resign()
+	assert.True(t, second.Elect(context.Background()), "a follower takes over")
+	assert.False(t, first.Elect(context.Background()))
+}
+
+func TestScheduler_WithLocker(t *testing.T) {
+	locker := lock.NewLocal()
+	held, err := locker.TryLock(context.Background(), "scheduler:job:gc")
+	require.NoError(t, err)
+
+	ran := make(chan struct{}, 1)
+	s := scheduler.New(scheduler.Always{}, scheduler.WithLocker(locker))
+	require.NoError(t, s.Register(scheduler.Job{Name: "gc", Schedule: "@daily", Run: func(context.Context) error {
+		ran <- struct{}{}
+		return nil
+	}}))
+
+	_, err = s.Trigger("gc")
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return !s.Jobs()[0].Running }, time.Second, time.Millisecond)
+	assert.Contains(t, s.Jobs()[0].LastRun.Error, "another replica")
+	assert.Empty(t, ran)
+
+	require.NoError(t, held.Release(context.Background()))
+	_, err = s.Trigger("gc")
+	require.NoError(t, err)
+	<-ran
+}
@@ -0,0 +1,185 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SchemeAWSSecretsManager references AWS Secrets Manager secrets by name or
+// ARN:
+//
+//	awssm://<secret-id>[#<json-field>]
+const SchemeAWSSecretsManager = "awssm"
+
+// AWSCredentials sign Secrets Manager requests
+type AWSCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// SecretsManagerResolver fetches secrets from AWS Secrets Manager with the
+// GetSecretValue action, signing requests with Signature Version 4
+type SecretsManagerResolver struct {
+	Client      *http.Client
+	Credentials AWSCredentials
+	Region      string // Used unless the secret is referenced by an ARN
+
+	// Endpoint overrides https://secretsmanager.<region>.amazonaws.com
+	Endpoint string
+}
+
+// AWSCredentialsFromEnv reads the standard AWS_ACCESS_KEY_ID,
+// AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN variables
+func AWSCredentialsFromEnv() AWSCredentials {
+	return AWSCredentials{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+}
+
+// Resolve fetches the current version of a secret
+func (s *SecretsManagerResolver) Resolve(ctx context.Context, ref Reference) (string, error) {
+	if ref.Path == "" {
+		return "", fmt.Errorf("expected awssm://<secret-id>")
+	}
+	if s.Credentials.AccessKeyID == "" || s.Credentials.SecretAccessKey == "" {
+		return "", fmt.Errorf("no AWS credentials available; set AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+	}
+	region := s.Region
+	if arnRegion := regionFromARN(ref.Path); arnRegion != "" {
+		region = arnRegion
+	}
+	if region == "" {
+		return "", fmt.Errorf("no AWS region; set AWS_REGION or reference the secret by ARN")
+	}
+
+	endpoint := s.Endpoint
+	if endpoint == "" {
+		endpoint = "https://secretsmanager." + region + ".amazonaws.com"
+	}
+	payload, err := json.Marshal(map[string]string{"SecretId": ref.Path})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint+"/", bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	s.sign(req, payload, region, time.Now().UTC())
+
+	resp, err := httpClient(s.Client).Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		SecretString string `json:"SecretString"`
+		Type         string `json:"__type"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseBytes)).Decode(&body); err != nil && resp.StatusCode == http.StatusOK {
+		return "", fmt.Errorf("invalid Secrets Manager response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		if body.Type != "" {
+			return "", fmt.Errorf("Secrets Manager returned %d: %s", resp.StatusCode, body.Type)
+		}
+		return "", fmt.Errorf("Secrets Manager returned %d", resp.StatusCode)
+	}
+	if body.SecretString == "" {
+		return "", fmt.Errorf("secret has no string value; binary secrets are not supported")
+	}
+	return jsonField(body.SecretString, ref.Key)
+}
+
+// sign adds a Signature Version 4 authorization to a request
+func (s *SecretsManagerResolver) sign(req *http.Request, payload []byte, region string, now time.Time) {
+	const service = "secretsmanager"
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	if s.Credentials.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.Credentials.SessionToken)
+	}
+
+	headers := map[string]string{
+		"content-type": req.Header.Get("Content-Type"),
+		"host":         req.URL.Host,
+		"x-amz-date":   amzDate,
+		"x-amz-target": req.Header.Get("X-Amz-Target"),
+	}
+	if s.Credentials.SessionToken != "" {
+		headers["x-amz-security-token"] = s.Credentials.SessionToken
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method, path, req.URL.RawQuery, canonicalHeaders.String(), signedHeaders, hexSHA256(payload),
+	}, "\n")
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256", amzDate, scope, hexSHA256([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.Credentials.SecretAccessKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.Credentials.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// regionFromARN returns the region of a secret ARN,
+// arn:aws:secretsmanager:<region>:<account>:secret:<name>
+func regionFromARN(id string) string {
+	parts := strings.Split(id, ":")
+	if len(parts) < 7 || parts[0] != "arn" || parts[2] != "secretsmanager" {
+		return ""
+	}
+	return parts[3]
+}
+
+// hexSHA256 returns the hex-encoded SHA-256 of data
+func hexSHA256(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// hmacSHA256 returns the HMAC-SHA256 of data with key
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
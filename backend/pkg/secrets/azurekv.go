@@ -0,0 +1,88 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+)
+
+// SchemeAzureKeyVault references Azure Key Vault secrets:
+//
+//	azurekv://<vault-name>/<secret-name>[/<version>][#<json-field>]
+const SchemeAzureKeyVault = "azurekv"
+
+// keyVaultAPIVersion is the Key Vault REST API version used
+const keyVaultAPIVersion = "7.4"
+
+// KeyVaultResolver fetches secrets from Azure Key Vault over its REST API,
+// authenticated with an Azure AD credential such as a managed identity
+type KeyVaultResolver struct {
+	Client     *http.Client
+	Credential azcore.TokenCredential
+
+	// Endpoint returns the base URL of a vault; nil uses
+	// https://<vault>.vault.azure.net
+	Endpoint func(vault string) string
+}
+
+// Resolve fetches the latest, or the given, version of a secret
+func (k *KeyVaultResolver) Resolve(ctx context.Context, ref Reference) (string, error) {
+	vault, name, ok := strings.Cut(ref.Path, "/")
+	if !ok || vault == "" || name == "" {
+		return "", fmt.Errorf("expected azurekv://<vault>/<secret>[/<version>]")
+	}
+	if k.Credential == nil {
+		return "", fmt.Errorf("no Azure credential available")
+	}
+
+	token, err := k.Credential.GetToken(ctx, policy.TokenRequestOptions{Scopes: []string{"https://vault.azure.net/.default"}})
+	if err != nil {
+		return "", fmt.Errorf("failed to get a Key Vault token: %w", err)
+	}
+
+	endpoint := "https://" + vault + ".vault.azure.net"
+	if k.Endpoint != nil {
+		endpoint = k.Endpoint(vault)
+	}
+	secretPath := "/secrets/" + url.PathEscape(name)
+	if secretName, version, ok := strings.Cut(name, "/"); ok {
+		secretPath = "/secrets/" + url.PathEscape(secretName) + "/" + url.PathEscape(version)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+secretPath+"?api-version="+keyVaultAPIVersion, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+token.Token)
+
+	resp, err := httpClient(k.Client).Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		Value string `json:"value"`
+		Error *struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseBytes)).Decode(&body); err != nil && resp.StatusCode == http.StatusOK {
+		return "", fmt.Errorf("invalid Key Vault response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		if body.Error != nil {
+			return "", fmt.Errorf("Key Vault returned %d: %s", resp.StatusCode, body.Error.Code)
+		}
+		return "", fmt.Errorf("Key Vault returned %d", resp.StatusCode)
+	}
+	return jsonField(body.Value, ref.Key)
+}
@@ -0,0 +1,62 @@
+package secrets
+
+import (
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+)
+
+// maxResponseBytes bounds the responses read from secrets managers
+const maxResponseBytes = 1 << 20
+
+// defaultTimeout applies when no client is given
+const defaultTimeout = 10 * time.Second
+
+// FromEnv creates a registry with the built-in resolvers, configured the way
+// each secrets manager's own tooling is:
+//
+//   - azurekv: the default Azure credential chain (environment, workload or
+//     managed identity, Azure CLI)
+//   - awssm: AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN and
+//     AWS_REGION or AWS_DEFAULT_REGION
+//   - vault: VAULT_ADDR, VAULT_TOKEN and VAULT_NAMESPACE
+//
+// A resolver that lacks its settings fails only when a reference to it is
+// resolved. A nil client uses a plain client with a 10 second timeout.
+func FromEnv(client *http.Client) *Registry {
+	registry := NewRegistry()
+
+	keyVault := &KeyVaultResolver{Client: client}
+	if credential, err := azidentity.NewDefaultAzureCredential(nil); err == nil {
+		keyVault.Credential = credential
+	}
+	registry.Register(SchemeAzureKeyVault, keyVault)
+
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	registry.Register(SchemeAWSSecretsManager, &SecretsManagerResolver{
+		Client:      client,
+		Credentials: AWSCredentialsFromEnv(),
+		Region:      region,
+	})
+
+	registry.Register(SchemeVault, &VaultResolver{
+		Client:    client,
+		Address:   os.Getenv("VAULT_ADDR"),
+		Token:     os.Getenv("VAULT_TOKEN"),
+		Namespace: os.Getenv("VAULT_NAMESPACE"),
+	})
+	return registry
+}
+
+// httpClient returns client, or a default one when it is nil
+func httpClient(client *http.Client) *http.Client {
+	if client == nil {
+		return &http.Client{Timeout: defaultTimeout}
+	}
+	return client
+}
@@ -0,0 +1,230 @@
+// Package secrets resolves secret references in the configuration. A setting
+// whose value is a secret URI, such as
+//
+//	vault://secret/data/nivai/db#password
+//
+// is replaced at startup by the secret it names, so credentials can live in
+// a secrets manager instead of plaintext environment variables or files.
+// Each URI scheme has a Resolver: Azure Key Vault (azurekv://), AWS Secrets
+// Manager (awssm://) and HashiCorp Vault (vault://) are built in, and others
+// can be registered.
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Reference is a parsed secret URI: <scheme>://<path>[#<key>]
+type Reference struct {
+	Scheme string
+	Path   string // Which secret, in the scheme's own notation
+	Key    string // Field of a secret holding several values; empty for the whole secret
+}
+
+// String returns the URI of the reference
+func (r Reference) String() string {
+	uri := r.Scheme + "://" + r.Path
+	if r.Key != "" {
+		uri += "#" + r.Key
+	}
+	return uri
+}
+
+// Parse splits a secret URI into its parts. It reports false for values
+// that are not URIs; whether the scheme names a secrets manager is up to
+// the Registry.
+func Parse(value string) (Reference, bool) {
+	scheme, rest, ok := strings.Cut(value, "://")
+	if !ok || scheme == "" || rest == "" || strings.ToLower(scheme) != scheme {
+		return Reference{}, false
+	}
+	path, key, _ := strings.Cut(rest, "#")
+	return Reference{Scheme: scheme, Path: path, Key: key}, true
+}
+
+// Resolver fetches the secrets of one URI scheme
+type Resolver interface {
+	// Resolve returns the secret a reference names
+	Resolve(ctx context.Context, ref Reference) (string, error)
+}
+
+// ResolverFunc adapts a function to the Resolver interface
+type ResolverFunc func(ctx context.Context, ref Reference) (string, error)
+
+// Resolve calls f
+func (f ResolverFunc) Resolve(ctx context.Context, ref Reference) (string, error) {
+	return f(ctx, ref)
+}
+
+// Registry resolves secret URIs with the resolver registered for their
+// scheme. Values with other schemes, such as http:// or postgres:// URLs,
+// are left alone.
+type Registry struct {
+	resolvers map[string]Resolver
+}
+
+// NewRegistry creates a registry without resolvers
+func NewRegistry() *Registry {
+	return &Registry{resolvers: map[string]Resolver{}}
+}
+
+// Register makes the registry resolve a URI scheme with resolver, replacing
+// any resolver registered for it before
+func (r *Registry) Register(scheme string, resolver Resolver) {
+	r.resolvers[scheme] = resolver
+}
+
+// Schemes returns the registered schemes in order
+func (r *Registry) Schemes() []string {
+	schemes := make([]string, 0, len(r.resolvers))
+	for scheme := range r.resolvers {
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+// Resolve returns the secret value names, or value itself when it is not
+// a reference to a registered scheme
+func (r *Registry) Resolve(ctx context.Context, value string) (string, error) {
+	ref, ok := Parse(value)
+	if !ok {
+		return value, nil
+	}
+	resolver, ok := r.resolvers[ref.Scheme]
+	if !ok {
+		return value, nil
+	}
+	secret, err := resolver.Resolve(ctx, ref)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve secret %s: %w", ref, err)
+	}
+	return secret, nil
+}
+
+// ResolveAll replaces every secret reference in the string settings of v, a
+// pointer to a configuration struct, by its secret. Settings are named by
+// their JSON keys in errors, which never contain secret values. Each
+// reference is fetched once, however often it is used.
+//
+// It returns the keys of the settings that were resolved.
+func (r *Registry) ResolveAll(ctx context.Context, v interface{}) ([]string, error) {
+	w := &walker{registry: r, ctx: ctx, cache: map[string]string{}}
+	if err := w.walk(reflect.ValueOf(v).Elem(), ""); err != nil {
+		return nil, err
+	}
+	sort.Strings(w.resolved)
+	return w.resolved, nil
+}
+
+// walker visits the settings of a configuration
+type walker struct {
+	registry *Registry
+	ctx      context.Context
+	cache    map[string]string
+	resolved []string
+}
+
+// walk resolves the references below v, whose key is key
+func (w *walker) walk(v reflect.Value, key string) error {
+	switch v.Kind() {
+	case reflect.String:
+		secret, changed, err := w.resolve(v.String(), key)
+		if err != nil || !changed {
+			return err
+		}
+		v.SetString(secret)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if !field.IsExported() || name == "-" {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			if err := w.walk(v.Field(i), joinKey(key, name)); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			if err := w.walk(v.Index(i), fmt.Sprintf("%s[%d]", key, i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		// Map entries are not addressable: resolve a copy and store it back
+		for _, k := range v.MapKeys() {
+			entry := reflect.New(v.Type().Elem()).Elem()
+			entry.Set(v.MapIndex(k))
+			if err := w.walk(entry, joinKey(key, fmt.Sprint(k.Interface()))); err != nil {
+				return err
+			}
+			v.SetMapIndex(k, entry)
+		}
+	}
+	return nil
+}
+
+// resolve resolves one setting, reporting whether it was a reference
+func (w *walker) resolve(value, key string) (string, bool, error) {
+	if secret, ok := w.cache[value]; ok {
+		w.resolved = append(w.resolved, key)
+		return secret, true, nil
+	}
+	secret, err := w.registry.Resolve(w.ctx, value)
+	if err != nil {
+		return "", false, fmt.Errorf("%s: %w", key, err)
+	}
+	if secret == value {
+		return value, false, nil
+	}
+	w.cache[value] = secret
+	w.resolved = append(w.resolved, key)
+	return secret, true, nil
+}
+
+// jsonField returns a field of a secret holding a JSON object, as AWS
+// Secrets Manager and Key Vault secrets with several values do. Without a
+// key the whole secret is returned.
+func jsonField(secret, key string) (string, error) {
+	if key == "" {
+		return secret, nil
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(secret), &fields); err != nil {
+		return "", fmt.Errorf("secret is not a JSON object, so it has no field %q", key)
+	}
+	return field(fields, key)
+}
+
+// field returns a string field of a decoded secret
+func field(fields map[string]interface{}, key string) (string, error) {
+	value, ok := fields[key]
+	if !ok {
+		return "", fmt.Errorf("secret has no field %q", key)
+	}
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case float64, bool:
+		return fmt.Sprint(v), nil
+	default:
+		return "", fmt.Errorf("secret field %q is not a string", key)
+	}
+}
+
+// joinKey appends a name to a dotted key
+func joinKey(key, name string) string {
+	if key == "" {
+		return name
+	}
+	return key + "." + name
+}
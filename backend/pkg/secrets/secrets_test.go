@@ -0,0 +1,215 @@
+package secrets_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"nivai/backend/pkg/secrets"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	ref, ok := secrets.Parse("vault://secret/data/nivai#db_password")
+	require.True(t, ok)
+	assert.Equal(t, secrets.Reference{Scheme: "vault", Path: "secret/data/nivai", Key: "db_password"}, ref)
+	assert.Equal(t, "vault://secret/data/nivai#db_password", ref.String())
+
+	for _, value := range []string{"", "password", "/data/matches", "Vault://x", "vault://"} {
+		_, ok := secrets.Parse(value)
+		assert.False(t, ok, value)
+	}
+}
+
+// settings stands in for a configuration struct
+type settings struct {
+	Password string            `json:"password"`
+	Hosts    []string          `json:"hosts"`
+	Keys     map[string]string `json:"keys"`
+	Nested   struct {
+		Token string `json:"token"`
+		Port  int    `json:"port"`
+	} `json:"nested"`
+	URL    string `json:"url"`
+	hidden string
+}
+
+func TestResolveAll(t *testing.T) {
+	calls := 0
+	registry := secrets.NewRegistry()
+	registry.Register("fake", secrets.ResolverFunc(func(ctx context.Context, ref secrets.Reference) (string, error) {
+		calls++
+		if ref.Path == "broken" {
+			return "", errors.New("not found")
+		}
+		return "resolved-" + ref.Path + ref.Key, nil
+	}))
+	assert.Equal(t, []string{"fake"}, registry.Schemes())
+
+	t.Run("references are replaced and other values kept", func(t *testing.T) {
+		calls = 0
+		s := settings{
+			Password: "fake://db",
+			Hosts:    []string{"fake://host", "plain"},
+			Keys:     map[string]string{"storage": "fake://db", "other": "value"},
+			URL:      "https://example.com/path",
+			hidden:   "fake://hidden",
+		}
+		s.Nested.Token = "fake://api#token"
+
+		resolved, err := registry.ResolveAll(context.Background(), &s)
+		require.NoError(t, err)
+
+		assert.Equal(t, "resolved-db", s.Password)
+		assert.Equal(t, []string{"resolved-host", "plain"}, s.Hosts)
+		assert.Equal(t, map[string]string{"storage": "resolved-db", "other": "value"}, s.Keys)
+		assert.Equal(t, "resolved-apitoken", s.Nested.Token)
+		assert.Equal(t, "https://example.com/path", s.URL, "unregistered schemes are not references")
+		assert.Equal(t, "fake://hidden", s.hidden)
+		assert.Equal(t, []string{"hosts[0]", "keys.storage", "nested.token", "password"}, resolved)
+		assert.Equal(t, 3, calls, "each reference is fetched once")
+	})
+
+	t.Run("errors name the setting", func(t *testing.T) {
+		s := settings{Password: "fake://broken"}
+		_, err := registry.ResolveAll(context.Background(), &s)
+		assert.ErrorContains(t, err, "password: failed to resolve secret fake://broken: not found")
+	})
+}
+
+// fakeCredential issues a fixed Azure AD token
+type fakeCredential struct{}
+
+func (fakeCredential) GetToken(ctx context.Context, options policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	return azcore.AccessToken{Token: "aad-token"}, nil
+}
+
+func TestKeyVaultResolver(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer aad-token", r.Header.Get("Authorization"))
+		assert.Equal(t, "7.4", r.URL.Query().Get("api-version"))
+		switch r.URL.Path {
+		case "/secrets/db-password":
+			json.NewEncoder(w).Encode(map[string]string{"value": "hunter2"})
+		case "/secrets/storage/abc123":
+			json.NewEncoder(w).Encode(map[string]string{"value": `{"account_key": "key=="}`})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]interface{}{"error": map[string]string{"code": "SecretNotFound"}})
+		}
+	}))
+	defer server.Close()
+
+	var vault string
+	resolver := &secrets.KeyVaultResolver{
+		Credential: fakeCredential{},
+		Endpoint: func(name string) string {
+			vault = name
+			return server.URL
+		},
+	}
+	registry := secrets.NewRegistry()
+	registry.Register(secrets.SchemeAzureKeyVault, resolver)
+
+	secret, err := registry.Resolve(context.Background(), "azurekv://nivai-kv/db-password")
+	require.NoError(t, err)
+	assert.Equal(t, "hunter2", secret)
+	assert.Equal(t, "nivai-kv", vault)
+
+	secret, err = registry.Resolve(context.Background(), "azurekv://nivai-kv/storage/abc123#account_key")
+	require.NoError(t, err)
+	assert.Equal(t, "key==", secret)
+
+	_, err = registry.Resolve(context.Background(), "azurekv://nivai-kv/missing")
+	assert.ErrorContains(t, err, "SecretNotFound")
+
+	_, err = registry.Resolve(context.Background(), "azurekv://nivai-kv")
+	assert.Error(t, err)
+}
+
+func TestSecretsManagerResolver(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secretsmanager.GetSecretValue", r.Header.Get("X-Amz-Target"))
+		assert.Equal(t, "session", r.Header.Get("X-Amz-Security-Token"))
+		auth := r.Header.Get("Authorization")
+		assert.True(t, strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKID/"), auth)
+		assert.Contains(t, auth, "/eu-west-1/secretsmanager/aws4_request")
+		assert.Contains(t, auth, "SignedHeaders=content-type;host;x-amz-date;x-amz-security-token;x-amz-target")
+
+		body, _ := io.ReadAll(r.Body)
+		var input struct{ SecretId string }
+		require.NoError(t, json.Unmarshal(body, &input))
+		if input.SecretId != "nivai/db" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"__type": "ResourceNotFoundException"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"SecretString": `{"username": "aifaa", "password": "hunter2"}`})
+	}))
+	defer server.Close()
+
+	resolver := &secrets.SecretsManagerResolver{
+		Credentials: secrets.AWSCredentials{AccessKeyID: "AKID", SecretAccessKey: "secret", SessionToken: "session"},
+		Region:      "eu-west-1",
+		Endpoint:    server.URL,
+	}
+	ref := secrets.Reference{Scheme: secrets.SchemeAWSSecretsManager, Path: "nivai/db", Key: "password"}
+	secret, err := resolver.Resolve(context.Background(), ref)
+	require.NoError(t, err)
+	assert.Equal(t, "hunter2", secret)
+
+	ref.Key = "missing"
+	_, err = resolver.Resolve(context.Background(), ref)
+	assert.ErrorContains(t, err, `no field "missing"`)
+
+	ref.Path = "other"
+	_, err = resolver.Resolve(context.Background(), ref)
+	assert.ErrorContains(t, err, "ResourceNotFoundException")
+
+	_, err = (&secrets.SecretsManagerResolver{Region: "eu-west-1"}).Resolve(context.Background(), ref)
+	assert.ErrorContains(t, err, "no AWS credentials")
+}
+
+func TestVaultResolver(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "vault-token", r.Header.Get("X-Vault-Token"))
+		assert.Equal(t, "team", r.Header.Get("X-Vault-Namespace"))
+		switch r.URL.Path {
+		case "/v1/secret/data/nivai":
+			json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{
+				"data":     map[string]string{"db_password": "hunter2"},
+				"metadata": map[string]int{"version": 3},
+			}})
+		case "/v1/kv/nivai":
+			json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]string{"value": "v1-secret"}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	resolver := &secrets.VaultResolver{Address: server.URL + "/", Token: "vault-token", Namespace: "team"}
+
+	secret, err := resolver.Resolve(context.Background(), secrets.Reference{Path: "secret/data/nivai", Key: "db_password"})
+	require.NoError(t, err)
+	assert.Equal(t, "hunter2", secret)
+
+	secret, err = resolver.Resolve(context.Background(), secrets.Reference{Path: "kv/nivai"})
+	require.NoError(t, err)
+	assert.Equal(t, "v1-secret", secret, "the field defaults to value")
+
+	_, err = resolver.Resolve(context.Background(), secrets.Reference{Path: "secret/data/missing"})
+	assert.ErrorContains(t, err, "404")
+
+	_, err = (&secrets.VaultResolver{}).Resolve(context.Background(), secrets.Reference{Path: "kv/nivai"})
+	assert.ErrorContains(t, err, "VAULT_ADDR")
+}
@@ -0,0 +1,75 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// SchemeVault references HashiCorp Vault secrets by API path; the field
+// defaults to "value":
+//
+//	vault://<path>[#<field>]
+const SchemeVault = "vault"
+
+// VaultResolver reads secrets from HashiCorp Vault over its HTTP API. Both
+// KV version 1 paths (secret/nivai) and version 2 paths (secret/data/nivai)
+// are supported.
+type VaultResolver struct {
+	Client    *http.Client
+	Address   string // VAULT_ADDR, e.g. https://vault.example.com:8200
+	Token     string // VAULT_TOKEN
+	Namespace string // VAULT_NAMESPACE, for Vault Enterprise
+}
+
+// Resolve reads a field of the secret at a path
+func (v *VaultResolver) Resolve(ctx context.Context, ref Reference) (string, error) {
+	if v.Address == "" || v.Token == "" {
+		return "", fmt.Errorf("Vault is not configured; set VAULT_ADDR and VAULT_TOKEN")
+	}
+	path := strings.Trim(ref.Path, "/")
+	if path == "" {
+		return "", fmt.Errorf("expected vault://<path>")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(v.Address, "/")+"/v1/"+path, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", v.Token)
+	if v.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.Namespace)
+	}
+
+	resp, err := httpClient(v.Client).Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Vault returned %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseBytes)).Decode(&body); err != nil {
+		return "", fmt.Errorf("invalid Vault response: %w", err)
+	}
+
+	// KV version 2 nests the fields under data.data, next to data.metadata
+	fields := body.Data
+	if nested, ok := fields["data"].(map[string]interface{}); ok {
+		if _, versioned := fields["metadata"]; versioned {
+			fields = nested
+		}
+	}
+	key := ref.Key
+	if key == "" {
+		key = "value"
+	}
+	return field(fields, key)
+}
@@ -0,0 +1,196 @@
+package seed
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/services"
+)
+
+//go:embed fixtures
+var fixtures embed.FS
+
+/**
+ * Match is a sample match of the fixture set, with the analytics summary the
+ * Python service would have produced for it. Teams and players are part of
+ * the summary, as they are in analytics results.
+ */
+type Match struct {
+	ID          string          `json:"id"`
+	Title       string          `json:"title"`
+	Description string          `json:"description"`
+	MatchID     string          `json:"match_id"`
+	MatchDate   time.Time       `json:"match_date"`
+	HomeTeam    string          `json:"home_team"`
+	AwayTeam    string          `json:"away_team"`
+	Competition string          `json:"competition"`
+	Season      string          `json:"season"`
+	Duration    float64         `json:"duration"`
+	Resolution  string          `json:"resolution"`
+	Summary     json.RawMessage `json:"summary"`
+}
+
+/**
+ * Result counts the matches a Load created and skipped.
+ */
+type Result struct {
+	Created int `json:"created"`
+	Skipped int `json:"skipped"`
+}
+
+/**
+ * Matches returns the sample matches of the embedded fixture set.
+ *
+ * @return The sample matches, or an error if the fixtures are unreadable
+ */
+func Matches() ([]Match, error) {
+	data, err := fixtures.ReadFile("fixtures/matches.json")
+	if err != nil {
+		return nil, err
+	}
+	var matches []Match
+	if err := json.Unmarshal(data, &matches); err != nil {
+		return nil, fmt.Errorf("invalid match fixtures: %v", err)
+	}
+	return matches, nil
+}
+
+/**
+ * Load stores the sample matches: their tracking and event files in storage,
+ * and the videos, file records and analytics snapshots in the repositories.
+ * Matches whose video already exists are skipped, so loading twice is safe.
+ *
+ * @param repos Repositories to store the matches in
+ * @param storage Storage for the match files
+ * @param paths Where the match files are stored
+ * @return The number of created and skipped matches, or an error
+ */
+func Load(repos *models.Repositories, storage services.StorageService, paths services.PathStrategy) (*Result, error) {
+	matches, err := Matches()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{}
+	for _, match := range matches {
+		_, err := repos.Videos.FindByID(match.ID)
+		if err == nil {
+			result.Skipped++
+			continue
+		}
+		if !strings.Contains(err.Error(), "not found") {
+			return result, err
+		}
+
+		if err := loadMatch(repos, storage, paths, match); err != nil {
+			return result, fmt.Errorf("failed to load match %s: %v", match.ID, err)
+		}
+		result.Created++
+	}
+	return result, nil
+}
+
+// loadMatch stores one sample match, files first so the video never refers
+// to missing files
+func loadMatch(repos *models.Repositories, storage services.StorageService, paths services.PathStrategy, match Match) error {
+	now := time.Now()
+	dir := paths.Dir(services.StoragePathInfo{VideoID: match.ID, UploadedAt: now})
+
+	var files []*models.VideoFile
+	var provider string
+	for _, kind := range []string{models.FileKindTracking, models.FileKindEvents} {
+		file, info, err := storeFile(storage, dir, match.ID, kind)
+		if err != nil {
+			return err
+		}
+		files = append(files, file)
+		provider = info.Provider
+	}
+
+	video := &models.Video{
+		ID:              match.ID,
+		Title:           match.Title,
+		Description:     match.Description,
+		StorageProvider: provider,
+		Duration:        match.Duration,
+		Resolution:      match.Resolution,
+		ProcessingState: "completed",
+		CreatedAt:       now,
+		UpdatedAt:       now,
+		MatchID:         match.MatchID,
+		MatchDate:       match.MatchDate,
+		HomeTeam:        match.HomeTeam,
+		AwayTeam:        match.AwayTeam,
+		Competition:     match.Competition,
+		Season:          match.Season,
+		TrackingPath:    files[0].Path,
+		EventFilePath:   files[1].Path,
+	}
+	if err := repos.Videos.Create(video); err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		file.CreatedAt, file.UpdatedAt = now, now
+		if err := repos.VideoFiles.Save(file); err != nil {
+			return err
+		}
+	}
+
+	return repos.Snapshots.Save(&models.AnalyticsSnapshot{
+		VideoID:   match.ID,
+		Kind:      models.SnapshotKindSummary,
+		Payload:   match.Summary,
+		FetchedAt: now,
+	})
+}
+
+// storeFile uploads the gzipped sample tracking or event data of a match
+func storeFile(storage services.StorageService, dir, videoID, kind string) (*models.VideoFile, *services.FileUploadInfo, error) {
+	data, err := fixtures.ReadFile("fixtures/" + kind + ".csv")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(data); err != nil {
+		return nil, nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, nil, err
+	}
+
+	sum := sha256.Sum256(buf.Bytes())
+	path := filepath.Join(dir, services.MatchFileName(videoID, kind, ""))
+	info, err := storage.UploadFile(fixtureFile{bytes.NewReader(buf.Bytes())}, path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	file := &models.VideoFile{
+		VideoID:  videoID,
+		Kind:     kind,
+		Path:     info.Path,
+		Size:     int64(buf.Len()),
+		Checksum: hex.EncodeToString(sum[:]),
+	}
+	return file, info, nil
+}
+
+// fixtureFile is generated fixture content, uploadable as a multipart file
+type fixtureFile struct {
+	*bytes.Reader
+}
+
+// Close does nothing; the content is in memory
+func (fixtureFile) Close() error { return nil }
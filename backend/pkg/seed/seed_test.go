@@ -0,0 +1,53 @@
+package seed_test
+
+import (
+	"testing"
+
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/seed"
+	"nivai/backend/pkg/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	repos := models.NewMemoryRepositories()
+	storage := services.NewMemoryStorageService()
+	snapshots := services.NewAnalyticsSnapshotService(repos.Snapshots, nil)
+
+	matches, err := seed.Matches()
+	require.NoError(t, err)
+	require.NotEmpty(t, matches)
+
+	result, err := seed.Load(repos, storage, services.IDShardStrategy{})
+	require.NoError(t, err)
+	assert.Equal(t, &seed.Result{Created: len(matches)}, result)
+
+	for _, match := range matches {
+		video, err := repos.Videos.FindByID(match.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.StateCompleted, video.ProcessingState)
+		assert.Equal(t, "memory", video.StorageProvider)
+
+		for _, path := range []string{video.TrackingPath, video.EventFilePath} {
+			exists, err := storage.Exists(path)
+			require.NoError(t, err)
+			assert.True(t, exists, path)
+		}
+
+		files, err := repos.VideoFiles.FindByVideoID(match.ID)
+		require.NoError(t, err)
+		assert.Len(t, files, 2)
+
+		// The snapshots are readable as analytics summaries
+		keyPlayers, err := snapshots.KeyPlayers([]string{match.ID}, 3)
+		require.NoError(t, err)
+		assert.Len(t, keyPlayers[match.ID], 3)
+	}
+
+	// Loading again skips the existing matches
+	result, err = seed.Load(repos, storage, services.IDShardStrategy{})
+	require.NoError(t, err)
+	assert.Equal(t, &seed.Result{Skipped: len(matches)}, result)
+}
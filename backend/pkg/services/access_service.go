@@ -0,0 +1,229 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/requestctx"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidAccessGrant is returned when an access grant names an unknown
+// subject type, resource type or permission, or no subject or resource.
+var ErrInvalidAccessGrant = errors.New("invalid access grant")
+
+/**
+ * AccessConfig tunes match-level access control.
+ */
+type AccessConfig struct {
+	Enforce           bool     // Restrict users to the matches granted to them; off, grants are kept but not enforced
+	UnrestrictedRoles []string // Roles that see every match regardless of grants, such as admin
+}
+
+/**
+ * AccessService keeps the access grants that give users and groups access
+ * to single matches or to all matches of a team, and decides from them
+ * which matches a caller may see and change. While enforcement is off, or
+ * for unrestricted roles, every match is accessible.
+ */
+type AccessService struct {
+	grants            models.AccessGrantRepository
+	videoRepo         models.VideoRepository
+	enforce           bool
+	unrestrictedRoles []string
+	now               func() time.Time
+}
+
+/**
+ * NewAccessService creates a new access service.
+ *
+ * @param grants Repository for the access grants
+ * @param videoRepo Repository for video data, to find the teams of a match
+ * @param config Whether grants are enforced, and for whom not
+ * @return A new access service
+ */
+func NewAccessService(grants models.AccessGrantRepository, videoRepo models.VideoRepository, config AccessConfig) *AccessService {
+	return &AccessService{
+		grants: grants, videoRepo: videoRepo,
+		enforce: config.Enforce, unrestrictedRoles: config.UnrestrictedRoles, now: time.Now,
+	}
+}
+
+/**
+ * Grant gives a subject access to a resource, replacing the permission of
+ * an earlier grant of the subject on the resource.
+ *
+ * @param grant The grant; Permission defaults to view
+ * @return An error, ErrInvalidAccessGrant or ErrVideoNotFound for unknown matches
+ */
+func (s *AccessService) Grant(grant *models.AccessGrant) error {
+	if grant.Permission == "" {
+		grant.Permission = models.AccessPermissionView
+	}
+	grant.SubjectID = strings.TrimSpace(grant.SubjectID)
+	grant.ResourceID = strings.TrimSpace(grant.ResourceID)
+	switch {
+	case grant.SubjectType != models.AccessSubjectUser && grant.SubjectType != models.AccessSubjectGroup:
+		return fmt.Errorf("%w: subject_type must be user or group", ErrInvalidAccessGrant)
+	case grant.ResourceType != models.AccessResourceMatch && grant.ResourceType != models.AccessResourceTeam:
+		return fmt.Errorf("%w: resource_type must be match or team", ErrInvalidAccessGrant)
+	case grant.Permission != models.AccessPermissionView && grant.Permission != models.AccessPermissionEdit:
+		return fmt.Errorf("%w: permission must be view or edit", ErrInvalidAccessGrant)
+	case grant.SubjectID == "" || grant.ResourceID == "":
+		return fmt.Errorf("%w: subject_id and resource_id are required", ErrInvalidAccessGrant)
+	}
+	if grant.ResourceType == models.AccessResourceMatch {
+		if _, err := s.videoRepo.FindByID(grant.ResourceID); err != nil {
+			if isNotFound(err) {
+				return ErrVideoNotFound
+			}
+			return err
+		}
+	}
+	grant.ID = uuid.New().String()
+	grant.CreatedAt = s.now().UTC()
+	return s.grants.Save(grant)
+}
+
+/**
+ * Revoke removes an access grant.
+ *
+ * @param id The grant
+ * @return An error, models.ErrAccessGrantNotFound when there is no such grant
+ */
+func (s *AccessService) Revoke(id string) error {
+	return s.grants.Delete(id)
+}
+
+/**
+ * List lists the grants on a resource, or every grant when resourceType is
+ * empty, oldest first.
+ *
+ * @param resourceType "match", "team" or empty
+ * @param resourceID The match ID or team name
+ * @return The grants or error
+ */
+func (s *AccessService) List(resourceType, resourceID string) ([]*models.AccessGrant, error) {
+	return s.grants.FindByResource(resourceType, resourceID)
+}
+
+/**
+ * ListForSubject lists the grants given to a user or a group, oldest first.
+ *
+ * @param subjectType "user" or "group"
+ * @param subjectID The user ID or group name
+ * @return The grants or error
+ */
+func (s *AccessService) ListForSubject(subjectType, subjectID string) ([]*models.AccessGrant, error) {
+	if subjectType == models.AccessSubjectGroup {
+		return s.grants.FindBySubjects("", []string{subjectID})
+	}
+	return s.grants.FindBySubjects(subjectID, nil)
+}
+
+/**
+ * Scope returns the matches a caller may see, for limiting queries: nil
+ * when the caller is not restricted.
+ *
+ * @param principal The caller
+ * @return The access scope or error
+ */
+func (s *AccessService) Scope(principal requestctx.Principal) (*models.AccessScope, error) {
+	if s.unrestricted(principal) {
+		return nil, nil
+	}
+	grants, err := s.grants.FindBySubjects(principal.UserID, principal.Groups)
+	if err != nil {
+		return nil, err
+	}
+	scope := &models.AccessScope{MatchIDs: []string{}, Teams: []string{}}
+	for _, grant := range grants {
+		if grant.ResourceType == models.AccessResourceMatch {
+			scope.MatchIDs = append(scope.MatchIDs, grant.ResourceID)
+		} else {
+			scope.Teams = append(scope.Teams, grant.ResourceID)
+		}
+	}
+	return scope, nil
+}
+
+/**
+ * CanAccessMatch reports whether a caller may see a match or, with write,
+ * change it: whether the caller or one of their groups was granted the
+ * match or one of its teams, with edit permission for writes.
+ *
+ * @param principal The caller
+ * @param matchID The match
+ * @param write Whether the caller wants to change the match
+ * @return Whether access is allowed, or error
+ */
+func (s *AccessService) CanAccessMatch(principal requestctx.Principal, matchID string, write bool) (bool, error) {
+	if s.unrestricted(principal) {
+		return true, nil
+	}
+	grants, err := s.grants.FindBySubjects(principal.UserID, principal.Groups)
+	if err != nil {
+		return false, err
+	}
+
+	var video *models.Video
+	for _, grant := range grants {
+		if write && grant.Permission != models.AccessPermissionEdit {
+			continue
+		}
+		switch grant.ResourceType {
+		case models.AccessResourceMatch:
+			if grant.ResourceID == matchID {
+				return true, nil
+			}
+		case models.AccessResourceTeam:
+			if video == nil {
+				if video, err = s.videoRepo.FindByID(matchID); err != nil {
+					if isNotFound(err) {
+						return false, nil
+					}
+					return false, err
+				}
+			}
+			if (&models.AccessScope{Teams: []string{grant.ResourceID}}).Allows(video) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+/**
+ * CanAccessTeam reports whether a caller may see a team's matches as a
+ * whole, as in season analytics: whether the caller or one of their groups
+ * was granted the team.
+ *
+ * @param principal The caller
+ * @param team The team name
+ * @return Whether access is allowed, or error
+ */
+func (s *AccessService) CanAccessTeam(principal requestctx.Principal, team string) (bool, error) {
+	if s.unrestricted(principal) {
+		return true, nil
+	}
+	grants, err := s.grants.FindBySubjects(principal.UserID, principal.Groups)
+	if err != nil {
+		return false, err
+	}
+	for _, grant := range grants {
+		if grant.ResourceType == models.AccessResourceTeam && strings.EqualFold(grant.ResourceID, team) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// unrestricted reports whether a caller sees every match.
+func (s *AccessService) unrestricted(principal requestctx.Principal) bool {
+	return !s.enforce || slices.Contains(s.unrestrictedRoles, principal.Role)
+}
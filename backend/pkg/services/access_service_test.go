@@ -0,0 +1,95 @@
+package services_test
+
+import (
+	"testing"
+
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/requestctx"
+	"nivai/backend/pkg/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newAccessService serves matches m1 (Ajax - PSV) and m2 (AZ - Feyenoord).
+func newAccessService(t *testing.T, enforce bool) *services.AccessService {
+	t.Helper()
+	videoRepo := models.NewMemoryVideoRepository()
+	require.NoError(t, videoRepo.Create(&models.Video{ID: "m1", HomeTeam: "Ajax", AwayTeam: "PSV"}))
+	require.NoError(t, videoRepo.Create(&models.Video{ID: "m2", HomeTeam: "AZ", AwayTeam: "Feyenoord"}))
+	return services.NewAccessService(models.NewMemoryAccessGrantRepository(), videoRepo,
+		services.AccessConfig{Enforce: enforce, UnrestrictedRoles: []string{"admin"}})
+}
+
+func TestAccessService(t *testing.T) {
+	analyst := requestctx.Principal{UserID: "u1", Role: "user", Groups: []string{"scouts"}}
+
+	t.Run("Grants on matches, teams and groups decide access", func(t *testing.T) {
+		access := newAccessService(t, true)
+		require.NoError(t, access.Grant(&models.AccessGrant{SubjectType: "user", SubjectID: "u1", ResourceType: "match", ResourceID: "m1"}))
+		require.NoError(t, access.Grant(&models.AccessGrant{SubjectType: "group", SubjectID: "scouts", ResourceType: "team", ResourceID: "feyenoord", Permission: "edit"}))
+
+		for matchID, want := range map[string][2]bool{"m1": {true, false}, "m2": {true, true}, "m3": {false, false}} {
+			canView, err := access.CanAccessMatch(analyst, matchID, false)
+			require.NoError(t, err)
+			canEdit, err := access.CanAccessMatch(analyst, matchID, true)
+			require.NoError(t, err)
+			assert.Equal(t, want, [2]bool{canView, canEdit}, matchID)
+		}
+
+		allowed, err := access.CanAccessTeam(analyst, "Feyenoord")
+		require.NoError(t, err)
+		assert.True(t, allowed)
+		allowed, err = access.CanAccessTeam(analyst, "Ajax")
+		require.NoError(t, err)
+		assert.False(t, allowed, "a match grant does not open its teams")
+
+		scope, err := access.Scope(analyst)
+		require.NoError(t, err)
+		assert.Equal(t, &models.AccessScope{MatchIDs: []string{"m1"}, Teams: []string{"feyenoord"}}, scope)
+	})
+
+	t.Run("Admins and unenforced grants are unrestricted", func(t *testing.T) {
+		scope, err := newAccessService(t, true).Scope(requestctx.Principal{UserID: "root", Role: "admin"})
+		require.NoError(t, err)
+		assert.Nil(t, scope)
+
+		allowed, err := newAccessService(t, false).CanAccessMatch(analyst, "m2", true)
+		require.NoError(t, err)
+		assert.True(t, allowed)
+	})
+
+	t.Run("Granting again changes the permission of the grant", func(t *testing.T) {
+		access := newAccessService(t, true)
+		first := &models.AccessGrant{SubjectType: "user", SubjectID: "u1", ResourceType: "match", ResourceID: "m1"}
+		require.NoError(t, access.Grant(first))
+		second := &models.AccessGrant{SubjectType: "user", SubjectID: "u1", ResourceType: "match", ResourceID: "m1", Permission: "edit"}
+		require.NoError(t, access.Grant(second))
+		assert.Equal(t, first.ID, second.ID)
+
+		grants, err := access.List("match", "m1")
+		require.NoError(t, err)
+		require.Len(t, grants, 1)
+		assert.Equal(t, "edit", grants[0].Permission)
+
+		require.NoError(t, access.Revoke(first.ID))
+		assert.ErrorIs(t, access.Revoke(first.ID), models.ErrAccessGrantNotFound)
+		grants, err = access.ListForSubject("user", "u1")
+		require.NoError(t, err)
+		assert.Empty(t, grants)
+	})
+
+	t.Run("Invalid grants are refused", func(t *testing.T) {
+		access := newAccessService(t, true)
+		for _, grant := range []*models.AccessGrant{
+			{SubjectType: "role", SubjectID: "u1", ResourceType: "match", ResourceID: "m1"},
+			{SubjectType: "user", SubjectID: "u1", ResourceType: "season", ResourceID: "2024"},
+			{SubjectType: "user", SubjectID: "u1", ResourceType: "match", ResourceID: "m1", Permission: "owner"},
+			{SubjectType: "user", SubjectID: " ", ResourceType: "team", ResourceID: "Ajax"},
+		} {
+			assert.ErrorIs(t, access.Grant(grant), services.ErrInvalidAccessGrant)
+		}
+		err := access.Grant(&models.AccessGrant{SubjectType: "user", SubjectID: "u1", ResourceType: "match", ResourceID: "missing"})
+		assert.ErrorIs(t, err, services.ErrVideoNotFound)
+	})
+}
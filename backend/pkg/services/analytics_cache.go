@@ -0,0 +1,369 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"nivai/backend/pkg/cache"
+	"nivai/backend/pkg/events"
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/pythonapi"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Cache key prefixes per result kind
+const (
+	cacheKindSummary = "summary"
+	cacheKindPlayer  = "player"
+	cacheKindTeam    = "team"
+)
+
+/**
+ * TTLPolicy returns how long cached analytics for a match stay fresh.
+ */
+type TTLPolicy func(matchID string) time.Duration
+
+/**
+ * FinalPolicy reports whether a match's analytics are final, i.e. the match
+ * has been processed and its results will not change until it is reprocessed.
+ */
+type FinalPolicy func(matchID string) bool
+
+// cacheEntry is a cached analytics result.
+type cacheEntry struct {
+	value     interface{}
+	fetchedAt time.Time
+}
+
+/**
+ * AnalyticsCache is a read-through cache in front of the Python analytics
+ * service. The first level is in memory, with freshness decided per match by
+ * a TTL policy so matches in match-day mode can be kept fresher than the
+ * rest. An optional persistent store holds results of processed matches,
+ * which are immutable, until the match is invalidated. Errors are never cached.
+ * Concurrent misses of the same result share one upstream request.
+ */
+type AnalyticsCache struct {
+	source AnalyticsReader
+	ttl    TTLPolicy
+	now    func() time.Time
+
+	store cache.Store
+	final FinalPolicy
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+
+	flight singleflight.Group // Upstream requests in progress, by cache key
+}
+
+/**
+ * AnalyticsCacheOption configures an AnalyticsCache.
+ */
+type AnalyticsCacheOption func(*AnalyticsCache)
+
+/**
+ * WithResultStore persists results of final matches in store, so they
+ * survive restarts and are shared between API instances.
+ *
+ * @param store Persistent store; nil disables persistence
+ * @param final Decides which matches are final
+ * @return An option enabling the persistent store
+ */
+func WithResultStore(store cache.Store, final FinalPolicy) AnalyticsCacheOption {
+	return func(c *AnalyticsCache) {
+		c.store = store
+		c.final = final
+	}
+}
+
+/**
+ * NewAnalyticsCache creates a new analytics cache.
+ *
+ * @param source Analytics service to read through to
+ * @param ttl Freshness per match; nil caches for five minutes
+ * @param opts Optional settings such as WithResultStore
+ * @return A new analytics cache
+ */
+func NewAnalyticsCache(source AnalyticsReader, ttl TTLPolicy, opts ...AnalyticsCacheOption) *AnalyticsCache {
+	if ttl == nil {
+		ttl = func(string) time.Duration { return 5 * time.Minute }
+	}
+	c := &AnalyticsCache{
+		source:  source,
+		ttl:     ttl,
+		now:     time.Now,
+		entries: make(map[string]cacheEntry),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Compile-time check that the cache can stand in for the analytics service.
+var _ AnalyticsReader = (*AnalyticsCache)(nil)
+
+/**
+ * GetMatchSummary returns the match summary, fetching it when stale.
+ *
+ * @param ctx Context for the upstream request
+ * @param matchID The match ID
+ * @return The match summary, or an error
+ */
+func (c *AnalyticsCache) GetMatchSummary(ctx context.Context, matchID string) (*pythonapi.MatchSummary, error) {
+	value, err := c.get(ctx, matchID, cacheKindSummary, "", func(ctx context.Context) (interface{}, error) {
+		return c.source.GetMatchSummary(ctx, matchID)
+	}, func(data []byte) (interface{}, error) {
+		var summary pythonapi.MatchSummary
+		return &summary, json.Unmarshal(data, &summary)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.(*pythonapi.MatchSummary), nil
+}
+
+/**
+ * GetPlayerDetails returns a player's details for a match, fetching them when stale.
+ *
+ * @param ctx Context for the upstream request
+ * @param matchID The match ID
+ * @param playerID The player ID
+ * @return The player details, or an error
+ */
+func (c *AnalyticsCache) GetPlayerDetails(ctx context.Context, matchID, playerID string) (*pythonapi.PlayerDetails, error) {
+	value, err := c.get(ctx, matchID, cacheKindPlayer, playerID, func(ctx context.Context) (interface{}, error) {
+		return c.source.GetPlayerDetails(ctx, matchID, playerID)
+	}, func(data []byte) (interface{}, error) {
+		var details pythonapi.PlayerDetails
+		return &details, json.Unmarshal(data, &details)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.(*pythonapi.PlayerDetails), nil
+}
+
+/**
+ * GetTeamSummaryOverTime returns a team's interval statistics for a match,
+ * fetching them when stale.
+ *
+ * @param ctx Context for the upstream request
+ * @param matchID The match ID
+ * @param teamID The team ID
+ * @return The team summary, or an error
+ */
+func (c *AnalyticsCache) GetTeamSummaryOverTime(ctx context.Context, matchID, teamID string) (*pythonapi.TeamSummaryOverTime, error) {
+	value, err := c.get(ctx, matchID, cacheKindTeam, teamID, func(ctx context.Context) (interface{}, error) {
+		return c.source.GetTeamSummaryOverTime(ctx, matchID, teamID)
+	}, func(data []byte) (interface{}, error) {
+		var summary pythonapi.TeamSummaryOverTime
+		return &summary, json.Unmarshal(data, &summary)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.(*pythonapi.TeamSummaryOverTime), nil
+}
+
+/**
+ * RefreshMatchSummary fetches the match summary regardless of freshness and
+ * caches it. Used to pre-warm the cache as partial results arrive.
+ *
+ * @param ctx Context for the upstream request
+ * @param matchID The match ID
+ * @return The summary, whether it differs from the previously cached one, or an error
+ */
+func (c *AnalyticsCache) RefreshMatchSummary(ctx context.Context, matchID string) (*pythonapi.MatchSummary, bool, error) {
+	summary, err := c.source.GetMatchSummary(ctx, matchID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	key := cacheKey(cacheKindSummary, matchID, "")
+	c.mu.Lock()
+	previous, had := c.entries[key]
+	c.entries[key] = cacheEntry{value: summary, fetchedAt: c.now()}
+	c.mu.Unlock()
+
+	changed := !had || !sameSummary(previous.value.(*pythonapi.MatchSummary), summary)
+	if changed {
+		c.persist(ctx, matchID, cacheKindSummary, "", summary)
+	}
+	return summary, changed, nil
+}
+
+/**
+ * InvalidateMatch drops every cached result of a match, including persisted
+ * ones. Call it whenever a match is reprocessed.
+ *
+ * @param matchID The match ID
+ */
+func (c *AnalyticsCache) InvalidateMatch(matchID string) {
+	c.mu.Lock()
+	for key := range c.entries {
+		if keyMatch(key) == matchID {
+			delete(c.entries, key)
+		}
+	}
+	c.mu.Unlock()
+
+	if c.store != nil {
+		if err := c.store.DeleteGroup(context.Background(), matchID); err != nil {
+			log.Printf("Analytics cache: failed to invalidate stored results for match %s: %v", matchID, err)
+		}
+	}
+}
+
+/**
+ * HandleEvent drops cached results when a match is reprocessed, its
+ * analytics complete or fail, or the match is deleted, and those of the
+ * matches of an erased player.
+ *
+ * @param event The published event
+ */
+func (c *AnalyticsCache) HandleEvent(event events.Event) {
+	switch event.Type {
+	case events.AnalyticsRequested, events.AnalyticsCompleted, events.AnalyticsFailed, events.VideoDeleted:
+		if videoID, ok := event.Data["video_id"].(string); ok {
+			c.InvalidateMatch(videoID)
+		}
+	case events.PlayerErased:
+		matchIDs, _ := event.Data["match_ids"].([]string)
+		for _, matchID := range matchIDs {
+			c.InvalidateMatch(matchID)
+		}
+	}
+}
+
+// get returns a fresh in-memory value, or loads it on a miss. Concurrent
+// misses of a key share one load, which is detached from the cancellation of
+// the caller that started it so the others are not failed with it; a caller
+// whose context ends stops waiting.
+func (c *AnalyticsCache) get(
+	ctx context.Context,
+	matchID, kind, id string,
+	fetch func(context.Context) (interface{}, error),
+	decode func([]byte) (interface{}, error),
+) (interface{}, error) {
+	key := cacheKey(kind, matchID, id)
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok && c.now().Sub(entry.fetchedAt) < c.ttl(matchID) {
+		return entry.value, nil
+	}
+
+	shared := context.WithoutCancel(ctx)
+	result := c.flight.DoChan(key, func() (interface{}, error) {
+		return c.load(shared, key, matchID, kind, id, fetch, decode)
+	})
+	select {
+	case res := <-result:
+		return res.Val, res.Err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// load returns a persisted value of a final match, or fetches, caches and
+// (for final matches) persists a new one.
+func (c *AnalyticsCache) load(
+	ctx context.Context,
+	key, matchID, kind, id string,
+	fetch func(context.Context) (interface{}, error),
+	decode func([]byte) (interface{}, error),
+) (interface{}, error) {
+	if c.store != nil {
+		if data, err := c.store.Get(ctx, matchID, storeKey(kind, id)); err == nil {
+			if value, err := decode(data); err == nil {
+				c.remember(key, value)
+				return value, nil
+			}
+			log.Printf("Analytics cache: discarding undecodable %s result for match %s", kind, matchID)
+		} else if !errors.Is(err, cache.ErrMiss) {
+			log.Printf("Analytics cache: store lookup failed for match %s: %v", matchID, err)
+		}
+	}
+
+	value, err := fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c.remember(key, value)
+	c.persist(ctx, matchID, kind, id, value)
+	return value, nil
+}
+
+// remember stores a value in the in-memory cache.
+func (c *AnalyticsCache) remember(key string, value interface{}) {
+	c.mu.Lock()
+	c.entries[key] = cacheEntry{value: value, fetchedAt: c.now()}
+	c.mu.Unlock()
+}
+
+// persist writes a result of a final match to the persistent store.
+// Failures only cost a refetch later, so they are logged.
+func (c *AnalyticsCache) persist(ctx context.Context, matchID, kind, id string, value interface{}) {
+	if c.store == nil || c.final == nil || !c.final(matchID) {
+		return
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		log.Printf("Analytics cache: failed to encode %s result for match %s: %v", kind, matchID, err)
+		return
+	}
+	if err := c.store.Set(ctx, matchID, storeKey(kind, id), data); err != nil {
+		log.Printf("Analytics cache: failed to persist %s result for match %s: %v", kind, matchID, err)
+	}
+}
+
+// cacheKey builds "<kind>|<matchID>|<id>".
+func cacheKey(kind, matchID, id string) string {
+	return kind + "|" + matchID + "|" + id
+}
+
+// storeKey is the key of a result within its match's store group.
+func storeKey(kind, id string) string {
+	return kind + "|" + id
+}
+
+// keyMatch extracts the match ID from a cache key.
+func keyMatch(key string) string {
+	parts := strings.SplitN(key, "|", 3)
+	if len(parts) < 2 {
+		return ""
+	}
+	return parts[1]
+}
+
+// sameSummary compares two summaries by their JSON content.
+func sameSummary(a, b *pythonapi.MatchSummary) bool {
+	aJSON, errA := json.Marshal(a)
+	bJSON, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(aJSON, bJSON)
+}
+
+/**
+ * ProcessedMatches is a FinalPolicy treating matches whose processing has
+ * completed as final.
+ *
+ * @param videoRepo Repository for video data
+ * @return The policy
+ */
+func ProcessedMatches(videoRepo models.VideoRepository) FinalPolicy {
+	return func(matchID string) bool {
+		video, err := videoRepo.FindByID(matchID)
+		return err == nil && video.ProcessingState == "completed"
+	}
+}
@@ -0,0 +1,211 @@
+package services_test
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"nivai/backend/pkg/cache"
+	"nivai/backend/pkg/events"
+	"nivai/backend/pkg/pythonapi"
+	"nivai/backend/pkg/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAnalyticsReader counts upstream calls and serves configurable summaries.
+type fakeAnalyticsReader struct {
+	players json.RawMessage
+	calls   int
+	err     error
+}
+
+func (f *fakeAnalyticsReader) GetMatchSummary(ctx context.Context, matchID string) (*pythonapi.MatchSummary, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &pythonapi.MatchSummary{MatchID: matchID, Players: f.players, Teams: json.RawMessage(`{}`)}, nil
+}
+
+func (f *fakeAnalyticsReader) GetPlayerDetails(ctx context.Context, matchID, playerID string) (*pythonapi.PlayerDetails, error) {
+	f.calls++
+	return &pythonapi.PlayerDetails{MatchID: matchID, PlayerID: playerID}, f.err
+}
+
+func (f *fakeAnalyticsReader) GetTeamSummaryOverTime(ctx context.Context, matchID, teamID string) (*pythonapi.TeamSummaryOverTime, error) {
+	f.calls++
+	return &pythonapi.TeamSummaryOverTime{MatchID: matchID, TeamID: teamID}, f.err
+}
+
+// blockingAnalyticsReader holds every summary request until release is closed.
+type blockingAnalyticsReader struct {
+	fakeAnalyticsReader
+	started chan struct{}
+	release chan struct{}
+	summary atomic.Int32
+}
+
+func (b *blockingAnalyticsReader) GetMatchSummary(ctx context.Context, matchID string) (*pythonapi.MatchSummary, error) {
+	if b.summary.Add(1) == 1 {
+		close(b.started)
+	}
+	<-b.release
+	return &pythonapi.MatchSummary{MatchID: matchID}, ctx.Err()
+}
+
+func TestAnalyticsCache(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Fresh results are served from the cache", func(t *testing.T) {
+		source := &fakeAnalyticsReader{players: json.RawMessage(`{"1":{}}`)}
+		cache := services.NewAnalyticsCache(source, nil)
+
+		_, err := cache.GetMatchSummary(ctx, "m1")
+		require.NoError(t, err)
+		_, err = cache.GetMatchSummary(ctx, "m1")
+		require.NoError(t, err)
+		_, err = cache.GetPlayerDetails(ctx, "m1", "p1")
+		require.NoError(t, err)
+		_, err = cache.GetPlayerDetails(ctx, "m1", "p1")
+		require.NoError(t, err)
+
+		assert.Equal(t, 2, source.calls)
+	})
+
+	t.Run("TTL policy applies per match", func(t *testing.T) {
+		source := &fakeAnalyticsReader{}
+		cache := services.NewAnalyticsCache(source, func(matchID string) time.Duration {
+			if matchID == "live" {
+				return 0 // Always stale
+			}
+			return time.Hour
+		})
+
+		cache.GetTeamSummaryOverTime(ctx, "live", "home")
+		cache.GetTeamSummaryOverTime(ctx, "live", "home")
+		cache.GetTeamSummaryOverTime(ctx, "archived", "home")
+		cache.GetTeamSummaryOverTime(ctx, "archived", "home")
+
+		assert.Equal(t, 3, source.calls)
+	})
+
+	t.Run("Errors are not cached", func(t *testing.T) {
+		source := &fakeAnalyticsReader{err: &pythonapi.APIError{StatusCode: 404, Detail: "not processed"}}
+		cache := services.NewAnalyticsCache(source, nil)
+
+		_, err := cache.GetMatchSummary(ctx, "m1")
+		assert.ErrorIs(t, err, pythonapi.ErrNotFound)
+		source.err = nil
+		summary, err := cache.GetMatchSummary(ctx, "m1")
+		require.NoError(t, err)
+		assert.Equal(t, "m1", summary.MatchID)
+	})
+
+	t.Run("Refresh reports changes and events invalidate", func(t *testing.T) {
+		source := &fakeAnalyticsReader{players: json.RawMessage(`{"1":{"distance":10}}`)}
+		cache := services.NewAnalyticsCache(source, nil)
+
+		_, changed, err := cache.RefreshMatchSummary(ctx, "m1")
+		require.NoError(t, err)
+		assert.True(t, changed)
+		_, changed, _ = cache.RefreshMatchSummary(ctx, "m1")
+		assert.False(t, changed)
+		source.players = json.RawMessage(`{"1":{"distance":25}}`)
+		summary, changed, _ := cache.RefreshMatchSummary(ctx, "m1")
+		assert.True(t, changed)
+		assert.JSONEq(t, `{"1":{"distance":25}}`, string(summary.Players))
+
+		// Served from cache, then refetched after completion invalidates it
+		cache.GetMatchSummary(ctx, "m1")
+		assert.Equal(t, 3, source.calls)
+		cache.HandleEvent(events.New(events.AnalyticsCompleted, map[string]interface{}{"video_id": "m1"}))
+		cache.GetMatchSummary(ctx, "m1")
+		assert.Equal(t, 4, source.calls)
+
+		// Erasing a player drops the results of their matches
+		cache.HandleEvent(events.New(events.PlayerErased, map[string]interface{}{"player_id": "p7", "match_ids": []string{"m1"}}))
+		cache.GetMatchSummary(ctx, "m1")
+		assert.Equal(t, 5, source.calls)
+	})
+
+	t.Run("Concurrent misses share one upstream request", func(t *testing.T) {
+		source := &blockingAnalyticsReader{started: make(chan struct{}), release: make(chan struct{})}
+		cache := services.NewAnalyticsCache(source, nil)
+
+		// The first caller gives up; its cancellation must not fail the others
+		first, cancel := context.WithCancel(ctx)
+		firstErr := make(chan error, 1)
+		go func() {
+			_, err := cache.GetMatchSummary(first, "m1")
+			firstErr <- err
+		}()
+		<-source.started
+
+		var wg sync.WaitGroup
+		results := make([]*pythonapi.MatchSummary, 5)
+		for i := range results {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				results[i], _ = cache.GetMatchSummary(ctx, "m1")
+			}()
+		}
+		cancel()
+		assert.ErrorIs(t, <-firstErr, context.Canceled)
+		time.Sleep(20 * time.Millisecond) // Let the others join the request in progress
+		close(source.release)
+		wg.Wait()
+
+		assert.Equal(t, int32(1), source.summary.Load())
+		for _, summary := range results {
+			require.NotNil(t, summary)
+			assert.Equal(t, "m1", summary.MatchID)
+		}
+	})
+}
+
+func TestAnalyticsCache_ResultStore(t *testing.T) {
+	ctx := context.Background()
+	final := map[string]bool{"done": true}
+	policy := func(matchID string) bool { return final[matchID] }
+
+	t.Run("Final results are persisted and survive restarts", func(t *testing.T) {
+		store := cache.NewMemoryStore()
+		source := &fakeAnalyticsReader{players: json.RawMessage(`{"1":{}}`)}
+
+		first := services.NewAnalyticsCache(source, nil, services.WithResultStore(store, policy))
+		_, err := first.GetMatchSummary(ctx, "done")
+		require.NoError(t, err)
+		_, err = first.GetMatchSummary(ctx, "processing")
+		require.NoError(t, err)
+
+		// A new instance (restart) serves the final match from the store
+		second := services.NewAnalyticsCache(source, nil, services.WithResultStore(store, policy))
+		summary, err := second.GetMatchSummary(ctx, "done")
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"1":{}}`, string(summary.Players))
+		_, err = second.GetMatchSummary(ctx, "processing")
+		require.NoError(t, err)
+		assert.Equal(t, 3, source.calls)
+	})
+
+	t.Run("Invalidation clears the store", func(t *testing.T) {
+		store := cache.NewMemoryStore()
+		source := &fakeAnalyticsReader{}
+		c := services.NewAnalyticsCache(source, nil, services.WithResultStore(store, policy))
+
+		_, err := c.GetTeamSummaryOverTime(ctx, "done", "home")
+		require.NoError(t, err)
+		_, err = store.Get(ctx, "done", "team|home")
+		require.NoError(t, err)
+
+		c.HandleEvent(events.New(events.AnalyticsCompleted, map[string]interface{}{"video_id": "done"}))
+		_, err = store.Get(ctx, "done", "team|home")
+		assert.ErrorIs(t, err, cache.ErrMiss)
+	})
+}
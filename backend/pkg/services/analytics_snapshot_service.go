@@ -0,0 +1,172 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sort"
+	"time"
+
+	"nivai/backend/pkg/events"
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/pythonapi"
+)
+
+// keyPlayerStat ranks the top performers of a match
+const keyPlayerStat = "total_distance_m"
+
+/**
+ * KeyPlayer is one of the top performers of a match.
+ */
+type KeyPlayer struct {
+	PlayerID       string   `json:"player_id"`
+	TotalDistanceM float64  `json:"total_distance_m"`
+	MaxSpeedKmh    *float64 `json:"max_speed_kmh,omitempty"`
+}
+
+/**
+ * AnalyticsSnapshotService persists analytics results from the Python
+ * service once a match has been processed, so they survive restarts of the
+ * (in-memory) analytics service and can be served without it.
+ */
+type AnalyticsSnapshotService struct {
+	repo    models.AnalyticsSnapshotRepository
+	source  AnalyticsSource
+	timeout time.Duration
+}
+
+/**
+ * NewAnalyticsSnapshotService creates a new snapshot service.
+ *
+ * @param repo Repository for analytics snapshots
+ * @param source Analytics service to fetch results from
+ * @return A new snapshot service
+ */
+func NewAnalyticsSnapshotService(repo models.AnalyticsSnapshotRepository, source AnalyticsSource) *AnalyticsSnapshotService {
+	return &AnalyticsSnapshotService{repo: repo, source: source, timeout: 30 * time.Second}
+}
+
+/**
+ * Capture fetches the current match summary and stores it as a snapshot.
+ *
+ * @param ctx Context for the upstream request
+ * @param videoID The video/match ID
+ * @return Error if fetching or storing fails
+ */
+func (s *AnalyticsSnapshotService) Capture(ctx context.Context, videoID string) error {
+	summary, err := s.source.GetMatchSummary(ctx, videoID)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(summary)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.Save(&models.AnalyticsSnapshot{
+		VideoID:   videoID,
+		Kind:      models.SnapshotKindSummary,
+		Payload:   payload,
+		FetchedAt: time.Now(),
+	})
+}
+
+/**
+ * HasSnapshot reports whether a summary snapshot exists for the video.
+ *
+ * @param videoID The video/match ID
+ * @return Whether a snapshot exists, or an error
+ */
+func (s *AnalyticsSnapshotService) HasSnapshot(videoID string) (bool, error) {
+	_, err := s.repo.Find(videoID, models.SnapshotKindSummary)
+	if err != nil {
+		if isNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+/**
+ * KeyPlayers returns the top performers of several matches from their stored
+ * snapshots, ranked by distance covered. Matches without a snapshot (not
+ * processed yet) are absent from the result; the analytics service is not
+ * consulted.
+ *
+ * @param videoIDs The video/match IDs
+ * @param limit Maximum number of players per match
+ * @return The key players per video ID, or an error
+ */
+func (s *AnalyticsSnapshotService) KeyPlayers(videoIDs []string, limit int) (map[string][]KeyPlayer, error) {
+	snapshots, err := s.repo.FindMany(videoIDs, models.SnapshotKindSummary)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string][]KeyPlayer, len(snapshots))
+	for videoID, snapshot := range snapshots {
+		var summary pythonapi.MatchSummary
+		var players map[string]map[string]interface{}
+		if err := json.Unmarshal(snapshot.Payload, &summary); err != nil || json.Unmarshal(summary.Players, &players) != nil {
+			log.Printf("Snapshots: unreadable player statistics in snapshot of video %s", videoID)
+			continue
+		}
+		result[videoID] = rankKeyPlayers(players, limit)
+	}
+	return result, nil
+}
+
+/**
+ * rankKeyPlayers picks the players with the highest distance covered.
+ *
+ * @param players Raw statistics keyed by player ID
+ * @param limit Maximum number of players
+ * @return The key players, best first
+ */
+func rankKeyPlayers(players map[string]map[string]interface{}, limit int) []KeyPlayer {
+	ranked := make([]KeyPlayer, 0, len(players))
+	for id, raw := range players {
+		stats := numericStats(raw)
+		distance, ok := stats[keyPlayerStat]
+		if !ok {
+			continue
+		}
+		player := KeyPlayer{PlayerID: id, TotalDistanceM: distance}
+		if speed, ok := stats["max_speed_kmh"]; ok {
+			player.MaxSpeedKmh = &speed
+		}
+		ranked = append(ranked, player)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].TotalDistanceM != ranked[j].TotalDistanceM {
+			return ranked[i].TotalDistanceM > ranked[j].TotalDistanceM
+		}
+		return ranked[i].PlayerID < ranked[j].PlayerID
+	})
+	if len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+	return ranked
+}
+
+/**
+ * HandleEvent captures a snapshot when analytics complete for a video.
+ * The capture runs in the background so event publishers are not blocked.
+ *
+ * @param event An analytics.completed event
+ */
+func (s *AnalyticsSnapshotService) HandleEvent(event events.Event) {
+	videoID, _ := event.Data["video_id"].(string)
+	if videoID == "" {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+		defer cancel()
+		if err := s.Capture(ctx, videoID); err != nil {
+			log.Printf("Snapshots: failed to capture analytics for video %s: %v", videoID, err)
+		}
+	}()
+}
@@ -0,0 +1,34 @@
+package services
+
+import (
+	"context"
+
+	"nivai/backend/pkg/pythonapi"
+)
+
+/**
+ * AnalyticsSource defines read access to the Python analytics service
+ * needed by background services (snapshots, audits).
+ * It is implemented by *pythonapi.Client; errors.Is(err, pythonapi.ErrNotFound)
+ * reports that the service has no data for a match.
+ */
+type AnalyticsSource interface {
+	GetMatchStatus(ctx context.Context, matchID string) (*pythonapi.MatchStatus, error)
+	GetMatchSummary(ctx context.Context, matchID string) (*pythonapi.MatchSummary, error)
+}
+
+// Compile-time check that the Python API client satisfies AnalyticsSource.
+var _ AnalyticsSource = (*pythonapi.Client)(nil)
+
+/**
+ * AnalyticsReader defines the analytics results served to API clients.
+ * It is implemented by *pythonapi.Client and by the caching AnalyticsCache.
+ */
+type AnalyticsReader interface {
+	GetMatchSummary(ctx context.Context, matchID string) (*pythonapi.MatchSummary, error)
+	GetPlayerDetails(ctx context.Context, matchID, playerID string) (*pythonapi.PlayerDetails, error)
+	GetTeamSummaryOverTime(ctx context.Context, matchID, teamID string) (*pythonapi.TeamSummaryOverTime, error)
+}
+
+// Compile-time check that the Python API client satisfies AnalyticsReader.
+var _ AnalyticsReader = (*pythonapi.Client)(nil)
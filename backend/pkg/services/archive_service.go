@@ -0,0 +1,344 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"nivai/backend/pkg/lock"
+	"nivai/backend/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrArchivingDisabled is returned when no cold storage is configured.
+	ErrArchivingDisabled = errors.New("cold-storage archiving is not configured")
+	// ErrArchiveConflict is returned when a match's state does not allow
+	// the requested archive or restore.
+	ErrArchiveConflict = errors.New("match cannot be archived or restored in its current state")
+)
+
+/**
+ * ArchiveService moves the large files of processed matches (video and
+ * tracking data) to cold storage and restores them on demand. Archived
+ * matches have the processing state "archived"; their analytics remain
+ * available, but their files cannot be downloaded until restored.
+ */
+type ArchiveService struct {
+	videoRepo    models.VideoRepository
+	jobs         models.ArchiveJobRepository
+	archiver     Archiver
+	pollInterval time.Duration
+	now          func() time.Time
+	states       stateMachine
+	locker       lock.Locker // Nil polls without a lock
+
+	mu         sync.Mutex
+	requesting map[string]bool // Jobs still issuing restore requests, not to be polled yet
+}
+
+/**
+ * ArchiveServiceOption configures an ArchiveService.
+ */
+type ArchiveServiceOption func(*ArchiveService)
+
+/**
+ * WithArchiveStateHistory records the processing state changes of archive
+ * and restore jobs.
+ *
+ * @param repo Repository for the state history
+ * @return An archive service option
+ */
+func WithArchiveStateHistory(repo models.StateHistoryRepository) ArchiveServiceOption {
+	return func(s *ArchiveService) {
+		s.states.history = repo
+	}
+}
+
+/**
+ * WithArchiveLocker makes the restore poller hold a lock shared by the
+ * replicas, so only one replica polls the running restores at a time.
+ *
+ * @param locker Locks shared by the replicas
+ * @return An archive service option
+ */
+func WithArchiveLocker(locker lock.Locker) ArchiveServiceOption {
+	return func(s *ArchiveService) {
+		s.locker = locker
+	}
+}
+
+/**
+ * NewArchiveService creates a new archive service.
+ *
+ * @param videoRepo Repository for video data
+ * @param jobs Repository for archive jobs
+ * @param archiver Cold storage; nil disables archiving
+ * @param pollInterval Time between checks of running restores (default 5m)
+ * @param opts Optional settings such as WithArchiveStateHistory
+ * @return A new archive service
+ */
+func NewArchiveService(videoRepo models.VideoRepository, jobs models.ArchiveJobRepository, archiver Archiver, pollInterval time.Duration, opts ...ArchiveServiceOption) *ArchiveService {
+	if pollInterval <= 0 {
+		pollInterval = 5 * time.Minute
+	}
+	s := &ArchiveService{
+		videoRepo:    videoRepo,
+		jobs:         jobs,
+		archiver:     archiver,
+		pollInterval: pollInterval,
+		now:          time.Now,
+		requesting:   map[string]bool{},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.states = stateMachine{videos: videoRepo, history: s.states.history, now: func() time.Time { return s.now() }}
+	return s
+}
+
+/**
+ * Archive starts moving a completed match's large files to cold storage.
+ * The match is "archived" as soon as the job starts, so no downloads begin
+ * while files are moving.
+ *
+ * @param videoID The match's video ID
+ * @return The running job, or ErrVideoNotFound, ErrArchivingDisabled or ErrArchiveConflict
+ */
+func (s *ArchiveService) Archive(videoID string) (*models.ArchiveJob, error) {
+	return s.start(videoID, models.ArchiveOperationArchive, models.StateCompleted, models.StateArchived, s.archiveFiles)
+}
+
+/**
+ * Restore starts bringing an archived match's files back from cold storage.
+ * Depending on the backend a restore completes immediately or takes hours;
+ * the job is polled by Run until all files are readable, and the match
+ * returns to "completed".
+ *
+ * @param videoID The match's video ID
+ * @return The running job, or ErrVideoNotFound, ErrArchivingDisabled or ErrArchiveConflict
+ */
+func (s *ArchiveService) Restore(videoID string) (*models.ArchiveJob, error) {
+	return s.start(videoID, models.ArchiveOperationRestore, models.StateArchived, models.StateRestoring, s.restoreFiles)
+}
+
+/**
+ * Job returns an archive job.
+ *
+ * @param id The job ID
+ * @return The job, or models.ErrArchiveJobNotFound
+ */
+func (s *ArchiveService) Job(id string) (*models.ArchiveJob, error) {
+	return s.jobs.FindByID(id)
+}
+
+/**
+ * Run polls running restores every poll interval until ctx is cancelled,
+ * completing those whose files are readable again. Restores interrupted by
+ * a restart are picked up on the first poll.
+ *
+ * @param ctx Context controlling the poller
+ */
+func (s *ArchiveService) Run(ctx context.Context) {
+	if s.archiver == nil {
+		return
+	}
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+	for {
+		s.poll(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll checks the running restores, holding the poller's lock if there is
+// one. Another replica holding it polls instead.
+func (s *ArchiveService) poll(ctx context.Context) {
+	if s.locker == nil {
+		s.pollRestores(ctx)
+		return
+	}
+	err := lock.Do(ctx, s.locker, "archive:restores", func(ctx context.Context) error {
+		s.pollRestores(ctx)
+		return nil
+	})
+	if err != nil && !errors.Is(err, lock.ErrHeld) {
+		log.Printf("Archive: failed to lock the restore poller: %v", err)
+	}
+}
+
+// start moves a match from the required state to the next state, creates a
+// running job and performs it in the background.
+func (s *ArchiveService) start(videoID, operation string, fromState, toState models.ProcessingState, perform func(ctx context.Context, video *models.Video) error) (*models.ArchiveJob, error) {
+	if s.archiver == nil {
+		return nil, ErrArchivingDisabled
+	}
+	video, err := s.videoRepo.FindByID(videoID)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, ErrVideoNotFound
+		}
+		return nil, err
+	}
+	if video.ProcessingState != fromState {
+		return nil, fmt.Errorf("%w: %s requires %q, match is %q", ErrArchiveConflict, operation, fromState, video.ProcessingState)
+	}
+
+	now := s.now()
+	job := &models.ArchiveJob{
+		ID: uuid.New().String(), VideoID: videoID, Operation: operation,
+		Status: models.ArchiveJobRunning, CreatedAt: now, UpdatedAt: now,
+	}
+	if err := s.jobs.Create(job); err != nil {
+		return nil, err
+	}
+	if err := s.setState(video, toState, operation+" started"); err != nil {
+		s.finish(job, err)
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.requesting[job.ID] = true
+	s.mu.Unlock()
+
+	go func() {
+		err := perform(context.Background(), video)
+		s.mu.Lock()
+		delete(s.requesting, job.ID)
+		s.mu.Unlock()
+		if err != nil {
+			s.fail(job, video, err)
+			return
+		}
+		if operation == models.ArchiveOperationArchive {
+			s.finish(job, nil)
+		} else {
+			s.checkRestore(context.Background(), job)
+		}
+	}()
+	return job, nil
+}
+
+// archiveFiles moves each large file of a video to the cold tier.
+func (s *ArchiveService) archiveFiles(ctx context.Context, video *models.Video) error {
+	for _, path := range largeFiles(video) {
+		if err := s.archiver.ArchiveFile(ctx, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// restoreFiles starts restoring each large file of a video that is not
+// readable already.
+func (s *ArchiveService) restoreFiles(ctx context.Context, video *models.Video) error {
+	for _, path := range largeFiles(video) {
+		restored, err := s.archiver.Restored(ctx, path)
+		if err != nil {
+			return err
+		}
+		if restored {
+			continue
+		}
+		if err := s.archiver.RestoreFile(ctx, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pollRestores checks every running restore.
+func (s *ArchiveService) pollRestores(ctx context.Context) {
+	jobs, err := s.jobs.FindRunning(models.ArchiveOperationRestore)
+	if err != nil {
+		log.Printf("Archive: failed to list running restores: %v", err)
+		return
+	}
+	for _, job := range jobs {
+		if ctx.Err() != nil {
+			return
+		}
+		s.mu.Lock()
+		requesting := s.requesting[job.ID]
+		s.mu.Unlock()
+		if !requesting {
+			s.checkRestore(ctx, job)
+		}
+	}
+}
+
+// checkRestore completes a restore job once all its files are readable.
+func (s *ArchiveService) checkRestore(ctx context.Context, job *models.ArchiveJob) {
+	video, err := s.videoRepo.FindByID(job.VideoID)
+	if err != nil {
+		log.Printf("Archive: failed to load video %s of restore %s: %v", job.VideoID, job.ID, err)
+		return
+	}
+	for _, path := range largeFiles(video) {
+		restored, err := s.archiver.Restored(ctx, path)
+		if err != nil {
+			log.Printf("Archive: failed to check restore of %s: %v", path, err)
+			return
+		}
+		if !restored {
+			return
+		}
+	}
+	if err := s.setState(video, models.StateCompleted, "restore completed"); err != nil {
+		log.Printf("Archive: failed to mark video %s restored: %v", video.ID, err)
+		return
+	}
+	s.finish(job, nil)
+}
+
+// fail marks a job failed. The match stays "archived" either way: after a
+// failed archive some files may already be in cold storage, and a restore
+// brings back whatever was moved.
+func (s *ArchiveService) fail(job *models.ArchiveJob, video *models.Video, cause error) {
+	log.Printf("Archive: %s of video %s failed: %v", job.Operation, video.ID, cause)
+	if err := s.setState(video, models.StateArchived, job.Operation+" failed: "+cause.Error()); err != nil {
+		log.Printf("Archive: failed to reset state of video %s: %v", video.ID, err)
+	}
+	s.finish(job, cause)
+}
+
+// finish records the outcome of a job.
+func (s *ArchiveService) finish(job *models.ArchiveJob, cause error) {
+	now := s.now()
+	job.Status = models.ArchiveJobCompleted
+	if cause != nil {
+		job.Status = models.ArchiveJobFailed
+		job.Error = cause.Error()
+	}
+	job.UpdatedAt = now
+	job.CompletedAt = sql.NullTime{Time: now, Valid: true}
+	if err := s.jobs.Update(job); err != nil {
+		log.Printf("Archive: failed to save job %s: %v", job.ID, err)
+	}
+}
+
+// setState updates a video's processing state.
+func (s *ArchiveService) setState(video *models.Video, state models.ProcessingState, reason string) error {
+	_, _, err := s.states.transition(video, state, StateChange{Actor: ActorArchive, Reason: reason})
+	return err
+}
+
+// largeFiles returns the paths of a video's files worth archiving.
+func largeFiles(video *models.Video) []string {
+	var paths []string
+	for _, path := range []string{video.FilePath, video.TrackingPath} {
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
@@ -0,0 +1,230 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryArchiveJobs is an in-memory ArchiveJobRepository.
+type memoryArchiveJobs struct {
+	mu   sync.Mutex
+	jobs map[string]models.ArchiveJob
+}
+
+func (m *memoryArchiveJobs) Create(job *models.ArchiveJob) error { return m.Update(job) }
+func (m *memoryArchiveJobs) Update(job *models.ArchiveJob) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.jobs == nil {
+		m.jobs = map[string]models.ArchiveJob{}
+	}
+	m.jobs[job.ID] = *job
+	return nil
+}
+func (m *memoryArchiveJobs) FindByID(id string) (*models.ArchiveJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job, ok := m.jobs[id]
+	if !ok {
+		return nil, models.ErrArchiveJobNotFound
+	}
+	return &job, nil
+}
+func (m *memoryArchiveJobs) FindRunning(operation string) ([]*models.ArchiveJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var jobs []*models.ArchiveJob
+	for _, job := range m.jobs {
+		if job.Status == models.ArchiveJobRunning && job.Operation == operation {
+			job := job
+			jobs = append(jobs, &job)
+		}
+	}
+	return jobs, nil
+}
+func (m *memoryArchiveJobs) FindByVideoID(videoID string) ([]*models.ArchiveJob, error) {
+	return m.filter(func(job models.ArchiveJob) bool { return job.VideoID == videoID }), nil
+}
+func (m *memoryArchiveJobs) FindFailedSince(since time.Time) ([]*models.ArchiveJob, error) {
+	return m.filter(func(job models.ArchiveJob) bool {
+		return job.Status == models.ArchiveJobFailed && !job.UpdatedAt.Before(since)
+	}), nil
+}
+func (m *memoryArchiveJobs) filter(match func(models.ArchiveJob) bool) []*models.ArchiveJob {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var jobs []*models.ArchiveJob
+	for _, job := range m.jobs {
+		if match(job) {
+			job := job
+			jobs = append(jobs, &job)
+		}
+	}
+	return jobs
+}
+
+// tierArchiver simulates an archive tier whose restores finish when told to.
+type tierArchiver struct {
+	mu       sync.Mutex
+	archived map[string]bool
+	pending  map[string]bool
+	failOn   string
+}
+
+func (a *tierArchiver) ArchiveFile(ctx context.Context, path string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if path == a.failOn {
+		return errors.New("tier change refused")
+	}
+	a.archived[path] = true
+	return nil
+}
+func (a *tierArchiver) RestoreFile(ctx context.Context, path string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.pending[path] = true
+	return nil
+}
+func (a *tierArchiver) Restored(ctx context.Context, path string) (bool, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return !a.archived[path], nil
+}
+func (a *tierArchiver) rehydrate() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for path := range a.pending {
+		delete(a.archived, path)
+	}
+}
+
+func TestArchiveService(t *testing.T) {
+	newVideo := func(state models.ProcessingState) *models.Video {
+		return &models.Video{ID: "v1", FilePath: "videos/v1.mp4", TrackingPath: "videos/v1_tracking.parquet", EventFilePath: "videos/v1_events.csv", ProcessingState: state}
+	}
+	waitFor := func(t *testing.T, jobs *memoryArchiveJobs, id, status string) *models.ArchiveJob {
+		var job *models.ArchiveJob
+		require.Eventually(t, func() bool {
+			job, _ = jobs.FindByID(id)
+			return job.Status == status
+		}, time.Second, 5*time.Millisecond)
+		return job
+	}
+
+	t.Run("Archive and restore a match", func(t *testing.T) {
+		video := newVideo("completed")
+		repo := new(MockVideoRepository)
+		repo.On("FindByID", "v1").Return(video, nil)
+		repo.On("Update", video).Return(nil)
+		repo.On("UpdateProcessingState", "v1", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+		jobs := &memoryArchiveJobs{}
+		archiver := &tierArchiver{archived: map[string]bool{}, pending: map[string]bool{}}
+		service := services.NewArchiveService(repo, jobs, archiver, 10*time.Millisecond)
+
+		job, err := service.Archive("v1")
+		require.NoError(t, err)
+		assert.Equal(t, models.StateArchived, video.ProcessingState)
+		waitFor(t, jobs, job.ID, models.ArchiveJobCompleted)
+		assert.Equal(t, map[string]bool{video.FilePath: true, video.TrackingPath: true}, archiver.archived,
+			"only the large files are archived")
+
+		_, err = service.Archive("v1")
+		assert.ErrorIs(t, err, services.ErrArchiveConflict)
+
+		restore, err := service.Restore("v1")
+		require.NoError(t, err)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go service.Run(ctx)
+
+		time.Sleep(30 * time.Millisecond)
+		running, _ := jobs.FindByID(restore.ID)
+		assert.Equal(t, models.ArchiveJobRunning, running.Status, "restore waits for rehydration")
+
+		archiver.rehydrate()
+		done := waitFor(t, jobs, restore.ID, models.ArchiveJobCompleted)
+		assert.True(t, done.CompletedAt.Valid)
+		assert.Eventually(t, func() bool { return video.ProcessingState == "completed" }, time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("Failed archive leaves the match restorable", func(t *testing.T) {
+		video := newVideo("completed")
+		repo := new(MockVideoRepository)
+		repo.On("FindByID", "v1").Return(video, nil)
+		repo.On("Update", mock.Anything).Return(nil)
+		repo.On("UpdateProcessingState", "v1", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+		jobs := &memoryArchiveJobs{}
+		archiver := &tierArchiver{archived: map[string]bool{}, pending: map[string]bool{}, failOn: video.TrackingPath}
+		service := services.NewArchiveService(repo, jobs, archiver, time.Hour)
+
+		job, err := service.Archive("v1")
+		require.NoError(t, err)
+		failed := waitFor(t, jobs, job.ID, models.ArchiveJobFailed)
+		assert.Contains(t, failed.Error, "tier change refused")
+		assert.Equal(t, models.StateArchived, video.ProcessingState)
+	})
+
+	t.Run("Disabled, missing match and wrong state", func(t *testing.T) {
+		repo := new(MockVideoRepository)
+		repo.On("FindByID", "v1").Return(newVideo("processing"), nil)
+		repo.On("FindByID", "nope").Return(nil, errors.New("video not found"))
+
+		_, err := services.NewArchiveService(repo, &memoryArchiveJobs{}, nil, 0).Archive("v1")
+		assert.ErrorIs(t, err, services.ErrArchivingDisabled)
+
+		service := services.NewArchiveService(repo, &memoryArchiveJobs{}, &tierArchiver{}, 0)
+		_, err = service.Archive("nope")
+		assert.ErrorIs(t, err, services.ErrVideoNotFound)
+		_, err = service.Archive("v1")
+		assert.ErrorIs(t, err, services.ErrArchiveConflict)
+		_, err = service.Restore("v1")
+		assert.ErrorIs(t, err, services.ErrArchiveConflict)
+	})
+}
+
+func TestColdStorage(t *testing.T) {
+	primaryDir, coldDir := t.TempDir(), t.TempDir()
+	primary, err := services.NewLocalFileStorage(primaryDir)
+	require.NoError(t, err)
+	cold, err := services.NewLocalFileStorage(coldDir)
+	require.NoError(t, err)
+	_, err = primary.(services.StreamUploader).UploadStream(strings.NewReader("video bytes"), "videos/v1.mp4")
+	require.NoError(t, err)
+
+	archiver, err := services.NewColdStorage(primary, cold)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	require.NoError(t, archiver.ArchiveFile(ctx, "videos/v1.mp4"))
+	restored, _ := archiver.Restored(ctx, "videos/v1.mp4")
+	assert.False(t, restored)
+	_, err = os.Stat(filepath.Join(coldDir, "videos/v1.mp4"))
+	assert.NoError(t, err, "the file is in cold storage")
+
+	require.NoError(t, archiver.RestoreFile(ctx, "videos/v1.mp4"))
+	restored, _ = archiver.Restored(ctx, "videos/v1.mp4")
+	assert.True(t, restored)
+	file, err := primary.GetFile("videos/v1.mp4")
+	require.NoError(t, err)
+	defer file.Close()
+	content, _ := io.ReadAll(file)
+	assert.Equal(t, "video bytes", string(content))
+
+	_, err = services.NewColdStorage(primary, new(MockStorageService))
+	assert.Error(t, err, "cold storage must support streamed uploads")
+}
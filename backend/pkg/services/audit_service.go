@@ -0,0 +1,464 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/pythonapi"
+
+	"github.com/google/uuid"
+)
+
+// Discrepancy kinds reported by the consistency auditor
+const (
+	DiscrepancyMissingFile          = "missing_file"
+	DiscrepancyChecksumMismatch     = "checksum_mismatch"
+	DiscrepancyMissingChecksum      = "missing_checksum"
+	DiscrepancyStatusMismatch       = "status_mismatch"
+	DiscrepancyAnalyticsMissing     = "analytics_missing"
+	DiscrepancyAnalyticsUnreachable = "analytics_unreachable"
+	DiscrepancyMissingSnapshot      = "missing_snapshot"
+)
+
+// Audit run states
+const (
+	AuditStatusRunning   = "running"
+	AuditStatusCompleted = "completed"
+	AuditStatusFailed    = "failed"
+)
+
+// ErrAuditNotFound is returned when an audit report ID is unknown.
+var ErrAuditNotFound = errors.New("audit not found")
+
+/**
+ * AuditOptions controls what an audit run checks and whether it repairs.
+ */
+type AuditOptions struct {
+	AutoRepair      bool `json:"auto_repair"`
+	VerifyChecksums bool `json:"verify_checksums"`
+}
+
+/**
+ * Discrepancy describes a single inconsistency found for a match.
+ * Repairable discrepancies can be fixed without risk of data loss.
+ */
+type Discrepancy struct {
+	VideoID     string `json:"video_id"`
+	Kind        string `json:"kind"`
+	Path        string `json:"path,omitempty"`
+	Detail      string `json:"detail"`
+	Repairable  bool   `json:"repairable"`
+	Repaired    bool   `json:"repaired"`
+	RepairError string `json:"repair_error,omitempty"`
+}
+
+/**
+ * AuditReport is the result of an audit run.
+ */
+type AuditReport struct {
+	ID             string        `json:"id"`
+	Status         string        `json:"status"`
+	Options        AuditOptions  `json:"options"`
+	StartedAt      time.Time     `json:"started_at"`
+	FinishedAt     *time.Time    `json:"finished_at,omitempty"`
+	MatchesChecked int           `json:"matches_checked"`
+	Discrepancies  []Discrepancy `json:"discrepancies"`
+	Error          string        `json:"error,omitempty"`
+}
+
+/**
+ * ConsistencyAuditor cross-checks every match across the database, file
+ * storage and the analytics service, and optionally repairs safe cases:
+ * recording missing checksums, syncing statuses that the analytics service
+ * has finished, and capturing missing analytics snapshots.
+ */
+type ConsistencyAuditor struct {
+	videoRepo    models.VideoRepository
+	fileRepo     models.VideoFileRepository
+	storage      StorageService
+	source       AnalyticsSource
+	snapshots    *AnalyticsSnapshotService
+	videoService VideoService
+
+	mu         sync.Mutex
+	reports    map[string]*AuditReport
+	order      []string
+	maxReports int
+}
+
+/**
+ * NewConsistencyAuditor creates a new consistency auditor.
+ *
+ * @param videoRepo Repository for video data
+ * @param fileRepo Repository for stored file checksums
+ * @param storage Storage service holding the files
+ * @param source Analytics service to compare statuses with
+ * @param snapshots Snapshot service for analytics snapshot checks/repairs
+ * @param videoService Video service used to repair processing states
+ * @return A new consistency auditor
+ */
+func NewConsistencyAuditor(
+	videoRepo models.VideoRepository,
+	fileRepo models.VideoFileRepository,
+	storage StorageService,
+	source AnalyticsSource,
+	snapshots *AnalyticsSnapshotService,
+	videoService VideoService,
+) *ConsistencyAuditor {
+	return &ConsistencyAuditor{
+		videoRepo:    videoRepo,
+		fileRepo:     fileRepo,
+		storage:      storage,
+		source:       source,
+		snapshots:    snapshots,
+		videoService: videoService,
+		reports:      make(map[string]*AuditReport),
+		maxReports:   20,
+	}
+}
+
+/**
+ * Start launches an audit run in the background.
+ *
+ * @param opts Audit options
+ * @return A copy of the newly created (running) report
+ */
+func (a *ConsistencyAuditor) Start(opts AuditOptions) *AuditReport {
+	report := a.newReport(opts)
+	go a.execute(context.Background(), report)
+	return a.copyReport(report)
+}
+
+/**
+ * Run performs an audit synchronously.
+ *
+ * @param ctx Context for the run
+ * @param opts Audit options
+ * @return The finished report
+ */
+func (a *ConsistencyAuditor) Run(ctx context.Context, opts AuditOptions) *AuditReport {
+	report := a.newReport(opts)
+	a.execute(ctx, report)
+	return a.copyReport(report)
+}
+
+/**
+ * Get returns a copy of a stored report.
+ *
+ * @param id The report ID
+ * @return The report, or ErrAuditNotFound
+ */
+func (a *ConsistencyAuditor) Get(id string) (*AuditReport, error) {
+	a.mu.Lock()
+	report, ok := a.reports[id]
+	a.mu.Unlock()
+	if !ok {
+		return nil, ErrAuditNotFound
+	}
+	return a.copyReport(report), nil
+}
+
+/**
+ * List returns copies of the retained reports, newest first.
+ *
+ * @return A slice of reports
+ */
+func (a *ConsistencyAuditor) List() []*AuditReport {
+	a.mu.Lock()
+	ids := append([]string(nil), a.order...)
+	a.mu.Unlock()
+
+	reports := make([]*AuditReport, 0, len(ids))
+	for i := len(ids) - 1; i >= 0; i-- {
+		if report, err := a.Get(ids[i]); err == nil {
+			reports = append(reports, report)
+		}
+	}
+	return reports
+}
+
+// newReport registers a running report, evicting the oldest beyond maxReports.
+func (a *ConsistencyAuditor) newReport(opts AuditOptions) *AuditReport {
+	report := &AuditReport{
+		ID:            uuid.New().String(),
+		Status:        AuditStatusRunning,
+		Options:       opts,
+		StartedAt:     time.Now(),
+		Discrepancies: []Discrepancy{},
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.reports[report.ID] = report
+	a.order = append(a.order, report.ID)
+	if len(a.order) > a.maxReports {
+		delete(a.reports, a.order[0])
+		a.order = a.order[1:]
+	}
+	return report
+}
+
+// copyReport returns a snapshot of a report safe to hand out while it runs.
+func (a *ConsistencyAuditor) copyReport(report *AuditReport) *AuditReport {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	c := *report
+	c.Discrepancies = append([]Discrepancy(nil), report.Discrepancies...)
+	return &c
+}
+
+// execute iterates all videos page by page and audits each one.
+func (a *ConsistencyAuditor) execute(ctx context.Context, report *AuditReport) {
+	const pageSize = 100
+	var runErr error
+
+	for offset := 0; ; offset += pageSize {
+		if err := ctx.Err(); err != nil {
+			runErr = err
+			break
+		}
+
+		videos, err := a.videoRepo.WithContext(ctx).FindAll(pageSize, offset)
+		if err != nil {
+			runErr = fmt.Errorf("failed to list videos: %w", err)
+			break
+		}
+
+		for _, video := range videos {
+			found := a.auditVideo(ctx, video, report.Options)
+			a.mu.Lock()
+			report.MatchesChecked++
+			report.Discrepancies = append(report.Discrepancies, found...)
+			a.mu.Unlock()
+		}
+
+		if len(videos) < pageSize {
+			break
+		}
+	}
+
+	finished := time.Now()
+	a.mu.Lock()
+	report.FinishedAt = &finished
+	report.Status = AuditStatusCompleted
+	if runErr != nil {
+		report.Status = AuditStatusFailed
+		report.Error = runErr.Error()
+		log.Printf("Audit %s failed: %v", report.ID, runErr)
+	}
+	a.mu.Unlock()
+}
+
+// auditVideo runs all checks for a single video.
+func (a *ConsistencyAuditor) auditVideo(ctx context.Context, video *models.Video, opts AuditOptions) []Discrepancy {
+	var found []Discrepancy
+	found = append(found, a.checkFiles(video, opts)...)
+
+	// Analytics checks only apply to uploads that carry tracking data.
+	if video.TrackingPath != "" {
+		found = append(found, a.checkAnalytics(ctx, video, opts)...)
+	}
+	return found
+}
+
+// checkFiles verifies that every referenced file exists and, optionally,
+// that its content matches the recorded checksum.
+func (a *ConsistencyAuditor) checkFiles(video *models.Video, opts AuditOptions) []Discrepancy {
+	var found []Discrepancy
+
+	records := map[string]*models.VideoFile{}
+	if opts.VerifyChecksums && a.fileRepo != nil {
+		files, err := a.fileRepo.FindByVideoID(video.ID)
+		if err != nil {
+			log.Printf("Audit: failed to load file records for video %s: %v", video.ID, err)
+		}
+		for _, f := range files {
+			records[f.Kind] = f
+		}
+	}
+
+	referenced := []struct{ kind, path string }{
+		{models.FileKindVideo, video.FilePath},
+		{models.FileKindTracking, video.TrackingPath},
+		{models.FileKindEvents, video.EventFilePath},
+	}
+
+	for _, ref := range referenced {
+		if ref.path == "" {
+			continue
+		}
+
+		if _, err := a.storage.GetFileMetadata(ref.path); err != nil {
+			found = append(found, Discrepancy{
+				VideoID: video.ID, Kind: DiscrepancyMissingFile, Path: ref.path,
+				Detail: fmt.Sprintf("%s file not accessible in storage: %v", ref.kind, err),
+			})
+			continue
+		}
+
+		if !opts.VerifyChecksums || a.fileRepo == nil {
+			continue
+		}
+
+		size, checksum, err := a.computeChecksum(ref.path)
+		if err != nil {
+			found = append(found, Discrepancy{
+				VideoID: video.ID, Kind: DiscrepancyMissingFile, Path: ref.path,
+				Detail: fmt.Sprintf("failed to read %s file: %v", ref.kind, err),
+			})
+			continue
+		}
+
+		record, ok := records[ref.kind]
+		if !ok || record.Checksum == "" {
+			d := Discrepancy{
+				VideoID: video.ID, Kind: DiscrepancyMissingChecksum, Path: ref.path,
+				Detail:     fmt.Sprintf("no checksum recorded for %s file", ref.kind),
+				Repairable: true,
+			}
+			if opts.AutoRepair {
+				now := time.Now()
+				a.recordRepair(&d, a.fileRepo.Save(&models.VideoFile{
+					VideoID: video.ID, Kind: ref.kind, Path: ref.path,
+					Size: size, Checksum: checksum, CreatedAt: now, UpdatedAt: now,
+				}))
+			}
+			found = append(found, d)
+			continue
+		}
+
+		if record.Checksum != checksum {
+			found = append(found, Discrepancy{
+				VideoID: video.ID, Kind: DiscrepancyChecksumMismatch, Path: ref.path,
+				Detail: fmt.Sprintf("%s file checksum %s does not match recorded %s", ref.kind, checksum, record.Checksum),
+			})
+		}
+	}
+
+	return found
+}
+
+// checkAnalytics compares the stored processing state with the analytics
+// service and verifies that processed matches have a snapshot.
+func (a *ConsistencyAuditor) checkAnalytics(ctx context.Context, video *models.Video, opts AuditOptions) []Discrepancy {
+	var found []Discrepancy
+
+	var pythonStatus string
+	status, err := a.source.GetMatchStatus(ctx, video.ID)
+	if err == nil {
+		pythonStatus = status.Status
+	}
+	switch {
+	case errors.Is(err, pythonapi.ErrNotFound):
+		if video.ProcessingState != "failed" {
+			found = append(found, Discrepancy{
+				VideoID: video.ID, Kind: DiscrepancyAnalyticsMissing,
+				Detail: fmt.Sprintf("analytics service has no record of match in state %q", video.ProcessingState),
+			})
+		}
+	case err != nil:
+		found = append(found, Discrepancy{
+			VideoID: video.ID, Kind: DiscrepancyAnalyticsUnreachable,
+			Detail: fmt.Sprintf("failed to fetch analytics status: %v", err),
+		})
+	default:
+		if d, ok := a.compareStatus(video, pythonStatus, opts); ok {
+			found = append(found, d)
+		}
+	}
+
+	if video.ProcessingState == "completed" && a.snapshots != nil {
+		hasSnapshot, err := a.snapshots.HasSnapshot(video.ID)
+		if err != nil {
+			log.Printf("Audit: failed to check snapshot for video %s: %v", video.ID, err)
+		} else if !hasSnapshot {
+			d := Discrepancy{
+				VideoID: video.ID, Kind: DiscrepancyMissingSnapshot,
+				Detail:     "processed match has no analytics snapshot",
+				Repairable: pythonStatus == pythonapi.StatusProcessed,
+			}
+			if opts.AutoRepair && d.Repairable {
+				a.recordRepair(&d, a.snapshots.Capture(ctx, video.ID))
+			}
+			found = append(found, d)
+		}
+	}
+
+	return found
+}
+
+// compareStatus reports a mismatch between the DB state and the analytics
+// status. Matches the analytics service has finished are repairable; the
+// repair updates the video, which also sets video.ProcessingState.
+func (a *ConsistencyAuditor) compareStatus(video *models.Video, pythonStatus string, opts AuditOptions) (Discrepancy, bool) {
+	expected := map[models.ProcessingState]string{
+		models.StateCompleted:        pythonapi.StatusProcessed,
+		models.StateFailed:           pythonapi.StatusError,
+		models.StatePendingAnalytics: pythonapi.StatusPending,
+		models.StateProcessing:       pythonapi.StatusPending,
+	}
+
+	want, tracked := expected[video.ProcessingState]
+	if !tracked || want == pythonStatus {
+		return Discrepancy{}, false
+	}
+
+	d := Discrepancy{
+		VideoID: video.ID, Kind: DiscrepancyStatusMismatch,
+		Detail: fmt.Sprintf("database state %q but analytics status %q", video.ProcessingState, pythonStatus),
+	}
+
+	waiting := video.ProcessingState == models.StatePendingAnalytics || video.ProcessingState == models.StateProcessing
+	var repairState models.ProcessingState
+	switch {
+	case waiting && pythonStatus == pythonapi.StatusProcessed:
+		repairState = models.StateCompleted
+	case waiting && pythonStatus == pythonapi.StatusError:
+		repairState = models.StateFailed
+	}
+
+	if repairState != "" {
+		d.Repairable = true
+		if opts.AutoRepair {
+			err := a.videoService.UpdateProcessingState(video.ID, repairState, StateChange{Actor: ActorAudit, Reason: d.Detail})
+			a.recordRepair(&d, err)
+			if err == nil {
+				video.ProcessingState = repairState
+			}
+		}
+	}
+	return d, true
+}
+
+// recordRepair stores the outcome of a repair attempt on a discrepancy.
+func (a *ConsistencyAuditor) recordRepair(d *Discrepancy, err error) {
+	if err != nil {
+		d.RepairError = err.Error()
+		return
+	}
+	d.Repaired = true
+}
+
+// computeChecksum streams a stored file and returns its size and SHA-256.
+func (a *ConsistencyAuditor) computeChecksum(path string) (int64, string, error) {
+	reader, err := a.storage.GetFile(path)
+	if err != nil {
+		return 0, "", err
+	}
+	defer reader.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, reader)
+	if err != nil {
+		return 0, "", err
+	}
+	return size, hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,191 @@
+package services_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/pythonapi"
+	"nivai/backend/pkg/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// --- MockVideoFileRepository ---
+type MockVideoFileRepository struct {
+	mock.Mock
+}
+
+func (m *MockVideoFileRepository) Save(file *models.VideoFile) error {
+	args := m.Called(file)
+	return args.Error(0)
+}
+func (m *MockVideoFileRepository) FindByVideoID(videoID string) ([]*models.VideoFile, error) {
+	args := m.Called(videoID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.VideoFile), args.Error(1)
+}
+
+// --- MockAnalyticsSnapshotRepository ---
+type MockAnalyticsSnapshotRepository struct {
+	mock.Mock
+}
+
+func (m *MockAnalyticsSnapshotRepository) Save(snapshot *models.AnalyticsSnapshot) error {
+	args := m.Called(snapshot)
+	return args.Error(0)
+}
+func (m *MockAnalyticsSnapshotRepository) Find(videoID, kind string) (*models.AnalyticsSnapshot, error) {
+	args := m.Called(videoID, kind)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.AnalyticsSnapshot), args.Error(1)
+}
+func (m *MockAnalyticsSnapshotRepository) FindMany(videoIDs []string, kind string) (map[string]*models.AnalyticsSnapshot, error) {
+	args := m.Called(videoIDs, kind)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]*models.AnalyticsSnapshot), args.Error(1)
+}
+
+// fakeAnalyticsSource serves fixed statuses and summaries keyed by match ID.
+type fakeAnalyticsSource struct {
+	statuses map[string]string
+}
+
+func (f *fakeAnalyticsSource) GetMatchStatus(ctx context.Context, matchID string) (*pythonapi.MatchStatus, error) {
+	status, ok := f.statuses[matchID]
+	if !ok {
+		return nil, &pythonapi.APIError{StatusCode: 404, Detail: "Match ID not found."}
+	}
+	return &pythonapi.MatchStatus{Status: status, MatchID: matchID}, nil
+}
+
+func (f *fakeAnalyticsSource) GetMatchSummary(ctx context.Context, matchID string) (*pythonapi.MatchSummary, error) {
+	if _, ok := f.statuses[matchID]; !ok {
+		return nil, &pythonapi.APIError{StatusCode: 404, Detail: "Match data not processed or match ID not found."}
+	}
+	return &pythonapi.MatchSummary{MatchID: matchID, Players: json.RawMessage(`{}`), Teams: json.RawMessage(`{}`)}, nil
+}
+
+func TestConsistencyAuditor_Run(t *testing.T) {
+	t.Run("Missing files are reported", func(t *testing.T) {
+		videoRepo := new(MockVideoRepository)
+		storage := new(MockStorageService)
+		video := &models.Video{ID: "v1", FilePath: "videos/v1.mp4", ProcessingState: "completed"}
+		videoRepo.On("FindAll", 100, 0).Return([]*models.Video{video}, nil).Once()
+		storage.On("GetFileMetadata", "videos/v1.mp4").Return(nil, errors.New("file not found")).Once()
+
+		auditor := services.NewConsistencyAuditor(videoRepo, nil, storage, &fakeAnalyticsSource{}, nil, nil)
+		report := auditor.Run(context.Background(), services.AuditOptions{})
+
+		assert.Equal(t, services.AuditStatusCompleted, report.Status)
+		assert.Equal(t, 1, report.MatchesChecked)
+		require.Len(t, report.Discrepancies, 1)
+		assert.Equal(t, services.DiscrepancyMissingFile, report.Discrepancies[0].Kind)
+		assert.False(t, report.Discrepancies[0].Repairable)
+		storage.AssertExpectations(t)
+	})
+
+	t.Run("Checksum mismatch and missing checksum are detected", func(t *testing.T) {
+		videoRepo := new(MockVideoRepository)
+		storage := new(MockStorageService)
+		fileRepo := new(MockVideoFileRepository)
+		video := &models.Video{ID: "v1", FilePath: "videos/v1.mp4", EventFilePath: "videos/v1_events.csv"}
+		videoRepo.On("FindAll", 100, 0).Return([]*models.Video{video}, nil).Once()
+		storage.On("GetFileMetadata", mock.Anything).Return(map[string]string{}, nil)
+		storage.On("GetFile", "videos/v1.mp4").Return(io.NopCloser(strings.NewReader("corrupted")), nil).Once()
+		storage.On("GetFile", "videos/v1_events.csv").Return(io.NopCloser(strings.NewReader("events")), nil).Once()
+		fileRepo.On("FindByVideoID", "v1").Return([]*models.VideoFile{
+			{VideoID: "v1", Kind: models.FileKindVideo, Checksum: "deadbeef"},
+		}, nil).Once()
+		fileRepo.On("Save", mock.MatchedBy(func(f *models.VideoFile) bool {
+			return f.Kind == models.FileKindEvents && f.Size == 6 && len(f.Checksum) == 64
+		})).Return(nil).Once()
+
+		auditor := services.NewConsistencyAuditor(videoRepo, fileRepo, storage, &fakeAnalyticsSource{}, nil, nil)
+		report := auditor.Run(context.Background(), services.AuditOptions{VerifyChecksums: true, AutoRepair: true})
+
+		require.Len(t, report.Discrepancies, 2)
+		assert.Equal(t, services.DiscrepancyChecksumMismatch, report.Discrepancies[0].Kind)
+		assert.False(t, report.Discrepancies[0].Repaired)
+		assert.Equal(t, services.DiscrepancyMissingChecksum, report.Discrepancies[1].Kind)
+		assert.True(t, report.Discrepancies[1].Repaired)
+		fileRepo.AssertExpectations(t)
+	})
+
+	t.Run("Finished analytics are repaired and snapshotted", func(t *testing.T) {
+		videoRepo := new(MockVideoRepository)
+		storage := new(MockStorageService)
+		snapshotRepo := new(MockAnalyticsSnapshotRepository)
+		source := &fakeAnalyticsSource{statuses: map[string]string{"v1": "processed"}}
+		video := &models.Video{ID: "v1", TrackingPath: "videos/v1_tracking.parquet", ProcessingState: "pending_analytics"}
+
+		videoRepo.On("FindAll", 100, 0).Return([]*models.Video{video}, nil).Once()
+		videoRepo.On("FindByID", "v1").Return(&models.Video{ID: "v1", ProcessingState: "pending_analytics"}, nil).Once()
+		videoRepo.On("UpdateProcessingState", "v1", models.StatePendingAnalytics, models.StateCompleted, mock.Anything).Return(nil).Once()
+		storage.On("GetFileMetadata", "videos/v1_tracking.parquet").Return(map[string]string{}, nil).Once()
+		snapshotRepo.On("Find", "v1", models.SnapshotKindSummary).Return(nil, errors.New("snapshot not found")).Once()
+		snapshotRepo.On("Save", mock.MatchedBy(func(s *models.AnalyticsSnapshot) bool {
+			return s.VideoID == "v1" && s.Kind == models.SnapshotKindSummary
+		})).Return(nil).Once()
+
+		videoService := services.NewVideoService(videoRepo, storage)
+		snapshots := services.NewAnalyticsSnapshotService(snapshotRepo, source)
+		auditor := services.NewConsistencyAuditor(videoRepo, nil, storage, source, snapshots, videoService)
+		report := auditor.Run(context.Background(), services.AuditOptions{AutoRepair: true})
+
+		require.Len(t, report.Discrepancies, 2)
+		assert.Equal(t, services.DiscrepancyStatusMismatch, report.Discrepancies[0].Kind)
+		assert.True(t, report.Discrepancies[0].Repaired)
+		assert.Equal(t, services.DiscrepancyMissingSnapshot, report.Discrepancies[1].Kind)
+		assert.True(t, report.Discrepancies[1].Repaired)
+		videoRepo.AssertExpectations(t)
+		snapshotRepo.AssertExpectations(t)
+	})
+
+	t.Run("Without auto-repair nothing is changed", func(t *testing.T) {
+		videoRepo := new(MockVideoRepository)
+		storage := new(MockStorageService)
+		source := &fakeAnalyticsSource{statuses: map[string]string{"v1": "error"}}
+		video := &models.Video{ID: "v1", TrackingPath: "videos/v1_tracking.parquet", ProcessingState: "processing"}
+
+		videoRepo.On("FindAll", 100, 0).Return([]*models.Video{video}, nil).Once()
+		storage.On("GetFileMetadata", "videos/v1_tracking.parquet").Return(map[string]string{}, nil).Once()
+
+		auditor := services.NewConsistencyAuditor(videoRepo, nil, storage, source, nil, services.NewVideoService(videoRepo, storage))
+		report := auditor.Run(context.Background(), services.AuditOptions{})
+
+		require.Len(t, report.Discrepancies, 1)
+		assert.True(t, report.Discrepancies[0].Repairable)
+		assert.False(t, report.Discrepancies[0].Repaired)
+		videoRepo.AssertNotCalled(t, "Update", mock.Anything)
+	})
+
+	t.Run("Repository failure fails the audit", func(t *testing.T) {
+		videoRepo := new(MockVideoRepository)
+		videoRepo.On("FindAll", 100, 0).Return(nil, errors.New("db down")).Once()
+
+		auditor := services.NewConsistencyAuditor(videoRepo, nil, new(MockStorageService), &fakeAnalyticsSource{}, nil, nil)
+		report := auditor.Run(context.Background(), services.AuditOptions{})
+
+		assert.Equal(t, services.AuditStatusFailed, report.Status)
+		assert.Contains(t, report.Error, "db down")
+
+		stored, err := auditor.Get(report.ID)
+		require.NoError(t, err)
+		assert.Equal(t, report.ID, stored.ID)
+		_, err = auditor.Get("unknown")
+		assert.ErrorIs(t, err, services.ErrAuditNotFound)
+	})
+}
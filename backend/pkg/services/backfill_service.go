@@ -0,0 +1,584 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/pythonapi"
+
+	"github.com/google/uuid"
+)
+
+// Backfill job states
+const (
+	BackfillStatusQueued    = "queued"
+	BackfillStatusRunning   = "running"
+	BackfillStatusCompleted = "completed"
+	BackfillStatusFailed    = "failed"
+)
+
+// Backfill entry states
+const (
+	BackfillEntryPending  = "pending"  // Not imported yet
+	BackfillEntryImported = "imported" // Match created, processing not finished
+	BackfillEntryDone     = "done"     // Processing finished, see ProcessingState
+	BackfillEntrySkipped  = "skipped"  // A match with the same match ID exists
+	BackfillEntryFailed   = "failed"   // The import failed, see Error
+)
+
+// ErrBackfillNotFound is returned when a backfill job ID is unknown.
+var ErrBackfillNotFound = errors.New("backfill job not found")
+
+/**
+ * MatchImporter creates a match from files in a source, like the drop
+ * folder ingestion does for deliveries.
+ */
+type MatchImporter interface {
+	Import(ctx context.Context, video *models.Video, files map[string]string, reason string) (string, error)
+}
+
+// Compile-time check that IngestionService satisfies MatchImporter.
+var _ MatchImporter = (*IngestionService)(nil)
+
+/**
+ * BackfillEntry is one past match in a backfill manifest. The paths are
+ * relative to the backfill directory.
+ */
+type BackfillEntry struct {
+	Title         string `json:"title,omitempty"`
+	MatchID       string `json:"match_id,omitempty"`
+	HomeTeam      string `json:"home_team,omitempty"`
+	AwayTeam      string `json:"away_team,omitempty"`
+	Competition   string `json:"competition,omitempty"`
+	Season        string `json:"season,omitempty"`
+	MatchDate     string `json:"match_date,omitempty"` // YYYY-MM-DD
+	TrackingPath  string `json:"tracking_path"`
+	EventFilePath string `json:"event_file_path"`
+	VideoPath     string `json:"video_path,omitempty"`
+}
+
+// backfillColumns are the CSV manifest columns, matching the JSON keys
+var backfillColumns = []string{"title", "match_id", "home_team", "away_team", "competition", "season", "match_date", "tracking_path", "event_file_path", "video_path"}
+
+/**
+ * ManifestError lists the problems that make a backfill manifest invalid.
+ */
+type ManifestError struct {
+	Problems []string
+}
+
+func (e *ManifestError) Error() string {
+	return "invalid backfill manifest: " + strings.Join(e.Problems, "; ")
+}
+
+/**
+ * BackfillEntryStatus is the progress of one manifest entry.
+ */
+type BackfillEntryStatus struct {
+	BackfillEntry
+	Status          string `json:"status"`
+	VideoID         string `json:"video_id,omitempty"`
+	ProcessingState string `json:"processing_state,omitempty"`
+	Error           string `json:"error,omitempty"`
+}
+
+/**
+ * BackfillJob tracks the import of a manifest. The counts summarise the
+ * entries; Imported includes the entries whose processing is Done.
+ */
+type BackfillJob struct {
+	ID         string                 `json:"id"`
+	Status     string                 `json:"status"`
+	CreatedBy  string                 `json:"created_by,omitempty"`
+	CreatedAt  time.Time              `json:"created_at"`
+	StartedAt  *time.Time             `json:"started_at,omitempty"`
+	FinishedAt *time.Time             `json:"finished_at,omitempty"`
+	Total      int                    `json:"total"`
+	Imported   int                    `json:"imported"`
+	Done       int                    `json:"done"`
+	Skipped    int                    `json:"skipped"`
+	Failed     int                    `json:"failed"`
+	Batch      int                    `json:"batch"` // Batch being imported or awaited, 1-based
+	Batches    int                    `json:"batches"`
+	Error      string                 `json:"error,omitempty"`
+	Entries    []*BackfillEntryStatus `json:"entries,omitempty"`
+}
+
+/**
+ * BackfillConfig tunes the pace of backfill imports.
+ */
+type BackfillConfig struct {
+	BatchSize    int           // Matches imported before waiting for their processing (default 10)
+	PollInterval time.Duration // How often a batch's processing is checked (default 30s)
+	BatchTimeout time.Duration // Longest wait for a batch before the next one starts (default 2h)
+	MaxEntries   int           // Largest manifest accepted (default 10000)
+}
+
+/**
+ * BackfillService imports past matches listed in manifests. Matches are
+ * imported in batches at low processing priority; each batch waits until
+ * its matches finished processing, so a backfill never floods the
+ * analytics service. Jobs run one at a time and are kept in memory on the
+ * instance that received them; the most recent maxJobs are retained.
+ */
+type BackfillService struct {
+	importer MatchImporter
+	videos   VideoService
+	cfg      BackfillConfig
+	now      func() time.Time
+	wake     chan struct{}
+
+	mu      sync.Mutex
+	jobs    map[string]*BackfillJob
+	order   []string
+	maxJobs int
+}
+
+/**
+ * NewBackfillService creates a new backfill service.
+ *
+ * @param importer Imports the matches from the backfill directory
+ * @param videos Video service used to find existing matches and follow processing
+ * @param cfg Backfill settings
+ * @return A new backfill service
+ */
+func NewBackfillService(importer MatchImporter, videos VideoService, cfg BackfillConfig) *BackfillService {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 10
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = 30 * time.Second
+	}
+	if cfg.BatchTimeout <= 0 {
+		cfg.BatchTimeout = 2 * time.Hour
+	}
+	if cfg.MaxEntries <= 0 {
+		cfg.MaxEntries = 10000
+	}
+	return &BackfillService{
+		importer: importer,
+		videos:   videos,
+		cfg:      cfg,
+		now:      time.Now,
+		wake:     make(chan struct{}, 1),
+		jobs:     make(map[string]*BackfillJob),
+		maxJobs:  50,
+	}
+}
+
+/**
+ * ParseManifest reads a backfill manifest, either a JSON array of entries
+ * (optionally wrapped as {"matches": [...]}) or a CSV file with a header
+ * row naming the columns, and validates its entries.
+ *
+ * @param data The manifest
+ * @return The entries, or a *ManifestError
+ */
+func (s *BackfillService) ParseManifest(data []byte) ([]BackfillEntry, error) {
+	var entries []BackfillEntry
+	var err error
+	switch trimmed := bytes.TrimSpace(data); {
+	case len(trimmed) == 0:
+		return nil, &ManifestError{Problems: []string{"manifest is empty"}}
+	case trimmed[0] == '[':
+		err = json.Unmarshal(trimmed, &entries)
+	case trimmed[0] == '{':
+		var wrapped struct {
+			Matches []BackfillEntry `json:"matches"`
+		}
+		err = json.Unmarshal(trimmed, &wrapped)
+		entries = wrapped.Matches
+	default:
+		entries, err = parseManifestCSV(trimmed)
+	}
+	if err != nil {
+		return nil, &ManifestError{Problems: []string{err.Error()}}
+	}
+
+	var problems []string
+	if len(entries) == 0 {
+		problems = append(problems, "manifest lists no matches")
+	}
+	if len(entries) > s.cfg.MaxEntries {
+		problems = append(problems, fmt.Sprintf("manifest lists %d matches, at most %d are accepted", len(entries), s.cfg.MaxEntries))
+	}
+	seen := map[string]int{}
+	for i := range entries {
+		entry := &entries[i]
+		problem := func(format string, args ...interface{}) {
+			problems = append(problems, fmt.Sprintf("entry %d: ", i+1)+fmt.Sprintf(format, args...))
+		}
+		if entry.Title == "" && entry.HomeTeam != "" && entry.AwayTeam != "" {
+			entry.Title = entry.HomeTeam + " vs " + entry.AwayTeam
+		}
+		if entry.Title == "" {
+			problem("title or home_team and away_team are required")
+		}
+		if entry.MatchDate != "" {
+			if _, err := time.Parse("2006-01-02", entry.MatchDate); err != nil {
+				problem("match_date must be YYYY-MM-DD")
+			}
+		}
+		if entry.MatchID != "" {
+			if first, ok := seen[entry.MatchID]; ok {
+				problem("match_id %q is also used by entry %d", entry.MatchID, first)
+			} else {
+				seen[entry.MatchID] = i + 1
+			}
+		}
+		for _, p := range []struct{ name, value string }{
+			{"tracking_path", entry.TrackingPath},
+			{"event_file_path", entry.EventFilePath},
+			{"video_path", entry.VideoPath},
+		} {
+			switch {
+			case p.value == "" && p.name != "video_path":
+				problem("%s is required", p.name)
+			case p.value != "" && !filepath.IsLocal(p.value):
+				problem("%s must be relative to the backfill directory", p.name)
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return nil, &ManifestError{Problems: problems}
+	}
+	return entries, nil
+}
+
+// parseManifestCSV reads CSV manifest rows by their header's column names.
+func parseManifestCSV(data []byte) ([]BackfillEntry, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	known := map[string]bool{}
+	for _, name := range backfillColumns {
+		known[name] = true
+	}
+	for name := range columns {
+		if !known[name] {
+			return nil, fmt.Errorf("unknown CSV column %q, expected %s", name, strings.Join(backfillColumns, ", "))
+		}
+	}
+
+	var entries []BackfillEntry
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV: %w", err)
+		}
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		entries = append(entries, BackfillEntry{
+			Title:         field("title"),
+			MatchID:       field("match_id"),
+			HomeTeam:      field("home_team"),
+			AwayTeam:      field("away_team"),
+			Competition:   field("competition"),
+			Season:        field("season"),
+			MatchDate:     field("match_date"),
+			TrackingPath:  field("tracking_path"),
+			EventFilePath: field("event_file_path"),
+			VideoPath:     field("video_path"),
+		})
+	}
+}
+
+/**
+ * Start queues a backfill job for validated manifest entries; it runs
+ * once the jobs queued before it finished.
+ *
+ * @param entries The entries from ParseManifest
+ * @param createdBy The requesting user's ID
+ * @return A copy of the job, without its entries
+ */
+func (s *BackfillService) Start(entries []BackfillEntry, createdBy string) *BackfillJob {
+	job := &BackfillJob{
+		ID:        uuid.New().String(),
+		Status:    BackfillStatusQueued,
+		CreatedBy: createdBy,
+		CreatedAt: s.now(),
+		Batches:   (len(entries) + s.cfg.BatchSize - 1) / s.cfg.BatchSize,
+	}
+	for _, entry := range entries {
+		job.Entries = append(job.Entries, &BackfillEntryStatus{BackfillEntry: entry, Status: BackfillEntryPending})
+	}
+
+	s.mu.Lock()
+	s.jobs[job.ID] = job
+	s.order = append(s.order, job.ID)
+	s.evict()
+	c := s.snapshot(job, false)
+	s.mu.Unlock()
+
+	select {
+	case s.wake <- struct{}{}:
+	default: // The worker is already woken
+	}
+	return c
+}
+
+// evict forgets the oldest finished jobs beyond maxJobs. Must hold s.mu.
+func (s *BackfillService) evict() {
+	for i := 0; len(s.order) > s.maxJobs && i < len(s.order); {
+		job := s.jobs[s.order[i]]
+		if job.Status != BackfillStatusCompleted && job.Status != BackfillStatusFailed {
+			i++
+			continue
+		}
+		delete(s.jobs, job.ID)
+		s.order = append(s.order[:i], s.order[i+1:]...)
+	}
+}
+
+/**
+ * Get returns a copy of a backfill job with its entries.
+ *
+ * @param id The job ID
+ * @return The job, or ErrBackfillNotFound
+ */
+func (s *BackfillService) Get(id string) (*BackfillJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, ErrBackfillNotFound
+	}
+	return s.snapshot(job, true), nil
+}
+
+/**
+ * List returns copies of the backfill jobs without their entries, newest
+ * first.
+ *
+ * @return The jobs
+ */
+func (s *BackfillService) List() []*BackfillJob {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	jobs := make([]*BackfillJob, 0, len(s.order))
+	for i := len(s.order) - 1; i >= 0; i-- {
+		jobs = append(jobs, s.snapshot(s.jobs[s.order[i]], false))
+	}
+	return jobs
+}
+
+// snapshot copies a job and tallies its entries. Must hold s.mu.
+func (s *BackfillService) snapshot(job *BackfillJob, withEntries bool) *BackfillJob {
+	c := *job
+	c.Entries = nil
+	c.Total, c.Imported, c.Done, c.Skipped, c.Failed = len(job.Entries), 0, 0, 0, 0
+	for _, entry := range job.Entries {
+		switch entry.Status {
+		case BackfillEntryImported:
+			c.Imported++
+		case BackfillEntryDone:
+			c.Imported++
+			c.Done++
+		case BackfillEntrySkipped:
+			c.Skipped++
+		case BackfillEntryFailed:
+			c.Failed++
+		}
+		if withEntries {
+			e := *entry
+			c.Entries = append(c.Entries, &e)
+		}
+	}
+	return &c
+}
+
+/**
+ * Run imports the queued backfill jobs one at a time until ctx is
+ * cancelled. A job interrupted by cancellation is marked failed; its
+ * imported matches are kept.
+ *
+ * @param ctx Context whose cancellation stops the worker
+ */
+func (s *BackfillService) Run(ctx context.Context) {
+	for {
+		for id := s.next(); id != ""; id = s.next() {
+			s.run(ctx, id)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wake:
+		}
+	}
+}
+
+// next returns the oldest queued job, or "" if none is queued.
+func (s *BackfillService) next() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, id := range s.order {
+		if s.jobs[id].Status == BackfillStatusQueued {
+			return id
+		}
+	}
+	return ""
+}
+
+// run imports a job's entries batch by batch.
+func (s *BackfillService) run(ctx context.Context, id string) {
+	var entries []*BackfillEntryStatus
+	s.update(id, func(job *BackfillJob) {
+		started := s.now()
+		job.Status, job.StartedAt = BackfillStatusRunning, &started
+		entries = job.Entries
+	})
+	log.Printf("Backfill %s: importing %d matches", id, len(entries))
+
+	ctx = WithPriority(ctx, pythonapi.PriorityLow)
+	for start, batch := 0, 1; start < len(entries); start, batch = start+s.cfg.BatchSize, batch+1 {
+		s.update(id, func(job *BackfillJob) { job.Batch = batch })
+		end := min(start+s.cfg.BatchSize, len(entries))
+		for _, entry := range entries[start:end] {
+			if ctx.Err() != nil {
+				break
+			}
+			s.importEntry(ctx, id, entry)
+		}
+		s.await(ctx, id, entries[start:end])
+		if ctx.Err() != nil {
+			s.finish(id, errors.New("interrupted by shutdown"))
+			return
+		}
+	}
+	s.finish(id, nil)
+}
+
+// importEntry imports one entry, skipping matches that exist already.
+func (s *BackfillService) importEntry(ctx context.Context, id string, entry *BackfillEntryStatus) {
+	if entry.MatchID != "" {
+		existing, err := s.videos.ListVideos(1, 0, map[string]string{"match_id": entry.MatchID})
+		if err != nil {
+			s.update(id, func(*BackfillJob) { entry.Status, entry.Error = BackfillEntryFailed, err.Error() })
+			return
+		}
+		if len(existing) > 0 {
+			s.update(id, func(*BackfillJob) { entry.Status, entry.VideoID = BackfillEntrySkipped, existing[0].ID })
+			return
+		}
+	}
+
+	video := &models.Video{
+		Title:       entry.Title,
+		MatchID:     entry.MatchID,
+		HomeTeam:    entry.HomeTeam,
+		AwayTeam:    entry.AwayTeam,
+		Competition: entry.Competition,
+		Season:      entry.Season,
+	}
+	video.MatchDate, _ = time.Parse("2006-01-02", entry.MatchDate) // Validated by ParseManifest
+	files := map[string]string{models.FileKindTracking: entry.TrackingPath, models.FileKindEvents: entry.EventFilePath}
+	if entry.VideoPath != "" {
+		files[models.FileKindVideo] = entry.VideoPath
+	}
+
+	videoID, err := s.importer.Import(ctx, video, files, "backfill "+id)
+	s.update(id, func(*BackfillJob) {
+		if err != nil {
+			entry.Status, entry.Error = BackfillEntryFailed, err.Error()
+			return
+		}
+		entry.Status, entry.VideoID, entry.ProcessingState = BackfillEntryImported, videoID, string(video.ProcessingState)
+	})
+	if err != nil {
+		log.Printf("Backfill %s: failed to import %q: %v", id, entry.Title, err)
+	}
+}
+
+// await polls a batch's imported matches until their processing finished,
+// the batch timeout passed or ctx is cancelled.
+func (s *BackfillService) await(ctx context.Context, id string, batch []*BackfillEntryStatus) {
+	deadline := s.now().Add(s.cfg.BatchTimeout)
+	for {
+		waiting := 0
+		for _, entry := range batch {
+			if entry.Status != BackfillEntryImported {
+				continue
+			}
+			video, err := s.videos.GetVideoByID(entry.VideoID)
+			if err != nil {
+				log.Printf("Backfill %s: failed to check video %s: %v", id, entry.VideoID, err)
+				waiting++
+				continue
+			}
+			s.update(id, func(*BackfillJob) {
+				entry.ProcessingState = string(video.ProcessingState)
+				switch video.ProcessingState {
+				case models.StatePending, models.StatePendingAnalytics, models.StateProcessing:
+				default:
+					entry.Status = BackfillEntryDone
+				}
+			})
+			if entry.Status == BackfillEntryImported {
+				waiting++
+			}
+		}
+		if waiting == 0 {
+			return
+		}
+		if !s.now().Before(deadline) {
+			log.Printf("Backfill %s: %d matches still processing after %s, starting the next batch", id, waiting, s.cfg.BatchTimeout)
+			return
+		}
+
+		timer := time.NewTimer(s.cfg.PollInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// finish marks a job completed, or failed with err.
+func (s *BackfillService) finish(id string, err error) {
+	s.update(id, func(job *BackfillJob) {
+		finished := s.now()
+		job.FinishedAt = &finished
+		job.Status = BackfillStatusCompleted
+		if err != nil {
+			job.Status, job.Error = BackfillStatusFailed, err.Error()
+		}
+	})
+	log.Printf("Backfill %s: finished", id)
+}
+
+// update changes a job under the lock. Entries are only changed through
+// update, so snapshots never see a half-written entry.
+func (s *BackfillService) update(id string, change func(job *BackfillJob)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if job, ok := s.jobs[id]; ok {
+		change(job)
+	}
+}
@@ -0,0 +1,146 @@
+package services_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// completingStarter finishes the processing of started matches at once.
+type completingStarter struct {
+	videos services.VideoService
+}
+
+func (s *completingStarter) Start(ctx context.Context, videoID, trackingPath, eventPath string) error {
+	return s.videos.UpdateProcessingState(videoID, models.StateCompleted, services.StateChange{Actor: services.ActorSystem})
+}
+
+func TestBackfillService(t *testing.T) {
+	setup := func(t *testing.T, starter func(services.VideoService) services.MatchStarter, cfg services.BackfillConfig) (*services.BackfillService, *models.MemoryVideoRepository) {
+		dir := t.TempDir()
+		for _, name := range []string{"2019/ajax-psv_tracking.csv", "2019/ajax-psv_events.json", "2019/az-fey_tracking.csv", "2019/az-fey_events.json", "2019/psv-az_tracking.csv"} {
+			require.NoError(t, os.MkdirAll(filepath.Join(dir, filepath.Dir(name)), 0o755))
+			require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("content of "+name), 0o644))
+		}
+		videoRepo := models.NewMemoryVideoRepository()
+		storage := services.NewMemoryStorageService()
+		videoService := services.NewVideoService(videoRepo, storage)
+		importer := services.NewIngestionService(services.NewDirectorySource(dir), videoService, storage, starter(videoService), services.IngestionConfig{})
+		return services.NewBackfillService(importer, videoService, cfg), videoRepo
+	}
+	run := func(t *testing.T, backfill *services.BackfillService, jobID string) *services.BackfillJob {
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			backfill.Run(ctx)
+			close(done)
+		}()
+		t.Cleanup(func() {
+			cancel()
+			<-done
+		})
+		var job *services.BackfillJob
+		require.Eventually(t, func() bool {
+			job, _ = backfill.Get(jobID)
+			return job.Status == services.BackfillStatusCompleted
+		}, 5*time.Second, 5*time.Millisecond)
+		return job
+	}
+
+	t.Run("Manifests are read as CSV or JSON and validated", func(t *testing.T) {
+		backfill, _ := setup(t, func(services.VideoService) services.MatchStarter { return &recordingStarter{} }, services.BackfillConfig{MaxEntries: 3})
+
+		entries, err := backfill.ParseManifest([]byte("home_team,away_team,match_date,tracking_path,event_file_path\nAjax,PSV,2019-05-12,2019/ajax-psv_tracking.csv,2019/ajax-psv_events.json\n"))
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, "Ajax vs PSV", entries[0].Title)
+		assert.Equal(t, "2019/ajax-psv_events.json", entries[0].EventFilePath)
+
+		entries, err = backfill.ParseManifest([]byte(`{"matches":[{"title":"AZ - Feyenoord","tracking_path":"t.csv","event_file_path":"e.json"}]}`))
+		require.NoError(t, err)
+		assert.Equal(t, "AZ - Feyenoord", entries[0].Title)
+
+		_, err = backfill.ParseManifest([]byte(`[
+			{"title":"a","match_id":"m1","tracking_path":"../secret","event_file_path":"e.json"},
+			{"match_id":"m1","match_date":"12-05-2019","tracking_path":"t.csv"}
+		]`))
+		var invalid *services.ManifestError
+		require.ErrorAs(t, err, &invalid)
+		assert.Equal(t, []string{
+			"entry 1: tracking_path must be relative to the backfill directory",
+			"entry 2: title or home_team and away_team are required",
+			"entry 2: match_date must be YYYY-MM-DD",
+			`entry 2: match_id "m1" is also used by entry 1`,
+			"entry 2: event_file_path is required",
+		}, invalid.Problems)
+
+		_, err = backfill.ParseManifest([]byte("title,stadium\na,b\n"))
+		require.ErrorAs(t, err, &invalid)
+		_, err = backfill.ParseManifest([]byte(" "))
+		require.ErrorAs(t, err, &invalid)
+	})
+
+	t.Run("Matches are imported in batches and existing ones skipped", func(t *testing.T) {
+		backfill, videoRepo := setup(t, func(videos services.VideoService) services.MatchStarter { return &completingStarter{videos: videos} },
+			services.BackfillConfig{BatchSize: 2, PollInterval: time.Millisecond})
+		require.NoError(t, videoRepo.Create(&models.Video{ID: "existing", Title: "PSV - AZ", MatchID: "psv-az-2019"}))
+		entries, err := backfill.ParseManifest([]byte(`[
+			{"home_team":"Ajax","away_team":"PSV","match_id":"ajax-psv-2019","match_date":"2019-05-12","tracking_path":"2019/ajax-psv_tracking.csv","event_file_path":"2019/ajax-psv_events.json"},
+			{"home_team":"PSV","away_team":"AZ","match_id":"psv-az-2019","tracking_path":"2019/psv-az_tracking.csv","event_file_path":"2019/psv-az_events.json"},
+			{"home_team":"AZ","away_team":"Feyenoord","tracking_path":"2019/az-fey_tracking.csv","event_file_path":"2019/missing.json"}
+		]`))
+		require.NoError(t, err)
+
+		started := backfill.Start(entries, "root")
+		assert.Equal(t, services.BackfillStatusQueued, started.Status)
+		assert.Equal(t, 2, started.Batches)
+		assert.Empty(t, started.Entries)
+
+		job := run(t, backfill, started.ID)
+		assert.Equal(t, 3, job.Total)
+		assert.Equal(t, 1, job.Imported)
+		assert.Equal(t, 1, job.Done)
+		assert.Equal(t, 1, job.Skipped)
+		assert.Equal(t, 1, job.Failed)
+		assert.Equal(t, 2, job.Batch)
+		require.Len(t, job.Entries, 3)
+		assert.Equal(t, services.BackfillEntryDone, job.Entries[0].Status)
+		assert.Equal(t, string(models.StateCompleted), job.Entries[0].ProcessingState)
+		assert.Equal(t, services.BackfillEntrySkipped, job.Entries[1].Status)
+		assert.Equal(t, "existing", job.Entries[1].VideoID)
+		assert.Equal(t, services.BackfillEntryFailed, job.Entries[2].Status)
+		assert.NotEmpty(t, job.Entries[2].Error)
+
+		video, err := videoRepo.FindByID(job.Entries[0].VideoID)
+		require.NoError(t, err)
+		assert.Equal(t, "Ajax vs PSV", video.Title)
+		assert.Equal(t, "ajax-psv-2019", video.MatchID)
+		assert.Equal(t, time.Date(2019, 5, 12, 0, 0, 0, 0, time.UTC), video.MatchDate)
+
+		listed := backfill.List()
+		require.Len(t, listed, 1)
+		assert.Empty(t, listed[0].Entries)
+		_, err = backfill.Get("unknown")
+		assert.ErrorIs(t, err, services.ErrBackfillNotFound)
+	})
+
+	t.Run("Batches still processing after the timeout are left behind", func(t *testing.T) {
+		backfill, _ := setup(t, func(services.VideoService) services.MatchStarter { return &recordingStarter{} },
+			services.BackfillConfig{BatchSize: 1, PollInterval: time.Millisecond, BatchTimeout: time.Millisecond})
+		entries, err := backfill.ParseManifest([]byte("title,tracking_path,event_file_path\na,2019/ajax-psv_tracking.csv,2019/ajax-psv_events.json\nb,2019/az-fey_tracking.csv,2019/az-fey_events.json\n"))
+		require.NoError(t, err)
+
+		job := run(t, backfill, backfill.Start(entries, "root").ID)
+		assert.Equal(t, 2, job.Imported)
+		assert.Zero(t, job.Done)
+		assert.Equal(t, string(models.StatePendingAnalytics), job.Entries[1].ProcessingState)
+	})
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"nivai/backend/pkg/models"
+)
+
+/**
+ * OrganizationSettings are the organization-wide settings shown to users.
+ */
+type OrganizationSettings struct {
+	Name     string `json:"name"`
+	Timezone string `json:"timezone"`
+	Locale   string `json:"locale"`
+}
+
+/**
+ * BootstrapUser identifies the authenticated user requesting the bootstrap.
+ */
+type BootstrapUser struct {
+	ID   string `json:"id"`
+	Role string `json:"role"`
+}
+
+/**
+ * Bootstrap is everything the frontend needs on startup, in one response.
+ */
+type Bootstrap struct {
+	User                BootstrapUser         `json:"user"`
+	Organization        OrganizationSettings  `json:"organization"`
+	FeatureFlags        map[string]bool       `json:"feature_flags"`
+	ReferenceData       *models.ReferenceData `json:"reference_data"`
+	UnreadNotifications int                   `json:"unread_notifications"`
+}
+
+/**
+ * UnreadNotificationCounter reports how many unread notifications a user has.
+ */
+type UnreadNotificationCounter interface {
+	CountUnread(ctx context.Context, userID string) (int, error)
+}
+
+/**
+ * BootstrapService defines the interface for assembling the bootstrap payload.
+ */
+type BootstrapService interface {
+	Bootstrap(ctx context.Context, user BootstrapUser) (*Bootstrap, error)
+}
+
+/**
+ * DefaultBootstrapService implements BootstrapService.
+ */
+type DefaultBootstrapService struct {
+	referenceData models.ReferenceDataRepository
+	organization  OrganizationSettings
+	featureFlags  map[string]bool
+	notifications UnreadNotificationCounter
+}
+
+/**
+ * NewBootstrapService creates a new bootstrap service.
+ *
+ * @param referenceData Repository for competitions, seasons and teams
+ * @param organization Organization settings
+ * @param featureFlags Feature flags keyed by name
+ * @param notifications Unread notification counter (optional; nil reports 0)
+ * @return A new bootstrap service implementation
+ */
+func NewBootstrapService(
+	referenceData models.ReferenceDataRepository,
+	organization OrganizationSettings,
+	featureFlags map[string]bool,
+	notifications UnreadNotificationCounter,
+) BootstrapService {
+	if featureFlags == nil {
+		featureFlags = map[string]bool{}
+	}
+	return &DefaultBootstrapService{
+		referenceData: referenceData,
+		organization:  organization,
+		featureFlags:  featureFlags,
+		notifications: notifications,
+	}
+}
+
+/**
+ * Bootstrap assembles the startup payload for a user. Reference data and the
+ * unread count are loaded concurrently. A failing notification count is
+ * reported as 0 so it cannot block startup.
+ *
+ * @param ctx Request context
+ * @param user The authenticated user
+ * @return The bootstrap payload, or an error if reference data cannot be loaded
+ */
+func (s *DefaultBootstrapService) Bootstrap(ctx context.Context, user BootstrapUser) (*Bootstrap, error) {
+	var (
+		wg            sync.WaitGroup
+		referenceData *models.ReferenceData
+		refErr        error
+		unread        int
+	)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		referenceData, refErr = s.referenceData.Load()
+	}()
+
+	if s.notifications != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			count, err := s.notifications.CountUnread(ctx, user.ID)
+			if err != nil {
+				log.Printf("Bootstrap: failed to count unread notifications for user %s: %v", user.ID, err)
+				return
+			}
+			unread = count
+		}()
+	}
+
+	wg.Wait()
+	if refErr != nil {
+		return nil, refErr
+	}
+
+	// Copy flags so callers cannot mutate the service's configuration.
+	flags := make(map[string]bool, len(s.featureFlags))
+	for name, enabled := range s.featureFlags {
+		flags[name] = enabled
+	}
+
+	return &Bootstrap{
+		User:                user,
+		Organization:        s.organization,
+		FeatureFlags:        flags,
+		ReferenceData:       referenceData,
+		UnreadNotifications: unread,
+	}, nil
+}
@@ -0,0 +1,89 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// --- MockReferenceDataRepository ---
+type MockReferenceDataRepository struct {
+	mock.Mock
+}
+
+func (m *MockReferenceDataRepository) Load() (*models.ReferenceData, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ReferenceData), args.Error(1)
+}
+
+// --- MockUnreadNotificationCounter ---
+type MockUnreadNotificationCounter struct {
+	mock.Mock
+}
+
+func (m *MockUnreadNotificationCounter) CountUnread(ctx context.Context, userID string) (int, error) {
+	args := m.Called(ctx, userID)
+	return args.Int(0), args.Error(1)
+}
+
+func TestBootstrapService_Bootstrap(t *testing.T) {
+	org := services.OrganizationSettings{Name: "FC Example", Timezone: "Europe/Amsterdam", Locale: "nl"}
+	refData := &models.ReferenceData{
+		Competitions: []string{"Eredivisie"},
+		Seasons:      []string{"2024/2025"},
+		Teams:        []string{"Ajax", "PSV"},
+	}
+	user := services.BootstrapUser{ID: "u1", Role: "admin"}
+
+	t.Run("Aggregates all sections", func(t *testing.T) {
+		refRepo := new(MockReferenceDataRepository)
+		refRepo.On("Load").Return(refData, nil).Once()
+		counter := new(MockUnreadNotificationCounter)
+		counter.On("CountUnread", mock.Anything, "u1").Return(3, nil).Once()
+
+		svc := services.NewBootstrapService(refRepo, org, map[string]bool{"exports": true}, counter)
+		bootstrap, err := svc.Bootstrap(context.Background(), user)
+		require.NoError(t, err)
+
+		assert.Equal(t, user, bootstrap.User)
+		assert.Equal(t, org, bootstrap.Organization)
+		assert.Equal(t, map[string]bool{"exports": true}, bootstrap.FeatureFlags)
+		assert.Equal(t, refData, bootstrap.ReferenceData)
+		assert.Equal(t, 3, bootstrap.UnreadNotifications)
+		refRepo.AssertExpectations(t)
+		counter.AssertExpectations(t)
+	})
+
+	t.Run("Notification failures do not block startup", func(t *testing.T) {
+		refRepo := new(MockReferenceDataRepository)
+		refRepo.On("Load").Return(refData, nil).Once()
+		counter := new(MockUnreadNotificationCounter)
+		counter.On("CountUnread", mock.Anything, "u1").Return(0, errors.New("inbox down")).Once()
+
+		svc := services.NewBootstrapService(refRepo, org, nil, counter)
+		bootstrap, err := svc.Bootstrap(context.Background(), user)
+		require.NoError(t, err)
+
+		assert.Equal(t, 0, bootstrap.UnreadNotifications)
+		assert.NotNil(t, bootstrap.FeatureFlags)
+	})
+
+	t.Run("Reference data failure is returned", func(t *testing.T) {
+		refRepo := new(MockReferenceDataRepository)
+		refRepo.On("Load").Return(nil, errors.New("db down")).Once()
+
+		svc := services.NewBootstrapService(refRepo, org, nil, nil)
+		_, err := svc.Bootstrap(context.Background(), user)
+		assert.EqualError(t, err, "db down")
+	})
+}
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+/**
+ * ColdStorage implements Archiver for backends without access tiers by
+ * moving files to a second, cheaper storage backend (another container,
+ * bucket or mount) under the same path.
+ */
+type ColdStorage struct {
+	primary StorageService
+	cold    StorageService
+}
+
+/**
+ * NewColdStorage creates an archiver moving files between two backends.
+ * Both must support streamed uploads.
+ *
+ * @param primary The storage backend serving the files
+ * @param cold The storage backend holding archived files
+ * @return A new archiver or error
+ */
+func NewColdStorage(primary, cold StorageService) (*ColdStorage, error) {
+	if _, ok := primary.(StreamUploader); !ok {
+		return nil, errors.New("primary storage does not support streamed uploads")
+	}
+	if _, ok := cold.(StreamUploader); !ok {
+		return nil, errors.New("cold storage does not support streamed uploads")
+	}
+	return &ColdStorage{primary: primary, cold: cold}, nil
+}
+
+/**
+ * ArchiveFile copies a file to cold storage and deletes the original.
+ *
+ * @param ctx Context for the operation
+ * @param path The path of the file
+ * @return Error if the copy fails
+ */
+func (c *ColdStorage) ArchiveFile(ctx context.Context, path string) error {
+	return moveFile(c.primary, c.cold, path)
+}
+
+/**
+ * RestoreFile copies a file back from cold storage and deletes the cold
+ * copy. The restore is complete when it returns.
+ *
+ * @param ctx Context for the operation
+ * @param path The path of the file
+ * @return Error if the copy fails
+ */
+func (c *ColdStorage) RestoreFile(ctx context.Context, path string) error {
+	return moveFile(c.cold, c.primary, path)
+}
+
+/**
+ * Restored reports whether the file is back in primary storage.
+ *
+ * @param ctx Context for the operation
+ * @param path The path of the file
+ * @return Whether the file can be read
+ */
+func (c *ColdStorage) Restored(ctx context.Context, path string) (bool, error) {
+	return c.primary.Exists(path)
+}
+
+// moveFile streams a file from one backend to another, then deletes the source.
+func moveFile(from, to StorageService, path string) error {
+	src, err := from.GetFile(path)
+	if err != nil {
+		return err
+	}
+	_, err = to.(StreamUploader).UploadStream(src, path)
+	src.Close()
+	if err != nil {
+		return fmt.Errorf("failed to copy %s: %w", path, err)
+	}
+	return from.DeleteFile(path)
+}
@@ -0,0 +1,501 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Replication operations
+const (
+	replicateCopy   = "copy"
+	replicateDelete = "delete"
+)
+
+/**
+ * ReplicationConfig tunes how CompositeStorage replicates to its secondary.
+ */
+type ReplicationConfig struct {
+	RetryInterval time.Duration // Delay before retrying a failed replication (default 30s)
+	MaxAttempts   int           // Attempts before a replication is given up (default 10)
+}
+
+/**
+ * ReplicationStats reports how far the secondary lags behind the primary.
+ */
+type ReplicationStats struct {
+	Pending          int        `json:"pending"`
+	LagSeconds       float64    `json:"lag_seconds"` // Age of the oldest pending replication
+	Replicated       int64      `json:"replicated"`
+	Failed           int64      `json:"failed"` // Replications given up after the maximum attempts
+	FailoverReads    int64      `json:"failover_reads"`
+	LastReplicatedAt *time.Time `json:"last_replicated_at,omitempty"`
+	LastError        string     `json:"last_error,omitempty"`
+	LastErrorAt      *time.Time `json:"last_error_at,omitempty"`
+}
+
+// replication is a pending change to apply to the secondary.
+type replication struct {
+	op        string
+	queuedAt  time.Time
+	attempts  int
+	notBefore time.Time
+}
+
+/**
+ * CompositeStorage implements StorageService over two backends, e.g. a local
+ * mount and Azure Blob Storage. Writes go to the primary and are replicated
+ * to the secondary in the background; reads fall back to the secondary when
+ * the primary fails.
+ *
+ * Pending replications are kept in memory. Run resyncs files missing from
+ * the secondary on start, so replications lost in a restart are caught up.
+ */
+type CompositeStorage struct {
+	primary   StorageService
+	secondary StorageService
+	cfg       ReplicationConfig
+	now       func() time.Time
+
+	wake chan struct{}
+
+	mu               sync.Mutex
+	pending          map[string]*replication // Latest change per path
+	replicated       int64
+	failed           int64
+	failoverReads    int64
+	lastReplicatedAt time.Time
+	lastError        string
+	lastErrorAt      time.Time
+}
+
+/**
+ * NewCompositeStorage creates a storage service replicating from primary to
+ * secondary. The secondary must support streamed uploads.
+ *
+ * @param primary The storage backend serving writes and reads
+ * @param secondary The storage backend holding replicas
+ * @param cfg Replication settings
+ * @return A new composite storage or error
+ */
+func NewCompositeStorage(primary, secondary StorageService, cfg ReplicationConfig) (*CompositeStorage, error) {
+	if _, ok := secondary.(StreamUploader); !ok {
+		return nil, errors.New("secondary storage does not support streamed uploads")
+	}
+	if cfg.RetryInterval <= 0 {
+		cfg.RetryInterval = 30 * time.Second
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 10
+	}
+	return &CompositeStorage{
+		primary:   primary,
+		secondary: secondary,
+		cfg:       cfg,
+		now:       time.Now,
+		wake:      make(chan struct{}, 1),
+		pending:   map[string]*replication{},
+	}, nil
+}
+
+/**
+ * Run resyncs the secondary, then replicates changes as they are queued
+ * until ctx is cancelled.
+ *
+ * @param ctx Context controlling the replicator
+ */
+func (c *CompositeStorage) Run(ctx context.Context) {
+	if queued, err := c.Resync(""); err != nil {
+		log.Printf("Storage replication: resync failed: %v", err)
+	} else if queued > 0 {
+		log.Printf("Storage replication: resync queued %d files missing from the secondary", queued)
+	}
+
+	ticker := time.NewTicker(c.cfg.RetryInterval)
+	defer ticker.Stop()
+	for {
+		c.ReplicatePending(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-c.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+/**
+ * Resync compares the files under prefix in both backends and queues the
+ * files that are missing from the secondary or differ in size. Files only
+ * the secondary holds are left alone, so an emptied primary never empties
+ * its replica.
+ *
+ * @param prefix The path prefix to compare; empty compares everything
+ * @return The number of files queued, or error if a backend cannot be listed
+ */
+func (c *CompositeStorage) Resync(prefix string) (int, error) {
+	primaryFiles, err := c.primary.ListFiles(prefix)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list primary: %w", err)
+	}
+	secondaryFiles, err := c.secondary.ListFiles(prefix)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list secondary: %w", err)
+	}
+
+	replicas := make(map[string]int64, len(secondaryFiles))
+	for _, file := range secondaryFiles {
+		replicas[file.Path] = file.Size
+	}
+	queued := 0
+	for _, file := range primaryFiles {
+		if size, ok := replicas[file.Path]; ok && size == file.Size {
+			continue
+		}
+		c.enqueue(file.Path, replicateCopy)
+		queued++
+	}
+	return queued, nil
+}
+
+/**
+ * ReplicatePending applies every queued change that is due to the
+ * secondary. Failed changes are retried after the retry interval.
+ *
+ * @param ctx Context for the pass; cancelling it stops between files
+ */
+func (c *CompositeStorage) ReplicatePending(ctx context.Context) {
+	for _, path := range c.duePaths() {
+		if ctx.Err() != nil {
+			return
+		}
+
+		c.mu.Lock()
+		change, ok := c.pending[path]
+		c.mu.Unlock()
+		if !ok {
+			continue
+		}
+
+		err := c.apply(path, change.op)
+
+		c.mu.Lock()
+		// A change queued for the path meanwhile stays pending
+		current := c.pending[path] == change
+		if err == nil {
+			c.replicated++
+			c.lastReplicatedAt = c.now()
+			if current {
+				delete(c.pending, path)
+			}
+		} else {
+			c.lastError = fmt.Sprintf("%s %s: %v", change.op, path, err)
+			c.lastErrorAt = c.now()
+			change.attempts++
+			if current && change.attempts >= c.cfg.MaxAttempts {
+				delete(c.pending, path)
+				c.failed++
+			} else {
+				change.notBefore = c.now().Add(c.cfg.RetryInterval)
+			}
+		}
+		c.mu.Unlock()
+
+		if err != nil {
+			log.Printf("Storage replication: failed to %s %s: %v", change.op, path, err)
+		}
+	}
+}
+
+/**
+ * Stats reports the replication lag and counters.
+ *
+ * @return The replication statistics
+ */
+func (c *CompositeStorage) Stats() ReplicationStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := ReplicationStats{
+		Pending:       len(c.pending),
+		Replicated:    c.replicated,
+		Failed:        c.failed,
+		FailoverReads: c.failoverReads,
+		LastError:     c.lastError,
+	}
+	now := c.now()
+	for _, change := range c.pending {
+		if lag := now.Sub(change.queuedAt).Seconds(); lag > stats.LagSeconds {
+			stats.LagSeconds = lag
+		}
+	}
+	if !c.lastReplicatedAt.IsZero() {
+		at := c.lastReplicatedAt
+		stats.LastReplicatedAt = &at
+	}
+	if !c.lastErrorAt.IsZero() {
+		at := c.lastErrorAt
+		stats.LastErrorAt = &at
+	}
+	return stats
+}
+
+// enqueue records a change for a path, replacing any pending one, and wakes
+// the replicator.
+func (c *CompositeStorage) enqueue(path, op string) {
+	c.mu.Lock()
+	queuedAt := c.now()
+	if previous, ok := c.pending[path]; ok {
+		queuedAt = previous.queuedAt // The secondary has been behind since then
+	}
+	c.pending[path] = &replication{op: op, queuedAt: queuedAt}
+	c.mu.Unlock()
+
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+}
+
+// duePaths returns the pending paths whose retry delay has passed, oldest first.
+func (c *CompositeStorage) duePaths() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	paths := make([]string, 0, len(c.pending))
+	for path, change := range c.pending {
+		if !now.Before(change.notBefore) {
+			paths = append(paths, path)
+		}
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		return c.pending[paths[i]].queuedAt.Before(c.pending[paths[j]].queuedAt)
+	})
+	return paths
+}
+
+// apply replicates one change to the secondary.
+func (c *CompositeStorage) apply(path, op string) error {
+	if op == replicateDelete {
+		exists, err := c.secondary.Exists(path)
+		if err != nil || !exists {
+			return err
+		}
+		return c.secondary.DeleteFile(path)
+	}
+
+	src, err := c.primary.GetFile(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	_, err = c.secondary.(StreamUploader).UploadStream(src, path)
+	return err
+}
+
+// failover records a read served by the secondary.
+func (c *CompositeStorage) failover(op, path string, err error) {
+	c.mu.Lock()
+	c.failoverReads++
+	c.mu.Unlock()
+	log.Printf("Storage replication: %s of %s failed on the primary, reading the secondary: %v", op, path, err)
+}
+
+/**
+ * UploadFile stores a file in the primary and queues its replication.
+ *
+ * @param file The file to upload
+ * @param path The destination path in the storage
+ * @return Upload information from the primary or error
+ */
+func (c *CompositeStorage) UploadFile(file multipart.File, path string) (*FileUploadInfo, error) {
+	info, err := c.primary.UploadFile(file, path)
+	if err != nil {
+		return nil, err
+	}
+	c.enqueue(path, replicateCopy)
+	return info, nil
+}
+
+/**
+ * UploadStream stores a stream in the primary and queues its replication.
+ * It fails when the primary does not support streamed uploads.
+ *
+ * @param r The content to store
+ * @param path The destination path in the storage
+ * @return Upload information from the primary or error
+ */
+func (c *CompositeStorage) UploadStream(r io.Reader, path string) (*FileUploadInfo, error) {
+	uploader, ok := c.primary.(StreamUploader)
+	if !ok {
+		return nil, errors.New("primary storage does not support streamed uploads")
+	}
+	info, err := uploader.UploadStream(r, path)
+	if err != nil {
+		return nil, err
+	}
+	c.enqueue(path, replicateCopy)
+	return info, nil
+}
+
+/**
+ * GetFile retrieves a file from the primary, or from the secondary when the
+ * primary fails.
+ *
+ * @param path The path of the file in storage
+ * @return A reader for the file content or error
+ */
+func (c *CompositeStorage) GetFile(path string) (io.ReadCloser, error) {
+	file, err := c.primary.GetFile(path)
+	if err == nil {
+		return file, nil
+	}
+	if replica, replicaErr := c.secondary.GetFile(path); replicaErr == nil {
+		c.failover("read", path, err)
+		return replica, nil
+	}
+	return nil, err
+}
+
+/**
+ * DeleteFile removes a file from the primary and queues its removal from
+ * the secondary.
+ *
+ * @param path The path of the file to delete
+ * @return Error if the primary deletion fails
+ */
+func (c *CompositeStorage) DeleteFile(path string) error {
+	if err := c.primary.DeleteFile(path); err != nil {
+		return err
+	}
+	c.enqueue(path, replicateDelete)
+	return nil
+}
+
+/**
+ * GetStreamURL generates a streaming URL on the primary, or on the secondary
+ * when the primary fails.
+ *
+ * @param path The path of the file in storage
+ * @return A URL for accessing the file or error
+ */
+func (c *CompositeStorage) GetStreamURL(path string) (string, error) {
+	url, err := c.primary.GetStreamURL(path)
+	if err == nil {
+		return url, nil
+	}
+	if replica, replicaErr := c.secondary.GetStreamURL(path); replicaErr == nil {
+		c.failover("stream URL", path, err)
+		return replica, nil
+	}
+	return "", err
+}
+
+/**
+ * GetFileMetadata retrieves metadata from the primary, or from the
+ * secondary when the primary fails.
+ *
+ * @param path The path of the file in storage
+ * @return A map of metadata or error
+ */
+func (c *CompositeStorage) GetFileMetadata(path string) (map[string]string, error) {
+	metadata, err := c.primary.GetFileMetadata(path)
+	if err == nil {
+		return metadata, nil
+	}
+	if replica, replicaErr := c.secondary.GetFileMetadata(path); replicaErr == nil {
+		c.failover("metadata", path, err)
+		return replica, nil
+	}
+	return nil, err
+}
+
+/**
+ * ListFiles lists the files in the primary, or in the secondary when the
+ * primary fails.
+ *
+ * @param prefix The path prefix
+ * @return The matching files or error
+ */
+func (c *CompositeStorage) ListFiles(prefix string) ([]FileInfo, error) {
+	files, err := c.primary.ListFiles(prefix)
+	if err == nil {
+		return files, nil
+	}
+	if replica, replicaErr := c.secondary.ListFiles(prefix); replicaErr == nil {
+		c.failover("list", prefix, err)
+		return replica, nil
+	}
+	return nil, err
+}
+
+/**
+ * Exists reports whether a file is in the primary, asking the secondary
+ * when the primary fails.
+ *
+ * @param path The path of the file in storage
+ * @return Whether the file exists, or error
+ */
+func (c *CompositeStorage) Exists(path string) (bool, error) {
+	exists, err := c.primary.Exists(path)
+	if err == nil {
+		return exists, nil
+	}
+	if replica, replicaErr := c.secondary.Exists(path); replicaErr == nil {
+		c.failover("exists", path, err)
+		return replica, nil
+	}
+	return false, err
+}
+
+/**
+ * CopyFile copies a file in the primary and queues replication of the copy.
+ *
+ * @param src The path of the file to copy
+ * @param dst The destination path
+ * @return Error if the copy fails
+ */
+func (c *CompositeStorage) CopyFile(src, dst string) error {
+	if err := c.primary.CopyFile(src, dst); err != nil {
+		return err
+	}
+	c.enqueue(dst, replicateCopy)
+	return nil
+}
+
+/**
+ * MoveFile moves a file in the primary and queues the same move on the
+ * secondary, as a copy of the destination and a removal of the source.
+ *
+ * @param src The path of the file to move
+ * @param dst The destination path
+ * @return Error if the move fails
+ */
+func (c *CompositeStorage) MoveFile(src, dst string) error {
+	if err := c.primary.MoveFile(src, dst); err != nil {
+		return err
+	}
+	c.enqueue(dst, replicateCopy)
+	c.enqueue(src, replicateDelete)
+	return nil
+}
+
+/**
+ * HealthCheck reports the health of the primary, which serves all writes.
+ *
+ * @param ctx Context bounding the check
+ * @return Error if the primary is unhealthy
+ */
+func (c *CompositeStorage) HealthCheck(ctx context.Context) error {
+	if checker, ok := c.primary.(HealthChecker); ok {
+		return checker.HealthCheck(ctx)
+	}
+	return nil
+}
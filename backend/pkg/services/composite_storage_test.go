@@ -0,0 +1,128 @@
+package services_test
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"nivai/backend/pkg/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompositeStorage(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func(t *testing.T, cfg services.ReplicationConfig) (*services.CompositeStorage, string, services.StorageService) {
+		primaryDir, secondaryDir := t.TempDir(), t.TempDir()
+		primary, err := services.NewLocalFileStorage(primaryDir)
+		require.NoError(t, err)
+		secondary, err := services.NewLocalFileStorage(secondaryDir)
+		require.NoError(t, err)
+		storage, err := services.NewCompositeStorage(primary, secondary, cfg)
+		require.NoError(t, err)
+		return storage, primaryDir, secondary
+	}
+	read := func(t *testing.T, storage services.StorageService, path string) string {
+		t.Helper()
+		file, err := storage.GetFile(path)
+		require.NoError(t, err, path)
+		defer file.Close()
+		content, _ := io.ReadAll(file)
+		return string(content)
+	}
+
+	t.Run("Writes are replicated in the background", func(t *testing.T) {
+		storage, _, secondary := setup(t, services.ReplicationConfig{})
+
+		_, err := storage.UploadStream(strings.NewReader("video bytes"), "videos/v1.mp4")
+		require.NoError(t, err)
+		_, err = storage.UploadStream(strings.NewReader("frames"), "videos/v1_tracking.parquet")
+		require.NoError(t, err)
+		exists, _ := secondary.Exists("videos/v1.mp4")
+		assert.False(t, exists, "replication is asynchronous")
+		assert.Equal(t, 2, storage.Stats().Pending)
+
+		storage.ReplicatePending(ctx)
+		assert.Equal(t, "video bytes", read(t, secondary, "videos/v1.mp4"))
+		stats := storage.Stats()
+		assert.Equal(t, 0, stats.Pending)
+		assert.Zero(t, stats.LagSeconds)
+		assert.Equal(t, int64(2), stats.Replicated)
+		assert.NotNil(t, stats.LastReplicatedAt)
+
+		require.NoError(t, storage.MoveFile("videos/v1_tracking.parquet", "archive/videos/v1_tracking.parquet"))
+		require.NoError(t, storage.DeleteFile("videos/v1.mp4"))
+		storage.ReplicatePending(ctx)
+		exists, _ = secondary.Exists("videos/v1.mp4")
+		assert.False(t, exists)
+		exists, _ = secondary.Exists("videos/v1_tracking.parquet")
+		assert.False(t, exists)
+		assert.Equal(t, "frames", read(t, secondary, "archive/videos/v1_tracking.parquet"))
+	})
+
+	t.Run("Reads fail over to the secondary", func(t *testing.T) {
+		storage, primaryDir, _ := setup(t, services.ReplicationConfig{})
+		_, err := storage.UploadStream(strings.NewReader("video bytes"), "videos/v1.mp4")
+		require.NoError(t, err)
+		storage.ReplicatePending(ctx)
+
+		require.NoError(t, os.Remove(filepath.Join(primaryDir, "videos/v1.mp4")))
+		assert.Equal(t, "video bytes", read(t, storage, "videos/v1.mp4"))
+		_, err = storage.GetFileMetadata("videos/v1.mp4")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), storage.Stats().FailoverReads)
+
+		_, err = storage.GetFile("videos/missing.mp4")
+		assert.EqualError(t, err, "file not found", "the primary's error is returned when both fail")
+	})
+
+	t.Run("Resync queues files missing from the secondary", func(t *testing.T) {
+		primaryDir, secondaryDir := t.TempDir(), t.TempDir()
+		writeStoredFile(t, primaryDir, "videos/a.mp4", 0)
+		writeStoredFile(t, primaryDir, "videos/b.mp4", 0)
+		writeStoredFile(t, secondaryDir, "videos/a.mp4", 0)
+		writeStoredFile(t, secondaryDir, "videos/only-replica.mp4", 0)
+		primary, _ := services.NewLocalFileStorage(primaryDir)
+		secondary, _ := services.NewLocalFileStorage(secondaryDir)
+		storage, err := services.NewCompositeStorage(primary, secondary, services.ReplicationConfig{})
+		require.NoError(t, err)
+
+		queued, err := storage.Resync("videos/")
+		require.NoError(t, err)
+		assert.Equal(t, 1, queued)
+		storage.ReplicatePending(ctx)
+		exists, _ := secondary.Exists("videos/b.mp4")
+		assert.True(t, exists)
+		exists, _ = secondary.Exists("videos/only-replica.mp4")
+		assert.True(t, exists, "files only on the secondary are kept")
+	})
+
+	t.Run("Failed replications are retried, then given up", func(t *testing.T) {
+		storage, primaryDir, _ := setup(t, services.ReplicationConfig{RetryInterval: time.Nanosecond, MaxAttempts: 2})
+		_, err := storage.UploadStream(strings.NewReader("video bytes"), "videos/v1.mp4")
+		require.NoError(t, err)
+		require.NoError(t, os.Remove(filepath.Join(primaryDir, "videos/v1.mp4")))
+
+		storage.ReplicatePending(ctx)
+		stats := storage.Stats()
+		assert.Equal(t, 1, stats.Pending)
+		assert.Contains(t, stats.LastError, "copy videos/v1.mp4")
+
+		time.Sleep(time.Millisecond)
+		storage.ReplicatePending(ctx)
+		stats = storage.Stats()
+		assert.Equal(t, 0, stats.Pending)
+		assert.Equal(t, int64(1), stats.Failed)
+	})
+
+	t.Run("The secondary must accept streamed uploads", func(t *testing.T) {
+		_, err := services.NewCompositeStorage(new(MockStorageService), new(MockStorageService), services.ReplicationConfig{})
+		assert.Error(t, err)
+	})
+}
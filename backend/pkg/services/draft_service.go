@@ -0,0 +1,172 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"nivai/backend/pkg/models"
+)
+
+// ErrNotDraft is returned when attaching files to a match that is no draft.
+var ErrNotDraft = errors.New("match is not a draft")
+
+// draftPageSize is how many drafts an expiry run reads per query
+const draftPageSize = 100
+
+/**
+ * DraftService manages matches created from their metadata first, whose
+ * files are attached in later requests. A draft is submitted for
+ * processing once its tracking and event files are attached; drafts left
+ * unchanged for the TTL are deleted like matches deleted by a user.
+ */
+type DraftService struct {
+	videoRepo    models.VideoRepository
+	videoService VideoService
+	storage      StorageService
+	purges       *VideoPurgeService
+	ttl          time.Duration
+	now          func() time.Time
+}
+
+/**
+ * NewDraftService creates a new draft service.
+ *
+ * @param videoRepo Repository for video data, to save attached files
+ * @param videoService Video service the drafts are created with
+ * @param storage Storage service holding the files, to remove replaced ones
+ * @param purges Deletes expired drafts and, after the grace period, their files
+ * @param ttl How long a draft is kept after its last change (default 72h)
+ * @return A new draft service
+ */
+func NewDraftService(videoRepo models.VideoRepository, videoService VideoService, storage StorageService, purges *VideoPurgeService, ttl time.Duration) *DraftService {
+	if ttl <= 0 {
+		ttl = 72 * time.Hour
+	}
+	return &DraftService{
+		videoRepo:    videoRepo,
+		videoService: videoService,
+		storage:      storage,
+		purges:       purges,
+		ttl:          ttl,
+		now:          time.Now,
+	}
+}
+
+/**
+ * Create saves a draft match from its metadata.
+ *
+ * @param video The match metadata, with its ID set
+ * @param change Who created the draft, for the state history
+ * @return The saved draft, or an error
+ */
+func (s *DraftService) Create(video *models.Video, change StateChange) (*models.Video, error) {
+	video.ProcessingState = models.StateDraft
+	video.CreatedAt = s.now()
+	return s.videoService.CreateVideoEntry(video, change)
+}
+
+/**
+ * ExpiresAt returns when a draft is deleted unless it changes before.
+ *
+ * @param video The draft
+ * @return The expiry time
+ */
+func (s *DraftService) ExpiresAt(video *models.Video) time.Time {
+	return video.UpdatedAt.Add(s.ttl)
+}
+
+/**
+ * Attach sets a stored file as a draft's file of its kind and records it.
+ * A file it replaces is removed from storage unless stored at the same
+ * path.
+ *
+ * @param id The draft's ID
+ * @param file The stored file
+ * @return The updated draft, ErrVideoNotFound, or ErrNotDraft
+ */
+func (s *DraftService) Attach(id string, file *models.VideoFile) (*models.Video, error) {
+	video, err := s.videoRepo.FindByID(id)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, ErrVideoNotFound
+		}
+		return nil, err
+	}
+	if video.ProcessingState != models.StateDraft {
+		return nil, ErrNotDraft
+	}
+
+	var replaced string
+	switch file.Kind {
+	case models.FileKindTracking:
+		replaced, video.TrackingPath = video.TrackingPath, file.Path
+	case models.FileKindEvents:
+		replaced, video.EventFilePath = video.EventFilePath, file.Path
+	case models.FileKindVideo:
+		replaced, video.FilePath = video.FilePath, file.Path
+		video.Size, video.StorageProvider = file.Size, "default"
+		video.Format = strings.TrimPrefix(filepath.Ext(file.Path), ".")
+	default:
+		return nil, fmt.Errorf("unknown file kind %q", file.Kind)
+	}
+	video.UpdatedAt = s.now()
+	if err := s.videoRepo.Update(video); err != nil {
+		return nil, err
+	}
+
+	if replaced != "" && replaced != file.Path {
+		if err := s.storage.DeleteFile(replaced); err != nil {
+			log.Printf("Warning: Failed to remove replaced %s file %s of draft %s: %v", file.Kind, replaced, id, err)
+		}
+	}
+	if err := s.videoService.RecordVideoFiles(id, []*models.VideoFile{file}); err != nil {
+		log.Printf("Warning: Failed to record file checksum for draft %s: %v", id, err)
+	}
+	return video, nil
+}
+
+/**
+ * RunScheduled deletes the drafts unchanged for longer than the TTL. Their
+ * files are purged after the deletion grace period, as for matches deleted
+ * by a user.
+ *
+ * @param ctx Context for the run
+ * @return An error if the drafts cannot be read or one could not be deleted
+ */
+func (s *DraftService) RunScheduled(ctx context.Context) error {
+	cutoff := s.now().Add(-s.ttl)
+	var expired []*models.Video
+	for offset := 0; ; offset += draftPageSize {
+		drafts, err := s.videoRepo.WithContext(ctx).FindByProcessingState(string(models.StateDraft), draftPageSize, offset)
+		if err != nil {
+			return err
+		}
+		for _, draft := range drafts {
+			if draft.UpdatedAt.Before(cutoff) {
+				expired = append(expired, draft)
+			}
+		}
+		if len(drafts) < draftPageSize {
+			break
+		}
+	}
+
+	failed := 0
+	for _, draft := range expired {
+		if _, err := s.purges.Delete(draft, ActorDraftExpiry); err != nil {
+			log.Printf("Draft expiry: failed to delete draft %s: %v", draft.ID, err)
+			failed++
+			continue
+		}
+		log.Printf("Draft expiry: deleted draft %s, unchanged since %s", draft.ID, draft.UpdatedAt.Format(time.RFC3339))
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d expired drafts could not be deleted", failed, len(expired))
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package services_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDraftService(t *testing.T) {
+	setup := func(t *testing.T) (*models.Repositories, *services.MemoryStorageService, *services.DraftService) {
+		repos := models.NewMemoryRepositories()
+		storage := services.NewMemoryStorageService()
+		videoService := services.NewVideoService(repos.Videos, storage)
+		purges := services.NewVideoPurgeService(repos.Videos, videoService, repos.VideoPurges, storage, nil, 24*time.Hour)
+		return repos, storage, services.NewDraftService(repos.Videos, videoService, storage, purges, 72*time.Hour)
+	}
+	store := func(t *testing.T, storage *services.MemoryStorageService, path string) *models.VideoFile {
+		_, err := storage.UploadStream(strings.NewReader("content of "+path), path)
+		require.NoError(t, err)
+		return &models.VideoFile{Path: path, Size: int64(len("content of " + path))}
+	}
+
+	t.Run("Attached files replace the draft's file of their kind", func(t *testing.T) {
+		repos, storage, drafts := setup(t)
+		draft, err := drafts.Create(&models.Video{ID: "d1", Title: "Ajax - PSV"}, services.StateChange{Actor: "alice"})
+		require.NoError(t, err)
+		assert.Equal(t, models.StateDraft, draft.ProcessingState)
+		assert.Equal(t, 72*time.Hour, drafts.ExpiresAt(draft).Sub(draft.UpdatedAt))
+
+		first := store(t, storage, "videos/d1/d1_tracking.csv")
+		first.Kind = models.FileKindTracking
+		_, err = drafts.Attach("d1", first)
+		require.NoError(t, err)
+		second := store(t, storage, "videos/d1/d1_tracking.parquet")
+		second.Kind = models.FileKindTracking
+		video := store(t, storage, "videos/d1/match.mp4")
+		video.Kind = models.FileKindVideo
+		_, err = drafts.Attach("d1", second)
+		require.NoError(t, err)
+		draft, err = drafts.Attach("d1", video)
+		require.NoError(t, err)
+
+		assert.Equal(t, "videos/d1/d1_tracking.parquet", draft.TrackingPath)
+		assert.Equal(t, "videos/d1/match.mp4", draft.FilePath)
+		assert.Equal(t, "mp4", draft.Format)
+		assert.Equal(t, video.Size, draft.Size)
+		exists, err := storage.Exists("videos/d1/d1_tracking.csv")
+		require.NoError(t, err)
+		assert.False(t, exists, "the replaced file is removed")
+		exists, err = storage.Exists("videos/d1/d1_tracking.parquet")
+		require.NoError(t, err)
+		assert.True(t, exists)
+		stored, err := repos.Videos.FindByID("d1")
+		require.NoError(t, err)
+		assert.Equal(t, models.StateDraft, stored.ProcessingState)
+	})
+
+	t.Run("Only drafts get files attached", func(t *testing.T) {
+		repos, storage, drafts := setup(t)
+		require.NoError(t, repos.Videos.Create(&models.Video{ID: "m1", Title: "AZ - Feyenoord", ProcessingState: models.StateCompleted}))
+		file := store(t, storage, "videos/m1/m1_events.csv")
+		file.Kind = models.FileKindEvents
+
+		_, err := drafts.Attach("m1", file)
+		assert.ErrorIs(t, err, services.ErrNotDraft)
+		_, err = drafts.Attach("missing", file)
+		assert.ErrorIs(t, err, services.ErrVideoNotFound)
+	})
+
+	t.Run("Drafts unchanged for the TTL are deleted", func(t *testing.T) {
+		repos, _, drafts := setup(t)
+		old := time.Now().Add(-73 * time.Hour)
+		require.NoError(t, repos.Videos.Create(&models.Video{ID: "old", Title: "a", ProcessingState: models.StateDraft, TrackingPath: "videos/old/t.csv", UpdatedAt: old}))
+		require.NoError(t, repos.Videos.Create(&models.Video{ID: "recent", Title: "b", ProcessingState: models.StateDraft, UpdatedAt: time.Now().Add(-time.Hour)}))
+		require.NoError(t, repos.Videos.Create(&models.Video{ID: "submitted", Title: "c", ProcessingState: models.StatePendingAnalytics, UpdatedAt: old}))
+
+		require.NoError(t, drafts.RunScheduled(context.Background()))
+
+		_, err := repos.Videos.FindByID("old")
+		assert.Error(t, err, "the expired draft is deleted")
+		purge, err := repos.VideoPurges.FindByVideoID("old")
+		require.NoError(t, err)
+		assert.Equal(t, services.ActorDraftExpiry, purge.DeletedBy)
+		assert.Equal(t, []string{"videos/old/t.csv"}, purge.Paths)
+		for _, id := range []string{"recent", "submitted"} {
+			_, err := repos.Videos.FindByID(id)
+			assert.NoError(t, err, id)
+		}
+	})
+}
@@ -0,0 +1,573 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"strconv"
+	"strings"
+)
+
+// Encrypted file format: a header holding the wrapped data key, followed by
+// the content in AES-GCM sealed chunks. Each chunk's nonce holds its index
+// and whether it is the last one, so reordered, dropped or truncated chunks
+// fail to decrypt.
+const (
+	encryptionMagic      = "NVENC1"
+	encryptionChunkSize  = 64 * 1024
+	encryptionNonceBase  = 7 // Random bytes of each chunk nonce
+	encryptionDataKeyLen = 32
+)
+
+var (
+	// ErrDecryptionFailed is returned when a stored file cannot be
+	// decrypted, because it was modified, truncated or its key is unknown.
+	ErrDecryptionFailed = errors.New("failed to decrypt stored file")
+
+	// ErrEncryptedStreamURL is returned for stream URLs of encrypted
+	// storage, as they would serve the encrypted content.
+	ErrEncryptedStreamURL = errors.New("stream URLs are not available for encrypted storage")
+)
+
+/**
+ * KeyProvider wraps the data keys of encrypted files with a key encryption
+ * key, e.g. from the configuration or a key management service. Rotating
+ * the key encryption key only changes how new files are wrapped; files keep
+ * the ID of the key that wrapped them.
+ */
+type KeyProvider interface {
+	// WrapKey encrypts a data key with the current key encryption key
+	WrapKey(dataKey []byte) (keyID string, wrapped []byte, err error)
+
+	// UnwrapKey decrypts a data key wrapped with the key keyID
+	UnwrapKey(keyID string, wrapped []byte) ([]byte, error)
+}
+
+/**
+ * Keyring is a KeyProvider holding AES-256 key encryption keys by ID, one of
+ * which wraps new data keys.
+ */
+type Keyring struct {
+	keys     map[string]cipher.AEAD
+	activeID string
+}
+
+/**
+ * NewKeyring creates a keyring from 32-byte keys.
+ *
+ * @param keys Key encryption keys by ID
+ * @param activeID ID of the key wrapping new data keys
+ * @return A new keyring or error
+ */
+func NewKeyring(keys map[string][]byte, activeID string) (*Keyring, error) {
+	if _, ok := keys[activeID]; !ok {
+		return nil, fmt.Errorf("active encryption key %q is not in the keyring", activeID)
+	}
+	keyring := &Keyring{keys: map[string]cipher.AEAD{}, activeID: activeID}
+	for id, key := range keys {
+		if id == "" || len(id) > 255 {
+			return nil, fmt.Errorf("invalid encryption key ID %q", id)
+		}
+		if len(key) != 32 {
+			return nil, fmt.Errorf("encryption key %q must be 32 bytes, got %d", id, len(key))
+		}
+		aead, err := newGCM(key)
+		if err != nil {
+			return nil, err
+		}
+		keyring.keys[id] = aead
+	}
+	return keyring, nil
+}
+
+/**
+ * ParseKeyring creates a keyring from a comma-separated list of
+ * "<id>:<base64 key>" entries.
+ *
+ * @param spec The keys
+ * @param activeID ID of the key wrapping new data keys; the first key if empty
+ * @return A new keyring or error
+ */
+func ParseKeyring(spec, activeID string) (*Keyring, error) {
+	keys := map[string][]byte{}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, encoded, ok := strings.Cut(entry, ":")
+		if !ok {
+			return nil, errors.New("encryption keys must be given as <id>:<base64 key>")
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("encryption key %q is not valid base64: %w", id, err)
+		}
+		keys[id] = key
+		if activeID == "" {
+			activeID = id
+		}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no encryption keys configured")
+	}
+	return NewKeyring(keys, activeID)
+}
+
+/**
+ * WrapKey encrypts a data key with the active key.
+ *
+ * @param dataKey The key to wrap
+ * @return The active key ID and the wrapped key, or error
+ */
+func (k *Keyring) WrapKey(dataKey []byte) (string, []byte, error) {
+	aead := k.keys[k.activeID]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", nil, err
+	}
+	return k.activeID, aead.Seal(nonce, nonce, dataKey, []byte(k.activeID)), nil
+}
+
+/**
+ * UnwrapKey decrypts a data key wrapped with one of the keyring's keys.
+ *
+ * @param keyID ID of the key that wrapped the data key
+ * @param wrapped The wrapped data key
+ * @return The data key or error
+ */
+func (k *Keyring) UnwrapKey(keyID string, wrapped []byte) ([]byte, error) {
+	aead, ok := k.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("unknown encryption key %q", keyID)
+	}
+	if len(wrapped) < aead.NonceSize() {
+		return nil, errors.New("wrapped key too short")
+	}
+	return aead.Open(nil, wrapped[:aead.NonceSize()], wrapped[aead.NonceSize():], []byte(keyID))
+}
+
+/**
+ * EncryptedStorage implements StorageService over another backend,
+ * encrypting files with AES-GCM before they are stored and decrypting them
+ * when they are read. Each file has its own data key, wrapped by the key
+ * provider and stored in the file's header.
+ *
+ * Copies, moves, listings and deletions work on the stored bytes, so the
+ * sizes listed include the encryption overhead. Files stored before
+ * encryption was enabled are read as is.
+ */
+type EncryptedStorage struct {
+	inner StorageService
+	keys  KeyProvider
+}
+
+/**
+ * NewEncryptedStorage creates a storage service encrypting the files of
+ * another. The backend must support streamed uploads.
+ *
+ * @param inner The storage backend holding the encrypted files
+ * @param keys The provider wrapping data keys
+ * @return A new encrypted storage or error
+ */
+func NewEncryptedStorage(inner StorageService, keys KeyProvider) (*EncryptedStorage, error) {
+	if _, ok := inner.(StreamUploader); !ok {
+		return nil, errors.New("encrypted storage needs a backend supporting streamed uploads")
+	}
+	return &EncryptedStorage{inner: inner, keys: keys}, nil
+}
+
+/**
+ * Unwrap returns the backend holding the encrypted files, for operations
+ * that must work on the stored bytes, like replication and archiving.
+ *
+ * @return The wrapped storage service
+ */
+func (e *EncryptedStorage) Unwrap() StorageService {
+	return e.inner
+}
+
+/**
+ * UploadFile encrypts and stores a file.
+ *
+ * @param file The file to upload
+ * @param path The destination path in the storage
+ * @return Upload information with the unencrypted size, or error
+ */
+func (e *EncryptedStorage) UploadFile(file multipart.File, path string) (*FileUploadInfo, error) {
+	return e.UploadStream(file, path)
+}
+
+/**
+ * UploadStream encrypts and stores a stream.
+ *
+ * @param r The content to store
+ * @param path The destination path in the storage
+ * @return Upload information with the unencrypted size, or error
+ */
+func (e *EncryptedStorage) UploadStream(r io.Reader, path string) (*FileUploadInfo, error) {
+	dataKey := make([]byte, encryptionDataKeyLen)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, err
+	}
+	keyID, wrapped, err := e.keys.WrapKey(dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wrap data key: %w", err)
+	}
+	aead, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	nonceBase := make([]byte, encryptionNonceBase)
+	if _, err := rand.Read(nonceBase); err != nil {
+		return nil, err
+	}
+	header := encodeEncryptionHeader(keyID, wrapped, nonceBase)
+	encrypter := &encryptReader{
+		src:       bufio.NewReaderSize(r, encryptionChunkSize),
+		aead:      aead,
+		header:    header,
+		nonceBase: nonceBase,
+		out:       header,
+		chunk:     make([]byte, encryptionChunkSize),
+		sealed:    make([]byte, 0, encryptionChunkSize+aead.Overhead()),
+	}
+
+	info, err := e.inner.(StreamUploader).UploadStream(encrypter, path)
+	if err != nil {
+		return nil, err
+	}
+	info.Size = encrypter.size
+	return info, nil
+}
+
+/**
+ * GetFile retrieves and decrypts a file.
+ *
+ * @param path The path of the file in storage
+ * @return A reader for the decrypted content or error
+ */
+func (e *EncryptedStorage) GetFile(path string) (io.ReadCloser, error) {
+	file, err := e.inner.GetFile(path)
+	if err != nil {
+		return nil, err
+	}
+	src := bufio.NewReaderSize(file, encryptionChunkSize+16)
+	header, err := readEncryptionHeader(src)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if header == nil {
+		return struct {
+			io.Reader
+			io.Closer
+		}{src, file}, nil
+	}
+
+	dataKey, err := e.keys.UnwrapKey(header.keyID, header.wrapped)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("%w: %v", ErrDecryptionFailed, err)
+	}
+	aead, err := newGCM(dataKey)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &decryptReader{
+		src:       src,
+		closer:    file,
+		aead:      aead,
+		header:    header.raw,
+		nonceBase: header.nonceBase,
+		chunk:     make([]byte, encryptionChunkSize+aead.Overhead()),
+	}, nil
+}
+
+/**
+ * DeleteFile removes a file.
+ *
+ * @param path The path of the file to delete
+ * @return Error if deletion fails
+ */
+func (e *EncryptedStorage) DeleteFile(path string) error {
+	return e.inner.DeleteFile(path)
+}
+
+/**
+ * GetStreamURL is not supported, as a URL to the backend would serve the
+ * encrypted content; files are served through the API instead.
+ *
+ * @param path The path of the file in storage
+ * @return ErrEncryptedStreamURL
+ */
+func (e *EncryptedStorage) GetStreamURL(path string) (string, error) {
+	return "", ErrEncryptedStreamURL
+}
+
+/**
+ * GetFileMetadata retrieves metadata about a file, with the unencrypted
+ * content length and the ID of the key that wrapped its data key. The
+ * header is read to tell the two apart.
+ *
+ * @param path The path of the file in storage
+ * @return A map of metadata or error
+ */
+func (e *EncryptedStorage) GetFileMetadata(path string) (map[string]string, error) {
+	metadata, err := e.inner.GetFileMetadata(path)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := e.inner.GetFile(path)
+	if err != nil {
+		return nil, err
+	}
+	header, err := readEncryptionHeader(bufio.NewReader(file))
+	file.Close()
+	if err != nil || header == nil {
+		return metadata, err
+	}
+
+	stored, err := strconv.ParseInt(metadata["content-length"], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid content length of %s: %w", path, err)
+	}
+	const sealedChunk = encryptionChunkSize + 16
+	body := stored - int64(len(header.raw))
+	chunks := (body + sealedChunk - 1) / sealedChunk
+	metadata["content-length"] = strconv.FormatInt(body-chunks*16, 10)
+	metadata["encrypted"] = "true"
+	metadata["encryption-key-id"] = header.keyID
+	return metadata, nil
+}
+
+/**
+ * ListFiles lists the stored files under prefix, with their encrypted sizes.
+ *
+ * @param prefix The path prefix to list
+ * @return The stored files or error
+ */
+func (e *EncryptedStorage) ListFiles(prefix string) ([]FileInfo, error) {
+	return e.inner.ListFiles(prefix)
+}
+
+/**
+ * Exists reports whether a file is stored at path.
+ *
+ * @param path The path of the file
+ * @return Whether the file exists, or error
+ */
+func (e *EncryptedStorage) Exists(path string) (bool, error) {
+	return e.inner.Exists(path)
+}
+
+/**
+ * CopyFile copies a file without decrypting it; the copy keeps the data key.
+ *
+ * @param src The path of the file to copy
+ * @param dst The destination path
+ * @return Error if the copy fails
+ */
+func (e *EncryptedStorage) CopyFile(src, dst string) error {
+	return e.inner.CopyFile(src, dst)
+}
+
+/**
+ * MoveFile moves a file without decrypting it.
+ *
+ * @param src The path of the file to move
+ * @param dst The destination path
+ * @return Error if the move fails
+ */
+func (e *EncryptedStorage) MoveFile(src, dst string) error {
+	return e.inner.MoveFile(src, dst)
+}
+
+// encryptionHeader is the parsed header of an encrypted file.
+type encryptionHeader struct {
+	raw       []byte // Authenticated with every chunk
+	keyID     string
+	wrapped   []byte
+	nonceBase []byte
+}
+
+// encodeEncryptionHeader lays out the magic, the key ID, the wrapped data
+// key and the nonce base.
+func encodeEncryptionHeader(keyID string, wrapped, nonceBase []byte) []byte {
+	header := []byte(encryptionMagic)
+	header = append(header, byte(len(keyID)))
+	header = append(header, keyID...)
+	header = binary.BigEndian.AppendUint16(header, uint16(len(wrapped)))
+	header = append(header, wrapped...)
+	return append(header, nonceBase...)
+}
+
+// readEncryptionHeader reads the header of an encrypted file, or returns nil
+// without consuming anything when the content does not start with one.
+func readEncryptionHeader(src *bufio.Reader) (*encryptionHeader, error) {
+	magic, _ := src.Peek(len(encryptionMagic))
+	if string(magic) != encryptionMagic {
+		return nil, nil
+	}
+
+	raw := &bytes.Buffer{}
+	read := func(n int) ([]byte, error) {
+		b := make([]byte, n)
+		if _, err := io.ReadFull(src, b); err != nil {
+			return nil, fmt.Errorf("%w: truncated header", ErrDecryptionFailed)
+		}
+		raw.Write(b)
+		return b, nil
+	}
+
+	if _, err := read(len(encryptionMagic)); err != nil {
+		return nil, err
+	}
+	keyIDLen, err := read(1)
+	if err != nil {
+		return nil, err
+	}
+	keyID, err := read(int(keyIDLen[0]))
+	if err != nil {
+		return nil, err
+	}
+	wrappedLen, err := read(2)
+	if err != nil {
+		return nil, err
+	}
+	wrapped, err := read(int(binary.BigEndian.Uint16(wrappedLen)))
+	if err != nil {
+		return nil, err
+	}
+	nonceBase, err := read(encryptionNonceBase)
+	if err != nil {
+		return nil, err
+	}
+	return &encryptionHeader{raw: raw.Bytes(), keyID: string(keyID), wrapped: wrapped, nonceBase: nonceBase}, nil
+}
+
+// chunkNonce derives the nonce of a chunk from the nonce base, the chunk
+// index and whether it is the last chunk.
+func chunkNonce(nonceBase []byte, index uint32, last bool) []byte {
+	nonce := make([]byte, 0, 12)
+	nonce = append(nonce, nonceBase...)
+	nonce = binary.BigEndian.AppendUint32(nonce, index)
+	if last {
+		return append(nonce, 1)
+	}
+	return append(nonce, 0)
+}
+
+// encryptReader produces the header and sealed chunks of its source.
+type encryptReader struct {
+	src       *bufio.Reader
+	aead      cipher.AEAD
+	header    []byte
+	nonceBase []byte
+	out       []byte // Sealed bytes not read yet
+	chunk     []byte
+	sealed    []byte
+	index     uint32
+	done      bool
+	size      int64 // Unencrypted bytes read from src
+}
+
+func (e *encryptReader) Read(p []byte) (int, error) {
+	for len(e.out) == 0 {
+		if e.done {
+			return 0, io.EOF
+		}
+		n, err := io.ReadFull(e.src, e.chunk)
+		switch {
+		case err == io.EOF || err == io.ErrUnexpectedEOF:
+			e.done = true
+		case err != nil:
+			return 0, err
+		default:
+			// A full chunk is the last one when nothing follows it
+			if _, peekErr := e.src.Peek(1); peekErr == io.EOF {
+				e.done = true
+			} else if peekErr != nil {
+				return 0, peekErr
+			}
+		}
+		if e.index == ^uint32(0) {
+			return 0, errors.New("file too large to encrypt")
+		}
+		e.size += int64(n)
+		e.out = e.aead.Seal(e.sealed[:0], chunkNonce(e.nonceBase, e.index, e.done), e.chunk[:n], e.header)
+		e.index++
+	}
+	n := copy(p, e.out)
+	e.out = e.out[n:]
+	return n, nil
+}
+
+// decryptReader opens the sealed chunks following an encrypted file's header.
+type decryptReader struct {
+	src       *bufio.Reader
+	closer    io.Closer
+	aead      cipher.AEAD
+	header    []byte
+	nonceBase []byte
+	out       []byte // Opened bytes not read yet
+	chunk     []byte
+	index     uint32
+	done      bool
+}
+
+func (d *decryptReader) Read(p []byte) (int, error) {
+	for len(d.out) == 0 {
+		if d.done {
+			return 0, io.EOF
+		}
+		n, err := io.ReadFull(d.src, d.chunk)
+		last := false
+		switch {
+		case err == io.EOF || err == io.ErrUnexpectedEOF:
+			last = true
+		case err != nil:
+			return 0, err
+		default:
+			if _, peekErr := d.src.Peek(1); peekErr == io.EOF {
+				last = true
+			} else if peekErr != nil {
+				return 0, peekErr
+			}
+		}
+		opened, err := d.aead.Open(d.chunk[:0], chunkNonce(d.nonceBase, d.index, last), d.chunk[:n], d.header)
+		if err != nil {
+			return 0, fmt.Errorf("%w: chunk %d", ErrDecryptionFailed, d.index)
+		}
+		d.out = opened
+		d.done = last
+		d.index++
+	}
+	n := copy(p, d.out)
+	d.out = d.out[n:]
+	return n, nil
+}
+
+func (d *decryptReader) Close() error {
+	return d.closer.Close()
+}
+
+// newGCM creates an AES-GCM cipher from a key.
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
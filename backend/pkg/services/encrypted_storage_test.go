@@ -0,0 +1,164 @@
+package services_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"nivai/backend/pkg/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newEncryptedLocalStorage creates encrypted storage over a local directory.
+func newEncryptedLocalStorage(t *testing.T, base string, keys map[string][]byte, activeID string) *services.EncryptedStorage {
+	t.Helper()
+	local, err := services.NewLocalFileStorage(base)
+	require.NoError(t, err)
+	keyring, err := services.NewKeyring(keys, activeID)
+	require.NoError(t, err)
+	storage, err := services.NewEncryptedStorage(local, keyring)
+	require.NoError(t, err)
+	return storage
+}
+
+func randomBytes(t *testing.T, n int) []byte {
+	t.Helper()
+	b := make([]byte, n)
+	_, err := rand.Read(b)
+	require.NoError(t, err)
+	return b
+}
+
+func base64Key(fill byte) string {
+	return base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{fill}, 32))
+}
+
+func readStored(t *testing.T, storage services.StorageService, path string) ([]byte, error) {
+	t.Helper()
+	file, err := storage.GetFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return io.ReadAll(file)
+}
+
+func TestEncryptedStorage(t *testing.T) {
+	keys := map[string][]byte{"2024": bytes.Repeat([]byte{1}, 32), "2025": bytes.Repeat([]byte{2}, 32)}
+
+	t.Run("Files are encrypted at rest and decrypted on read", func(t *testing.T) {
+		base := t.TempDir()
+		storage := newEncryptedLocalStorage(t, base, keys, "2024")
+
+		for _, size := range []int{0, 1, 64 * 1024, 64*1024 + 1, 3*64*1024 + 17} {
+			content := randomBytes(t, size)
+			path := "videos/m1/tracking-" + strconv.Itoa(size)
+			info, err := storage.UploadStream(bytes.NewReader(content), path)
+			require.NoError(t, err)
+			assert.Equal(t, int64(size), info.Size)
+
+			stored, err := os.ReadFile(filepath.Join(base, path))
+			require.NoError(t, err)
+			assert.Greater(t, len(stored), size)
+			if size >= 16 {
+				assert.False(t, bytes.Contains(stored, content), "stored content is encrypted")
+			}
+
+			read, err := readStored(t, storage, path)
+			require.NoError(t, err)
+			assert.True(t, bytes.Equal(content, read), "round trip of %d bytes", size)
+
+			metadata, err := storage.GetFileMetadata(path)
+			require.NoError(t, err)
+			assert.Equal(t, strconv.Itoa(size), metadata["content-length"])
+			assert.Equal(t, "2024", metadata["encryption-key-id"])
+		}
+	})
+
+	t.Run("Modified or truncated files fail to decrypt", func(t *testing.T) {
+		base := t.TempDir()
+		storage := newEncryptedLocalStorage(t, base, keys, "2024")
+		_, err := storage.UploadStream(bytes.NewReader(randomBytes(t, 2*64*1024+5)), "videos/m1/events")
+		require.NoError(t, err)
+		fullPath := filepath.Join(base, "videos/m1/events")
+		stored, err := os.ReadFile(fullPath)
+		require.NoError(t, err)
+
+		tampered := append([]byte{}, stored...)
+		tampered[len(tampered)/2] ^= 1
+		require.NoError(t, os.WriteFile(fullPath, tampered, 0644))
+		_, err = readStored(t, storage, "videos/m1/events")
+		assert.ErrorIs(t, err, services.ErrDecryptionFailed)
+
+		// Dropping the last chunk leaves a stream of whole chunks, none marked last
+		require.NoError(t, os.WriteFile(fullPath, stored[:len(stored)-21], 0644))
+		_, err = readStored(t, storage, "videos/m1/events")
+		assert.ErrorIs(t, err, services.ErrDecryptionFailed)
+	})
+
+	t.Run("Rotated keys still decrypt older files", func(t *testing.T) {
+		base := t.TempDir()
+		old := newEncryptedLocalStorage(t, base, keys, "2024")
+		_, err := old.UploadStream(bytes.NewReader([]byte("before rotation")), "videos/m1/a")
+		require.NoError(t, err)
+
+		rotated := newEncryptedLocalStorage(t, base, keys, "2025")
+		_, err = rotated.UploadStream(bytes.NewReader([]byte("after rotation")), "videos/m1/b")
+		require.NoError(t, err)
+		require.NoError(t, rotated.CopyFile("videos/m1/a", "archive/m1/a"))
+
+		for path, want := range map[string]string{"videos/m1/a": "before rotation", "videos/m1/b": "after rotation", "archive/m1/a": "before rotation"} {
+			read, err := readStored(t, rotated, path)
+			require.NoError(t, err)
+			assert.Equal(t, want, string(read))
+		}
+
+		retired := newEncryptedLocalStorage(t, base, map[string][]byte{"2025": keys["2025"]}, "2025")
+		_, err = readStored(t, retired, "videos/m1/a")
+		assert.ErrorIs(t, err, services.ErrDecryptionFailed)
+	})
+
+	t.Run("Files stored before encryption are read as is", func(t *testing.T) {
+		base := t.TempDir()
+		writeStoredFile(t, base, "videos/m1/legacy.csv", 0)
+		storage := newEncryptedLocalStorage(t, base, keys, "2024")
+
+		read, err := readStored(t, storage, "videos/m1/legacy.csv")
+		require.NoError(t, err)
+		assert.Equal(t, "content", string(read))
+		metadata, err := storage.GetFileMetadata("videos/m1/legacy.csv")
+		require.NoError(t, err)
+		assert.Equal(t, "7", metadata["content-length"])
+		assert.Empty(t, metadata["encrypted"])
+
+		_, err = storage.GetStreamURL("videos/m1/legacy.csv")
+		assert.ErrorIs(t, err, services.ErrEncryptedStreamURL)
+	})
+
+	t.Run("Keyrings are parsed from the configuration", func(t *testing.T) {
+		_, err := services.ParseKeyring("2024:AAAA", "")
+		assert.ErrorContains(t, err, "32 bytes")
+		_, err = services.ParseKeyring("2024", "")
+		assert.Error(t, err)
+		_, err = services.ParseKeyring("", "")
+		assert.Error(t, err)
+		_, err = services.ParseKeyring("2024:"+base64Key(1), "2025")
+		assert.ErrorContains(t, err, "2025")
+
+		keyring, err := services.ParseKeyring("2024:"+base64Key(1)+", 2025:"+base64Key(2), "2025")
+		require.NoError(t, err)
+		id, wrapped, err := keyring.WrapKey([]byte("data key"))
+		require.NoError(t, err)
+		assert.Equal(t, "2025", id)
+		unwrapped, err := keyring.UnwrapKey(id, wrapped)
+		require.NoError(t, err)
+		assert.Equal(t, "data key", string(unwrapped))
+	})
+}
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// fieldCipherPrefix marks encrypted field values. Values without it are
+// plain text, stored before encryption was enabled.
+const fieldCipherPrefix = "enc:v1:"
+
+// ErrFieldDecryption is returned when an encrypted field cannot be
+// decrypted, because it was modified or its key is unknown.
+var ErrFieldDecryption = errors.New("failed to decrypt field")
+
+/**
+ * FieldCipher encrypts single database fields holding personal data, such
+ * as player names, with AES-GCM under the keys of a key provider, so a
+ * leaked database does not reveal them. Encrypted values read
+ * "enc:v1:<key ID>:<base64>" and name the key that sealed them, so keys can
+ * be rotated while old values stay readable.
+ *
+ * Encrypted values differ each time, so they cannot be searched. With an
+ * index key the cipher also derives a blind index of values: the same for
+ * equal values, and meaningless without the key.
+ *
+ * A nil FieldCipher leaves values in plain text.
+ */
+type FieldCipher struct {
+	keys     KeyProvider
+	indexKey []byte
+}
+
+// FieldCipherOption configures a FieldCipher
+type FieldCipherOption func(*FieldCipher)
+
+/**
+ * WithIndexKey enables blind indexes of values, keyed HMAC-SHA256 hashes.
+ * The key must differ from the encryption keys and must not be rotated
+ * while indexed values remain.
+ *
+ * @param key The secret index key
+ * @return The option
+ */
+func WithIndexKey(key []byte) FieldCipherOption {
+	return func(c *FieldCipher) {
+		c.indexKey = key
+	}
+}
+
+/**
+ * NewFieldCipher creates a field cipher.
+ *
+ * @param keys Provider of the keys sealing the values, e.g. a Keyring
+ * @param opts Options, e.g. WithIndexKey
+ * @return A new field cipher
+ */
+func NewFieldCipher(keys KeyProvider, opts ...FieldCipherOption) *FieldCipher {
+	c := &FieldCipher{keys: keys}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+/**
+ * Index returns the blind index of a value, to find encrypted values equal
+ * to it. Empty values, nil ciphers and ciphers without an index key have
+ * none.
+ *
+ * @param plaintext The value
+ * @return The blind index, or ""
+ */
+func (c *FieldCipher) Index(plaintext string) string {
+	if c == nil || len(c.indexKey) == 0 || plaintext == "" {
+		return ""
+	}
+	h := hmac.New(sha256.New, c.indexKey)
+	h.Write([]byte(plaintext))
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
+
+/**
+ * Encrypt seals a value with the active key. Empty values stay empty.
+ *
+ * @param plaintext The value
+ * @return The encrypted value, or the value itself for a nil cipher, or error
+ */
+func (c *FieldCipher) Encrypt(plaintext string) (string, error) {
+	if c == nil || plaintext == "" {
+		return plaintext, nil
+	}
+	keyID, sealed, err := c.keys.WrapKey([]byte(plaintext))
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt field: %w", err)
+	}
+	return fieldCipherPrefix + keyID + ":" + base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+/**
+ * Decrypt opens a value sealed by Encrypt. Plain text values are returned
+ * as they are.
+ *
+ * @param value The stored value
+ * @return The plain text, or ErrFieldDecryption
+ */
+func (c *FieldCipher) Decrypt(value string) (string, error) {
+	if !strings.HasPrefix(value, fieldCipherPrefix) {
+		return value, nil
+	}
+	if c == nil {
+		return "", fmt.Errorf("%w: no keys configured", ErrFieldDecryption)
+	}
+	rest := strings.TrimPrefix(value, fieldCipherPrefix)
+	sep := strings.LastIndex(rest, ":") // Key IDs may hold colons; base64 does not
+	if sep < 0 {
+		return "", fmt.Errorf("%w: malformed value", ErrFieldDecryption)
+	}
+	sealed, err := base64.RawURLEncoding.DecodeString(rest[sep+1:])
+	if err != nil {
+		return "", fmt.Errorf("%w: malformed value", ErrFieldDecryption)
+	}
+	plaintext, err := c.keys.UnwrapKey(rest[:sep], sealed)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrFieldDecryption, err)
+	}
+	return string(plaintext), nil
+}
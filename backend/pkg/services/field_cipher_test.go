@@ -0,0 +1,78 @@
+package services_test
+
+import (
+	"strings"
+	"testing"
+
+	"nivai/backend/pkg/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFieldCipher(t *testing.T) {
+	keyring, err := services.ParseKeyring("2024:"+base64Key(1), "")
+	require.NoError(t, err)
+	cipher := services.NewFieldCipher(keyring)
+
+	t.Run("Values are sealed and opened with the key that sealed them", func(t *testing.T) {
+		sealed, err := cipher.Encrypt("Steven Berghuis")
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(sealed, "enc:v1:2024:"))
+		assert.NotContains(t, sealed, "Berghuis")
+		other, err := cipher.Encrypt("Steven Berghuis")
+		require.NoError(t, err)
+		assert.NotEqual(t, sealed, other, "each value has its own nonce")
+
+		rotated, err := services.ParseKeyring("2024:"+base64Key(1)+",2025:"+base64Key(2), "2025")
+		require.NoError(t, err)
+		opened, err := services.NewFieldCipher(rotated).Decrypt(sealed)
+		require.NoError(t, err)
+		assert.Equal(t, "Steven Berghuis", opened, "old keys stay readable after rotation")
+	})
+
+	t.Run("Plain text and empty values pass through", func(t *testing.T) {
+		opened, err := cipher.Decrypt("Davy Klaassen")
+		require.NoError(t, err)
+		assert.Equal(t, "Davy Klaassen", opened)
+		sealed, err := cipher.Encrypt("")
+		require.NoError(t, err)
+		assert.Empty(t, sealed)
+
+		var none *services.FieldCipher
+		sealed, err = none.Encrypt("Davy Klaassen")
+		require.NoError(t, err)
+		assert.Equal(t, "Davy Klaassen", sealed)
+	})
+
+	t.Run("Modified values and unknown keys fail", func(t *testing.T) {
+		sealed, err := cipher.Encrypt("Steven Berghuis")
+		require.NoError(t, err)
+
+		tampered := sealed[:len(sealed)-2] + "AA"
+		_, err = cipher.Decrypt(tampered)
+		assert.ErrorIs(t, err, services.ErrFieldDecryption)
+
+		other, err := services.ParseKeyring("2025:"+base64Key(2), "")
+		require.NoError(t, err)
+		_, err = services.NewFieldCipher(other).Decrypt(sealed)
+		assert.ErrorIs(t, err, services.ErrFieldDecryption)
+
+		var none *services.FieldCipher
+		_, err = none.Decrypt(sealed)
+		assert.ErrorIs(t, err, services.ErrFieldDecryption)
+	})
+
+	t.Run("Blind indexes match equal values only", func(t *testing.T) {
+		indexed := services.NewFieldCipher(keyring, services.WithIndexKey([]byte("index key")))
+		index := indexed.Index("D. Makkelie")
+		assert.NotEmpty(t, index)
+		assert.NotContains(t, index, "Makkelie")
+		assert.Equal(t, index, indexed.Index("D. Makkelie"))
+		assert.NotEqual(t, index, indexed.Index("B. Nijhuis"))
+		assert.NotEqual(t, index, services.NewFieldCipher(keyring, services.WithIndexKey([]byte("other key"))).Index("D. Makkelie"))
+
+		assert.Empty(t, indexed.Index(""))
+		assert.Empty(t, cipher.Index("D. Makkelie"), "no index without an index key")
+	})
+}
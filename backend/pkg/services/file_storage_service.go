@@ -1,9 +1,11 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"mime/multipart"
 	"os"
 	"path/filepath"
@@ -16,16 +18,34 @@ import (
  * This can be used for local development or for accessing a mounted file share.
  */
 type LocalFileStorage struct {
-	basePath string // Base path for file storage
+	basePath string         // Base path for file storage
+	urls     *FileURLSigner // Signs stream URLs; nil gives file:// URLs
+}
+
+/**
+ * LocalStorageOption configures a LocalFileStorage.
+ */
+type LocalStorageOption func(*LocalFileStorage)
+
+/**
+ * WithSignedURLs makes GetStreamURL return temporary URLs the API serves,
+ * signed by urls, instead of file:// URLs.
+ *
+ * @param urls The signer of the URLs; nil keeps file:// URLs
+ * @return The storage option
+ */
+func WithSignedURLs(urls *FileURLSigner) LocalStorageOption {
+	return func(s *LocalFileStorage) { s.urls = urls }
 }
 
 /**
  * NewLocalFileStorage creates a new local file storage service.
  *
  * @param basePath The base directory path for file storage
+ * @param opts Storage options
  * @return A new storage service client or error
  */
-func NewLocalFileStorage(basePath string) (StorageService, error) {
+func NewLocalFileStorage(basePath string, opts ...LocalStorageOption) (StorageService, error) {
 	// Validate parameters
 	if basePath == "" {
 		return nil, errors.New("base path cannot be empty")
@@ -40,9 +60,29 @@ func NewLocalFileStorage(basePath string) (StorageService, error) {
 		return nil, errors.New("base path must be a directory")
 	}
 
-	return &LocalFileStorage{
+	storage := &LocalFileStorage{
 		basePath: basePath,
-	}, nil
+	}
+	for _, opt := range opts {
+		opt(storage)
+	}
+	return storage, nil
+}
+
+/**
+ * HealthCheck verifies that the base directory is still available and
+ * writable, e.g. that a mounted share has not gone away.
+ *
+ * @param ctx Unused; local checks do not block on the network
+ * @return Error if the base directory cannot be written
+ */
+func (s *LocalFileStorage) HealthCheck(ctx context.Context) error {
+	probe, err := os.CreateTemp(s.basePath, ".health-*")
+	if err != nil {
+		return err
+	}
+	probe.Close()
+	return os.Remove(probe.Name())
 }
 
 /**
@@ -54,6 +94,17 @@ func NewLocalFileStorage(basePath string) (StorageService, error) {
  * @return Upload information or error
  */
 func (s *LocalFileStorage) UploadFile(file multipart.File, path string) (*FileUploadInfo, error) {
+	return s.UploadStream(file, path)
+}
+
+/**
+ * UploadStream stores a stream in the local file system.
+ *
+ * @param r The content to store
+ * @param path The destination path relative to the base path
+ * @return Upload information or error
+ */
+func (s *LocalFileStorage) UploadStream(r io.Reader, path string) (*FileUploadInfo, error) {
 	// Create full path
 	fullPath := filepath.Join(s.basePath, path)
 	dirPath := filepath.Dir(fullPath)
@@ -71,7 +122,7 @@ func (s *LocalFileStorage) UploadFile(file multipart.File, path string) (*FileUp
 	defer dst.Close()
 
 	// Copy file contents
-	written, err := io.Copy(dst, file)
+	written, err := io.Copy(dst, r)
 	if err != nil {
 		return nil, fmt.Errorf("failed to copy file: %v", err)
 	}
@@ -132,9 +183,10 @@ func (s *LocalFileStorage) DeleteFile(path string) error {
 }
 
 /**
- * GetStreamURL generates a local file URL for streaming.
- * Since this is a local implementation, it returns a file:// URL.
- * Note: This may not work in all contexts due to browser security restrictions.
+ * GetStreamURL generates a URL for streaming a local file. With signed URLs
+ * it returns a temporary /api/v1/files/{token} URL the API serves with range
+ * support, like an Azure SAS URL. Otherwise it returns a file:// URL, which
+ * may not work in all contexts due to browser security restrictions.
  *
  * @param path The path of the file in storage
  * @return A URL for accessing the file or error
@@ -151,6 +203,9 @@ func (s *LocalFileStorage) GetStreamURL(path string) (string, error) {
 		}
 		return "", fmt.Errorf("failed to access file: %v", err)
 	}
+	if s.urls != nil {
+		return s.urls.Sign(path)
+	}
 
 	// Convert to absolute path for URL
 	absPath, err := filepath.Abs(fullPath)
@@ -192,3 +247,143 @@ func (s *LocalFileStorage) GetFileMetadata(path string) (map[string]string, erro
 
 	return metadata, nil
 }
+
+/**
+ * ListFiles lists the files under the base path whose relative path starts
+ * with prefix. Paths use forward slashes, as they do in the repository.
+ *
+ * @param prefix The path prefix, e.g. "videos/"
+ * @return The matching files or error
+ */
+func (s *LocalFileStorage) ListFiles(prefix string) ([]FileInfo, error) {
+	// Only walk the directory the prefix points into
+	root := s.basePath
+	if dir := filepath.Dir(filepath.FromSlash(prefix + "x")); dir != "." {
+		root = filepath.Join(s.basePath, dir)
+	}
+
+	files := []FileInfo{}
+	err := filepath.WalkDir(root, func(fullPath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && fullPath == root {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(s.basePath, fullPath)
+		if err != nil {
+			return err
+		}
+		path := filepath.ToSlash(rel)
+		if !strings.HasPrefix(path, prefix) {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil // Deleted while listing
+			}
+			return err
+		}
+		files = append(files, FileInfo{Path: path, Size: info.Size(), ModifiedAt: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files: %v", err)
+	}
+	return files, nil
+}
+
+/**
+ * Exists reports whether a file exists in local storage.
+ *
+ * @param path The path of the file in storage
+ * @return Whether the file exists, or error if it cannot be determined
+ */
+func (s *LocalFileStorage) Exists(path string) (bool, error) {
+	info, err := os.Stat(filepath.Join(s.basePath, path))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to access file: %v", err)
+	}
+	return !info.IsDir(), nil
+}
+
+/**
+ * CopyFile copies a file within local storage. The copy is written to a
+ * temporary file first, so dst never holds a partial copy.
+ *
+ * @param src The path of the file to copy
+ * @param dst The destination path
+ * @return Error if the copy fails
+ */
+func (s *LocalFileStorage) CopyFile(src, dst string) error {
+	in, err := s.GetFile(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	// Ensure the destination directory exists
+	dstPath := filepath.Join(s.basePath, dst)
+	if err := os.MkdirAll(filepath.Dir(dstPath), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %v", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(dstPath), ".copy-*")
+	if err != nil {
+		return fmt.Errorf("failed to create file: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, in); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to copy file: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to copy file: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), dstPath); err != nil {
+		return fmt.Errorf("failed to copy file: %v", err)
+	}
+	return nil
+}
+
+/**
+ * MoveFile moves a file within local storage. Files are renamed when
+ * possible, and copied and deleted when src and dst are on different
+ * devices, e.g. separately mounted shares.
+ *
+ * @param src The path of the file to move
+ * @param dst The destination path
+ * @return Error if the move fails
+ */
+func (s *LocalFileStorage) MoveFile(src, dst string) error {
+	srcPath := filepath.Join(s.basePath, src)
+	dstPath := filepath.Join(s.basePath, dst)
+
+	if _, err := os.Stat(srcPath); err != nil {
+		if os.IsNotExist(err) {
+			return errors.New("file not found")
+		}
+		return fmt.Errorf("failed to access file: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(dstPath), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %v", err)
+	}
+
+	if err := os.Rename(srcPath, dstPath); err == nil {
+		return nil
+	}
+	if err := s.CopyFile(src, dst); err != nil {
+		return err
+	}
+	return s.DeleteFile(src)
+}
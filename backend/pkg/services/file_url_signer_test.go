@@ -0,0 +1,53 @@
+package services_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"nivai/backend/pkg/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileURLSigner(t *testing.T) {
+	signer := services.NewFileURLSigner("secret", "https://api.example.com/", time.Hour)
+
+	t.Run("Signed URLs name the file until they expire", func(t *testing.T) {
+		url, err := signer.Sign("videos/m1/m1.mp4")
+		require.NoError(t, err)
+		token, ok := strings.CutPrefix(url, "https://api.example.com/api/v1/files/")
+		require.True(t, ok, url)
+
+		path, err := signer.Verify(token)
+		require.NoError(t, err)
+		assert.Equal(t, "videos/m1/m1.mp4", path)
+
+		// Seconds are truncated, so a nanosecond lifetime has already passed
+		url, err = services.NewFileURLSigner("secret", "", time.Nanosecond).Sign("videos/m1/m1.mp4")
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(url, "/api/v1/files/"), "without a base URL, URLs are relative")
+		_, err = signer.Verify(strings.TrimPrefix(url, "/api/v1/files/"))
+		assert.ErrorIs(t, err, services.ErrFileURLExpired)
+	})
+
+	t.Run("Tampered and foreign tokens are refused", func(t *testing.T) {
+		url, err := signer.Sign("videos/m1/m1.mp4")
+		require.NoError(t, err)
+		token := url[strings.LastIndex(url, "/")+1:]
+		payload, signature, _ := strings.Cut(token, ".")
+
+		other, err := signer.Sign("videos/m2/m2.mp4")
+		require.NoError(t, err)
+		otherPayload, _, _ := strings.Cut(other[strings.LastIndex(other, "/")+1:], ".")
+
+		foreign, err := services.NewFileURLSigner("other", "", time.Hour).Sign("videos/m1/m1.mp4")
+		require.NoError(t, err)
+
+		for _, invalid := range []string{"", payload, payload + ".", otherPayload + "." + signature, "x" + token, strings.TrimPrefix(foreign, "/api/v1/files/")} {
+			_, err := signer.Verify(invalid)
+			assert.ErrorIs(t, err, services.ErrFileURLInvalid, invalid)
+		}
+	})
+}
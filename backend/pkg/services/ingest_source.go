@@ -0,0 +1,299 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+// ingestFailedDir is the subdirectory of a drop that files which could not
+// be ingested are moved to.
+const ingestFailedDir = "failed"
+
+/**
+ * IngestFile is a file waiting in an ingestion drop.
+ */
+type IngestFile struct {
+	Name       string // File name within the drop
+	Size       int64
+	ModifiedAt time.Time
+}
+
+/**
+ * IngestSource is a drop folder that tracking providers deliver match
+ * files to: a local or mounted directory, or a directory on an SFTP server.
+ * Only the files directly in the drop are listed; subdirectories, like the
+ * one failed files are moved to, are not.
+ */
+type IngestSource interface {
+	List() ([]IngestFile, error)
+	Open(name string) (io.ReadCloser, error)
+	Remove(name string) error
+	MoveToFailed(name string) error // Moves a file to the drop's "failed" subdirectory
+	String() string                 // Describes the drop in logs and state history
+}
+
+/**
+ * DirectorySource is a drop on a local or mounted directory.
+ */
+type DirectorySource struct {
+	dir string
+}
+
+/**
+ * NewDirectorySource creates a drop on a directory.
+ *
+ * @param dir The directory the match files are delivered to
+ * @return A new directory drop
+ */
+func NewDirectorySource(dir string) *DirectorySource {
+	return &DirectorySource{dir: dir}
+}
+
+/**
+ * List lists the regular files in the directory.
+ */
+func (s *DirectorySource) List() ([]IngestFile, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []IngestFile
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue // Removed since the directory was read
+		}
+		files = append(files, IngestFile{Name: entry.Name(), Size: info.Size(), ModifiedAt: info.ModTime()})
+	}
+	return files, nil
+}
+
+/**
+ * Open opens a file in the directory.
+ */
+func (s *DirectorySource) Open(name string) (io.ReadCloser, error) {
+	return os.Open(filepath.Join(s.dir, name))
+}
+
+/**
+ * Remove deletes a file from the directory.
+ */
+func (s *DirectorySource) Remove(name string) error {
+	return os.Remove(filepath.Join(s.dir, name))
+}
+
+/**
+ * MoveToFailed moves a file to the directory's "failed" subdirectory.
+ */
+func (s *DirectorySource) MoveToFailed(name string) error {
+	failed := filepath.Join(s.dir, ingestFailedDir)
+	if err := os.MkdirAll(failed, 0o755); err != nil {
+		return err
+	}
+	return os.Rename(filepath.Join(s.dir, name), filepath.Join(failed, name))
+}
+
+func (s *DirectorySource) String() string {
+	return s.dir
+}
+
+/**
+ * SFTPConfig configures an SFTP drop. The server must be identified by its
+ * host key fingerprint; a password, a private key or both authenticate.
+ */
+type SFTPConfig struct {
+	Host               string
+	Port               int // Default 22
+	User               string
+	Password           string
+	PrivateKeyFile     string // PEM or OpenSSH private key, unencrypted
+	HostKeyFingerprint string // "SHA256:..." as printed by ssh-keygen -l
+	Directory          string // Directory of the drop on the server
+	Timeout            time.Duration
+}
+
+/**
+ * SFTPSource is a drop on an SFTP server. It connects on first use and
+ * reconnects after a failed operation.
+ */
+type SFTPSource struct {
+	addr string
+	dir  string
+	ssh  *ssh.ClientConfig
+
+	mu     sync.Mutex
+	conn   *ssh.Client
+	client *sftp.Client
+}
+
+/**
+ * NewSFTPSource creates an SFTP drop. No connection is made yet.
+ *
+ * @param cfg Server, credentials and directory of the drop
+ * @return A new SFTP drop, or an error if the credentials cannot be loaded
+ */
+func NewSFTPSource(cfg SFTPConfig) (*SFTPSource, error) {
+	if cfg.HostKeyFingerprint == "" {
+		return nil, errors.New("the SFTP server's host key fingerprint is required")
+	}
+	var auth []ssh.AuthMethod
+	if cfg.PrivateKeyFile != "" {
+		pem, err := os.ReadFile(cfg.PrivateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read SFTP private key: %w", err)
+		}
+		signer, err := ssh.ParsePrivateKey(pem)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse SFTP private key: %w", err)
+		}
+		auth = append(auth, ssh.PublicKeys(signer))
+	}
+	if cfg.Password != "" {
+		auth = append(auth, ssh.Password(cfg.Password))
+	}
+	if len(auth) == 0 {
+		return nil, errors.New("an SFTP password or private key is required")
+	}
+	if cfg.Port == 0 {
+		cfg.Port = 22
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+
+	fingerprint := cfg.HostKeyFingerprint
+	return &SFTPSource{
+		addr: net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
+		dir:  cfg.Directory,
+		ssh: &ssh.ClientConfig{
+			User: cfg.User,
+			Auth: auth,
+			HostKeyCallback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+				if got := ssh.FingerprintSHA256(key); got != fingerprint {
+					return fmt.Errorf("host key of %s is %s, expected %s", hostname, got, fingerprint)
+				}
+				return nil
+			},
+			Timeout: cfg.Timeout,
+		},
+	}, nil
+}
+
+// do runs op with a connected client, dropping the connection when op
+// fails so the next operation reconnects.
+func (s *SFTPSource) do(op func(client *sftp.Client) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.client == nil {
+		conn, err := ssh.Dial("tcp", s.addr, s.ssh)
+		if err != nil {
+			return fmt.Errorf("failed to connect to SFTP server %s: %w", s.addr, err)
+		}
+		client, err := sftp.NewClient(conn)
+		if err != nil {
+			conn.Close()
+			return fmt.Errorf("failed to start SFTP session on %s: %w", s.addr, err)
+		}
+		s.conn, s.client = conn, client
+	}
+	err := op(s.client)
+	var status *sftp.StatusError
+	if err != nil && !errors.Is(err, os.ErrNotExist) && !errors.As(err, &status) {
+		s.closeLocked()
+	}
+	return err
+}
+
+/**
+ * List lists the regular files in the drop directory.
+ */
+func (s *SFTPSource) List() ([]IngestFile, error) {
+	var files []IngestFile
+	err := s.do(func(client *sftp.Client) error {
+		entries, err := client.ReadDir(s.dir)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if entry.Mode().IsRegular() {
+				files = append(files, IngestFile{Name: entry.Name(), Size: entry.Size(), ModifiedAt: entry.ModTime()})
+			}
+		}
+		return nil
+	})
+	return files, err
+}
+
+/**
+ * Open opens a file in the drop directory for reading.
+ */
+func (s *SFTPSource) Open(name string) (io.ReadCloser, error) {
+	var file *sftp.File
+	err := s.do(func(client *sftp.Client) (err error) {
+		file, err = client.Open(path.Join(s.dir, name))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+/**
+ * Remove deletes a file from the drop directory.
+ */
+func (s *SFTPSource) Remove(name string) error {
+	return s.do(func(client *sftp.Client) error {
+		return client.Remove(path.Join(s.dir, name))
+	})
+}
+
+/**
+ * MoveToFailed moves a file to the drop directory's "failed" subdirectory.
+ */
+func (s *SFTPSource) MoveToFailed(name string) error {
+	return s.do(func(client *sftp.Client) error {
+		failed := path.Join(s.dir, ingestFailedDir)
+		if err := client.MkdirAll(failed); err != nil {
+			return err
+		}
+		return client.Rename(path.Join(s.dir, name), path.Join(failed, name))
+	})
+}
+
+func (s *SFTPSource) String() string {
+	return fmt.Sprintf("sftp://%s@%s%s", s.ssh.User, s.addr, path.Join("/", s.dir))
+}
+
+/**
+ * Close closes the connection to the server, if any.
+ */
+func (s *SFTPSource) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closeLocked()
+	return nil
+}
+
+// closeLocked drops the connection. s.mu must be held.
+func (s *SFTPSource) closeLocked() {
+	if s.client != nil {
+		s.client.Close()
+		s.conn.Close()
+		s.client, s.conn = nil, nil
+	}
+}
@@ -0,0 +1,350 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"nivai/backend/pkg/ingest/adapters"
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/upload"
+
+	"github.com/google/uuid"
+)
+
+// ingestNamePattern matches the file names of a delivery: "<match>_tracking",
+// "<match>_events" and "<match>_video", each with any extension.
+var ingestNamePattern = regexp.MustCompile(`(?i)^(.+)_(tracking|events|video)(\.[^_]*)?$`)
+
+/**
+ * MatchStarter starts the analytics processing of a stored match.
+ * It is implemented by *MatchProcessor.
+ */
+type MatchStarter interface {
+	Start(ctx context.Context, videoID, trackingPath, eventPath string) error
+}
+
+// Compile-time check that MatchProcessor satisfies MatchStarter.
+var _ MatchStarter = (*MatchProcessor)(nil)
+
+/**
+ * IngestionConfig tunes the drop folder ingestion.
+ */
+type IngestionConfig struct {
+	SettleAfter  time.Duration  // Time a delivery's files must stay unchanged before ingestion (default 2m)
+	Scanner      upload.Scanner // Malware scanner; nil ingests unscanned
+	PathStrategy PathStrategy   // Must be the strategy uploads use (default IDShardStrategy)
+}
+
+/**
+ * IngestReport is the result of one ingestion run.
+ */
+type IngestReport struct {
+	Ingested []string // Video IDs of the matches created
+	Waiting  int      // Deliveries still incomplete or being written
+	Failed   int      // Deliveries moved to the drop's "failed" subdirectory
+}
+
+/**
+ * IngestionService creates matches from the files tracking providers
+ * deliver to a drop folder. A delivery is the files sharing a match name:
+ * "<match>_tracking.<ext>" and "<match>_events.<ext>", and optionally
+ * "<match>_video.<ext>". Once both required files are present and no file
+ * changed for the settle time, the files are scanned and moved into
+ * managed storage, the match is created and its processing starts. Tracking
+ * and event files in a provider's export format are converted to the
+ * canonical format on the way.
+ *
+ * Deliveries that cannot be stored are moved to the drop's "failed"
+ * subdirectory, so they are not retried until delivered again.
+ */
+type IngestionService struct {
+	source       IngestSource
+	videoService VideoService
+	storage      StorageService
+	starter      MatchStarter
+	cfg          IngestionConfig
+	now          func() time.Time
+}
+
+/**
+ * NewIngestionService creates a new drop folder ingestion service.
+ *
+ * @param source The drop the deliveries arrive in
+ * @param videoService Video service the matches are created with
+ * @param storage Managed storage the files are moved to
+ * @param starter Starts the processing of ingested matches
+ * @param cfg Ingestion settings
+ * @return A new ingestion service
+ */
+func NewIngestionService(source IngestSource, videoService VideoService, storage StorageService, starter MatchStarter, cfg IngestionConfig) *IngestionService {
+	if cfg.SettleAfter <= 0 {
+		cfg.SettleAfter = 2 * time.Minute
+	}
+	if cfg.PathStrategy == nil {
+		cfg.PathStrategy = IDShardStrategy{}
+	}
+	return &IngestionService{
+		source:       source,
+		videoService: videoService,
+		storage:      storage,
+		starter:      starter,
+		cfg:          cfg,
+		now:          time.Now,
+	}
+}
+
+/**
+ * RunScheduled runs a scheduled ingestion, logging what it did.
+ *
+ * @param ctx Context for the run
+ * @return An error if the drop cannot be listed
+ */
+func (s *IngestionService) RunScheduled(ctx context.Context) error {
+	report, err := s.Ingest(ctx)
+	if err != nil {
+		return err
+	}
+	if len(report.Ingested) > 0 || report.Failed > 0 {
+		log.Printf("Ingestion: %d matches ingested from %s, %d failed, %d waiting",
+			len(report.Ingested), s.source, report.Failed, report.Waiting)
+	}
+	return nil
+}
+
+/**
+ * Ingest creates a match from every complete, settled delivery in the drop.
+ *
+ * @param ctx Context for the run
+ * @return The run report, or an error if the drop cannot be listed
+ */
+func (s *IngestionService) Ingest(ctx context.Context) (*IngestReport, error) {
+	files, err := s.source.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", s.source, err)
+	}
+
+	deliveries := map[string]map[string]IngestFile{} // Files by kind, by match name
+	for _, file := range files {
+		match := ingestNamePattern.FindStringSubmatch(file.Name)
+		if match == nil {
+			continue
+		}
+		name, kind := match[1], strings.ToLower(match[2])
+		if deliveries[name] == nil {
+			deliveries[name] = map[string]IngestFile{}
+		}
+		deliveries[name][kind] = file
+	}
+	names := make([]string, 0, len(deliveries))
+	for name := range deliveries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	report := &IngestReport{}
+	settled := s.now().Add(-s.cfg.SettleAfter)
+	for _, name := range names {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		delivery := deliveries[name]
+		if !deliveryReady(delivery, settled) {
+			report.Waiting++
+			continue
+		}
+		videoID, err := s.ingest(ctx, name, delivery)
+		if err != nil {
+			log.Printf("Ingestion: failed to ingest %s from %s: %v", name, s.source, err)
+			if ctx.Err() == nil {
+				s.moveToFailed(delivery)
+				report.Failed++
+			}
+			continue
+		}
+		report.Ingested = append(report.Ingested, videoID)
+	}
+	return report, nil
+}
+
+// deliveryReady reports whether a delivery has its tracking and event files
+// and none of its files changed after settled.
+func deliveryReady(delivery map[string]IngestFile, settled time.Time) bool {
+	if _, ok := delivery[models.FileKindTracking]; !ok {
+		return false
+	}
+	if _, ok := delivery[models.FileKindEvents]; !ok {
+		return false
+	}
+	for _, file := range delivery {
+		if file.ModifiedAt.After(settled) {
+			return false
+		}
+	}
+	return true
+}
+
+// ingest stores a delivery's files, creates its match and starts its
+// processing, returning the new video ID. The drop's files are removed
+// once the match exists.
+func (s *IngestionService) ingest(ctx context.Context, name string, delivery map[string]IngestFile) (string, error) {
+	files := make(map[string]string, len(delivery))
+	for kind, file := range delivery {
+		files[kind] = file.Name
+	}
+	videoID, err := s.Import(ctx, &models.Video{Title: name}, files, "ingested from "+s.source.String())
+	if err != nil {
+		return "", err
+	}
+	// The files are in managed storage now; a leftover would be ingested again
+	for _, file := range delivery {
+		if err := s.source.Remove(file.Name); err != nil {
+			log.Printf("Ingestion: failed to remove %s from %s: %v", file.Name, s.source, err)
+		}
+	}
+	return videoID, nil
+}
+
+/**
+ * Import stores a match's files from the source, creates the match and
+ * starts its processing, like a delivery. The source's files are left in
+ * place. Infected matches are quarantined instead of processed.
+ *
+ * @param ctx Context for the import; WithPriority sets the processing priority
+ * @param video The match metadata; its ID, state, paths and creation time are set here
+ * @param files Names of the files in the source by kind; tracking and events are required
+ * @param reason Reason recorded in the state history
+ * @return The new video ID, or an error if a file cannot be stored or the match created
+ */
+func (s *IngestionService) Import(ctx context.Context, video *models.Video, files map[string]string, reason string) (string, error) {
+	if files[models.FileKindTracking] == "" || files[models.FileKindEvents] == "" {
+		return "", fmt.Errorf("tracking and event files are required")
+	}
+	videoID := uuid.New().String()
+	now := s.now()
+	dir := s.cfg.PathStrategy.Dir(StoragePathInfo{VideoID: videoID, UploadedAt: now})
+	video.ID, video.ProcessingState, video.CreatedAt = videoID, models.StatePendingAnalytics, now
+
+	var stored []*models.VideoFile
+	threats := map[string]string{}
+	for _, kind := range []string{models.FileKindTracking, models.FileKindEvents, models.FileKindVideo} {
+		name, ok := files[kind]
+		if !ok || name == "" {
+			continue
+		}
+		record, threat, err := s.storeFile(ctx, IngestFile{Name: name}, filepath.Join(dir, MatchFileName(videoID, kind, path.Base(name))), kind)
+		if err != nil {
+			s.deleteStored(stored)
+			return "", err
+		}
+		stored = append(stored, record)
+		if threat != "" {
+			threats[kind] = threat
+		}
+		switch kind {
+		case models.FileKindTracking:
+			video.TrackingPath = record.Path
+		case models.FileKindEvents:
+			video.EventFilePath = record.Path
+		case models.FileKindVideo:
+			video.FilePath, video.Size, video.StorageProvider = record.Path, record.Size, "default"
+			video.Format = strings.TrimPrefix(strings.ToLower(filepath.Ext(name)), ".")
+		}
+	}
+
+	change := StateChange{Actor: ActorIngestion, Reason: reason}
+	if _, err := s.videoService.CreateVideoEntry(video, change); err != nil {
+		s.deleteStored(stored)
+		return "", fmt.Errorf("failed to create match: %w", err)
+	}
+	if err := s.videoService.RecordVideoFiles(videoID, stored); err != nil {
+		log.Printf("Ingestion: failed to record file checksums for video %s: %v", videoID, err)
+	}
+
+	// Infected deliveries are quarantined like infected uploads
+	if len(threats) > 0 {
+		log.Printf("Ingestion: match %s quarantined, malware detected: %v", videoID, threats)
+		if err := s.videoService.RejectVideo(videoID, threats); err != nil {
+			log.Printf("Ingestion: failed to reject infected video %s: %v", videoID, err)
+		}
+		return videoID, nil
+	}
+	if err := s.starter.Start(ctx, videoID, video.TrackingPath, video.EventFilePath); err != nil {
+		log.Printf("Ingestion: failed to start processing of video %s: %v", videoID, err)
+	}
+	return videoID, nil
+}
+
+// storeFile copies a file from the drop to dest, converting, checksumming
+// and scanning it on the way. It is spooled to a temporary file first, as
+// storage backends may need to seek.
+func (s *IngestionService) storeFile(ctx context.Context, file IngestFile, dest, kind string) (*models.VideoFile, string, error) {
+	src, err := s.source.Open(file.Name)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to open %s: %w", file.Name, err)
+	}
+	defer src.Close()
+
+	// Tracking and event files in a provider's format are converted on the way
+	var content io.Reader = src
+	if kind == models.FileKindTracking || kind == models.FileKindEvents {
+		converted, provider, err := adapters.Normalize(ctx, kind, "", src)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read %s: %w", file.Name, err)
+		}
+		defer converted.Close()
+		if provider != "" {
+			log.Printf("Ingestion: converting %s from the %s format", file.Name, provider)
+		}
+		content = converted
+	}
+
+	spool, err := os.CreateTemp("", "nivai-ingest-*")
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to spool %s: %w", file.Name, err)
+	}
+	defer os.Remove(spool.Name())
+	defer spool.Close()
+
+	result, err := upload.Tee(ctx, content, func(r io.Reader) error {
+		_, err := io.Copy(spool, r)
+		return err
+	}, upload.Options{Scanner: s.cfg.Scanner})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read %s: %w", file.Name, err)
+	}
+	if _, err := spool.Seek(0, io.SeekStart); err != nil {
+		return nil, "", fmt.Errorf("failed to rewind %s: %w", file.Name, err)
+	}
+	info, err := s.storage.UploadFile(spool, dest)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to store %s at %s: %w", file.Name, dest, err)
+	}
+	return &models.VideoFile{Kind: kind, Path: info.Path, Size: result.Size, Checksum: result.Checksum}, result.Threat, nil
+}
+
+// deleteStored removes the files of a delivery that failed to ingest.
+func (s *IngestionService) deleteStored(stored []*models.VideoFile) {
+	for _, file := range stored {
+		if err := s.storage.DeleteFile(file.Path); err != nil {
+			log.Printf("Ingestion: failed to delete %s: %v", file.Path, err)
+		}
+	}
+}
+
+// moveToFailed moves a delivery's files out of the drop.
+func (s *IngestionService) moveToFailed(delivery map[string]IngestFile) {
+	for _, file := range delivery {
+		if err := s.source.MoveToFailed(file.Name); err != nil {
+			log.Printf("Ingestion: failed to move %s to %s/%s: %v", file.Name, s.source, ingestFailedDir, err)
+		}
+	}
+}
@@ -0,0 +1,275 @@
+package services_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/services"
+	"nivai/backend/pkg/upload"
+
+	"github.com/pkg/sftp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+// recordingStarter records the matches whose processing was started.
+type recordingStarter struct {
+	started []string
+}
+
+func (s *recordingStarter) Start(ctx context.Context, videoID, trackingPath, eventPath string) error {
+	s.started = append(s.started, videoID)
+	return nil
+}
+
+// deliver writes files to a drop directory, modified at modTime.
+func deliver(t *testing.T, dir string, modTime time.Time, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		p := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(p, []byte("content of "+name), 0o644))
+		require.NoError(t, os.Chtimes(p, modTime, modTime))
+	}
+}
+
+func TestIngestionService(t *testing.T) {
+	settled := time.Now().Add(-time.Hour)
+
+	setup := func(t *testing.T, storage services.StorageService, scanner upload.Scanner) (string, *services.IngestionService, *models.MemoryVideoRepository, *recordingStarter) {
+		drop := t.TempDir()
+		videoRepo := models.NewMemoryVideoRepository()
+		starter := &recordingStarter{}
+		ingestion := services.NewIngestionService(services.NewDirectorySource(drop), services.NewVideoService(videoRepo, storage),
+			storage, starter, services.IngestionConfig{Scanner: scanner})
+		return drop, ingestion, videoRepo, starter
+	}
+
+	t.Run("Complete deliveries become matches", func(t *testing.T) {
+		storage := services.NewMemoryStorageService()
+		drop, ingestion, videoRepo, starter := setup(t, storage, nil)
+		deliver(t, drop, settled, "ajax-psv_tracking.csv.gz", "ajax-psv_events.json", "ajax-psv_video.MP4", "readme.txt")
+
+		report, err := ingestion.Ingest(context.Background())
+
+		require.NoError(t, err)
+		require.Len(t, report.Ingested, 1)
+		video, err := videoRepo.FindByID(report.Ingested[0])
+		require.NoError(t, err)
+		assert.Equal(t, "ajax-psv", video.Title)
+		assert.Equal(t, models.StatePendingAnalytics, video.ProcessingState)
+		assert.Equal(t, "mp4", video.Format)
+		assert.True(t, strings.HasSuffix(video.TrackingPath, "_tracking.gzip"), video.TrackingPath)
+		stored, err := storage.GetFile(video.EventFilePath)
+		require.NoError(t, err)
+		content, _ := io.ReadAll(stored)
+		assert.Equal(t, "content of ajax-psv_events.json", string(content))
+		assert.Equal(t, report.Ingested, starter.started)
+
+		remaining, _ := os.ReadDir(drop)
+		require.Len(t, remaining, 1, "ingested files leave the drop")
+		assert.Equal(t, "readme.txt", remaining[0].Name())
+	})
+
+	t.Run("Provider exports are converted to the canonical format", func(t *testing.T) {
+		storage := services.NewMemoryStorageService()
+		drop, ingestion, videoRepo, _ := setup(t, storage, nil)
+		deliver(t, drop, settled, "m_events.csv")
+		tracab := filepath.Join(drop, "m_tracking.dat")
+		require.NoError(t, os.WriteFile(tracab, []byte("1000:1,7,10,100,-200,0.0;:0,0,0,0.0,,Alive;:\n"), 0o644))
+		require.NoError(t, os.Chtimes(tracab, settled, settled))
+
+		report, err := ingestion.Ingest(context.Background())
+
+		require.NoError(t, err)
+		require.Len(t, report.Ingested, 1)
+		video, err := videoRepo.FindByID(report.Ingested[0])
+		require.NoError(t, err)
+		stored, err := storage.GetFile(video.TrackingPath)
+		require.NoError(t, err)
+		content, _ := io.ReadAll(stored)
+		assert.True(t, strings.HasPrefix(string(content), "PAR1"), "the Tracab file is stored as Parquet")
+	})
+
+	t.Run("Incomplete and recently changed deliveries wait", func(t *testing.T) {
+		drop, ingestion, _, starter := setup(t, services.NewMemoryStorageService(), nil)
+		deliver(t, drop, settled, "a_tracking.csv")
+		deliver(t, drop, settled, "b_tracking.csv")
+		deliver(t, drop, time.Now(), "b_events.csv")
+
+		report, err := ingestion.Ingest(context.Background())
+
+		require.NoError(t, err)
+		assert.Empty(t, report.Ingested)
+		assert.Equal(t, 2, report.Waiting)
+		assert.Empty(t, starter.started)
+	})
+
+	t.Run("Deliveries that cannot be stored move to failed", func(t *testing.T) {
+		storage := new(MockStorageService)
+		storage.On("UploadFile", mock.Anything, mock.Anything).Return(nil, errors.New("disk full")).Once()
+		drop, ingestion, _, _ := setup(t, storage, nil)
+		deliver(t, drop, settled, "m_tracking.csv", "m_events.csv")
+
+		report, err := ingestion.Ingest(context.Background())
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, report.Failed)
+		assert.FileExists(t, filepath.Join(drop, "failed", "m_tracking.csv"))
+		assert.FileExists(t, filepath.Join(drop, "failed", "m_events.csv"))
+		assert.NoFileExists(t, filepath.Join(drop, "m_events.csv"))
+	})
+
+	t.Run("Infected deliveries are quarantined", func(t *testing.T) {
+		scanner := upload.ScannerFunc(func(ctx context.Context, r io.Reader) error {
+			data, _ := io.ReadAll(r)
+			if strings.Contains(string(data), "events") {
+				return &upload.InfectedError{Signature: "Eicar-Test-Signature"}
+			}
+			return nil
+		})
+		drop, ingestion, videoRepo, starter := setup(t, services.NewMemoryStorageService(), scanner)
+		deliver(t, drop, settled, "m_tracking.csv", "m_events.csv")
+
+		report, err := ingestion.Ingest(context.Background())
+
+		require.NoError(t, err)
+		require.Len(t, report.Ingested, 1)
+		video, err := videoRepo.FindByID(report.Ingested[0])
+		require.NoError(t, err)
+		assert.Equal(t, models.StateRejected, video.ProcessingState)
+		assert.Empty(t, starter.started)
+	})
+}
+
+// startSFTPServer serves dir over SFTP on a local port, accepting user
+// "ingest" with password "secret", and returns its address and host key
+// fingerprint.
+func startSFTPServer(t *testing.T, dir string) (string, int, string) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	hostKey, err := ssh.NewSignerFromKey(private)
+	require.NoError(t, err)
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if conn.User() == "ingest" && string(password) == "secret" {
+				return nil, nil
+			}
+			return nil, errors.New("access denied")
+		},
+	}
+	config.AddHostKey(hostKey)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveSFTP(conn, config, dir)
+		}
+	}()
+	addr := listener.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port, ssh.FingerprintSHA256(hostKey.PublicKey())
+}
+
+// serveSFTP serves the sftp subsystem on one SSH connection.
+func serveSFTP(conn net.Conn, config *ssh.ServerConfig, dir string) {
+	_, channels, requests, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(requests)
+	for newChannel := range channels {
+		channel, channelRequests, err := newChannel.Accept()
+		if err != nil {
+			continue
+		}
+		go func() {
+			for req := range channelRequests {
+				req.Reply(req.Type == "subsystem" && len(req.Payload) > 4 && string(req.Payload[4:]) == "sftp", nil)
+			}
+		}()
+		server, err := sftp.NewServer(channel, sftp.WithServerWorkingDirectory(dir))
+		if err != nil {
+			channel.Close()
+			continue
+		}
+		go func() {
+			server.Serve()
+			server.Close()
+		}()
+	}
+}
+
+func TestSFTPSource(t *testing.T) {
+	dir := t.TempDir()
+	host, port, fingerprint := startSFTPServer(t, dir)
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "drop"), 0o755))
+	deliver(t, filepath.Join(dir, "drop"), time.Now(), "m_tracking.csv", "m_events.csv")
+
+	t.Run("Lists, reads, removes and fails files", func(t *testing.T) {
+		source, err := services.NewSFTPSource(services.SFTPConfig{
+			Host: host, Port: port, User: "ingest", Password: "secret", HostKeyFingerprint: fingerprint, Directory: "drop",
+		})
+		require.NoError(t, err)
+		t.Cleanup(func() { source.Close() })
+
+		files, err := source.List()
+		require.NoError(t, err)
+		require.Len(t, files, 2)
+		sizes := map[string]int64{}
+		for _, file := range files {
+			sizes[file.Name] = file.Size
+		}
+		assert.Equal(t, map[string]int64{"m_tracking.csv": 25, "m_events.csv": 23}, sizes)
+
+		r, err := source.Open("m_tracking.csv")
+		require.NoError(t, err)
+		content, err := io.ReadAll(r)
+		require.NoError(t, err)
+		r.Close()
+		assert.Equal(t, "content of m_tracking.csv", string(content))
+
+		require.NoError(t, source.Remove("m_tracking.csv"))
+		require.NoError(t, source.MoveToFailed("m_events.csv"))
+		assert.NoFileExists(t, filepath.Join(dir, "drop", "m_tracking.csv"))
+		assert.FileExists(t, filepath.Join(dir, "drop", "failed", "m_events.csv"))
+
+		files, err = source.List()
+		require.NoError(t, err)
+		assert.Empty(t, files, "the failed directory is not listed")
+	})
+
+	t.Run("Refuses a server with another host key", func(t *testing.T) {
+		source, err := services.NewSFTPSource(services.SFTPConfig{
+			Host: host, Port: port, User: "ingest", Password: "secret", HostKeyFingerprint: "SHA256:other", Directory: "drop",
+		})
+		require.NoError(t, err)
+
+		_, err = source.List()
+
+		assert.ErrorContains(t, err, "host key")
+	})
+
+	t.Run("Requires credentials and a host key fingerprint", func(t *testing.T) {
+		_, err := services.NewSFTPSource(services.SFTPConfig{Host: host, User: "ingest", HostKeyFingerprint: fingerprint})
+		assert.Error(t, err)
+		_, err = services.NewSFTPSource(services.SFTPConfig{Host: host, User: "ingest", Password: "secret"})
+		assert.Error(t, err)
+	})
+}
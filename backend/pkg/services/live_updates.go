@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"nivai/backend/pkg/events"
+)
+
+// WebSocket message types pushed in addition to relayed lifecycle events.
+const (
+	LiveMatchDayStarted  = "match_day.started"
+	LiveMatchDayEnded    = "match_day.ended"
+	LiveAnalyticsUpdated = "match_day.analytics_updated"
+)
+
+/**
+ * LiveBroadcaster sends a message to every connected WebSocket client.
+ * It is implemented by the WebSocket hub.
+ */
+type LiveBroadcaster interface {
+	Broadcast(message []byte)
+}
+
+/**
+ * LiveMessage is the JSON envelope of messages pushed to WebSocket clients.
+ */
+type LiveMessage struct {
+	Type       string                 `json:"type"`
+	MatchID    string                 `json:"match_id"`
+	MatchDay   bool                   `json:"match_day"`
+	OccurredAt time.Time              `json:"occurred_at"`
+	Data       map[string]interface{} `json:"data,omitempty"`
+}
+
+// newLiveMessage encodes a WebSocket message.
+func newLiveMessage(messageType, matchID string, matchDay bool, data map[string]interface{}) []byte {
+	message, err := json.Marshal(LiveMessage{
+		Type:       messageType,
+		MatchID:    matchID,
+		MatchDay:   matchDay,
+		OccurredAt: time.Now().UTC(),
+		Data:       data,
+	})
+	if err != nil {
+		log.Printf("Live updates: failed to encode %s message for match %s: %v", messageType, matchID, err)
+		return nil
+	}
+	return message
+}
+
+/**
+ * LiveNotifier pushes match lifecycle events to WebSocket clients. For
+ * matches in match-day mode the messages are richer: completed analytics
+ * carry the full match summary so dashboards update without a refetch.
+ */
+type LiveNotifier struct {
+	matchDay *MatchDayService
+	cache    *AnalyticsCache
+	live     LiveBroadcaster
+	timeout  time.Duration
+}
+
+/**
+ * NewLiveNotifier creates a new WebSocket notifier.
+ *
+ * @param matchDay Service deciding which matches are in match-day mode
+ * @param cache Analytics cache used to fetch summaries for match-day matches
+ * @param live Broadcaster for WebSocket clients
+ * @return A new live notifier
+ */
+func NewLiveNotifier(matchDay *MatchDayService, cache *AnalyticsCache, live LiveBroadcaster) *LiveNotifier {
+	return &LiveNotifier{matchDay: matchDay, cache: cache, live: live, timeout: 30 * time.Second}
+}
+
+/**
+ * HandleEvent relays lifecycle events to WebSocket clients. Fetching the
+ * summary for match-day matches happens in the background so publishers are
+ * not blocked.
+ *
+ * @param event The published event
+ */
+func (n *LiveNotifier) HandleEvent(event events.Event) {
+	if !lifecycleEventTypes[event.Type] && event.Type != events.VideoDeleted {
+		return
+	}
+	videoID, _ := event.Data["video_id"].(string)
+	if videoID == "" {
+		return
+	}
+
+	matchDay := n.matchDay.IsActive(videoID)
+	if !matchDay || event.Type != events.AnalyticsCompleted {
+		n.live.Broadcast(newLiveMessage(event.Type, videoID, matchDay, event.Data))
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), n.timeout)
+		defer cancel()
+
+		data := make(map[string]interface{}, len(event.Data)+1)
+		for key, value := range event.Data {
+			data[key] = value
+		}
+		if summary, _, err := n.cache.RefreshMatchSummary(ctx, videoID); err != nil {
+			log.Printf("Live updates: failed to fetch summary for match %s: %v", videoID, err)
+		} else {
+			data["summary"] = summary
+		}
+		n.live.Broadcast(newLiveMessage(event.Type, videoID, true, data))
+	}()
+}
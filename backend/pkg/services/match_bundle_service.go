@@ -0,0 +1,434 @@
+package services
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"time"
+
+	"nivai/backend/pkg/models"
+)
+
+// Match bundle format
+const (
+	MatchBundleFormat  = "nivai-match-bundle"
+	MatchBundleVersion = 1
+)
+
+// Entries of a match bundle; files are stored under bundleFilesDir
+const (
+	bundleManifestEntry  = "manifest.json"
+	bundleMatchEntry     = "match.json"
+	bundleAnalyticsEntry = "analytics/summary.json"
+	bundleFilesDir       = "files/"
+)
+
+var (
+	// ErrBundleUnavailable is returned when exporting a match whose files
+	// cannot be read, because they are archived or quarantined.
+	ErrBundleUnavailable = errors.New("match files cannot be exported in its current state")
+	// ErrInvalidBundle is returned when an imported bundle is malformed or
+	// its files do not match its manifest.
+	ErrInvalidBundle = errors.New("invalid match bundle")
+)
+
+/**
+ * MatchBundleFile describes one stored file of a match in a bundle.
+ */
+type MatchBundleFile struct {
+	Kind     string `json:"kind"` // "video", "tracking" or "events"
+	Name     string `json:"name"` // Entry name under files/
+	Size     int64  `json:"size"`
+	Checksum string `json:"checksum,omitempty"` // Hex-encoded SHA-256; empty when never recorded
+}
+
+/**
+ * MatchBundleManifest is the first entry of a match bundle and lists what
+ * it contains.
+ */
+type MatchBundleManifest struct {
+	Format     string            `json:"format"`
+	Version    int               `json:"version"`
+	ExportedAt time.Time         `json:"exported_at"`
+	VideoID    string            `json:"video_id"` // ID of the match in the exporting environment
+	Files      []MatchBundleFile `json:"files"`
+	Analytics  bool              `json:"analytics"` // Whether the analytics summary is included
+}
+
+/**
+ * MatchExport is a match ready to be written as a bundle with WriteTarGz.
+ */
+type MatchExport struct {
+	Manifest  MatchBundleManifest
+	Video     *models.Video
+	Analytics json.RawMessage // Analytics summary; nil when not included
+
+	paths   map[string]string // Storage path by file kind
+	storage StorageService
+}
+
+/**
+ * ImportedBundle is the content of an imported bundle, whose files have
+ * been stored.
+ */
+type ImportedBundle struct {
+	Manifest  MatchBundleManifest
+	Video     *models.Video // Metadata as exported; IDs and paths are the exporting environment's
+	Files     []*models.VideoFile
+	Analytics json.RawMessage
+}
+
+/**
+ * BundleFileStore stores one file of an imported bundle, reading it from r,
+ * and returns where it was stored with its size and checksum.
+ */
+type BundleFileStore func(ctx context.Context, file MatchBundleFile, r io.Reader) (*models.VideoFile, error)
+
+/**
+ * MatchBundleService exports a match, with its stored files and optionally
+ * its analytics summary, as a tar.gz bundle, and reads such bundles back,
+ * for moving matches between environments or offline backups.
+ */
+type MatchBundleService struct {
+	videoRepo models.VideoRepository
+	fileRepo  models.VideoFileRepository
+	snapshots models.AnalyticsSnapshotRepository
+	storage   StorageService
+	now       func() time.Time
+}
+
+/**
+ * NewMatchBundleService creates a new match bundle service.
+ *
+ * @param videoRepo Repository for video data
+ * @param fileRepo Repository for stored file records, for sizes and checksums; may be nil
+ * @param snapshots Repository for analytics snapshots; nil exports no analytics
+ * @param storage Storage holding the match files
+ * @return A new match bundle service
+ */
+func NewMatchBundleService(videoRepo models.VideoRepository, fileRepo models.VideoFileRepository, snapshots models.AnalyticsSnapshotRepository, storage StorageService) *MatchBundleService {
+	return &MatchBundleService{videoRepo: videoRepo, fileRepo: fileRepo, snapshots: snapshots, storage: storage, now: time.Now}
+}
+
+/**
+ * Export collects what a match's bundle contains. Nothing is read from
+ * storage until the bundle is written, so errors here can still be
+ * answered before the download starts.
+ *
+ * @param videoID The match's video ID
+ * @param includeAnalytics Whether to include the stored analytics summary
+ * @return The export, or ErrVideoNotFound or ErrBundleUnavailable
+ */
+func (s *MatchBundleService) Export(videoID string, includeAnalytics bool) (*MatchExport, error) {
+	video, err := s.videoRepo.FindByID(videoID)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, ErrVideoNotFound
+		}
+		return nil, err
+	}
+	switch video.ProcessingState {
+	case models.StateArchived, models.StateRestoring, models.StateRejected:
+		return nil, fmt.Errorf("%w: match is %q", ErrBundleUnavailable, video.ProcessingState)
+	}
+
+	recorded := map[string]*models.VideoFile{}
+	if s.fileRepo != nil {
+		files, err := s.fileRepo.FindByVideoID(videoID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file records: %w", err)
+		}
+		for _, f := range files {
+			recorded[f.Kind] = f
+		}
+	}
+
+	export := &MatchExport{
+		Manifest: MatchBundleManifest{
+			Format: MatchBundleFormat, Version: MatchBundleVersion, ExportedAt: s.now().UTC(), VideoID: videoID,
+		},
+		Video:   video,
+		paths:   map[string]string{},
+		storage: s.storage,
+	}
+	for _, kind := range []string{models.FileKindVideo, models.FileKindTracking, models.FileKindEvents} {
+		p := matchFilePath(video, kind)
+		if p == "" {
+			continue
+		}
+		file := MatchBundleFile{Kind: kind, Name: path.Base(p)}
+		if record, ok := recorded[kind]; ok && record.Path == p {
+			file.Size, file.Checksum = record.Size, record.Checksum
+		} else if file.Size, err = s.storedSize(p); err != nil {
+			return nil, err
+		}
+		export.Manifest.Files = append(export.Manifest.Files, file)
+		export.paths[kind] = p
+	}
+
+	if includeAnalytics && s.snapshots != nil {
+		snapshot, err := s.snapshots.Find(videoID, models.SnapshotKindSummary)
+		switch {
+		case err == nil:
+			export.Analytics = snapshot.Payload
+			export.Manifest.Analytics = true
+		case !isNotFound(err):
+			return nil, fmt.Errorf("failed to read analytics snapshot: %w", err)
+		}
+	}
+	return export, nil
+}
+
+// storedSize looks up the size of a stored file without a record.
+func (s *MatchBundleService) storedSize(p string) (int64, error) {
+	files, err := s.storage.ListFiles(p)
+	if err != nil {
+		return 0, fmt.Errorf("failed to look up %s: %w", p, err)
+	}
+	for _, f := range files {
+		if f.Path == p {
+			return f.Size, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: %s is missing from storage", ErrBundleUnavailable, p)
+}
+
+// matchFilePath returns the storage path of a match's file of a kind.
+func matchFilePath(video *models.Video, kind string) string {
+	switch kind {
+	case models.FileKindVideo:
+		return video.FilePath
+	case models.FileKindTracking:
+		return video.TrackingPath
+	case models.FileKindEvents:
+		return video.EventFilePath
+	}
+	return ""
+}
+
+/**
+ * Filename returns the download file name of the bundle.
+ */
+func (e *MatchExport) Filename() string {
+	return fmt.Sprintf("match-%s.tar.gz", e.Manifest.VideoID)
+}
+
+/**
+ * WriteTarGz writes the bundle: the manifest, the match metadata, the
+ * stored files and the analytics summary, in that order.
+ *
+ * @param ctx Context for reading the files
+ * @param w Writer receiving the tar.gz stream
+ * @return An error if an entry cannot be read or written
+ */
+func (e *MatchExport) WriteTarGz(ctx context.Context, w io.Writer) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	modTime := e.Manifest.ExportedAt
+
+	for _, entry := range []struct {
+		name  string
+		value interface{}
+	}{
+		{bundleManifestEntry, e.Manifest},
+		{bundleMatchEntry, e.Video},
+	} {
+		if err := writeTarJSON(tw, entry.name, entry.value, modTime); err != nil {
+			return err
+		}
+	}
+
+	for _, file := range e.Manifest.Files {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := e.writeFile(tw, file, modTime); err != nil {
+			return err
+		}
+	}
+
+	if e.Analytics != nil {
+		if err := writeTarEntry(tw, bundleAnalyticsEntry, e.Analytics, modTime); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// writeFile copies one stored file into the bundle.
+func (e *MatchExport) writeFile(tw *tar.Writer, file MatchBundleFile, modTime time.Time) error {
+	src, err := e.storage.GetFile(e.paths[file.Kind])
+	if err != nil {
+		return fmt.Errorf("failed to read %s file: %w", file.Kind, err)
+	}
+	defer src.Close()
+
+	if err := tw.WriteHeader(&tar.Header{Name: bundleFilesDir + file.Name, Mode: 0o644, Size: file.Size, ModTime: modTime}); err != nil {
+		return err
+	}
+	if _, err := io.CopyN(tw, src, file.Size); err != nil {
+		return fmt.Errorf("failed to copy %s file: %w", file.Kind, err)
+	}
+	return nil
+}
+
+// writeTarJSON writes value as an indented JSON entry.
+func writeTarJSON(tw *tar.Writer, name string, value interface{}, modTime time.Time) error {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeTarEntry(tw, name, data, modTime)
+}
+
+// writeTarEntry writes an entry held in memory.
+func writeTarEntry(tw *tar.Writer, name string, data []byte, modTime time.Time) error {
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(data)), ModTime: modTime}); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
+
+/**
+ * Import reads a bundle, handing each file to store as it is read. The
+ * manifest and match metadata must come first, as Export writes them. A
+ * file whose size or checksum differs from the manifest fails the import;
+ * the files already stored are returned with the error, for the caller to
+ * remove.
+ *
+ * @param ctx Context for storing the files
+ * @param r The tar.gz stream
+ * @param store Stores each file
+ * @return The bundle's content, or ErrInvalidBundle (wrapping the error
+ *         reading r, if any) or store's error
+ */
+func (s *MatchBundleService) Import(ctx context.Context, r io.Reader, store BundleFileStore) (*ImportedBundle, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: not gzip-compressed: %w", ErrInvalidBundle, err)
+	}
+	tr := tar.NewReader(gz)
+	bundle := &ImportedBundle{}
+
+	var manifestRead bool
+	expected := map[string]MatchBundleFile{} // By entry name
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return bundle, fmt.Errorf("%w: %w", ErrInvalidBundle, err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		switch name := header.Name; {
+		case name == bundleManifestEntry:
+			if err := readTarJSON(tr, &bundle.Manifest); err != nil {
+				return bundle, err
+			}
+			if bundle.Manifest.Format != MatchBundleFormat || bundle.Manifest.Version != MatchBundleVersion {
+				return bundle, fmt.Errorf("%w: unsupported format %q version %d", ErrInvalidBundle, bundle.Manifest.Format, bundle.Manifest.Version)
+			}
+			for _, file := range bundle.Manifest.Files {
+				expected[bundleFilesDir+file.Name] = file
+			}
+			manifestRead = true
+		case !manifestRead:
+			return bundle, fmt.Errorf("%w: %s must be the first entry", ErrInvalidBundle, bundleManifestEntry)
+		case name == bundleMatchEntry:
+			bundle.Video = &models.Video{}
+			if err := readTarJSON(tr, bundle.Video); err != nil {
+				return bundle, err
+			}
+		case name == bundleAnalyticsEntry:
+			var payload json.RawMessage
+			if err := readTarJSON(tr, &payload); err != nil {
+				return bundle, err
+			}
+			bundle.Analytics = payload
+		default:
+			file, ok := expected[name]
+			if !ok {
+				return bundle, fmt.Errorf("%w: unexpected entry %s", ErrInvalidBundle, name)
+			}
+			delete(expected, name)
+			stored, err := s.importFile(ctx, tr, file, store)
+			if stored != nil {
+				bundle.Files = append(bundle.Files, stored)
+			}
+			if err != nil {
+				return bundle, err
+			}
+		}
+	}
+
+	switch {
+	case !manifestRead:
+		return bundle, fmt.Errorf("%w: no %s", ErrInvalidBundle, bundleManifestEntry)
+	case bundle.Video == nil:
+		return bundle, fmt.Errorf("%w: no %s", ErrInvalidBundle, bundleMatchEntry)
+	case len(expected) > 0:
+		return bundle, fmt.Errorf("%w: %d files listed in the manifest are missing", ErrInvalidBundle, len(expected))
+	}
+	return bundle, nil
+}
+
+// importFile stores one file of a bundle, checking it against the manifest.
+func (s *MatchBundleService) importFile(ctx context.Context, r io.Reader, file MatchBundleFile, store BundleFileStore) (*models.VideoFile, error) {
+	hash := sha256.New()
+	counted := &countingReader{r: io.TeeReader(r, hash)}
+	stored, err := store(ctx, file, counted)
+	if err != nil {
+		return stored, err
+	}
+	// Drain what store left unread, so the checks cover the whole entry
+	if _, err := io.Copy(io.Discard, counted); err != nil {
+		return stored, fmt.Errorf("%w: %v", ErrInvalidBundle, err)
+	}
+	if counted.n != file.Size {
+		return stored, fmt.Errorf("%w: %s file is %d bytes, manifest says %d", ErrInvalidBundle, file.Kind, counted.n, file.Size)
+	}
+	if sum := hex.EncodeToString(hash.Sum(nil)); file.Checksum != "" && sum != file.Checksum {
+		return stored, fmt.Errorf("%w: %s file checksum mismatch", ErrInvalidBundle, file.Kind)
+	}
+	return stored, nil
+}
+
+// readTarJSON decodes a JSON entry.
+func readTarJSON(r io.Reader, value interface{}) error {
+	if err := json.NewDecoder(r).Decode(value); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidBundle, err)
+	}
+	return nil
+}
+
+/**
+ * SaveAnalytics stores an imported bundle's analytics summary as the
+ * snapshot of the match it was imported as.
+ *
+ * @param videoID The imported match's video ID
+ * @param payload The analytics summary
+ * @return An error if the snapshot cannot be saved
+ */
+func (s *MatchBundleService) SaveAnalytics(videoID string, payload json.RawMessage) error {
+	if s.snapshots == nil || payload == nil {
+		return nil
+	}
+	return s.snapshots.Save(&models.AnalyticsSnapshot{
+		VideoID: videoID, Kind: models.SnapshotKindSummary, Payload: payload, FetchedAt: s.now(),
+	})
+}
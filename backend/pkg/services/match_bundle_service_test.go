@@ -0,0 +1,137 @@
+package services_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchBundleService(t *testing.T) {
+	setup := func(t *testing.T) (*services.MatchBundleService, *models.MemoryVideoRepository, *models.MemoryAnalyticsSnapshotRepository) {
+		videoRepo := models.NewMemoryVideoRepository()
+		snapshots := models.NewMemoryAnalyticsSnapshotRepository()
+		storage := services.NewMemoryStorageService()
+		_, err := storage.UploadStream(strings.NewReader("tracking rows"), "videos/v1/tracking.parquet")
+		require.NoError(t, err)
+		_, err = storage.UploadStream(strings.NewReader("event rows"), "videos/v1/events.csv")
+		require.NoError(t, err)
+		require.NoError(t, videoRepo.Create(&models.Video{
+			ID: "v1", Title: "Ajax - PSV", HomeTeam: "Ajax", AwayTeam: "PSV", ProcessingState: models.StateCompleted,
+			TrackingPath: "videos/v1/tracking.parquet", EventFilePath: "videos/v1/events.csv",
+		}))
+		require.NoError(t, snapshots.Save(&models.AnalyticsSnapshot{VideoID: "v1", Kind: models.SnapshotKindSummary, Payload: json.RawMessage(`{"goals":3}`)}))
+		return services.NewMatchBundleService(videoRepo, nil, snapshots, storage), videoRepo, snapshots
+	}
+
+	// collect stores imported files in memory by kind.
+	collect := func(stored map[string]string) services.BundleFileStore {
+		return func(ctx context.Context, file services.MatchBundleFile, r io.Reader) (*models.VideoFile, error) {
+			data, err := io.ReadAll(r)
+			if err != nil {
+				return nil, err
+			}
+			stored[file.Kind] = string(data)
+			return &models.VideoFile{Kind: file.Kind, Path: "imported/" + file.Name, Size: int64(len(data))}, nil
+		}
+	}
+
+	export := func(t *testing.T, svc *services.MatchBundleService, includeAnalytics bool) *bytes.Buffer {
+		e, err := svc.Export("v1", includeAnalytics)
+		require.NoError(t, err)
+		var buf bytes.Buffer
+		require.NoError(t, e.WriteTarGz(context.Background(), &buf))
+		return &buf
+	}
+
+	t.Run("Round trip", func(t *testing.T) {
+		svc, _, _ := setup(t)
+		bundle := export(t, svc, true)
+
+		stored := map[string]string{}
+		imported, err := svc.Import(context.Background(), bundle, collect(stored))
+
+		require.NoError(t, err)
+		assert.Equal(t, "v1", imported.Manifest.VideoID)
+		assert.True(t, imported.Manifest.Analytics)
+		assert.Equal(t, "Ajax - PSV", imported.Video.Title)
+		assert.Equal(t, map[string]string{models.FileKindTracking: "tracking rows", models.FileKindEvents: "event rows"}, stored)
+		assert.Len(t, imported.Files, 2)
+		assert.JSONEq(t, `{"goals":3}`, string(imported.Analytics))
+	})
+
+	t.Run("Analytics are left out unless asked for", func(t *testing.T) {
+		svc, _, _ := setup(t)
+
+		imported, err := svc.Import(context.Background(), export(t, svc, false), collect(map[string]string{}))
+
+		require.NoError(t, err)
+		assert.False(t, imported.Manifest.Analytics)
+		assert.Nil(t, imported.Analytics)
+	})
+
+	t.Run("Archived matches cannot be exported", func(t *testing.T) {
+		svc, videoRepo, _ := setup(t)
+		video, _ := videoRepo.FindByID("v1")
+		require.NoError(t, videoRepo.UpdateProcessingState("v1", video.ProcessingState, models.StateArchived, time.Now()))
+
+		_, err := svc.Export("v1", false)
+
+		assert.ErrorIs(t, err, services.ErrBundleUnavailable)
+		_, err = svc.Export("missing", false)
+		assert.ErrorIs(t, err, services.ErrVideoNotFound)
+	})
+
+	t.Run("Tampered files are rejected", func(t *testing.T) {
+		svc, _, _ := setup(t)
+		e, err := svc.Export("v1", false)
+		require.NoError(t, err)
+		e.Manifest.Files[0].Checksum = strings.Repeat("0", 64)
+		var buf bytes.Buffer
+		require.NoError(t, e.WriteTarGz(context.Background(), &buf))
+
+		imported, err := svc.Import(context.Background(), &buf, collect(map[string]string{}))
+
+		assert.ErrorIs(t, err, services.ErrInvalidBundle)
+		assert.Len(t, imported.Files, 1, "stored files are returned for cleanup")
+	})
+
+	t.Run("The manifest must come first", func(t *testing.T) {
+		svc, _, _ := setup(t)
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		tw := tar.NewWriter(gz)
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: "match.json", Mode: 0o644, Size: 2}))
+		_, err := tw.Write([]byte("{}"))
+		require.NoError(t, err)
+		require.NoError(t, tw.Close())
+		require.NoError(t, gz.Close())
+
+		_, err = svc.Import(context.Background(), &buf, collect(map[string]string{}))
+
+		assert.ErrorIs(t, err, services.ErrInvalidBundle)
+		_, err = svc.Import(context.Background(), strings.NewReader("not a bundle"), collect(map[string]string{}))
+		assert.ErrorIs(t, err, services.ErrInvalidBundle)
+	})
+
+	t.Run("Imported analytics are saved under the new match", func(t *testing.T) {
+		svc, _, snapshots := setup(t)
+
+		require.NoError(t, svc.SaveAnalytics("v2", json.RawMessage(`{"goals":1}`)))
+
+		snapshot, err := snapshots.Find("v2", models.SnapshotKindSummary)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"goals":1}`, string(snapshot.Payload))
+	})
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"slices"
+	"time"
+
+	"nivai/backend/pkg/models"
+)
+
+/**
+ * CalendarGroup is a week or month of the match calendar, with its matches
+ * counted by analytics status as well as by processing state.
+ */
+type CalendarGroup struct {
+	*models.MatchCalendarGroup
+	End               time.Time      `json:"end"`                // Start of the next period
+	AnalyticsStatuses map[string]int `json:"analytics_statuses"` // By pending, processed, error and cancelled
+}
+
+/**
+ * MatchCalendarService groups matches by the week or month they were
+ * played in, for the schedule view of the dashboard.
+ */
+type MatchCalendarService struct {
+	repo models.MatchCalendarRepository
+}
+
+/**
+ * NewMatchCalendarService creates a new match calendar service.
+ *
+ * @param repo Repository grouping the matches
+ * @return A new match calendar service
+ */
+func NewMatchCalendarService(repo models.MatchCalendarRepository) *MatchCalendarService {
+	return &MatchCalendarService{repo: repo}
+}
+
+/**
+ * Calendar groups the matches with a match date by week or month. Rejected
+ * matches have no analytics status and are only counted by state.
+ *
+ * @param access The matches the caller was granted; nil groups all matches
+ * @param filters Filters as accepted by ListVideos; sorting is ignored
+ * @param period models.CalendarByWeek or models.CalendarByMonth
+ * @return The periods with matches, earliest first, or an error
+ */
+func (s *MatchCalendarService) Calendar(access *models.AccessScope, filters map[string]string, period string) ([]*CalendarGroup, error) {
+	query, err := VideoQueryFromFilters(filters)
+	if err != nil {
+		return nil, err
+	}
+	query.Access = access
+
+	groups, err := s.repo.Summarize(query, period)
+	if err != nil {
+		return nil, err
+	}
+
+	calendar := make([]*CalendarGroup, 0, len(groups))
+	for _, group := range groups {
+		entry := &CalendarGroup{MatchCalendarGroup: group, AnalyticsStatuses: map[string]int{}}
+		if period == models.CalendarByMonth {
+			entry.End = group.Start.AddDate(0, 1, 0)
+		} else {
+			entry.End = group.Start.AddDate(0, 0, 7)
+		}
+		for state, count := range group.States {
+			for status, states := range analyticsStatusStates {
+				if slices.Contains(states, state) {
+					entry.AnalyticsStatuses[status] += count
+				}
+			}
+		}
+		calendar = append(calendar, entry)
+	}
+	return calendar, nil
+}
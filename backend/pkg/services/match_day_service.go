@@ -0,0 +1,351 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/pythonapi"
+)
+
+// ErrInvalidMatchDayMode is returned for modes other than auto, on and off.
+var ErrInvalidMatchDayMode = errors.New("invalid match-day mode")
+
+/**
+ * MatchDayConfig controls when match-day mode is active and how fresh
+ * analytics are kept.
+ */
+type MatchDayConfig struct {
+	Lead             time.Duration // Activate this long before kickoff
+	Duration         time.Duration // Stay active this long after kickoff
+	CacheTTL         time.Duration // Analytics freshness outside match-day mode
+	MatchDayCacheTTL time.Duration // Analytics freshness in match-day mode
+}
+
+/**
+ * MatchDayStatus describes the match-day mode of a match.
+ */
+type MatchDayStatus struct {
+	MatchID      string     `json:"match_id"`
+	Mode         string     `json:"mode"`
+	KickoffAt    *time.Time `json:"kickoff_at,omitempty"`
+	WindowStart  *time.Time `json:"window_start,omitempty"`
+	WindowEnd    *time.Time `json:"window_end,omitempty"`
+	Active       bool       `json:"active"`
+	CacheTTLSecs int        `json:"cache_ttl_seconds"`
+}
+
+/**
+ * MatchDayService decides which matches are in match-day mode. A match is
+ * active when switched on manually, or in mode "auto" from Lead before its
+ * kickoff until Duration after it. Scheduled matches are kept in memory and
+ * reloaded by Refresh, so IsActive is cheap enough for every request.
+ */
+type MatchDayService struct {
+	repo models.MatchDayRepository
+	cfg  MatchDayConfig
+	now  func() time.Time
+
+	mu        sync.RWMutex
+	scheduled map[string]*models.MatchDaySetting
+}
+
+/**
+ * NewMatchDayService creates a new match-day service.
+ *
+ * @param repo Repository for match-day settings
+ * @param cfg Activation window and cache freshness settings
+ * @return A new match-day service
+ */
+func NewMatchDayService(repo models.MatchDayRepository, cfg MatchDayConfig) *MatchDayService {
+	if cfg.CacheTTL <= 0 {
+		cfg.CacheTTL = 5 * time.Minute
+	}
+	if cfg.MatchDayCacheTTL <= 0 {
+		cfg.MatchDayCacheTTL = 10 * time.Second
+	}
+	return &MatchDayService{
+		repo:      repo,
+		cfg:       cfg,
+		now:       time.Now,
+		scheduled: make(map[string]*models.MatchDaySetting),
+	}
+}
+
+/**
+ * Refresh reloads the scheduled and manually activated matches.
+ *
+ * @return Error if the settings cannot be loaded
+ */
+func (s *MatchDayService) Refresh() error {
+	settings, err := s.repo.FindScheduled()
+	if err != nil {
+		return err
+	}
+
+	scheduled := make(map[string]*models.MatchDaySetting, len(settings))
+	for _, setting := range settings {
+		scheduled[setting.VideoID] = setting
+	}
+
+	s.mu.Lock()
+	s.scheduled = scheduled
+	s.mu.Unlock()
+	return nil
+}
+
+/**
+ * Status returns the match-day mode of a match.
+ *
+ * @param matchID The video/match ID
+ * @return The match-day status, or an error
+ */
+func (s *MatchDayService) Status(matchID string) (*MatchDayStatus, error) {
+	setting, err := s.repo.Find(matchID)
+	if err != nil {
+		if !isNotFound(err) {
+			return nil, err
+		}
+		setting = &models.MatchDaySetting{VideoID: matchID, Mode: models.MatchDayModeAuto}
+	}
+	return s.statusFor(setting), nil
+}
+
+/**
+ * Update sets the mode and kickoff time of a match, replacing the previous
+ * settings.
+ *
+ * @param matchID The video/match ID
+ * @param mode One of auto, on or off
+ * @param kickoffAt The kickoff time, or nil if unknown
+ * @return The new match-day status, or an error
+ */
+func (s *MatchDayService) Update(matchID, mode string, kickoffAt *time.Time) (*MatchDayStatus, error) {
+	switch mode {
+	case models.MatchDayModeAuto, models.MatchDayModeOn, models.MatchDayModeOff:
+	default:
+		return nil, ErrInvalidMatchDayMode
+	}
+
+	setting := &models.MatchDaySetting{VideoID: matchID, Mode: mode, UpdatedAt: s.now()}
+	if kickoffAt != nil {
+		setting.KickoffAt = sql.NullTime{Time: kickoffAt.UTC(), Valid: true}
+	}
+	if err := s.repo.Save(setting); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	if mode == models.MatchDayModeOn || setting.KickoffAt.Valid {
+		s.scheduled[matchID] = setting
+	} else {
+		delete(s.scheduled, matchID)
+	}
+	s.mu.Unlock()
+
+	return s.statusFor(setting), nil
+}
+
+/**
+ * IsActive reports whether a match is currently in match-day mode.
+ *
+ * @param matchID The video/match ID
+ * @return Whether match-day mode is active
+ */
+func (s *MatchDayService) IsActive(matchID string) bool {
+	s.mu.RLock()
+	setting, ok := s.scheduled[matchID]
+	s.mu.RUnlock()
+	return ok && s.isActive(setting, s.now())
+}
+
+/**
+ * ActiveMatches lists the matches currently in match-day mode.
+ *
+ * @return Sorted match IDs
+ */
+func (s *MatchDayService) ActiveMatches() []string {
+	now := s.now()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var active []string
+	for matchID, setting := range s.scheduled {
+		if s.isActive(setting, now) {
+			active = append(active, matchID)
+		}
+	}
+	sort.Strings(active)
+	return active
+}
+
+/**
+ * CacheTTL is the analytics freshness for a match; it implements TTLPolicy.
+ *
+ * @param matchID The video/match ID
+ * @return How long cached analytics stay fresh
+ */
+func (s *MatchDayService) CacheTTL(matchID string) time.Duration {
+	if s.IsActive(matchID) {
+		return s.cfg.MatchDayCacheTTL
+	}
+	return s.cfg.CacheTTL
+}
+
+/**
+ * Priority is the processing priority for a match's analytics jobs.
+ *
+ * @param matchID The video/match ID
+ * @return pythonapi.PriorityHigh in match-day mode, otherwise PriorityNormal
+ */
+func (s *MatchDayService) Priority(matchID string) string {
+	if s.IsActive(matchID) {
+		return pythonapi.PriorityHigh
+	}
+	return pythonapi.PriorityNormal
+}
+
+// isActive evaluates a setting at the given time.
+func (s *MatchDayService) isActive(setting *models.MatchDaySetting, now time.Time) bool {
+	switch setting.Mode {
+	case models.MatchDayModeOn:
+		return true
+	case models.MatchDayModeOff:
+		return false
+	}
+	if !setting.KickoffAt.Valid {
+		return false
+	}
+	kickoff := setting.KickoffAt.Time
+	return !now.Before(kickoff.Add(-s.cfg.Lead)) && now.Before(kickoff.Add(s.cfg.Duration))
+}
+
+// statusFor builds the status report for a setting.
+func (s *MatchDayService) statusFor(setting *models.MatchDaySetting) *MatchDayStatus {
+	active := s.isActive(setting, s.now())
+	status := &MatchDayStatus{
+		MatchID:      setting.VideoID,
+		Mode:         setting.Mode,
+		Active:       active,
+		CacheTTLSecs: int(s.cfg.CacheTTL / time.Second),
+	}
+	if active {
+		status.CacheTTLSecs = int(s.cfg.MatchDayCacheTTL / time.Second)
+	}
+	if setting.KickoffAt.Valid {
+		kickoff := setting.KickoffAt.Time
+		start, end := kickoff.Add(-s.cfg.Lead), kickoff.Add(s.cfg.Duration)
+		status.KickoffAt, status.WindowStart, status.WindowEnd = &kickoff, &start, &end
+	}
+	return status
+}
+
+/**
+ * MatchDayWarmer keeps analytics of matches in match-day mode warm. On each
+ * tick it polls their summaries as partial results arrive, refreshes the
+ * cache and pushes changed results to WebSocket clients, and announces
+ * matches entering or leaving match-day mode.
+ */
+type MatchDayWarmer struct {
+	matchDay *MatchDayService
+	cache    *AnalyticsCache
+	live     LiveBroadcaster
+	interval time.Duration
+
+	active map[string]bool // Matches active on the previous tick
+}
+
+/**
+ * NewMatchDayWarmer creates a new match-day cache warmer.
+ *
+ * @param matchDay Service deciding which matches are active
+ * @param cache Analytics cache to warm
+ * @param live Broadcaster for WebSocket clients
+ * @param interval How often to poll (defaults to 15s)
+ * @return A new match-day warmer
+ */
+func NewMatchDayWarmer(matchDay *MatchDayService, cache *AnalyticsCache, live LiveBroadcaster, interval time.Duration) *MatchDayWarmer {
+	if interval <= 0 {
+		interval = 15 * time.Second
+	}
+	return &MatchDayWarmer{
+		matchDay: matchDay,
+		cache:    cache,
+		live:     live,
+		interval: interval,
+		active:   make(map[string]bool),
+	}
+}
+
+/**
+ * Run warms the cache until ctx is cancelled.
+ *
+ * @param ctx Context controlling the warmer's lifetime
+ */
+func (w *MatchDayWarmer) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	w.Tick(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.Tick(ctx)
+		}
+	}
+}
+
+/**
+ * Tick performs a single warming pass. It is not safe for concurrent use.
+ *
+ * @param ctx Context for upstream requests
+ */
+func (w *MatchDayWarmer) Tick(ctx context.Context) {
+	if err := w.matchDay.Refresh(); err != nil {
+		log.Printf("Match day: failed to load settings: %v", err)
+		return
+	}
+
+	current := make(map[string]bool)
+	for _, matchID := range w.matchDay.ActiveMatches() {
+		current[matchID] = true
+		if !w.active[matchID] {
+			w.live.Broadcast(newLiveMessage(LiveMatchDayStarted, matchID, true, nil))
+		}
+		w.warm(ctx, matchID)
+	}
+	for matchID := range w.active {
+		if !current[matchID] {
+			w.live.Broadcast(newLiveMessage(LiveMatchDayEnded, matchID, false, nil))
+		}
+	}
+	w.active = current
+}
+
+// warm refreshes one match's summary and pushes it when it changed.
+// Matches without any results yet are skipped silently.
+func (w *MatchDayWarmer) warm(ctx context.Context, matchID string) {
+	ctx, cancel := context.WithTimeout(ctx, w.interval)
+	defer cancel()
+
+	summary, changed, err := w.cache.RefreshMatchSummary(ctx, matchID)
+	if err != nil {
+		if !errors.Is(err, pythonapi.ErrNotFound) {
+			log.Printf("Match day: failed to refresh analytics for match %s: %v", matchID, err)
+		}
+		return
+	}
+	if changed {
+		w.live.Broadcast(newLiveMessage(LiveAnalyticsUpdated, matchID, true, map[string]interface{}{
+			"summary": summary,
+		}))
+	}
+}
@@ -0,0 +1,201 @@
+package services_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"nivai/backend/pkg/events"
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/pythonapi"
+	"nivai/backend/pkg/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// --- MockMatchDayRepository ---
+type MockMatchDayRepository struct {
+	mock.Mock
+}
+
+func (m *MockMatchDayRepository) Save(setting *models.MatchDaySetting) error {
+	args := m.Called(setting)
+	return args.Error(0)
+}
+func (m *MockMatchDayRepository) Find(videoID string) (*models.MatchDaySetting, error) {
+	args := m.Called(videoID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.MatchDaySetting), args.Error(1)
+}
+func (m *MockMatchDayRepository) FindScheduled() ([]*models.MatchDaySetting, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.MatchDaySetting), args.Error(1)
+}
+
+// recordingBroadcaster collects WebSocket messages.
+type recordingBroadcaster struct {
+	messages []services.LiveMessage
+}
+
+func (b *recordingBroadcaster) Broadcast(message []byte) {
+	var decoded services.LiveMessage
+	if err := json.Unmarshal(message, &decoded); err == nil {
+		b.messages = append(b.messages, decoded)
+	}
+}
+
+var matchDayConfig = services.MatchDayConfig{
+	Lead:             time.Hour,
+	Duration:         3 * time.Hour,
+	CacheTTL:         5 * time.Minute,
+	MatchDayCacheTTL: 10 * time.Second,
+}
+
+func kickoffIn(d time.Duration) sql.NullTime {
+	return sql.NullTime{Time: time.Now().Add(d), Valid: true}
+}
+
+func TestMatchDayService(t *testing.T) {
+	t.Run("Activation follows mode and kickoff window", func(t *testing.T) {
+		repo := new(MockMatchDayRepository)
+		repo.On("FindScheduled").Return([]*models.MatchDaySetting{
+			{VideoID: "soon", Mode: models.MatchDayModeAuto, KickoffAt: kickoffIn(30 * time.Minute)},
+			{VideoID: "later", Mode: models.MatchDayModeAuto, KickoffAt: kickoffIn(2 * time.Hour)},
+			{VideoID: "over", Mode: models.MatchDayModeAuto, KickoffAt: kickoffIn(-4 * time.Hour)},
+			{VideoID: "forced", Mode: models.MatchDayModeOn},
+			{VideoID: "disabled", Mode: models.MatchDayModeOff, KickoffAt: kickoffIn(0)},
+		}, nil).Once()
+
+		svc := services.NewMatchDayService(repo, matchDayConfig)
+		require.NoError(t, svc.Refresh())
+
+		assert.Equal(t, []string{"forced", "soon"}, svc.ActiveMatches())
+		assert.Equal(t, 10*time.Second, svc.CacheTTL("soon"))
+		assert.Equal(t, 5*time.Minute, svc.CacheTTL("later"))
+		assert.Equal(t, pythonapi.PriorityHigh, svc.Priority("forced"))
+		assert.Equal(t, pythonapi.PriorityNormal, svc.Priority("unknown"))
+	})
+
+	t.Run("Status defaults to auto without settings", func(t *testing.T) {
+		repo := new(MockMatchDayRepository)
+		repo.On("Find", "m1").Return(nil, errors.New("match-day setting not found")).Once()
+
+		status, err := services.NewMatchDayService(repo, matchDayConfig).Status("m1")
+		require.NoError(t, err)
+		assert.Equal(t, models.MatchDayModeAuto, status.Mode)
+		assert.False(t, status.Active)
+		assert.Nil(t, status.KickoffAt)
+		assert.Equal(t, 300, status.CacheTTLSecs)
+	})
+
+	t.Run("Update validates mode and activates immediately", func(t *testing.T) {
+		repo := new(MockMatchDayRepository)
+		svc := services.NewMatchDayService(repo, matchDayConfig)
+
+		_, err := svc.Update("m1", "always", nil)
+		assert.ErrorIs(t, err, services.ErrInvalidMatchDayMode)
+
+		kickoff := time.Now().Add(10 * time.Minute)
+		repo.On("Save", mock.MatchedBy(func(s *models.MatchDaySetting) bool {
+			return s.VideoID == "m1" && s.Mode == models.MatchDayModeAuto && s.KickoffAt.Valid
+		})).Return(nil).Once()
+
+		status, err := svc.Update("m1", models.MatchDayModeAuto, &kickoff)
+		require.NoError(t, err)
+		assert.True(t, status.Active)
+		assert.Equal(t, 10, status.CacheTTLSecs)
+		require.NotNil(t, status.WindowEnd)
+		assert.WithinDuration(t, kickoff.Add(3*time.Hour), *status.WindowEnd, time.Second)
+		assert.True(t, svc.IsActive("m1"))
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestMatchDayWarmer_Tick(t *testing.T) {
+	repo := new(MockMatchDayRepository)
+	repo.On("FindScheduled").Return([]*models.MatchDaySetting{
+		{VideoID: "live", Mode: models.MatchDayModeOn},
+		{VideoID: "pending", Mode: models.MatchDayModeOn},
+	}, nil).Once()
+	repo.On("FindScheduled").Return([]*models.MatchDaySetting{
+		{VideoID: "live", Mode: models.MatchDayModeOn},
+	}, nil)
+
+	source := &partialResultsSource{players: map[string]string{"live": `{"1":{"distance":10}}`}}
+	svc := services.NewMatchDayService(repo, matchDayConfig)
+	cache := services.NewAnalyticsCache(source, svc.CacheTTL)
+	live := &recordingBroadcaster{}
+	warmer := services.NewMatchDayWarmer(svc, cache, live, time.Second)
+
+	warmer.Tick(context.Background())
+	types := func() []string {
+		var out []string
+		for _, m := range live.messages {
+			out = append(out, m.Type+":"+m.MatchID)
+		}
+		return out
+	}
+	assert.Equal(t, []string{
+		"match_day.started:live", "match_day.analytics_updated:live", "match_day.started:pending",
+	}, types())
+
+	// Unchanged results are not pushed again; "pending" left match-day mode
+	live.messages = nil
+	warmer.Tick(context.Background())
+	assert.Equal(t, []string{"match_day.ended:pending"}, types())
+
+	// New partial results are pushed with the summary
+	live.messages = nil
+	source.players["live"] = `{"1":{"distance":35}}`
+	warmer.Tick(context.Background())
+	require.Len(t, live.messages, 1)
+	assert.True(t, live.messages[0].MatchDay)
+	assert.Contains(t, live.messages[0].Data, "summary")
+}
+
+func TestLiveNotifier_HandleEvent(t *testing.T) {
+	repo := new(MockMatchDayRepository)
+	svc := services.NewMatchDayService(repo, matchDayConfig)
+	live := &recordingBroadcaster{}
+	notifier := services.NewLiveNotifier(svc, services.NewAnalyticsCache(&fakeAnalyticsReader{}, nil), live)
+
+	notifier.HandleEvent(events.New(events.VideoUploaded, map[string]interface{}{"video_id": "v1", "title": "Final"}))
+	notifier.HandleEvent(events.New(events.SLOBurnRateAlert, map[string]interface{}{"objective": "reads-latency"}))
+
+	require.Len(t, live.messages, 1)
+	assert.Equal(t, events.VideoUploaded, live.messages[0].Type)
+	assert.Equal(t, "v1", live.messages[0].MatchID)
+	assert.False(t, live.messages[0].MatchDay)
+	assert.Equal(t, "Final", live.messages[0].Data["title"])
+}
+
+// partialResultsSource serves summaries only for matches with results so far.
+type partialResultsSource struct {
+	players map[string]string
+}
+
+func (p *partialResultsSource) GetMatchSummary(ctx context.Context, matchID string) (*pythonapi.MatchSummary, error) {
+	players, ok := p.players[matchID]
+	if !ok {
+		return nil, &pythonapi.APIError{StatusCode: 404, Detail: "Match data not processed or match ID not found."}
+	}
+	return &pythonapi.MatchSummary{MatchID: matchID, Players: json.RawMessage(players), Teams: json.RawMessage(`{}`)}, nil
+}
+
+func (p *partialResultsSource) GetPlayerDetails(ctx context.Context, matchID, playerID string) (*pythonapi.PlayerDetails, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (p *partialResultsSource) GetTeamSummaryOverTime(ctx context.Context, matchID, teamID string) (*pythonapi.TeamSummaryOverTime, error) {
+	return nil, errors.New("not implemented")
+}
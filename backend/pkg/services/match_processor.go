@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"nivai/backend/pkg/pythonapi"
+)
+
+// processMatchTimeout bounds a /process-match call.
+const processMatchTimeout = 20 * time.Second
+
+// priorityKey is the context key of a requested processing priority
+type priorityKey struct{}
+
+/**
+ * WithPriority returns a context asking MatchProcessor.Start to process a
+ * match at priority, e.g. pythonapi.PriorityLow for historical backfills.
+ * A requested priority takes precedence over match-day mode; an empty one
+ * leaves the choice to it.
+ *
+ * @param ctx The parent context
+ * @param priority One of the pythonapi priorities, or empty
+ * @return The context carrying the priority
+ */
+func WithPriority(ctx context.Context, priority string) context.Context {
+	if priority == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, priorityKey{}, priority)
+}
+
+/**
+ * MatchProcessor sends stored matches to the Python API for processing.
+ * Storage paths are translated by the path resolver first. Matches are
+ * processed at the priority requested with WithPriority, or else at high
+ * priority in match-day mode and normal priority otherwise; the Python API
+ * runs each priority in its own lane.
+ */
+type MatchProcessor struct {
+	client   *pythonapi.Client
+	resolver PathResolver
+	matchDay *MatchDayService
+}
+
+/**
+ * NewMatchProcessor creates a new match processor.
+ *
+ * @param client Python API client
+ * @param resolver Resolver making storage paths readable by the Python API; nil passes storage paths
+ * @param matchDay Match-day service deciding priorities; nil processes at normal priority
+ * @return A new match processor
+ */
+func NewMatchProcessor(client *pythonapi.Client, resolver PathResolver, matchDay *MatchDayService) *MatchProcessor {
+	if resolver == nil {
+		resolver = StoragePathResolver{}
+	}
+	return &MatchProcessor{client: client, resolver: resolver, matchDay: matchDay}
+}
+
+/**
+ * Start asks the Python API to process a match's tracking and event files.
+ *
+ * @param ctx Context for the call
+ * @param videoID The match's video ID
+ * @param trackingPath Storage path of the tracking file
+ * @param eventPath Storage path of the event file
+ * @return An error if the paths cannot be resolved or the Python API refuses
+ */
+func (p *MatchProcessor) Start(ctx context.Context, videoID, trackingPath, eventPath string) error {
+	ctx, cancel := context.WithTimeout(ctx, processMatchTimeout)
+	defer cancel()
+
+	priority, _ := ctx.Value(priorityKey{}).(string)
+	if priority == "" {
+		priority = pythonapi.PriorityNormal
+		if p.matchDay != nil {
+			priority = p.matchDay.Priority(videoID)
+		}
+	}
+
+	// Log storage paths only; resolved paths may be signed URLs
+	log.Printf("Calling Python API to process match %s (tracking: %s, events: %s, priority: %s)", videoID, trackingPath, eventPath, priority)
+	resolvedTracking, err := p.resolver.ResolvePath(trackingPath)
+	if err != nil {
+		return fmt.Errorf("failed to resolve tracking file for Python API: %w", err)
+	}
+	resolvedEvents, err := p.resolver.ResolvePath(eventPath)
+	if err != nil {
+		return fmt.Errorf("failed to resolve event file for Python API: %w", err)
+	}
+	resp, err := p.client.ProcessMatch(ctx, pythonapi.ProcessMatchRequest{
+		TrackingDataPath: resolvedTracking,
+		EventDataPath:    resolvedEvents,
+		MatchID:          videoID,
+		Priority:         priority,
+	})
+	if err != nil {
+		return fmt.Errorf("python api /process-match failed: %w", err)
+	}
+	log.Printf("Python API /process-match successfully triggered for video %s: %s", videoID, resp.Message)
+	return nil
+}
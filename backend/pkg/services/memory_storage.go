@@ -0,0 +1,238 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+/**
+ * MemoryStorageService implements the StorageService interface in memory,
+ * for demo mode and as a test double. Files are lost when the process
+ * exits.
+ */
+type MemoryStorageService struct {
+	mu    sync.RWMutex
+	files map[string]memoryFile
+}
+
+// memoryFile is the content of a stored file
+type memoryFile struct {
+	data       []byte
+	modifiedAt time.Time
+}
+
+/**
+ * NewMemoryStorageService creates an empty in-memory storage service.
+ *
+ * @return A new storage service
+ */
+func NewMemoryStorageService() *MemoryStorageService {
+	return &MemoryStorageService{files: map[string]memoryFile{}}
+}
+
+/**
+ * HealthCheck always succeeds; memory is always reachable.
+ *
+ * @param ctx Unused
+ * @return nil
+ */
+func (s *MemoryStorageService) HealthCheck(ctx context.Context) error {
+	return nil
+}
+
+/**
+ * UploadFile stores a file in memory.
+ *
+ * @param file The file to upload
+ * @param path The destination path in the storage
+ * @return Upload information or error
+ */
+func (s *MemoryStorageService) UploadFile(file multipart.File, path string) (*FileUploadInfo, error) {
+	return s.UploadStream(file, path)
+}
+
+/**
+ * UploadStream stores a stream in memory, replacing any file at path.
+ *
+ * @param r The content to store
+ * @param path The destination path in the storage
+ * @return Upload information or error
+ */
+func (s *MemoryStorageService) UploadStream(r io.Reader, path string) (*FileUploadInfo, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy file: %v", err)
+	}
+
+	s.mu.Lock()
+	s.files[path] = memoryFile{data: data, modifiedAt: time.Now()}
+	s.mu.Unlock()
+
+	return &FileUploadInfo{
+		Path:     path,
+		Provider: "memory",
+		Size:     int64(len(data)),
+		Format:   strings.TrimPrefix(filepath.Ext(path), "."),
+	}, nil
+}
+
+/**
+ * GetFile retrieves a file from memory. The reader is seekable, so
+ * downloads support range requests.
+ *
+ * @param path The path of the file in storage
+ * @return A reader for the file content or error
+ */
+func (s *MemoryStorageService) GetFile(path string) (io.ReadCloser, error) {
+	file, err := s.file(path)
+	if err != nil {
+		return nil, err
+	}
+	return memoryReader{bytes.NewReader(file.data)}, nil
+}
+
+// memoryReader is a seekable reader over a stored file
+type memoryReader struct {
+	*bytes.Reader
+}
+
+// Close does nothing; the content stays stored
+func (memoryReader) Close() error { return nil }
+
+/**
+ * DeleteFile removes a file from memory.
+ *
+ * @param path The path of the file to delete
+ * @return Error if the file does not exist
+ */
+func (s *MemoryStorageService) DeleteFile(path string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.files[path]; !ok {
+		return errors.New("file not found")
+	}
+	delete(s.files, path)
+	return nil
+}
+
+/**
+ * GetStreamURL returns a memory:// URL naming the file. Browsers cannot
+ * open it; files are served through the API's download endpoints instead.
+ *
+ * @param path The path of the file in storage
+ * @return A URL for the file or error
+ */
+func (s *MemoryStorageService) GetStreamURL(path string) (string, error) {
+	if _, err := s.file(path); err != nil {
+		return "", err
+	}
+	return "memory://" + path, nil
+}
+
+/**
+ * GetFileMetadata retrieves the size and modification time of a file.
+ *
+ * @param path The path of the file in storage
+ * @return A map of metadata or error
+ */
+func (s *MemoryStorageService) GetFileMetadata(path string) (map[string]string, error) {
+	file, err := s.file(path)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{
+		"content-length": fmt.Sprintf("%d", len(file.data)),
+		"last-modified":  file.modifiedAt.Format(time.RFC3339),
+		"name":           filepath.Base(path),
+	}, nil
+}
+
+/**
+ * ListFiles lists the stored files whose path starts with prefix, by path.
+ *
+ * @param prefix The path prefix, e.g. "videos/"
+ * @return The matching files
+ */
+func (s *MemoryStorageService) ListFiles(prefix string) ([]FileInfo, error) {
+	s.mu.RLock()
+	files := []FileInfo{}
+	for path, file := range s.files {
+		if strings.HasPrefix(path, prefix) {
+			files = append(files, FileInfo{Path: path, Size: int64(len(file.data)), ModifiedAt: file.modifiedAt})
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
+
+/**
+ * Exists reports whether a file is stored at path.
+ *
+ * @param path The path of the file in storage
+ * @return Whether the file exists
+ */
+func (s *MemoryStorageService) Exists(path string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.files[path]
+	return ok, nil
+}
+
+/**
+ * CopyFile copies a file within memory, replacing any file at dst.
+ *
+ * @param src The path of the file to copy
+ * @param dst The destination path
+ * @return Error if src does not exist
+ */
+func (s *MemoryStorageService) CopyFile(src, dst string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	file, ok := s.files[src]
+	if !ok {
+		return errors.New("file not found")
+	}
+	s.files[dst] = memoryFile{data: bytes.Clone(file.data), modifiedAt: time.Now()}
+	return nil
+}
+
+/**
+ * MoveFile moves a file within memory, replacing any file at dst.
+ *
+ * @param src The path of the file to move
+ * @param dst The destination path
+ * @return Error if src does not exist
+ */
+func (s *MemoryStorageService) MoveFile(src, dst string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	file, ok := s.files[src]
+	if !ok {
+		return errors.New("file not found")
+	}
+	delete(s.files, src)
+	s.files[dst] = file
+	return nil
+}
+
+// file returns the stored file at path
+func (s *MemoryStorageService) file(path string) (memoryFile, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	file, ok := s.files[path]
+	if !ok {
+		return memoryFile{}, errors.New("file not found")
+	}
+	return file, nil
+}
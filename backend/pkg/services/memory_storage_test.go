@@ -0,0 +1,42 @@
+package services_test
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"nivai/backend/pkg/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStorageService(t *testing.T) {
+	storage := services.NewMemoryStorageService()
+
+	info, err := storage.UploadStream(strings.NewReader("match video"), "videos/v1/match.mp4")
+	require.NoError(t, err)
+	assert.Equal(t, int64(11), info.Size)
+	assert.Equal(t, "mp4", info.Format)
+
+	file, err := storage.GetFile("videos/v1/match.mp4")
+	require.NoError(t, err)
+	_, isSeeker := file.(io.ReadSeeker)
+	assert.True(t, isSeeker, "downloads need a seekable reader for range requests")
+	content, _ := io.ReadAll(file)
+	assert.Equal(t, "match video", string(content))
+
+	require.NoError(t, storage.CopyFile("videos/v1/match.mp4", "videos/v1/copy.mp4"))
+	require.NoError(t, storage.MoveFile("videos/v1/copy.mp4", "archive/v1/match.mp4"))
+	files, err := storage.ListFiles("videos/")
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, "videos/v1/match.mp4", files[0].Path)
+
+	require.NoError(t, storage.DeleteFile("videos/v1/match.mp4"))
+	exists, err := storage.Exists("videos/v1/match.mp4")
+	require.NoError(t, err)
+	assert.False(t, exists)
+	_, err = storage.GetFile("videos/v1/match.mp4")
+	assert.EqualError(t, err, "file not found")
+}
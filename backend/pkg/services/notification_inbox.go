@@ -0,0 +1,103 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/notify"
+
+	"github.com/google/uuid"
+)
+
+/**
+ * NotificationInbox keeps the notifications of the in-app channel, so the
+ * dashboard shows them whether or not it was connected when they were sent.
+ * It is the notify.ChannelInApp channel of the notification service.
+ */
+type NotificationInbox struct {
+	repo models.NotificationRepository
+	now  func() time.Time
+}
+
+/**
+ * NewNotificationInbox creates a new in-app notification inbox.
+ *
+ * @param repo Repository for the inboxes
+ * @return A new inbox
+ */
+func NewNotificationInbox(repo models.NotificationRepository) *NotificationInbox {
+	return &NotificationInbox{repo: repo, now: time.Now}
+}
+
+/**
+ * Name returns notify.ChannelInApp.
+ *
+ * @return The channel name
+ */
+func (b *NotificationInbox) Name() string { return notify.ChannelInApp }
+
+/**
+ * Send stores a message in the recipient's inbox, unread.
+ *
+ * @param ctx Unused; stores are not cancelled
+ * @param to The recipient
+ * @param msg The message
+ * @return An error if the message cannot be stored
+ */
+func (b *NotificationInbox) Send(_ context.Context, to notify.Recipient, msg notify.Message) error {
+	return b.repo.Create(&models.Notification{
+		ID:        uuid.New().String(),
+		UserID:    to.UserID,
+		Kind:      msg.Kind,
+		Subject:   msg.Subject,
+		Body:      msg.Body,
+		VideoID:   msg.VideoID,
+		CreatedAt: b.now().UTC(),
+	})
+}
+
+/**
+ * List lists a user's notifications, newest first.
+ *
+ * @param userID The user
+ * @param unreadOnly Whether to leave out read notifications
+ * @param limit Maximum number of notifications to return
+ * @param offset Number of notifications to skip
+ * @return The notifications or error
+ */
+func (b *NotificationInbox) List(userID string, unreadOnly bool, limit, offset int) ([]*models.Notification, error) {
+	return b.repo.FindByUser(userID, unreadOnly, limit, offset)
+}
+
+/**
+ * CountUnread counts a user's unread notifications, for the bootstrap payload.
+ *
+ * @param ctx Request context
+ * @param userID The user
+ * @return The count or error
+ */
+func (b *NotificationInbox) CountUnread(ctx context.Context, userID string) (int, error) {
+	return b.repo.CountUnread(userID)
+}
+
+/**
+ * MarkRead marks one of a user's notifications read.
+ *
+ * @param userID The user
+ * @param id The notification
+ * @return An error, models.ErrNotificationNotFound for notifications of other users
+ */
+func (b *NotificationInbox) MarkRead(userID, id string) error {
+	return b.repo.MarkRead(userID, id, b.now().UTC())
+}
+
+/**
+ * MarkAllRead marks all of a user's notifications read.
+ *
+ * @param userID The user
+ * @return How many were unread, or error
+ */
+func (b *NotificationInbox) MarkAllRead(userID string) (int, error) {
+	return b.repo.MarkAllRead(userID, b.now().UTC())
+}
@@ -0,0 +1,78 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"nivai/backend/pkg/events"
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/notify"
+	"nivai/backend/pkg/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotificationInbox(t *testing.T) {
+	inbox := services.NewNotificationInbox(models.NewMemoryNotificationRepository())
+	notifications := services.NewNotificationService(models.NewMemoryNotificationPreferenceRepository(), nil,
+		[]notify.Channel{inbox}, services.NotificationConfig{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go notifications.Run(ctx)
+
+	require.NoError(t, notifications.UpdatePreferences(&models.NotificationPreference{
+		UserID: "coach", Channels: []string{"in_app"}, Kinds: []string{"processing_finished", "processing_failed"},
+	}))
+
+	// waitUnread waits for the queued notifications to reach the inbox.
+	waitUnread := func(t *testing.T, want int) {
+		t.Helper()
+		assert.Eventually(t, func() bool {
+			count, err := inbox.CountUnread(context.Background(), "coach")
+			return err == nil && count == want
+		}, 2*time.Second, 10*time.Millisecond)
+	}
+
+	t.Run("Subscribers find events in their inbox, newest first", func(t *testing.T) {
+		notifications.HandleEvent(events.New(events.AnalyticsCompleted, map[string]interface{}{"video_id": "m1", "title": "Ajax - PSV"}))
+		waitUnread(t, 1)
+		notifications.HandleEvent(events.New(events.AnalyticsFailed, map[string]interface{}{"video_id": "m2"}))
+		waitUnread(t, 2)
+
+		list, err := inbox.List("coach", false, 10, 0)
+		require.NoError(t, err)
+		require.Len(t, list, 2)
+		assert.Equal(t, "Processing failed: match m2", list[0].Subject)
+		assert.Equal(t, "Analytics ready: Ajax - PSV", list[1].Subject)
+		assert.Equal(t, "m1", list[1].VideoID)
+		assert.Nil(t, list[1].ReadAt)
+
+		list, err = inbox.List("admin", false, 10, 0)
+		require.NoError(t, err)
+		assert.Empty(t, list, "other users' inboxes are separate")
+	})
+
+	t.Run("Notifications are marked read one by one or all at once", func(t *testing.T) {
+		list, err := inbox.List("coach", true, 10, 0)
+		require.NoError(t, err)
+		require.Len(t, list, 2)
+
+		assert.ErrorIs(t, inbox.MarkRead("admin", list[0].ID), models.ErrNotificationNotFound, "only the owner marks a notification")
+		require.NoError(t, inbox.MarkRead("coach", list[0].ID))
+		require.NoError(t, inbox.MarkRead("coach", list[0].ID), "marking again is harmless")
+
+		unread, err := inbox.List("coach", true, 10, 0)
+		require.NoError(t, err)
+		require.Len(t, unread, 1)
+		assert.Equal(t, list[1].ID, unread[0].ID)
+
+		marked, err := inbox.MarkAllRead("coach")
+		require.NoError(t, err)
+		assert.Equal(t, 1, marked)
+		count, err := inbox.CountUnread(context.Background(), "coach")
+		require.NoError(t, err)
+		assert.Zero(t, count)
+	})
+}
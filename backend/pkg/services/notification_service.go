@@ -0,0 +1,313 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/mail"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"nivai/backend/pkg/events"
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/notify"
+)
+
+// ErrInvalidNotificationPreference is returned when notification preferences
+// name an unknown kind or channel, or an invalid address.
+var ErrInvalidNotificationPreference = errors.New("invalid notification preference")
+
+/**
+ * NotificationConfig tunes the notification service.
+ */
+type NotificationConfig struct {
+	Organization string // Organization whose storage usage summaries report
+	QueueSize    int    // Messages waiting to be sent; more are dropped. Defaults to 256
+}
+
+// outgoing is a message waiting to be sent on a channel.
+type outgoing struct {
+	channel notify.Channel
+	to      notify.Recipient
+	msg     notify.Message
+}
+
+/**
+ * NotificationService notifies users of finished and failed processing,
+ * given up webhook deliveries and, weekly, their storage usage, on the
+ * channels and for the kinds each user chose in their preferences.
+ * Messages are queued by the event handlers and sent by Run, so publishers
+ * never wait on a mail server.
+ */
+type NotificationService struct {
+	prefs    models.NotificationPreferenceRepository
+	quotas   *QuotaService
+	channels map[string]notify.Channel
+	org      string
+	queue    chan outgoing
+	now      func() time.Time
+}
+
+/**
+ * NewNotificationService creates a new notification service.
+ *
+ * @param prefs Repository for the users' notification preferences
+ * @param quotas Storage usage, for the usage summaries
+ * @param channels The channels messages can be sent on; preferences naming
+ *                 other channels are skipped on them
+ * @param config Organization and queue size
+ * @return A new notification service
+ */
+func NewNotificationService(prefs models.NotificationPreferenceRepository, quotas *QuotaService, channels []notify.Channel, config NotificationConfig) *NotificationService {
+	if config.QueueSize <= 0 {
+		config.QueueSize = 256
+	}
+	byName := make(map[string]notify.Channel, len(channels))
+	for _, channel := range channels {
+		byName[channel.Name()] = channel
+	}
+	return &NotificationService{
+		prefs: prefs, quotas: quotas, channels: byName, org: config.Organization,
+		queue: make(chan outgoing, config.QueueSize), now: time.Now,
+	}
+}
+
+/**
+ * Channels lists the names of the channels messages can be sent on.
+ *
+ * @return The channel names, sorted
+ */
+func (s *NotificationService) Channels() []string {
+	names := make([]string, 0, len(s.channels))
+	for name := range s.channels {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+/**
+ * Preferences returns a user's notification preferences; users who never
+ * set them receive nothing.
+ *
+ * @param userID The user
+ * @return The preferences or error
+ */
+func (s *NotificationService) Preferences(userID string) (*models.NotificationPreference, error) {
+	pref, err := s.prefs.FindByUserID(userID)
+	if errors.Is(err, models.ErrNotificationPreferenceNotFound) {
+		return &models.NotificationPreference{UserID: userID, Channels: []string{}, Kinds: []string{}}, nil
+	}
+	return pref, err
+}
+
+/**
+ * UpdatePreferences replaces a user's notification preferences.
+ *
+ * @param pref The preferences, with UserID set
+ * @return An error, ErrInvalidNotificationPreference for unknown kinds or
+ *         channels, invalid addresses or a channel without its address
+ */
+func (s *NotificationService) UpdatePreferences(pref *models.NotificationPreference) error {
+	pref.Email = strings.TrimSpace(pref.Email)
+	pref.SlackWebhookURL = strings.TrimSpace(pref.SlackWebhookURL)
+	pref.Channels = compactStrings(pref.Channels)
+	pref.Kinds = compactStrings(pref.Kinds)
+
+	for _, kind := range pref.Kinds {
+		if !slices.Contains(models.NotificationKinds, kind) {
+			return fmt.Errorf("%w: unknown kind %q", ErrInvalidNotificationPreference, kind)
+		}
+	}
+	for _, channel := range pref.Channels {
+		switch channel {
+		case notify.ChannelEmail:
+			if pref.Email == "" {
+				return fmt.Errorf("%w: email notifications need an email address", ErrInvalidNotificationPreference)
+			}
+		case notify.ChannelSlack:
+			if pref.SlackWebhookURL == "" {
+				return fmt.Errorf("%w: slack notifications need a slack_webhook_url", ErrInvalidNotificationPreference)
+			}
+		case notify.ChannelInApp:
+			// The inbox needs no address
+		default:
+			return fmt.Errorf("%w: unknown channel %q", ErrInvalidNotificationPreference, channel)
+		}
+	}
+	if pref.Email != "" {
+		if address, err := mail.ParseAddress(pref.Email); err != nil || address.Address != pref.Email {
+			return fmt.Errorf("%w: invalid email address", ErrInvalidNotificationPreference)
+		}
+	}
+	if pref.SlackWebhookURL != "" {
+		if parsed, err := url.Parse(pref.SlackWebhookURL); err != nil || parsed.Scheme != "https" || parsed.Host == "" {
+			return fmt.Errorf("%w: slack_webhook_url must be an https url", ErrInvalidNotificationPreference)
+		}
+	}
+
+	pref.UpdatedAt = s.now().UTC()
+	return s.prefs.Save(pref)
+}
+
+/**
+ * HandleEvent notifies the subscribed users of finished and failed
+ * processing and of given up webhook deliveries. Intended to be registered
+ * as a wildcard handler on the bus.
+ *
+ * @param e The published event
+ */
+func (s *NotificationService) HandleEvent(e events.Event) {
+	videoID, _ := e.Data["video_id"].(string)
+	title := func() string {
+		if title, _ := e.Data["title"].(string); title != "" {
+			return title
+		}
+		return "match " + videoID
+	}
+
+	switch e.Type {
+	case events.AnalyticsCompleted:
+		s.Notify(notify.Message{
+			Kind:    models.NotificationProcessingFinished,
+			Subject: "Analytics ready: " + title(),
+			Body:    fmt.Sprintf("The analytics of %s are ready to view (match ID %s).", title(), videoID),
+			VideoID: videoID,
+		})
+	case events.AnalyticsFailed:
+		s.Notify(notify.Message{
+			Kind:    models.NotificationProcessingFailed,
+			Subject: "Processing failed: " + title(),
+			Body:    fmt.Sprintf("Processing %s failed (match ID %s). Check its files and reprocess it.", title(), videoID),
+			VideoID: videoID,
+		})
+	case events.WebhookDeliveryFailed:
+		s.Notify(notify.Message{
+			Kind:    models.NotificationWebhookFailed,
+			Subject: fmt.Sprintf("Webhook delivery failed: %v", e.Data["event_type"]),
+			Body: fmt.Sprintf("Delivering %v to webhook %v (%v) was given up after %v attempts: %v",
+				e.Data["event_type"], e.Data["webhook_id"], e.Data["url"], e.Data["attempts"], e.Data["last_error"]),
+		})
+	}
+}
+
+/**
+ * Notify queues a message for every user subscribed to its kind, on each
+ * of their channels.
+ *
+ * @param msg The message; Kind selects the recipients
+ */
+func (s *NotificationService) Notify(msg notify.Message) {
+	prefs, err := s.prefs.FindByKind(msg.Kind)
+	if err != nil {
+		log.Printf("Notifications: failed to look up subscribers of %s: %v", msg.Kind, err)
+		return
+	}
+	for _, pref := range prefs {
+		s.notifyUser(pref, msg)
+	}
+}
+
+/**
+ * SendUsageSummaries queues every subscribed user's weekly summary of the
+ * storage they and their organization use. It is the scheduled usage
+ * summary job.
+ *
+ * @param ctx Context for the run
+ * @return An error if the subscribers cannot be read
+ */
+func (s *NotificationService) SendUsageSummaries(ctx context.Context) error {
+	prefs, err := s.prefs.FindByKind(models.NotificationUsageSummary)
+	if err != nil {
+		return fmt.Errorf("failed to list usage summary subscribers: %w", err)
+	}
+	for _, pref := range prefs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		report, err := s.quotas.Usage(s.org, pref.UserID)
+		if err != nil {
+			log.Printf("Notifications: failed to read storage usage of user %s: %v", pref.UserID, err)
+			continue
+		}
+		s.notifyUser(pref, notify.Message{
+			Kind:    models.NotificationUsageSummary,
+			Subject: "Weekly storage usage",
+			Body: fmt.Sprintf("You store %s in %d files.\n%s stores %s in %d files.",
+				describeUsage(report.User), report.User.Files,
+				s.org, describeUsage(report.Organization), report.Organization.Files),
+		})
+	}
+	return nil
+}
+
+/**
+ * Run sends queued messages until the context is cancelled.
+ * Should be run in a goroutine.
+ *
+ * @param ctx Context controlling the worker lifetime
+ */
+func (s *NotificationService) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case out := <-s.queue:
+			if err := out.channel.Send(ctx, out.to, out.msg); err != nil {
+				log.Printf("Notifications: failed to send %s to user %s by %s: %v", out.msg.Kind, out.to.UserID, out.channel.Name(), err)
+			}
+		}
+	}
+}
+
+// notifyUser queues msg on each of the user's channels that is available.
+func (s *NotificationService) notifyUser(pref *models.NotificationPreference, msg notify.Message) {
+	to := notify.Recipient{UserID: pref.UserID, Email: pref.Email, SlackWebhookURL: pref.SlackWebhookURL}
+	for _, name := range pref.Channels {
+		channel, ok := s.channels[name]
+		if !ok {
+			continue
+		}
+		select {
+		case s.queue <- outgoing{channel: channel, to: to, msg: msg}:
+		default:
+			log.Printf("Notifications: queue full, dropped %s to user %s by %s", msg.Kind, pref.UserID, name)
+		}
+	}
+}
+
+// describeUsage renders bytes used, and the quota if there is one.
+func describeUsage(usage Usage) string {
+	if usage.QuotaBytes > 0 {
+		return fmt.Sprintf("%s of %s", formatBytes(usage.BytesUsed), formatBytes(usage.QuotaBytes))
+	}
+	return formatBytes(usage.BytesUsed)
+}
+
+// formatBytes renders a byte count in binary units, e.g. "1.5 GiB".
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// compactStrings trims values and drops empty and repeated ones.
+func compactStrings(values []string) []string {
+	out := []string{}
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" && !slices.Contains(out, value) {
+			out = append(out, value)
+		}
+	}
+	return out
+}
@@ -0,0 +1,97 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"nivai/backend/pkg/events"
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/notify"
+	"nivai/backend/pkg/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingChannel passes every message it is sent on to sent.
+type recordingChannel struct {
+	name string
+	sent chan string
+}
+
+func (c recordingChannel) Name() string { return c.name }
+
+func (c recordingChannel) Send(_ context.Context, to notify.Recipient, msg notify.Message) error {
+	c.sent <- c.name + " " + to.UserID + ": " + msg.Subject
+	return nil
+}
+
+// nextSent waits for the next message sent on a recording channel.
+func nextSent(t *testing.T, sent chan string) string {
+	t.Helper()
+	select {
+	case message := <-sent:
+		return message
+	case <-time.After(2 * time.Second):
+		t.Fatal("no notification sent")
+		return ""
+	}
+}
+
+func TestNotificationService(t *testing.T) {
+	sent := make(chan string, 10)
+	usage := models.NewMemoryStorageUsageRepository()
+	require.NoError(t, usage.Charge(&models.StorageCharge{VideoID: "m1", Organization: "Ajax", UserID: "coach", Bytes: 3 << 30, Files: 3}))
+	notifications := services.NewNotificationService(models.NewMemoryNotificationPreferenceRepository(),
+		services.NewQuotaService(usage, services.QuotaConfig{UserBytes: 10 << 30}),
+		[]notify.Channel{recordingChannel{"email", sent}}, services.NotificationConfig{Organization: "Ajax"})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go notifications.Run(ctx)
+
+	require.NoError(t, notifications.UpdatePreferences(&models.NotificationPreference{
+		UserID: "coach", Email: "coach@ajax.nl", SlackWebhookURL: "https://hooks.slack.com/services/T0/B0/x",
+		Channels: []string{"email", "slack"}, Kinds: []string{"processing_finished", "usage_summary", "processing_finished"},
+	}))
+	require.NoError(t, notifications.UpdatePreferences(&models.NotificationPreference{
+		UserID: "admin", Email: "admin@ajax.nl", Channels: []string{"email"}, Kinds: []string{"webhook_failed"},
+	}))
+
+	t.Run("Subscribers are notified of events on their available channels", func(t *testing.T) {
+		notifications.HandleEvent(events.New(events.AnalyticsCompleted, map[string]interface{}{"video_id": "m1", "title": "Ajax - PSV"}))
+		assert.Equal(t, "email coach: Analytics ready: Ajax - PSV", nextSent(t, sent), "slack is not available")
+
+		notifications.HandleEvent(events.New(events.WebhookDeliveryFailed, map[string]interface{}{"event_type": "video.uploaded"}))
+		assert.Equal(t, "email admin: Webhook delivery failed: video.uploaded", nextSent(t, sent))
+
+		notifications.HandleEvent(events.New(events.AnalyticsFailed, map[string]interface{}{"video_id": "m2"}))
+		notifications.HandleEvent(events.New(events.VideoUploaded, map[string]interface{}{"video_id": "m2"}))
+		assert.Empty(t, sent, "nobody subscribed")
+	})
+
+	t.Run("Usage summaries report the subscribers' storage", func(t *testing.T) {
+		require.NoError(t, notifications.SendUsageSummaries(context.Background()))
+		assert.Equal(t, "email coach: Weekly storage usage", nextSent(t, sent))
+	})
+
+	t.Run("Preferences are validated and default to nothing", func(t *testing.T) {
+		pref, err := notifications.Preferences("coach")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"processing_finished", "usage_summary"}, pref.Kinds, "repeated kinds are dropped")
+
+		pref, err = notifications.Preferences("new-user")
+		require.NoError(t, err)
+		assert.Empty(t, pref.Kinds)
+
+		for _, invalid := range []*models.NotificationPreference{
+			{UserID: "u1", Kinds: []string{"goal_scored"}},
+			{UserID: "u1", Channels: []string{"sms"}},
+			{UserID: "u1", Channels: []string{"email"}},
+			{UserID: "u1", Email: "Coach <coach@ajax.nl>", Channels: []string{"email"}},
+			{UserID: "u1", SlackWebhookURL: "http://hooks.slack.com/x", Channels: []string{"slack"}},
+		} {
+			assert.ErrorIs(t, notifications.UpdatePreferences(invalid), services.ErrInvalidNotificationPreference)
+		}
+	})
+}
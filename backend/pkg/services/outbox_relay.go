@@ -0,0 +1,149 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"nivai/backend/pkg/broker"
+	"nivai/backend/pkg/events"
+	"nivai/backend/pkg/models"
+)
+
+// lifecycleEventTypes are the match lifecycle events relayed to the broker:
+// a match was uploaded, its analytics were requested, processed or cancelled,
+// or processing failed.
+var lifecycleEventTypes = map[string]bool{
+	events.VideoUploaded:      true,
+	events.AnalyticsRequested: true,
+	events.AnalyticsCompleted: true,
+	events.AnalyticsFailed:    true,
+	events.AnalyticsCancelled: true,
+	events.VideoRejected:      true,
+}
+
+/**
+ * OutboxRelay provides at-least-once delivery of match lifecycle events to
+ * a message broker. Events are first written to the outbox table; a
+ * background loop publishes unpublished messages and marks them once the
+ * broker acknowledges, so messages survive broker outages and restarts.
+ * Consumers should de-duplicate on the message ID.
+ */
+type OutboxRelay struct {
+	repo         models.OutboxRepository
+	publisher    broker.Publisher
+	pollInterval time.Duration
+	batchSize    int
+}
+
+/**
+ * NewOutboxRelay creates a new outbox relay.
+ *
+ * @param repo Repository for the event outbox
+ * @param publisher Broker publisher to relay messages to
+ * @param pollInterval How often to check for unpublished messages (defaults to 2s)
+ * @return A new outbox relay
+ */
+func NewOutboxRelay(repo models.OutboxRepository, publisher broker.Publisher, pollInterval time.Duration) *OutboxRelay {
+	if pollInterval <= 0 {
+		pollInterval = 2 * time.Second
+	}
+	return &OutboxRelay{repo: repo, publisher: publisher, pollInterval: pollInterval, batchSize: 100}
+}
+
+/**
+ * HandleEvent writes lifecycle events to the outbox. Other event types are ignored.
+ *
+ * @param event The published event
+ */
+func (r *OutboxRelay) HandleEvent(event events.Event) {
+	if !lifecycleEventTypes[event.Type] {
+		return
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("Outbox: failed to encode event %s: %v", event.ID, err)
+		return
+	}
+
+	key, _ := event.Data["video_id"].(string)
+	message := &models.OutboxMessage{
+		ID:        event.ID,
+		EventType: event.Type,
+		Key:       key,
+		Payload:   payload,
+		CreatedAt: event.OccurredAt,
+	}
+	if err := r.repo.Enqueue(message); err != nil {
+		log.Printf("Outbox: failed to enqueue event %s (%s): %v", event.ID, event.Type, err)
+	}
+}
+
+/**
+ * Run relays outbox messages until ctx is cancelled.
+ *
+ * @param ctx Context controlling the relay's lifetime
+ */
+func (r *OutboxRelay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := r.PublishPending(ctx); err != nil {
+				log.Printf("Outbox: relay pass failed: %v", err)
+			}
+		}
+	}
+}
+
+/**
+ * PublishPending publishes unpublished messages in order. A pass stops at
+ * the first failure so a match's events are not delivered out of order;
+ * the failed message is retried on the next pass.
+ *
+ * @param ctx Context for broker requests
+ * @return Number of messages published, or an error
+ */
+func (r *OutboxRelay) PublishPending(ctx context.Context) (int, error) {
+	messages, err := r.repo.FindUnpublished(r.batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	published := 0
+	for _, message := range messages {
+		if err := ctx.Err(); err != nil {
+			return published, err
+		}
+
+		err := r.publisher.Publish(ctx, broker.Message{
+			ID:        message.ID,
+			Type:      message.EventType,
+			Key:       message.Key,
+			Body:      message.Payload,
+			Timestamp: message.CreatedAt,
+		})
+		if err != nil {
+			log.Printf("Outbox: failed to publish message %s (attempt %d): %v", message.ID, message.Attempts+1, err)
+			if markErr := r.repo.MarkFailed(message.ID, err.Error()); markErr != nil {
+				log.Printf("Outbox: failed to record failure for message %s: %v", message.ID, markErr)
+			}
+			return published, nil
+		}
+
+		// If this update fails the message is published again later, which
+		// at-least-once delivery permits.
+		if err := r.repo.MarkPublished(message.ID, time.Now()); err != nil {
+			log.Printf("Outbox: failed to mark message %s published: %v", message.ID, err)
+		}
+		published++
+	}
+
+	return published, nil
+}
@@ -0,0 +1,122 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"nivai/backend/pkg/broker"
+	"nivai/backend/pkg/events"
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// --- MockOutboxRepository ---
+type MockOutboxRepository struct {
+	mock.Mock
+}
+
+func (m *MockOutboxRepository) Enqueue(message *models.OutboxMessage) error {
+	args := m.Called(message)
+	return args.Error(0)
+}
+func (m *MockOutboxRepository) FindUnpublished(limit int) ([]*models.OutboxMessage, error) {
+	args := m.Called(limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.OutboxMessage), args.Error(1)
+}
+func (m *MockOutboxRepository) MarkPublished(id string, at time.Time) error {
+	args := m.Called(id, at)
+	return args.Error(0)
+}
+func (m *MockOutboxRepository) MarkFailed(id string, lastError string) error {
+	args := m.Called(id, lastError)
+	return args.Error(0)
+}
+
+// fakePublisher records published messages and fails for IDs in failIDs.
+type fakePublisher struct {
+	published []broker.Message
+	failIDs   map[string]bool
+}
+
+func (p *fakePublisher) Publish(ctx context.Context, msg broker.Message) error {
+	if p.failIDs[msg.ID] {
+		return errors.New("broker unavailable")
+	}
+	p.published = append(p.published, msg)
+	return nil
+}
+
+func (p *fakePublisher) Close() error { return nil }
+
+func TestOutboxRelay_HandleEvent(t *testing.T) {
+	t.Run("Lifecycle events are enqueued keyed by video", func(t *testing.T) {
+		repo := new(MockOutboxRepository)
+		event := events.New(events.AnalyticsCompleted, map[string]interface{}{"video_id": "v1"})
+		repo.On("Enqueue", mock.MatchedBy(func(m *models.OutboxMessage) bool {
+			return m.ID == event.ID && m.Key == "v1" && m.EventType == events.AnalyticsCompleted && len(m.Payload) > 0
+		})).Return(nil).Once()
+
+		relay := services.NewOutboxRelay(repo, &fakePublisher{}, 0)
+		relay.HandleEvent(event)
+
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Other events are ignored", func(t *testing.T) {
+		repo := new(MockOutboxRepository)
+		relay := services.NewOutboxRelay(repo, &fakePublisher{}, 0)
+		relay.HandleEvent(events.New(events.VideoDeleted, map[string]interface{}{"video_id": "v1"}))
+
+		repo.AssertNotCalled(t, "Enqueue", mock.Anything)
+	})
+}
+
+func TestOutboxRelay_PublishPending(t *testing.T) {
+	t.Run("Published messages are marked", func(t *testing.T) {
+		repo := new(MockOutboxRepository)
+		messages := []*models.OutboxMessage{
+			{ID: "m1", EventType: events.VideoUploaded, Key: "v1", Payload: []byte(`{}`)},
+			{ID: "m2", EventType: events.AnalyticsCompleted, Key: "v1", Payload: []byte(`{}`)},
+		}
+		repo.On("FindUnpublished", 100).Return(messages, nil).Once()
+		repo.On("MarkPublished", "m1", mock.Anything).Return(nil).Once()
+		repo.On("MarkPublished", "m2", mock.Anything).Return(nil).Once()
+
+		publisher := &fakePublisher{}
+		relay := services.NewOutboxRelay(repo, publisher, 0)
+		n, err := relay.PublishPending(context.Background())
+		require.NoError(t, err)
+
+		assert.Equal(t, 2, n)
+		require.Len(t, publisher.published, 2)
+		assert.Equal(t, "v1", publisher.published[0].Key)
+		assert.Equal(t, events.AnalyticsCompleted, publisher.published[1].Type)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("A failure stops the pass to preserve ordering", func(t *testing.T) {
+		repo := new(MockOutboxRepository)
+		messages := []*models.OutboxMessage{{ID: "m1"}, {ID: "m2"}}
+		repo.On("FindUnpublished", 100).Return(messages, nil).Once()
+		repo.On("MarkFailed", "m1", "broker unavailable").Return(nil).Once()
+
+		publisher := &fakePublisher{failIDs: map[string]bool{"m1": true}}
+		relay := services.NewOutboxRelay(repo, publisher, 0)
+		n, err := relay.PublishPending(context.Background())
+		require.NoError(t, err)
+
+		assert.Equal(t, 0, n)
+		assert.Empty(t, publisher.published)
+		repo.AssertNotCalled(t, "MarkPublished", mock.Anything, mock.Anything)
+		repo.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Modes of handing stored files to the Python API, selected with
+// PYTHON_API_PATH_MODE.
+const (
+	PathModeStorage   = "storage"
+	PathModePrefix    = "prefix"
+	PathModeSignedURL = "signed_url"
+)
+
+// ErrUnmappedPath is returned when no prefix mapping matches a storage path.
+var ErrUnmappedPath = errors.New("no path mapping for storage path")
+
+/**
+ * PathResolver translates storage paths into locations another service
+ * can read the files from, such as the Python API in its own container.
+ */
+type PathResolver interface {
+	ResolvePath(storagePath string) (string, error)
+}
+
+/**
+ * StoragePathResolver passes storage paths unchanged, for services that
+ * read the same storage with their own configuration.
+ */
+type StoragePathResolver struct{}
+
+/**
+ * ResolvePath implements PathResolver.
+ */
+func (StoragePathResolver) ResolvePath(storagePath string) (string, error) {
+	return storagePath, nil
+}
+
+/**
+ * PathMapping maps storage paths starting with From to the same path below To.
+ */
+type PathMapping struct {
+	From string
+	To   string
+}
+
+/**
+ * PrefixPathResolver maps storage paths into another service's mount
+ * namespace, e.g. "videos/" to "/data/shared/videos/". The longest
+ * matching prefix wins; an empty From matches every path.
+ */
+type PrefixPathResolver struct {
+	mappings []PathMapping
+}
+
+/**
+ * NewPrefixPathResolver creates a resolver for the given mappings.
+ *
+ * @param mappings The prefix mappings
+ * @return A new prefix path resolver
+ */
+func NewPrefixPathResolver(mappings []PathMapping) *PrefixPathResolver {
+	sorted := append([]PathMapping(nil), mappings...)
+	sort.SliceStable(sorted, func(i, j int) bool { return len(sorted[i].From) > len(sorted[j].From) })
+	return &PrefixPathResolver{mappings: sorted}
+}
+
+/**
+ * ParsePathMappings reads a comma-separated list of "<from>=<to>" prefix
+ * mappings.
+ *
+ * @param spec The mappings
+ * @return The mappings or error
+ */
+func ParsePathMappings(spec string) ([]PathMapping, error) {
+	var mappings []PathMapping
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		from, to, ok := strings.Cut(entry, "=")
+		if !ok || to == "" {
+			return nil, fmt.Errorf("path mapping %q must be given as <from>=<to>", entry)
+		}
+		mappings = append(mappings, PathMapping{From: strings.TrimSpace(from), To: strings.TrimSpace(to)})
+	}
+	if len(mappings) == 0 {
+		return nil, errors.New("no path mappings configured")
+	}
+	return mappings, nil
+}
+
+/**
+ * ResolvePath implements PathResolver.
+ */
+func (r *PrefixPathResolver) ResolvePath(storagePath string) (string, error) {
+	storagePath = filepath.ToSlash(storagePath)
+	for _, mapping := range r.mappings {
+		if strings.HasPrefix(storagePath, mapping.From) {
+			return mapping.To + strings.TrimPrefix(storagePath, mapping.From), nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s", ErrUnmappedPath, storagePath)
+}
+
+/**
+ * SignedURLPathResolver hands out time-limited download URLs, so the files
+ * can be read without access to the storage account or a shared volume.
+ * It needs a storage backend that signs URLs; encrypted storage cannot.
+ */
+type SignedURLPathResolver struct {
+	storage StorageService
+}
+
+/**
+ * NewSignedURLPathResolver creates a resolver signing URLs with storage.
+ *
+ * @param storage The storage holding the files
+ * @return A new signed URL path resolver
+ */
+func NewSignedURLPathResolver(storage StorageService) *SignedURLPathResolver {
+	return &SignedURLPathResolver{storage: storage}
+}
+
+/**
+ * ResolvePath implements PathResolver.
+ */
+func (r *SignedURLPathResolver) ResolvePath(storagePath string) (string, error) {
+	url, err := r.storage.GetStreamURL(storagePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign download URL for %s: %w", storagePath, err)
+	}
+	if !strings.HasPrefix(url, "https://") && !strings.HasPrefix(url, "http://") {
+		return "", fmt.Errorf("storage backend cannot sign download URLs for %s", storagePath)
+	}
+	return url, nil
+}
+
+/**
+ * NewPathResolver returns the resolver for a handoff mode; an empty mode
+ * passes storage paths unchanged.
+ *
+ * @param mode "storage", "prefix" or "signed_url"
+ * @param mappings Prefix mappings for the "prefix" mode, see ParsePathMappings
+ * @param storage Storage signing URLs for the "signed_url" mode
+ * @return The path resolver or error
+ */
+func NewPathResolver(mode, mappings string, storage StorageService) (PathResolver, error) {
+	switch mode {
+	case "", PathModeStorage:
+		return StoragePathResolver{}, nil
+	case PathModePrefix:
+		parsed, err := ParsePathMappings(mappings)
+		if err != nil {
+			return nil, err
+		}
+		return NewPrefixPathResolver(parsed), nil
+	case PathModeSignedURL:
+		return NewSignedURLPathResolver(storage), nil
+	default:
+		return nil, fmt.Errorf("unknown path mode %q", mode)
+	}
+}
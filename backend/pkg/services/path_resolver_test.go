@@ -0,0 +1,66 @@
+package services_test
+
+import (
+	"errors"
+	"testing"
+
+	"nivai/backend/pkg/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPathResolver(t *testing.T) {
+	t.Run("Storage paths are passed unchanged by default", func(t *testing.T) {
+		resolver, err := services.NewPathResolver("", "", nil)
+		require.NoError(t, err)
+		resolved, err := resolver.ResolvePath("videos/ab/cd/m1/m1_tracking.gzip")
+		require.NoError(t, err)
+		assert.Equal(t, "videos/ab/cd/m1/m1_tracking.gzip", resolved)
+	})
+
+	t.Run("Prefixes are mapped into the mount namespace", func(t *testing.T) {
+		resolver, err := services.NewPathResolver(services.PathModePrefix, "videos/=/data/shared/videos/, videos/archive/=/mnt/cold/", nil)
+		require.NoError(t, err)
+
+		for path, want := range map[string]string{
+			"videos/ab/cd/m1/m1_events.gzip": "/data/shared/videos/ab/cd/m1/m1_events.gzip",
+			"videos/archive/m1/m1.mp4":       "/mnt/cold/m1/m1.mp4",
+		} {
+			resolved, err := resolver.ResolvePath(path)
+			require.NoError(t, err)
+			assert.Equal(t, want, resolved)
+		}
+
+		_, err = resolver.ResolvePath("exports/m1.csv")
+		assert.ErrorIs(t, err, services.ErrUnmappedPath)
+	})
+
+	t.Run("Invalid mappings are rejected", func(t *testing.T) {
+		for _, spec := range []string{"", "videos/", "videos/="} {
+			_, err := services.NewPathResolver(services.PathModePrefix, spec, nil)
+			assert.Error(t, err, spec)
+		}
+		_, err := services.NewPathResolver("nfs", "", nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("Signed URLs are handed out when storage can sign them", func(t *testing.T) {
+		storage := new(MockStorageService)
+		storage.On("GetStreamURL", "videos/m1/tracking").Return("https://nivai.blob.core.windows.net/matches/videos/m1/tracking?sig=abc", nil)
+		storage.On("GetStreamURL", "videos/m1/local").Return("/srv/storage/videos/m1/local", nil)
+		storage.On("GetStreamURL", mock.Anything).Return("", errors.New("file not found"))
+		resolver, err := services.NewPathResolver(services.PathModeSignedURL, "", storage)
+		require.NoError(t, err)
+
+		resolved, err := resolver.ResolvePath("videos/m1/tracking")
+		require.NoError(t, err)
+		assert.Contains(t, resolved, "sig=abc")
+
+		_, err = resolver.ResolvePath("videos/m1/local")
+		assert.ErrorContains(t, err, "cannot sign")
+		_, err = resolver.ResolvePath("videos/m1/missing")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"nivai/backend/pkg/models"
+)
+
+// Storage path strategies, selected with STORAGE_PATH_STRATEGY.
+const (
+	PathStrategyIDShard     = "id_shard"
+	PathStrategyDateTree    = "date_tree"
+	PathStrategyOrgPrefixed = "org_prefixed"
+)
+
+/**
+ * StoragePathInfo is what a path strategy may base a match's storage
+ * directory on.
+ */
+type StoragePathInfo struct {
+	VideoID      string
+	Organization string    // Uploading organization; may be empty
+	UploadedAt   time.Time // Zero means now
+}
+
+/**
+ * PathStrategy decides where the files of a match are stored. All files of
+ * a match share one directory below "videos/", so the orphaned file
+ * collector finds them whatever the strategy.
+ */
+type PathStrategy interface {
+	Dir(info StoragePathInfo) string
+}
+
+/**
+ * IDShardStrategy spreads matches over directories named after the first
+ * characters of their IDs: videos/ab/cd/abcd1234-...
+ */
+type IDShardStrategy struct{}
+
+/**
+ * Dir implements PathStrategy.
+ */
+func (IDShardStrategy) Dir(info StoragePathInfo) string {
+	return filepath.Join("videos", idShard(info.VideoID), info.VideoID)
+}
+
+/**
+ * DateTreeStrategy groups matches by upload date:
+ * videos/2024/05/01/abcd1234-...
+ */
+type DateTreeStrategy struct{}
+
+/**
+ * Dir implements PathStrategy.
+ */
+func (DateTreeStrategy) Dir(info StoragePathInfo) string {
+	uploadedAt := info.UploadedAt
+	if uploadedAt.IsZero() {
+		uploadedAt = time.Now()
+	}
+	return filepath.Join("videos", uploadedAt.UTC().Format("2006/01/02"), info.VideoID)
+}
+
+/**
+ * OrgPrefixedStrategy keeps each organization's matches together, sharded
+ * by ID below the organization: videos/acme/ab/cd/abcd1234-...
+ * Matches uploaded without an organization go under "default".
+ */
+type OrgPrefixedStrategy struct{}
+
+/**
+ * Dir implements PathStrategy.
+ */
+func (OrgPrefixedStrategy) Dir(info StoragePathInfo) string {
+	return filepath.Join("videos", pathSegment(info.Organization), idShard(info.VideoID), info.VideoID)
+}
+
+/**
+ * NewPathStrategy returns the strategy with the given name; an empty name
+ * selects the ID-sharded layout.
+ *
+ * @param name "id_shard", "date_tree" or "org_prefixed"
+ * @return The path strategy, or an error for unknown names
+ */
+func NewPathStrategy(name string) (PathStrategy, error) {
+	switch name {
+	case "", PathStrategyIDShard:
+		return IDShardStrategy{}, nil
+	case PathStrategyDateTree:
+		return DateTreeStrategy{}, nil
+	case PathStrategyOrgPrefixed:
+		return OrgPrefixedStrategy{}, nil
+	default:
+		return nil, fmt.Errorf("unknown storage path strategy %q", name)
+	}
+}
+
+/**
+ * MatchFileName names a stored match file after its match and kind,
+ * keeping the extension of video files.
+ *
+ * @param videoID The ID of the match
+ * @param kind The kind of file ("video", "tracking" or "events")
+ * @param originalFilename The name the file was uploaded with
+ * @return The file name within the match's directory
+ */
+func MatchFileName(videoID, kind, originalFilename string) string {
+	switch kind {
+	case models.FileKindTracking:
+		return videoID + "_tracking.gzip"
+	case models.FileKindEvents:
+		return videoID + "_events.gzip"
+	default:
+		return videoID + filepath.Ext(originalFilename)
+	}
+}
+
+// idShard returns the two directory levels of a match ID: "ab/cd".
+func idShard(videoID string) string {
+	if len(videoID) < 4 {
+		return filepath.Join("_", "_")
+	}
+	return filepath.Join(videoID[0:2], videoID[2:4])
+}
+
+// pathSegment makes a value safe to use as a single path segment.
+func pathSegment(value string) string {
+	if value == "" {
+		return "default"
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, value)
+}
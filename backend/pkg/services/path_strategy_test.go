@@ -0,0 +1,48 @@
+package services_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPathStrategy(t *testing.T) {
+	info := services.StoragePathInfo{
+		VideoID:      "abcd1234-0000-0000-0000-000000000000",
+		Organization: "acme/../fc",
+		UploadedAt:   time.Date(2024, 5, 1, 23, 30, 0, 0, time.FixedZone("CEST", 2*3600)),
+	}
+
+	t.Run("Each strategy lays out match directories below videos/", func(t *testing.T) {
+		for name, want := range map[string]string{
+			"":             "videos/ab/cd/" + info.VideoID,
+			"id_shard":     "videos/ab/cd/" + info.VideoID,
+			"date_tree":    "videos/2024/05/01/" + info.VideoID,
+			"org_prefixed": "videos/acme____fc/ab/cd/" + info.VideoID,
+		} {
+			strategy, err := services.NewPathStrategy(name)
+			require.NoError(t, err, name)
+			assert.Equal(t, want, filepath.ToSlash(strategy.Dir(info)), name)
+		}
+
+		_, err := services.NewPathStrategy("by_team")
+		assert.Error(t, err)
+	})
+
+	t.Run("Matches without an organization share a default prefix", func(t *testing.T) {
+		dir := services.OrgPrefixedStrategy{}.Dir(services.StoragePathInfo{VideoID: info.VideoID})
+		assert.Equal(t, "videos/default/ab/cd/"+info.VideoID, filepath.ToSlash(dir))
+	})
+
+	t.Run("Files are named after their match and kind", func(t *testing.T) {
+		assert.Equal(t, "m1.mp4", services.MatchFileName("m1", models.FileKindVideo, "final.mp4"))
+		assert.Equal(t, "m1_tracking.gzip", services.MatchFileName("m1", models.FileKindTracking, "tracking.json"))
+		assert.Equal(t, "m1_events.gzip", services.MatchFileName("m1", models.FileKindEvents, "events.xml"))
+	})
+}
@@ -0,0 +1,299 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/pythonapi"
+)
+
+/**
+ * StatDistribution describes one statistic across a player's matches.
+ */
+type StatDistribution struct {
+	Mean float64 `json:"mean"`
+	Min  float64 `json:"min"`
+	Max  float64 `json:"max"`
+	P25  float64 `json:"p25"`
+	P50  float64 `json:"p50"`
+	P75  float64 `json:"p75"`
+	P90  float64 `json:"p90"`
+}
+
+/**
+ * PlayerMatchStats holds a player's summary statistics for a single match,
+ * forming one point of the player's time series.
+ */
+type PlayerMatchStats struct {
+	MatchID          string             `json:"match_id"`
+	TrackingPlayerID string             `json:"tracking_player_id"` // The player's ID in the match's tracking data
+	MatchDate        time.Time          `json:"match_date"`
+	HomeTeam         string             `json:"home_team,omitempty"`
+	AwayTeam         string             `json:"away_team,omitempty"`
+	Competition      string             `json:"competition,omitempty"`
+	Stats            map[string]float64 `json:"stats"`
+}
+
+/**
+ * PlayerAggregate summarises a player's statistics over all processed
+ * matches they appear in within a date range.
+ */
+type PlayerAggregate struct {
+	PlayerID           string                      `json:"player_id"`
+	From               *time.Time                  `json:"from,omitempty"`
+	To                 time.Time                   `json:"to"`
+	MatchesPlayed      int                         `json:"matches_played"`
+	Stats              map[string]StatDistribution `json:"stats"`
+	Matches            []PlayerMatchStats          `json:"matches"`
+	UnavailableMatches []string                    `json:"unavailable_matches,omitempty"`
+}
+
+/**
+ * PlayerAggregateService builds longitudinal player analytics from the
+ * per-match summaries.
+ */
+type PlayerAggregateService struct {
+	videoRepo models.VideoRepository
+	analytics AnalyticsReader
+	mappings  models.PlayerMappingRepository
+}
+
+/**
+ * PlayerAggregateOption configures optional behaviour of the player aggregate service.
+ */
+type PlayerAggregateOption func(*PlayerAggregateService)
+
+/**
+ * WithPlayerMappings lets roster player IDs be aggregated: in each match
+ * the roster player is mapped to, its tracking player's statistics are
+ * used. IDs that are no mapped roster player are looked up as tracking
+ * player IDs in every match.
+ *
+ * @param mappings Repository for the mappings of tracking players
+ * @return The option
+ */
+func WithPlayerMappings(mappings models.PlayerMappingRepository) PlayerAggregateOption {
+	return func(s *PlayerAggregateService) {
+		s.mappings = mappings
+	}
+}
+
+/**
+ * NewPlayerAggregateService creates a new player aggregate service.
+ *
+ * @param videoRepo Repository used to find matches in the date range
+ * @param analytics Source of match summaries, normally the analytics cache
+ * @param opts Optional behaviour
+ * @return A new player aggregate service
+ */
+func NewPlayerAggregateService(videoRepo models.VideoRepository, analytics AnalyticsReader, opts ...PlayerAggregateOption) *PlayerAggregateService {
+	s := &PlayerAggregateService{videoRepo: videoRepo, analytics: analytics}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+/**
+ * Aggregate collects the player's statistics from every processed match
+ * between from and to (inclusive) within scope whose summary lists the
+ * player. A zero from covers all earlier matches. Matches whose summary cannot be fetched
+ * are listed in UnavailableMatches; an error is returned only if the matches
+ * cannot be listed or no summary at all could be fetched.
+ *
+ * @param ctx Context for the upstream requests
+ * @param playerID The player to aggregate: a roster or tracking player ID
+ * @param from Start of the range, or the zero time
+ * @param to End of the range
+ * @param scope The matches the caller may see; nil for all
+ * @return The player aggregate, or an error
+ */
+func (s *PlayerAggregateService) Aggregate(ctx context.Context, playerID string, from, to time.Time, scope *models.AccessScope) (*PlayerAggregate, error) {
+	videos, err := processedMatches(func(limit, offset int) ([]*models.Video, error) {
+		return s.videoRepo.FindByDateRange(from, to, limit, offset)
+	}, scope.Allows)
+	if err != nil {
+		return nil, err
+	}
+	trackingIDs, err := s.trackingPlayerIDs(playerID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &PlayerAggregate{
+		PlayerID: playerID,
+		To:       to,
+		Stats:    map[string]StatDistribution{},
+		Matches:  []PlayerMatchStats{},
+	}
+	if !from.IsZero() {
+		result.From = &from
+	}
+
+	stats := make([]map[string]float64, len(videos))
+	errs := make([]error, len(videos))
+	forEachConcurrently(len(videos), func(i int) {
+		stats[i], errs[i] = s.matchPlayerStats(ctx, videos[i].ID, trackingID(trackingIDs, videos[i].ID, playerID))
+	})
+
+	var firstErr error
+	for i, video := range videos {
+		if errs[i] != nil {
+			if !errors.Is(errs[i], pythonapi.ErrNotFound) {
+				log.Printf("Player aggregate: summary for match %s unavailable: %v", video.ID, errs[i])
+				if firstErr == nil {
+					firstErr = errs[i]
+				}
+			}
+			result.UnavailableMatches = append(result.UnavailableMatches, video.ID)
+			continue
+		}
+		if stats[i] == nil {
+			continue // Player did not appear in this match
+		}
+		result.Matches = append(result.Matches, PlayerMatchStats{
+			MatchID:          video.ID,
+			TrackingPlayerID: trackingID(trackingIDs, video.ID, playerID),
+			MatchDate:        video.MatchDate,
+			HomeTeam:         video.HomeTeam,
+			AwayTeam:         video.AwayTeam,
+			Competition:      video.Competition,
+			Stats:            stats[i],
+		})
+	}
+
+	if len(result.Matches) == 0 && firstErr != nil {
+		return nil, firstErr
+	}
+
+	sort.SliceStable(result.Matches, func(i, j int) bool {
+		return result.Matches[i].MatchDate.Before(result.Matches[j].MatchDate)
+	})
+	result.MatchesPlayed = len(result.Matches)
+
+	values := map[string][]float64{}
+	for _, match := range result.Matches {
+		for name, value := range match.Stats {
+			values[name] = append(values[name], value)
+		}
+	}
+	for name, series := range values {
+		result.Stats[name] = distribution(series)
+	}
+	return result, nil
+}
+
+/**
+ * trackingPlayerIDs returns the tracking player IDs a roster player is
+ * mapped to, by match. Without mappings, or for IDs that are no mapped
+ * roster player, it is empty.
+ *
+ * @param playerID The player to aggregate
+ * @return The tracking player IDs by match ID, or an error
+ */
+func (s *PlayerAggregateService) trackingPlayerIDs(playerID string) (map[string]string, error) {
+	ids := map[string]string{}
+	if s.mappings == nil {
+		return ids, nil
+	}
+	mappings, err := s.mappings.FindByPlayerID(playerID)
+	if err != nil {
+		return nil, err
+	}
+	for _, mapping := range mappings {
+		ids[mapping.VideoID] = mapping.TrackingPlayerID
+	}
+	return ids, nil
+}
+
+/**
+ * trackingID returns the tracking player ID of a match, falling back to
+ * playerID itself.
+ *
+ * @param ids Tracking player IDs by match ID
+ * @param matchID The match
+ * @param playerID The aggregated player ID
+ * @return The ID to look up in the match summary
+ */
+func trackingID(ids map[string]string, matchID, playerID string) string {
+	if id, ok := ids[matchID]; ok {
+		return id
+	}
+	return playerID
+}
+
+/**
+ * matchPlayerStats extracts the numeric summary statistics of one player
+ * from a match summary. It returns nil stats if the player does not appear.
+ *
+ * @param ctx Context for the upstream request
+ * @param matchID The match to read
+ * @param playerID The player whose statistics are wanted
+ * @return The player's statistics, nil if absent, or an error
+ */
+func (s *PlayerAggregateService) matchPlayerStats(ctx context.Context, matchID, playerID string) (map[string]float64, error) {
+	summary, err := s.analytics.GetMatchSummary(ctx, matchID)
+	if err != nil {
+		return nil, err
+	}
+
+	var players map[string]map[string]interface{}
+	if err := json.Unmarshal(summary.Players, &players); err != nil {
+		return nil, fmt.Errorf("%w: player summaries: %v", pythonapi.ErrInvalidResponse, err)
+	}
+	raw, ok := players[playerID]
+	if !ok {
+		return nil, nil
+	}
+	return numericStats(raw), nil
+}
+
+/**
+ * distribution computes the mean, range and percentiles of a series.
+ * Percentiles are linearly interpolated between the closest ranks.
+ *
+ * @param series The values, at least one
+ * @return The distribution of the series
+ */
+func distribution(series []float64) StatDistribution {
+	sorted := append([]float64(nil), series...)
+	sort.Float64s(sorted)
+
+	sum := 0.0
+	for _, v := range sorted {
+		sum += v
+	}
+	return StatDistribution{
+		Mean: sum / float64(len(sorted)),
+		Min:  sorted[0],
+		Max:  sorted[len(sorted)-1],
+		P25:  percentile(sorted, 0.25),
+		P50:  percentile(sorted, 0.50),
+		P75:  percentile(sorted, 0.75),
+		P90:  percentile(sorted, 0.90),
+	}
+}
+
+/**
+ * percentile returns the p-quantile (0..1) of sorted values.
+ *
+ * @param sorted Values in ascending order, at least one
+ * @param p The quantile
+ * @return The interpolated value
+ */
+func percentile(sorted []float64, p float64) float64 {
+	rank := p * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	if lower == upper {
+		return sorted[lower]
+	}
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(rank-float64(lower))
+}
@@ -0,0 +1,129 @@
+package services_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/pythonapi"
+	"nivai/backend/pkg/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// playerSummaryReader serves per-match player summaries; matches without an
+// entry return 404.
+type playerSummaryReader struct {
+	fakeAnalyticsReader
+	players map[string]string
+}
+
+func (f *playerSummaryReader) GetMatchSummary(ctx context.Context, matchID string) (*pythonapi.MatchSummary, error) {
+	players, ok := f.players[matchID]
+	if !ok {
+		return nil, &pythonapi.APIError{StatusCode: 404, Detail: "Match data not processed or match ID not found."}
+	}
+	return &pythonapi.MatchSummary{MatchID: matchID, Players: json.RawMessage(players), Teams: json.RawMessage(`{}`)}, nil
+}
+
+func TestPlayerAggregateService_Aggregate(t *testing.T) {
+	ctx := context.Background()
+	from := time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)
+	day := func(d int) time.Time { return time.Date(2024, 9, d, 15, 0, 0, 0, time.UTC) }
+
+	t.Run("Statistics are collected from matches the player appears in", func(t *testing.T) {
+		repo := new(MockVideoRepository)
+		repo.On("FindByDateRange", from, to, 100, 0).Return([]*models.Video{
+			{ID: "m4", MatchDate: day(28), ProcessingState: "completed"},
+			{ID: "m3", MatchDate: day(21), ProcessingState: "completed"},
+			{ID: "m2", MatchDate: day(14), ProcessingState: "completed"},
+			{ID: "m1", MatchDate: day(7), ProcessingState: "completed"},
+			{ID: "benched", MatchDate: day(3), ProcessingState: "completed"},
+			{ID: "pending", MatchDate: day(30), ProcessingState: "processing"},
+		}, nil).Once()
+		reader := &playerSummaryReader{players: map[string]string{
+			"m1":      `{"p7":{"total_distance_m":9000,"max_speed_kmh":30}}`,
+			"m2":      `{"p7":{"total_distance_m":10000,"max_speed_kmh":32}}`,
+			"m3":      `{"p7":{"total_distance_m":11000,"max_speed_kmh":31}}`,
+			"m4":      `{"p7":{"total_distance_m":12000,"max_speed_kmh":null}}`,
+			"benched": `{"p9":{"total_distance_m":8000}}`,
+		}}
+
+		aggregate, err := services.NewPlayerAggregateService(repo, reader).Aggregate(ctx, "p7", from, to, nil)
+		require.NoError(t, err)
+
+		assert.Equal(t, 4, aggregate.MatchesPlayed)
+		require.Len(t, aggregate.Matches, 4)
+		assert.Equal(t, "m1", aggregate.Matches[0].MatchID)
+		assert.Equal(t, "m4", aggregate.Matches[3].MatchID)
+		assert.NotContains(t, aggregate.Matches[3].Stats, "max_speed_kmh")
+
+		distance := aggregate.Stats["total_distance_m"]
+		assert.Equal(t, 10500.0, distance.Mean)
+		assert.Equal(t, 9000.0, distance.Min)
+		assert.Equal(t, 12000.0, distance.Max)
+		assert.Equal(t, 9750.0, distance.P25)
+		assert.Equal(t, 10500.0, distance.P50)
+		assert.InDelta(t, 11700.0, distance.P90, 1e-9)
+
+		speed := aggregate.Stats["max_speed_kmh"]
+		assert.Equal(t, 31.0, speed.P50)
+		assert.Equal(t, 32.0, speed.Max)
+		assert.Empty(t, aggregate.UnavailableMatches)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Roster players are followed through their mappings", func(t *testing.T) {
+		repo := new(MockVideoRepository)
+		repo.On("FindByDateRange", from, to, 100, 0).Return([]*models.Video{
+			{ID: "m2", MatchDate: day(14), ProcessingState: "completed"},
+			{ID: "m1", MatchDate: day(7), ProcessingState: "completed"},
+		}, nil).Once()
+		reader := &playerSummaryReader{players: map[string]string{
+			"m1": `{"home_10":{"total_distance_m":9000}}`,
+			"m2": `{"away_7":{"total_distance_m":11000},"home_10":{"total_distance_m":1}}`,
+		}}
+		mappings := models.NewMemoryPlayerMappingRepository()
+		require.NoError(t, mappings.Save(&models.PlayerMapping{VideoID: "m1", TrackingPlayerID: "home_10", PlayerID: "tadic"}))
+		require.NoError(t, mappings.Save(&models.PlayerMapping{VideoID: "m2", TrackingPlayerID: "away_7", PlayerID: "tadic"}))
+
+		aggregate, err := services.NewPlayerAggregateService(repo, reader, services.WithPlayerMappings(mappings)).Aggregate(ctx, "tadic", from, to, nil)
+		require.NoError(t, err)
+
+		require.Len(t, aggregate.Matches, 2)
+		assert.Equal(t, "home_10", aggregate.Matches[0].TrackingPlayerID)
+		assert.Equal(t, "away_7", aggregate.Matches[1].TrackingPlayerID)
+		assert.Equal(t, 10000.0, aggregate.Stats["total_distance_m"].Mean)
+	})
+
+	t.Run("Only matches within the caller's scope are aggregated", func(t *testing.T) {
+		repo := new(MockVideoRepository)
+		repo.On("FindByDateRange", from, to, 100, 0).Return([]*models.Video{
+			{ID: "m2", MatchDate: day(14), ProcessingState: "completed", HomeTeam: "AZ", AwayTeam: "Feyenoord"},
+			{ID: "m1", MatchDate: day(7), ProcessingState: "completed", HomeTeam: "Ajax", AwayTeam: "PSV"},
+		}, nil).Once()
+		reader := &playerSummaryReader{players: map[string]string{
+			"m1": `{"p7":{"total_distance_m":9000}}`,
+			"m2": `{"p7":{"total_distance_m":11000}}`,
+		}}
+
+		aggregate, err := services.NewPlayerAggregateService(repo, reader).Aggregate(ctx, "p7", from, to, &models.AccessScope{Teams: []string{"psv"}})
+		require.NoError(t, err)
+		require.Len(t, aggregate.Matches, 1)
+		assert.Equal(t, "m1", aggregate.Matches[0].MatchID)
+		assert.Equal(t, 9000.0, aggregate.Stats["total_distance_m"].Mean)
+	})
+
+	t.Run("Repository errors are returned", func(t *testing.T) {
+		repo := new(MockVideoRepository)
+		repo.On("FindByDateRange", from, to, 100, 0).Return(nil, errors.New("db down")).Once()
+
+		_, err := services.NewPlayerAggregateService(repo, &playerSummaryReader{}).Aggregate(ctx, "p7", from, to, nil)
+		assert.EqualError(t, err, "db down")
+	})
+}
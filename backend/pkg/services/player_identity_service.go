@@ -0,0 +1,293 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"nivai/backend/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrInvalidPlayer is returned when a roster player has no name.
+	ErrInvalidPlayer = errors.New("invalid player")
+	// ErrPlayerMappingUnavailable is returned when a match's files cannot be
+	// read to map its players, because they are missing, archived or not in
+	// the canonical format.
+	ErrPlayerMappingUnavailable = errors.New("match files cannot be read to map players")
+)
+
+/**
+ * PlayerIdentityService keeps the player roster and maps the player IDs of
+ * each match's tracking data to it, so players can be followed across
+ * matches by their roster ID. Mappings are set by hand, or derived from a
+ * match's files by AutoMap.
+ */
+type PlayerIdentityService struct {
+	players   models.PlayerRepository
+	mappings  models.PlayerMappingRepository
+	videoRepo models.VideoRepository
+	storage   StorageService
+	names     *FieldCipher
+	now       func() time.Time
+}
+
+/**
+ * PlayerIdentityOption configures optional behaviour of the player identity service.
+ */
+type PlayerIdentityOption func(*PlayerIdentityService)
+
+/**
+ * WithNameCipher stores player names encrypted. Names are only decrypted
+ * for the roster endpoints; mapping never reads them.
+ *
+ * @param names The cipher of the names
+ * @return The option
+ */
+func WithNameCipher(names *FieldCipher) PlayerIdentityOption {
+	return func(s *PlayerIdentityService) {
+		s.names = names
+	}
+}
+
+/**
+ * NewPlayerIdentityService creates a new player identity service.
+ *
+ * @param players Repository for the roster
+ * @param mappings Repository for the mappings of tracking players
+ * @param videoRepo Repository for video data
+ * @param storage Storage holding the match files, read by AutoMap
+ * @param opts Optional behaviour
+ * @return A new player identity service
+ */
+func NewPlayerIdentityService(players models.PlayerRepository, mappings models.PlayerMappingRepository, videoRepo models.VideoRepository, storage StorageService, opts ...PlayerIdentityOption) *PlayerIdentityService {
+	s := &PlayerIdentityService{players: players, mappings: mappings, videoRepo: videoRepo, storage: storage, now: time.Now}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+/**
+ * CreatePlayer adds a player to the roster with a new ID.
+ *
+ * @param player The player; Name is required
+ * @return An error, ErrInvalidPlayer when the player has no name
+ */
+func (s *PlayerIdentityService) CreatePlayer(player *models.Player) error {
+	player.Name = strings.TrimSpace(player.Name)
+	if player.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidPlayer)
+	}
+	if player.JerseyNumber < 0 {
+		return fmt.Errorf("%w: jersey_number must not be negative", ErrInvalidPlayer)
+	}
+	if player.ExternalIDs == nil {
+		player.ExternalIDs = []string{}
+	}
+	now := s.now().UTC()
+	player.ID = uuid.New().String()
+	player.CreatedAt = now
+	player.UpdatedAt = now
+
+	stored := *player
+	name, err := s.names.Encrypt(player.Name)
+	if err != nil {
+		return err
+	}
+	stored.Name = name
+	return s.players.Create(&stored)
+}
+
+/**
+ * GetPlayer retrieves a roster player.
+ *
+ * @param id The player ID
+ * @return The player, or models.ErrPlayerNotFound
+ */
+func (s *PlayerIdentityService) GetPlayer(id string) (*models.Player, error) {
+	player, err := s.players.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if player.Name, err = s.names.Decrypt(player.Name); err != nil {
+		return nil, err
+	}
+	return player, nil
+}
+
+/**
+ * ListPlayers lists the roster by name. With encrypted names the database
+ * cannot sort them, so the roster is listed in the order players were
+ * added instead, and only the page listed is decrypted.
+ *
+ * @param team Only list this team's players, unless empty
+ * @param limit Maximum number of players
+ * @param offset Number of players to skip
+ * @return The players or error
+ */
+func (s *PlayerIdentityService) ListPlayers(team string, limit, offset int) ([]*models.Player, error) {
+	if s.names == nil {
+		return s.players.FindAll(team, limit, offset)
+	}
+
+	players, err := s.players.FindAllByCreation(team, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	for _, player := range players {
+		if player.Name, err = s.names.Decrypt(player.Name); err != nil {
+			return nil, err
+		}
+	}
+	return players, nil
+}
+
+/**
+ * MatchMappings lists the mappings of a match's tracking players.
+ *
+ * @param matchID The match
+ * @return The mappings, or ErrVideoNotFound
+ */
+func (s *PlayerIdentityService) MatchMappings(matchID string) ([]*models.PlayerMapping, error) {
+	if _, err := s.match(matchID); err != nil {
+		return nil, err
+	}
+	return s.mappings.FindByVideoID(matchID)
+}
+
+/**
+ * MapPlayer maps a tracking player of a match to a roster player by hand.
+ * Manual mappings are never replaced by AutoMap. A mapping of the roster
+ * player to another tracking player of the match is removed.
+ *
+ * @param matchID The match
+ * @param trackingPlayerID The player's ID in the match's tracking data
+ * @param playerID The roster player
+ * @return The mapping, or ErrVideoNotFound or models.ErrPlayerNotFound
+ */
+func (s *PlayerIdentityService) MapPlayer(matchID, trackingPlayerID, playerID string) (*models.PlayerMapping, error) {
+	if _, err := s.match(matchID); err != nil {
+		return nil, err
+	}
+	if _, err := s.players.FindByID(playerID); err != nil {
+		return nil, err
+	}
+	now := s.now().UTC()
+	mapping := &models.PlayerMapping{
+		VideoID: matchID, TrackingPlayerID: trackingPlayerID, PlayerID: playerID,
+		Source: models.MappingSourceManual, Confidence: 1, CreatedAt: now, UpdatedAt: now,
+	}
+	if err := s.mappings.Save(mapping); err != nil {
+		return nil, err
+	}
+	return mapping, nil
+}
+
+/**
+ * UnmapPlayer removes the mapping of a match's tracking player.
+ *
+ * @param matchID The match
+ * @param trackingPlayerID The player's ID in the match's tracking data
+ * @return An error, models.ErrPlayerMappingNotFound when it is not mapped
+ */
+func (s *PlayerIdentityService) UnmapPlayer(matchID, trackingPlayerID string) error {
+	return s.mappings.Delete(matchID, trackingPlayerID)
+}
+
+/**
+ * TrackingPlayerID returns the ID a player has in a match's tracking data:
+ * the tracking player a roster player is mapped to, or the ID itself when
+ * it is no mapped roster player, so tracking IDs keep working.
+ *
+ * @param matchID The match
+ * @param playerID A roster player ID or a tracking player ID
+ * @return The tracking player ID or error
+ */
+func (s *PlayerIdentityService) TrackingPlayerID(matchID, playerID string) (string, error) {
+	mappings, err := s.mappings.FindByPlayerID(playerID)
+	if err != nil {
+		return "", err
+	}
+	for _, mapping := range mappings {
+		if mapping.VideoID == matchID {
+			return mapping.TrackingPlayerID, nil
+		}
+	}
+	return playerID, nil
+}
+
+/**
+ * AutoMap maps the tracking players of a match to the roster of its home
+ * and away team, by the heuristics of suggestMappings, and stores the
+ * mappings. Manual mappings are kept, as are the tracking and roster
+ * players they name; earlier automatic mappings are replaced.
+ *
+ * @param ctx Context for reading the match files
+ * @param matchID The match
+ * @return All mappings of the match, or ErrVideoNotFound or ErrPlayerMappingUnavailable
+ */
+func (s *PlayerIdentityService) AutoMap(ctx context.Context, matchID string) ([]*models.PlayerMapping, error) {
+	video, err := s.match(matchID)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := s.mappings.FindByVideoID(matchID)
+	if err != nil {
+		return nil, err
+	}
+	suggestions, err := s.suggestMappings(ctx, video)
+	if err != nil {
+		return nil, err
+	}
+
+	mappedTracking := map[string]bool{}
+	mappedPlayers := map[string]bool{}
+	for _, mapping := range existing {
+		if mapping.Source == models.MappingSourceManual {
+			mappedTracking[mapping.TrackingPlayerID] = true
+			mappedPlayers[mapping.PlayerID] = true
+		} else if err := s.mappings.Delete(matchID, mapping.TrackingPlayerID); err != nil && !errors.Is(err, models.ErrPlayerMappingNotFound) {
+			return nil, err
+		}
+	}
+
+	// The most certain suggestions are taken first
+	sort.SliceStable(suggestions, func(i, j int) bool { return suggestions[i].Confidence > suggestions[j].Confidence })
+	now := s.now().UTC()
+	mapped := 0
+	for _, suggestion := range suggestions {
+		if mappedTracking[suggestion.TrackingPlayerID] || mappedPlayers[suggestion.PlayerID] {
+			continue
+		}
+		suggestion.VideoID = matchID
+		suggestion.CreatedAt = now
+		suggestion.UpdatedAt = now
+		if err := s.mappings.Save(suggestion); err != nil {
+			return nil, err
+		}
+		mappedTracking[suggestion.TrackingPlayerID] = true
+		mappedPlayers[suggestion.PlayerID] = true
+		mapped++
+	}
+	log.Printf("Player identity: mapped %d tracking players of match %s automatically", mapped, matchID)
+	return s.mappings.FindByVideoID(matchID)
+}
+
+// match retrieves a match, mapping a missing one to ErrVideoNotFound.
+func (s *PlayerIdentityService) match(matchID string) (*models.Video, error) {
+	video, err := s.videoRepo.FindByID(matchID)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, ErrVideoNotFound
+		}
+		return nil, err
+	}
+	return video, nil
+}
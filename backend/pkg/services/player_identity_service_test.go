@@ -0,0 +1,176 @@
+package services_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"nivai/backend/pkg/ingest/adapters"
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/services"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newPlayerIdentityService serves match m1, Ajax at home against PSV, with
+// canonical tracking and event files in memory storage.
+func newPlayerIdentityService(t *testing.T, tracking []adapters.TrackingRow, events []adapters.EventRow) (*services.PlayerIdentityService, *models.MemoryPlayerMappingRepository) {
+	t.Helper()
+	storage := services.NewMemoryStorageService()
+	for path, write := range map[string]func(*bytes.Buffer) error{
+		"videos/m1/m1_tracking.gzip": func(b *bytes.Buffer) error { return parquet.Write(b, tracking) },
+		"videos/m1/m1_events.gzip":   func(b *bytes.Buffer) error { return parquet.Write(b, events) },
+	} {
+		var content bytes.Buffer
+		require.NoError(t, write(&content))
+		_, err := storage.UploadStream(&content, path)
+		require.NoError(t, err)
+	}
+	videoRepo := models.NewMemoryVideoRepository()
+	require.NoError(t, videoRepo.Create(&models.Video{
+		ID: "m1", Title: "Ajax - PSV", HomeTeam: "Ajax", AwayTeam: "PSV", ProcessingState: models.StateCompleted,
+		TrackingPath: "videos/m1/m1_tracking.gzip", EventFilePath: "videos/m1/m1_events.gzip",
+	}))
+	mappings := models.NewMemoryPlayerMappingRepository()
+	return services.NewPlayerIdentityService(models.NewMemoryPlayerRepository(), mappings, videoRepo, storage), mappings
+}
+
+// createPlayer adds a player to the roster and returns its ID.
+func createPlayer(t *testing.T, identities *services.PlayerIdentityService, player models.Player) string {
+	t.Helper()
+	require.NoError(t, identities.CreatePlayer(&player))
+	return player.ID
+}
+
+func TestPlayerIdentityService(t *testing.T) {
+	t.Run("Manual mappings resolve roster players per match", func(t *testing.T) {
+		identities, _ := newPlayerIdentityService(t, nil, nil)
+		playerID := createPlayer(t, identities, models.Player{Name: "Steven Berghuis", Team: "Ajax", JerseyNumber: 23})
+
+		_, err := identities.MapPlayer("m1", "home_23", playerID)
+		require.NoError(t, err)
+		mapping, err := identities.MapPlayer("m1", "h7", playerID)
+		require.NoError(t, err)
+		assert.Equal(t, models.MappingSourceManual, mapping.Source)
+
+		mappings, err := identities.MatchMappings("m1")
+		require.NoError(t, err)
+		require.Len(t, mappings, 1, "a roster player maps to one tracking player per match")
+		assert.Equal(t, "h7", mappings[0].TrackingPlayerID)
+
+		trackingID, err := identities.TrackingPlayerID("m1", playerID)
+		require.NoError(t, err)
+		assert.Equal(t, "h7", trackingID)
+		trackingID, err = identities.TrackingPlayerID("m1", "away_4")
+		require.NoError(t, err)
+		assert.Equal(t, "away_4", trackingID, "tracking IDs resolve to themselves")
+
+		require.NoError(t, identities.UnmapPlayer("m1", "h7"))
+		assert.ErrorIs(t, identities.UnmapPlayer("m1", "h7"), models.ErrPlayerMappingNotFound)
+	})
+
+	t.Run("Names are stored encrypted and listed a page at a time", func(t *testing.T) {
+		keyring, err := services.ParseKeyring("2024:"+base64Key(1), "")
+		require.NoError(t, err)
+		players := models.NewMemoryPlayerRepository()
+		identities := services.NewPlayerIdentityService(players, models.NewMemoryPlayerMappingRepository(), models.NewMemoryVideoRepository(),
+			services.NewMemoryStorageService(), services.WithNameCipher(services.NewFieldCipher(keyring)))
+		for _, name := range []string{"Steven Berghuis", "Davy Klaassen", "Brian Brobbey"} {
+			createPlayer(t, identities, models.Player{Name: name, Team: "Ajax"})
+		}
+
+		stored, err := players.FindAll("", 10, 0)
+		require.NoError(t, err)
+		for _, player := range stored {
+			assert.True(t, strings.HasPrefix(player.Name, "enc:v1:"), "the database holds no names")
+		}
+
+		// The database cannot sort encrypted names, so pages follow the order
+		// players were added, and only the page is decrypted
+		first, err := identities.ListPlayers("Ajax", 2, 0)
+		require.NoError(t, err)
+		require.Len(t, first, 2)
+		rest, err := identities.ListPlayers("Ajax", 2, 2)
+		require.NoError(t, err)
+		require.Len(t, rest, 1)
+		var names []string
+		for _, player := range append(first, rest...) {
+			names = append(names, player.Name)
+		}
+		assert.ElementsMatch(t, []string{"Steven Berghuis", "Davy Klaassen", "Brian Brobbey"}, names)
+		player, err := identities.GetPlayer(first[0].ID)
+		require.NoError(t, err)
+		assert.Equal(t, first[0].Name, player.Name)
+	})
+
+	t.Run("Unknown matches, players and names are refused", func(t *testing.T) {
+		identities, _ := newPlayerIdentityService(t, nil, nil)
+		playerID := createPlayer(t, identities, models.Player{Name: "Davy Klaassen"})
+
+		_, err := identities.MapPlayer("missing", "home_10", playerID)
+		assert.ErrorIs(t, err, services.ErrVideoNotFound)
+		_, err = identities.MapPlayer("m1", "home_10", "missing")
+		assert.ErrorIs(t, err, models.ErrPlayerNotFound)
+		assert.ErrorIs(t, identities.CreatePlayer(&models.Player{Name: " "}), services.ErrInvalidPlayer)
+	})
+
+	t.Run("Automatic mapping uses event data and jersey numbers", func(t *testing.T) {
+		var tracking []adapters.TrackingRow
+		var events []adapters.EventRow
+		for frame := int64(0); frame < 5; frame++ {
+			ms := frame * 1000
+			tracking = append(tracking,
+				adapters.TrackingRow{PlayerID: "home_10", TeamID: "home", TimestampMS: ms, X: float64(frame), Y: 0},
+				adapters.TrackingRow{PlayerID: "home_7", TeamID: "home", TimestampMS: ms, X: -20, Y: 10},
+				adapters.TrackingRow{PlayerID: "away_4", TeamID: "away", TimestampMS: ms, X: 0.5, Y: 0},
+			)
+			events = append(events, adapters.EventRow{
+				EventID: "e", EventType: "PASS", PlayerID: "5503", TeamID: "home", TimestampMS: ms + 40,
+				StartX: float64(frame) + 0.5, StartY: 0,
+			})
+		}
+		identities, mappings := newPlayerIdentityService(t, tracking, events)
+		byEvents := createPlayer(t, identities, models.Player{Name: "Dusan Tadic", Team: "Ajax", ExternalIDs: []string{"5503"}})
+		byJersey := createPlayer(t, identities, models.Player{Name: "Edson Alvarez", Team: "ajax", JerseyNumber: 7})
+		manual := createPlayer(t, identities, models.Player{Name: "Luuk de Jong", Team: "PSV", JerseyNumber: 9})
+		createPlayer(t, identities, models.Player{Name: "Other club", Team: "Feyenoord", JerseyNumber: 10})
+		_, err := identities.MapPlayer("m1", "away_4", manual)
+		require.NoError(t, err)
+		require.NoError(t, mappings.Save(&models.PlayerMapping{VideoID: "m1", TrackingPlayerID: "away_99", PlayerID: byJersey, Source: models.MappingSourceJersey}))
+
+		result, err := identities.AutoMap(context.Background(), "m1")
+		require.NoError(t, err)
+
+		byTracking := map[string]*models.PlayerMapping{}
+		for _, mapping := range result {
+			byTracking[mapping.TrackingPlayerID] = mapping
+		}
+		require.Len(t, byTracking, 3, "earlier automatic mappings are replaced")
+		assert.Equal(t, byEvents, byTracking["home_10"].PlayerID)
+		assert.Equal(t, models.MappingSourceEventData, byTracking["home_10"].Source)
+		assert.Equal(t, 1.0, byTracking["home_10"].Confidence)
+		assert.Equal(t, byJersey, byTracking["home_7"].PlayerID)
+		assert.Equal(t, models.MappingSourceJersey, byTracking["home_7"].Source)
+		assert.Equal(t, manual, byTracking["away_4"].PlayerID, "manual mappings are kept")
+		assert.Equal(t, models.MappingSourceManual, byTracking["away_4"].Source)
+	})
+
+	t.Run("Matches without readable tracking data cannot be mapped", func(t *testing.T) {
+		identities, _ := newPlayerIdentityService(t, nil, nil)
+		_, err := identities.AutoMap(context.Background(), "missing")
+		assert.ErrorIs(t, err, services.ErrVideoNotFound)
+
+		storage := services.NewMemoryStorageService()
+		_, err = storage.UploadStream(bytes.NewReader([]byte("player_id,x,y\n")), "videos/m2/m2_tracking.csv")
+		require.NoError(t, err)
+		videoRepo := models.NewMemoryVideoRepository()
+		require.NoError(t, videoRepo.Create(&models.Video{ID: "m2", TrackingPath: "videos/m2/m2_tracking.csv"}))
+		identities = services.NewPlayerIdentityService(models.NewMemoryPlayerRepository(), models.NewMemoryPlayerMappingRepository(), videoRepo, storage)
+
+		_, err = identities.AutoMap(context.Background(), "m2")
+		assert.ErrorIs(t, err, services.ErrPlayerMappingUnavailable)
+	})
+}
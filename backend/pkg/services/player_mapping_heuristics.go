@@ -0,0 +1,311 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"nivai/backend/pkg/ingest/adapters"
+	"nivai/backend/pkg/models"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// Heuristics mapping tracking players to the roster
+const (
+	// eventFrameWindowMS is how far in time a tracking frame may be from an
+	// event to locate the event's player
+	eventFrameWindowMS = 100
+	// eventMaxDistance is how far, in meters, the nearest tracking player
+	// may be from an event's position to be taken as its player
+	eventMaxDistance = 2.0
+	// minEventVotes is how many events must place an event player at the
+	// same tracking player before they are mapped
+	minEventVotes = 3
+	// jerseyConfidence is the confidence of a mapping by jersey number:
+	// tracking IDs are assigned by the provider and may be reused
+	jerseyConfidence = 0.8
+	// trackingBatch is how many tracking rows are read at a time
+	trackingBatch = 4096
+)
+
+// trackingJerseyPattern matches tracking player IDs made of the team and
+// jersey number, as the Tracab adapter writes them: "home_10".
+var trackingJerseyPattern = regexp.MustCompile(`^(home|away)_(\d+)$`)
+
+/**
+ * suggestMappings proposes roster players for the tracking players of a
+ * match, from its canonical tracking and event files and the roster of its
+ * home and away team:
+ *
+ *   - by event data: each event with a player is located in the tracking
+ *     frame at the same time; the tracking player nearest to the event's
+ *     position is taken as the event's player. A roster player whose
+ *     external IDs include the event player is mapped to the tracking
+ *     player most of its events point at, with that share as confidence.
+ *   - by jersey: tracking IDs of team and jersey number ("home_10") map to
+ *     the roster player of that team with that number.
+ *
+ * A tracking or roster player may get several suggestions.
+ *
+ * @param ctx Context for reading the match files
+ * @param video The match
+ * @return The suggested mappings, or ErrPlayerMappingUnavailable
+ */
+func (s *PlayerIdentityService) suggestMappings(ctx context.Context, video *models.Video) ([]*models.PlayerMapping, error) {
+	if video.TrackingPath == "" {
+		return nil, fmt.Errorf("%w: match has no tracking file", ErrPlayerMappingUnavailable)
+	}
+	roster, err := s.loadRoster(video)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []adapters.EventRow
+	if video.EventFilePath != "" {
+		events, err = s.readEvents(ctx, video.EventFilePath)
+		if err != nil {
+			// Jersey numbers still map players without events
+			log.Printf("Player identity: events of match %s not used: %v", video.ID, err)
+		}
+	}
+
+	tracking, err := s.spool(video.TrackingPath)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPlayerMappingUnavailable, err)
+	}
+	defer os.Remove(tracking.Name())
+	defer tracking.Close()
+
+	teams, actors, err := locateEventPlayers(ctx, tracking, events)
+	if err != nil {
+		return nil, fmt.Errorf("%w: tracking file: %v", ErrPlayerMappingUnavailable, err)
+	}
+
+	var suggestions []*models.PlayerMapping
+	for trackingID, team := range teams {
+		if player := roster.byJersey(team, trackingID); player != nil {
+			suggestions = append(suggestions, &models.PlayerMapping{
+				TrackingPlayerID: trackingID, PlayerID: player.ID,
+				Source: models.MappingSourceJersey, Confidence: jerseyConfidence,
+			})
+		}
+	}
+	for eventPlayer, votes := range actors {
+		player := roster.byExternalID(eventPlayer)
+		if player == nil {
+			continue
+		}
+		trackingID, count, total := mostVoted(votes)
+		if count < minEventVotes || count*2 <= total {
+			continue
+		}
+		suggestions = append(suggestions, &models.PlayerMapping{
+			TrackingPlayerID: trackingID, PlayerID: player.ID,
+			Source: models.MappingSourceEventData, Confidence: float64(count) / float64(total),
+		})
+	}
+	// Map iteration order must not decide between equally certain suggestions
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].TrackingPlayerID != suggestions[j].TrackingPlayerID {
+			return suggestions[i].TrackingPlayerID < suggestions[j].TrackingPlayerID
+		}
+		return suggestions[i].Source < suggestions[j].Source
+	})
+	return suggestions, nil
+}
+
+// matchRoster is the roster of a match's teams.
+type matchRoster map[string][]*models.Player // By "home" and "away"
+
+// loadRoster loads the roster of a match's home and away team.
+func (s *PlayerIdentityService) loadRoster(video *models.Video) (matchRoster, error) {
+	roster := matchRoster{}
+	for side, team := range map[string]string{"home": video.HomeTeam, "away": video.AwayTeam} {
+		if team == "" {
+			continue
+		}
+		for offset := 0; ; offset += aggregatePageSize {
+			players, err := s.players.FindAll(team, aggregatePageSize, offset)
+			if err != nil {
+				return nil, err
+			}
+			roster[side] = append(roster[side], players...)
+			if len(players) < aggregatePageSize {
+				break
+			}
+		}
+	}
+	return roster, nil
+}
+
+// byJersey returns the player of a side whose jersey number is in the
+// tracking ID, or nil.
+func (roster matchRoster) byJersey(side, trackingID string) *models.Player {
+	match := trackingJerseyPattern.FindStringSubmatch(trackingID)
+	if match == nil || match[1] != side {
+		return nil
+	}
+	number, _ := strconv.Atoi(match[2])
+	for _, player := range roster[side] {
+		if player.JerseyNumber == number && number > 0 {
+			return player
+		}
+	}
+	return nil
+}
+
+// byExternalID returns the player of either side with the event feed ID,
+// or nil.
+func (roster matchRoster) byExternalID(id string) *models.Player {
+	for _, players := range roster {
+		for _, player := range players {
+			for _, external := range player.ExternalIDs {
+				if strings.EqualFold(external, id) {
+					return player
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// readEvents reads the events with a player from a canonical event file,
+// in time order.
+func (s *PlayerIdentityService) readEvents(ctx context.Context, path string) ([]adapters.EventRow, error) {
+	f, err := s.spool(path)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	var events []adapters.EventRow
+	err = readCanonical(f, func(rows []adapters.EventRow) error {
+		for _, row := range rows {
+			if row.PlayerID != "" {
+				events = append(events, row)
+			}
+		}
+		return ctx.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].TimestampMS < events[j].TimestampMS })
+	return events, nil
+}
+
+// locateEventPlayers reads a canonical tracking file and returns the team
+// of every tracking player, and for every event player how many of their
+// events each tracking player was nearest to. events must be in time order.
+func locateEventPlayers(ctx context.Context, tracking *os.File, events []adapters.EventRow) (map[string]string, map[string]map[string]int, error) {
+	teams := map[string]string{}
+	nearest := make([]string, len(events))
+	distances := make([]float64, len(events))
+	for i := range distances {
+		distances[i] = math.Inf(1)
+	}
+
+	err := readCanonical(tracking, func(rows []adapters.TrackingRow) error {
+		for _, row := range rows {
+			teams[row.PlayerID] = row.TeamID
+			first := sort.Search(len(events), func(i int) bool { return events[i].TimestampMS >= row.TimestampMS-eventFrameWindowMS })
+			for i := first; i < len(events) && events[i].TimestampMS <= row.TimestampMS+eventFrameWindowMS; i++ {
+				if events[i].TeamID != row.TeamID {
+					continue
+				}
+				if d := math.Hypot(events[i].StartX-row.X, events[i].StartY-row.Y); d < distances[i] {
+					distances[i], nearest[i] = d, row.PlayerID
+				}
+			}
+		}
+		return ctx.Err()
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	actors := map[string]map[string]int{}
+	for i, event := range events {
+		if nearest[i] == "" || distances[i] > eventMaxDistance {
+			continue
+		}
+		if actors[event.PlayerID] == nil {
+			actors[event.PlayerID] = map[string]int{}
+		}
+		actors[event.PlayerID][nearest[i]]++
+	}
+	return teams, actors, nil
+}
+
+// mostVoted returns the tracking player with the most votes, breaking ties
+// by ID, with its votes and the total.
+func mostVoted(votes map[string]int) (trackingID string, count, total int) {
+	for id, n := range votes {
+		total += n
+		if n > count || n == count && id < trackingID {
+			trackingID, count = id, n
+		}
+	}
+	return trackingID, count, total
+}
+
+// readCanonical reads the rows of a canonical Parquet file in batches,
+// passing each to batch, which may stop the read with an error.
+func readCanonical[T any](f *os.File, batch func([]T) error) error {
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	file, err := parquet.OpenFile(f, info.Size())
+	if err != nil {
+		return err
+	}
+	reader := parquet.NewGenericReader[T](file)
+	defer reader.Close()
+
+	rows := make([]T, trackingBatch)
+	for {
+		n, err := reader.Read(rows)
+		if n > 0 {
+			if batchErr := batch(rows[:n]); batchErr != nil {
+				return batchErr
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// spool copies a stored file to a temporary file, which the caller removes.
+func (s *PlayerIdentityService) spool(path string) (*os.File, error) {
+	src, err := s.storage.GetFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	f, err := os.CreateTemp("", "nivai-players-*")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(f, src); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, err
+	}
+	return f, nil
+}
@@ -0,0 +1,306 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"nivai/backend/pkg/events"
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/pythonapi"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidErasure is returned when an erasure request has an unknown mode
+// or no reason.
+var ErrInvalidErasure = errors.New("invalid erasure request")
+
+/**
+ * PlayerMatchData is what a match holds about a roster player.
+ */
+type PlayerMatchData struct {
+	MatchID          string          `json:"match_id"`
+	Title            string          `json:"title,omitempty"`
+	MatchDate        time.Time       `json:"match_date,omitempty"`
+	HomeTeam         string          `json:"home_team,omitempty"`
+	AwayTeam         string          `json:"away_team,omitempty"`
+	Competition      string          `json:"competition,omitempty"`
+	TrackingPlayerID string          `json:"tracking_player_id"`
+	MappingSource    string          `json:"mapping_source"`
+	Summary          json.RawMessage `json:"summary,omitempty"` // The player's statistics in the stored match summary
+	// Details is the player's time series from the analytics service, for processed matches
+	Details *pythonapi.PlayerDetails `json:"details,omitempty"`
+}
+
+/**
+ * PlayerExport is all data associated with a roster player, for a data
+ * subject access request.
+ */
+type PlayerExport struct {
+	ExportedAt         time.Time               `json:"exported_at"`
+	Player             *models.Player          `json:"player"`
+	Matches            []PlayerMatchData       `json:"matches"`
+	UnavailableMatches []string                `json:"unavailable_matches,omitempty"` // Processed matches whose analytics could not be read
+	Erasures           []*models.PlayerErasure `json:"erasures"`
+}
+
+/**
+ * ErasureRequest asks for a roster player to be pseudonymized or erased.
+ */
+type ErasureRequest struct {
+	PlayerID    string
+	Mode        string // models.ErasureModePseudonymize or models.ErasureModeErase
+	Reason      string // Required, e.g. the ticket of the data subject request
+	RequestedBy string
+}
+
+/**
+ * PlayerPrivacyService handles data subject requests for players: it
+ * exports everything stored about a roster player, and pseudonymizes or
+ * erases a player across matches. Every erasure is recorded, and announced
+ * as events.PlayerErased so cached analytics of the player's matches are
+ * dropped and integrators can erase their copies.
+ *
+ * The match files and the analytics service's results stay untouched;
+ * reprocess the recorded matches to rebuild those without the player.
+ */
+type PlayerPrivacyService struct {
+	players   models.PlayerRepository
+	mappings  models.PlayerMappingRepository
+	erasures  models.PlayerErasureRepository
+	snapshots models.AnalyticsSnapshotRepository
+	videoRepo models.VideoRepository
+	analytics AnalyticsReader
+	bus       *events.Bus
+	names     *FieldCipher
+	now       func() time.Time
+}
+
+/**
+ * NewPlayerPrivacyService creates a new player privacy service.
+ *
+ * @param repos Repositories of the roster, mappings, erasures, snapshots and videos
+ * @param analytics Reader of the players' time series, for exports
+ * @param bus Bus events.PlayerErased is published on
+ * @param names Cipher of the stored player names; nil when they are plain text
+ * @return A new player privacy service
+ */
+func NewPlayerPrivacyService(repos *models.Repositories, analytics AnalyticsReader, bus *events.Bus, names *FieldCipher) *PlayerPrivacyService {
+	return &PlayerPrivacyService{
+		players: repos.Players, mappings: repos.PlayerMappings, erasures: repos.PlayerErasures,
+		snapshots: repos.Snapshots, videoRepo: repos.Videos, analytics: analytics, bus: bus, names: names, now: time.Now,
+	}
+}
+
+/**
+ * Export collects the roster entry of a player, what each of their matches
+ * holds about them, and their erasures.
+ *
+ * @param ctx Context for the analytics requests
+ * @param playerID The roster player
+ * @return The export, or models.ErrPlayerNotFound
+ */
+func (s *PlayerPrivacyService) Export(ctx context.Context, playerID string) (*PlayerExport, error) {
+	player, err := s.players.FindByID(playerID)
+	if err != nil {
+		return nil, err
+	}
+	if player.Name, err = s.names.Decrypt(player.Name); err != nil {
+		return nil, err
+	}
+	mappings, err := s.mappings.FindByPlayerID(playerID)
+	if err != nil {
+		return nil, err
+	}
+	erasures, err := s.erasures.FindAll(playerID, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &PlayerExport{ExportedAt: s.now().UTC(), Player: player, Matches: []PlayerMatchData{}, Erasures: erasures}
+	for _, mapping := range mappings {
+		data := PlayerMatchData{MatchID: mapping.VideoID, TrackingPlayerID: mapping.TrackingPlayerID, MappingSource: mapping.Source}
+		video, err := s.videoRepo.FindByID(mapping.VideoID)
+		if err != nil {
+			if !isNotFound(err) {
+				return nil, fmt.Errorf("failed to read match %s: %w", mapping.VideoID, err)
+			}
+			video = &models.Video{ID: mapping.VideoID} // Deleted; only the mapping is left
+		}
+		data.Title, data.MatchDate, data.HomeTeam, data.AwayTeam, data.Competition =
+			video.Title, video.MatchDate, video.HomeTeam, video.AwayTeam, video.Competition
+
+		if summary, err := s.snapshotPlayers(mapping.VideoID); err == nil {
+			data.Summary = summary[mapping.TrackingPlayerID]
+		} else if !isNotFound(err) {
+			return nil, err
+		}
+		if video.ProcessingState == models.StateCompleted {
+			details, err := s.analytics.GetPlayerDetails(ctx, mapping.VideoID, mapping.TrackingPlayerID)
+			if err != nil {
+				log.Printf("Privacy: failed to read analytics of player %s in match %s: %v", playerID, mapping.VideoID, err)
+				export.UnavailableMatches = append(export.UnavailableMatches, mapping.VideoID)
+			}
+			data.Details = details
+		}
+		export.Matches = append(export.Matches, data)
+	}
+	return export, nil
+}
+
+/**
+ * Erase pseudonymizes or erases a roster player, records the erasure and
+ * publishes events.PlayerErased with the player's matches.
+ *
+ * Pseudonymizing replaces the player's name by a pseudonym and clears their
+ * jersey number and feed IDs; mappings and statistics stay. Erasing also
+ * removes the player's mappings, their statistics from the stored match
+ * summaries and the roster entry itself.
+ *
+ * @param ctx Unused; erasures are not cancelled halfway
+ * @param req The player, mode, reason and requester
+ * @return The recorded erasure, or ErrInvalidErasure or models.ErrPlayerNotFound
+ */
+func (s *PlayerPrivacyService) Erase(_ context.Context, req ErasureRequest) (*models.PlayerErasure, error) {
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Mode != models.ErasureModePseudonymize && req.Mode != models.ErasureModeErase {
+		return nil, fmt.Errorf("%w: mode must be %q or %q", ErrInvalidErasure, models.ErasureModePseudonymize, models.ErasureModeErase)
+	}
+	if req.Reason == "" {
+		return nil, fmt.Errorf("%w: reason is required", ErrInvalidErasure)
+	}
+
+	player, err := s.players.FindByID(req.PlayerID)
+	if err != nil {
+		return nil, err
+	}
+	mappings, err := s.mappings.FindByPlayerID(player.ID)
+	if err != nil {
+		return nil, err
+	}
+	matchIDs := make([]string, 0, len(mappings))
+	for _, mapping := range mappings {
+		matchIDs = append(matchIDs, mapping.VideoID)
+	}
+
+	now := s.now().UTC()
+	switch req.Mode {
+	case models.ErasureModePseudonymize:
+		if player.Name, err = s.names.Encrypt(pseudonym(player.ID)); err != nil {
+			return nil, err
+		}
+		player.JerseyNumber = 0
+		player.ExternalIDs = []string{}
+		player.UpdatedAt = now
+		if err := s.players.Update(player); err != nil {
+			return nil, err
+		}
+	case models.ErasureModeErase:
+		for _, mapping := range mappings {
+			if err := s.scrubSnapshot(mapping.VideoID, mapping.TrackingPlayerID); err != nil {
+				return nil, fmt.Errorf("failed to remove the player from the summary of match %s: %w", mapping.VideoID, err)
+			}
+			if err := s.mappings.Delete(mapping.VideoID, mapping.TrackingPlayerID); err != nil && !errors.Is(err, models.ErrPlayerMappingNotFound) {
+				return nil, err
+			}
+		}
+		if err := s.players.Delete(player.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	erasure := &models.PlayerErasure{
+		ID: uuid.New().String(), PlayerID: player.ID, Mode: req.Mode, Reason: req.Reason,
+		RequestedBy: req.RequestedBy, MatchIDs: matchIDs, CreatedAt: now,
+	}
+	if err := s.erasures.Create(erasure); err != nil {
+		return nil, fmt.Errorf("player %s was erased but the erasure was not recorded: %w", player.ID, err)
+	}
+	s.bus.Publish(events.New(events.PlayerErased, map[string]interface{}{
+		"player_id": player.ID,
+		"mode":      req.Mode,
+		"match_ids": slices.Clone(matchIDs),
+	}))
+	return erasure, nil
+}
+
+/**
+ * Erasures lists the recorded erasures, newest first.
+ *
+ * @param playerID Only list this player's erasures, unless empty
+ * @param limit Maximum number of erasures
+ * @param offset Number of erasures to skip
+ * @return The erasures or error
+ */
+func (s *PlayerPrivacyService) Erasures(playerID string, limit, offset int) ([]*models.PlayerErasure, error) {
+	return s.erasures.FindAll(playerID, limit, offset)
+}
+
+// snapshotPlayers returns the per-player statistics of a match's stored
+// summary, keyed by tracking player ID.
+func (s *PlayerPrivacyService) snapshotPlayers(matchID string) (map[string]json.RawMessage, error) {
+	snapshot, err := s.snapshots.Find(matchID, models.SnapshotKindSummary)
+	if err != nil {
+		return nil, err
+	}
+	var summary pythonapi.MatchSummary
+	var players map[string]json.RawMessage
+	if err := json.Unmarshal(snapshot.Payload, &summary); err != nil {
+		return nil, err
+	}
+	if len(summary.Players) > 0 {
+		if err := json.Unmarshal(summary.Players, &players); err != nil {
+			return nil, err
+		}
+	}
+	return players, nil
+}
+
+// scrubSnapshot removes a tracking player's statistics from a match's
+// stored summary, if it has one.
+func (s *PlayerPrivacyService) scrubSnapshot(matchID, trackingPlayerID string) error {
+	snapshot, err := s.snapshots.Find(matchID, models.SnapshotKindSummary)
+	if isNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var summary pythonapi.MatchSummary
+	var players map[string]json.RawMessage
+	if err := json.Unmarshal(snapshot.Payload, &summary); err != nil {
+		return err
+	}
+	if len(summary.Players) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(summary.Players, &players); err != nil {
+		return err
+	}
+	if _, ok := players[trackingPlayerID]; !ok {
+		return nil
+	}
+	delete(players, trackingPlayerID)
+	if summary.Players, err = json.Marshal(players); err != nil {
+		return err
+	}
+	if snapshot.Payload, err = json.Marshal(summary); err != nil {
+		return err
+	}
+	return s.snapshots.Save(snapshot)
+}
+
+// pseudonym names a pseudonymized player by the start of their roster ID.
+func pseudonym(playerID string) string {
+	if len(playerID) > 8 {
+		playerID = playerID[:8]
+	}
+	return "Player " + playerID
+}
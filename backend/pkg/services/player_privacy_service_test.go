@@ -0,0 +1,123 @@
+package services_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"nivai/backend/pkg/events"
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlayerPrivacyService(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 9, 7, 15, 0, 0, 0, time.UTC)
+
+	// setup stores two players, each mapped in two matches with a stored summary.
+	setup := func(t *testing.T) (*models.Repositories, *services.PlayerPrivacyService, *[]events.Event) {
+		repos := models.NewMemoryRepositories()
+		for _, id := range []string{"m1", "m2"} {
+			require.NoError(t, repos.Videos.Create(&models.Video{ID: id, Title: "Ajax - " + id, HomeTeam: "Ajax", ProcessingState: models.StateCompleted}))
+			require.NoError(t, repos.Snapshots.Save(&models.AnalyticsSnapshot{VideoID: id, Kind: models.SnapshotKindSummary, FetchedAt: now,
+				Payload: json.RawMessage(`{"match_id":"` + id + `","players":{"7":{"total_distance_m":9000},"9":{"total_distance_m":8000}},"teams":{}}`)}))
+		}
+		for _, player := range []*models.Player{
+			{ID: "3f2c9a61-aaaa", Name: "Jan de Vries", Team: "Ajax", JerseyNumber: 7, ExternalIDs: []string{"sb:101"}, CreatedAt: now, UpdatedAt: now},
+			{ID: "other", Name: "Piet Jansen", Team: "Ajax", JerseyNumber: 9, ExternalIDs: []string{}, CreatedAt: now, UpdatedAt: now},
+		} {
+			require.NoError(t, repos.Players.Create(player))
+		}
+		for _, id := range []string{"m1", "m2"} {
+			require.NoError(t, repos.PlayerMappings.Save(&models.PlayerMapping{VideoID: id, TrackingPlayerID: "7", PlayerID: "3f2c9a61-aaaa", Source: models.MappingSourceJersey}))
+			require.NoError(t, repos.PlayerMappings.Save(&models.PlayerMapping{VideoID: id, TrackingPlayerID: "9", PlayerID: "other", Source: models.MappingSourceJersey}))
+		}
+
+		bus := events.NewBus()
+		var published []events.Event
+		bus.Subscribe(events.PlayerErased, func(e events.Event) { published = append(published, e) })
+		return repos, services.NewPlayerPrivacyService(repos, &fakeAnalyticsReader{}, bus, nil), &published
+	}
+
+	t.Run("Exports hold the roster entry and what each match holds about the player", func(t *testing.T) {
+		_, privacy, _ := setup(t)
+
+		export, err := privacy.Export(ctx, "3f2c9a61-aaaa")
+		require.NoError(t, err)
+		assert.Equal(t, "Jan de Vries", export.Player.Name)
+		require.Len(t, export.Matches, 2)
+		assert.Equal(t, "Ajax - m1", export.Matches[0].Title)
+		assert.Equal(t, "7", export.Matches[0].TrackingPlayerID)
+		assert.JSONEq(t, `{"total_distance_m":9000}`, string(export.Matches[0].Summary))
+		require.NotNil(t, export.Matches[0].Details)
+		assert.Equal(t, "7", export.Matches[0].Details.PlayerID)
+		assert.Empty(t, export.Erasures)
+
+		_, err = privacy.Export(ctx, "unknown")
+		assert.ErrorIs(t, err, models.ErrPlayerNotFound)
+	})
+
+	t.Run("Pseudonymizing replaces identifying fields and keeps the statistics", func(t *testing.T) {
+		repos, privacy, published := setup(t)
+
+		erasure, err := privacy.Erase(ctx, services.ErasureRequest{PlayerID: "3f2c9a61-aaaa", Mode: "pseudonymize", Reason: "DSR-12", RequestedBy: "dpo"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"m1", "m2"}, erasure.MatchIDs)
+
+		player, err := repos.Players.FindByID("3f2c9a61-aaaa")
+		require.NoError(t, err)
+		assert.Equal(t, "Player 3f2c9a61", player.Name)
+		assert.Zero(t, player.JerseyNumber)
+		assert.Empty(t, player.ExternalIDs)
+		mappings, err := repos.PlayerMappings.FindByPlayerID("3f2c9a61-aaaa")
+		require.NoError(t, err)
+		assert.Len(t, mappings, 2)
+
+		require.Len(t, *published, 1)
+		assert.Equal(t, []string{"m1", "m2"}, (*published)[0].Data["match_ids"])
+	})
+
+	t.Run("Erasing removes the player, their mappings and their statistics", func(t *testing.T) {
+		repos, privacy, published := setup(t)
+
+		_, err := privacy.Erase(ctx, services.ErasureRequest{PlayerID: "3f2c9a61-aaaa", Mode: "erase", Reason: "DSR-13", RequestedBy: "dpo"})
+		require.NoError(t, err)
+
+		_, err = repos.Players.FindByID("3f2c9a61-aaaa")
+		assert.ErrorIs(t, err, models.ErrPlayerNotFound)
+		mappings, err := repos.PlayerMappings.FindByVideoID("m1")
+		require.NoError(t, err)
+		require.Len(t, mappings, 1)
+		assert.Equal(t, "other", mappings[0].PlayerID, "other players keep their mappings")
+		snapshot, err := repos.Snapshots.Find("m2", models.SnapshotKindSummary)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"match_id":"m2","players":{"9":{"total_distance_m":8000}},"teams":{}}`, string(snapshot.Payload))
+		assert.Len(t, *published, 1)
+
+		erasures, err := privacy.Erasures("3f2c9a61-aaaa", 10, 0)
+		require.NoError(t, err)
+		require.Len(t, erasures, 1, "the audit trail outlives the player")
+		assert.Equal(t, "erase", erasures[0].Mode)
+		assert.Equal(t, "DSR-13", erasures[0].Reason)
+		assert.Equal(t, "dpo", erasures[0].RequestedBy)
+	})
+
+	t.Run("Erasures need a known mode and a reason", func(t *testing.T) {
+		_, privacy, published := setup(t)
+
+		for _, invalid := range []services.ErasureRequest{
+			{PlayerID: "3f2c9a61-aaaa", Mode: "delete", Reason: "DSR-14"},
+			{PlayerID: "3f2c9a61-aaaa", Mode: "erase", Reason: "  "},
+		} {
+			_, err := privacy.Erase(ctx, invalid)
+			assert.ErrorIs(t, err, services.ErrInvalidErasure)
+		}
+		_, err := privacy.Erase(ctx, services.ErasureRequest{PlayerID: "unknown", Mode: "erase", Reason: "DSR-14"})
+		assert.ErrorIs(t, err, models.ErrPlayerNotFound)
+		assert.Empty(t, *published)
+	})
+}
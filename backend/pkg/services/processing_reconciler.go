@@ -0,0 +1,209 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"nivai/backend/pkg/events"
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/pythonapi"
+)
+
+// reconcileBatchSize is how many waiting matches are read per page.
+const reconcileBatchSize = 100
+
+/**
+ * ReconcilerConfig tunes the processing state reconciler.
+ */
+type ReconcilerConfig struct {
+	StuckAfter time.Duration // Time since the last state change after which a waiting match is checked (default 30m)
+}
+
+/**
+ * ReconcileReport is the result of one reconciler run.
+ */
+type ReconcileReport struct {
+	Checked     int // Waiting matches older than the stuck age
+	Updated     int // Matches moved to the state the analytics service reported
+	Stalled     int // Matches the analytics service is still working on
+	Unreachable int // Matches whose status could not be fetched
+}
+
+/**
+ * ProcessingReconciler finds matches stuck in "pending_analytics" or
+ * "processing" and settles them with the analytics service's status, for
+ * when the status update that should have moved them on was lost.
+ *
+ * Finished runs are recorded like the match list does; a match the service
+ * has no record of, e.g. after a restart, is marked failed so it can be
+ * retried. Matches the service is still working on are stalled and publish
+ * an analytics.stalled alert, once until they move on.
+ */
+type ProcessingReconciler struct {
+	videoRepo    models.VideoRepository
+	source       AnalyticsSource
+	videoService VideoService
+	eventBus     *events.Bus
+	cfg          ReconcilerConfig
+	now          func() time.Time
+
+	mu      sync.Mutex
+	stalled map[string]bool // Matches alerted as stalled
+}
+
+/**
+ * NewProcessingReconciler creates a new processing state reconciler.
+ *
+ * @param videoRepo Repository the waiting matches are read from
+ * @param source Analytics service whose statuses are authoritative
+ * @param videoService Video service used to update processing states
+ * @param eventBus Bus the stall alerts are published on; nil publishes none
+ * @param cfg Reconciler settings
+ * @return A new reconciler
+ */
+func NewProcessingReconciler(videoRepo models.VideoRepository, source AnalyticsSource, videoService VideoService, eventBus *events.Bus, cfg ReconcilerConfig) *ProcessingReconciler {
+	if cfg.StuckAfter <= 0 {
+		cfg.StuckAfter = 30 * time.Minute
+	}
+	return &ProcessingReconciler{
+		videoRepo:    videoRepo,
+		source:       source,
+		videoService: videoService,
+		eventBus:     eventBus,
+		cfg:          cfg,
+		now:          time.Now,
+		stalled:      make(map[string]bool),
+	}
+}
+
+/**
+ * RunScheduled runs a scheduled reconciliation, logging what it changed.
+ *
+ * @param ctx Context for the run
+ * @return An error if the waiting matches cannot be read
+ */
+func (r *ProcessingReconciler) RunScheduled(ctx context.Context) error {
+	report, err := r.Reconcile(ctx)
+	if err != nil {
+		return err
+	}
+	if report.Updated > 0 || report.Stalled > 0 || report.Unreachable > 0 {
+		log.Printf("Processing reconciler: %d stuck matches, %d updated, %d stalled, %d unreachable",
+			report.Checked, report.Updated, report.Stalled, report.Unreachable)
+	}
+	return nil
+}
+
+/**
+ * Reconcile checks every match waiting on analytics whose state last
+ * changed longer ago than the stuck age.
+ *
+ * @param ctx Context for the run
+ * @return The run report, or an error if the matches cannot be read
+ */
+func (r *ProcessingReconciler) Reconcile(ctx context.Context) (*ReconcileReport, error) {
+	report := &ReconcileReport{}
+	cutoff := r.now().Add(-r.cfg.StuckAfter)
+	stalled := make(map[string]bool)
+
+	for _, state := range []models.ProcessingState{models.StatePendingAnalytics, models.StateProcessing} {
+		stuck, err := r.stuckVideos(state, cutoff)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s matches: %w", state, err)
+		}
+		for _, video := range stuck {
+			if ctx.Err() != nil {
+				return report, ctx.Err()
+			}
+			report.Checked++
+			r.reconcile(ctx, video, report, stalled)
+		}
+	}
+
+	// Matches no longer stalled may alert again if they stall later
+	r.mu.Lock()
+	r.stalled = stalled
+	r.mu.Unlock()
+	return report, nil
+}
+
+// stuckVideos pages through the matches in a state, keeping those whose
+// state last changed before cutoff.
+func (r *ProcessingReconciler) stuckVideos(state models.ProcessingState, cutoff time.Time) ([]*models.Video, error) {
+	var stuck []*models.Video
+	for offset := 0; ; offset += reconcileBatchSize {
+		videos, err := r.videoRepo.FindByQuery(models.VideoQuery{
+			ProcessingState: string(state), Sort: "created_at", Order: "asc",
+			Limit: reconcileBatchSize, Offset: offset,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, video := range videos {
+			if video.UpdatedAt.Before(cutoff) {
+				stuck = append(stuck, video)
+			}
+		}
+		if len(videos) < reconcileBatchSize {
+			return stuck, nil
+		}
+	}
+}
+
+// reconcile settles one stuck match with the analytics service's status.
+func (r *ProcessingReconciler) reconcile(ctx context.Context, video *models.Video, report *ReconcileReport, stalled map[string]bool) {
+	status, err := r.source.GetMatchStatus(ctx, video.ID)
+	var next models.ProcessingState
+	var reason string
+	switch {
+	case errors.Is(err, pythonapi.ErrNotFound):
+		next, reason = models.StateFailed, "analytics service has no record of the match"
+	case err != nil:
+		log.Printf("Processing reconciler: failed to fetch analytics status of match %s: %v", video.ID, err)
+		report.Unreachable++
+		return
+	case status.Status == pythonapi.StatusProcessed:
+		next, reason = models.StateCompleted, "analytics status processed"
+	case status.Status == pythonapi.StatusError:
+		next, reason = models.StateFailed, "analytics status error"
+		if status.Message != "" {
+			reason += ": " + status.Message
+		}
+	case status.Status == pythonapi.StatusCancelled:
+		next, reason = models.StateCancelled, "analytics status cancelled"
+	default:
+		report.Stalled++
+		stalled[video.ID] = true
+		r.alertStalled(video, status.Status)
+		return
+	}
+
+	change := StateChange{Actor: ActorReconciler, Reason: reason}
+	if err := r.videoService.UpdateProcessingState(video.ID, next, change); err != nil {
+		log.Printf("Processing reconciler: failed to update match %s to %q: %v", video.ID, next, err)
+		return
+	}
+	report.Updated++
+}
+
+// alertStalled publishes an analytics.stalled event, unless the match was
+// already alerted during an earlier run.
+func (r *ProcessingReconciler) alertStalled(video *models.Video, analyticsStatus string) {
+	r.mu.Lock()
+	alerted := r.stalled[video.ID]
+	r.mu.Unlock()
+	if alerted {
+		return
+	}
+
+	log.Printf("Processing reconciler: match %s stalled in %q since %s (analytics status %q)",
+		video.ID, video.ProcessingState, video.UpdatedAt.Format(time.RFC3339), analyticsStatus)
+	data := videoEventData(video)
+	data["stalled_since"] = video.UpdatedAt
+	data["analytics_status"] = analyticsStatus
+	r.eventBus.Publish(events.New(events.AnalyticsStalled, data))
+}
@@ -0,0 +1,71 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"nivai/backend/pkg/events"
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessingReconciler_Reconcile(t *testing.T) {
+	videos := models.NewMemoryVideoRepository()
+	history := models.NewMemoryStateHistoryRepository()
+	bus := events.NewBus()
+	videoService := services.NewVideoService(videos, new(MockStorageService), services.WithEventBus(bus), services.WithStateHistory(history))
+
+	stuckSince := time.Now().Add(-2 * time.Hour)
+	for _, video := range []*models.Video{
+		{ID: "done", ProcessingState: models.StatePendingAnalytics, UpdatedAt: stuckSince},
+		{ID: "broken", ProcessingState: models.StateProcessing, UpdatedAt: stuckSince},
+		{ID: "lost", ProcessingState: models.StatePendingAnalytics, UpdatedAt: stuckSince},
+		{ID: "slow", ProcessingState: models.StatePendingAnalytics, UpdatedAt: stuckSince},
+		{ID: "recent", ProcessingState: models.StatePendingAnalytics, UpdatedAt: time.Now()},
+	} {
+		require.NoError(t, videos.Create(video))
+	}
+	source := &fakeAnalyticsSource{statuses: map[string]string{
+		"done": "processed", "broken": "error", "slow": "pending", "recent": "processed",
+	}}
+
+	var stalled []events.Event
+	bus.Subscribe(events.AnalyticsStalled, func(e events.Event) { stalled = append(stalled, e) })
+
+	reconciler := services.NewProcessingReconciler(videos, source, videoService, bus, services.ReconcilerConfig{StuckAfter: time.Hour})
+	report, err := reconciler.Reconcile(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, services.ReconcileReport{Checked: 4, Updated: 3, Stalled: 1}, *report)
+
+	for id, want := range map[string]models.ProcessingState{
+		"done":   models.StateCompleted,
+		"broken": models.StateFailed,
+		"lost":   models.StateFailed,
+		"slow":   models.StatePendingAnalytics,
+		"recent": models.StatePendingAnalytics, // Not stuck yet
+	} {
+		video, err := videos.FindByID(id)
+		require.NoError(t, err)
+		assert.Equal(t, want, video.ProcessingState, id)
+	}
+
+	transitions, err := history.FindByVideoID("lost")
+	require.NoError(t, err)
+	require.Len(t, transitions, 1)
+	assert.Equal(t, services.ActorReconciler, transitions[0].Actor)
+	assert.Equal(t, "analytics service has no record of the match", transitions[0].Reason)
+
+	require.Len(t, stalled, 1)
+	assert.Equal(t, "slow", stalled[0].Data["video_id"])
+
+	t.Run("A stall is alerted once", func(t *testing.T) {
+		report, err := reconciler.Reconcile(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 1, report.Stalled)
+		assert.Len(t, stalled, 1)
+	})
+}
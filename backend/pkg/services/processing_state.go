@@ -0,0 +1,113 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"nivai/backend/pkg/models"
+)
+
+// Actors of the processing state changes the backend makes on its own
+const (
+	ActorSystem          = "system"
+	ActorAnalytics       = "system:analytics"        // Status reported by the analytics service
+	ActorAudit           = "system:audit"            // Repairs of the consistency auditor
+	ActorDraftExpiry     = "system:draft-expiry"     // Deletion of abandoned drafts
+	ActorArchive         = "system:archive"          // Cold storage jobs
+	ActorIngestion       = "system:ingestion"        // Matches created from drop folder deliveries
+	ActorMalwareScan     = "system:malware-scan"     // Quarantine of infected uploads
+	ActorReconciler      = "system:reconciler"       // Settling of matches stuck waiting on analytics
+	ActorVideoProcessing = "system:video-processing" // Extraction of video properties
+)
+
+/**
+ * StateChange tells who changes a match's processing state and why, for
+ * the state history.
+ */
+type StateChange struct {
+	Actor  string // User ID, or one of the system actors
+	Reason string
+}
+
+/**
+ * stateMachine changes processing states along the transitions the models
+ * allow and records each change in the state history.
+ */
+type stateMachine struct {
+	videos  models.VideoRepository
+	history models.StateHistoryRepository // nil records no history
+	now     func() time.Time
+}
+
+// transitionAttempts bounds how often a transition is retried after the
+// state changed concurrently.
+const transitionAttempts = 3
+
+/**
+ * transition moves a video to a new state. Only the state is saved, and
+ * only while the video is still in the state the move was checked from:
+ * when another writer changed it first, the video is read again and the
+ * move checked from the new state. Moving to the current state changes
+ * nothing. Other changes to the video are not saved.
+ *
+ * @param video The video, updated in place (and reloaded after a conflict)
+ * @param to The new state
+ * @param change Who changes the state and why
+ * @return The state the video moved from, whether it changed, or an error
+ *         wrapping models.ErrInvalidTransition or models.ErrStateConflict
+ */
+func (m stateMachine) transition(video *models.Video, to models.ProcessingState, change StateChange) (models.ProcessingState, bool, error) {
+	for attempt := 1; ; attempt++ {
+		from := video.ProcessingState
+		if from == to {
+			return from, false, nil
+		}
+		if err := from.CheckTransition(to); err != nil {
+			return "", false, err
+		}
+
+		at := m.now()
+		err := m.videos.UpdateProcessingState(video.ID, from, to, at)
+		if err == nil {
+			video.ProcessingState, video.UpdatedAt = to, at
+			m.record(video.ID, from, to, change, at)
+			return from, true, nil
+		}
+		if !errors.Is(err, models.ErrStateConflict) || attempt == transitionAttempts {
+			return "", false, err
+		}
+		current, err := m.videos.FindByID(video.ID)
+		if err != nil {
+			return "", false, err
+		}
+		*video = *current
+	}
+}
+
+/**
+ * created records the state a new video was saved in.
+ *
+ * @param video The saved video
+ * @param change Who created the video and why
+ */
+func (m stateMachine) created(video *models.Video, change StateChange) {
+	m.record(video.ID, "", video.ProcessingState, change, video.UpdatedAt)
+}
+
+// record appends a transition to the history. The state itself is already
+// saved, so a failure is only logged.
+func (m stateMachine) record(videoID string, from, to models.ProcessingState, change StateChange, at time.Time) {
+	if m.history == nil {
+		return
+	}
+	if change.Actor == "" {
+		change.Actor = ActorSystem
+	}
+	err := m.history.Create(&models.StateTransition{
+		VideoID: videoID, From: from, To: to, Actor: change.Actor, Reason: change.Reason, CreatedAt: at,
+	})
+	if err != nil {
+		log.Printf("Failed to record state change of video %s from %q to %q: %v", videoID, from, to, err)
+	}
+}
@@ -0,0 +1,259 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"nivai/backend/pkg/events"
+	"nivai/backend/pkg/models"
+)
+
+var (
+	// ErrQuotaExceeded is returned when an upload would take an organization
+	// or user over its storage quota.
+	ErrQuotaExceeded = errors.New("storage quota exceeded")
+	// ErrUploadExceedsQuota is returned when an upload is larger than the
+	// whole quota, so it cannot be stored even after deleting other matches.
+	ErrUploadExceedsQuota = errors.New("upload is larger than the storage quota")
+)
+
+/**
+ * QuotaConfig holds the storage quotas. A quota of 0 is unlimited.
+ */
+type QuotaConfig struct {
+	OrganizationBytes int64 // Bytes an organization may store in total
+	UserBytes         int64 // Bytes a single user may store in total
+}
+
+/**
+ * Usage is the storage used by an organization or user and its quota.
+ */
+type Usage struct {
+	Owner          string `json:"owner"`
+	BytesUsed      int64  `json:"bytes_used"`
+	Files          int    `json:"files"`
+	QuotaBytes     int64  `json:"quota_bytes,omitempty"`     // Absent when unlimited
+	RemainingBytes *int64 `json:"remaining_bytes,omitempty"` // Absent when unlimited
+}
+
+/**
+ * UsageReport is the storage usage visible to a user.
+ */
+type UsageReport struct {
+	Organization Usage `json:"organization"`
+	User         Usage `json:"user"`
+}
+
+/**
+ * QuotaService accounts the bytes stored per organization and user and
+ * enforces storage quotas on uploads. Uploads are charged once stored, and
+ * released when their match is deleted.
+ */
+type QuotaService struct {
+	repo models.StorageUsageRepository
+	cfg  QuotaConfig
+	now  func() time.Time
+}
+
+/**
+ * NewQuotaService creates a new quota service.
+ *
+ * @param repo Repository for storage usage
+ * @param cfg The quotas to enforce
+ * @return A new quota service
+ */
+func NewQuotaService(repo models.StorageUsageRepository, cfg QuotaConfig) *QuotaService {
+	return &QuotaService{repo: repo, cfg: cfg, now: time.Now}
+}
+
+/**
+ * CheckUpload verifies that an upload of size bytes fits in the quotas of
+ * the organization and the user. Concurrent uploads are checked against the
+ * same usage, so they can overshoot a quota by at most their own size.
+ *
+ * @param org The organization uploading
+ * @param userID The user uploading
+ * @param size The size of the upload in bytes
+ * @return ErrUploadExceedsQuota or ErrQuotaExceeded (wrapped with details) if it does not fit
+ */
+func (s *QuotaService) CheckUpload(org, userID string, size int64) error {
+	for _, quota := range []struct {
+		scope, owner string
+		limit        int64
+	}{
+		{models.UsageScopeOrganization, org, s.cfg.OrganizationBytes},
+		{models.UsageScopeUser, userID, s.cfg.UserBytes},
+	} {
+		if quota.limit <= 0 {
+			continue
+		}
+		if size > quota.limit {
+			return fmt.Errorf("%w: the upload is %d bytes, the %s quota is %d bytes", ErrUploadExceedsQuota, size, quota.scope, quota.limit)
+		}
+		usage, err := s.repo.FindUsage(quota.scope, quota.owner)
+		if err != nil {
+			return err
+		}
+		if usage.Bytes+size > quota.limit {
+			return fmt.Errorf("%w: the %s uses %d of %d bytes, the upload needs %d more", ErrQuotaExceeded, quota.scope, usage.Bytes, quota.limit, size)
+		}
+	}
+	return nil
+}
+
+/**
+ * Allowance returns how many more bytes the organization and the user may
+ * store: the smaller of their remaining quotas, 0 when one is used up, or
+ * -1 when neither has a quota. Uploads of unknown size are cut off there.
+ *
+ * @param org The organization uploading
+ * @param userID The user uploading
+ * @return The bytes left, or -1 when unlimited
+ */
+func (s *QuotaService) Allowance(org, userID string) (int64, error) {
+	allowance := int64(-1)
+	for _, quota := range []struct {
+		scope, owner string
+		limit        int64
+	}{
+		{models.UsageScopeOrganization, org, s.cfg.OrganizationBytes},
+		{models.UsageScopeUser, userID, s.cfg.UserBytes},
+	} {
+		if quota.limit <= 0 {
+			continue
+		}
+		usage, err := s.repo.FindUsage(quota.scope, quota.owner)
+		if err != nil {
+			return 0, err
+		}
+		left := max(quota.limit-usage.Bytes, 0)
+		if allowance < 0 || left < allowance {
+			allowance = left
+		}
+	}
+	return allowance, nil
+}
+
+/**
+ * Charge adds a stored upload to the usage of its organization and user.
+ *
+ * @param videoID The match the files belong to
+ * @param org The organization that uploaded
+ * @param userID The user that uploaded
+ * @param files The stored files
+ * @return Error if the usage cannot be updated
+ */
+func (s *QuotaService) Charge(videoID, org, userID string, files []*models.VideoFile) error {
+	charge := &models.StorageCharge{
+		VideoID: videoID, Organization: org, UserID: userID, Files: len(files), CreatedAt: s.now(),
+	}
+	for _, file := range files {
+		charge.Bytes += file.Size
+	}
+	return s.repo.Charge(charge)
+}
+
+/**
+ * Usage reports the usage and quotas of an organization and user.
+ *
+ * @param org The organization
+ * @param userID The user
+ * @return The usage report, or an error
+ */
+func (s *QuotaService) Usage(org, userID string) (*UsageReport, error) {
+	orgUsage, err := s.usage(models.UsageScopeOrganization, org, s.cfg.OrganizationBytes)
+	if err != nil {
+		return nil, err
+	}
+	userUsage, err := s.usage(models.UsageScopeUser, userID, s.cfg.UserBytes)
+	if err != nil {
+		return nil, err
+	}
+	return &UsageReport{Organization: *orgUsage, User: *userUsage}, nil
+}
+
+/**
+ * HandleEvent releases the storage of deleted matches once their files are
+ * purged. Subscribe it to events.VideoPurged; a deleted match keeps its
+ * charge during the grace period, as its files are still stored.
+ *
+ * @param e The event
+ */
+func (s *QuotaService) HandleEvent(e events.Event) {
+	if e.Type != events.VideoPurged {
+		return
+	}
+	videoID, _ := e.Data["video_id"].(string)
+	charge, err := s.repo.Release(videoID)
+	if err != nil {
+		if !errors.Is(err, models.ErrStorageChargeNotFound) {
+			log.Printf("Quota: failed to release storage of deleted video %s: %v", videoID, err)
+		}
+		return
+	}
+	log.Printf("Quota: released %d bytes of deleted video %s from %s/%s", charge.Bytes, videoID, charge.Organization, charge.UserID)
+}
+
+/**
+ * ReleaseDeleted releases the storage of charged matches that no longer
+ * exist, for purges whose video.purged event was lost. Matches whose files
+ * wait out their grace period keep their charge. It is the scheduled usage
+ * accounting job.
+ *
+ * @param ctx Context for the run
+ * @param videos Video service the matches are looked up in
+ * @param purges Repository of the pending purges; may be nil
+ * @return The number of charges released, or an error if the charges cannot be read
+ */
+func (s *QuotaService) ReleaseDeleted(ctx context.Context, videos VideoService, purges models.VideoPurgeRepository) (int, error) {
+	ids, err := s.repo.ChargedVideoIDs()
+	if err != nil {
+		return 0, fmt.Errorf("failed to list storage charges: %w", err)
+	}
+
+	released := 0
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return released, err
+		}
+		if _, err := videos.GetVideoByID(id); !errors.Is(err, ErrVideoNotFound) {
+			continue
+		}
+		if purges != nil {
+			if _, err := purges.FindByVideoID(id); !errors.Is(err, models.ErrVideoPurgeNotFound) {
+				continue
+			}
+		}
+		charge, err := s.repo.Release(id)
+		if err != nil {
+			if !errors.Is(err, models.ErrStorageChargeNotFound) {
+				log.Printf("Quota: failed to release storage of deleted video %s: %v", id, err)
+			}
+			continue
+		}
+		log.Printf("Quota: released %d bytes of deleted video %s from %s/%s", charge.Bytes, id, charge.Organization, charge.UserID)
+		released++
+	}
+	return released, nil
+}
+
+// usage reports one owner's usage against its quota.
+func (s *QuotaService) usage(scope, owner string, limit int64) (*Usage, error) {
+	stored, err := s.repo.FindUsage(scope, owner)
+	if err != nil {
+		return nil, err
+	}
+	usage := &Usage{Owner: owner, BytesUsed: stored.Bytes, Files: stored.Files}
+	if limit > 0 {
+		remaining := limit - stored.Bytes
+		if remaining < 0 {
+			remaining = 0
+		}
+		usage.QuotaBytes = limit
+		usage.RemainingBytes = &remaining
+	}
+	return usage, nil
+}
@@ -0,0 +1,165 @@
+package services_test
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"nivai/backend/pkg/events"
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryStorageUsage is an in-memory StorageUsageRepository.
+type memoryStorageUsage struct {
+	mu      sync.Mutex
+	usage   map[string]*models.StorageUsage
+	charges map[string]*models.StorageCharge
+}
+
+func newMemoryStorageUsage() *memoryStorageUsage {
+	return &memoryStorageUsage{usage: map[string]*models.StorageUsage{}, charges: map[string]*models.StorageCharge{}}
+}
+
+func (m *memoryStorageUsage) FindUsage(scope, owner string) (*models.StorageUsage, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if usage, ok := m.usage[scope+"/"+owner]; ok {
+		copied := *usage
+		return &copied, nil
+	}
+	return &models.StorageUsage{Scope: scope, Owner: owner}, nil
+}
+func (m *memoryStorageUsage) Charge(charge *models.StorageCharge) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.charges[charge.VideoID]; ok {
+		return nil
+	}
+	m.charges[charge.VideoID] = charge
+	m.adjust(charge, 1)
+	return nil
+}
+func (m *memoryStorageUsage) Release(videoID string) (*models.StorageCharge, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	charge, ok := m.charges[videoID]
+	if !ok {
+		return nil, models.ErrStorageChargeNotFound
+	}
+	delete(m.charges, videoID)
+	m.adjust(charge, -1)
+	return charge, nil
+}
+func (m *memoryStorageUsage) ChargedVideoIDs() ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ids := make([]string, 0, len(m.charges))
+	for id := range m.charges {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+func (m *memoryStorageUsage) adjust(charge *models.StorageCharge, sign int) {
+	for scope, owner := range map[string]string{models.UsageScopeOrganization: charge.Organization, models.UsageScopeUser: charge.UserID} {
+		usage, ok := m.usage[scope+"/"+owner]
+		if !ok {
+			usage = &models.StorageUsage{Scope: scope, Owner: owner}
+			m.usage[scope+"/"+owner] = usage
+		}
+		usage.Bytes += int64(sign) * charge.Bytes
+		usage.Files += sign * charge.Files
+	}
+}
+
+func TestQuotaService(t *testing.T) {
+	files := []*models.VideoFile{{Kind: models.FileKindTracking, Size: 600}, {Kind: models.FileKindEvents, Size: 100}}
+
+	t.Run("Uploads are charged and released on delete", func(t *testing.T) {
+		repo := newMemoryStorageUsage()
+		quotas := services.NewQuotaService(repo, services.QuotaConfig{OrganizationBytes: 10000})
+
+		require.NoError(t, quotas.Charge("v1", "club", "alice", files))
+		require.NoError(t, quotas.Charge("v1", "club", "alice", files), "charging twice is a no-op")
+		require.NoError(t, quotas.Charge("v2", "club", "bob", files[:1]))
+
+		report, err := quotas.Usage("club", "alice")
+		require.NoError(t, err)
+		assert.Equal(t, int64(1300), report.Organization.BytesUsed)
+		assert.Equal(t, 3, report.Organization.Files)
+		assert.Equal(t, int64(10000), report.Organization.QuotaBytes)
+		assert.Equal(t, int64(8700), *report.Organization.RemainingBytes)
+		assert.Equal(t, int64(700), report.User.BytesUsed)
+		assert.Nil(t, report.User.RemainingBytes, "users are unlimited")
+
+		quotas.HandleEvent(events.New(events.VideoDeleted, map[string]interface{}{"video_id": "v2"}))
+		quotas.HandleEvent(events.New(events.VideoPurged, map[string]interface{}{"video_id": "v1"}))
+		quotas.HandleEvent(events.New(events.VideoPurged, map[string]interface{}{"video_id": "legacy"}))
+
+		report, err = quotas.Usage("club", "alice")
+		require.NoError(t, err)
+		assert.Equal(t, int64(600), report.Organization.BytesUsed)
+		assert.Equal(t, int64(0), report.User.BytesUsed)
+	})
+
+	t.Run("Uploads over a quota are rejected", func(t *testing.T) {
+		repo := newMemoryStorageUsage()
+		quotas := services.NewQuotaService(repo, services.QuotaConfig{OrganizationBytes: 2000, UserBytes: 1000})
+		require.NoError(t, quotas.Charge("v1", "club", "alice", files))
+
+		assert.NoError(t, quotas.CheckUpload("club", "alice", 300))
+		assert.ErrorIs(t, quotas.CheckUpload("club", "alice", 301), services.ErrQuotaExceeded, "user quota")
+		assert.NoError(t, quotas.CheckUpload("club", "bob", 1000))
+		assert.ErrorIs(t, quotas.CheckUpload("club", "bob", 1001), services.ErrUploadExceedsQuota)
+
+		require.NoError(t, quotas.Charge("v2", "club", "bob", files))
+		assert.ErrorIs(t, quotas.CheckUpload("club", "carol", 601), services.ErrQuotaExceeded, "organization quota")
+	})
+
+	t.Run("Allowance is the smallest quota left", func(t *testing.T) {
+		repo := newMemoryStorageUsage()
+		quotas := services.NewQuotaService(repo, services.QuotaConfig{OrganizationBytes: 2000, UserBytes: 1000})
+		require.NoError(t, quotas.Charge("v1", "club", "alice", files))
+
+		allowance, err := quotas.Allowance("club", "alice")
+		require.NoError(t, err)
+		assert.Equal(t, int64(300), allowance, "user quota")
+		allowance, err = quotas.Allowance("club", "bob")
+		require.NoError(t, err)
+		assert.Equal(t, int64(1000), allowance)
+		require.NoError(t, quotas.Charge("v2", "club", "bob", append(files, &models.VideoFile{Kind: models.FileKindVideo, Size: 900})))
+		allowance, err = quotas.Allowance("club", "carol")
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), allowance, "the organization quota is used up")
+
+		allowance, err = services.NewQuotaService(repo, services.QuotaConfig{}).Allowance("club", "alice")
+		require.NoError(t, err)
+		assert.Equal(t, int64(-1), allowance, "no quota")
+	})
+
+	t.Run("Charges of deleted matches are released", func(t *testing.T) {
+		repo := newMemoryStorageUsage()
+		quotas := services.NewQuotaService(repo, services.QuotaConfig{})
+		videos := models.NewMemoryVideoRepository()
+		require.NoError(t, videos.Create(&models.Video{ID: "kept"}))
+		videoService := services.NewVideoService(videos, new(MockStorageService))
+
+		require.NoError(t, quotas.Charge("kept", "club", "alice", files))
+		require.NoError(t, quotas.Charge("gone", "club", "alice", files))
+		require.NoError(t, quotas.Charge("pending", "club", "alice", files))
+		purges := models.NewMemoryVideoPurgeRepository()
+		require.NoError(t, purges.Create(&models.VideoPurge{VideoID: "pending", PurgeAfter: time.Now().Add(time.Hour)}))
+
+		released, err := quotas.ReleaseDeleted(context.Background(), videoService, purges)
+		require.NoError(t, err)
+		assert.Equal(t, 1, released)
+		ids, _ := repo.ChargedVideoIDs()
+		assert.Equal(t, []string{"kept", "pending"}, ids, "matches in their grace period keep their charge")
+	})
+}
@@ -0,0 +1,172 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"nivai/backend/pkg/models"
+)
+
+/**
+ * RefereeCipherRepository stores the referees of videos encrypted with a
+ * field cipher, and decrypts them on read, so callers only see plain
+ * names. Each encrypted referee is stored with its blind index, which
+ * referee queries match on; referees stored before encryption are matched
+ * by name and encrypted on their next update.
+ */
+type RefereeCipherRepository struct {
+	models.VideoRepository
+	referees *FieldCipher
+}
+
+/**
+ * NewRefereeCipherRepository wraps a video repository to encrypt referees.
+ *
+ * @param repo The video repository
+ * @param referees The cipher of the referees, with an index key; nil keeps them in plain text
+ * @return The wrapped repository, or repo itself for a nil cipher
+ */
+func NewRefereeCipherRepository(repo models.VideoRepository, referees *FieldCipher) models.VideoRepository {
+	if referees == nil {
+		return repo
+	}
+	return &RefereeCipherRepository{VideoRepository: repo, referees: referees}
+}
+
+// WithContext returns the wrapped repository bound to ctx
+func (r *RefereeCipherRepository) WithContext(ctx context.Context) models.VideoRepository {
+	return &RefereeCipherRepository{VideoRepository: r.VideoRepository.WithContext(ctx), referees: r.referees}
+}
+
+// Create saves a video with its referee encrypted
+func (r *RefereeCipherRepository) Create(video *models.Video) error {
+	return r.sealed(video, r.VideoRepository.Create)
+}
+
+// Update saves a video with its referee encrypted
+func (r *RefereeCipherRepository) Update(video *models.Video) error {
+	return r.sealed(video, r.VideoRepository.Update)
+}
+
+// FindByID retrieves a video with its referee decrypted
+func (r *RefereeCipherRepository) FindByID(id string) (*models.Video, error) {
+	video, err := r.VideoRepository.FindByID(id)
+	if err != nil || video == nil {
+		return video, err
+	}
+	return video, r.open(video)
+}
+
+// FindAll lists videos with their referees decrypted
+func (r *RefereeCipherRepository) FindAll(limit, offset int) ([]*models.Video, error) {
+	return r.opened(r.VideoRepository.FindAll(limit, offset))
+}
+
+// FindByMatchID lists the videos of a match with their referees decrypted
+func (r *RefereeCipherRepository) FindByMatchID(matchID string) ([]*models.Video, error) {
+	return r.opened(r.VideoRepository.FindByMatchID(matchID))
+}
+
+// FindByTeam lists the videos of a team with their referees decrypted
+func (r *RefereeCipherRepository) FindByTeam(teamName string, limit, offset int) ([]*models.Video, error) {
+	return r.opened(r.VideoRepository.FindByTeam(teamName, limit, offset))
+}
+
+// FindByDateRange lists videos in a date range with their referees decrypted
+func (r *RefereeCipherRepository) FindByDateRange(start, end time.Time, limit, offset int) ([]*models.Video, error) {
+	return r.opened(r.VideoRepository.FindByDateRange(start, end, limit, offset))
+}
+
+// FindByProcessingState lists videos in a state with their referees decrypted
+func (r *RefereeCipherRepository) FindByProcessingState(state string, limit, offset int) ([]*models.Video, error) {
+	return r.opened(r.VideoRepository.FindByProcessingState(state, limit, offset))
+}
+
+// FindByQuery lists matching videos with their referees decrypted. A
+// referee filter matches encrypted referees by their blind index
+func (r *RefereeCipherRepository) FindByQuery(query models.VideoQuery) ([]*models.Video, error) {
+	query.RefereeKey = r.referees.Index(query.Referee)
+	return r.opened(r.VideoRepository.FindByQuery(query))
+}
+
+// sealed saves a video with save, its referee encrypted and indexed for
+// the call only, so the caller keeps the plain name
+func (r *RefereeCipherRepository) sealed(video *models.Video, save func(*models.Video) error) error {
+	referee, key := video.Referee, video.RefereeKey
+	sealed, err := r.referees.Encrypt(referee)
+	if err != nil {
+		return err
+	}
+	video.Referee, video.RefereeKey = sealed, r.referees.Index(referee)
+	defer func() { video.Referee, video.RefereeKey = referee, key }()
+	return save(video)
+}
+
+// open decrypts the referee of a video
+func (r *RefereeCipherRepository) open(video *models.Video) (err error) {
+	video.Referee, err = r.referees.Decrypt(video.Referee)
+	return err
+}
+
+// opened decrypts the referees of listed videos
+func (r *RefereeCipherRepository) opened(videos []*models.Video, err error) ([]*models.Video, error) {
+	if err != nil {
+		return nil, err
+	}
+	for _, video := range videos {
+		if err := r.open(video); err != nil {
+			return nil, err
+		}
+	}
+	return videos, nil
+}
+
+/**
+ * RefereeCipherReferenceData decrypts the referees of reference data
+ * loaded from videos whose referees are stored encrypted.
+ */
+type RefereeCipherReferenceData struct {
+	models.ReferenceDataRepository
+	referees *FieldCipher
+}
+
+/**
+ * NewRefereeCipherReferenceData wraps a reference data repository to
+ * decrypt referees.
+ *
+ * @param repo The reference data repository
+ * @param referees The cipher of the referees; nil keeps them in plain text
+ * @return The wrapped repository, or repo itself for a nil cipher
+ */
+func NewRefereeCipherReferenceData(repo models.ReferenceDataRepository, referees *FieldCipher) models.ReferenceDataRepository {
+	if referees == nil {
+		return repo
+	}
+	return &RefereeCipherReferenceData{ReferenceDataRepository: repo, referees: referees}
+}
+
+// Load retrieves the reference data with the referees decrypted, each
+// once and sorted. A referee stored both in plain text and encrypted is
+// listed by the database twice
+func (r *RefereeCipherReferenceData) Load() (*models.ReferenceData, error) {
+	data, err := r.ReferenceDataRepository.Load()
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool, len(data.Referees))
+	referees := make([]string, 0, len(data.Referees))
+	for _, stored := range data.Referees {
+		referee, err := r.referees.Decrypt(stored)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[referee] {
+			seen[referee] = true
+			referees = append(referees, referee)
+		}
+	}
+	sort.Strings(referees)
+	data.Referees = referees
+	return data, nil
+}
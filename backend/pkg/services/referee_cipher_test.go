@@ -0,0 +1,78 @@
+package services_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRefereeCipherRepository(t *testing.T) {
+	keyring, err := services.ParseKeyring("2024:"+base64Key(1), "")
+	require.NoError(t, err)
+	referees := services.NewFieldCipher(keyring, services.WithIndexKey([]byte("index key")))
+
+	stored := models.NewMemoryVideoRepository()
+	repo := services.NewRefereeCipherRepository(stored, referees)
+	require.NoError(t, stored.Create(&models.Video{ID: "v1", Title: "Ajax - PSV", Referee: "D. Makkelie"}))
+	for _, video := range []*models.Video{
+		{ID: "v2", Title: "PSV - AZ", Referee: "D. Makkelie"},
+		{ID: "v3", Title: "AZ - Ajax", Referee: "B. Nijhuis"},
+	} {
+		require.NoError(t, repo.Create(video))
+		assert.NotContains(t, video.Referee, "enc:", "the caller keeps the plain name")
+	}
+
+	t.Run("Referees are stored encrypted and read decrypted", func(t *testing.T) {
+		raw, err := stored.FindByID("v2")
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(raw.Referee, "enc:v1:2024:"))
+		assert.Equal(t, referees.Index("D. Makkelie"), raw.RefereeKey)
+
+		video, err := repo.FindByID("v2")
+		require.NoError(t, err)
+		assert.Equal(t, "D. Makkelie", video.Referee)
+
+		videos, err := repo.WithContext(context.Background()).FindAll(10, 0)
+		require.NoError(t, err)
+		for _, video := range videos {
+			assert.NotContains(t, video.Referee, "enc:")
+		}
+	})
+
+	t.Run("Referee filters match encrypted and plain text referees", func(t *testing.T) {
+		videos, err := repo.FindByQuery(models.VideoQuery{Referee: "D. Makkelie"})
+		require.NoError(t, err)
+		ids := make([]string, 0, len(videos))
+		for _, video := range videos {
+			ids = append(ids, video.ID)
+			assert.Equal(t, "D. Makkelie", video.Referee)
+		}
+		assert.ElementsMatch(t, []string{"v1", "v2"}, ids)
+	})
+
+	t.Run("Updates encrypt plain text referees", func(t *testing.T) {
+		video, err := repo.FindByID("v1")
+		require.NoError(t, err)
+		require.NoError(t, repo.Update(video))
+		raw, err := stored.FindByID("v1")
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(raw.Referee, "enc:v1:"))
+	})
+
+	t.Run("Reference data lists each referee once", func(t *testing.T) {
+		require.NoError(t, stored.Create(&models.Video{ID: "v4", Title: "Feyenoord - PSV", Referee: "B. Nijhuis"}))
+		data, err := services.NewRefereeCipherReferenceData(models.NewMemoryReferenceDataRepository(stored), referees).Load()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"B. Nijhuis", "D. Makkelie"}, data.Referees)
+	})
+
+	t.Run("Without a cipher repositories are left as they are", func(t *testing.T) {
+		assert.Same(t, stored, services.NewRefereeCipherRepository(stored, nil))
+	})
+}
@@ -0,0 +1,287 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/pythonapi"
+	"nivai/backend/pkg/report"
+
+	"github.com/google/uuid"
+)
+
+// Report job states
+const (
+	ReportStatusPending   = "pending"
+	ReportStatusRunning   = "running"
+	ReportStatusCompleted = "completed"
+	ReportStatusFailed    = "failed"
+)
+
+// reportTimeout bounds the analytics fetch and rendering of one report
+const reportTimeout = 2 * time.Minute
+
+var (
+	// ErrReportNotFound is returned when a report job ID is unknown.
+	ErrReportNotFound = errors.New("report not found")
+	// ErrReportNotReady is returned when downloading a report that has not completed.
+	ErrReportNotReady = errors.New("report not ready")
+)
+
+/**
+ * ReportJob tracks the asynchronous generation of a match report.
+ */
+type ReportJob struct {
+	ID          string     `json:"id"`
+	MatchID     string     `json:"match_id"`
+	Status      string     `json:"status"`
+	CreatedAt   time.Time  `json:"created_at"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+	Size        int        `json:"size,omitempty"` // PDF size in bytes once completed
+	DownloadURL string     `json:"download_url,omitempty"`
+	Error       string     `json:"error,omitempty"`
+}
+
+/**
+ * ReportService generates PDF match reports in the background. Jobs and
+ * their documents are kept in memory; the most recent maxJobs are retained.
+ */
+type ReportService struct {
+	videos    VideoService
+	analytics AnalyticsReader
+	now       func() time.Time
+
+	mu      sync.Mutex
+	jobs    map[string]*ReportJob
+	pdfs    map[string][]byte
+	order   []string
+	maxJobs int
+}
+
+/**
+ * NewReportService creates a new report service.
+ *
+ * @param videos Video service used to look up match metadata
+ * @param analytics Analytics reader (typically the analytics cache)
+ * @return A new report service
+ */
+func NewReportService(videos VideoService, analytics AnalyticsReader) *ReportService {
+	return &ReportService{
+		videos:    videos,
+		analytics: analytics,
+		now:       time.Now,
+		jobs:      make(map[string]*ReportJob),
+		pdfs:      make(map[string][]byte),
+		maxJobs:   50,
+	}
+}
+
+/**
+ * Start queues report generation for a match. A job that is still pending
+ * or running for the same match is returned instead of starting another.
+ *
+ * @param matchID The match (video) ID
+ * @return A copy of the job, or ErrVideoNotFound
+ */
+func (s *ReportService) Start(matchID string) (*ReportJob, error) {
+	video, err := s.videos.GetVideoByID(matchID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	for i := len(s.order) - 1; i >= 0; i-- {
+		job := s.jobs[s.order[i]]
+		if job.MatchID == matchID && (job.Status == ReportStatusPending || job.Status == ReportStatusRunning) {
+			c := *job
+			s.mu.Unlock()
+			return &c, nil
+		}
+	}
+	job := &ReportJob{
+		ID:        uuid.New().String(),
+		MatchID:   matchID,
+		Status:    ReportStatusPending,
+		CreatedAt: s.now(),
+	}
+	s.jobs[job.ID] = job
+	s.order = append(s.order, job.ID)
+	if len(s.order) > s.maxJobs {
+		delete(s.jobs, s.order[0])
+		delete(s.pdfs, s.order[0])
+		s.order = s.order[1:]
+	}
+	c := *job
+	s.mu.Unlock()
+
+	go s.generate(job.ID, video)
+	return &c, nil
+}
+
+/**
+ * Get returns a copy of a report job.
+ *
+ * @param id The job ID
+ * @return The job, or ErrReportNotFound
+ */
+func (s *ReportService) Get(id string) (*ReportJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, ErrReportNotFound
+	}
+	c := *job
+	return &c, nil
+}
+
+/**
+ * Download returns the PDF of a completed report.
+ *
+ * @param id The job ID
+ * @return The PDF document and the job, ErrReportNotFound, or
+ *         ErrReportNotReady while the report is pending, running or failed
+ */
+func (s *ReportService) Download(id string) ([]byte, *ReportJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, nil, ErrReportNotFound
+	}
+	c := *job
+	if job.Status != ReportStatusCompleted {
+		return nil, &c, ErrReportNotReady
+	}
+	return s.pdfs[id], &c, nil
+}
+
+// generate renders a report and records the outcome on its job.
+func (s *ReportService) generate(jobID string, video *models.Video) {
+	s.setStatus(jobID, ReportStatusRunning, nil, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), reportTimeout)
+	defer cancel()
+
+	var buf bytes.Buffer
+	err := s.render(ctx, video, &buf)
+	if err != nil {
+		log.Printf("Error generating report for match %s: %v", video.ID, err)
+		s.setStatus(jobID, ReportStatusFailed, nil, err)
+		return
+	}
+	s.setStatus(jobID, ReportStatusCompleted, buf.Bytes(), nil)
+}
+
+// setStatus updates a job, storing the document when it completes.
+func (s *ReportService) setStatus(jobID, status string, pdf []byte, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[jobID]
+	if !ok {
+		return // Evicted while running
+	}
+	job.Status = status
+	switch status {
+	case ReportStatusCompleted:
+		finished := s.now()
+		job.FinishedAt = &finished
+		job.Size = len(pdf)
+		job.DownloadURL = "/api/v1/reports/" + jobID + "/download"
+		s.pdfs[jobID] = pdf
+	case ReportStatusFailed:
+		finished := s.now()
+		job.FinishedAt = &finished
+		job.Error = err.Error()
+	}
+}
+
+/**
+ * render builds the report content for a match and writes the PDF.
+ *
+ * @param ctx Context for the analytics requests
+ * @param video The match
+ * @param w Destination of the PDF
+ * @return An error if the analytics cannot be read or rendering fails
+ */
+func (s *ReportService) render(ctx context.Context, video *models.Video, w io.Writer) error {
+	summary, err := s.analytics.GetMatchSummary(ctx, video.ID)
+	if err != nil {
+		return err
+	}
+
+	teams, err := entityStats(summary.Teams)
+	if err != nil {
+		return err
+	}
+	players, err := entityStats(summary.Players)
+	if err != nil {
+		return err
+	}
+	orderTeams(teams, video.HomeTeam, video.AwayTeam)
+
+	return report.RenderPDF(w, &report.MatchReport{
+		MatchID:     video.ID,
+		Title:       video.Title,
+		HomeTeam:    video.HomeTeam,
+		AwayTeam:    video.AwayTeam,
+		Competition: video.Competition,
+		Season:      video.Season,
+		MatchDate:   video.MatchDate,
+		GeneratedAt: s.now(),
+		Teams:       teams,
+		Players:     players,
+	})
+}
+
+/**
+ * entityStats decodes a summary's teams or players object into numeric
+ * statistics per entity, sorted by ID.
+ *
+ * @param raw The raw JSON object keyed by entity ID
+ * @return The statistics per entity
+ */
+func entityStats(raw json.RawMessage) ([]report.Stats, error) {
+	var entities map[string]map[string]interface{}
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &entities); err != nil {
+			return nil, fmt.Errorf("%w: %v", pythonapi.ErrInvalidResponse, err)
+		}
+	}
+	stats := make([]report.Stats, 0, len(entities))
+	for id, values := range entities {
+		stats = append(stats, report.Stats{ID: id, Stats: numericStats(values)})
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].ID < stats[j].ID })
+	return stats, nil
+}
+
+/**
+ * orderTeams puts the home team first and the away team second, when the
+ * summary's team keys match the fixture.
+ *
+ * @param teams The team statistics, sorted by ID
+ * @param home The home team name
+ * @param away The away team name
+ */
+func orderTeams(teams []report.Stats, home, away string) {
+	rank := func(id string) int {
+		switch id {
+		case home:
+			return 0
+		case away:
+			return 1
+		}
+		return 2
+	}
+	sort.SliceStable(teams, func(i, j int) bool { return rank(teams[i].ID) < rank(teams[j].ID) })
+}
@@ -0,0 +1,108 @@
+package services_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/pythonapi"
+	"nivai/backend/pkg/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// reportSummaryReader serves one match summary and can hold requests until
+// released, so tests can observe running jobs.
+type reportSummaryReader struct {
+	fakeAnalyticsReader
+	release chan struct{}
+}
+
+func (f *reportSummaryReader) GetMatchSummary(ctx context.Context, matchID string) (*pythonapi.MatchSummary, error) {
+	if f.release != nil {
+		<-f.release
+	}
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &pythonapi.MatchSummary{
+		MatchID: matchID,
+		Players: json.RawMessage(`{"7":{"total_distance_m":10250.5,"max_speed_kmh":31.2}}`),
+		Teams:   json.RawMessage(`{"Feyenoord":{"total_distance_m":108000},"Ajax":{"total_distance_m":112000}}`),
+	}, nil
+}
+
+// waitForReport polls a job until it leaves the pending and running states.
+func waitForReport(t *testing.T, reports *services.ReportService, id string) *services.ReportJob {
+	t.Helper()
+	var job *services.ReportJob
+	require.Eventually(t, func() bool {
+		var err error
+		job, err = reports.Get(id)
+		require.NoError(t, err)
+		return job.Status == services.ReportStatusCompleted || job.Status == services.ReportStatusFailed
+	}, 5*time.Second, 10*time.Millisecond)
+	return job
+}
+
+func TestReportService(t *testing.T) {
+	match := &models.Video{ID: "m1", HomeTeam: "Ajax", AwayTeam: "Feyenoord", ProcessingState: "completed"}
+
+	t.Run("Reports are generated in the background", func(t *testing.T) {
+		repo := new(MockVideoRepository)
+		repo.On("FindByID", "m1").Return(match, nil)
+		reader := &reportSummaryReader{release: make(chan struct{})}
+		reports := services.NewReportService(services.NewVideoService(repo, nil), reader)
+
+		job, err := reports.Start("m1")
+		require.NoError(t, err)
+		assert.Equal(t, "m1", job.MatchID)
+
+		again, err := reports.Start("m1")
+		require.NoError(t, err)
+		assert.Equal(t, job.ID, again.ID, "an unfinished job is reused")
+
+		_, _, err = reports.Download(job.ID)
+		assert.ErrorIs(t, err, services.ErrReportNotReady)
+
+		close(reader.release)
+		done := waitForReport(t, reports, job.ID)
+		require.Equal(t, services.ReportStatusCompleted, done.Status, done.Error)
+		assert.Equal(t, "/api/v1/reports/"+job.ID+"/download", done.DownloadURL)
+
+		pdf, _, err := reports.Download(job.ID)
+		require.NoError(t, err)
+		assert.True(t, bytes.HasPrefix(pdf, []byte("%PDF-")))
+		assert.Equal(t, done.Size, len(pdf))
+	})
+
+	t.Run("Analytics errors fail the job", func(t *testing.T) {
+		repo := new(MockVideoRepository)
+		repo.On("FindByID", "m1").Return(match, nil)
+		reader := &reportSummaryReader{}
+		reader.err = &pythonapi.APIError{StatusCode: 404, Detail: "Match data not processed or match ID not found."}
+		reports := services.NewReportService(services.NewVideoService(repo, nil), reader)
+
+		job, err := reports.Start("m1")
+		require.NoError(t, err)
+		done := waitForReport(t, reports, job.ID)
+		assert.Equal(t, services.ReportStatusFailed, done.Status)
+		assert.Contains(t, done.Error, "not processed")
+	})
+
+	t.Run("Unknown matches and jobs", func(t *testing.T) {
+		repo := new(MockVideoRepository)
+		repo.On("FindByID", "missing").Return(nil, errors.New("video not found"))
+		reports := services.NewReportService(services.NewVideoService(repo, nil), &reportSummaryReader{})
+
+		_, err := reports.Start("missing")
+		assert.ErrorIs(t, err, services.ErrVideoNotFound)
+		_, err = reports.Get("nope")
+		assert.ErrorIs(t, err, services.ErrReportNotFound)
+	})
+}
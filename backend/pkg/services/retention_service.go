@@ -0,0 +1,478 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"nivai/backend/pkg/models"
+)
+
+// Retention actions
+const (
+	RetentionKeep    = "keep"    // Keep forever
+	RetentionArchive = "archive" // Move to the archive prefix after Days
+	RetentionDelete  = "delete"  // Delete after Days
+)
+
+// ErrInvalidRetentionRule is returned for rules with an unknown kind or
+// action, or a negative age.
+var ErrInvalidRetentionRule = errors.New("invalid retention rule")
+
+/**
+ * RetentionRule says what happens to one kind of file once it is older than
+ * Days. Kinds without a rule are kept forever, as are analytics results,
+ * which are not files in storage.
+ */
+type RetentionRule struct {
+	Kind   string `json:"kind"`   // "video", "tracking" or "events"
+	Action string `json:"action"` // "keep", "archive" or "delete"
+	Days   int    `json:"days,omitempty"`
+}
+
+/**
+ * ParseRetentionRules parses a comma-separated list of rules in the form
+ * "<kind>:<action>[:<days>]", e.g. "video:delete:180,tracking:archive:365".
+ *
+ * @param spec The rule list
+ * @return The rules, or ErrInvalidRetentionRule
+ */
+func ParseRetentionRules(spec string) ([]RetentionRule, error) {
+	var rules []RetentionRule
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.Split(entry, ":")
+		rule := RetentionRule{Kind: parts[0]}
+		if len(parts) > 1 {
+			rule.Action = parts[1]
+		}
+		if len(parts) > 2 {
+			days, err := strconv.Atoi(parts[2])
+			if err != nil {
+				return nil, fmt.Errorf("%w: %q", ErrInvalidRetentionRule, entry)
+			}
+			rule.Days = days
+		}
+		if len(parts) > 3 {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidRetentionRule, entry)
+		}
+		if err := rule.validate(); err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// validate checks the kind, action and age of a rule.
+func (r RetentionRule) validate() error {
+	switch r.Kind {
+	case models.FileKindVideo, models.FileKindTracking, models.FileKindEvents:
+	default:
+		return fmt.Errorf("%w: unknown file kind %q", ErrInvalidRetentionRule, r.Kind)
+	}
+	switch r.Action {
+	case RetentionKeep, RetentionArchive, RetentionDelete:
+	default:
+		return fmt.Errorf("%w: unknown action %q", ErrInvalidRetentionRule, r.Action)
+	}
+	if r.Days < 0 {
+		return fmt.Errorf("%w: negative age for %s", ErrInvalidRetentionRule, r.Kind)
+	}
+	return nil
+}
+
+/**
+ * RetentionConfig tunes the retention sweeper.
+ */
+type RetentionConfig struct {
+	Rules         []RetentionRule
+	DryRun        bool   // Scheduled sweeps only report what they would do
+	ArchivePrefix string // Storage prefix archived files are moved under (default "archive/")
+}
+
+/**
+ * RetentionAction is one file that is due for archiving or deletion, with
+ * the outcome when the sweep was not a dry run.
+ */
+type RetentionAction struct {
+	VideoID     string    `json:"video_id"`
+	Kind        string    `json:"kind"`
+	Action      string    `json:"action"`
+	Path        string    `json:"path"`
+	ArchivePath string    `json:"archive_path,omitempty"`
+	DueAt       time.Time `json:"due_at"`
+	Done        bool      `json:"done"`
+	Error       string    `json:"error,omitempty"`
+}
+
+/**
+ * RetentionReport is the result of a retention sweep.
+ */
+type RetentionReport struct {
+	DryRun         bool              `json:"dry_run"`
+	StartedAt      time.Time         `json:"started_at"`
+	FinishedAt     time.Time         `json:"finished_at"`
+	MatchesChecked int               `json:"matches_checked"`
+	Actions        []RetentionAction `json:"actions"`
+	Error          string            `json:"error,omitempty"`
+}
+
+/**
+ * MatchRetention describes the rules in force for a match and when each
+ * kind of file is due.
+ */
+type MatchRetention struct {
+	VideoID string               `json:"video_id"`
+	Rules   []MatchRetentionRule `json:"rules"`
+}
+
+/**
+ * MatchRetentionRule is a rule applied to one of a match's files.
+ */
+type MatchRetentionRule struct {
+	RetentionRule
+	Override bool       `json:"override"` // Set for the match rather than by default
+	DueAt    *time.Time `json:"due_at,omitempty"`
+}
+
+/**
+ * RetentionService applies retention rules to stored match files: a
+ * scheduled sweeper archives or deletes files once they are older than the
+ * rule for their kind allows, honouring per-match overrides.
+ */
+type RetentionService struct {
+	videoRepo models.VideoRepository
+	fileRepo  models.VideoFileRepository
+	repo      models.RetentionRepository
+	storage   StorageService
+	cfg       RetentionConfig
+	now       func() time.Time
+}
+
+/**
+ * NewRetentionService creates a new retention service.
+ *
+ * @param videoRepo Repository for video data
+ * @param fileRepo Repository for stored file records, updated when files move
+ * @param repo Repository for overrides and the action log
+ * @param storage Storage service holding the files
+ * @param cfg Default rules and sweeper settings
+ * @return A new retention service
+ */
+func NewRetentionService(
+	videoRepo models.VideoRepository,
+	fileRepo models.VideoFileRepository,
+	repo models.RetentionRepository,
+	storage StorageService,
+	cfg RetentionConfig,
+) *RetentionService {
+	if cfg.ArchivePrefix == "" {
+		cfg.ArchivePrefix = "archive/"
+	}
+	return &RetentionService{
+		videoRepo: videoRepo,
+		fileRepo:  fileRepo,
+		repo:      repo,
+		storage:   storage,
+		cfg:       cfg,
+		now:       time.Now,
+	}
+}
+
+/**
+ * RunScheduled runs a scheduled sweep, which is a dry run when the service
+ * is configured so.
+ *
+ * @param ctx Context for the sweep
+ * @return An error if the sweep failed
+ */
+func (s *RetentionService) RunScheduled(ctx context.Context) error {
+	if report := s.Sweep(ctx, s.cfg.DryRun); report.Error != "" {
+		return errors.New(report.Error)
+	}
+	return nil
+}
+
+/**
+ * Sweep checks every match against the retention rules and, unless dryRun
+ * is set, archives or deletes the files that are due.
+ *
+ * @param ctx Context for the sweep
+ * @param dryRun Only report what would be done
+ * @return The sweep report
+ */
+func (s *RetentionService) Sweep(ctx context.Context, dryRun bool) *RetentionReport {
+	const pageSize = 100
+	report := &RetentionReport{DryRun: dryRun, StartedAt: s.now(), Actions: []RetentionAction{}}
+
+	for offset := 0; ; offset += pageSize {
+		if err := ctx.Err(); err != nil {
+			report.Error = err.Error()
+			break
+		}
+
+		videos, err := s.videoRepo.FindAll(pageSize, offset)
+		if err != nil {
+			report.Error = fmt.Sprintf("failed to list videos: %v", err)
+			break
+		}
+
+		for _, video := range videos {
+			report.MatchesChecked++
+			actions, err := s.dueActions(video)
+			if err != nil {
+				log.Printf("Retention: skipping video %s: %v", video.ID, err)
+				continue
+			}
+			for _, action := range actions {
+				if !dryRun {
+					s.apply(video, &action)
+				}
+				report.Actions = append(report.Actions, action)
+			}
+		}
+
+		if len(videos) < pageSize {
+			break
+		}
+	}
+
+	report.FinishedAt = s.now()
+	if !dryRun {
+		done := 0
+		for _, action := range report.Actions {
+			if action.Done {
+				done++
+			}
+		}
+		log.Printf("Retention: sweep checked %d matches, %d of %d due files handled",
+			report.MatchesChecked, done, len(report.Actions))
+	}
+	return report
+}
+
+/**
+ * MatchRetention returns the rules in force for a match.
+ *
+ * @param videoID The match's video ID
+ * @return The effective rules with their due dates
+ */
+func (s *RetentionService) MatchRetention(videoID string) (*MatchRetention, error) {
+	video, err := s.videoRepo.FindByID(videoID)
+	if err != nil {
+		return nil, err
+	}
+	overrides, err := s.overrides(videoID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &MatchRetention{VideoID: videoID, Rules: []MatchRetentionRule{}}
+	for _, rule := range s.effectiveRules(overrides) {
+		_, override := overrides[rule.Kind]
+		entry := MatchRetentionRule{RetentionRule: rule, Override: override}
+		if rule.Action != RetentionKeep {
+			due := dueAt(video, rule)
+			entry.DueAt = &due
+		}
+		result.Rules = append(result.Rules, entry)
+	}
+	return result, nil
+}
+
+/**
+ * SetOverrides replaces a match's overrides. An empty list restores the
+ * default rules.
+ *
+ * @param videoID The match's video ID
+ * @param rules The override rules, at most one per kind
+ * @return ErrInvalidRetentionRule for invalid or duplicate rules
+ */
+func (s *RetentionService) SetOverrides(videoID string, rules []RetentionRule) error {
+	seen := map[string]bool{}
+	overrides := make([]*models.RetentionOverride, 0, len(rules))
+	for _, rule := range rules {
+		if err := rule.validate(); err != nil {
+			return err
+		}
+		if seen[rule.Kind] {
+			return fmt.Errorf("%w: duplicate rule for %s", ErrInvalidRetentionRule, rule.Kind)
+		}
+		seen[rule.Kind] = true
+		overrides = append(overrides, &models.RetentionOverride{
+			VideoID: videoID, Kind: rule.Kind, Action: rule.Action, Days: rule.Days, UpdatedAt: s.now(),
+		})
+	}
+	return s.repo.ReplaceOverrides(videoID, overrides)
+}
+
+// overrides loads a match's overrides keyed by kind.
+func (s *RetentionService) overrides(videoID string) (map[string]RetentionRule, error) {
+	records, err := s.repo.FindOverrides(videoID)
+	if err != nil {
+		return nil, err
+	}
+	overrides := make(map[string]RetentionRule, len(records))
+	for _, o := range records {
+		overrides[o.Kind] = RetentionRule{Kind: o.Kind, Action: o.Action, Days: o.Days}
+	}
+	return overrides, nil
+}
+
+// effectiveRules merges the default rules with a match's overrides, sorted by kind.
+func (s *RetentionService) effectiveRules(overrides map[string]RetentionRule) []RetentionRule {
+	byKind := map[string]RetentionRule{}
+	for _, rule := range s.cfg.Rules {
+		byKind[rule.Kind] = rule
+	}
+	for kind, rule := range overrides {
+		byKind[kind] = rule
+	}
+	rules := make([]RetentionRule, 0, len(byKind))
+	for _, rule := range byKind {
+		rules = append(rules, rule)
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].Kind < rules[j].Kind })
+	return rules
+}
+
+// dueActions lists the files of a video that are due under its rules.
+// Matches still being processed, or in cold storage, are left alone.
+func (s *RetentionService) dueActions(video *models.Video) ([]RetentionAction, error) {
+	switch video.ProcessingState {
+	case "pending_analytics", "processing", "archived", "restoring":
+		return nil, nil
+	}
+
+	overrides, err := s.overrides(video.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var actions []RetentionAction
+	for _, rule := range s.effectiveRules(overrides) {
+		if rule.Action == RetentionKeep {
+			continue
+		}
+		filePath := *filePathField(video, rule.Kind)
+		if filePath == "" || (rule.Action == RetentionArchive && s.archived(filePath)) {
+			continue
+		}
+		due := dueAt(video, rule)
+		if s.now().Before(due) {
+			continue
+		}
+		action := RetentionAction{VideoID: video.ID, Kind: rule.Kind, Action: rule.Action, Path: filePath, DueAt: due}
+		if rule.Action == RetentionArchive {
+			action.ArchivePath = path.Join(s.cfg.ArchivePrefix, filePath)
+		}
+		actions = append(actions, action)
+	}
+	return actions, nil
+}
+
+// apply archives or deletes one file and records the outcome on action.
+func (s *RetentionService) apply(video *models.Video, action *RetentionAction) {
+	var err error
+	switch action.Action {
+	case RetentionArchive:
+		err = s.archive(video, action)
+	case RetentionDelete:
+		err = s.delete(video, action)
+	}
+	if err != nil {
+		action.Error = err.Error()
+		log.Printf("Retention: failed to %s %s file of video %s: %v", action.Action, action.Kind, video.ID, err)
+		return
+	}
+	action.Done = true
+
+	if err := s.repo.RecordAction(&models.RetentionActionRecord{
+		VideoID: video.ID, Kind: action.Kind, Action: action.Action,
+		Path: action.Path, ArchivePath: action.ArchivePath, PerformedAt: s.now(),
+	}); err != nil {
+		log.Printf("Retention: failed to log %s of %s: %v", action.Action, action.Path, err)
+	}
+}
+
+// archive copies a file under the archive prefix, points the video and its
+// file record at the copy, and deletes the original.
+func (s *RetentionService) archive(video *models.Video, action *RetentionAction) error {
+	if err := s.storage.CopyFile(action.Path, action.ArchivePath); err != nil {
+		return fmt.Errorf("failed to copy to archive: %w", err)
+	}
+
+	*filePathField(video, action.Kind) = action.ArchivePath
+	if err := s.videoRepo.Update(video); err != nil {
+		return err
+	}
+	s.moveFileRecord(video.ID, action.Kind, action.ArchivePath)
+
+	if err := s.storage.DeleteFile(action.Path); err != nil {
+		log.Printf("Retention: archived %s but failed to delete the original: %v", action.Path, err)
+	}
+	return nil
+}
+
+// delete removes a file and clears its path on the video.
+func (s *RetentionService) delete(video *models.Video, action *RetentionAction) error {
+	if err := s.storage.DeleteFile(action.Path); err != nil {
+		return err
+	}
+	*filePathField(video, action.Kind) = ""
+	return s.videoRepo.Update(video)
+}
+
+// moveFileRecord points a video's file record of the given kind at a new path.
+func (s *RetentionService) moveFileRecord(videoID, kind, newPath string) {
+	if s.fileRepo == nil {
+		return
+	}
+	files, err := s.fileRepo.FindByVideoID(videoID)
+	if err != nil {
+		log.Printf("Retention: failed to load file records of video %s: %v", videoID, err)
+		return
+	}
+	for _, file := range files {
+		if file.Kind != kind {
+			continue
+		}
+		file.Path = newPath
+		file.UpdatedAt = s.now()
+		if err := s.fileRepo.Save(file); err != nil {
+			log.Printf("Retention: failed to update file record of video %s: %v", videoID, err)
+		}
+	}
+}
+
+// archived reports whether a path is already under the archive prefix.
+func (s *RetentionService) archived(filePath string) bool {
+	return strings.HasPrefix(filePath, strings.TrimSuffix(s.cfg.ArchivePrefix, "/")+"/")
+}
+
+// dueAt returns when a rule applies to a video's files, counted from upload.
+func dueAt(video *models.Video, rule RetentionRule) time.Time {
+	return video.CreatedAt.AddDate(0, 0, rule.Days)
+}
+
+// filePathField returns the video field holding the path of a kind of file.
+func filePathField(video *models.Video, kind string) *string {
+	switch kind {
+	case models.FileKindTracking:
+		return &video.TrackingPath
+	case models.FileKindEvents:
+		return &video.EventFilePath
+	default:
+		return &video.FilePath
+	}
+}
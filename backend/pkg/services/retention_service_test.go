@@ -0,0 +1,159 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// --- MockRetentionRepository ---
+type MockRetentionRepository struct {
+	mock.Mock
+}
+
+func (m *MockRetentionRepository) ReplaceOverrides(videoID string, overrides []*models.RetentionOverride) error {
+	args := m.Called(videoID, overrides)
+	return args.Error(0)
+}
+func (m *MockRetentionRepository) FindOverrides(videoID string) ([]*models.RetentionOverride, error) {
+	args := m.Called(videoID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.RetentionOverride), args.Error(1)
+}
+func (m *MockRetentionRepository) RecordAction(record *models.RetentionActionRecord) error {
+	args := m.Called(record)
+	return args.Error(0)
+}
+func (m *MockRetentionRepository) FindActions(videoID string) ([]*models.RetentionActionRecord, error) {
+	args := m.Called(videoID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.RetentionActionRecord), args.Error(1)
+}
+
+func TestParseRetentionRules(t *testing.T) {
+	rules, err := services.ParseRetentionRules("video:delete:180, tracking:archive:365,events:keep")
+	require.NoError(t, err)
+	assert.Equal(t, []services.RetentionRule{
+		{Kind: "video", Action: services.RetentionDelete, Days: 180},
+		{Kind: "tracking", Action: services.RetentionArchive, Days: 365},
+		{Kind: "events", Action: services.RetentionKeep},
+	}, rules)
+
+	for _, spec := range []string{"video", "video:shred:10", "analytics:keep", "video:delete:soon", "video:delete:-1", "video:delete:1:2"} {
+		_, err := services.ParseRetentionRules(spec)
+		assert.ErrorIs(t, err, services.ErrInvalidRetentionRule, spec)
+	}
+}
+
+func TestRetentionService(t *testing.T) {
+	old := time.Now().AddDate(0, 0, -200)
+	rules := []services.RetentionRule{
+		{Kind: models.FileKindVideo, Action: services.RetentionDelete, Days: 180},
+		{Kind: models.FileKindTracking, Action: services.RetentionArchive, Days: 180},
+	}
+
+	t.Run("Dry run reports due files without touching them", func(t *testing.T) {
+		videoRepo := new(MockVideoRepository)
+		retentionRepo := new(MockRetentionRepository)
+		storage := new(MockStorageService)
+		videoRepo.On("FindAll", 100, 0).Return([]*models.Video{
+			{ID: "old", FilePath: "videos/old.mp4", TrackingPath: "videos/old_tracking.parquet", ProcessingState: "completed", CreatedAt: old},
+			{ID: "new", FilePath: "videos/new.mp4", ProcessingState: "completed", CreatedAt: time.Now()},
+			{ID: "busy", FilePath: "videos/busy.mp4", ProcessingState: "processing", CreatedAt: old},
+		}, nil).Once()
+		retentionRepo.On("FindOverrides", mock.Anything).Return([]*models.RetentionOverride{}, nil)
+
+		service := services.NewRetentionService(videoRepo, nil, retentionRepo, storage, services.RetentionConfig{Rules: rules})
+		report := service.Sweep(context.Background(), true)
+
+		assert.True(t, report.DryRun)
+		assert.Equal(t, 3, report.MatchesChecked)
+		require.Len(t, report.Actions, 2)
+		assert.Equal(t, "videos/old_tracking.parquet", report.Actions[0].Path)
+		assert.Equal(t, "archive/videos/old_tracking.parquet", report.Actions[0].ArchivePath)
+		assert.Equal(t, "videos/old.mp4", report.Actions[1].Path)
+		assert.Equal(t, services.RetentionDelete, report.Actions[1].Action)
+		assert.False(t, report.Actions[1].Done)
+		storage.AssertNotCalled(t, "DeleteFile", mock.Anything)
+		videoRepo.AssertNotCalled(t, "Update", mock.Anything)
+	})
+
+	t.Run("Sweep archives and deletes due files", func(t *testing.T) {
+		videoRepo := new(MockVideoRepository)
+		retentionRepo := new(MockRetentionRepository)
+		fileRepo := new(MockVideoFileRepository)
+		storage := new(MockStorageService)
+		video := &models.Video{ID: "v1", FilePath: "videos/v1.mp4", TrackingPath: "videos/v1_tracking.parquet", ProcessingState: "completed", CreatedAt: old}
+
+		videoRepo.On("FindAll", 100, 0).Return([]*models.Video{video}, nil).Once()
+		retentionRepo.On("FindOverrides", "v1").Return([]*models.RetentionOverride{}, nil)
+		storage.On("CopyFile", "videos/v1_tracking.parquet", "archive/videos/v1_tracking.parquet").Return(nil).Once()
+		storage.On("DeleteFile", "videos/v1_tracking.parquet").Return(nil).Once()
+		storage.On("DeleteFile", "videos/v1.mp4").Return(nil).Once()
+		fileRepo.On("FindByVideoID", "v1").Return([]*models.VideoFile{{VideoID: "v1", Kind: models.FileKindTracking, Path: "videos/v1_tracking.parquet"}}, nil).Once()
+		fileRepo.On("Save", mock.MatchedBy(func(f *models.VideoFile) bool {
+			return f.Path == "archive/videos/v1_tracking.parquet"
+		})).Return(nil).Once()
+		videoRepo.On("Update", video).Return(nil).Twice()
+		retentionRepo.On("RecordAction", mock.Anything).Return(nil).Twice()
+
+		service := services.NewRetentionService(videoRepo, fileRepo, retentionRepo, storage, services.RetentionConfig{Rules: rules})
+		report := service.Sweep(context.Background(), false)
+
+		require.Len(t, report.Actions, 2)
+		assert.True(t, report.Actions[0].Done)
+		assert.True(t, report.Actions[1].Done)
+		assert.Equal(t, "archive/videos/v1_tracking.parquet", video.TrackingPath)
+		assert.Empty(t, video.FilePath)
+		storage.AssertExpectations(t)
+		fileRepo.AssertExpectations(t)
+		retentionRepo.AssertExpectations(t)
+	})
+
+	t.Run("Overrides replace the default rule of a match", func(t *testing.T) {
+		videoRepo := new(MockVideoRepository)
+		retentionRepo := new(MockRetentionRepository)
+		video := &models.Video{ID: "final", FilePath: "videos/final.mp4", ProcessingState: "completed", CreatedAt: old}
+		videoRepo.On("FindAll", 100, 0).Return([]*models.Video{video}, nil).Once()
+		videoRepo.On("FindByID", "final").Return(video, nil).Once()
+		retentionRepo.On("FindOverrides", "final").Return([]*models.RetentionOverride{
+			{VideoID: "final", Kind: models.FileKindVideo, Action: services.RetentionKeep},
+		}, nil)
+
+		service := services.NewRetentionService(videoRepo, nil, retentionRepo, new(MockStorageService), services.RetentionConfig{Rules: rules})
+		assert.Empty(t, service.Sweep(context.Background(), true).Actions)
+
+		retention, err := service.MatchRetention("final")
+		require.NoError(t, err)
+		require.Len(t, retention.Rules, 2)
+		assert.Equal(t, services.RetentionArchive, retention.Rules[0].Action)
+		assert.False(t, retention.Rules[0].Override)
+		assert.WithinDuration(t, old.AddDate(0, 0, 180), *retention.Rules[0].DueAt, time.Second)
+		assert.Equal(t, services.RetentionKeep, retention.Rules[1].Action)
+		assert.True(t, retention.Rules[1].Override)
+		assert.Nil(t, retention.Rules[1].DueAt)
+	})
+
+	t.Run("Invalid overrides are rejected", func(t *testing.T) {
+		retentionRepo := new(MockRetentionRepository)
+		service := services.NewRetentionService(new(MockVideoRepository), nil, retentionRepo, new(MockStorageService), services.RetentionConfig{})
+
+		err := service.SetOverrides("v1", []services.RetentionRule{
+			{Kind: models.FileKindVideo, Action: services.RetentionKeep},
+			{Kind: models.FileKindVideo, Action: services.RetentionDelete, Days: 30},
+		})
+		assert.ErrorIs(t, err, services.ErrInvalidRetentionRule)
+		retentionRepo.AssertNotCalled(t, "ReplaceOverrides", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,294 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/pythonapi"
+)
+
+// aggregatePageSize is how many videos are read per repository page, and
+// aggregateFetchConcurrency bounds the parallel summary requests made for one
+// aggregate (season or player) request.
+const (
+	aggregatePageSize         = 100
+	aggregateFetchConcurrency = 8
+)
+
+/**
+ * TeamMatchStats holds one team's summary statistics for a single match,
+ * forming one point of the season trend.
+ */
+type TeamMatchStats struct {
+	MatchID     string             `json:"match_id"`
+	MatchDate   time.Time          `json:"match_date"`
+	Opponent    string             `json:"opponent"`
+	Home        bool               `json:"home"`
+	Competition string             `json:"competition,omitempty"`
+	Stats       map[string]float64 `json:"stats"`
+}
+
+/**
+ * TeamSeasonSummary aggregates a team's match summaries over a season.
+ * Totals follow the per-match team aggregation of the analytics service:
+ * avg_* statistics are averaged, max_* take the maximum and everything else
+ * is summed. PerMatchAverages holds the mean of every statistic per match.
+ */
+type TeamSeasonSummary struct {
+	TeamID             string             `json:"team_id"`
+	Season             string             `json:"season,omitempty"`
+	MatchesPlayed      int                `json:"matches_played"`
+	Totals             map[string]float64 `json:"totals"`
+	PerMatchAverages   map[string]float64 `json:"per_match_averages"`
+	Matches            []TeamMatchStats   `json:"matches"`
+	UnavailableMatches []string           `json:"unavailable_matches,omitempty"`
+}
+
+/**
+ * SeasonAnalyticsService builds season-level analytics from the per-match
+ * summaries, so dashboards need a single request instead of one per match.
+ */
+type SeasonAnalyticsService struct {
+	videoRepo models.VideoRepository
+	analytics AnalyticsReader
+}
+
+/**
+ * NewSeasonAnalyticsService creates a new season analytics service.
+ *
+ * @param videoRepo Repository used to find a team's matches
+ * @param analytics Source of match summaries, normally the analytics cache
+ * @return A new season analytics service
+ */
+func NewSeasonAnalyticsService(videoRepo models.VideoRepository, analytics AnalyticsReader) *SeasonAnalyticsService {
+	return &SeasonAnalyticsService{videoRepo: videoRepo, analytics: analytics}
+}
+
+/**
+ * TeamSeason aggregates the processed matches of a team in a season.
+ * The team ID is matched against the home/away team of each match and
+ * against the team keys of the analytics summary. An empty season covers
+ * all seasons. Matches whose summary cannot be fetched are listed in
+ * UnavailableMatches; an error is returned only if the matches cannot be
+ * listed or no summary at all could be fetched.
+ *
+ * @param ctx Context for the upstream requests
+ * @param teamID The team to aggregate
+ * @param season The season to aggregate, or "" for all seasons
+ * @return The season summary, or an error
+ */
+func (s *SeasonAnalyticsService) TeamSeason(ctx context.Context, teamID, season string) (*TeamSeasonSummary, error) {
+	videos, err := s.teamMatches(teamID, season)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &TeamSeasonSummary{
+		TeamID:           teamID,
+		Season:           season,
+		Totals:           map[string]float64{},
+		PerMatchAverages: map[string]float64{},
+		Matches:          []TeamMatchStats{},
+	}
+	if len(videos) == 0 {
+		return result, nil
+	}
+
+	stats := make([]map[string]float64, len(videos))
+	errs := make([]error, len(videos))
+	forEachConcurrently(len(videos), func(i int) {
+		stats[i], errs[i] = s.matchTeamStats(ctx, videos[i].ID, teamID)
+	})
+
+	var firstErr error
+	for i, video := range videos {
+		if errs[i] != nil {
+			if !errors.Is(errs[i], pythonapi.ErrNotFound) {
+				log.Printf("Season analytics: summary for match %s unavailable: %v", video.ID, errs[i])
+				if firstErr == nil {
+					firstErr = errs[i]
+				}
+			}
+			result.UnavailableMatches = append(result.UnavailableMatches, video.ID)
+			continue
+		}
+
+		home := strings.EqualFold(video.HomeTeam, teamID)
+		opponent := video.HomeTeam
+		if home {
+			opponent = video.AwayTeam
+		}
+		result.Matches = append(result.Matches, TeamMatchStats{
+			MatchID:     video.ID,
+			MatchDate:   video.MatchDate,
+			Opponent:    opponent,
+			Home:        home,
+			Competition: video.Competition,
+			Stats:       stats[i],
+		})
+	}
+
+	if len(result.Matches) == 0 && firstErr != nil {
+		return nil, firstErr
+	}
+
+	sort.SliceStable(result.Matches, func(i, j int) bool {
+		return result.Matches[i].MatchDate.Before(result.Matches[j].MatchDate)
+	})
+	aggregateSeason(result)
+	return result, nil
+}
+
+/**
+ * teamMatches lists the processed matches of a team, optionally restricted
+ * to one season, paging through the repository.
+ *
+ * @param teamID The team name
+ * @param season The season, or "" for all seasons
+ * @return The matching videos, or an error
+ */
+func (s *SeasonAnalyticsService) teamMatches(teamID, season string) ([]*models.Video, error) {
+	return processedMatches(func(limit, offset int) ([]*models.Video, error) {
+		return s.videoRepo.FindByTeam(teamID, limit, offset)
+	}, func(video *models.Video) bool {
+		return season == "" || video.Season == season
+	})
+}
+
+/**
+ * processedMatches pages through a repository query and keeps the completed
+ * matches accepted by keep.
+ *
+ * @param page Repository query returning one page of videos
+ * @param keep Additional filter applied to each completed video
+ * @return The kept videos, or an error
+ */
+func processedMatches(page func(limit, offset int) ([]*models.Video, error), keep func(*models.Video) bool) ([]*models.Video, error) {
+	var matches []*models.Video
+	for offset := 0; ; offset += aggregatePageSize {
+		videos, err := page(aggregatePageSize, offset)
+		if err != nil {
+			return nil, err
+		}
+		for _, video := range videos {
+			if video.ProcessingState == "completed" && keep(video) {
+				matches = append(matches, video)
+			}
+		}
+		if len(videos) < aggregatePageSize {
+			return matches, nil
+		}
+	}
+}
+
+/**
+ * forEachConcurrently calls fn for every index below n, with at most
+ * aggregateFetchConcurrency calls in flight, and waits for all of them.
+ *
+ * @param n Number of items
+ * @param fn Function called with each item index
+ */
+func forEachConcurrently(n int, fn func(i int)) {
+	sem := make(chan struct{}, aggregateFetchConcurrency)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
+
+/**
+ * matchTeamStats extracts the numeric summary statistics of one team from a
+ * match summary. A team missing from the summary is reported as not found.
+ *
+ * @param ctx Context for the upstream request
+ * @param matchID The match to read
+ * @param teamID The team whose statistics are wanted
+ * @return The team's statistics, or an error
+ */
+func (s *SeasonAnalyticsService) matchTeamStats(ctx context.Context, matchID, teamID string) (map[string]float64, error) {
+	summary, err := s.analytics.GetMatchSummary(ctx, matchID)
+	if err != nil {
+		return nil, err
+	}
+
+	var teams map[string]map[string]interface{}
+	if err := json.Unmarshal(summary.Teams, &teams); err != nil {
+		return nil, fmt.Errorf("%w: team summaries: %v", pythonapi.ErrInvalidResponse, err)
+	}
+
+	for key, raw := range teams {
+		if !strings.EqualFold(key, teamID) {
+			continue
+		}
+		return numericStats(raw), nil
+	}
+	return nil, &pythonapi.APIError{StatusCode: 404, Detail: "Team not found in match summary."}
+}
+
+/**
+ * numericStats keeps the numeric values of a decoded statistics object;
+ * missing values (JSON null) and non-numeric fields are dropped.
+ *
+ * @param raw The decoded statistics
+ * @return The numeric statistics by name
+ */
+func numericStats(raw map[string]interface{}) map[string]float64 {
+	stats := make(map[string]float64, len(raw))
+	for name, value := range raw {
+		if number, ok := value.(float64); ok {
+			stats[name] = number
+		}
+	}
+	return stats
+}
+
+/**
+ * aggregateSeason fills in the season totals and per-match averages from the
+ * per-match statistics.
+ *
+ * @param result The season summary to complete
+ */
+func aggregateSeason(result *TeamSeasonSummary) {
+	sums := map[string]float64{}
+	counts := map[string]int{}
+	for _, match := range result.Matches {
+		for name, value := range match.Stats {
+			if strings.HasPrefix(name, "max_") {
+				if current, seen := result.Totals[name]; !seen || value > current {
+					result.Totals[name] = value
+				}
+			}
+			sums[name] += value
+			counts[name]++
+		}
+	}
+
+	result.MatchesPlayed = len(result.Matches)
+	for name, sum := range sums {
+		mean := sum / float64(counts[name])
+		result.PerMatchAverages[name] = mean
+		switch {
+		case strings.HasPrefix(name, "max_"):
+			// Season maximum already recorded
+		case strings.HasPrefix(name, "avg_"):
+			result.Totals[name] = mean
+		default:
+			result.Totals[name] = sum
+		}
+	}
+}
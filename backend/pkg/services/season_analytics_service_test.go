@@ -0,0 +1,103 @@
+package services_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/pythonapi"
+	"nivai/backend/pkg/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// teamSummaryReader serves per-match team summaries; matches without an
+// entry return the configured error, or 404 if none is set.
+type teamSummaryReader struct {
+	fakeAnalyticsReader
+	teams map[string]string
+}
+
+func (f *teamSummaryReader) GetMatchSummary(ctx context.Context, matchID string) (*pythonapi.MatchSummary, error) {
+	teams, ok := f.teams[matchID]
+	if !ok {
+		if f.err != nil {
+			return nil, f.err
+		}
+		return nil, &pythonapi.APIError{StatusCode: 404, Detail: "Match data not processed or match ID not found."}
+	}
+	return &pythonapi.MatchSummary{MatchID: matchID, Players: json.RawMessage(`{}`), Teams: json.RawMessage(teams)}, nil
+}
+
+func TestSeasonAnalyticsService_TeamSeason(t *testing.T) {
+	ctx := context.Background()
+	day := func(d int) time.Time { return time.Date(2024, 9, d, 15, 0, 0, 0, time.UTC) }
+
+	t.Run("Season totals and trend are aggregated", func(t *testing.T) {
+		repo := new(MockVideoRepository)
+		repo.On("FindByTeam", "Ajax", 100, 0).Return([]*models.Video{
+			{ID: "m2", HomeTeam: "PSV", AwayTeam: "Ajax", Season: "2024/25", MatchDate: day(14), ProcessingState: "completed"},
+			{ID: "m1", HomeTeam: "Ajax", AwayTeam: "AZ", Season: "2024/25", MatchDate: day(7), ProcessingState: "completed"},
+			{ID: "old", HomeTeam: "Ajax", AwayTeam: "AZ", Season: "2023/24", MatchDate: day(1), ProcessingState: "completed"},
+			{ID: "pending", HomeTeam: "Ajax", AwayTeam: "FCU", Season: "2024/25", MatchDate: day(21), ProcessingState: "processing"},
+			{ID: "missing", HomeTeam: "Ajax", AwayTeam: "NEC", Season: "2024/25", MatchDate: day(28), ProcessingState: "completed"},
+		}, nil).Once()
+		reader := &teamSummaryReader{teams: map[string]string{
+			"m1": `{"Ajax":{"total_distance_m":100000,"avg_speed_kmh":7,"max_speed_kmh":33},"AZ":{"total_distance_m":1}}`,
+			"m2": `{"Ajax":{"total_distance_m":110000,"avg_speed_kmh":8,"max_speed_kmh":31}}`,
+		}}
+
+		summary, err := services.NewSeasonAnalyticsService(repo, reader).TeamSeason(ctx, "Ajax", "2024/25")
+		require.NoError(t, err)
+
+		assert.Equal(t, 2, summary.MatchesPlayed)
+		assert.Equal(t, 210000.0, summary.Totals["total_distance_m"])
+		assert.Equal(t, 7.5, summary.Totals["avg_speed_kmh"])
+		assert.Equal(t, 33.0, summary.Totals["max_speed_kmh"])
+		assert.Equal(t, 105000.0, summary.PerMatchAverages["total_distance_m"])
+		assert.Equal(t, 32.0, summary.PerMatchAverages["max_speed_kmh"])
+
+		require.Len(t, summary.Matches, 2)
+		assert.Equal(t, "m1", summary.Matches[0].MatchID)
+		assert.Equal(t, "AZ", summary.Matches[0].Opponent)
+		assert.True(t, summary.Matches[0].Home)
+		assert.Equal(t, "PSV", summary.Matches[1].Opponent)
+		assert.False(t, summary.Matches[1].Home)
+		assert.Equal(t, []string{"missing"}, summary.UnavailableMatches)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Team without matches yields an empty season", func(t *testing.T) {
+		repo := new(MockVideoRepository)
+		repo.On("FindByTeam", "Nobody", 100, 0).Return([]*models.Video{}, nil).Once()
+
+		summary, err := services.NewSeasonAnalyticsService(repo, &teamSummaryReader{}).TeamSeason(ctx, "Nobody", "")
+		require.NoError(t, err)
+		assert.Equal(t, 0, summary.MatchesPlayed)
+		assert.Empty(t, summary.Matches)
+	})
+
+	t.Run("Analytics outage fails when nothing could be fetched", func(t *testing.T) {
+		repo := new(MockVideoRepository)
+		repo.On("FindByTeam", "Ajax", 100, 0).Return([]*models.Video{
+			{ID: "m1", HomeTeam: "Ajax", ProcessingState: "completed"},
+		}, nil).Once()
+		reader := &teamSummaryReader{}
+		reader.err = pythonapi.ErrUnavailable
+
+		_, err := services.NewSeasonAnalyticsService(repo, reader).TeamSeason(ctx, "Ajax", "")
+		assert.ErrorIs(t, err, pythonapi.ErrUnavailable)
+	})
+
+	t.Run("Repository errors are returned", func(t *testing.T) {
+		repo := new(MockVideoRepository)
+		repo.On("FindByTeam", "Ajax", 100, 0).Return(nil, errors.New("db down")).Once()
+
+		_, err := services.NewSeasonAnalyticsService(repo, &teamSummaryReader{}).TeamSeason(ctx, "Ajax", "")
+		assert.EqualError(t, err, "db down")
+	})
+}
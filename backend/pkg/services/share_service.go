@@ -0,0 +1,305 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"nivai/backend/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrInvalidShare is returned when a share link request has an unknown
+	// scope, a lifetime beyond the maximum or a bad clip window.
+	ErrInvalidShare = errors.New("invalid share link")
+	// ErrShareTokenInvalid is returned for tokens that are malformed, not
+	// signed with the current key or name no share link.
+	ErrShareTokenInvalid = errors.New("share token is invalid")
+	// ErrShareExpired is returned for tokens of an expired share link.
+	ErrShareExpired = errors.New("share link has expired")
+	// ErrShareRevoked is returned for tokens of a revoked share link.
+	ErrShareRevoked = errors.New("share link has been revoked")
+	// ErrShareScope is returned when a share link does not grant access to
+	// the requested resource.
+	ErrShareScope = errors.New("share link does not grant access to this resource")
+)
+
+// Resources a share link can grant access to
+const (
+	ShareResourceMatch     = "match"
+	ShareResourceAnalytics = "analytics"
+	ShareResourceVideo     = "video"
+)
+
+// shareScopes lists the resources each scope grants access to.
+var shareScopes = map[string][]string{
+	models.ShareScopeFull:      {ShareResourceMatch, ShareResourceAnalytics, ShareResourceVideo},
+	models.ShareScopeAnalytics: {ShareResourceMatch, ShareResourceAnalytics},
+	models.ShareScopeClip:      {ShareResourceMatch, ShareResourceVideo},
+}
+
+/**
+ * ShareConfig tunes share links.
+ */
+type ShareConfig struct {
+	SigningKey string        // HMAC key of the tokens; empty uses a random key, so tokens die on restart
+	DefaultTTL time.Duration // Lifetime of links created without one (default 7 days)
+	MaxTTL     time.Duration // Longest lifetime a link may be given (default 30 days)
+}
+
+/**
+ * ShareRequest describes a share link to create.
+ */
+type ShareRequest struct {
+	Scope       string        // One of the models.ShareScope constants; empty is full
+	TTL         time.Duration // Zero uses the default lifetime
+	ClipStartMS int64         // Window of the video, for the clip scope
+	ClipEndMS   int64
+	Label       string
+}
+
+// shareClaims is the signed payload of a share token.
+type shareClaims struct {
+	tokenHeader
+	ShareID string `json:"sid"`
+	VideoID string `json:"vid"`
+}
+
+/**
+ * ShareService creates share links that give people without an account
+ * access to one match, resolves their tokens and logs every use of them.
+ * A token is the link's claims signed with HMAC-SHA256, so it cannot be
+ * forged or extended; the link itself is looked up on every use, so
+ * revocation takes effect at once.
+ */
+type ShareService struct {
+	links      models.ShareLinkRepository
+	videoRepo  models.VideoRepository
+	tokens     *tokenSigner
+	defaultTTL time.Duration
+	maxTTL     time.Duration
+	now        func() time.Time
+}
+
+/**
+ * NewShareService creates a new share service.
+ *
+ * @param links Repository for share links and their access log
+ * @param videoRepo Repository for video data
+ * @param config Signing key and link lifetimes
+ * @return A new share service
+ */
+func NewShareService(links models.ShareLinkRepository, videoRepo models.VideoRepository, config ShareConfig) *ShareService {
+	if config.SigningKey == "" {
+		log.Printf("Warning: no sharing signing key configured; share links are invalidated on restart")
+	}
+	tokens := newTokenSigner(config.SigningKey, tokenPurposeShare)
+	tokens.unmarked = true // Links shared before tokens had a purpose
+	if config.DefaultTTL <= 0 {
+		config.DefaultTTL = 7 * 24 * time.Hour
+	}
+	if config.MaxTTL <= 0 {
+		config.MaxTTL = 30 * 24 * time.Hour
+	}
+	return &ShareService{
+		links: links, videoRepo: videoRepo, tokens: tokens,
+		defaultTTL: config.DefaultTTL, maxTTL: config.MaxTTL, now: time.Now,
+	}
+}
+
+/**
+ * Create creates a share link for a match and returns it with its token.
+ * The token is only returned here; a lost token is replaced by a new link.
+ *
+ * @param matchID The match to share
+ * @param createdBy The user sharing the match
+ * @param req Scope, lifetime and clip window of the link
+ * @return The link and its token, or ErrVideoNotFound or ErrInvalidShare
+ */
+func (s *ShareService) Create(matchID, createdBy string, req ShareRequest) (*models.ShareLink, string, error) {
+	if req.Scope == "" {
+		req.Scope = models.ShareScopeFull
+	}
+	if _, ok := shareScopes[req.Scope]; !ok {
+		return nil, "", fmt.Errorf("%w: scope must be full, analytics or clip", ErrInvalidShare)
+	}
+	if req.TTL == 0 {
+		req.TTL = s.defaultTTL
+	}
+	if req.TTL < 0 || req.TTL > s.maxTTL {
+		return nil, "", fmt.Errorf("%w: lifetime must be positive and at most %s", ErrInvalidShare, s.maxTTL)
+	}
+	if req.Scope == models.ShareScopeClip {
+		if req.ClipStartMS < 0 || req.ClipEndMS <= req.ClipStartMS {
+			return nil, "", fmt.Errorf("%w: a clip needs an end after its start", ErrInvalidShare)
+		}
+	} else if req.ClipStartMS != 0 || req.ClipEndMS != 0 {
+		return nil, "", fmt.Errorf("%w: only clip links have a clip window", ErrInvalidShare)
+	}
+	if _, err := s.match(matchID); err != nil {
+		return nil, "", err
+	}
+
+	now := s.now().UTC()
+	link := &models.ShareLink{
+		ID:          uuid.New().String(),
+		VideoID:     matchID,
+		Scope:       req.Scope,
+		ClipStartMS: req.ClipStartMS,
+		ClipEndMS:   req.ClipEndMS,
+		Label:       strings.TrimSpace(req.Label),
+		CreatedBy:   createdBy,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(req.TTL).Truncate(time.Second),
+	}
+	if err := s.links.Create(link); err != nil {
+		return nil, "", err
+	}
+	token, err := s.tokens.sign(&shareClaims{ShareID: link.ID, VideoID: link.VideoID}, link.ExpiresAt)
+	if err != nil {
+		return nil, "", err
+	}
+	return link, token, nil
+}
+
+/**
+ * List lists the share links of a match, newest first, revoked and expired
+ * ones included.
+ *
+ * @param matchID The match
+ * @return The links, or ErrVideoNotFound
+ */
+func (s *ShareService) List(matchID string) ([]*models.ShareLink, error) {
+	if _, err := s.match(matchID); err != nil {
+		return nil, err
+	}
+	return s.links.FindByVideoID(matchID)
+}
+
+/**
+ * Revoke revokes a share link of a match; its tokens stop working at once.
+ *
+ * @param matchID The match
+ * @param shareID The link
+ * @return An error, models.ErrShareLinkNotFound when the match has no such link
+ */
+func (s *ShareService) Revoke(matchID, shareID string) error {
+	if _, err := s.matchLink(matchID, shareID); err != nil {
+		return err
+	}
+	return s.links.Revoke(shareID, s.now().UTC())
+}
+
+/**
+ * Accesses lists the uses of a share link of a match, newest first.
+ *
+ * @param matchID The match
+ * @param shareID The link
+ * @param limit Maximum number of accesses
+ * @param offset Number of accesses to skip
+ * @return The accesses, or models.ErrShareLinkNotFound
+ */
+func (s *ShareService) Accesses(matchID, shareID string, limit, offset int) ([]*models.ShareAccess, error) {
+	if _, err := s.matchLink(matchID, shareID); err != nil {
+		return nil, err
+	}
+	return s.links.FindAccesses(shareID, limit, offset)
+}
+
+/**
+ * Open resolves a share token for access to a resource of its match and
+ * records the attempt in the link's access log, whether it is allowed or
+ * not. Tokens that name no link are not logged, as there is no link to log
+ * them to.
+ *
+ * @param token The share token
+ * @param access The resource requested and who requested it; ShareID,
+ *               Allowed, Reason and At are filled in
+ * @return The link, or ErrShareTokenInvalid, ErrShareExpired, ErrShareRevoked or ErrShareScope
+ */
+func (s *ShareService) Open(token string, access models.ShareAccess) (*models.ShareLink, error) {
+	claims, err := s.verify(token)
+	if err != nil {
+		return nil, err
+	}
+	link, err := s.links.FindByID(claims.ShareID)
+	if err != nil {
+		if errors.Is(err, models.ErrShareLinkNotFound) {
+			return nil, ErrShareTokenInvalid
+		}
+		return nil, err
+	}
+	if link.VideoID != claims.VideoID {
+		return nil, ErrShareTokenInvalid
+	}
+
+	now := s.now().UTC()
+	switch {
+	case link.RevokedAt != nil:
+		err = ErrShareRevoked
+	case !now.Before(link.ExpiresAt):
+		err = ErrShareExpired
+	case !grants(link.Scope, access.Resource):
+		err = ErrShareScope
+	}
+
+	access.ShareID = link.ID
+	access.Allowed = err == nil
+	access.At = now
+	if err != nil {
+		access.Reason = err.Error()
+	}
+	if logErr := s.links.RecordAccess(&access); logErr != nil {
+		// Access that cannot be logged is not granted
+		return nil, fmt.Errorf("recording share access: %w", logErr)
+	}
+	return link, err
+}
+
+// grants reports whether a scope grants access to a resource.
+func grants(scope, resource string) bool {
+	for _, granted := range shareScopes[scope] {
+		if granted == resource {
+			return true
+		}
+	}
+	return false
+}
+
+// verify checks a token's signature and decodes its claims. Expiry is
+// checked against the link by Open, so that expired uses are logged.
+func (s *ShareService) verify(token string) (*shareClaims, error) {
+	var claims shareClaims
+	if err := s.tokens.verify(token, &claims, s.now()); (err != nil && !errors.Is(err, errTokenExpired)) || claims.ShareID == "" {
+		return nil, ErrShareTokenInvalid
+	}
+	return &claims, nil
+}
+
+// match retrieves a match, mapping a missing one to ErrVideoNotFound.
+func (s *ShareService) match(matchID string) (*models.Video, error) {
+	video, err := s.videoRepo.FindByID(matchID)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, ErrVideoNotFound
+		}
+		return nil, err
+	}
+	return video, nil
+}
+
+// matchLink retrieves a share link of a match.
+func (s *ShareService) matchLink(matchID, shareID string) (*models.ShareLink, error) {
+	link, err := s.links.FindByID(shareID)
+	if err != nil {
+		return nil, err
+	}
+	if link.VideoID != matchID {
+		return nil, models.ErrShareLinkNotFound
+	}
+	return link, nil
+}
@@ -0,0 +1,143 @@
+package services_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// expiredShareLinks serves every share link as expired.
+type expiredShareLinks struct {
+	*models.MemoryShareLinkRepository
+}
+
+func (r expiredShareLinks) FindByID(id string) (*models.ShareLink, error) {
+	link, err := r.MemoryShareLinkRepository.FindByID(id)
+	if err == nil {
+		link.ExpiresAt = time.Now().Add(-time.Minute)
+	}
+	return link, err
+}
+
+// newShareService serves match m1 with the given share link repository.
+func newShareService(t *testing.T, links models.ShareLinkRepository, key string) *services.ShareService {
+	t.Helper()
+	videoRepo := models.NewMemoryVideoRepository()
+	require.NoError(t, videoRepo.Create(&models.Video{ID: "m1", Title: "Ajax - PSV", ProcessingState: models.StateCompleted}))
+	return services.NewShareService(links, videoRepo, services.ShareConfig{SigningKey: key, DefaultTTL: time.Hour, MaxTTL: 24 * time.Hour})
+}
+
+func TestShareService(t *testing.T) {
+	visit := func(resource string) models.ShareAccess {
+		return models.ShareAccess{Resource: resource, RemoteAddr: "203.0.113.7", UserAgent: "scout"}
+	}
+
+	t.Run("Tokens open their match within their scope and every use is logged", func(t *testing.T) {
+		links := models.NewMemoryShareLinkRepository()
+		shares := newShareService(t, links, "secret")
+		link, token, err := shares.Create("m1", "coach", services.ShareRequest{Scope: models.ShareScopeAnalytics, Label: " PSV scouting "})
+		require.NoError(t, err)
+		assert.Equal(t, "PSV scouting", link.Label)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), link.ExpiresAt, time.Minute, "the default lifetime applies")
+
+		opened, err := shares.Open(token, visit(services.ShareResourceAnalytics))
+		require.NoError(t, err)
+		assert.Equal(t, "m1", opened.VideoID)
+		_, err = shares.Open(token, visit(services.ShareResourceVideo))
+		assert.ErrorIs(t, err, services.ErrShareScope)
+
+		accesses, err := shares.Accesses("m1", link.ID, 10, 0)
+		require.NoError(t, err)
+		require.Len(t, accesses, 2)
+		assert.False(t, accesses[0].Allowed, "newest first")
+		assert.Equal(t, services.ErrShareScope.Error(), accesses[0].Reason)
+		assert.True(t, accesses[1].Allowed)
+		assert.Equal(t, "203.0.113.7", accesses[1].RemoteAddr)
+	})
+
+	t.Run("Revoked links stop working at once", func(t *testing.T) {
+		shares := newShareService(t, models.NewMemoryShareLinkRepository(), "secret")
+		link, token, err := shares.Create("m1", "coach", services.ShareRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, models.ShareScopeFull, link.Scope)
+
+		require.NoError(t, shares.Revoke("m1", link.ID))
+		_, err = shares.Open(token, visit(services.ShareResourceMatch))
+		assert.ErrorIs(t, err, services.ErrShareRevoked)
+		assert.ErrorIs(t, shares.Revoke("m2", link.ID), models.ErrShareLinkNotFound, "links are revoked through their own match")
+
+		listed, err := shares.List("m1")
+		require.NoError(t, err)
+		require.Len(t, listed, 1)
+		assert.NotNil(t, listed[0].RevokedAt)
+	})
+
+	t.Run("Expired, forged and foreign tokens are refused", func(t *testing.T) {
+		links := models.NewMemoryShareLinkRepository()
+		shares := newShareService(t, expiredShareLinks{links}, "secret")
+		link, token, err := shares.Create("m1", "coach", services.ShareRequest{})
+		require.NoError(t, err)
+
+		_, err = shares.Open(token, visit(services.ShareResourceMatch))
+		assert.ErrorIs(t, err, services.ErrShareExpired)
+		accesses, err := links.FindAccesses(link.ID, 10, 0)
+		require.NoError(t, err)
+		require.Len(t, accesses, 1, "refused uses are logged too")
+
+		_, err = shares.Open(token[:len(token)-2]+"xx", visit(services.ShareResourceMatch))
+		assert.ErrorIs(t, err, services.ErrShareTokenInvalid)
+		_, err = shares.Open("not-a-token", visit(services.ShareResourceMatch))
+		assert.ErrorIs(t, err, services.ErrShareTokenInvalid)
+		_, err = newShareService(t, links, "other key").Open(token, visit(services.ShareResourceMatch))
+		assert.ErrorIs(t, err, services.ErrShareTokenInvalid)
+	})
+
+	t.Run("Tokens only open for the purpose they were signed for", func(t *testing.T) {
+		links := models.NewMemoryShareLinkRepository()
+		shares := newShareService(t, links, "secret")
+		link, token, err := shares.Create("m1", "coach", services.ShareRequest{})
+		require.NoError(t, err)
+
+		signer := services.NewFileURLSigner("secret", "", time.Hour)
+		_, err = signer.Verify(token)
+		assert.ErrorIs(t, err, services.ErrFileURLInvalid, "a share token is not a file URL")
+		url, err := signer.Sign("videos/m1/m1.mp4")
+		require.NoError(t, err)
+		_, err = shares.Open(strings.TrimPrefix(url, "/api/v1/files/"), visit(services.ShareResourceMatch))
+		assert.ErrorIs(t, err, services.ErrShareTokenInvalid, "a file URL is not a share token")
+
+		// Tokens signed before they carried a purpose keep working
+		payload := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"sid":%q,"vid":"m1","exp":%d}`, link.ID, link.ExpiresAt.Unix())))
+		mac := hmac.New(sha256.New, []byte("secret"))
+		mac.Write([]byte(payload))
+		opened, err := shares.Open(payload+"."+base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), visit(services.ShareResourceMatch))
+		require.NoError(t, err)
+		assert.Equal(t, link.ID, opened.ID)
+	})
+
+	t.Run("Invalid requests are refused", func(t *testing.T) {
+		shares := newShareService(t, models.NewMemoryShareLinkRepository(), "secret")
+		for name, req := range map[string]services.ShareRequest{
+			"unknown scope":         {Scope: "edit"},
+			"lifetime over maximum": {TTL: 48 * time.Hour},
+			"clip without window":   {Scope: models.ShareScopeClip},
+			"clip ending early":     {Scope: models.ShareScopeClip, ClipStartMS: 5000, ClipEndMS: 1000},
+			"window without clip":   {ClipEndMS: 1000},
+		} {
+			_, _, err := shares.Create("m1", "coach", req)
+			assert.ErrorIs(t, err, services.ErrInvalidShare, name)
+		}
+		_, _, err := shares.Create("missing", "coach", services.ShareRequest{})
+		assert.ErrorIs(t, err, services.ErrVideoNotFound)
+	})
+}
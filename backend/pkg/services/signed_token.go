@@ -0,0 +1,115 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	// errTokenInvalid is returned for tokens that are malformed, not signed
+	// with the signer's key or signed for another purpose.
+	errTokenInvalid = errors.New("token is invalid")
+	// errTokenExpired is returned for tokens past their expiry; their
+	// claims are decoded all the same.
+	errTokenExpired = errors.New("token has expired")
+)
+
+// Purposes of signed tokens. A token only verifies for the purpose it was
+// signed for, even when two signers share a key.
+const (
+	tokenPurposeFileURL = "file"
+	tokenPurposeShare   = "share"
+)
+
+// tokenHeader is the part of a token's claims every purpose has. Claims
+// embed it to be signed.
+type tokenHeader struct {
+	Purpose   string `json:"pur,omitempty"`
+	ExpiresAt int64  `json:"exp"` // Unix seconds
+}
+
+func (h *tokenHeader) header() *tokenHeader { return h }
+
+// tokenClaims are the claims of a signed token: a struct embedding
+// tokenHeader.
+type tokenClaims interface {
+	header() *tokenHeader
+}
+
+// tokenSigner signs and verifies the tokens of one purpose: the base64 JSON
+// claims and their base64 HMAC-SHA256, joined by a dot. The purpose and
+// expiry are signed with the claims, so a token cannot be used for another
+// purpose or extended.
+type tokenSigner struct {
+	key     []byte
+	purpose string
+	// unmarked accepts tokens without a purpose, signed before tokens had one
+	unmarked bool
+}
+
+// newTokenSigner creates a signer of tokens for a purpose. An empty key is
+// replaced by a random one, so the tokens die on restart.
+func newTokenSigner(key, purpose string) *tokenSigner {
+	secret := []byte(key)
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			panic(fmt.Sprintf("generating %s token signing key: %v", purpose, err))
+		}
+	}
+	return &tokenSigner{key: secret, purpose: purpose}
+}
+
+// sign encodes claims, valid until expiresAt, as a token.
+func (t *tokenSigner) sign(claims tokenClaims, expiresAt time.Time) (string, error) {
+	*claims.header() = tokenHeader{Purpose: t.purpose, ExpiresAt: expiresAt.Unix()}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(t.mac(encoded)), nil
+}
+
+// verify checks a token's signature and purpose and decodes its claims
+// into claims. It returns errTokenExpired, with the claims decoded, when
+// the token is no longer valid at now.
+func (t *tokenSigner) verify(token string, claims tokenClaims, now time.Time) error {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return errTokenInvalid
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(sig, t.mac(encoded)) {
+		return errTokenInvalid
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return errTokenInvalid
+	}
+	if err := json.Unmarshal(payload, claims); err != nil {
+		return errTokenInvalid
+	}
+	header := claims.header()
+	if header.Purpose != t.purpose && !(t.unmarked && header.Purpose == "") {
+		return errTokenInvalid
+	}
+	if !now.Before(time.Unix(header.ExpiresAt, 0)) {
+		return errTokenExpired
+	}
+	return nil
+}
+
+// mac returns the HMAC-SHA256 of a token payload.
+func (t *tokenSigner) mac(payload string) []byte {
+	h := hmac.New(sha256.New, t.key)
+	h.Write([]byte(payload))
+	return h.Sum(nil)
+}
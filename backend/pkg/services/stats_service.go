@@ -0,0 +1,85 @@
+package services
+
+import (
+	"sync"
+	"time"
+
+	"nivai/backend/pkg/models"
+)
+
+// statsUploadWindow is how far back uploads and processing times are counted
+const statsUploadWindow = 30 * 24 * time.Hour
+
+/**
+ * StatsOverview is the summary shown on the dashboard's landing page.
+ */
+type StatsOverview struct {
+	Matches                  int                            `json:"matches"`
+	MatchesByState           map[models.ProcessingState]int `json:"matches_by_state"`
+	StorageBytes             int64                          `json:"storage_bytes"`
+	UploadsLast30Days        int                            `json:"uploads_last_30_days"`
+	ProcessedLast30Days      int                            `json:"processed_last_30_days"`
+	AvgProcessingTimeSeconds *float64                       `json:"avg_processing_time_seconds"` // Over the matches processed in the last 30 days; null without any
+	GeneratedAt              time.Time                      `json:"generated_at"`
+}
+
+/**
+ * StatsService computes the dashboard statistics with aggregate queries and
+ * keeps the result for a short while, so dashboards polling the overview
+ * do not each scan the videos table.
+ */
+type StatsService struct {
+	repo models.MatchStatsRepository
+	ttl  time.Duration
+	now  func() time.Time
+
+	mu     sync.Mutex
+	cached *StatsOverview
+}
+
+/**
+ * NewStatsService creates a new dashboard statistics service.
+ *
+ * @param repo Repository aggregating the matches
+ * @param ttl How long an overview is served from cache; zero disables caching
+ * @return A new statistics service
+ */
+func NewStatsService(repo models.MatchStatsRepository, ttl time.Duration) *StatsService {
+	return &StatsService{repo: repo, ttl: ttl, now: time.Now}
+}
+
+/**
+ * Overview returns the dashboard statistics, computed at most once per
+ * cache period. Concurrent callers of an expired overview wait for one
+ * computation instead of each running the queries.
+ *
+ * @return The statistics, or an error if they cannot be computed
+ */
+func (s *StatsService) Overview() (*StatsOverview, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if s.cached != nil && now.Sub(s.cached.GeneratedAt) < s.ttl {
+		return s.cached, nil
+	}
+
+	stats, err := s.repo.Overview(now.Add(-statsUploadWindow))
+	if err != nil {
+		return nil, err
+	}
+	overview := &StatsOverview{
+		Matches:             stats.Total,
+		MatchesByState:      stats.ByState,
+		StorageBytes:        stats.StorageBytes,
+		UploadsLast30Days:   stats.UploadsSince,
+		ProcessedLast30Days: stats.ProcessedSince,
+		GeneratedAt:         now.UTC(),
+	}
+	if stats.ProcessedSince > 0 {
+		avg := stats.AvgProcessingSeconds
+		overview.AvgProcessingTimeSeconds = &avg
+	}
+	s.cached = overview
+	return overview, nil
+}
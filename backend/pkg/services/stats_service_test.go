@@ -0,0 +1,56 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatsService(t *testing.T) {
+	setup := func(t *testing.T) *models.Repositories {
+		repos := models.NewMemoryRepositories()
+		require.NoError(t, repos.Videos.Create(&models.Video{ID: "m1", Size: 100, ProcessingState: models.StatePendingAnalytics, CreatedAt: time.Now()}))
+		return repos
+	}
+
+	t.Run("Overviews are served from cache until they expire", func(t *testing.T) {
+		repos := setup(t)
+		stats := services.NewStatsService(repos.MatchStats, time.Hour)
+
+		first, err := stats.Overview()
+		require.NoError(t, err)
+		assert.Equal(t, 1, first.Matches)
+		assert.Equal(t, 1, first.UploadsLast30Days)
+		assert.Nil(t, first.AvgProcessingTimeSeconds, "nothing was processed")
+
+		require.NoError(t, repos.Videos.Create(&models.Video{ID: "m2", Size: 200}))
+		second, err := stats.Overview()
+		require.NoError(t, err)
+		assert.Same(t, first, second)
+	})
+
+	t.Run("Without a cache period every overview is computed", func(t *testing.T) {
+		repos := setup(t)
+		stats := services.NewStatsService(repos.MatchStats, 0)
+		_, err := stats.Overview()
+		require.NoError(t, err)
+
+		require.NoError(t, repos.Videos.Create(&models.Video{ID: "m2", Size: 200}))
+		started := time.Now().Add(-90 * time.Second)
+		require.NoError(t, repos.StateHistory.Create(&models.StateTransition{VideoID: "m1", To: models.StatePendingAnalytics, CreatedAt: started}))
+		require.NoError(t, repos.StateHistory.Create(&models.StateTransition{VideoID: "m1", From: models.StatePendingAnalytics, To: models.StateCompleted, CreatedAt: started.Add(time.Minute)}))
+
+		overview, err := stats.Overview()
+		require.NoError(t, err)
+		assert.Equal(t, 2, overview.Matches)
+		assert.Equal(t, int64(300), overview.StorageBytes)
+		assert.Equal(t, 1, overview.ProcessedLast30Days)
+		require.NotNil(t, overview.AvgProcessingTimeSeconds)
+		assert.InDelta(t, 60, *overview.AvgProcessingTimeSeconds, 0.001)
+	})
+}
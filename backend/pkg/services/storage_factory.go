@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"strings"
 )
 
 // OsStat is a function variable that defaults to os.Stat, allowing it to be mocked in tests.
@@ -20,13 +21,20 @@ const (
 
 	// LocalFileStorageType represents local file system storage
 	LocalFileStorageType StorageType = "local_file"
+
+	// MemoryStorageType represents in-memory storage, for demo mode
+	MemoryStorageType StorageType = "memory"
 )
 
 /**
  * StorageFactory creates and configures storage services based on configuration.
  * Implements the Factory design pattern to abstract storage implementation creation.
  */
-type StorageFactory struct{}
+type StorageFactory struct {
+	azure     *AzureStorageConfig
+	localPath *string
+	localURLs *FileURLSigner
+}
 
 /**
  * NewStorageFactory creates a new storage factory instance.
@@ -37,6 +45,62 @@ func NewStorageFactory() *StorageFactory {
 	return &StorageFactory{}
 }
 
+/**
+ * WithAzureConfig makes the factory use the given Azure account, container
+ * and credentials, typically from the loaded configuration, instead of the
+ * AZURE_* environment variables.
+ *
+ * @param config The Azure Blob Storage configuration
+ * @return The factory
+ */
+func (f *StorageFactory) WithAzureConfig(config AzureStorageConfig) *StorageFactory {
+	f.azure = &config
+	return f
+}
+
+/**
+ * WithLocalPath makes the factory store files in the given directory,
+ * typically from the loaded configuration, instead of the directory in
+ * EXTERNAL_DATA_PATH. An empty path disables local storage.
+ *
+ * @param path The local storage root
+ * @return The factory
+ */
+func (f *StorageFactory) WithLocalPath(path string) *StorageFactory {
+	f.localPath = &path
+	return f
+}
+
+/**
+ * WithLocalURLSigner makes local storage hand out temporary URLs the API
+ * serves, signed by urls, instead of file:// URLs.
+ *
+ * @param urls The signer of the URLs
+ * @return The factory
+ */
+func (f *StorageFactory) WithLocalURLSigner(urls *FileURLSigner) *StorageFactory {
+	f.localURLs = urls
+	return f
+}
+
+// localRoot returns the local storage root given to WithLocalPath, or else
+// the one in the environment
+func (f *StorageFactory) localRoot() string {
+	if f.localPath != nil {
+		return *f.localPath
+	}
+	return os.Getenv("EXTERNAL_DATA_PATH")
+}
+
+// azureConfig returns the Azure configuration given to WithAzureConfig, or
+// else the one in the environment
+func (f *StorageFactory) azureConfig() AzureStorageConfig {
+	if f.azure != nil {
+		return *f.azure
+	}
+	return AzureStorageConfigFromEnv(os.Getenv("AZURE_STORAGE_CONTAINER"))
+}
+
 /**
  * CreateStorage creates and returns the appropriate storage service based on configuration.
  * Selects between Azure Blob Storage and Local File Storage based on environment variables.
@@ -47,22 +111,20 @@ func NewStorageFactory() *StorageFactory {
 func (f *StorageFactory) CreateStorage(storageType StorageType) (StorageService, error) {
 	switch storageType {
 	case AzureBlobStorageType:
-		// Get Azure credentials from environment
-		accountName := os.Getenv("AZURE_STORAGE_ACCOUNT")
-		accountKey := os.Getenv("AZURE_STORAGE_KEY")
-		containerName := os.Getenv("AZURE_STORAGE_CONTAINER")
+		// Get Azure account and credentials from the configuration
+		azureConfig := f.azureConfig()
 
 		// Validate required values
-		if accountName == "" || accountKey == "" || containerName == "" {
+		if (azureConfig.AccountName == "" && azureConfig.ConnectionString == "") || azureConfig.ContainerName == "" {
 			return nil, errors.New("missing required Azure Storage configuration")
 		}
 
 		// Create and return Azure blob storage service
-		return NewAzureBlobStorage(accountName, accountKey, containerName)
+		return NewAzureBlobStorageWithConfig(azureConfig)
 
 	case LocalFileStorageType:
-		// Get base path from environment
-		basePath := os.Getenv("EXTERNAL_DATA_PATH")
+		// Get base path from the configuration
+		basePath := f.localRoot()
 
 		// Validate required values
 		if basePath == "" {
@@ -70,22 +132,113 @@ func (f *StorageFactory) CreateStorage(storageType StorageType) (StorageService,
 		}
 
 		// Create and return local file storage service
-		return NewLocalFileStorage(basePath)
+		return NewLocalFileStorage(basePath, WithSignedURLs(f.localURLs))
+
+	case MemoryStorageType:
+		return NewMemoryStorageService(), nil
 
 	default:
 		return nil, fmt.Errorf("unsupported storage type: %s", storageType)
 	}
 }
 
+/**
+ * CreateReplicatedStorage wraps a storage service in a CompositeStorage that
+ * replicates its files to a second backend. A local replica is stored under
+ * STORAGE_REPLICA_PATH; an Azure replica uses the Azure account and credentials with the
+ * container STORAGE_REPLICA_CONTAINER, or the primary container if unset.
+ *
+ * @param primary The storage service serving reads and writes
+ * @param replicaType The type of storage to replicate to
+ * @return The replicated storage service or error
+ */
+func (f *StorageFactory) CreateReplicatedStorage(primary StorageService, replicaType StorageType) (*CompositeStorage, error) {
+	var secondary StorageService
+	var err error
+	switch replicaType {
+	case AzureBlobStorageType:
+		azureConfig := f.azureConfig()
+		if containerName := os.Getenv("STORAGE_REPLICA_CONTAINER"); containerName != "" {
+			azureConfig.ContainerName = containerName
+		}
+		if (azureConfig.AccountName == "" && azureConfig.ConnectionString == "") || azureConfig.ContainerName == "" {
+			return nil, errors.New("missing required Azure Storage configuration for the replica")
+		}
+		secondary, err = NewAzureBlobStorageWithConfig(azureConfig)
+
+	case LocalFileStorageType:
+		basePath := os.Getenv("STORAGE_REPLICA_PATH")
+		if basePath == "" {
+			return nil, errors.New("missing required replica configuration: STORAGE_REPLICA_PATH")
+		}
+		secondary, err = NewLocalFileStorage(basePath, WithSignedURLs(f.localURLs))
+
+	default:
+		return nil, fmt.Errorf("unsupported replica storage type: %s", replicaType)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create replica storage: %w", err)
+	}
+
+	return NewCompositeStorage(primary, secondary, ReplicationConfig{})
+}
+
 /**
  * CreateDefaultStorage creates a storage service based on environment variables.
- * Automatically determines which storage type to use based on available configuration.
+ * Automatically determines which storage type to use based on available configuration,
+ * replicates it to STORAGE_REPLICA_TYPE when set, and encrypts files when
+ * encryption keys are configured.
  *
  * @return A configured storage service or error
  */
 func (f *StorageFactory) CreateDefaultStorage() (StorageService, error) {
+	storage, err := f.createPrimaryStorage()
+	if err != nil {
+		return nil, err
+	}
+
+	if replicaType := os.Getenv("STORAGE_REPLICA_TYPE"); replicaType != "" {
+		replicated, err := f.CreateReplicatedStorage(storage, StorageType(replicaType))
+		if err != nil {
+			return nil, err
+		}
+		storage = replicated
+	}
+
+	return f.encryptStorage(storage)
+}
+
+// encryptStorage wraps a storage service in an EncryptedStorage when keys are
+// configured in STORAGE_ENCRYPTION_KEYS, or in the file named by
+// STORAGE_ENCRYPTION_KEYS_FILE, e.g. a secret mounted from a key vault.
+func (f *StorageFactory) encryptStorage(storage StorageService) (StorageService, error) {
+	spec := os.Getenv("STORAGE_ENCRYPTION_KEYS")
+	if keysFile := os.Getenv("STORAGE_ENCRYPTION_KEYS_FILE"); keysFile != "" {
+		content, err := os.ReadFile(keysFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read encryption keys: %w", err)
+		}
+		spec = strings.TrimSpace(string(content))
+	}
+	if spec == "" {
+		return storage, nil
+	}
+
+	keyring, err := ParseKeyring(spec, os.Getenv("STORAGE_ENCRYPTION_ACTIVE_KEY"))
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := NewEncryptedStorage(storage, keyring)
+	if err != nil {
+		return nil, err
+	}
+	return encrypted, nil
+}
+
+// createPrimaryStorage picks the storage type from the available configuration.
+func (f *StorageFactory) createPrimaryStorage() (StorageService, error) {
 	// First, check if external data path is set for local file storage
-	if externalPath := os.Getenv("EXTERNAL_DATA_PATH"); externalPath != "" {
+	if externalPath := f.localRoot(); externalPath != "" {
 		// Verify the path exists and is accessible
 		if _, err := OsStat(externalPath); err == nil { // Use OsStat here
 			return f.CreateStorage(LocalFileStorageType)
@@ -93,7 +246,7 @@ func (f *StorageFactory) CreateDefaultStorage() (StorageService, error) {
 	}
 
 	// If local storage isn't configured, try Azure Blob
-	if accountName := os.Getenv("AZURE_STORAGE_ACCOUNT"); accountName != "" {
+	if azure := f.azureConfig(); azure.AccountName != "" || azure.ConnectionString != "" {
 		return f.CreateStorage(AzureBlobStorageType)
 	}
 
@@ -1,6 +1,7 @@
 package services_test
 
 import (
+	"encoding/base64"
 	"os"
 	"path/filepath"
 	"testing"
@@ -70,6 +71,23 @@ func TestStorageFactory_CreateStorage(t *testing.T) {
 		assert.Contains(t, err.Error(), "missing required Azure Storage configuration")
 	})
 
+	t.Run("Azure Blob Storage from configuration ignores environment", func(t *testing.T) {
+		t.Setenv("AZURE_STORAGE_ACCOUNT", "")
+		t.Setenv("AZURE_STORAGE_CONTAINER", "")
+
+		configured := services.NewStorageFactory().WithAzureConfig(services.AzureStorageConfig{
+			AccountName:   "testaccount",
+			ContainerName: "testcontainer",
+			AccountKey:    "dGVzdGtleV9tdXN0X2JlX2xvbmdlcl9hbmRfZW5jb2RlZF9jb3JyZWN0bHlhYmMxMjM0NTY3ODkwYWJjZGVmZ2hpamtsbW5vcHFyc3R1dnd4eXo=",
+		})
+		storage, err := configured.CreateStorage(services.AzureBlobStorageType)
+		require.NoError(t, err)
+		assert.NotNil(t, storage)
+
+		_, err = services.NewStorageFactory().WithAzureConfig(services.AzureStorageConfig{}).CreateStorage(services.AzureBlobStorageType)
+		assert.Error(t, err)
+	})
+
 	t.Run("Local File Storage type", func(t *testing.T) {
 		// Create a temporary directory for the test
 		tempDir, err := os.MkdirTemp("", "localstorage_test")
@@ -205,6 +223,50 @@ func TestStorageFactory_CreateDefaultStorage(t *testing.T) {
 		// This should be a Local storage instance.
 	})
 
+	t.Run("Local storage replicated to a second path", func(t *testing.T) {
+		cleanupEnv()
+		primaryDir, replicaDir := t.TempDir(), t.TempDir()
+		t.Setenv("EXTERNAL_DATA_PATH", primaryDir)
+		t.Setenv("STORAGE_REPLICA_TYPE", "local_file")
+
+		storage, err := factory.CreateDefaultStorage()
+		assert.Nil(t, storage)
+		assert.ErrorContains(t, err, "STORAGE_REPLICA_PATH")
+
+		t.Setenv("STORAGE_REPLICA_PATH", replicaDir)
+		storage, err = factory.CreateDefaultStorage()
+		require.NoError(t, err)
+		_, ok := storage.(*services.CompositeStorage)
+		assert.True(t, ok)
+	})
+
+	t.Run("Azure storage from a connection string", func(t *testing.T) {
+		cleanupEnv()
+		t.Setenv("AZURE_STORAGE_CONNECTION_STRING", "BlobEndpoint=https://nivai.blob.core.windows.net/;SharedAccessSignature=sv=2022-11-02&sig=abc")
+		t.Setenv("AZURE_STORAGE_CONTAINER", "matches")
+
+		storage, err := factory.CreateDefaultStorage()
+		require.NoError(t, err)
+		_, ok := storage.(*services.AzureBlobStorage)
+		assert.True(t, ok)
+	})
+
+	t.Run("Local storage encrypted with configured keys", func(t *testing.T) {
+		cleanupEnv()
+		t.Setenv("EXTERNAL_DATA_PATH", t.TempDir())
+		t.Setenv("STORAGE_ENCRYPTION_KEYS", "2024:not-base64")
+
+		storage, err := factory.CreateDefaultStorage()
+		assert.Nil(t, storage)
+		assert.ErrorContains(t, err, "base64")
+
+		t.Setenv("STORAGE_ENCRYPTION_KEYS", "2024:"+base64.StdEncoding.EncodeToString(make([]byte, 32)))
+		storage, err = factory.CreateDefaultStorage()
+		require.NoError(t, err)
+		_, ok := storage.(*services.EncryptedStorage)
+		assert.True(t, ok)
+	})
+
 	t.Run("No storage configuration found", func(t *testing.T) {
 		cleanupEnv()
 		mockOsStat = func(name string) (os.FileInfo, error) {
@@ -0,0 +1,273 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"nivai/backend/pkg/models"
+)
+
+/**
+ * StorageGCConfig tunes the orphaned file collector.
+ */
+type StorageGCConfig struct {
+	Prefix        string        // Storage prefix holding match files (default "videos/")
+	MinAge        time.Duration // Orphans younger than this are left alone, as their upload may still be running (default 24h)
+	DeleteOrphans bool          // Scheduled runs delete orphans instead of only reporting them
+}
+
+/**
+ * OrphanFile is a stored file that no match refers to, with the outcome
+ * when the run deleted orphans.
+ */
+type OrphanFile struct {
+	FileInfo
+	Deleted bool   `json:"deleted"`
+	Skipped string `json:"skipped,omitempty"` // Why the file was not deleted
+	Error   string `json:"error,omitempty"`
+}
+
+/**
+ * MissingFile is a file a match refers to that is not in storage.
+ */
+type MissingFile struct {
+	VideoID string `json:"video_id"`
+	Kind    string `json:"kind"`
+	Path    string `json:"path"`
+}
+
+/**
+ * StorageGCReport is the result of reconciling storage with the repository.
+ */
+type StorageGCReport struct {
+	DryRun         bool          `json:"dry_run"`
+	Prefix         string        `json:"prefix"`
+	StartedAt      time.Time     `json:"started_at"`
+	FinishedAt     time.Time     `json:"finished_at"`
+	FilesScanned   int           `json:"files_scanned"`
+	MatchesChecked int           `json:"matches_checked"`
+	Orphans        []OrphanFile  `json:"orphans"`
+	Missing        []MissingFile `json:"missing"`
+	BytesReclaimed int64         `json:"bytes_reclaimed"`
+	Error          string        `json:"error,omitempty"`
+}
+
+/**
+ * StorageGCService reconciles stored match files with the repository.
+ * Failed uploads and partial cleanups leave files no match refers to
+ * (orphans), and matches referring to files that are gone (missing files).
+ * Runs report both, and can delete orphans.
+ */
+type StorageGCService struct {
+	videoRepo models.VideoRepository
+	storage   StorageService
+	cfg       StorageGCConfig
+	purges    models.VideoPurgeRepository
+	now       func() time.Time
+}
+
+/**
+ * StorageGCOption configures optional dependencies of StorageGCService.
+ */
+type StorageGCOption func(*StorageGCService)
+
+/**
+ * WithPendingPurges keeps the files of deleted matches in their grace
+ * period, which the purge worker removes, from being collected as orphans.
+ *
+ * @param purges Repository of the pending purges
+ * @return The option
+ */
+func WithPendingPurges(purges models.VideoPurgeRepository) StorageGCOption {
+	return func(s *StorageGCService) {
+		s.purges = purges
+	}
+}
+
+/**
+ * NewStorageGCService creates a new orphaned file collector.
+ *
+ * @param videoRepo Repository for video data
+ * @param storage Storage service holding the files
+ * @param cfg Collector settings
+ * @param opts Optional dependencies
+ * @return A new collector
+ */
+func NewStorageGCService(videoRepo models.VideoRepository, storage StorageService, cfg StorageGCConfig, opts ...StorageGCOption) *StorageGCService {
+	if cfg.Prefix == "" {
+		cfg.Prefix = "videos/"
+	}
+	if cfg.MinAge <= 0 {
+		cfg.MinAge = 24 * time.Hour
+	}
+	s := &StorageGCService{videoRepo: videoRepo, storage: storage, cfg: cfg, now: time.Now}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+/**
+ * RunScheduled runs a scheduled reconciliation, which only deletes orphans
+ * when the service is configured so.
+ *
+ * @param ctx Context for the run
+ * @return An error if the run failed
+ */
+func (s *StorageGCService) RunScheduled(ctx context.Context) error {
+	if report := s.Reconcile(ctx, !s.cfg.DeleteOrphans); report.Error != "" {
+		return errors.New(report.Error)
+	}
+	return nil
+}
+
+/**
+ * Reconcile lists the files under the prefix, compares them with the files
+ * the matches in the repository refer to, and, unless dryRun is set,
+ * deletes orphans older than the minimum age.
+ *
+ * Files of archived or restoring matches may have moved to cold storage, so
+ * they are not reported missing.
+ *
+ * @param ctx Context for the run
+ * @param dryRun Only report divergences
+ * @return The run report
+ */
+func (s *StorageGCService) Reconcile(ctx context.Context, dryRun bool) *StorageGCReport {
+	report := &StorageGCReport{
+		DryRun:    dryRun,
+		Prefix:    s.cfg.Prefix,
+		StartedAt: s.now(),
+		Orphans:   []OrphanFile{},
+		Missing:   []MissingFile{},
+	}
+
+	// List storage before reading the matches, so a match created during the
+	// run still claims its files. Uploads whose match does not exist yet are
+	// protected by the minimum age.
+	stored, err := s.storage.ListFiles(s.cfg.Prefix)
+	if err != nil {
+		report.Error = fmt.Sprintf("failed to list storage: %v", err)
+		report.FinishedAt = s.now()
+		return report
+	}
+	report.FilesScanned = len(stored)
+
+	referenced, missing, err := s.referencedFiles(ctx, report)
+	if err != nil {
+		report.Error = err.Error()
+		report.FinishedAt = s.now()
+		return report
+	}
+
+	present := make(map[string]bool, len(stored))
+	for _, file := range stored {
+		present[file.Path] = true
+		if referenced[file.Path] {
+			continue
+		}
+		orphan := OrphanFile{FileInfo: file}
+		switch {
+		case s.now().Sub(file.ModifiedAt) < s.cfg.MinAge:
+			orphan.Skipped = "recent"
+		case !dryRun:
+			if err := s.storage.DeleteFile(file.Path); err != nil {
+				orphan.Error = err.Error()
+			} else {
+				orphan.Deleted = true
+				report.BytesReclaimed += file.Size
+			}
+		}
+		report.Orphans = append(report.Orphans, orphan)
+	}
+
+	for _, file := range missing {
+		if !present[file.Path] {
+			report.Missing = append(report.Missing, file)
+		}
+	}
+	sort.Slice(report.Orphans, func(i, j int) bool { return report.Orphans[i].Path < report.Orphans[j].Path })
+
+	report.FinishedAt = s.now()
+	log.Printf("Storage GC: scanned %d files and %d matches, found %d orphans (%d bytes reclaimed) and %d missing files",
+		report.FilesScanned, report.MatchesChecked, len(report.Orphans), report.BytesReclaimed, len(report.Missing))
+	return report
+}
+
+// referencedFiles pages through every match and collects the paths under
+// the prefix they refer to, along with the candidates for missing files.
+func (s *StorageGCService) referencedFiles(ctx context.Context, report *StorageGCReport) (map[string]bool, []MissingFile, error) {
+	const pageSize = 100
+	referenced := map[string]bool{}
+	var candidates []MissingFile
+
+	for offset := 0; ; offset += pageSize {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+
+		videos, err := s.videoRepo.FindAll(pageSize, offset)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to list videos: %v", err)
+		}
+
+		for _, video := range videos {
+			report.MatchesChecked++
+			for kind, path := range map[string]string{
+				models.FileKindVideo:    video.FilePath,
+				models.FileKindTracking: video.TrackingPath,
+				models.FileKindEvents:   video.EventFilePath,
+			} {
+				if path == "" || !strings.HasPrefix(path, s.cfg.Prefix) {
+					continue
+				}
+				referenced[path] = true
+				if video.ProcessingState != "archived" && video.ProcessingState != "restoring" {
+					candidates = append(candidates, MissingFile{VideoID: video.ID, Kind: kind, Path: path})
+				}
+			}
+		}
+
+		if len(videos) < pageSize {
+			break
+		}
+	}
+
+	if err := s.pendingPurgeFiles(ctx, referenced); err != nil {
+		return nil, nil, err
+	}
+
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].Path < candidates[j].Path })
+	return referenced, candidates, nil
+}
+
+// pendingPurgeFiles marks the files of deleted matches waiting to be
+// purged as referenced, so undoing a deletion finds them still stored.
+func (s *StorageGCService) pendingPurgeFiles(ctx context.Context, referenced map[string]bool) error {
+	if s.purges == nil {
+		return nil
+	}
+	const pageSize = 100
+	for offset := 0; ; offset += pageSize {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		purges, err := s.purges.FindAll(pageSize, offset)
+		if err != nil {
+			return fmt.Errorf("failed to list pending purges: %v", err)
+		}
+		for _, purge := range purges {
+			for _, path := range purge.Paths {
+				referenced[path] = true
+			}
+		}
+		if len(purges) < pageSize {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorageGCService(t *testing.T) {
+	day := 24 * time.Hour
+
+	setup := func(t *testing.T) (string, services.StorageService, *MockVideoRepository) {
+		base := t.TempDir()
+		writeStoredFile(t, base, "videos/v1/match.mp4", 3*day)
+		writeStoredFile(t, base, "videos/v1/tracking.parquet", 3*day)
+		writeStoredFile(t, base, "videos/failed/match.mp4", 3*day)
+		writeStoredFile(t, base, "videos/uploading/match.mp4", time.Hour)
+		writeStoredFile(t, base, "archive/videos/old.mp4", 3*day)
+		storage, err := services.NewLocalFileStorage(base)
+		require.NoError(t, err)
+
+		videoRepo := new(MockVideoRepository)
+		videoRepo.On("FindAll", 100, 0).Return([]*models.Video{
+			{ID: "v1", FilePath: "videos/v1/match.mp4", TrackingPath: "videos/v1/tracking.parquet", EventFilePath: "videos/v1/events.csv", ProcessingState: "completed"},
+			{ID: "v2", FilePath: "videos/v2/match.mp4", ProcessingState: "archived"},
+			{ID: "v3", FilePath: "archive/videos/old.mp4", ProcessingState: "completed"},
+		}, nil).Once()
+		return base, storage, videoRepo
+	}
+
+	t.Run("Dry run reports orphans and missing files", func(t *testing.T) {
+		base, storage, videoRepo := setup(t)
+		gc := services.NewStorageGCService(videoRepo, storage, services.StorageGCConfig{})
+
+		report := gc.Reconcile(context.Background(), true)
+
+		assert.Empty(t, report.Error)
+		assert.Equal(t, 4, report.FilesScanned)
+		assert.Equal(t, 3, report.MatchesChecked)
+		require.Len(t, report.Orphans, 2)
+		assert.Equal(t, "videos/failed/match.mp4", report.Orphans[0].Path)
+		assert.False(t, report.Orphans[0].Deleted)
+		assert.Equal(t, "videos/uploading/match.mp4", report.Orphans[1].Path)
+		assert.Equal(t, "recent", report.Orphans[1].Skipped)
+		assert.Equal(t, []services.MissingFile{{VideoID: "v1", Kind: models.FileKindEvents, Path: "videos/v1/events.csv"}}, report.Missing,
+			"archived matches' files may be in cold storage")
+		assert.FileExists(t, filepath.Join(base, "videos/failed/match.mp4"))
+	})
+
+	t.Run("Collection deletes old orphans only", func(t *testing.T) {
+		base, storage, videoRepo := setup(t)
+		gc := services.NewStorageGCService(videoRepo, storage, services.StorageGCConfig{})
+
+		report := gc.Reconcile(context.Background(), false)
+
+		require.Len(t, report.Orphans, 2)
+		assert.True(t, report.Orphans[0].Deleted)
+		assert.False(t, report.Orphans[1].Deleted)
+		assert.Equal(t, int64(7), report.BytesReclaimed)
+		assert.NoFileExists(t, filepath.Join(base, "videos/failed/match.mp4"))
+		assert.FileExists(t, filepath.Join(base, "videos/uploading/match.mp4"))
+		assert.FileExists(t, filepath.Join(base, "videos/v1/match.mp4"))
+		assert.FileExists(t, filepath.Join(base, "archive/videos/old.mp4"))
+	})
+
+	t.Run("Files of deleted matches in their grace period are kept", func(t *testing.T) {
+		base, storage, videoRepo := setup(t)
+		purges := models.NewMemoryVideoPurgeRepository()
+		require.NoError(t, purges.Create(&models.VideoPurge{VideoID: "failed", Paths: []string{"videos/failed/match.mp4"}, PurgeAfter: time.Now().Add(day)}))
+		gc := services.NewStorageGCService(videoRepo, storage, services.StorageGCConfig{}, services.WithPendingPurges(purges))
+
+		report := gc.Reconcile(context.Background(), false)
+
+		require.Len(t, report.Orphans, 1)
+		assert.Equal(t, "videos/uploading/match.mp4", report.Orphans[0].Path)
+		assert.FileExists(t, filepath.Join(base, "videos/failed/match.mp4"))
+	})
+
+	t.Run("Nothing is deleted when the repository cannot be read", func(t *testing.T) {
+		storage := new(MockStorageService)
+		videoRepo := new(MockVideoRepository)
+		storage.On("ListFiles", "videos/").Return([]services.FileInfo{{Path: "videos/a.mp4", ModifiedAt: time.Now().Add(-3 * day)}}, nil).Once()
+		videoRepo.On("FindAll", 100, 0).Return(nil, errors.New("connection refused")).Once()
+		gc := services.NewStorageGCService(videoRepo, storage, services.StorageGCConfig{})
+
+		report := gc.Reconcile(context.Background(), false)
+
+		assert.Contains(t, report.Error, "connection refused")
+		assert.Empty(t, report.Orphans)
+		storage.AssertNotCalled(t, "DeleteFile", mock.Anything)
+	})
+}
@@ -0,0 +1,83 @@
+package services_test
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"nivai/backend/pkg/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeStoredFile creates a file under base with the given age.
+func writeStoredFile(t *testing.T, base, path string, age time.Duration) {
+	t.Helper()
+	fullPath := filepath.Join(base, filepath.FromSlash(path))
+	require.NoError(t, os.MkdirAll(filepath.Dir(fullPath), 0755))
+	require.NoError(t, os.WriteFile(fullPath, []byte("content"), 0644))
+	modified := time.Now().Add(-age)
+	require.NoError(t, os.Chtimes(fullPath, modified, modified))
+}
+
+func TestLocalFileStorageListFiles(t *testing.T) {
+	base := t.TempDir()
+	writeStoredFile(t, base, "videos/ab/cd/v1/match.mp4", 0)
+	writeStoredFile(t, base, "videos/uploads/other.csv", 0)
+	writeStoredFile(t, base, "archive/videos/old.mp4", 0)
+	storage, err := services.NewLocalFileStorage(base)
+	require.NoError(t, err)
+
+	files, err := storage.ListFiles("videos/")
+	require.NoError(t, err)
+	paths := []string{}
+	for _, file := range files {
+		paths = append(paths, file.Path)
+		assert.Equal(t, int64(7), file.Size)
+	}
+	assert.ElementsMatch(t, []string{"videos/ab/cd/v1/match.mp4", "videos/uploads/other.csv"}, paths)
+
+	files, err = storage.ListFiles("videos/ab/cd/v")
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, "videos/ab/cd/v1/match.mp4", files[0].Path)
+
+	files, err = storage.ListFiles("missing/")
+	require.NoError(t, err)
+	assert.Empty(t, files)
+}
+
+func TestLocalFileStorageCopyAndMove(t *testing.T) {
+	base := t.TempDir()
+	writeStoredFile(t, base, "videos/v1/match.mp4", 0)
+	storage, err := services.NewLocalFileStorage(base)
+	require.NoError(t, err)
+
+	exists, err := storage.Exists("videos/v1/match.mp4")
+	require.NoError(t, err)
+	assert.True(t, exists)
+	exists, err = storage.Exists("videos/v1")
+	require.NoError(t, err)
+	assert.False(t, exists, "directories are not files")
+
+	require.NoError(t, storage.CopyFile("videos/v1/match.mp4", "archive/videos/v1/match.mp4"))
+	require.NoError(t, storage.MoveFile("videos/v1/match.mp4", "videos/v2/match.mp4"))
+
+	exists, _ = storage.Exists("videos/v1/match.mp4")
+	assert.False(t, exists, "moved files are gone from the source")
+	for _, path := range []string{"archive/videos/v1/match.mp4", "videos/v2/match.mp4"} {
+		file, err := storage.GetFile(path)
+		require.NoError(t, err, path)
+		content, _ := io.ReadAll(file)
+		file.Close()
+		assert.Equal(t, "content", string(content), path)
+	}
+
+	assert.EqualError(t, storage.CopyFile("videos/v1/match.mp4", "videos/v3/match.mp4"), "file not found")
+	assert.EqualError(t, storage.MoveFile("videos/v1/match.mp4", "videos/v3/match.mp4"), "file not found")
+	exists, _ = storage.Exists("videos/v3/match.mp4")
+	assert.False(t, exists)
+}
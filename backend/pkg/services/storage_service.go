@@ -6,12 +6,23 @@ import (
 	"fmt"
 	"io"
 	"mime/multipart"
-	"net/url"
+	"net/http"
+	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/Azure/azure-storage-blob-go/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blockblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/sas"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/service"
 )
 
 // FileUploadInfo contains information about an uploaded file
@@ -22,6 +33,13 @@ type FileUploadInfo struct {
 	Format   string // File format/extension
 }
 
+// FileInfo describes a stored file found by listing storage
+type FileInfo struct {
+	Path       string    `json:"path"`        // Storage path
+	Size       int64     `json:"size"`        // File size in bytes
+	ModifiedAt time.Time `json:"modified_at"` // Time the file was last written
+}
+
 /**
  * StorageService defines the interface for file storage operations.
  * Abstracts operations for uploading, retrieving, and managing stored files.
@@ -41,23 +59,164 @@ type StorageService interface {
 
 	// GetFileMetadata retrieves metadata about a stored file
 	GetFileMetadata(path string) (map[string]string, error)
+
+	// ListFiles lists the stored files whose path starts with prefix
+	ListFiles(prefix string) ([]FileInfo, error)
+
+	// Exists reports whether a file is stored at path
+	Exists(path string) (bool, error)
+
+	// CopyFile copies the file at src to dst, replacing any file at dst
+	CopyFile(src, dst string) error
+
+	// MoveFile moves the file at src to dst, replacing any file at dst
+	MoveFile(src, dst string) error
+}
+
+/**
+ * StreamUploader is implemented by storage backends that can store content
+ * from a plain stream of unknown length, without seeking. Uploads use it to
+ * store a file while it is being checksummed and scanned in the same pass.
+ */
+type StreamUploader interface {
+	// UploadStream stores everything read from r at path
+	UploadStream(r io.Reader, path string) (*FileUploadInfo, error)
+}
+
+/**
+ * HealthChecker is implemented by storage backends that can report whether
+ * they are reachable, for example to decide whether to accept uploads.
+ */
+type HealthChecker interface {
+	// HealthCheck returns an error when the backend cannot serve requests
+	HealthCheck(ctx context.Context) error
+}
+
+/**
+ * Archiver is implemented by storage backends that can move files to a
+ * cheaper cold tier and bring them back. Archived files cannot be read until
+ * they are restored, and restores may take hours to complete.
+ */
+type Archiver interface {
+	// ArchiveFile moves the file at path to the cold tier
+	ArchiveFile(ctx context.Context, path string) error
+
+	// RestoreFile starts moving the file at path back to the hot tier
+	RestoreFile(ctx context.Context, path string) error
+
+	// Restored reports whether the file at path can be read again
+	Restored(ctx context.Context, path string) (bool, error)
+}
+
+/**
+ * UploadURL is a time-limited URL a client can upload one file to directly,
+ * without sending it through the API.
+ */
+type UploadURL struct {
+	URL       string            `json:"url"`
+	Method    string            `json:"method"`
+	Headers   map[string]string `json:"headers,omitempty"` // Headers the client must send with the upload
+	ExpiresAt time.Time         `json:"expires_at"`
+}
+
+/**
+ * UploadURLSigner is implemented by storage backends that clients can
+ * upload to directly, e.g. with an Azure SAS or an S3 presigned PUT URL.
+ */
+type UploadURLSigner interface {
+	// GetUploadURL returns a URL the file at path can be uploaded to until expiry
+	GetUploadURL(path string, expiry time.Duration) (*UploadURL, error)
+}
+
+// Azure authentication modes
+const (
+	AzureAuthSharedKey        = "shared_key"
+	AzureAuthServicePrincipal = "service_principal"
+	AzureAuthManagedIdentity  = "managed_identity"
+	AzureAuthDefault          = "default" // Environment, workload identity, managed identity or Azure CLI, in that order
+	AzureAuthConnectionString = "connection_string"
+)
+
+// errNoSigningKey is returned for SAS URLs when the storage is authenticated
+// with a SAS connection string, which cannot sign new ones.
+var errNoSigningKey = errors.New("storage credentials cannot sign SAS URLs")
+
+/**
+ * AzureStorageConfig holds the account, container and credentials of Azure
+ * Blob Storage.
+ */
+type AzureStorageConfig struct {
+	AccountName   string
+	ContainerName string
+	AuthMode      string // One of the AzureAuth modes; chosen from the credentials given when empty
+
+	AccountKey       string // Shared key
+	TenantID         string // Service principal
+	ClientID         string // Service principal, or user-assigned managed identity
+	ClientSecret     string // Service principal
+	ConnectionString string // With an account key or a SAS token
+}
+
+/**
+ * AzureStorageConfigFromEnv reads the Azure Blob Storage configuration from
+ * the AZURE_STORAGE_* variables and the standard AZURE_TENANT_ID,
+ * AZURE_CLIENT_ID and AZURE_CLIENT_SECRET variables.
+ *
+ * @param containerName The container to use
+ * @return The configuration
+ */
+func AzureStorageConfigFromEnv(containerName string) AzureStorageConfig {
+	return AzureStorageConfig{
+		AccountName:      os.Getenv("AZURE_STORAGE_ACCOUNT"),
+		ContainerName:    containerName,
+		AuthMode:         os.Getenv("AZURE_STORAGE_AUTH_MODE"),
+		AccountKey:       os.Getenv("AZURE_STORAGE_KEY"),
+		TenantID:         os.Getenv("AZURE_TENANT_ID"),
+		ClientID:         os.Getenv("AZURE_CLIENT_ID"),
+		ClientSecret:     os.Getenv("AZURE_CLIENT_SECRET"),
+		ConnectionString: os.Getenv("AZURE_STORAGE_CONNECTION_STRING"),
+	}
+}
+
+// authMode returns the configured mode, or the mode the given credentials
+// point to: a connection string, then a shared key, then the default chain.
+func (c AzureStorageConfig) authMode() string {
+	switch {
+	case c.AuthMode != "":
+		return c.AuthMode
+	case c.ConnectionString != "":
+		return AzureAuthConnectionString
+	case c.AccountKey != "":
+		return AzureAuthSharedKey
+	case c.ClientSecret != "":
+		return AzureAuthServicePrincipal
+	default:
+		return AzureAuthDefault
+	}
 }
 
 /**
  * AzureBlobStorage implements the StorageService interface using Azure Blob Storage.
  */
 type AzureBlobStorage struct {
-	accountName   string
-	accountKey    string
 	containerName string
-	credential    *azblob.SharedKeyCredential
-	serviceURL    azblob.ServiceURL
-	containerURL  azblob.ContainerURL
+	client        *azblob.Client
+	container     *container.Client
+
+	// SAS URLs are signed with the shared key, or with a user delegation
+	// key when authenticated with Azure AD
+	sharedKey  *azblob.SharedKeyCredential
+	tokenAuth  bool
+	delegation struct {
+		sync.Mutex
+		credential *service.UserDelegationCredential
+		expiresAt  time.Time
+	}
 }
 
 /**
- * NewAzureBlobStorage creates a new Azure Blob Storage service client.
- * Initializes the connection to Azure Blob Storage using provided credentials.
+ * NewAzureBlobStorage creates a new Azure Blob Storage service client
+ * authenticated with a shared key.
  *
  * @param accountName Azure storage account name
  * @param accountKey Azure storage account key
@@ -69,43 +228,117 @@ func NewAzureBlobStorage(accountName, accountKey, containerName string) (Storage
 	if accountName == "" || accountKey == "" || containerName == "" {
 		return nil, errors.New("azure credentials cannot be empty")
 	}
+	return NewAzureBlobStorageWithConfig(AzureStorageConfig{
+		AccountName:   accountName,
+		AccountKey:    accountKey,
+		ContainerName: containerName,
+		AuthMode:      AzureAuthSharedKey,
+	})
+}
 
-	// Create credential
-	credential, err := azblob.NewSharedKeyCredential(accountName, accountKey)
-	if err != nil {
-		return nil, err
+/**
+ * NewAzureBlobStorageWithConfig creates a new Azure Blob Storage service
+ * client with a shared key, an Azure AD service principal, a managed
+ * identity, the default Azure credential chain or a connection string.
+ *
+ * @param cfg The account, container and credentials
+ * @return A new storage service client or error
+ */
+func NewAzureBlobStorageWithConfig(cfg AzureStorageConfig) (*AzureBlobStorage, error) {
+	if cfg.ContainerName == "" {
+		return nil, errors.New("azure container name cannot be empty")
+	}
+	mode := cfg.authMode()
+	if mode != AzureAuthConnectionString && cfg.AccountName == "" {
+		return nil, errors.New("azure storage account cannot be empty")
 	}
 
-	// Create pipeline
-	pipeline := azblob.NewPipeline(credential, azblob.PipelineOptions{
-		Retry: azblob.RetryOptions{
-			MaxTries:      3,
+	options := &azblob.ClientOptions{ClientOptions: policy.ClientOptions{
+		Retry: policy.RetryOptions{
+			MaxRetries:    3,
 			TryTimeout:    30 * time.Second,
 			RetryDelay:    3 * time.Second,
 			MaxRetryDelay: 30 * time.Second,
 		},
-	})
+	}}
+	serviceURL := "https://" + cfg.AccountName + ".blob.core.windows.net/"
+
+	s := &AzureBlobStorage{containerName: cfg.ContainerName}
+	var err error
+	switch mode {
+	case AzureAuthSharedKey:
+		if s.sharedKey, err = azblob.NewSharedKeyCredential(cfg.AccountName, cfg.AccountKey); err != nil {
+			return nil, err
+		}
+		s.client, err = azblob.NewClientWithSharedKeyCredential(serviceURL, s.sharedKey, options)
+
+	case AzureAuthConnectionString:
+		// Connection strings with an account key can sign SAS URLs too
+		if name, key := parseAzureConnectionString(cfg.ConnectionString); name != "" && key != "" {
+			if s.sharedKey, err = azblob.NewSharedKeyCredential(name, key); err != nil {
+				return nil, err
+			}
+		}
+		s.client, err = azblob.NewClientFromConnectionString(cfg.ConnectionString, options)
+
+	case AzureAuthServicePrincipal, AzureAuthManagedIdentity, AzureAuthDefault:
+		var credential azcore.TokenCredential
+		if credential, err = newAzureTokenCredential(mode, cfg); err != nil {
+			return nil, err
+		}
+		s.tokenAuth = true
+		s.client, err = azblob.NewClient(serviceURL, credential, options)
+
+	default:
+		return nil, fmt.Errorf("unsupported azure auth mode: %s", mode)
+	}
+	if err != nil {
+		return nil, err
+	}
 
-	// Create service URL
-	serviceURL := azblob.NewServiceURL(
-		url.URL{
-			Scheme: "https",
-			Host:   accountName + ".blob.core.windows.net",
-		},
-		pipeline,
-	)
-
-	// Get container URL
-	containerURL := serviceURL.NewContainerURL(containerName)
-
-	return &AzureBlobStorage{
-		accountName:   accountName,
-		accountKey:    accountKey,
-		containerName: containerName,
-		credential:    credential,
-		serviceURL:    serviceURL,
-		containerURL:  containerURL,
-	}, nil
+	s.container = s.client.ServiceClient().NewContainerClient(cfg.ContainerName)
+	return s, nil
+}
+
+// newAzureTokenCredential creates the Azure AD credential of a token auth mode.
+func newAzureTokenCredential(mode string, cfg AzureStorageConfig) (azcore.TokenCredential, error) {
+	switch mode {
+	case AzureAuthServicePrincipal:
+		if cfg.TenantID == "" || cfg.ClientID == "" || cfg.ClientSecret == "" {
+			return nil, errors.New("service principal auth needs a tenant ID, client ID and client secret")
+		}
+		return azidentity.NewClientSecretCredential(cfg.TenantID, cfg.ClientID, cfg.ClientSecret, nil)
+	case AzureAuthManagedIdentity:
+		options := &azidentity.ManagedIdentityCredentialOptions{}
+		if cfg.ClientID != "" {
+			options.ID = azidentity.ClientID(cfg.ClientID) // User-assigned identity
+		}
+		return azidentity.NewManagedIdentityCredential(options)
+	default:
+		return azidentity.NewDefaultAzureCredential(nil)
+	}
+}
+
+// parseAzureConnectionString returns the account name and key of a
+// connection string, which are empty for SAS connection strings.
+func parseAzureConnectionString(connectionString string) (accountName, accountKey string) {
+	for _, part := range strings.Split(connectionString, ";") {
+		name, value, _ := strings.Cut(part, "=")
+		switch strings.TrimSpace(name) {
+		case "AccountName":
+			accountName = value
+		case "AccountKey":
+			accountKey = value
+		}
+	}
+	return accountName, accountKey
+}
+
+// isAzureNotFound reports whether an Azure request failed with 404 Not Found.
+// HEAD responses carry no error code, so the status code is checked.
+func isAzureNotFound(err error) bool {
+	var responseErr *azcore.ResponseError
+	return errors.As(err, &responseErr) && responseErr.StatusCode == http.StatusNotFound
 }
 
 /**
@@ -117,53 +350,106 @@ func NewAzureBlobStorage(accountName, accountKey, containerName string) (Storage
  * @return Upload information or error
  */
 func (s *AzureBlobStorage) UploadFile(file multipart.File, path string) (*FileUploadInfo, error) {
-	ctx := context.Background()
-
-	// Get file size
-	fileSeeker, ok := file.(io.Seeker)
-	if !ok {
-		return nil, errors.New("file does not support seeking")
-	}
-
-	size, err := fileSeeker.Seek(0, io.SeekEnd)
-	if err != nil {
-		return nil, err
-	}
-
-	_, err = fileSeeker.Seek(0, io.SeekStart)
-	if err != nil {
-		return nil, err
-	}
-
-	// Create blob URL
-	blobURL := s.containerURL.NewBlockBlobURL(path)
+	return s.UploadStream(file, path)
+}
 
-	// Upload file
-	_, err = azblob.UploadStreamToBlockBlob(
-		ctx,
-		file,
-		blobURL,
-		azblob.UploadStreamToBlockBlobOptions{
-			BufferSize: 2 * 1024 * 1024, // 2MB buffer
-			MaxBuffers: 3,
-		},
-	)
+/**
+ * UploadStream uploads a stream of unknown length to Azure Blob Storage.
+ * The content is staged as blocks, so only a few blocks are held in memory.
+ *
+ * @param r The content to upload
+ * @param path The destination path in the storage
+ * @return Upload information or error
+ */
+func (s *AzureBlobStorage) UploadStream(r io.Reader, path string) (*FileUploadInfo, error) {
+	counter := &countingReader{r: r}
+	_, err := s.container.NewBlockBlobClient(path).UploadStream(context.Background(), counter, &blockblob.UploadStreamOptions{
+		BlockSize:   2 * 1024 * 1024, // 2MB blocks
+		Concurrency: 3,
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	// Return upload info
 	return &FileUploadInfo{
 		Path:     path,
 		Provider: "azure_blob",
-		Size:     size,
+		Size:     counter.n,
 		Format:   strings.TrimPrefix(filepath.Ext(path), "."),
 	}, nil
 }
 
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+/**
+ * HealthCheck verifies that the storage container is reachable.
+ *
+ * @param ctx Context bounding the request
+ * @return Error if the container properties cannot be read
+ */
+func (s *AzureBlobStorage) HealthCheck(ctx context.Context) error {
+	_, err := s.container.GetProperties(ctx, nil)
+	return err
+}
+
+/**
+ * ArchiveFile moves a blob to the Archive access tier.
+ *
+ * @param ctx Context bounding the request
+ * @param path The path of the file in storage
+ * @return Error if the tier cannot be set
+ */
+func (s *AzureBlobStorage) ArchiveFile(ctx context.Context, path string) error {
+	_, err := s.container.NewBlobClient(path).SetTier(ctx, blob.AccessTierArchive, nil)
+	return err
+}
+
+/**
+ * RestoreFile starts rehydrating an archived blob to the Hot access tier.
+ * Azure completes standard-priority rehydration within 15 hours.
+ *
+ * @param ctx Context bounding the request
+ * @param path The path of the file in storage
+ * @return Error if the tier cannot be set
+ */
+func (s *AzureBlobStorage) RestoreFile(ctx context.Context, path string) error {
+	_, err := s.container.NewBlobClient(path).SetTier(ctx, blob.AccessTierHot, &blob.SetTierOptions{
+		RehydratePriority: to.Ptr(blob.RehydratePriorityStandard),
+	})
+	return err
+}
+
+/**
+ * Restored reports whether a blob has left the Archive tier and finished
+ * rehydrating.
+ *
+ * @param ctx Context bounding the request
+ * @param path The path of the file in storage
+ * @return Whether the blob can be read, or error
+ */
+func (s *AzureBlobStorage) Restored(ctx context.Context, path string) (bool, error) {
+	props, err := s.container.NewBlobClient(path).GetProperties(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	archived := props.AccessTier != nil && *props.AccessTier == string(blob.AccessTierArchive)
+	rehydrating := props.ArchiveStatus != nil && *props.ArchiveStatus != ""
+	return !archived && !rehydrating, nil
+}
+
 /**
  * GetFile retrieves a file from Azure Blob Storage.
- * Downloads the blob from the specified path.
+ * Downloads the blob from the specified path, resuming interrupted downloads.
  *
  * @param path The path of the file in storage
  * @return A reader for the file content or error
@@ -171,19 +457,11 @@ func (s *AzureBlobStorage) UploadFile(file multipart.File, path string) (*FileUp
 func (s *AzureBlobStorage) GetFile(path string) (io.ReadCloser, error) {
 	ctx := context.Background()
 
-	// Create blob URL
-	blobURL := s.containerURL.NewBlockBlobURL(path)
-
-	// Download blob
-	response, err := blobURL.Download(ctx, 0, azblob.CountToEnd, azblob.BlobAccessConditions{}, false, azblob.ClientProvidedKeyOptions{})
+	response, err := s.container.NewBlobClient(path).DownloadStream(ctx, nil)
 	if err != nil {
 		return nil, err
 	}
-
-	// Create a reader from the response
-	reader := response.Body(azblob.RetryReaderOptions{})
-
-	return reader, nil
+	return response.NewRetryReader(ctx, &blob.RetryReaderOptions{}), nil
 }
 
 /**
@@ -194,13 +472,7 @@ func (s *AzureBlobStorage) GetFile(path string) (io.ReadCloser, error) {
  * @return Error if deletion fails
  */
 func (s *AzureBlobStorage) DeleteFile(path string) error {
-	ctx := context.Background()
-
-	// Create blob URL
-	blobURL := s.containerURL.NewBlockBlobURL(path)
-
-	// Delete blob
-	_, err := blobURL.Delete(ctx, azblob.DeleteSnapshotsOptionNone, azblob.BlobAccessConditions{})
+	_, err := s.container.NewBlobClient(path).Delete(context.Background(), nil)
 	return err
 }
 
@@ -212,27 +484,67 @@ func (s *AzureBlobStorage) DeleteFile(path string) error {
  * @return A temporary URL for accessing the file or error
  */
 func (s *AzureBlobStorage) GetStreamURL(path string) (string, error) {
-	// Create blob URL
-	blobURL := s.containerURL.NewBlockBlobURL(path)
+	return s.signedURL(path, sas.BlobPermissions{Read: true}, time.Now().Add(1*time.Hour)) // URL valid for 1 hour
+}
 
-	// Create SAS token for the blob
-	sasQueryParams, err := azblob.BlobSASSignatureValues{
-		Protocol:      azblob.SASProtocolHTTPS,
-		ExpiryTime:    time.Now().Add(1 * time.Hour), // URL valid for 1 hour
+// signedURL returns the URL of a blob with a SAS granting permissions until
+// expiresAt, signed with the shared key or a user delegation key.
+func (s *AzureBlobStorage) signedURL(path string, permissions sas.BlobPermissions, expiresAt time.Time) (string, error) {
+	values := sas.BlobSignatureValues{
+		Protocol:      sas.ProtocolHTTPS,
+		ExpiryTime:    expiresAt.UTC(),
 		ContainerName: s.containerName,
 		BlobName:      path,
-		Permissions:   azblob.BlobSASPermissions{Read: true}.String(),
-	}.NewSASQueryParameters(s.credential)
+		Permissions:   permissions.String(),
+	}
 
+	var params sas.QueryParameters
+	var err error
+	switch {
+	case s.sharedKey != nil:
+		params, err = values.SignWithSharedKey(s.sharedKey)
+	case s.tokenAuth:
+		var credential *service.UserDelegationCredential
+		if credential, err = s.userDelegationCredential(expiresAt); err == nil {
+			params, err = values.SignWithUserDelegation(credential)
+		}
+	default:
+		err = errNoSigningKey
+	}
 	if err != nil {
 		return "", err
 	}
+	return s.container.NewBlobClient(path).URL() + "?" + params.Encode(), nil
+}
+
+// userDelegationCredential returns a user delegation key valid until at
+// least expiresAt, reusing the last one while it lasts. Keys are requested
+// for a day, or longer for longer-lived URLs, up to the maximum of 7 days.
+func (s *AzureBlobStorage) userDelegationCredential(expiresAt time.Time) (*service.UserDelegationCredential, error) {
+	s.delegation.Lock()
+	defer s.delegation.Unlock()
+	if s.delegation.credential != nil && !s.delegation.expiresAt.Before(expiresAt) {
+		return s.delegation.credential, nil
+	}
 
-	// Construct the SAS URL
-	qp := sasQueryParams.Encode()
-	blobURLWithSAS := blobURL.URL()
-	blobURLWithSAS.RawQuery = qp
-	return blobURLWithSAS.String(), nil
+	now := time.Now().UTC()
+	keyExpiry := now.Add(24 * time.Hour)
+	if expiresAt.After(keyExpiry) {
+		keyExpiry = expiresAt.UTC()
+	}
+	if max := now.Add(7 * 24 * time.Hour); keyExpiry.After(max) {
+		return nil, errors.New("SAS URLs signed with Azure AD credentials expire within 7 days")
+	}
+	credential, err := s.client.ServiceClient().GetUserDelegationCredential(context.Background(), service.KeyInfo{
+		Start:  to.Ptr(now.Add(-5 * time.Minute).Format(sas.TimeFormat)), // Tolerate clock skew
+		Expiry: to.Ptr(keyExpiry.Format(sas.TimeFormat)),
+	}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user delegation key: %w", err)
+	}
+	s.delegation.credential = credential
+	s.delegation.expiresAt = keyExpiry
+	return credential, nil
 }
 
 /**
@@ -243,27 +555,165 @@ func (s *AzureBlobStorage) GetStreamURL(path string) (string, error) {
  * @return A map of metadata or error
  */
 func (s *AzureBlobStorage) GetFileMetadata(path string) (map[string]string, error) {
-	ctx := context.Background()
-
-	// Create blob URL
-	blobURL := s.containerURL.NewBlockBlobURL(path)
-
-	// Get blob properties
-	props, err := blobURL.GetProperties(ctx, azblob.BlobAccessConditions{}, azblob.ClientProvidedKeyOptions{})
+	props, err := s.container.NewBlobClient(path).GetProperties(context.Background(), nil)
 	if err != nil {
 		return nil, err
 	}
 
 	// Extract metadata into a map
 	metadata := make(map[string]string)
-	for k, v := range props.NewMetadata() {
-		metadata[k] = v
+	for k, v := range props.Metadata {
+		if v != nil {
+			metadata[strings.ToLower(k)] = *v
+		}
 	}
 
 	// Add content properties
-	metadata["content-length"] = fmt.Sprintf("%d", props.ContentLength())
-	metadata["content-type"] = props.ContentType()
-	metadata["last-modified"] = props.LastModified().Format(time.RFC3339)
+	if props.ContentLength != nil {
+		metadata["content-length"] = fmt.Sprintf("%d", *props.ContentLength)
+	}
+	if props.ContentType != nil {
+		metadata["content-type"] = *props.ContentType
+	}
+	if props.LastModified != nil {
+		metadata["last-modified"] = props.LastModified.Format(time.RFC3339)
+	}
 
 	return metadata, nil
 }
+
+/**
+ * ListFiles lists the blobs whose name starts with prefix.
+ * Pages through the listing until it has been read completely.
+ *
+ * @param prefix The path prefix, e.g. "videos/"
+ * @return The matching files or error
+ */
+func (s *AzureBlobStorage) ListFiles(prefix string) ([]FileInfo, error) {
+	ctx := context.Background()
+
+	files := []FileInfo{}
+	pager := s.container.NewListBlobsFlatPager(&container.ListBlobsFlatOptions{Prefix: &prefix})
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range page.Segment.BlobItems {
+			if item.Name == nil {
+				continue
+			}
+			info := FileInfo{Path: *item.Name}
+			if item.Properties != nil {
+				if item.Properties.ContentLength != nil {
+					info.Size = *item.Properties.ContentLength
+				}
+				if item.Properties.LastModified != nil {
+					info.ModifiedAt = *item.Properties.LastModified
+				}
+			}
+			files = append(files, info)
+		}
+	}
+	return files, nil
+}
+
+/**
+ * Exists reports whether a blob exists in Azure Blob Storage.
+ *
+ * @param path The path of the file in storage
+ * @return Whether the blob exists, or error if it cannot be determined
+ */
+func (s *AzureBlobStorage) Exists(path string) (bool, error) {
+	_, err := s.container.NewBlobClient(path).GetProperties(context.Background(), nil)
+	if err != nil {
+		if isAzureNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+/**
+ * CopyFile copies a blob within the container using a server-side copy.
+ * Waits for the copy to complete; copies within an account usually finish
+ * immediately.
+ *
+ * @param src The path of the file to copy
+ * @param dst The destination path
+ * @return Error if the copy fails
+ */
+func (s *AzureBlobStorage) CopyFile(src, dst string) error {
+	ctx := context.Background()
+
+	// Authorize the copy source with a short-lived read SAS. Clients of a SAS
+	// connection string already carry a SAS in their URLs.
+	source, err := s.signedURL(src, sas.BlobPermissions{Read: true}, time.Now().Add(1*time.Hour))
+	if errors.Is(err, errNoSigningKey) {
+		source, err = s.container.NewBlobClient(src).URL(), nil
+	}
+	if err != nil {
+		return err
+	}
+
+	dstClient := s.container.NewBlobClient(dst)
+	response, err := dstClient.StartCopyFromURL(ctx, source, nil)
+	if err != nil {
+		return err
+	}
+
+	status := response.CopyStatus
+	for status != nil && *status == blob.CopyStatusTypePending {
+		time.Sleep(time.Second)
+		props, err := dstClient.GetProperties(ctx, nil)
+		if err != nil {
+			return err
+		}
+		status = props.CopyStatus
+	}
+	if status == nil {
+		return fmt.Errorf("copy of %s to %s returned no status", src, dst)
+	}
+	if *status != blob.CopyStatusTypeSuccess {
+		return fmt.Errorf("copy of %s to %s ended with status %s", src, dst, *status)
+	}
+	return nil
+}
+
+/**
+ * MoveFile moves a blob within the container. Blob storage cannot rename,
+ * so the blob is copied and the original deleted.
+ *
+ * @param src The path of the file to move
+ * @param dst The destination path
+ * @return Error if the copy or the deletion fails
+ */
+func (s *AzureBlobStorage) MoveFile(src, dst string) error {
+	if err := s.CopyFile(src, dst); err != nil {
+		return err
+	}
+	return s.DeleteFile(src)
+}
+
+/**
+ * GetUploadURL generates a SAS URL a client can PUT a block blob to.
+ * The SAS only allows creating and writing the one blob.
+ *
+ * @param path The path of the file in storage
+ * @param expiry How long the URL stays valid
+ * @return The upload URL or error
+ */
+func (s *AzureBlobStorage) GetUploadURL(path string, expiry time.Duration) (*UploadURL, error) {
+	expiresAt := time.Now().Add(expiry)
+	uploadURL, err := s.signedURL(path, sas.BlobPermissions{Create: true, Write: true}, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+	return &UploadURL{
+		URL:       uploadURL,
+		Method:    http.MethodPut,
+		Headers:   map[string]string{"x-ms-blob-type": "BlockBlob"},
+		ExpiresAt: expiresAt,
+	}, nil
+}
@@ -0,0 +1,80 @@
+package services_test
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"nivai/backend/pkg/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testAzureKey = "dGVzdGtleV9tdXN0X2JlX2xvbmdlcl9hbmRfZW5jb2RlZF9jb3JyZWN0bHlhYmMxMjM0NTY3ODkwYWJjZGVmZ2hpamtsbW5vcHFyc3R1dnd4eXo="
+
+func TestNewAzureBlobStorageWithConfig(t *testing.T) {
+	t.Run("Auth mode follows the credentials given", func(t *testing.T) {
+		for name, cfg := range map[string]services.AzureStorageConfig{
+			"shared key":        {AccountName: "nivai", ContainerName: "matches", AccountKey: testAzureKey},
+			"service principal": {AccountName: "nivai", ContainerName: "matches", TenantID: "tenant", ClientID: "client", ClientSecret: "secret"},
+			"managed identity":  {AccountName: "nivai", ContainerName: "matches", AuthMode: services.AzureAuthManagedIdentity, ClientID: "client"},
+			"connection string": {ContainerName: "matches", ConnectionString: "DefaultEndpointsProtocol=https;AccountName=nivai;AccountKey=" + testAzureKey + ";EndpointSuffix=core.windows.net"},
+			"sas":               {ContainerName: "matches", ConnectionString: "BlobEndpoint=https://nivai.blob.core.windows.net/;SharedAccessSignature=sv=2022-11-02&ss=b&sig=abc"},
+		} {
+			storage, err := services.NewAzureBlobStorageWithConfig(cfg)
+			require.NoError(t, err, name)
+			assert.NotNil(t, storage, name)
+		}
+	})
+
+	t.Run("Incomplete configuration is rejected", func(t *testing.T) {
+		for name, cfg := range map[string]services.AzureStorageConfig{
+			"no container":      {AccountName: "nivai", AccountKey: testAzureKey},
+			"no account":        {ContainerName: "matches", AccountKey: testAzureKey},
+			"service principal": {AccountName: "nivai", ContainerName: "matches", AuthMode: services.AzureAuthServicePrincipal, ClientID: "client"},
+			"unknown mode":      {AccountName: "nivai", ContainerName: "matches", AuthMode: "certificate"},
+		} {
+			_, err := services.NewAzureBlobStorageWithConfig(cfg)
+			assert.Error(t, err, name)
+		}
+	})
+
+	t.Run("SAS URLs are signed with the account key", func(t *testing.T) {
+		connectionString := "DefaultEndpointsProtocol=https;AccountName=nivai;AccountKey=" + testAzureKey + ";EndpointSuffix=core.windows.net"
+		for _, cfg := range []services.AzureStorageConfig{
+			{AccountName: "nivai", ContainerName: "matches", AccountKey: testAzureKey},
+			{ContainerName: "matches", ConnectionString: connectionString},
+		} {
+			storage, err := services.NewAzureBlobStorageWithConfig(cfg)
+			require.NoError(t, err)
+
+			streamURL, err := storage.GetStreamURL("videos/m1/match.mp4")
+			require.NoError(t, err)
+			parsed, err := url.Parse(streamURL)
+			require.NoError(t, err)
+			assert.Equal(t, "nivai.blob.core.windows.net", parsed.Host)
+			assert.Equal(t, "/matches/videos/m1/match.mp4", parsed.Path)
+			assert.Equal(t, "r", parsed.Query().Get("sp"))
+			assert.NotEmpty(t, parsed.Query().Get("sig"))
+
+			upload, err := storage.GetUploadURL("videos/m1/match.mp4", 15*time.Minute)
+			require.NoError(t, err)
+			parsed, err = url.Parse(upload.URL)
+			require.NoError(t, err)
+			assert.Equal(t, "cw", parsed.Query().Get("sp"))
+			assert.Equal(t, "BlockBlob", upload.Headers["x-ms-blob-type"])
+		}
+	})
+
+	t.Run("SAS connection strings cannot sign URLs", func(t *testing.T) {
+		storage, err := services.NewAzureBlobStorageWithConfig(services.AzureStorageConfig{
+			ContainerName:    "matches",
+			ConnectionString: "BlobEndpoint=https://nivai.blob.core.windows.net/;SharedAccessSignature=sv=2022-11-02&ss=b&sig=abc",
+		})
+		require.NoError(t, err)
+
+		_, err = storage.GetStreamURL("videos/m1/match.mp4")
+		assert.Error(t, err)
+	})
+}
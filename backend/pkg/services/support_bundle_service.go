@@ -0,0 +1,450 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"nivai/backend/pkg/events"
+	"nivai/backend/pkg/lifecycle"
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/support"
+)
+
+// ErrInvalidSupportWindow is returned when a support bundle's time window ends before it starts.
+var ErrInvalidSupportWindow = errors.New("support bundle window must end after it starts")
+
+/**
+ * SupportBundleConfig holds the process-wide sources of a support bundle.
+ * Every field is optional; missing sources are left out of the bundle.
+ */
+type SupportBundleConfig struct {
+	Config       interface{}                                // Application configuration, sanitized before it is written
+	Checks       map[string]func(ctx context.Context) error // Dependency health checks by name
+	Degraded     func() []string                            // Dependencies currently considered degraded
+	Lifecycle    *lifecycle.Manager                         // Readiness and in-flight work
+	Logs         *support.LogBuffer                         // Recent log output
+	Events       *support.EventRecorder                     // Recent events
+	Window       time.Duration                              // Default time window (default 24h)
+	CheckTimeout time.Duration                              // Timeout per dependency check (default 5s)
+}
+
+/**
+ * SupportBundleRequest selects what a support bundle covers: a time window
+ * and optionally a single match. Zero times default to the configured window
+ * ending now.
+ */
+type SupportBundleRequest struct {
+	VideoID string
+	Since   time.Time
+	Until   time.Time
+}
+
+/**
+ * SupportManifest describes a support bundle and lists the parts that could
+ * not be collected.
+ */
+type SupportManifest struct {
+	GeneratedAt time.Time `json:"generated_at"`
+	Since       time.Time `json:"since"`
+	Until       time.Time `json:"until"`
+	VideoID     string    `json:"video_id,omitempty"`
+	Files       []string  `json:"files"`
+	Errors      []string  `json:"errors,omitempty"`
+}
+
+/**
+ * SupportHealth is the health of the instance when a bundle was generated.
+ */
+type SupportHealth struct {
+	Dependencies map[string]string `json:"dependencies"` // "ok" or the check's error
+	Degraded     []string          `json:"degraded"`
+	Ready        bool              `json:"ready"`
+	InFlight     int               `json:"in_flight"`
+	StartedAt    time.Time         `json:"started_at"`
+	GoVersion    string            `json:"go_version"`
+	Goroutines   int               `json:"goroutines"`
+}
+
+/**
+ * SupportFailedJob is a piece of background work that failed in the window:
+ * match analysis, a quarantined upload, an archive or restore, or a webhook
+ * delivery that ran out of attempts.
+ */
+type SupportFailedJob struct {
+	Type     string    `json:"type"` // "analytics", "quarantine", "archive", "restore" or "webhook_delivery"
+	ID       string    `json:"id"`
+	VideoID  string    `json:"video_id,omitempty"`
+	Error    string    `json:"error,omitempty"`
+	Attempts int       `json:"attempts,omitempty"`
+	FailedAt time.Time `json:"failed_at"`
+}
+
+/**
+ * SupportTimelineEntry is one step in a match's lifecycle history.
+ */
+type SupportTimelineEntry struct {
+	At          time.Time `json:"at"`
+	Source      string    `json:"source"` // "video", "file", "event", "archive" or "retention"
+	Description string    `json:"description"`
+}
+
+/**
+ * SupportMatchHistory is everything recorded about one match, with a
+ * timeline merging it in order.
+ */
+type SupportMatchHistory struct {
+	Video            *models.Video                   `json:"video"`
+	Files            []*models.VideoFile             `json:"files"`
+	ArchiveJobs      []*models.ArchiveJob            `json:"archive_jobs"`
+	RetentionActions []*models.RetentionActionRecord `json:"retention_actions"`
+	Timeline         []SupportTimelineEntry          `json:"timeline"`
+}
+
+/**
+ * SupportBundle is the collected diagnostics, ready to be written as a zip
+ * archive with WriteZip.
+ */
+type SupportBundle struct {
+	Manifest   SupportManifest
+	Config     interface{}
+	Health     *SupportHealth
+	Logs       []support.LogLine
+	Events     []events.Event
+	FailedJobs []SupportFailedJob
+	Match      *SupportMatchHistory
+}
+
+/**
+ * SupportBundleService assembles support bundles: sanitized configuration,
+ * health status, recent logs and events, failed jobs and, for a match, its
+ * lifecycle history. Parts that cannot be collected are listed in the
+ * manifest instead of failing the whole bundle.
+ */
+type SupportBundleService struct {
+	videoRepo     models.VideoRepository
+	fileRepo      models.VideoFileRepository
+	archiveJobs   models.ArchiveJobRepository
+	retentionRepo models.RetentionRepository
+	webhookRepo   models.WebhookRepository
+	cfg           SupportBundleConfig
+	startedAt     time.Time
+	now           func() time.Time
+}
+
+/**
+ * NewSupportBundleService creates a new support bundle service.
+ *
+ * @param videoRepo Repository for video data
+ * @param fileRepo Repository for stored file records
+ * @param archiveJobs Repository for archive jobs
+ * @param retentionRepo Repository for retention actions
+ * @param webhookRepo Repository for webhook deliveries
+ * @param cfg Process-wide sources of the bundle
+ * @return A new support bundle service
+ */
+func NewSupportBundleService(
+	videoRepo models.VideoRepository,
+	fileRepo models.VideoFileRepository,
+	archiveJobs models.ArchiveJobRepository,
+	retentionRepo models.RetentionRepository,
+	webhookRepo models.WebhookRepository,
+	cfg SupportBundleConfig,
+) *SupportBundleService {
+	if cfg.Window <= 0 {
+		cfg.Window = 24 * time.Hour
+	}
+	if cfg.CheckTimeout <= 0 {
+		cfg.CheckTimeout = 5 * time.Second
+	}
+	return &SupportBundleService{
+		videoRepo:     videoRepo,
+		fileRepo:      fileRepo,
+		archiveJobs:   archiveJobs,
+		retentionRepo: retentionRepo,
+		webhookRepo:   webhookRepo,
+		cfg:           cfg,
+		startedAt:     time.Now(),
+		now:           time.Now,
+	}
+}
+
+/**
+ * Generate collects a support bundle.
+ *
+ * @param ctx Context for the dependency checks
+ * @param req The window and match the bundle covers
+ * @return The bundle, or ErrVideoNotFound or ErrInvalidSupportWindow
+ */
+func (s *SupportBundleService) Generate(ctx context.Context, req SupportBundleRequest) (*SupportBundle, error) {
+	now := s.now()
+	if req.Until.IsZero() {
+		req.Until = now
+	}
+	if req.Since.IsZero() {
+		req.Since = req.Until.Add(-s.cfg.Window)
+	}
+	if !req.Until.After(req.Since) {
+		return nil, ErrInvalidSupportWindow
+	}
+
+	bundle := &SupportBundle{Manifest: SupportManifest{
+		GeneratedAt: now, Since: req.Since, Until: req.Until, VideoID: req.VideoID,
+	}}
+
+	if req.VideoID != "" {
+		match, err := s.matchHistory(req.VideoID)
+		if err != nil {
+			if isNotFound(err) {
+				return nil, ErrVideoNotFound
+			}
+			return nil, err
+		}
+		bundle.Match = match
+	}
+
+	if s.cfg.Config != nil {
+		config, err := support.Sanitize(s.cfg.Config)
+		if err != nil {
+			bundle.fail("config", err)
+		}
+		bundle.Config = config
+	}
+	bundle.Health = s.health(ctx)
+	if s.cfg.Logs != nil {
+		bundle.Logs = s.cfg.Logs.Lines(req.Since, req.Until, req.VideoID)
+	}
+	if s.cfg.Events != nil {
+		bundle.Events = s.cfg.Events.Events(req.Since, req.Until, req.VideoID)
+	}
+	bundle.FailedJobs = s.failedJobs(bundle, req)
+	return bundle, nil
+}
+
+// health runs the dependency checks concurrently and reports the state of
+// the instance.
+func (s *SupportBundleService) health(ctx context.Context) *SupportHealth {
+	health := &SupportHealth{
+		Dependencies: map[string]string{},
+		Degraded:     []string{},
+		Ready:        true,
+		StartedAt:    s.startedAt,
+		GoVersion:    runtime.Version(),
+		Goroutines:   runtime.NumGoroutine(),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range s.cfg.Checks {
+		wg.Add(1)
+		go func(name string, check func(ctx context.Context) error) {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, s.cfg.CheckTimeout)
+			defer cancel()
+			status := "ok"
+			if err := check(checkCtx); err != nil {
+				status = err.Error()
+			}
+			mu.Lock()
+			health.Dependencies[name] = status
+			mu.Unlock()
+		}(name, check)
+	}
+	wg.Wait()
+
+	if s.cfg.Degraded != nil {
+		health.Degraded = append(health.Degraded, s.cfg.Degraded()...)
+	}
+	if s.cfg.Lifecycle != nil {
+		health.Ready = s.cfg.Lifecycle.Ready()
+		health.InFlight = s.cfg.Lifecycle.InFlight()
+	}
+	return health
+}
+
+// failedJobs lists the work that failed in the window, newest first.
+func (s *SupportBundleService) failedJobs(bundle *SupportBundle, req SupportBundleRequest) []SupportFailedJob {
+	inWindow := func(at time.Time) bool { return !at.Before(req.Since) && !at.After(req.Until) }
+	jobs := []SupportFailedJob{}
+
+	for state, jobType := range map[string]string{"failed": "analytics", "rejected": "quarantine"} {
+		videos, err := s.videoRepo.FindByProcessingState(state, 100, 0)
+		if err != nil {
+			bundle.fail("failed_jobs: "+jobType, err)
+			continue
+		}
+		for _, video := range videos {
+			if inWindow(video.UpdatedAt) && (req.VideoID == "" || video.ID == req.VideoID) {
+				jobs = append(jobs, SupportFailedJob{Type: jobType, ID: video.ID, VideoID: video.ID, FailedAt: video.UpdatedAt})
+			}
+		}
+	}
+
+	archiveJobs, err := s.archiveJobs.FindFailedSince(req.Since)
+	if err != nil {
+		bundle.fail("failed_jobs: archive", err)
+	}
+	for _, job := range archiveJobs {
+		if inWindow(job.UpdatedAt) && (req.VideoID == "" || job.VideoID == req.VideoID) {
+			jobs = append(jobs, SupportFailedJob{
+				Type: job.Operation, ID: job.ID, VideoID: job.VideoID, Error: job.Error, FailedAt: job.UpdatedAt,
+			})
+		}
+	}
+
+	deliveries, err := s.webhookRepo.FindFailedDeliveries(req.Since, 100)
+	if err != nil {
+		bundle.fail("failed_jobs: webhook_delivery", err)
+	}
+	for _, delivery := range deliveries {
+		// Deliveries belong to events, not matches; the payload names the match
+		if inWindow(delivery.UpdatedAt) && (req.VideoID == "" || bytes.Contains(delivery.Payload, []byte(req.VideoID))) {
+			jobs = append(jobs, SupportFailedJob{
+				Type: "webhook_delivery", ID: delivery.ID, Error: delivery.LastError,
+				Attempts: delivery.Attempts, FailedAt: delivery.UpdatedAt,
+			})
+		}
+	}
+
+	sort.SliceStable(jobs, func(i, j int) bool { return jobs[i].FailedAt.After(jobs[j].FailedAt) })
+	return jobs
+}
+
+// matchHistory collects everything recorded about a match. Events are taken
+// from the whole recorder, not just the window, so the timeline is complete
+// as far as this instance knows.
+func (s *SupportBundleService) matchHistory(videoID string) (*SupportMatchHistory, error) {
+	video, err := s.videoRepo.FindByID(videoID)
+	if err != nil {
+		return nil, err
+	}
+	files, err := s.fileRepo.FindByVideoID(videoID)
+	if err != nil {
+		return nil, fmt.Errorf("files of match %s: %w", videoID, err)
+	}
+	archiveJobs, err := s.archiveJobs.FindByVideoID(videoID)
+	if err != nil {
+		return nil, fmt.Errorf("archive jobs of match %s: %w", videoID, err)
+	}
+	retentionActions, err := s.retentionRepo.FindActions(videoID)
+	if err != nil {
+		return nil, fmt.Errorf("retention actions of match %s: %w", videoID, err)
+	}
+
+	history := &SupportMatchHistory{
+		Video: video, Files: files, ArchiveJobs: archiveJobs, RetentionActions: retentionActions,
+	}
+	add := func(at time.Time, source, format string, args ...interface{}) {
+		history.Timeline = append(history.Timeline, SupportTimelineEntry{At: at, Source: source, Description: fmt.Sprintf(format, args...)})
+	}
+
+	add(video.CreatedAt, "video", "Match created")
+	for _, file := range files {
+		add(file.CreatedAt, "file", "Stored %s file %s (%d bytes)", file.Kind, file.Path, file.Size)
+	}
+	if s.cfg.Events != nil {
+		for _, e := range s.cfg.Events.Events(time.Time{}, time.Time{}, videoID) {
+			add(e.OccurredAt, "event", "Event %s", e.Type)
+		}
+	}
+	for _, job := range archiveJobs {
+		add(job.CreatedAt, "archive", "%s started (job %s)", job.Operation, job.ID)
+		if job.CompletedAt.Valid {
+			if job.Status == models.ArchiveJobFailed {
+				add(job.CompletedAt.Time, "archive", "%s failed: %s", job.Operation, job.Error)
+			} else {
+				add(job.CompletedAt.Time, "archive", "%s completed", job.Operation)
+			}
+		}
+	}
+	for _, action := range retentionActions {
+		add(action.PerformedAt, "retention", "Retention %s of %s file %s", action.Action, action.Kind, action.Path)
+	}
+	add(video.UpdatedAt, "video", "Last updated, processing state %q", video.ProcessingState)
+
+	sort.SliceStable(history.Timeline, func(i, j int) bool {
+		return history.Timeline[i].At.Before(history.Timeline[j].At)
+	})
+	return history, nil
+}
+
+// fail records a part of the bundle that could not be collected.
+func (b *SupportBundle) fail(part string, err error) {
+	b.Manifest.Errors = append(b.Manifest.Errors, fmt.Sprintf("%s: %v", part, err))
+}
+
+/**
+ * Filename returns the file name to offer the bundle for download under.
+ *
+ * @return The file name, e.g. "support-bundle-20240101T120000Z.zip"
+ */
+func (b *SupportBundle) Filename() string {
+	stamp := b.Manifest.GeneratedAt.UTC().Format("20060102T150405Z")
+	if b.Manifest.VideoID != "" {
+		return fmt.Sprintf("support-bundle-%s-%s.zip", b.Manifest.VideoID, stamp)
+	}
+	return fmt.Sprintf("support-bundle-%s.zip", stamp)
+}
+
+/**
+ * WriteZip writes the bundle as a zip archive with a manifest.json, one JSON
+ * file per part and the log lines as logs.txt.
+ *
+ * @param w The writer to write the archive to
+ * @return An error if writing fails
+ */
+func (b *SupportBundle) WriteZip(w io.Writer) error {
+	var logs bytes.Buffer
+	for _, line := range b.Logs {
+		fmt.Fprintf(&logs, "%s %s\n", line.At.UTC().Format(time.RFC3339Nano), line.Text)
+	}
+
+	type part struct {
+		name string
+		data interface{}
+	}
+	parts := []part{
+		{"config.json", b.Config},
+		{"health.json", b.Health},
+		{"logs.txt", logs.Bytes()},
+		{"events.json", b.Events},
+		{"failed_jobs.json", b.FailedJobs},
+	}
+	if b.Match != nil {
+		parts = append(parts, part{"match.json", b.Match})
+	}
+
+	manifest := b.Manifest
+	manifest.Files = []string{"manifest.json"}
+	for _, p := range parts {
+		manifest.Files = append(manifest.Files, p.name)
+	}
+	parts = append([]part{{"manifest.json", manifest}}, parts...)
+
+	archive := zip.NewWriter(w)
+	for _, p := range parts {
+		f, err := archive.CreateHeader(&zip.FileHeader{Name: p.name, Method: zip.Deflate, Modified: b.Manifest.GeneratedAt})
+		if err != nil {
+			return err
+		}
+		if raw, ok := p.data.([]byte); ok {
+			_, err = f.Write(raw)
+		} else {
+			encoder := json.NewEncoder(f)
+			encoder.SetIndent("", "  ")
+			err = encoder.Encode(p.data)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
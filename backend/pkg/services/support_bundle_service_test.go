@@ -0,0 +1,173 @@
+package services_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"nivai/backend/pkg/events"
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/services"
+	"nivai/backend/pkg/support"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSupportBundleService(t *testing.T) {
+	now := time.Now()
+
+	t.Run("Bundle for a match", func(t *testing.T) {
+		videoRepo := new(MockVideoRepository)
+		fileRepo := new(MockVideoFileRepository)
+		retentionRepo := new(MockRetentionRepository)
+		webhookRepo := new(MockWebhookRepository)
+		archiveJobs := &memoryArchiveJobs{}
+
+		video := &models.Video{ID: "m1", ProcessingState: "archived", CreatedAt: now.Add(-3 * time.Hour), UpdatedAt: now.Add(-time.Hour)}
+		videoRepo.On("FindByID", "m1").Return(video, nil).Once()
+		videoRepo.On("FindByProcessingState", "failed", 100, 0).Return([]*models.Video{
+			{ID: "m2", ProcessingState: "failed", UpdatedAt: now.Add(-time.Minute)},
+		}, nil).Once()
+		videoRepo.On("FindByProcessingState", "rejected", 100, 0).Return([]*models.Video{}, nil).Once()
+		fileRepo.On("FindByVideoID", "m1").Return([]*models.VideoFile{
+			{VideoID: "m1", Kind: models.FileKindVideo, Path: "videos/m1.mp4", Size: 1024, CreatedAt: now.Add(-3 * time.Hour)},
+		}, nil).Once()
+		retentionRepo.On("FindActions", "m1").Return([]*models.RetentionActionRecord{}, nil).Once()
+		webhookRepo.On("FindFailedDeliveries", mock.Anything, 100).Return([]*models.WebhookDelivery{
+			{ID: "d1", Payload: json.RawMessage(`{"data":{"video_id":"m1"}}`), LastError: "HTTP 500", Attempts: 8, UpdatedAt: now.Add(-10 * time.Minute)},
+			{ID: "d2", Payload: json.RawMessage(`{"data":{"video_id":"m2"}}`), LastError: "HTTP 500", Attempts: 8, UpdatedAt: now.Add(-10 * time.Minute)},
+		}, nil).Once()
+		archiveJobs.Create(&models.ArchiveJob{
+			ID: "j1", VideoID: "m1", Operation: models.ArchiveOperationRestore, Status: models.ArchiveJobFailed,
+			Error: "blob missing", CreatedAt: now.Add(-2 * time.Hour), UpdatedAt: now.Add(-90 * time.Minute),
+			CompletedAt: sql.NullTime{Time: now.Add(-90 * time.Minute), Valid: true},
+		})
+
+		logs := support.NewLogBuffer(10)
+		fmt.Fprintln(logs, "Archive: restore of video m1 failed: blob missing")
+		fmt.Fprintln(logs, "Unrelated line")
+		recorder := support.NewEventRecorder(10)
+		uploaded := events.New(events.VideoUploaded, map[string]interface{}{"video_id": "m1"})
+		uploaded.OccurredAt = now.Add(-150 * time.Minute)
+		recorder.HandleEvent(uploaded)
+
+		config := struct {
+			Host     string `json:"host"`
+			Password string `json:"password"`
+		}{"db", "hunter2"}
+		service := services.NewSupportBundleService(videoRepo, fileRepo, archiveJobs, retentionRepo, webhookRepo, services.SupportBundleConfig{
+			Config: config,
+			Checks: map[string]func(ctx context.Context) error{
+				"database": func(ctx context.Context) error { return nil },
+				"storage":  func(ctx context.Context) error { return errors.New("connection refused") },
+			},
+			Degraded: func() []string { return []string{"storage"} },
+			Logs:     logs,
+			Events:   recorder,
+		})
+
+		bundle, err := service.Generate(context.Background(), services.SupportBundleRequest{VideoID: "m1"})
+		require.NoError(t, err)
+
+		assert.Empty(t, bundle.Manifest.Errors)
+		assert.Equal(t, map[string]string{"database": "ok", "storage": "connection refused"}, bundle.Health.Dependencies)
+		assert.Equal(t, []string{"storage"}, bundle.Health.Degraded)
+		require.Len(t, bundle.Logs, 1)
+		assert.Contains(t, bundle.Logs[0].Text, "restore of video m1 failed")
+
+		require.Len(t, bundle.FailedJobs, 2, "only failures of the match, newest first")
+		assert.Equal(t, "webhook_delivery", bundle.FailedJobs[0].Type)
+		assert.Equal(t, "d1", bundle.FailedJobs[0].ID)
+		assert.Equal(t, models.ArchiveOperationRestore, bundle.FailedJobs[1].Type)
+		assert.Equal(t, "blob missing", bundle.FailedJobs[1].Error)
+
+		var timeline []string
+		for _, entry := range bundle.Match.Timeline {
+			timeline = append(timeline, entry.Description)
+		}
+		assert.Equal(t, []string{
+			"Match created",
+			"Stored video file videos/m1.mp4 (1024 bytes)",
+			"Event video.uploaded",
+			"restore started (job j1)",
+			"restore failed: blob missing",
+			`Last updated, processing state "archived"`,
+		}, timeline)
+
+		var archive bytes.Buffer
+		require.NoError(t, bundle.WriteZip(&archive))
+		reader, err := zip.NewReader(bytes.NewReader(archive.Bytes()), int64(archive.Len()))
+		require.NoError(t, err)
+		contents := map[string]string{}
+		for _, f := range reader.File {
+			rc, err := f.Open()
+			require.NoError(t, err)
+			data, err := io.ReadAll(rc)
+			require.NoError(t, err)
+			rc.Close()
+			contents[f.Name] = string(data)
+		}
+		assert.ElementsMatch(t, []string{"manifest.json", "config.json", "health.json", "logs.txt", "events.json", "failed_jobs.json", "match.json"}, mapKeys(contents))
+		assert.Contains(t, contents["config.json"], support.Redacted)
+		assert.NotContains(t, contents["config.json"], "hunter2")
+		assert.Contains(t, contents["logs.txt"], "restore of video m1 failed")
+		assert.Regexp(t, `^support-bundle-m1-\d{8}T\d{6}Z\.zip$`, bundle.Filename())
+	})
+
+	t.Run("Bundle without a match covers all failures in the window", func(t *testing.T) {
+		videoRepo := new(MockVideoRepository)
+		webhookRepo := new(MockWebhookRepository)
+		archiveJobs := &memoryArchiveJobs{}
+		videoRepo.On("FindByProcessingState", "failed", 100, 0).Return([]*models.Video{
+			{ID: "recent", UpdatedAt: now.Add(-time.Hour)},
+			{ID: "old", UpdatedAt: now.Add(-48 * time.Hour)},
+		}, nil).Once()
+		videoRepo.On("FindByProcessingState", "rejected", 100, 0).Return(nil, errors.New("connection reset")).Once()
+		webhookRepo.On("FindFailedDeliveries", mock.Anything, 100).Return([]*models.WebhookDelivery{}, nil).Once()
+		archiveJobs.Create(&models.ArchiveJob{
+			ID: "j1", VideoID: "other", Operation: models.ArchiveOperationArchive, Status: models.ArchiveJobFailed,
+			Error: "tier change refused", UpdatedAt: now.Add(-30 * time.Minute),
+		})
+
+		service := services.NewSupportBundleService(videoRepo, nil, archiveJobs, nil, webhookRepo, services.SupportBundleConfig{})
+		bundle, err := service.Generate(context.Background(), services.SupportBundleRequest{})
+		require.NoError(t, err)
+
+		require.Len(t, bundle.FailedJobs, 2)
+		assert.Equal(t, "archive", bundle.FailedJobs[0].Type)
+		assert.Equal(t, "tier change refused", bundle.FailedJobs[0].Error)
+		assert.Equal(t, "analytics", bundle.FailedJobs[1].Type)
+		assert.Equal(t, "recent", bundle.FailedJobs[1].VideoID)
+		assert.Equal(t, []string{"failed_jobs: quarantine: connection reset"}, bundle.Manifest.Errors)
+		assert.Nil(t, bundle.Match)
+	})
+
+	t.Run("Rejects unknown matches and empty windows", func(t *testing.T) {
+		videoRepo := new(MockVideoRepository)
+		videoRepo.On("FindByID", "missing").Return(nil, errors.New("video not found")).Once()
+		service := services.NewSupportBundleService(videoRepo, nil, &memoryArchiveJobs{}, nil, nil, services.SupportBundleConfig{})
+
+		_, err := service.Generate(context.Background(), services.SupportBundleRequest{VideoID: "missing"})
+		assert.ErrorIs(t, err, services.ErrVideoNotFound)
+
+		_, err = service.Generate(context.Background(), services.SupportBundleRequest{Since: now, Until: now.Add(-time.Hour)})
+		assert.ErrorIs(t, err, services.ErrInvalidSupportWindow)
+	})
+}
+
+func mapKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	return keys
+}
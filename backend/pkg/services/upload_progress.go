@@ -0,0 +1,356 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrUploadProgressNotFound is returned when no progress is known for an upload.
+var ErrUploadProgressNotFound = errors.New("upload progress not found")
+
+// States of the server-side copy of an upload.
+const (
+	UploadStateCopying = "copying"
+	UploadStateCopied  = "copied"
+	UploadStateFailed  = "failed"
+)
+
+// WebSocket message type of upload progress reports.
+const LiveUploadProgress = "upload.progress"
+
+/**
+ * UploadProgress is the progress of copying an upload's files to storage.
+ */
+type UploadProgress struct {
+	UploadID     string    `json:"upload_id"`
+	VideoID      string    `json:"video_id"`
+	Organization string    `json:"organization"`
+	UserID       string    `json:"user_id"`
+	State        string    `json:"state"`
+	File         string    `json:"file,omitempty"` // Kind of the file copy started last; files are copied concurrently
+	BytesCopied  int64     `json:"bytes_copied"`
+	TotalBytes   int64     `json:"total_bytes"`
+	Percent      int       `json:"percent"`
+	Error        string    `json:"error,omitempty"`
+	StartedAt    time.Time `json:"started_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+/**
+ * UploadProgressStore keeps the latest progress of each upload until it
+ * expires. Implementations are safe for concurrent use.
+ */
+type UploadProgressStore interface {
+	Save(ctx context.Context, progress *UploadProgress, ttl time.Duration) error
+	Get(ctx context.Context, uploadID string) (*UploadProgress, error)
+}
+
+/**
+ * MemoryUploadProgressStore keeps upload progress in process memory, so
+ * progress is only visible on the instance that receives the upload.
+ */
+type MemoryUploadProgressStore struct {
+	mu      sync.Mutex
+	entries map[string]memoryUploadProgress
+	now     func() time.Time
+}
+
+type memoryUploadProgress struct {
+	progress  UploadProgress
+	expiresAt time.Time
+}
+
+/**
+ * NewMemoryUploadProgressStore creates an empty in-memory progress store.
+ *
+ * @return A new in-memory progress store
+ */
+func NewMemoryUploadProgressStore() *MemoryUploadProgressStore {
+	return &MemoryUploadProgressStore{entries: make(map[string]memoryUploadProgress), now: time.Now}
+}
+
+/**
+ * Save implements UploadProgressStore. Expired entries are dropped when a
+ * new upload is saved.
+ */
+func (s *MemoryUploadProgressStore) Save(ctx context.Context, progress *UploadProgress, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	if _, ok := s.entries[progress.UploadID]; !ok {
+		for id, entry := range s.entries {
+			if now.After(entry.expiresAt) {
+				delete(s.entries, id)
+			}
+		}
+	}
+	s.entries[progress.UploadID] = memoryUploadProgress{progress: *progress, expiresAt: now.Add(ttl)}
+	return nil
+}
+
+/**
+ * Get implements UploadProgressStore.
+ */
+func (s *MemoryUploadProgressStore) Get(ctx context.Context, uploadID string) (*UploadProgress, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.entries[uploadID]
+	if !ok || s.now().After(entry.expiresAt) {
+		return nil, ErrUploadProgressNotFound
+	}
+	progress := entry.progress
+	return &progress, nil
+}
+
+/**
+ * RedisUploadProgressStore keeps upload progress in Redis, so any instance
+ * can report the progress of uploads received by another.
+ */
+type RedisUploadProgressStore struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+/**
+ * NewRedisUploadProgressStore creates a progress store whose keys are
+ * prefix+uploadID.
+ *
+ * @param client The Redis client
+ * @param prefix Prefix of the keys
+ * @return A new Redis progress store
+ */
+func NewRedisUploadProgressStore(client redis.UniversalClient, prefix string) *RedisUploadProgressStore {
+	return &RedisUploadProgressStore{client: client, prefix: prefix}
+}
+
+/**
+ * Save implements UploadProgressStore.
+ */
+func (s *RedisUploadProgressStore) Save(ctx context.Context, progress *UploadProgress, ttl time.Duration) error {
+	value, err := json.Marshal(progress)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(ctx, s.prefix+progress.UploadID, value, ttl).Err()
+}
+
+/**
+ * Get implements UploadProgressStore.
+ */
+func (s *RedisUploadProgressStore) Get(ctx context.Context, uploadID string) (*UploadProgress, error) {
+	value, err := s.client.Get(ctx, s.prefix+uploadID).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrUploadProgressNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var progress UploadProgress
+	if err := json.Unmarshal(value, &progress); err != nil {
+		return nil, err
+	}
+	return &progress, nil
+}
+
+/**
+ * UploadProgressConfig configures progress tracking.
+ */
+type UploadProgressConfig struct {
+	Interval time.Duration // Minimum time between reports while copying
+	TTL      time.Duration // How long progress is kept after the last report
+}
+
+/**
+ * UploadProgressTracker reports the progress of copying uploads to storage,
+ * saving it for polling clients and pushing it to WebSocket clients.
+ */
+type UploadProgressTracker struct {
+	store UploadProgressStore
+	live  LiveBroadcaster
+	cfg   UploadProgressConfig
+	now   func() time.Time
+}
+
+/**
+ * NewUploadProgressTracker creates a new progress tracker.
+ *
+ * @param store Store the progress is saved in
+ * @param live Broadcaster for WebSocket clients; may be nil
+ * @param cfg Report interval and retention
+ * @return A new progress tracker
+ */
+func NewUploadProgressTracker(store UploadProgressStore, live LiveBroadcaster, cfg UploadProgressConfig) *UploadProgressTracker {
+	if cfg.Interval <= 0 {
+		cfg.Interval = 250 * time.Millisecond
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = time.Hour
+	}
+	return &UploadProgressTracker{store: store, live: live, cfg: cfg, now: time.Now}
+}
+
+/**
+ * Get returns the latest progress of an upload.
+ *
+ * @param ctx Context for the store
+ * @param uploadID The client-chosen upload ID
+ * @return The progress, or ErrUploadProgressNotFound
+ */
+func (t *UploadProgressTracker) Get(ctx context.Context, uploadID string) (*UploadProgress, error) {
+	return t.store.Get(ctx, uploadID)
+}
+
+/**
+ * Start begins tracking the copy of an upload's files. A nil tracker
+ * returns a nil copy, whose methods do nothing.
+ *
+ * @param uploadID The client-chosen upload ID
+ * @param videoID The ID of the match being uploaded
+ * @param org The uploading organization
+ * @param userID The uploading user
+ * @param totalBytes Combined size of the files to copy
+ * @return The copy to report progress on
+ */
+func (t *UploadProgressTracker) Start(uploadID, videoID, org, userID string, totalBytes int64) *UploadCopy {
+	if t == nil {
+		return nil
+	}
+	now := t.now()
+	c := &UploadCopy{tracker: t, progress: UploadProgress{
+		UploadID:     uploadID,
+		VideoID:      videoID,
+		Organization: org,
+		UserID:       userID,
+		State:        UploadStateCopying,
+		TotalBytes:   totalBytes,
+		StartedAt:    now,
+		UpdatedAt:    now,
+	}}
+	c.report()
+	return c
+}
+
+/**
+ * UploadCopy reports the progress of a single upload.
+ */
+type UploadCopy struct {
+	tracker *UploadProgressTracker
+
+	mu         sync.Mutex
+	progress   UploadProgress
+	lastReport time.Time
+}
+
+/**
+ * Reader counts the bytes read from r as copied bytes of the given file.
+ *
+ * @param kind The kind of file being copied
+ * @param r The file content
+ * @return A reader reporting progress, or r on a nil copy
+ */
+func (c *UploadCopy) Reader(kind string, r io.Reader) io.Reader {
+	if c == nil {
+		return r
+	}
+	c.mu.Lock()
+	c.progress.File = kind
+	c.mu.Unlock()
+	return &progressReader{r: r, upload: c}
+}
+
+/**
+ * Copied reports that all files are in storage.
+ */
+func (c *UploadCopy) Copied() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	c.progress.State = UploadStateCopied
+	c.progress.File = ""
+	c.progress.BytesCopied = c.progress.TotalBytes
+	c.mu.Unlock()
+	c.report()
+}
+
+/**
+ * Failed reports that the copy failed.
+ *
+ * @param err The reason, shown to the client
+ */
+func (c *UploadCopy) Failed(err error) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	c.progress.State = UploadStateFailed
+	c.progress.Error = err.Error()
+	c.mu.Unlock()
+	c.report()
+}
+
+// add counts n copied bytes, reporting at most once per interval.
+func (c *UploadCopy) add(n int) {
+	c.mu.Lock()
+	c.progress.BytesCopied += int64(n)
+	due := c.tracker.now().Sub(c.lastReport) >= c.tracker.cfg.Interval
+	c.mu.Unlock()
+	if due {
+		c.report()
+	}
+}
+
+// report saves the current progress and pushes it to WebSocket clients.
+// Failures are logged only; progress reporting must not fail uploads.
+func (c *UploadCopy) report() {
+	c.mu.Lock()
+	now := c.tracker.now()
+	c.lastReport = now
+	c.progress.UpdatedAt = now
+	if c.progress.TotalBytes > 0 {
+		c.progress.Percent = int(c.progress.BytesCopied * 100 / c.progress.TotalBytes)
+	}
+	progress := c.progress
+	c.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := c.tracker.store.Save(ctx, &progress, c.tracker.cfg.TTL); err != nil {
+		log.Printf("Upload progress: failed to save progress of upload %s: %v", progress.UploadID, err)
+	}
+	if c.tracker.live != nil {
+		data := map[string]interface{}{
+			"upload_id":    progress.UploadID,
+			"state":        progress.State,
+			"file":         progress.File,
+			"bytes_copied": progress.BytesCopied,
+			"total_bytes":  progress.TotalBytes,
+			"percent":      progress.Percent,
+		}
+		if progress.Error != "" {
+			data["error"] = progress.Error
+		}
+		c.tracker.live.Broadcast(newLiveMessage(LiveUploadProgress, progress.VideoID, false, data))
+	}
+}
+
+// progressReader counts the bytes read through it.
+type progressReader struct {
+	r      io.Reader
+	upload *UploadCopy
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n > 0 {
+		p.upload.add(n)
+	}
+	return n, err
+}
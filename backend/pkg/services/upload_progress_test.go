@@ -0,0 +1,110 @@
+package services_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"nivai/backend/pkg/services"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func exerciseUploadProgressStore(t *testing.T, store services.UploadProgressStore) {
+	t.Helper()
+	ctx := context.Background()
+
+	_, err := store.Get(ctx, "u1")
+	assert.ErrorIs(t, err, services.ErrUploadProgressNotFound)
+
+	require.NoError(t, store.Save(ctx, &services.UploadProgress{UploadID: "u1", BytesCopied: 10, TotalBytes: 100}, time.Minute))
+	require.NoError(t, store.Save(ctx, &services.UploadProgress{UploadID: "u1", BytesCopied: 50, TotalBytes: 100}, time.Minute))
+	progress, err := store.Get(ctx, "u1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(50), progress.BytesCopied)
+}
+
+func TestUploadProgressStores(t *testing.T) {
+	t.Run("Memory", func(t *testing.T) {
+		exerciseUploadProgressStore(t, services.NewMemoryUploadProgressStore())
+	})
+
+	t.Run("Redis", func(t *testing.T) {
+		server := miniredis.RunT(t)
+		client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+		defer client.Close()
+
+		exerciseUploadProgressStore(t, services.NewRedisUploadProgressStore(client, "test:"))
+		assert.True(t, server.Exists("test:u1"))
+		server.FastForward(2 * time.Minute)
+		assert.False(t, server.Exists("test:u1"))
+	})
+}
+
+func TestUploadProgressTracker(t *testing.T) {
+	t.Run("Copied bytes are reported while reading", func(t *testing.T) {
+		store := services.NewMemoryUploadProgressStore()
+		live := &recordingBroadcaster{}
+		// Report on every read
+		tracker := services.NewUploadProgressTracker(store, live, services.UploadProgressConfig{Interval: time.Nanosecond})
+
+		upload := tracker.Start("u1", "v1", "org", "user", 300)
+		_, err := io.Copy(io.Discard, upload.Reader("tracking", io.LimitReader(bytes.NewReader(make([]byte, 200)), 100)))
+		require.NoError(t, err)
+
+		progress, err := tracker.Get(context.Background(), "u1")
+		require.NoError(t, err)
+		assert.Equal(t, services.UploadStateCopying, progress.State)
+		assert.Equal(t, "tracking", progress.File)
+		assert.Equal(t, int64(100), progress.BytesCopied)
+		assert.Equal(t, 33, progress.Percent)
+		assert.Equal(t, "user", progress.UserID)
+
+		upload.Copied()
+		progress, err = tracker.Get(context.Background(), "u1")
+		require.NoError(t, err)
+		assert.Equal(t, services.UploadStateCopied, progress.State)
+		assert.Equal(t, 100, progress.Percent)
+
+		require.NotEmpty(t, live.messages)
+		last := live.messages[len(live.messages)-1]
+		assert.Equal(t, services.LiveUploadProgress, last.Type)
+		assert.Equal(t, "v1", last.MatchID)
+		assert.Equal(t, "u1", last.Data["upload_id"])
+		assert.Equal(t, services.UploadStateCopied, last.Data["state"])
+	})
+
+	t.Run("Reports are throttled while copying", func(t *testing.T) {
+		live := &recordingBroadcaster{}
+		tracker := services.NewUploadProgressTracker(services.NewMemoryUploadProgressStore(), live, services.UploadProgressConfig{Interval: time.Hour})
+
+		upload := tracker.Start("u1", "v1", "org", "user", 1<<20)
+		_, err := io.CopyBuffer(io.Discard, upload.Reader("video", bytes.NewReader(make([]byte, 1<<20))), make([]byte, 1024))
+		require.NoError(t, err)
+		assert.Len(t, live.messages, 1, "only the start is reported within the interval")
+
+		upload.Failed(errors.New("storage unavailable"))
+		progress, err := tracker.Get(context.Background(), "u1")
+		require.NoError(t, err)
+		assert.Equal(t, services.UploadStateFailed, progress.State)
+		assert.Equal(t, "storage unavailable", progress.Error)
+		assert.Len(t, live.messages, 2)
+	})
+
+	t.Run("A nil tracker tracks nothing", func(t *testing.T) {
+		var tracker *services.UploadProgressTracker
+		upload := tracker.Start("u1", "v1", "org", "user", 10)
+		assert.Nil(t, upload)
+
+		r := bytes.NewReader([]byte("content"))
+		assert.Same(t, r, upload.Reader("events", r))
+		upload.Copied()
+		upload.Failed(errors.New("ignored"))
+	})
+}
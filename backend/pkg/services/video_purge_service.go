@@ -0,0 +1,185 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"nivai/backend/pkg/events"
+	"nivai/backend/pkg/models"
+)
+
+/**
+ * VideoPurgeService deletes matches in two phases. Deleting a match soft
+ * deletes it and queues its files for purging once the grace period ends;
+ * until then the deletion can be undone. A scheduled purge run removes the
+ * files of matches past their grace period.
+ */
+type VideoPurgeService struct {
+	videoRepo    models.VideoRepository
+	videoService VideoService
+	purges       models.VideoPurgeRepository
+	storage      StorageService
+	eventBus     *events.Bus
+	grace        time.Duration
+	now          func() time.Time
+}
+
+/**
+ * NewVideoPurgeService creates a new two-phase deletion service.
+ *
+ * @param videoRepo Repository for video data, to undo deletions
+ * @param videoService Video service soft deleting matches
+ * @param purges Repository of the pending purges
+ * @param storage Storage service holding the files
+ * @param eventBus Bus the restore and purge events are published on; may be nil
+ * @param grace How long the files of a deleted match are kept
+ * @return A new two-phase deletion service
+ */
+func NewVideoPurgeService(videoRepo models.VideoRepository, videoService VideoService, purges models.VideoPurgeRepository,
+	storage StorageService, eventBus *events.Bus, grace time.Duration) *VideoPurgeService {
+	return &VideoPurgeService{
+		videoRepo:    videoRepo,
+		videoService: videoService,
+		purges:       purges,
+		storage:      storage,
+		eventBus:     eventBus,
+		grace:        max(grace, 0),
+		now:          time.Now,
+	}
+}
+
+/**
+ * Delete soft deletes a match and queues its files for purging. The purge
+ * is queued first, so a match is never deleted without its files being
+ * purged later.
+ *
+ * @param video The match to delete
+ * @param deletedBy The user deleting the match
+ * @return The pending purge, or ErrVideoNotFound
+ */
+func (s *VideoPurgeService) Delete(video *models.Video, deletedBy string) (*models.VideoPurge, error) {
+	now := s.now()
+	purge := &models.VideoPurge{
+		VideoID:    video.ID,
+		Title:      video.Title,
+		Paths:      []string{},
+		DeletedBy:  deletedBy,
+		DeletedAt:  now,
+		PurgeAfter: now.Add(s.grace),
+	}
+	for _, path := range []string{video.FilePath, video.TrackingPath, video.EventFilePath} {
+		if path != "" {
+			purge.Paths = append(purge.Paths, path)
+		}
+	}
+
+	if err := s.purges.Create(purge); err != nil {
+		return nil, fmt.Errorf("failed to queue purge: %w", err)
+	}
+	if err := s.videoService.DeleteVideo(video.ID); err != nil {
+		if rollbackErr := s.purges.Delete(video.ID); rollbackErr != nil {
+			log.Printf("Purge: failed to unqueue purge of %s after a failed delete: %v", video.ID, rollbackErr)
+		}
+		return nil, err
+	}
+	return purge, nil
+}
+
+/**
+ * Restore undoes the deletion of a match whose grace period has not ended.
+ *
+ * @param videoID The deleted match
+ * @return The restored match, or models.ErrVideoPurgeNotFound when no
+ *         deletion is pending
+ */
+func (s *VideoPurgeService) Restore(videoID string) (*models.Video, error) {
+	if _, err := s.purges.FindByVideoID(videoID); err != nil {
+		return nil, err
+	}
+	if err := s.videoRepo.Undelete(videoID); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, models.ErrVideoPurgeNotFound
+		}
+		return nil, err
+	}
+	if err := s.purges.Delete(videoID); err != nil && !errors.Is(err, models.ErrVideoPurgeNotFound) {
+		return nil, err
+	}
+
+	s.eventBus.Publish(events.New(events.VideoRestored, map[string]interface{}{
+		"video_id": videoID,
+	}))
+	return s.videoRepo.FindByID(videoID)
+}
+
+/**
+ * Pending lists the deleted matches whose files wait to be purged.
+ *
+ * @param limit Maximum number of purges to return
+ * @param offset Number of purges to skip
+ * @return The pending purges, soonest first
+ */
+func (s *VideoPurgeService) Pending(limit, offset int) ([]*models.VideoPurge, error) {
+	return s.purges.FindAll(limit, offset)
+}
+
+/**
+ * RunScheduled removes the files of the deleted matches past their grace
+ * period. Files that are already gone count as removed; a purge whose
+ * files cannot all be removed stays queued and is retried on the next run.
+ *
+ * @param ctx Context for the run
+ * @return An error if the queue cannot be read or a purge failed
+ */
+func (s *VideoPurgeService) RunScheduled(ctx context.Context) error {
+	const batchSize = 100
+	due, err := s.purges.FindDue(s.now(), batchSize)
+	if err != nil {
+		return fmt.Errorf("failed to list due purges: %w", err)
+	}
+
+	failed := 0
+	for _, purge := range due {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := s.purge(purge); err != nil {
+			log.Printf("Purge: failed to purge files of %s: %v", purge.VideoID, err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d purges failed", failed, len(due))
+	}
+	return nil
+}
+
+// purge removes the files of one deleted match and dequeues it.
+func (s *VideoPurgeService) purge(purge *models.VideoPurge) error {
+	// A match restored behind the queue's back keeps its files
+	if _, err := s.videoRepo.FindByID(purge.VideoID); err == nil {
+		log.Printf("Purge: match %s exists again, keeping its files", purge.VideoID)
+		return s.purges.Delete(purge.VideoID)
+	}
+
+	for _, path := range purge.Paths {
+		if err := s.storage.DeleteFile(path); err != nil && !os.IsNotExist(err) && !strings.Contains(err.Error(), "not found") {
+			return fmt.Errorf("failed to delete %s: %w", path, err)
+		}
+	}
+	if err := s.purges.Delete(purge.VideoID); err != nil && !errors.Is(err, models.ErrVideoPurgeNotFound) {
+		return err
+	}
+
+	log.Printf("Purge: removed %d files of deleted match %s", len(purge.Paths), purge.VideoID)
+	s.eventBus.Publish(events.New(events.VideoPurged, map[string]interface{}{
+		"video_id": purge.VideoID,
+		"paths":    purge.Paths,
+	}))
+	return nil
+}
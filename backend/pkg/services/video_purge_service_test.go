@@ -0,0 +1,118 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"nivai/backend/pkg/events"
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVideoPurgeService(t *testing.T) {
+	ctx := context.Background()
+
+	// setup stores one match with two files and a service with a 24 hour grace period.
+	setup := func(t *testing.T) (*models.Repositories, *MockStorageService, *services.VideoPurgeService, *[]events.Event) {
+		repos := models.NewMemoryRepositories()
+		require.NoError(t, repos.Videos.Create(&models.Video{ID: "m1", Title: "Ajax - PSV", FilePath: "videos/m1/match.mp4", TrackingPath: "videos/m1/tracking.parquet"}))
+		storage := new(MockStorageService)
+
+		bus := events.NewBus()
+		var published []events.Event
+		for _, eventType := range []string{events.VideoDeleted, events.VideoRestored, events.VideoPurged} {
+			bus.Subscribe(eventType, func(e events.Event) { published = append(published, e) })
+		}
+		videoService := services.NewVideoService(repos.Videos, storage, services.WithEventBus(bus))
+		return repos, storage, services.NewVideoPurgeService(repos.Videos, videoService, repos.VideoPurges, storage, bus, 24*time.Hour), &published
+	}
+	deleteMatch := func(t *testing.T, repos *models.Repositories, purges *services.VideoPurgeService) *models.VideoPurge {
+		video, err := repos.Videos.FindByID("m1")
+		require.NoError(t, err)
+		purge, err := purges.Delete(video, "alice")
+		require.NoError(t, err)
+		return purge
+	}
+
+	t.Run("Deleting keeps the files until the grace period ends", func(t *testing.T) {
+		repos, storage, purges, published := setup(t)
+
+		purge := deleteMatch(t, repos, purges)
+
+		assert.Equal(t, []string{"videos/m1/match.mp4", "videos/m1/tracking.parquet"}, purge.Paths)
+		assert.Equal(t, "alice", purge.DeletedBy)
+		assert.Equal(t, 24*time.Hour, purge.PurgeAfter.Sub(purge.DeletedAt))
+		_, err := repos.Videos.FindByID("m1")
+		assert.Error(t, err, "the match is soft deleted")
+
+		require.NoError(t, purges.RunScheduled(ctx))
+		storage.AssertNotCalled(t, "DeleteFile", mock.Anything)
+		require.Len(t, *published, 1)
+		assert.Equal(t, events.VideoDeleted, (*published)[0].Type)
+	})
+
+	t.Run("Deletions can be undone within the grace period", func(t *testing.T) {
+		repos, _, purges, published := setup(t)
+		deleteMatch(t, repos, purges)
+
+		video, err := purges.Restore("m1")
+		require.NoError(t, err)
+		assert.Equal(t, "Ajax - PSV", video.Title)
+		assert.False(t, video.DeletedAt.Valid)
+		pending, err := purges.Pending(10, 0)
+		require.NoError(t, err)
+		assert.Empty(t, pending)
+		assert.Equal(t, events.VideoRestored, (*published)[1].Type)
+
+		_, err = purges.Restore("m1")
+		assert.ErrorIs(t, err, models.ErrVideoPurgeNotFound, "nothing is pending any more")
+	})
+
+	t.Run("Purges past the grace period remove the files", func(t *testing.T) {
+		repos, storage, purges, published := setup(t)
+		purge := deleteMatch(t, repos, purges)
+		purge.PurgeAfter = time.Now().Add(-time.Minute)
+		require.NoError(t, repos.VideoPurges.Create(purge))
+		storage.On("DeleteFile", "videos/m1/match.mp4").Return(nil).Once()
+		storage.On("DeleteFile", "videos/m1/tracking.parquet").Return(errors.New("file not found")).Once()
+
+		require.NoError(t, purges.RunScheduled(ctx))
+
+		storage.AssertExpectations(t)
+		_, err := repos.VideoPurges.FindByVideoID("m1")
+		assert.ErrorIs(t, err, models.ErrVideoPurgeNotFound)
+		require.Len(t, *published, 2)
+		assert.Equal(t, events.VideoPurged, (*published)[1].Type)
+		_, err = purges.Restore("m1")
+		assert.ErrorIs(t, err, models.ErrVideoPurgeNotFound, "purged matches cannot be restored")
+	})
+
+	t.Run("Failed purges stay queued", func(t *testing.T) {
+		repos, storage, purges, _ := setup(t)
+		purge := deleteMatch(t, repos, purges)
+		purge.PurgeAfter = time.Now().Add(-time.Minute)
+		require.NoError(t, repos.VideoPurges.Create(purge))
+		storage.On("DeleteFile", "videos/m1/match.mp4").Return(errors.New("permission denied")).Once()
+
+		assert.Error(t, purges.RunScheduled(ctx))
+
+		_, err := repos.VideoPurges.FindByVideoID("m1")
+		assert.NoError(t, err)
+	})
+
+	t.Run("Unknown matches are not queued", func(t *testing.T) {
+		repos, _, purges, _ := setup(t)
+
+		_, err := purges.Delete(&models.Video{ID: "unknown"}, "alice")
+		assert.ErrorIs(t, err, services.ErrVideoNotFound)
+		pending, err := repos.VideoPurges.FindAll(10, 0)
+		require.NoError(t, err)
+		assert.Empty(t, pending)
+	})
+}
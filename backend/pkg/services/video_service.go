@@ -2,12 +2,17 @@ package services
 
 import (
 	"errors"
+	"fmt"
+	"log"
 	"mime/multipart"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
+	"nivai/backend/pkg/events"
 	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/pythonapi"
 )
 
 // Common service errors
@@ -24,11 +29,17 @@ var (
 type VideoService interface {
 	GetVideoByID(id string) (*models.Video, error)
 	ListVideos(limit, offset int, filters map[string]string) ([]*models.Video, error)
+	ListVideosFor(access *models.AccessScope, limit, offset int, filters map[string]string) ([]*models.Video, error)
 	UploadVideo(file multipart.File, header *multipart.FileHeader, metadata *models.Video) (*models.Video, error)
 	DeleteVideo(id string) error
 	GetVideoStreamURL(id string) (string, error)
 	ProcessVideo(id string) error
-	CreateVideoEntry(metadata *models.Video) (*models.Video, error)
+	CreateVideoEntry(metadata *models.Video, change StateChange) (*models.Video, error)
+	UpdateProcessingState(id string, state models.ProcessingState, change StateChange) error
+	OverrideState(id string, override StateOverride, change StateChange) (*models.Video, error)
+	GetStateHistory(id string) ([]*models.StateTransition, error)
+	RecordVideoFiles(id string, files []*models.VideoFile) error
+	RejectVideo(id string, threats map[string]string) error
 }
 
 /**
@@ -38,21 +49,88 @@ type VideoService interface {
 type DefaultVideoService struct {
 	videoRepo      models.VideoRepository
 	storageService StorageService
+	eventBus       *events.Bus
+	fileRepo       models.VideoFileRepository
+	pathStrategy   PathStrategy
+	states         stateMachine
 	// Add more dependencies as needed (e.g., queue service, notification service)
 }
 
+/**
+ * VideoServiceOption configures optional dependencies of DefaultVideoService.
+ */
+type VideoServiceOption func(*DefaultVideoService)
+
+/**
+ * WithEventBus makes the service publish lifecycle events
+ * (video.uploaded, video.deleted, analytics.completed/failed) on bus.
+ *
+ * @param bus The event bus to publish to
+ * @return A video service option
+ */
+func WithEventBus(bus *events.Bus) VideoServiceOption {
+	return func(s *DefaultVideoService) {
+		s.eventBus = bus
+	}
+}
+
+/**
+ * WithFileRepository enables recording of stored files and their checksums.
+ *
+ * @param repo Repository for stored file records
+ * @return A video service option
+ */
+func WithFileRepository(repo models.VideoFileRepository) VideoServiceOption {
+	return func(s *DefaultVideoService) {
+		s.fileRepo = repo
+	}
+}
+
+/**
+ * WithPathStrategy sets where uploaded videos are stored. The controller
+ * must use the same strategy, so all files of a match share a directory.
+ *
+ * @param strategy The storage path strategy
+ * @return A video service option
+ */
+func WithPathStrategy(strategy PathStrategy) VideoServiceOption {
+	return func(s *DefaultVideoService) {
+		s.pathStrategy = strategy
+	}
+}
+
+/**
+ * WithStateHistory records every processing state change of a video, with
+ * who made it and why.
+ *
+ * @param repo Repository for the state history
+ * @return A video service option
+ */
+func WithStateHistory(repo models.StateHistoryRepository) VideoServiceOption {
+	return func(s *DefaultVideoService) {
+		s.states.history = repo
+	}
+}
+
 /**
  * NewVideoService creates a new video service instance.
  *
  * @param videoRepo Repository for video data access
  * @param storageService Service for file storage operations
+ * @param opts Optional dependencies such as the event bus
  * @return A new video service implementation
  */
-func NewVideoService(videoRepo models.VideoRepository, storageService StorageService) VideoService {
-	return &DefaultVideoService{
+func NewVideoService(videoRepo models.VideoRepository, storageService StorageService, opts ...VideoServiceOption) VideoService {
+	s := &DefaultVideoService{
 		videoRepo:      videoRepo,
 		storageService: storageService,
+		pathStrategy:   IDShardStrategy{},
+		states:         stateMachine{videos: videoRepo, now: time.Now},
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 /**
@@ -89,32 +167,130 @@ func (s *DefaultVideoService) GetVideoByID(id string) (*models.Video, error) {
  * @return A slice of videos matching the criteria, or an error
  */
 func (s *DefaultVideoService) ListVideos(limit, offset int, filters map[string]string) ([]*models.Video, error) {
+	return s.ListVideosFor(nil, limit, offset, filters)
+}
+
+/**
+ * ListVideosFor retrieves a filtered, paginated list of the videos within
+ * an access scope.
+ *
+ * @param access The matches the caller was granted; nil lists all videos
+ * @param limit Maximum number of videos to return
+ * @param offset Number of videos to skip for pagination
+ * @param filters Map of filter criteria
+ * @return A slice of videos matching the criteria, or an error
+ */
+func (s *DefaultVideoService) ListVideosFor(access *models.AccessScope, limit, offset int, filters map[string]string) ([]*models.Video, error) {
+	query, err := VideoQueryFromFilters(filters)
+	if err != nil {
+		return nil, err
+	}
+	query.Access = access
+
 	// Apply default pagination if needed
-	if limit <= 0 {
-		limit = 10
+	query.Limit, query.Offset = limit, offset
+	if query.Limit <= 0 {
+		query.Limit = 10
+	}
+	if query.Offset < 0 {
+		query.Offset = 0
 	}
-	if offset < 0 {
-		offset = 0
+
+	return s.videoRepo.FindByQuery(query)
+}
+
+/**
+ * VideoQueryFromFilters combines list filters into a video query. The
+ * "from" and "to" filters bound the match date and are RFC 3339 times;
+ * "sort" and "order" select the sort order.
+ *
+ * @param filters Map of filter criteria
+ * @return The video query, or an error for invalid dates
+ */
+func VideoQueryFromFilters(filters map[string]string) (models.VideoQuery, error) {
+	query := models.VideoQuery{
+		MatchID:         filters["match_id"],
+		Team:            filters["team"],
+		Competition:     filters["competition"],
+		Season:          filters["season"],
+		Venue:           filters["venue"],
+		Referee:         filters["referee"],
+		ProcessingState: filters["processing_state"],
+		Sort:            filters["sort"],
+		Order:           filters["order"],
 	}
 
-	// Process filters
-	if matchID, ok := filters["match_id"]; ok && matchID != "" {
-		// Return videos for a specific match
-		return s.videoRepo.FindByMatchID(matchID)
+	var err error
+	if status := filters["analytics_status"]; status != "" {
+		if query.ProcessingStates, err = AnalyticsStatusStates(status); err != nil {
+			return query, err
+		}
+	}
+	if query.MatchDateFrom, err = parseFilterTime(filters["from"]); err != nil {
+		return query, err
 	}
+	if query.MatchDateTo, err = parseFilterTime(filters["to"]); err != nil {
+		return query, err
+	}
+	return query, nil
+}
 
-	if team, ok := filters["team"]; ok && team != "" {
-		// Return videos for a specific team
-		return s.videoRepo.FindByTeam(team, limit, offset)
+// analyticsStatusStates maps the statuses of the analytics service to the
+// processing states recorded once a match reaches them
+var analyticsStatusStates = map[string][]models.ProcessingState{
+	pythonapi.StatusPending:   {models.StatePending, models.StatePendingAnalytics, models.StateProcessing},
+	pythonapi.StatusProcessed: {models.StateCompleted, models.StateArchived, models.StateRestoring},
+	pythonapi.StatusError:     {models.StateFailed},
+	pythonapi.StatusCancelled: {models.StateCancelled},
+}
+
+/**
+ * AnalyticsStatusStates returns the processing states of matches with an
+ * analytics status. The states are synced from the analytics service, so
+ * lists can be filtered by status without a call per match; a match whose
+ * run just finished keeps its old state until its status is next read.
+ *
+ * @param status An analytics status: pending, processed, error or cancelled
+ * @return The processing states, or an error naming the accepted statuses
+ */
+func AnalyticsStatusStates(status string) ([]string, error) {
+	states, ok := analyticsStatusStates[status]
+	if !ok {
+		return nil, fmt.Errorf("invalid analytics_status %q: use pending, processed, error or cancelled", status)
 	}
+	names := make([]string, len(states))
+	for i, state := range states {
+		names[i] = string(state)
+	}
+	return names, nil
+}
 
-	if state, ok := filters["processing_state"]; ok && state != "" {
-		// Return videos with a specific processing state
-		return s.videoRepo.FindByProcessingState(state, limit, offset)
+/**
+ * AnalyticsStatusOf returns the analytics status a processing state was
+ * synced from, the reverse of AnalyticsStatusStates.
+ *
+ * @param state A processing state
+ * @return The analytics status, or "" for states the analytics service never reports
+ */
+func AnalyticsStatusOf(state models.ProcessingState) string {
+	for status, states := range analyticsStatusStates {
+		if slices.Contains(states, state) {
+			return status
+		}
 	}
+	return ""
+}
 
-	// If no specific filters are applied, return all videos with pagination
-	return s.videoRepo.FindAll(limit, offset)
+// parseFilterTime parses an optional RFC 3339 filter value
+func parseFilterTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q: use RFC 3339", value)
+	}
+	return t, nil
 }
 
 /**
@@ -138,7 +314,7 @@ func (s *DefaultVideoService) UploadVideo(file multipart.File, header *multipart
 	}
 
 	// Generate storage path
-	storagePath := generateStoragePath(metadata)
+	storagePath := generateStoragePath(s.pathStrategy, metadata)
 
 	// Upload file to storage
 	uploadInfo, err := s.storageService.UploadFile(file, storagePath)
@@ -151,7 +327,7 @@ func (s *DefaultVideoService) UploadVideo(file multipart.File, header *multipart
 	metadata.StorageProvider = uploadInfo.Provider
 	metadata.Size = uploadInfo.Size
 	metadata.Format = uploadInfo.Format
-	metadata.ProcessingState = "pending"
+	metadata.ProcessingState = models.StatePending
 	metadata.CreatedAt = time.Now()
 	metadata.UpdatedAt = time.Now()
 
@@ -161,6 +337,7 @@ func (s *DefaultVideoService) UploadVideo(file multipart.File, header *multipart
 		_ = s.storageService.DeleteFile(uploadInfo.Path)
 		return nil, err
 	}
+	s.states.created(metadata, StateChange{Reason: "video uploaded"})
 
 	// Queue video for processing (extraction of duration, resolution, etc.)
 	go s.ProcessVideo(metadata.ID)
@@ -193,6 +370,10 @@ func (s *DefaultVideoService) DeleteVideo(id string) error {
 		}
 		return err
 	}
+
+	s.eventBus.Publish(events.New(events.VideoDeleted, map[string]interface{}{
+		"video_id": id,
+	}))
 	return nil
 }
 
@@ -237,9 +418,8 @@ func (s *DefaultVideoService) ProcessVideo(id string) error {
 	}
 
 	// Update processing state
-	video.ProcessingState = "processing"
-	video.UpdatedAt = time.Now()
-	if err := s.videoRepo.Update(video); err != nil {
+	processing := StateChange{Actor: ActorVideoProcessing, Reason: "video processing started"}
+	if _, _, err := s.states.transition(video, models.StateProcessing, processing); err != nil {
 		return err
 	}
 
@@ -250,12 +430,13 @@ func (s *DefaultVideoService) ProcessVideo(id string) error {
 	// Simulate extraction of video properties
 	video.Duration = 120.5 // Example: 2 minutes and 30 seconds
 	video.Resolution = "1920x1080"
+	if err := s.videoRepo.Update(video); err != nil {
+		return err
+	}
 
 	// Update processing state to completed
-	video.ProcessingState = "completed"
-	video.UpdatedAt = time.Now()
-
-	return s.videoRepo.Update(video)
+	_, _, err = s.states.transition(video, models.StateCompleted, StateChange{Actor: ActorVideoProcessing, Reason: "video processed"})
+	return err
 }
 
 /**
@@ -279,29 +460,37 @@ func isValidVideoType(filename string) bool {
 
 /**
  * generateStoragePath creates a unique path for storing the video.
- * Typically organizes files by date, type, etc. for easy management.
+ * Uses the path strategy's directory for the match.
  *
- * @param metadata The video metadata
- * @return Storage path for the video
+ * @param strategy The storage path strategy
+ * @param metadata Video metadata including ID and match information
+ * @return A storage path for the video
  */
-func generateStoragePath(metadata *models.Video) string {
-	// Generate path based on date and ID
-	datePrefix := time.Now().Format("2006/01/02")
-	fileName := metadata.ID + filepath.Ext(metadata.FilePath)
-
-	// If this is associated with a match, include match info in path
-	if metadata.MatchID != "" {
-		return filepath.Join("videos", "matches", metadata.MatchID, datePrefix, fileName)
-	}
-
-	return filepath.Join("videos", "uploads", datePrefix, fileName)
+func generateStoragePath(strategy PathStrategy, metadata *models.Video) string {
+	dir := strategy.Dir(StoragePathInfo{VideoID: metadata.ID, UploadedAt: metadata.CreatedAt})
+	return filepath.Join(dir, MatchFileName(metadata.ID, models.FileKindVideo, metadata.FilePath))
 }
 
-func (s *DefaultVideoService) CreateVideoEntry(metadata *models.Video) (*models.Video, error) {
+/**
+ * CreateVideoEntry saves the metadata of a match whose files are stored,
+ * or of a draft awaiting them. Without a processing state the match starts
+ * "pending".
+ *
+ * @param metadata The match metadata, with its ID set
+ * @param change Who created the match, for the state history
+ * @return The saved match, or an error
+ */
+func (s *DefaultVideoService) CreateVideoEntry(metadata *models.Video, change StateChange) (*models.Video, error) {
 	if metadata.ID == "" {
 		// Or generate UUID here if not already set by controller
 		return nil, errors.New("metadata ID is required")
 	}
+	if metadata.ProcessingState == "" {
+		metadata.ProcessingState = models.StatePending
+	}
+	if err := models.ProcessingState("").CheckTransition(metadata.ProcessingState); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidVideo, err)
+	}
 	metadata.UpdatedAt = time.Now()
 	// CreatedAt should already be set by the controller
 	// StorageProvider might also be set by controller after uploading main video file
@@ -309,10 +498,229 @@ func (s *DefaultVideoService) CreateVideoEntry(metadata *models.Video) (*models.
 	if err := s.videoRepo.Create(metadata); err != nil {
 		return nil, err
 	}
+	s.states.created(metadata, change)
+
+	// A draft has no files yet; it is uploaded once submitted
+	if metadata.ProcessingState != models.StateDraft {
+		s.eventBus.Publish(events.New(events.VideoUploaded, videoEventData(metadata)))
+	}
 	return metadata, nil
 }
 
+/**
+ * UpdateProcessingState moves a video to a new processing state and
+ * records the change in the state history. Transitions into
+ * "pending_analytics" (reprocessing), "completed", "failed" or "cancelled"
+ * publish the corresponding analytics event; a submitted draft is
+ * published as uploaded first.
+ *
+ * @param id The unique ID of the video
+ * @param state The new processing state
+ * @param change Who changes the state and why
+ * @return Error if the video cannot be found or updated, or wrapping
+ *         models.ErrInvalidTransition if the state machine does not allow the change
+ */
+func (s *DefaultVideoService) UpdateProcessingState(id string, state models.ProcessingState, change StateChange) error {
+	video, err := s.GetVideoByID(id)
+	if err != nil {
+		return err
+	}
+	from, changed, err := s.states.transition(video, state, change)
+	if err != nil || !changed {
+		return err
+	}
+
+	if from == models.StateDraft {
+		s.eventBus.Publish(events.New(events.VideoUploaded, videoEventData(video)))
+	}
+	switch state {
+	case models.StatePendingAnalytics:
+		s.eventBus.Publish(events.New(events.AnalyticsRequested, videoEventData(video)))
+	case models.StateCancelled:
+		s.eventBus.Publish(events.New(events.AnalyticsCancelled, videoEventData(video)))
+	case models.StateCompleted:
+		s.eventBus.Publish(events.New(events.AnalyticsCompleted, videoEventData(video)))
+	case models.StateFailed:
+		s.eventBus.Publish(events.New(events.AnalyticsFailed, videoEventData(video)))
+	}
+	return nil
+}
+
+/**
+ * StateOverride is a manual correction of a match's processing state and
+ * file paths, for recovering from operational incidents. Nil paths are
+ * kept; an empty state keeps the state.
+ */
+type StateOverride struct {
+	State         models.ProcessingState
+	FilePath      *string
+	TrackingPath  *string
+	EventFilePath *string
+}
+
+// overrideReason marks manual overrides in the state history
+const overrideReason = "manual override: "
+
+/**
+ * OverrideState sets a video's processing state and file paths as an admin
+ * corrects them. Unlike UpdateProcessingState, the state machine is not
+ * consulted and no events are published, so nothing starts processing; the
+ * override is recorded in the state history with its reason. Re-pointed
+ * files are recorded without a checksum, which is unknown.
+ *
+ * @param id The unique ID of the video
+ * @param override The new state and paths
+ * @param change Who overrides the state and why
+ * @return The updated video, ErrVideoNotFound, ErrInvalidVideo for an unknown state,
+ *         or models.ErrStateConflict when the state changed since it was read
+ */
+func (s *DefaultVideoService) OverrideState(id string, override StateOverride, change StateChange) (*models.Video, error) {
+	if override.State != "" && !override.State.Valid() {
+		return nil, fmt.Errorf("%w: unknown processing state %q", ErrInvalidVideo, override.State)
+	}
+	video, err := s.GetVideoByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	updated := *video
+	if override.State != "" {
+		updated.ProcessingState = override.State
+	}
+	var files []*models.VideoFile
+	var repointed []string
+	for _, path := range []struct {
+		kind, field string
+		current     *string
+		value       *string
+	}{
+		{models.FileKindVideo, "file_path", &updated.FilePath, override.FilePath},
+		{models.FileKindTracking, "tracking_path", &updated.TrackingPath, override.TrackingPath},
+		{models.FileKindEvents, "event_file_path", &updated.EventFilePath, override.EventFilePath},
+	} {
+		if path.value == nil || *path.value == *path.current {
+			continue
+		}
+		*path.current = *path.value
+		repointed = append(repointed, path.field)
+		if *path.value != "" {
+			files = append(files, &models.VideoFile{Kind: path.kind, Path: *path.value})
+		}
+	}
+	if override.FilePath != nil && updated.FilePath != video.FilePath {
+		updated.Format = strings.TrimPrefix(filepath.Ext(updated.FilePath), ".")
+	}
+	if updated.ProcessingState == video.ProcessingState && len(repointed) == 0 {
+		return video, nil
+	}
+
+	updated.UpdatedAt = s.states.now()
+	if updated.ProcessingState != video.ProcessingState {
+		if err := s.videoRepo.UpdateProcessingState(id, video.ProcessingState, updated.ProcessingState, updated.UpdatedAt); err != nil {
+			return nil, err
+		}
+	}
+	if len(repointed) > 0 {
+		if err := s.videoRepo.Update(&updated); err != nil {
+			return nil, err
+		}
+	}
+	change.Reason = overrideReason + change.Reason
+	if len(repointed) > 0 {
+		change.Reason += " (re-pointed " + strings.Join(repointed, ", ") + ")"
+	}
+	s.states.record(id, video.ProcessingState, updated.ProcessingState, change, updated.UpdatedAt)
+	if err := s.RecordVideoFiles(id, files); err != nil {
+		log.Printf("Warning: Failed to record re-pointed files of video %s: %v", id, err)
+	}
+	return &updated, nil
+}
+
+/**
+ * GetStateHistory returns the processing state changes of a video, oldest
+ * first; empty without a state history.
+ *
+ * @param id The unique ID of the video
+ * @return The state changes, or ErrVideoNotFound
+ */
+func (s *DefaultVideoService) GetStateHistory(id string) ([]*models.StateTransition, error) {
+	if _, err := s.GetVideoByID(id); err != nil {
+		return nil, err
+	}
+	if s.states.history == nil {
+		return []*models.StateTransition{}, nil
+	}
+	return s.states.history.FindByVideoID(id)
+}
+
+/**
+ * RejectVideo quarantines a video whose files contain malware: its state
+ * becomes "rejected", so it is never analysed or served, and a
+ * video.rejected event reports the detected threats.
+ *
+ * @param id The unique ID of the video
+ * @param threats Detected threat signatures keyed by file kind
+ * @return Error if the video cannot be found or updated
+ */
+func (s *DefaultVideoService) RejectVideo(id string, threats map[string]string) error {
+	video, err := s.GetVideoByID(id)
+	if err != nil {
+		return err
+	}
+
+	if _, _, err := s.states.transition(video, models.StateRejected, StateChange{Actor: ActorMalwareScan, Reason: "malware detected"}); err != nil {
+		return err
+	}
+
+	data := videoEventData(video)
+	data["threats"] = threats
+	s.eventBus.Publish(events.New(events.VideoRejected, data))
+	return nil
+}
+
+/**
+ * RecordVideoFiles stores size and checksum records for a video's files.
+ * It is a no-op when no file repository is configured.
+ *
+ * @param id The unique ID of the video
+ * @param files The stored files to record
+ * @return Error if a record cannot be saved
+ */
+func (s *DefaultVideoService) RecordVideoFiles(id string, files []*models.VideoFile) error {
+	if s.fileRepo == nil {
+		return nil
+	}
+
+	now := time.Now()
+	for _, file := range files {
+		file.VideoID = id
+		file.CreatedAt = now
+		file.UpdatedAt = now
+		if err := s.fileRepo.Save(file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isNotFound reports whether a repository error signals a missing record.
+func isNotFound(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "not found")
+}
+
+// videoEventData builds the event payload describing a video/match.
+func videoEventData(video *models.Video) map[string]interface{} {
+	return map[string]interface{}{
+		"video_id":         video.ID,
+		"title":            video.Title,
+		"match_id":         video.MatchID,
+		"home_team":        video.HomeTeam,
+		"away_team":        video.AwayTeam,
+		"processing_state": video.ProcessingState,
+	}
+}
+
 // GenerateStoragePathForTesting provides access to the unexported generateStoragePath for testing purposes.
 func GenerateStoragePathForTesting(metadata *models.Video) string {
-	return generateStoragePath(metadata)
+	return generateStoragePath(IDShardStrategy{}, metadata)
 }
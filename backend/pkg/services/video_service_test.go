@@ -2,6 +2,7 @@ package services_test
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"io"
 	"mime/multipart"
@@ -43,10 +44,18 @@ func (m *MockVideoRepository) Update(video *models.Video) error {
 	args := m.Called(video)
 	return args.Error(0)
 }
+func (m *MockVideoRepository) UpdateProcessingState(id string, from, to models.ProcessingState, updatedAt time.Time) error {
+	args := m.Called(id, from, to, updatedAt)
+	return args.Error(0)
+}
 func (m *MockVideoRepository) Delete(id string) error {
 	args := m.Called(id)
 	return args.Error(0)
 }
+func (m *MockVideoRepository) Undelete(id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
 func (m *MockVideoRepository) FindByMatchID(matchID string) ([]*models.Video, error) {
 	args := m.Called(matchID)
 	if args.Get(0) == nil {
@@ -76,6 +85,18 @@ func (m *MockVideoRepository) FindByProcessingState(state string, limit, offset
 	return args.Get(0).([]*models.Video), args.Error(1)
 }
 
+func (m *MockVideoRepository) FindByQuery(query models.VideoQuery) ([]*models.Video, error) {
+	args := m.Called(query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Video), args.Error(1)
+}
+
+func (m *MockVideoRepository) WithContext(ctx context.Context) models.VideoRepository {
+	return m
+}
+
 // --- MockStorageService for video_service_test ---
 type MockStorageService struct {
 	mock.Mock
@@ -115,6 +136,25 @@ func (m *MockStorageService) GetFileMetadata(path string) (map[string]string, er
 	}
 	return args.Get(0).(map[string]string), args.Error(1)
 }
+func (m *MockStorageService) ListFiles(prefix string) ([]services.FileInfo, error) {
+	args := m.Called(prefix)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]services.FileInfo), args.Error(1)
+}
+func (m *MockStorageService) Exists(path string) (bool, error) {
+	args := m.Called(path)
+	return args.Bool(0), args.Error(1)
+}
+func (m *MockStorageService) CopyFile(src, dst string) error {
+	args := m.Called(src, dst)
+	return args.Error(0)
+}
+func (m *MockStorageService) MoveFile(src, dst string) error {
+	args := m.Called(src, dst)
+	return args.Error(0)
+}
 
 // Helper to create a dummy multipart.File for testing UploadVideo
 type mockMultipartFileVS struct { // Renamed to avoid conflict if in same package for testing
@@ -188,7 +228,7 @@ func TestDefaultVideoService_ListVideos(t *testing.T) {
 	expectedVideos := []*models.Video{{ID: "vid1"}, {ID: "vid2"}}
 
 	t.Run("No filters", func(t *testing.T) {
-		mockRepo.On("FindAll", 10, 0).Return(expectedVideos, nil).Once()
+		mockRepo.On("FindByQuery", models.VideoQuery{Limit: 10}).Return(expectedVideos, nil).Once()
 		videos, err := videoService.ListVideos(0, 0, make(map[string]string))
 		require.NoError(t, err)
 		assert.Equal(t, expectedVideos, videos)
@@ -197,33 +237,45 @@ func TestDefaultVideoService_ListVideos(t *testing.T) {
 
 	t.Run("With match_id filter", func(t *testing.T) {
 		filters := map[string]string{"match_id": "match123"}
-		mockRepo.On("FindByMatchID", "match123").Return(expectedVideos, nil).Once()
-		videos, err := videoService.ListVideos(10, 0, filters) // limit, offset might be ignored by FindByMatchID in some impls
+		mockRepo.On("FindByQuery", models.VideoQuery{MatchID: "match123", Limit: 10}).Return(expectedVideos, nil).Once()
+		videos, err := videoService.ListVideos(10, 0, filters)
 		require.NoError(t, err)
 		assert.Equal(t, expectedVideos, videos)
 		mockRepo.AssertExpectations(t)
 	})
 
-	t.Run("With team filter", func(t *testing.T) {
-		filters := map[string]string{"team": "TeamX"}
-		mockRepo.On("FindByTeam", "TeamX", 10, 0).Return(expectedVideos, nil).Once()
-		videos, err := videoService.ListVideos(10, 0, filters)
+	t.Run("Filters are combined", func(t *testing.T) {
+		filters := map[string]string{
+			"team":             "TeamX",
+			"competition":      "Eredivisie",
+			"season":           "2024",
+			"processing_state": "completed",
+			"from":             "2024-08-01T00:00:00Z",
+			"to":               "2024-08-31T23:59:59Z",
+		}
+		mockRepo.On("FindByQuery", models.VideoQuery{
+			Team:            "TeamX",
+			Competition:     "Eredivisie",
+			Season:          "2024",
+			ProcessingState: "completed",
+			MatchDateFrom:   time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC),
+			MatchDateTo:     time.Date(2024, 8, 31, 23, 59, 59, 0, time.UTC),
+			Limit:           10,
+			Offset:          20,
+		}).Return(expectedVideos, nil).Once()
+		videos, err := videoService.ListVideos(10, 20, filters)
 		require.NoError(t, err)
 		assert.Equal(t, expectedVideos, videos)
 		mockRepo.AssertExpectations(t)
 	})
 
-	t.Run("With processing_state filter", func(t *testing.T) {
-		filters := map[string]string{"processing_state": "completed"}
-		mockRepo.On("FindByProcessingState", "completed", 10, 0).Return(expectedVideos, nil).Once()
-		videos, err := videoService.ListVideos(10, 0, filters)
-		require.NoError(t, err)
-		assert.Equal(t, expectedVideos, videos)
-		mockRepo.AssertExpectations(t)
+	t.Run("Invalid dates are rejected", func(t *testing.T) {
+		_, err := videoService.ListVideos(10, 0, map[string]string{"from": "yesterday"})
+		assert.ErrorContains(t, err, "invalid date")
 	})
 
-	t.Run("Repository FindAll error", func(t *testing.T) {
-		mockRepo.On("FindAll", 10, 0).Return(nil, errors.New("db error")).Once()
+	t.Run("Repository error", func(t *testing.T) {
+		mockRepo.On("FindByQuery", models.VideoQuery{Limit: 10}).Return(nil, errors.New("db error")).Once()
 		_, err := videoService.ListVideos(0, 0, make(map[string]string))
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "db error")
@@ -270,9 +322,10 @@ func TestDefaultVideoService_UploadVideo(t *testing.T) {
 		// Mocks for ProcessVideo goroutine
 		// FindByID will be called by ProcessVideo
 		mockRepo.On("FindByID", videoMetaWithExtension.ID).Return(&freshVideoFromCreate, nil).Maybe() // Maybe, as timing of goroutine is not guaranteed in test
-		// Update will be called twice by ProcessVideo
+		// ProcessVideo moves the state twice and saves the video properties once
+		mockRepo.On("UpdateProcessingState", videoMetaWithExtension.ID, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
 		mockRepo.On("Update", mock.MatchedBy(func(v *models.Video) bool {
-			return v.ID == videoMetaWithExtension.ID && (v.ProcessingState == "processing" || v.ProcessingState == "completed")
+			return v.ID == videoMetaWithExtension.ID
 		})).Return(nil).Maybe()
 
 		createdVideo, err := videoService.UploadVideo(mockFile, mockHeader, videoMetaWithExtension)
@@ -434,23 +487,20 @@ func TestDefaultVideoService_GetVideoStreamURL(t *testing.T) {
 
 func TestDefaultVideoService_ProcessVideo(t *testing.T) {
 	videoID := "processVid1"
-	initialVideoState := &models.Video{ID: videoID, ProcessingState: "pending"}
+	// A fresh video per subtest, since processing updates it in place
+	initialVideoState := func() *models.Video { return &models.Video{ID: videoID, ProcessingState: "pending"} }
 
 	t.Run("Success", func(t *testing.T) {
 		mockRepo := new(MockVideoRepository)
 		mockStorage := new(MockStorageService)
 		videoService := services.NewVideoService(mockRepo, mockStorage)
 
-		mockRepo.On("FindByID", videoID).Return(initialVideoState, nil).Once()
-		mockRepo.On("Update", mock.MatchedBy(func(v *models.Video) bool {
-			return v.ID == videoID && v.ProcessingState == "processing"
-		})).Return(nil).Once()
+		mockRepo.On("FindByID", videoID).Return(initialVideoState(), nil).Once()
+		mockRepo.On("UpdateProcessingState", videoID, models.StatePending, models.StateProcessing, mock.Anything).Return(nil).Once()
 		mockRepo.On("Update", mock.MatchedBy(func(v *models.Video) bool {
-			return v.ID == videoID &&
-				v.ProcessingState == "completed" &&
-				v.Duration == 120.5 &&
-				v.Resolution == "1920x1080"
+			return v.ID == videoID && v.Duration == 120.5 && v.Resolution == "1920x1080"
 		})).Return(nil).Once()
+		mockRepo.On("UpdateProcessingState", videoID, models.StateProcessing, models.StateCompleted, mock.Anything).Return(nil).Once()
 
 		err := videoService.ProcessVideo(videoID)
 		require.NoError(t, err)
@@ -467,7 +517,7 @@ func TestDefaultVideoService_ProcessVideo(t *testing.T) {
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "repo: not found")
 		mockRepo.AssertExpectations(t)
-		mockRepo.AssertNotCalled(t, "Update", mock.Anything)
+		mockRepo.AssertNotCalled(t, "UpdateProcessingState", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
 	})
 
 	t.Run("First Update fails", func(t *testing.T) {
@@ -475,16 +525,16 @@ func TestDefaultVideoService_ProcessVideo(t *testing.T) {
 		mockStorage := new(MockStorageService)
 		videoService := services.NewVideoService(mockRepo, mockStorage)
 
-		mockRepo.On("FindByID", videoID).Return(initialVideoState, nil).Once()
-		mockRepo.On("Update", mock.MatchedBy(func(v *models.Video) bool {
-			return v.ID == videoID && v.ProcessingState == "processing"
-		})).Return(errors.New("db error on first update")).Once()
+		mockRepo.On("FindByID", videoID).Return(initialVideoState(), nil).Once()
+		mockRepo.On("UpdateProcessingState", videoID, models.StatePending, models.StateProcessing, mock.Anything).
+			Return(errors.New("db error on first update")).Once()
 
 		err := videoService.ProcessVideo(videoID)
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "db error on first update")
 		mockRepo.AssertExpectations(t)
-		mockRepo.AssertNumberOfCalls(t, "Update", 1)
+		mockRepo.AssertNumberOfCalls(t, "UpdateProcessingState", 1)
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything)
 	})
 
 	t.Run("Second Update fails", func(t *testing.T) {
@@ -492,19 +542,17 @@ func TestDefaultVideoService_ProcessVideo(t *testing.T) {
 		mockStorage := new(MockStorageService)
 		videoService := services.NewVideoService(mockRepo, mockStorage)
 
-		mockRepo.On("FindByID", videoID).Return(initialVideoState, nil).Once()
-		mockRepo.On("Update", mock.MatchedBy(func(v *models.Video) bool {
-			return v.ID == videoID && v.ProcessingState == "processing"
-		})).Return(nil).Once()
-		mockRepo.On("Update", mock.MatchedBy(func(v *models.Video) bool {
-			return v.ID == videoID && v.ProcessingState == "completed"
-		})).Return(errors.New("db error on second update")).Once()
+		mockRepo.On("FindByID", videoID).Return(initialVideoState(), nil).Once()
+		mockRepo.On("UpdateProcessingState", videoID, models.StatePending, models.StateProcessing, mock.Anything).Return(nil).Once()
+		mockRepo.On("Update", mock.Anything).Return(nil).Once()
+		mockRepo.On("UpdateProcessingState", videoID, models.StateProcessing, models.StateCompleted, mock.Anything).
+			Return(errors.New("db error on second update")).Once()
 
 		err := videoService.ProcessVideo(videoID)
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "db error on second update")
 		mockRepo.AssertExpectations(t)
-		mockRepo.AssertNumberOfCalls(t, "Update", 2)
+		mockRepo.AssertNumberOfCalls(t, "UpdateProcessingState", 2)
 	})
 }
 
@@ -520,7 +568,7 @@ func TestDefaultVideoService_CreateVideoEntry(t *testing.T) {
 			return v.ID == videoMeta.ID && v.Title == videoMeta.Title && !v.UpdatedAt.IsZero()
 		})).Return(nil).Once()
 
-		createdVideo, err := videoService.CreateVideoEntry(videoMeta)
+		createdVideo, err := videoService.CreateVideoEntry(videoMeta, services.StateChange{Actor: "user-1"})
 		require.NoError(t, err)
 		assert.Equal(t, videoMeta, createdVideo)
 		assert.False(t, createdVideo.UpdatedAt.IsZero(), "UpdatedAt should be set by the service")
@@ -534,7 +582,7 @@ func TestDefaultVideoService_CreateVideoEntry(t *testing.T) {
 
 		mockRepo.On("Create", mock.AnythingOfType("*models.Video")).Return(errors.New("db unique constraint failed")).Once()
 
-		_, err := videoService.CreateVideoEntry(videoMeta)
+		_, err := videoService.CreateVideoEntry(videoMeta, services.StateChange{Actor: "user-1"})
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "db unique constraint failed")
 		mockRepo.AssertExpectations(t)
@@ -546,9 +594,151 @@ func TestDefaultVideoService_CreateVideoEntry(t *testing.T) {
 		videoService := services.NewVideoService(mockRepo, mockStorage)
 
 		metaNoID := &models.Video{Title: "Test No ID"}
-		_, err := videoService.CreateVideoEntry(metaNoID)
+		_, err := videoService.CreateVideoEntry(metaNoID, services.StateChange{})
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "metadata ID is required")
 		mockRepo.AssertNotCalled(t, "Create", mock.Anything)
 	})
 }
+
+func TestDefaultVideoService_StateHistory(t *testing.T) {
+	videos := models.NewMemoryVideoRepository()
+	history := models.NewMemoryStateHistoryRepository()
+	videoService := services.NewVideoService(videos, new(MockStorageService), services.WithStateHistory(history))
+
+	_, err := videoService.CreateVideoEntry(&models.Video{ID: "v1", ProcessingState: models.StatePendingAnalytics},
+		services.StateChange{Actor: "user-1", Reason: "upload completed"})
+	require.NoError(t, err)
+	require.NoError(t, videoService.UpdateProcessingState("v1", models.StateCompleted,
+		services.StateChange{Actor: services.ActorAnalytics, Reason: "analytics status processed"}))
+
+	t.Run("Invalid transitions are refused", func(t *testing.T) {
+		err := videoService.UpdateProcessingState("v1", models.StateProcessing, services.StateChange{Actor: "user-1"})
+		assert.ErrorIs(t, err, models.ErrInvalidTransition)
+		video, err := videoService.GetVideoByID("v1")
+		require.NoError(t, err)
+		assert.Equal(t, models.StateCompleted, video.ProcessingState)
+	})
+
+	t.Run("Repeating the current state records nothing", func(t *testing.T) {
+		require.NoError(t, videoService.UpdateProcessingState("v1", models.StateCompleted, services.StateChange{}))
+	})
+
+	t.Run("History lists who changed the state and why", func(t *testing.T) {
+		transitions, err := videoService.GetStateHistory("v1")
+		require.NoError(t, err)
+		require.Len(t, transitions, 2)
+		assert.Equal(t, models.ProcessingState(""), transitions[0].From)
+		assert.Equal(t, models.StatePendingAnalytics, transitions[0].To)
+		assert.Equal(t, "user-1", transitions[0].Actor)
+		assert.Equal(t, models.StateCompleted, transitions[1].To)
+		assert.Equal(t, services.ActorAnalytics, transitions[1].Actor)
+		assert.Equal(t, "analytics status processed", transitions[1].Reason)
+		assert.False(t, transitions[1].CreatedAt.IsZero())
+
+		_, err = videoService.GetStateHistory("missing")
+		assert.ErrorIs(t, err, services.ErrVideoNotFound)
+	})
+}
+
+// racingVideoRepository changes a video's state right after it is first
+// read, as a concurrent writer would.
+type racingVideoRepository struct {
+	*models.MemoryVideoRepository
+	race func(video *models.Video)
+}
+
+func (r *racingVideoRepository) FindByID(id string) (*models.Video, error) {
+	video, err := r.MemoryVideoRepository.FindByID(id)
+	if err == nil && r.race != nil {
+		race := r.race
+		r.race = nil
+		race(video)
+	}
+	return video, err
+}
+
+func TestDefaultVideoService_ConcurrentStateChanges(t *testing.T) {
+	setup := func(t *testing.T, to models.ProcessingState) (*models.MemoryStateHistoryRepository, services.VideoService) {
+		videos := &racingVideoRepository{MemoryVideoRepository: models.NewMemoryVideoRepository()}
+		history := models.NewMemoryStateHistoryRepository()
+		require.NoError(t, videos.Create(&models.Video{ID: "v1", ProcessingState: models.StatePendingAnalytics}))
+		videos.race = func(video *models.Video) {
+			require.NoError(t, videos.UpdateProcessingState(video.ID, video.ProcessingState, to, time.Now()))
+		}
+		return history, services.NewVideoService(videos, new(MockStorageService), services.WithStateHistory(history))
+	}
+
+	t.Run("A transition the concurrent change made invalid is refused", func(t *testing.T) {
+		history, videoService := setup(t, models.StateCancelled)
+
+		err := videoService.UpdateProcessingState("v1", models.StateCompleted, services.StateChange{Actor: services.ActorAnalytics})
+		assert.ErrorIs(t, err, models.ErrInvalidTransition)
+		video, err := videoService.GetVideoByID("v1")
+		require.NoError(t, err)
+		assert.Equal(t, models.StateCancelled, video.ProcessingState, "the cancellation is not overwritten")
+		transitions, err := history.FindByVideoID("v1")
+		require.NoError(t, err)
+		assert.Empty(t, transitions, "a refused transition records no history")
+	})
+
+	t.Run("A transition still valid is checked and recorded from the new state", func(t *testing.T) {
+		history, videoService := setup(t, models.StateProcessing)
+
+		require.NoError(t, videoService.UpdateProcessingState("v1", models.StateCompleted, services.StateChange{Actor: services.ActorAnalytics}))
+		transitions, err := history.FindByVideoID("v1")
+		require.NoError(t, err)
+		require.Len(t, transitions, 1)
+		assert.Equal(t, models.StateProcessing, transitions[0].From)
+		assert.Equal(t, models.StateCompleted, transitions[0].To)
+	})
+}
+
+func TestDefaultVideoService_OverrideState(t *testing.T) {
+	setup := func(t *testing.T) (*models.MemoryStateHistoryRepository, *models.MemoryVideoFileRepository, services.VideoService) {
+		videos := models.NewMemoryVideoRepository()
+		history := models.NewMemoryStateHistoryRepository()
+		files := models.NewMemoryVideoFileRepository()
+		videoService := services.NewVideoService(videos, new(MockStorageService), services.WithStateHistory(history), services.WithFileRepository(files))
+		require.NoError(t, videos.Create(&models.Video{ID: "v1", ProcessingState: models.StateProcessing, TrackingPath: "videos/v1/t.gzip", EventFilePath: "videos/v1/e.gzip"}))
+		return history, files, videoService
+	}
+	path := func(p string) *string { return &p }
+
+	t.Run("Transitions the state machine refuses are forced and recorded", func(t *testing.T) {
+		history, files, videoService := setup(t)
+
+		video, err := videoService.OverrideState("v1", services.StateOverride{State: models.StateCompleted, TrackingPath: path("restored/v1/t.gzip")},
+			services.StateChange{Actor: "admin-1", Reason: "analytics finished during the outage"})
+		require.NoError(t, err)
+		assert.Equal(t, models.StateCompleted, video.ProcessingState)
+		assert.Equal(t, "restored/v1/t.gzip", video.TrackingPath)
+		assert.Equal(t, "videos/v1/e.gzip", video.EventFilePath, "omitted paths are kept")
+
+		transitions, err := history.FindByVideoID("v1")
+		require.NoError(t, err)
+		require.Len(t, transitions, 1)
+		assert.Equal(t, models.StateProcessing, transitions[0].From)
+		assert.Equal(t, models.StateCompleted, transitions[0].To)
+		assert.Equal(t, "admin-1", transitions[0].Actor)
+		assert.Equal(t, "manual override: analytics finished during the outage (re-pointed tracking_path)", transitions[0].Reason)
+		recorded, err := files.FindByVideoID("v1")
+		require.NoError(t, err)
+		require.Len(t, recorded, 1)
+		assert.Equal(t, "restored/v1/t.gzip", recorded[0].Path)
+		assert.Empty(t, recorded[0].Checksum)
+
+		_, err = videoService.OverrideState("v1", services.StateOverride{State: models.StateCompleted}, services.StateChange{Actor: "admin-1", Reason: "again"})
+		require.NoError(t, err)
+		transitions, _ = history.FindByVideoID("v1")
+		assert.Len(t, transitions, 1, "an override changing nothing records nothing")
+	})
+
+	t.Run("Unknown states and videos are refused", func(t *testing.T) {
+		_, _, videoService := setup(t)
+		_, err := videoService.OverrideState("v1", services.StateOverride{State: "done"}, services.StateChange{Reason: "x"})
+		assert.ErrorIs(t, err, services.ErrInvalidVideo)
+		_, err = videoService.OverrideState("missing", services.StateOverride{State: models.StateFailed}, services.StateChange{Reason: "x"})
+		assert.ErrorIs(t, err, services.ErrVideoNotFound)
+	})
+}
@@ -0,0 +1,511 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"nivai/backend/pkg/events"
+	"nivai/backend/pkg/httpclient"
+	"nivai/backend/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// Webhook service errors
+var (
+	ErrWebhookNotFound     = errors.New("webhook not found")
+	ErrInvalidWebhookURL   = errors.New("webhook url must be an absolute http or https url")
+	ErrInvalidWebhookEvent = errors.New("unknown webhook event type")
+)
+
+// Headers sent with every webhook delivery
+const (
+	WebhookEventHeader     = "X-NIVAI-Event"
+	WebhookDeliveryHeader  = "X-NIVAI-Delivery"
+	WebhookTimestampHeader = "X-NIVAI-Timestamp"
+	WebhookSignatureHeader = "X-NIVAI-Signature"
+)
+
+/**
+ * WebhookService defines the interface for managing webhook subscriptions.
+ */
+type WebhookService interface {
+	CreateWebhook(rawURL, secret string, eventTypes []string) (*models.Webhook, error)
+	ListWebhooks() ([]*models.Webhook, error)
+	GetWebhook(id string) (*models.Webhook, error)
+	DeleteWebhook(id string) error
+	ListDeliveries(webhookID string, limit, offset int) ([]*models.WebhookDelivery, error)
+}
+
+/**
+ * DefaultWebhookService implements WebhookService on top of a WebhookRepository.
+ */
+type DefaultWebhookService struct {
+	repo          models.WebhookRepository
+	allowInternal bool
+	lookup        func(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+/**
+ * WebhookServiceOption configures optional behaviour of the webhook service.
+ */
+type WebhookServiceOption func(*DefaultWebhookService)
+
+/**
+ * WithInternalWebhookTargets lets webhooks point at loopback, private and
+ * link-local addresses, for receivers on the internal network. Deliveries
+ * to them also need the webhooks HTTP client's public_only turned off.
+ *
+ * @return The option
+ */
+func WithInternalWebhookTargets() WebhookServiceOption {
+	return func(s *DefaultWebhookService) {
+		s.allowInternal = true
+	}
+}
+
+/**
+ * WithWebhookResolver replaces the DNS lookup that webhook hosts are
+ * checked with.
+ *
+ * @param lookup Resolves a host name to its addresses
+ * @return The option
+ */
+func WithWebhookResolver(lookup func(ctx context.Context, host string) ([]net.IPAddr, error)) WebhookServiceOption {
+	return func(s *DefaultWebhookService) {
+		s.lookup = lookup
+	}
+}
+
+/**
+ * NewWebhookService creates a new webhook management service.
+ *
+ * @param repo Repository for webhook data access
+ * @param opts Optional behaviour
+ * @return A new webhook service implementation
+ */
+func NewWebhookService(repo models.WebhookRepository, opts ...WebhookServiceOption) WebhookService {
+	s := &DefaultWebhookService{repo: repo, lookup: net.DefaultResolver.LookupIPAddr}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+/**
+ * CreateWebhook validates and stores a new webhook subscription. URLs whose
+ * host is or resolves to a non-public address are refused, unless internal
+ * targets are allowed. A random secret is generated when none is supplied.
+ *
+ * @param rawURL Destination URL for deliveries
+ * @param secret Shared secret used for HMAC signatures (optional)
+ * @param eventTypes Event types to subscribe to
+ * @return The created webhook (with Secret populated), or an error
+ */
+func (s *DefaultWebhookService) CreateWebhook(rawURL, secret string, eventTypes []string) (*models.Webhook, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, ErrInvalidWebhookURL
+	}
+	if !s.allowInternal {
+		if err := s.checkPublicHost(parsed.Hostname()); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(eventTypes) == 0 {
+		return nil, fmt.Errorf("%w: at least one event type is required", ErrInvalidWebhookEvent)
+	}
+	for _, eventType := range eventTypes {
+		if !events.IsKnownType(eventType) {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidWebhookEvent, eventType)
+		}
+	}
+
+	if secret == "" {
+		secret, err = generateWebhookSecret()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	webhook := &models.Webhook{
+		ID:         uuid.New().String(),
+		URL:        parsed.String(),
+		Secret:     secret,
+		EventTypes: eventTypes,
+		Active:     true,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+
+	if err := s.repo.Create(webhook); err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+// webhookLookupTimeout bounds the DNS lookup of a new webhook's host.
+const webhookLookupTimeout = 5 * time.Second
+
+// checkPublicHost refuses webhook hosts that are, or resolve to, addresses
+// off the internet. Deliveries check the addresses dialed again, as DNS may
+// change after the webhook is created.
+func (s *DefaultWebhookService) checkPublicHost(host string) error {
+	var ips []net.IP
+	if ip := net.ParseIP(host); ip != nil {
+		ips = []net.IP{ip}
+	} else {
+		ctx, cancel := context.WithTimeout(context.Background(), webhookLookupTimeout)
+		defer cancel()
+		addrs, err := s.lookup(ctx, host)
+		if err != nil {
+			return fmt.Errorf("%w: host %s cannot be resolved", ErrInvalidWebhookURL, host)
+		}
+		for _, addr := range addrs {
+			ips = append(ips, addr.IP)
+		}
+	}
+	for _, ip := range ips {
+		if !httpclient.IsPublicIP(ip) {
+			return fmt.Errorf("%w: host %s is not a public address", ErrInvalidWebhookURL, host)
+		}
+	}
+	return nil
+}
+
+/**
+ * ListWebhooks returns all webhook subscriptions.
+ *
+ * @return A slice of webhooks, or an error
+ */
+func (s *DefaultWebhookService) ListWebhooks() ([]*models.Webhook, error) {
+	return s.repo.FindAll()
+}
+
+/**
+ * GetWebhook retrieves a single webhook subscription.
+ *
+ * @param id The webhook ID
+ * @return The webhook, or ErrWebhookNotFound
+ */
+func (s *DefaultWebhookService) GetWebhook(id string) (*models.Webhook, error) {
+	webhook, err := s.repo.FindByID(id)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, err
+	}
+	return webhook, nil
+}
+
+/**
+ * DeleteWebhook removes a webhook subscription. Pending deliveries for it
+ * are abandoned by the dispatcher.
+ *
+ * @param id The webhook ID
+ * @return Error if deletion fails
+ */
+func (s *DefaultWebhookService) DeleteWebhook(id string) error {
+	if err := s.repo.Delete(id); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return ErrWebhookNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+/**
+ * ListDeliveries returns the delivery log for a webhook.
+ *
+ * @param webhookID The webhook ID
+ * @param limit Maximum number of deliveries to return
+ * @param offset Number of deliveries to skip
+ * @return A slice of deliveries, or an error
+ */
+func (s *DefaultWebhookService) ListDeliveries(webhookID string, limit, offset int) ([]*models.WebhookDelivery, error) {
+	if _, err := s.GetWebhook(webhookID); err != nil {
+		return nil, err
+	}
+	return s.repo.FindDeliveriesByWebhook(webhookID, limit, offset)
+}
+
+/**
+ * WebhookDispatcherConfig controls delivery retries and polling.
+ */
+type WebhookDispatcherConfig struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	PollInterval   time.Duration
+	BatchSize      int
+	Events         *events.Bus // Told of deliveries given up, as events.WebhookDeliveryFailed; optional
+}
+
+/**
+ * WebhookDispatcher turns published events into webhook deliveries and
+ * delivers them with HMAC signatures and exponential backoff retries.
+ * Deliveries are persisted first, so they survive restarts.
+ */
+type WebhookDispatcher struct {
+	repo   models.WebhookRepository
+	client *http.Client
+	config WebhookDispatcherConfig
+	now    func() time.Time
+}
+
+/**
+ * NewWebhookDispatcher creates a new webhook dispatcher.
+ * Zero-valued config fields are replaced by sensible defaults.
+ *
+ * @param repo Repository for webhook data access
+ * @param client HTTP client used for deliveries
+ * @param config Retry and polling configuration
+ * @return A new webhook dispatcher
+ */
+func NewWebhookDispatcher(repo models.WebhookRepository, client *http.Client, config WebhookDispatcherConfig) *WebhookDispatcher {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 8
+	}
+	if config.InitialBackoff <= 0 {
+		config.InitialBackoff = 10 * time.Second
+	}
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = time.Hour
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = 5 * time.Second
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 50
+	}
+	return &WebhookDispatcher{
+		repo:   repo,
+		client: client,
+		config: config,
+		now:    time.Now,
+	}
+}
+
+/**
+ * HandleEvent enqueues a delivery for every active webhook subscribed to
+ * the event. Intended to be registered as a wildcard handler on the bus.
+ *
+ * @param event The published event
+ */
+func (d *WebhookDispatcher) HandleEvent(event events.Event) {
+	if event.Type == events.WebhookDeliveryFailed {
+		return
+	}
+	webhooks, err := d.repo.FindActiveByEventType(event.Type)
+	if err != nil {
+		log.Printf("Webhooks: failed to look up subscribers for %s: %v", event.Type, err)
+		return
+	}
+	if len(webhooks) == 0 {
+		return
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("Webhooks: failed to marshal event %s: %v", event.ID, err)
+		return
+	}
+
+	now := d.now()
+	for _, webhook := range webhooks {
+		delivery := &models.WebhookDelivery{
+			ID:            uuid.New().String(),
+			WebhookID:     webhook.ID,
+			EventID:       event.ID,
+			EventType:     event.Type,
+			Payload:       payload,
+			Status:        models.DeliveryStatusPending,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		}
+		if err := d.repo.CreateDelivery(delivery); err != nil {
+			log.Printf("Webhooks: failed to enqueue delivery of %s to webhook %s: %v", event.ID, webhook.ID, err)
+		}
+	}
+}
+
+/**
+ * Run polls for due deliveries until the context is cancelled.
+ * Should be run in a goroutine.
+ *
+ * @param ctx Context controlling the worker lifetime
+ */
+func (d *WebhookDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := d.DeliverDue(ctx); err != nil {
+				log.Printf("Webhooks: delivery pass failed: %v", err)
+			}
+		}
+	}
+}
+
+/**
+ * DeliverDue performs one delivery pass over all due deliveries.
+ *
+ * @param ctx Context for outgoing requests
+ * @return Number of deliveries attempted, or an error
+ */
+func (d *WebhookDispatcher) DeliverDue(ctx context.Context) (int, error) {
+	deliveries, err := d.repo.FindDueDeliveries(d.now(), d.config.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, delivery := range deliveries {
+		d.attempt(ctx, delivery)
+	}
+	return len(deliveries), nil
+}
+
+// attempt sends a single delivery and records the outcome.
+func (d *WebhookDispatcher) attempt(ctx context.Context, delivery *models.WebhookDelivery) {
+	webhook, err := d.repo.FindByID(delivery.WebhookID)
+	if err != nil || !webhook.Active {
+		// Subscription was removed; stop retrying.
+		delivery.Status = models.DeliveryStatusFailed
+		delivery.LastError = "webhook no longer active"
+		d.save(delivery)
+		return
+	}
+
+	delivery.Attempts++
+	statusCode, sendErr := d.send(ctx, webhook, delivery)
+	delivery.LastStatusCode = statusCode
+
+	switch {
+	case sendErr == nil:
+		delivery.Status = models.DeliveryStatusSucceeded
+		delivery.LastError = ""
+		delivery.DeliveredAt.Time = d.now()
+		delivery.DeliveredAt.Valid = true
+	case delivery.Attempts >= d.config.MaxAttempts:
+		delivery.Status = models.DeliveryStatusFailed
+		delivery.LastError = sendErr.Error()
+	default:
+		delivery.LastError = sendErr.Error()
+		delivery.NextAttemptAt = d.now().Add(d.backoff(delivery.Attempts))
+	}
+
+	d.save(delivery)
+	if delivery.Status == models.DeliveryStatusFailed {
+		d.config.Events.Publish(events.New(events.WebhookDeliveryFailed, map[string]interface{}{
+			"webhook_id":  webhook.ID,
+			"url":         webhook.URL,
+			"delivery_id": delivery.ID,
+			"event_type":  delivery.EventType,
+			"attempts":    delivery.Attempts,
+			"last_error":  delivery.LastError,
+		}))
+	}
+}
+
+// send POSTs the delivery payload to the webhook URL.
+func (d *WebhookDispatcher) send(ctx context.Context, webhook *models.Webhook, delivery *models.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := strconv.FormatInt(d.now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "NIVAI-Webhooks/1.0")
+	req.Header.Set(WebhookEventHeader, delivery.EventType)
+	req.Header.Set(WebhookDeliveryHeader, delivery.ID)
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(webhook.Secret, timestamp, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("endpoint responded with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff returns the delay before the next attempt: InitialBackoff doubled
+// for every failed attempt, capped at MaxBackoff.
+func (d *WebhookDispatcher) backoff(attempts int) time.Duration {
+	delay := d.config.InitialBackoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= d.config.MaxBackoff {
+			return d.config.MaxBackoff
+		}
+	}
+	return delay
+}
+
+// save persists a delivery, logging failures.
+func (d *WebhookDispatcher) save(delivery *models.WebhookDelivery) {
+	delivery.UpdatedAt = d.now()
+	if err := d.repo.UpdateDelivery(delivery); err != nil {
+		log.Printf("Webhooks: failed to update delivery %s: %v", delivery.ID, err)
+	}
+}
+
+/**
+ * SignWebhookPayload computes the signature sent in the X-NIVAI-Signature header.
+ * Receivers recompute HMAC-SHA256 over "<timestamp>.<body>" with their secret
+ * and compare in constant time.
+ *
+ * @param secret The webhook's shared secret
+ * @param timestamp Unix timestamp sent in X-NIVAI-Timestamp
+ * @param body The raw request body
+ * @return The signature in the form "sha256=<hex>"
+ */
+func SignWebhookPayload(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// generateWebhookSecret returns a random 32-byte hex-encoded secret.
+func generateWebhookSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,265 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"nivai/backend/pkg/events"
+	"nivai/backend/pkg/models"
+	"nivai/backend/pkg/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// --- MockWebhookRepository ---
+type MockWebhookRepository struct {
+	mock.Mock
+}
+
+func (m *MockWebhookRepository) Create(webhook *models.Webhook) error {
+	args := m.Called(webhook)
+	return args.Error(0)
+}
+func (m *MockWebhookRepository) FindByID(id string) (*models.Webhook, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Webhook), args.Error(1)
+}
+func (m *MockWebhookRepository) FindAll() ([]*models.Webhook, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Webhook), args.Error(1)
+}
+func (m *MockWebhookRepository) FindActiveByEventType(eventType string) ([]*models.Webhook, error) {
+	args := m.Called(eventType)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Webhook), args.Error(1)
+}
+func (m *MockWebhookRepository) Delete(id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+func (m *MockWebhookRepository) CreateDelivery(delivery *models.WebhookDelivery) error {
+	args := m.Called(delivery)
+	return args.Error(0)
+}
+func (m *MockWebhookRepository) UpdateDelivery(delivery *models.WebhookDelivery) error {
+	args := m.Called(delivery)
+	return args.Error(0)
+}
+func (m *MockWebhookRepository) FindDueDeliveries(now time.Time, limit int) ([]*models.WebhookDelivery, error) {
+	args := m.Called(now, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.WebhookDelivery), args.Error(1)
+}
+func (m *MockWebhookRepository) FindDeliveriesByWebhook(webhookID string, limit, offset int) ([]*models.WebhookDelivery, error) {
+	args := m.Called(webhookID, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.WebhookDelivery), args.Error(1)
+}
+func (m *MockWebhookRepository) FindFailedDeliveries(since time.Time, limit int) ([]*models.WebhookDelivery, error) {
+	args := m.Called(since, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.WebhookDelivery), args.Error(1)
+}
+
+func TestWebhookService_CreateWebhook(t *testing.T) {
+	// example.com is public, intranet.example.com an internal host
+	resolver := services.WithWebhookResolver(func(_ context.Context, host string) ([]net.IPAddr, error) {
+		switch host {
+		case "example.com":
+			return []net.IPAddr{{IP: net.ParseIP("93.184.215.14")}}, nil
+		case "intranet.example.com":
+			return []net.IPAddr{{IP: net.ParseIP("93.184.215.14")}, {IP: net.ParseIP("10.1.2.3")}}, nil
+		}
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	})
+
+	t.Run("Rejects non-http URL", func(t *testing.T) {
+		svc := services.NewWebhookService(new(MockWebhookRepository), resolver)
+		_, err := svc.CreateWebhook("ftp://example.com/hook", "", []string{events.VideoUploaded})
+		assert.ErrorIs(t, err, services.ErrInvalidWebhookURL)
+	})
+
+	t.Run("Rejects unknown event type", func(t *testing.T) {
+		svc := services.NewWebhookService(new(MockWebhookRepository), resolver)
+		_, err := svc.CreateWebhook("https://example.com/hook", "", []string{"video.exploded"})
+		assert.ErrorIs(t, err, services.ErrInvalidWebhookEvent)
+	})
+
+	t.Run("Rejects internal and unresolvable hosts", func(t *testing.T) {
+		svc := services.NewWebhookService(new(MockWebhookRepository), resolver)
+		for _, target := range []string{
+			"http://169.254.169.254/latest/meta-data",
+			"http://127.0.0.1:8080/hook",
+			"http://[::1]/hook",
+			"http://192.168.1.10/hook",
+			"https://intranet.example.com/hook",
+			"https://unknown.example.com/hook",
+		} {
+			_, err := svc.CreateWebhook(target, "", []string{events.VideoUploaded})
+			assert.ErrorIs(t, err, services.ErrInvalidWebhookURL, target)
+		}
+	})
+
+	t.Run("Internal hosts are allowed when configured", func(t *testing.T) {
+		repo := new(MockWebhookRepository)
+		repo.On("Create", mock.AnythingOfType("*models.Webhook")).Return(nil).Once()
+		svc := services.NewWebhookService(repo, resolver, services.WithInternalWebhookTargets())
+
+		_, err := svc.CreateWebhook("http://10.0.0.5:9000/hook", "", []string{events.VideoUploaded})
+		require.NoError(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Generates a secret when none is supplied", func(t *testing.T) {
+		repo := new(MockWebhookRepository)
+		repo.On("Create", mock.AnythingOfType("*models.Webhook")).Return(nil).Once()
+		svc := services.NewWebhookService(repo, resolver)
+
+		webhook, err := svc.CreateWebhook("https://example.com/hook", "", []string{events.VideoUploaded, events.VideoDeleted})
+		require.NoError(t, err)
+		assert.Len(t, webhook.Secret, 64)
+		assert.True(t, webhook.Active)
+		assert.Equal(t, []string{events.VideoUploaded, events.VideoDeleted}, webhook.EventTypes)
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestWebhookDispatcher_HandleEvent(t *testing.T) {
+	repo := new(MockWebhookRepository)
+	webhooks := []*models.Webhook{{ID: "wh1", Active: true}, {ID: "wh2", Active: true}}
+	repo.On("FindActiveByEventType", events.VideoUploaded).Return(webhooks, nil).Once()
+	repo.On("CreateDelivery", mock.MatchedBy(func(d *models.WebhookDelivery) bool {
+		return d.Status == models.DeliveryStatusPending && d.EventType == events.VideoUploaded && len(d.Payload) > 0
+	})).Return(nil).Twice()
+
+	dispatcher := services.NewWebhookDispatcher(repo, nil, services.WebhookDispatcherConfig{})
+	dispatcher.HandleEvent(events.New(events.VideoUploaded, map[string]interface{}{"video_id": "v1"}))
+
+	repo.AssertExpectations(t)
+}
+
+func TestWebhookDispatcher_DeliverDue(t *testing.T) {
+	t.Run("Successful delivery is signed and marked succeeded", func(t *testing.T) {
+		secret := "topsecret"
+		payload := []byte(`{"id":"evt1","type":"video.uploaded"}`)
+
+		var gotSignature, gotTimestamp, gotEvent string
+		var gotBody []byte
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			gotSignature = r.Header.Get(services.WebhookSignatureHeader)
+			gotTimestamp = r.Header.Get(services.WebhookTimestampHeader)
+			gotEvent = r.Header.Get(services.WebhookEventHeader)
+			gotBody, _ = io.ReadAll(r.Body)
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		repo := new(MockWebhookRepository)
+		delivery := &models.WebhookDelivery{ID: "d1", WebhookID: "wh1", EventType: events.VideoUploaded, Payload: payload, Status: models.DeliveryStatusPending}
+		repo.On("FindDueDeliveries", mock.Anything, 50).Return([]*models.WebhookDelivery{delivery}, nil).Once()
+		repo.On("FindByID", "wh1").Return(&models.Webhook{ID: "wh1", URL: server.URL, Secret: secret, Active: true}, nil).Once()
+		repo.On("UpdateDelivery", delivery).Return(nil).Once()
+
+		dispatcher := services.NewWebhookDispatcher(repo, server.Client(), services.WebhookDispatcherConfig{})
+		n, err := dispatcher.DeliverDue(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+
+		assert.Equal(t, payload, gotBody)
+		assert.Equal(t, events.VideoUploaded, gotEvent)
+		assert.Equal(t, services.SignWebhookPayload(secret, gotTimestamp, payload), gotSignature)
+		assert.Equal(t, models.DeliveryStatusSucceeded, delivery.Status)
+		assert.Equal(t, 1, delivery.Attempts)
+		assert.True(t, delivery.DeliveredAt.Valid)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Failed delivery is rescheduled with exponential backoff", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+
+		repo := new(MockWebhookRepository)
+		delivery := &models.WebhookDelivery{ID: "d1", WebhookID: "wh1", Attempts: 2, Status: models.DeliveryStatusPending}
+		repo.On("FindDueDeliveries", mock.Anything, 50).Return([]*models.WebhookDelivery{delivery}, nil).Once()
+		repo.On("FindByID", "wh1").Return(&models.Webhook{ID: "wh1", URL: server.URL, Active: true}, nil).Once()
+		repo.On("UpdateDelivery", delivery).Return(nil).Once()
+
+		dispatcher := services.NewWebhookDispatcher(repo, server.Client(), services.WebhookDispatcherConfig{
+			InitialBackoff: time.Minute,
+		})
+		before := time.Now()
+		_, err := dispatcher.DeliverDue(context.Background())
+		require.NoError(t, err)
+
+		assert.Equal(t, models.DeliveryStatusPending, delivery.Status)
+		assert.Equal(t, 3, delivery.Attempts)
+		assert.Equal(t, http.StatusInternalServerError, delivery.LastStatusCode)
+		// Third attempt failed: 1m * 2^2 = 4m
+		assert.WithinDuration(t, before.Add(4*time.Minute), delivery.NextAttemptAt, 5*time.Second)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Delivery is marked failed after max attempts", func(t *testing.T) {
+		repo := new(MockWebhookRepository)
+		delivery := &models.WebhookDelivery{ID: "d1", WebhookID: "wh1", Attempts: 2, Status: models.DeliveryStatusPending}
+		repo.On("FindDueDeliveries", mock.Anything, 50).Return([]*models.WebhookDelivery{delivery}, nil).Once()
+		repo.On("FindByID", "wh1").Return(&models.Webhook{ID: "wh1", URL: "http://127.0.0.1:1", Active: true}, nil).Once()
+		repo.On("UpdateDelivery", delivery).Return(nil).Once()
+
+		bus := events.NewBus()
+		var failed []events.Event
+		bus.Subscribe(events.WebhookDeliveryFailed, func(e events.Event) { failed = append(failed, e) })
+		dispatcher := services.NewWebhookDispatcher(repo, nil, services.WebhookDispatcherConfig{MaxAttempts: 3, Events: bus})
+		_, err := dispatcher.DeliverDue(context.Background())
+		require.NoError(t, err)
+
+		assert.Equal(t, models.DeliveryStatusFailed, delivery.Status)
+		assert.NotEmpty(t, delivery.LastError)
+		require.Len(t, failed, 1, "giving up is published")
+		assert.Equal(t, "d1", failed[0].Data["delivery_id"])
+		assert.Equal(t, 3, failed[0].Data["attempts"])
+
+		dispatcher.HandleEvent(failed[0])
+		repo.AssertExpectations(t) // Failures are not delivered to webhooks
+	})
+
+	t.Run("Deliveries for removed webhooks are abandoned", func(t *testing.T) {
+		repo := new(MockWebhookRepository)
+		delivery := &models.WebhookDelivery{ID: "d1", WebhookID: "gone", Status: models.DeliveryStatusPending}
+		repo.On("FindDueDeliveries", mock.Anything, 50).Return([]*models.WebhookDelivery{delivery}, nil).Once()
+		repo.On("FindByID", "gone").Return(nil, errors.New("webhook not found")).Once()
+		repo.On("UpdateDelivery", delivery).Return(nil).Once()
+
+		dispatcher := services.NewWebhookDispatcher(repo, nil, services.WebhookDispatcherConfig{})
+		_, err := dispatcher.DeliverDue(context.Background())
+		require.NoError(t, err)
+
+		assert.Equal(t, models.DeliveryStatusFailed, delivery.Status)
+		assert.Equal(t, 0, delivery.Attempts)
+		repo.AssertExpectations(t)
+	})
+}
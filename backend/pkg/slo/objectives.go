@@ -0,0 +1,126 @@
+// Package slo tracks service level objectives per route group, computes
+// error budget burn rates from request observations and raises alert events
+// when a budget burns too fast.
+package slo
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"nivai/backend/pkg/metrics"
+
+	"github.com/gorilla/mux"
+)
+
+// Route groups used to classify API requests.
+const (
+	GroupReads   = "reads"
+	GroupUploads = "uploads"
+	GroupWrites  = "writes"
+)
+
+// Objective kinds.
+const (
+	// KindLatency counts a request as good when it completes within the threshold.
+	KindLatency = "latency"
+	// KindAvailability counts a request as good when it does not fail with a 5xx.
+	KindAvailability = "availability"
+)
+
+// Objective is a service level objective for one route group.
+type Objective struct {
+	Name        string
+	Group       string
+	Kind        string
+	Description string
+	// Target is the fraction of requests that must be good, e.g. 0.95.
+	Target float64
+	// Threshold is the latency bound for KindLatency objectives.
+	Threshold time.Duration
+}
+
+// isBad reports whether an observation consumes error budget.
+func (o Objective) isBad(obs metrics.Observation) bool {
+	switch o.Kind {
+	case KindLatency:
+		return obs.Duration > o.Threshold
+	default:
+		return obs.Status >= http.StatusInternalServerError
+	}
+}
+
+// errorBudget is the fraction of requests allowed to be bad.
+func (o Objective) errorBudget() float64 {
+	return 1 - o.Target
+}
+
+// DefaultObjectives returns the platform's objectives: 95% of reads within
+// 300ms (p95 < 300ms) and 99% of uploads not failing server-side.
+func DefaultObjectives() []Objective {
+	return []Objective{
+		{
+			Name:        "reads-latency",
+			Group:       GroupReads,
+			Kind:        KindLatency,
+			Description: "95% of read requests complete within 300ms",
+			Target:      0.95,
+			Threshold:   300 * time.Millisecond,
+		},
+		{
+			Name:        "uploads-availability",
+			Group:       GroupUploads,
+			Kind:        KindAvailability,
+			Description: "99% of uploads succeed without a server error",
+			Target:      0.99,
+		},
+	}
+}
+
+// AlertRule fires when the burn rate over both the long and the short window
+// is at least Factor. The short window makes the alert reset quickly once
+// the problem is fixed.
+type AlertRule struct {
+	Name        string
+	Severity    string
+	LongWindow  time.Duration
+	ShortWindow time.Duration
+	Factor      float64
+}
+
+// DefaultAlertRules returns the usual multi-window burn-rate alerts for a
+// 30-day budget: a fast burn spends 2% of the budget in an hour, a slow burn
+// spends 5% in six hours.
+func DefaultAlertRules() []AlertRule {
+	return []AlertRule{
+		{Name: "fast-burn", Severity: "page", LongWindow: time.Hour, ShortWindow: 5 * time.Minute, Factor: 14.4},
+		{Name: "slow-burn", Severity: "ticket", LongWindow: 6 * time.Hour, ShortWindow: 30 * time.Minute, Factor: 6},
+	}
+}
+
+// Classify assigns API requests to route groups for the metrics middleware.
+// Uploads are POSTs to the video collection, reads are GETs, and all other
+// API methods are writes. Requests outside /api/ are not observed.
+func Classify(r *http.Request) (group, route string) {
+	route = r.URL.Path
+	if current := mux.CurrentRoute(r); current != nil {
+		if template, err := current.GetPathTemplate(); err == nil {
+			route = template
+		}
+	}
+
+	if !strings.HasPrefix(route, "/api/") {
+		return "", route
+	}
+
+	switch {
+	case r.Method == http.MethodPost && route == "/api/v1/videos":
+		return GroupUploads, route
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		return GroupReads, route
+	case r.Method == http.MethodOptions:
+		return "", route
+	default:
+		return GroupWrites, route
+	}
+}
@@ -0,0 +1,346 @@
+package slo
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"nivai/backend/pkg/events"
+	"nivai/backend/pkg/metrics"
+)
+
+const (
+	// bucketCount one-minute buckets cover the longest alert window (6h).
+	bucketCount = 360
+	// defaultMinRequests avoids alerting on a handful of requests at night.
+	defaultMinRequests = 20
+)
+
+// reportedWindows are the windows shown in the status report.
+var reportedWindows = []time.Duration{5 * time.Minute, 30 * time.Minute, time.Hour, 6 * time.Hour}
+
+// latencyBounds are the histogram bucket upper bounds used to estimate p95.
+var latencyBounds = [...]time.Duration{
+	25 * time.Millisecond, 50 * time.Millisecond, 100 * time.Millisecond, 200 * time.Millisecond,
+	300 * time.Millisecond, 500 * time.Millisecond, time.Second, 2500 * time.Millisecond,
+	5 * time.Second, 10 * time.Second,
+}
+
+// bucket holds the counts for one minute.
+type bucket struct {
+	minute    int64
+	total     int64
+	bad       int64
+	histogram [len(latencyBounds) + 1]int64 // Last slot counts requests above every bound
+}
+
+// objectiveState is the rolling state of one objective.
+type objectiveState struct {
+	objective Objective
+	buckets   [bucketCount]bucket
+	firing    map[string]bool // Alert rule name -> currently firing
+}
+
+// WindowStatus is the measured state of an objective over one window.
+type WindowStatus struct {
+	Window    string  `json:"window"`
+	Requests  int64   `json:"requests"`
+	Bad       int64   `json:"bad"`
+	ErrorRate float64 `json:"error_rate"`
+	BurnRate  float64 `json:"burn_rate"`
+	// P95Ms is an upper-bound estimate of the 95th percentile latency.
+	P95Ms *int64 `json:"p95_ms,omitempty"`
+}
+
+// AlertStatus reports whether an alert rule is firing for an objective.
+type AlertStatus struct {
+	Rule          string  `json:"rule"`
+	Severity      string  `json:"severity"`
+	Factor        float64 `json:"factor"`
+	LongBurnRate  float64 `json:"long_burn_rate"`
+	ShortBurnRate float64 `json:"short_burn_rate"`
+	Firing        bool    `json:"firing"`
+}
+
+// ObjectiveStatus is the report for one objective.
+type ObjectiveStatus struct {
+	Name        string         `json:"name"`
+	Group       string         `json:"group"`
+	Kind        string         `json:"kind"`
+	Description string         `json:"description"`
+	Target      float64        `json:"target"`
+	ThresholdMs int64          `json:"threshold_ms,omitempty"`
+	Windows     []WindowStatus `json:"windows"`
+	Alerts      []AlertStatus  `json:"alerts"`
+}
+
+// Report is the full SLO status at a point in time.
+type Report struct {
+	GeneratedAt time.Time         `json:"generated_at"`
+	Objectives  []ObjectiveStatus `json:"objectives"`
+}
+
+// Tracker records observations against objectives and evaluates burn-rate
+// alerts. It implements metrics.Observer and is safe for concurrent use.
+type Tracker struct {
+	mu          sync.Mutex
+	states      []*objectiveState
+	rules       []AlertRule
+	bus         *events.Bus
+	now         func() time.Time
+	interval    time.Duration
+	minRequests int64
+}
+
+// Option configures a Tracker.
+type Option func(*Tracker)
+
+// WithAlertRules replaces the default alert rules.
+func WithAlertRules(rules []AlertRule) Option {
+	return func(t *Tracker) { t.rules = rules }
+}
+
+// WithEventBus publishes alert and resolution events on bus.
+func WithEventBus(bus *events.Bus) Option {
+	return func(t *Tracker) { t.bus = bus }
+}
+
+// WithClock overrides the time source, mainly for tests.
+func WithClock(now func() time.Time) Option {
+	return func(t *Tracker) { t.now = now }
+}
+
+// WithMinRequests sets how many requests the long window needs before an
+// alert can fire.
+func WithMinRequests(n int64) Option {
+	return func(t *Tracker) { t.minRequests = n }
+}
+
+// NewTracker creates a tracker for the given objectives.
+func NewTracker(objectives []Objective, opts ...Option) *Tracker {
+	t := &Tracker{
+		rules:       DefaultAlertRules(),
+		now:         time.Now,
+		interval:    time.Minute,
+		minRequests: defaultMinRequests,
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	for _, objective := range objectives {
+		t.states = append(t.states, &objectiveState{objective: objective, firing: make(map[string]bool)})
+	}
+	return t
+}
+
+// Observe implements metrics.Observer.
+func (t *Tracker) Observe(obs metrics.Observation) {
+	minute := obs.At.Unix() / 60
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, state := range t.states {
+		if state.objective.Group != obs.Group {
+			continue
+		}
+		b := &state.buckets[minute%bucketCount]
+		if b.minute != minute {
+			*b = bucket{minute: minute}
+		}
+		b.total++
+		if state.objective.isBad(obs) {
+			b.bad++
+		}
+		b.histogram[latencyBucket(obs.Duration)]++
+	}
+}
+
+// Report returns the current status of every objective.
+func (t *Tracker) Report() Report {
+	now := t.now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	report := Report{GeneratedAt: now.UTC(), Objectives: make([]ObjectiveStatus, 0, len(t.states))}
+	for _, state := range t.states {
+		objective := state.objective
+		status := ObjectiveStatus{
+			Name:        objective.Name,
+			Group:       objective.Group,
+			Kind:        objective.Kind,
+			Description: objective.Description,
+			Target:      objective.Target,
+			ThresholdMs: objective.Threshold.Milliseconds(),
+		}
+
+		for _, window := range reportedWindows {
+			sum := state.sum(now, window)
+			ws := WindowStatus{
+				Window:    window.String(),
+				Requests:  sum.total,
+				Bad:       sum.bad,
+				ErrorRate: sum.errorRate(),
+				BurnRate:  sum.burnRate(objective),
+			}
+			if objective.Kind == KindLatency && sum.total > 0 {
+				p95 := sum.percentile(0.95).Milliseconds()
+				ws.P95Ms = &p95
+			}
+			status.Windows = append(status.Windows, ws)
+		}
+
+		for _, rule := range t.rules {
+			long := state.sum(now, rule.LongWindow)
+			short := state.sum(now, rule.ShortWindow)
+			status.Alerts = append(status.Alerts, AlertStatus{
+				Rule:          rule.Name,
+				Severity:      rule.Severity,
+				Factor:        rule.Factor,
+				LongBurnRate:  long.burnRate(objective),
+				ShortBurnRate: short.burnRate(objective),
+				Firing:        state.firing[rule.Name],
+			})
+		}
+
+		report.Objectives = append(report.Objectives, status)
+	}
+	return report
+}
+
+// Evaluate checks every alert rule and publishes an event whenever an alert
+// starts or stops firing.
+func (t *Tracker) Evaluate() {
+	now := t.now()
+	var pending []events.Event
+
+	t.mu.Lock()
+	for _, state := range t.states {
+		objective := state.objective
+		for _, rule := range t.rules {
+			long := state.sum(now, rule.LongWindow)
+			short := state.sum(now, rule.ShortWindow)
+			longBurn, shortBurn := long.burnRate(objective), short.burnRate(objective)
+			firing := long.total >= t.minRequests && longBurn >= rule.Factor && shortBurn >= rule.Factor
+
+			if firing == state.firing[rule.Name] {
+				continue
+			}
+			state.firing[rule.Name] = firing
+
+			eventType := events.SLOBurnRateResolved
+			if firing {
+				eventType = events.SLOBurnRateAlert
+			}
+			pending = append(pending, events.New(eventType, map[string]interface{}{
+				"objective":       objective.Name,
+				"group":           objective.Group,
+				"kind":            objective.Kind,
+				"target":          objective.Target,
+				"rule":            rule.Name,
+				"severity":        rule.Severity,
+				"factor":          rule.Factor,
+				"long_window":     rule.LongWindow.String(),
+				"short_window":    rule.ShortWindow.String(),
+				"long_burn_rate":  longBurn,
+				"short_burn_rate": shortBurn,
+			}))
+		}
+	}
+	t.mu.Unlock()
+
+	// Publish outside the lock; subscribers may call back into the tracker.
+	for _, event := range pending {
+		t.bus.Publish(event)
+	}
+}
+
+// Run evaluates alerts every minute until ctx is cancelled.
+func (t *Tracker) Run(ctx context.Context) {
+	ticker := time.NewTicker(t.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			t.Evaluate()
+		}
+	}
+}
+
+// windowSum aggregates buckets over a window.
+type windowSum struct {
+	total     int64
+	bad       int64
+	histogram [len(latencyBounds) + 1]int64
+}
+
+// sum aggregates the buckets of the last window, including the current minute.
+func (s *objectiveState) sum(now time.Time, window time.Duration) windowSum {
+	var sum windowSum
+	minutes := int64(window / time.Minute)
+	if minutes > bucketCount {
+		minutes = bucketCount
+	}
+	current := now.Unix() / 60
+	for minute := current - minutes + 1; minute <= current; minute++ {
+		b := &s.buckets[minute%bucketCount]
+		if b.minute != minute {
+			continue
+		}
+		sum.total += b.total
+		sum.bad += b.bad
+		for i, n := range b.histogram {
+			sum.histogram[i] += n
+		}
+	}
+	return sum
+}
+
+func (w windowSum) errorRate() float64 {
+	if w.total == 0 {
+		return 0
+	}
+	return float64(w.bad) / float64(w.total)
+}
+
+// burnRate is how fast the error budget is consumed relative to the rate
+// that would exactly exhaust it; 1 means on budget.
+func (w windowSum) burnRate(o Objective) float64 {
+	budget := o.errorBudget()
+	if budget <= 0 {
+		return 0
+	}
+	return w.errorRate() / budget
+}
+
+// percentile returns the upper bound of the histogram bucket containing the
+// q-th quantile. Requests above the largest bound report that bound.
+func (w windowSum) percentile(q float64) time.Duration {
+	rank := int64(math.Ceil(float64(w.total) * q))
+	if rank < 1 {
+		rank = 1
+	}
+	var seen int64
+	for i, n := range w.histogram {
+		seen += n
+		if seen >= rank && i < len(latencyBounds) {
+			return latencyBounds[i]
+		}
+	}
+	return latencyBounds[len(latencyBounds)-1]
+}
+
+// latencyBucket returns the histogram slot for a duration.
+func latencyBucket(d time.Duration) int {
+	for i, bound := range latencyBounds {
+		if d <= bound {
+			return i
+		}
+	}
+	return len(latencyBounds)
+}
@@ -0,0 +1,128 @@
+package slo_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"nivai/backend/pkg/events"
+	"nivai/backend/pkg/metrics"
+	"nivai/backend/pkg/slo"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func observe(tracker *slo.Tracker, at time.Time, group string, n int, status int, duration time.Duration) {
+	for i := 0; i < n; i++ {
+		tracker.Observe(metrics.Observation{Group: group, Status: status, Duration: duration, At: at})
+	}
+}
+
+func TestTracker_Report(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 30, 0, time.UTC)
+	tracker := slo.NewTracker(slo.DefaultObjectives(), slo.WithClock(func() time.Time { return now }))
+
+	// 90 fast reads and 10 slow ones: 10% bad against a 5% budget
+	observe(tracker, now, slo.GroupReads, 90, http.StatusOK, 40*time.Millisecond)
+	observe(tracker, now, slo.GroupReads, 10, http.StatusOK, 800*time.Millisecond)
+	// Uploads: one server error in 50, against a 1% budget
+	observe(tracker, now.Add(-20*time.Minute), slo.GroupUploads, 49, http.StatusCreated, 2*time.Second)
+	observe(tracker, now.Add(-20*time.Minute), slo.GroupUploads, 1, http.StatusInternalServerError, time.Second)
+	// Observations older than every window are ignored
+	observe(tracker, now.Add(-7*time.Hour), slo.GroupReads, 100, http.StatusOK, 5*time.Second)
+
+	report := tracker.Report()
+	require.Len(t, report.Objectives, 2)
+
+	reads := report.Objectives[0]
+	assert.Equal(t, "reads-latency", reads.Name)
+	assert.Equal(t, int64(300), reads.ThresholdMs)
+	require.Len(t, reads.Windows, 4)
+	assert.Equal(t, "5m0s", reads.Windows[0].Window)
+	assert.Equal(t, int64(100), reads.Windows[0].Requests)
+	assert.Equal(t, int64(10), reads.Windows[0].Bad)
+	assert.InDelta(t, 2.0, reads.Windows[0].BurnRate, 1e-9)
+	require.NotNil(t, reads.Windows[0].P95Ms)
+	assert.Equal(t, int64(1000), *reads.Windows[0].P95Ms)
+	assert.Equal(t, int64(100), reads.Windows[3].Requests)
+
+	uploads := report.Objectives[1]
+	assert.Equal(t, int64(0), uploads.Windows[0].Requests) // Outside the 5m window
+	assert.Equal(t, int64(50), uploads.Windows[1].Requests)
+	assert.InDelta(t, 2.0, uploads.Windows[1].BurnRate, 1e-9)
+	assert.Nil(t, uploads.Windows[1].P95Ms)
+	for _, alert := range uploads.Alerts {
+		assert.False(t, alert.Firing)
+	}
+}
+
+func TestTracker_Evaluate(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 30, 0, time.UTC)
+	bus := events.NewBus()
+	var received []events.Event
+	bus.Subscribe(events.Wildcard, func(e events.Event) { received = append(received, e) })
+
+	tracker := slo.NewTracker(slo.DefaultObjectives(),
+		slo.WithClock(func() time.Time { return now }),
+		slo.WithEventBus(bus),
+	)
+
+	t.Run("Low traffic does not alert", func(t *testing.T) {
+		observe(tracker, now, slo.GroupUploads, 5, http.StatusBadGateway, time.Second)
+		tracker.Evaluate()
+		assert.Empty(t, received)
+	})
+
+	t.Run("Fast burn fires once", func(t *testing.T) {
+		// 25 of 45 uploads failed: burn rate ~55 against a 1% budget
+		observe(tracker, now, slo.GroupUploads, 20, http.StatusServiceUnavailable, time.Second)
+		observe(tracker, now, slo.GroupUploads, 20, http.StatusCreated, time.Second)
+		tracker.Evaluate()
+		tracker.Evaluate()
+
+		require.Len(t, received, 2) // Fast and slow burn
+		assert.Equal(t, events.SLOBurnRateAlert, received[0].Type)
+		assert.Equal(t, "uploads-availability", received[0].Data["objective"])
+		assert.Equal(t, "fast-burn", received[0].Data["rule"])
+		assert.Equal(t, "page", received[0].Data["severity"])
+		assert.Equal(t, "slow-burn", received[1].Data["rule"])
+	})
+
+	t.Run("Alert resolves when the short window recovers", func(t *testing.T) {
+		received = nil
+		now = now.Add(40 * time.Minute) // Failures are still in the long windows only
+		observe(tracker, now, slo.GroupUploads, 30, http.StatusCreated, time.Second)
+		tracker.Evaluate()
+
+		require.Len(t, received, 2)
+		assert.Equal(t, events.SLOBurnRateResolved, received[0].Type)
+		assert.Equal(t, events.SLOBurnRateResolved, received[1].Type)
+	})
+}
+
+func TestClassify(t *testing.T) {
+	router := mux.NewRouter()
+	var group, route string
+	capture := func(w http.ResponseWriter, r *http.Request) { group, route = slo.Classify(r) }
+	router.HandleFunc("/api/v1/videos", capture).Methods("GET", "POST")
+	router.HandleFunc("/api/v1/videos/{id}", capture).Methods("GET", "DELETE")
+	router.HandleFunc("/ws", capture).Methods("GET")
+
+	tests := []struct {
+		method, path, group, route string
+	}{
+		{"POST", "/api/v1/videos", slo.GroupUploads, "/api/v1/videos"},
+		{"GET", "/api/v1/videos", slo.GroupReads, "/api/v1/videos"},
+		{"GET", "/api/v1/videos/abc", slo.GroupReads, "/api/v1/videos/{id}"},
+		{"DELETE", "/api/v1/videos/abc", slo.GroupWrites, "/api/v1/videos/{id}"},
+		{"GET", "/ws", "", "/ws"},
+	}
+	for _, tt := range tests {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tt.method, tt.path, nil))
+		assert.Equal(t, tt.group, group, "%s %s", tt.method, tt.path)
+		assert.Equal(t, tt.route, route, "%s %s", tt.method, tt.path)
+	}
+}
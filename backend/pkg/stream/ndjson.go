@@ -0,0 +1,250 @@
+// Package stream writes long-running list and export responses as
+// newline-delimited JSON (NDJSON), so clients can start consuming records
+// before the query has finished.
+package stream
+
+import (
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ContentType is the media type of NDJSON responses.
+const ContentType = "application/x-ndjson"
+
+// Default flush and heartbeat cadence. The heartbeat interval stays well below
+// the 60s idle timeout common to reverse proxies and load balancers.
+const (
+	DefaultFlushInterval     = 500 * time.Millisecond
+	DefaultHeartbeatInterval = 15 * time.Second
+	DefaultFlushEvery        = 100
+)
+
+// ErrClosed is returned when writing to a stream that has been closed.
+var ErrClosed = errors.New("stream closed")
+
+// Requested reports whether the client asked for an NDJSON response, either
+// with ?format=ndjson or an Accept header naming an NDJSON media type.
+func Requested(r *http.Request) bool {
+	if strings.EqualFold(r.URL.Query().Get("format"), "ndjson") {
+		return true
+	}
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		switch mediaType {
+		case ContentType, "application/ndjson", "application/jsonl":
+			return true
+		}
+	}
+	return false
+}
+
+// Option configures a Writer.
+type Option func(*Writer)
+
+// WithFlushInterval sets how often buffered records are flushed to the client.
+func WithFlushInterval(d time.Duration) Option {
+	return func(s *Writer) {
+		if d > 0 {
+			s.flushInterval = d
+		}
+	}
+}
+
+// WithHeartbeatInterval sets how long the stream may stay silent before a
+// heartbeat line is sent.
+func WithHeartbeatInterval(d time.Duration) Option {
+	return func(s *Writer) {
+		if d > 0 {
+			s.heartbeatInterval = d
+		}
+	}
+}
+
+// WithFlushEvery flushes immediately once n records have been buffered,
+// independent of the flush interval.
+func WithFlushEvery(n int) Option {
+	return func(s *Writer) {
+		if n > 0 {
+			s.flushEvery = n
+		}
+	}
+}
+
+// Writer encodes one JSON value per line onto an HTTP response. Records are
+// flushed every flush interval (or every flushEvery records), and while the
+// producer is quiet an empty heartbeat line is written, which NDJSON readers
+// skip, so intermediaries do not time the connection out.
+//
+// The status line is sent by New; failures after that point must be reported
+// in-band with Fail. Writer is safe for concurrent use.
+type Writer struct {
+	w       http.ResponseWriter
+	rc      *http.ResponseController
+	encoder *json.Encoder
+
+	flushInterval     time.Duration
+	heartbeatInterval time.Duration
+	flushEvery        int
+
+	mu        sync.Mutex
+	pending   int
+	lastWrite time.Time
+	err       error
+	closed    bool
+
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+// New sends the NDJSON response headers and starts the background flusher.
+// The stream ends when Close is called or the request context is cancelled.
+func New(w http.ResponseWriter, r *http.Request, opts ...Option) *Writer {
+	s := &Writer{
+		w:                 w,
+		rc:                http.NewResponseController(w),
+		encoder:           json.NewEncoder(w),
+		flushInterval:     DefaultFlushInterval,
+		heartbeatInterval: DefaultHeartbeatInterval,
+		flushEvery:        DefaultFlushEvery,
+		lastWrite:         time.Now(),
+		done:              make(chan struct{}),
+		stopped:           make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	header := w.Header()
+	header.Set("Content-Type", ContentType)
+	header.Set("Cache-Control", "no-cache")
+	header.Set("X-Accel-Buffering", "no") // Disable nginx response buffering
+	header.Del("Content-Length")
+	s.extendDeadline()
+	w.WriteHeader(http.StatusOK)
+	s.flushLocked()
+
+	go s.run(r)
+	return s
+}
+
+// Write encodes v as a single NDJSON line. It returns the first write error
+// seen on the stream, e.g. once the client has disconnected, so producers can
+// stop early.
+func (s *Writer) Write(v interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrClosed
+	}
+	if s.err != nil {
+		return s.err
+	}
+	if err := s.encoder.Encode(v); err != nil {
+		s.err = err
+		return err
+	}
+	s.lastWrite = time.Now()
+	s.pending++
+	if s.pending >= s.flushEvery {
+		s.flushLocked()
+	}
+	return s.err
+}
+
+// Fail writes a terminal {"error": ...} line. Clients should treat a stream
+// whose last line is an error object as incomplete.
+func (s *Writer) Fail(message string) error {
+	return s.Write(map[string]string{"error": message})
+}
+
+// Close flushes any buffered records and stops the background flusher.
+// It returns the first write error seen on the stream.
+func (s *Writer) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return s.err
+	}
+	s.closed = true
+	close(s.done)
+	s.mu.Unlock()
+
+	<-s.stopped
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.flushLocked()
+	return s.err
+}
+
+// run flushes pending records and sends heartbeats until the stream closes.
+func (s *Writer) run(r *http.Request) {
+	defer close(s.stopped)
+
+	ticker := time.NewTicker(s.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-r.Context().Done():
+			s.mu.Lock()
+			if s.err == nil {
+				s.err = r.Context().Err()
+			}
+			s.mu.Unlock()
+			return
+		case <-ticker.C:
+			s.tick()
+		}
+	}
+}
+
+// tick flushes buffered records, or writes a heartbeat if nothing has been
+// sent for a full heartbeat interval.
+func (s *Writer) tick() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed || s.err != nil {
+		return
+	}
+	if s.pending > 0 {
+		s.flushLocked()
+		return
+	}
+	if time.Since(s.lastWrite) < s.heartbeatInterval {
+		return
+	}
+	if _, err := s.w.Write([]byte("\n")); err != nil {
+		s.err = err
+		return
+	}
+	s.lastWrite = time.Now()
+	s.flushLocked()
+}
+
+// flushLocked pushes buffered bytes to the client. Writers that cannot flush
+// are tolerated; their output is simply delivered when the handler returns.
+func (s *Writer) flushLocked() {
+	s.pending = 0
+	if err := s.rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) && s.err == nil {
+		s.err = err
+	}
+	s.extendDeadline()
+}
+
+// extendDeadline pushes the server's write deadline past the next heartbeat,
+// so a stream outlives the server-wide WriteTimeout while the client keeps
+// reading, but a stalled client is still cut off.
+func (s *Writer) extendDeadline() {
+	_ = s.rc.SetWriteDeadline(time.Now().Add(2 * s.heartbeatInterval))
+}
@@ -0,0 +1,110 @@
+package stream_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"nivai/backend/pkg/stream"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequested(t *testing.T) {
+	cases := []struct {
+		name   string
+		url    string
+		accept string
+		want   bool
+	}{
+		{"plain JSON", "/api/v1/matches", "application/json", false},
+		{"format query", "/api/v1/matches?format=ndjson", "", true},
+		{"x-ndjson accept", "/api/v1/matches", "application/x-ndjson", true},
+		{"ndjson among others", "/api/v1/matches", "application/json;q=0.5, application/ndjson", true},
+		{"no accept", "/api/v1/matches", "", false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tc.url, nil)
+			if tc.accept != "" {
+				req.Header.Set("Accept", tc.accept)
+			}
+			assert.Equal(t, tc.want, stream.Requested(req))
+		})
+	}
+}
+
+func TestWriter(t *testing.T) {
+	t.Run("Records are written one per line", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/", nil)
+
+		s := stream.New(rr, req)
+		for i := 0; i < 3; i++ {
+			require.NoError(t, s.Write(map[string]int{"n": i}))
+		}
+		require.NoError(t, s.Close())
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, stream.ContentType, rr.Header().Get("Content-Type"))
+		assert.True(t, rr.Flushed)
+
+		scanner := bufio.NewScanner(strings.NewReader(rr.Body.String()))
+		var got []int
+		for scanner.Scan() {
+			var rec map[string]int
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &rec))
+			got = append(got, rec["n"])
+		}
+		assert.Equal(t, []int{0, 1, 2}, got)
+		assert.ErrorIs(t, s.Write("late"), stream.ErrClosed)
+	})
+
+	t.Run("Idle streams send heartbeat lines", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/", nil)
+
+		s := stream.New(rr, req, stream.WithFlushInterval(5*time.Millisecond), stream.WithHeartbeatInterval(10*time.Millisecond))
+		time.Sleep(60 * time.Millisecond)
+		require.NoError(t, s.Write(map[string]string{"id": "a"}))
+		require.NoError(t, s.Close())
+
+		body := rr.Body.String()
+		assert.True(t, strings.HasPrefix(body, "\n"), "expected heartbeat before first record, got %q", body)
+		assert.True(t, strings.HasSuffix(body, "{\"id\":\"a\"}\n"))
+	})
+
+	t.Run("Failures are reported in-band", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/", nil)
+
+		s := stream.New(rr, req)
+		require.NoError(t, s.Write(map[string]string{"id": "a"}))
+		require.NoError(t, s.Fail("database error"))
+		require.NoError(t, s.Close())
+
+		lines := strings.Split(strings.TrimSpace(rr.Body.String()), "\n")
+		require.Len(t, lines, 2)
+		assert.JSONEq(t, `{"error":"database error"}`, lines[1])
+	})
+
+	t.Run("Cancelled requests stop the stream", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/", nil).WithContext(ctx)
+
+		s := stream.New(rr, req, stream.WithFlushInterval(time.Millisecond))
+		cancel()
+
+		assert.Eventually(t, func() bool {
+			return s.Write("x") != nil
+		}, time.Second, 5*time.Millisecond)
+		assert.ErrorIs(t, s.Close(), context.Canceled)
+	})
+}
@@ -0,0 +1,62 @@
+package support
+
+import (
+	"sync"
+	"time"
+
+	"nivai/backend/pkg/events"
+)
+
+// DefaultEvents is the number of events an EventRecorder keeps by default.
+const DefaultEvents = 1000
+
+// EventRecorder keeps the most recent events published on the bus since the
+// process started, so a support bundle can show what happened to a match
+// even when no webhook or broker recorded it.
+type EventRecorder struct {
+	mu     sync.Mutex
+	events []events.Event // Ring of at most capacity events
+	next   int
+}
+
+// NewEventRecorder creates a recorder keeping the last capacity events
+// (DefaultEvents when capacity is not positive).
+func NewEventRecorder(capacity int) *EventRecorder {
+	if capacity <= 0 {
+		capacity = DefaultEvents
+	}
+	return &EventRecorder{events: make([]events.Event, 0, capacity)}
+}
+
+// HandleEvent records an event. Subscribe it to events.Wildcard.
+func (r *EventRecorder) HandleEvent(e events.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.events) < cap(r.events) {
+		r.events = append(r.events, e)
+		return
+	}
+	r.events[r.next] = e
+	r.next = (r.next + 1) % len(r.events)
+}
+
+// Events returns the recorded events that occurred in [since, until], oldest
+// first. A zero until means no upper bound; a non-empty videoID keeps only
+// the events of that match.
+func (r *EventRecorder) Events(since, until time.Time, videoID string) []events.Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	recorded := make([]events.Event, 0, len(r.events))
+	for i := range r.events {
+		e := r.events[(r.next+i)%len(r.events)]
+		if e.OccurredAt.Before(since) || (!until.IsZero() && e.OccurredAt.After(until)) {
+			continue
+		}
+		if videoID != "" && e.Data["video_id"] != videoID {
+			continue
+		}
+		recorded = append(recorded, e)
+	}
+	return recorded
+}
@@ -0,0 +1,99 @@
+// Package support collects the diagnostics support staff ask for when a club
+// reports a problem: recent log output, recent events and a sanitized copy of
+// the configuration. The pieces are assembled into a downloadable bundle by
+// services.SupportBundleService.
+package support
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultLogLines is the number of log lines a LogBuffer keeps by default.
+const DefaultLogLines = 5000
+
+// maxPartialLine bounds a line still waiting for its newline.
+const maxPartialLine = 64 * 1024
+
+// LogLine is one captured line of log output.
+type LogLine struct {
+	At   time.Time `json:"at"`
+	Text string    `json:"text"`
+}
+
+// LogBuffer keeps the most recent lines of log output in memory. It is an
+// io.Writer meant to sit next to the regular output, for example
+// log.SetOutput(io.MultiWriter(os.Stderr, buffer)).
+type LogBuffer struct {
+	mu      sync.Mutex
+	lines   []LogLine // Ring of at most capacity lines
+	next    int       // Index the next line is written to once the ring is full
+	partial []byte
+	now     func() time.Time
+}
+
+// NewLogBuffer creates a buffer keeping the last capacity lines
+// (DefaultLogLines when capacity is not positive).
+func NewLogBuffer(capacity int) *LogBuffer {
+	if capacity <= 0 {
+		capacity = DefaultLogLines
+	}
+	return &LogBuffer{lines: make([]LogLine, 0, capacity), now: time.Now}
+}
+
+// Write records every complete line in p; an incomplete last line is kept
+// until the rest of it is written. It never fails.
+func (b *LogBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	data := append(b.partial, p...)
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			break
+		}
+		b.add(string(data[:i]))
+		data = data[i+1:]
+	}
+	if len(data) > maxPartialLine {
+		b.add(string(data))
+		data = nil
+	}
+	b.partial = append([]byte(nil), data...)
+	return len(p), nil
+}
+
+// add appends a line, overwriting the oldest one when the ring is full.
+func (b *LogBuffer) add(text string) {
+	line := LogLine{At: b.now(), Text: text}
+	if len(b.lines) < cap(b.lines) {
+		b.lines = append(b.lines, line)
+		return
+	}
+	b.lines[b.next] = line
+	b.next = (b.next + 1) % len(b.lines)
+}
+
+// Lines returns the captured lines written in [since, until], oldest first.
+// A zero until means no upper bound; a non-empty contains keeps only lines
+// containing it, such as a match ID.
+func (b *LogBuffer) Lines(since, until time.Time, contains string) []LogLine {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	lines := make([]LogLine, 0, len(b.lines))
+	for i := range b.lines {
+		line := b.lines[(b.next+i)%len(b.lines)]
+		if line.At.Before(since) || (!until.IsZero() && line.At.After(until)) {
+			continue
+		}
+		if contains != "" && !strings.Contains(line.Text, contains) {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -0,0 +1,183 @@
+package upload
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strings"
+	"time"
+)
+
+// ClamAV scans content with a clamd daemon using the INSTREAM command.
+//
+// clamd refuses streams longer than its StreamMaxLength setting (25 MB by
+// default). Content beyond MaxStreamBytes, which should match that setting,
+// is not sent; the verdict then covers the first MaxStreamBytes only.
+type ClamAV struct {
+	network        string // "tcp" or "unix"
+	address        string
+	timeout        time.Duration // Per network operation
+	maxStreamBytes int64
+	chunkSize      int
+}
+
+// NewClamAV creates a clamd scanner. address is "host:port",
+// "tcp://host:port" or "unix:///path/to/clamd.sock". A timeout of 0 uses
+// 30 seconds; maxStreamBytes of 0 sends everything.
+func NewClamAV(address string, timeout time.Duration, maxStreamBytes int64) (*ClamAV, error) {
+	network := "tcp"
+	switch {
+	case strings.HasPrefix(address, "unix://"):
+		network, address = "unix", strings.TrimPrefix(address, "unix://")
+	case strings.HasPrefix(address, "tcp://"):
+		address = strings.TrimPrefix(address, "tcp://")
+	}
+	if address == "" {
+		return nil, errors.New("clamav: address is required")
+	}
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	return &ClamAV{
+		network:        network,
+		address:        address,
+		timeout:        timeout,
+		maxStreamBytes: maxStreamBytes,
+		chunkSize:      64 << 10,
+	}, nil
+}
+
+// Scan streams r to clamd and returns its verdict.
+func (c *ClamAV) Scan(ctx context.Context, r io.Reader) error {
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := c.write(conn, []byte("zINSTREAM\x00")); err != nil {
+		return err
+	}
+
+	header := make([]byte, 4)
+	buf := make([]byte, c.chunkSize)
+	var sent int64
+	for {
+		n, readErr := r.Read(buf)
+		if c.maxStreamBytes > 0 && sent+int64(n) > c.maxStreamBytes {
+			n = int(c.maxStreamBytes - sent)
+		}
+		if n > 0 {
+			binary.BigEndian.PutUint32(header, uint32(n))
+			if err := c.write(conn, header, buf[:n]); err != nil {
+				return err
+			}
+			sent += int64(n)
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return readErr
+		}
+		if c.maxStreamBytes > 0 && sent >= c.maxStreamBytes {
+			log.Printf("ClamAV: stream limit of %d bytes reached, scanning the first part only", c.maxStreamBytes)
+			break
+		}
+	}
+
+	// A zero-length chunk ends the stream
+	binary.BigEndian.PutUint32(header, 0)
+	if err := c.write(conn, header); err != nil {
+		return err
+	}
+
+	reply, err := c.reply(conn)
+	if err != nil {
+		return err
+	}
+	return parseClamdReply(reply)
+}
+
+// Ping checks that clamd is reachable and answering, for health checks.
+func (c *ClamAV) Ping(ctx context.Context) error {
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := c.write(conn, []byte("zPING\x00")); err != nil {
+		return err
+	}
+	reply, err := c.reply(conn)
+	if err != nil {
+		return err
+	}
+	if reply != "PONG" {
+		return fmt.Errorf("clamav: unexpected ping reply %q", reply)
+	}
+	return nil
+}
+
+// dial connects to clamd. Cancelling ctx aborts any pending I/O.
+func (c *ClamAV) dial(ctx context.Context) (net.Conn, error) {
+	dialer := net.Dialer{Timeout: c.timeout}
+	conn, err := dialer.DialContext(ctx, c.network, c.address)
+	if err != nil {
+		return nil, fmt.Errorf("clamav: %w", err)
+	}
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	return &ctxConn{Conn: conn, stop: stop}, nil
+}
+
+// write sends parts to clamd under the I/O timeout.
+func (c *ClamAV) write(conn net.Conn, parts ...[]byte) error {
+	conn.SetWriteDeadline(time.Now().Add(c.timeout))
+	for _, part := range parts {
+		if _, err := conn.Write(part); err != nil {
+			return fmt.Errorf("clamav: %w", err)
+		}
+	}
+	return nil
+}
+
+// reply reads one NUL-terminated reply from clamd.
+func (c *ClamAV) reply(conn net.Conn) (string, error) {
+	conn.SetReadDeadline(time.Now().Add(c.timeout))
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && !(err == io.EOF && reply != "") {
+		return "", fmt.Errorf("clamav: reading reply: %w", err)
+	}
+	return strings.TrimRight(reply, "\x00\n"), nil
+}
+
+// parseClamdReply turns an INSTREAM reply ("stream: OK",
+// "stream: <signature> FOUND" or "<message> ERROR") into a verdict.
+func parseClamdReply(reply string) error {
+	result := strings.TrimPrefix(reply, "stream: ")
+	switch {
+	case result == "OK":
+		return nil
+	case strings.HasSuffix(result, " FOUND"):
+		return &InfectedError{Signature: strings.TrimSuffix(result, " FOUND")}
+	default:
+		return fmt.Errorf("clamav: %s", result)
+	}
+}
+
+// ctxConn releases its context watcher when closed.
+type ctxConn struct {
+	net.Conn
+	stop func() bool
+}
+
+func (c *ctxConn) Close() error {
+	c.stop()
+	return c.Conn.Close()
+}
@@ -0,0 +1,105 @@
+package upload_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"nivai/backend/pkg/upload"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClamd answers INSTREAM and PING commands like clamd, flagging streams
+// that contain "EICAR". It records the bytes received per stream.
+func fakeClamd(t *testing.T) (string, *[][]byte) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	var received [][]byte
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			reader := bufio.NewReader(conn)
+			command, _ := reader.ReadString(0)
+			switch command {
+			case "zPING\x00":
+				conn.Write([]byte("PONG\x00"))
+			case "zINSTREAM\x00":
+				var stream bytes.Buffer
+				for {
+					var size uint32
+					if binary.Read(reader, binary.BigEndian, &size) != nil || size == 0 {
+						break
+					}
+					io.CopyN(&stream, reader, int64(size))
+				}
+				received = append(received, stream.Bytes())
+				if bytes.Contains(stream.Bytes(), []byte("EICAR")) {
+					conn.Write([]byte("stream: Eicar-Test-Signature FOUND\x00"))
+				} else {
+					conn.Write([]byte("stream: OK\x00"))
+				}
+			default:
+				conn.Write([]byte("UNKNOWN COMMAND\x00"))
+			}
+			conn.Close()
+		}
+	}()
+	return listener.Addr().String(), &received
+}
+
+func TestClamAV(t *testing.T) {
+	address, received := fakeClamd(t)
+
+	scanner, err := upload.NewClamAV("tcp://"+address, time.Second, 0)
+	require.NoError(t, err)
+
+	t.Run("clean content", func(t *testing.T) {
+		content := strings.Repeat("tracking frame\n", 10_000)
+		require.NoError(t, scanner.Scan(context.Background(), strings.NewReader(content)))
+		assert.Equal(t, content, string((*received)[len(*received)-1]))
+	})
+
+	t.Run("infected content", func(t *testing.T) {
+		err := scanner.Scan(context.Background(), strings.NewReader("X5O!P%@AP EICAR"))
+		var infected *upload.InfectedError
+		require.ErrorAs(t, err, &infected)
+		assert.Equal(t, "Eicar-Test-Signature", infected.Signature)
+	})
+
+	t.Run("stream is capped at the clamd limit", func(t *testing.T) {
+		capped, err := upload.NewClamAV(address, time.Second, 10)
+		require.NoError(t, err)
+		require.NoError(t, capped.Scan(context.Background(), strings.NewReader("0123456789 EICAR beyond the limit")))
+		assert.Equal(t, "0123456789", string((*received)[len(*received)-1]))
+	})
+
+	t.Run("ping", func(t *testing.T) {
+		assert.NoError(t, scanner.Ping(context.Background()))
+	})
+
+	t.Run("unreachable daemon", func(t *testing.T) {
+		down, err := upload.NewClamAV("127.0.0.1:1", time.Second, 0)
+		require.NoError(t, err)
+		err = down.Scan(context.Background(), strings.NewReader("data"))
+		assert.Error(t, err)
+		var infected *upload.InfectedError
+		assert.False(t, errors.As(err, &infected))
+	})
+
+	_, err = upload.NewClamAV("unix://", time.Second, 0)
+	assert.Error(t, err)
+}
@@ -0,0 +1,214 @@
+// Package upload streams uploaded files through checksumming, optional
+// malware scanning and storage in a single pass.
+//
+// Tee reads the source once in fixed-size chunks. Each chunk is hashed inline
+// and handed to the storage and scanner consumers, which run concurrently and
+// read from small bounded queues, so memory use is bounded by
+// ChunkSize*(QueueDepth+1) regardless of file size and the slowest consumer
+// sets the pace.
+package upload
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Defaults for Options fields left zero.
+const (
+	DefaultChunkSize  = 256 << 10 // 256 KiB
+	DefaultQueueDepth = 8
+)
+
+// ErrScanFailed wraps the error of a Scanner that could not reach a verdict,
+// for example because the scanning daemon is unavailable. Uploads fail closed.
+var ErrScanFailed = errors.New("malware scan failed")
+
+// InfectedError is returned by a Scanner that found malware.
+type InfectedError struct {
+	Signature string // Name of the detected threat, e.g. "Eicar-Test-Signature"
+}
+
+func (e *InfectedError) Error() string {
+	return "malware detected: " + e.Signature
+}
+
+// Scanner inspects content as it streams past. Scan reads r, usually until
+// EOF; it may stop early once it has a verdict. It returns nil for clean
+// content, an *InfectedError for malware, and any other error when no
+// verdict could be reached.
+type Scanner interface {
+	Scan(ctx context.Context, r io.Reader) error
+}
+
+// NopScanner accepts all content without inspecting it; it is the default
+// when no scanner is configured.
+type NopScanner struct{}
+
+// Scan returns nil.
+func (NopScanner) Scan(ctx context.Context, r io.Reader) error {
+	return nil
+}
+
+// ScannerFunc adapts a function to the Scanner interface.
+type ScannerFunc func(ctx context.Context, r io.Reader) error
+
+// Scan calls f(ctx, r).
+func (f ScannerFunc) Scan(ctx context.Context, r io.Reader) error {
+	return f(ctx, r)
+}
+
+// Options tune a Tee.
+type Options struct {
+	ChunkSize  int     // Bytes read from the source at a time
+	QueueDepth int     // Chunks buffered per consumer
+	Scanner    Scanner // Optional
+}
+
+// Result describes the content that passed through a Tee.
+type Result struct {
+	Size     int64
+	Checksum string // Hex-encoded SHA-256
+	Threat   string // Signature found by the scanner; empty when clean or not scanned
+}
+
+// Tee reads src once, computing its SHA-256 checksum while store and the
+// optional scanner consume the same bytes concurrently. store may be nil to
+// only checksum and scan.
+//
+// Infected content is still stored in full, so it can be quarantined; the
+// detection is reported in Result.Threat. Any other failure cancels the other
+// consumers: a read error from src or a store error is returned as is, a
+// scanner error is wrapped in ErrScanFailed. When an error is returned, store
+// may have written part of the content and the caller is responsible for
+// cleaning it up.
+func Tee(ctx context.Context, src io.Reader, store func(io.Reader) error, opts Options) (*Result, error) {
+	if opts.ChunkSize <= 0 {
+		opts.ChunkSize = DefaultChunkSize
+	}
+	if opts.QueueDepth <= 0 {
+		opts.QueueDepth = DefaultQueueDepth
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		once     sync.Once
+		firstErr error
+	)
+	fail := func(err error) {
+		once.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+
+	var threat string
+	var consumers []func(io.Reader) error
+	if store != nil {
+		consumers = append(consumers, store)
+	}
+	if opts.Scanner != nil {
+		consumers = append(consumers, func(r io.Reader) error {
+			err := opts.Scanner.Scan(ctx, r)
+			var infected *InfectedError
+			switch {
+			case errors.As(err, &infected):
+				threat = infected.Signature
+			case err != nil:
+				return fmt.Errorf("%w: %v", ErrScanFailed, err)
+			}
+			return nil
+		})
+	}
+
+	var wg sync.WaitGroup
+	queues := make([]*queue, len(consumers))
+	for i, consume := range consumers {
+		q := &queue{ch: make(chan []byte, opts.QueueDepth)}
+		queues[i] = q
+		wg.Add(1)
+		go func(consume func(io.Reader) error) {
+			defer wg.Done()
+			if err := consume(q); err != nil {
+				fail(err)
+			}
+			// Keep the producer moving if the consumer stopped reading early.
+			for range q.ch {
+			}
+		}(consume)
+	}
+
+	hash := sha256.New()
+	var size int64
+	readErr := func() error {
+		for {
+			chunk := make([]byte, opts.ChunkSize)
+			n, err := io.ReadFull(src, chunk)
+			if n > 0 {
+				chunk = chunk[:n]
+				hash.Write(chunk)
+				size += int64(n)
+				for _, q := range queues {
+					select {
+					case q.ch <- chunk:
+					case <-ctx.Done():
+						return ctx.Err()
+					}
+				}
+			}
+			switch {
+			case err == io.EOF || err == io.ErrUnexpectedEOF:
+				return nil
+			case err != nil:
+				return err
+			}
+		}
+	}()
+	if readErr != nil {
+		fail(readErr)
+	}
+	for _, q := range queues {
+		q.close(readErr)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return &Result{Size: size, Checksum: hex.EncodeToString(hash.Sum(nil)), Threat: threat}, nil
+}
+
+// queue is the io.Reader a consumer reads its chunks from.
+type queue struct {
+	ch   chan []byte
+	err  error // Set before ch is closed; nil means clean EOF
+	head []byte
+}
+
+func (q *queue) close(err error) {
+	q.err = err
+	close(q.ch)
+}
+
+// Read implements io.Reader.
+func (q *queue) Read(p []byte) (int, error) {
+	if len(q.head) == 0 {
+		chunk, ok := <-q.ch
+		if !ok {
+			if q.err != nil {
+				return 0, q.err
+			}
+			return 0, io.EOF
+		}
+		q.head = chunk
+	}
+	n := copy(p, q.head)
+	q.head = q.head[n:]
+	return n, nil
+}
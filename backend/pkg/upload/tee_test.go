@@ -0,0 +1,112 @@
+package upload_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"nivai/backend/pkg/upload"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTee(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 10_000) // Spans many chunks
+	sum := sha256.Sum256(content)
+
+	t.Run("stores, scans and checksums in one pass", func(t *testing.T) {
+		var stored, scanned bytes.Buffer
+		scanner := upload.ScannerFunc(func(ctx context.Context, r io.Reader) error {
+			_, err := io.Copy(&scanned, r)
+			return err
+		})
+
+		src := &countingReader{r: bytes.NewReader(content)}
+		result, err := upload.Tee(context.Background(), src, func(r io.Reader) error {
+			_, err := io.Copy(&stored, r)
+			return err
+		}, upload.Options{ChunkSize: 4096, QueueDepth: 2, Scanner: scanner})
+		require.NoError(t, err)
+
+		assert.Equal(t, int64(len(content)), result.Size)
+		assert.Equal(t, hex.EncodeToString(sum[:]), result.Checksum)
+		assert.Equal(t, content, stored.Bytes())
+		assert.Equal(t, content, scanned.Bytes())
+		assert.Equal(t, len(content), src.n, "source is read exactly once")
+	})
+
+	t.Run("checksum only", func(t *testing.T) {
+		result, err := upload.Tee(context.Background(), bytes.NewReader(content), nil, upload.Options{})
+		require.NoError(t, err)
+		assert.Equal(t, hex.EncodeToString(sum[:]), result.Checksum)
+	})
+
+	t.Run("infected content is stored and reported", func(t *testing.T) {
+		scanner := upload.ScannerFunc(func(ctx context.Context, r io.Reader) error {
+			return &upload.InfectedError{Signature: "Eicar-Test-Signature"}
+		})
+		var stored bytes.Buffer
+		result, err := upload.Tee(context.Background(), bytes.NewReader(content), func(r io.Reader) error {
+			_, err := io.Copy(&stored, r)
+			return err
+		}, upload.Options{ChunkSize: 1024, QueueDepth: 1, Scanner: scanner})
+
+		require.NoError(t, err)
+		assert.Equal(t, "Eicar-Test-Signature", result.Threat)
+		assert.Equal(t, content, stored.Bytes(), "quarantined content is kept whole")
+	})
+
+	t.Run("scan failure aborts storage", func(t *testing.T) {
+		scanner := upload.ScannerFunc(func(ctx context.Context, r io.Reader) error {
+			return errors.New("dial tcp 127.0.0.1:3310: connection refused")
+		})
+		var storeErr error
+		_, err := upload.Tee(context.Background(), bytes.NewReader(content), func(r io.Reader) error {
+			_, storeErr = io.Copy(io.Discard, r)
+			return storeErr
+		}, upload.Options{ChunkSize: 1024, QueueDepth: 1, Scanner: scanner})
+
+		assert.ErrorIs(t, err, upload.ErrScanFailed)
+		assert.Contains(t, err.Error(), "connection refused")
+		assert.ErrorIs(t, storeErr, context.Canceled, "storage sees the abort instead of a clean EOF")
+	})
+
+	t.Run("store error", func(t *testing.T) {
+		_, err := upload.Tee(context.Background(), bytes.NewReader(content), func(r io.Reader) error {
+			return errors.New("disk full")
+		}, upload.Options{ChunkSize: 1024, QueueDepth: 1})
+		assert.EqualError(t, err, "disk full")
+	})
+
+	t.Run("source error reaches consumers", func(t *testing.T) {
+		src := io.MultiReader(strings.NewReader("partial"), errReader{errors.New("connection reset")})
+		var storeErr error
+		_, err := upload.Tee(context.Background(), src, func(r io.Reader) error {
+			_, storeErr = io.Copy(io.Discard, r)
+			return storeErr
+		}, upload.Options{})
+		assert.EqualError(t, err, "connection reset")
+		assert.EqualError(t, storeErr, "connection reset")
+	})
+}
+
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += n
+	return n, err
+}
+
+type errReader struct{ err error }
+
+func (e errReader) Read([]byte) (int, error) { return 0, e.err }
@@ -1,13 +1,18 @@
+import asyncio
 import logging
 import uuid
 from pathlib import Path
-from typing import Any, Dict
+from typing import Any, Dict, Union
 import os
+import shutil
 import tempfile
+import urllib.parse
+import urllib.request
 from azure.storage.blob import BlobServiceClient
 
 import pandas as pd
-from fastapi import BackgroundTasks, FastAPI, HTTPException
+from fastapi import BackgroundTasks, FastAPI, HTTPException, Request
+from fastapi.responses import JSONResponse
 
 # Data Loading Functions
 from ..data_loader import load_event_data, load_tracking_data
@@ -48,12 +53,89 @@ def _download_blob_to_tempfile(blob_name: str, connection_string: str, container
         raise
 
 
+def _is_url(path: str) -> bool:
+    """Whether a data path is a (signed) download URL rather than a file path."""
+    return path.startswith(("http://", "https://"))
+
+
+def _download_url_to_tempfile(url: str, logger_instance: logging.Logger) -> Path:
+    # Signed URLs carry credentials in the query, so only log the location
+    location = urllib.parse.urlsplit(url)._replace(query="").geturl()
+    logger_instance.info(f"Attempting to download: {location}")
+    try:
+        suffix = Path(urllib.parse.urlsplit(url).path).suffix
+        temp_file = tempfile.NamedTemporaryFile(delete=False, suffix=suffix)
+        with urllib.request.urlopen(url, timeout=60) as response, open(temp_file.name, "wb") as download_file:
+            shutil.copyfileobj(response, download_file)
+
+        logger_instance.info(f"Successfully downloaded {location} to {temp_file.name}")
+        return Path(temp_file.name)
+    except Exception as e:
+        logger_instance.exception(f"Failed to download {location}: {e}")
+        raise
+
+
 app = FastAPI(title="Football Analysis API")
 
+# Contract versions this service speaks. The JSON schemas of each version live
+# in backend/pkg/pythonapi/schemas; tests/test_contract.py checks them.
+SUPPORTED_SCHEMA_VERSIONS = ("1",)
+SCHEMA_VERSION_HEADER = "X-Schema-Version"
+
+
+@app.middleware("http")
+async def negotiate_schema_version(request: Request, call_next):
+    """
+    Answers in the contract version the client asks for, and says which one
+    it used; clients asking for a version this service does not speak get 406.
+    """
+    requested = request.headers.get(SCHEMA_VERSION_HEADER)
+    if requested and requested not in SUPPORTED_SCHEMA_VERSIONS:
+        return JSONResponse(
+            status_code=406,
+            content={
+                "detail": f"Unsupported schema version {requested}; supported: {', '.join(SUPPORTED_SCHEMA_VERSIONS)}"
+            },
+            headers={SCHEMA_VERSION_HEADER: SUPPORTED_SCHEMA_VERSIONS[-1]},
+        )
+    response = await call_next(request)
+    response.headers[SCHEMA_VERSION_HEADER] = requested or SUPPORTED_SCHEMA_VERSIONS[-1]
+    return response
+
 # In-memory cache for processed data
 # For production, consider Redis or another distributed cache.
 processed_match_data_cache: Dict[str, Dict[str, Any]] = {}
 
+# Processing lanes by priority. Each lane processes at most its concurrency
+# limit of matches at once, so a historical backfill in the low lane never
+# holds up tonight's match in the high lane. The limits are read from
+# PROCESSING_LANE_<PRIORITY>_CONCURRENCY, e.g. PROCESSING_LANE_LOW_CONCURRENCY.
+PROCESSING_PRIORITIES = ("high", "normal", "low")
+DEFAULT_PRIORITY = "normal"
+DEFAULT_LANE_CONCURRENCY = {"high": 2, "normal": 2, "low": 1}
+LANE_CONCURRENCY_ENV = "PROCESSING_LANE_{}_CONCURRENCY"
+processing_lanes: Dict[str, asyncio.Semaphore] = {}
+
+
+def _lane_concurrency(priority: str) -> int:
+    """The configured concurrency limit of a lane, at least 1."""
+    env_name = LANE_CONCURRENCY_ENV.format(priority.upper())
+    raw = os.getenv(env_name)
+    if raw is None:
+        return DEFAULT_LANE_CONCURRENCY[priority]
+    try:
+        return max(int(raw), 1)
+    except ValueError:
+        logger.warning(f"Invalid {env_name} {raw!r}; using {DEFAULT_LANE_CONCURRENCY[priority]}")
+        return DEFAULT_LANE_CONCURRENCY[priority]
+
+
+def _lane(priority: str) -> asyncio.Semaphore:
+    """The semaphore limiting the matches processed at once in a lane."""
+    if priority not in processing_lanes:
+        processing_lanes[priority] = asyncio.Semaphore(_lane_concurrency(priority))
+    return processing_lanes[priority]
+
 # --- Background Processing Task ---
 
 
@@ -69,11 +151,37 @@ def _get_player_to_team_map(df: pd.DataFrame) -> Dict[str, str]:
     )
 
 
+def _is_cancelled(match_id: str) -> bool:
+    """
+    Reports whether processing of a match was cancelled via /match/{match_id}/cancel.
+    """
+    return processed_match_data_cache.get(match_id, {}).get("status") == "cancelled"
+
+
 async def _process_match_data_background(
-    match_id: str, tracking_path: Path, event_path: Path
+    match_id: str,
+    tracking_path: Union[Path, str],
+    event_path: Union[Path, str],
+    priority: str = DEFAULT_PRIORITY,
+):
+    """
+    Background task processing a match once its priority's lane has room.
+    """
+    async with _lane(priority):
+        if _is_cancelled(match_id):
+            logger.info(f"[{match_id}] Processing cancelled while queued in the {priority} lane.")
+            return
+        if match_id in processed_match_data_cache:
+            processed_match_data_cache[match_id] = {"status": "pending"}
+        await _process_match_data(match_id, tracking_path, event_path)
+
+
+async def _process_match_data(
+    match_id: str, tracking_path: Union[Path, str], event_path: Union[Path, str]
 ):
     """
-    Background task to load, process, and cache match data.
+    Loads, processes, and caches match data. The calculations run in worker
+    threads, so the lanes process their matches side by side.
     """
     temp_files_to_clean: list[Path] = []
     logger.info(
@@ -91,7 +199,25 @@ async def _process_match_data_background(
         final_tracking_path: Path
         final_event_path: Path
 
-        if storage_type == "azure":
+        if _is_url(input_tracking_path_str) and _is_url(input_event_path_str):
+            # Signed download URLs work whatever the storage type
+            try:
+                temp_tracking_file = _download_url_to_tempfile(input_tracking_path_str, logger)
+                final_tracking_path = temp_tracking_file
+                temp_files_to_clean.append(temp_tracking_file)
+
+                temp_event_file = _download_url_to_tempfile(input_event_path_str, logger)
+                final_event_path = temp_event_file
+                temp_files_to_clean.append(temp_event_file)
+            except Exception as e:
+                logger.error(f"[{match_id}] Failed to download one or more files: {e}")
+                processed_match_data_cache[match_id] = {
+                    "status": "error",
+                    "message": f"File download failed: {e}",
+                }
+                raise
+
+        elif storage_type == "azure":
             connection_string = os.getenv(AZURE_STORAGE_CONNECTION_STRING_ENV)
             container_name = os.getenv(AZURE_STORAGE_CONTAINER_NAME_ENV)
             if not connection_string or not container_name:
@@ -153,12 +279,16 @@ async def _process_match_data_background(
             }
             return # Exit if config is bad
 
+        if _is_cancelled(match_id):
+            logger.info(f"[{match_id}] Processing cancelled before loading data.")
+            return
+
         # Load data
-        # Note: load_tracking_data/load_event_data are synchronous.
-        # For very large files or remote storage, consider running them in a thread pool.
-        tracking_df = load_tracking_data(final_tracking_path)
-        event_df = load_event_data(
-            final_event_path
+        # Note: load_tracking_data/load_event_data are synchronous, so they run
+        # in a worker thread.
+        tracking_df = await asyncio.to_thread(load_tracking_data, final_tracking_path)
+        event_df = await asyncio.to_thread(
+            load_event_data, final_event_path
         )  # Currently not used extensively by stats_calculator
 
         if tracking_df.empty:
@@ -171,9 +301,13 @@ async def _process_match_data_background(
             }
             return
 
+        if _is_cancelled(match_id):
+            logger.info(f"[{match_id}] Processing cancelled after loading data.")
+            return
+
         # Enrich tracking data
         logger.info(f"[{match_id}] Enriching tracking data...")
-        enriched_df = enrich_tracking_data(tracking_df)  # This can be CPU intensive
+        enriched_df = await asyncio.to_thread(enrich_tracking_data, tracking_df)  # This can be CPU intensive
         if enriched_df.empty:
             logger.error(
                 f"[{match_id}] Enriched tracking data is empty. Aborting processing."
@@ -185,7 +319,7 @@ async def _process_match_data_background(
             return
 
         logger.info(f"[{match_id}] Generating player summaries...")
-        player_summaries = generate_all_player_summaries(enriched_df)
+        player_summaries = await asyncio.to_thread(generate_all_player_summaries, enriched_df)
 
         logger.info(f"[{match_id}] Generating player to team map...")
         # Assuming team_id is present in enriched_df (comes from tracking_df)
@@ -200,6 +334,11 @@ async def _process_match_data_background(
         logger.info(f"[{match_id}] Generating team summaries...")
         team_summaries = generate_team_summaries(player_summaries, player_to_team_map)
 
+        # A cancellation during the calculations discards their results
+        if _is_cancelled(match_id):
+            logger.info(f"[{match_id}] Processing cancelled; discarding results.")
+            return
+
         # Store results in cache
         processed_match_data_cache[match_id] = {
             "status": "processed",
@@ -213,7 +352,8 @@ async def _process_match_data_background(
 
     except Exception as e:
         logger.exception(f"[{match_id}] Error during background processing: {e}")
-        processed_match_data_cache[match_id] = {"status": "error", "message": str(e)}
+        if not _is_cancelled(match_id):
+            processed_match_data_cache[match_id] = {"status": "error", "message": str(e)}
     finally:
         logger.info(f"[{match_id}] Cleaning up temporary files: {temp_files_to_clean}")
         for temp_file_path in temp_files_to_clean:
@@ -237,23 +377,32 @@ async def process_match(
     """
     match_id = request.match_id or str(uuid.uuid4())
 
-    tracking_file = Path(request.tracking_data_path)
-    event_file = Path(request.event_data_path)
+    # Signed URLs are downloaded by the background task; paths must exist
+    if _is_url(request.tracking_data_path) and _is_url(request.event_data_path):
+        tracking_file: Union[Path, str] = request.tracking_data_path
+        event_file: Union[Path, str] = request.event_data_path
+    else:
+        tracking_file = Path(request.tracking_data_path)
+        event_file = Path(request.event_data_path)
 
-    if not tracking_file.exists():
-        raise HTTPException(
-            status_code=404, detail=f"Tracking data file not found: {tracking_file}"
-        )
-    if not event_file.exists():
-        raise HTTPException(
-            status_code=404, detail=f"Event data file not found: {event_file}"
-        )
+        if not tracking_file.exists():
+            raise HTTPException(
+                status_code=404, detail=f"Tracking data file not found: {tracking_file}"
+            )
+        if not event_file.exists():
+            raise HTTPException(
+                status_code=404, detail=f"Event data file not found: {event_file}"
+            )
 
-    # Mark as pending before starting task
-    processed_match_data_cache[match_id] = {"status": "pending"}
+    # Mark as pending before starting task; it waits for room in its lane
+    priority = request.priority or DEFAULT_PRIORITY
+    processed_match_data_cache[match_id] = {
+        "status": "pending",
+        "message": f"Queued in the {priority} lane.",
+    }
 
     background_tasks.add_task(
-        _process_match_data_background, match_id, tracking_file, event_file
+        _process_match_data_background, match_id, tracking_file, event_file, priority=priority
     )
 
     return BasicResponse(
@@ -278,6 +427,26 @@ async def get_match_status(match_id: str):
     return StatusResponse(status=status, match_id=match_id, message=message)
 
 
+@app.post("/match/{match_id}/cancel", response_model=BasicResponse)
+async def cancel_match(match_id: str):
+    """
+    Cancels processing of a match. The background task stops at its next
+    checkpoint and discards its results. Finished matches cannot be cancelled.
+    """
+    cache_entry = processed_match_data_cache.get(match_id)
+    if not cache_entry:
+        raise HTTPException(status_code=404, detail="Match ID not found.")
+
+    status = cache_entry.get("status")
+    if status in ("processed", "error"):
+        raise HTTPException(
+            status_code=409, detail=f"Match processing already finished with status '{status}'."
+        )
+
+    processed_match_data_cache[match_id] = {"status": "cancelled"}
+    return BasicResponse(message="Match processing cancelled.", match_id=match_id)
+
+
 @app.get(
     "/match/{match_id}/stats/summary"
 )  # Consider a more specific response model later
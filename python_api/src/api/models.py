@@ -1,4 +1,4 @@
-from typing import Optional
+from typing import Literal, Optional
 
 from pydantic import BaseModel
 
@@ -7,6 +7,7 @@ class ProcessMatchRequest(BaseModel):
     tracking_data_path: str
     event_data_path: str
     match_id: Optional[str] = None
+    priority: Optional[Literal["low", "normal", "high"]] = None
 
 
 class BasicResponse(BaseModel):
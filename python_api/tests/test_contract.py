@@ -0,0 +1,152 @@
+"""
+Contract tests: the models and responses of this service against the JSON
+schemas the Go backend validates payloads with (backend/pkg/pythonapi/schemas).
+A renamed or dropped key fails here before it reaches the backend.
+"""
+import json
+from pathlib import Path
+from unittest.mock import MagicMock, patch
+
+import pandas as pd
+import pytest
+from fastapi.testclient import TestClient
+
+from python_api.src.api.main import (SCHEMA_VERSION_HEADER,
+                                     SUPPORTED_SCHEMA_VERSIONS, app,
+                                     processed_match_data_cache)
+from python_api.src.api.models import (BasicResponse, ProcessMatchRequest,
+                                       StatusResponse)
+
+SCHEMA_DIR = Path(__file__).resolve().parents[2] / "backend" / "pkg" / "pythonapi" / "schemas"
+
+client = TestClient(app)
+
+
+def load_schema(name: str, version: str = SUPPORTED_SCHEMA_VERSIONS[-1]) -> dict:
+    return json.loads((SCHEMA_DIR / f"v{version}" / f"{name}.json").read_text())
+
+
+def violations(schema: dict, value, path: str = "$") -> list:
+    """Checks value against the JSON Schema subset the contracts use."""
+    types = schema.get("type")
+    if types is not None:
+        types = [types] if isinstance(types, str) else types
+        kind = {dict: "object", list: "array", str: "string", bool: "boolean", type(None): "null"}.get(type(value))
+        if kind is None:
+            kind = "integer" if isinstance(value, int) else "number"
+        if kind not in types and not (kind == "integer" and "number" in types):
+            return [f"{path}: is {kind}, want {' or '.join(types)}"]
+
+    problems = []
+    if isinstance(value, str):
+        if len(value) < schema.get("minLength", 0):
+            problems.append(f"{path}: is too short")
+        if "enum" in schema and value not in schema["enum"]:
+            problems.append(f"{path}: is {value!r}, want one of {schema['enum']}")
+    elif isinstance(value, dict):
+        properties = schema.get("properties", {})
+        problems += [f"{path}.{name}: is required" for name in schema.get("required", []) if name not in value]
+        for name, child in value.items():
+            if name in properties:
+                problems += violations(properties[name], child, f"{path}.{name}")
+            elif schema.get("additionalProperties") is False:
+                problems.append(f"{path}.{name}: is not part of the contract")
+    elif isinstance(value, list) and "items" in schema:
+        for i, item in enumerate(value):
+            problems += violations(schema["items"], item, f"{path}[{i}]")
+    return problems
+
+
+@pytest.fixture(autouse=True)
+def clear_cache():
+    processed_match_data_cache.clear()
+    yield
+    processed_match_data_cache.clear()
+
+
+def test_request_model_matches_schema():
+    schema = load_schema("process_match_request")
+    assert set(ProcessMatchRequest.model_fields) == set(schema["properties"])
+    required = {name for name, field in ProcessMatchRequest.model_fields.items() if field.is_required()}
+    assert required == set(schema["required"])
+
+
+@pytest.mark.parametrize(
+    "model,contract",
+    [(BasicResponse, "process_match_response"), (StatusResponse, "match_status")],
+)
+def test_response_models_match_schema(model, contract):
+    schema = load_schema(contract)
+    assert set(model.model_fields) <= set(schema["properties"])
+    required = {name for name, field in model.model_fields.items() if field.is_required()}
+    assert set(schema["required"]) <= required
+
+
+@patch("python_api.src.api.main._process_match_data_background", new_callable=MagicMock)
+def test_process_match_follows_contract(mock_bg_task, monkeypatch):
+    monkeypatch.setattr(Path, "exists", MagicMock(return_value=True))
+    payload = {"tracking_data_path": "t.parquet", "event_data_path": "e.parquet", "match_id": "m1", "priority": "high"}
+    assert violations(load_schema("process_match_request"), payload) == []
+
+    response = client.post("/process-match", json=payload)
+    assert response.status_code == 202
+    assert violations(load_schema("process_match_response"), response.json()) == []
+
+
+def test_status_and_cancel_follow_contract():
+    processed_match_data_cache["m1"] = {"status": "pending"}
+    status = client.get("/match/m1/status")
+    assert violations(load_schema("match_status"), status.json()) == []
+
+    cancel = client.post("/match/m1/cancel")
+    assert violations(load_schema("process_match_response"), cancel.json()) == []
+
+
+def test_statistics_follow_contract():
+    tracking = pd.DataFrame(
+        {
+            "player_id": ["p1", "p1", "p2"],
+            "team_id": ["tA", "tA", "tB"],
+            "timestamp_ms": [0, 100, 0],
+            "x": [10.0, 12.0, 50.0],
+            "y": [20.0, 22.0, 60.0],
+        }
+    )
+    processed_match_data_cache["m1"] = {
+        "status": "processed",
+        "player_summaries": {"p1": {"total_distance_m": 100}},
+        "team_summaries": {"tA": {"total_distance_m": 100}},
+        "enriched_tracking_df": tracking,
+    }
+
+    summary = client.get("/match/m1/stats/summary")
+    assert violations(load_schema("match_summary"), summary.json()) == []
+
+    with patch("python_api.src.api.main.generate_player_time_series", return_value=[{"timestamp_ms": 0, "speed_kmh": 5.0}]):
+        details = client.get("/match/m1/player/p1/details")
+    assert violations(load_schema("player_details"), details.json()) == []
+
+    intervals = pd.DataFrame({"interval_start_min": [0], "total_distance_m": [100.0]})
+    with patch("python_api.src.api.main.generate_team_intervals", return_value=intervals):
+        team = client.get("/match/m1/team/tA/summary-over-time")
+    assert violations(load_schema("team_summary_over_time"), team.json()) == []
+
+
+def test_drifted_keys_are_violations():
+    drifted = {"tracking_data_path": "t.parquet", "event_file_path": "e.parquet"}
+    assert violations(load_schema("process_match_request"), drifted) == [
+        "$.event_data_path: is required",
+        "$.event_file_path: is not part of the contract",
+    ]
+
+
+def test_schema_version_is_negotiated():
+    response = client.get("/", headers={SCHEMA_VERSION_HEADER: "1"})
+    assert response.headers[SCHEMA_VERSION_HEADER] == "1"
+
+    response = client.get("/")
+    assert response.headers[SCHEMA_VERSION_HEADER] == SUPPORTED_SCHEMA_VERSIONS[-1]
+
+    response = client.get("/", headers={SCHEMA_VERSION_HEADER: "99"})
+    assert response.status_code == 406
+    assert "Unsupported schema version 99" in response.json()["detail"]
@@ -1,3 +1,4 @@
+import asyncio
 from pathlib import Path
 from unittest.mock import MagicMock, patch
 
@@ -8,7 +9,8 @@ from fastapi.testclient import TestClient
 # Import stats_calculator to mock its functions
 # Import the app instance and cache from your main application file
 from python_api.src.api.main import (_process_match_data_background, app,
-                                     processed_match_data_cache)
+                                     processed_match_data_cache,
+                                     processing_lanes)
 
 # Initialize the TestClient
 client = TestClient(app)
@@ -81,11 +83,31 @@ def test_process_match_success(mock_bg_task, mock_path_exists):
         "test_match_01",
         Path("/fake/tracking.gzip"),  # Updated extension
         Path("/fake/events.gzip"),  # Updated extension
+        priority="normal",
     )
     assert "test_match_01" in processed_match_data_cache
     assert processed_match_data_cache["test_match_01"]["status"] == "pending"
 
 
+@patch("python_api.src.api.main._process_match_data_background", new_callable=MagicMock)
+def test_process_match_signed_urls(mock_bg_task, mock_path_exists):
+    mock_path_exists.return_value = False  # URLs are not checked on disk
+    payload = {
+        "tracking_data_path": "https://nivai.blob.core.windows.net/m/tracking.gzip?sig=abc",
+        "event_data_path": "https://nivai.blob.core.windows.net/m/events.gzip?sig=def",
+        "match_id": "test_match_urls",
+    }
+    response = client.post("/process-match", json=payload)
+
+    assert response.status_code == 202
+    mock_bg_task.assert_called_once_with(
+        "test_match_urls",
+        "https://nivai.blob.core.windows.net/m/tracking.gzip?sig=abc",
+        "https://nivai.blob.core.windows.net/m/events.gzip?sig=def",
+        priority="normal",
+    )
+
+
 def test_process_match_missing_tracking_file(mock_path_exists):
     def side_effect_func_missing_tracking(path_obj=None):
         if path_obj is None:
@@ -194,6 +216,29 @@ def test_get_match_status_non_existent():
     assert response.status_code == 404
 
 
+# --- Tests for /match/{match_id}/cancel ---
+def test_cancel_match_pending():
+    match_id = "test_cancel_pending"
+    processed_match_data_cache[match_id] = {"status": "pending"}
+    response = client.post(f"/match/{match_id}/cancel")
+    assert response.status_code == 200
+    assert response.json()["match_id"] == match_id
+    assert processed_match_data_cache[match_id]["status"] == "cancelled"
+
+
+def test_cancel_match_already_processed():
+    match_id = "test_cancel_processed"
+    processed_match_data_cache[match_id] = {"status": "processed"}
+    response = client.post(f"/match/{match_id}/cancel")
+    assert response.status_code == 409
+    assert processed_match_data_cache[match_id]["status"] == "processed"
+
+
+def test_cancel_match_non_existent():
+    response = client.post("/match/non_existent_match/cancel")
+    assert response.status_code == 404
+
+
 # --- Tests for /match/{match_id}/stats/summary ---
 def test_get_match_summary_success():
     match_id = "test_summary_ok"
@@ -451,3 +496,56 @@ async def test_process_match_data_background_tracking_load_fails(
     cache_item = processed_match_data_cache[match_id]
     assert cache_item["status"] == "error"
     assert "Tracking data loading failed" in cache_item["message"]
+
+
+@pytest.mark.asyncio
+@patch("python_api.src.api.main.enrich_tracking_data")
+@patch("python_api.src.api.main.load_event_data")
+@patch("python_api.src.api.main.load_tracking_data")
+async def test_process_match_data_background_cancelled(
+    mock_main_load_tracking, mock_main_load_event, mock_main_enrich
+):
+    match_id = "bg_test_cancelled"
+    processed_match_data_cache[match_id] = {"status": "pending"}
+
+    def load_and_cancel(path):
+        # The cancel request arrives while the data loads
+        processed_match_data_cache[match_id] = {"status": "cancelled"}
+        return get_dummy_tracking_df()
+
+    mock_main_load_tracking.side_effect = load_and_cancel
+    mock_main_load_event.return_value = get_dummy_event_df()
+
+    await _process_match_data_background(
+        match_id, Path("/fake/tracking.gzip"), Path("/fake/events.gzip")
+    )
+
+    assert processed_match_data_cache[match_id]["status"] == "cancelled"
+    mock_main_enrich.assert_not_called()
+
+
+@pytest.mark.asyncio
+async def test_processing_lanes_keep_priorities_apart(monkeypatch):
+    monkeypatch.setenv("PROCESSING_LANE_LOW_CONCURRENCY", "1")
+    processing_lanes.clear()
+    started = []
+    release = asyncio.Event()
+
+    async def fake_process(match_id, tracking_path, event_path):
+        started.append(match_id)
+        if match_id.startswith("low"):
+            await release.wait()
+
+    with patch("python_api.src.api.main._process_match_data", new=fake_process):
+        low1 = asyncio.create_task(_process_match_data_background("low1", "t", "e", priority="low"))
+        low2 = asyncio.create_task(_process_match_data_background("low2", "t", "e", priority="low"))
+        high = asyncio.create_task(_process_match_data_background("high1", "t", "e", priority="high"))
+
+        # The backfill fills the low lane; the high lane is not held up
+        await asyncio.wait_for(high, timeout=1)
+        assert started == ["low1", "high1"]
+
+        release.set()
+        await asyncio.wait_for(asyncio.gather(low1, low2), timeout=1)
+    assert started == ["low1", "high1", "low2"]
+    processing_lanes.clear()